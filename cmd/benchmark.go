@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/sizing"
+)
+
+var (
+	benchTable       string
+	benchPartition   string
+	benchDryRun      bool
+	benchMaxDuration time.Duration
+	benchMaxRows     int64
+	benchMaxBytes    int64
+	benchOffPeak     string
+	benchTarget      bool
+)
+
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark",
+	Short: "Measure source read or target write throughput",
+	Long: `Read a small sample of a source table to measure throughput for sizing.
+
+The read is bounded by the benchmark profile in the config file (benchmark:
+max_duration, max_rows, max_bytes, off_peak_window) and by the flags below,
+which override it. Use --dry-run to print the exact query for a DBA to review
+before anything touches the source.
+
+With --target, the target cluster is benchmarked instead: synthetic documents
+sized like the mapped collections' are bulk-inserted into a scratch collection,
+which is dropped afterwards, within the same limits (max_rows counts
+documents). The sustained write rate is saved, and sizing estimates the
+migration time with the slower of it and the source read rate.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+		cfgPath := cfgFile
+		if cfgPath == "" {
+			cfgPath = config.ExpandHome(config.DefaultPath)
+		}
+
+		eng := engine.New(nil, logger)
+		st, err := eng.LoadState()
+		if err != nil {
+			return fmt.Errorf("loading state: %w", err)
+		}
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			// Config not strictly required — build from state
+			cfg = buildConfigFromState(st)
+		}
+		if cmd.Flags().Changed("max-duration") {
+			cfg.Benchmark.MaxDuration = benchMaxDuration.String()
+		}
+		if cmd.Flags().Changed("max-rows") {
+			cfg.Benchmark.MaxRows = benchMaxRows
+		}
+		if cmd.Flags().Changed("max-bytes") {
+			cfg.Benchmark.MaxBytes = benchMaxBytes
+		}
+		if cmd.Flags().Changed("off-peak") {
+			cfg.Benchmark.OffPeakWindow = benchOffPeak
+		}
+		eng.Config = cfg
+
+		if st.SchemaPath != "" {
+			if s, err := schema.LoadYAML(st.SchemaPath); err == nil {
+				eng.Schema = s
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if benchTarget {
+			if st.MappingPath != "" {
+				if m, err := mapping.LoadYAML(st.MappingPath); err == nil {
+					eng.Mapping = m
+				}
+			}
+			return runWriteBenchmark(ctx, eng)
+		}
+		if benchTable == "" {
+			return fmt.Errorf("--table is required to benchmark the source")
+		}
+
+		result, err := eng.RunBenchmark(ctx, benchTable, benchPartition, benchDryRun)
+		if err != nil {
+			return fmt.Errorf("benchmark: %w", err)
+		}
+
+		fmt.Printf("Guard rails: %s\n", result.Guard)
+		if result.DryRun {
+			fmt.Println("Dry run: the benchmark would execute:")
+			for _, q := range result.Queries {
+				fmt.Printf("\n  %s;\n", q)
+			}
+			fmt.Println("\nNothing was executed.")
+			return nil
+		}
+
+		fmt.Printf("Rows read:   %d\n", result.RowsRead)
+		fmt.Printf("Bytes read:  %s\n", sizing.FormatBytes(result.BytesRead))
+		fmt.Printf("Throughput:  %.1f MB/s\n", result.ThroughputMBps)
+		fmt.Println()
+		fmt.Println(result.Explanation)
+		return nil
+	},
+}
+
+// runWriteBenchmark benchmarks the target and prints the result.
+func runWriteBenchmark(ctx context.Context, eng *engine.Engine) error {
+	result, err := eng.RunWriteBenchmark(ctx, benchDryRun)
+	if err != nil {
+		return fmt.Errorf("write benchmark: %w", err)
+	}
+	fmt.Printf("Guard rails: %s\n", result.Guard)
+	if result.DryRun {
+		fmt.Println(result.Explanation)
+		return nil
+	}
+	fmt.Printf("Documents written: %d\n", result.DocsWritten)
+	fmt.Printf("Bytes written:     %s\n", sizing.FormatBytes(result.BytesWritten))
+	fmt.Printf("Throughput:        %.1f MB/s, %.0f docs/s\n", result.ThroughputMBps, result.OpsPerSec)
+	fmt.Println()
+	fmt.Println(result.Explanation)
+	return nil
+}
+
+func init() {
+	benchmarkCmd.Flags().StringVar(&benchTable, "table", "", "source table to sample (required unless --target)")
+	benchmarkCmd.Flags().StringVar(&benchPartition, "partition-col", "", "partition column of the table")
+	benchmarkCmd.Flags().BoolVar(&benchDryRun, "dry-run", false, "print the queries that would run, without connecting")
+	benchmarkCmd.Flags().DurationVar(&benchMaxDuration, "max-duration", 0, "stop reading after this long (default 5m)")
+	benchmarkCmd.Flags().Int64Var(&benchMaxRows, "max-rows", 0, "stop reading after this many rows (default unlimited)")
+	benchmarkCmd.Flags().Int64Var(&benchMaxBytes, "max-bytes", 0, "stop reading after this many bytes (default 1 GB)")
+	benchmarkCmd.Flags().StringVar(&benchOffPeak, "off-peak", "", "only run inside this local-time window, e.g. 22:00-06:00")
+	benchmarkCmd.Flags().BoolVar(&benchTarget, "target", false, "measure target write throughput with synthetic documents instead")
+	rootCmd.AddCommand(benchmarkCmd)
+}
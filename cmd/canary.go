@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/postmigration"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+var canaryDryRun bool
+
+var canaryCmd = &cobra.Command{
+	Use:   "canary",
+	Short: "Check that representative queries are served by indexes",
+	Long: `Run the canary queries registered under queries in the mapping with explain
+and capture their executionStats. Without registered queries, one query is
+generated per foreign key kept as a reference. Queries that scan a whole
+collection or examine far more index keys than they return are flagged in
+the readiness report.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, err := state.Load("")
+		if err != nil {
+			return fmt.Errorf("loading state: %w", err)
+		}
+
+		if st.MappingPath == "" {
+			return fmt.Errorf("no mapping available; run denormalization design first")
+		}
+		m, err := mapping.LoadYAML(st.MappingPath)
+		if err != nil {
+			return fmt.Errorf("loading mapping: %w", err)
+		}
+		if err := m.ValidateQueries(); err != nil {
+			return fmt.Errorf("invalid canary queries: %w", err)
+		}
+		queries := m.CanaryQueries()
+		if len(queries) == 0 {
+			fmt.Println("No canary queries registered and no foreign key access paths to generate them from.")
+			return nil
+		}
+
+		if canaryDryRun {
+			fmt.Printf("Canary queries: %d\n\n", len(queries))
+			for _, q := range queries {
+				fmt.Printf("  %s\n", describeCanaryQuery(q))
+			}
+			return nil
+		}
+
+		if st.TargetConfig == nil {
+			return fmt.Errorf("no target configuration; run the wizard first")
+		}
+		tgtOp, err := target.Open(context.Background(),
+			st.TargetConfig.ConnectionString, st.TargetConfig.Database)
+		if err != nil {
+			return fmt.Errorf("connecting to target: %w", err)
+		}
+		defer tgtOp.Close(context.Background())
+
+		orch := &postmigration.Orchestrator{
+			Target:    tgtOp,
+			Mapping:   m,
+			State:     st,
+			StatePath: state.Active().StatePath(),
+		}
+
+		fmt.Printf("Running %d canary queries...\n", len(queries))
+		if _, err := orch.RunCanaryQueries(context.Background(), postmigration.Callbacks{
+			OnCanaryQuery: printCanaryResult,
+		}); err != nil {
+			return fmt.Errorf("running canary queries: %w", err)
+		}
+		fmt.Printf("Report: %s\n", st.CanaryReportPath)
+		return nil
+	},
+}
+
+func describeCanaryQuery(q mapping.CanaryQuery) string {
+	filter := q.Filter
+	if filter == "" {
+		filter = "{" + strings.Join(q.Fields, ", ") + ": <sampled>}"
+	}
+	desc := fmt.Sprintf("%s: db.%s.find(%s)", q.Name, q.Collection, filter)
+	if q.Sort != "" {
+		desc += fmt.Sprintf(".sort(%s)", q.Sort)
+	}
+	if q.Limit > 0 {
+		desc += fmt.Sprintf(".limit(%d)", q.Limit)
+	}
+	return desc
+}
+
+func printCanaryResult(r postmigration.CanaryResult) {
+	switch {
+	case r.Error != "":
+		fmt.Printf("  %s: FAILED (%s)\n", r.Query.Name, r.Error)
+	case len(r.Flags) > 0:
+		fmt.Printf("  %s: FLAGGED (%s)\n", r.Query.Name, strings.Join(r.Flags, "; "))
+	default:
+		index := r.Stats.IndexName
+		if index == "" {
+			index = strings.Join(r.Stats.Stages, " > ")
+		}
+		fmt.Printf("  %s: ok (%s, %d keys, %d returned, %dms)\n",
+			r.Query.Name, index, r.Stats.KeysExamined, r.Stats.NReturned, r.Stats.ExecutionMS)
+	}
+}
+
+func init() {
+	canaryCmd.Flags().BoolVar(&canaryDryRun, "dry-run", false, "list the canary queries without running them")
+	rootCmd.AddCommand(canaryCmd)
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/cdc"
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+var cdcCmd = &cobra.Command{
+	Use:   "cdc",
+	Short: "Replicate ongoing source changes for cutover",
+	Long: `Change data capture keeps MongoDB in sync with the source after the bulk load
+so the application can cut over with minimal downtime.
+
+Run "reloquent cdc prepare" before "reloquent migrate" so no changes are missed,
+then "reloquent cdc run" after the migration until cutover, and finally
+"reloquent cdc teardown" to release the replication slot.
+
+PostgreSQL requires wal_level = logical. Oracle requires ARCHIVELOG mode and
+supplemental logging of all columns.`,
+}
+
+var cdcPrepareCmd = &cobra.Command{
+	Use:   "prepare",
+	Short: "Create the replication slot or record the starting SCN",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := cdcEngine(false)
+		if err != nil {
+			return err
+		}
+		pos, err := eng.PrepareCDC(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("CDC prepared at position %s\n", pos)
+		return nil
+	},
+}
+
+var cdcRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Apply source changes to MongoDB until interrupted",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := cdcEngine(true)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Println("Replicating changes (Ctrl+C to stop)...")
+		err = eng.RunCDC(ctx, func(s cdc.Status) {
+			fmt.Printf("\rApplied: %d  Skipped: %d  Lag: %d bytes, %.1fs  Position: %s   ",
+				s.Applied, s.Skipped, s.LagBytes, s.LagSeconds, s.Position)
+		})
+		fmt.Println()
+		return err
+	},
+}
+
+var cdcTeardownCmd = &cobra.Command{
+	Use:   "teardown",
+	Short: "Drop the replication slot after cutover",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := cdcEngine(false)
+		if err != nil {
+			return err
+		}
+		if err := eng.TeardownCDC(context.Background()); err != nil {
+			return err
+		}
+		fmt.Println("CDC resources released.")
+		return nil
+	},
+}
+
+// cdcEngine loads config and state, and optionally the schema and mapping.
+func cdcEngine(needMapping bool) (*engine.Engine, error) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	cfgPath := cfgFile
+	if cfgPath == "" {
+		cfgPath = config.ExpandHome(config.DefaultPath)
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	eng := engine.New(cfg, logger)
+	st, err := eng.LoadState()
+	if err != nil {
+		return nil, fmt.Errorf("loading state: %w", err)
+	}
+	if !needMapping {
+		return eng, nil
+	}
+
+	if st.SchemaPath == "" || st.MappingPath == "" {
+		return nil, fmt.Errorf("run `reloquent discover` and `reloquent design` before starting CDC")
+	}
+	s, err := schema.LoadYAML(st.SchemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading schema: %w", err)
+	}
+	m, err := mapping.LoadYAML(st.MappingPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading mapping: %w", err)
+	}
+	eng.Schema = s
+	eng.SetMapping(m)
+	return eng, nil
+}
+
+func init() {
+	cdcCmd.AddCommand(cdcPrepareCmd)
+	cdcCmd.AddCommand(cdcRunCmd)
+	cdcCmd.AddCommand(cdcTeardownCmd)
+	rootCmd.AddCommand(cdcCmd)
+}
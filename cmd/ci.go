@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/ci"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+var (
+	ciPhases         []string
+	ciDelta          bool
+	ciSummary        string
+	ciValidationMode string
+)
+
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Run migration phases non-interactively with CI-friendly output",
+	Long: `Run the given phases in order without prompts, stopping at the first failure.
+
+Phases: prepare, migrate, validate, readiness.
+
+A Markdown job summary is appended to --summary, which defaults to
+$GITHUB_STEP_SUMMARY, and each failed validation check is printed as a GitHub
+Actions error annotation. The exit code tells the failure class apart:
+
+  0  all phases passed
+  1  unclassified failure
+  2  bad flags, config or project state
+  3  pre-migration preparation failed
+  4  the migration failed or partially failed
+  5  validation found differences between source and target
+  6  production readiness checks failed
+
+Examples:
+  reloquent ci --phases prepare,migrate,validate,readiness
+  reloquent ci --phases migrate,validate --delta`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		vcfg := validation.Config{Mode: ciValidationMode}
+		if err := vcfg.Validate(); err != nil {
+			return &exitError{code: ci.ExitUsage, err: err}
+		}
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return &exitError{code: ci.ExitUsage, err: err}
+		}
+
+		var phases []ci.Phase
+		for _, name := range ciPhases {
+			p, err := ciPhase(eng, strings.TrimSpace(name), vcfg)
+			if err != nil {
+				return &exitError{code: ci.ExitUsage, err: err}
+			}
+			phases = append(phases, p)
+		}
+
+		rep := ci.Run(cmd.Context(), eng.Project().Name, phases)
+		for _, p := range rep.Phases {
+			line := fmt.Sprintf("%-10s %s", p.Name, p.Status)
+			if p.Err != nil {
+				line += ": " + p.Err.Error()
+			} else if p.Detail != "" {
+				line += ": " + p.Detail
+			}
+			fmt.Println(line)
+		}
+		for _, a := range rep.Annotations() {
+			fmt.Println(a)
+		}
+
+		summary := ciSummary
+		if summary == "" {
+			summary = os.Getenv("GITHUB_STEP_SUMMARY")
+		}
+		if summary != "" {
+			if err := rep.WriteSummary(summary); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+
+		if code := rep.ExitCode(); code != ci.ExitOK {
+			return &exitError{code: code, err: fmt.Errorf("ci run failed")}
+		}
+		return nil
+	},
+}
+
+// ciPhase binds a phase name to the engine operation it runs.
+func ciPhase(eng *engine.Engine, name string, vcfg validation.Config) (ci.Phase, error) {
+	switch name {
+	case "prepare":
+		return ci.Phase{Name: name, ExitCode: ci.ExitPrepare, Run: func(ctx context.Context, _ *ci.Report) (string, error) {
+			if err := eng.PreMigrationPrepare(ctx); err != nil {
+				return "", err
+			}
+			return "target collections created", nil
+		}}, nil
+	case "migrate":
+		return ci.Phase{Name: name, ExitCode: ci.ExitMigration, Run: func(ctx context.Context, _ *ci.Report) (string, error) {
+			migrate := eng.Migrate
+			if ciDelta {
+				migrate = eng.MigrateDelta
+			}
+			status, err := migrate(ctx, func(*migration.Status) {})
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d documents written to %d collections", status.Overall.DocsWritten, len(status.Collections)), nil
+		}}, nil
+	case "validate":
+		return ci.Phase{Name: name, ExitCode: ci.ExitValidation, Run: func(ctx context.Context, rep *ci.Report) (string, error) {
+			result, err := eng.Validate(ctx, vcfg, nil, nil)
+			if err != nil {
+				return "", err
+			}
+			rep.Validation = result
+			if result.Status != "PASS" {
+				return "", fmt.Errorf("validation %s", result.Status)
+			}
+			return fmt.Sprintf("%d collections match", len(result.Collections)), nil
+		}}, nil
+	case "readiness":
+		return ci.Phase{Name: name, ExitCode: ci.ExitReadiness, Run: func(ctx context.Context, rep *ci.Report) (string, error) {
+			r, err := eng.CheckReadiness(ctx)
+			if err != nil {
+				return "", err
+			}
+			rep.Readiness = r
+			failed := 0
+			for _, c := range r.ReadinessChecks {
+				if !c.Passed {
+					failed++
+				}
+			}
+			if !r.ProductionReady {
+				return "", fmt.Errorf("%d of %d readiness checks failed", failed, len(r.ReadinessChecks))
+			}
+			return "production ready", nil
+		}}, nil
+	}
+	return ci.Phase{}, fmt.Errorf("unknown phase %q (want prepare, migrate, validate or readiness)", name)
+}
+
+func init() {
+	ciCmd.Flags().StringSliceVar(&ciPhases, "phases", []string{"migrate", "validate"}, "phases to run, in order")
+	ciCmd.Flags().BoolVar(&ciDelta, "delta", false, "run a delta migration in the migrate phase")
+	ciCmd.Flags().StringVar(&ciSummary, "summary", "", "file to append the Markdown job summary to (default: $GITHUB_STEP_SUMMARY)")
+	ciCmd.Flags().StringVar(&ciValidationMode, "validation-mode", validation.ModeFull, "validation mode: full or checksum")
+	rootCmd.AddCommand(ciCmd)
+}
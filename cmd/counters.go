@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/postmigration"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+var (
+	countersDryRun bool
+	countersSet    []string
+)
+
+var countersCmd = &cobra.Command{
+	Use:   "counters",
+	Short: "Seed counters for collections whose ids came from sequences",
+	Long: `Choose how collections whose source primary key was filled from a sequence or
+identity column make new ids after cutover, and apply the choice.
+
+A counter strategy seeds a document in the counters collection with the
+highest source id, for the application to take the next one with $inc. An
+objectid strategy leaves new documents identified by an ObjectId _id. Both
+are described, with the code to use, in id-generation.md next to the state
+file.
+
+--set collection=counter|objectid|none changes a collection's strategy in
+the mapping; --dry-run lists the candidates without seeding.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, err := state.Load("")
+		if err != nil {
+			return fmt.Errorf("loading state: %w", err)
+		}
+		if st.SchemaPath == "" || st.MappingPath == "" {
+			return fmt.Errorf("no schema and mapping available; run the wizard first")
+		}
+		s, err := schema.LoadYAML(st.SchemaPath)
+		if err != nil {
+			return fmt.Errorf("loading schema: %w", err)
+		}
+		m, err := mapping.LoadYAML(st.MappingPath)
+		if err != nil {
+			return fmt.Errorf("loading mapping: %w", err)
+		}
+
+		if len(countersSet) > 0 {
+			if err := setIDGeneration(m, countersSet); err != nil {
+				return err
+			}
+			if err := m.ValidateIDGeneration(s); err != nil {
+				return err
+			}
+			if err := m.WriteYAML(st.MappingPath); err != nil {
+				return fmt.Errorf("saving mapping: %w", err)
+			}
+		}
+
+		candidates := postmigration.IDCandidates(s, m)
+		if len(candidates) == 0 {
+			fmt.Println("No collections with sequence or identity primary keys.")
+			return nil
+		}
+		if countersDryRun || !hasIDGeneration(m) {
+			for _, c := range candidates {
+				strategy := c.Strategy
+				if strategy == "" {
+					strategy = "undecided"
+				}
+				fmt.Printf("  %s (%s.%s): %s\n", c.Collection, c.Table, c.Column, strategy)
+			}
+			return nil
+		}
+
+		if st.TargetConfig == nil {
+			return fmt.Errorf("no target configuration; run the wizard first")
+		}
+		src, err := buildSourceReader(st.SourceConfig)
+		if err != nil {
+			return fmt.Errorf("connecting to source: %w", err)
+		}
+		defer src.Close()
+		tgtOp, err := target.Open(context.Background(),
+			st.TargetConfig.ConnectionString, st.TargetConfig.Database)
+		if err != nil {
+			return fmt.Errorf("connecting to target: %w", err)
+		}
+		defer tgtOp.Close(context.Background())
+
+		orch := &postmigration.Orchestrator{
+			Source:    src,
+			Target:    tgtOp,
+			Schema:    s,
+			Mapping:   m,
+			State:     st,
+			StatePath: state.Active().StatePath(),
+		}
+		if _, err := orch.RunIDGeneration(context.Background(), postmigration.Callbacks{
+			OnCounterSeeded: printCounterSeed,
+		}); err != nil {
+			return fmt.Errorf("seeding counters: %w", err)
+		}
+		fmt.Printf("ID generation guide: %s\n", st.IDGenerationDocPath)
+		return nil
+	},
+}
+
+// setIDGeneration applies collection=strategy assignments to the mapping;
+// "none" clears a collection's strategy.
+func setIDGeneration(m *mapping.Mapping, assignments []string) error {
+	for _, a := range assignments {
+		name, strategy, ok := strings.Cut(a, "=")
+		if !ok {
+			return fmt.Errorf("invalid --set %q: use collection=counter|objectid|none", a)
+		}
+		if strategy == "none" {
+			strategy = ""
+		} else if !slices.Contains(mapping.IDGenerations, strategy) {
+			return fmt.Errorf("invalid --set %q: use counter, objectid or none", a)
+		}
+		found := false
+		for i := range m.Collections {
+			if m.Collections[i].Name == name {
+				m.Collections[i].IDGeneration = strategy
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("no collection %s in the mapping", name)
+		}
+	}
+	return nil
+}
+
+func hasIDGeneration(m *mapping.Mapping) bool {
+	for _, c := range m.Collections {
+		if c.IDGeneration != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func printCounterSeed(s postmigration.CounterSeed) {
+	if s.Error != "" {
+		fmt.Printf("  %s: FAILED (%s)\n", s.Collection, s.Error)
+		return
+	}
+	fmt.Printf("  %s: seeded at %d (highest source id %d)\n", s.Collection, s.Seq, s.MaxID)
+}
+
+func init() {
+	countersCmd.Flags().BoolVar(&countersDryRun, "dry-run", false, "list the collections and their strategies without seeding")
+	countersCmd.Flags().StringSliceVar(&countersSet, "set", nil, "set a collection's strategy: collection=counter|objectid|none")
+	rootCmd.AddCommand(countersCmd)
+}
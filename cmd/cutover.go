@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+var (
+	cutoverFormat   string
+	cutoverOutput   string
+	cutoverFallback bool
+)
+
+var cutoverCmd = &cobra.Command{
+	Use:   "cutover",
+	Short: "Generate the cutover runbook for this project",
+	Long: `Write the ordered checklist for switching applications to MongoDB: stop
+application writes, drain change data capture (or run the final delta
+migration), pass validation, restore the write concern, enable the balancer
+on a sharded cluster, switch connection strings and run smoke tests. The
+commands in each step use this project's source, target and collections, and
+steps the state already shows done are ticked.
+
+With --fallback, write the plan for switching applications back to the
+source instead: what happens to the writes made to MongoDB since the cutover
+depends on whether applications dual-write (migration.dual_write in the
+config) and whether change data capture is set up.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+		cfgPath := cfgFile
+		if cfgPath == "" {
+			cfgPath = config.ExpandHome(config.DefaultPath)
+		}
+
+		eng := engine.New(nil, logger)
+		st, err := eng.LoadState()
+		if err != nil {
+			return fmt.Errorf("loading state: %w", err)
+		}
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			cfg = buildConfigFromState(st)
+		}
+		eng.Config = cfg
+
+		if st.SchemaPath != "" {
+			if s, err := schema.LoadYAML(st.SchemaPath); err == nil {
+				eng.Schema = s
+			}
+		}
+		if st.MappingPath != "" {
+			m, err := mapping.LoadYAML(st.MappingPath)
+			if err != nil {
+				return fmt.Errorf("loading mapping: %w", err)
+			}
+			eng.SetMapping(m)
+		}
+
+		build := eng.CutoverRunbook
+		if cutoverFallback {
+			build = eng.FallbackPlan
+		}
+		rb, err := build()
+		if err != nil {
+			return fmt.Errorf("building %s: %w", strings.ToLower(cutoverKind()), err)
+		}
+
+		if cutoverOutput != "" {
+			if err := rb.Write(cutoverOutput); err != nil {
+				return fmt.Errorf("writing %s: %w", strings.ToLower(cutoverKind()), err)
+			}
+			fmt.Printf("%s written to %s\n", cutoverKind(), cutoverOutput)
+			return nil
+		}
+
+		switch cutoverFormat {
+		case "markdown":
+			fmt.Print(rb.Markdown())
+		case "json":
+			data, err := json.MarshalIndent(rb, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+		default:
+			return fmt.Errorf("unknown format %q (use markdown or json)", cutoverFormat)
+		}
+		return nil
+	},
+}
+
+func cutoverKind() string {
+	if cutoverFallback {
+		return "Fallback plan"
+	}
+	return "Cutover runbook"
+}
+
+func init() {
+	cutoverCmd.Flags().StringVar(&cutoverFormat, "format", "markdown", "output format when writing to stdout (markdown or json)")
+	cutoverCmd.Flags().BoolVar(&cutoverFallback, "fallback", false, "generate the fallback plan instead of the cutover runbook")
+	cutoverCmd.Flags().StringVar(&cutoverOutput, "output", "", "write the runbook to this file as Markdown")
+	rootCmd.AddCommand(cutoverCmd)
+}
@@ -10,7 +10,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/reloquent/reloquent/internal/config"
 	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/state"
 	"github.com/reloquent/reloquent/internal/wizard"
@@ -29,7 +28,7 @@ var designCmd = &cobra.Command{
 	Long: `Define how relational tables map to MongoDB collections and embedded documents.
 
 Requires a previously discovered schema file. If --schema is not provided,
-looks for the schema at the default location (~/.reloquent/source-schema.yaml).`,
+uses the project's baseline schema snapshot.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if designImport != "" {
 			m, err := mapping.LoadYAML(designImport)
@@ -38,7 +37,7 @@ looks for the schema at the default location (~/.reloquent/source-schema.yaml).`
 			}
 
 			// Save to default location
-			mappingPath := config.ExpandHome("~/.reloquent/mapping.yaml")
+			mappingPath := state.Active().Path("mapping.yaml")
 			if err := m.WriteYAML(mappingPath); err != nil {
 				return fmt.Errorf("saving mapping: %w", err)
 			}
@@ -99,7 +98,7 @@ looks for the schema at the default location (~/.reloquent/source-schema.yaml).`
 
 		schemaPath := designSchemaFile
 		if schemaPath == "" {
-			schemaPath = filepath.Join(config.ExpandHome("~/.reloquent"), "source-schema.yaml")
+			schemaPath = projectSchemaPath()
 		}
 
 		statePath := ""
@@ -116,7 +115,7 @@ func init() {
 	designCmd.Flags().StringVar(&designImport, "import", "", "import a pre-built mapping file")
 	designCmd.Flags().StringVar(&designExport, "export", "", "export the current mapping")
 	designCmd.Flags().BoolVar(&designWeb, "web", false, "launch browser-based visual designer")
-	designCmd.Flags().StringVar(&designSchemaFile, "schema", "", "path to source schema YAML (default: ~/.reloquent/source-schema.yaml)")
+	designCmd.Flags().StringVar(&designSchemaFile, "schema", "", "path to source schema YAML (default: the project's baseline schema snapshot)")
 	rootCmd.AddCommand(designCmd)
 }
 
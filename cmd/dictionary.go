@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+var (
+	dictionaryFormat    string
+	dictionaryOutput    string
+	dictionarySamples   int
+	dictionaryNoSamples bool
+)
+
+var dictionaryCmd = &cobra.Command{
+	Use:   "dictionary",
+	Short: "Generate a data dictionary for the migrated collections",
+	Long: `Describe every field of the target document model: its path, BSON type,
+the source column it came from, whether it can be null, and example values
+sampled from the migrated collections. The dictionary is written as Markdown
+or HTML for application teams.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+		cfgPath := cfgFile
+		if cfgPath == "" {
+			cfgPath = config.ExpandHome(config.DefaultPath)
+		}
+
+		eng := engine.New(nil, logger)
+		st, err := eng.LoadState()
+		if err != nil {
+			return fmt.Errorf("loading state: %w", err)
+		}
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			cfg = buildConfigFromState(st)
+		}
+		eng.Config = cfg
+
+		if st.SchemaPath == "" || st.MappingPath == "" {
+			return fmt.Errorf("run `reloquent discover` and `reloquent design` before generating a data dictionary")
+		}
+		s, err := schema.LoadYAML(st.SchemaPath)
+		if err != nil {
+			return fmt.Errorf("loading schema: %w", err)
+		}
+		m, err := mapping.LoadYAML(st.MappingPath)
+		if err != nil {
+			return fmt.Errorf("loading mapping: %w", err)
+		}
+		eng.Schema = s
+		eng.SetMapping(m)
+
+		if st.TypeMappingPath != "" {
+			tm, err := typemap.LoadYAML(st.TypeMappingPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not load type mapping: %v (using defaults)\n", err)
+			} else {
+				eng.TypeMap = tm
+			}
+		}
+
+		samples := dictionarySamples
+		if dictionaryNoSamples {
+			samples = 0
+		}
+		d, err := eng.DataDictionary(context.Background(), samples)
+		if err != nil {
+			return fmt.Errorf("building data dictionary: %w", err)
+		}
+
+		if dictionaryOutput != "" {
+			if err := d.Write(dictionaryOutput); err != nil {
+				return fmt.Errorf("writing data dictionary: %w", err)
+			}
+			fmt.Printf("Data dictionary written to %s\n", dictionaryOutput)
+			return nil
+		}
+
+		switch dictionaryFormat {
+		case "markdown":
+			fmt.Print(d.Markdown())
+		case "html":
+			out, err := d.HTML()
+			if err != nil {
+				return err
+			}
+			fmt.Print(out)
+		default:
+			return fmt.Errorf("unknown format %q (use markdown or html)", dictionaryFormat)
+		}
+		return nil
+	},
+}
+
+func init() {
+	dictionaryCmd.Flags().StringVar(&dictionaryFormat, "format", "markdown", "output format when writing to stdout (markdown or html)")
+	dictionaryCmd.Flags().StringVar(&dictionaryOutput, "output", "", "write the dictionary to this file; .html selects HTML, anything else Markdown")
+	dictionaryCmd.Flags().IntVar(&dictionarySamples, "samples", 5, "documents sampled per collection for example values")
+	dictionaryCmd.Flags().BoolVar(&dictionaryNoSamples, "no-samples", false, "do not connect to the target; omit example values")
+	rootCmd.AddCommand(dictionaryCmd)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -47,7 +48,7 @@ var discoverCmd = &cobra.Command{
 		}
 
 		fmt.Println("Discovering schema...")
-		schema, err := d.Discover(ctx)
+		schema, err := d.Discover(ctx, printDiscoveryProgress())
 		if err != nil {
 			return fmt.Errorf("discovering schema: %w", err)
 		}
@@ -115,3 +116,13 @@ func init() {
 	discoverCmd.Flags().StringVarP(&discoverOutput, "output", "o", "", "output path for schema YAML (default: output/config/source-schema.yaml)")
 	rootCmd.AddCommand(discoverCmd)
 }
+
+// printDiscoveryProgress prints a line as each discovery phase finishes.
+func printDiscoveryProgress() discovery.ProgressFunc {
+	return func(p discovery.Progress) {
+		if p.Table != "" || p.TablesDone != p.TablesTotal || p.TablesTotal == 0 {
+			return
+		}
+		fmt.Printf("  %-18s %d tables  (%.0f%%)\n", strings.ReplaceAll(p.Phase, "_", " "), p.TablesTotal, p.Percent)
+	}
+}
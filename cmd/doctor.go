@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/doctor"
+	"github.com/reloquent/reloquent/internal/engine"
+)
+
+var (
+	doctorCDC      bool
+	doctorProbeEMR bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment before migration day",
+	Long: `Check everything the migration depends on, before it runs:
+
+  source   connects, reads every selected table and, with --cdc or once
+           ` + "`reloquent cdc prepare`" + ` has run, can use logical replication or LogMiner
+  target   connects and holds the actions the migration needs
+  aws      the credentials work and hold the IAM actions EMR or Glue and the
+           S3 bucket need
+  network  with --probe-emr, a short-lived cluster in the EMR subnets
+           (aws.subnets) opens a connection to the source and target
+  disk     the project and log directories have at least 1 GiB free
+
+Each failure comes with how to fix it. The results are saved in the project
+and included in the readiness report. The command exits non-zero when a
+check fails.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		if doctorProbeEMR {
+			fmt.Println("Probing the network from EMR; this takes several minutes...")
+		}
+		result, err := eng.Doctor(context.Background(), engine.DoctorOptions{CDC: doctorCDC, ProbeEMR: doctorProbeEMR})
+		if err != nil {
+			return err
+		}
+
+		category := ""
+		for _, c := range result.Checks {
+			if c.Category != category {
+				category = c.Category
+				fmt.Printf("\n%s\n", strings.ToUpper(category))
+			}
+			fmt.Printf("  [%s] %s: %s\n", c.Status, c.Name, c.Message)
+			if c.Fix != "" && c.Status != doctor.StatusPass {
+				fmt.Printf("         fix: %s\n", c.Fix)
+			}
+		}
+		fmt.Printf("\n%d passed, %d failed, %d warnings, %d skipped\n",
+			result.Count(doctor.StatusPass), result.Count(doctor.StatusFail),
+			result.Count(doctor.StatusWarn), result.Count(doctor.StatusSkip))
+		if !result.Passed() {
+			return fmt.Errorf("%d environment checks failed", len(result.Failed()))
+		}
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorCDC, "cdc", false, "check the replication grants change data capture needs (default: on once CDC is prepared)")
+	doctorCmd.Flags().BoolVar(&doctorProbeEMR, "probe-emr", false, "launch a short-lived EMR cluster to test network reachability from its subnets")
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/reloquent/reloquent/internal/benchmark"
 	"github.com/reloquent/reloquent/internal/schema"
 	"github.com/reloquent/reloquent/internal/sizing"
 	"github.com/reloquent/reloquent/internal/state"
@@ -54,10 +55,16 @@ var estimateCmd = &cobra.Command{
 		if st.SourceConfig != nil {
 			input.MaxSourceConnections = st.SourceConfig.MaxConnections
 		}
+		if st.WriteBenchmarkPath != "" {
+			if wb, err := benchmark.LoadWriteResult(st.WriteBenchmarkPath); err == nil {
+				input.TargetWriteMBps = wb.ThroughputMBps
+			}
+		}
 
 		if estimateBenchmark {
-			fmt.Println("Running source DB read benchmark...")
-			fmt.Println("(Benchmark requires a live database connection — run the wizard for interactive benchmarking)")
+			fmt.Println("Run `reloquent benchmark --table <name>` to measure source read throughput")
+			fmt.Println("(add --dry-run first to review the query it will execute)")
+			fmt.Println("Run `reloquent benchmark --target` to measure target write throughput")
 		}
 
 		plan := sizing.Calculate(input)
@@ -70,8 +77,7 @@ var estimateCmd = &cobra.Command{
 		}
 
 		// Save sizing plan
-		stateDir := st.SchemaPath[:len(st.SchemaPath)-len("source-schema.yaml")]
-		sizingPath := stateDir + "sizing.yaml"
+		sizingPath := state.Active().Path("sizing.yaml")
 		if err := plan.WriteYAML(sizingPath); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: could not save sizing plan: %v\n", err)
 		} else {
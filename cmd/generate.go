@@ -16,12 +16,26 @@ import (
 	"github.com/reloquent/reloquent/internal/typemap"
 )
 
-var generateOutput string
+var (
+	generateOutput        string
+	generateParameterized bool
+)
 
 var generateCmd = &cobra.Command{
 	Use:   "generate",
 	Short: "Generate PySpark migration scripts",
-	Long:  `Generate self-contained PySpark scripts based on the schema design, type mappings, and configuration.`,
+	Long: `Generate self-contained PySpark scripts based on the schema design, type mappings, and configuration.
+
+With --parameterized, the script reads the source JDBC URL, user and password
+and the target URI and database from environment variables instead of
+containing them, and migration.env is written next to it with this config's
+values. Review the script once and run it in every environment with that
+environment's parameters file; values may be ${ENV:NAME} or Secrets Manager
+references, resolved when the job starts.
+
+README.md is written alongside: the files of the bundle, the collections and
+how each is migrated, the environment variables and secrets the job needs,
+the spark-submit and EMR commands for this config, and the rollback steps.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load state
 		st, err := state.Load("")
@@ -76,28 +90,55 @@ var generateCmd = &cobra.Command{
 
 		// Generate
 		g := &codegen.Generator{
-			Config:  cfg,
-			Schema:  s,
-			Mapping: m,
-			TypeMap: tm,
+			Config:        cfg,
+			Schema:        s,
+			Mapping:       m,
+			TypeMap:       tm,
+			Parameterized: generateParameterized,
 		}
 
 		result, err := g.Generate()
 		if err != nil {
 			return fmt.Errorf("generating migration script: %w", err)
 		}
+		for _, w := range result.Warnings {
+			fmt.Printf("Warning: %s\n", w)
+		}
 
 		// Write output
 		outputDir := generateOutput
 		if err := os.MkdirAll(outputDir, 0o755); err != nil {
 			return fmt.Errorf("creating output directory: %w", err)
 		}
-		outputPath := filepath.Join(outputDir, "migration.py")
+		outputPath := filepath.Join(outputDir, codegen.ScriptFile)
 		if err := os.WriteFile(outputPath, []byte(result.MigrationScript), 0o644); err != nil {
 			return fmt.Errorf("writing migration script: %w", err)
 		}
 
 		fmt.Printf("Migration script written to %s\n", outputPath)
+
+		// Migrations refuse to start once the mapping moves on from this script
+		if abs, err := filepath.Abs(outputPath); err == nil {
+			outputPath = abs
+		}
+		st.SetGeneratedScript(outputPath, result.MappingHash)
+		if err := st.Save(""); err != nil {
+			return fmt.Errorf("saving state: %w", err)
+		}
+
+		if generateParameterized {
+			paramsPath := filepath.Join(outputDir, codegen.ParametersFile)
+			if err := os.WriteFile(paramsPath, []byte(result.Parameters), 0o600); err != nil {
+				return fmt.Errorf("writing parameters file: %w", err)
+			}
+			fmt.Printf("Parameters written to %s\n", paramsPath)
+		}
+
+		runbookPath := filepath.Join(outputDir, codegen.RunbookFile)
+		if err := os.WriteFile(runbookPath, []byte(result.Runbook), 0o644); err != nil {
+			return fmt.Errorf("writing runbook: %w", err)
+		}
+		fmt.Printf("Runbook written to %s\n", runbookPath)
 		return nil
 	},
 }
@@ -118,5 +159,6 @@ func buildConfigFromState(st *state.State) *config.Config {
 
 func init() {
 	generateCmd.Flags().StringVar(&generateOutput, "output", "output", "output directory for generated scripts")
+	generateCmd.Flags().BoolVar(&generateParameterized, "parameterized", false, "read connection settings from the environment and write them to migration.env")
 	rootCmd.AddCommand(generateCmd)
 }
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/hooks"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "List and run custom step hooks",
+	Long: `Custom steps are external commands declared under hooks in the config. Each
+runs before or after a migration phase (pre_migration, migration, validation,
+index_builds or cdc), receives the event as JSON on stdin, and may answer with
+JSON on stdout:
+
+  {"status": "ok", "message": "registered CHG-1042", "outputs": {"ticket": "CHG-1042"}}
+
+Runs are recorded in the project state and checked for production readiness.
+
+Example config:
+  hooks:
+    - name: cmdb
+      when: after:migration
+      command: ["/opt/cmdb/register", "--env", "prod"]
+      timeout: 2m
+
+Examples:
+  reloquent hooks list
+  reloquent hooks run cmdb`,
+}
+
+var hooksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configured hooks and their last runs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := loadHookRunner()
+		if err != nil {
+			return err
+		}
+		if len(r.Hooks) == 0 {
+			fmt.Println("No hooks configured.")
+			return nil
+		}
+		for _, s := range hooks.Statuses(r.Hooks, r.State) {
+			last := "never run"
+			if s.LastRun != nil {
+				last = s.LastRun.Status
+				if s.LastRun.Message != "" {
+					last += ": " + s.LastRun.Message
+				}
+			}
+			fmt.Printf("%-20s %-22s %s\n", s.Name, s.When, strings.Join(s.Command, " "))
+			fmt.Printf("  last run: %s\n", last)
+		}
+		return nil
+	},
+}
+
+var hooksRunCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "Run a hook now",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := loadHookRunner()
+		if err != nil {
+			return err
+		}
+		if err := r.RunNamed(context.Background(), args[0], nil); err != nil {
+			return err
+		}
+		run := r.State.HookRuns[args[0]]
+		fmt.Printf("Hook %s complete.\n", args[0])
+		if run.Message != "" {
+			fmt.Println(run.Message)
+		}
+		for k, v := range run.Outputs {
+			fmt.Printf("  %s: %s\n", k, v)
+		}
+		return nil
+	},
+}
+
+// loadHookRunner builds a runner for the hooks in the config file, recording
+// runs in the active project's state.
+func loadHookRunner() (*hooks.Runner, error) {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	if err := hooks.Validate(cfg.Hooks); err != nil {
+		return nil, err
+	}
+	p := state.Active()
+	st, err := state.Load(p.StatePath())
+	if err != nil {
+		return nil, fmt.Errorf("loading state: %w", err)
+	}
+	return &hooks.Runner{
+		Hooks:     cfg.Hooks,
+		State:     st,
+		StatePath: p.StatePath(),
+		Project:   p.Name,
+		Database:  cfg.Target.Database,
+	}, nil
+}
+
+// optionalHookRunner is loadHookRunner for commands that also work without
+// a config file: it returns nil when no config can be loaded.
+func optionalHookRunner(st *state.State) *hooks.Runner {
+	cfg, err := config.Load(cfgFile)
+	if err != nil || len(cfg.Hooks) == 0 {
+		return nil
+	}
+	p := state.Active()
+	return &hooks.Runner{
+		Hooks:     cfg.Hooks,
+		State:     st,
+		StatePath: p.StatePath(),
+		Project:   p.Name,
+		Database:  cfg.Target.Database,
+	}
+}
+
+func init() {
+	hooksCmd.AddCommand(hooksListCmd)
+	hooksCmd.AddCommand(hooksRunCmd)
+	rootCmd.AddCommand(hooksCmd)
+}
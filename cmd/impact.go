@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/impact"
+)
+
+var (
+	impactFormat string
+	impactLast   bool
+)
+
+var impactCmd = &cobra.Command{
+	Use:   "impact",
+	Short: "Estimate the load a migration places on the source database",
+	Long: `Estimate how a full migration will load the source: the connections it
+holds, the expected read rate, how long each table is read for, and how much
+of the source's buffer cache each read is likely to displace, so DBAs can
+schedule the run.
+
+Each full migration records the estimate made when it started together with
+what it actually did; --last shows them side by side.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+
+		var rep *impact.Report
+		if impactLast {
+			rep, err = eng.LastSourceImpact()
+			if err == nil && rep == nil {
+				err = fmt.Errorf("no full migration has been recorded yet")
+			}
+		} else {
+			rep, err = eng.SourceImpact()
+		}
+		if err != nil {
+			return err
+		}
+
+		switch impactFormat {
+		case "text":
+			fmt.Print(rep.Format())
+		case "json":
+			data, err := json.MarshalIndent(rep, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+		case "yaml":
+			data, err := yaml.Marshal(rep)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(data))
+		default:
+			return fmt.Errorf("unknown format %q (want text, json or yaml)", impactFormat)
+		}
+		return nil
+	},
+}
+
+func init() {
+	impactCmd.Flags().StringVar(&impactFormat, "format", "text", "output format: text, json or yaml")
+	impactCmd.Flags().BoolVar(&impactLast, "last", false, "show the last full migration's estimate with its actuals")
+	rootCmd.AddCommand(impactCmd)
+}
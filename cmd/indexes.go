@@ -7,30 +7,70 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/reloquent/reloquent/internal/atlas"
 	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
 	"github.com/reloquent/reloquent/internal/indexes"
 	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/postmigration"
 	"github.com/reloquent/reloquent/internal/schema"
 	"github.com/reloquent/reloquent/internal/state"
 	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
 )
 
 var (
-	indexesDryRun  bool
-	indexesMonitor bool
+	indexesDryRun      bool
+	indexesMonitor     bool
+	indexesQueryLog    string
+	indexesMinCalls    int64
+	indexesQueryLogSQL bool
+	indexesPartialUniq bool
+	indexesAtlasSearch bool
+	indexesConcurrency int
+	indexesMaxPerShard int
 )
 
 var indexesCmd = &cobra.Command{
 	Use:   "indexes",
 	Short: "Build indexes on target collections",
-	Long:  `Create indexes on the target MongoDB collections after data insertion completes.`,
+	Long: `Create indexes on the target MongoDB collections after data insertion completes.
+
+With --query-log, the filters and sorts of the source workload add suggested
+indexes, ranked by how often each access pattern runs. The log is a CSV export
+of pg_stat_statements or Oracle AWR top SQL; --query-log-sql prints the query
+that produces it.
+
+With --partial-unique, source unique constraints on nullable columns, or in
+embedded subdocuments that may be missing, are built as partial unique
+indexes so documents without a value do not collide.
+
+With --atlas-search, long text columns the source indexes for full-text search
+get an Atlas Search index per collection, created through the Atlas API with
+the atlas section of the config. Without it they are skipped.
+
+Indexes of one collection are built one after another. --concurrency builds
+that many collections at once and --max-per-shard caps the builds running on
+any one shard; both default to the indexes section of the config.
+
+With indexes.name_template set in the config, e.g. {collection}_{keys}, every
+inferred index is named from the template, and an edited plan whose names do
+not follow it is refused before any index is built.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		st, err := state.Load("")
 		if err != nil {
 			return fmt.Errorf("loading state: %w", err)
 		}
 
+		if indexesQueryLogSQL {
+			dbType := ""
+			if st.SourceConfig != nil {
+				dbType = st.SourceConfig.Type
+			}
+			fmt.Print(indexes.QueryLogSQL(dbType))
+			return nil
+		}
+
 		// Load schema and mapping
 		if st.SchemaPath == "" {
 			return fmt.Errorf("no schema available; run source discovery first")
@@ -48,8 +88,60 @@ var indexesCmd = &cobra.Command{
 			return fmt.Errorf("loading mapping: %w", err)
 		}
 
-		// Infer indexes
-		plan := indexes.Infer(s, m)
+		// Build the edited plan when one was saved, else infer indexes
+		var plan *indexes.IndexPlan
+		if st.IndexPlanPath != "" {
+			plan, err = indexes.LoadYAML(st.IndexPlanPath)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Using edited index plan: %s\n", st.IndexPlanPath)
+		} else {
+			plan = indexes.Infer(s, m)
+
+			// Add suggestions from the source query log, from the flag or config
+			ic := config.IndexesConfig{}
+			if cfg, err := config.Load(cfgFile); err == nil {
+				ic = cfg.Indexes
+			}
+			if indexesQueryLog != "" {
+				ic.QueryLog = indexesQueryLog
+			}
+			if cmd.Flags().Changed("min-calls") {
+				ic.MinCalls = indexesMinCalls
+			}
+			if ic.QueryLog != "" {
+				stats, err := indexes.LoadQueryStats(config.ExpandHome(ic.QueryLog))
+				if err != nil {
+					return err
+				}
+				sugs := indexes.SuggestFromQueries(s, m, stats, ic.MinCalls, ic.MaxSuggestions)
+				plan.AddQuerySuggestions(sugs)
+				fmt.Printf("Query log: %d statements, %d suggested indexes\n", len(stats), len(sugs))
+			}
+			if indexesPartialUniq {
+				ic.PartialUnique = true
+			}
+			if ic.PartialUnique {
+				var tm *typemap.TypeMap
+				if st.TypeMappingPath != "" {
+					if tm, err = typemap.LoadYAML(st.TypeMappingPath); err != nil {
+						fmt.Printf("Warning: could not load type mapping: %v (using defaults)\n", err)
+					}
+				}
+				n := plan.AddUniqueConversions(indexes.AnalyzeUnique(s, m, tm))
+				fmt.Printf("Unique constraints: %d partial unique indexes\n", n)
+			}
+			if indexesAtlasSearch {
+				ic.AtlasSearch = true
+			}
+			if ic.AtlasSearch {
+				fmt.Printf("Full-text search: %d Atlas Search indexes\n", plan.AddSearchIndexes(s, m))
+			}
+			if err := indexes.NameConvention(ic.NameTemplate).Apply(plan); err != nil {
+				return err
+			}
+		}
 
 		if indexesDryRun {
 			fmt.Printf("Index plan: %d indexes\n\n", len(plan.Indexes))
@@ -58,13 +150,27 @@ var indexesCmd = &cobra.Command{
 				if ci.Index.Unique {
 					unique = " (unique)"
 				}
+				if ci.Index.ExpireAfterSeconds > 0 {
+					unique += fmt.Sprintf(" (ttl %ds)", ci.Index.ExpireAfterSeconds)
+				}
+				if len(ci.Index.PartialFilter) > 0 {
+					unique += " (partial)"
+				}
+				if ci.Index.Sparse {
+					unique += " (sparse)"
+				}
+				if c := ci.Index.Collation; c != nil {
+					unique += fmt.Sprintf(" (collation %s/%d)", c.Locale, c.Strength)
+				}
 				fields := ""
 				for i, k := range ci.Index.Keys {
 					if i > 0 {
 						fields += ", "
 					}
 					dir := "asc"
-					if k.Order == -1 {
+					if k.Type != "" {
+						dir = k.Type
+					} else if k.Order == -1 {
 						dir = "desc"
 					}
 					fields += fmt.Sprintf("%s:%s", k.Field, dir)
@@ -80,7 +186,7 @@ var indexesCmd = &cobra.Command{
 
 		if indexesMonitor {
 			// Just monitor existing index builds
-			tgtOp, err := target.NewMongoOperator(context.Background(),
+			tgtOp, err := target.Open(context.Background(),
 				st.TargetConfig.ConnectionString, st.TargetConfig.Database)
 			if err != nil {
 				return fmt.Errorf("connecting to target: %w", err)
@@ -105,7 +211,7 @@ var indexesCmd = &cobra.Command{
 		}
 
 		// Default: create indexes + run post-ops + generate report
-		tgtOp, err := target.NewMongoOperator(context.Background(),
+		tgtOp, err := target.Open(context.Background(),
 			st.TargetConfig.ConnectionString, st.TargetConfig.Database)
 		if err != nil {
 			return fmt.Errorf("connecting to target: %w", err)
@@ -122,16 +228,47 @@ var indexesCmd = &cobra.Command{
 			Schema:    s,
 			Mapping:   m,
 			State:     st,
-			StatePath: config.ExpandHome(state.DefaultPath),
+			StatePath: state.Active().StatePath(),
 			IndexPlan: plan,
 			Topology:  topo,
+			Hooks:     optionalHookRunner(st),
+		}
+
+		if cfg, err := config.Load(cfgFile); err == nil {
+			orch.IndexBuilds = engine.IndexBuildOptions(cfg.Indexes)
+			if cfg.Atlas.Configured() {
+				client, err := atlas.NewClient(cfg.Atlas, st.TargetConfig.Database)
+				if err != nil {
+					return err
+				}
+				orch.SearchIndexes = client
+			}
+		}
+		if cmd.Flags().Changed("concurrency") {
+			orch.IndexBuilds.Concurrency = indexesConcurrency
+		}
+		if cmd.Flags().Changed("max-per-shard") {
+			orch.IndexBuilds.MaxPerShard = indexesMaxPerShard
 		}
 
 		fmt.Printf("Building %d indexes...\n", len(plan.Indexes))
+		reported := make(map[string]string)
 		cb := postmigration.Callbacks{
 			OnStepComplete: func(step string) {
 				fmt.Printf("  Step complete: %s\n", step)
 			},
+			OnIndexProgress: func(statuses []target.IndexBuildStatus) {
+				// Print each index when its phase or whole percentage changes
+				for _, s := range statuses {
+					line := fmt.Sprintf("%s (%.0f%%)", s.Phase, s.Progress)
+					key := s.Collection + "." + s.IndexName
+					if s.Phase == "pending" || reported[key] == line {
+						continue
+					}
+					reported[key] = line
+					fmt.Printf("  %s: %s\n", key, line)
+				}
+			},
 		}
 
 		if err := orch.RunIndexBuilds(context.Background(), cb); err != nil {
@@ -139,6 +276,40 @@ var indexesCmd = &cobra.Command{
 		}
 		fmt.Println("Indexes built successfully.")
 
+		// Materialized views, if the mapping defines any
+		if len(m.Views) > 0 {
+			fmt.Printf("Building %d materialized views...\n", len(m.Views))
+			cb.OnViewBuilt = printViewBuilt
+			if err := orch.RunViews(context.Background(), cb); err != nil {
+				return fmt.Errorf("building views: %w", err)
+			}
+			fmt.Printf("Refresh scripts: %s\n", st.ViewScriptsDir)
+		}
+
+		// Canary queries, registered or generated from FK access paths
+		if queries := m.CanaryQueries(); len(queries) > 0 {
+			fmt.Printf("Running %d canary queries...\n", len(queries))
+			cb.OnCanaryQuery = printCanaryResult
+			if _, err := orch.RunCanaryQueries(context.Background(), cb); err != nil {
+				return fmt.Errorf("running canary queries: %w", err)
+			}
+		}
+
+		// Counters for collections that keep generating integer ids
+		if hasIDGeneration(m) {
+			src, err := buildSourceReader(st.SourceConfig)
+			if err != nil {
+				return fmt.Errorf("connecting to source to seed counters: %w", err)
+			}
+			defer src.Close()
+			orch.Source = src
+			cb.OnCounterSeeded = printCounterSeed
+			if _, err := orch.RunIDGeneration(context.Background(), cb); err != nil {
+				return fmt.Errorf("seeding counters: %w", err)
+			}
+			fmt.Printf("ID generation guide: %s\n", st.IDGenerationDocPath)
+		}
+
 		// Post-ops
 		if err := orch.RunPostOps(context.Background()); err != nil {
 			return fmt.Errorf("post-ops: %w", err)
@@ -168,5 +339,12 @@ var indexesCmd = &cobra.Command{
 func init() {
 	indexesCmd.Flags().BoolVar(&indexesDryRun, "dry-run", false, "show indexes without creating them")
 	indexesCmd.Flags().BoolVar(&indexesMonitor, "monitor", false, "watch index build progress")
+	indexesCmd.Flags().StringVar(&indexesQueryLog, "query-log", "", "CSV export of pg_stat_statements or AWR top SQL to suggest indexes from")
+	indexesCmd.Flags().Int64Var(&indexesMinCalls, "min-calls", 0, "ignore query-log access patterns run fewer times than this")
+	indexesCmd.Flags().IntVar(&indexesConcurrency, "concurrency", 0, "collections to build indexes on at once (default from config, else 1)")
+	indexesCmd.Flags().IntVar(&indexesMaxPerShard, "max-per-shard", 0, "most index builds running at once on any shard, 0 for no limit")
+	indexesCmd.Flags().BoolVar(&indexesPartialUniq, "partial-unique", false, "build unique constraints on nullable columns as partial unique indexes")
+	indexesCmd.Flags().BoolVar(&indexesAtlasSearch, "atlas-search", false, "add Atlas Search indexes on long text columns with source full-text indexes")
+	indexesCmd.Flags().BoolVar(&indexesQueryLogSQL, "query-log-sql", false, "print the query that exports the source query log, then exit")
 	rootCmd.AddCommand(indexesCmd)
 }
@@ -2,11 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 
-	"github.com/reloquent/reloquent/internal/config"
 	"github.com/reloquent/reloquent/internal/schema"
 	"github.com/reloquent/reloquent/internal/state"
 )
@@ -25,27 +23,29 @@ var ingestCmd = &cobra.Command{
 
 		fmt.Println(s.Summary())
 
-		// Save schema to the standard location
-		statePath := config.ExpandHome(state.DefaultPath)
-		stateDir := filepath.Dir(statePath)
-		schemaPath := filepath.Join(stateDir, "source-schema.yaml")
-		if err := s.WriteYAML(schemaPath); err != nil {
-			return fmt.Errorf("saving schema: %w", err)
-		}
-
-		// Update state
+		// Update state, keeping the schema as a snapshot like a discovery
 		st, err := state.Load("")
 		if err != nil {
 			return fmt.Errorf("loading state: %w", err)
 		}
-		st.SchemaPath = schemaPath
+		snaps := schema.OpenSnapshots(state.Active().Dir)
+		info, err := snaps.Record(s, st.SchemaPath)
+		if err != nil {
+			return err
+		}
+		if st.SchemaSnapshot == "" {
+			st.SchemaPath = snaps.Path(info.ID)
+		}
 		st.CompleteStep(state.StepSourceConnection, state.StepTargetConnection)
 		if err := st.Save(""); err != nil {
 			return fmt.Errorf("saving state: %w", err)
 		}
 
 		fmt.Printf("\nSchema ingested from %s\n", ingestFile)
-		fmt.Printf("Schema saved to %s\n", schemaPath)
+		fmt.Printf("Schema saved as snapshot %s in %s\n", info.ID, snaps.Path(info.ID))
+		if st.SchemaSnapshot != "" {
+			fmt.Printf("Snapshot %s stays pinned as the design baseline.\n", st.SchemaSnapshot)
+		}
 		fmt.Println("Source connection step marked complete. Run the wizard to continue from target connection.")
 
 		return nil
@@ -75,6 +75,7 @@ var initCmd = &cobra.Command{
 
 		fmt.Printf("Config written to %s\n", cfgPath)
 		fmt.Println()
+		askTelemetryConsent(reader)
 		fmt.Println("Next steps:")
 		fmt.Println("  reloquent discover   — Discover the source database schema")
 		fmt.Println("  reloquent            — Launch the interactive wizard")
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/transform"
+)
+
+var maskSet []string
+
+var maskCmd = &cobra.Command{
+	Use:   "mask",
+	Short: "Mask personal data during migration",
+	Long: `List the columns of the migrated tables that look like personal data, with
+the mask suggested for each and whether the mapping applies it.
+
+--set table.column=op[:value] masks a column wherever its table is
+migrated, with hash (value is an optional salt), redact (value replaces the
+data; without one every letter and digit is starred), synthesize (value is
+name, first_name, last_name, email or phone) or nullify. --set
+table.column=suggested applies the suggested mask and --set
+table.column=off removes the column's masks.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng := engine.New(nil, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+		st, err := eng.LoadState()
+		if err != nil {
+			return fmt.Errorf("loading state: %w", err)
+		}
+		if st.SchemaPath == "" || st.MappingPath == "" {
+			return fmt.Errorf("no schema and mapping available; run the wizard first")
+		}
+		s, err := schema.LoadYAML(st.SchemaPath)
+		if err != nil {
+			return fmt.Errorf("loading schema: %w", err)
+		}
+		m, err := mapping.LoadYAML(st.MappingPath)
+		if err != nil {
+			return fmt.Errorf("loading mapping: %w", err)
+		}
+		eng.Schema = s
+		eng.SetMapping(m)
+
+		cols, err := eng.PIIColumns()
+		if err != nil {
+			return err
+		}
+		if len(maskSet) > 0 {
+			changes, err := maskAssignments(cols, maskSet)
+			if err != nil {
+				return err
+			}
+			if err := eng.SaveMasks(changes); err != nil {
+				return err
+			}
+			if cols, err = eng.PIIColumns(); err != nil {
+				return err
+			}
+		}
+
+		if len(cols) == 0 {
+			fmt.Println("No columns look like personal data.")
+			return nil
+		}
+		for _, c := range cols {
+			mask := c.Operation
+			if c.Value != "" {
+				mask += ":" + c.Value
+			}
+			status := "suggested"
+			if c.Accepted {
+				status = "masked"
+			}
+			fmt.Printf("  %s.%s (%s): %s %s — %s\n", c.Table, c.Column, c.DataType, status, mask, c.Reason)
+		}
+		return nil
+	},
+}
+
+// maskAssignments turns table.column=op[:value] assignments into masking
+// changes, starting from the listed column when there is one.
+func maskAssignments(cols []transform.PIIColumn, assignments []string) ([]transform.PIIColumn, error) {
+	var out []transform.PIIColumn
+	for _, a := range assignments {
+		target, mask, ok := strings.Cut(a, "=")
+		table, column, ok2 := strings.Cut(target, ".")
+		if !ok || !ok2 || mask == "" {
+			return nil, fmt.Errorf("invalid --set %q: use table.column=op[:value], suggested or off", a)
+		}
+		p := transform.PIIColumn{Table: table, Column: column}
+		for _, c := range cols {
+			if c.Table == table && c.Column == column {
+				p = c
+			}
+		}
+		switch mask {
+		case "off":
+			p.Accepted = false
+		case "suggested":
+			if p.Operation == "" {
+				return nil, fmt.Errorf("invalid --set %q: no mask is suggested for %s", a, target)
+			}
+			p.Accepted = true
+		default:
+			p.Operation, p.Value, _ = strings.Cut(mask, ":")
+			p.Accepted = true
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func init() {
+	maskCmd.Flags().StringSliceVar(&maskSet, "set", nil, "mask a column: table.column=op[:value], suggested or off")
+	rootCmd.AddCommand(maskCmd)
+}
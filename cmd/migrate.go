@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -14,19 +15,46 @@ import (
 	"github.com/reloquent/reloquent/internal/codegen"
 	"github.com/reloquent/reloquent/internal/config"
 	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/schema"
 )
 
 var (
 	migrateSkipProvision bool
 	migrateCollection    string
 	migrateDryRun        bool
+	migrateResume        bool
+	migrateDelta         bool
+	migrateTakeover      bool
 )
 
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Run the migration",
-	Long:  `Execute the PySpark migration job on the Spark cluster, monitor progress, and report results.`,
+	Long: `Execute the PySpark migration job on the Spark cluster, monitor progress, and report results.
+
+When aws.platform is "native", rows are streamed directly from the source into
+MongoDB by the built-in Go mover and no Spark infrastructure is required.
+
+With --delta, only rows changed since the last run are migrated: each
+collection's root rows whose watermark column (set in the mapping) is past the
+recorded high-watermark are upserted by key. Collections without a watermark
+column are skipped, and deletes and changes only to embedded child rows are not
+picked up. Use it to catch up after a bulk load or for periodic syncs where
+CDC is not available.
+
+When migration.deadline or migration.max_duration is set, a run still going
+at the end of the window is stopped: checkpoints are kept, the target's write
+concern and balancer are restored, and the run is marked window-expired.
+Continue it in the next window with --resume.
+
+With --takeover, a migration interrupted on another host is continued here:
+import its bundle with ` + "`reloquent project import`" + `, stop any job still writing
+from the old host, then run migrate --takeover. The checkpoints the run recorded
+in the target are loaded and each completed partition or collection is
+re-validated by comparing its source rows with the documents in the target;
+anything not fully present is migrated again. It implies --resume.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
 
@@ -45,7 +73,15 @@ var migrateCmd = &cobra.Command{
 			return fmt.Errorf("loading state: %w", err)
 		}
 
-		if st.AWSResourceID == "" && !migrateSkipProvision && !migrateDryRun {
+		if migrateDelta && (migrateResume || migrateTakeover || migrateCollection != "") {
+			return fmt.Errorf("--delta cannot be combined with --resume, --takeover or --collection")
+		}
+		if migrateTakeover && migrateCollection != "" {
+			return fmt.Errorf("--takeover cannot be combined with --collection")
+		}
+
+		native := cfg.AWS.Platform == "native"
+		if st.AWSResourceID == "" && !migrateSkipProvision && !migrateDryRun && !native {
 			return fmt.Errorf("no AWS infrastructure provisioned; run `reloquent provision` first or use --dry-run")
 		}
 
@@ -83,6 +119,13 @@ var migrateCmd = &cobra.Command{
 			return nil
 		}
 
+		// A Spark run must not silently drift from the script last generated for review
+		if !native {
+			if err := eng.CheckGeneratedScript(); err != nil {
+				return err
+			}
+		}
+
 		// Real migration
 		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 		defer stop()
@@ -101,6 +144,11 @@ var migrateCmd = &cobra.Command{
 				}
 			case "completed":
 				fmt.Printf("\nMigration completed in %s\n", status.ElapsedTime)
+				for _, col := range status.Collections {
+					if col.DocsArchived > 0 {
+						fmt.Printf("  %s: %d old rows archived to S3\n", col.Name, col.DocsArchived)
+					}
+				}
 			case "failed":
 				fmt.Printf("\nMigration failed: %v\n", status.Errors)
 			case "partial_failure":
@@ -111,12 +159,64 @@ var migrateCmd = &cobra.Command{
 					}
 				}
 				fmt.Println("Use `reloquent migrate --collection <name>` to retry failed collections.")
+			case migration.PhaseWindowExpired:
+				fmt.Println("\nMigration window expired — the run was stopped:")
+				for _, msg := range status.Errors {
+					fmt.Printf("  %s\n", msg)
+				}
+			}
+		}
+
+		if migrateTakeover {
+			if err := takeOverMigration(ctx, eng); err != nil {
+				return err
 			}
+			migrateResume = true
 		}
 
 		st.MigrationStatus = "running"
 		_ = eng.SaveState()
 
+		// Native platform: stream rows directly without Spark
+		if native {
+			if st.SchemaPath == "" || st.MappingPath == "" {
+				return fmt.Errorf("run `reloquent discover` and `reloquent design` before migrating")
+			}
+			s, err := schema.LoadYAML(st.SchemaPath)
+			if err != nil {
+				return fmt.Errorf("loading schema: %w", err)
+			}
+			m, err := mapping.LoadYAML(st.MappingPath)
+			if err != nil {
+				return fmt.Errorf("loading mapping: %w", err)
+			}
+			eng.Schema = s
+			eng.SetMapping(m)
+
+			if migrateDelta {
+				fmt.Println("Running native delta migration...")
+				_, err = eng.MigrateDelta(ctx, callback)
+				return err
+			}
+
+			var only []string
+			if migrateCollection != "" {
+				fmt.Printf("Retrying migration for collection: %s\n", migrateCollection)
+				only = []string{migrateCollection}
+			} else if migrateResume {
+				only = eng.PendingCollections()
+				if len(only) == 0 {
+					fmt.Println("All collections already migrated.")
+					return nil
+				}
+				fmt.Printf("Resuming native migration: %v\n", only)
+			} else {
+				fmt.Println("Running native migration...")
+			}
+			_, err = eng.MigrateNative(ctx, only, callback)
+			return err
+		}
+
 		// Build provisioner
 		awsClient, err := aws.NewRealClient(ctx, cfg.AWS.Profile, cfg.AWS.Region)
 		if err != nil {
@@ -127,12 +227,24 @@ var migrateCmd = &cobra.Command{
 		uploader := aws.NewArtifactUploader(awsClient, cfg.AWS.S3Bucket, "reloquent/"+cfg.Target.Database)
 
 		var script []byte
+		var watermarks map[string]mapping.WatermarkRange
 		if eng.Schema != nil && eng.Mapping != nil {
+			// Completed partitions are skipped on resume; otherwise start clean
+			if err := eng.LoadCheckpoints(ctx, migrateResume); err != nil {
+				return fmt.Errorf("preparing checkpoints: %w", err)
+			}
+			watermarks, err = eng.WatermarkRanges(ctx, migrateDelta)
+			if err != nil {
+				return err
+			}
 			gen := &codegen.Generator{
-				Config:  cfg,
-				Schema:  eng.Schema,
-				Mapping: eng.Mapping,
-				TypeMap: eng.GetTypeMap(),
+				Config:      cfg,
+				Schema:      eng.Schema,
+				Mapping:     eng.Mapping,
+				TypeMap:     eng.GetTypeMap(),
+				Checkpoints: st.Checkpoints,
+				Delta:       migrateDelta,
+				Watermarks:  watermarks,
 			}
 			result, err := gen.Generate()
 			if err != nil {
@@ -172,14 +284,36 @@ var migrateCmd = &cobra.Command{
 		executor := migration.NewExecutor(prov, nil, artifacts, nil)
 		executor.SetResourceID(st.AWSResourceID)
 
+		runCtx, cancel, deadline, err := eng.MigrationWindow(ctx)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		if !deadline.IsZero() {
+			fmt.Printf("Migration window ends at %s\n", deadline.Format("2006-01-02 15:04 MST"))
+		}
+
+		var status *migration.Status
 		if migrateCollection != "" {
 			fmt.Printf("Retrying migration for collection: %s\n", migrateCollection)
-			_, err = executor.RetryFailed(ctx, []string{migrateCollection}, callback)
+			status, err = executor.RetryFailed(runCtx, []string{migrateCollection}, callback)
 		} else {
-			fmt.Println("Running full migration...")
-			_, err = executor.Run(ctx, callback)
+			if migrateDelta {
+				fmt.Println("Running delta migration...")
+			} else {
+				fmt.Println("Running full migration...")
+			}
+			status, err = executor.Run(runCtx, callback)
 		}
 
+		if status != nil && status.Phase != "completed" && errors.Is(context.Cause(runCtx), migration.ErrWindowExpired) {
+			if expErr := eng.ExpireWindow(status, deadline); expErr != nil {
+				fmt.Printf("Warning: %v\n", expErr)
+			}
+			callback(status)
+			fmt.Printf("Cancel the Spark step still running on %s to stop its writes to the target.\n", st.AWSResourceID)
+			return migration.ErrWindowExpired
+		}
 		if err != nil {
 			st.MigrationStatus = "failed"
 			eng.SaveState()
@@ -188,6 +322,15 @@ var migrateCmd = &cobra.Command{
 
 		st.MigrationStatus = "completed"
 		eng.SaveState()
+		if migrateCollection == "" && !migrateResume {
+			var names []string
+			for _, c := range eng.Mapping.Collections {
+				names = append(names, c.Name)
+			}
+			if err := eng.RecordWatermarks(watermarks, names); err != nil {
+				return fmt.Errorf("recording watermarks: %w", err)
+			}
+		}
 		return nil
 	},
 }
@@ -195,7 +338,47 @@ var migrateCmd = &cobra.Command{
 func init() {
 	migrateCmd.Flags().BoolVar(&migrateSkipProvision, "skip-provision", false, "use existing cluster")
 	migrateCmd.Flags().StringVar(&migrateCollection, "collection", "", "retry a specific failed collection")
+	migrateCmd.Flags().BoolVar(&migrateResume, "resume", false, "skip collections and partitions completed by an interrupted run")
+	migrateCmd.Flags().BoolVar(&migrateDelta, "delta", false, "migrate only rows changed since the last run, by watermark column")
+	migrateCmd.Flags().BoolVar(&migrateTakeover, "takeover", false, "re-validate and resume a migration interrupted on another host")
 	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "show what would happen without executing")
 	rootCmd.AddCommand(migrateCmd)
 }
 
+
+// takeOverMigration re-validates an interrupted migration's progress against
+// the target before it is resumed on this host, and prints what was found.
+func takeOverMigration(ctx context.Context, eng *engine.Engine) error {
+	st := eng.State
+	if st.SchemaPath == "" || st.MappingPath == "" {
+		return fmt.Errorf("no schema or mapping in the project; import the project bundle first")
+	}
+	s, err := schema.LoadYAML(st.SchemaPath)
+	if err != nil {
+		return fmt.Errorf("loading schema: %w", err)
+	}
+	m, err := mapping.LoadYAML(st.MappingPath)
+	if err != nil {
+		return fmt.Errorf("loading mapping: %w", err)
+	}
+	eng.Schema = s
+	eng.SetMapping(m)
+
+	fmt.Println("Re-validating the interrupted migration against the target...")
+	report, err := eng.TakeOver(ctx)
+	if err != nil {
+		return fmt.Errorf("taking over migration: %w", err)
+	}
+	for _, c := range report.Collections {
+		status := "pending"
+		if c.Done {
+			status = "done"
+		}
+		fmt.Printf("  %-24s %-8s %d partitions verified, %d dropped", c.Name, status, c.Verified, c.Dropped)
+		if c.Message != "" {
+			fmt.Printf(" (%s)", c.Message)
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+var (
+	planFormat string
+	planOutput string
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Write the full migration plan without touching the target",
+	Long: `Combine the schema, mapping, type mappings and sizing into a single plan
+document covering each collection's source reads and SQL, field types, shard
+key, indexes and estimated sizes. Nothing is created on the target.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+		cfgPath := cfgFile
+		if cfgPath == "" {
+			cfgPath = config.ExpandHome(config.DefaultPath)
+		}
+
+		eng := engine.New(nil, logger)
+		st, err := eng.LoadState()
+		if err != nil {
+			return fmt.Errorf("loading state: %w", err)
+		}
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			// Config not strictly required — build from state
+			cfg = buildConfigFromState(st)
+		}
+		eng.Config = cfg
+
+		if st.SchemaPath == "" || st.MappingPath == "" {
+			return fmt.Errorf("run `reloquent discover` and `reloquent design` before planning")
+		}
+		s, err := schema.LoadYAML(st.SchemaPath)
+		if err != nil {
+			return fmt.Errorf("loading schema: %w", err)
+		}
+		m, err := mapping.LoadYAML(st.MappingPath)
+		if err != nil {
+			return fmt.Errorf("loading mapping: %w", err)
+		}
+		eng.Schema = s
+		eng.SetMapping(m)
+
+		if st.TypeMappingPath != "" {
+			tm, err := typemap.LoadYAML(st.TypeMappingPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not load type mapping: %v (using defaults)\n", err)
+			} else {
+				eng.TypeMap = tm
+			}
+		}
+
+		p, err := eng.Plan()
+		if err != nil {
+			return fmt.Errorf("building plan: %w", err)
+		}
+
+		if planOutput != "" {
+			if err := p.Write(planOutput); err != nil {
+				return fmt.Errorf("writing plan: %w", err)
+			}
+			fmt.Printf("Migration plan written to %s\n", planOutput)
+			return nil
+		}
+
+		data, err := p.Marshal(planFormat)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+		return nil
+	},
+}
+
+func init() {
+	planCmd.Flags().StringVar(&planFormat, "format", "yaml", "output format when writing to stdout (yaml or json)")
+	planCmd.Flags().StringVar(&planOutput, "output", "", "write the plan to this file; .json selects JSON, anything else YAML")
+	rootCmd.AddCommand(planCmd)
+}
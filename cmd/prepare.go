@@ -7,9 +7,15 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/reloquent/reloquent/internal/collation"
+	"github.com/reloquent/reloquent/internal/hooks"
+	"github.com/reloquent/reloquent/internal/jsonschema"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
 	"github.com/reloquent/reloquent/internal/sizing"
 	"github.com/reloquent/reloquent/internal/state"
 	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
 )
 
 var (
@@ -46,14 +52,73 @@ var prepareCmd = &cobra.Command{
 			return fmt.Errorf("no tables selected; run table selection first")
 		}
 
+		// Storage options come from the mapping when one has been designed
+		var specs []target.CollectionSpec
+		// Collections with a validation level get a $jsonSchema validator
+		var validated []mapping.Collection
+		validators := make(map[string]map[string]interface{})
+		if st.MappingPath != "" {
+			m, err := mapping.LoadYAML(st.MappingPath)
+			if err != nil {
+				return fmt.Errorf("loading mapping: %w", err)
+			}
+			if err := m.ValidateStorage(); err != nil {
+				return fmt.Errorf("invalid storage options: %w", err)
+			}
+			if err := m.ValidateValidation(); err != nil {
+				return fmt.Errorf("invalid validation level: %w", err)
+			}
+			specs = target.CollectionSpecs(m)
+			if st.SchemaPath != "" {
+				if sch, err := schema.LoadYAML(st.SchemaPath); err == nil {
+					// Case-insensitive source columns get a matching default collation
+					collation.Analyze(sch, m).ApplyToSpecs(specs)
+
+					var tm *typemap.TypeMap
+					if st.TypeMappingPath != "" {
+						tm, _ = typemap.LoadYAML(st.TypeMappingPath)
+					}
+					for _, col := range m.Collections {
+						if col.ValidationLevel() == mapping.ValidationOff {
+							continue
+						}
+						if v := jsonschema.Validator(sch, tm, col); v != nil {
+							validated = append(validated, col)
+							validators[col.Name] = v
+						}
+					}
+				}
+			}
+			collections = make([]string, len(specs))
+			for i, s := range specs {
+				collections[i] = s.Name
+			}
+		}
+
 		if prepareDryRun {
 			fmt.Println("Dry run — showing what would be prepared:")
 			fmt.Printf("  Target: %s / %s\n", st.TargetConfig.ConnectionString, st.TargetConfig.Database)
 			fmt.Printf("  Collections to create: %v\n", collections)
+			for _, s := range specs {
+				if cfg := s.WiredTigerConfig(); cfg != "" {
+					fmt.Printf("    %s: %s\n", s.Name, cfg)
+				}
+				if s.Collation != nil {
+					fmt.Printf("    %s: collation %s\n", s.Name, collation.Describe(s.Collation))
+				}
+			}
+			for _, col := range validated {
+				fmt.Printf("    %s: $jsonSchema validator, %s\n", col.Name, col.ValidationLevel())
+			}
 			if plan != nil && plan.ShardPlan != nil && plan.ShardPlan.Recommended && !prepareSkipShard {
 				fmt.Printf("  Sharding: %d shards\n", plan.ShardPlan.ShardCount)
 				for _, col := range plan.ShardPlan.Collections {
 					fmt.Printf("    %s: %s\n", col.CollectionName, col.ShardKeyString())
+					if col.Zones != nil {
+						for _, r := range col.Zones.Ranges {
+							fmt.Printf("      zone %s: %s in [%v, %v)\n", r.Zone, col.Zones.Field, r.Min, r.Max)
+						}
+					}
 				}
 			}
 			return nil
@@ -63,7 +128,7 @@ var prepareCmd = &cobra.Command{
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		op, err := target.NewMongoOperator(ctx, st.TargetConfig.ConnectionString, st.TargetConfig.Database)
+		op, err := target.Open(ctx, st.TargetConfig.ConnectionString, st.TargetConfig.Database)
 		if err != nil {
 			return fmt.Errorf("connecting to MongoDB: %w", err)
 		}
@@ -93,14 +158,37 @@ var prepareCmd = &cobra.Command{
 			}
 		}
 
+		hookRunner := optionalHookRunner(st)
+		if err := hookRunner.Run(context.Background(), hooks.Before, state.StepPreMigration, nil); err != nil {
+			return err
+		}
+
 		// Create collections
 		fmt.Printf("Creating %d collections...\n", len(collections))
-		if err := op.CreateCollections(ctx, collections); err != nil {
+		if specs != nil {
+			err = op.CreateCollectionsWithOptions(ctx, specs)
+		} else {
+			err = op.CreateCollections(ctx, collections)
+		}
+		if err != nil {
 			return fmt.Errorf("creating collections: %w", err)
 		}
+		caps := target.CapabilitiesOf(op)
+		if len(validated) > 0 && !caps.Validators {
+			fmt.Println("Skipping validators: the target does not support schema validation")
+			validated = nil
+		}
+		for _, col := range validated {
+			fmt.Printf("Setting %s validator on %s...\n", col.ValidationLevel(), col.Name)
+			if err := op.SetValidator(ctx, col.Name, validators[col.Name], col.ValidationLevel()); err != nil {
+				return err
+			}
+		}
 
 		// Setup sharding
-		if plan != nil && plan.ShardPlan != nil && plan.ShardPlan.Recommended && !prepareSkipShard {
+		if plan != nil && plan.ShardPlan != nil && plan.ShardPlan.Recommended && !prepareSkipShard && !caps.Sharding {
+			fmt.Println("Skipping sharding: the target does not support it")
+		} else if plan != nil && plan.ShardPlan != nil && plan.ShardPlan.Recommended && !prepareSkipShard {
 			fmt.Println("Setting up sharding...")
 			if err := op.SetupSharding(ctx, plan.ShardPlan); err != nil {
 				return fmt.Errorf("setting up sharding: %w", err)
@@ -114,6 +202,10 @@ var prepareCmd = &cobra.Command{
 		st.CompleteStep(state.StepPreMigration, state.StepReview)
 		_ = st.Save("")
 
+		if err := hookRunner.Run(context.Background(), hooks.After, state.StepPreMigration, nil); err != nil {
+			return err
+		}
+
 		fmt.Println("Target preparation complete.")
 		return nil
 	},
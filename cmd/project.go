@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+var (
+	projectHistoryLimit int
+	projectImportName   string
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage migration projects",
+	Long: `Keep several migrations side by side. Each project has its own state,
+schema, mapping, type mapping, sizing plan and reports.
+
+The "default" project uses ~/.reloquent directly; named projects live in
+~/.reloquent/projects/<name>. Use --project to run a single command in another
+project without switching.`,
+}
+
+var projectCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a new project",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := state.CreateProject(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created project %s in %s\n", p.Name, p.Dir)
+		fmt.Printf("Run `reloquent project switch %s` to make it current.\n", p.Name)
+		return nil
+	},
+}
+
+var projectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List projects",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projects, err := state.ListProjects()
+		if err != nil {
+			return err
+		}
+		current := state.CurrentProject()
+		for _, p := range projects {
+			marker := " "
+			if p.Name == current {
+				marker = "*"
+			}
+			fmt.Printf("%s %-20s %s\n", marker, p.Name, p.Dir)
+		}
+		return nil
+	},
+}
+
+var projectSwitchCmd = &cobra.Command{
+	Use:   "switch <name>",
+	Short: "Make a project current",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := state.SwitchProject(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Switched to project %s\n", args[0])
+		return nil
+	},
+}
+
+var projectHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the current project's runs, jobs and audit events",
+	Long: `Show what the project's metadata store has recorded: headless runs and
+migrations, migration and index build jobs, and audit events, newest first.
+
+Projects keep these records in JSON-lines files next to state.yaml; with
+metadata.store set to sqlite in the config they move, with the state, into
+metadata.db on first use.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		h, err := eng.History(projectHistoryLimit)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Project %s (%s store)\n", eng.Project().Name, h.Store)
+		fmt.Println("\nRuns:")
+		if len(h.Runs) == 0 {
+			fmt.Println("  none")
+		}
+		for _, r := range h.Runs {
+			took := ""
+			if !r.FinishedAt.IsZero() {
+				took = r.FinishedAt.Sub(r.StartedAt).Round(time.Second).String()
+			}
+			fmt.Printf("  #%-4d %s  %-7s %-7s %s\n", r.ID, r.StartedAt.Local().Format(time.DateTime), r.Command, r.Status, took)
+		}
+		fmt.Println("\nJobs:")
+		if len(h.Jobs) == 0 {
+			fmt.Println("  none")
+		}
+		for _, j := range h.Jobs {
+			fmt.Printf("  %s  %-16s %-9s %s\n", j.StartedAt.Local().Format(time.DateTime), j.Kind, j.Status, j.Detail)
+		}
+		fmt.Println("\nAudit:")
+		if len(h.Audit) == 0 {
+			fmt.Println("  none")
+		}
+		for _, ev := range h.Audit {
+			fmt.Printf("  %s  %-20s %s\n", ev.Time.Local().Format(time.DateTime), ev.Action, ev.Detail)
+		}
+		return nil
+	},
+}
+
+var projectExportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Write the current project to a bundle for another host",
+	Long: `Write the current project's state, schema, mapping, type mapping, sizing
+plan, reports and records to a gzipped tar, so another host can import it and
+take over its migration with ` + "`reloquent migrate --takeover`" + `.
+
+The config and the passwords kept in the OS keychain are not included; the
+importing host needs its own config with the same source and target.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		f, err := os.Create(args[0])
+		if err != nil {
+			return fmt.Errorf("creating bundle: %w", err)
+		}
+		manifest, err := state.ExportBundle(state.Active(), f)
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("writing bundle: %w", cerr)
+		}
+		if err != nil {
+			os.Remove(args[0])
+			return err
+		}
+		fmt.Printf("Exported project %s to %s\n", manifest.Project, args[0])
+		return nil
+	},
+}
+
+var projectImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a project bundle exported on another host",
+	Long: `Create a project from a bundle written by ` + "`reloquent project export`" + ` and
+make it current. The project takes the exported project's name unless --name
+is given, and must not already have state.
+
+To continue a migration that was running on the exporting host, stop any job
+still writing from it, then run ` + "`reloquent migrate --takeover`" + `.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("opening bundle: %w", err)
+		}
+		defer f.Close()
+		p, manifest, err := state.ImportBundle(f, projectImportName)
+		if err != nil {
+			return err
+		}
+		if err := state.SwitchProject(p.Name); err != nil {
+			return err
+		}
+		fmt.Printf("Imported project %s into %s and made it current\n", p.Name, p.Dir)
+		fmt.Printf("Exported from %s at %s\n", manifest.Host, manifest.ExportedAt.Local().Format(time.DateTime))
+		switch manifest.MigrationStatus {
+		case "running", "failed", "partial_failure", "aborted", migration.PhaseWindowExpired:
+			fmt.Printf("Its migration was %s. Stop any job still writing from %s, then run\n", manifest.MigrationStatus, manifest.Host)
+			fmt.Println("`reloquent migrate --takeover` to re-validate the target and resume it here.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	projectHistoryCmd.Flags().IntVar(&projectHistoryLimit, "limit", 20, "most runs and audit events to show")
+	projectImportCmd.Flags().StringVar(&projectImportName, "name", "", "project to import into (default: the exported project's name)")
+	projectCmd.AddCommand(projectCreateCmd)
+	projectCmd.AddCommand(projectListCmd)
+	projectCmd.AddCommand(projectSwitchCmd)
+	projectCmd.AddCommand(projectHistoryCmd)
+	projectCmd.AddCommand(projectExportCmd)
+	projectCmd.AddCommand(projectImportCmd)
+	rootCmd.AddCommand(projectCmd)
+}
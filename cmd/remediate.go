@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+var (
+	remediateAction        string
+	remediateJustification string
+)
+
+var remediateCmd = &cobra.Command{
+	Use:   "remediate [collection]",
+	Short: "Diagnose validation failures and decide how to fix each",
+	Long: `List the collections that failed the last validation with the probable causes
+of each failure (a row filter that changed, type coercion, documents over
+MongoDB's 16 MB limit, rows changed in the source since the migration, ...) and
+the remediation suggested for it.
+
+Name a collection and an --action to record the decision, which the final
+report lists:
+
+  remigrate       empty the collection in the target, with the rest of its
+                  consistency group, and migrate it again
+  adjust_mapping  change the mapping or type mapping, then re-migrate
+  accept          keep the data as it is; needs a --justification
+
+Without --action the suggested remediation is applied. Run validate again after
+re-migrating; the readiness report passes once every failed collection is
+either fixed or accepted.
+
+Examples:
+  reloquent remediate
+  reloquent remediate orders
+  reloquent remediate payments --action accept --justification "sums differ by rounding"`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		failures, err := eng.ValidationFailures()
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			if len(failures) == 0 {
+				fmt.Println("No collection failed the last validation.")
+				return nil
+			}
+			for _, f := range failures {
+				fmt.Printf("%s\n", f.Collection)
+				for _, c := range f.Causes {
+					fmt.Printf("  - %s\n", c.Message)
+				}
+				if f.Remediation != nil {
+					fmt.Printf("  Decided: %s", f.Remediation.Action)
+					if f.Remediation.Justification != "" {
+						fmt.Printf(" (%s)", f.Remediation.Justification)
+					}
+					fmt.Println()
+				} else {
+					fmt.Printf("  Suggested: %s\n", f.Suggested)
+				}
+			}
+			return nil
+		}
+
+		collection := args[0]
+		action := remediateAction
+		if action == "" {
+			for _, f := range failures {
+				if f.Collection == collection {
+					action = f.Suggested
+				}
+			}
+		}
+		if action == "" {
+			return fmt.Errorf("collection %s did not fail the last validation", collection)
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		done := make(chan *migration.Status, 1)
+		callback := func(status *migration.Status) {
+			switch status.Phase {
+			case "running":
+				if pct := status.Overall.PercentComplete; pct > 0 {
+					fmt.Printf("\rProgress: %.1f%% (%d/%d docs)",
+						pct, status.Overall.DocsWritten, status.Overall.DocsTotal)
+				}
+			case "completed", "failed", "partial_failure", migration.PhaseWindowExpired:
+				select {
+				case done <- status:
+				default:
+				}
+			}
+		}
+
+		rem, err := eng.Remediate(ctx, collection, action, remediateJustification, callback)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Recorded %s for %s.\n", rem.Action, collection)
+
+		switch rem.Action {
+		case validation.ActionRemigrate:
+			var status *migration.Status
+			select {
+			case status = <-done:
+			case <-ctx.Done():
+				fmt.Println()
+				return fmt.Errorf("re-migration interrupted; resume it with `reloquent migrate --resume`")
+			}
+			fmt.Println()
+			if status.Phase != "completed" {
+				return fmt.Errorf("re-migration %s: %s", status.Phase, strings.Join(status.Errors, "; "))
+			}
+			fmt.Println("Re-migration completed. Run `reloquent validate` to check it.")
+		case validation.ActionAdjustMapping:
+			fmt.Printf("Change the mapping of %s, then run `reloquent remediate %s --action remigrate`.\n", collection, collection)
+		}
+		return nil
+	},
+}
+
+func init() {
+	remediateCmd.Flags().StringVar(&remediateAction, "action", "", "remediation: remigrate, adjust_mapping or accept (default the suggested one)")
+	remediateCmd.Flags().StringVar(&remediateJustification, "justification", "", "why a variance is accepted")
+	rootCmd.AddCommand(remediateCmd)
+}
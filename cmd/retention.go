@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/retention"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+var (
+	retentionSet           string
+	retentionColumn        string
+	retentionExpireDays    int
+	retentionArchiveDays   int
+	retentionClear         string
+	retentionNoHistogram   bool
+	retentionOnlineArchive bool
+)
+
+var retentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Design TTL and archival policies for time-based collections",
+	Long: `List the mapped collections whose source tables look like logs, audit trails,
+events or sessions, with a histogram of how old their rows are, and set a
+retention policy on them.
+
+A policy that expires documents becomes a TTL index in the index plan. A policy
+that archives documents becomes an Atlas Online Archive rule, printed with
+--online-archive in the form the Atlas Administration API accepts.
+
+Examples:
+  reloquent retention
+  reloquent retention --set auditLog --column created_at --archive-after-days 90 --expire-after-days 730
+  reloquent retention --clear auditLog
+  reloquent retention --online-archive`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, err := state.Load("")
+		if err != nil {
+			return fmt.Errorf("loading state: %w", err)
+		}
+		if st.MappingPath == "" {
+			return fmt.Errorf("no mapping available; run denormalization design first")
+		}
+		m, err := mapping.LoadYAML(st.MappingPath)
+		if err != nil {
+			return fmt.Errorf("loading mapping: %w", err)
+		}
+
+		if retentionSet != "" || retentionClear != "" {
+			return setRetentionPolicy(m, st.MappingPath)
+		}
+
+		if retentionOnlineArchive {
+			database := ""
+			if st.TargetConfig != nil {
+				database = st.TargetConfig.Database
+			}
+			rules := retention.OnlineArchiveRules(m, database)
+			if len(rules) == 0 {
+				fmt.Println("No retention policy archives documents.")
+				return nil
+			}
+			data, err := json.MarshalIndent(rules, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		if st.SchemaPath == "" {
+			return fmt.Errorf("no schema available; run source discovery first")
+		}
+		s, err := schema.LoadYAML(st.SchemaPath)
+		if err != nil {
+			return fmt.Errorf("loading schema: %w", err)
+		}
+		candidates := retention.Candidates(s, m)
+		if len(candidates) == 0 {
+			fmt.Println("No collections look like they need a retention policy.")
+			return nil
+		}
+
+		var reader source.Reader
+		if !retentionNoHistogram {
+			r, err := buildSourceReader(st.SourceConfig)
+			if err != nil {
+				return fmt.Errorf("connecting to source: %w", err)
+			}
+			defer r.Close()
+			reader = r
+		}
+
+		for _, c := range candidates {
+			fmt.Printf("%s (%s.%s): %s; policy: %s\n", c.Collection, c.Table, c.Column, c.Reason, retention.Describe(c.Policy))
+			if reader == nil {
+				continue
+			}
+			h, err := retention.Load(context.Background(), reader, c.Table, c.Column)
+			if err != nil {
+				fmt.Printf("  histogram: %v\n\n", err)
+				continue
+			}
+			p := c.Policy
+			if p == nil {
+				p = retention.Suggest(h)
+				if p != nil {
+					fmt.Printf("  suggested: %s\n", retention.Describe(p))
+				}
+			}
+			fmt.Println(h.Format(40, p))
+		}
+		return nil
+	},
+}
+
+func setRetentionPolicy(m *mapping.Mapping, mappingPath string) error {
+	name := retentionSet
+	var p *mapping.RetentionPolicy
+	if retentionClear != "" {
+		name = retentionClear
+	} else {
+		p = &mapping.RetentionPolicy{
+			Column:           retentionColumn,
+			ExpireAfterDays:  retentionExpireDays,
+			ArchiveAfterDays: retentionArchiveDays,
+		}
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+
+	var col *mapping.Collection
+	for i := range m.Collections {
+		if m.Collections[i].Name == name {
+			col = &m.Collections[i]
+		}
+	}
+	if col == nil {
+		return fmt.Errorf("no collection %s in the mapping", name)
+	}
+	col.Retention = p
+	if err := m.ValidateRetention(); err != nil {
+		return err
+	}
+	if err := m.WriteYAML(mappingPath); err != nil {
+		return fmt.Errorf("saving mapping: %w", err)
+	}
+	fmt.Printf("Retention policy for %s: %s\n", name, retention.Describe(p))
+	return nil
+}
+
+func init() {
+	retentionCmd.Flags().StringVar(&retentionSet, "set", "", "collection to set a retention policy on")
+	retentionCmd.Flags().StringVar(&retentionColumn, "column", "", "source date column to age documents by (with --set)")
+	retentionCmd.Flags().IntVar(&retentionExpireDays, "expire-after-days", 0, "delete documents this many days old with a TTL index (with --set)")
+	retentionCmd.Flags().IntVar(&retentionArchiveDays, "archive-after-days", 0, "move documents this many days old to Atlas Online Archive (with --set)")
+	retentionCmd.Flags().StringVar(&retentionClear, "clear", "", "collection to remove the retention policy from")
+	retentionCmd.Flags().BoolVar(&retentionNoHistogram, "no-histogram", false, "list candidates without reading row ages from the source")
+	retentionCmd.Flags().BoolVar(&retentionOnlineArchive, "online-archive", false, "print the Atlas Online Archive rules as JSON")
+	rootCmd.AddCommand(retentionCmd)
+}
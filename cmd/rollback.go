@@ -40,7 +40,7 @@ var rollbackCmd = &cobra.Command{
 		// Connect to MongoDB if target config is available
 		var tgt target.Operator
 		if st.TargetConfig != nil {
-			op, err := target.NewMongoOperator(ctx, st.TargetConfig.ConnectionString, st.TargetConfig.Database)
+			op, err := target.Open(ctx, st.TargetConfig.ConnectionString, st.TargetConfig.Database)
 			if err != nil {
 				fmt.Printf("Warning: could not connect to MongoDB: %v\n", err)
 			} else {
@@ -1,17 +1,26 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/reloquent/reloquent/internal/client"
+	"github.com/reloquent/reloquent/internal/state"
 	"github.com/reloquent/reloquent/internal/wizard"
 )
 
 var (
 	cfgFile  string
 	logLevel string
+	project  string
+	remote   string
+	replay   string
+	token    string
 	version  = "dev"
 	commit   = "none"
 	date     = "unknown"
@@ -23,25 +32,88 @@ var rootCmd = &cobra.Command{
 	Long: `Reloquent automates offline migrations from relational databases
 (Oracle, PostgreSQL) to MongoDB using Apache Spark.
 
-Running without a subcommand launches the interactive wizard.`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("Launching interactive wizard...")
-		w, err := wizard.New("")
+Running without a subcommand launches the interactive wizard. With
+--remote the wizard runs here against a reloquent server
+(reloquent serve), which connects to the databases and runs the
+migration; --project then names a project on the server.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if project == "" || remote != "" {
+			return nil
+		}
+		p, err := state.OpenProject(project)
 		if err != nil {
 			return err
 		}
-		return w.Run()
+		state.Use(p)
+		return nil
 	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWizard()
+	},
+}
+
+// runWizard runs the wizard: against the server named by --remote, from the
+// session named by --replay, or interactively here.
+func runWizard() error {
+	if replay != "" {
+		if remote != "" {
+			return fmt.Errorf("--replay runs the wizard here and cannot be combined with --remote")
+		}
+		return replayWizard(replay)
+	}
+	askTelemetryConsent(bufio.NewReader(os.Stdin))
+	if remote != "" {
+		return runRemoteWizard()
+	}
+	fmt.Println("Launching interactive wizard...")
+	w, err := wizard.New("")
+	if err != nil {
+		return err
+	}
+	return w.Run()
+}
+
+// runRemoteWizard runs the wizard against the server named by --remote.
+func runRemoteWizard() error {
+	if token == "" {
+		token = os.Getenv("RELOQUENT_TOKEN")
+	}
+	c, err := client.New(remote, client.WithToken(token), client.WithProject(project))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Launching interactive wizard against %s...\n", c.URL())
+	return wizard.NewRemote(c).Run()
 }
 
 func Execute() {
 	rootCmd.Version = version
-	if err := rootCmd.Execute(); err != nil {
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	recordTelemetry(cmd, start, err)
+	if err != nil {
+		var ee *exitError
+		if errors.As(err, &ee) {
+			os.Exit(ee.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitError makes the process exit with a specific code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: ~/.reloquent/reloquent.yaml)")
+	rootCmd.PersistentFlags().StringVar(&project, "project", "", "project to work in (default: the current project)")
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "log level (debug, info, warn, error)")
+	rootCmd.Flags().StringVar(&remote, "remote", "", "run the wizard against a reloquent server at this URL")
+	rootCmd.Flags().StringVar(&token, "token", "", "API token for --remote (default: $RELOQUENT_TOKEN)")
+	rootCmd.Flags().StringVar(&replay, "replay", "", "replay the design decisions recorded in this session file without the screens")
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/rpc"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+var rpcCmd = &cobra.Command{
+	Use:   "rpc",
+	Short: "Serve core operations over a versioned JSON protocol on stdin/stdout",
+	Long: `Answer JSON requests, one per line on stdin, with one JSON response per line
+on stdout. This is the stable interface for tools that drive migrations from
+outside, such as a Terraform provider: every method can run with "dry_run"
+to report what it would do and whether anything would change (plan), and
+runs for real without it (apply). Logs go to stderr.
+
+Methods: status, design.import, premigration, migrate, validate.
+
+Example:
+  {"version": 1, "id": "1", "method": "design.import", "dry_run": true, "params": {"path": "mapping.yaml"}}
+  {"version": 1, "id": "2", "method": "migrate", "params": {"delta": true}}`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		h := &rpc.Handler{Engine: eng}
+		return h.Serve(cmd.Context(), os.Stdin, os.Stdout)
+	},
+}
+
+// loadProjectEngine builds an engine from the config file and the active
+// project's state, with the schema, mapping and type mapping loaded when
+// they have been saved.
+func loadProjectEngine() (*engine.Engine, error) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	cfgPath := cfgFile
+	if cfgPath == "" {
+		cfgPath = config.ExpandHome(config.DefaultPath)
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	eng := engine.New(cfg, logger)
+	st, err := eng.LoadState()
+	if err != nil {
+		return nil, fmt.Errorf("loading state: %w", err)
+	}
+	if st.SchemaPath != "" {
+		s, err := schema.LoadYAML(st.SchemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading schema: %w", err)
+		}
+		eng.Schema = s
+	}
+	if st.MappingPath != "" {
+		m, err := mapping.LoadYAML(st.MappingPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading mapping: %w", err)
+		}
+		eng.SetMapping(m)
+	}
+	if st.TypeMappingPath != "" {
+		tm, err := typemap.LoadYAML(st.TypeMappingPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading type mapping: %w", err)
+		}
+		eng.TypeMap = tm
+	}
+	return eng, nil
+}
+
+func init() {
+	rootCmd.AddCommand(rpcCmd)
+}
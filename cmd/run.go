@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/logging"
+)
+
+var (
+	runYes      bool
+	runSteps    []string
+	runReport   string
+	runProgress string
+	runFormat   string
+	runQuiet    bool
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the whole migration headlessly from a single config",
+	Long: `Run discovery, design, pre-migration, migration, validation and index builds
+without the wizard, taking every decision from the config file. Progress is
+logged as JSON lines on stdout, and the command exits non-zero at the first
+failing step.
+
+With --format text, stdout gets one readable line per step, phase change and
+failure, and a progress line every 10 seconds with the running step's
+percentage, throughput, time left and collections, suited to CI logs;
+warnings and errors go to stderr. --quiet implies text and leaves out the
+progress lines.
+
+The run section of the config answers the wizard steps that are choices:
+
+  run:
+    steps: [discover, design, premigration, migrate, validate, indexes]
+    tables: [customers, orders, order_items]   # default: all discovered
+    mapping: ./mapping.yaml                    # from "reloquent design"
+    type_overrides:
+      numeric: decimal
+    index_plan: ./index-plan.yaml              # default: inferred
+    validation_mode: checksum                  # full (default) or checksum
+    validation_parallelism: 4                  # collections validated at once
+    validation_time_budget: 2h                 # the rest are reported as skipped
+    validation_priority: [orders]              # first; then largest first
+    delta: false
+    progress_file: ./run-progress.json         # rewritten as the run goes
+
+The progress file holds the run's status, the running step and its phase,
+per-collection progress and an estimated finish time as JSON. It is
+replaced atomically, so schedulers and monitors can poll it at any time.
+
+Because it writes to the target, the run only starts with --yes; without it
+the steps are listed and nothing runs.
+
+Examples:
+  reloquent run --config migration.yaml --yes
+  reloquent run --config migration.yaml --steps migrate,validate --yes
+  reloquent run --config migration.yaml --progress-file /var/run/reloquent.json --yes
+  reloquent run --config migration.yaml --format text --yes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		if runQuiet {
+			runFormat = "text"
+		}
+		if runFormat != "json" && runFormat != "text" {
+			return fmt.Errorf("unknown format %q: use json or text", runFormat)
+		}
+		rc := eng.Config.Run
+		if cmd.Flags().Changed("progress-file") {
+			eng.Config.Run.ProgressFile = runProgress
+		}
+		if cmd.Flags().Changed("steps") {
+			rc.Steps = nil
+			for _, s := range runSteps {
+				rc.Steps = append(rc.Steps, strings.TrimSpace(s))
+			}
+		}
+		steps, err := engine.RunPlan(rc)
+		if err != nil {
+			return err
+		}
+
+		if !runYes {
+			fmt.Printf("Would run in project %s: %s\n", eng.Project().Name, strings.Join(steps, " -> "))
+			return fmt.Errorf("pass --yes to run without prompts")
+		}
+
+		levels, err := logging.NewLevels(logLevel, eng.Config.Logging.Components)
+		if err != nil {
+			return err
+		}
+		if runFormat == "text" {
+			eng.Renderer = engine.NewLineRenderer(os.Stdout, runQuiet)
+			eng.Logger = slog.New(logging.NewHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}), levels))
+		} else {
+			eng.Logger = slog.New(logging.NewHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}), levels))
+		}
+		rep, runErr := eng.Run(cmd.Context(), steps)
+		if rep != nil && runReport != "" {
+			data, err := json.MarshalIndent(rep, "", "  ")
+			if err == nil {
+				err = os.WriteFile(runReport, data, 0o644)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: writing run report: %v\n", err)
+			}
+		}
+		return runErr
+	},
+}
+
+func init() {
+	runCmd.Flags().BoolVar(&runYes, "yes", false, "run without confirmation")
+	runCmd.Flags().StringSliceVar(&runSteps, "steps", nil, "steps to run, overriding run.steps (discover, design, premigration, migrate, validate, indexes)")
+	runCmd.Flags().StringVar(&runReport, "report", "", "write the per-step results as JSON to this file")
+	runCmd.Flags().StringVar(&runProgress, "progress-file", "", "keep the run's progress as JSON in this file, overriding run.progress_file")
+	runCmd.Flags().StringVar(&runFormat, "format", "json", "progress output: json log records or text lines")
+	runCmd.Flags().BoolVar(&runQuiet, "quiet", false, "print only step results, phase changes and failures (implies --format text)")
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+var schemaSnapshotsAll bool
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Manage the project's discovered schema snapshots",
+	Long: `Every discovery of the source is kept as a timestamped snapshot in the
+project's schema-snapshots directory. The latest one is the baseline the
+design is built on, unless another snapshot is pinned.
+
+Deleting a snapshot only hides it; restore brings it back.`,
+}
+
+var schemaSnapshotsCmd = &cobra.Command{
+	Use:   "snapshots",
+	Short: "List schema snapshots, newest first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		list, err := eng.SchemaSnapshots(schemaSnapshotsAll)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			fmt.Println("No schema snapshots yet. Run `reloquent discover` or the wizard.")
+			return nil
+		}
+		for _, snap := range list {
+			var notes string
+			switch {
+			case snap.Pinned:
+				notes = "baseline (pinned)"
+			case snap.Baseline:
+				notes = "baseline"
+			case snap.Deleted():
+				notes = "deleted " + snap.DeletedAt.Local().Format(time.DateTime)
+			}
+			fmt.Printf("  %-22s %s  %4d tables  %s\n", snap.ID, snap.CreatedAt.Local().Format(time.DateTime), snap.Tables, notes)
+		}
+		return nil
+	},
+}
+
+var schemaPinCmd = &cobra.Command{
+	Use:   "pin <snapshot>",
+	Short: "Make a snapshot the design baseline, kept through later discoveries",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		if err := eng.PinSchemaSnapshot(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Pinned schema snapshot %s as the design baseline.\n", args[0])
+		return nil
+	},
+}
+
+var schemaUnpinCmd = &cobra.Command{
+	Use:   "unpin",
+	Short: "Make the latest discovery the design baseline again",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		if err := eng.UnpinSchemaSnapshot(); err != nil {
+			return err
+		}
+		fmt.Println("The latest discovery is the design baseline.")
+		return nil
+	},
+}
+
+var schemaDeleteCmd = &cobra.Command{
+	Use:   "delete <snapshot>",
+	Short: "Hide a snapshot; it can be restored",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		if err := eng.DeleteSchemaSnapshot(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted schema snapshot %s. Restore it with `reloquent schema restore %s`.\n", args[0], args[0])
+		return nil
+	},
+}
+
+var schemaRestoreCmd = &cobra.Command{
+	Use:   "restore <snapshot>",
+	Short: "Bring back a deleted snapshot",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		if err := eng.RestoreSchemaSnapshot(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Restored schema snapshot %s.\n", args[0])
+		return nil
+	},
+}
+
+var schemaDiffCmd = &cobra.Command{
+	Use:   "diff <from> <to>",
+	Short: "Show what changed between two snapshots",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		changes, err := eng.DiffSchemaSnapshots(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if !changes.Diff.HasChanges() {
+			fmt.Println("No schema changes.")
+			return nil
+		}
+		fmt.Println(changes.Diff.Summary())
+		for _, ref := range changes.StaleReferences {
+			fmt.Printf("  mapping: %s\n", ref)
+		}
+		return nil
+	},
+}
+
+// projectSchemaPath is the schema the project's design is built on: the
+// baseline snapshot, or source-schema.yaml in projects discovered before
+// snapshots were kept.
+func projectSchemaPath() string {
+	if st, err := state.Load(""); err == nil && st.SchemaPath != "" {
+		return st.SchemaPath
+	}
+	return state.Active().Path("source-schema.yaml")
+}
+
+func init() {
+	schemaSnapshotsCmd.Flags().BoolVar(&schemaSnapshotsAll, "all", false, "include deleted snapshots")
+	schemaCmd.AddCommand(schemaSnapshotsCmd)
+	schemaCmd.AddCommand(schemaPinCmd)
+	schemaCmd.AddCommand(schemaUnpinCmd)
+	schemaCmd.AddCommand(schemaDeleteCmd)
+	schemaCmd.AddCommand(schemaRestoreCmd)
+	schemaCmd.AddCommand(schemaDiffCmd)
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -6,7 +6,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/reloquent/reloquent/internal/config"
 	"github.com/reloquent/reloquent/internal/wizard"
 )
 
@@ -18,11 +17,11 @@ var selectCmd = &cobra.Command{
 	Long: `Interactively select which source tables to include in the migration.
 
 Requires a previously discovered schema file. If --schema is not provided,
-looks for the schema at the default location (~/.reloquent/source-schema.yaml).`,
+uses the project's baseline schema snapshot.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		schemaPath := selectSchemaFile
 		if schemaPath == "" {
-			schemaPath = filepath.Join(config.ExpandHome("~/.reloquent"), "source-schema.yaml")
+			schemaPath = projectSchemaPath()
 		}
 
 		statePath := ""
@@ -36,6 +35,6 @@ looks for the schema at the default location (~/.reloquent/source-schema.yaml).`
 }
 
 func init() {
-	selectCmd.Flags().StringVar(&selectSchemaFile, "schema", "", "path to source schema YAML (default: ~/.reloquent/source-schema.yaml)")
+	selectCmd.Flags().StringVar(&selectSchemaFile, "schema", "", "path to source schema YAML (default: the project's baseline schema snapshot)")
 	rootCmd.AddCommand(selectCmd)
 }
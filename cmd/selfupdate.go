@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/selfupdate"
+)
+
+var (
+	selfUpdateCheck   bool
+	selfUpdateVersion string
+	selfUpdateForce   bool
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Replace this binary with the latest release",
+	Long: `Download the latest Reloquent release (or the one named by --version) for
+this platform, verify it against the release's checksums.txt, and replace the
+running binary with it.
+
+A build newer than this one may write state and config files this build cannot
+read; loading one fails with a hint to run self-update.
+
+Examples:
+  reloquent self-update --check
+  reloquent self-update
+  reloquent self-update --version v1.4.2 --force`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		ctx := cmd.Context()
+		u := selfupdate.New()
+
+		var rel *selfupdate.Release
+		var err error
+		if selfUpdateVersion != "" {
+			rel, err = u.Tagged(ctx, selfUpdateVersion)
+		} else {
+			rel, err = u.Latest(ctx)
+		}
+		if err != nil {
+			return err
+		}
+
+		cmp, cmpErr := selfupdate.Compare(version, rel.Tag)
+		switch {
+		case cmpErr != nil:
+			fmt.Printf("Current: %s (not a release build)\nRelease: %s\n", version, rel.Tag)
+			if selfUpdateCheck {
+				return nil
+			}
+			if !selfUpdateForce {
+				return fmt.Errorf("this is a %s build; pass --force to replace it with %s", version, rel.Tag)
+			}
+		case cmp >= 0 && !selfUpdateForce:
+			if selfUpdateVersion != "" && cmp > 0 {
+				fmt.Printf("Current: %s is newer than %s; pass --force to downgrade\n", version, rel.Tag)
+			} else {
+				fmt.Printf("Current: %s is up to date\n", version)
+			}
+			return nil
+		default:
+			fmt.Printf("Current: %s\nRelease: %s\n", version, rel.Tag)
+			if selfUpdateCheck {
+				fmt.Println("Run 'reloquent self-update' to install it.")
+				return nil
+			}
+		}
+
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("finding current binary: %w", err)
+		}
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+
+		fmt.Printf("Downloading %s...\n", selfupdate.ArchiveName(rel.Tag, u.OS, u.Arch))
+		bin, err := u.Download(ctx, rel)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Checksum verified.")
+		if err := selfupdate.Replace(exe, bin); err != nil {
+			return err
+		}
+		fmt.Printf("Updated %s to %s\n", exe, rel.Tag)
+		return nil
+	},
+}
+
+func init() {
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateCheck, "check", false, "only report whether a newer release exists")
+	selfUpdateCmd.Flags().StringVar(&selfUpdateVersion, "version", "", "install this release tag instead of the latest")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateForce, "force", false, "reinstall, downgrade, or replace a development build")
+	rootCmd.AddCommand(selfUpdateCmd)
+}
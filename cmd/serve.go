@@ -17,6 +17,7 @@ import (
 	"github.com/reloquent/reloquent/internal/api"
 	"github.com/reloquent/reloquent/internal/config"
 	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/logging"
 	"github.com/reloquent/reloquent/internal/ws"
 	"github.com/reloquent/reloquent/web"
 )
@@ -24,15 +25,33 @@ import (
 var servePort int
 var serveDevMode bool
 var serveConfig string
+var serveAuth string
+var serveToken string
+var serveNoMetrics bool
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the web UI server",
-	Long:  `Start the full web UI wizard on localhost. The web UI provides the complete migration workflow in the browser.`,
+	Long: `Start the full web UI wizard on localhost. The web UI provides the complete migration workflow in the browser.
+
+Send the server SIGHUP, or POST /api/config/reload, to reload the config
+file's log levels, limits, migration window and hooks without a restart.
+PUT /api/logging/levels changes the level of one component (discovery,
+migration, validation, api or aws) until the next reload.
+
+GET /metrics serves migration, validation, index build and API latency
+metrics for Prometheus, behind the same authentication as the API; turn it
+off with --no-metrics.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-			Level: slog.LevelInfo,
-		}))
+		// Levels so a reloaded logging section, or one set through the API,
+		// takes effect
+		levels, err := logging.NewLevels("info", nil)
+		if err != nil {
+			return err
+		}
+		logger := slog.New(logging.NewHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}), levels))
 
 		// Load config if provided
 		var cfg *config.Config
@@ -41,15 +60,21 @@ var serveCmd = &cobra.Command{
 			configPath = cfgFile
 		}
 		if configPath != "" {
-			var err error
 			cfg, err = config.Load(configPath)
 			if err != nil {
 				return fmt.Errorf("loading config: %w", err)
 			}
+			if err := levels.Configure(cfg.Logging.Level, cfg.Logging.Components); err != nil {
+				return fmt.Errorf("logging: %w", err)
+			}
 			logger.Info("loaded config", "path", configPath)
 		}
 
 		eng := engine.New(cfg, logger)
+		eng.SetLogLevels(levels)
+		if cfg != nil {
+			eng.SetConfigPath(configPath)
+		}
 
 		// Seed state from config so the UI can pre-fill connection forms
 		if cfg != nil {
@@ -80,10 +105,27 @@ var serveCmd = &cobra.Command{
 			return fmt.Errorf("loading embedded web UI: %w", err)
 		}
 
+		var authCfg config.AuthConfig
+		if cfg != nil {
+			authCfg = cfg.Server.Auth
+		}
+		if serveAuth != "" {
+			authCfg.Mode = serveAuth
+		}
+		if serveToken != "" {
+			authCfg.Token = serveToken
+		}
+		auth, err := api.NewAuthenticator(cmd.Context(), authCfg)
+		if err != nil {
+			return fmt.Errorf("configuring API auth: %w", err)
+		}
+
 		srv := api.New(eng, logger, servePort,
 			api.WithStaticFS(distFS),
 			api.WithHub(hub),
 			api.WithDevMode(serveDevMode),
+			api.WithAuth(auth),
+			api.WithMetrics(!serveNoMetrics),
 		)
 
 		// Graceful shutdown on signals
@@ -95,7 +137,28 @@ var serveCmd = &cobra.Command{
 			errCh <- srv.Start()
 		}()
 
-		fmt.Fprintf(os.Stderr, "Reloquent web UI: http://localhost:%d\n", servePort)
+		// SIGHUP reloads the config, as POST /api/config/reload does
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+		go func() {
+			for range hup {
+				if _, err := srv.ReloadConfig(); err != nil {
+					logger.Error("config reload failed", "error", err)
+				}
+			}
+		}()
+
+		switch auth.Mode() {
+		case api.AuthToken:
+			fmt.Fprintf(os.Stderr, "Reloquent web UI: http://localhost:%d/?token=%s\n", servePort, auth.Token())
+			fmt.Fprintf(os.Stderr, "API clients: send \"Authorization: Bearer %s\"\n", auth.Token())
+		case api.AuthNone:
+			fmt.Fprintf(os.Stderr, "Reloquent web UI: http://localhost:%d\n", servePort)
+			fmt.Fprintln(os.Stderr, "Warning: authentication is disabled; anyone who can reach this port can run migrations")
+		default:
+			fmt.Fprintf(os.Stderr, "Reloquent web UI: http://localhost:%d (%s auth)\n", servePort, auth.Mode())
+		}
 
 		select {
 		case err := <-errCh:
@@ -119,5 +182,8 @@ func init() {
 	serveCmd.Flags().IntVar(&servePort, "port", 8230, "port for the web UI server")
 	serveCmd.Flags().BoolVar(&serveDevMode, "dev", false, "enable CORS for development mode")
 	serveCmd.Flags().StringVar(&serveConfig, "config", "", "path to config file for pre-configured connections")
+	serveCmd.Flags().StringVar(&serveAuth, "auth", "", "API authentication: token (default), basic, oidc, or none; overrides server.auth.mode")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "API token for token auth (may be a secret reference); generated when unset")
+	serveCmd.Flags().BoolVar(&serveNoMetrics, "no-metrics", false, "do not serve Prometheus metrics on /metrics")
 	rootCmd.AddCommand(serveCmd)
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	sourceRoleName   string
+	sourceRoleCDC    bool
+	sourceRoleOutput string
+)
+
+var sourceRoleCmd = &cobra.Command{
+	Use:   "source-role",
+	Short: "Generate SQL creating a least-privilege source role",
+	Long: `Generate a script for the DBA creating a dedicated read-only role for the
+migration, so reloquent does not need superuser or application credentials.
+
+The role can log in and read the catalog, and gets SELECT on the selected
+tables and every table the mapping reads. With --cdc, or by default once
+` + "`reloquent cdc prepare`" + ` has run, it also gets what change data capture
+needs: REPLICATION on PostgreSQL, LogMiner access on Oracle. Regenerate the
+script after changing the selection or mapping.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		cdc := eng.CDCPrepared()
+		if cmd.Flags().Changed("cdc") {
+			cdc = sourceRoleCDC
+		}
+		script, err := eng.SourceRoleScript(sourceRoleName, cdc)
+		if err != nil {
+			return err
+		}
+		if sourceRoleOutput == "" {
+			fmt.Print(script)
+			return nil
+		}
+		if err := os.WriteFile(sourceRoleOutput, []byte(script), 0o644); err != nil {
+			return fmt.Errorf("writing script: %w", err)
+		}
+		fmt.Printf("Source role script written to %s\n", sourceRoleOutput)
+		return nil
+	},
+}
+
+func init() {
+	sourceRoleCmd.Flags().StringVar(&sourceRoleName, "name", "", "role to create (default: reloquent_migration)")
+	sourceRoleCmd.Flags().BoolVar(&sourceRoleCDC, "cdc", false, "include the grants change data capture needs (default: on once CDC is prepared)")
+	sourceRoleCmd.Flags().StringVarP(&sourceRoleOutput, "output", "o", "", "write the script to a file instead of stdout")
+	rootCmd.AddCommand(sourceRoleCmd)
+}
@@ -33,6 +33,7 @@ var statusCmd = &cobra.Command{
 			state.StepMigration,
 			state.StepValidation,
 			state.StepIndexBuilds,
+			state.StepCDC,
 		}
 
 		labels := map[state.Step]string{
@@ -48,6 +49,7 @@ var statusCmd = &cobra.Command{
 			state.StepMigration:         "10. Migration",
 			state.StepValidation:        "11. Validation",
 			state.StepIndexBuilds:       "12. Index Builds",
+			state.StepCDC:               "13. Change Data Capture",
 		}
 
 		for _, step := range steps {
@@ -89,6 +91,9 @@ var statusCmd = &cobra.Command{
 		if st.IndexPlanPath != "" {
 			fmt.Printf("Index Plan: %s\n", st.IndexPlanPath)
 		}
+		if st.CDCStatus != "" {
+			fmt.Printf("CDC: %s (from %s)\n", st.CDCStatus, st.CDCStartPosition)
+		}
 		if st.WriteConcernRestored {
 			fmt.Println("Write Concern: restored (majority, j:true)")
 		}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/telemetry"
+)
+
+// telemetryUploadTimeout bounds the upload made at the end of a command, so
+// an unreachable endpoint never holds up the user.
+const telemetryUploadTimeout = 3 * time.Second
+
+const telemetryPrompt = `Help improve Reloquent by sharing anonymous usage statistics?
+
+  Shared: the command run and how long it took, whether it failed and the
+  class of failure, the source database type, and the table count and data
+  size rounded into ranges.
+  Never shared: names, hosts, credentials, queries or error messages.
+
+Events are kept in %s until uploaded. Change
+your answer any time with "reloquent telemetry on" or "reloquent telemetry off".
+`
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Manage anonymous usage statistics",
+	Long: `Reloquent can share anonymous usage statistics to help its maintainers decide
+what to work on. It is off until you opt in, either when asked by "reloquent
+init" or the wizard, or with "reloquent telemetry on".
+
+Each event holds the command run, its duration, its outcome and error class,
+the source database type, and the table count and data size rounded into
+ranges. Events are spooled in ~/.reloquent/telemetry/spool.jsonl and uploaded
+to telemetry.endpoint from the config about once a day.
+
+RELOQUENT_TELEMETRY=off or DO_NOT_TRACK=1 turns telemetry off regardless of
+the recorded answer.
+
+Examples:
+  reloquent telemetry status
+  reloquent telemetry show
+  reloquent telemetry off`,
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether usage statistics are shared",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store := telemetry.Default()
+		c, err := store.Consent()
+		if err != nil {
+			return err
+		}
+		switch {
+		case c == nil:
+			fmt.Println("Telemetry: off (not asked yet)")
+		case c.Enabled:
+			fmt.Printf("Telemetry: on since %s\n", c.DecidedAt.Local().Format("2006-01-02"))
+		default:
+			fmt.Printf("Telemetry: off since %s\n", c.DecidedAt.Local().Format("2006-01-02"))
+		}
+		if telemetry.DisabledByEnv() {
+			fmt.Println("Turned off by RELOQUENT_TELEMETRY or DO_NOT_TRACK in the environment.")
+		}
+		events, err := store.Pending()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Spooled events: %d (%s)\n", len(events), store.SpoolPath())
+		if endpoint := telemetryEndpoint(); endpoint != "" {
+			fmt.Printf("Endpoint: %s\n", endpoint)
+		} else {
+			fmt.Println("Endpoint: none configured; events stay local")
+		}
+		return nil
+	},
+}
+
+var telemetryOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Share anonymous usage statistics",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := telemetry.Default().SetConsent(true, time.Now()); err != nil {
+			return err
+		}
+		fmt.Println("Telemetry on. Thank you!")
+		return nil
+	},
+}
+
+var telemetryOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Stop sharing usage statistics and delete spooled events",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := telemetry.Default().SetConsent(false, time.Now()); err != nil {
+			return err
+		}
+		fmt.Println("Telemetry off. Spooled events deleted.")
+		return nil
+	},
+}
+
+var telemetryShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the spooled events exactly as they would be uploaded",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		events, err := telemetry.Default().Pending()
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			fmt.Println("No spooled events.")
+			return nil
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(events)
+	},
+}
+
+var telemetryFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Upload the spooled events now",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := telemetry.Default().Flush(context.Background(), http.DefaultClient, telemetryEndpoint())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Uploaded %d events.\n", n)
+		return nil
+	},
+}
+
+func init() {
+	telemetryCmd.AddCommand(telemetryStatusCmd)
+	telemetryCmd.AddCommand(telemetryOnCmd)
+	telemetryCmd.AddCommand(telemetryOffCmd)
+	telemetryCmd.AddCommand(telemetryShowCmd)
+	telemetryCmd.AddCommand(telemetryFlushCmd)
+	rootCmd.AddCommand(telemetryCmd)
+}
+
+func telemetryEndpoint() string {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return ""
+	}
+	return cfg.Telemetry.Endpoint
+}
+
+// askTelemetryConsent asks for consent once, when stdin is a terminal and
+// the user has not answered yet. Any answer but yes leaves telemetry off.
+func askTelemetryConsent(reader *bufio.Reader) {
+	store := telemetry.Default()
+	if telemetry.DisabledByEnv() || !isTerminal(os.Stdin) {
+		return
+	}
+	if c, err := store.Consent(); err != nil || c != nil {
+		return
+	}
+	fmt.Printf(telemetryPrompt, store.SpoolPath())
+	answer := strings.ToLower(prompt(reader, "Share anonymous usage statistics? (y/N)", "n"))
+	enabled := answer == "y" || answer == "yes"
+	if err := store.SetConsent(enabled, time.Now()); err != nil {
+		fmt.Printf("Warning: could not save telemetry answer: %v\n", err)
+	}
+	fmt.Println()
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// recordTelemetry spools the event of a finished command and uploads the
+// spool once it is due. It never fails the command.
+func recordTelemetry(cmd *cobra.Command, start time.Time, runErr error) {
+	store := telemetry.Default()
+	if cmd == nil || !store.Enabled() {
+		return
+	}
+	name := strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()))
+	if name == "" {
+		name = "wizard"
+	}
+	if name == "telemetry" || strings.HasPrefix(name, "telemetry ") || name == "help" || strings.HasPrefix(name, "completion") {
+		return
+	}
+	ev := telemetry.NewEvent(version, name, start, time.Now(), runErr)
+	describeSource(&ev)
+	if err := store.Record(ev); err != nil {
+		return
+	}
+
+	endpoint := telemetryEndpoint()
+	if endpoint == "" || !store.Due(time.Now()) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryUploadTimeout)
+	defer cancel()
+	store.Flush(ctx, http.DefaultClient, endpoint)
+}
+
+// describeSource adds the bucketed shape of the active project's source to
+// ev: its database type, selected table count and data size.
+func describeSource(ev *telemetry.Event) {
+	st, err := state.Load("")
+	if err != nil {
+		return
+	}
+	if st.SourceConfig != nil {
+		ev.SourceType = st.SourceConfig.Type
+	}
+	if st.SchemaPath == "" {
+		return
+	}
+	s, err := schema.LoadYAML(st.SchemaPath)
+	if err != nil {
+		return
+	}
+	if ev.SourceType == "" {
+		ev.SourceType = s.DatabaseType
+	}
+	selected := make(map[string]bool, len(st.SelectedTables))
+	for _, t := range st.SelectedTables {
+		selected[t] = true
+	}
+	var tables int
+	var size int64
+	for _, t := range s.Tables {
+		if len(selected) > 0 && !selected[t.Name] {
+			continue
+		}
+		tables++
+		size += t.SizeBytes
+	}
+	ev.TableBucket = telemetry.TableBucket(tables)
+	ev.SizeBucket = telemetry.SizeBucket(size)
+}
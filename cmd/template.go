@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/template"
+)
+
+var (
+	templateName  string
+	templateCheck bool
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Reuse a mapping across environments",
+	Long: `Save the current project's mapping, type mapping and index plan as a
+template, and apply it in the project of another environment with the same
+schema, such as staging and prod after dev.
+
+Templates hold no connection details: the source host, credentials and target
+are placeholders, bound to the config of the project the template is applied in.`,
+}
+
+var templateExportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Write the current mapping, type mapping and index plan to a template",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+		t, err := eng.ExportTemplate(templateName)
+		if err != nil {
+			return err
+		}
+		if err := t.WriteYAML(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Exported template %s (%d collections, %d tables) to %s\n",
+			t.Name, len(t.Mapping.Collections), len(t.Schema.Tables), args[0])
+		return nil
+	},
+}
+
+var templateApplyCmd = &cobra.Command{
+	Use:   "apply <file>",
+	Short: "Apply a template to the current project's discovered schema",
+	Long: `Check a template against the schema discovered in the current project and,
+if it fits, select its tables and save its mapping, type mapping and index plan
+as the project's. Run ` + "`reloquent discover`" + ` first.
+
+Tables or join columns the mapping needs that the schema lacks stop the apply.
+Other differences in the mapped tables, such as new columns or changed types,
+are listed as warnings. With --check nothing is saved.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		t, err := template.LoadYAML(args[0])
+		if err != nil {
+			return err
+		}
+		eng, err := loadProjectEngine()
+		if err != nil {
+			return err
+		}
+
+		if templateCheck {
+			c, err := eng.CheckTemplate(t)
+			if err != nil {
+				return templateError(err)
+			}
+			printCompatibility(c)
+			if !c.Compatible {
+				return fmt.Errorf("template %s does not fit this schema", t.Name)
+			}
+			fmt.Printf("Template %s fits this schema.\n", t.Name)
+			return nil
+		}
+
+		res, err := eng.ApplyTemplate(t)
+		if res != nil {
+			printCompatibility(res.Compatibility)
+		}
+		if err != nil {
+			return templateError(err)
+		}
+		fmt.Println("Connection:")
+		for _, b := range res.Bindings {
+			fmt.Printf("  %-20s %s\n", b.Placeholder, b.Value)
+		}
+		fmt.Printf("Applied template %s: %d tables selected; mapping, type mapping and index plan saved.\n", res.Template, len(res.Tables))
+		return nil
+	},
+}
+
+func printCompatibility(c *template.Compatibility) {
+	for _, e := range c.Errors {
+		fmt.Printf("  ✗ %s\n", e)
+	}
+	for _, w := range c.Warnings {
+		fmt.Printf("  ! %s\n", w)
+	}
+}
+
+func templateError(err error) error {
+	if errors.Is(err, engine.ErrNoSchema) {
+		return fmt.Errorf("no schema discovered yet; run `reloquent discover` first")
+	}
+	if errors.Is(err, engine.ErrTemplateIncompatible) {
+		return engine.ErrTemplateIncompatible
+	}
+	return err
+}
+
+func init() {
+	templateExportCmd.Flags().StringVar(&templateName, "name", "", "template name (default: the project's name)")
+	templateApplyCmd.Flags().BoolVar(&templateCheck, "check", false, "only check the template against the schema")
+	templateCmd.AddCommand(templateExportCmd)
+	templateCmd.AddCommand(templateApplyCmd)
+	rootCmd.AddCommand(templateCmd)
+}
@@ -14,22 +14,86 @@ import (
 	"github.com/reloquent/reloquent/internal/source"
 	"github.com/reloquent/reloquent/internal/state"
 	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
+	"github.com/reloquent/reloquent/internal/validation"
 )
 
 var (
-	validateSamples int
-	validateFull    bool
+	validateSamples     int
+	validateFull        bool
+	validateMode        string
+	validateChunkSize   int64
+	validateConcurrency int
+	validateReadPref    string
+	validateReadConcern string
+	validateParallelism int
+	validateTimeBudget  string
+	validatePriority    []string
+	validateReferential string
+	validateRules       string
 )
 
 var validateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "Validate migration results",
-	Long:  `Compare source and target data to verify migration correctness via row counts, sampling, and aggregates.`,
+	Long: `Compare source and target data to verify migration correctness via row counts, sampling,
+aggregates, and BSON types checked against the type mapping.
+
+For very large collections, --mode checksum instead compares a checksum of
+every row, reading both sides in ranges of the primary key several at a time.
+
+--read-preference secondaryPreferred moves the target reads off the primary.
+Reads that may go to a secondary use a causally consistent session that
+waits for the primary's last write, with read concern majority unless
+--read-concern says otherwise, so counts and aggregates see every migrated
+document. Both default to the target's read_preference and read_concern.
+
+Collections are validated --parallelism at a time: those named by --priority
+first, then the largest first. With --time-budget, collections not validated
+when it runs out are reported as SKIPPED and the overall status is PARTIAL.
+
+Every document holding a reference to another collection (a foreign key
+kept as a reference in the mapping) is checked to have its parent there, and
+the orphans are counted per relationship. --referential sample checks the
+sampled documents, full every document, and off skips the check.
+
+--rules names a file of custom rules per collection, such as an aggregate
+that must match per group or a field that must never be null; each runs as
+SQL on the source and an aggregation pipeline on the target, both generated
+from the mapping. validation-rules.yaml next to the state file is used when
+--rules is not given.
+
+Examples:
+  reloquent validate
+  reloquent validate --parallelism 4 --time-budget 2h --priority orders,payments
+  reloquent validate --read-preference secondaryPreferred
+  reloquent validate --referential full
+  reloquent validate --rules rules.yaml
+  reloquent validate --mode checksum --chunk-size 50000 --concurrency 8`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := validation.Config{
+			Mode:           validateMode,
+			ChunkSize:      validateChunkSize,
+			Concurrency:    validateConcurrency,
+			Parallelism:    validateParallelism,
+			TimeBudget:     validateTimeBudget,
+			Priority:       validatePriority,
+			ReadPreference: validateReadPref,
+			ReadConcern:    validateReadConcern,
+			Referential:    validateReferential,
+			RulesFile:      validateRules,
+		}
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+
 		st, err := state.Load("")
 		if err != nil {
 			return fmt.Errorf("loading state: %w", err)
 		}
+		if st.TargetConfig != nil {
+			cfg = cfg.WithTargetDefaults(*st.TargetConfig)
+		}
 
 		if st.MigrationStatus != "completed" {
 			return fmt.Errorf("migration has not completed; run the migration first")
@@ -52,6 +116,16 @@ var validateCmd = &cobra.Command{
 			return fmt.Errorf("loading mapping: %w", err)
 		}
 
+		// Load type mapping for the type fidelity check
+		tm := typemap.ForDatabase(s.DatabaseType)
+		if st.TypeMappingPath != "" {
+			if loaded, err := typemap.LoadYAML(st.TypeMappingPath); err == nil {
+				tm = loaded
+			} else {
+				fmt.Printf("Warning: could not load type mapping: %v (using defaults)\n", err)
+			}
+		}
+
 		// Connect to source
 		srcReader, err := buildSourceReader(st.SourceConfig)
 		if err != nil {
@@ -60,7 +134,7 @@ var validateCmd = &cobra.Command{
 		defer srcReader.Close()
 
 		// Connect to target
-		tgtOp, err := target.NewMongoOperator(context.Background(),
+		tgtOp, err := target.Open(context.Background(),
 			st.TargetConfig.ConnectionString, st.TargetConfig.Database)
 		if err != nil {
 			return fmt.Errorf("connecting to target: %w", err)
@@ -73,8 +147,11 @@ var validateCmd = &cobra.Command{
 			Schema:     s,
 			Mapping:    m,
 			State:      st,
-			StatePath:  config.ExpandHome(state.DefaultPath),
+			StatePath:  state.Active().StatePath(),
 			SampleSize: validateSamples,
+			TypeMap:    tm,
+			Validation: cfg,
+			Hooks:      optionalHookRunner(st),
 		}
 
 		cb := postmigration.Callbacks{
@@ -85,6 +162,15 @@ var validateCmd = &cobra.Command{
 				}
 				fmt.Printf("  [%s] %s: %s\n", status, collection, checkType)
 			},
+			OnValidationChunk: func(p validation.ChunkProgress) {
+				if !p.Match {
+					fmt.Printf("  [FAIL] %s: keys %d-%d differ (source %d rows, target %d)\n",
+						p.Collection, p.From, p.To-1, p.SourceRows, p.TargetRows)
+				}
+				if p.Chunk == p.Chunks || p.Chunk%100 == 0 {
+					fmt.Printf("  %s: %d/%d chunks checked\n", p.Collection, p.Chunk, p.Chunks)
+				}
+			},
 		}
 
 		fmt.Println("Running validation...")
@@ -111,23 +197,9 @@ func buildSourceReader(sc *config.SourceConfig) (source.Reader, error) {
 	if sc == nil {
 		return nil, fmt.Errorf("no source configuration")
 	}
-	var reader source.Reader
-	switch sc.Type {
-	case "postgresql":
-		connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/%s",
-			sc.Username, sc.Password, sc.Host, sc.Port, sc.Database)
-		if sc.SSL {
-			connStr += "?sslmode=require"
-		} else {
-			connStr += "?sslmode=disable"
-		}
-		reader = source.NewPostgresReader(connStr, sc.Schema)
-	case "oracle":
-		connStr := fmt.Sprintf("oracle://%s:%s@%s:%d/%s",
-			sc.Username, sc.Password, sc.Host, sc.Port, sc.Database)
-		reader = source.NewOracleReader(connStr, sc.Schema)
-	default:
-		return nil, fmt.Errorf("unsupported source type: %s", sc.Type)
+	reader, err := source.NewReader(*sc)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := reader.Connect(context.Background()); err != nil {
@@ -139,5 +211,15 @@ func buildSourceReader(sc *config.SourceConfig) (source.Reader, error) {
 func init() {
 	validateCmd.Flags().IntVar(&validateSamples, "samples", 1000, "number of documents to sample per collection")
 	validateCmd.Flags().BoolVar(&validateFull, "full", false, "full row count + aggregate validation")
+	validateCmd.Flags().StringVar(&validateMode, "mode", validation.ModeFull, "validation mode: full or checksum")
+	validateCmd.Flags().Int64Var(&validateChunkSize, "chunk-size", validation.DefaultChunkSize, "primary key values per checksum chunk")
+	validateCmd.Flags().IntVar(&validateConcurrency, "concurrency", validation.DefaultConcurrency, "checksum chunks compared at once")
+	validateCmd.Flags().IntVar(&validateParallelism, "parallelism", 1, "collections validated at once")
+	validateCmd.Flags().StringVar(&validateTimeBudget, "time-budget", "", "time allowed for validation, e.g. 2h; collections not validated in time are reported as skipped")
+	validateCmd.Flags().StringSliceVar(&validatePriority, "priority", nil, "collections to validate first, in order; the rest go largest first")
+	validateCmd.Flags().StringVar(&validateReadPref, "read-preference", "", "target read preference: primary, primaryPreferred, secondary, secondaryPreferred or nearest")
+	validateCmd.Flags().StringVar(&validateReferential, "referential", validation.ReferentialSample, "referential integrity check: sample, full or off")
+	validateCmd.Flags().StringVar(&validateRules, "rules", "", "custom validation rules file; default validation-rules.yaml next to the state file")
+	validateCmd.Flags().StringVar(&validateReadConcern, "read-concern", "", "target read concern: local, majority or linearizable")
 	rootCmd.AddCommand(validateCmd)
 }
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/postmigration"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+var (
+	viewsDryRun      bool
+	viewsScriptsOnly bool
+)
+
+var viewsCmd = &cobra.Command{
+	Use:   "views",
+	Short: "Build or refresh materialized aggregation views",
+	Long: `Run the aggregation pipelines defined under views in the mapping and $merge
+their results into summary collections. Refresh scripts for mongosh are
+written next to the state file so views can be refreshed on demand.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, err := state.Load("")
+		if err != nil {
+			return fmt.Errorf("loading state: %w", err)
+		}
+
+		if st.MappingPath == "" {
+			return fmt.Errorf("no mapping available; run denormalization design first")
+		}
+		m, err := mapping.LoadYAML(st.MappingPath)
+		if err != nil {
+			return fmt.Errorf("loading mapping: %w", err)
+		}
+		if len(m.Views) == 0 {
+			fmt.Println("No materialized views defined in the mapping.")
+			return nil
+		}
+		if err := m.ValidateViews(); err != nil {
+			return fmt.Errorf("invalid views: %w", err)
+		}
+
+		if viewsDryRun {
+			fmt.Printf("Materialized views: %d\n\n", len(m.Views))
+			for _, v := range m.Views {
+				fmt.Printf("  %s <- %s (on %v, whenMatched %s, whenNotMatched %s)\n",
+					v.Name, v.Source, v.MergeOn(), v.MatchedAction(), v.NotMatchedAction())
+				fmt.Printf("    %s\n", v.Pipeline)
+			}
+			return nil
+		}
+
+		orch := &postmigration.Orchestrator{
+			Mapping:   m,
+			State:     st,
+			StatePath: state.Active().StatePath(),
+		}
+
+		if viewsScriptsOnly {
+			dir, err := orch.WriteViewScripts()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Refresh scripts written to %s\n", dir)
+			return nil
+		}
+
+		if st.TargetConfig == nil {
+			return fmt.Errorf("no target configuration; run the wizard first")
+		}
+		tgtOp, err := target.Open(context.Background(),
+			st.TargetConfig.ConnectionString, st.TargetConfig.Database)
+		if err != nil {
+			return fmt.Errorf("connecting to target: %w", err)
+		}
+		defer tgtOp.Close(context.Background())
+		orch.Target = tgtOp
+
+		fmt.Printf("Building %d materialized views...\n", len(m.Views))
+		if err := orch.RunViews(context.Background(), postmigration.Callbacks{
+			OnViewBuilt: printViewBuilt,
+		}); err != nil {
+			return fmt.Errorf("building views: %w", err)
+		}
+		fmt.Printf("Refresh scripts: %s\n", st.ViewScriptsDir)
+		return nil
+	},
+}
+
+func printViewBuilt(view string, err error) {
+	if err != nil {
+		fmt.Printf("  %s: FAILED (%v)\n", view, err)
+		return
+	}
+	fmt.Printf("  %s: built\n", view)
+}
+
+func init() {
+	viewsCmd.Flags().BoolVar(&viewsDryRun, "dry-run", false, "show views without building them")
+	viewsCmd.Flags().BoolVar(&viewsScriptsOnly, "scripts-only", false, "write refresh scripts without connecting to the target")
+	rootCmd.AddCommand(viewsCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/reloquent/reloquent/internal/wizard"
+)
+
+var wizardCmd = &cobra.Command{
+	Use:   "wizard",
+	Short: "Run the wizard, or replay a recorded design session",
+	Long: `Run the interactive wizard, as running reloquent without a subcommand does.
+
+Every wizard run records the decisions of its design steps (source and
+target connections, table selection, the denormalization design with its
+retention and ID generation choices, type mapping, shard keys and AWS setup)
+in session.yaml next to the project's state. --replay makes the decisions
+of such a file without the screens: the source is discovered afresh and the
+recorded design applied to it, stopping before pre-migration. Copy the file
+from a staging project, edit the hosts, databases and credential references,
+and replay it in a production project:
+
+  reloquent --project prod wizard --replay staging-session.yaml
+
+Replay fails if the recorded tables or mapping no longer match the
+discovered schema.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWizard()
+	},
+}
+
+// replayWizard replays a recorded session into the current project.
+func replayWizard(path string) error {
+	sess, err := wizard.LoadSession(path)
+	if err != nil {
+		return err
+	}
+	w, err := wizard.New("")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Replaying %s...\n", path)
+	return w.Replay(sess)
+}
+
+func init() {
+	wizardCmd.Flags().StringVar(&remote, "remote", "", "run the wizard against a reloquent server at this URL")
+	wizardCmd.Flags().StringVar(&token, "token", "", "API token for --remote (default: $RELOQUENT_TOKEN)")
+	wizardCmd.Flags().StringVar(&replay, "replay", "", "replay the design decisions recorded in this session file without the screens")
+	rootCmd.AddCommand(wizardCmd)
+}
@@ -0,0 +1,306 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/config"
+)
+
+// Auth modes for the API server.
+const (
+	AuthToken = "token"
+	AuthBasic = "basic"
+	AuthOIDC  = "oidc"
+	AuthNone  = "none"
+)
+
+const (
+	sessionCookie = "reloquent_session"
+	stateCookie   = "reloquent_oidc_state"
+	sessionTTL    = 12 * time.Hour
+)
+
+// Authenticator enforces authentication on every route except the health
+// check and the sign-in endpoints.
+type Authenticator struct {
+	mode     string
+	token    string
+	username string
+	password string
+	oidc     *oidcProvider
+
+	mu       sync.Mutex
+	sessions map[string]time.Time // OIDC session ID -> expiry
+}
+
+// NewAuthenticator builds an Authenticator from the auth config, resolving
+// secret references. Token mode without a configured token generates one;
+// read it back with Token. OIDC mode fetches the provider's discovery
+// document.
+func NewAuthenticator(ctx context.Context, cfg config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		mode:     cfg.Mode,
+		username: cfg.Username,
+		sessions: make(map[string]time.Time),
+	}
+	if a.mode == "" {
+		a.mode = AuthToken
+	}
+
+	var err error
+	switch a.mode {
+	case AuthToken:
+		if a.token, err = config.ResolveValue(cfg.Token); err != nil {
+			return nil, fmt.Errorf("resolving API token: %w", err)
+		}
+		if a.token == "" {
+			if a.token, err = randomToken(); err != nil {
+				return nil, err
+			}
+		}
+	case AuthBasic:
+		if cfg.Username == "" || cfg.Password == "" {
+			return nil, fmt.Errorf("basic auth requires a username and password")
+		}
+		if a.password, err = config.ResolveValue(cfg.Password); err != nil {
+			return nil, fmt.Errorf("resolving basic auth password: %w", err)
+		}
+	case AuthOIDC:
+		oc := cfg.OIDC
+		if oc.ClientSecret, err = config.ResolveValue(oc.ClientSecret); err != nil {
+			return nil, fmt.Errorf("resolving OIDC client secret: %w", err)
+		}
+		if a.oidc, err = discoverOIDC(ctx, oc); err != nil {
+			return nil, err
+		}
+	case AuthNone:
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q (expected token, basic, oidc, or none)", cfg.Mode)
+	}
+	return a, nil
+}
+
+// Mode returns the auth mode in effect.
+func (a *Authenticator) Mode() string {
+	return a.mode
+}
+
+// Token returns the bearer token accepted in token mode.
+func (a *Authenticator) Token() string {
+	return a.token
+}
+
+// registerRoutes adds the sign-in endpoints used by OIDC mode.
+func (a *Authenticator) registerRoutes(mux *http.ServeMux) {
+	if a.oidc == nil {
+		return
+	}
+	mux.HandleFunc("GET /auth/login", a.handleLogin)
+	mux.HandleFunc("GET /auth/callback", a.handleCallback)
+	mux.HandleFunc("POST /auth/logout", a.handleLogout)
+}
+
+// middleware rejects unauthenticated requests. Browsers opening a page in
+// OIDC mode are sent to the provider instead.
+func (a *Authenticator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.mode == AuthNone || r.URL.Path == "/api/health" || strings.HasPrefix(r.URL.Path, "/auth/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch a.mode {
+		case AuthToken:
+			if t := r.URL.Query().Get("token"); t != "" && a.validToken(t) {
+				// Exchange the token from the startup URL for a cookie so the
+				// SPA, downloads and the WebSocket carry it from then on.
+				a.setSession(w, r, a.token)
+				if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/") {
+					q := r.URL.Query()
+					q.Del("token")
+					u := *r.URL
+					u.RawQuery = q.Encode()
+					http.Redirect(w, r, u.String(), http.StatusFound)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if a.validToken(bearerToken(r)) || a.validToken(cookieValue(r, sessionCookie)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		case AuthBasic:
+			user, pass, ok := r.BasicAuth()
+			if ok && secureEqual(user, a.username) && secureEqual(pass, a.password) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="reloquent", charset="UTF-8"`)
+		case AuthOIDC:
+			if a.validSession(cookieValue(r, sessionCookie)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if raw := bearerToken(r); raw != "" {
+				if claims, err := a.oidc.verify(r.Context(), raw, ""); err == nil && a.oidc.allowed(claims) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/") {
+				http.Redirect(w, r, "/auth/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+				return
+			}
+		}
+		errorResponse(w, http.StatusUnauthorized, "authentication required")
+	})
+}
+
+func (a *Authenticator) validToken(t string) bool {
+	return t != "" && secureEqual(t, a.token)
+}
+
+func (a *Authenticator) validSession(id string) bool {
+	if id == "" {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	expires, ok := a.sessions[id]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expires) {
+		delete(a.sessions, id)
+		return false
+	}
+	return true
+}
+
+func (a *Authenticator) setSession(w http.ResponseWriter, r *http.Request, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(sessionTTL / time.Second),
+	})
+}
+
+func (a *Authenticator) handleLogin(w http.ResponseWriter, r *http.Request) {
+	state, err := randomToken()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	next := r.URL.Query().Get("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+		next = "/"
+	}
+	// The state doubles as the nonce; the cookie ties the callback to this
+	// browser and remembers where to return to.
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state + "|" + url.QueryEscape(next),
+		Path:     "/auth/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   600,
+	})
+	http.Redirect(w, r, a.oidc.authCodeURL(state), http.StatusFound)
+}
+
+func (a *Authenticator) handleCallback(w http.ResponseWriter, r *http.Request) {
+	state, nextEnc, _ := strings.Cut(cookieValue(r, stateCookie), "|")
+	if state == "" || !secureEqual(r.URL.Query().Get("state"), state) {
+		errorResponse(w, http.StatusBadRequest, "invalid sign-in state")
+		return
+	}
+	if e := r.URL.Query().Get("error"); e != "" {
+		errorResponse(w, http.StatusUnauthorized, "sign-in failed: "+e)
+		return
+	}
+
+	raw, err := a.oidc.exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		errorResponse(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	claims, err := a.oidc.verify(r.Context(), raw, state)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if !a.oidc.allowed(claims) {
+		errorResponse(w, http.StatusForbidden, fmt.Sprintf("%s is not allowed to use this server", claims.Email))
+		return
+	}
+
+	id, err := randomToken()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	a.mu.Lock()
+	a.sessions[id] = time.Now().Add(sessionTTL)
+	a.mu.Unlock()
+
+	a.setSession(w, r, id)
+	http.SetCookie(w, &http.Cookie{Name: stateCookie, Path: "/auth/", MaxAge: -1})
+	next, err := url.QueryUnescape(nextEnc)
+	if err != nil || next == "" {
+		next = "/"
+	}
+	http.Redirect(w, r, next, http.StatusFound)
+}
+
+func (a *Authenticator) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if id := cookieValue(r, sessionCookie); id != "" {
+		a.mu.Lock()
+		delete(a.sessions, id)
+		a.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
+
+func cookieValue(r *http.Request, name string) string {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
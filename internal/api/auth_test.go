@@ -0,0 +1,266 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/config"
+)
+
+func authServer(t *testing.T, cfg config.AuthConfig) (http.Handler, *Authenticator) {
+	t.Helper()
+	a, err := NewAuthenticator(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	s, _ := testServer(t, WithAuth(a))
+	return s.handler(), a
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	t.Setenv("RELOQUENT_TEST_API_TOKEN", "from-env")
+
+	tests := []struct {
+		name      string
+		cfg       config.AuthConfig
+		wantMode  string
+		wantToken string
+		wantErr   bool
+	}{
+		{"default generates token", config.AuthConfig{}, AuthToken, "", false},
+		{"configured token", config.AuthConfig{Mode: AuthToken, Token: "s3cret"}, AuthToken, "s3cret", false},
+		{"token reference", config.AuthConfig{Token: "${RELOQUENT_TEST_API_TOKEN}"}, AuthToken, "from-env", false},
+		{"basic", config.AuthConfig{Mode: AuthBasic, Username: "admin", Password: "pw"}, AuthBasic, "", false},
+		{"basic without password", config.AuthConfig{Mode: AuthBasic, Username: "admin"}, "", "", true},
+		{"oidc without issuer", config.AuthConfig{Mode: AuthOIDC}, "", "", true},
+		{"none", config.AuthConfig{Mode: AuthNone}, AuthNone, "", false},
+		{"unknown", config.AuthConfig{Mode: "kerberos"}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAuthenticator(context.Background(), tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if a.Mode() != tt.wantMode {
+				t.Errorf("Mode = %q, want %q", a.Mode(), tt.wantMode)
+			}
+			if tt.wantToken != "" && a.Token() != tt.wantToken {
+				t.Errorf("Token = %q, want %q", a.Token(), tt.wantToken)
+			}
+			if tt.wantMode == AuthToken && tt.wantToken == "" && len(a.Token()) < 32 {
+				t.Errorf("Token = %q, want a generated token", a.Token())
+			}
+		})
+	}
+}
+
+func TestTokenAuth(t *testing.T) {
+	h, _ := authServer(t, config.AuthConfig{Mode: AuthToken, Token: "s3cret"})
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		cookie string
+		want   int
+	}{
+		{"health is public", "/api/health", "", "", http.StatusOK},
+		{"no credentials", "/api/state", "", "", http.StatusUnauthorized},
+		{"wrong token", "/api/state", "Bearer nope", "", http.StatusUnauthorized},
+		{"bearer token", "/api/state", "Bearer s3cret", "", http.StatusOK},
+		{"session cookie", "/api/state", "", "s3cret", http.StatusOK},
+		{"query token on API", "/api/state?token=s3cret", "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: sessionCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenAuth_StartupURL(t *testing.T) {
+	h, _ := authServer(t, config.AuthConfig{Mode: AuthToken, Token: "s3cret"})
+
+	req := httptest.NewRequest("GET", "/review?token=s3cret", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusFound)
+	}
+	if loc := w.Header().Get("Location"); loc != "/review" {
+		t.Errorf("Location = %q, want token stripped", loc)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sessionCookie || !cookies[0].HttpOnly {
+		t.Errorf("cookies = %+v", cookies)
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	h, _ := authServer(t, config.AuthConfig{Mode: AuthBasic, Username: "admin", Password: "pw"})
+
+	req := httptest.NewRequest("GET", "/api/state", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic") {
+		t.Errorf("WWW-Authenticate = %q", w.Header().Get("WWW-Authenticate"))
+	}
+
+	req = httptest.NewRequest("GET", "/api/state", nil)
+	req.SetBasicAuth("admin", "pw")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+// testOIDC is a minimal OpenID Connect provider that signs ID tokens with
+// an RSA key.
+type testOIDC struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+}
+
+func newTestOIDC(t *testing.T) *testOIDC {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testOIDC{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *testOIDC) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	enc := func(v any) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := enc(map[string]string{"alg": "RS256", "kid": "k1"}) + "." + enc(claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestOIDCAuth(t *testing.T) {
+	idp := newTestOIDC(t)
+	h, _ := authServer(t, config.AuthConfig{
+		Mode: AuthOIDC,
+		OIDC: config.OIDCConfig{
+			Issuer:        idp.URL,
+			ClientID:      "reloquent",
+			RedirectURL:   "http://localhost:8230/auth/callback",
+			AllowedEmails: []string{"@example.com"},
+		},
+	})
+
+	exp := time.Now().Add(time.Hour).Unix()
+	tests := []struct {
+		name   string
+		claims map[string]any
+		want   int
+	}{
+		{"valid", map[string]any{"iss": idp.URL, "aud": "reloquent", "exp": exp, "email": "dba@example.com"}, http.StatusOK},
+		{"audience array", map[string]any{"iss": idp.URL, "aud": []string{"other", "reloquent"}, "exp": exp, "email": "dba@example.com"}, http.StatusOK},
+		{"wrong audience", map[string]any{"iss": idp.URL, "aud": "other", "exp": exp, "email": "dba@example.com"}, http.StatusUnauthorized},
+		{"wrong issuer", map[string]any{"iss": "https://evil.example", "aud": "reloquent", "exp": exp, "email": "dba@example.com"}, http.StatusUnauthorized},
+		{"expired", map[string]any{"iss": idp.URL, "aud": "reloquent", "exp": time.Now().Add(-time.Minute).Unix(), "email": "dba@example.com"}, http.StatusUnauthorized},
+		{"email not allowed", map[string]any{"iss": idp.URL, "aud": "reloquent", "exp": exp, "email": "someone@elsewhere.com"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/state", nil)
+			req.Header.Set("Authorization", "Bearer "+idp.sign(t, tt.claims))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("tampered signature", func(t *testing.T) {
+		raw := idp.sign(t, map[string]any{"iss": idp.URL, "aud": "reloquent", "exp": exp, "email": "dba@example.com"})
+		parts := strings.Split(raw, ".")
+		forged, _ := json.Marshal(map[string]any{"iss": idp.URL, "aud": "reloquent", "exp": exp, "email": "ceo@example.com"})
+		parts[1] = base64.RawURLEncoding.EncodeToString(forged)
+
+		req := httptest.NewRequest("GET", "/api/state", nil)
+		req.Header.Set("Authorization", "Bearer "+strings.Join(parts, "."))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("browser redirected to sign in", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/review", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusFound || w.Header().Get("Location") != "/auth/login?next=%2Freview" {
+			t.Fatalf("status = %d, Location = %q", w.Code, w.Header().Get("Location"))
+		}
+
+		req = httptest.NewRequest("GET", "/auth/login?next=%2Freview", nil)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if loc := w.Header().Get("Location"); !strings.HasPrefix(loc, idp.URL+"/authorize?") || !strings.Contains(loc, "client_id=reloquent") {
+			t.Errorf("Location = %q", loc)
+		}
+	})
+}
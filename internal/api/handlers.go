@@ -2,17 +2,63 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/reloquent/reloquent/internal/audit"
+	"github.com/reloquent/reloquent/internal/cdc"
+	"github.com/reloquent/reloquent/internal/collation"
 	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/cutover"
+	"github.com/reloquent/reloquent/internal/discovery"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/postmigration"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/sizing"
 	"github.com/reloquent/reloquent/internal/state"
 	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/template"
+	"github.com/reloquent/reloquent/internal/transform"
+	"github.com/reloquent/reloquent/internal/typemap"
+	"github.com/reloquent/reloquent/internal/validation"
 )
 
+func (s *Server) handleListProjectsImpl(w http.ResponseWriter, r *http.Request) {
+	projects, err := state.ListProjects()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := ProjectsResponse{Current: state.DefaultProject, Projects: projects}
+	if p := s.eng(r).Project(); p != nil {
+		resp.Current = p.Name
+	}
+	jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleCreateProjectImpl(w http.ResponseWriter, r *http.Request) {
+	var req CreateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	p, err := state.CreateProject(req.Name)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusCreated, p)
+}
+
 func (s *Server) handleGetStateImpl(w http.ResponseWriter, r *http.Request) {
-	st, err := s.engine.LoadState()
+	st, err := s.eng(r).LoadState()
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
@@ -34,6 +80,13 @@ func (s *Server) handleGetStateImpl(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, resp)
 }
 
+// handleGetDashboardImpl responds with every phase of the project at a
+// glance, for wall displays that poll it.
+func (s *Server) handleGetDashboardImpl(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	jsonResponse(w, http.StatusOK, s.eng(r).Dashboard())
+}
+
 func (s *Server) handleSetStepImpl(w http.ResponseWriter, r *http.Request) {
 	var req SetStepRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -41,7 +94,7 @@ func (s *Server) handleSetStepImpl(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.engine.NavigateToStep(state.Step(req.Step)); err != nil {
+	if err := s.eng(r).NavigateToStep(state.Step(req.Step)); err != nil {
 		errorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -50,7 +103,7 @@ func (s *Server) handleSetStepImpl(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleGetSourceConfigImpl(w http.ResponseWriter, r *http.Request) {
-	cfg := s.engine.Config
+	cfg := s.eng(r).Config
 	if cfg == nil {
 		jsonResponse(w, http.StatusOK, SourceConfigRequest{})
 		return
@@ -75,7 +128,7 @@ func (s *Server) handleTestSourceConnectionImpl(w http.ResponseWriter, r *http.R
 	}
 
 	cfg := req.toSourceConfig()
-	err := s.engine.TestSourceConnection(r.Context(), &cfg)
+	err := s.eng(r).TestSourceConnection(r.Context(), &cfg)
 	if err != nil {
 		jsonResponse(w, http.StatusOK, ConnectionTestResponse{
 			Success: false,
@@ -98,22 +151,30 @@ func (s *Server) handleDiscoverImpl(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cfg := req.toSourceConfig()
-	s.engine.SetSourceConfig(&cfg)
+	if cur := s.eng(r).Config; cur != nil {
+		// Not on the form; keep the config file's setting.
+		cfg.DiscoveryParallelism = cur.Source.DiscoveryParallelism
+	}
+	s.eng(r).SetSourceConfig(&cfg)
 
-	sch, err := s.engine.Discover(r.Context())
+	sch, err := s.eng(r).Discover(r.Context(), func(p discovery.Progress) {
+		if s.hub != nil {
+			s.hub.BroadcastDiscoveryProgress(p)
+		}
+	})
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	// Mark source_connection as complete
-	s.engine.CompleteCurrentStep()
+	s.eng(r).CompleteCurrentStep()
 
 	jsonResponse(w, http.StatusOK, sch)
 }
 
 func (s *Server) handleGetSchemaImpl(w http.ResponseWriter, r *http.Request) {
-	sch := s.engine.GetSchema()
+	sch := s.eng(r).GetSchema()
 	if sch == nil {
 		errorResponse(w, http.StatusNotFound, "no schema discovered yet")
 		return
@@ -121,8 +182,133 @@ func (s *Server) handleGetSchemaImpl(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, sch)
 }
 
+func (s *Server) handleGetSchemaDiffImpl(w http.ResponseWriter, r *http.Request) {
+	changes, err := s.eng(r).RediscoverAndDiff(r.Context())
+	if errors.Is(err, engine.ErrNoSchema) {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, changes)
+}
+
+func (s *Server) handleListSchemaSnapshotsImpl(w http.ResponseWriter, r *http.Request) {
+	list, err := s.eng(r).SchemaSnapshots(r.URL.Query().Get("deleted") == "true")
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, list)
+}
+
+func (s *Server) handleDiffSchemaSnapshotsImpl(w http.ResponseWriter, r *http.Request) {
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		errorResponse(w, http.StatusBadRequest, "from and to snapshot IDs are required")
+		return
+	}
+	changes, err := s.eng(r).DiffSchemaSnapshots(from, to)
+	if err != nil {
+		snapshotErrorResponse(w, err)
+		return
+	}
+	jsonResponse(w, http.StatusOK, changes)
+}
+
+func (s *Server) handlePinSchemaSnapshotImpl(w http.ResponseWriter, r *http.Request) {
+	s.updateSchemaSnapshots(w, r, func(eng *engine.Engine) error {
+		return eng.PinSchemaSnapshot(r.PathValue("id"))
+	})
+}
+
+func (s *Server) handleUnpinSchemaSnapshotImpl(w http.ResponseWriter, r *http.Request) {
+	s.updateSchemaSnapshots(w, r, (*engine.Engine).UnpinSchemaSnapshot)
+}
+
+func (s *Server) handleDeleteSchemaSnapshotImpl(w http.ResponseWriter, r *http.Request) {
+	s.updateSchemaSnapshots(w, r, func(eng *engine.Engine) error {
+		return eng.DeleteSchemaSnapshot(r.PathValue("id"))
+	})
+}
+
+func (s *Server) handleRestoreSchemaSnapshotImpl(w http.ResponseWriter, r *http.Request) {
+	s.updateSchemaSnapshots(w, r, func(eng *engine.Engine) error {
+		return eng.RestoreSchemaSnapshot(r.PathValue("id"))
+	})
+}
+
+// updateSchemaSnapshots applies a change to the project's snapshots and
+// responds with the full list, deleted ones included, so the client can
+// redraw it.
+func (s *Server) updateSchemaSnapshots(w http.ResponseWriter, r *http.Request, fn func(*engine.Engine) error) {
+	eng := s.eng(r)
+	if err := fn(eng); err != nil {
+		snapshotErrorResponse(w, err)
+		return
+	}
+	list, err := eng.SchemaSnapshots(true)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, list)
+}
+
+func snapshotErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, schema.ErrSnapshotNotFound):
+		errorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, schema.ErrSnapshotDeleted), errors.Is(err, engine.ErrSnapshotPinned):
+		errorResponse(w, http.StatusConflict, err.Error())
+	default:
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func (s *Server) handleGetSourceImpactImpl(w http.ResponseWriter, r *http.Request) {
+	eng := s.eng(r)
+	est, err := eng.SourceImpact()
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	last, err := eng.LastSourceImpact()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, SourceImpactResponse{Estimate: est, LastRun: last})
+}
+
+// handleGetSourceRoleScriptImpl responds with the SQL creating a read-only
+// source role, named by ?role=, with CDC grants when ?cdc=true or, without
+// it, once CDC has been prepared.
+func (s *Server) handleGetSourceRoleScriptImpl(w http.ResponseWriter, r *http.Request) {
+	eng := s.eng(r)
+	cdc := eng.CDCPrepared()
+	if v := r.URL.Query().Get("cdc"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "invalid cdc: "+v)
+			return
+		}
+		cdc = b
+	}
+	script, err := eng.SourceRoleScript(r.URL.Query().Get("role"), cdc)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/sql; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(script))
+}
+
 func (s *Server) handleGetTargetConfigImpl(w http.ResponseWriter, r *http.Request) {
-	cfg := s.engine.Config
+	cfg := s.eng(r).Config
 	if cfg == nil || cfg.Target.ConnectionString == "" {
 		jsonResponse(w, http.StatusOK, TargetConfigRequest{})
 		return
@@ -133,6 +319,25 @@ func (s *Server) handleGetTargetConfigImpl(w http.ResponseWriter, r *http.Reques
 	})
 }
 
+func (s *Server) handleSaveTargetConfigImpl(w http.ResponseWriter, r *http.Request) {
+	var req TargetConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ConnectionString == "" || req.Database == "" {
+		errorResponse(w, http.StatusBadRequest, "connection_string and database are required")
+		return
+	}
+
+	cfg := req.toTargetConfig()
+	if err := s.eng(r).SetTargetConfig(&cfg); err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
 func (s *Server) handleTestTargetConnectionImpl(w http.ResponseWriter, r *http.Request) {
 	var req TargetConfigRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -141,7 +346,7 @@ func (s *Server) handleTestTargetConnectionImpl(w http.ResponseWriter, r *http.R
 	}
 
 	cfg := req.toTargetConfig()
-	err := s.engine.TestTargetConnection(r.Context(), &cfg)
+	err := s.eng(r).TestTargetConnection(r.Context(), &cfg)
 	if err != nil {
 		jsonResponse(w, http.StatusOK, ConnectionTestResponse{
 			Success: false,
@@ -164,7 +369,7 @@ func (s *Server) handleDetectTopologyImpl(w http.ResponseWriter, r *http.Request
 	}
 
 	cfg := req.toTargetConfig()
-	topo, err := s.engine.DetectTopology(r.Context(), &cfg)
+	topo, err := s.eng(r).DetectTopology(r.Context(), &cfg)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
@@ -179,13 +384,13 @@ func (s *Server) handleDetectTopologyImpl(w http.ResponseWriter, r *http.Request
 }
 
 func (s *Server) handleGetTablesImpl(w http.ResponseWriter, r *http.Request) {
-	sch := s.engine.GetSchema()
+	sch := s.eng(r).GetSchema()
 	if sch == nil {
 		errorResponse(w, http.StatusNotFound, "no schema discovered yet")
 		return
 	}
 
-	st, err := s.engine.LoadState()
+	st, err := s.eng(r).LoadState()
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
@@ -223,7 +428,7 @@ func (s *Server) handleSelectTablesImpl(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := s.engine.SelectTables(req.Tables); err != nil {
+	if err := s.eng(r).SelectTables(req.Tables); err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -232,7 +437,7 @@ func (s *Server) handleSelectTablesImpl(w http.ResponseWriter, r *http.Request)
 }
 
 func (s *Server) handleGetMappingImpl(w http.ResponseWriter, r *http.Request) {
-	m := s.engine.GetMapping()
+	m := s.eng(r).GetMapping()
 	if m == nil {
 		errorResponse(w, http.StatusNotFound, "no mapping defined yet")
 		return
@@ -248,15 +453,40 @@ func (s *Server) handleSaveMappingImpl(w http.ResponseWriter, r *http.Request) {
 	}
 	// Re-encode and pass through to engine
 	data, _ := json.Marshal(m)
-	if err := s.engine.SaveMappingJSON(data); err != nil {
+	if err := s.eng(r).SaveMappingJSON(data); err != nil {
+		var lint *mapping.LintError
+		if errors.As(err, &lint) {
+			jsonResponse(w, http.StatusBadRequest, MappingIssuesResponse{Error: err.Error(), Issues: lint.Issues})
+			return
+		}
+		if errors.Is(err, engine.ErrInvalidMapping) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+	issues, _ := s.eng(r).MappingIssues()
+	if issues == nil {
+		issues = mapping.Issues{}
+	}
+	jsonResponse(w, http.StatusOK, MappingIssuesResponse{Status: "ok", Issues: issues})
+}
+
+func (s *Server) handleGetMappingLintImpl(w http.ResponseWriter, r *http.Request) {
+	issues, err := s.eng(r).MappingIssues()
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if issues == nil {
+		issues = mapping.Issues{}
+	}
+	jsonResponse(w, http.StatusOK, MappingIssuesResponse{Issues: issues})
 }
 
 func (s *Server) handleGetTypeMapImpl(w http.ResponseWriter, r *http.Request) {
-	tm := s.engine.GetTypeMap()
+	tm := s.eng(r).GetTypeMap()
 	if tm == nil {
 		errorResponse(w, http.StatusNotFound, "no type map available")
 		return
@@ -264,17 +494,22 @@ func (s *Server) handleGetTypeMapImpl(w http.ResponseWriter, r *http.Request) {
 
 	type typeMapEntry struct {
 		SourceType string `json:"source_type"`
+		Label      string `json:"label,omitempty"` // enum(…) for the enum type
 		BSONType   string `json:"bson_type"`
 		Overridden bool   `json:"overridden"`
 	}
 
 	entries := make([]typeMapEntry, 0)
 	for _, st := range tm.SortedTypes() {
-		entries = append(entries, typeMapEntry{
+		e := typeMapEntry{
 			SourceType: st,
 			BSONType:   string(tm.Resolve(st)),
 			Overridden: tm.IsOverridden(st),
-		})
+		}
+		if typemap.IsEnum(st) {
+			e.Label = typemap.EnumLabel(s.eng(r).GetSchema())
+		}
+		entries = append(entries, e)
 	}
 
 	jsonResponse(w, http.StatusOK, entries)
@@ -287,7 +522,7 @@ func (s *Server) handleSaveTypeMapImpl(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.engine.SaveTypeMapOverrides(overrides); err != nil {
+	if err := s.eng(r).SaveTypeMapOverrides(overrides); err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -295,204 +530,986 @@ func (s *Server) handleSaveTypeMapImpl(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-func (s *Server) handleGetSizingImpl(w http.ResponseWriter, r *http.Request) {
-	plan, err := s.engine.ComputeSizing()
+func (s *Server) handleGetLOBsImpl(w http.ResponseWriter, r *http.Request) {
+	lobs, err := s.eng(r).LOBColumns()
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, err.Error())
+		errorResponse(w, http.StatusNotFound, err.Error())
 		return
 	}
-	jsonResponse(w, http.StatusOK, plan)
+	if lobs == nil {
+		lobs = []typemap.LOBColumn{}
+	}
+	jsonResponse(w, http.StatusOK, lobs)
 }
 
-func (s *Server) handleRunBenchmarkImpl(w http.ResponseWriter, r *http.Request) {
-	var req BenchmarkRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+func (s *Server) handleSaveLOBsImpl(w http.ResponseWriter, r *http.Request) {
+	var lobs []typemap.LOBColumn
+	if err := json.NewDecoder(r.Body).Decode(&lobs); err != nil {
 		errorResponse(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
-
-	if req.Table == "" {
-		errorResponse(w, http.StatusBadRequest, "table is required")
+	for _, lob := range lobs {
+		if err := lob.Validate(); err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if err := s.eng(r).SaveLOBStrategies(lobs); err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
 
-	result, err := s.engine.RunBenchmark(r.Context(), req.Table, req.PartitionCol)
+func (s *Server) handleGetTimestampsImpl(w http.ResponseWriter, r *http.Request) {
+	cols, err := s.eng(r).TimestampColumns()
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, err.Error())
+		errorResponse(w, http.StatusNotFound, err.Error())
 		return
 	}
+	if cols == nil {
+		cols = []typemap.TimestampColumn{}
+	}
+	jsonResponse(w, http.StatusOK, cols)
+}
 
-	jsonResponse(w, http.StatusOK, result)
+func (s *Server) handleGetDecimalsImpl(w http.ResponseWriter, r *http.Request) {
+	cols, err := s.eng(r).DecimalColumns()
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if cols == nil {
+		cols = []typemap.DecimalColumn{}
+	}
+	jsonResponse(w, http.StatusOK, cols)
 }
 
-func (s *Server) handleConfigureAWSImpl(w http.ResponseWriter, r *http.Request) {
-	var req AWSConfigRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+func (s *Server) handleSaveTimestampsImpl(w http.ResponseWriter, r *http.Request) {
+	var cols []typemap.TimestampColumn
+	if err := json.NewDecoder(r.Body).Decode(&cols); err != nil {
 		errorResponse(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
-
-	cfg := config.AWSConfig{
-		Region:   req.Region,
-		Profile:  req.Profile,
-		S3Bucket: req.S3Bucket,
-		Platform: req.Platform,
+	for _, c := range cols {
+		if err := c.Validate(); err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
-
-	if err := s.engine.SaveAWSConfig(&cfg); err != nil {
+	if err := s.eng(r).SaveTimestampPolicies(cols); err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-
 	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-func (s *Server) handleValidateAWSImpl(w http.ResponseWriter, r *http.Request) {
-	result, err := s.engine.ValidateAWS(r.Context())
+// handleAuditTrailImpl returns the project's audit trail, newest first,
+// filtered by the action, since (RFC 3339) and limit query parameters.
+func (s *Server) handleAuditTrailImpl(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := audit.Filter{Action: q.Get("action"), Limit: 100}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errorResponse(w, http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		f.Limit = n
+	}
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "since must be an RFC 3339 time")
+			return
+		}
+		f.Since = t
+	}
+	entries, err := s.eng(r).AuditTrail(f)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	jsonResponse(w, http.StatusOK, result)
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	jsonResponse(w, http.StatusOK, entries)
 }
 
-func (s *Server) handlePreMigrationPrepareImpl(w http.ResponseWriter, r *http.Request) {
-	if err := s.engine.PreMigrationPrepare(r.Context()); err != nil {
+func (s *Server) handleGetBooleansImpl(w http.ResponseWriter, r *http.Request) {
+	sample := 0
+	if v := r.URL.Query().Get("sample"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errorResponse(w, http.StatusBadRequest, "sample must be a non-negative integer")
+			return
+		}
+		sample = n
+	}
+	cols, err := s.eng(r).BooleanColumns(r.Context(), sample)
+	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
-}
-
-func (s *Server) handlePreMigrationStatusImpl(w http.ResponseWriter, r *http.Request) {
-	result := s.engine.PreMigrationStatus()
-	jsonResponse(w, http.StatusOK, result)
+	if cols == nil {
+		cols = []typemap.BooleanColumn{}
+	}
+	jsonResponse(w, http.StatusOK, cols)
 }
 
-func (s *Server) handleStartMigrationImpl(w http.ResponseWriter, r *http.Request) {
-	callback := func(status *migration.Status) {
-		if s.hub != nil {
-			s.hub.BroadcastMigrationProgress(status)
-		}
+func (s *Server) handleSaveBooleansImpl(w http.ResponseWriter, r *http.Request) {
+	var cols []typemap.BooleanColumn
+	if err := json.NewDecoder(r.Body).Decode(&cols); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
 	}
-
-	if err := s.engine.StartMigration(r.Context(), callback); err != nil {
-		errorResponse(w, http.StatusConflict, err.Error())
+	if err := s.eng(r).SaveBooleanConversions(cols); err != nil {
+		if errors.Is(err, engine.ErrInvalidMapping) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-
-	jsonResponse(w, http.StatusAccepted, AsyncAcceptedResponse{
-		Status:  "accepted",
-		Message: "Migration started",
-	})
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-func (s *Server) handleMigrationStatusImpl(w http.ResponseWriter, r *http.Request) {
-	status := s.engine.MigrationStatus()
-	jsonResponse(w, http.StatusOK, status)
+func (s *Server) handleGetMasksImpl(w http.ResponseWriter, r *http.Request) {
+	cols, err := s.eng(r).PIIColumns()
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if cols == nil {
+		cols = []transform.PIIColumn{}
+	}
+	jsonResponse(w, http.StatusOK, cols)
 }
 
-func (s *Server) handleRetryMigrationImpl(w http.ResponseWriter, r *http.Request) {
-	var req RetryMigrationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+func (s *Server) handleSaveMasksImpl(w http.ResponseWriter, r *http.Request) {
+	var cols []transform.PIIColumn
+	if err := json.NewDecoder(r.Body).Decode(&cols); err != nil {
 		errorResponse(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
-
-	callback := func(status *migration.Status) {
-		if s.hub != nil {
-			s.hub.BroadcastMigrationProgress(status)
+	if err := s.eng(r).SaveMasks(cols); err != nil {
+		if errors.Is(err, engine.ErrInvalidMapping) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
 		}
-	}
-
-	if err := s.engine.RetryMigration(r.Context(), req.Collections, callback); err != nil {
-		errorResponse(w, http.StatusConflict, err.Error())
+		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-
-	jsonResponse(w, http.StatusAccepted, AsyncAcceptedResponse{
-		Status:  "accepted",
-		Message: "Migration retry started",
-	})
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-func (s *Server) handleAbortMigrationImpl(w http.ResponseWriter, r *http.Request) {
-	if err := s.engine.AbortMigration(); err != nil {
-		errorResponse(w, http.StatusConflict, err.Error())
+func (s *Server) handleGetCollationImpl(w http.ResponseWriter, r *http.Request) {
+	report, err := s.eng(r).CollationAdvice()
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
 		return
 	}
-	jsonResponse(w, http.StatusOK, map[string]string{"status": "aborted"})
+	jsonResponse(w, http.StatusOK, report)
 }
 
-func (s *Server) handleRunValidationImpl(w http.ResponseWriter, r *http.Request) {
-	callback := func(collection, checkType string, passed bool) {
-		if s.hub != nil {
-			s.hub.BroadcastValidationCheck(map[string]any{
-				"collection": collection,
-				"check_type": checkType,
-				"passed":     passed,
-			})
-		}
+func (s *Server) handleSaveJoinNormalizationsImpl(w http.ResponseWriter, r *http.Request) {
+	var joins []collation.JoinAdvice
+	if err := json.NewDecoder(r.Body).Decode(&joins); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
 	}
-
-	if err := s.engine.RunValidation(r.Context(), callback); err != nil {
+	if err := s.eng(r).SaveJoinNormalizations(joins); err != nil {
+		if errors.Is(err, engine.ErrInvalidMapping) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-
-	jsonResponse(w, http.StatusAccepted, AsyncAcceptedResponse{
-		Status:  "accepted",
-		Message: "Validation started",
-	})
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-func (s *Server) handleValidationResultsImpl(w http.ResponseWriter, r *http.Request) {
-	result := s.engine.ValidationResults()
-	if result == nil {
-		errorResponse(w, http.StatusNotFound, "no validation results available")
+func (s *Server) handleGetUniqueConstraintsImpl(w http.ResponseWriter, r *http.Request) {
+	eng := s.eng(r)
+	report, err := eng.UniqueConstraints()
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
 		return
 	}
-	jsonResponse(w, http.StatusOK, result)
-}
-
-func (s *Server) handleGetIndexPlanImpl(w http.ResponseWriter, r *http.Request) {
-	plan, err := s.engine.GetIndexPlan()
+	plan, err := eng.GetIndexPlan()
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	jsonResponse(w, http.StatusOK, plan)
+	unenforced := plan.Unenforced(report)
+	if unenforced == nil {
+		unenforced = []indexes.UniqueConstraint{}
+	}
+	jsonResponse(w, http.StatusOK, UniqueConstraintsResponse{UniqueReport: report, Unenforced: unenforced})
 }
 
-func (s *Server) handleBuildIndexesImpl(w http.ResponseWriter, r *http.Request) {
-	callback := func(status []target.IndexBuildStatus) {
-		if s.hub != nil {
-			s.hub.BroadcastIndexProgress(status)
+func (s *Server) handleApplyUniqueConversionsImpl(w http.ResponseWriter, r *http.Request) {
+	n, err := s.eng(r).ApplyUniqueConversions()
+	if err != nil {
+		if errors.Is(err, engine.ErrInvalidIndexPlan) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
 		}
-	}
-
-	if err := s.engine.BuildIndexes(r.Context(), callback); err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-
-	jsonResponse(w, http.StatusAccepted, AsyncAcceptedResponse{
-		Status:  "accepted",
-		Message: "Index build started",
-	})
+	jsonResponse(w, http.StatusOK, ApplyUniqueResponse{Added: n})
 }
 
-func (s *Server) handleIndexStatusImpl(w http.ResponseWriter, r *http.Request) {
-	result, err := s.engine.IndexBuildStatus()
+func (s *Server) handleGetSizingImpl(w http.ResponseWriter, r *http.Request) {
+	plan, err := s.eng(r).ComputeSizing()
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	jsonResponse(w, http.StatusOK, result)
-}
+	// units previews the run on a cluster of that many workers or DPUs.
+	if v := r.URL.Query().Get("units"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errorResponse(w, http.StatusBadRequest, "units must be a non-negative integer")
+			return
+		}
+		if err := plan.Resize(n); err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	jsonResponse(w, http.StatusOK, plan)
+}
+
+func (s *Server) handleGetShardAdvisorImpl(w http.ResponseWriter, r *http.Request) {
+	sample := 0
+	if v := r.URL.Query().Get("sample"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errorResponse(w, http.StatusBadRequest, "sample must be a non-negative integer")
+			return
+		}
+		sample = n
+	}
+
+	advice, err := s.eng(r).ShardAdvisor(r.Context(), sample)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if advice == nil {
+		advice = []sizing.ShardAdvice{}
+	}
+	jsonResponse(w, http.StatusOK, ShardAdvisorResponse{Collections: advice})
+}
+
+func (s *Server) handleGetPlanImpl(w http.ResponseWriter, r *http.Request) {
+	p, err := s.eng(r).Plan()
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if format := r.URL.Query().Get("format"); format == "yaml" {
+		data, err := p.Marshal(format)
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+		return
+	}
+	jsonResponse(w, http.StatusOK, p)
+}
+
+// handleExportTemplateImpl responds with a template of the project's
+// mapping, type mapping and index plan, named by the name parameter.
+func (s *Server) handleExportTemplateImpl(w http.ResponseWriter, r *http.Request) {
+	t, err := s.eng(r).ExportTemplate(r.URL.Query().Get("name"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, t)
+}
+
+// decodeTemplate reads a template from the request body, responding with
+// an error when it cannot be applied.
+func decodeTemplate(w http.ResponseWriter, r *http.Request) (*template.Template, bool) {
+	var t template.Template
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return nil, false
+	}
+	if err := t.Validate(); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return &t, true
+}
+
+func (s *Server) handleCheckTemplateImpl(w http.ResponseWriter, r *http.Request) {
+	t, ok := decodeTemplate(w, r)
+	if !ok {
+		return
+	}
+	c, err := s.eng(r).CheckTemplate(t)
+	if errors.Is(err, engine.ErrNoSchema) {
+		errorResponse(w, http.StatusConflict, "discover the source schema before applying a template")
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, c)
+}
+
+func (s *Server) handleApplyTemplateImpl(w http.ResponseWriter, r *http.Request) {
+	t, ok := decodeTemplate(w, r)
+	if !ok {
+		return
+	}
+	res, err := s.eng(r).ApplyTemplate(t)
+	switch {
+	case errors.Is(err, engine.ErrNoSchema):
+		errorResponse(w, http.StatusConflict, "discover the source schema before applying a template")
+	case errors.Is(err, engine.ErrTemplateIncompatible):
+		errorResponse(w, http.StatusConflict, err.Error())
+	case err != nil:
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+	default:
+		jsonResponse(w, http.StatusOK, res)
+	}
+}
+
+func (s *Server) handleGetDictionaryImpl(w http.ResponseWriter, r *http.Request) {
+	samples := 0
+	if v := r.URL.Query().Get("samples"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errorResponse(w, http.StatusBadRequest, "samples must be a non-negative integer")
+			return
+		}
+		samples = n
+	}
+
+	d, err := s.eng(r).DataDictionary(r.Context(), samples)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		jsonResponse(w, http.StatusOK, d)
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(d.Markdown()))
+	case "html":
+		out, err := d.HTML()
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(out))
+	default:
+		errorResponse(w, http.StatusBadRequest, "unknown format: "+format)
+	}
+}
+
+func (s *Server) handleGetCutoverImpl(w http.ResponseWriter, r *http.Request) {
+	rb, err := s.eng(r).CutoverRunbook()
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeRunbook(w, r, rb)
+}
+
+func (s *Server) handleGetFallbackImpl(w http.ResponseWriter, r *http.Request) {
+	rb, err := s.eng(r).FallbackPlan()
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeRunbook(w, r, rb)
+}
+
+// writeRunbook responds with a runbook as JSON, or as Markdown with
+// ?format=markdown.
+func writeRunbook(w http.ResponseWriter, r *http.Request, rb *cutover.Runbook) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		jsonResponse(w, http.StatusOK, rb)
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(rb.Markdown()))
+	default:
+		errorResponse(w, http.StatusBadRequest, "unknown format: "+format)
+	}
+}
+
+func (s *Server) handleRunBenchmarkImpl(w http.ResponseWriter, r *http.Request) {
+	var req BenchmarkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Table == "" {
+		errorResponse(w, http.StatusBadRequest, "table is required")
+		return
+	}
+
+	result, err := s.eng(r).RunBenchmark(r.Context(), req.Table, req.PartitionCol, req.DryRun)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, result)
+}
+
+func (s *Server) handleRunWriteBenchmarkImpl(w http.ResponseWriter, r *http.Request) {
+	var req WriteBenchmarkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	result, err := s.eng(r).RunWriteBenchmark(r.Context(), req.DryRun)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, result)
+}
+
+func (s *Server) handleConfigureAWSImpl(w http.ResponseWriter, r *http.Request) {
+	var req AWSConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	cfg := config.AWSConfig{
+		Region:   req.Region,
+		Profile:  req.Profile,
+		S3Bucket: req.S3Bucket,
+		Platform: req.Platform,
+	}
+
+	if err := s.eng(r).SaveAWSConfig(&cfg); err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleValidateAWSImpl(w http.ResponseWriter, r *http.Request) {
+	result, err := s.eng(r).ValidateAWS(r.Context())
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, result)
+}
+
+func (s *Server) handlePreMigrationPrepareImpl(w http.ResponseWriter, r *http.Request) {
+	if err := s.eng(r).PreMigrationPrepare(r.Context()); err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handlePreMigrationStatusImpl(w http.ResponseWriter, r *http.Request) {
+	result := s.eng(r).PreMigrationStatus()
+	jsonResponse(w, http.StatusOK, result)
+}
+
+func (s *Server) handleStartMigrationImpl(w http.ResponseWriter, r *http.Request) {
+	// The body is optional; without one a full migration starts.
+	var req StartMigrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	eng := s.eng(r)
+	callback := func(status *migration.Status) {
+		if s.hub != nil {
+			s.hub.BroadcastMigrationProgress(status)
+		}
+		if s.metrics != nil {
+			s.metrics.observeMigration(projectLabel(eng), status)
+		}
+	}
+
+	start, msg := eng.StartMigration, "Migration started"
+	if req.Delta {
+		start, msg = eng.StartDeltaMigration, "Delta migration started"
+	}
+	if err := start(r.Context(), callback); err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusAccepted, AsyncAcceptedResponse{
+		Status:  "accepted",
+		Message: msg,
+	})
+}
+
+func (s *Server) handleMigrationStatusImpl(w http.ResponseWriter, r *http.Request) {
+	status := s.eng(r).MigrationStatus()
+	jsonResponse(w, http.StatusOK, status)
+}
+
+// handleRunThroughputImpl returns the throughput samples a run recorded
+// while migrating, for charting.
+func (s *Server) handleRunThroughputImpl(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid run ID")
+		return
+	}
+	tp, err := s.eng(r).RunThroughput(id)
+	if errors.Is(err, engine.ErrRunNotFound) {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, tp)
+}
+
+func (s *Server) handleRetryMigrationImpl(w http.ResponseWriter, r *http.Request) {
+	var req RetryMigrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	eng := s.eng(r)
+	callback := func(status *migration.Status) {
+		if s.hub != nil {
+			s.hub.BroadcastMigrationProgress(status)
+		}
+		if s.metrics != nil {
+			s.metrics.observeMigration(projectLabel(eng), status)
+		}
+	}
+
+	if err := eng.RetryMigration(r.Context(), req.Collections, callback); err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusAccepted, AsyncAcceptedResponse{
+		Status:  "accepted",
+		Message: "Migration retry started",
+	})
+}
+
+func (s *Server) handleAbortMigrationImpl(w http.ResponseWriter, r *http.Request) {
+	if err := s.eng(r).AbortMigration(); err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "aborted"})
+}
+
+func (s *Server) handlePauseMigrationImpl(w http.ResponseWriter, r *http.Request) {
+	if err := s.eng(r).PauseMigration(); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, engine.ErrNoMigration) {
+			status = http.StatusConflict
+		}
+		errorResponse(w, status, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": migration.PhasePaused})
+}
+
+func (s *Server) handleResumeMigrationImpl(w http.ResponseWriter, r *http.Request) {
+	eng := s.eng(r)
+	callback := func(status *migration.Status) {
+		if s.hub != nil {
+			s.hub.BroadcastMigrationProgress(status)
+		}
+		if s.metrics != nil {
+			s.metrics.observeMigration(projectLabel(eng), status)
+		}
+	}
+
+	// A migration paused before a restart runs again, reporting here
+	if err := eng.ResumeMigration(r.Context(), callback); err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "resumed"})
+}
+
+func (s *Server) handleRunValidationImpl(w http.ResponseWriter, r *http.Request) {
+	// The body is optional; without one the full checks run.
+	var req RunValidationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := req.Config.Validate(); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	eng := s.eng(r)
+	callback := func(collection, checkType string, passed bool) {
+		if s.hub != nil {
+			s.hub.BroadcastValidationCheck(map[string]any{
+				"collection": collection,
+				"check_type": checkType,
+				"passed":     passed,
+			})
+		}
+		if s.metrics != nil {
+			s.metrics.observeValidationCheck(projectLabel(eng), checkType, passed)
+		}
+	}
+	onChunk := func(p validation.ChunkProgress) {
+		if s.hub != nil {
+			s.hub.BroadcastValidationChunk(p)
+		}
+	}
+
+	if err := eng.RunValidation(r.Context(), req.Config, callback, onChunk); err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusAccepted, AsyncAcceptedResponse{
+		Status:  "accepted",
+		Message: "Validation started",
+	})
+}
+
+func (s *Server) handleValidationResultsImpl(w http.ResponseWriter, r *http.Request) {
+	result := s.eng(r).ValidationResults()
+	if result == nil {
+		errorResponse(w, http.StatusNotFound, "no validation results available")
+		return
+	}
+	jsonResponse(w, http.StatusOK, result)
+}
+
+// handleValidationFailuresImpl diagnoses the collections that failed the
+// last validation, with the remediation suggested and decided for each.
+func (s *Server) handleValidationFailuresImpl(w http.ResponseWriter, r *http.Request) {
+	failures, err := s.eng(r).ValidationFailures()
+	if errors.Is(err, engine.ErrNoValidation) {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, failures)
+}
+
+// handleRemediateImpl records the decision on a failed collection; a
+// re-migration runs in the background and reports progress like any other.
+func (s *Server) handleRemediateImpl(w http.ResponseWriter, r *http.Request) {
+	var req RemediationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	eng := s.eng(r)
+	callback := func(status *migration.Status) {
+		if s.hub != nil {
+			s.hub.BroadcastMigrationProgress(status)
+		}
+		if s.metrics != nil {
+			s.metrics.observeMigration(projectLabel(eng), status)
+		}
+	}
+
+	rem, err := eng.Remediate(r.Context(), req.Collection, req.Action, req.Justification, callback)
+	switch {
+	case errors.Is(err, engine.ErrInvalidRemediation):
+		errorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, engine.ErrNoValidation):
+		errorResponse(w, http.StatusConflict, err.Error())
+	case err != nil:
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+	default:
+		if s.hub != nil {
+			s.hub.BroadcastStateChanged()
+		}
+		jsonResponse(w, http.StatusOK, rem)
+	}
+}
+
+func (s *Server) handleGetRetentionImpl(w http.ResponseWriter, r *http.Request) {
+	eng := s.eng(r)
+	candidates, err := eng.RetentionCandidates()
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	resp := RetentionResponse{Candidates: candidates}
+	if rules, err := eng.OnlineArchiveRules(); err == nil {
+		resp.OnlineArchive = rules
+	}
+	jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGetRetentionHistogramImpl(w http.ResponseWriter, r *http.Request) {
+	table := r.URL.Query().Get("table")
+	column := r.URL.Query().Get("column")
+	if table == "" || column == "" {
+		errorResponse(w, http.StatusBadRequest, "table and column are required")
+		return
+	}
+	h, err := s.eng(r).RetentionHistogram(r.Context(), table, column)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, h)
+}
+
+func (s *Server) handleSetRetentionPolicyImpl(w http.ResponseWriter, r *http.Request) {
+	var req SetRetentionPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := s.eng(r).SetRetentionPolicy(req.Collection, req.Policy); err != nil {
+		if errors.Is(err, engine.ErrInvalidMapping) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleGetIDGenerationImpl(w http.ResponseWriter, r *http.Request) {
+	candidates, err := s.eng(r).IDCandidates()
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, candidates)
+}
+
+func (s *Server) handleSetIDGenerationImpl(w http.ResponseWriter, r *http.Request) {
+	var req SetIDGenerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := s.eng(r).SetIDGeneration(req.Collection, req.Strategy); err != nil {
+		if errors.Is(err, engine.ErrInvalidMapping) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleGetValidatorsImpl(w http.ResponseWriter, r *http.Request) {
+	validators, err := s.eng(r).Validators()
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if validators == nil {
+		validators = []engine.CollectionValidator{}
+	}
+	jsonResponse(w, http.StatusOK, validators)
+}
+
+func (s *Server) handleSetValidationLevelImpl(w http.ResponseWriter, r *http.Request) {
+	var req SetValidationLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := s.eng(r).SetValidationLevel(req.Collection, req.Level); err != nil {
+		if errors.Is(err, engine.ErrInvalidMapping) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleSeedCountersImpl(w http.ResponseWriter, r *http.Request) {
+	seeds, err := s.eng(r).SeedCounters(r.Context())
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if seeds == nil {
+		seeds = []postmigration.CounterSeed{}
+	}
+	jsonResponse(w, http.StatusOK, seeds)
+}
+
+func (s *Server) handleGetIndexPlanImpl(w http.ResponseWriter, r *http.Request) {
+	plan, err := s.eng(r).GetIndexPlan()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, plan)
+}
+
+func (s *Server) handleSaveIndexPlanImpl(w http.ResponseWriter, r *http.Request) {
+	var plan indexes.IndexPlan
+	if err := json.NewDecoder(r.Body).Decode(&plan); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := s.eng(r).SaveIndexPlan(&plan); err != nil {
+		if errors.Is(err, engine.ErrInvalidIndexPlan) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, &plan)
+}
+
+func (s *Server) handleResetIndexPlanImpl(w http.ResponseWriter, r *http.Request) {
+	eng := s.eng(r)
+	if err := eng.ResetIndexPlan(); err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	plan, err := eng.GetIndexPlan()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, plan)
+}
+
+func (s *Server) handleBuildIndexesImpl(w http.ResponseWriter, r *http.Request) {
+	eng := s.eng(r)
+	callback := func(status []target.IndexBuildStatus) {
+		if s.hub != nil {
+			s.hub.BroadcastIndexProgress(status)
+		}
+		if s.metrics != nil {
+			s.metrics.observeIndexBuilds(projectLabel(eng), status)
+		}
+	}
+
+	if err := eng.BuildIndexes(r.Context(), callback); err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusAccepted, AsyncAcceptedResponse{
+		Status:  "accepted",
+		Message: "Index build started",
+	})
+}
+
+func (s *Server) handleIndexStatusImpl(w http.ResponseWriter, r *http.Request) {
+	result, err := s.eng(r).IndexBuildStatus()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, result)
+}
+
+func (s *Server) handlePauseIndexBuildsImpl(w http.ResponseWriter, r *http.Request) {
+	s.setIndexBuildsPaused(w, r, true)
+}
+
+func (s *Server) handleResumeIndexBuildsImpl(w http.ResponseWriter, r *http.Request) {
+	s.setIndexBuildsPaused(w, r, false)
+}
+
+// setIndexBuildsPaused pauses or resumes the running index builds and
+// responds with their status.
+func (s *Server) setIndexBuildsPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	eng := s.eng(r)
+	toggle := eng.ResumeIndexBuilds
+	if paused {
+		toggle = eng.PauseIndexBuilds
+	}
+	err := toggle()
+	if errors.Is(err, engine.ErrNoIndexBuilds) {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.handleIndexStatusImpl(w, r)
+}
+
+func (s *Server) handleGetViewsImpl(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, s.eng(r).ViewsStatus())
+}
+
+func (s *Server) handleBuildViewsImpl(w http.ResponseWriter, r *http.Request) {
+	if err := s.eng(r).BuildViews(r.Context(), nil); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusAccepted, AsyncAcceptedResponse{
+		Status:  "accepted",
+		Message: "Materialized view build started",
+	})
+}
+
+func (s *Server) handleGetCanaryImpl(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, s.eng(r).CanaryStatus())
+}
+
+func (s *Server) handleRunCanaryImpl(w http.ResponseWriter, r *http.Request) {
+	if err := s.eng(r).RunCanaryQueries(r.Context(), nil); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusAccepted, AsyncAcceptedResponse{
+		Status:  "accepted",
+		Message: "Canary queries started",
+	})
+}
 
 func (s *Server) handleReadinessImpl(w http.ResponseWriter, r *http.Request) {
-	rpt, err := s.engine.CheckReadiness(r.Context())
+	rpt, err := s.eng(r).CheckReadiness(r.Context())
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
@@ -500,6 +1517,142 @@ func (s *Server) handleReadinessImpl(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, rpt)
 }
 
+// handleGetDoctorImpl responds with the results of the last doctor run.
+func (s *Server) handleGetDoctorImpl(w http.ResponseWriter, r *http.Request) {
+	result, err := s.eng(r).LastDoctorReport()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if result == nil {
+		errorResponse(w, http.StatusNotFound, "no environment checks yet; run the doctor first")
+		return
+	}
+	jsonResponse(w, http.StatusOK, result)
+}
+
+// handleRunDoctorImpl checks the environment and responds with the results.
+// The body is optional; without one the CDC and EMR network checks run only
+// as the project's state implies.
+func (s *Server) handleRunDoctorImpl(w http.ResponseWriter, r *http.Request) {
+	var opts engine.DoctorOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	result, err := s.eng(r).Doctor(r.Context(), opts)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, result)
+}
+
+// handleReloadConfigImpl reloads the config file for every project. A file
+// that does not load leaves the running config in place.
+func (s *Server) handleReloadConfigImpl(w http.ResponseWriter, r *http.Request) {
+	res, err := s.ReloadConfig()
+	if errors.Is(err, engine.ErrNoConfigFile) {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, res)
+}
+
+// handleGetLogLevelsImpl reports the log levels. They belong to the process,
+// not a project.
+func (s *Server) handleGetLogLevelsImpl(w http.ResponseWriter, r *http.Request) {
+	levels, err := s.engine.LogLevels()
+	if err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, levels)
+}
+
+// handleSetLogLevelImpl changes the default log level, or one component's
+// when the request names it, until the config is reloaded.
+func (s *Server) handleSetLogLevelImpl(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Component string `json:"component"`
+		Level     string `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	levels, err := s.engine.SetComponentLevel(req.Component, req.Level)
+	if errors.Is(err, engine.ErrFixedLogLevels) {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, levels)
+}
+
+func (s *Server) handleGetHooksImpl(w http.ResponseWriter, r *http.Request) {
+	hs, err := s.eng(r).Hooks()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, hs)
+}
+
+func (s *Server) handlePrepareCDCImpl(w http.ResponseWriter, r *http.Request) {
+	pos, err := s.eng(r).PrepareCDC(r.Context())
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "prepared", "position": pos})
+}
+
+func (s *Server) handleStartCDCImpl(w http.ResponseWriter, r *http.Request) {
+	callback := func(status cdc.Status) {
+		if s.hub != nil {
+			s.hub.BroadcastCDCLag(status)
+		}
+	}
+
+	if err := s.eng(r).StartCDC(r.Context(), callback); err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusAccepted, AsyncAcceptedResponse{
+		Status:  "accepted",
+		Message: "CDC started",
+	})
+}
+
+func (s *Server) handleStopCDCImpl(w http.ResponseWriter, r *http.Request) {
+	if err := s.eng(r).StopCDC(); err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "stopped"})
+}
+
+func (s *Server) handleCDCStatusImpl(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, s.eng(r).CDCStatus())
+}
+
+func (s *Server) handleTeardownCDCImpl(w http.ResponseWriter, r *http.Request) {
+	if err := s.eng(r).TeardownCDC(r.Context()); err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "torn_down"})
+}
+
 func (s *Server) handleGetMappingPreviewImpl(w http.ResponseWriter, r *http.Request) {
 	// Accept optional ?roots=table1,table2 to specify root collections
 	var roots []string
@@ -511,7 +1664,7 @@ func (s *Server) handleGetMappingPreviewImpl(w http.ResponseWriter, r *http.Requ
 			}
 		}
 	}
-	m, err := s.engine.PreviewMapping(roots...)
+	m, err := s.eng(r).PreviewMapping(roots...)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
@@ -519,11 +1672,88 @@ func (s *Server) handleGetMappingPreviewImpl(w http.ResponseWriter, r *http.Requ
 	jsonResponse(w, http.StatusOK, m)
 }
 
+// handleGetDocumentPreviewImpl builds a few documents of the collection
+// query parameter from source rows; limit sets how many.
+func (s *Server) handleGetDocumentPreviewImpl(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	collection := q.Get("collection")
+	if collection == "" {
+		errorResponse(w, http.StatusBadRequest, "collection is required")
+		return
+	}
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errorResponse(w, http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		limit = n
+	}
+	if s.eng(r).GetMapping() == nil {
+		errorResponse(w, http.StatusNotFound, "no mapping defined")
+		return
+	}
+	preview, err := s.eng(r).DocumentPreview(r.Context(), collection, limit)
+	if err != nil {
+		if errors.Is(err, engine.ErrInvalidMapping) {
+			errorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, preview)
+}
+
 func (s *Server) handleGetSizeEstimateImpl(w http.ResponseWriter, r *http.Request) {
-	estimates, err := s.engine.MappingSizeEstimate()
+	estimates, err := s.eng(r).MappingSizeEstimate()
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	jsonResponse(w, http.StatusOK, estimates)
 }
+
+func (s *Server) handleGetEmbedDistributionImpl(w http.ResponseWriter, r *http.Request) {
+	dists, err := s.eng(r).EmbedDistribution(r.Context(), r.URL.Query().Get("collection"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, dists)
+}
+
+func (s *Server) handleGetFieldGroupsImpl(w http.ResponseWriter, r *http.Request) {
+	suggestions, err := s.eng(r).FieldGroupSuggestions()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if suggestions == nil {
+		suggestions = []mapping.FieldGroupSuggestion{}
+	}
+	jsonResponse(w, http.StatusOK, suggestions)
+}
+
+func (s *Server) handlePreviewFilterImpl(w http.ResponseWriter, r *http.Request) {
+	var req FilterPreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Table == "" {
+		errorResponse(w, http.StatusBadRequest, "table is required")
+		return
+	}
+	preview, err := s.eng(r).PreviewFilter(r.Context(), req.Table, req.Filter)
+	if err != nil {
+		if errors.Is(err, engine.ErrInvalidMapping) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, preview)
+}
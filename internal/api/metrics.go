@@ -0,0 +1,194 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/metrics"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// serverMetrics holds what GET /metrics reports: the progress of the last
+// migration and index build started through the server in each project,
+// validation check results, and API request latencies.
+type serverMetrics struct {
+	registry   *metrics.Registry
+	validation *metrics.Counter
+	requests   *metrics.Histogram
+
+	mu         sync.Mutex
+	migrations map[string]migrationMetrics          // by project
+	indexes    map[string][]target.IndexBuildStatus // by project
+}
+
+// migrationMetrics is a copy of a migration's progress, taken when the
+// executor reports it.
+type migrationMetrics struct {
+	percent     float64
+	collections []collectionMetrics
+	started     map[string]time.Time // when each collection was first seen running
+}
+
+type collectionMetrics struct {
+	name        string
+	docsWritten int64
+	docsTotal   int64
+	bytes       int64
+	rate        float64 // bytes written per second since the collection started
+}
+
+func newServerMetrics() *serverMetrics {
+	m := &serverMetrics{
+		registry:   metrics.NewRegistry(),
+		migrations: make(map[string]migrationMetrics),
+		indexes:    make(map[string][]target.IndexBuildStatus),
+	}
+	m.registry.GaugeFunc("reloquent_migration_progress_percent",
+		"Overall progress of the last migration, in percent.",
+		m.migrationProgress, "project")
+	m.registry.GaugeFunc("reloquent_migration_docs_written",
+		"Documents written to a collection by the last migration.",
+		m.collectionSamples(func(c collectionMetrics) float64 { return float64(c.docsWritten) }),
+		"project", "collection")
+	m.registry.GaugeFunc("reloquent_migration_docs_total",
+		"Rows the last migration expects to write to a collection.",
+		m.collectionSamples(func(c collectionMetrics) float64 { return float64(c.docsTotal) }),
+		"project", "collection")
+	m.registry.GaugeFunc("reloquent_migration_bytes_written",
+		"BSON bytes written to a collection by the last migration, when the mover measures them.",
+		m.collectionSamples(func(c collectionMetrics) float64 { return float64(c.bytes) }),
+		"project", "collection")
+	m.registry.GaugeFunc("reloquent_migration_bytes_per_second",
+		"BSON bytes written to a collection per second since it started.",
+		m.collectionSamples(func(c collectionMetrics) float64 { return c.rate }),
+		"project", "collection")
+	m.validation = m.registry.Counter("reloquent_validation_checks_total",
+		"Validation checks run, by check type and result.",
+		"project", "check", "result")
+	m.registry.GaugeFunc("reloquent_index_build_progress_percent",
+		"Progress of each index of the last index build, in percent.",
+		m.indexProgress, "project", "collection", "index", "shard")
+	m.requests = m.registry.Histogram("reloquent_api_request_duration_seconds",
+		"Latency of API requests, by method and route.",
+		metrics.DefaultBuckets, "method", "route")
+	return m
+}
+
+// projectLabel names the engine's project, or is empty for the default
+// state.
+func projectLabel(eng *engine.Engine) string {
+	if p := eng.Project(); p != nil {
+		return p.Name
+	}
+	return ""
+}
+
+// observeMigration records the progress a migration reported.
+func (m *serverMetrics) observeMigration(project string, status *migration.Status) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prev := m.migrations[project]
+	mm := migrationMetrics{percent: status.Overall.PercentComplete, started: make(map[string]time.Time)}
+	for _, cs := range status.Collections {
+		c := collectionMetrics{name: cs.Name, docsWritten: cs.DocsWritten, docsTotal: cs.DocsTotal, bytes: cs.BytesWritten}
+		start, ok := prev.started[cs.Name]
+		if !ok && cs.State == "running" {
+			start, ok = now, true
+		}
+		if ok {
+			mm.started[cs.Name] = start
+		}
+		elapsed := cs.Elapsed
+		if elapsed == 0 && ok {
+			elapsed = now.Sub(start)
+		}
+		if elapsed > 0 {
+			c.rate = float64(cs.BytesWritten) / elapsed.Seconds()
+		}
+		mm.collections = append(mm.collections, c)
+	}
+	m.migrations[project] = mm
+}
+
+// observeValidationCheck counts a validation check result.
+func (m *serverMetrics) observeValidationCheck(project, checkType string, passed bool) {
+	result := "fail"
+	if passed {
+		result = "pass"
+	}
+	m.validation.Inc(project, checkType, result)
+}
+
+// observeIndexBuilds records the progress an index build reported.
+func (m *serverMetrics) observeIndexBuilds(project string, statuses []target.IndexBuildStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.indexes[project] = append([]target.IndexBuildStatus(nil), statuses...)
+}
+
+func (m *serverMetrics) migrationProgress() []metrics.Sample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var samples []metrics.Sample
+	for project, mm := range m.migrations {
+		samples = append(samples, metrics.Sample{Labels: []string{project}, Value: mm.percent})
+	}
+	return samples
+}
+
+func (m *serverMetrics) collectionSamples(value func(collectionMetrics) float64) func() []metrics.Sample {
+	return func() []metrics.Sample {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		var samples []metrics.Sample
+		for project, mm := range m.migrations {
+			for _, c := range mm.collections {
+				samples = append(samples, metrics.Sample{Labels: []string{project, c.name}, Value: value(c)})
+			}
+		}
+		return samples
+	}
+}
+
+func (m *serverMetrics) indexProgress() []metrics.Sample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var samples []metrics.Sample
+	for project, statuses := range m.indexes {
+		for _, st := range statuses {
+			progress := st.Progress
+			if st.Phase == "complete" {
+				progress = 100
+			}
+			samples = append(samples, metrics.Sample{Labels: []string{project, st.Collection, st.IndexName, st.Shard}, Value: progress})
+		}
+	}
+	return samples
+}
+
+// middleware times API requests, labelled with the route pattern they
+// matched rather than their path so IDs in paths do not multiply series.
+// It must wrap the mux directly, which sets the pattern on the request.
+func (m *serverMetrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		route := r.Pattern
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = path
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+		m.requests.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
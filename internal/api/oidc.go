@@ -0,0 +1,276 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/config"
+)
+
+// oidcProvider signs users in with the OpenID Connect authorization code
+// flow and verifies the RS256 ID tokens the provider issues.
+type oidcProvider struct {
+	cfg           config.OIDCConfig
+	authEndpoint  string
+	tokenEndpoint string
+	jwksURI       string
+	client        *http.Client
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PublicKey // by key ID
+}
+
+// idClaims are the ID token claims reloquent checks.
+type idClaims struct {
+	Issuer   string   `json:"iss"`
+	Audience audience `json:"aud"`
+	Expiry   int64    `json:"exp"`
+	Nonce    string   `json:"nonce"`
+	Email    string   `json:"email"`
+}
+
+// audience accepts the aud claim as a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// discoverOIDC reads the provider's discovery document.
+func discoverOIDC(ctx context.Context, cfg config.OIDCConfig) (*oidcProvider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("OIDC requires issuer, client_id and redirect_url")
+	}
+	p := &oidcProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		keys:   make(map[string]*rsa.PublicKey),
+	}
+
+	var doc struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+	wellKnown := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &doc); err != nil {
+		return nil, fmt.Errorf("OIDC discovery: %w", err)
+	}
+	if doc.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("OIDC discovery: issuer %q does not match configured %q", doc.Issuer, cfg.Issuer)
+	}
+	p.authEndpoint = doc.AuthorizationEndpoint
+	p.tokenEndpoint = doc.TokenEndpoint
+	p.jwksURI = doc.JWKSURI
+	return p, nil
+}
+
+// authCodeURL is where the browser is sent to sign in.
+func (p *oidcProvider) authCodeURL(state string) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.cfg.ClientID},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"scope":         {"openid email"},
+		"state":         {state},
+		"nonce":         {state},
+	}
+	sep := "?"
+	if strings.Contains(p.authEndpoint, "?") {
+		sep = "&"
+	}
+	return p.authEndpoint + sep + q.Encode()
+}
+
+// exchange trades an authorization code for the raw ID token.
+func (p *oidcProvider) exchange(ctx context.Context, code string) (string, error) {
+	if code == "" {
+		return "", fmt.Errorf("missing authorization code")
+	}
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {p.cfg.RedirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("exchanging authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("exchanging authorization code: %s", resp.Status)
+	}
+	var tok struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("decoding token response: %w", err)
+	}
+	if tok.IDToken == "" {
+		return "", fmt.Errorf("token response has no id_token")
+	}
+	return tok.IDToken, nil
+}
+
+// verify checks an ID token's signature, issuer, audience and expiry. When
+// nonce is non-empty the token must carry it.
+func (p *oidcProvider) verify(ctx context.Context, raw, nonce string) (*idClaims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed ID token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("decoding ID token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported ID token algorithm %q", header.Alg)
+	}
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decoding ID token signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, fmt.Errorf("invalid ID token signature")
+	}
+
+	var claims idClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("decoding ID token claims: %w", err)
+	}
+	switch {
+	case claims.Issuer != p.cfg.Issuer:
+		return nil, fmt.Errorf("ID token issued by %q", claims.Issuer)
+	case !slices.Contains(claims.Audience, p.cfg.ClientID):
+		return nil, fmt.Errorf("ID token not issued for this client")
+	case time.Now().Unix() >= claims.Expiry:
+		return nil, fmt.Errorf("ID token expired")
+	case nonce != "" && claims.Nonce != nonce:
+		return nil, fmt.Errorf("ID token nonce mismatch")
+	}
+	return &claims, nil
+}
+
+// allowed applies the AllowedEmails list.
+func (p *oidcProvider) allowed(c *idClaims) bool {
+	if len(p.cfg.AllowedEmails) == 0 {
+		return true
+	}
+	email := strings.ToLower(c.Email)
+	if email == "" {
+		return false
+	}
+	for _, a := range p.cfg.AllowedEmails {
+		a = strings.ToLower(a)
+		if email == a || (strings.HasPrefix(a, "@") && strings.HasSuffix(email, a)) {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the signing key with the given ID, refetching the key set
+// once when it is unknown so provider key rotation is picked up.
+func (p *oidcProvider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	k, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return k, nil
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching OIDC signing keys: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown ID token signing key %q", kid)
+}
+
+func (p *oidcProvider) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
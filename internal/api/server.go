@@ -7,8 +7,12 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 
+	"github.com/reloquent/reloquent/internal/config"
 	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/logging"
+	"github.com/reloquent/reloquent/internal/state"
 	"github.com/reloquent/reloquent/internal/ws"
 )
 
@@ -21,6 +25,12 @@ type Server struct {
 	server  *http.Server
 	staticFS fs.FS
 	devMode  bool
+	auth     *Authenticator
+	metrics  *serverMetrics // nil when GET /metrics is disabled
+	noMetrics bool
+
+	mu       sync.Mutex
+	projects map[string]*engine.Engine // engines for other projects, by name
 }
 
 // Option configures the API server.
@@ -47,6 +57,22 @@ func WithHub(hub *ws.Hub) Option {
 	}
 }
 
+// WithAuth requires requests to pass the authenticator. Without it the
+// server accepts every request.
+func WithAuth(a *Authenticator) Option {
+	return func(s *Server) {
+		s.auth = a
+	}
+}
+
+// WithMetrics turns GET /metrics, the Prometheus endpoint, on or off. It
+// is on by default.
+func WithMetrics(enabled bool) Option {
+	return func(s *Server) {
+		s.noMetrics = !enabled
+	}
+}
+
 // New creates a new API server.
 func New(eng *engine.Engine, logger *slog.Logger, port int, opts ...Option) *Server {
 	s := &Server{
@@ -57,72 +83,179 @@ func New(eng *engine.Engine, logger *slog.Logger, port int, opts ...Option) *Ser
 	for _, opt := range opts {
 		opt(s)
 	}
+	if !s.noMetrics {
+		s.metrics = newServerMetrics()
+	}
 	return s
 }
 
 // Start starts the HTTP server.
 func (s *Server) Start() error {
-	mux := http.NewServeMux()
-	s.registerRoutes(mux)
-
-	var handler http.Handler = mux
-	if s.devMode {
-		handler = s.corsMiddleware(mux)
-	}
-
 	s.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", s.port),
-		Handler: handler,
+		Handler: s.handler(),
 	}
 
-	s.logger.Info("starting web UI server", "port", s.port, "dev_mode", s.devMode)
+	authMode := AuthNone
+	if s.auth != nil {
+		authMode = s.auth.Mode()
+	}
+	logging.For(s.logger, logging.ComponentAPI).Info("starting web UI server", "port", s.port, "dev_mode", s.devMode, "auth", authMode)
 	return s.server.ListenAndServe()
 }
 
+// Handler returns the server's routes with its middleware, for serving
+// them from another listener.
+func (s *Server) Handler() http.Handler {
+	return s.handler()
+}
+
+// handler builds the routes wrapped in the auth and CORS middleware.
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	s.registerRoutes(mux)
+
+	var routes http.Handler = mux
+	if s.metrics != nil {
+		mux.Handle("GET /metrics", s.metrics.registry.Handler())
+		routes = s.metrics.middleware(mux)
+	}
+	handler := s.projectMiddleware(routes)
+	if s.auth != nil {
+		s.auth.registerRoutes(mux)
+		handler = s.auth.middleware(handler)
+	}
+	if s.devMode {
+		handler = s.corsMiddleware(handler)
+	}
+	return requestLogger(logging.For(s.logger, logging.ComponentAPI), handler)
+}
+
 // Shutdown gracefully stops the server.
 func (s *Server) Shutdown(ctx context.Context) error {
+	var err error
 	if s.server != nil {
-		return s.server.Shutdown(ctx)
+		err = s.server.Shutdown(ctx)
 	}
-	return nil
+	// Close the connections the engines keep open between requests
+	s.engine.CloseConnections()
+	s.mu.Lock()
+	for _, eng := range s.projects {
+		eng.CloseConnections()
+	}
+	s.mu.Unlock()
+	return err
 }
 
 func (s *Server) registerRoutes(mux *http.ServeMux) {
 	// API routes
 	mux.HandleFunc("GET /api/health", s.handleHealth)
+	mux.HandleFunc("GET /api/projects", s.handleListProjects)
+	mux.HandleFunc("POST /api/projects", s.handleCreateProject)
 	mux.HandleFunc("GET /api/state", s.handleGetState)
+	mux.HandleFunc("GET /api/dashboard", s.handleGetDashboard)
 	mux.HandleFunc("PUT /api/state/step", s.handleSetStep)
+	mux.HandleFunc("POST /api/config/reload", s.handleReloadConfig)
+	mux.HandleFunc("GET /api/logging/levels", s.handleGetLogLevels)
+	mux.HandleFunc("GET /api/audit", s.handleAuditTrail)
+	mux.HandleFunc("PUT /api/logging/levels", s.handleSetLogLevel)
 	mux.HandleFunc("GET /api/source/config", s.handleGetSourceConfig)
 	mux.HandleFunc("POST /api/source/test-connection", s.handleTestSourceConnection)
 	mux.HandleFunc("POST /api/source/discover", s.handleDiscover)
 	mux.HandleFunc("GET /api/source/schema", s.handleGetSchema)
+	mux.HandleFunc("GET /api/source/schema/diff", s.handleGetSchemaDiff)
+	mux.HandleFunc("GET /api/source/impact", s.handleGetSourceImpact)
+	mux.HandleFunc("GET /api/source/role-script", s.handleGetSourceRoleScript)
+	mux.HandleFunc("GET /api/schema/snapshots", s.handleListSchemaSnapshots)
+	mux.HandleFunc("GET /api/schema/snapshots/diff", s.handleDiffSchemaSnapshots)
+	mux.HandleFunc("POST /api/schema/snapshots/{id}/pin", s.handlePinSchemaSnapshot)
+	mux.HandleFunc("POST /api/schema/unpin", s.handleUnpinSchemaSnapshot)
+	mux.HandleFunc("DELETE /api/schema/snapshots/{id}", s.handleDeleteSchemaSnapshot)
+	mux.HandleFunc("POST /api/schema/snapshots/{id}/restore", s.handleRestoreSchemaSnapshot)
 	mux.HandleFunc("GET /api/target/config", s.handleGetTargetConfig)
+	mux.HandleFunc("PUT /api/target/config", s.handleSaveTargetConfig)
 	mux.HandleFunc("POST /api/target/test-connection", s.handleTestTargetConnection)
 	mux.HandleFunc("POST /api/target/detect-topology", s.handleDetectTopology)
 	mux.HandleFunc("GET /api/tables", s.handleGetTables)
 	mux.HandleFunc("POST /api/tables/select", s.handleSelectTables)
 	mux.HandleFunc("GET /api/mapping", s.handleGetMapping)
 	mux.HandleFunc("POST /api/mapping", s.handleSaveMapping)
+	mux.HandleFunc("GET /api/mapping/lint", s.handleGetMappingLint)
 	mux.HandleFunc("GET /api/mapping/preview", s.handleGetMappingPreview)
+	mux.HandleFunc("GET /api/mapping/document-preview", s.handleGetDocumentPreview)
 	mux.HandleFunc("GET /api/mapping/size-estimate", s.handleGetSizeEstimate)
+	mux.HandleFunc("GET /api/mapping/embed-distribution", s.handleGetEmbedDistribution)
+	mux.HandleFunc("GET /api/mapping/field-groups", s.handleGetFieldGroups)
+	mux.HandleFunc("POST /api/mapping/filter-preview", s.handlePreviewFilter)
 	mux.HandleFunc("GET /api/typemap", s.handleGetTypeMap)
 	mux.HandleFunc("POST /api/typemap", s.handleSaveTypeMap)
+	mux.HandleFunc("GET /api/typemap/lobs", s.handleGetLOBs)
+	mux.HandleFunc("POST /api/typemap/lobs", s.handleSaveLOBs)
+	mux.HandleFunc("GET /api/typemap/timestamps", s.handleGetTimestamps)
+	mux.HandleFunc("POST /api/typemap/timestamps", s.handleSaveTimestamps)
+	mux.HandleFunc("GET /api/typemap/decimals", s.handleGetDecimals)
+	mux.HandleFunc("GET /api/typemap/booleans", s.handleGetBooleans)
+	mux.HandleFunc("POST /api/typemap/booleans", s.handleSaveBooleans)
+	mux.HandleFunc("GET /api/masking", s.handleGetMasks)
+	mux.HandleFunc("POST /api/masking", s.handleSaveMasks)
+	mux.HandleFunc("GET /api/collation", s.handleGetCollation)
+	mux.HandleFunc("POST /api/collation/joins", s.handleSaveJoinNormalizations)
+	mux.HandleFunc("GET /api/unique-constraints", s.handleGetUniqueConstraints)
+	mux.HandleFunc("POST /api/unique-constraints/apply", s.handleApplyUniqueConversions)
 	mux.HandleFunc("GET /api/sizing", s.handleGetSizing)
 	mux.HandleFunc("POST /api/sizing/benchmark", s.handleRunBenchmark)
+	mux.HandleFunc("POST /api/sizing/benchmark/write", s.handleRunWriteBenchmark)
+	mux.HandleFunc("GET /api/sizing/shard-advisor", s.handleGetShardAdvisor)
+	mux.HandleFunc("GET /api/plan", s.handleGetPlan)
+	mux.HandleFunc("GET /api/dictionary", s.handleGetDictionary)
+	mux.HandleFunc("GET /api/templates", s.handleExportTemplate)
+	mux.HandleFunc("POST /api/templates/check", s.handleCheckTemplate)
+	mux.HandleFunc("POST /api/templates/apply", s.handleApplyTemplate)
+	mux.HandleFunc("GET /api/cutover", s.handleGetCutover)
+	mux.HandleFunc("GET /api/cutover/fallback", s.handleGetFallback)
 	mux.HandleFunc("POST /api/aws/configure", s.handleConfigureAWS)
 	mux.HandleFunc("GET /api/aws/validate", s.handleValidateAWS)
 	mux.HandleFunc("POST /api/premigration/prepare", s.handlePreMigrationPrepare)
 	mux.HandleFunc("GET /api/premigration/status", s.handlePreMigrationStatus)
+	mux.HandleFunc("GET /api/premigration/validators", s.handleGetValidators)
+	mux.HandleFunc("PUT /api/premigration/validators", s.handleSetValidationLevel)
 	mux.HandleFunc("POST /api/migration/start", s.handleStartMigration)
 	mux.HandleFunc("GET /api/migration/status", s.handleMigrationStatus)
 	mux.HandleFunc("POST /api/migration/retry", s.handleRetryMigration)
 	mux.HandleFunc("POST /api/migration/abort", s.handleAbortMigration)
+	mux.HandleFunc("POST /api/migration/pause", s.handlePauseMigration)
+	mux.HandleFunc("POST /api/migration/resume", s.handleResumeMigration)
+	mux.HandleFunc("GET /api/runs/{id}/throughput", s.handleRunThroughput)
 	mux.HandleFunc("POST /api/validation/run", s.handleRunValidation)
 	mux.HandleFunc("GET /api/validation/results", s.handleValidationResults)
+	mux.HandleFunc("GET /api/validation/failures", s.handleValidationFailures)
+	mux.HandleFunc("POST /api/validation/remediations", s.handleRemediate)
+	mux.HandleFunc("GET /api/retention", s.handleGetRetention)
+	mux.HandleFunc("GET /api/retention/histogram", s.handleGetRetentionHistogram)
+	mux.HandleFunc("PUT /api/retention/policy", s.handleSetRetentionPolicy)
+	mux.HandleFunc("GET /api/id-generation", s.handleGetIDGeneration)
+	mux.HandleFunc("PUT /api/id-generation", s.handleSetIDGeneration)
+	mux.HandleFunc("POST /api/id-generation/seed", s.handleSeedCounters)
 	mux.HandleFunc("GET /api/indexes/plan", s.handleGetIndexPlan)
+	mux.HandleFunc("PUT /api/indexes/plan", s.handleSaveIndexPlan)
+	mux.HandleFunc("DELETE /api/indexes/plan", s.handleResetIndexPlan)
 	mux.HandleFunc("POST /api/indexes/build", s.handleBuildIndexes)
 	mux.HandleFunc("GET /api/indexes/status", s.handleIndexStatus)
+	mux.HandleFunc("POST /api/indexes/pause", s.handlePauseIndexBuilds)
+	mux.HandleFunc("POST /api/indexes/resume", s.handleResumeIndexBuilds)
+	mux.HandleFunc("GET /api/views", s.handleGetViews)
+	mux.HandleFunc("POST /api/views/build", s.handleBuildViews)
+	mux.HandleFunc("GET /api/canary", s.handleGetCanary)
+	mux.HandleFunc("POST /api/canary/run", s.handleRunCanary)
 	mux.HandleFunc("GET /api/readiness", s.handleReadiness)
+	mux.HandleFunc("GET /api/doctor", s.handleGetDoctor)
+	mux.HandleFunc("POST /api/doctor", s.handleRunDoctor)
+	mux.HandleFunc("GET /api/hooks", s.handleGetHooks)
+	mux.HandleFunc("POST /api/cdc/prepare", s.handlePrepareCDC)
+	mux.HandleFunc("POST /api/cdc/start", s.handleStartCDC)
+	mux.HandleFunc("POST /api/cdc/stop", s.handleStopCDC)
+	mux.HandleFunc("GET /api/cdc/status", s.handleCDCStatus)
+	mux.HandleFunc("POST /api/cdc/teardown", s.handleTeardownCDC)
 
 	// WebSocket
 	if s.hub != nil {
@@ -165,7 +298,7 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+ProjectHeader)
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -179,10 +312,97 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"status":"ok"}`))
 }
 
+// ProjectHeader names the project a request works in. Requests without it
+// use the project the server was started in.
+const ProjectHeader = "X-Reloquent-Project"
+
+type engineKey struct{}
+
+// projectMiddleware resolves the request's project to its engine.
+func (s *Server) projectMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get(ProjectHeader)
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		eng, err := s.projectEngine(name)
+		if err != nil {
+			errorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), engineKey{}, eng)))
+	})
+}
+
+// projectEngine returns the engine for a project, creating it on first use
+// with a copy of the server engine's config.
+func (s *Server) projectEngine(name string) (*engine.Engine, error) {
+	if p := s.engine.Project(); p != nil && p.Name == name {
+		return s.engine, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if eng, ok := s.projects[name]; ok {
+		return eng, nil
+	}
+	p, err := state.OpenProject(name)
+	if err != nil {
+		return nil, err
+	}
+	var cfg *config.Config
+	if s.engine.Config != nil {
+		c := *s.engine.Config
+		cfg = &c
+	}
+	eng := engine.New(cfg, s.logger)
+	eng.SetProject(p)
+	if s.projects == nil {
+		s.projects = make(map[string]*engine.Engine)
+	}
+	s.projects[name] = eng
+	return eng, nil
+}
+
+// ReloadConfig re-reads the config file and applies it to the engine of
+// every open project. serve calls it on SIGHUP.
+func (s *Server) ReloadConfig() (*engine.ConfigReload, error) {
+	r, err := s.engine.ReloadConfig()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, eng := range s.projects {
+		eng.ApplyConfig(r.Config)
+	}
+	return r, nil
+}
+
+// eng returns the engine for the request's project.
+func (s *Server) eng(r *http.Request) *engine.Engine {
+	if eng, ok := r.Context().Value(engineKey{}).(*engine.Engine); ok {
+		return eng
+	}
+	return s.engine
+}
+
 // Handlers delegate to implementations in handlers.go
+func (s *Server) handleListProjects(w http.ResponseWriter, r *http.Request) {
+	s.handleListProjectsImpl(w, r)
+}
+func (s *Server) handleAuditTrail(w http.ResponseWriter, r *http.Request) {
+	s.handleAuditTrailImpl(w, r)
+}
+func (s *Server) handleCreateProject(w http.ResponseWriter, r *http.Request) {
+	s.handleCreateProjectImpl(w, r)
+}
 func (s *Server) handleGetState(w http.ResponseWriter, r *http.Request) {
 	s.handleGetStateImpl(w, r)
 }
+func (s *Server) handleGetDashboard(w http.ResponseWriter, r *http.Request) {
+	s.handleGetDashboardImpl(w, r)
+}
 func (s *Server) handleSetStep(w http.ResponseWriter, r *http.Request) {
 	s.handleSetStepImpl(w, r)
 }
@@ -198,9 +418,39 @@ func (s *Server) handleDiscover(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleGetSchema(w http.ResponseWriter, r *http.Request) {
 	s.handleGetSchemaImpl(w, r)
 }
+func (s *Server) handleGetSchemaDiff(w http.ResponseWriter, r *http.Request) {
+	s.handleGetSchemaDiffImpl(w, r)
+}
+func (s *Server) handleGetSourceImpact(w http.ResponseWriter, r *http.Request) {
+	s.handleGetSourceImpactImpl(w, r)
+}
+func (s *Server) handleGetSourceRoleScript(w http.ResponseWriter, r *http.Request) {
+	s.handleGetSourceRoleScriptImpl(w, r)
+}
+func (s *Server) handleListSchemaSnapshots(w http.ResponseWriter, r *http.Request) {
+	s.handleListSchemaSnapshotsImpl(w, r)
+}
+func (s *Server) handleDiffSchemaSnapshots(w http.ResponseWriter, r *http.Request) {
+	s.handleDiffSchemaSnapshotsImpl(w, r)
+}
+func (s *Server) handlePinSchemaSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.handlePinSchemaSnapshotImpl(w, r)
+}
+func (s *Server) handleUnpinSchemaSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.handleUnpinSchemaSnapshotImpl(w, r)
+}
+func (s *Server) handleDeleteSchemaSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.handleDeleteSchemaSnapshotImpl(w, r)
+}
+func (s *Server) handleRestoreSchemaSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.handleRestoreSchemaSnapshotImpl(w, r)
+}
 func (s *Server) handleGetTargetConfig(w http.ResponseWriter, r *http.Request) {
 	s.handleGetTargetConfigImpl(w, r)
 }
+func (s *Server) handleSaveTargetConfig(w http.ResponseWriter, r *http.Request) {
+	s.handleSaveTargetConfigImpl(w, r)
+}
 func (s *Server) handleTestTargetConnection(w http.ResponseWriter, r *http.Request) {
 	s.handleTestTargetConnectionImpl(w, r)
 }
@@ -219,24 +469,105 @@ func (s *Server) handleGetMapping(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleSaveMapping(w http.ResponseWriter, r *http.Request) {
 	s.handleSaveMappingImpl(w, r)
 }
+func (s *Server) handleGetMappingLint(w http.ResponseWriter, r *http.Request) {
+	s.handleGetMappingLintImpl(w, r)
+}
 func (s *Server) handleGetMappingPreview(w http.ResponseWriter, r *http.Request) {
 	s.handleGetMappingPreviewImpl(w, r)
 }
+func (s *Server) handleGetDocumentPreview(w http.ResponseWriter, r *http.Request) {
+	s.handleGetDocumentPreviewImpl(w, r)
+}
 func (s *Server) handleGetSizeEstimate(w http.ResponseWriter, r *http.Request) {
 	s.handleGetSizeEstimateImpl(w, r)
 }
+func (s *Server) handleGetEmbedDistribution(w http.ResponseWriter, r *http.Request) {
+	s.handleGetEmbedDistributionImpl(w, r)
+}
+func (s *Server) handleGetFieldGroups(w http.ResponseWriter, r *http.Request) {
+	s.handleGetFieldGroupsImpl(w, r)
+}
+func (s *Server) handlePreviewFilter(w http.ResponseWriter, r *http.Request) {
+	s.handlePreviewFilterImpl(w, r)
+}
 func (s *Server) handleGetTypeMap(w http.ResponseWriter, r *http.Request) {
 	s.handleGetTypeMapImpl(w, r)
 }
 func (s *Server) handleSaveTypeMap(w http.ResponseWriter, r *http.Request) {
 	s.handleSaveTypeMapImpl(w, r)
 }
+func (s *Server) handleGetLOBs(w http.ResponseWriter, r *http.Request) {
+	s.handleGetLOBsImpl(w, r)
+}
+func (s *Server) handleSaveLOBs(w http.ResponseWriter, r *http.Request) {
+	s.handleSaveLOBsImpl(w, r)
+}
+func (s *Server) handleGetTimestamps(w http.ResponseWriter, r *http.Request) {
+	s.handleGetTimestampsImpl(w, r)
+}
+func (s *Server) handleSaveTimestamps(w http.ResponseWriter, r *http.Request) {
+	s.handleSaveTimestampsImpl(w, r)
+}
+func (s *Server) handleGetDecimals(w http.ResponseWriter, r *http.Request) {
+	s.handleGetDecimalsImpl(w, r)
+}
+func (s *Server) handleGetBooleans(w http.ResponseWriter, r *http.Request) {
+	s.handleGetBooleansImpl(w, r)
+}
+func (s *Server) handleSaveBooleans(w http.ResponseWriter, r *http.Request) {
+	s.handleSaveBooleansImpl(w, r)
+}
+func (s *Server) handleGetMasks(w http.ResponseWriter, r *http.Request) {
+	s.handleGetMasksImpl(w, r)
+}
+func (s *Server) handleSaveMasks(w http.ResponseWriter, r *http.Request) {
+	s.handleSaveMasksImpl(w, r)
+}
+func (s *Server) handleGetCollation(w http.ResponseWriter, r *http.Request) {
+	s.handleGetCollationImpl(w, r)
+}
+func (s *Server) handleSaveJoinNormalizations(w http.ResponseWriter, r *http.Request) {
+	s.handleSaveJoinNormalizationsImpl(w, r)
+}
+func (s *Server) handleGetUniqueConstraints(w http.ResponseWriter, r *http.Request) {
+	s.handleGetUniqueConstraintsImpl(w, r)
+}
+func (s *Server) handleApplyUniqueConversions(w http.ResponseWriter, r *http.Request) {
+	s.handleApplyUniqueConversionsImpl(w, r)
+}
 func (s *Server) handleGetSizing(w http.ResponseWriter, r *http.Request) {
 	s.handleGetSizingImpl(w, r)
 }
 func (s *Server) handleRunBenchmark(w http.ResponseWriter, r *http.Request) {
 	s.handleRunBenchmarkImpl(w, r)
 }
+func (s *Server) handleRunWriteBenchmark(w http.ResponseWriter, r *http.Request) {
+	s.handleRunWriteBenchmarkImpl(w, r)
+}
+func (s *Server) handleGetShardAdvisor(w http.ResponseWriter, r *http.Request) {
+	s.handleGetShardAdvisorImpl(w, r)
+}
+func (s *Server) handleGetPlan(w http.ResponseWriter, r *http.Request) {
+	s.handleGetPlanImpl(w, r)
+}
+func (s *Server) handleGetDictionary(w http.ResponseWriter, r *http.Request) {
+	s.handleGetDictionaryImpl(w, r)
+}
+func (s *Server) handleExportTemplate(w http.ResponseWriter, r *http.Request) {
+	s.handleExportTemplateImpl(w, r)
+}
+func (s *Server) handleCheckTemplate(w http.ResponseWriter, r *http.Request) {
+	s.handleCheckTemplateImpl(w, r)
+}
+func (s *Server) handleApplyTemplate(w http.ResponseWriter, r *http.Request) {
+	s.handleApplyTemplateImpl(w, r)
+}
+func (s *Server) handleGetCutover(w http.ResponseWriter, r *http.Request) {
+	s.handleGetCutoverImpl(w, r)
+}
+func (s *Server) handleGetFallback(w http.ResponseWriter, r *http.Request) {
+	s.handleGetFallbackImpl(w, r)
+}
 func (s *Server) handleConfigureAWS(w http.ResponseWriter, r *http.Request) {
 	s.handleConfigureAWSImpl(w, r)
 }
@@ -249,33 +580,129 @@ func (s *Server) handlePreMigrationPrepare(w http.ResponseWriter, r *http.Reques
 func (s *Server) handlePreMigrationStatus(w http.ResponseWriter, r *http.Request) {
 	s.handlePreMigrationStatusImpl(w, r)
 }
+func (s *Server) handleGetValidators(w http.ResponseWriter, r *http.Request) {
+	s.handleGetValidatorsImpl(w, r)
+}
+func (s *Server) handleSetValidationLevel(w http.ResponseWriter, r *http.Request) {
+	s.handleSetValidationLevelImpl(w, r)
+}
 func (s *Server) handleStartMigration(w http.ResponseWriter, r *http.Request) {
 	s.handleStartMigrationImpl(w, r)
 }
 func (s *Server) handleMigrationStatus(w http.ResponseWriter, r *http.Request) {
 	s.handleMigrationStatusImpl(w, r)
 }
+func (s *Server) handleRunThroughput(w http.ResponseWriter, r *http.Request) {
+	s.handleRunThroughputImpl(w, r)
+}
 func (s *Server) handleRetryMigration(w http.ResponseWriter, r *http.Request) {
 	s.handleRetryMigrationImpl(w, r)
 }
 func (s *Server) handleAbortMigration(w http.ResponseWriter, r *http.Request) {
 	s.handleAbortMigrationImpl(w, r)
 }
+func (s *Server) handlePauseMigration(w http.ResponseWriter, r *http.Request) {
+	s.handlePauseMigrationImpl(w, r)
+}
+func (s *Server) handleResumeMigration(w http.ResponseWriter, r *http.Request) {
+	s.handleResumeMigrationImpl(w, r)
+}
 func (s *Server) handleRunValidation(w http.ResponseWriter, r *http.Request) {
 	s.handleRunValidationImpl(w, r)
 }
 func (s *Server) handleValidationResults(w http.ResponseWriter, r *http.Request) {
 	s.handleValidationResultsImpl(w, r)
 }
+func (s *Server) handleValidationFailures(w http.ResponseWriter, r *http.Request) {
+	s.handleValidationFailuresImpl(w, r)
+}
+func (s *Server) handleRemediate(w http.ResponseWriter, r *http.Request) {
+	s.handleRemediateImpl(w, r)
+}
+func (s *Server) handleGetRetention(w http.ResponseWriter, r *http.Request) {
+	s.handleGetRetentionImpl(w, r)
+}
+func (s *Server) handleGetRetentionHistogram(w http.ResponseWriter, r *http.Request) {
+	s.handleGetRetentionHistogramImpl(w, r)
+}
+func (s *Server) handleSetRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	s.handleSetRetentionPolicyImpl(w, r)
+}
+func (s *Server) handleGetIDGeneration(w http.ResponseWriter, r *http.Request) {
+	s.handleGetIDGenerationImpl(w, r)
+}
+func (s *Server) handleSetIDGeneration(w http.ResponseWriter, r *http.Request) {
+	s.handleSetIDGenerationImpl(w, r)
+}
+func (s *Server) handleSeedCounters(w http.ResponseWriter, r *http.Request) {
+	s.handleSeedCountersImpl(w, r)
+}
 func (s *Server) handleGetIndexPlan(w http.ResponseWriter, r *http.Request) {
 	s.handleGetIndexPlanImpl(w, r)
 }
+func (s *Server) handleSaveIndexPlan(w http.ResponseWriter, r *http.Request) {
+	s.handleSaveIndexPlanImpl(w, r)
+}
+func (s *Server) handleResetIndexPlan(w http.ResponseWriter, r *http.Request) {
+	s.handleResetIndexPlanImpl(w, r)
+}
 func (s *Server) handleBuildIndexes(w http.ResponseWriter, r *http.Request) {
 	s.handleBuildIndexesImpl(w, r)
 }
 func (s *Server) handleIndexStatus(w http.ResponseWriter, r *http.Request) {
 	s.handleIndexStatusImpl(w, r)
 }
+func (s *Server) handlePauseIndexBuilds(w http.ResponseWriter, r *http.Request) {
+	s.handlePauseIndexBuildsImpl(w, r)
+}
+func (s *Server) handleResumeIndexBuilds(w http.ResponseWriter, r *http.Request) {
+	s.handleResumeIndexBuildsImpl(w, r)
+}
+func (s *Server) handleGetViews(w http.ResponseWriter, r *http.Request) {
+	s.handleGetViewsImpl(w, r)
+}
+func (s *Server) handleBuildViews(w http.ResponseWriter, r *http.Request) {
+	s.handleBuildViewsImpl(w, r)
+}
+func (s *Server) handleGetCanary(w http.ResponseWriter, r *http.Request) {
+	s.handleGetCanaryImpl(w, r)
+}
+func (s *Server) handleRunCanary(w http.ResponseWriter, r *http.Request) {
+	s.handleRunCanaryImpl(w, r)
+}
 func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
 	s.handleReadinessImpl(w, r)
 }
+func (s *Server) handleGetDoctor(w http.ResponseWriter, r *http.Request) {
+	s.handleGetDoctorImpl(w, r)
+}
+func (s *Server) handleRunDoctor(w http.ResponseWriter, r *http.Request) {
+	s.handleRunDoctorImpl(w, r)
+}
+func (s *Server) handleReloadConfig(w http.ResponseWriter, r *http.Request) {
+	s.handleReloadConfigImpl(w, r)
+}
+func (s *Server) handleGetLogLevels(w http.ResponseWriter, r *http.Request) {
+	s.handleGetLogLevelsImpl(w, r)
+}
+func (s *Server) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	s.handleSetLogLevelImpl(w, r)
+}
+func (s *Server) handleGetHooks(w http.ResponseWriter, r *http.Request) {
+	s.handleGetHooksImpl(w, r)
+}
+func (s *Server) handlePrepareCDC(w http.ResponseWriter, r *http.Request) {
+	s.handlePrepareCDCImpl(w, r)
+}
+func (s *Server) handleStartCDC(w http.ResponseWriter, r *http.Request) {
+	s.handleStartCDCImpl(w, r)
+}
+func (s *Server) handleStopCDC(w http.ResponseWriter, r *http.Request) {
+	s.handleStopCDCImpl(w, r)
+}
+func (s *Server) handleCDCStatus(w http.ResponseWriter, r *http.Request) {
+	s.handleCDCStatusImpl(w, r)
+}
+func (s *Server) handleTeardownCDC(w http.ResponseWriter, r *http.Request) {
+	s.handleTeardownCDCImpl(w, r)
+}
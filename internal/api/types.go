@@ -2,7 +2,13 @@ package api
 
 import (
 	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/impact"
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/retention"
+	"github.com/reloquent/reloquent/internal/sizing"
 	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/validation"
 )
 
 // StateResponse is the API response for wizard state.
@@ -90,6 +96,7 @@ var AllSteps = []StepInfo{
 	{ID: string(state.StepMigration), Label: "Migration", Order: 10},
 	{ID: string(state.StepValidation), Label: "Validation", Order: 11},
 	{ID: string(state.StepIndexBuilds), Label: "Index Builds", Order: 12},
+	{ID: string(state.StepCDC), Label: "Change Data Capture", Order: 13},
 }
 
 // toSourceConfig converts an API request to internal config.
@@ -119,6 +126,19 @@ func (r *TargetConfigRequest) toTargetConfig() config.TargetConfig {
 type BenchmarkRequest struct {
 	Table        string `json:"table"`
 	PartitionCol string `json:"partition_col"`
+	DryRun       bool   `json:"dry_run"` // return the queries without running them
+}
+
+// WriteBenchmarkRequest is the optional request body for benchmarking
+// target writes.
+type WriteBenchmarkRequest struct {
+	DryRun bool `json:"dry_run"` // describe the run without writing
+}
+
+// StartMigrationRequest is the optional request body for starting a
+// migration. Delta upserts only the rows changed since the last run.
+type StartMigrationRequest struct {
+	Delta bool `json:"delta"`
 }
 
 // RetryMigrationRequest is the request body for retrying a migration.
@@ -131,3 +151,97 @@ type AsyncAcceptedResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
 }
+
+// RunValidationRequest is the optional request body for POST
+// /api/validation/run, e.g. {"mode": "checksum", "chunk_size": 50000}.
+type RunValidationRequest struct {
+	validation.Config
+}
+
+// RemediationRequest is the request body for POST
+// /api/validation/remediations.
+type RemediationRequest struct {
+	Collection    string `json:"collection"`
+	Action        string `json:"action"` // remigrate, adjust_mapping or accept
+	Justification string `json:"justification,omitempty"`
+}
+
+// ProjectsResponse is the API response for GET /api/projects.
+type ProjectsResponse struct {
+	Current  string          `json:"current"`
+	Projects []state.Project `json:"projects"`
+}
+
+// CreateProjectRequest is the request body for POST /api/projects.
+type CreateProjectRequest struct {
+	Name string `json:"name"`
+}
+
+// SourceImpactResponse is the API response for GET /api/source/impact: the
+// estimate for the current design, and the last full run's estimate with
+// its actuals.
+type SourceImpactResponse struct {
+	Estimate *impact.Report `json:"estimate"`
+	LastRun  *impact.Report `json:"last_run,omitempty"`
+}
+
+// MappingIssuesResponse is the API response for GET /api/mapping/lint and
+// POST /api/mapping: the problems the mapping lint found. A mapping with
+// errors is not saved, and POST answers it with 400 and Error set.
+type MappingIssuesResponse struct {
+	Status string         `json:"status,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	Issues mapping.Issues `json:"issues"`
+}
+
+// UniqueConstraintsResponse is the API response for GET
+// /api/unique-constraints: how each source unique constraint carries over,
+// and the convertible ones the index plan does not build yet.
+type UniqueConstraintsResponse struct {
+	*indexes.UniqueReport
+	Unenforced []indexes.UniqueConstraint `json:"unenforced"`
+}
+
+// ApplyUniqueResponse is the API response for POST
+// /api/unique-constraints/apply.
+type ApplyUniqueResponse struct {
+	Added int `json:"added"`
+}
+
+// ShardAdvisorResponse is the API response for GET /api/sizing/shard-advisor.
+type ShardAdvisorResponse struct {
+	Collections []sizing.ShardAdvice `json:"collections"`
+}
+
+// RetentionResponse is the API response for GET /api/retention.
+type RetentionResponse struct {
+	Candidates    []retention.Candidate         `json:"candidates"`
+	OnlineArchive []retention.OnlineArchiveRule `json:"online_archive,omitempty"`
+}
+
+// SetRetentionPolicyRequest is the request body for PUT /api/retention/policy.
+// A missing policy clears the collection's policy.
+type SetRetentionPolicyRequest struct {
+	Collection string                   `json:"collection"`
+	Policy     *mapping.RetentionPolicy `json:"policy"`
+}
+
+// SetIDGenerationRequest is the request body for PUT /api/id-generation. An
+// empty strategy clears the collection's.
+type SetIDGenerationRequest struct {
+	Collection string `json:"collection"`
+	Strategy   string `json:"strategy"`
+}
+
+// SetValidationLevelRequest is the request body for PUT
+// /api/premigration/validators.
+type SetValidationLevelRequest struct {
+	Collection string `json:"collection"`
+	Level      string `json:"level"` // off, moderate or strict
+}
+
+// FilterPreviewRequest is the request body for POST /api/mapping/filter-preview.
+type FilterPreviewRequest struct {
+	Table  string `json:"table"`
+	Filter string `json:"filter"`
+}
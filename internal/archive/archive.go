@@ -0,0 +1,96 @@
+// Package archive writes source rows diverted from a migration to Parquet
+// files in S3.
+package archive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultFileRows is the number of rows written to each Parquet file.
+const DefaultFileRows = 100000
+
+// Sink stores archive files at s3:// URIs.
+type Sink interface {
+	Put(ctx context.Context, uri string, data []byte) error
+	// DeletePrefix removes the files under a prefix, so a rerun replaces
+	// the archive rather than adding to it.
+	DeletePrefix(ctx context.Context, uri string) error
+}
+
+// Writer buffers rows and writes them under Dir as numbered Parquet files,
+// part-00000.parquet and on, of at most FileRows rows each.
+type Writer struct {
+	Dir      string
+	FileRows int
+
+	sink    Sink
+	columns []Column
+	rows    []map[string]interface{}
+	started bool
+
+	Rows  int64    // rows written so far
+	Files []string // URIs of the files written
+}
+
+// NewWriter returns a writer of the columns to files under dir.
+func NewWriter(sink Sink, dir string, columns []Column) *Writer {
+	return &Writer{
+		Dir:      strings.TrimRight(dir, "/"),
+		FileRows: DefaultFileRows,
+		sink:     sink,
+		columns:  columns,
+	}
+}
+
+// Write adds a row, writing a file when FileRows are buffered. The first
+// write clears what an earlier run left under Dir.
+func (w *Writer) Write(ctx context.Context, row map[string]interface{}) error {
+	if err := w.start(ctx); err != nil {
+		return err
+	}
+	w.rows = append(w.rows, row)
+	if len(w.rows) >= w.FileRows {
+		return w.flush(ctx)
+	}
+	return nil
+}
+
+// Close writes the buffered rows. A writer given no rows clears Dir and
+// writes nothing.
+func (w *Writer) Close(ctx context.Context) error {
+	if err := w.start(ctx); err != nil {
+		return err
+	}
+	return w.flush(ctx)
+}
+
+func (w *Writer) start(ctx context.Context) error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	if err := w.sink.DeletePrefix(ctx, w.Dir+"/"); err != nil {
+		return fmt.Errorf("clearing archive %s: %w", w.Dir, err)
+	}
+	return nil
+}
+
+func (w *Writer) flush(ctx context.Context) error {
+	if len(w.rows) == 0 {
+		return nil
+	}
+	data, err := encodeParquet(w.columns, w.rows)
+	if err != nil {
+		return err
+	}
+	uri := fmt.Sprintf("%s/part-%05d.parquet", w.Dir, len(w.Files))
+	if err := w.sink.Put(ctx, uri, data); err != nil {
+		return fmt.Errorf("writing archive file %s: %w", uri, err)
+	}
+	w.Files = append(w.Files, uri)
+	w.Rows += int64(len(w.rows))
+	w.rows = w.rows[:0]
+	return nil
+}
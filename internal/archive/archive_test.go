@@ -0,0 +1,263 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+type memSink struct {
+	files   map[string][]byte
+	deleted []string
+}
+
+func (s *memSink) Put(_ context.Context, uri string, data []byte) error {
+	if s.files == nil {
+		s.files = make(map[string][]byte)
+	}
+	s.files[uri] = data
+	return nil
+}
+
+func (s *memSink) DeletePrefix(_ context.Context, uri string) error {
+	s.deleted = append(s.deleted, uri)
+	return nil
+}
+
+// compactReader decodes the Thrift compact protocol into maps of field id
+// to value, enough to read back the metadata encodeParquet writes.
+type compactReader struct {
+	r *bytes.Reader
+}
+
+func (c compactReader) varint() int64 {
+	v, _ := binary.ReadVarint(c.r)
+	return v
+}
+
+func (c compactReader) value(typ byte) interface{} {
+	switch typ {
+	case 5, 6:
+		return c.varint()
+	case 8:
+		n, _ := binary.ReadUvarint(c.r)
+		b := make([]byte, n)
+		io.ReadFull(c.r, b)
+		return string(b)
+	case 9:
+		h, _ := c.r.ReadByte()
+		n := int(h >> 4)
+		if n == 15 {
+			u, _ := binary.ReadUvarint(c.r)
+			n = int(u)
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = c.value(h & 0x0f)
+		}
+		return list
+	case 12:
+		return c.structure()
+	}
+	panic("unexpected compact type")
+}
+
+func (c compactReader) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var id int16
+	for {
+		h, _ := c.r.ReadByte()
+		if h == 0 {
+			return fields
+		}
+		if d := h >> 4; d != 0 {
+			id += int16(d)
+		} else {
+			id = int16(c.varint())
+		}
+		fields[id] = c.value(h & 0x0f)
+	}
+}
+
+func readFooter(t *testing.T, data []byte) map[int16]interface{} {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatal("missing PAR1 magic")
+	}
+	n := binary.LittleEndian.Uint32(data[len(data)-8:])
+	meta := data[len(data)-8-int(n) : len(data)-8]
+	return compactReader{bytes.NewReader(meta)}.structure()
+}
+
+// readColumn decodes the data page of column i into its definition levels
+// and plain-encoded values.
+func readColumn(t *testing.T, data []byte, footer map[int16]interface{}, i int) ([]bool, []byte) {
+	t.Helper()
+	rg := footer[4].([]interface{})[0].(map[int16]interface{})
+	chunk := rg[1].([]interface{})[i].(map[int16]interface{})
+	md := chunk[3].(map[int16]interface{})
+	r := bytes.NewReader(data[md[9].(int64):])
+	hdr := compactReader{r}.structure()
+	page := make([]byte, hdr[3].(int64))
+	io.ReadFull(r, page)
+	zr, err := gzip.NewReader(bytes.NewReader(page))
+	if err != nil {
+		t.Fatalf("page is not gzip: %v", err)
+	}
+	plain, _ := io.ReadAll(zr)
+	if int64(len(plain)) != hdr[2].(int64) {
+		t.Fatalf("uncompressed size = %d, header says %d", len(plain), hdr[2])
+	}
+	runLen := binary.LittleEndian.Uint32(plain)
+	run := bytes.NewReader(plain[4 : 4+runLen])
+	head, _ := binary.ReadUvarint(run)
+	packed, _ := io.ReadAll(run)
+	if head&1 != 1 || int(head>>1) != len(packed) {
+		t.Fatalf("definition levels run header %d for %d bytes", head, len(packed))
+	}
+	levels := make([]bool, md[5].(int64))
+	for j := range levels {
+		levels[j] = packed[j/8]&(1<<(j%8)) != 0
+	}
+	return levels, plain[4+runLen:]
+}
+
+func TestEncodeParquet(t *testing.T) {
+	cols := []Column{
+		{Name: "id", Kind: KindInt64},
+		{Name: "note", Kind: KindString},
+		{Name: "paid", Kind: KindBool},
+		{Name: "created_at", Kind: KindTimestamp},
+		{Name: "total", Kind: KindDouble},
+	}
+	created := time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)
+	rows := []map[string]interface{}{
+		{"id": int32(1), "note": "first", "paid": true, "created_at": created, "total": 12.5},
+		{"id": int64(2), "note": nil, "paid": false, "created_at": created.Add(time.Second), "total": int64(3)},
+		{"id": int64(3), "note": "third", "created_at": nil},
+	}
+	data, err := encodeParquet(cols, rows)
+	if err != nil {
+		t.Fatalf("encodeParquet: %v", err)
+	}
+	footer := readFooter(t, data)
+	if footer[3].(int64) != 3 {
+		t.Errorf("num_rows = %v, want 3", footer[3])
+	}
+	var names []string
+	for _, el := range footer[2].([]interface{})[1:] {
+		names = append(names, el.(map[int16]interface{})[4].(string))
+	}
+	if !reflect.DeepEqual(names, []string{"id", "note", "paid", "created_at", "total"}) {
+		t.Errorf("schema = %v", names)
+	}
+
+	levels, values := readColumn(t, data, footer, 0)
+	if !reflect.DeepEqual(levels, []bool{true, true, true}) {
+		t.Errorf("id levels = %v", levels)
+	}
+	var ids [3]int64
+	binary.Read(bytes.NewReader(values), binary.LittleEndian, &ids)
+	if ids != [3]int64{1, 2, 3} {
+		t.Errorf("ids = %v", ids)
+	}
+
+	levels, values = readColumn(t, data, footer, 1)
+	if !reflect.DeepEqual(levels, []bool{true, false, true}) {
+		t.Errorf("note levels = %v", levels)
+	}
+	if want := "\x05\x00\x00\x00first\x05\x00\x00\x00third"; string(values) != want {
+		t.Errorf("notes = %q, want %q", values, want)
+	}
+
+	levels, values = readColumn(t, data, footer, 2)
+	if !reflect.DeepEqual(levels, []bool{true, true, false}) || !bytes.Equal(values, []byte{0x01}) {
+		t.Errorf("paid = %v %v", levels, values)
+	}
+
+	_, values = readColumn(t, data, footer, 3)
+	if got := int64(binary.LittleEndian.Uint64(values)); got != created.UnixMicro() {
+		t.Errorf("created_at = %d, want %d", got, created.UnixMicro())
+	}
+
+	_, values = readColumn(t, data, footer, 4)
+	if got := math.Float64frombits(binary.LittleEndian.Uint64(values[8:])); got != 3 {
+		t.Errorf("total = %v, want 3", got)
+	}
+}
+
+func TestEncodeParquet_BadValue(t *testing.T) {
+	_, err := encodeParquet([]Column{{Name: "id", Kind: KindInt64}}, []map[string]interface{}{{"id": "abc"}})
+	if err == nil || !strings.Contains(err.Error(), "column id") {
+		t.Errorf("err = %v, want a conversion error naming the column", err)
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		dataType string
+		want     Kind
+	}{
+		{"integer", KindInt64},
+		{"bigint", KindInt64},
+		{"boolean", KindBool},
+		{"timestamp with time zone", KindTimestamp},
+		{"DATE", KindTimestamp},
+		{"double precision", KindDouble},
+		{"BINARY_DOUBLE", KindDouble},
+		{"numeric", KindString},
+		{"NUMBER", KindString},
+		{"interval", KindString},
+		{"point", KindString},
+		{"bytea", KindBinary},
+		{"BLOB", KindBinary},
+		{"character varying", KindString},
+	}
+	for _, tt := range tests {
+		if got := KindOf(tt.dataType); got != tt.want {
+			t.Errorf("KindOf(%q) = %s, want %s", tt.dataType, got, tt.want)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	sink := &memSink{}
+	table := &schema.Table{Name: "orders", Columns: []schema.Column{{Name: "id", DataType: "integer"}}}
+	w := NewWriter(sink, "s3://cold/shop/orders/", ColumnsOf(table))
+	w.FileRows = 2
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if err := w.Write(ctx, map[string]interface{}{"id": i}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if w.Rows != 5 {
+		t.Errorf("Rows = %d, want 5", w.Rows)
+	}
+	want := []string{
+		"s3://cold/shop/orders/part-00000.parquet",
+		"s3://cold/shop/orders/part-00001.parquet",
+		"s3://cold/shop/orders/part-00002.parquet",
+	}
+	if !reflect.DeepEqual(w.Files, want) || len(sink.files) != 3 {
+		t.Errorf("files = %v", w.Files)
+	}
+	if !reflect.DeepEqual(sink.deleted, []string{"s3://cold/shop/orders/"}) {
+		t.Errorf("deleted = %v, want the archive cleared once", sink.deleted)
+	}
+	if n := readFooter(t, sink.files[want[2]])[3].(int64); n != 1 {
+		t.Errorf("last file rows = %d, want 1", n)
+	}
+}
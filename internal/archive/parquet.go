@@ -0,0 +1,442 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// Kind is the Parquet type a source column is archived as.
+type Kind int
+
+const (
+	KindString    Kind = iota // BYTE_ARRAY, UTF8
+	KindBinary                // BYTE_ARRAY
+	KindBool                  // BOOLEAN
+	KindInt64                 // INT64
+	KindDouble                // DOUBLE
+	KindTimestamp             // INT64, TIMESTAMP_MICROS in UTC
+)
+
+// Column is an archived column.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// KindOf picks the Parquet type for a source data type. Exact numerics
+// other than integers are archived as text so no precision is lost.
+func KindOf(dataType string) Kind {
+	t := strings.ToLower(dataType)
+	switch {
+	case strings.Contains(t, "bool"):
+		return KindBool
+	case strings.Contains(t, "timestamp") || t == "date":
+		return KindTimestamp
+	case (strings.Contains(t, "int") && !strings.Contains(t, "interval") && !strings.Contains(t, "point")) ||
+		strings.Contains(t, "serial"):
+		return KindInt64
+	case strings.Contains(t, "float") || strings.Contains(t, "double") || t == "real" ||
+		strings.HasPrefix(t, "binary_"):
+		return KindDouble
+	case t == "bytea" || strings.Contains(t, "blob") || strings.Contains(t, "raw"):
+		return KindBinary
+	default:
+		return KindString
+	}
+}
+
+// ColumnsOf returns the archived columns of a source table.
+func ColumnsOf(t *schema.Table) []Column {
+	cols := make([]Column, len(t.Columns))
+	for i, c := range t.Columns {
+		cols[i] = Column{Name: c.Name, Kind: KindOf(c.DataType)}
+	}
+	return cols
+}
+
+// Parquet physical types, encodings and other enum values of the format.
+const (
+	typeBoolean   = 0
+	typeInt64     = 2
+	typeDouble    = 5
+	typeByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+
+	repetitionOptional = 1
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecGzip          = 2
+	pageData           = 0
+)
+
+func (k Kind) physical() int32 {
+	switch k {
+	case KindBool:
+		return typeBoolean
+	case KindInt64, KindTimestamp:
+		return typeInt64
+	case KindDouble:
+		return typeDouble
+	default:
+		return typeByteArray
+	}
+}
+
+// encodeParquet writes rows as a Parquet file with one row group and one
+// gzip-compressed, plain-encoded data page per column. Every column is
+// optional; missing and NULL values are written as nulls.
+func encodeParquet(columns []Column, rows []map[string]interface{}) ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString("PAR1")
+
+	type chunk struct {
+		offset            int64
+		compressed, plain int64
+	}
+	chunks := make([]chunk, len(columns))
+	var total int64
+	for i, col := range columns {
+		page, err := encodePage(col, rows)
+		if err != nil {
+			return nil, err
+		}
+		var gz bytes.Buffer
+		zw := gzip.NewWriter(&gz)
+		zw.Write(page)
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("compressing column %s: %w", col.Name, err)
+		}
+
+		var hdr compactWriter
+		hdr.i32(1, pageData)
+		hdr.i32(2, int32(len(page)))
+		hdr.i32(3, int32(gz.Len()))
+		hdr.beginStruct(5)
+		hdr.i32(1, int32(len(rows)))
+		hdr.i32(2, encodingPlain)
+		hdr.i32(3, encodingRLE)
+		hdr.i32(4, encodingRLE)
+		hdr.endStruct()
+		hdr.stop()
+
+		chunks[i] = chunk{
+			offset:     int64(out.Len()),
+			compressed: int64(hdr.buf.Len() + gz.Len()),
+			plain:      int64(hdr.buf.Len() + len(page)),
+		}
+		total += chunks[i].plain
+		out.Write(hdr.buf.Bytes())
+		out.Write(gz.Bytes())
+	}
+
+	var meta compactWriter
+	meta.i32(1, 1)
+	meta.beginList(2, compactStruct, len(columns)+1)
+	meta.listStruct()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(columns)))
+	meta.endStruct()
+	for _, col := range columns {
+		meta.listStruct()
+		meta.i32(1, col.Kind.physical())
+		meta.i32(3, repetitionOptional)
+		meta.binary(4, col.Name)
+		switch col.Kind {
+		case KindString:
+			meta.i32(6, convertedUTF8)
+		case KindTimestamp:
+			meta.i32(6, convertedTimestampMicros)
+		}
+		meta.endStruct()
+	}
+	meta.i64(3, int64(len(rows)))
+	meta.beginList(4, compactStruct, 1)
+	meta.listStruct()
+	meta.beginList(1, compactStruct, len(columns))
+	for i, col := range columns {
+		meta.listStruct()
+		meta.i64(2, chunks[i].offset)
+		meta.beginStruct(3)
+		meta.i32(1, col.Kind.physical())
+		meta.beginList(2, compactI32, 2)
+		meta.listI32(encodingPlain)
+		meta.listI32(encodingRLE)
+		meta.beginList(3, compactBinary, 1)
+		meta.listBinary(col.Name)
+		meta.i32(4, codecGzip)
+		meta.i64(5, int64(len(rows)))
+		meta.i64(6, chunks[i].plain)
+		meta.i64(7, chunks[i].compressed)
+		meta.i64(9, chunks[i].offset)
+		meta.endStruct()
+		meta.endStruct()
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(len(rows)))
+	meta.endStruct()
+	meta.binary(6, "reloquent")
+	meta.stop()
+
+	out.Write(meta.buf.Bytes())
+	binary.Write(&out, binary.LittleEndian, uint32(meta.buf.Len()))
+	out.WriteString("PAR1")
+	return out.Bytes(), nil
+}
+
+// encodePage returns a column's uncompressed data page: the definition
+// levels, then the plain-encoded non-null values.
+func encodePage(col Column, rows []map[string]interface{}) ([]byte, error) {
+	levels := make([]bool, len(rows))
+	var values bytes.Buffer
+	var bits []bool
+	for i, row := range rows {
+		v, err := plainValue(col, row[col.Name])
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.Name, err)
+		}
+		if v == nil {
+			continue
+		}
+		levels[i] = true
+		switch x := v.(type) {
+		case bool:
+			bits = append(bits, x)
+		case int64:
+			binary.Write(&values, binary.LittleEndian, x)
+		case float64:
+			binary.Write(&values, binary.LittleEndian, math.Float64bits(x))
+		case []byte:
+			binary.Write(&values, binary.LittleEndian, uint32(len(x)))
+			values.Write(x)
+		}
+	}
+	if col.Kind == KindBool {
+		values.Write(packBits(bits))
+	}
+
+	// Definition levels: one bit-packed run of bit width 1, length-prefixed.
+	packed := packBits(levels)
+	var run bytes.Buffer
+	run.Write(uvarint(uint64(len(packed))<<1 | 1))
+	run.Write(packed)
+
+	var page bytes.Buffer
+	binary.Write(&page, binary.LittleEndian, uint32(run.Len()))
+	page.Write(run.Bytes())
+	page.Write(values.Bytes())
+	return page.Bytes(), nil
+}
+
+// plainValue converts a value read from the source to the Go type the
+// column's Parquet type is written from, or nil for NULL.
+func plainValue(col Column, v interface{}) (interface{}, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return nil, err
+		}
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch col.Kind {
+	case KindBool:
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case string:
+			return strconv.ParseBool(x)
+		}
+		if n, ok := toInt64(v); ok {
+			return n != 0, nil
+		}
+	case KindInt64:
+		if n, ok := toInt64(v); ok {
+			return n, nil
+		}
+		if s, ok := v.(string); ok {
+			return strconv.ParseInt(s, 10, 64)
+		}
+	case KindDouble:
+		if f, ok := toFloat64(v); ok {
+			return f, nil
+		}
+		if s, ok := v.(string); ok {
+			return strconv.ParseFloat(s, 64)
+		}
+	case KindTimestamp:
+		if t, ok := v.(time.Time); ok {
+			return t.UTC().UnixMicro(), nil
+		}
+	case KindBinary:
+		switch x := v.(type) {
+		case []byte:
+			return x, nil
+		case string:
+			return []byte(x), nil
+		}
+	default:
+		switch x := v.(type) {
+		case string:
+			return []byte(x), nil
+		case []byte:
+			return x, nil
+		case time.Time:
+			return []byte(x.Format(time.RFC3339Nano)), nil
+		}
+		return []byte(fmt.Sprint(v)), nil
+	}
+	return nil, fmt.Errorf("cannot archive %T value as %s", v, col.Kind)
+}
+
+func (k Kind) String() string {
+	return [...]string{"string", "binary", "boolean", "int64", "double", "timestamp"}[k]
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch x := v.(type) {
+	case int:
+		return int64(x), true
+	case int8:
+		return int64(x), true
+	case int16:
+		return int64(x), true
+	case int32:
+		return int64(x), true
+	case int64:
+		return x, true
+	case uint8:
+		return int64(x), true
+	case uint16:
+		return int64(x), true
+	case uint32:
+		return int64(x), true
+	case float64:
+		if x == math.Trunc(x) {
+			return int64(x), true
+		}
+	}
+	return 0, false
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float32:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	if n, ok := toInt64(v); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// packBits packs booleans eight to a byte, least significant bit first.
+func packBits(bits []bool) []byte {
+	out := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	return out
+}
+
+func uvarint(n uint64) []byte {
+	return binary.AppendUvarint(nil, n)
+}
+
+// Thrift compact protocol types used by the Parquet metadata.
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+)
+
+// compactWriter writes the Thrift compact protocol encoding of the Parquet
+// page headers and file metadata.
+type compactWriter struct {
+	buf  bytes.Buffer
+	last []int16 // last field id of each open struct
+	id   int16
+}
+
+func (w *compactWriter) field(id int16, typ byte) {
+	if d := id - w.id; d > 0 && d <= 15 {
+		w.buf.WriteByte(byte(d)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.buf.Write(binary.AppendVarint(nil, int64(id)))
+	}
+	w.id = id
+}
+
+func (w *compactWriter) i32(id int16, v int32) {
+	w.field(id, compactI32)
+	w.buf.Write(binary.AppendVarint(nil, int64(v)))
+}
+
+func (w *compactWriter) i64(id int16, v int64) {
+	w.field(id, compactI64)
+	w.buf.Write(binary.AppendVarint(nil, v))
+}
+
+func (w *compactWriter) binary(id int16, s string) {
+	w.field(id, compactBinary)
+	w.listBinary(s)
+}
+
+func (w *compactWriter) beginStruct(id int16) {
+	w.field(id, compactStruct)
+	w.last = append(w.last, w.id)
+	w.id = 0
+}
+
+// listStruct starts a struct element of a list.
+func (w *compactWriter) listStruct() {
+	w.last = append(w.last, w.id)
+	w.id = 0
+}
+
+func (w *compactWriter) endStruct() {
+	w.stop()
+	w.id = w.last[len(w.last)-1]
+	w.last = w.last[:len(w.last)-1]
+}
+
+func (w *compactWriter) stop() {
+	w.buf.WriteByte(0)
+}
+
+func (w *compactWriter) beginList(id int16, elem byte, n int) {
+	w.field(id, compactList)
+	if n < 15 {
+		w.buf.WriteByte(byte(n)<<4 | elem)
+		return
+	}
+	w.buf.WriteByte(0xf0 | elem)
+	w.buf.Write(uvarint(uint64(n)))
+}
+
+func (w *compactWriter) listI32(v int32) {
+	w.buf.Write(binary.AppendVarint(nil, int64(v)))
+}
+
+func (w *compactWriter) listBinary(s string) {
+	w.buf.Write(uvarint(uint64(len(s))))
+	w.buf.WriteString(s)
+}
@@ -0,0 +1,219 @@
+// Package atlas calls the MongoDB Atlas Administration API for operations
+// the database server does not expose, such as creating Atlas Search
+// indexes. Requests are authenticated with a programmatic API key using
+// HTTP digest authentication.
+package atlas
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// DefaultBaseURL is the Atlas API host used when the config names none.
+const DefaultBaseURL = "https://cloud.mongodb.com"
+
+// apiAccept selects the versioned Atlas Administration API.
+const apiAccept = "application/vnd.atlas.2024-05-30+json"
+
+// Client creates Atlas Search indexes on the collections of one database
+// of an Atlas cluster.
+type Client struct {
+	BaseURL    string
+	ProjectID  string
+	Cluster    string
+	Database   string
+	PublicKey  string
+	PrivateKey string
+	HTTP       *http.Client
+}
+
+// NewClient returns a client for the cluster in cfg, resolving the private
+// key reference.
+func NewClient(cfg config.AtlasConfig, database string) (*Client, error) {
+	if !cfg.Configured() {
+		return nil, fmt.Errorf("atlas project_id, cluster, public_key and private_key are required")
+	}
+	key, err := config.ResolveValue(cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("resolving atlas private key: %w", err)
+	}
+	base := cfg.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL:    strings.TrimSuffix(base, "/"),
+		ProjectID:  cfg.ProjectID,
+		Cluster:    cfg.Cluster,
+		Database:   database,
+		PublicKey:  cfg.PublicKey,
+		PrivateKey: key,
+		HTTP:       &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// SearchIndexDefinition returns the Atlas Search index definition of an
+// index plan entry: static mappings of its fields as strings analyzed with
+// its analyzer. Fields in subdocuments nest as documents.
+func SearchIndexDefinition(idx target.IndexDefinition) map[string]any {
+	analyzer := idx.Analyzer
+	if analyzer == "" {
+		analyzer = "lucene.standard"
+	}
+	fields := make(map[string]any)
+	for _, k := range idx.Keys {
+		parts := strings.Split(k.Field, ".")
+		m := fields
+		for _, p := range parts[:len(parts)-1] {
+			doc, ok := m[p].(map[string]any)
+			if !ok {
+				doc = map[string]any{"type": "document", "dynamic": false, "fields": map[string]any{}}
+				m[p] = doc
+			}
+			m = doc["fields"].(map[string]any)
+		}
+		m[parts[len(parts)-1]] = map[string]any{"type": "string", "analyzer": analyzer}
+	}
+	return map[string]any{
+		"analyzer": analyzer,
+		"mappings": map[string]any{"dynamic": false, "fields": fields},
+	}
+}
+
+// CreateSearchIndex creates an Atlas Search index on a collection. Atlas
+// builds it in the background; the call returns once it is accepted.
+func (c *Client) CreateSearchIndex(ctx context.Context, collection string, idx target.IndexDefinition) error {
+	body, err := json.Marshal(map[string]any{
+		"collectionName": collection,
+		"database":       c.Database,
+		"name":           idx.Name,
+		"type":           "search",
+		"definition":     SearchIndexDefinition(idx),
+	})
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/api/atlas/v2/groups/%s/clusters/%s/search/indexes",
+		url.PathEscape(c.ProjectID), url.PathEscape(c.Cluster))
+	if err := c.do(ctx, http.MethodPost, path, body); err != nil {
+		return fmt.Errorf("creating search index %s on %s: %w", idx.Name, collection, err)
+	}
+	return nil
+}
+
+// do sends a request, answering the digest challenge of the first attempt.
+func (c *Client) do(ctx context.Context, method, path string, body []byte) error {
+	send := func(auth string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", apiAccept)
+		req.Header.Set("Content-Type", "application/json")
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		return c.HTTP.Do(req)
+	}
+
+	resp, err := send("")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		auth, err := digestAuth(challenge, c.PublicKey, c.PrivateKey, method, path)
+		if err != nil {
+			return err
+		}
+		if resp, err = send(auth); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Detail    string `json:"detail"`
+			ErrorCode string `json:"errorCode"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Detail != "" {
+			return fmt.Errorf("atlas API %s: %s (%s)", resp.Status, apiErr.Detail, apiErr.ErrorCode)
+		}
+		return fmt.Errorf("atlas API %s", resp.Status)
+	}
+	return nil
+}
+
+// digestAuth answers an RFC 7616 MD5 digest challenge with qop auth.
+func digestAuth(challenge, user, password, method, uri string) (string, error) {
+	scheme, rest, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return "", fmt.Errorf("atlas API rejected the request without a digest challenge")
+	}
+	params := make(map[string]string)
+	for _, part := range splitParams(rest) {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			params[strings.ToLower(k)] = strings.Trim(v, `"`)
+		}
+	}
+	if alg := params["algorithm"]; alg != "" && !strings.EqualFold(alg, "MD5") {
+		return "", fmt.Errorf("unsupported digest algorithm %s", alg)
+	}
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	cnonce := hex.EncodeToString(b)
+	const nc = "00000001"
+	ha1 := md5Hex(user + ":" + params["realm"] + ":" + password)
+	ha2 := md5Hex(method + ":" + uri)
+	response := md5Hex(ha1 + ":" + params["nonce"] + ":" + nc + ":" + cnonce + ":auth:" + ha2)
+
+	auth := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", qop=auth, nc=%s, cnonce="%s", response="%s", algorithm=MD5`,
+		user, params["realm"], params["nonce"], uri, nc, cnonce, response)
+	if params["opaque"] != "" {
+		auth += fmt.Sprintf(`, opaque="%s"`, params["opaque"])
+	}
+	return auth, nil
+}
+
+// splitParams splits challenge parameters on commas outside quotes.
+func splitParams(s string) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,89 @@
+package atlas
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func TestSearchIndexDefinition(t *testing.T) {
+	def := SearchIndexDefinition(target.IndexDefinition{
+		Name:     "search_articles",
+		Analyzer: "lucene.english",
+		Keys: []target.IndexKey{
+			{Field: "body", Type: target.IndexSearch},
+			{Field: "comments.text", Type: target.IndexSearch},
+		},
+	})
+	want := map[string]any{
+		"analyzer": "lucene.english",
+		"mappings": map[string]any{
+			"dynamic": false,
+			"fields": map[string]any{
+				"body": map[string]any{"type": "string", "analyzer": "lucene.english"},
+				"comments": map[string]any{"type": "document", "dynamic": false, "fields": map[string]any{
+					"text": map[string]any{"type": "string", "analyzer": "lucene.english"},
+				}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(def, want) {
+		t.Errorf("definition = %v, want %v", def, want)
+	}
+}
+
+func TestCreateSearchIndex_DigestAuth(t *testing.T) {
+	const path = "/api/atlas/v2/groups/proj1/clusters/prod/search/indexes"
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth == "" {
+			w.Header().Set("WWW-Authenticate", `Digest realm="MMS Public API", domain="", nonce="abc", algorithm=MD5, qop="auth", stale=false`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		p := digestParams(auth)
+		ha1 := md5Hex("pub:MMS Public API:secret")
+		ha2 := md5Hex(r.Method + ":" + r.URL.Path)
+		sum := md5.Sum([]byte(ha1 + ":abc:" + p["nc"] + ":" + p["cnonce"] + ":auth:" + ha2))
+		if r.URL.Path != path || p["username"] != "pub" || p["response"] != hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, ProjectID: "proj1", Cluster: "prod", Database: "shop",
+		PublicKey: "pub", PrivateKey: "secret", HTTP: srv.Client()}
+	idx := target.IndexDefinition{Name: "search_articles", Keys: []target.IndexKey{{Field: "body", Type: target.IndexSearch}}}
+	if err := c.CreateSearchIndex(context.Background(), "articles", idx); err != nil {
+		t.Fatalf("CreateSearchIndex: %v", err)
+	}
+	if got["collectionName"] != "articles" || got["database"] != "shop" || got["name"] != "search_articles" {
+		t.Errorf("request body = %v", got)
+	}
+
+	c.PrivateKey = "wrong"
+	if err := c.CreateSearchIndex(context.Background(), "articles", idx); err == nil {
+		t.Error("expected an error for a rejected key")
+	}
+}
+
+func digestParams(auth string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range splitParams(strings.TrimPrefix(auth, "Digest ")) {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		params[k] = strings.Trim(v, `"`)
+	}
+	return params
+}
@@ -0,0 +1,180 @@
+// Package audit keeps a project's audit trail: who changed what and when,
+// with snapshots of the configuration artifacts an action rewrote. The trail
+// is a JSON-lines file in the project directory that entries are only ever
+// appended to.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the audit trail's file in a project directory.
+const FileName = "audit-trail.jsonl"
+
+// ActorEnv names who acts, overriding the operating system user, for
+// shared service accounts and CI jobs.
+const ActorEnv = "RELOQUENT_ACTOR"
+
+// Entry is one action in the trail.
+type Entry struct {
+	Time      time.Time  `json:"time"`
+	Actor     string     `json:"actor"`
+	Action    string     `json:"action"`
+	Detail    string     `json:"detail,omitempty"`
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+}
+
+// Artifact is a configuration file as it was before and after an action.
+// Before is empty when the action created the file, After when it removed
+// it.
+type Artifact struct {
+	Name   string `json:"name"` // mapping, typemap or index_plan
+	Path   string `json:"path"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Snapshot reads the artifact at path as it is before an action; Capture
+// reads it again afterwards.
+func Snapshot(name, path string) Artifact {
+	return Artifact{Name: name, Path: path, Before: readFile(path)}
+}
+
+// Capture records the artifact as the action left it.
+func (a *Artifact) Capture() {
+	a.After = readFile(a.Path)
+}
+
+// Changed reports whether the action changed the artifact.
+func (a Artifact) Changed() bool {
+	return a.Before != a.After
+}
+
+func readFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// CurrentActor names who is acting: $RELOQUENT_ACTOR, or else the operating
+// system user and host.
+func CurrentActor() string {
+	if a := os.Getenv(ActorEnv); a != "" {
+		return a
+	}
+	name := "unknown"
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		name += "@" + host
+	}
+	return name
+}
+
+// Log is the audit trail of the project in a directory.
+type Log struct {
+	path string
+}
+
+// Open returns the audit trail of the project in dir.
+func Open(dir string) *Log {
+	return &Log{path: filepath.Join(dir, FileName)}
+}
+
+// Path returns the trail's file.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// writeMu serializes appends within the process, so concurrent entries are
+// never interleaved.
+var writeMu sync.Mutex
+
+// Append adds an entry to the end of the trail, timed now unless it already
+// has a time.
+func (l *Log) Append(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Filter selects entries of the trail.
+type Filter struct {
+	Action string    // only this action, when set
+	Since  time.Time // only entries at or after, when set
+	Limit  int       // at most this many, newest first; <= 0 returns all
+}
+
+// Entries returns the entries the filter selects, newest first. A trail
+// that does not exist yet has none.
+func (l *Log) Entries(f Filter) ([]Entry, error) {
+	data, err := os.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", l.path, n, err)
+		}
+		if f.Action != "" && e.Action != f.Action {
+			continue
+		}
+		if !f.Since.IsZero() && e.Time.Before(f.Since) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if f.Limit > 0 && len(entries) > f.Limit {
+		entries = entries[:f.Limit]
+	}
+	return entries, nil
+}
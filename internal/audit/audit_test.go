@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLog(t *testing.T) {
+	dir := t.TempDir()
+	l := Open(dir)
+	if entries, err := l.Entries(Filter{}); err != nil || entries != nil {
+		t.Fatalf("Entries of a missing trail = %v, %v", entries, err)
+	}
+
+	mappingPath := filepath.Join(dir, "mapping.yaml")
+	snap := Snapshot("mapping", mappingPath)
+	if err := os.WriteFile(mappingPath, []byte("collections: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	snap.Capture()
+	if !snap.Changed() || snap.Before != "" || snap.After != "collections: []\n" {
+		t.Errorf("artifact = %+v, want the file created", snap)
+	}
+
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	for i, e := range []Entry{
+		{Time: start, Actor: "ana", Action: "schema_discovered"},
+		{Time: start.Add(time.Hour), Actor: "ana", Action: "mapping_saved", Artifacts: []Artifact{snap}},
+		{Time: start.Add(2 * time.Hour), Actor: "ben", Action: "migration_started", Detail: "full"},
+	} {
+		if err := l.Append(e); err != nil {
+			t.Fatalf("Append %d: %v", i, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		f    Filter
+		want []string
+	}{
+		{"all", Filter{}, []string{"migration_started", "mapping_saved", "schema_discovered"}},
+		{"limit", Filter{Limit: 1}, []string{"migration_started"}},
+		{"action", Filter{Action: "mapping_saved"}, []string{"mapping_saved"}},
+		{"since", Filter{Since: start.Add(time.Hour)}, []string{"migration_started", "mapping_saved"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := l.Entries(tt.f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Action)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("actions = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("actions = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	entries, _ := l.Entries(Filter{Action: "mapping_saved"})
+	if a := entries[0].Artifacts; len(a) != 1 || a[0].After != "collections: []\n" || entries[0].Actor != "ana" {
+		t.Errorf("entry = %+v, want the mapping snapshot", entries[0])
+	}
+}
+
+func TestCurrentActor(t *testing.T) {
+	t.Setenv(ActorEnv, "ci-bot")
+	if got := CurrentActor(); got != "ci-bot" {
+		t.Errorf("CurrentActor = %q, want ci-bot", got)
+	}
+	t.Setenv(ActorEnv, "")
+	if got := CurrentActor(); got == "" {
+		t.Error("CurrentActor is empty without RELOQUENT_ACTOR")
+	}
+}
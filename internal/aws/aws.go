@@ -10,6 +10,7 @@ type Client interface {
 	UploadToS3(ctx context.Context, bucket, key string, data []byte) error
 	UploadFileToS3(ctx context.Context, bucket, key, localPath string) error
 	DeleteS3Prefix(ctx context.Context, bucket, prefix string) error
+	DeniedPermissions(ctx context.Context, perms []Permission) ([]Permission, error)
 }
 
 // CallerIdentity holds AWS STS caller identity information.
@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -138,6 +139,30 @@ func TestArtifactUpload_WithJDBC(t *testing.T) {
 	}
 }
 
+func TestArtifactUpload_WithJars(t *testing.T) {
+	mock := NewMockClient()
+	uploader := NewArtifactUploader(mock, "bucket", "prefix")
+
+	artifacts := ArtifactSet{
+		MigrationScript: []byte("script"),
+		ConfigYAML:      []byte("config"),
+		Jars:            []string{"/drivers/bson-5.1.4.jar", "/drivers/postgresql-42.7.4.jar"},
+	}
+
+	result, err := uploader.UploadArtifacts(context.Background(), artifacts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"s3://bucket/prefix/jars/bson-5.1.4.jar", "s3://bucket/prefix/jars/postgresql-42.7.4.jar"}
+	if !reflect.DeepEqual(result.JarS3URIs, want) {
+		t.Errorf("jar URIs = %v, want %v", result.JarS3URIs, want)
+	}
+	if mock.UploadedFiles["bucket/prefix/jars/bson-5.1.4.jar"] != "/drivers/bson-5.1.4.jar" {
+		t.Errorf("uploaded files = %v", mock.UploadedFiles)
+	}
+}
+
 func TestDeleteS3Prefix(t *testing.T) {
 	mock := NewMockClient()
 	err := mock.DeleteS3Prefix(context.Background(), "my-bucket", "reloquent/run-123/")
@@ -148,3 +173,25 @@ func TestDeleteS3Prefix(t *testing.T) {
 		t.Errorf("expected 1 deleted prefix, got %d", len(mock.DeletedPrefixes))
 	}
 }
+
+func TestArchiveStore(t *testing.T) {
+	mock := NewMockClient()
+	store := ArchiveStore{Client: mock}
+	ctx := context.Background()
+
+	if err := store.DeletePrefix(ctx, "s3://cold/shop/orders/"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Put(ctx, "s3://cold/shop/orders/part-00000.parquet", []byte("PAR1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.DeletedPrefixes) != 1 || mock.DeletedPrefixes[0] != "cold/shop/orders/" {
+		t.Errorf("deleted = %v", mock.DeletedPrefixes)
+	}
+	if _, ok := mock.UploadedObjects["cold/shop/orders/part-00000.parquet"]; !ok {
+		t.Errorf("uploads = %v", mock.UploadedObjects)
+	}
+	if err := store.Put(ctx, "/var/archive/part-00000.parquet", nil); err == nil {
+		t.Error("expected an error for a URI that is not s3://")
+	}
+}
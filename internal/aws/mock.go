@@ -13,6 +13,8 @@ type MockClient struct {
 	UploadErr    error
 	UploadFileErr error
 	DeleteErr    error
+	Denied       []string // actions DeniedPermissions reports
+	DeniedErr    error
 
 	// Track calls
 	UploadedObjects map[string][]byte // key → data
@@ -70,3 +72,15 @@ func (m *MockClient) DeleteS3Prefix(_ context.Context, bucket, prefix string) er
 	m.DeletedPrefixes = append(m.DeletedPrefixes, bucket+"/"+prefix)
 	return nil
 }
+
+func (m *MockClient) DeniedPermissions(_ context.Context, perms []Permission) ([]Permission, error) {
+	var denied []Permission
+	for _, p := range perms {
+		for _, a := range m.Denied {
+			if p.Action == a {
+				denied = append(denied, p)
+			}
+		}
+	}
+	return denied, m.DeniedErr
+}
@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+)
+
+// Permission is an IAM action on a resource.
+type Permission struct {
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+}
+
+func (p Permission) String() string {
+	return p.Action + " on " + p.Resource
+}
+
+// IAM roles the Spark platforms run as: the EMR service and instance roles
+// of the transient cluster and the Glue job role.
+const (
+	EMRServiceRole  = "EMR_DefaultRole"
+	EMRInstanceRole = "EMR_EC2_DefaultRole"
+	GlueJobRole     = "AWSGlueServiceRole"
+)
+
+// RequiredPermissions lists the IAM actions a migration on the platform
+// needs: running and watching the Spark job, passing its roles, and
+// writing, reading and removing the artifacts under reloquent/ in the
+// bucket. Platforms other than emr, emr-serverless and glue need only the
+// bucket. The EMR Serverless job role is configured per project, so any
+// role is checked for it.
+func RequiredPermissions(platform, bucket string) []Permission {
+	var perms []Permission
+	passRole := func(role string) {
+		perms = append(perms, Permission{"iam:PassRole", "arn:aws:iam::*:role/" + role})
+	}
+	switch platform {
+	case "emr":
+		for _, a := range []string{"RunJobFlow", "ListSteps", "DescribeCluster", "AddJobFlowSteps", "TerminateJobFlows"} {
+			perms = append(perms, Permission{"elasticmapreduce:" + a, "arn:aws:elasticmapreduce:*:*:cluster/*"})
+		}
+		passRole(EMRServiceRole)
+		passRole(EMRInstanceRole)
+	case "emr-serverless":
+		for _, a := range []string{"StartJobRun", "GetJobRun", "CancelJobRun"} {
+			perms = append(perms, Permission{"emr-serverless:" + a, "arn:aws:emr-serverless:*:*:/applications/*"})
+		}
+		passRole("*")
+	case "glue":
+		for _, a := range []string{"CreateJob", "UpdateJob", "StartJobRun", "GetJobRun", "BatchStopJobRun"} {
+			perms = append(perms, Permission{"glue:" + a, "arn:aws:glue:*:*:job/*"})
+		}
+		passRole(GlueJobRole)
+	}
+	if bucket != "" {
+		objects := "arn:aws:s3:::" + bucket + "/reloquent/*"
+		perms = append(perms,
+			Permission{"s3:ListBucket", "arn:aws:s3:::" + bucket},
+			Permission{"s3:PutObject", objects},
+			Permission{"s3:GetObject", objects},
+			Permission{"s3:DeleteObject", objects},
+		)
+	}
+	return perms
+}
+
+// DeniedPermissions simulates the permissions against the caller's IAM
+// policies and returns those not allowed.
+func (c *RealClient) DeniedPermissions(ctx context.Context, perms []Permission) ([]Permission, error) {
+	identity, err := c.VerifyCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	principal := principalARN(identity.ARN)
+
+	var resources []string
+	actions := map[string][]string{}
+	for _, p := range perms {
+		if _, ok := actions[p.Resource]; !ok {
+			resources = append(resources, p.Resource)
+		}
+		actions[p.Resource] = append(actions[p.Resource], p.Action)
+	}
+
+	var denied []Permission
+	for _, resource := range resources {
+		out, err := c.iamClient.SimulatePrincipalPolicy(ctx, &iam.SimulatePrincipalPolicyInput{
+			PolicySourceArn: aws.String(principal),
+			ActionNames:     actions[resource],
+			ResourceArns:    []string{resource},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("simulating IAM policy for %s: %w", principal, err)
+		}
+		for _, r := range out.EvaluationResults {
+			if r.EvalDecision != "allowed" {
+				denied = append(denied, Permission{Action: aws.ToString(r.EvalActionName), Resource: resource})
+			}
+		}
+	}
+	return denied, nil
+}
+
+// principalARN turns the STS ARN of an assumed role session into the ARN
+// of the role, which is what IAM simulates policies for.
+func principalARN(arn string) string {
+	// arn:aws:sts::123456789012:assumed-role/Name/session
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[2] != "sts" || !strings.HasPrefix(parts[5], "assumed-role/") {
+		return arn
+	}
+	role := strings.Split(strings.TrimPrefix(parts[5], "assumed-role/"), "/")[0]
+	return fmt.Sprintf("%s:%s:iam::%s:role/%s", parts[0], parts[1], parts[4], role)
+}
@@ -0,0 +1,55 @@
+package aws
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRequiredPermissions(t *testing.T) {
+	tests := []struct {
+		platform, bucket string
+		want             []string // actions that must be included
+		wantNot          []string
+	}{
+		{"emr", "b", []string{"elasticmapreduce:RunJobFlow", "iam:PassRole", "s3:PutObject", "s3:ListBucket"}, []string{"glue:CreateJob"}},
+		{"glue", "b", []string{"glue:StartJobRun", "iam:PassRole", "s3:GetObject"}, []string{"elasticmapreduce:RunJobFlow"}},
+		{"emr-serverless", "b", []string{"emr-serverless:StartJobRun", "emr-serverless:CancelJobRun", "iam:PassRole", "s3:GetObject"}, []string{"elasticmapreduce:RunJobFlow"}},
+		{"emr", "", []string{"elasticmapreduce:ListSteps"}, []string{"s3:PutObject"}},
+		{"native", "b", []string{"s3:DeleteObject"}, []string{"iam:PassRole"}},
+	}
+	for _, tt := range tests {
+		var actions []string
+		for _, p := range RequiredPermissions(tt.platform, tt.bucket) {
+			actions = append(actions, p.Action)
+		}
+		for _, a := range tt.want {
+			if !slices.Contains(actions, a) {
+				t.Errorf("%s/%q: missing %s in %v", tt.platform, tt.bucket, a, actions)
+			}
+		}
+		for _, a := range tt.wantNot {
+			if slices.Contains(actions, a) {
+				t.Errorf("%s/%q: unexpected %s", tt.platform, tt.bucket, a)
+			}
+		}
+	}
+
+	for _, p := range RequiredPermissions("emr", "b") {
+		if p.Action == "s3:PutObject" && p.Resource != "arn:aws:s3:::b/reloquent/*" {
+			t.Errorf("put resource = %s", p.Resource)
+		}
+	}
+}
+
+func TestPrincipalARN(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"arn:aws:sts::123456789012:assumed-role/Admin/alice", "arn:aws:iam::123456789012:role/Admin"},
+		{"arn:aws:iam::123456789012:user/alice", "arn:aws:iam::123456789012:user/alice"},
+		{"arn:aws-us-gov:sts::1:assumed-role/R/s", "arn:aws-us-gov:iam::1:role/R"},
+	}
+	for _, tt := range tests {
+		if got := principalARN(tt.in); got != tt.want {
+			t.Errorf("principalARN(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
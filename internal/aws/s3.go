@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"path/filepath"
+	"strings"
 )
 
 // ArtifactUploader manages uploading migration artifacts to S3.
@@ -26,7 +28,8 @@ func NewArtifactUploader(client Client, bucket, prefix string) *ArtifactUploader
 type ArtifactSet struct {
 	MigrationScript []byte
 	ConfigYAML      []byte
-	OracleJDBCPath  string // empty if not Oracle
+	OracleJDBCPath  string   // empty if not Oracle
+	Jars            []string // local paths of other jars the script needs
 }
 
 // UploadResult holds the S3 URIs of uploaded artifacts.
@@ -34,6 +37,7 @@ type UploadResult struct {
 	ScriptS3URI string
 	ConfigS3URI string
 	JDBCS3URI   string
+	JarS3URIs   []string
 }
 
 // UploadArtifacts uploads migration artifacts to S3.
@@ -63,5 +67,48 @@ func (u *ArtifactUploader) UploadArtifacts(ctx context.Context, artifacts Artifa
 		result.JDBCS3URI = fmt.Sprintf("s3://%s/%s", u.bucket, jdbcKey)
 	}
 
+	// Upload the other jars, so the job needs no access to Maven Central
+	for _, jar := range artifacts.Jars {
+		jarKey := path.Join(u.prefix, "jars", filepath.Base(jar))
+		if err := u.client.UploadFileToS3(ctx, u.bucket, jarKey, jar); err != nil {
+			return nil, fmt.Errorf("uploading %s: %w", filepath.Base(jar), err)
+		}
+		result.JarS3URIs = append(result.JarS3URIs, fmt.Sprintf("s3://%s/%s", u.bucket, jarKey))
+	}
+
 	return result, nil
 }
+
+// ArchiveStore writes the archive files of rows diverted from a migration to
+// S3 through a Client.
+type ArchiveStore struct {
+	Client Client
+}
+
+// Put uploads data to an s3:// URI.
+func (s ArchiveStore) Put(ctx context.Context, uri string, data []byte) error {
+	bucket, key, err := splitS3URI(uri)
+	if err != nil {
+		return err
+	}
+	return s.Client.UploadToS3(ctx, bucket, key, data)
+}
+
+// DeletePrefix deletes the objects under an s3:// URI prefix.
+func (s ArchiveStore) DeletePrefix(ctx context.Context, uri string) error {
+	bucket, prefix, err := splitS3URI(uri)
+	if err != nil {
+		return err
+	}
+	return s.Client.DeleteS3Prefix(ctx, bucket, prefix)
+}
+
+// splitS3URI splits s3://bucket/key into the bucket and key.
+func splitS3URI(uri string) (string, string, error) {
+	rest, ok := strings.CutPrefix(uri, "s3://")
+	bucket, key, _ := strings.Cut(rest, "/")
+	if !ok || bucket == "" {
+		return "", "", fmt.Errorf("not an S3 URI: %s", uri)
+	}
+	return bucket, key, nil
+}
@@ -0,0 +1,197 @@
+package spark
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/emr"
+	"github.com/aws/aws-sdk-go-v2/service/emr/types"
+
+	"github.com/reloquent/reloquent/internal/aws"
+)
+
+// emrRelease is the EMR release of the clusters the backend creates.
+const emrRelease = "emr-7.0.0"
+
+// EMRBackend runs the job as a step of the cluster `reloquent provision`
+// created, or, when there is none, as the single step of a transient EMR
+// cluster that terminates itself when the step finishes.
+type EMRBackend struct {
+	client *emr.Client
+
+	// ClusterID is the provisioned cluster steps are added to. Empty
+	// creates a transient cluster for each run.
+	ClusterID string
+
+	// SubnetIDs are the subnets clusters may launch in; EMR picks one.
+	// Empty launches in the account's default subnet.
+	SubnetIDs []string
+}
+
+// NewEMRBackend creates an EMR backend with the given profile and region.
+func NewEMRBackend(ctx context.Context, profile, region string) (*EMRBackend, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(profile))
+	}
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	return &EMRBackend{client: emr.NewFromConfig(cfg)}, nil
+}
+
+// Submit adds the migration step to the provisioned cluster, the run ID
+// being the cluster and step IDs joined by a slash, or creates a transient
+// cluster with the step, the run ID being the cluster ID.
+func (b *EMRBackend) Submit(ctx context.Context, job Job) (string, error) {
+	if b.ClusterID != "" {
+		out, err := b.client.AddJobFlowSteps(ctx, &emr.AddJobFlowStepsInput{
+			JobFlowId: awssdk.String(b.ClusterID),
+			Steps: []types.StepConfig{{
+				Name:            awssdk.String(job.Name),
+				ActionOnFailure: types.ActionOnFailureContinue,
+				HadoopJarStep: &types.HadoopJarStepConfig{
+					Jar:  awssdk.String("command-runner.jar"),
+					Args: sparkSubmitArgs(job),
+				},
+			}},
+		})
+		if err != nil {
+			return "", fmt.Errorf("submitting EMR step: %w", err)
+		}
+		if len(out.StepIds) == 0 {
+			return "", fmt.Errorf("submitting EMR step: no step ID returned")
+		}
+		return b.ClusterID + "/" + out.StepIds[0], nil
+	}
+
+	tags := []types.Tag{{Key: awssdk.String("reloquent"), Value: awssdk.String("migration")}}
+	for k, v := range job.Tags {
+		tags = append(tags, types.Tag{Key: awssdk.String(k), Value: awssdk.String(v)})
+	}
+
+	out, err := b.client.RunJobFlow(ctx, &emr.RunJobFlowInput{
+		Name:              awssdk.String(job.Name),
+		ReleaseLabel:      awssdk.String(emrRelease),
+		Applications:      []types.Application{{Name: awssdk.String("Spark")}},
+		Tags:              tags,
+		JobFlowRole:       awssdk.String(aws.EMRInstanceRole),
+		ServiceRole:       awssdk.String(aws.EMRServiceRole),
+		VisibleToAllUsers: awssdk.Bool(true),
+		Instances: &types.JobFlowInstancesConfig{
+			KeepJobFlowAliveWhenNoSteps: awssdk.Bool(false),
+			InstanceGroups: []types.InstanceGroupConfig{
+				{
+					InstanceRole:  types.InstanceRoleTypeMaster,
+					InstanceType:  awssdk.String(job.SparkPlan.InstanceType),
+					InstanceCount: awssdk.Int32(1),
+				},
+				{
+					InstanceRole:  types.InstanceRoleTypeCore,
+					InstanceType:  awssdk.String(job.SparkPlan.InstanceType),
+					InstanceCount: awssdk.Int32(int32(job.SparkPlan.WorkerCount)),
+				},
+			},
+			Ec2SubnetIds: b.SubnetIDs,
+		},
+		Steps: []types.StepConfig{{
+			Name:            awssdk.String(job.Name),
+			ActionOnFailure: types.ActionOnFailureTerminateCluster,
+			HadoopJarStep: &types.HadoopJarStepConfig{
+				Jar:  awssdk.String("command-runner.jar"),
+				Args: sparkSubmitArgs(job),
+			},
+		}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating EMR cluster: %w", err)
+	}
+	return awssdk.ToString(out.JobFlowId), nil
+}
+
+// JobState reports the state of the migration step.
+func (b *EMRBackend) JobState(ctx context.Context, runID string) (*JobState, error) {
+	var st *types.StepStatus
+	if cluster, step, ok := strings.Cut(runID, "/"); ok {
+		out, err := b.client.DescribeStep(ctx, &emr.DescribeStepInput{ClusterId: awssdk.String(cluster), StepId: awssdk.String(step)})
+		if err != nil {
+			return nil, fmt.Errorf("describing EMR step: %w", err)
+		}
+		if out.Step != nil {
+			st = out.Step.Status
+		}
+	} else {
+		out, err := b.client.ListSteps(ctx, &emr.ListStepsInput{ClusterId: awssdk.String(runID)})
+		if err != nil {
+			return nil, fmt.Errorf("listing EMR steps: %w", err)
+		}
+		if len(out.Steps) > 0 {
+			st = out.Steps[0].Status
+		}
+	}
+	if st == nil {
+		return &JobState{State: StatePending}, nil
+	}
+
+	js := &JobState{State: mapEMRStepState(st.State)}
+	if fd := st.FailureDetails; fd != nil {
+		js.Message = awssdk.ToString(fd.Message)
+		if js.Message == "" {
+			js.Message = awssdk.ToString(fd.Reason)
+		}
+	}
+	return js, nil
+}
+
+// Cancel cancels the step on a provisioned cluster, leaving the cluster
+// running, or terminates a transient cluster.
+func (b *EMRBackend) Cancel(ctx context.Context, runID string) error {
+	if cluster, step, ok := strings.Cut(runID, "/"); ok {
+		_, err := b.client.CancelSteps(ctx, &emr.CancelStepsInput{ClusterId: awssdk.String(cluster), StepIds: []string{step}})
+		if err != nil {
+			return fmt.Errorf("cancelling EMR step: %w", err)
+		}
+		return nil
+	}
+	_, err := b.client.TerminateJobFlows(ctx, &emr.TerminateJobFlowsInput{JobFlowIds: []string{runID}})
+	if err != nil {
+		return fmt.Errorf("terminating EMR cluster: %w", err)
+	}
+	return nil
+}
+
+// sparkSubmitArgs builds the spark-submit command for an EMR step, with the
+// job's jars read from S3.
+func sparkSubmitArgs(job Job) []string {
+	args := []string{"spark-submit", "--deploy-mode", "cluster"}
+	if jars := job.jars(); len(jars) > 0 {
+		args = append(args, "--jars", strings.Join(jars, ","))
+	}
+	return append(args, job.ScriptS3URI)
+}
+
+func mapEMRStepState(state types.StepState) string {
+	switch state {
+	case types.StepStatePending, types.StepStateCancelPending:
+		return StatePending
+	case types.StepStateRunning:
+		return StateRunning
+	case types.StepStateCompleted:
+		return StateSucceeded
+	case types.StepStateCancelled:
+		return StateCancelled
+	case types.StepStateFailed, types.StepStateInterrupted:
+		return StateFailed
+	default:
+		return string(state)
+	}
+}
@@ -0,0 +1,149 @@
+package spark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+
+	"github.com/reloquent/reloquent/internal/aws"
+)
+
+// DefaultGlueRole is the IAM role Glue jobs run as unless overridden.
+const DefaultGlueRole = aws.GlueJobRole
+
+// GlueBackend runs the job as an AWS Glue ETL job, creating or updating the
+// job definition before each run.
+type GlueBackend struct {
+	client  *glue.Client
+	role    string
+	jobName string
+}
+
+// NewGlueBackend creates a Glue backend with the given profile and region.
+func NewGlueBackend(ctx context.Context, profile, region, role string) (*GlueBackend, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(profile))
+	}
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+	if role == "" {
+		role = DefaultGlueRole
+	}
+
+	return &GlueBackend{client: glue.NewFromConfig(cfg), role: role}, nil
+}
+
+// Submit creates (or updates) the Glue job and starts a run.
+func (b *GlueBackend) Submit(ctx context.Context, job Job) (string, error) {
+	update := &gluetypes.JobUpdate{
+		Role: awssdk.String(b.role),
+		Command: &gluetypes.JobCommand{
+			Name:           awssdk.String("glueetl"),
+			ScriptLocation: awssdk.String(job.ScriptS3URI),
+			PythonVersion:  awssdk.String("3"),
+		},
+		GlueVersion:      awssdk.String("4.0"),
+		NumberOfWorkers:  awssdk.Int32(int32(job.SparkPlan.DPUCount)),
+		WorkerType:       gluetypes.WorkerTypeG2x,
+		DefaultArguments: glueArguments(job),
+	}
+
+	tags := map[string]string{"reloquent": "migration"}
+	for k, v := range job.Tags {
+		tags[k] = v
+	}
+
+	_, err := b.client.CreateJob(ctx, &glue.CreateJobInput{
+		Name:             awssdk.String(job.Name),
+		Role:             update.Role,
+		Command:          update.Command,
+		GlueVersion:      update.GlueVersion,
+		NumberOfWorkers:  update.NumberOfWorkers,
+		WorkerType:       update.WorkerType,
+		DefaultArguments: update.DefaultArguments,
+		Tags:             tags,
+	})
+	var exists *gluetypes.AlreadyExistsException
+	if errors.As(err, &exists) {
+		_, err = b.client.UpdateJob(ctx, &glue.UpdateJobInput{JobName: awssdk.String(job.Name), JobUpdate: update})
+	}
+	if err != nil {
+		return "", fmt.Errorf("creating Glue job: %w", err)
+	}
+
+	out, err := b.client.StartJobRun(ctx, &glue.StartJobRunInput{JobName: awssdk.String(job.Name)})
+	if err != nil {
+		return "", fmt.Errorf("starting Glue job run: %w", err)
+	}
+	b.jobName = job.Name
+	return awssdk.ToString(out.JobRunId), nil
+}
+
+// JobState reports the state of a job run.
+func (b *GlueBackend) JobState(ctx context.Context, runID string) (*JobState, error) {
+	out, err := b.client.GetJobRun(ctx, &glue.GetJobRunInput{
+		JobName: awssdk.String(b.jobName),
+		RunId:   awssdk.String(runID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Glue job run: %w", err)
+	}
+	return &JobState{
+		State:   mapGlueRunState(out.JobRun.JobRunState),
+		Message: awssdk.ToString(out.JobRun.ErrorMessage),
+	}, nil
+}
+
+// Cancel stops the job run.
+func (b *GlueBackend) Cancel(ctx context.Context, runID string) error {
+	_, err := b.client.BatchStopJobRun(ctx, &glue.BatchStopJobRunInput{
+		JobName:   awssdk.String(b.jobName),
+		JobRunIds: []string{runID},
+	})
+	if err != nil {
+		return fmt.Errorf("stopping Glue job run: %w", err)
+	}
+	return nil
+}
+
+// glueArguments builds the default job arguments.
+func glueArguments(job Job) map[string]string {
+	args := map[string]string{
+		"--enable-metrics":                   "true",
+		"--enable-continuous-cloudwatch-log": "true",
+	}
+	if jars := job.jars(); len(jars) > 0 {
+		args["--extra-jars"] = strings.Join(jars, ",")
+	}
+	return args
+}
+
+func mapGlueRunState(state gluetypes.JobRunState) string {
+	switch state {
+	case gluetypes.JobRunStateStarting, gluetypes.JobRunStateWaiting:
+		return StatePending
+	case gluetypes.JobRunStateRunning:
+		return StateRunning
+	case gluetypes.JobRunStateSucceeded:
+		return StateSucceeded
+	case gluetypes.JobRunStateStopping, gluetypes.JobRunStateStopped:
+		return StateCancelled
+	case gluetypes.JobRunStateFailed, gluetypes.JobRunStateError, gluetypes.JobRunStateTimeout, gluetypes.JobRunStateExpired:
+		return StateFailed
+	default:
+		return string(state)
+	}
+}
@@ -0,0 +1,67 @@
+package spark
+
+import (
+	"context"
+	"sync"
+)
+
+// MockBackend is a test double for the Backend interface. Each JobState call
+// returns the next entry of States, repeating the last one.
+type MockBackend struct {
+	RunID     string
+	SubmitErr error
+	States    []JobState
+	StateErr  error
+	CancelErr error
+
+	mu        sync.Mutex
+	submitted []Job
+	polls     int
+	cancelled []string
+}
+
+func (m *MockBackend) Submit(_ context.Context, job Job) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.submitted = append(m.submitted, job)
+	return m.RunID, m.SubmitErr
+}
+
+func (m *MockBackend) JobState(_ context.Context, _ string) (*JobState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.StateErr != nil {
+		return nil, m.StateErr
+	}
+	if len(m.States) == 0 {
+		return &JobState{State: StatePending}, nil
+	}
+	i := m.polls
+	if i >= len(m.States) {
+		i = len(m.States) - 1
+	}
+	m.polls++
+	st := m.States[i]
+	return &st, nil
+}
+
+func (m *MockBackend) Cancel(_ context.Context, runID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cancelled = append(m.cancelled, runID)
+	return m.CancelErr
+}
+
+// Submitted returns the jobs passed to Submit.
+func (m *MockBackend) Submitted() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Job(nil), m.submitted...)
+}
+
+// Cancelled returns the run IDs passed to Cancel.
+func (m *MockBackend) Cancelled() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.cancelled...)
+}
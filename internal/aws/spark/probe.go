@@ -0,0 +1,141 @@
+package spark
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emr"
+	"github.com/aws/aws-sdk-go-v2/service/emr/types"
+
+	"github.com/reloquent/reloquent/internal/aws"
+)
+
+// probeInstanceType is the single node of a network probe cluster.
+const probeInstanceType = "m5.xlarge"
+
+// probePollInterval is how often ProbeNetwork checks its steps.
+var probePollInterval = defaultPollInterval
+
+// probeHost matches the host names and addresses a probe step may put in
+// its shell command.
+var probeHost = regexp.MustCompile(`^[A-Za-z0-9.:_-]+$`)
+
+// Reachability is whether an endpoint answered from a subnet.
+type Reachability struct {
+	Subnet    string `json:"subnet,omitempty"` // empty for the account's default subnet
+	Endpoint  string `json:"endpoint"`
+	Reachable bool   `json:"reachable"`
+	Message   string `json:"message,omitempty"`
+}
+
+// ProbeNetwork starts a one-node cluster in each of the backend's subnets,
+// or in the default one, whose steps open a TCP connection to each
+// host:port endpoint, and reports which endpoints answered. The clusters
+// terminate when their steps finish; this takes several minutes.
+func (b *EMRBackend) ProbeNetwork(ctx context.Context, endpoints []string) ([]Reachability, error) {
+	var steps []types.StepConfig
+	for _, ep := range endpoints {
+		host, port, err := net.SplitHostPort(ep)
+		if err != nil || !probeHost.MatchString(host) || !probeHost.MatchString(port) {
+			return nil, fmt.Errorf("invalid endpoint %q; use host:port", ep)
+		}
+		steps = append(steps, types.StepConfig{
+			Name:            awssdk.String(ep),
+			ActionOnFailure: types.ActionOnFailureContinue,
+			HadoopJarStep: &types.HadoopJarStepConfig{
+				Jar:  awssdk.String("command-runner.jar"),
+				Args: []string{"bash", "-c", fmt.Sprintf("timeout 10 bash -c '</dev/tcp/%s/%s'", host, port)},
+			},
+		})
+	}
+	subnets := b.SubnetIDs
+	if len(subnets) == 0 {
+		subnets = []string{""}
+	}
+
+	var results []Reachability
+	for _, subnet := range subnets {
+		rs, err := b.probeSubnet(ctx, subnet, steps)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rs...)
+	}
+	return results, nil
+}
+
+func (b *EMRBackend) probeSubnet(ctx context.Context, subnet string, steps []types.StepConfig) ([]Reachability, error) {
+	instances := &types.JobFlowInstancesConfig{
+		KeepJobFlowAliveWhenNoSteps: awssdk.Bool(false),
+		InstanceGroups: []types.InstanceGroupConfig{{
+			InstanceRole:  types.InstanceRoleTypeMaster,
+			InstanceType:  awssdk.String(probeInstanceType),
+			InstanceCount: awssdk.Int32(1),
+		}},
+	}
+	if subnet != "" {
+		instances.Ec2SubnetId = awssdk.String(subnet)
+	}
+	out, err := b.client.RunJobFlow(ctx, &emr.RunJobFlowInput{
+		Name:              awssdk.String("reloquent-network-probe"),
+		ReleaseLabel:      awssdk.String(emrRelease),
+		Tags:              []types.Tag{{Key: awssdk.String("reloquent"), Value: awssdk.String("doctor")}},
+		JobFlowRole:       awssdk.String(aws.EMRInstanceRole),
+		ServiceRole:       awssdk.String(aws.EMRServiceRole),
+		VisibleToAllUsers: awssdk.Bool(true),
+		Instances:         instances,
+		Steps:             steps,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating EMR probe cluster: %w", err)
+	}
+	clusterID := awssdk.ToString(out.JobFlowId)
+	defer b.Cancel(context.Background(), clusterID)
+
+	for {
+		listed, err := b.client.ListSteps(ctx, &emr.ListStepsInput{ClusterId: awssdk.String(clusterID)})
+		if err != nil {
+			return nil, fmt.Errorf("listing EMR probe steps: %w", err)
+		}
+		if rs, done := probeResults(subnet, listed.Steps, len(steps)); done {
+			return rs, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(probePollInterval):
+		}
+	}
+}
+
+// probeResults turns the probe steps into results once all have finished.
+func probeResults(subnet string, steps []types.StepSummary, want int) ([]Reachability, bool) {
+	if len(steps) < want {
+		return nil, false
+	}
+	var results []Reachability
+	// ListSteps returns the most recent step first
+	for i := len(steps) - 1; i >= 0; i-- {
+		s := steps[i]
+		if s.Status == nil {
+			return nil, false
+		}
+		r := Reachability{Subnet: subnet, Endpoint: awssdk.ToString(s.Name)}
+		switch mapEMRStepState(s.Status.State) {
+		case StateSucceeded:
+			r.Reachable = true
+		case StateFailed:
+			r.Message = "no TCP connection within 10s"
+		case StateCancelled:
+			r.Message = "probe step cancelled before it ran"
+		default:
+			return nil, false
+		}
+		results = append(results, r)
+	}
+	return results, true
+}
@@ -0,0 +1,284 @@
+// Package spark runs the generated PySpark migration on a managed AWS Spark
+// service and reports its progress as migration statuses.
+package spark
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/aws"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/sizing"
+)
+
+// Job run states reported by a Backend.
+const (
+	StatePending   = "PENDING"
+	StateRunning   = "RUNNING"
+	StateSucceeded = "SUCCEEDED"
+	StateFailed    = "FAILED"
+	StateCancelled = "CANCELLED"
+)
+
+const defaultPollInterval = 15 * time.Second
+
+// Backend submits and tracks a PySpark job on a Spark service.
+type Backend interface {
+	// Submit starts the job and returns an identifier for the run.
+	Submit(ctx context.Context, job Job) (string, error)
+	// JobState reports the current state of a run.
+	JobState(ctx context.Context, runID string) (*JobState, error)
+	// Cancel stops a run and releases its resources.
+	Cancel(ctx context.Context, runID string) error
+}
+
+// Job describes a migration job to run.
+type Job struct {
+	Name        string
+	Artifacts   aws.ArtifactSet
+	SparkPlan   sizing.SparkPlan
+	Tags        map[string]string
+	Collections []CollectionTarget
+
+	// Filled in by the runner after the artifacts are uploaded.
+	ScriptS3URI string
+	ConfigS3URI string
+	JDBCS3URI   string
+	JarS3URIs   []string
+}
+
+// jars returns the S3 URIs of every jar the job needs on its classpath.
+func (j Job) jars() []string {
+	jars := append([]string(nil), j.JarS3URIs...)
+	if j.JDBCS3URI != "" {
+		jars = append(jars, j.JDBCS3URI)
+	}
+	return jars
+}
+
+// Jars the generated script needs on the Spark classpath: the MongoDB Spark
+// Connector it writes with, with the driver it depends on, and the
+// PostgreSQL JDBC driver. They are staged in S3 with the script, since jobs
+// in a VPC usually cannot reach Maven Central. The Oracle driver cannot be
+// fetched from Maven Central, so it is uploaded from ~/.reloquent/drivers/.
+var (
+	MongoConnectorJars = []string{
+		"org.mongodb.spark:mongo-spark-connector_2.12:10.4.0",
+		"org.mongodb:mongodb-driver-sync:5.1.4",
+		"org.mongodb:mongodb-driver-core:5.1.4",
+		"org.mongodb:bson:5.1.4",
+		"org.mongodb:bson-record-codec:5.1.4",
+	}
+	PostgresJDBCJar = "org.postgresql:postgresql:42.7.4"
+)
+
+// Jars returns the Maven coordinates of the jars a job reading a source of
+// the given type needs.
+func Jars(sourceType string) []string {
+	jars := append([]string(nil), MongoConnectorJars...)
+	if sourceType == "postgresql" {
+		jars = append(jars, PostgresJDBCJar)
+	}
+	return jars
+}
+
+// CollectionTarget is a collection the job writes and its expected size.
+type CollectionTarget struct {
+	Name         string
+	ExpectedDocs int64
+}
+
+// JobState is the service-side state of a run.
+type JobState struct {
+	State   string
+	Message string
+}
+
+// ProgressSource counts documents already written to the target.
+type ProgressSource interface {
+	CountDocuments(ctx context.Context, collection string) (int64, error)
+}
+
+// Runner uploads the migration artifacts, submits the job and polls it
+// until it finishes.
+type Runner struct {
+	uploader     *aws.ArtifactUploader
+	backend      Backend
+	progress     ProgressSource
+	pollInterval time.Duration
+}
+
+// NewRunner creates a runner that uploads with uploader and runs on backend.
+func NewRunner(uploader *aws.ArtifactUploader, backend Backend) *Runner {
+	return &Runner{
+		uploader:     uploader,
+		backend:      backend,
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// SetPollInterval changes how often the job state is polled.
+func (r *Runner) SetPollInterval(d time.Duration) {
+	r.pollInterval = d
+}
+
+// SetProgressSource enables per-collection progress from target document
+// counts while the job runs.
+func (r *Runner) SetProgressSource(p ProgressSource) {
+	r.progress = p
+}
+
+// Run executes the job and returns its final status. Cancelling ctx cancels
+// the run on the service.
+func (r *Runner) Run(ctx context.Context, job Job, callback migration.StatusCallback) (*migration.Status, error) {
+	start := time.Now()
+	status := &migration.Status{Phase: "uploading"}
+	for _, c := range job.Collections {
+		status.Collections = append(status.Collections, migration.CollectionStatus{
+			Name:      c.Name,
+			State:     "pending",
+			DocsTotal: c.ExpectedDocs,
+		})
+		status.Overall.DocsTotal += c.ExpectedDocs
+	}
+	notify(callback, status)
+
+	uploaded, err := r.uploader.UploadArtifacts(ctx, job.Artifacts)
+	if err != nil {
+		return fail(callback, status, fmt.Errorf("uploading artifacts: %w", err))
+	}
+	job.ScriptS3URI = uploaded.ScriptS3URI
+	job.ConfigS3URI = uploaded.ConfigS3URI
+	job.JDBCS3URI = uploaded.JDBCS3URI
+	job.JarS3URIs = uploaded.JarS3URIs
+
+	status.Phase = "submitting"
+	notify(callback, status)
+
+	runID, err := r.backend.Submit(ctx, job)
+	if err != nil {
+		return fail(callback, status, fmt.Errorf("submitting job: %w", err))
+	}
+
+	status.Phase = "starting"
+	notify(callback, status)
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		st, err := r.backend.JobState(ctx, runID)
+		if err != nil && ctx.Err() == nil {
+			return fail(callback, status, fmt.Errorf("polling job: %w", err))
+		}
+		status.ElapsedTime = time.Since(start)
+
+		if err == nil {
+			switch st.State {
+			case StatePending:
+				status.Phase = "starting"
+			case StateRunning:
+				status.Phase = "running"
+				r.updateProgress(ctx, status, false)
+			case StateSucceeded:
+				status.Phase = "completed"
+				r.updateProgress(ctx, status, true)
+				status.EstimatedRemain = 0
+				notify(callback, status)
+				return status, nil
+			case StateFailed, StateCancelled:
+				msg := st.Message
+				if msg == "" {
+					msg = "job " + st.State
+				}
+				r.updateProgress(ctx, status, false)
+				for i := range status.Collections {
+					if status.Collections[i].State != "completed" {
+						status.Collections[i].State = "failed"
+					}
+				}
+				return fail(callback, status, fmt.Errorf("spark job %s: %s", runID, msg))
+			}
+			notify(callback, status)
+		}
+
+		select {
+		case <-ctx.Done():
+			cancelCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			if err := r.backend.Cancel(cancelCtx, runID); err != nil {
+				status.Errors = append(status.Errors, fmt.Sprintf("cancelling job: %v", err))
+			}
+			status.Phase = "aborted"
+			notify(callback, status)
+			return status, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateProgress refreshes document counts from the target. While the job
+// runs no collection is reported as fully complete, since Spark may still be
+// writing its final partitions.
+func (r *Runner) updateProgress(ctx context.Context, status *migration.Status, done bool) {
+	var written int64
+	for i := range status.Collections {
+		c := &status.Collections[i]
+		if r.progress != nil {
+			if n, err := r.progress.CountDocuments(ctx, c.Name); err == nil {
+				c.DocsWritten = n
+			}
+		}
+		switch {
+		case done:
+			c.State = "completed"
+			c.PercentComplete = 100
+		case c.DocsWritten > 0:
+			c.State = "running"
+			c.PercentComplete = percent(c.DocsWritten, c.DocsTotal)
+		}
+		written += c.DocsWritten
+	}
+
+	status.Overall.DocsWritten = written
+	if done {
+		status.Overall.PercentComplete = 100
+		return
+	}
+	status.Overall.PercentComplete = percent(written, status.Overall.DocsTotal)
+	if p := status.Overall.PercentComplete; p > 0 {
+		status.EstimatedRemain = time.Duration(float64(status.ElapsedTime) * (100 - p) / p)
+	}
+}
+
+// percent returns written/total as a percentage, capped below 100 so an
+// underestimated total does not report a running job as finished.
+func percent(written, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	p := float64(written) / float64(total) * 100
+	if p > 99 {
+		p = 99
+	}
+	return p
+}
+
+func fail(callback migration.StatusCallback, status *migration.Status, err error) (*migration.Status, error) {
+	status.Phase = "failed"
+	status.Errors = append(status.Errors, err.Error())
+	notify(callback, status)
+	return status, err
+}
+
+// notify passes a copy of status so callers may keep it while the runner
+// continues to update its own.
+func notify(callback migration.StatusCallback, status *migration.Status) {
+	if callback == nil {
+		return
+	}
+	s := *status
+	s.Collections = append([]migration.CollectionStatus(nil), status.Collections...)
+	s.Errors = append([]string(nil), status.Errors...)
+	callback(&s)
+}
@@ -0,0 +1,356 @@
+package spark
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	emrtypes "github.com/aws/aws-sdk-go-v2/service/emr/types"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+
+	"github.com/reloquent/reloquent/internal/aws"
+	"github.com/reloquent/reloquent/internal/migration"
+)
+
+type countSource struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (c *countSource) CountDocuments(_ context.Context, collection string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[collection], nil
+}
+
+func testJob() Job {
+	return Job{
+		Name:      "reloquent-migration",
+		Artifacts: aws.ArtifactSet{MigrationScript: []byte("print('hi')"), ConfigYAML: []byte("collections: []")},
+		Collections: []CollectionTarget{
+			{Name: "customers", ExpectedDocs: 100},
+			{Name: "orders", ExpectedDocs: 300},
+		},
+	}
+}
+
+func testRunner(backend Backend) (*Runner, *aws.MockClient) {
+	client := aws.NewMockClient()
+	r := NewRunner(aws.NewArtifactUploader(client, "bucket", "runs/1"), backend)
+	r.SetPollInterval(time.Millisecond)
+	return r, client
+}
+
+func TestRunner_Succeeds(t *testing.T) {
+	backend := &MockBackend{RunID: "run-1", States: []JobState{
+		{State: StatePending},
+		{State: StateRunning},
+		{State: StateSucceeded},
+	}}
+	r, client := testRunner(backend)
+	r.SetProgressSource(&countSource{counts: map[string]int64{"customers": 100, "orders": 150}})
+
+	var phases []string
+	var running *migration.Status
+	status, err := r.Run(context.Background(), testJob(), func(s *migration.Status) {
+		if len(phases) == 0 || phases[len(phases)-1] != s.Phase {
+			phases = append(phases, s.Phase)
+		}
+		if s.Phase == "running" {
+			running = s
+		}
+	})
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	want := []string{"uploading", "submitting", "starting", "running", "completed"}
+	if strings.Join(phases, ",") != strings.Join(want, ",") {
+		t.Errorf("phases = %v, want %v", phases, want)
+	}
+	if _, ok := client.UploadedObjects["bucket/runs/1/migration.py"]; !ok {
+		t.Error("expected script upload")
+	}
+	jobs := backend.Submitted()
+	if len(jobs) != 1 || jobs[0].ScriptS3URI != "s3://bucket/runs/1/migration.py" {
+		t.Errorf("submitted = %+v", jobs)
+	}
+	if running == nil || running.Overall.DocsWritten != 250 || running.Overall.PercentComplete != 62.5 {
+		t.Errorf("running status = %+v", running)
+	}
+	if running != nil && running.Collections[0].PercentComplete != 99 {
+		t.Errorf("running collection should cap below 100, got %v", running.Collections[0].PercentComplete)
+	}
+	if status.Phase != "completed" || status.Overall.PercentComplete != 100 {
+		t.Errorf("final status = %+v", status)
+	}
+	for _, c := range status.Collections {
+		if c.State != "completed" {
+			t.Errorf("collection %s state = %s", c.Name, c.State)
+		}
+	}
+}
+
+func TestRunner_JobFails(t *testing.T) {
+	backend := &MockBackend{RunID: "run-1", States: []JobState{
+		{State: StateRunning},
+		{State: StateFailed, Message: "OutOfMemoryError"},
+	}}
+	r, _ := testRunner(backend)
+
+	status, err := r.Run(context.Background(), testJob(), nil)
+	if err == nil || !strings.Contains(err.Error(), "OutOfMemoryError") {
+		t.Fatalf("error = %v", err)
+	}
+	if status.Phase != "failed" || len(status.Errors) == 0 {
+		t.Errorf("status = %+v", status)
+	}
+	if status.Collections[0].State != "failed" {
+		t.Errorf("collection state = %s, want failed", status.Collections[0].State)
+	}
+}
+
+func TestRunner_SetupErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend *MockBackend
+		upload  error
+		wantErr string
+	}{
+		{"upload", &MockBackend{}, errors.New("access denied"), "uploading artifacts"},
+		{"submit", &MockBackend{SubmitErr: errors.New("quota")}, nil, "submitting job"},
+		{"poll", &MockBackend{StateErr: errors.New("throttled")}, nil, "polling job"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, client := testRunner(tt.backend)
+			client.UploadErr = tt.upload
+			status, err := r.Run(context.Background(), testJob(), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if status.Phase != "failed" {
+				t.Errorf("phase = %s, want failed", status.Phase)
+			}
+		})
+	}
+}
+
+func TestRunner_CancelStopsJob(t *testing.T) {
+	backend := &MockBackend{RunID: "run-9", States: []JobState{{State: StateRunning}}}
+	r, _ := testRunner(backend)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	status, err := r.Run(ctx, testJob(), func(s *migration.Status) {
+		if s.Phase == "running" {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if status.Phase != "aborted" {
+		t.Errorf("phase = %s, want aborted", status.Phase)
+	}
+	if got := backend.Cancelled(); len(got) != 1 || got[0] != "run-9" {
+		t.Errorf("cancelled = %v", got)
+	}
+}
+
+func TestMapEMRStepState(t *testing.T) {
+	tests := []struct {
+		in   emrtypes.StepState
+		want string
+	}{
+		{emrtypes.StepStatePending, StatePending},
+		{emrtypes.StepStateRunning, StateRunning},
+		{emrtypes.StepStateCompleted, StateSucceeded},
+		{emrtypes.StepStateCancelled, StateCancelled},
+		{emrtypes.StepStateFailed, StateFailed},
+		{emrtypes.StepStateInterrupted, StateFailed},
+	}
+	for _, tt := range tests {
+		if got := mapEMRStepState(tt.in); got != tt.want {
+			t.Errorf("mapEMRStepState(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMapGlueRunState(t *testing.T) {
+	tests := []struct {
+		in   gluetypes.JobRunState
+		want string
+	}{
+		{gluetypes.JobRunStateStarting, StatePending},
+		{gluetypes.JobRunStateRunning, StateRunning},
+		{gluetypes.JobRunStateSucceeded, StateSucceeded},
+		{gluetypes.JobRunStateStopped, StateCancelled},
+		{gluetypes.JobRunStateTimeout, StateFailed},
+		{gluetypes.JobRunStateError, StateFailed},
+	}
+	for _, tt := range tests {
+		if got := mapGlueRunState(tt.in); got != tt.want {
+			t.Errorf("mapGlueRunState(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestJars(t *testing.T) {
+	if got := Jars("oracle"); !reflect.DeepEqual(got, MongoConnectorJars) {
+		t.Errorf("Jars(oracle) = %v", got)
+	}
+	if got := Jars("postgresql"); len(got) != len(MongoConnectorJars)+1 || got[len(got)-1] != PostgresJDBCJar {
+		t.Errorf("Jars(postgresql) = %v", got)
+	}
+}
+
+func TestSparkSubmitArgs(t *testing.T) {
+	job := Job{ScriptS3URI: "s3://b/migration.py", JDBCS3URI: "s3://b/ojdbc11.jar", JarS3URIs: []string{"s3://b/jars/connector.jar"}}
+	got := strings.Join(sparkSubmitArgs(job), " ")
+	want := "spark-submit --deploy-mode cluster --jars s3://b/jars/connector.jar,s3://b/ojdbc11.jar s3://b/migration.py"
+	if got != want {
+		t.Errorf("sparkSubmitArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestGlueArguments(t *testing.T) {
+	args := glueArguments(Job{JarS3URIs: []string{"s3://b/jars/connector.jar", "s3://b/jars/postgresql.jar"}})
+	if want := "s3://b/jars/connector.jar,s3://b/jars/postgresql.jar"; args["--extra-jars"] != want {
+		t.Errorf("--extra-jars = %q, want %q", args["--extra-jars"], want)
+	}
+	if _, ok := args["--conf"]; ok {
+		t.Error("jars must not be fetched from Maven at run time")
+	}
+	if _, ok := glueArguments(Job{})["--extra-jars"]; ok {
+		t.Error("no jars were uploaded")
+	}
+	if args := glueArguments(Job{JDBCS3URI: "s3://b/ojdbc11.jar"}); args["--extra-jars"] != "s3://b/ojdbc11.jar" {
+		t.Errorf("--extra-jars = %q", args["--extra-jars"])
+	}
+}
+
+func TestEMRServerlessBackend(t *testing.T) {
+	var started map[string]interface{}
+	var cancelled bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "/emr-serverless/aws4_request") {
+			t.Errorf("request not signed for emr-serverless: %q", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/applications/app-1/jobruns":
+			json.NewDecoder(r.Body).Decode(&started)
+			w.Write([]byte(`{"applicationId":"app-1","jobRunId":"run-1"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/applications/app-1/jobruns/run-1":
+			w.Write([]byte(`{"jobRun":{"state":"FAILED","stateDetails":"out of memory"}}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/applications/app-1/jobruns/run-1":
+			cancelled = true
+			w.Write([]byte(`{"applicationId":"app-1","jobRunId":"run-1"}`))
+		default:
+			w.Header().Set("X-Amzn-ErrorType", "ResourceNotFoundException:http://internal.amazon.com/coral/")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Job run not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	b := &EMRServerlessBackend{
+		cfg: awssdk.Config{Region: "us-east-1", Credentials: awssdk.CredentialsProviderFunc(func(context.Context) (awssdk.Credentials, error) {
+			return awssdk.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+		})},
+		client:           srv.Client(),
+		endpoint:         srv.URL,
+		ApplicationID:    "app-1",
+		ExecutionRoleARN: "arn:aws:iam::123456789012:role/migration",
+	}
+	job := testJob()
+	job.ScriptS3URI = "s3://b/migration.py"
+	job.JarS3URIs = []string{"s3://b/jars/connector.jar"}
+	job.SparkPlan.WorkerCount = 4
+
+	runID, err := b.Submit(context.Background(), job)
+	if err != nil || runID != "run-1" {
+		t.Fatalf("Submit = %q, %v", runID, err)
+	}
+	if started["executionRoleArn"] != b.ExecutionRoleARN || started["clientToken"] == "" {
+		t.Errorf("start request = %v", started)
+	}
+	submit := started["jobDriver"].(map[string]interface{})["sparkSubmit"].(map[string]interface{})
+	if submit["entryPoint"] != "s3://b/migration.py" ||
+		submit["sparkSubmitParameters"] != "--jars s3://b/jars/connector.jar --conf spark.executor.instances=4" {
+		t.Errorf("sparkSubmit = %v", submit)
+	}
+
+	st, err := b.JobState(context.Background(), runID)
+	if err != nil || st.State != StateFailed || st.Message != "out of memory" {
+		t.Errorf("JobState = %+v, %v", st, err)
+	}
+	if err := b.Cancel(context.Background(), runID); err != nil || !cancelled {
+		t.Errorf("Cancel = %v, cancelled %v", err, cancelled)
+	}
+	if _, err := b.JobState(context.Background(), "missing"); err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException: Job run not found") {
+		t.Errorf("JobState(missing) error = %v", err)
+	}
+}
+
+func TestMapServerlessRunState(t *testing.T) {
+	tests := map[string]string{
+		"SUBMITTED": StatePending,
+		"SCHEDULED": StatePending,
+		"RUNNING":   StateRunning,
+		"SUCCESS":   StateSucceeded,
+		"CANCELLED": StateCancelled,
+		"FAILED":    StateFailed,
+	}
+	for in, want := range tests {
+		if got := mapServerlessRunState(in); got != want {
+			t.Errorf("mapServerlessRunState(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestProbeResults(t *testing.T) {
+	step := func(name string, state emrtypes.StepState) emrtypes.StepSummary {
+		return emrtypes.StepSummary{Name: awssdk.String(name), Status: &emrtypes.StepStatus{State: state}}
+	}
+
+	// still running
+	if _, done := probeResults("subnet-1", []emrtypes.StepSummary{
+		step("mongo:27017", emrtypes.StepStateRunning), step("db:5432", emrtypes.StepStateCompleted),
+	}, 2); done {
+		t.Error("done while a step is running")
+	}
+	// not all steps listed yet
+	if _, done := probeResults("subnet-1", []emrtypes.StepSummary{step("db:5432", emrtypes.StepStateCompleted)}, 2); done {
+		t.Error("done before every step was listed")
+	}
+
+	results, done := probeResults("subnet-1", []emrtypes.StepSummary{
+		step("mongo:27017", emrtypes.StepStateFailed), step("db:5432", emrtypes.StepStateCompleted),
+	}, 2)
+	if !done || len(results) != 2 {
+		t.Fatalf("results = %+v, done = %v", results, done)
+	}
+	if r := results[0]; r.Endpoint != "db:5432" || !r.Reachable || r.Subnet != "subnet-1" {
+		t.Errorf("first = %+v", r)
+	}
+	if r := results[1]; r.Endpoint != "mongo:27017" || r.Reachable || r.Message == "" {
+		t.Errorf("second = %+v", r)
+	}
+}
+
+func TestProbeNetwork_InvalidEndpoint(t *testing.T) {
+	b := &EMRBackend{}
+	for _, ep := range []string{"db", "db:5432;rm -rf /", "$(id):1"} {
+		if _, err := b.ProbeNetwork(context.Background(), []string{ep}); err == nil {
+			t.Errorf("ProbeNetwork(%q) succeeded", ep)
+		}
+	}
+}
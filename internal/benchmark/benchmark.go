@@ -2,25 +2,146 @@ package benchmark
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
+// Default guard rails, applied when the profile leaves them unset. Rows are
+// not limited by default; the byte and time limits bound the read instead.
+const (
+	DefaultMaxDuration = 5 * time.Minute
+	DefaultMaxBytes    = 1 << 30 // 1 GB
+)
+
+// Reasons a sample read stopped before the sample was exhausted.
+const (
+	StoppedByMaxDuration = "max_duration"
+	StoppedByMaxRows     = "max_rows"
+	StoppedByMaxBytes    = "max_bytes"
+)
+
+// ErrOutsideOffPeak is returned when an off-peak window is configured and
+// the benchmark is started outside it.
+var ErrOutsideOffPeak = errors.New("benchmark is restricted to the off-peak window")
+
 // SourceReader reads sample data from a source database for benchmarking.
 type SourceReader interface {
-	ReadSample(ctx context.Context, tableName, partitionCol string, samplePct float64) (bytesRead int64, elapsed time.Duration, err error)
+	// SampleQuery returns the statement ReadSample runs, for dry runs.
+	SampleQuery(tableName string, samplePct float64, guard Guard) string
+	ReadSample(ctx context.Context, tableName, partitionCol string, samplePct float64, guard Guard) (Sample, error)
+}
+
+// Sample is what a reader measured. StoppedBy is set when a guard limit
+// ended the read early; the throughput is still valid.
+type Sample struct {
+	BytesRead int64
+	RowsRead  int64
+	Elapsed   time.Duration
+	StoppedBy string
 }
 
+// Guard limits how much a benchmark may read from a production source.
+type Guard struct {
+	MaxDuration time.Duration
+	MaxRows     int64
+	MaxBytes    int64
+	OffPeak     *Window // nil allows any time
+}
+
+// Window is a daily time window in local time. End before Start wraps past
+// midnight, e.g. 22:00-06:00.
+type Window struct {
+	Start time.Duration // offset from midnight
+	End   time.Duration
+}
+
+// ParseWindow parses a window written as HH:MM-HH:MM.
+func ParseWindow(s string) (*Window, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid window %q: expected HH:MM-HH:MM", s)
+	}
+	start, err := parseClock(strings.TrimSpace(from))
+	if err != nil {
+		return nil, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	end, err := parseClock(strings.TrimSpace(to))
+	if err != nil {
+		return nil, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	return &Window{Start: start, End: end}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether t falls inside the window.
+func (w Window) Contains(t time.Time) bool {
+	d := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if w.Start <= w.End {
+		return d >= w.Start && d < w.End
+	}
+	return d >= w.Start || d < w.End
+}
+
+func (w Window) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return clock(w.Start) + "-" + clock(w.End)
+}
+
+// withDefaults fills in the default duration and byte limits.
+func (g Guard) withDefaults() Guard {
+	if g.MaxDuration == 0 {
+		g.MaxDuration = DefaultMaxDuration
+	}
+	if g.MaxBytes == 0 {
+		g.MaxBytes = DefaultMaxBytes
+	}
+	return g
+}
+
+// String summarizes the limits for display.
+func (g Guard) String() string {
+	parts := []string{"max " + formatDuration(g.MaxDuration)}
+	if g.MaxRows > 0 {
+		parts = append(parts, fmt.Sprintf("max %d rows", g.MaxRows))
+	}
+	if g.MaxBytes > 0 {
+		parts = append(parts, "max "+formatBytes(g.MaxBytes))
+	}
+	if g.OffPeak != nil {
+		parts = append(parts, "off-peak "+g.OffPeak.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// now is the clock used for the off-peak check. Tests replace it.
+var now = time.Now
+
 // Result holds the output of a benchmark run.
 type Result struct {
 	TableName             string        `yaml:"table_name"`
 	BytesRead             int64         `yaml:"bytes_read"`
+	RowsRead              int64         `yaml:"rows_read"`
 	Elapsed               time.Duration `yaml:"elapsed"`
 	ThroughputMBps        float64       `yaml:"throughput_mbps"`
 	Connections           int           `yaml:"connections"`
 	EstimatedFullReadTime time.Duration `yaml:"estimated_full_read_time"`
 	OneHourAchievable     bool          `yaml:"one_hour_achievable"`
 	Explanation           string        `yaml:"explanation"`
+	StoppedBy             string        `yaml:"stopped_by,omitempty"`
+	Guard                 string        `yaml:"guard"`
+	DryRun                bool          `yaml:"dry_run,omitempty"`
+	Queries               []string      `yaml:"queries"`
 }
 
 // BenchmarkInput defines parameters for a benchmark run.
@@ -30,9 +151,12 @@ type BenchmarkInput struct {
 	TotalDataBytes int64
 	MaxConnections int
 	SamplePercent  float64 // default 1.0%
+	Guard          Guard
+	DryRun         bool // return the queries without running them
 }
 
-// Run executes a benchmark against the given source database.
+// Run executes a benchmark against the given source database, within the
+// input's guard rails. A dry run only reports the queries it would execute.
 func Run(ctx context.Context, reader SourceReader, input BenchmarkInput) (*Result, error) {
 	if input.SamplePercent == 0 {
 		input.SamplePercent = 1.0
@@ -40,11 +164,33 @@ func Run(ctx context.Context, reader SourceReader, input BenchmarkInput) (*Resul
 	if input.MaxConnections == 0 {
 		input.MaxConnections = 20
 	}
+	guard := input.Guard.withDefaults()
+	query := reader.SampleQuery(input.TableName, input.SamplePercent, guard)
+
+	if input.DryRun {
+		return &Result{
+			TableName:   input.TableName,
+			Connections: input.MaxConnections,
+			Guard:       guard.String(),
+			DryRun:      true,
+			Queries:     []string{query},
+			Explanation: fmt.Sprintf("Dry run: would read a %.1f%% sample of '%s' (%s). Nothing was executed.",
+				input.SamplePercent, input.TableName, guard),
+		}, nil
+	}
+
+	if guard.OffPeak != nil && !guard.OffPeak.Contains(now()) {
+		return nil, fmt.Errorf("%w %s (now %s)", ErrOutsideOffPeak, guard.OffPeak, now().Format("15:04"))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, guard.MaxDuration)
+	defer cancel()
 
-	bytesRead, elapsed, err := reader.ReadSample(ctx, input.TableName, input.PartitionCol, input.SamplePercent)
+	sample, err := reader.ReadSample(ctx, input.TableName, input.PartitionCol, input.SamplePercent, guard)
 	if err != nil {
 		return nil, fmt.Errorf("reading sample from %s: %w", input.TableName, err)
 	}
+	bytesRead, elapsed := sample.BytesRead, sample.Elapsed
 
 	if elapsed == 0 {
 		elapsed = time.Millisecond // avoid division by zero
@@ -80,19 +226,71 @@ func Run(ctx context.Context, reader SourceReader, input BenchmarkInput) (*Resul
 	} else {
 		explanation += fmt.Sprintf(" Full migration estimated at %s — consider increasing parallelism or migration window.", formatDuration(estFullRead))
 	}
+	if sample.StoppedBy != "" {
+		explanation += fmt.Sprintf(" The read stopped early at the %s limit.", strings.ReplaceAll(sample.StoppedBy, "_", " "))
+	}
 
 	return &Result{
 		TableName:             input.TableName,
 		BytesRead:             bytesRead,
+		RowsRead:              sample.RowsRead,
 		Elapsed:               elapsed,
 		ThroughputMBps:        throughputMBps,
 		Connections:           input.MaxConnections,
 		EstimatedFullReadTime: estFullRead,
 		OneHourAchievable:     oneHour,
 		Explanation:           explanation,
+		StoppedBy:             sample.StoppedBy,
+		Guard:                 guard.String(),
+		Queries:               []string{query},
 	}, nil
 }
 
+// meter tracks a sample read against the guard's limits.
+type meter struct {
+	ctx    context.Context
+	guard  Guard
+	start  time.Time
+	sample Sample
+}
+
+func newMeter(ctx context.Context, guard Guard) *meter {
+	return &meter{ctx: ctx, guard: guard, start: time.Now()}
+}
+
+// add counts a row and reports whether a limit has been reached.
+func (m *meter) add(values []any) bool {
+	m.sample.RowsRead++
+	for _, v := range values {
+		if v != nil {
+			m.sample.BytesRead += estimateValueSize(v)
+		}
+	}
+	switch {
+	case m.guard.MaxRows > 0 && m.sample.RowsRead >= m.guard.MaxRows:
+		m.sample.StoppedBy = StoppedByMaxRows
+	case m.guard.MaxBytes > 0 && m.sample.BytesRead >= m.guard.MaxBytes:
+		m.sample.StoppedBy = StoppedByMaxBytes
+	case m.guard.MaxDuration > 0 && time.Since(m.start) >= m.guard.MaxDuration:
+		m.sample.StoppedBy = StoppedByMaxDuration
+	}
+	return m.sample.StoppedBy != ""
+}
+
+// finish returns the sample. A read cut off by the time limit is kept as a
+// partial sample rather than treated as a failure.
+func (m *meter) finish(err error) (Sample, error) {
+	m.sample.Elapsed = time.Since(m.start)
+	if err != nil {
+		if errors.Is(m.ctx.Err(), context.DeadlineExceeded) && m.sample.RowsRead > 0 {
+			m.sample.StoppedBy = StoppedByMaxDuration
+			return m.sample, nil
+		}
+		return Sample{}, err
+	}
+	return m.sample, nil
+}
+
 func formatBytes(b int64) string {
 	const (
 		kb = 1024
@@ -3,6 +3,7 @@ package benchmark
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -14,8 +15,12 @@ type mockReader struct {
 	err       error
 }
 
-func (m *mockReader) ReadSample(_ context.Context, _, _ string, _ float64) (int64, time.Duration, error) {
-	return m.bytesRead, m.elapsed, m.err
+func (m *mockReader) SampleQuery(table string, _ float64, _ Guard) string {
+	return "SELECT * FROM " + table
+}
+
+func (m *mockReader) ReadSample(_ context.Context, _, _ string, _ float64, _ Guard) (Sample, error) {
+	return Sample{BytesRead: m.bytesRead, Elapsed: m.elapsed}, m.err
 }
 
 func TestRun_ThroughputCalculation(t *testing.T) {
@@ -167,3 +172,142 @@ func TestRun_DefaultSamplePercent(t *testing.T) {
 		t.Error("expected non-empty explanation")
 	}
 }
+
+func TestRun_DryRun(t *testing.T) {
+	reader := &mockReader{err: errors.New("must not be called")}
+
+	result, err := Run(context.Background(), reader, BenchmarkInput{
+		TableName: "orders",
+		DryRun:    true,
+		Guard:     Guard{MaxRows: 1000},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !result.DryRun || len(result.Queries) != 1 || result.Queries[0] != "SELECT * FROM orders" {
+		t.Errorf("result = %+v", result)
+	}
+	if result.BytesRead != 0 {
+		t.Errorf("dry run read %d bytes", result.BytesRead)
+	}
+	if !strings.Contains(result.Guard, "max 1000 rows") {
+		t.Errorf("Guard = %q", result.Guard)
+	}
+}
+
+func TestRun_OffPeak(t *testing.T) {
+	window, err := ParseWindow("22:00-06:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(orig func() time.Time) { now = orig }(now)
+
+	tests := []struct {
+		clock   string
+		wantErr bool
+	}{
+		{"23:30", false},
+		{"03:00", false},
+		{"06:00", true},
+		{"14:00", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.clock, func(t *testing.T) {
+			at, _ := time.Parse("15:04", tt.clock)
+			now = func() time.Time { return at }
+
+			reader := &mockReader{bytesRead: 1024, elapsed: time.Second}
+			_, err := Run(context.Background(), reader, BenchmarkInput{TableName: "orders", Guard: Guard{OffPeak: window}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrOutsideOffPeak) {
+				t.Errorf("err = %v, want ErrOutsideOffPeak", err)
+			}
+		})
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"22:00-06:00", "22:00-06:00", false},
+		{"01:30 - 04:45", "01:30-04:45", false},
+		{"22:00", "", true},
+		{"25:00-06:00", "", true},
+	}
+	for _, tt := range tests {
+		w, err := ParseWindow(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWindow(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && w.String() != tt.want {
+			t.Errorf("ParseWindow(%q) = %s, want %s", tt.in, w, tt.want)
+		}
+	}
+}
+
+func TestMeter(t *testing.T) {
+	tests := []struct {
+		name  string
+		guard Guard
+		rows  int
+		want  string
+	}{
+		{"row limit", Guard{MaxRows: 3}, 3, StoppedByMaxRows},
+		{"byte limit", Guard{MaxBytes: 20}, 3, StoppedByMaxBytes},
+		{"within limits", Guard{MaxRows: 100, MaxBytes: 1 << 20}, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMeter(context.Background(), tt.guard)
+			rows := 0
+			for i := 0; i < 10; i++ {
+				rows++
+				if m.add([]any{int64(i)}) {
+					break
+				}
+			}
+			s, err := m.finish(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.StoppedBy != tt.want {
+				t.Errorf("StoppedBy = %q, want %q", s.StoppedBy, tt.want)
+			}
+			if tt.rows > 0 && rows != tt.rows {
+				t.Errorf("stopped after %d rows, want %d", rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestMeter_DeadlineKeepsPartialSample(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	m := newMeter(ctx, Guard{})
+	m.add([]any{"row"})
+	s, err := m.finish(ctx.Err())
+	if err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if s.StoppedBy != StoppedByMaxDuration || s.RowsRead != 1 {
+		t.Errorf("sample = %+v", s)
+	}
+}
+
+func TestPostgresSampleQuery(t *testing.T) {
+	r := &PostgresReader{}
+	if got := r.SampleQuery("orders", 1, Guard{}); got != `SELECT * FROM "orders" TABLESAMPLE SYSTEM(1.00)` {
+		t.Errorf("query = %q", got)
+	}
+	if got := r.SampleQuery("orders", 0.5, Guard{MaxRows: 500}); !strings.HasSuffix(got, " LIMIT 500") {
+		t.Errorf("query = %q", got)
+	}
+}
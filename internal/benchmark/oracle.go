@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"time"
 
 	_ "github.com/sijms/go-ora/v2"
 )
@@ -14,30 +13,37 @@ type OracleReader struct {
 	ConnString string
 }
 
-// ReadSample reads a sample from an Oracle table using SAMPLE().
-func (r *OracleReader) ReadSample(ctx context.Context, tableName, partitionCol string, samplePct float64) (int64, time.Duration, error) {
+// SampleQuery returns the SAMPLE query used for the benchmark.
+func (r *OracleReader) SampleQuery(tableName string, samplePct float64, guard Guard) string {
+	query := fmt.Sprintf("SELECT * FROM %s SAMPLE(%.2f)", tableName, samplePct)
+	if guard.MaxRows > 0 {
+		query += fmt.Sprintf(" FETCH FIRST %d ROWS ONLY", guard.MaxRows)
+	}
+	return query
+}
+
+// ReadSample reads a sample from an Oracle table using SAMPLE(), stopping at
+// the guard's limits.
+func (r *OracleReader) ReadSample(ctx context.Context, tableName, partitionCol string, samplePct float64, guard Guard) (Sample, error) {
 	db, err := sql.Open("oracle", r.ConnString)
 	if err != nil {
-		return 0, 0, fmt.Errorf("connecting to Oracle: %w", err)
+		return Sample{}, fmt.Errorf("connecting to Oracle: %w", err)
 	}
 	defer db.Close()
 
-	query := fmt.Sprintf("SELECT * FROM %s SAMPLE(%.2f)", tableName, samplePct)
-
-	start := time.Now()
+	m := newMeter(ctx, guard)
 
-	rows, err := db.QueryContext(ctx, query)
+	rows, err := db.QueryContext(ctx, r.SampleQuery(tableName, samplePct, guard))
 	if err != nil {
-		return 0, 0, fmt.Errorf("executing sample query: %w", err)
+		return m.finish(fmt.Errorf("executing sample query: %w", err))
 	}
 	defer rows.Close()
 
 	cols, err := rows.Columns()
 	if err != nil {
-		return 0, 0, fmt.Errorf("getting columns: %w", err)
+		return m.finish(fmt.Errorf("getting columns: %w", err))
 	}
 
-	var bytesRead int64
 	scanDest := make([]any, len(cols))
 	scanPtrs := make([]any, len(cols))
 	for i := range scanDest {
@@ -46,18 +52,14 @@ func (r *OracleReader) ReadSample(ctx context.Context, tableName, partitionCol s
 
 	for rows.Next() {
 		if err := rows.Scan(scanPtrs...); err != nil {
-			return 0, 0, fmt.Errorf("scanning row: %w", err)
+			return m.finish(fmt.Errorf("scanning row: %w", err))
 		}
-		for _, v := range scanDest {
-			if v != nil {
-				bytesRead += estimateValueSize(v)
-			}
+		if m.add(scanDest) {
+			return m.finish(nil)
 		}
 	}
 	if err := rows.Err(); err != nil {
-		return 0, 0, fmt.Errorf("iterating rows: %w", err)
+		return m.finish(fmt.Errorf("iterating rows: %w", err))
 	}
-
-	elapsed := time.Since(start)
-	return bytesRead, elapsed, nil
+	return m.finish(nil)
 }
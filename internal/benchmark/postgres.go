@@ -3,7 +3,6 @@ package benchmark
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -13,42 +12,45 @@ type PostgresReader struct {
 	ConnString string
 }
 
-// ReadSample reads a sample from a PostgreSQL table using TABLESAMPLE.
-func (r *PostgresReader) ReadSample(ctx context.Context, tableName, partitionCol string, samplePct float64) (int64, time.Duration, error) {
+// SampleQuery returns the TABLESAMPLE query used for the benchmark.
+func (r *PostgresReader) SampleQuery(tableName string, samplePct float64, guard Guard) string {
+	query := fmt.Sprintf("SELECT * FROM %s TABLESAMPLE SYSTEM(%.2f)", pgx.Identifier{tableName}.Sanitize(), samplePct)
+	if guard.MaxRows > 0 {
+		query += fmt.Sprintf(" LIMIT %d", guard.MaxRows)
+	}
+	return query
+}
+
+// ReadSample reads a sample from a PostgreSQL table using TABLESAMPLE,
+// stopping at the guard's limits.
+func (r *PostgresReader) ReadSample(ctx context.Context, tableName, partitionCol string, samplePct float64, guard Guard) (Sample, error) {
 	conn, err := pgx.Connect(ctx, r.ConnString)
 	if err != nil {
-		return 0, 0, fmt.Errorf("connecting to PostgreSQL: %w", err)
+		return Sample{}, fmt.Errorf("connecting to PostgreSQL: %w", err)
 	}
-	defer conn.Close(ctx)
+	defer conn.Close(context.Background())
 
-	query := fmt.Sprintf("SELECT * FROM %s TABLESAMPLE SYSTEM(%.2f)", pgx.Identifier{tableName}.Sanitize(), samplePct)
-
-	start := time.Now()
+	m := newMeter(ctx, guard)
 
-	rows, err := conn.Query(ctx, query)
+	rows, err := conn.Query(ctx, r.SampleQuery(tableName, samplePct, guard))
 	if err != nil {
-		return 0, 0, fmt.Errorf("executing sample query: %w", err)
+		return m.finish(fmt.Errorf("executing sample query: %w", err))
 	}
 	defer rows.Close()
 
-	var bytesRead int64
 	for rows.Next() {
 		values, err := rows.Values()
 		if err != nil {
-			return 0, 0, fmt.Errorf("reading row: %w", err)
+			return m.finish(fmt.Errorf("reading row: %w", err))
 		}
-		for _, v := range values {
-			if v != nil {
-				bytesRead += estimateValueSize(v)
-			}
+		if m.add(values) {
+			return m.finish(nil)
 		}
 	}
 	if err := rows.Err(); err != nil {
-		return 0, 0, fmt.Errorf("iterating rows: %w", err)
+		return m.finish(fmt.Errorf("iterating rows: %w", err))
 	}
-
-	elapsed := time.Since(start)
-	return bytesRead, elapsed, nil
+	return m.finish(nil)
 }
 
 func estimateValueSize(v any) int64 {
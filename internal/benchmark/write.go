@@ -0,0 +1,371 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+)
+
+// Defaults for write benchmarks.
+const (
+	DefaultWriteWorkers   = 4
+	DefaultWriteBatchSize = 1000
+	// maxBatchBytes keeps a batch of large documents from being built in
+	// memory all at once.
+	maxBatchBytes = 8 << 20
+	// minDocBytes is the smallest document written: an _id and one field.
+	minDocBytes = 64
+	// fieldBytes is the string value size documents are split into, so they
+	// carry field names the way mapped documents do.
+	fieldBytes = 256
+	// minFieldBytes is the least left over for a further field, covering its
+	// type, name, length and terminator; less is added to the current one.
+	minFieldBytes = 16
+)
+
+// WriteCollectionPrefix names the scratch collections write benchmarks
+// insert into. Each run drops its collection when it ends.
+const WriteCollectionPrefix = "reloquent_write_benchmark_"
+
+// TargetWriter inserts documents into the target for write benchmarks.
+// target.Operator implements it.
+type TargetWriter interface {
+	InsertDocuments(ctx context.Context, collection string, docs []interface{}) (int64, error)
+	DropCollections(ctx context.Context, names []string) error
+}
+
+// DocumentShape is the size of one mapped collection's documents, and its
+// share of the data written.
+type DocumentShape struct {
+	Collection string
+	AvgBytes   int64
+	MaxBytes   int64
+	Weight     int64 // bytes the collection holds; documents are drawn in proportion
+}
+
+// ShapesFromEstimates returns the document shapes of the size estimates of
+// the mapped collections.
+func ShapesFromEstimates(ests []mapping.CollectionSizeEstimate) []DocumentShape {
+	var shapes []DocumentShape
+	for _, e := range ests {
+		if e.AvgDocSizeBytes <= 0 {
+			continue
+		}
+		weight := e.AvgRowCount * e.AvgDocSizeBytes
+		if weight <= 0 {
+			weight = e.AvgDocSizeBytes
+		}
+		shapes = append(shapes, DocumentShape{
+			Collection: e.Collection,
+			AvgBytes:   e.AvgDocSizeBytes,
+			MaxBytes:   e.MaxDocSizeBytes,
+			Weight:     weight,
+		})
+	}
+	return shapes
+}
+
+// WriteInput defines parameters for a write benchmark. The guard's
+// duration, document (MaxRows) and byte limits bound the run.
+type WriteInput struct {
+	Shapes         []DocumentShape
+	TotalDataBytes int64 // bytes the migration writes, for the full write estimate
+	Workers        int   // concurrent inserters; default 4
+	BatchSize      int   // documents per insert; default 1000
+	Guard          Guard
+	DryRun         bool
+	Seed           int64 // document sizes are drawn from this seed; 0 uses the clock
+}
+
+// WriteResult holds the output of a write benchmark.
+type WriteResult struct {
+	Collection             string        `yaml:"collection" json:"collection"`
+	DocsWritten            int64         `yaml:"docs_written" json:"docs_written"`
+	BytesWritten           int64         `yaml:"bytes_written" json:"bytes_written"`
+	Elapsed                time.Duration `yaml:"elapsed" json:"elapsed"`
+	ThroughputMBps         float64       `yaml:"throughput_mbps" json:"throughput_mbps"`
+	OpsPerSec              float64       `yaml:"ops_per_sec" json:"ops_per_sec"`
+	Workers                int           `yaml:"workers" json:"workers"`
+	BatchSize              int           `yaml:"batch_size" json:"batch_size"`
+	EstimatedFullWriteTime time.Duration `yaml:"estimated_full_write_time" json:"estimated_full_write_time"`
+	StoppedBy              string        `yaml:"stopped_by,omitempty" json:"stopped_by,omitempty"`
+	Guard                  string        `yaml:"guard" json:"guard"`
+	DryRun                 bool          `yaml:"dry_run,omitempty" json:"dry_run,omitempty"`
+	Explanation            string        `yaml:"explanation" json:"explanation"`
+}
+
+// RunWrite bulk-inserts synthetic documents sized like the mapped
+// collections' into a scratch collection on the target, measures the
+// sustained write rate and drops the collection again. The first batch of
+// each worker warms up connections and the collection and is not counted
+// in the rate. A dry run describes the run without connecting.
+func RunWrite(ctx context.Context, w TargetWriter, input WriteInput) (*WriteResult, error) {
+	if len(input.Shapes) == 0 {
+		return nil, fmt.Errorf("no document sizes to write; design the mapping first")
+	}
+	if input.Workers <= 0 {
+		input.Workers = DefaultWriteWorkers
+	}
+	if input.BatchSize <= 0 {
+		input.BatchSize = DefaultWriteBatchSize
+	}
+	if input.Seed == 0 {
+		input.Seed = time.Now().UnixNano()
+	}
+	guard := input.Guard.withDefaults()
+	coll := WriteCollectionPrefix + now().Format("20060102150405")
+	res := &WriteResult{Collection: coll, Workers: input.Workers, BatchSize: input.BatchSize, Guard: guard.String()}
+
+	if input.DryRun {
+		res.DryRun = true
+		res.Explanation = fmt.Sprintf("Dry run: would insert synthetic documents shaped like %s into %s with %d workers in batches of %d (%s), then drop it. Nothing was written.",
+			shapeNames(input.Shapes), coll, input.Workers, input.BatchSize, guard)
+		return res, nil
+	}
+	if guard.OffPeak != nil && !guard.OffPeak.Contains(now()) {
+		return nil, fmt.Errorf("%w %s (now %s)", ErrOutsideOffPeak, guard.OffPeak, now().Format("15:04"))
+	}
+
+	defer func() {
+		// Clean up even when the run was cancelled
+		dropCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		w.DropCollections(dropCtx, []string{coll})
+	}()
+
+	runCtx, cancel := context.WithTimeout(ctx, guard.MaxDuration)
+	defer cancel()
+
+	wm := &writeMeter{guard: guard, start: time.Now()}
+	var wg sync.WaitGroup
+	for i := range input.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gen := newDocGenerator(input.Shapes, input.Seed+int64(i))
+			for warm := true; !wm.done() && runCtx.Err() == nil; warm = false {
+				docs, bytes := gen.batch(input.BatchSize)
+				if _, err := w.InsertDocuments(runCtx, coll, docs); err != nil {
+					wm.fail(runCtx, err)
+					return
+				}
+				wm.add(int64(len(docs)), bytes, warm)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := wm.err; err != nil {
+		return nil, fmt.Errorf("writing to %s: %w", coll, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if wm.stoppedBy == "" && runCtx.Err() != nil {
+		wm.stoppedBy = StoppedByMaxDuration
+	}
+
+	res.DocsWritten, res.BytesWritten, res.StoppedBy = wm.docs, wm.bytes, wm.stoppedBy
+	res.Elapsed = time.Since(wm.start)
+	docs, bytes, elapsed := wm.sustained()
+	if elapsed > 0 {
+		res.ThroughputMBps = float64(bytes) / (1024 * 1024) / elapsed.Seconds()
+		res.OpsPerSec = float64(docs) / elapsed.Seconds()
+	}
+	if res.ThroughputMBps > 0 && input.TotalDataBytes > 0 {
+		totalMB := float64(input.TotalDataBytes) / (1024 * 1024)
+		res.EstimatedFullWriteTime = time.Duration(totalMB/res.ThroughputMBps) * time.Second
+	}
+
+	res.Explanation = fmt.Sprintf(
+		"Wrote %d documents (%s) in %s to %s with %d workers. Sustained write rate: %.1f MB/s, %.0f documents/s.",
+		res.DocsWritten, formatBytes(res.BytesWritten), formatDuration(res.Elapsed), coll, input.Workers,
+		res.ThroughputMBps, res.OpsPerSec)
+	if res.EstimatedFullWriteTime > 0 {
+		res.Explanation += fmt.Sprintf(" Writing the migrated data at this rate takes %s.", formatDuration(res.EstimatedFullWriteTime))
+	}
+	if res.StoppedBy != "" {
+		res.Explanation += fmt.Sprintf(" The run stopped at the %s limit.", strings.ReplaceAll(res.StoppedBy, "_", " "))
+	}
+	return res, nil
+}
+
+// writeMeter tracks a write benchmark against the guard's limits. Counts
+// after the workers' warm-up batches make up the sustained rate.
+type writeMeter struct {
+	guard Guard
+	start time.Time
+
+	mu          sync.Mutex
+	docs, bytes int64
+	warmDocs    int64
+	warmBytes   int64
+	warmedAt    time.Time // end of the last warm-up batch
+	end         time.Time
+	stoppedBy   string
+	err         error
+}
+
+func (m *writeMeter) add(docs, bytes int64, warm bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs += docs
+	m.bytes += bytes
+	m.end = time.Now()
+	if warm {
+		m.warmDocs += docs
+		m.warmBytes += bytes
+		m.warmedAt = m.end
+	}
+	switch {
+	case m.stoppedBy != "":
+	case m.guard.MaxRows > 0 && m.docs >= m.guard.MaxRows:
+		m.stoppedBy = StoppedByMaxRows
+	case m.guard.MaxBytes > 0 && m.bytes >= m.guard.MaxBytes:
+		m.stoppedBy = StoppedByMaxBytes
+	}
+}
+
+// fail records an insert error. Inserts cut off by the time limit end the
+// run instead of failing it.
+func (m *writeMeter) fail(ctx context.Context, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ctx.Err() != nil && m.docs > 0 {
+		if m.stoppedBy == "" {
+			m.stoppedBy = StoppedByMaxDuration
+		}
+		return
+	}
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+func (m *writeMeter) done() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stoppedBy != "" || m.err != nil
+}
+
+// sustained returns what was written after the warm-up and how long it
+// took, or the whole run when nothing followed the warm-up.
+func (m *writeMeter) sustained() (docs, bytes int64, elapsed time.Duration) {
+	if d := m.docs - m.warmDocs; d > 0 && m.end.After(m.warmedAt) {
+		return d, m.bytes - m.warmBytes, m.end.Sub(m.warmedAt)
+	}
+	return m.docs, m.bytes, m.end.Sub(m.start)
+}
+
+// docGenerator builds synthetic documents whose BSON sizes spread around
+// each shape's average, up to its maximum.
+type docGenerator struct {
+	shapes []DocumentShape
+	total  int64
+	rnd    *rand.Rand
+	seq    int64
+}
+
+func newDocGenerator(shapes []DocumentShape, seed int64) *docGenerator {
+	g := &docGenerator{shapes: shapes, rnd: rand.New(rand.NewSource(seed))}
+	for _, s := range shapes {
+		g.total += max(s.Weight, 1)
+	}
+	return g
+}
+
+// batch returns up to n documents, stopping early past maxBatchBytes, and
+// their BSON size.
+func (g *docGenerator) batch(n int) ([]interface{}, int64) {
+	docs := make([]interface{}, 0, n)
+	var bytes int64
+	for len(docs) < n && bytes < maxBatchBytes {
+		size := g.size(g.shape())
+		docs = append(docs, g.document(size))
+		bytes += size
+	}
+	return docs, bytes
+}
+
+// shape picks a collection in proportion to its weight.
+func (g *docGenerator) shape() DocumentShape {
+	r := g.rnd.Int63n(g.total)
+	for _, s := range g.shapes {
+		if r -= max(s.Weight, 1); r < 0 {
+			return s
+		}
+	}
+	return g.shapes[len(g.shapes)-1]
+}
+
+// size draws a document size between half and one and a half times the
+// average, capped at the maximum.
+func (g *docGenerator) size(s DocumentShape) int64 {
+	size := s.AvgBytes/2 + g.rnd.Int63n(s.AvgBytes+1)
+	if s.MaxBytes > 0 {
+		size = min(size, s.MaxBytes)
+	}
+	return max(size, minDocBytes)
+}
+
+// document builds a document of size BSON bytes: an ObjectId the driver
+// adds, a sequence number, and string fields of up to fieldBytes.
+func (g *docGenerator) document(size int64) map[string]interface{} {
+	g.seq++
+	doc := map[string]interface{}{"seq": g.seq}
+	// document length and terminator, _id ObjectId, seq int64
+	remaining := size - 5 - (1 + 4 + 12) - (1 + 4 + 8)
+	for i := 0; remaining > 0; i++ {
+		name := fmt.Sprintf("f%d", i)
+		overhead := int64(1 + len(name) + 1 + 4 + 1) // type, name, length, terminator
+		n := remaining - overhead
+		if n > fieldBytes && n-fieldBytes >= minFieldBytes {
+			n = fieldBytes
+		}
+		doc[name] = strings.Repeat("x", int(max(n, 0)))
+		remaining -= overhead + n
+	}
+	return doc
+}
+
+func shapeNames(shapes []DocumentShape) string {
+	names := make([]string, len(shapes))
+	for i, s := range shapes {
+		names[i] = s.Collection
+	}
+	return strings.Join(names, ", ")
+}
+
+// WriteYAML saves a write benchmark result so later sizing can use it.
+func (r *WriteResult) WriteYAML(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshaling write benchmark: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadWriteResult reads a saved write benchmark result.
+func LoadWriteResult(path string) (*WriteResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading write benchmark: %w", err)
+	}
+	var r WriteResult
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing write benchmark: %w", err)
+	}
+	return &r, nil
+}
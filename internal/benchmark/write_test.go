@@ -0,0 +1,120 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// mockWriter is a TargetWriter that counts what it is sent.
+type mockWriter struct {
+	mu      sync.Mutex
+	docs    int
+	colls   map[string]bool
+	dropped []string
+	err     error
+}
+
+func (m *mockWriter) InsertDocuments(_ context.Context, collection string, docs []interface{}) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return 0, m.err
+	}
+	if m.colls == nil {
+		m.colls = make(map[string]bool)
+	}
+	m.colls[collection] = true
+	m.docs += len(docs)
+	time.Sleep(time.Millisecond)
+	return int64(len(docs)), nil
+}
+
+func (m *mockWriter) DropCollections(_ context.Context, names []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dropped = append(m.dropped, names...)
+	return nil
+}
+
+func TestRunWrite(t *testing.T) {
+	w := &mockWriter{}
+	shapes := []DocumentShape{{Collection: "orders", AvgBytes: 2000, MaxBytes: 2500, Weight: 1}}
+	result, err := RunWrite(context.Background(), w, WriteInput{
+		Shapes:         shapes,
+		TotalDataBytes: 1 << 30,
+		Workers:        2,
+		BatchSize:      10,
+		Guard:          Guard{MaxRows: 200},
+		Seed:           1,
+	})
+	if err != nil {
+		t.Fatalf("RunWrite: %v", err)
+	}
+	if result.StoppedBy != StoppedByMaxRows || result.DocsWritten < 200 || int(result.DocsWritten) != w.docs {
+		t.Errorf("wrote %d documents (writer saw %d), stopped by %q", result.DocsWritten, w.docs, result.StoppedBy)
+	}
+	if avg := result.BytesWritten / result.DocsWritten; avg < 1500 || avg > 2500 {
+		t.Errorf("average document size = %d, want near 2000", avg)
+	}
+	if result.ThroughputMBps <= 0 || result.OpsPerSec <= 0 || result.EstimatedFullWriteTime <= 0 {
+		t.Errorf("result = %+v", result)
+	}
+	if len(w.colls) != 1 || !w.colls[result.Collection] || !strings.HasPrefix(result.Collection, WriteCollectionPrefix) {
+		t.Errorf("wrote to %v, want only %s", w.colls, result.Collection)
+	}
+	if len(w.dropped) != 1 || w.dropped[0] != result.Collection {
+		t.Errorf("dropped %v, want the scratch collection", w.dropped)
+	}
+}
+
+func TestRunWrite_DropsCollectionOnFailure(t *testing.T) {
+	w := &mockWriter{err: errors.New("not authorized")}
+	_, err := RunWrite(context.Background(), w, WriteInput{
+		Shapes: []DocumentShape{{Collection: "orders", AvgBytes: 500}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Fatalf("err = %v", err)
+	}
+	if len(w.dropped) != 1 {
+		t.Errorf("dropped %v, want the scratch collection dropped", w.dropped)
+	}
+}
+
+func TestRunWrite_DryRun(t *testing.T) {
+	result, err := RunWrite(context.Background(), nil, WriteInput{
+		Shapes: []DocumentShape{{Collection: "orders", AvgBytes: 500}, {Collection: "customers", AvgBytes: 300}},
+		DryRun: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.DryRun || !strings.Contains(result.Explanation, "orders, customers") {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestDocGenerator_Sizes(t *testing.T) {
+	shape := DocumentShape{Collection: "orders", AvgBytes: 1200, MaxBytes: 1300, Weight: 1}
+	g := newDocGenerator([]DocumentShape{shape}, 7)
+	for range 50 {
+		size := g.size(shape)
+		if size < 600 || size > 1300 {
+			t.Fatalf("size %d outside [avg/2, max]", size)
+		}
+		doc := g.document(size)
+		doc["_id"] = bson.NewObjectID()
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(data)) != size {
+			t.Fatalf("document of %d BSON bytes, want %d", len(data), size)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/transform"
+)
+
+// Applier translates captured row changes into MongoDB writes according to
+// the migration mapping.
+//
+// Changes to a collection's root table become upserts and deletes keyed by the
+// table's primary key. Changes to a first-level embedded table update the
+// parent document in place: array embeds are maintained with $push/$pull and
+// single embeds with $set/$unset. Changes to deeper embeds and to tables that
+// are not part of the mapping are skipped and counted. Masked columns are
+// masked before the change is translated, keys included, so the writes find
+// the documents the migration wrote.
+type Applier struct {
+	Mapping *mapping.Mapping
+	Schema  *schema.Schema
+	Target  target.Operator
+}
+
+// Apply writes changes to the target in capture order and returns how many
+// were applied and how many were skipped.
+func (a *Applier) Apply(ctx context.Context, changes []Change) (applied, skipped int, err error) {
+	if len(changes) == 0 {
+		return 0, 0, nil
+	}
+
+	// Group writes by collection while preserving order within each one.
+	var order []string
+	byColl := make(map[string][]target.WriteOp)
+	for _, ch := range changes {
+		coll, ops := a.translate(ch)
+		if len(ops) == 0 {
+			skipped++
+			continue
+		}
+		if _, ok := byColl[coll]; !ok {
+			order = append(order, coll)
+		}
+		byColl[coll] = append(byColl[coll], ops...)
+		applied++
+	}
+
+	for _, coll := range order {
+		if err := a.Target.ApplyWrites(ctx, coll, byColl[coll]); err != nil {
+			return 0, 0, fmt.Errorf("writing to %s: %w", coll, err)
+		}
+	}
+	return applied, skipped, nil
+}
+
+// translate returns the target collection and writes for a single change.
+// It returns no writes if the change cannot be applied.
+func (a *Applier) translate(ch Change) (string, []target.WriteOp) {
+	if a.Mapping == nil {
+		return "", nil
+	}
+	for _, c := range a.Mapping.Collections {
+		if strings.EqualFold(c.SourceTable, ch.Table) {
+			return c.Name, a.rootWrites(maskChange(ch, c.Transformations), c.Fields)
+		}
+	}
+	for _, c := range a.Mapping.Collections {
+		for i := range c.Embedded {
+			if strings.EqualFold(c.Embedded[i].SourceTable, ch.Table) {
+				return c.Name, a.embeddedWrites(maskChange(ch, c.Embedded[i].Transformations), &c.Embedded[i], c.Fields)
+			}
+		}
+	}
+	return "", nil
+}
+
+func (a *Applier) rootWrites(ch Change, fields []mapping.FieldMapping) []target.WriteOp {
+	pk := a.primaryKey(ch.Table)
+	key := ch.OldKey
+	if len(key) == 0 {
+		key = ch.Row
+	}
+	filter, ok := pick(key, pk)
+	if !ok {
+		return nil
+	}
+
+	filter = fieldKeys(filter, fields)
+	switch ch.Op {
+	case OpInsert, OpUpdate:
+		var ops []target.WriteOp
+		if len(ch.OldKey) > 0 {
+			// The primary key changed: remove the old document first.
+			if newFilter, ok := pick(ch.Row, pk); ok && !sameValues(filter, fieldKeys(newFilter, fields)) {
+				ops = append(ops, target.WriteOp{Type: target.WriteDelete, Filter: filter})
+				filter = fieldKeys(newFilter, fields)
+			}
+		}
+		doc := mapping.ApplyFields(copyRow(ch.Row), fields)
+		return append(ops, target.WriteOp{Type: target.WriteUpsert, Filter: filter, Doc: doc})
+	case OpDelete:
+		return []target.WriteOp{{Type: target.WriteDelete, Filter: filter}}
+	}
+	return nil
+}
+
+func (a *Applier) embeddedWrites(ch Change, emb *mapping.Embedded, parentFields []mapping.FieldMapping) []target.WriteOp {
+	joinCols := emb.JoinKeys()
+	parentCols := emb.ParentKeys()
+	if len(joinCols) == 0 || len(joinCols) != len(parentCols) {
+		return nil
+	}
+
+	parentFilter := func(row map[string]interface{}) (map[string]interface{}, bool) {
+		f := make(map[string]interface{}, len(joinCols))
+		for i, col := range joinCols {
+			v, ok := row[col]
+			if !ok || v == nil {
+				return nil, false
+			}
+			f[parentCols[i]] = v
+		}
+		return fieldKeys(f, parentFields), true
+	}
+
+	// Join columns are stripped from embedded documents, so match array
+	// elements on the remaining primary key columns.
+	var matchCols []string
+	for _, col := range a.primaryKey(ch.Table) {
+		if !contains(joinCols, col) {
+			matchCols = append(matchCols, col)
+		}
+	}
+
+	key := ch.OldKey
+	if len(key) == 0 {
+		key = ch.Row
+	}
+	filter, ok := parentFilter(key)
+	if !ok {
+		// Deletes usually carry only the child's key. For array embeds the
+		// parent can still be found through the embedded element itself.
+		if ch.Op != OpDelete || emb.Relationship == "single" {
+			return nil
+		}
+		match, hasMatch := pick(key, matchCols)
+		if !hasMatch {
+			return nil
+		}
+		match = fieldKeys(match, emb.Fields)
+		filter = make(map[string]interface{}, len(match))
+		for col, v := range match {
+			filter[emb.FieldName+"."+col] = v
+		}
+		return []target.WriteOp{{Type: target.WritePull, Filter: filter, Field: emb.FieldName, Match: match}}
+	}
+
+	doc := copyRow(ch.Row)
+	for _, col := range joinCols {
+		delete(doc, col)
+	}
+	doc = mapping.ApplyFields(doc, emb.Fields)
+
+	if emb.Relationship == "single" {
+		switch ch.Op {
+		case OpInsert, OpUpdate:
+			var ops []target.WriteOp
+			if newFilter, ok := parentFilter(ch.Row); ok && !sameValues(filter, newFilter) {
+				ops = append(ops, target.WriteOp{Type: target.WriteUnsetField, Filter: filter, Field: emb.FieldName})
+				filter = newFilter
+			}
+			return append(ops, target.WriteOp{Type: target.WriteSetField, Filter: filter, Field: emb.FieldName, Doc: doc})
+		case OpDelete:
+			return []target.WriteOp{{Type: target.WriteUnsetField, Filter: filter, Field: emb.FieldName}}
+		}
+		return nil
+	}
+
+	match, hasMatch := pick(key, matchCols)
+	match = fieldKeys(match, emb.Fields)
+	switch ch.Op {
+	case OpInsert:
+		return []target.WriteOp{{Type: target.WritePush, Filter: filter, Field: emb.FieldName, Doc: doc}}
+	case OpUpdate:
+		if !hasMatch {
+			return nil
+		}
+		newFilter, ok := parentFilter(ch.Row)
+		if !ok {
+			newFilter = filter
+		}
+		return []target.WriteOp{
+			{Type: target.WritePull, Filter: filter, Field: emb.FieldName, Match: match},
+			{Type: target.WritePush, Filter: newFilter, Field: emb.FieldName, Doc: doc},
+		}
+	case OpDelete:
+		if !hasMatch {
+			return nil
+		}
+		return []target.WriteOp{{Type: target.WritePull, Filter: filter, Field: emb.FieldName, Match: match}}
+	}
+	return nil
+}
+
+func (a *Applier) primaryKey(table string) []string {
+	if a.Schema == nil {
+		return nil
+	}
+	for _, t := range a.Schema.Tables {
+		if strings.EqualFold(t.Name, table) && t.PrimaryKey != nil {
+			return t.PrimaryKey.Columns
+		}
+	}
+	return nil
+}
+
+// pick returns the values of cols from row. It reports false if cols is empty
+// or any value is missing or NULL.
+func pick(row map[string]interface{}, cols []string) (map[string]interface{}, bool) {
+	if len(cols) == 0 {
+		return nil, false
+	}
+	out := make(map[string]interface{}, len(cols))
+	for _, c := range cols {
+		v, ok := row[c]
+		if !ok || v == nil {
+			return nil, false
+		}
+		out[c] = v
+	}
+	return out, true
+}
+
+// fieldKeys renames the columns of a filter to the document fields they are
+// mapped to. Excluded columns keep their names.
+func fieldKeys(filter map[string]interface{}, fields []mapping.FieldMapping) map[string]interface{} {
+	if len(fields) == 0 || filter == nil {
+		return filter
+	}
+	out := make(map[string]interface{}, len(filter))
+	for col, v := range filter {
+		if field, ok := mapping.FieldTarget(fields, col); ok {
+			col = field
+		}
+		out[col] = v
+	}
+	return out
+}
+
+func sameValues(a, b map[string]interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if fmt.Sprint(b[k]) != fmt.Sprint(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// maskChange returns the change with the columns the transformations mask
+// masked in its row and old key.
+func maskChange(ch Change, ts []mapping.Transformation) Change {
+	if !transform.HasMasks(ts) {
+		return ch
+	}
+	ch.Row = copyRow(ch.Row)
+	transform.ApplyMasks(ch.Row, ts)
+	if len(ch.OldKey) > 0 {
+		ch.OldKey = copyRow(ch.OldKey)
+		transform.ApplyMasks(ch.OldKey, ts)
+	}
+	return ch
+}
+
+func copyRow(row map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		out[k] = v
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
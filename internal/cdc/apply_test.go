@@ -0,0 +1,223 @@
+package cdc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func applyFixture() (*Applier, *target.MockOperator) {
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name:        "customers",
+		SourceTable: "customers",
+		Embedded: []mapping.Embedded{
+			{
+				SourceTable:  "orders",
+				FieldName:    "orders",
+				Relationship: "array",
+				JoinColumn:   "customer_id",
+				ParentColumn: "id",
+				Embedded: []mapping.Embedded{{
+					SourceTable:  "order_items",
+					FieldName:    "items",
+					Relationship: "array",
+					JoinColumn:   "order_id",
+					ParentColumn: "order_id",
+				}},
+			},
+			{
+				SourceTable:  "profiles",
+				FieldName:    "profile",
+				Relationship: "single",
+				JoinColumn:   "customer_id",
+				ParentColumn: "id",
+			},
+		},
+	}}}
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "customers", PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}}},
+		{Name: "orders", PrimaryKey: &schema.PrimaryKey{Columns: []string{"order_id"}}},
+		{Name: "profiles", PrimaryKey: &schema.PrimaryKey{Columns: []string{"customer_id"}}},
+	}}
+	tgt := &target.MockOperator{}
+	return &Applier{Mapping: m, Schema: s, Target: tgt}, tgt
+}
+
+func TestApplier_Translate(t *testing.T) {
+	tests := []struct {
+		name string
+		ch   Change
+		want []target.WriteOp
+	}{
+		{
+			name: "root insert",
+			ch:   Change{Op: OpInsert, Table: "customers", Row: map[string]interface{}{"id": int64(1), "name": "Alice"}},
+			want: []target.WriteOp{{Type: target.WriteUpsert, Filter: map[string]interface{}{"id": int64(1)}, Doc: map[string]interface{}{"id": int64(1), "name": "Alice"}}},
+		},
+		{
+			name: "root key change",
+			ch: Change{Op: OpUpdate, Table: "customers",
+				OldKey: map[string]interface{}{"id": int64(1)},
+				Row:    map[string]interface{}{"id": int64(2), "name": "Alice"}},
+			want: []target.WriteOp{
+				{Type: target.WriteDelete, Filter: map[string]interface{}{"id": int64(1)}},
+				{Type: target.WriteUpsert, Filter: map[string]interface{}{"id": int64(2)}, Doc: map[string]interface{}{"id": int64(2), "name": "Alice"}},
+			},
+		},
+		{
+			name: "root delete",
+			ch:   Change{Op: OpDelete, Table: "customers", Row: map[string]interface{}{"id": int64(1)}},
+			want: []target.WriteOp{{Type: target.WriteDelete, Filter: map[string]interface{}{"id": int64(1)}}},
+		},
+		{
+			name: "array insert",
+			ch:   Change{Op: OpInsert, Table: "orders", Row: map[string]interface{}{"order_id": int64(10), "customer_id": int64(1), "total": 5.0}},
+			want: []target.WriteOp{{Type: target.WritePush, Filter: map[string]interface{}{"id": int64(1)}, Field: "orders", Doc: map[string]interface{}{"order_id": int64(10), "total": 5.0}}},
+		},
+		{
+			name: "array update",
+			ch:   Change{Op: OpUpdate, Table: "orders", Row: map[string]interface{}{"order_id": int64(10), "customer_id": int64(1), "total": 6.0}},
+			want: []target.WriteOp{
+				{Type: target.WritePull, Filter: map[string]interface{}{"id": int64(1)}, Field: "orders", Match: map[string]interface{}{"order_id": int64(10)}},
+				{Type: target.WritePush, Filter: map[string]interface{}{"id": int64(1)}, Field: "orders", Doc: map[string]interface{}{"order_id": int64(10), "total": 6.0}},
+			},
+		},
+		{
+			name: "array delete by key only",
+			ch:   Change{Op: OpDelete, Table: "orders", Row: map[string]interface{}{"order_id": int64(10)}},
+			want: []target.WriteOp{{Type: target.WritePull, Filter: map[string]interface{}{"orders.order_id": int64(10)}, Field: "orders", Match: map[string]interface{}{"order_id": int64(10)}}},
+		},
+		{
+			name: "single upsert",
+			ch:   Change{Op: OpInsert, Table: "profiles", Row: map[string]interface{}{"customer_id": int64(2), "bio": "hi"}},
+			want: []target.WriteOp{{Type: target.WriteSetField, Filter: map[string]interface{}{"id": int64(2)}, Field: "profile", Doc: map[string]interface{}{"bio": "hi"}}},
+		},
+		{
+			name: "single delete",
+			ch:   Change{Op: OpDelete, Table: "profiles", Row: map[string]interface{}{"customer_id": int64(2)}},
+			want: []target.WriteOp{{Type: target.WriteUnsetField, Filter: map[string]interface{}{"id": int64(2)}, Field: "profile"}},
+		},
+		{
+			name: "nested embed skipped",
+			ch:   Change{Op: OpInsert, Table: "order_items", Row: map[string]interface{}{"item_id": int64(1), "order_id": int64(10)}},
+		},
+		{
+			name: "unmapped table skipped",
+			ch:   Change{Op: OpInsert, Table: "audit", Row: map[string]interface{}{"id": int64(1)}},
+		},
+		{
+			name: "missing key skipped",
+			ch:   Change{Op: OpDelete, Table: "customers", Row: map[string]interface{}{"name": "Alice"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := applyFixture()
+			_, got := a.translate(tt.ch)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("translate() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplier_Translate_FieldMappings(t *testing.T) {
+	a, _ := applyFixture()
+	a.Mapping.Collections[0].Fields = []mapping.FieldMapping{
+		{Column: "id", Target: "customerId"},
+		{Column: "street", Target: "address.street"},
+	}
+	a.Mapping.Collections[0].Embedded[0].Fields = []mapping.FieldMapping{
+		{Column: "order_id", Target: "orderId"},
+		{Column: "total", Exclude: true},
+	}
+
+	tests := []struct {
+		name string
+		ch   Change
+		want []target.WriteOp
+	}{
+		{
+			name: "root insert",
+			ch:   Change{Op: OpInsert, Table: "customers", Row: map[string]interface{}{"id": int64(1), "street": "Main"}},
+			want: []target.WriteOp{{Type: target.WriteUpsert,
+				Filter: map[string]interface{}{"customerId": int64(1)},
+				Doc:    map[string]interface{}{"customerId": int64(1), "address": map[string]interface{}{"street": "Main"}}}},
+		},
+		{
+			name: "array update",
+			ch:   Change{Op: OpUpdate, Table: "orders", Row: map[string]interface{}{"order_id": int64(10), "customer_id": int64(1), "total": 5.0}},
+			want: []target.WriteOp{
+				{Type: target.WritePull, Filter: map[string]interface{}{"customerId": int64(1)}, Field: "orders", Match: map[string]interface{}{"orderId": int64(10)}},
+				{Type: target.WritePush, Filter: map[string]interface{}{"customerId": int64(1)}, Field: "orders", Doc: map[string]interface{}{"orderId": int64(10)}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := a.translate(tt.ch)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("translate() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplier_Translate_Masks(t *testing.T) {
+	a, _ := applyFixture()
+	a.Mapping.Collections[0].Transformations = []mapping.Transformation{{Operation: "redact", SourceField: "name"}}
+	a.Mapping.Collections[0].Embedded[1].Transformations = []mapping.Transformation{{Operation: "nullify", SourceField: "bio"}}
+
+	row := map[string]interface{}{"id": int64(1), "name": "Alice"}
+	_, ops := a.translate(Change{Op: OpInsert, Table: "customers", Row: row})
+	if len(ops) != 1 || ops[0].Doc["name"] != "*****" {
+		t.Errorf("root insert = %#v, want name redacted", ops)
+	}
+	if row["name"] != "Alice" {
+		t.Error("masking changed the change's row")
+	}
+
+	_, ops = a.translate(Change{Op: OpUpdate, Table: "profiles", Row: map[string]interface{}{"customer_id": int64(1), "bio": "hi"}})
+	if len(ops) != 1 || ops[0].Doc["profile.bio"] != nil {
+		t.Errorf("single update = %#v, want bio null", ops)
+	}
+}
+
+func TestApplier_Apply(t *testing.T) {
+	a, tgt := applyFixture()
+	changes := []Change{
+		{Op: OpInsert, Table: "customers", Row: map[string]interface{}{"id": int64(1)}},
+		{Op: OpInsert, Table: "orders", Row: map[string]interface{}{"order_id": int64(10), "customer_id": int64(1)}},
+		{Op: OpInsert, Table: "audit", Row: map[string]interface{}{"id": int64(1)}},
+	}
+
+	applied, skipped, err := a.Apply(context.Background(), changes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied != 2 || skipped != 1 {
+		t.Errorf("applied=%d skipped=%d, want 2 and 1", applied, skipped)
+	}
+	ops := tgt.AppliedWrites["customers"]
+	if len(ops) != 2 || ops[0].Type != target.WriteUpsert || ops[1].Type != target.WritePush {
+		t.Errorf("unexpected writes: %+v", ops)
+	}
+}
+
+func TestApplier_Apply_Error(t *testing.T) {
+	a, tgt := applyFixture()
+	tgt.ApplyWritesErr = errors.New("write failed")
+
+	_, _, err := a.Apply(context.Background(), []Change{
+		{Op: OpInsert, Table: "customers", Row: map[string]interface{}{"id": int64(1)}},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,258 @@
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Change operation types.
+const (
+	OpInsert = "insert"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Change is a single row-level change captured from the source database.
+type Change struct {
+	Op       string                 `json:"op"`
+	Table    string                 `json:"table"`
+	Row      map[string]interface{} `json:"row,omitempty"`     // new values for insert/update, key values for delete
+	OldKey   map[string]interface{} `json:"old_key,omitempty"` // previous key when an update changed it
+	Position string                 `json:"position"`          // LSN (PostgreSQL) or SCN (Oracle)
+}
+
+// Lag describes how far the capturer is behind the source's current position.
+type Lag struct {
+	Bytes   int64   `json:"bytes"`   // unconsumed WAL bytes (PostgreSQL only)
+	Seconds float64 `json:"seconds"` // time since the oldest unapplied commit
+}
+
+// Capturer reads row changes from a source database's change log.
+type Capturer interface {
+	// Prepare creates the replication slot or records the starting position.
+	// It must be called before the bulk load so no changes are missed, and is
+	// safe to call again. It returns the starting position.
+	Prepare(ctx context.Context) (string, error)
+
+	// Poll returns up to max changes after the last committed position.
+	// Changes are not consumed until Commit is called, so a failed apply
+	// is retried on the next Poll.
+	Poll(ctx context.Context, max int) ([]Change, error)
+
+	// Commit acknowledges that every change up to position has been applied.
+	Commit(ctx context.Context, position string) error
+
+	// Lag reports replication lag relative to the source.
+	Lag(ctx context.Context) (*Lag, error)
+
+	// Teardown releases server-side resources such as replication slots.
+	Teardown(ctx context.Context) error
+
+	Close() error
+}
+
+// Status reports the state of a running replicator.
+type Status struct {
+	State      string    `json:"state"` // idle, running, stopped, failed
+	Position   string    `json:"position,omitempty"`
+	Applied    int64     `json:"applied"`
+	Skipped    int64     `json:"skipped"`
+	LagBytes   int64     `json:"lag_bytes"`
+	LagSeconds float64   `json:"lag_seconds"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// StatusCallback is called after every replication cycle.
+type StatusCallback func(status Status)
+
+const (
+	defaultPollInterval = 2 * time.Second
+	defaultBatchSize    = 500
+)
+
+// Replicator continuously polls a Capturer and applies changes to MongoDB.
+type Replicator struct {
+	capturer  Capturer
+	applier   *Applier
+	interval  time.Duration
+	batchSize int
+
+	mu     sync.Mutex
+	status Status
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewReplicator creates a replicator that feeds capturer changes to applier.
+func NewReplicator(c Capturer, a *Applier) *Replicator {
+	return &Replicator{
+		capturer:  c,
+		applier:   a,
+		interval:  defaultPollInterval,
+		batchSize: defaultBatchSize,
+		status:    Status{State: "idle"},
+	}
+}
+
+// SetPollInterval overrides how long the replicator waits when no changes are pending.
+func (r *Replicator) SetPollInterval(d time.Duration) {
+	if d > 0 {
+		r.interval = d
+	}
+}
+
+// Start launches replication in the background. Use Stop to end it.
+func (r *Replicator) Start(ctx context.Context, callback StatusCallback) error {
+	r.mu.Lock()
+	if r.cancel != nil {
+		r.mu.Unlock()
+		return fmt.Errorf("replication already running")
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	r.cancel = cancel
+	r.done = done
+	r.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		_ = r.Run(runCtx, callback)
+
+		// A failed loop is no longer running; allow Start to be called again.
+		r.mu.Lock()
+		if r.done == done && r.cancel != nil {
+			r.cancel()
+			r.cancel = nil
+		}
+		r.mu.Unlock()
+	}()
+	return nil
+}
+
+// Stop ends background replication and waits for the loop to exit.
+func (r *Replicator) Stop() error {
+	r.mu.Lock()
+	if r.cancel == nil {
+		r.mu.Unlock()
+		return fmt.Errorf("replication not running")
+	}
+	r.cancel()
+	r.cancel = nil
+	done := r.done
+	r.mu.Unlock()
+
+	<-done
+	return nil
+}
+
+// Status returns a snapshot of the replicator's progress.
+func (r *Replicator) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Run polls and applies changes until ctx is cancelled or an error occurs.
+func (r *Replicator) Run(ctx context.Context, callback StatusCallback) error {
+	r.update(callback, func(s *Status) {
+		s.State = "running"
+		s.StartedAt = time.Now()
+		s.LastError = ""
+	})
+
+	for {
+		if ctx.Err() != nil {
+			r.update(callback, func(s *Status) { s.State = "stopped" })
+			return nil
+		}
+
+		n, err := r.cycle(ctx, callback)
+		if err != nil {
+			if ctx.Err() != nil {
+				r.update(callback, func(s *Status) { s.State = "stopped" })
+				return nil
+			}
+			r.fail(callback, err)
+			return err
+		}
+
+		// Drain quickly while there is a backlog; otherwise wait.
+		if n >= r.batchSize {
+			continue
+		}
+		timer := time.NewTimer(r.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Drain applies changes until the capturer has none pending. Call it after
+// stopping writes to the source so the target is fully caught up at cutover.
+func (r *Replicator) Drain(ctx context.Context, callback StatusCallback) error {
+	for {
+		n, err := r.cycle(ctx, callback)
+		if err != nil {
+			r.fail(callback, err)
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
+
+// cycle polls, applies, and commits one batch, returning its size.
+func (r *Replicator) cycle(ctx context.Context, callback StatusCallback) (int, error) {
+	changes, err := r.capturer.Poll(ctx, r.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("polling changes: %w", err)
+	}
+
+	applied, skipped, err := r.applier.Apply(ctx, changes)
+	if err != nil {
+		return 0, fmt.Errorf("applying changes: %w", err)
+	}
+
+	if len(changes) > 0 {
+		if err := r.capturer.Commit(ctx, changes[len(changes)-1].Position); err != nil {
+			return 0, fmt.Errorf("committing position: %w", err)
+		}
+	}
+
+	lag, lagErr := r.capturer.Lag(ctx)
+	r.update(callback, func(s *Status) {
+		s.Applied += int64(applied)
+		s.Skipped += int64(skipped)
+		if len(changes) > 0 {
+			s.Position = changes[len(changes)-1].Position
+		}
+		if lagErr == nil && lag != nil {
+			s.LagBytes = lag.Bytes
+			s.LagSeconds = lag.Seconds
+		}
+	})
+	return len(changes), nil
+}
+
+func (r *Replicator) fail(callback StatusCallback, err error) {
+	r.update(callback, func(s *Status) {
+		s.State = "failed"
+		s.LastError = err.Error()
+	})
+}
+
+func (r *Replicator) update(callback StatusCallback, fn func(s *Status)) {
+	r.mu.Lock()
+	fn(&r.status)
+	snapshot := r.status
+	r.mu.Unlock()
+	if callback != nil {
+		callback(snapshot)
+	}
+}
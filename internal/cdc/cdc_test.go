@@ -0,0 +1,138 @@
+package cdc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReplicator_AppliesAndCommits(t *testing.T) {
+	a, tgt := applyFixture()
+	capt := &MockCapturer{
+		Batches: [][]Change{
+			{
+				{Op: OpInsert, Table: "customers", Row: map[string]interface{}{"id": int64(1)}, Position: "0/10"},
+				{Op: OpInsert, Table: "customers", Row: map[string]interface{}{"id": int64(2)}, Position: "0/20"},
+			},
+			{
+				{Op: OpDelete, Table: "customers", Row: map[string]interface{}{"id": int64(1)}, Position: "0/30"},
+			},
+		},
+		LagResult: &Lag{Bytes: 42, Seconds: 1.5},
+	}
+	r := NewReplicator(capt, a)
+	r.SetPollInterval(time.Millisecond)
+
+	var last Status
+	statuses := make(chan Status, 100)
+	if err := r.Start(context.Background(), func(s Status) {
+		select {
+		case statuses <- s:
+		default:
+		}
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := r.Start(context.Background(), nil); err == nil {
+		t.Error("expected error starting twice")
+	}
+
+	deadline := time.After(2 * time.Second)
+	for last.Applied < 3 {
+		select {
+		case last = <-statuses:
+		case <-deadline:
+			t.Fatalf("timed out; last status %+v", last)
+		}
+	}
+	if err := r.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	s := r.Status()
+	if s.State != "stopped" {
+		t.Errorf("state = %q, want stopped", s.State)
+	}
+	if s.Position != "0/30" {
+		t.Errorf("position = %q, want 0/30", s.Position)
+	}
+	if s.LagBytes != 42 || s.LagSeconds != 1.5 {
+		t.Errorf("lag = %d/%v, want 42/1.5", s.LagBytes, s.LagSeconds)
+	}
+	committed := capt.Committed()
+	if len(committed) != 2 || committed[0] != "0/20" || committed[1] != "0/30" {
+		t.Errorf("committed = %v", committed)
+	}
+	if len(tgt.AppliedWrites["customers"]) != 3 {
+		t.Errorf("expected 3 writes, got %d", len(tgt.AppliedWrites["customers"]))
+	}
+	if err := r.Stop(); err == nil {
+		t.Error("expected error stopping twice")
+	}
+}
+
+func TestReplicator_ApplyFailureDoesNotCommit(t *testing.T) {
+	a, tgt := applyFixture()
+	tgt.ApplyWritesErr = errors.New("write failed")
+	capt := &MockCapturer{Batches: [][]Change{{
+		{Op: OpInsert, Table: "customers", Row: map[string]interface{}{"id": int64(1)}, Position: "0/10"},
+	}}}
+	r := NewReplicator(capt, a)
+
+	err := r.Run(context.Background(), nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if s := r.Status(); s.State != "failed" || s.LastError == "" {
+		t.Errorf("unexpected status %+v", s)
+	}
+	if len(capt.Committed()) != 0 {
+		t.Errorf("failed batch must not be committed: %v", capt.Committed())
+	}
+}
+
+func TestReplicator_PollError(t *testing.T) {
+	a, _ := applyFixture()
+	r := NewReplicator(&MockCapturer{PollErr: errors.New("slot missing")}, a)
+
+	if err := r.Run(context.Background(), nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if s := r.Status(); s.State != "failed" {
+		t.Errorf("state = %q, want failed", s.State)
+	}
+}
+
+func TestReplicator_Cancel(t *testing.T) {
+	a, _ := applyFixture()
+	r := NewReplicator(&MockCapturer{}, a)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := r.Run(ctx, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := r.Status(); s.State != "stopped" {
+		t.Errorf("state = %q, want stopped", s.State)
+	}
+}
+
+func TestReplicator_Drain(t *testing.T) {
+	a, tgt := applyFixture()
+	capt := &MockCapturer{Batches: [][]Change{
+		{{Op: OpInsert, Table: "customers", Row: map[string]interface{}{"id": int64(1)}, Position: "10"}},
+		{{Op: OpInsert, Table: "customers", Row: map[string]interface{}{"id": int64(2)}, Position: "20"}},
+	}}
+	r := NewReplicator(capt, a)
+
+	if err := r.Drain(context.Background(), nil); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if len(tgt.AppliedWrites["customers"]) != 2 {
+		t.Errorf("expected 2 writes, got %d", len(tgt.AppliedWrites["customers"]))
+	}
+	if s := r.Status(); s.Applied != 2 || s.Position != "20" {
+		t.Errorf("unexpected status %+v", s)
+	}
+}
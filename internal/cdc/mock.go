@@ -0,0 +1,115 @@
+package cdc
+
+import (
+	"context"
+	"sync"
+)
+
+// MockCapturer is a test double for the Capturer interface. Each Poll returns
+// the next entry of Batches, then empty results once they are exhausted.
+type MockCapturer struct {
+	PreparePosition string
+	PrepareErr      error
+	Batches         [][]Change
+	PollErr         error
+	CommitErr       error
+	LagResult       *Lag
+	LagErr          error
+	TeardownErr     error
+
+	mu        sync.Mutex
+	polls     int
+	committed []string
+	prepared  bool
+	tornDown  bool
+	closed    bool
+}
+
+func (m *MockCapturer) Prepare(_ context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.PrepareErr != nil {
+		return "", m.PrepareErr
+	}
+	m.prepared = true
+	return m.PreparePosition, nil
+}
+
+func (m *MockCapturer) Poll(_ context.Context, _ int) ([]Change, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.PollErr != nil {
+		return nil, m.PollErr
+	}
+	if m.polls >= len(m.Batches) {
+		return nil, nil
+	}
+	batch := m.Batches[m.polls]
+	m.polls++
+	return batch, nil
+}
+
+func (m *MockCapturer) Commit(_ context.Context, position string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.CommitErr != nil {
+		return m.CommitErr
+	}
+	m.committed = append(m.committed, position)
+	return nil
+}
+
+func (m *MockCapturer) Lag(_ context.Context) (*Lag, error) {
+	if m.LagErr != nil {
+		return nil, m.LagErr
+	}
+	if m.LagResult != nil {
+		return m.LagResult, nil
+	}
+	return &Lag{}, nil
+}
+
+func (m *MockCapturer) Teardown(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.TeardownErr != nil {
+		return m.TeardownErr
+	}
+	m.tornDown = true
+	return nil
+}
+
+func (m *MockCapturer) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+// Committed returns the positions passed to Commit, in order.
+func (m *MockCapturer) Committed() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.committed...)
+}
+
+// Prepared reports whether Prepare succeeded.
+func (m *MockCapturer) Prepared() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.prepared
+}
+
+// TornDown reports whether Teardown succeeded.
+func (m *MockCapturer) TornDown() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tornDown
+}
+
+// Closed reports whether Close was called.
+func (m *MockCapturer) Closed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.closed
+}
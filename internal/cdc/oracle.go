@@ -0,0 +1,547 @@
+package cdc
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	// Oracle driver
+	_ "github.com/sijms/go-ora/v2"
+)
+
+// OracleCapturer reads committed changes with LogMiner. The source needs
+// ARCHIVELOG mode and supplemental logging of all columns
+// (ALTER DATABASE ADD SUPPLEMENTAL LOG DATA (ALL) COLUMNS) so that redo
+// statements carry complete rows.
+type OracleCapturer struct {
+	connStr   string
+	schema    string
+	db        *sql.DB
+	committed uint64 // highest commit SCN that has been applied
+
+	colTypes map[string]map[string]string // table -> column -> data type
+}
+
+// NewOracleCapturer creates a capturer for tables owned by schema. startSCN
+// is the position returned by an earlier Prepare; it may be empty.
+func NewOracleCapturer(connStr, schema, startSCN string) *OracleCapturer {
+	c := &OracleCapturer{
+		connStr:  connStr,
+		schema:   strings.ToUpper(schema),
+		colTypes: make(map[string]map[string]string),
+	}
+	c.committed, _ = strconv.ParseUint(startSCN, 10, 64)
+	return c
+}
+
+func (c *OracleCapturer) Connect(ctx context.Context) error {
+	db, err := sql.Open("oracle", c.connStr)
+	if err != nil {
+		return fmt.Errorf("opening Oracle connection: %w", err)
+	}
+	// LogMiner sessions are per connection.
+	db.SetMaxOpenConns(1)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("pinging Oracle: %w", err)
+	}
+	c.db = db
+	return nil
+}
+
+// Prepare records the current SCN as the starting position, unless one was
+// already supplied.
+func (c *OracleCapturer) Prepare(ctx context.Context) (string, error) {
+	if c.committed == 0 {
+		scn, err := c.currentSCN(ctx)
+		if err != nil {
+			return "", err
+		}
+		c.committed = scn
+	}
+	return strconv.FormatUint(c.committed, 10), nil
+}
+
+// Poll mines committed changes after the last committed SCN.
+func (c *OracleCapturer) Poll(ctx context.Context, max int) ([]Change, error) {
+	if c.committed == 0 {
+		return nil, fmt.Errorf("no starting SCN; run prepare first")
+	}
+	if err := c.startLogMiner(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = c.db.ExecContext(context.Background(), "BEGIN DBMS_LOGMNR.END_LOGMNR; END;")
+	}()
+
+	rows, err := c.db.QueryContext(ctx,
+		`SELECT COMMIT_SCN, OPERATION, TABLE_NAME, SQL_REDO, CSF FROM V$LOGMNR_CONTENTS
+		 WHERE SEG_OWNER = :1 AND COMMIT_SCN > :2 AND OPERATION IN ('INSERT', 'UPDATE', 'DELETE')`,
+		c.schema, c.committed)
+	if err != nil {
+		return nil, fmt.Errorf("querying LogMiner contents: %w", err)
+	}
+	defer rows.Close()
+
+	// Collect statements before converting values: the single connection
+	// is busy until the LogMiner rows are closed.
+	var changes []Change
+	var redo strings.Builder
+	for rows.Next() {
+		var (
+			commitSCN uint64
+			op, table string
+			part      sql.NullString
+			continued int
+		)
+		if err := rows.Scan(&commitSCN, &op, &table, &part, &continued); err != nil {
+			return nil, fmt.Errorf("scanning LogMiner row: %w", err)
+		}
+		// Long statements are split across rows flagged with CSF = 1.
+		redo.WriteString(part.String)
+		if continued == 1 {
+			continue
+		}
+		stmt := redo.String()
+		redo.Reset()
+
+		position := strconv.FormatUint(commitSCN, 10)
+		if len(changes) >= max && changes[len(changes)-1].Position != position {
+			break // never split a transaction across polls
+		}
+
+		ch, err := parseRedo(stmt)
+		if err != nil {
+			return nil, err
+		}
+		ch.Table = table
+		ch.Position = position
+		changes = append(changes, ch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating LogMiner rows: %w", err)
+	}
+	rows.Close()
+
+	for i := range changes {
+		if err := c.convert(ctx, &changes[i]); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// Commit records position as applied; the next Poll starts after it.
+func (c *OracleCapturer) Commit(_ context.Context, position string) error {
+	scn, err := strconv.ParseUint(position, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid SCN %q: %w", position, err)
+	}
+	if scn > c.committed {
+		c.committed = scn
+	}
+	return nil
+}
+
+// Lag reports how old the last applied commit is.
+func (c *OracleCapturer) Lag(ctx context.Context) (*Lag, error) {
+	current, err := c.currentSCN(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lag := &Lag{}
+	if current <= c.committed {
+		return lag, nil
+	}
+	var secs sql.NullFloat64
+	err = c.db.QueryRowContext(ctx,
+		"SELECT (CAST(SYSTIMESTAMP AS DATE) - CAST(SCN_TO_TIMESTAMP(:1) AS DATE)) * 86400 FROM DUAL",
+		c.committed).Scan(&secs)
+	if err != nil {
+		return nil, fmt.Errorf("querying SCN time: %w", err)
+	}
+	lag.Seconds = secs.Float64
+	return lag, nil
+}
+
+// Teardown is a no-op; LogMiner holds no server-side state between polls.
+func (c *OracleCapturer) Teardown(_ context.Context) error {
+	return nil
+}
+
+func (c *OracleCapturer) Close() error {
+	if c.db != nil {
+		return c.db.Close()
+	}
+	return nil
+}
+
+func (c *OracleCapturer) currentSCN(ctx context.Context) (uint64, error) {
+	var scn uint64
+	if err := c.db.QueryRowContext(ctx, "SELECT CURRENT_SCN FROM V$DATABASE").Scan(&scn); err != nil {
+		return 0, fmt.Errorf("querying current SCN: %w", err)
+	}
+	return scn, nil
+}
+
+// startLogMiner registers the redo logs covering the committed SCN onward and
+// starts a LogMiner session using the online catalog as the dictionary.
+func (c *OracleCapturer) startLogMiner(ctx context.Context) error {
+	rows, err := c.db.QueryContext(ctx,
+		`SELECT MIN(f.MEMBER) FROM V$LOG l JOIN V$LOGFILE f ON f.GROUP# = l.GROUP#
+		 WHERE l.NEXT_CHANGE# > :1 GROUP BY l.GROUP#
+		 UNION
+		 SELECT NAME FROM V$ARCHIVED_LOG
+		 WHERE NEXT_CHANGE# > :1 AND NAME IS NOT NULL AND DELETED = 'NO' AND STANDBY_DEST = 'NO'`,
+		c.committed)
+	if err != nil {
+		return fmt.Errorf("listing redo logs: %w", err)
+	}
+	var files []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("scanning redo log: %w", err)
+		}
+		files = append(files, name)
+	}
+	rows.Close()
+	if len(files) == 0 {
+		return fmt.Errorf("no redo logs cover SCN %d", c.committed)
+	}
+
+	for i, f := range files {
+		opt := "DBMS_LOGMNR.ADDFILE"
+		if i == 0 {
+			opt = "DBMS_LOGMNR.NEW"
+		}
+		stmt := fmt.Sprintf("BEGIN DBMS_LOGMNR.ADD_LOGFILE(LOGFILENAME => :1, OPTIONS => %s); END;", opt)
+		if _, err := c.db.ExecContext(ctx, stmt, f); err != nil {
+			return fmt.Errorf("adding redo log %s: %w", f, err)
+		}
+	}
+
+	_, err = c.db.ExecContext(ctx,
+		`BEGIN DBMS_LOGMNR.START_LOGMNR(STARTSCN => :1,
+		 OPTIONS => DBMS_LOGMNR.DICT_FROM_ONLINE_CATALOG + DBMS_LOGMNR.COMMITTED_DATA_ONLY); END;`,
+		c.committed)
+	if err != nil {
+		return fmt.Errorf("starting LogMiner: %w", err)
+	}
+	return nil
+}
+
+// convert turns the string literals from SQL_REDO into numbers for NUMBER
+// columns so keys match the documents written by the bulk load.
+func (c *OracleCapturer) convert(ctx context.Context, ch *Change) error {
+	types, ok := c.colTypes[ch.Table]
+	if !ok {
+		rows, err := c.db.QueryContext(ctx,
+			"SELECT COLUMN_NAME, DATA_TYPE FROM ALL_TAB_COLUMNS WHERE OWNER = :1 AND TABLE_NAME = :2",
+			c.schema, ch.Table)
+		if err != nil {
+			return fmt.Errorf("loading columns for %s: %w", ch.Table, err)
+		}
+		types = make(map[string]string)
+		for rows.Next() {
+			var name, typ string
+			if err := rows.Scan(&name, &typ); err != nil {
+				rows.Close()
+				return fmt.Errorf("scanning column: %w", err)
+			}
+			types[name] = typ
+		}
+		rows.Close()
+		c.colTypes[ch.Table] = types
+	}
+	convertNumbers(ch.Row, types)
+	convertNumbers(ch.OldKey, types)
+	return nil
+}
+
+func convertNumbers(row map[string]interface{}, types map[string]string) {
+	for k, v := range row {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		switch types[k] {
+		case "NUMBER", "FLOAT", "BINARY_FLOAT", "BINARY_DOUBLE":
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				row[k] = n
+			} else if f, err := strconv.ParseFloat(s, 64); err == nil {
+				row[k] = f
+			}
+		}
+	}
+}
+
+// parseRedo parses a LogMiner SQL_REDO statement. For updates, Row holds the
+// WHERE-clause values overlaid with the SET values and OldKey holds the
+// WHERE-clause values. The ROWID predicate LogMiner appends is dropped.
+// Function-wrapped literals such as TO_DATE('...', fmt)
+// are reduced to their first argument.
+func parseRedo(stmt string) (Change, error) {
+	toks, err := tokenizeRedo(stmt)
+	if err != nil {
+		return Change{}, err
+	}
+	p := &redoParser{toks: toks}
+
+	switch strings.ToLower(p.next().text) {
+	case "insert":
+		p.expectWord("into")
+		p.qualifiedName()
+		cols := p.identList()
+		p.expectWord("values")
+		vals := p.valueList()
+		if p.err != nil {
+			return Change{}, fmt.Errorf("parsing %q: %w", stmt, p.err)
+		}
+		if len(cols) != len(vals) {
+			return Change{}, fmt.Errorf("parsing %q: %d columns but %d values", stmt, len(cols), len(vals))
+		}
+		row := make(map[string]interface{}, len(cols))
+		for i, c := range cols {
+			row[c] = vals[i]
+		}
+		return Change{Op: OpInsert, Row: row}, nil
+
+	case "update":
+		p.qualifiedName()
+		p.expectWord("set")
+		set := p.assignments(",")
+		where := map[string]interface{}{}
+		if p.peekWord("where") {
+			p.next()
+			where = p.assignments("and")
+		}
+		delete(where, "ROWID")
+		if p.err != nil {
+			return Change{}, fmt.Errorf("parsing %q: %w", stmt, p.err)
+		}
+		row := copyRow(where)
+		for k, v := range set {
+			row[k] = v
+		}
+		return Change{Op: OpUpdate, Row: row, OldKey: where}, nil
+
+	case "delete":
+		p.expectWord("from")
+		p.qualifiedName()
+		p.expectWord("where")
+		where := p.assignments("and")
+		delete(where, "ROWID")
+		if p.err != nil {
+			return Change{}, fmt.Errorf("parsing %q: %w", stmt, p.err)
+		}
+		return Change{Op: OpDelete, Row: where}, nil
+	}
+	return Change{}, fmt.Errorf("unsupported redo statement %q", stmt)
+}
+
+type redoTokenKind int
+
+const (
+	tokWord redoTokenKind = iota
+	tokIdent
+	tokString
+	tokPunct
+)
+
+type redoToken struct {
+	kind redoTokenKind
+	text string
+}
+
+func tokenizeRedo(s string) ([]redoToken, error) {
+	var toks []redoToken
+	for i := 0; i < len(s); {
+		ch := s[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '"' || ch == '\'':
+			v, next, err := readQuoted(s, i, ch)
+			if err != nil {
+				return nil, err
+			}
+			kind := tokString
+			if ch == '"' {
+				kind = tokIdent
+			}
+			toks = append(toks, redoToken{kind: kind, text: v})
+			i = next
+		case strings.IndexByte("(),.=;", ch) >= 0:
+			toks = append(toks, redoToken{kind: tokPunct, text: string(ch)})
+			i++
+		default:
+			j := i
+			for j < len(s) && strings.IndexByte(" \t\n\r\"'(),.=;", s[j]) < 0 {
+				j++
+			}
+			// Keep decimal numbers such as 1.5 together.
+			for j+1 < len(s) && s[j] == '.' && s[j+1] >= '0' && s[j+1] <= '9' {
+				j++
+				for j < len(s) && strings.IndexByte(" \t\n\r\"'(),.=;", s[j]) < 0 {
+					j++
+				}
+			}
+			toks = append(toks, redoToken{kind: tokWord, text: s[i:j]})
+			i = j
+		}
+	}
+	return toks, nil
+}
+
+type redoParser struct {
+	toks []redoToken
+	pos  int
+	err  error
+}
+
+func (p *redoParser) next() redoToken {
+	if p.pos >= len(p.toks) {
+		if p.err == nil {
+			p.err = fmt.Errorf("unexpected end of statement")
+		}
+		return redoToken{}
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *redoParser) peek() redoToken {
+	if p.pos >= len(p.toks) {
+		return redoToken{}
+	}
+	return p.toks[p.pos]
+}
+
+func (p *redoParser) peekWord(w string) bool {
+	t := p.peek()
+	return t.kind == tokWord && strings.EqualFold(t.text, w)
+}
+
+func (p *redoParser) expectWord(w string) {
+	if t := p.next(); p.err == nil && (t.kind != tokWord || !strings.EqualFold(t.text, w)) {
+		p.err = fmt.Errorf("expected %s, got %q", w, t.text)
+	}
+}
+
+func (p *redoParser) expectPunct(s string) {
+	if t := p.next(); p.err == nil && (t.kind != tokPunct || t.text != s) {
+		p.err = fmt.Errorf("expected %s, got %q", s, t.text)
+	}
+}
+
+func (p *redoParser) ident() string {
+	t := p.next()
+	if p.err == nil && t.kind != tokIdent && t.kind != tokWord {
+		p.err = fmt.Errorf("expected identifier, got %q", t.text)
+	}
+	return t.text
+}
+
+// qualifiedName consumes "SCHEMA"."TABLE" and returns the table.
+func (p *redoParser) qualifiedName() string {
+	name := p.ident()
+	for p.peek().kind == tokPunct && p.peek().text == "." {
+		p.next()
+		name = p.ident()
+	}
+	return name
+}
+
+func (p *redoParser) identList() []string {
+	p.expectPunct("(")
+	var cols []string
+	for p.err == nil {
+		cols = append(cols, p.ident())
+		if t := p.next(); t.text == ")" {
+			break
+		} else if t.text != "," && p.err == nil {
+			p.err = fmt.Errorf("expected , or ), got %q", t.text)
+		}
+	}
+	return cols
+}
+
+func (p *redoParser) valueList() []interface{} {
+	p.expectPunct("(")
+	var vals []interface{}
+	for p.err == nil {
+		vals = append(vals, p.value())
+		if t := p.next(); t.text == ")" {
+			break
+		} else if t.text != "," && p.err == nil {
+			p.err = fmt.Errorf("expected , or ), got %q", t.text)
+		}
+	}
+	return vals
+}
+
+// value parses a literal, NULL, or a function call whose first argument is
+// the literal (TO_DATE, TO_TIMESTAMP, HEXTORAW, ...).
+func (p *redoParser) value() interface{} {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return t.text
+	case tokWord:
+		if strings.EqualFold(t.text, "NULL") {
+			return nil
+		}
+		if p.peek().kind == tokPunct && p.peek().text == "(" {
+			p.next()
+			v := p.value()
+			// Skip remaining arguments, allowing nested parentheses.
+			for depth := 1; depth > 0 && p.err == nil; {
+				switch p.next().text {
+				case "(":
+					depth++
+				case ")":
+					depth--
+				}
+			}
+			return v
+		}
+		return t.text
+	}
+	if p.err == nil {
+		p.err = fmt.Errorf("unexpected value %q", t.text)
+	}
+	return nil
+}
+
+// assignments parses col = value pairs separated by sep ("," or "and").
+// "col IS NULL" is accepted in WHERE clauses.
+func (p *redoParser) assignments(sep string) map[string]interface{} {
+	out := make(map[string]interface{})
+	for p.err == nil {
+		col := p.ident()
+		if p.peekWord("IS") {
+			p.next()
+			p.expectWord("NULL")
+			out[col] = nil
+		} else {
+			p.expectPunct("=")
+			out[col] = p.value()
+		}
+
+		t := p.peek()
+		if t.kind == tokPunct && t.text == ";" || t.kind == tokWord && strings.EqualFold(t.text, "where") || t.text == "" {
+			break
+		}
+		if !strings.EqualFold(t.text, sep) {
+			p.err = fmt.Errorf("expected %s, got %q", sep, t.text)
+			break
+		}
+		p.next()
+	}
+	return out
+}
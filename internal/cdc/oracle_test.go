@@ -0,0 +1,65 @@
+package cdc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRedo(t *testing.T) {
+	tests := []struct {
+		name    string
+		stmt    string
+		want    Change
+		wantErr bool
+	}{
+		{
+			name: "insert",
+			stmt: `insert into "HR"."EMPLOYEES"("ID","NAME","HIRED","MANAGER") values ('1','O''Neil',TO_DATE('2024-01-02 00:00:00', 'YYYY-MM-DD HH24:MI:SS'),NULL);`,
+			want: Change{Op: OpInsert, Row: map[string]interface{}{
+				"ID": "1", "NAME": "O'Neil", "HIRED": "2024-01-02 00:00:00", "MANAGER": nil,
+			}},
+		},
+		{
+			name: "update",
+			stmt: `update "HR"."EMPLOYEES" set "NAME" = 'Bob' where "ID" = '1' and "NAME" = 'Rob' and "MANAGER" IS NULL and ROWID = 'AAAR3sAAEAAAACXAAA';`,
+			want: Change{Op: OpUpdate,
+				Row:    map[string]interface{}{"ID": "1", "NAME": "Bob", "MANAGER": nil},
+				OldKey: map[string]interface{}{"ID": "1", "NAME": "Rob", "MANAGER": nil}},
+		},
+		{
+			name: "delete",
+			stmt: `delete from "HR"."EMPLOYEES" where "ID" = '1' and "SALARY" = 1.5 and ROWID = 'AAAR3sAAEAAAACXAAA';`,
+			want: Change{Op: OpDelete, Row: map[string]interface{}{"ID": "1", "SALARY": "1.5"}},
+		},
+		{
+			name: "raw value",
+			stmt: `insert into "HR"."FILES"("ID","DATA") values ('2',HEXTORAW('0a0b'));`,
+			want: Change{Op: OpInsert, Row: map[string]interface{}{"ID": "2", "DATA": "0a0b"}},
+		},
+		{name: "unsupported", stmt: `create table x (id number);`, wantErr: true},
+		{name: "column count mismatch", stmt: `insert into "HR"."T"("A","B") values ('1');`, wantErr: true},
+		{name: "truncated", stmt: `delete from "HR"."T" where "A" =`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRedo(tt.stmt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertNumbers(t *testing.T) {
+	row := map[string]interface{}{"ID": "7", "PRICE": "2.5", "NAME": "42", "NOTE": nil}
+	convertNumbers(row, map[string]string{"ID": "NUMBER", "PRICE": "NUMBER", "NAME": "VARCHAR2"})
+
+	want := map[string]interface{}{"ID": int64(7), "PRICE": 2.5, "NAME": "42", "NOTE": nil}
+	if !reflect.DeepEqual(row, want) {
+		t.Errorf("got %#v, want %#v", row, want)
+	}
+}
@@ -0,0 +1,383 @@
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultSlotName is the logical replication slot created for CDC.
+const DefaultSlotName = "reloquent_cdc"
+
+// PostgresCapturer reads changes from a logical replication slot using the
+// built-in test_decoding plugin through SQL-level decoding functions, so no
+// replication-protocol connection or extra extension is required. The source
+// must run with wal_level = logical.
+type PostgresCapturer struct {
+	connStr string
+	schema  string
+	slot    string
+	pool    *pgxpool.Pool
+
+	lastCommit time.Time // commit time of the most recently polled transaction
+	appliedAt  time.Time // commit time of the most recently committed position
+}
+
+// NewPostgresCapturer creates a capturer for tables in the given schema.
+func NewPostgresCapturer(connStr, schema, slot string) *PostgresCapturer {
+	if schema == "" {
+		schema = "public"
+	}
+	if slot == "" {
+		slot = DefaultSlotName
+	}
+	return &PostgresCapturer{connStr: connStr, schema: schema, slot: slot}
+}
+
+func (c *PostgresCapturer) Connect(ctx context.Context) error {
+	cfg, err := pgxpool.ParseConfig(c.connStr)
+	if err != nil {
+		return fmt.Errorf("parsing connection string: %w", err)
+	}
+	cfg.MaxConns = 1
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("connecting to PostgreSQL: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return fmt.Errorf("pinging PostgreSQL: %w", err)
+	}
+	c.pool = pool
+	return nil
+}
+
+// SlotName returns the replication slot used by this capturer.
+func (c *PostgresCapturer) SlotName() string {
+	return c.slot
+}
+
+// Prepare creates the replication slot if it does not already exist.
+func (c *PostgresCapturer) Prepare(ctx context.Context) (string, error) {
+	var lsn string
+	err := c.pool.QueryRow(ctx,
+		"SELECT COALESCE(confirmed_flush_lsn, restart_lsn)::text FROM pg_replication_slots WHERE slot_name = $1",
+		c.slot).Scan(&lsn)
+	if err == nil {
+		return lsn, nil
+	}
+
+	err = c.pool.QueryRow(ctx,
+		"SELECT lsn::text FROM pg_create_logical_replication_slot($1, 'test_decoding')",
+		c.slot).Scan(&lsn)
+	if err != nil {
+		return "", fmt.Errorf("creating replication slot %s: %w", c.slot, err)
+	}
+	return lsn, nil
+}
+
+// Poll peeks at pending changes without consuming them. Every change in a
+// transaction carries the transaction's commit LSN as its position.
+func (c *PostgresCapturer) Poll(ctx context.Context, max int) ([]Change, error) {
+	rows, err := c.pool.Query(ctx,
+		"SELECT lsn::text, data FROM pg_logical_slot_peek_changes($1, NULL, $2, 'include-timestamp', 'on')",
+		c.slot, max)
+	if err != nil {
+		return nil, fmt.Errorf("reading slot %s: %w", c.slot, err)
+	}
+	defer rows.Close()
+
+	var changes, pending []Change
+	for rows.Next() {
+		var lsn, data string
+		if err := rows.Scan(&lsn, &data); err != nil {
+			return nil, fmt.Errorf("scanning change: %w", err)
+		}
+		switch {
+		case strings.HasPrefix(data, "BEGIN"):
+			pending = pending[:0]
+		case strings.HasPrefix(data, "COMMIT"):
+			if ts, ok := parseCommitTime(data); ok {
+				c.lastCommit = ts
+			}
+			for _, ch := range pending {
+				ch.Position = lsn
+				changes = append(changes, ch)
+			}
+			pending = pending[:0]
+		case !strings.HasPrefix(data, "table "), strings.Contains(data, ": TRUNCATE:"):
+			// Logical messages and truncates are not replicated.
+		default:
+			schemaName, ch, err := parseTestDecoding(data)
+			if err != nil {
+				return nil, err
+			}
+			if !strings.EqualFold(schemaName, c.schema) {
+				continue
+			}
+			pending = append(pending, ch)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating changes: %w", err)
+	}
+	return changes, nil
+}
+
+// Commit advances the slot so changes up to position are not decoded again.
+func (c *PostgresCapturer) Commit(ctx context.Context, position string) error {
+	if _, err := c.pool.Exec(ctx, "SELECT pg_replication_slot_advance($1, $2::pg_lsn)", c.slot, position); err != nil {
+		return fmt.Errorf("advancing slot %s: %w", c.slot, err)
+	}
+	c.appliedAt = c.lastCommit
+	return nil
+}
+
+// Lag reports how much WAL the slot has not yet confirmed.
+func (c *PostgresCapturer) Lag(ctx context.Context) (*Lag, error) {
+	var bytes int64
+	err := c.pool.QueryRow(ctx,
+		"SELECT COALESCE(pg_wal_lsn_diff(pg_current_wal_lsn(), confirmed_flush_lsn), 0)::bigint FROM pg_replication_slots WHERE slot_name = $1",
+		c.slot).Scan(&bytes)
+	if err != nil {
+		return nil, fmt.Errorf("querying slot lag: %w", err)
+	}
+	lag := &Lag{Bytes: bytes}
+	if bytes > 0 && !c.appliedAt.IsZero() {
+		lag.Seconds = time.Since(c.appliedAt).Seconds()
+	}
+	return lag, nil
+}
+
+// Teardown drops the replication slot so the source stops retaining WAL.
+func (c *PostgresCapturer) Teardown(ctx context.Context) error {
+	_, err := c.pool.Exec(ctx,
+		"SELECT pg_drop_replication_slot(slot_name) FROM pg_replication_slots WHERE slot_name = $1",
+		c.slot)
+	if err != nil {
+		return fmt.Errorf("dropping replication slot %s: %w", c.slot, err)
+	}
+	return nil
+}
+
+func (c *PostgresCapturer) Close() error {
+	if c.pool != nil {
+		c.pool.Close()
+	}
+	return nil
+}
+
+// parseCommitTime extracts the timestamp from a test_decoding COMMIT line
+// such as "COMMIT 123 (at 2024-01-01 12:00:00.123456+00)".
+func parseCommitTime(line string) (time.Time, bool) {
+	i := strings.Index(line, "(at ")
+	if i < 0 || !strings.HasSuffix(line, ")") {
+		return time.Time{}, false
+	}
+	s := line[i+4 : len(line)-1]
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999-07", "2006-01-02 15:04:05.999999999-07:00"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseTestDecoding parses a test_decoding row line, for example:
+//
+//	table public.users: INSERT: id[integer]:1 name[text]:'Alice'
+//	table public.users: UPDATE: old-key: id[integer]:1 new-tuple: id[integer]:2 name[text]:'Alice'
+//	table public.users: DELETE: id[integer]:1
+//
+// It returns the table's schema along with the change.
+func parseTestDecoding(line string) (string, Change, error) {
+	rest, ok := strings.CutPrefix(line, "table ")
+	if !ok {
+		return "", Change{}, fmt.Errorf("unexpected decoding output: %q", line)
+	}
+	name, rest, ok := strings.Cut(rest, ": ")
+	if !ok {
+		return "", Change{}, fmt.Errorf("unexpected decoding output: %q", line)
+	}
+	schemaName, table := splitQualified(name)
+
+	action, rest, _ := strings.Cut(rest, ":")
+	rest = strings.TrimSpace(rest)
+
+	var ch Change
+	ch.Table = table
+	switch action {
+	case "INSERT":
+		ch.Op = OpInsert
+	case "UPDATE":
+		ch.Op = OpUpdate
+	case "DELETE":
+		ch.Op = OpDelete
+	default:
+		return "", Change{}, fmt.Errorf("unexpected decoding action %q", action)
+	}
+
+	if rest == "(no-tuple data)" {
+		return schemaName, ch, nil
+	}
+
+	if oldPart, ok := strings.CutPrefix(rest, "old-key: "); ok {
+		i := strings.Index(oldPart, " new-tuple: ")
+		if i < 0 {
+			return "", Change{}, fmt.Errorf("unexpected update output: %q", line)
+		}
+		oldKey, err := parseTuple(oldPart[:i])
+		if err != nil {
+			return "", Change{}, err
+		}
+		ch.OldKey = oldKey
+		rest = oldPart[i+len(" new-tuple: "):]
+	}
+
+	row, err := parseTuple(rest)
+	if err != nil {
+		return "", Change{}, err
+	}
+	ch.Row = row
+	return schemaName, ch, nil
+}
+
+// parseTuple parses space-separated name[type]:value pairs.
+func parseTuple(s string) (map[string]interface{}, error) {
+	row := make(map[string]interface{})
+	for i := 0; i < len(s); {
+		if s[i] == ' ' {
+			i++
+			continue
+		}
+
+		var name string
+		if s[i] == '"' {
+			n, next, err := readQuoted(s, i, '"')
+			if err != nil {
+				return nil, err
+			}
+			name, i = n, next
+		} else {
+			j := strings.IndexByte(s[i:], '[')
+			if j < 0 {
+				return nil, fmt.Errorf("malformed column in %q", s)
+			}
+			name, i = s[i:i+j], i+j
+		}
+
+		if i >= len(s) || s[i] != '[' {
+			return nil, fmt.Errorf("malformed column %s in %q", name, s)
+		}
+		// Types may themselves contain brackets, e.g. integer[].
+		depth, j := 0, i
+		for ; j < len(s); j++ {
+			if s[j] == '[' {
+				depth++
+			} else if s[j] == ']' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		if j+1 >= len(s) || s[j+1] != ':' {
+			return nil, fmt.Errorf("malformed column %s in %q", name, s)
+		}
+		typ := s[i+1 : j]
+		i = j + 2
+
+		if i < len(s) && s[i] == '\'' {
+			v, next, err := readQuoted(s, i, '\'')
+			if err != nil {
+				return nil, err
+			}
+			row[name] = v
+			i = next
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], ' ')
+		if end < 0 {
+			end = len(s) - i
+		}
+		raw := s[i : i+end]
+		i += end
+		if raw == "unchanged-toast-datum" {
+			continue // value unchanged and not sent; leave it out of the update
+		}
+		row[name] = convertValue(raw, typ)
+	}
+	return row, nil
+}
+
+// readQuoted reads a quoted token starting at s[i], where a doubled quote
+// character is an escaped quote. It returns the value and the index after it.
+func readQuoted(s string, i int, q byte) (string, int, error) {
+	var b strings.Builder
+	for j := i + 1; j < len(s); j++ {
+		if s[j] != q {
+			b.WriteByte(s[j])
+			continue
+		}
+		if j+1 < len(s) && s[j+1] == q {
+			b.WriteByte(q)
+			j++
+			continue
+		}
+		return b.String(), j + 1, nil
+	}
+	return "", 0, fmt.Errorf("unterminated quoted value in %q", s)
+}
+
+// convertValue converts an unquoted test_decoding value to a Go value.
+func convertValue(raw, typ string) interface{} {
+	if raw == "null" {
+		return nil
+	}
+	switch typ {
+	case "smallint", "integer", "bigint":
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return n
+		}
+	case "real", "double precision", "numeric":
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		return raw == "true"
+	}
+	if strings.HasPrefix(typ, "numeric(") {
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			return f
+		}
+	}
+	return raw
+}
+
+// splitQualified splits a possibly quoted schema.table name.
+func splitQualified(name string) (string, string) {
+	inQuote := false
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '"':
+			inQuote = !inQuote
+		case '.':
+			if !inQuote {
+				return unquoteIdent(name[:i]), unquoteIdent(name[i+1:])
+			}
+		}
+	}
+	return "", unquoteIdent(name)
+}
+
+func unquoteIdent(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package cdc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTestDecoding(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantSchema string
+		want       Change
+		wantErr    bool
+	}{
+		{
+			name:       "insert",
+			line:       `table public.users: INSERT: id[integer]:1 name[text]:'O''Brien' active[boolean]:true score[numeric]:1.5 note[text]:null`,
+			wantSchema: "public",
+			want: Change{Op: OpInsert, Table: "users", Row: map[string]interface{}{
+				"id": int64(1), "name": "O'Brien", "active": true, "score": 1.5, "note": nil,
+			}},
+		},
+		{
+			name:       "types with spaces and brackets",
+			line:       `table public.events: INSERT: at[timestamp without time zone]:'2024-01-01 10:00:00' tags[text[]]:'{a,b}'`,
+			wantSchema: "public",
+			want: Change{Op: OpInsert, Table: "events", Row: map[string]interface{}{
+				"at": "2024-01-01 10:00:00", "tags": "{a,b}",
+			}},
+		},
+		{
+			name:       "quoted identifiers",
+			line:       `table "Sales"."Order Lines": INSERT: "Line Id"[integer]:7`,
+			wantSchema: "Sales",
+			want:       Change{Op: OpInsert, Table: "Order Lines", Row: map[string]interface{}{"Line Id": int64(7)}},
+		},
+		{
+			name:       "update with old key",
+			line:       `table public.users: UPDATE: old-key: id[integer]:1 new-tuple: id[integer]:2 name[text]:'Alice'`,
+			wantSchema: "public",
+			want: Change{Op: OpUpdate, Table: "users",
+				OldKey: map[string]interface{}{"id": int64(1)},
+				Row:    map[string]interface{}{"id": int64(2), "name": "Alice"}},
+		},
+		{
+			name:       "update skips unchanged toast",
+			line:       `table public.docs: UPDATE: id[integer]:3 body[text]:unchanged-toast-datum`,
+			wantSchema: "public",
+			want:       Change{Op: OpUpdate, Table: "docs", Row: map[string]interface{}{"id": int64(3)}},
+		},
+		{
+			name:       "delete",
+			line:       `table public.users: DELETE: id[integer]:1`,
+			wantSchema: "public",
+			want:       Change{Op: OpDelete, Table: "users", Row: map[string]interface{}{"id": int64(1)}},
+		},
+		{
+			name:       "delete without replica identity",
+			line:       `table public.logs: DELETE: (no-tuple data)`,
+			wantSchema: "public",
+			want:       Change{Op: OpDelete, Table: "logs"},
+		},
+		{name: "not a table line", line: `message: something`, wantErr: true},
+		{name: "unknown action", line: `table public.users: TRUNCATE: (no-flags)`, wantErr: true},
+		{name: "unterminated string", line: `table public.users: INSERT: name[text]:'abc`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemaName, got, err := parseTestDecoding(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if schemaName != tt.wantSchema {
+				t.Errorf("schema = %q, want %q", schemaName, tt.wantSchema)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommitTime(t *testing.T) {
+	got, ok := parseCommitTime("COMMIT 754 (at 2024-03-01 12:30:45.123456+00)")
+	if !ok {
+		t.Fatal("expected timestamp")
+	}
+	want := time.Date(2024, 3, 1, 12, 30, 45, 123456000, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, ok := parseCommitTime("COMMIT 754"); ok {
+		t.Error("expected no timestamp without include-timestamp")
+	}
+}
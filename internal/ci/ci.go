@@ -0,0 +1,270 @@
+// Package ci runs migration phases non-interactively for CI pipelines and
+// reports on them the way GitHub Actions expects: a Markdown job summary,
+// workflow-command annotations for validation failures, and an exit code
+// that tells the kind of failure apart.
+package ci
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/report"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+// Exit codes, one per failure class. The first failing phase decides.
+const (
+	ExitOK         = 0
+	ExitError      = 1 // unclassified failure
+	ExitUsage      = 2 // bad flags, config or project state
+	ExitPrepare    = 3 // pre-migration preparation failed
+	ExitMigration  = 4 // the migration failed or partially failed
+	ExitValidation = 5 // source and target data differ
+	ExitReadiness  = 6 // production readiness checks failed
+)
+
+// Phase statuses.
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Phase is a step the runner can run. Run returns a one-line summary of the
+// outcome and may record details in the report.
+type Phase struct {
+	Name     string
+	ExitCode int // returned when the phase fails
+	Run      func(ctx context.Context, rep *Report) (string, error)
+}
+
+// PhaseResult is the outcome of one phase.
+type PhaseResult struct {
+	Name     string
+	Status   string
+	Duration time.Duration
+	Detail   string
+	Err      error
+	ExitCode int
+}
+
+// Report collects the results of a run.
+type Report struct {
+	Project    string
+	Phases     []PhaseResult
+	Validation *validation.Result
+	Readiness  *report.MigrationReport
+}
+
+// Run runs the phases in order. After a phase fails the rest are skipped.
+func Run(ctx context.Context, project string, phases []Phase) *Report {
+	rep := &Report{Project: project}
+	failed := false
+	for _, p := range phases {
+		res := PhaseResult{Name: p.Name}
+		if failed {
+			res.Status = StatusSkipped
+			rep.Phases = append(rep.Phases, res)
+			continue
+		}
+		start := time.Now()
+		res.Detail, res.Err = p.Run(ctx, rep)
+		res.Duration = time.Since(start)
+		res.Status = StatusPassed
+		if res.Err != nil {
+			res.Status = StatusFailed
+			res.ExitCode = p.ExitCode
+			if res.ExitCode == ExitOK {
+				res.ExitCode = ExitError
+			}
+			failed = true
+		}
+		rep.Phases = append(rep.Phases, res)
+	}
+	return rep
+}
+
+// ExitCode returns the exit code of the first failed phase, or ExitOK.
+func (r *Report) ExitCode() int {
+	for _, p := range r.Phases {
+		if p.Status == StatusFailed {
+			return p.ExitCode
+		}
+	}
+	return ExitOK
+}
+
+// Markdown renders the report as a job summary.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	title := "Reloquent"
+	if r.Project != "" {
+		title += ": " + r.Project
+	}
+	outcome := "All phases passed."
+	if code := r.ExitCode(); code != ExitOK {
+		outcome = fmt.Sprintf("**Failed** with exit code %d.", code)
+	}
+	fmt.Fprintf(&b, "## %s\n\n%s\n\n", title, outcome)
+
+	b.WriteString("| Phase | Status | Duration | Details |\n|---|---|---|---|\n")
+	for _, p := range r.Phases {
+		detail := p.Detail
+		if p.Err != nil {
+			detail = p.Err.Error()
+		}
+		dur := ""
+		if p.Status != StatusSkipped {
+			dur = p.Duration.Round(time.Second).String()
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", p.Name, p.Status, dur, cell(detail))
+	}
+
+	if v := r.Validation; v != nil {
+		fmt.Fprintf(&b, "\n### Validation: %s\n\n| Collection | Status | Failed checks |\n|---|---|---|\n", v.Status)
+		for _, c := range v.Collections {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", c.Name, c.Status, cell(strings.Join(failedChecks(c), "; ")))
+		}
+	}
+
+	if rd := r.Readiness; rd != nil {
+		ready := "yes"
+		if !rd.ProductionReady {
+			ready = "no"
+		}
+		fmt.Fprintf(&b, "\n### Production ready: %s\n\n", ready)
+		for _, c := range rd.ReadinessChecks {
+			mark := "x"
+			if !c.Passed {
+				mark = " "
+			}
+			fmt.Fprintf(&b, "- [%s] %s: %s\n", mark, c.Name, c.Message)
+		}
+	}
+	return b.String()
+}
+
+// cell makes text safe for a Markdown table cell.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// failedChecks describes the checks a collection failed.
+func failedChecks(c validation.CollectionResult) []string {
+	var out []string
+	if rc := c.RowCountCheck; rc != nil && !rc.Match {
+		out = append(out, fmt.Sprintf("row count: source %d, target %d", rc.SourceCount, rc.TargetCount))
+	}
+	if sc := c.SampleCheck; sc != nil && sc.MismatchCount > 0 {
+		out = append(out, fmt.Sprintf("sample: %d of %d documents differ", sc.MismatchCount, sc.Checked))
+	}
+	if ac := c.AggregateCheck; ac != nil && !ac.Match {
+		msg := "aggregates differ"
+		if ac.Message != "" {
+			msg = "aggregates: " + ac.Message
+		}
+		out = append(out, msg)
+		for _, a := range ac.Checks {
+			if a.Type == "decimal_sum" && !a.Match {
+				out = append(out, fmt.Sprintf("exact sum of %s: source %s, target %s", a.Column, a.SourceExact, a.TargetExact))
+			}
+		}
+	}
+	if cc := c.ChecksumCheck; cc != nil && !cc.Match {
+		out = append(out, fmt.Sprintf("checksum: %d of %d chunks differ", cc.MismatchedChunks, cc.Chunks))
+	}
+	if tc := c.TypeCheck; tc != nil && !tc.Match {
+		out = append(out, fmt.Sprintf("types: %d fields with unexpected BSON types", tc.MismatchFields))
+	}
+	if rc := c.ReferentialCheck; rc != nil && !rc.Match {
+		for _, r := range rc.Relationships {
+			if r.Orphans > 0 {
+				out = append(out, fmt.Sprintf("references: %s has %d of %d documents with no parent", r.Relationship(c.Name), r.Orphans, r.Checked))
+			}
+		}
+	}
+	if gc := c.GroupCheck; gc != nil && !gc.Match {
+		if gc.Message != "" {
+			out = append(out, fmt.Sprintf("consistency group %s: %s", gc.Group, gc.Message))
+		}
+		for _, r := range gc.Relationships {
+			if r.SourceCount != r.TargetCount {
+				out = append(out, fmt.Sprintf("consistency group %s: %s has %d documents with their parent, the source %d rows", gc.Group, r.Relationship(c.Name), r.TargetCount, r.SourceCount))
+			}
+		}
+	}
+	if sc := c.ShardKeyCheck; sc != nil && !sc.Match {
+		out = append(out, fmt.Sprintf("shard key: %d of %d sampled documents (%.1f%%) lack %s", sc.Missing, sc.Sampled, sc.MissingPercent, strings.Join(sc.Fields, ", ")))
+	}
+	if tc := c.TimestampCheck; tc != nil && !tc.Match {
+		for _, f := range tc.Fields {
+			if f.Mismatched > 0 {
+				out = append(out, fmt.Sprintf("timestamps: %s: %s", f.Field, f.Summary()))
+			}
+		}
+	}
+	if cc := c.CollationCheck; cc != nil && !cc.Match {
+		for _, j := range cc.Joins {
+			if j.Dropped > 0 {
+				out = append(out, fmt.Sprintf("collation: %s: %s", j.Field, j.Summary()))
+			}
+		}
+	}
+	for _, rc := range c.RuleChecks {
+		if !rc.Match {
+			out = append(out, "rule "+rc.Rule+": "+rc.Summary())
+		}
+	}
+	return out
+}
+
+// Annotations returns a workflow command for each failed validation check,
+// which GitHub shows as an error annotation on the run.
+func (r *Report) Annotations() []string {
+	if r.Validation == nil {
+		return nil
+	}
+	var out []string
+	for _, c := range r.Validation.Collections {
+		if c.Status == "PASS" {
+			continue
+		}
+		checks := failedChecks(c)
+		if len(checks) == 0 {
+			checks = []string{"validation " + strings.ToLower(c.Status)}
+		}
+		for _, msg := range checks {
+			out = append(out, fmt.Sprintf("::error title=%s::%s",
+				escapeProperty("Validation failed: "+c.Name), escapeData(msg)))
+		}
+	}
+	return out
+}
+
+// escapeData and escapeProperty encode workflow command values as the
+// Actions runner expects.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// WriteSummary appends the Markdown report to the job summary file, usually
+// $GITHUB_STEP_SUMMARY.
+func (r *Report) WriteSummary(path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening job summary: %w", err)
+	}
+	if _, err := f.WriteString(r.Markdown()); err != nil {
+		f.Close()
+		return fmt.Errorf("writing job summary: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,127 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+func phase(name string, code int, err error) Phase {
+	return Phase{Name: name, ExitCode: code, Run: func(context.Context, *Report) (string, error) {
+		return name + " done", err
+	}}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		phases   []Phase
+		want     int
+		statuses []string
+	}{
+		{
+			name:     "all pass",
+			phases:   []Phase{phase("prepare", ExitPrepare, nil), phase("migrate", ExitMigration, nil)},
+			want:     ExitOK,
+			statuses: []string{StatusPassed, StatusPassed},
+		},
+		{
+			name: "first failure decides and skips the rest",
+			phases: []Phase{
+				phase("migrate", ExitMigration, nil),
+				phase("validate", ExitValidation, errors.New("FAIL")),
+				phase("readiness", ExitReadiness, errors.New("not ready")),
+			},
+			want:     ExitValidation,
+			statuses: []string{StatusPassed, StatusFailed, StatusSkipped},
+		},
+		{
+			name:     "unclassified",
+			phases:   []Phase{phase("custom", ExitOK, errors.New("boom"))},
+			want:     ExitError,
+			statuses: []string{StatusFailed},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := Run(context.Background(), "demo", tt.phases)
+			if got := rep.ExitCode(); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+			for i, want := range tt.statuses {
+				if rep.Phases[i].Status != want {
+					t.Errorf("phase %s status = %s, want %s", rep.Phases[i].Name, rep.Phases[i].Status, want)
+				}
+			}
+		})
+	}
+}
+
+func failedValidation() *validation.Result {
+	return &validation.Result{
+		Status: "FAIL",
+		Collections: []validation.CollectionResult{
+			{Name: "users", Status: "PASS", RowCountCheck: &validation.RowCountCheck{SourceCount: 5, TargetCount: 5, Match: true}},
+			{
+				Name:          "orders",
+				Status:        "FAIL",
+				RowCountCheck: &validation.RowCountCheck{SourceCount: 10, TargetCount: 9},
+				SampleCheck:   &validation.SampleCheck{Checked: 100, MismatchCount: 2},
+			},
+		},
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	rep := &Report{Validation: failedValidation()}
+	got := rep.Annotations()
+	want := []string{
+		"::error title=Validation failed%3A orders::row count: source 10, target 9",
+		"::error title=Validation failed%3A orders::sample: 2 of 100 documents differ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Annotations() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := escapeData("50%\nnext"); got != "50%25%0Anext" {
+		t.Errorf("escapeData() = %q", got)
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	rep := Run(context.Background(), "demo", []Phase{
+		phase("migrate", ExitMigration, nil),
+		{Name: "validate", ExitCode: ExitValidation, Run: func(_ context.Context, rep *Report) (string, error) {
+			rep.Validation = failedValidation()
+			return "", errors.New("validation FAIL | 1 collection")
+		}},
+	})
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(path, []byte("earlier step\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := rep.WriteSummary(path); err != nil {
+		t.Fatalf("WriteSummary() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := string(data)
+	for _, want := range []string{
+		"earlier step\n## Reloquent: demo",
+		"**Failed** with exit code 5.",
+		"| migrate | passed |",
+		`validation FAIL \| 1 collection`,
+		"| orders | FAIL | row count: source 10, target 9; sample: 2 of 100 documents differ |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("summary missing %q:\n%s", want, md)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+// Package client calls a reloquent API server (`reloquent serve`), so the
+// interactive wizard can run on a laptop while discovery, migration and
+// validation run on a host that can reach the databases.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/api"
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/sizing"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+// Client is a reloquent API client. It is safe for concurrent use.
+type Client struct {
+	base    *url.URL
+	token   string
+	project string
+	http    *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithToken sends a bearer token, for servers started with token auth.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithProject works in the named project on the server instead of its
+// current one.
+func WithProject(name string) Option {
+	return func(c *Client) {
+		c.project = name
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		c.http = h
+	}
+}
+
+// New returns a client for the server at baseURL. A username and password
+// in the URL are sent as basic auth.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing server URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("server URL %q must be http:// or https:// with a host", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	c := &Client{base: u, http: &http.Client{Timeout: 5 * time.Minute}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// URL returns the server URL without credentials.
+func (c *Client) URL() string {
+	u := *c.base
+	u.User = nil
+	return u.String()
+}
+
+// Error is an error response from the server.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.Status, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the server.
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Status == http.StatusNotFound
+}
+
+// do sends a request with body encoded as JSON, if not nil, and decodes the
+// response into out, if not nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	u := *c.base
+	u.User = nil
+	u.Path += path
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if user := c.base.User; user != nil {
+		pass, _ := user.Password()
+		req.SetBasicAuth(user.Username(), pass)
+	}
+	if c.project != "" {
+		req.Header.Set(api.ProjectHeader, c.project)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(data, &e) != nil || e.Error == "" {
+			e.Error = strings.TrimSpace(string(data))
+		}
+		return &Error{Status: resp.StatusCode, Message: e.Error}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// Health checks that the server answers.
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/api/health", nil, nil)
+}
+
+// State returns the project's wizard state.
+func (c *Client) State(ctx context.Context) (*api.StateResponse, error) {
+	var st api.StateResponse
+	if err := c.do(ctx, http.MethodGet, "/api/state", nil, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// SetStep moves the project to a wizard step.
+func (c *Client) SetStep(ctx context.Context, step state.Step) error {
+	return c.do(ctx, http.MethodPut, "/api/state/step", api.SetStepRequest{Step: string(step)}, nil)
+}
+
+// Discover connects to the source from the server, discovers its schema and
+// records the source with the project.
+func (c *Client) Discover(ctx context.Context, cfg *config.SourceConfig) (*schema.Schema, error) {
+	req := api.SourceConfigRequest{
+		Type:     cfg.Type,
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Database: cfg.Database,
+		Schema:   cfg.Schema,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		SSL:      cfg.SSL,
+	}
+	var s schema.Schema
+	if err := c.do(ctx, http.MethodPost, "/api/source/discover", req, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Schema returns the project's discovered schema.
+func (c *Client) Schema(ctx context.Context) (*schema.Schema, error) {
+	var s schema.Schema
+	if err := c.do(ctx, http.MethodGet, "/api/source/schema", nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// TestTargetConnection connects to MongoDB from the server.
+func (c *Client) TestTargetConnection(ctx context.Context, cfg *config.TargetConfig) error {
+	var resp api.ConnectionTestResponse
+	req := api.TargetConfigRequest{ConnectionString: cfg.ConnectionString, Database: cfg.Database}
+	if err := c.do(ctx, http.MethodPost, "/api/target/test-connection", req, &resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s", resp.Error)
+	}
+	return nil
+}
+
+// SaveTargetConfig records the target with the project.
+func (c *Client) SaveTargetConfig(ctx context.Context, cfg *config.TargetConfig) error {
+	req := api.TargetConfigRequest{ConnectionString: cfg.ConnectionString, Database: cfg.Database}
+	return c.do(ctx, http.MethodPut, "/api/target/config", req, nil)
+}
+
+// Table is a discovered table and whether it is selected for migration.
+type Table struct {
+	Name      string `json:"name"`
+	RowCount  int64  `json:"row_count"`
+	SizeBytes int64  `json:"size_bytes"`
+	Selected  bool   `json:"selected"`
+}
+
+// Tables returns the discovered tables.
+func (c *Client) Tables(ctx context.Context) ([]Table, error) {
+	var tables []Table
+	if err := c.do(ctx, http.MethodGet, "/api/tables", nil, &tables); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// SelectTables sets the tables to migrate.
+func (c *Client) SelectTables(ctx context.Context, names []string) error {
+	return c.do(ctx, http.MethodPost, "/api/tables/select", api.SelectTablesRequest{Tables: names}, nil)
+}
+
+// Mapping returns the project's mapping, or nil if none has been designed.
+func (c *Client) Mapping(ctx context.Context) (*mapping.Mapping, error) {
+	var m mapping.Mapping
+	if err := c.do(ctx, http.MethodGet, "/api/mapping", nil, &m); err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// SaveMapping replaces the project's mapping.
+func (c *Client) SaveMapping(ctx context.Context, m *mapping.Mapping) error {
+	return c.do(ctx, http.MethodPost, "/api/mapping", m, nil)
+}
+
+// MappingIssues returns the problems the mapping lint finds in the
+// project's mapping.
+func (c *Client) MappingIssues(ctx context.Context) (mapping.Issues, error) {
+	var resp api.MappingIssuesResponse
+	if err := c.do(ctx, http.MethodGet, "/api/mapping/lint", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Issues, nil
+}
+
+// TypeMapEntry is the BSON type a source type maps to.
+type TypeMapEntry struct {
+	SourceType string `json:"source_type"`
+	Label      string `json:"label,omitempty"`
+	BSONType   string `json:"bson_type"`
+	Overridden bool   `json:"overridden"`
+}
+
+// TypeMap returns the project's type mapping.
+func (c *Client) TypeMap(ctx context.Context) ([]TypeMapEntry, error) {
+	var entries []TypeMapEntry
+	if err := c.do(ctx, http.MethodGet, "/api/typemap", nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// SaveTypeMap sets the BSON type of the given source types. A type set to
+// its default is no longer overridden.
+func (c *Client) SaveTypeMap(ctx context.Context, bsonTypes map[string]string) error {
+	return c.do(ctx, http.MethodPost, "/api/typemap", bsonTypes, nil)
+}
+
+// UniqueConstraints returns how the source unique constraints carry over to
+// MongoDB.
+func (c *Client) UniqueConstraints(ctx context.Context) (*api.UniqueConstraintsResponse, error) {
+	var resp api.UniqueConstraintsResponse
+	if err := c.do(ctx, http.MethodGet, "/api/unique-constraints", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Sizing computes the sizing plan on the server.
+func (c *Client) Sizing(ctx context.Context) (*sizing.SizingPlan, error) {
+	var plan sizing.SizingPlan
+	if err := c.do(ctx, http.MethodGet, "/api/sizing", nil, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// PrepareTarget creates the collections and sets the migration write
+// concern on the target.
+func (c *Client) PrepareTarget(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/api/premigration/prepare", nil, nil)
+}
+
+// StartMigration starts a full migration on the server.
+func (c *Client) StartMigration(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/api/migration/start", nil, nil)
+}
+
+// MigrationStatus returns the status of the running or last migration.
+func (c *Client) MigrationStatus(ctx context.Context) (*migration.Status, error) {
+	var st migration.Status
+	if err := c.do(ctx, http.MethodGet, "/api/migration/status", nil, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// RunValidation starts validation on the server.
+func (c *Client) RunValidation(ctx context.Context, cfg validation.Config) error {
+	return c.do(ctx, http.MethodPost, "/api/validation/run", api.RunValidationRequest{Config: cfg}, nil)
+}
+
+// ValidationResults returns the last validation result, or nil if
+// validation has not finished since the server started.
+func (c *Client) ValidationResults(ctx context.Context) (*validation.Result, error) {
+	var r validation.Result
+	if err := c.do(ctx, http.MethodGet, "/api/validation/results", nil, &r); err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &r, nil
+}
+
+// IndexPlan returns the saved or inferred index plan.
+func (c *Client) IndexPlan(ctx context.Context) (*indexes.IndexPlan, error) {
+	var plan indexes.IndexPlan
+	if err := c.do(ctx, http.MethodGet, "/api/indexes/plan", nil, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// SaveIndexPlan saves an edited index plan.
+func (c *Client) SaveIndexPlan(ctx context.Context, plan *indexes.IndexPlan) error {
+	return c.do(ctx, http.MethodPut, "/api/indexes/plan", plan, nil)
+}
+
+// BuildIndexes starts building the index plan on the server.
+func (c *Client) BuildIndexes(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/api/indexes/build", nil, nil)
+}
+
+// IndexStatus returns the progress of the index builds.
+func (c *Client) IndexStatus(ctx context.Context) (*engine.IndexBuildStatusResult, error) {
+	var st engine.IndexBuildStatusResult
+	if err := c.do(ctx, http.MethodGet, "/api/indexes/status", nil, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/api"
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+// testServer serves the API with token auth from a fresh project.
+func testServer(t *testing.T) (*httptest.Server, *engine.Engine) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	eng := engine.New(&config.Config{Version: 1}, slog.Default())
+	auth, err := api.NewAuthenticator(context.Background(), config.AuthConfig{Mode: api.AuthToken, Token: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(api.New(eng, slog.Default(), 0, api.WithAuth(auth)).Handler())
+	t.Cleanup(srv.Close)
+	return srv, eng
+}
+
+func TestNew(t *testing.T) {
+	for _, u := range []string{"", "bastion:8230", "ftp://bastion", "http://"} {
+		if _, err := New(u); err == nil {
+			t.Errorf("New(%q) should fail", u)
+		}
+	}
+	c, err := New("https://admin:pw@bastion:8230/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.URL(); got != "https://bastion:8230" {
+		t.Errorf("URL() = %q, want the URL without credentials", got)
+	}
+}
+
+func TestClient_Auth(t *testing.T) {
+	srv, _ := testServer(t)
+	ctx := context.Background()
+
+	c, _ := New(srv.URL)
+	if _, err := c.State(ctx); err == nil {
+		t.Fatal("expected an error without the token")
+	} else if e, ok := err.(*Error); !ok || e.Status != 401 {
+		t.Errorf("err = %v, want a 401", err)
+	}
+
+	c, _ = New(srv.URL, WithToken("s3cret"))
+	st, err := c.State(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.CurrentStep != string(state.StepSourceConnection) {
+		t.Errorf("current step = %q", st.CurrentStep)
+	}
+}
+
+func TestClient_Design(t *testing.T) {
+	srv, eng := testServer(t)
+	ctx := context.Background()
+	c, _ := New(srv.URL, WithToken("s3cret"))
+
+	if _, err := c.Schema(ctx); !IsNotFound(err) {
+		t.Errorf("schema before discovery: err = %v, want not found", err)
+	}
+	if m, err := c.Mapping(ctx); m != nil || err != nil {
+		t.Errorf("mapping before design = %v, %v; want nil, nil", m, err)
+	}
+
+	eng.Schema = &schema.Schema{DatabaseType: "postgresql", Tables: []schema.Table{
+		{Name: "users", RowCount: 10, Columns: []schema.Column{{Name: "id", DataType: "integer"}}},
+		{Name: "orders", Columns: []schema.Column{{Name: "id", DataType: "integer"}}},
+	}}
+	if err := c.SelectTables(ctx, []string{"users"}); err != nil {
+		t.Fatal(err)
+	}
+	tables, err := c.Tables(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 || !tables[0].Selected || tables[1].Selected || tables[0].RowCount != 10 {
+		t.Errorf("tables = %+v, want users selected", tables)
+	}
+
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "users", SourceTable: "users"}}}
+	if err := c.SaveMapping(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Mapping(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Collections) != 1 || got.Collections[0].Name != "users" {
+		t.Errorf("mapping = %+v", got)
+	}
+
+	if err := c.SaveTypeMap(ctx, map[string]string{"integer": "long"}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := c.TypeMap(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var overridden bool
+	for _, e := range entries {
+		if e.SourceType == "integer" {
+			overridden = e.Overridden && e.BSONType == "long"
+		}
+	}
+	if !overridden {
+		t.Errorf("integer should map to long: %+v", entries)
+	}
+}
+
+func TestClient_SaveTargetConfig(t *testing.T) {
+	srv, eng := testServer(t)
+	ctx := context.Background()
+	c, _ := New(srv.URL, WithToken("s3cret"))
+
+	cfg := &config.TargetConfig{ConnectionString: "mongodb://mongo:27017", Database: "shop"}
+	if err := c.SaveTargetConfig(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if eng.Config.Target.Database != "shop" || eng.State.TargetConfig == nil || eng.State.TargetConfig.ConnectionString != cfg.ConnectionString {
+		t.Errorf("target not recorded: config %+v, state %+v", eng.Config.Target, eng.State.TargetConfig)
+	}
+
+	if err := c.SaveTargetConfig(ctx, &config.TargetConfig{}); err == nil {
+		t.Error("expected an error for an empty target")
+	}
+}
@@ -3,6 +3,8 @@ package codegen
 import (
 	"bytes"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -10,40 +12,104 @@ import (
 	"github.com/reloquent/reloquent/internal/drivers"
 	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
 	"github.com/reloquent/reloquent/internal/transform"
 	"github.com/reloquent/reloquent/internal/typemap"
 )
 
+// CheckpointCollection is the target collection the generated script records
+// completed partitions in, so an interrupted migration can be resumed.
+const CheckpointCollection = "_reloquent_checkpoints"
+
+// DefaultCheckpointPartitions is the number of ranges each root table is
+// split into for checkpointing.
+const DefaultCheckpointPartitions = 8
+
+// Environment variables the generated script reads credentials from when the
+// config holds them as plaintext or in a store the cluster cannot reach, such
+// as the OS keychain.
+const (
+	SourcePasswordEnv = "RELOQUENT_SOURCE_PASSWORD"
+	TargetPasswordEnv = "RELOQUENT_TARGET_PASSWORD"
+	TargetURIEnv      = "RELOQUENT_TARGET_URI"
+
+	// A parameterized script also reads its connection settings from these.
+	SourceJDBCURLEnv  = "RELOQUENT_SOURCE_JDBC_URL"
+	SourceUserEnv     = "RELOQUENT_SOURCE_USER"
+	TargetDatabaseEnv = "RELOQUENT_TARGET_DATABASE"
+)
+
 // Generator produces PySpark migration scripts.
 type Generator struct {
 	Config  *config.Config
 	Schema  *schema.Schema
 	Mapping *mapping.Mapping
 	TypeMap *typemap.TypeMap
+	// Checkpoints from an interrupted run; completed partitions are skipped.
+	// Ignored by delta runs.
+	Checkpoints map[string]*state.Checkpoint
+	// Delta generates an incremental run: each collection with a watermark
+	// reads only the rows in its range from Watermarks and upserts them, and
+	// collections without one are skipped.
+	Delta      bool
+	Watermarks map[string]mapping.WatermarkRange
+	// Parameterized reads the source JDBC URL, user and password and the
+	// target URI and database from environment variables instead of writing
+	// them into the script, so one reviewed script can run in every
+	// environment. The values for this config come back as the result's
+	// Parameters.
+	Parameterized bool
+
+	// snapshotSCN is the script variable holding the SCN the reads of an
+	// Oracle consistency group are made as of, while generating them.
+	snapshotSCN string
 }
 
 // GenerateResult contains the generated PySpark code.
 type GenerateResult struct {
 	MigrationScript string
 	OracleGuidance  string // non-empty if Oracle JDBC is missing
+	// Parameters is the environment file with this config's values for a
+	// parameterized script.
+	Parameters string
+	// Runbook is the README written into the bundle with the script.
+	Runbook string
+	// MappingHash is the hash of the mapping and type mapping the script was
+	// generated from, also written in its header.
+	MappingHash string
+	// Warnings list the columns whose names are not safe document keys,
+	// each with the field it is written as.
+	Warnings []string
 }
 
 // Generate produces the PySpark migration script.
 func (g *Generator) Generate() (*GenerateResult, error) {
 	var buf bytes.Buffer
 
-	tmpl, err := template.New("migration").Parse(migrationTemplate)
+	tmpl, err := template.New("migration").Funcs(template.FuncMap{"indent": indent}).Parse(migrationTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("parsing template: %w", err)
 	}
 
-	data := g.buildTemplateData()
+	data, err := g.buildTemplateData()
+	if err != nil {
+		return nil, err
+	}
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return nil, fmt.Errorf("executing template: %w", err)
 	}
 
 	result := &GenerateResult{
 		MigrationScript: buf.String(),
+		Runbook:         g.runbook(data),
+		MappingHash:     data.MappingHash,
+	}
+	for _, f := range g.Mapping.UnsafeFields(g.Schema) {
+		result.Warnings = append(result.Warnings, f.Warning())
+	}
+	if g.Parameterized {
+		result.Parameters = parametersFile(g.Config)
 	}
 
 	// Check Oracle JDBC
@@ -57,50 +123,186 @@ func (g *Generator) Generate() (*GenerateResult, error) {
 }
 
 type templateData struct {
-	SourceType     string
-	JDBCUrl        string
-	MongoURI       string
-	MongoDatabase  string
-	Collections    []collectionData
-	MaxConnections int
-	HasTransforms  bool
-	OracleGuidance string
+	SourceType           string
+	JDBCUrl              string
+	SourceUser           string
+	SourcePassword       string
+	MongoURI             string
+	MongoDatabase        string
+	Collections          []collectionData
+	Stages               int // collection stages; see mapping.Mapping.Stages
+	MaxConnections       int
+	HasTransforms        bool
+	HasFields            bool
+	HasLOBOffload        bool // offload_lob is used, for large objects or fields offloaded to GridFS
+	HasFieldOffload      bool // an embedded field is offloaded
+	HasGeoJSON           bool // geometry columns are parsed into GeoJSON documents
+	HasRowErrors         bool // a collection skips or quarantines documents that cannot be written
+	HasMasks             bool // a column is masked; see transform.IsMask
+	HasTimestamps        bool // a timestamp column has no time zone; the session reads them as UTC
+	FirstNames           string
+	LastNames            string
+	ErrorCollection      string
+	OracleGuidance       string
+	CheckpointCollection string
+	CheckpointPartitions int
+	Parameterized        bool
+	MappingHash          string // see MappingHash
 }
 
 type collectionData struct {
 	Name          string
 	SourceTable   string
+	ReadTable     string // JDBC table argument, filtered when the mapping says so
 	PartitionCol  string
 	NumPartitions int
-	Operations    []string // ordered PySpark operation lines
+	Setup         []string // operations that do not depend on the root partition
+	Operations    []string // ordered PySpark operation lines, run per partition
+	Checkpointed  bool     // split into partition ranges that can be resumed
+	IDField       string   // document field holding the partition column
+	Completed     string   // Python set of completed (index, lower, upper) tuples
+	Skip          string   // reason the collection is not migrated, if any
+	Delta         bool     // upsert the rows changed since the last run
+	Archive       string   // PySpark code writing the rows diverted to S3, if any
+	Group         string   // consistency group the collection is in, if any
+	GroupStart    bool     // first collection of its group
+	Stage         int      // 1-based stage the collection is migrated in
+	StageStart    bool     // first collection of its stage
+	OnError       string   // row error policy; see mapping.Collection.ErrorPolicy
+	QuarantineKey string   // Python dict of key columns to document fields, under quarantine
 }
 
-func (g *Generator) buildTemplateData() templateData {
+func (g *Generator) buildTemplateData() (templateData, error) {
 	jdbcURL := buildJDBCURL(g.Config.Source)
 
-	var hasTransforms bool
+	var hasTransforms, hasFields, hasOffload, hasFieldOffload, hasGeoJSON, hasRowErrors, hasMasks, hasTimestamps bool
+	// Collections are written stage by stage, after those they depend on
+	stages, err := g.Mapping.Stages(g.Mapping.Collections)
+	if err != nil {
+		return templateData{}, err
+	}
+	if err := g.checkTimestampZones(); err != nil {
+		return templateData{}, err
+	}
+	if err := g.Mapping.ValidateFieldNames(g.Schema); err != nil {
+		return templateData{}, err
+	}
+	stageOf := make(map[string]int)
+	for i, st := range stages {
+		for _, c := range st {
+			stageOf[c.Name] = i + 1
+		}
+	}
+
 	var collections []collectionData
-	for _, c := range g.Mapping.Collections {
+	var group string
+	stage := 0
+	defer func() { g.snapshotSCN = "" }()
+	for _, c := range slices.Concat(stages...) {
+		// Group members follow each other; on Oracle they are read as of
+		// the SCN taken when the group starts
+		grp, start := g.Mapping.GroupOf(c.Name), false
+		g.snapshotSCN = ""
+		if grp != nil {
+			start = grp.Name != group
+			group = grp.Name
+			if g.Config.Source.Type == "oracle" {
+				g.snapshotSCN = "group_scn"
+			}
+		} else {
+			group = ""
+		}
+
 		partCol := findPartitionColumn(g.Schema, c.SourceTable)
-		ops := g.buildPySparkOperations(c.Name, &c, g.Config.Source.MaxConnections, jdbcURL)
+		idField, checkpointed := checkpointField(g.Schema, &c, partCol)
+		rng, delta := g.Watermarks[c.Name]
+		filter := c.Filter
+		c.Filter = c.LiveFilter()
+		if g.Delta && delta {
+			if !checkpointed {
+				return templateData{}, fmt.Errorf("collection %s: delta migration needs a numeric key column to upsert on", c.Name)
+			}
+			// Read only the root rows in the watermark range
+			c.Filter = rng.Filter(c.Filter)
+		}
+		setup, ops := g.buildPySparkOperations(c.Name, &c, g.Config.Source.MaxConnections, checkpointed)
 
 		// Check if any transforms are present
 		if len(c.Transformations) > 0 {
 			hasTransforms = true
 		}
+		if transform.HasMasks(c.Transformations) || masksInEmbedded(c.Embedded) {
+			hasMasks = true
+		}
 		for _, e := range c.Embedded {
 			if hasTransformsInEmbedded(e) {
 				hasTransforms = true
 			}
+			if hasFieldsInEmbedded(e) {
+				hasFields = true
+			}
+		}
+		if len(c.Fields) > 0 || len(c.FieldOrder) > 0 {
+			hasFields = true
+		}
+		if g.offloadsLOBs(c.SourceTable) || g.offloadsLOBsInEmbedded(c.Embedded) {
+			hasOffload = true
+		}
+		if g.parsesGeoJSON(c.SourceTable, c.Embedded) {
+			hasGeoJSON = true
+		}
+		if g.readsTimestamps(c.SourceTable, c.Embedded) {
+			hasTimestamps = true
+		}
+		for _, e := range c.Embedded {
+			if e.Offload == nil {
+				continue
+			}
+			hasFieldOffload = true
+			if e.Offload.Strategy == mapping.OffloadGridFS {
+				hasOffload = true
+			}
 		}
 
-		collections = append(collections, collectionData{
+		cd := collectionData{
 			Name:          c.Name,
 			SourceTable:   c.SourceTable,
+			ReadTable:     g.jdbcTable(c.SourceTable, c.Filter),
 			PartitionCol:  partCol,
 			NumPartitions: g.Config.Source.MaxConnections,
+			Setup:         setup,
 			Operations:    ops,
-		})
+			Checkpointed:  checkpointed,
+			IDField:       idField,
+			Delta:         g.Delta && delta,
+			GroupStart:    start,
+			Stage:         stageOf[c.Name],
+			StageStart:    stageOf[c.Name] != stage,
+			OnError:       c.ErrorPolicy(),
+		}
+		stage = cd.Stage
+		if cd.OnError != mapping.OnErrorFail {
+			hasRowErrors = true
+		}
+		if cd.OnError == mapping.OnErrorQuarantine {
+			cd.QuarantineKey = quarantineKeys(g.Schema, &c)
+		}
+		if grp != nil {
+			cd.Group = grp.Name
+		}
+		if c.Archive != nil && !g.Delta {
+			cd.Archive = g.archiveOperation(&c, filter, partCol)
+		}
+		if cp := g.Checkpoints[c.Name]; cp != nil && !g.Delta {
+			if cp.Done {
+				cd.Skip = "already migrated"
+			}
+			cd.Completed = completedSet(cp.Completed)
+		}
+		if g.Delta && !delta {
+			cd.Skip = "no watermark column for a delta run"
+		}
+		collections = append(collections, cd)
 	}
 
 	var guidance string
@@ -109,17 +311,278 @@ func (g *Generator) buildTemplateData() templateData {
 			guidance = drivers.OracleJDBCGuidance()
 		}
 	}
+	hash, err := MappingHash(g.Mapping, g.TypeMap)
+	if err != nil {
+		return templateData{}, err
+	}
 
 	return templateData{
-		SourceType:     g.Config.Source.Type,
-		JDBCUrl:        jdbcURL,
-		MongoURI:       g.Config.Target.ConnectionString,
-		MongoDatabase:  g.Config.Target.Database,
-		Collections:    collections,
-		MaxConnections: g.Config.Source.MaxConnections,
-		HasTransforms:  hasTransforms,
-		OracleGuidance: guidance,
+		SourceType:      g.Config.Source.Type,
+		JDBCUrl:         jdbcURL,
+		SourceUser:      g.Config.Source.Username,
+		SourcePassword:  scriptSecret(g.Config.Source.Password, SourcePasswordEnv),
+		MongoURI:        scriptMongoURI(g.Config.Target.ConnectionString),
+		MongoDatabase:   g.Config.Target.Database,
+		Collections:     collections,
+		Stages:          len(stages),
+		MaxConnections:  g.Config.Source.MaxConnections,
+		HasTransforms:   hasTransforms,
+		HasFields:       hasFields,
+		HasLOBOffload:   hasOffload,
+		HasFieldOffload: hasFieldOffload,
+		HasGeoJSON:      hasGeoJSON,
+		HasRowErrors:    hasRowErrors,
+		HasMasks:        hasMasks,
+		HasTimestamps:   hasTimestamps,
+		FirstNames:      pyList(transform.FirstNames),
+		LastNames:       pyList(transform.LastNames),
+		OracleGuidance:  guidance,
+
+		CheckpointCollection: CheckpointCollection,
+		ErrorCollection:      mapping.ErrorCollection,
+		CheckpointPartitions: DefaultCheckpointPartitions,
+		Parameterized:        g.Parameterized,
+		MappingHash:          hash,
+	}, nil
+}
+
+// scriptSecret returns the form of a credential to write into the generated
+// script. Environment and Secrets Manager references are resolved by the
+// script itself at run time; anything else is swapped for a reference to env
+// so no secret is written into the script.
+func scriptSecret(val, env string) string {
+	if val == "" || config.IsPortableReference(val) {
+		return val
+	}
+	return "${ENV:" + env + "}"
+}
+
+// scriptMongoURI applies scriptSecret to the password in a MongoDB URI,
+// rewritten to the scheme the Spark connector takes.
+func scriptMongoURI(uri string) string {
+	uri = target.WireURI(uri)
+	pw, ok := config.URIPassword(uri)
+	if !ok {
+		if config.IsReference(uri) {
+			return scriptSecret(uri, TargetURIEnv)
+		}
+		return uri
+	}
+	return config.WithURIPassword(uri, scriptSecret(pw, TargetPasswordEnv))
+}
+
+// checkpointField reports whether a collection can be migrated in resumable
+// partition ranges, and the document field the partition column ends up in.
+// That needs a numeric root column that survives the collection transforms,
+// since resumed partitions are upserted on it, and that stays a top-level
+// field after the field mappings.
+func checkpointField(s *schema.Schema, c *mapping.Collection, partCol string) (string, bool) {
+	if !hasColumn(s, c.SourceTable, partCol) {
+		return "", false
+	}
+	field, ok := documentField(c, partCol)
+	if !ok || strings.Contains(field, ".") {
+		return "", false
+	}
+	return field, true
+}
+
+// documentField returns the document path a root column ends up in after
+// the collection transforms and field mappings, or false if it is dropped.
+func documentField(c *mapping.Collection, column string) (string, bool) {
+	field := column
+	for _, t := range c.Transformations {
+		if t.SourceField != field {
+			continue
+		}
+		switch t.Operation {
+		case transform.OpExclude:
+			return "", false
+		case transform.OpRename:
+			field = t.TargetField
+		}
+	}
+	return mapping.FieldTarget(c.Fields, field)
+}
+
+// quarantineKeys renders the Python dict from a table's primary key columns
+// to the document fields holding them, which a quarantined row is recorded
+// by. It is empty when the table has no primary key or one of its columns
+// does not reach the document.
+func quarantineKeys(s *schema.Schema, c *mapping.Collection) string {
+	var pairs []string
+	for _, t := range s.Tables {
+		if t.Name != c.SourceTable || t.PrimaryKey == nil {
+			continue
+		}
+		for _, col := range t.PrimaryKey.Columns {
+			field, ok := documentField(c, col)
+			if !ok {
+				return "{}"
+			}
+			pairs = append(pairs, fmt.Sprintf("%q: %q", col, field))
+		}
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// CheckpointKeys returns the root column a collection's checkpointed
+// partitions are ranges of and the top-level document field it is written
+// to. ok is false when the collection is not migrated in resumable
+// partitions.
+func CheckpointKeys(s *schema.Schema, c *mapping.Collection) (column, field string, ok bool) {
+	column = findPartitionColumn(s, c.SourceTable)
+	field, ok = checkpointField(s, c, column)
+	return column, field, ok
+}
+
+func hasColumn(s *schema.Schema, table, column string) bool {
+	for _, t := range s.Tables {
+		if t.Name != table {
+			continue
+		}
+		for _, col := range t.Columns {
+			if col.Name == column && isNumericType(col.DataType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// completedSet renders completed partitions as a Python set literal.
+func completedSet(parts []state.PartitionCheckpoint) string {
+	if len(parts) == 0 {
+		return ""
+	}
+	items := make([]string, len(parts))
+	for i, p := range parts {
+		items[i] = fmt.Sprintf("(%d, %d, %d)", p.Index, p.Lower, p.Upper)
+	}
+	return "{" + strings.Join(items, ", ") + "}"
+}
+
+// indent shifts a block of Python code into a loop body.
+func indent(code string) string {
+	return "    " + strings.ReplaceAll(code, "\n", "\n    ")
+}
+
+func hasFieldsInEmbedded(e mapping.Embedded) bool {
+	if len(e.Fields) > 0 || len(e.FieldOrder) > 0 {
+		return true
+	}
+	for _, child := range e.Embedded {
+		if hasFieldsInEmbedded(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldColumnsArgs renders field mappings as the mapping dict and exclude
+// list arguments of the generated field_columns helper, followed by the
+// field order when there is one. Prefix groups are expanded over the
+// table's columns.
+func fieldColumnsArgs(fields []mapping.FieldMapping, columns, order []string) string {
+	var targets, excluded []string
+	explicit := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if f.Prefix != "" {
+			continue
+		}
+		explicit[f.Column] = true
+		if f.Exclude {
+			excluded = append(excluded, fmt.Sprintf("%q", f.Column))
+		} else if f.Target != "" {
+			targets = append(targets, fmt.Sprintf("%q: %q", f.Column, f.Target))
+		}
+	}
+	for _, col := range columns {
+		if explicit[col] {
+			continue
+		}
+		path, ok := mapping.FieldTarget(fields, col)
+		switch {
+		case !ok:
+			excluded = append(excluded, fmt.Sprintf("%q", col))
+		case path != col:
+			targets = append(targets, fmt.Sprintf("%q: %q", col, path))
+		}
+	}
+	args := "{" + strings.Join(targets, ", ") + "}, [" + strings.Join(excluded, ", ") + "]"
+	if len(order) > 0 {
+		quoted := make([]string, len(order))
+		for i, p := range order {
+			quoted[i] = fmt.Sprintf("%q", p)
+		}
+		args += ", [" + strings.Join(quoted, ", ") + "]"
+	}
+	return args
+}
+
+// lobOperations returns the PySpark lines that apply the type map's large
+// object strategies to a table just read into df: skipped columns are
+// dropped and offloaded ones replaced by a reference to the stored value.
+// Inlined columns are left as they are.
+func (g *Generator) lobOperations(table, df string) []string {
+	if g.TypeMap == nil {
+		return nil
+	}
+	var ops []string
+	for _, lob := range g.TypeMap.LOBColumns(g.Schema) {
+		if lob.Table != table {
+			continue
+		}
+		switch {
+		case lob.Strategy == typemap.LOBSkip:
+			ops = append(ops, fmt.Sprintf("%s = %s.drop(%q)", df, df, lob.Column))
+		case lob.Offloaded():
+			ops = append(ops, fmt.Sprintf("%s = offload_lob(%s, %q, %q, %q, %q)",
+				df, df, table, lob.Column, lob.Strategy, lob.Location))
+		}
 	}
+	return ops
+}
+
+func (g *Generator) offloadsLOBs(table string) bool {
+	if g.TypeMap == nil {
+		return false
+	}
+	for _, lob := range g.TypeMap.LOBColumns(g.Schema) {
+		if lob.Table == table && lob.Offloaded() {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Generator) offloadsLOBsInEmbedded(embedded []mapping.Embedded) bool {
+	for _, e := range embedded {
+		if g.offloadsLOBs(e.SourceTable) || g.offloadsLOBsInEmbedded(e.Embedded) {
+			return true
+		}
+	}
+	return false
+}
+
+// masksInEmbedded reports whether an embedded table at any depth masks a
+// column.
+func masksInEmbedded(embs []mapping.Embedded) bool {
+	for _, e := range embs {
+		if transform.HasMasks(e.Transformations) || masksInEmbedded(e.Embedded) {
+			return true
+		}
+	}
+	return false
+}
+
+// pyList renders strings as a Python list literal.
+func pyList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = strconv.Quote(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
 }
 
 func hasTransformsInEmbedded(e mapping.Embedded) bool {
@@ -134,22 +597,67 @@ func hasTransformsInEmbedded(e mapping.Embedded) bool {
 	return false
 }
 
+// archiveOperation generates the code that writes the root rows the
+// collection's archive policy diverts, as they are in the source table, to
+// Parquet files under the policy's path for the collection.
+func (g *Generator) archiveOperation(c *mapping.Collection, filter, partCol string) string {
+	read := fmt.Sprintf(`# Rows with %s before %s go to the archive, not MongoDB
+%s_archive_df = spark.read.jdbc(
+    url=jdbc_url,
+    table=%s,
+    column="%s",
+    lowerBound=0,
+    upperBound=1000000,
+    numPartitions=%d,
+    properties=jdbc_properties,
+)`,
+		c.Archive.Column, c.Archive.Before, c.Name, g.jdbcTable(c.SourceTable, c.Archive.Filter(filter)), partCol,
+		g.Config.Source.MaxConnections)
+	// Masked columns are masked in the archive too
+	lines := []string{read}
+	for _, t := range c.Transformations {
+		if transform.IsMask(t.Operation) {
+			lines = append(lines, transform.ToPySpark(t, c.Name+"_archive_df"))
+		}
+	}
+	lines = append(lines, fmt.Sprintf(`%s_archive_df.write.mode("overwrite").parquet("%s")
+print("Archived old rows of %s to %s")`, c.Name, c.Archive.Path(c.Name), c.Name, c.Archive.Path(c.Name)))
+	return strings.Join(lines, "\n")
+}
+
 // buildPySparkOperations generates the ordered code blocks for a collection.
 // Bottom-up: read leaves first, groupBy+collect_list, join into parent, repeat upward.
-func (g *Generator) buildPySparkOperations(rootDF string, c *mapping.Collection, numPartitions int, jdbcURL string) []string {
-	var ops []string
-
+// Embedded tables are prepared once in setup; the root table is read and
+// joined per partition range (lower, upper) when checkpointed.
+func (g *Generator) buildPySparkOperations(rootDF string, c *mapping.Collection, numPartitions int, checkpointed bool) (setup, ops []string) {
 	// Read root table
 	partCol := findPartitionColumn(g.Schema, c.SourceTable)
-	ops = append(ops, fmt.Sprintf(`%s_df = spark.read.jdbc(
+	if checkpointed {
+		ops = append(ops, fmt.Sprintf(`%s_df = spark.read.jdbc(
     url=jdbc_url,
-    table="%s",
+    table=%s,
+    column="%s",
+    lowerBound=lower,
+    upperBound=upper,
+    numPartitions=%d,
+    properties=jdbc_properties,
+).where(f"%s >= {lower} AND %s < {upper}")`, rootDF, g.jdbcTable(c.SourceTable, c.Filter), partCol, numPartitions, partCol, partCol))
+	} else {
+		ops = append(ops, fmt.Sprintf(`%s_df = spark.read.jdbc(
+    url=jdbc_url,
+    table=%s,
     column="%s",
     lowerBound=0,
     upperBound=1000000,
     numPartitions=%d,
     properties=jdbc_properties,
-)`, rootDF, c.SourceTable, partCol, numPartitions))
+)`, rootDF, g.jdbcTable(c.SourceTable, c.Filter), partCol, numPartitions))
+	}
+
+	ops = append(ops, g.lobOperations(c.SourceTable, rootDF+"_df")...)
+	ops = append(ops, g.timestampOperations(c.SourceTable, rootDF+"_df")...)
+	ops = append(ops, g.numberOperations(c.SourceTable, rootDF+"_df")...)
+	ops = append(ops, g.geoOperations(c.SourceTable, rootDF+"_df")...)
 
 	// Apply collection-level transforms
 	if len(c.Transformations) > 0 {
@@ -157,18 +665,32 @@ func (g *Generator) buildPySparkOperations(rootDF string, c *mapping.Collection,
 		ops = append(ops, transformLines...)
 	}
 
-	// Process embedded tables bottom-up recursively
+	// Process embedded tables bottom-up recursively. Only the final join
+	// into the root depends on the partition; the rest is cached in setup.
 	for _, emb := range c.Embedded {
-		embOps := g.buildEmbeddedOperations(rootDF+"_df", &emb, numPartitions)
-		ops = append(ops, embOps...)
+		embOps := g.buildEmbeddedOperations(c.Name, rootDF+"_df", &emb, numPartitions)
+		last := len(embOps) - 1
+		setup = append(setup, embOps[:last]...)
+		nestedDF := emb.SourceTable + "_nested"
+		setup = append(setup, fmt.Sprintf("%s = %s.cache()", nestedDF, nestedDF))
+		ops = append(ops, embOps[last])
 	}
 
-	return ops
+	// Rename, exclude and nest root columns once the joins are done
+	if len(c.Fields) > 0 || len(c.FieldOrder) > 0 {
+		args := fieldColumnsArgs(c.Fields, mapping.TableColumns(g.Schema, c.SourceTable, c.Transformations), c.FieldOrder)
+		ops = append(ops, fmt.Sprintf("%s_df = %s_df.select(*field_columns(%s_df, %s))",
+			rootDF, rootDF, rootDF, args))
+	}
+
+	return setup, ops
 }
 
 // buildEmbeddedOperations generates PySpark code for an embedded table and its children.
-// Processes bottom-up: children first, then this level.
-func (g *Generator) buildEmbeddedOperations(parentDFName string, emb *mapping.Embedded, numPartitions int) []string {
+// Processes bottom-up: children first, then this level. A field offloaded
+// from the collection is written to GridFS or its side collection before
+// the reference to it is joined into the parent.
+func (g *Generator) buildEmbeddedOperations(collection, parentDFName string, emb *mapping.Embedded, numPartitions int) []string {
 	var ops []string
 	childDF := emb.SourceTable + "_df"
 
@@ -176,13 +698,18 @@ func (g *Generator) buildEmbeddedOperations(parentDFName string, emb *mapping.Em
 	partCol := findPartitionColumn(g.Schema, emb.SourceTable)
 	ops = append(ops, fmt.Sprintf(`%s = spark.read.jdbc(
     url=jdbc_url,
-    table="%s",
+    table=%s,
     column="%s",
     lowerBound=0,
     upperBound=1000000,
     numPartitions=%d,
     properties=jdbc_properties,
-)`, childDF, emb.SourceTable, partCol, numPartitions))
+)`, childDF, g.jdbcTable(emb.SourceTable, emb.Filter), partCol, numPartitions))
+
+	ops = append(ops, g.lobOperations(emb.SourceTable, childDF)...)
+	ops = append(ops, g.timestampOperations(emb.SourceTable, childDF)...)
+	ops = append(ops, g.numberOperations(emb.SourceTable, childDF)...)
+	ops = append(ops, g.geoOperations(emb.SourceTable, childDF)...)
 
 	// Apply embedded-level transforms
 	if len(emb.Transformations) > 0 {
@@ -192,23 +719,59 @@ func (g *Generator) buildEmbeddedOperations(parentDFName string, emb *mapping.Em
 
 	// Process nested children first (bottom-up)
 	for _, nested := range emb.Embedded {
-		nestedOps := g.buildEmbeddedOperations(childDF, &nested, numPartitions)
+		nestedOps := g.buildEmbeddedOperations(collection, childDF, &nested, numPartitions)
 		ops = append(ops, nestedOps...)
 	}
 
-	// GroupBy + collect_list + join into parent
+	// GroupBy + collect_list + join into parent, on every column of a
+	// composite key
 	nestedDF := emb.SourceTable + "_nested"
-	ops = append(ops, fmt.Sprintf(`%s = %s.groupBy("%s").agg(
-    collect_list(struct("*")).alias("%s")
-)`, nestedDF, childDF, emb.JoinColumn, emb.FieldName))
+	joinKeys, parentKeys := emb.JoinKeys(), emb.ParentKeys()
+	groupCols := make([]string, len(joinKeys))
+	conds := make([]string, 0, len(joinKeys))
+	drops := ""
+	for i, k := range joinKeys {
+		groupCols[i] = `"` + k + `"`
+		if i < len(parentKeys) {
+			conds = append(conds, fmt.Sprintf(`%s["%s"] == %s["%s"]`, parentDFName, parentKeys[i], nestedDF, k))
+		}
+		drops += fmt.Sprintf(`.drop(%s["%s"])`, nestedDF, k)
+	}
+	groupBy := strings.Join(groupCols, ", ")
+	cond := strings.Join(conds, "")
+	if len(conds) > 1 {
+		cond = "(" + strings.Join(conds, ") & (") + ")"
+	}
+	fields := `"*"`
+	if len(emb.Fields) > 0 || len(emb.FieldOrder) > 0 {
+		args := fieldColumnsArgs(emb.Fields, mapping.TableColumns(g.Schema, emb.SourceTable, emb.Transformations), emb.FieldOrder)
+		fields = fmt.Sprintf("*field_columns(%s, %s)", childDF, args)
+	}
+	if emb.Offload != nil && emb.Offload.Strategy == mapping.OffloadCollection {
+		side := mapping.OffloadTarget(collection, *emb)
+		ops = append(ops, fmt.Sprintf(`%s.select(%s).write \
+    .format("mongodb") \
+    .mode("overwrite") \
+    .option("collection", %q) \
+    .save()`, childDF, fields, side))
+		ops = append(ops, fmt.Sprintf(`%s = %s.groupBy(%s).agg(
+    struct(lit(%q).alias("collection"), count("*").alias("count")).alias("%s")
+)`, nestedDF, childDF, groupBy, side, emb.FieldName))
+	} else {
+		ops = append(ops, fmt.Sprintf(`%s = %s.groupBy(%s).agg(
+    collect_list(struct(%s)).alias("%s")
+)`, nestedDF, childDF, groupBy, fields, emb.FieldName))
+	}
+	if emb.Offload != nil && emb.Offload.Strategy == mapping.OffloadGridFS {
+		ops = append(ops, fmt.Sprintf(`%s = offload_lob(%s.withColumn("%s", to_json("%s")), %q, %q, "gridfs", %q)`,
+			nestedDF, nestedDF, emb.FieldName, emb.FieldName, collection, emb.FieldName, mapping.OffloadTarget(collection, *emb)))
+	}
 
 	ops = append(ops, fmt.Sprintf(`%s = %s.join(
     %s,
-    %s["%s"] == %s["%s"],
+    %s,
     "left",
-).drop(%s["%s"])`, parentDFName, parentDFName, nestedDF,
-		parentDFName, emb.ParentColumn, nestedDF, emb.JoinColumn,
-		nestedDF, emb.JoinColumn))
+)%s`, parentDFName, parentDFName, nestedDF, cond, drops))
 
 	return ops
 }
@@ -265,41 +828,347 @@ var migrationTemplate = `"""
 Reloquent Migration Script
 Generated by Reloquent -- https://github.com/reloquent/reloquent
 
+{{ if .Parameterized -}}
+Source: {{ .SourceType }} (from the environment)
+Target: MongoDB (from the environment)
+
+Connection settings are read from environment variables, each of which may
+hold a secret reference; see the parameters file generated with this script.
+{{- else -}}
 Source: {{ .SourceType }} ({{ .JDBCUrl }})
 Target: MongoDB ({{ .MongoDatabase }})
+{{- end }}
+Mapping hash: {{ .MappingHash }}
+
+Generate the script again after changing the mapping or type mapping;
+reloquent migrate refuses to start while this one is out of date.
 """
 {{ if .OracleGuidance }}{{ .OracleGuidance }}{{ end }}
+import os
+import re
+
 from pyspark.sql import SparkSession
 from pyspark.sql.functions import collect_list, struct{{ if .HasTransforms }}, coalesce, lit, expr, col{{ end }}
+{{- if .HasFieldOffload }}
+from pyspark.sql.functions import count, lit, to_json
+{{- end }}
+{{- if .HasGeoJSON }}
+from pyspark.sql.functions import from_json
+{{- end }}
+{{- if or .HasRowErrors .HasMasks .HasTimestamps }}
+from pyspark.sql import functions as F
+{{- end }}
+
+
+def resolve_secret(value):
+    """Resolve ${ENV:NAME}, ${NAME}, ${AWS_SM:name} and Secrets Manager ARN references."""
+    def aws_secret(ref):
+        import boto3
+        return boto3.client("secretsmanager").get_secret_value(SecretId=ref)["SecretString"]
+
+    def lookup(match):
+        provider, ref = match.group(1), match.group(2)
+        if provider == "AWS_SM":
+            return aws_secret(ref)
+        if ref not in os.environ:
+            raise RuntimeError(f"environment variable {ref} not set")
+        return os.environ[ref]
+
+    if value.startswith("arn:aws:secretsmanager:"):
+        return aws_secret(value)
+    return re.sub(r"\$\{(?:(ENV|AWS_SM):)?([^}]+)\}", lookup, value)
+
+
+{{ if .Parameterized -}}
+def param(name):
+    """Read a connection setting from the environment, resolving secret references."""
+    if name not in os.environ:
+        raise RuntimeError(f"parameter {name} not set; see the parameters file generated with this script")
+    return resolve_secret(os.environ[name])
 
-spark = SparkSession.builder \
-    .appName("reloquent-migration") \
-    .config("spark.mongodb.write.connection.uri", "{{ .MongoURI }}") \
-    .config("spark.mongodb.write.database", "{{ .MongoDatabase }}") \
-    .getOrCreate()
 
+mongo_uri = param("RELOQUENT_TARGET_URI")
+mongo_database = param("RELOQUENT_TARGET_DATABASE")
+jdbc_url = param("RELOQUENT_SOURCE_JDBC_URL")
+jdbc_properties = {
+    "user": param("RELOQUENT_SOURCE_USER"),
+    "password": param("RELOQUENT_SOURCE_PASSWORD"),
+{{- else -}}
+mongo_uri = resolve_secret("{{ .MongoURI }}")
+mongo_database = "{{ .MongoDatabase }}"
 jdbc_url = "{{ .JDBCUrl }}"
 jdbc_properties = {
+    "user": "{{ .SourceUser }}",
+    "password": resolve_secret("{{ .SourcePassword }}"),
+{{- end }}
     "driver": "{{ if eq .SourceType "postgresql" }}org.postgresql.Driver{{ else }}oracle.jdbc.OracleDriver{{ end }}",
 }
+
+spark = SparkSession.builder \
+    .appName("reloquent-migration") \
+    .config("spark.mongodb.write.connection.uri", mongo_uri) \
+    .config("spark.mongodb.write.database", mongo_database) \
+{{- if .HasTimestamps }}
+    .config("spark.sql.session.timeZone", "UTC") \
+{{- end }}
+    .getOrCreate()
+
+# Partitions finished by an earlier, interrupted run: (index, lower, upper)
+completed = {
+{{- range .Collections }}{{ if .Completed }}
+    "{{ .Name }}": {{ .Completed }},
+{{- end }}{{ end }}
+}
+
+
+def partition_ranges(table, column, parts):
+    """Split the column's value range into at most parts [lower, upper) ranges."""
+    bounds = spark.read.jdbc(
+        url=jdbc_url,
+        table=f"(SELECT MIN({column}) AS lo, MAX({column}) AS hi FROM {table}) bounds",
+        properties=jdbc_properties,
+    ).first()
+    if bounds[0] is None:
+        return []
+    lo, hi = int(bounds[0]), int(bounds[1]) + 1
+    step = max(1, -(-(hi - lo) // parts))
+    return [(i, lo + i * step, min(hi, lo + (i + 1) * step)) for i in range(parts) if lo + i * step < hi]
+
+
+def checkpoint(collection, part, lower, upper, partitions):
+    """Record a completed partition in the target so a retry can skip it."""
+    spark.createDataFrame(
+        [(collection, part, lower, upper, partitions)],
+        "collection string, partition int, lower long, upper long, partitions int",
+    ).write \
+        .format("mongodb") \
+        .mode("append") \
+        .option("collection", "{{ .CheckpointCollection }}") \
+        .save()
+{{- if .HasFields }}
+
+
+def field_columns(df, mapping, exclude, order=()):
+    """Select df's columns renamed by mapping, nesting dotted targets in structs.
+
+    Fields whose paths are listed in order come first, in that order, and the
+    rest follow by name.
+    """
+    rank = {path: i for i, path in enumerate(order)}
+    tree = {}
+    for name in df.columns:
+        if name in exclude:
+            continue
+        path = mapping.get(name, name).split(".")
+        node = tree
+        for part in path[:-1]:
+            node = node.setdefault(part, {})
+        node[path[-1]] = df[name]
+
+    def build(node, path=""):
+        items = list(node.items())
+        if rank:
+            items.sort(key=lambda kv: (0, rank[path + kv[0]]) if path + kv[0] in rank else (1, kv[0]))
+        return [struct(*build(v, path + k + ".")).alias(k) if isinstance(v, dict) else v.alias(k) for k, v in items]
+
+    return build(tree)
+{{- end }}
+{{- if .HasLOBOffload }}
+
+
+def offload_lob(df, table, column, store, location):
+    """Write each value of a large object or offloaded field to GridFS or S3.
+
+    The column is replaced by the GridFS file ID or the s3:// URI of the
+    object, so the document holds a reference instead of the value.
+    """
+    from pyspark.sql.types import StringType, StructField, StructType
+
+    index = df.columns.index(column)
+    schema = StructType([
+        StructField(f.name, StringType(), True) if f.name == column else f
+        for f in df.schema.fields
+    ])
+
+    def upload(rows):
+        import uuid
+
+        if store == "gridfs":
+            import gridfs
+            from pymongo import MongoClient
+
+            client = MongoClient(mongo_uri)
+            fs = gridfs.GridFS(client[mongo_database], collection=location)
+
+            def put(data):
+                return str(fs.put(data, filename=f"{table}/{column}/{uuid.uuid4()}"))
+        else:
+            import boto3
+
+            bucket, _, prefix = location[len("s3://"):].partition("/")
+            s3 = boto3.client("s3")
+
+            def put(data):
+                key = "/".join(p for p in (prefix.strip("/"), table, column, str(uuid.uuid4())) if p)
+                s3.put_object(Bucket=bucket, Key=key, Body=data)
+                return f"s3://{bucket}/{key}"
+
+        for row in rows:
+            values = list(row)
+            value = values[index]
+            if value is not None:
+                values[index] = put(value.encode("utf-8") if isinstance(value, str) else bytes(value))
+            yield values
+
+    return spark.createDataFrame(df.rdd.mapPartitions(upload), schema)
+{{- end }}
+{{- if .HasMasks }}
+
+# Names synthesized values are made of, as the native mover makes them
+FIRST_NAMES = {{ .FirstNames }}
+LAST_NAMES = {{ .LastNames }}
+
+
+def mask_column(df, column, operation, value):
+    """Replace a column's values with hash, redact, synthesize or nullify.
+
+    Each is deterministic, so the same source value is masked the same way
+    in every row and table. Nulls stay null.
+    """
+    if operation == "nullify":
+        return df.withColumn(column, F.lit(None).cast(df.schema[column].dataType))
+    text = F.col(column).cast("string")
+    if operation == "hash":
+        masked = F.sha2(F.concat(F.lit(value), text), 256)
+    elif operation == "redact":
+        masked = F.lit(value) if value else F.regexp_replace(text, "[A-Za-z0-9]", "*")
+    else:
+        digest = F.sha2(text, 256)
+
+        def pick(seg, n):
+            return F.conv(F.substring(digest, seg * 8 + 1, 8), 16, 10).cast("long") % n
+
+        def choose(names, seg):
+            return F.element_at(F.array(*[F.lit(n) for n in names]), (pick(seg, len(names)) + 1).cast("int"))
+
+        first, last = choose(FIRST_NAMES, 0), choose(LAST_NAMES, 1)
+        masked = {
+            "first_name": first,
+            "last_name": last,
+            "name": F.concat_ws(" ", first, last),
+            "email": F.concat(F.lower(first), F.lit("."), F.lower(last), F.lit("."),
+                              pick(2, 1000).cast("string"), F.lit("@example.com")),
+            "phone": F.concat(F.lit("555-01"), F.lpad(pick(2, 100).cast("string"), 2, "0")),
+        }[value]
+    return df.withColumn(column, F.when(F.col(column).isNotNull(), masked))
+{{- end }}
+{{- if .HasRowErrors }}
+
+# MongoDB's BSON document limit; documents over it are skipped or quarantined
+MAX_DOCUMENT_BYTES = 16 * 1024 * 1024
+
+
+def oversized_rows(df, collection, table, policy, key_fields):
+    """Leave out the documents over MAX_DOCUMENT_BYTES under a skip or
+    quarantine policy, recording quarantined ones in {{ $.ErrorCollection }}.
+
+    The size is that of the document as JSON, close to its BSON size.
+    """
+    size = F.length(F.to_json(F.struct(*[df[c] for c in df.columns])))
+    df = df.withColumn("_reloquent_size", size)
+    bad = df.filter(F.col("_reloquent_size") > MAX_DOCUMENT_BYTES)
+    if policy == "quarantine":
+        keys = [F.col(field).alias(column) for column, field in key_fields.items()]
+        errors = bad.select(
+            F.lit(collection).alias("collection"),
+            F.lit(table).alias("source_table"),
+            *([F.struct(*keys).alias("key")] if keys else []),
+            F.concat(F.lit("document of "), F.col("_reloquent_size").cast("string"),
+                     F.lit(" bytes is over the 16 MB BSON limit")).alias("error"),
+            F.current_timestamp().alias("at"),
+        )
+        if keys:
+            # One document per row, replaced when the row fails again
+            key_id = F.concat_ws("/", F.lit(collection), *[F.col(f"key.{c}").cast("string") for c in key_fields])
+            errors = errors.withColumn("_id", key_id)
+        writer = errors.write.format("mongodb").mode("append").option("collection", "{{ $.ErrorCollection }}")
+        if keys:
+            writer = writer.option("operationType", "replace").option("idFieldList", "_id")
+        writer.save()
+    count = bad.count()
+    if count:
+        print(f"{'Quarantined' if policy == 'quarantine' else 'Skipped'} {count} rows of {collection} over the BSON limit")
+    return df.filter(F.col("_reloquent_size") <= MAX_DOCUMENT_BYTES).drop("_reloquent_size")
+{{- end }}
 {{ range .Collections }}
+{{- if and .StageStart (gt $.Stages 1) }}
+# ===== Stage {{ .Stage }} of {{ $.Stages }}{{ if gt .Stage 1 }}, after the collections these depend on{{ end }} =====
+{{ end }}
+{{- if .GroupStart }}
+{{ if eq $.SourceType "oracle" -}}
+# === Consistency group: {{ .Group }}, read as of one SCN ===
+group_scn = int(spark.read.jdbc(
+    url=jdbc_url,
+    table="(SELECT CURRENT_SCN AS scn FROM V$DATABASE) scn",
+    properties=jdbc_properties,
+).first()[0])
+print(f"Reading consistency group {{ .Group }} as of SCN {group_scn}")
+{{- else -}}
+# === Consistency group: {{ .Group }} ===
+# Its collections are migrated back to back, but Spark's JDBC reads cannot
+# share one PostgreSQL snapshot; use the native mover to pin one.
+{{- end }}
+{{ end }}
 # === Collection: {{ .Name }} (from: {{ .SourceTable }}) ===
-{{ range .Operations }}
+{{- if .Skip }}
+print("Skipping {{ .Name }}: {{ .Skip }}")
+{{ else }}
+{{- if .Archive }}
+{{ .Archive }}
+{{ end }}
+{{ range .Setup }}
 {{ . }}
 {{ end }}
-{{ .Name }}_df.write \
-    .format("mongodb") \
-    .mode("overwrite") \
-    .option("collection", "{{ .Name }}") \
-    .option("ordered", "false") \
-    .option("writeConcern.w", "1") \
-    .option("writeConcern.journal", "false") \
-    .option("maxBatchSize", "100000") \
-    .option("compressors", "zstd") \
-    .save()
-
-print(f"Done: {{ .Name }}: { {{ .Name }}_df.count()} documents written")
+{{ .Name }}_partitions = {{ if .Checkpointed }}partition_ranges({{ .ReadTable }}, "{{ .PartitionCol }}", {{ $.CheckpointPartitions }}){{ else }}[(0, 0, 0)]{{ end }}
+{{- if .Delta }}
+{{ .Name }}_resumed = True  # delta run: upsert the changed rows
+{{- else if .Checkpointed }}
+{{ .Name }}_resumed = "{{ .Name }}" in completed
+{{- end }}
+for part, lower, upper in {{ .Name }}_partitions:
+    if (part, lower, upper) in completed.get("{{ .Name }}", set()):
+        print(f"Skipping {{ .Name }} partition {part}: already migrated")
+        continue
+{{ range .Operations }}
+{{ indent . }}
+{{ end }}
+{{- if ne .OnError "fail" }}
+    {{ .Name }}_df = oversized_rows({{ .Name }}_df, "{{ .Name }}", "{{ .SourceTable }}", "{{ .OnError }}", {{ if .QuarantineKey }}{{ .QuarantineKey }}{{ else }}{}{{ end }})
+{{- end }}
+    writer = {{ .Name }}_df.write \
+        .format("mongodb") \
+        .option("collection", "{{ .Name }}") \
+        .option("ordered", "false") \
+        .option("writeConcern.w", "1") \
+        .option("writeConcern.journal", "false") \
+        .option("maxBatchSize", "100000") \
+        .option("compressors", "zstd")
+{{- if .Checkpointed }}
+    if {{ .Name }}_resumed:
+        # Upsert so rows left by the interrupted partition are not duplicated
+        writer = writer.mode("append").option("operationType", "replace").option("idFieldList", "{{ .IDField }}")
+    elif part == 0:
+        writer = writer.mode("overwrite")
+    else:
+        writer = writer.mode("append")
+{{- else }}
+    writer = writer.mode("overwrite")
+{{- end }}
+    writer.save()
+    checkpoint("{{ .Name }}", part, lower, upper, len({{ .Name }}_partitions))
+    print(f"Done: {{ .Name }} partition {part + 1}/{len({{ .Name }}_partitions)}")
 {{ end }}
+{{- end }}
 print("Migration complete.")
 spark.stop()
 `
@@ -0,0 +1,56 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// numberOperations returns the PySpark lines that cast a table's
+// fixed-point columns, just read into df, to the BSON type their precision
+// and scale resolve to. The connector writes Spark decimals as Decimal128,
+// so decimal columns are cast to their declared precision and scale, and
+// the others to long, double or string. Columns declared without a
+// precision, or wider than a Decimal128, keep the decimal type the JDBC
+// dialect reads them as.
+func (g *Generator) numberOperations(table, df string) []string {
+	tm := g.TypeMap
+	if tm == nil {
+		tm = typemap.ForDatabase(g.Config.Source.Type)
+	}
+	var ops []string
+	for _, c := range tm.DecimalColumns(g.Schema) {
+		if c.Table != table {
+			continue
+		}
+		var cast string
+		switch c.BSONType {
+		case typemap.BSONDecimal128:
+			if c.Precision == nil {
+				continue
+			}
+			digits, scale := *c.Precision, 0
+			if c.Scale != nil {
+				if *c.Scale > 0 {
+					scale = *c.Scale
+				} else {
+					digits -= *c.Scale
+				}
+			}
+			if digits > typemap.MaxDecimalPrecision {
+				continue // reported by the type map's warnings
+			}
+			cast = fmt.Sprintf("decimal(%d,%d)", digits, scale)
+		case typemap.BSONNumberLong:
+			cast = "long"
+		case typemap.BSONDouble:
+			cast = "double"
+		case typemap.BSONString:
+			cast = "string"
+		default:
+			continue
+		}
+		ops = append(ops, fmt.Sprintf("%s = %s.withColumn(%q, %s[%q].cast(%q))", df, df, c.Column, df, c.Column, cast))
+	}
+	return ops
+}
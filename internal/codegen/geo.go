@@ -0,0 +1,63 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// geoOperations returns the PySpark lines that parse the GeoJSON text of a
+// table's geometry columns into documents, so 2dsphere indexes can cover
+// them. Columns mapped to another type, or allowing any shape, whose
+// coordinates have no fixed nesting, stay GeoJSON text.
+func (g *Generator) geoOperations(table, df string) []string {
+	var ops []string
+	for _, c := range g.geoJSONColumns(table) {
+		coords := "double"
+		for range typemap.CoordinateDepth(c.GeometryType) {
+			coords = "array<" + coords + ">"
+		}
+		ops = append(ops, fmt.Sprintf(`%s = %s.withColumn(%q, from_json(%q, "type string, coordinates %s"))`,
+			df, df, c.Name, c.Name, coords))
+	}
+	return ops
+}
+
+// geoJSONColumns returns the geometry columns of table written as GeoJSON
+// documents.
+func (g *Generator) geoJSONColumns(table string) []schema.Column {
+	var out []schema.Column
+	tm := g.TypeMap
+	if tm == nil {
+		tm = typemap.ForDatabase(g.Config.Source.Type)
+	}
+	for _, c := range typemap.GeoColumns(g.table(table)) {
+		if tm.ResolveColumn(c) == typemap.BSONGeoJSON {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (g *Generator) parsesGeoJSON(table string, embedded []mapping.Embedded) bool {
+	if len(g.geoJSONColumns(table)) > 0 {
+		return true
+	}
+	for _, e := range embedded {
+		if g.parsesGeoJSON(e.SourceTable, e.Embedded) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Generator) table(name string) *schema.Table {
+	for i := range g.Schema.Tables {
+		if g.Schema.Tables[i].Name == name {
+			return &g.Schema.Tables[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package codegen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// MappingHash returns the hash of a mapping and type mapping that a
+// generated script records in its header, so a script generated from an
+// older design can be told apart before it is run.
+func MappingHash(m *mapping.Mapping, tm *typemap.TypeMap) (string, error) {
+	h := sha256.New()
+	for _, v := range []interface{}{m, tm} {
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("hashing mapping: %w", err)
+		}
+		h.Write(data)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+var scriptHashLine = regexp.MustCompile(`(?m)^Mapping hash: (sha256:[0-9a-f]{64})$`)
+
+// ScriptMappingHash returns the mapping hash in a generated script's
+// header, or "" for a script without one.
+func ScriptMappingHash(script []byte) string {
+	if m := scriptHashLine.FindSubmatch(script); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// parametersFile renders the values a parameterized script reads for this
+// config as an environment file. Credentials held as environment or Secrets
+// Manager references are written as those references, which the script
+// resolves; any other credential is left commented out to be set from a
+// secret store, so the file never holds a secret.
+func parametersFile(cfg *config.Config) string {
+	var b strings.Builder
+	b.WriteString(`# Reloquent migration parameters
+# Set these in the environment of the Spark job running the parameterized
+# migration script, for example with: set -a; . ./migration.env; set +a
+# Any value may be a secret reference, ${ENV:NAME}, ${AWS_SM:name} or a
+# Secrets Manager ARN, resolved by the script when it starts. Keep one file
+# per environment and the same script for all of them.
+
+`)
+	writeParam(&b, SourceJDBCURLEnv, buildJDBCURL(cfg.Source))
+	writeParam(&b, SourceUserEnv, cfg.Source.Username)
+	writeSecretParam(&b, SourcePasswordEnv, cfg.Source.Password)
+
+	uri := target.WireURI(cfg.Target.ConnectionString)
+	if pw, ok := config.URIPassword(uri); ok {
+		writeParam(&b, TargetURIEnv, scriptMongoURI(uri))
+		if !config.IsPortableReference(pw) {
+			writeSecretParam(&b, TargetPasswordEnv, pw)
+		}
+	} else if config.IsReference(uri) {
+		writeSecretParam(&b, TargetURIEnv, uri)
+	} else {
+		writeParam(&b, TargetURIEnv, uri)
+	}
+	writeParam(&b, TargetDatabaseEnv, cfg.Target.Database)
+	return b.String()
+}
+
+// writeSecretParam writes a credential that is empty or a portable reference
+// as is, and otherwise only a commented placeholder.
+func writeSecretParam(b *strings.Builder, name, val string) {
+	if val == "" || config.IsPortableReference(val) {
+		writeParam(b, name, val)
+		return
+	}
+	fmt.Fprintf(b, "# %s is not stored where the cluster can read it; set it, or point it\n", name)
+	fmt.Fprintf(b, "# at Secrets Manager:\n# %s='${AWS_SM:secret-name}'\n", name)
+}
+
+// writeParam writes name=val, single-quoted so a shell sourcing the file
+// leaves references for the script to resolve.
+func writeParam(b *strings.Builder, name, val string) {
+	fmt.Fprintf(b, "%s='%s'\n", name, strings.ReplaceAll(val, "'", `'\''`))
+}
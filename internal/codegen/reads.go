@@ -0,0 +1,118 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// SourceRead describes one partitioned JDBC read the migration script issues.
+type SourceRead struct {
+	Collection      string `yaml:"collection" json:"collection"`
+	Table           string `yaml:"table" json:"table"`
+	PartitionColumn string `yaml:"partition_column" json:"partition_column"`
+	NumPartitions   int    `yaml:"num_partitions" json:"num_partitions"`
+	Filter          string `yaml:"filter,omitempty" json:"filter,omitempty"`
+	SQL             string `yaml:"sql" json:"sql"`
+}
+
+// SourceReads lists the reads the generated script performs for every
+// collection, root table first, with the SQL Spark sends for each partition.
+func (g *Generator) SourceReads() []SourceRead {
+	var reads []SourceRead
+	for _, c := range g.Mapping.ScheduleOrder(g.Mapping.Collections) {
+		reads = append(reads, g.sourceRead(c.Name, c.SourceTable, c.LiveFilter()))
+		reads = append(reads, g.embeddedReads(c.Name, c.Embedded)...)
+	}
+	return reads
+}
+
+func (g *Generator) embeddedReads(collection string, embedded []mapping.Embedded) []SourceRead {
+	var reads []SourceRead
+	for _, emb := range embedded {
+		reads = append(reads, g.sourceRead(collection, emb.SourceTable, emb.Filter))
+		reads = append(reads, g.embeddedReads(collection, emb.Embedded)...)
+	}
+	return reads
+}
+
+func (g *Generator) sourceRead(collection, table, filter string) SourceRead {
+	partCol := findPartitionColumn(g.Schema, table)
+	return SourceRead{
+		Collection:      collection,
+		Table:           table,
+		PartitionColumn: partCol,
+		NumPartitions:   g.Config.Source.MaxConnections,
+		Filter:          filter,
+		SQL: fmt.Sprintf("SELECT * FROM %s WHERE %s >= :lower AND %s < :upper",
+			g.readTable(table, filter), partCol, partCol),
+	}
+}
+
+// jdbcTable renders the table argument of a JDBC read as a Python string.
+// A row filter turns it into a subquery so the source database applies it,
+// as do columns Spark cannot read as they are: the subquery selects
+// geometries as GeoJSON text in WGS 84 and enums as their labels. Reads of
+// an Oracle consistency group are flashback queries as of the group's SCN,
+// an f-string filled in when the script runs.
+func (g *Generator) jdbcTable(table, filter string) string {
+	if g.snapshotSCN != "" {
+		return "f" + strconv.Quote(g.readTable(table, filter))
+	}
+	return strconv.Quote(g.readTable(table, filter))
+}
+
+// readTable returns the SQL table expression a read of table uses.
+func (g *Generator) readTable(table, filter string) string {
+	cols := g.readColumns(table)
+	if g.snapshotSCN != "" {
+		// Braces are doubled for the f-string the SCN is filled in by
+		braces := strings.NewReplacer("{", "{{", "}", "}}")
+		for i, c := range cols {
+			cols[i] = braces.Replace(c)
+		}
+		list := "*"
+		if len(cols) > 0 {
+			list = strings.Join(cols, ", ")
+		}
+		query := fmt.Sprintf("SELECT %s FROM %s AS OF SCN {%s}", list, table, g.snapshotSCN)
+		if filter != "" {
+			query += " WHERE " + braces.Replace(filter)
+		}
+		return fmt.Sprintf("(%s) %s", query, table[strings.LastIndex(table, ".")+1:])
+	}
+	if cols == nil {
+		return mapping.FilteredTable(table, filter)
+	}
+	return mapping.SelectTable(table, cols, filter)
+}
+
+// readColumns returns the column expressions a read of table selects, or
+// nil when Spark can read every column as it is.
+func (g *Generator) readColumns(table string) []string {
+	t := g.table(table)
+	if t == nil {
+		return nil
+	}
+	converted := false
+	cols := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		cols[i] = c.Name
+		switch {
+		case typemap.IsGeo(c.DataType):
+			cols[i] = fmt.Sprintf("%s AS %s", typemap.GeoJSONSQL(g.Config.Source.Type, c), c.Name)
+		case typemap.IsEnum(c.DataType):
+			cols[i] = fmt.Sprintf("%s AS %s", typemap.EnumSQL(c), c.Name)
+		default:
+			continue
+		}
+		converted = true
+	}
+	if !converted {
+		return nil
+	}
+	return cols
+}
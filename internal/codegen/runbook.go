@@ -0,0 +1,263 @@
+package codegen
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/drivers"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// Files of the generated script bundle.
+const (
+	ScriptFile     = "migration.py"
+	ParametersFile = "migration.env"
+	RunbookFile    = "README.md"
+)
+
+// Spark packages the runbook's spark-submit command pulls in: the MongoDB
+// connector the script writes through and the PostgreSQL JDBC driver. The
+// Oracle driver is not on Maven Central and is passed with --jars instead.
+const (
+	MongoSparkPackage = "org.mongodb.spark:mongo-spark-connector_2.12:10.4.0"
+	PostgresPackage   = "org.postgresql:postgresql:42.7.4"
+)
+
+// scriptReference matches the secret references resolve_secret handles in
+// the generated script.
+var scriptReference = regexp.MustCompile(`\$\{(?:(ENV|AWS_SM):)?([^}]+)\}`)
+
+// runbook writes the README of the script bundle: what each file is, the
+// collections the script migrates, the environment it needs, the exact
+// commands to run it locally and on EMR, and how to undo it. Everything in
+// it comes from this config and mapping, and no secret is written into it.
+func (g *Generator) runbook(data templateData) string {
+	cfg := g.Config
+	var b strings.Builder
+
+	b.WriteString("# Reloquent migration bundle\n\n")
+	if g.Parameterized {
+		fmt.Fprintf(&b, "Migrates a %s source to MongoDB, with the connection settings read from\nthe environment.\n", data.SourceType)
+	} else {
+		fmt.Fprintf(&b, "Migrates the %s source `%s`\nto the MongoDB database `%s`.\n", data.SourceType, data.JDBCUrl, data.MongoDatabase)
+	}
+	b.WriteString("Generated by `reloquent generate` from the current config and mapping;\ngenerate the bundle again after changing either.\n\n")
+
+	b.WriteString("## Artifacts\n\n| File | Purpose |\n|---|---|\n")
+	fmt.Fprintf(&b, "| `%s` | PySpark job migrating %d collections |\n", ScriptFile, len(data.Collections))
+	if g.Parameterized {
+		fmt.Fprintf(&b, "| `%s` | connection parameters for this environment; secrets are left as references or placeholders |\n", ParametersFile)
+	}
+	fmt.Fprintf(&b, "| `%s` | this runbook |\n", RunbookFile)
+	jar, jarName := "", "ojdbc11.jar"
+	if data.SourceType == "oracle" {
+		jar = "<path to " + jarName + ">"
+		if p, err := drivers.FindOracleJDBC(); err == nil {
+			jar, jarName = p, filepath.Base(p)
+		}
+		fmt.Fprintf(&b, "\nThe Oracle JDBC driver is not in the bundle; the commands below expect it\nat `%s`.\n", jar)
+	}
+
+	b.WriteString("\n## Collections\n\n| Collection | Source table | Run |\n|---|---|---|\n")
+	for _, c := range data.Collections {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", c.Name, c.SourceTable, runbookMode(c, data.CheckpointPartitions))
+	}
+
+	b.WriteString("\n## Environment\n\n")
+	envs, secrets := g.runbookEnvironment(data)
+	if len(envs) == 0 {
+		b.WriteString("The script holds every connection setting; no environment variables are needed.\n")
+	} else {
+		b.WriteString("The job reads these variables when it starts:\n\n")
+		for _, name := range envs {
+			fmt.Fprintf(&b, "- `%s`\n", name)
+		}
+		if g.Parameterized {
+			fmt.Fprintf(&b, "\nLoad them from the parameters file with `set -a; . ./%s; set +a`.\n", ParametersFile)
+		} else {
+			b.WriteString("\nExport them in the shell that runs spark-submit.\n")
+		}
+	}
+	if len(secrets) > 0 {
+		b.WriteString("\nThese AWS Secrets Manager secrets are read with boto3, so the job's role needs\n`secretsmanager:GetSecretValue` on them:\n\n")
+		for _, s := range secrets {
+			fmt.Fprintf(&b, "- `%s`\n", s)
+		}
+	}
+
+	b.WriteString("\n## Run with spark-submit\n\n```sh\n")
+	packages := MongoSparkPackage
+	if data.SourceType == "postgresql" {
+		packages += "," + PostgresPackage
+	}
+	fmt.Fprintf(&b, "spark-submit \\\n  --packages %s \\\n", packages)
+	if jar != "" {
+		fmt.Fprintf(&b, "  --jars %s \\\n", jar)
+	}
+	fmt.Fprintf(&b, "  %s\n```\n", ScriptFile)
+
+	b.WriteString("\n## Run on EMR\n\n")
+	bucket := cfg.AWS.S3Bucket
+	if bucket == "" {
+		bucket = "<bucket>"
+		b.WriteString("Set `aws.s3_bucket` in the config to fill in the bucket below.\n\n")
+	}
+	prefix := "s3://" + path.Join(bucket, "reloquent", data.MongoDatabase)
+	awsFlags := ""
+	if cfg.AWS.Region != "" {
+		awsFlags += " --region " + cfg.AWS.Region
+	}
+	if cfg.AWS.Profile != "" {
+		awsFlags += " --profile " + cfg.AWS.Profile
+	}
+	b.WriteString("```sh\n")
+	fmt.Fprintf(&b, "aws s3 cp %s %s/%s%s\n", ScriptFile, prefix, ScriptFile, awsFlags)
+	args := []string{"--deploy-mode", "cluster"}
+	if jar != "" {
+		fmt.Fprintf(&b, "aws s3 cp %s %s/%s%s\n", jar, prefix, jarName, awsFlags)
+		args = append(args, "--jars", prefix+"/"+jarName)
+	}
+	for _, name := range envs {
+		args = append(args, "--conf", fmt.Sprintf("spark.yarn.appMasterEnv.%s=$%s", name, name))
+	}
+	args = append(args, prefix+"/"+ScriptFile)
+	fmt.Fprintf(&b, "aws emr add-steps%s --cluster-id <cluster-id> \\\n  --steps \"Type=Spark,Name=reloquent-migration,ActionOnFailure=CONTINUE,Args=[%s]\"\n```\n",
+		awsFlags, strings.Join(args, ","))
+	if len(envs) > 0 {
+		b.WriteString("\nValues passed with `--conf` are visible in the step's configuration; hold\ncredentials as `${AWS_SM:name}` references to keep them out of it.\n")
+	}
+
+	b.WriteString("\n## Rollback\n\n")
+	fmt.Fprintf(&b, "1. Stop the job: interrupt spark-submit, or\n   `aws emr cancel-steps%s --cluster-id <cluster-id> --step-ids <step-id>`.\n", awsFlags)
+	if g.Delta {
+		b.WriteString("2. This is a delta run that upserts into collections already holding the full\n   load. Dropping them undoes the full migration too; to undo only this run,\n   restore the target from a backup taken before it.\n")
+	} else {
+		fmt.Fprintf(&b, "2. Drop what the script wrote, in mongosh connected to the target:\n\n   ```js\n   use %s\n", data.MongoDatabase)
+		for _, name := range g.runbookTargets(data) {
+			fmt.Fprintf(&b, "   db.getCollection(%q).drop()\n", name)
+		}
+		b.WriteString("   ```\n\n   `reloquent rollback --confirm` drops the migrated collections and releases\n   the migration lock as well.\n")
+	}
+	step := 3
+	if objects := g.runbookObjects(); len(objects) > 0 {
+		fmt.Fprintf(&b, "%d. Remove the rows the script archived and the large objects it offloaded\n   to S3:\n\n   ```sh\n", step)
+		for _, o := range objects {
+			fmt.Fprintf(&b, "   aws s3 rm --recursive %s%s\n", o, awsFlags)
+		}
+		b.WriteString("   ```\n")
+		step++
+	}
+	fmt.Fprintf(&b, "%d. If the job ran on EMR, remove the uploaded bundle:\n   `aws s3 rm --recursive %s/%s`.\n", step, prefix, awsFlags)
+	return b.String()
+}
+
+// runbookMode describes how the script migrates a collection.
+func runbookMode(c collectionData, partitions int) string {
+	var mode string
+	switch {
+	case c.Skip != "":
+		return "skipped: " + c.Skip
+	case c.Delta:
+		mode = fmt.Sprintf("delta upsert on %s", c.IDField)
+	case c.Checkpointed:
+		mode = fmt.Sprintf("resumable, %d partitions on %s", partitions, c.PartitionCol)
+	default:
+		mode = "single read, not resumable"
+	}
+	if c.Group != "" {
+		mode += ", consistency group " + c.Group
+	}
+	return mode
+}
+
+// runbookEnvironment lists the environment variables the script reads and
+// the Secrets Manager secrets it resolves.
+func (g *Generator) runbookEnvironment(data templateData) (envs, secrets []string) {
+	if g.Parameterized {
+		envs = []string{SourceJDBCURLEnv, SourceUserEnv, SourcePasswordEnv, TargetURIEnv, TargetDatabaseEnv}
+	}
+	for _, v := range []string{data.SourcePassword, data.MongoURI} {
+		if strings.HasPrefix(v, "arn:aws:secretsmanager:") {
+			secrets = append(secrets, v)
+			continue
+		}
+		for _, m := range scriptReference.FindAllStringSubmatch(v, -1) {
+			if m[1] == "AWS_SM" {
+				secrets = append(secrets, m[2])
+			} else if !slices.Contains(envs, m[2]) {
+				envs = append(envs, m[2])
+			}
+		}
+	}
+	return envs, secrets
+}
+
+// runbookTargets lists the target collections and GridFS buckets a full run
+// writes to, including the checkpoint collection.
+func (g *Generator) runbookTargets(data templateData) []string {
+	var names []string
+	add := func(name string) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, c := range data.Collections {
+		if c.Skip == "" {
+			add(c.Name)
+		}
+	}
+	var walk func(collection string, embedded []mapping.Embedded)
+	walk = func(collection string, embedded []mapping.Embedded) {
+		for _, e := range embedded {
+			if t := mapping.OffloadTarget(collection, e); t != "" {
+				if e.Offload.Strategy == mapping.OffloadGridFS {
+					add(t + ".files")
+					add(t + ".chunks")
+				} else {
+					add(t)
+				}
+			}
+			walk(collection, e.Embedded)
+		}
+	}
+	for _, c := range g.Mapping.Collections {
+		walk(c.Name, c.Embedded)
+	}
+	if g.TypeMap != nil {
+		for _, lob := range g.TypeMap.LOBColumns(g.Schema) {
+			if lob.Strategy == typemap.LOBGridFS {
+				add(lob.Location + ".files")
+				add(lob.Location + ".chunks")
+			}
+		}
+	}
+	add(CheckpointCollection)
+	return names
+}
+
+// runbookObjects lists the S3 locations a full run writes archived rows and
+// offloaded large objects under.
+func (g *Generator) runbookObjects() []string {
+	if g.Delta {
+		return nil
+	}
+	var objects []string
+	for _, c := range g.Mapping.Collections {
+		if c.Archive != nil {
+			objects = append(objects, c.Archive.Path(c.Name))
+		}
+	}
+	if g.TypeMap != nil {
+		for _, lob := range g.TypeMap.LOBColumns(g.Schema) {
+			if lob.Strategy == typemap.LOBS3 && !slices.Contains(objects, lob.Location) {
+				objects = append(objects, lob.Location)
+			}
+		}
+	}
+	return objects
+}
@@ -0,0 +1,82 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+func TestRunbook(t *testing.T) {
+	cfg := &config.Config{
+		Version: 1,
+		Source: config.SourceConfig{
+			Type: "postgresql", Host: "db.internal", Port: 5432, Database: "shop",
+			Username: "migrator", Password: "hunter2", MaxConnections: 20,
+		},
+		Target: config.TargetConfig{
+			ConnectionString: "mongodb://app:${AWS_SM:prod/mongo}@cluster0:27017",
+			Database:         "shop",
+		},
+		AWS: config.AWSConfig{Region: "eu-west-1", S3Bucket: "migrations"},
+	}
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "orders", Columns: []schema.Column{{Name: "id", DataType: "integer"}, {Name: "placed_at", DataType: "timestamp"}}},
+		{Name: "notes", Columns: []schema.Column{{Name: "order_id", DataType: "integer"}, {Name: "body", DataType: "text"}}},
+		{Name: "audit", Columns: []schema.Column{{Name: "event", DataType: "text"}}},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{
+			Name: "orders", SourceTable: "orders",
+			Archive: &mapping.ArchivePolicy{Column: "placed_at", Before: "2020-01-01", Location: "s3://archive/shop"},
+			Embedded: []mapping.Embedded{{
+				SourceTable: "notes", FieldName: "notes", Relationship: "array",
+				JoinColumn: "order_id", ParentColumn: "id",
+				Offload: &mapping.Offload{Strategy: mapping.OffloadCollection},
+			}},
+		},
+		{Name: "audit", SourceTable: "audit"},
+	}}
+
+	g := &Generator{Config: cfg, Schema: s, Mapping: m, TypeMap: typemap.DefaultPostgres()}
+	result, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb := result.Runbook
+
+	for _, want := range []string{
+		"`jdbc:postgresql://db.internal:5432/shop?ssl=false`",
+		"| orders | orders | resumable, 8 partitions on id |",
+		"| audit | audit | single read, not resumable |",
+		"- `" + SourcePasswordEnv + "`",
+		"- `prod/mongo`",
+		"--packages " + MongoSparkPackage + "," + PostgresPackage,
+		"aws s3 cp migration.py s3://migrations/reloquent/shop/migration.py --region eu-west-1",
+		"--conf,spark.yarn.appMasterEnv." + SourcePasswordEnv + "=$" + SourcePasswordEnv,
+		`db.getCollection("orders_notes").drop()`,
+		`db.getCollection("_reloquent_checkpoints").drop()`,
+		"aws s3 rm --recursive s3://archive/shop/orders",
+	} {
+		if !strings.Contains(rb, want) {
+			t.Errorf("runbook missing %q:\n%s", want, rb)
+		}
+	}
+	if strings.Contains(rb, "hunter2") {
+		t.Error("runbook contains the source password")
+	}
+
+	g.Parameterized = true
+	result, err = g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"`" + ParametersFile + "`", "- `" + TargetDatabaseEnv + "`", "set -a; . ./migration.env; set +a"} {
+		if !strings.Contains(result.Runbook, want) {
+			t.Errorf("parameterized runbook missing %q", want)
+		}
+	}
+}
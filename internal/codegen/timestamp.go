@@ -0,0 +1,69 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// timestampOperations returns the PySpark lines that apply the type map's
+// time zone policies to a table just read into df. The session reads
+// timestamps without a time zone as UTC, so columns read as UTC are left
+// as they are and the others are shifted from their zone to UTC.
+func (g *Generator) timestampOperations(table, df string) []string {
+	if g.TypeMap == nil {
+		return nil
+	}
+	var ops []string
+	for _, c := range g.TypeMap.TimestampColumns(g.Schema) {
+		if c.Table != table || c.Policy == typemap.TimezoneUTC {
+			continue
+		}
+		zone, err := c.SourceZone(g.Schema.TimeZone)
+		if err != nil {
+			continue // reported by checkTimestampZones
+		}
+		ops = append(ops, fmt.Sprintf("%s = %s.withColumn(%q, F.to_utc_timestamp(%q, %q))", df, df, c.Column, c.Column, zone))
+	}
+	return ops
+}
+
+// checkTimestampZones reports a time zone policy of a migrated table whose
+// zone is unknown.
+func (g *Generator) checkTimestampZones() error {
+	if g.TypeMap == nil {
+		return nil
+	}
+	tables := make(map[string]bool)
+	for _, t := range g.Mapping.SourceTables() {
+		tables[t] = true
+	}
+	for _, c := range g.TypeMap.TimestampColumns(g.Schema) {
+		if !tables[c.Table] {
+			continue
+		}
+		if _, err := c.SourceZone(g.Schema.TimeZone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readsTimestamps reports whether the table or one embedded in it at any
+// depth has timestamp columns without a time zone.
+func (g *Generator) readsTimestamps(table string, embedded []mapping.Embedded) bool {
+	if t := g.table(table); t != nil {
+		for _, c := range t.Columns {
+			if typemap.IsNaiveTimestamp(c.DataType) {
+				return true
+			}
+		}
+	}
+	for _, e := range embedded {
+		if g.readsTimestamps(e.SourceTable, e.Embedded) {
+			return true
+		}
+	}
+	return false
+}
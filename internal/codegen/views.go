@@ -0,0 +1,62 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+)
+
+// GenerateViewRefreshScript produces a mongosh script that re-runs a
+// materialized view's pipeline and merges the results into the view
+// collection. The pipeline is embedded as Extended JSON and parsed with
+// EJSON so typed values ($date, $numberLong, ...) survive.
+func GenerateViewRefreshScript(database string, v mapping.View) (string, error) {
+	tmpl, err := template.New("view").Parse(viewRefreshTemplate)
+	if err != nil {
+		return "", fmt.Errorf("parsing template: %w", err)
+	}
+
+	data := struct {
+		Name, Source, Database, Pipeline string
+		On, WhenMatched, WhenNotMatched  string
+	}{
+		Name:           jsLiteral(v.Name),
+		Source:         jsLiteral(v.Source),
+		Database:       jsLiteral(database),
+		Pipeline:       jsLiteral(v.Pipeline),
+		On:             jsLiteral(v.MergeOn()),
+		WhenMatched:    jsLiteral(v.MatchedAction()),
+		WhenNotMatched: jsLiteral(v.NotMatchedAction()),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// jsLiteral renders a string or string slice as a JavaScript literal.
+func jsLiteral(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+const viewRefreshTemplate = `// Refresh a materialized view. Generated by Reloquent.
+// Run on demand or on a schedule: mongosh "<target-uri>" <this file>
+const target = db.getSiblingDB({{.Database}});
+const pipeline = EJSON.parse({{.Pipeline}});
+pipeline.push({
+  $merge: {
+    into: { db: {{.Database}}, coll: {{.Name}} },
+    on: {{.On}},
+    whenMatched: {{.WhenMatched}},
+    whenNotMatched: {{.WhenNotMatched}},
+  },
+});
+target.getCollection({{.Source}}).aggregate(pipeline).toArray();
+print("Refreshed " + {{.Name}} + ": " + target.getCollection({{.Name}}).countDocuments({}) + " documents");
+`
@@ -0,0 +1,37 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+)
+
+func TestGenerateViewRefreshScript(t *testing.T) {
+	v := mapping.View{
+		Name:        "orders_by_day",
+		Source:      "orders",
+		Pipeline:    `[{"$group": {"_id": "$order_date", "total": {"$sum": "$amount"}}}]`,
+		On:          []string{"_id"},
+		WhenMatched: "merge",
+	}
+
+	script, err := GenerateViewRefreshScript("shop", v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`db.getSiblingDB("shop")`,
+		`EJSON.parse("[{\"$group\": {\"_id\": \"$order_date\", \"total\": {\"$sum\": \"$amount\"}}}]")`,
+		`into: { db: "shop", coll: "orders_by_day" }`,
+		`on: ["_id"]`,
+		`whenMatched: "merge"`,
+		`whenNotMatched: "insert"`,
+		`target.getCollection("orders").aggregate(pipeline)`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+// Package collation compares how the source and MongoDB compare text.
+// Source columns often use case- or accent-insensitive collations, while
+// MongoDB compares strings byte by byte unless told otherwise, which
+// silently lets values that were duplicates in the source coexist under a
+// unique index and changes sort order. The analyzer recommends a MongoDB
+// collation for each collection and index that needs one.
+package collation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// defaultLocale is used when the source collation names no language, as
+// ICU's root locale (und) and Oracle's BINARY_CI do.
+const defaultLocale = "en"
+
+// oracleLanguages maps the language prefix of Oracle linguistic sorts to
+// the MongoDB locale.
+var oracleLanguages = map[string]string{
+	"ARABIC": "ar", "CZECH": "cs", "DANISH": "da", "DUTCH": "nl",
+	"FINNISH": "fi", "FRENCH": "fr", "GERMAN": "de", "GREEK": "el",
+	"HUNGARIAN": "hu", "ITALIAN": "it", "JAPANESE": "ja", "KOREAN": "ko",
+	"NORWEGIAN": "nb", "POLISH": "pl", "PORTUGUESE": "pt", "RUSSIAN": "ru",
+	"SCHINESE": "zh", "SPANISH": "es", "SWEDISH": "sv", "TURKISH": "tr",
+}
+
+// Report holds the collation advice for every collection with a column
+// whose source collation MongoDB would not reproduce.
+type Report struct {
+	Collections []CollectionAdvice `json:"collections"`
+	Joins       []JoinAdvice       `json:"joins"` // embeds and references joined ignoring case
+}
+
+// CollectionAdvice is the advice for one collection. Collation is the
+// default collation to create the collection with, set when every text
+// field from its source table compares the same insensitive way; indexes
+// and queries then use it without naming it.
+type CollectionAdvice struct {
+	Collection string            `json:"collection"`
+	Collation  *target.Collation `json:"collation,omitempty"`
+	Fields     []FieldAdvice     `json:"fields"`
+	Notes      []string          `json:"notes,omitempty"`
+}
+
+// FieldAdvice describes a field whose source column has a collation.
+// Collation is nil when only the sort order differs from MongoDB's.
+type FieldAdvice struct {
+	Field     string            `json:"field"`
+	Column    string            `json:"column"` // table.column
+	Source    string            `json:"source"` // the source collation
+	Locale    string            `json:"locale"` // MongoDB locale of the source collation's language
+	Collation *target.Collation `json:"collation,omitempty"`
+	Unique    bool              `json:"unique,omitempty"` // part of a primary key or unique index
+}
+
+// For returns the MongoDB collation that treats as equal the values the
+// source collation does, or nil when byte comparison already matches.
+func For(c *schema.Collation) *target.Collation {
+	if c == nil || !c.CaseInsensitive {
+		return nil
+	}
+	strength := 2
+	if c.AccentInsensitive {
+		strength = 1
+	}
+	return &target.Collation{Locale: Locale(c), Strength: strength}
+}
+
+// ForColumns returns the collation an index on the given columns of t
+// needs: that of the first column with an insensitive collation.
+func ForColumns(t *schema.Table, columns []string) *target.Collation {
+	for _, name := range columns {
+		for _, c := range t.Columns {
+			if c.Name == name {
+				if coll := For(c.Collation); coll != nil {
+					return coll
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Locale returns the MongoDB locale for a source collation: the language
+// of its PostgreSQL locale (de-DE-x-icu, sv_SE.utf8) or of its Oracle
+// linguistic sort (XGERMAN_CI), or English when it names none.
+func Locale(c *schema.Collation) string {
+	if c.Locale != "" {
+		lang := strings.ToLower(c.Locale)
+		if i := strings.IndexAny(lang, "-_.@"); i >= 0 {
+			lang = lang[:i]
+		}
+		switch lang {
+		case "", "und", "root", "c", "posix":
+			return defaultLocale
+		}
+		return lang
+	}
+	name := strings.TrimPrefix(strings.ToUpper(c.Name), "X")
+	if i := strings.IndexByte(name, '_'); i > 0 {
+		name = name[:i]
+	}
+	if lang, ok := oracleLanguages[name]; ok {
+		return lang
+	}
+	return defaultLocale
+}
+
+// Analyze inspects the collations of the columns each collection is built
+// from and recommends MongoDB collations for them.
+func Analyze(s *schema.Schema, m *mapping.Mapping) *Report {
+	r := &Report{Collections: []CollectionAdvice{}, Joins: []JoinAdvice{}}
+	if s == nil || m == nil {
+		return r
+	}
+	tables := tableMap(s)
+	r.Joins = append(r.Joins, Joins(s, m)...)
+
+	for i := range m.Collections {
+		col := &m.Collections[i]
+		t := tables[col.SourceTable]
+		if t == nil {
+			continue
+		}
+		a := CollectionAdvice{Collection: col.Name}
+		a.Fields = fields(t, "", col.RootField)
+		a.Collation = collectionDefault(t, col.RootField)
+		embeddedFields(&a, col.Embedded, tables, "")
+		if len(a.Fields) == 0 {
+			continue
+		}
+		a.Notes = notes(col.Name, a)
+		r.Collections = append(r.Collections, a)
+	}
+	return r
+}
+
+// Collection returns the advice for a collection, or nil if it needs none.
+func (r *Report) Collection(name string) *CollectionAdvice {
+	for i := range r.Collections {
+		if r.Collections[i].Collection == name {
+			return &r.Collections[i]
+		}
+	}
+	return nil
+}
+
+// ApplyToSpecs sets the recommended default collation on the creation
+// specs of the collections that have one, unless a spec already sets one.
+func (r *Report) ApplyToSpecs(specs []target.CollectionSpec) {
+	for i := range specs {
+		if specs[i].Collation != nil {
+			continue
+		}
+		if a := r.Collection(specs[i].Name); a != nil && a.Collation != nil {
+			c := *a.Collation
+			specs[i].Collation = &c
+		}
+	}
+}
+
+func fields(t *schema.Table, prefix string, field func(string) (string, bool)) []FieldAdvice {
+	unique := uniqueColumns(t)
+	var out []FieldAdvice
+	for _, c := range t.Columns {
+		if c.Collation == nil {
+			continue
+		}
+		f, ok := field(c.Name)
+		if !ok {
+			continue
+		}
+		if prefix != "" {
+			f = prefix + "." + f
+		}
+		out = append(out, FieldAdvice{
+			Field:     f,
+			Column:    t.Name + "." + c.Name,
+			Source:    c.Collation.String(),
+			Locale:    Locale(c.Collation),
+			Collation: For(c.Collation),
+			Unique:    unique[c.Name],
+		})
+	}
+	return out
+}
+
+func embeddedFields(a *CollectionAdvice, embedded []mapping.Embedded, tables map[string]*schema.Table, prefix string) {
+	for i := range embedded {
+		emb := &embedded[i]
+		path := emb.FieldName
+		if prefix != "" {
+			path = prefix + "." + emb.FieldName
+		}
+		if t := tables[emb.SourceTable]; t != nil {
+			a.Fields = append(a.Fields, fields(t, path, emb.SubField)...)
+		}
+		embeddedFields(a, emb.Embedded, tables, path)
+	}
+}
+
+// collectionDefault returns the insensitive collation shared by every
+// migrated text column of the root table, or nil if they differ or any
+// compares byte by byte.
+func collectionDefault(t *schema.Table, field func(string) (string, bool)) *target.Collation {
+	var shared *target.Collation
+	for _, c := range t.Columns {
+		if !isText(c.DataType) {
+			continue
+		}
+		if _, ok := field(c.Name); !ok {
+			continue
+		}
+		coll := For(c.Collation)
+		if coll == nil || (shared != nil && *coll != *shared) {
+			return nil
+		}
+		shared = coll
+	}
+	return shared
+}
+
+func notes(collection string, a CollectionAdvice) []string {
+	var out []string
+	if a.Collation != nil {
+		out = append(out, fmt.Sprintf("%s is created with collation %s so its text fields compare as in the source; indexes and queries use it by default",
+			collection, Describe(a.Collation)))
+	}
+	for _, f := range a.Fields {
+		switch {
+		case f.Collation == nil:
+			out = append(out, fmt.Sprintf("%s sorts by %s rules in the source; MongoDB sorts it byte by byte unless queries pass a collation with locale %q",
+				f.Field, f.Source, f.Locale))
+		case a.Collation != nil:
+		case f.Field == "_id":
+			out = append(out, fmt.Sprintf("_id from %s is unique byte by byte: only a collection default collation applies to _id, and the collection's other text fields compare differently",
+				f.Column))
+		case f.Unique:
+			out = append(out, fmt.Sprintf("unique index on %s is built with collation %s; without it values differing only in case would not be duplicates",
+				f.Field, Describe(f.Collation)))
+		default:
+			out = append(out, fmt.Sprintf("indexes on %s are built with collation %s; queries must pass the same collation to use them",
+				f.Field, Describe(f.Collation)))
+		}
+	}
+	return out
+}
+
+func uniqueColumns(t *schema.Table) map[string]bool {
+	unique := make(map[string]bool)
+	if t.PrimaryKey != nil {
+		for _, c := range t.PrimaryKey.Columns {
+			unique[c] = true
+		}
+	}
+	for _, idx := range t.Indexes {
+		if idx.Unique {
+			for _, c := range idx.Columns {
+				unique[c] = true
+			}
+		}
+	}
+	return unique
+}
+
+// Describe formats a collation the way mongosh takes it.
+func Describe(c *target.Collation) string {
+	return fmt.Sprintf("{locale: %q, strength: %d}", c.Locale, c.Strength)
+}
+
+// isText reports whether columns of the source type hold text.
+func isText(dataType string) bool {
+	t := strings.ToLower(dataType)
+	return strings.Contains(t, "char") || strings.Contains(t, "text") || strings.Contains(t, "clob")
+}
@@ -0,0 +1,226 @@
+package collation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *schema.Collation
+		want *target.Collation
+	}{
+		{name: "none", in: nil},
+		{name: "sensitive", in: &schema.Collation{Name: "de-x-icu", Locale: "de"}},
+		{name: "citext", in: &schema.Collation{Name: "citext", CaseInsensitive: true}, want: &target.Collation{Locale: "en", Strength: 2}},
+		{name: "icu nondeterministic", in: &schema.Collation{Name: "ci", Locale: "de-DE-u-ks-level2", CaseInsensitive: true}, want: &target.Collation{Locale: "de", Strength: 2}},
+		{name: "oracle accent insensitive", in: &schema.Collation{Name: "XFRENCH_AI", CaseInsensitive: true, AccentInsensitive: true}, want: &target.Collation{Locale: "fr", Strength: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := For(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("For = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocale(t *testing.T) {
+	tests := []struct {
+		in   schema.Collation
+		want string
+	}{
+		{schema.Collation{Name: "sv-x-icu", Locale: "sv_SE.utf8"}, "sv"},
+		{schema.Collation{Name: "und-x-icu", Locale: "und"}, "en"},
+		{schema.Collation{Name: "ci", Locale: "@colStrength=secondary"}, "en"},
+		{schema.Collation{Name: "GERMAN_CI"}, "de"},
+		{schema.Collation{Name: "BINARY_CI"}, "en"},
+	}
+	for _, tt := range tests {
+		if got := Locale(&tt.in); got != tt.want {
+			t.Errorf("Locale(%+v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func testSchema() *schema.Schema {
+	ci := &schema.Collation{Name: "citext", CaseInsensitive: true}
+	return &schema.Schema{Tables: []schema.Table{
+		{
+			Name: "users",
+			Columns: []schema.Column{
+				{Name: "id", DataType: "bigint"},
+				{Name: "email", DataType: "citext", Collation: ci},
+				{Name: "login", DataType: "citext", Collation: ci},
+			},
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}},
+			Indexes:    []schema.Index{{Name: "ux_email", Columns: []string{"email"}, Unique: true}},
+		},
+		{
+			Name: "products",
+			Columns: []schema.Column{
+				{Name: "sku", DataType: "varchar", Collation: ci},
+				{Name: "title", DataType: "text", Collation: &schema.Collation{Name: "sv-x-icu", Locale: "sv-SE"}},
+			},
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"sku"}},
+		},
+		{
+			Name: "tags",
+			Columns: []schema.Column{
+				{Name: "product_sku", DataType: "varchar"},
+				{Name: "label", DataType: "citext", Collation: ci},
+			},
+		},
+		{
+			Name:    "orders",
+			Columns: []schema.Column{{Name: "id", DataType: "bigint"}, {Name: "note", DataType: "text"}},
+		},
+	}}
+}
+
+func testMapping() *mapping.Mapping {
+	return &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users", SourceTable: "users", Transformations: []mapping.Transformation{
+			{SourceField: "login", Operation: "rename", TargetField: "username"},
+		}},
+		{Name: "products", SourceTable: "products", Embedded: []mapping.Embedded{
+			{SourceTable: "tags", FieldName: "tags", Relationship: "array"},
+		}},
+		{Name: "orders", SourceTable: "orders"},
+	}}
+}
+
+func TestAnalyze(t *testing.T) {
+	r := Analyze(testSchema(), testMapping())
+
+	if len(r.Collections) != 2 {
+		t.Fatalf("collections = %+v, want users and products", r.Collections)
+	}
+	if r.Collection("orders") != nil {
+		t.Error("orders has no collated column and needs no advice")
+	}
+
+	users := r.Collection("users")
+	want := &target.Collation{Locale: "en", Strength: 2}
+	if !reflect.DeepEqual(users.Collation, want) {
+		t.Errorf("users default collation = %+v, want %+v", users.Collation, want)
+	}
+	if len(users.Fields) != 2 || users.Fields[1].Field != "username" || !users.Fields[0].Unique {
+		t.Errorf("users fields = %+v", users.Fields)
+	}
+	if len(users.Notes) != 1 || !strings.Contains(users.Notes[0], "created with collation") {
+		t.Errorf("users notes = %v", users.Notes)
+	}
+
+	// The sort-only title column rules out a collection default
+	products := r.Collection("products")
+	if products.Collation != nil {
+		t.Errorf("products default collation = %+v, want none", products.Collation)
+	}
+	var fields []string
+	for _, f := range products.Fields {
+		fields = append(fields, f.Field)
+	}
+	if strings.Join(fields, ",") != "sku,title,tags.label" {
+		t.Errorf("products fields = %v", fields)
+	}
+	notes := strings.Join(products.Notes, "\n")
+	for _, want := range []string{
+		"unique index on sku is built with collation",
+		`title sorts by sv-x-icu rules in the source; MongoDB sorts it byte by byte unless queries pass a collation with locale "sv"`,
+		"indexes on tags.label are built with collation",
+	} {
+		if !strings.Contains(notes, want) {
+			t.Errorf("products notes missing %q:\n%s", want, notes)
+		}
+	}
+}
+
+func TestAnalyze_Empty(t *testing.T) {
+	if r := Analyze(nil, testMapping()); len(r.Collections) != 0 {
+		t.Errorf("no schema should give no advice: %+v", r)
+	}
+}
+
+func TestApplyToSpecs(t *testing.T) {
+	r := Analyze(testSchema(), testMapping())
+	own := &target.Collation{Locale: "fr", Strength: 1}
+	specs := []target.CollectionSpec{{Name: "users"}, {Name: "products"}, {Name: "orders", Collation: own}}
+	r.ApplyToSpecs(specs)
+
+	if specs[0].Collation == nil || specs[0].Collation.Strength != 2 {
+		t.Errorf("users spec collation = %+v", specs[0].Collation)
+	}
+	if specs[1].Collation != nil {
+		t.Errorf("products spec collation = %+v, want none", specs[1].Collation)
+	}
+	if specs[2].Collation != own {
+		t.Error("a spec's own collation should be kept")
+	}
+}
+
+func TestJoins(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "customers", Columns: []schema.Column{
+			{Name: "code", DataType: "citext", Collation: &schema.Collation{Name: "citext", CaseInsensitive: true}},
+			{Name: "region", DataType: "integer"},
+		}},
+		{Name: "orders", Columns: []schema.Column{{Name: "id", DataType: "integer"}, {Name: "customer_code", DataType: "text"}}},
+		{Name: "items", Columns: []schema.Column{{Name: "order_id", DataType: "integer"}}},
+		{Name: "notes", Columns: []schema.Column{{Name: "customer_code", DataType: "VARCHAR2", Collation: &schema.Collation{Name: "BINARY_AI", CaseInsensitive: true, AccentInsensitive: true}}}},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name: "customers", SourceTable: "customers",
+		Embedded: []mapping.Embedded{{
+			SourceTable: "orders", FieldName: "orders", Relationship: "array", JoinColumn: "customer_code", ParentColumn: "code",
+			Embedded: []mapping.Embedded{{SourceTable: "items", FieldName: "items", Relationship: "array", JoinColumn: "order_id", ParentColumn: "id"}},
+		}},
+		References: []mapping.Reference{{SourceTable: "notes", FieldName: "notes", JoinColumn: "customer_code", ParentColumn: "code"}},
+	}, {Name: "notes", SourceTable: "notes"}}}
+
+	joins := Joins(s, m)
+	if len(joins) != 2 {
+		t.Fatalf("joins = %+v, want orders and notes", joins)
+	}
+	orders, notes := joins[0], joins[1]
+	if orders.Field != "orders" || orders.Reference || orders.Normalized || orders.Source != "citext (case-insensitive)" {
+		t.Errorf("orders = %+v", orders)
+	}
+	if !strings.Contains(orders.Note, "left out of the documents") {
+		t.Errorf("orders note = %q", orders.Note)
+	}
+	if !notes.Reference || !notes.AccentInsensitive || notes.Source != "BINARY_AI (case- and accent-insensitive)" {
+		t.Errorf("notes = %+v", notes)
+	}
+
+	if n := SetNormalized(s, m, orders, true); n != 2 {
+		t.Errorf("SetNormalized changed %d tables, want 2", n)
+	}
+	joins = Joins(s, m)
+	if !joins[0].Normalized || !strings.Contains(joins[0].Note, "lower-cased on both sides") {
+		t.Errorf("orders = %+v, want normalized", joins[0])
+	}
+	// The reference shares the customers key, but its own key is not normalized yet
+	if joins[1].Normalized {
+		t.Error("notes should not be normalized")
+	}
+	SetNormalized(s, m, joins[1], true)
+	if j := Joins(s, m)[1]; !j.Normalized || !strings.Contains(j.Note, "differ in accents") {
+		t.Errorf("notes = %+v, want normalized with an accent note", j)
+	}
+
+	SetNormalized(s, m, orders, false)
+	if len(m.Collections[0].Embedded[0].Transformations) != 0 || len(m.Collections[0].Transformations) != 0 {
+		t.Errorf("transformations left after removing the normalization: %+v", m.Collections[0])
+	}
+
+	if r := Analyze(s, m); len(r.Joins) != 2 {
+		t.Errorf("report joins = %+v", r.Joins)
+	}
+}
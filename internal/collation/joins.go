@@ -0,0 +1,174 @@
+package collation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// JoinAdvice describes an embed or reference whose source join compares its
+// key ignoring case. The migration joins rows by comparing keys byte by
+// byte, as MongoDB does, so child rows whose key differs from their
+// parent's only in case would silently fall out of the document, unless
+// the mapping lower-cases the key on both sides.
+type JoinAdvice struct {
+	Collection    string   `json:"collection"`
+	Field         string   `json:"field"` // the embedded field path, or the reference's field
+	Reference     bool     `json:"reference,omitempty"`
+	Table         string   `json:"table"`
+	Columns       []string `json:"columns"`
+	ParentTable   string   `json:"parent_table"`
+	ParentColumns []string `json:"parent_columns"`
+	Source        string   `json:"source"` // the insensitive source collation
+	// AccentInsensitive joins also ignore accents, which lower-casing does
+	// not normalize.
+	AccentInsensitive bool   `json:"accent_insensitive,omitempty"`
+	Normalized        bool   `json:"normalized"` // the mapping lower-cases the text key columns on both sides
+	Note              string `json:"note"`
+}
+
+// Joins returns the embeds and references of the mapping whose key has a
+// case-insensitive collation on either side, in mapping order.
+func Joins(s *schema.Schema, m *mapping.Mapping) []JoinAdvice {
+	var out []JoinAdvice
+	if s == nil || m == nil {
+		return out
+	}
+	tables := tableMap(s)
+	add := func(j JoinAdvice) {
+		child, parent := tables[j.Table], tables[j.ParentTable]
+		if child == nil || parent == nil {
+			return
+		}
+		c := KeyCollation(child, j.Columns)
+		if c == nil {
+			c = KeyCollation(parent, j.ParentColumns)
+		}
+		if c == nil {
+			return
+		}
+		j.Source, j.AccentInsensitive = c.String(), c.AccentInsensitive
+		j.Normalized = normalized(m, child, j.Columns) && normalized(m, parent, j.ParentColumns)
+		j.Note = joinNote(j)
+		out = append(out, j)
+	}
+
+	var walk func(col string, embs []mapping.Embedded, parent, prefix string)
+	walk = func(col string, embs []mapping.Embedded, parent, prefix string) {
+		for i := range embs {
+			emb := &embs[i]
+			path := prefix + emb.FieldName
+			add(JoinAdvice{
+				Collection: col, Field: path,
+				Table: emb.SourceTable, Columns: emb.JoinKeys(),
+				ParentTable: parent, ParentColumns: emb.ParentKeys(),
+			})
+			walk(col, emb.Embedded, emb.SourceTable, path+".")
+		}
+	}
+	for _, c := range m.Collections {
+		walk(c.Name, c.Embedded, c.SourceTable, "")
+		for _, ref := range c.References {
+			add(JoinAdvice{
+				Collection: c.Name, Field: ref.FieldName, Reference: true,
+				Table: ref.SourceTable, Columns: ref.JoinKeys(),
+				ParentTable: c.SourceTable, ParentColumns: ref.ParentKeys(),
+			})
+		}
+	}
+	return out
+}
+
+// SetNormalized lower-cases the text key columns of both sides of the join
+// wherever their tables are migrated, or stops doing so, and returns how
+// many tables were changed.
+func SetNormalized(s *schema.Schema, m *mapping.Mapping, j JoinAdvice, normalize bool) int {
+	tables := tableMap(s)
+	n := 0
+	for _, side := range []struct {
+		table   string
+		columns []string
+	}{{j.Table, j.Columns}, {j.ParentTable, j.ParentColumns}} {
+		t := tables[side.table]
+		if t == nil {
+			continue
+		}
+		for _, c := range textKeys(t, side.columns) {
+			n += m.SetNormalization(t.Name, c, normalize)
+		}
+	}
+	return n
+}
+
+// KeyCollation returns the case-insensitive collation of the first key
+// column with one, or nil.
+func KeyCollation(t *schema.Table, columns []string) *schema.Collation {
+	for _, name := range columns {
+		for _, c := range t.Columns {
+			if c.Name == name && c.Collation != nil && c.Collation.CaseInsensitive {
+				return c.Collation
+			}
+		}
+	}
+	return nil
+}
+
+// textKeys returns the key columns holding text, the ones lower-casing
+// normalizes.
+func textKeys(t *schema.Table, columns []string) []string {
+	var out []string
+	for _, name := range columns {
+		for _, c := range t.Columns {
+			if c.Name == name && isText(c.DataType) {
+				out = append(out, name)
+			}
+		}
+	}
+	return out
+}
+
+// normalized reports whether the mapping lower-cases every text key column
+// of the table, and there is one.
+func normalized(m *mapping.Mapping, t *schema.Table, columns []string) bool {
+	keys := textKeys(t, columns)
+	for _, c := range keys {
+		if !m.NormalizesColumn(t.Name, c) {
+			return false
+		}
+	}
+	return len(keys) > 0
+}
+
+func joinNote(j JoinAdvice) string {
+	join := fmt.Sprintf("%s.%s joins %s on %s, which compares as %s", j.Collection, j.Field, j.ParentTable, qualified(j.Table, j.Columns), j.Source)
+	lost := "rows whose key differs from their parent's only in case are left out of the documents"
+	if j.Reference {
+		lost = "references whose key differs from their parent's only in case are not matched by $lookup"
+	}
+	switch {
+	case j.Normalized && j.AccentInsensitive:
+		return join + "; the keys are lower-cased, but rows whose keys differ in accents are still not joined"
+	case j.Normalized:
+		return join + "; the keys are lower-cased on both sides so the migration joins them the same way"
+	}
+	return join + "; the migration compares keys byte by byte, so " + lost + " unless the keys are normalized to lower case"
+}
+
+// qualified joins the columns, each prefixed with its table.
+func qualified(table string, columns []string) string {
+	out := make([]string, len(columns))
+	for i, c := range columns {
+		out[i] = table + "." + c
+	}
+	return strings.Join(out, ", ")
+}
+
+func tableMap(s *schema.Schema) map[string]*schema.Table {
+	tables := make(map[string]*schema.Table, len(s.Tables))
+	for i := range s.Tables {
+		tables[s.Tables[i].Name] = &s.Tables[i]
+	}
+	return tables
+}
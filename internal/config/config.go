@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -17,11 +16,20 @@ const (
 
 // Config is the top-level configuration.
 type Config struct {
-	Version int          `yaml:"version"`
-	Source  SourceConfig `yaml:"source"`
-	Target  TargetConfig `yaml:"target"`
-	AWS     AWSConfig    `yaml:"aws,omitempty"`
-	Logging LogConfig    `yaml:"logging,omitempty"`
+	Version   int             `yaml:"version"`
+	Source    SourceConfig    `yaml:"source"`
+	Target    TargetConfig    `yaml:"target"`
+	AWS       AWSConfig       `yaml:"aws,omitempty"`
+	Atlas     AtlasConfig     `yaml:"atlas,omitempty"`
+	Logging   LogConfig       `yaml:"logging,omitempty"`
+	Server    ServerConfig    `yaml:"server,omitempty"`
+	Benchmark BenchmarkConfig `yaml:"benchmark,omitempty"`
+	Indexes   IndexesConfig   `yaml:"indexes,omitempty"`
+	Migration MigrationConfig `yaml:"migration,omitempty"`
+	Hooks     []HookConfig    `yaml:"hooks,omitempty"`
+	Telemetry TelemetryConfig `yaml:"telemetry,omitempty"`
+	Run       RunConfig       `yaml:"run,omitempty"`
+	Metadata  MetadataConfig  `yaml:"metadata,omitempty"`
 }
 
 // SourceConfig defines the source database connection.
@@ -36,6 +44,12 @@ type SourceConfig struct {
 	SSL            bool   `yaml:"ssl,omitempty"`
 	ReadOnly       bool   `yaml:"read_only,omitempty"`
 	MaxConnections int    `yaml:"max_connections,omitempty"` // default 20, max 50
+
+	// DiscoveryParallelism is how many connections PostgreSQL schema
+	// discovery uses. The default, 1, reads the catalog over a single
+	// connection; higher values split the per-table catalog queries into
+	// batches run concurrently, for schemas with thousands of tables.
+	DiscoveryParallelism int `yaml:"discovery_parallelism,omitempty"`
 }
 
 // TargetConfig defines the MongoDB target connection.
@@ -43,15 +57,44 @@ type TargetConfig struct {
 	Type             string `yaml:"type"` // mongodb
 	ConnectionString string `yaml:"connection_string"`
 	Database         string `yaml:"database"`
+
+	// How validation reads the target; the validate --read-preference and
+	// --read-concern flags override these. secondaryPreferred keeps the
+	// comparison queries off the primary.
+	ReadPreference string `yaml:"read_preference,omitempty"` // default primary
+	ReadConcern    string `yaml:"read_concern,omitempty"`    // local, majority or linearizable; default majority off the primary
 }
 
 // AWSConfig defines AWS infrastructure settings.
 type AWSConfig struct {
 	Region   string            `yaml:"region,omitempty"`
 	Profile  string            `yaml:"profile,omitempty"`
-	Platform string            `yaml:"platform,omitempty"` // emr or glue
+	Platform string            `yaml:"platform,omitempty"` // emr, emr-serverless, glue, or native
 	S3Bucket string            `yaml:"s3_bucket,omitempty"`
 	Tags     map[string]string `yaml:"tags,omitempty"`
+	Subnets  []string          `yaml:"subnets,omitempty"` // EMR cluster subnets; default subnet when empty
+
+	// The EMR Serverless Spark application and the ARN of the role its jobs
+	// run as, for platform emr-serverless.
+	EMRServerlessApplication string `yaml:"emr_serverless_application,omitempty"`
+	EMRServerlessRole        string `yaml:"emr_serverless_role,omitempty"`
+}
+
+// AtlasConfig identifies the Atlas cluster behind the target and the
+// programmatic API key used for operations the database server does not
+// expose, such as creating Atlas Search indexes. The private key may be a
+// secret reference.
+type AtlasConfig struct {
+	ProjectID  string `yaml:"project_id,omitempty"`
+	Cluster    string `yaml:"cluster,omitempty"`
+	PublicKey  string `yaml:"public_key,omitempty"`
+	PrivateKey string `yaml:"private_key,omitempty"`
+	BaseURL    string `yaml:"base_url,omitempty"` // default https://cloud.mongodb.com
+}
+
+// Configured reports whether the Atlas API can be called.
+func (a AtlasConfig) Configured() bool {
+	return a.ProjectID != "" && a.Cluster != "" && a.PublicKey != "" && a.PrivateKey != ""
 }
 
 // LogConfig defines logging settings.
@@ -59,9 +102,148 @@ type LogConfig struct {
 	Level         string `yaml:"level,omitempty"`     // debug, info, warn, error
 	Directory     string `yaml:"directory,omitempty"`  // default ~/.reloquent/logs/
 	RetentionDays int    `yaml:"retention_days,omitempty"` // default 30
+
+	// Components sets the level of single subsystems (discovery, migration,
+	// validation, api, aws), overriding Level for their records.
+	Components map[string]string `yaml:"components,omitempty"`
+}
+
+// BenchmarkConfig is the guard-rail profile for source read benchmarks,
+// which run against production tables.
+type BenchmarkConfig struct {
+	SamplePercent float64 `yaml:"sample_percent,omitempty"`  // default 1.0
+	MaxDuration   string  `yaml:"max_duration,omitempty"`    // e.g. 2m; default 5m
+	MaxRows       int64   `yaml:"max_rows,omitempty"`        // default unlimited
+	MaxBytes      int64   `yaml:"max_bytes,omitempty"`       // default 1 GB
+	OffPeakWindow string  `yaml:"off_peak_window,omitempty"` // local time, e.g. 22:00-06:00
+}
+
+// IndexesConfig points index inference at an export of the source's query
+// statistics (pg_stat_statements or Oracle AWR top SQL), whose filters and
+// sorts become additional suggested indexes, and sets how index builds are
+// scheduled.
+type IndexesConfig struct {
+	QueryLog       string `yaml:"query_log,omitempty"`       // CSV with query and calls columns
+	MinCalls       int64  `yaml:"min_calls,omitempty"`       // ignore access patterns run fewer times
+	MaxSuggestions int    `yaml:"max_suggestions,omitempty"` // default 20
+
+	Concurrency       int `yaml:"concurrency,omitempty"`          // collections indexed at once; default 1
+	MaxBuildsPerShard int `yaml:"max_builds_per_shard,omitempty"` // concurrent builds on any one shard; default unlimited
+
+	// PartialUnique builds unique constraints on nullable columns, or in
+	// embedded subdocuments that may be missing, as partial unique indexes
+	// so documents without a value do not collide.
+	PartialUnique bool `yaml:"partial_unique,omitempty"`
+
+	// AtlasSearch adds Atlas Search indexes on long text columns the source
+	// indexes for full-text search. They are created through the Atlas API
+	// and need the atlas section.
+	AtlasSearch bool `yaml:"atlas_search,omitempty"`
+
+	// NameTemplate names every generated index, e.g. {collection}_{keys},
+	// from the placeholders {collection}, {fields}, {direction} and {keys}.
+	// Index builds refuse a plan whose names do not follow it.
+	NameTemplate string `yaml:"name_template,omitempty"`
+}
+
+// MigrationConfig bounds the migration window. A run still going at the
+// deadline is stopped cleanly and marked window-expired so it can be resumed
+// in the next window instead of overrunning into business hours.
+type MigrationConfig struct {
+	Deadline    string `yaml:"deadline,omitempty"`     // local time (HH:MM) or RFC 3339 time to stop by
+	MaxDuration string `yaml:"max_duration,omitempty"` // e.g. 6h; the earlier of the two applies
+
+	ThroughputInterval string `yaml:"throughput_interval,omitempty"` // e.g. 30s; how often throughput is sampled, default 10s
+
+	// DualWrite records that applications keep writing to the source as well
+	// as MongoDB after the cutover, so the fallback plan can rely on the
+	// source being current.
+	DualWrite bool `yaml:"dual_write,omitempty"`
+
+	// StaleScript is what a Spark migration does when the mapping or type
+	// mapping changed since `reloquent generate` last wrote the script:
+	// fail (the default) or warn and go on with the current mapping.
+	StaleScript string `yaml:"stale_script,omitempty"`
+}
+
+// RunConfig answers the wizard steps that are decisions rather than
+// connections, so `reloquent run` can take a migration end to end without
+// prompts. Source, target, aws, migration and indexes keep their own
+// sections. Paths may start with ~.
+type RunConfig struct {
+	Steps                 []string          `yaml:"steps,omitempty"`                  // subset of discover, design, premigration, migrate, validate, indexes; default all
+	Tables                []string          `yaml:"tables,omitempty"`                 // tables to migrate; default every discovered table
+	Mapping               string            `yaml:"mapping,omitempty"`                // mapping YAML from the design step; default the project's saved mapping
+	TypeOverrides         map[string]string `yaml:"type_overrides,omitempty"`         // source type to BSON type, e.g. numeric: decimal
+	IndexPlan             string            `yaml:"index_plan,omitempty"`             // edited index plan YAML; default inferred
+	ValidationMode        string            `yaml:"validation_mode,omitempty"`        // full (default) or checksum
+	ValidationParallelism int               `yaml:"validation_parallelism,omitempty"` // collections validated at once; default 1
+	ValidationTimeBudget  string            `yaml:"validation_time_budget,omitempty"` // e.g. 2h; collections left are reported as skipped
+	ValidationPriority    []string          `yaml:"validation_priority,omitempty"`    // collections validated first; the rest go largest first
+	ValidationReferential string            `yaml:"validation_referential,omitempty"` // sample (default), full or off: checking referenced documents exist
+	ValidationRules       string            `yaml:"validation_rules,omitempty"`       // custom validation rules file; default validation-rules.yaml next to the state file
+	Delta                 bool              `yaml:"delta,omitempty"`                  // migrate only rows changed since the last run
+	ProgressFile          string            `yaml:"progress_file,omitempty"`          // JSON progress rewritten as the run goes, for external monitors
+}
+
+// HookConfig declares a custom step: an external command run before or
+// after a migration phase. See package hooks for how it is invoked.
+type HookConfig struct {
+	Name            string            `yaml:"name" json:"name"`
+	When            string            `yaml:"when" json:"when"`                                     // before:<phase> or after:<phase>
+	Command         []string          `yaml:"command" json:"command"`                               // program and arguments
+	Env             map[string]string `yaml:"env,omitempty" json:"-"`                               // added to the command's environment
+	Timeout         string            `yaml:"timeout,omitempty" json:"timeout,omitempty"`           // e.g. 30s; default 5m
+	ContinueOnError bool              `yaml:"continue_on_error,omitempty" json:"continue_on_error"` // a failure is logged, not fatal
+	RunOnce         bool              `yaml:"run_once,omitempty" json:"run_once"`                   // skipped once it has completed
+}
+
+// TelemetryConfig sets where anonymous usage statistics are uploaded once
+// the user opts in. Consent itself is kept outside the config; see package
+// telemetry.
+type TelemetryConfig struct {
+	Endpoint string `yaml:"endpoint,omitempty"` // HTTPS URL accepting a JSON array of events; empty keeps events local
+}
+
+// MetadataConfig chooses where a project keeps its state, run history,
+// audit events and job records.
+type MetadataConfig struct {
+	Store string `yaml:"store,omitempty"` // yaml (default) or sqlite; the YAML files are migrated on first use
 }
 
-// Load reads and parses the config file from the given path.
+// ServerConfig defines web UI server settings.
+type ServerConfig struct {
+	Auth AuthConfig `yaml:"auth,omitempty"`
+
+	// IdleTimeout is how long source and MongoDB connections kept open
+	// between engine calls may go unused before they are closed, e.g. 10m;
+	// default 5m. 0s closes them after every call.
+	IdleTimeout string `yaml:"idle_timeout,omitempty"`
+}
+
+// AuthConfig defines how the web API authenticates requests. Token,
+// Password and the OIDC client secret may be secret references.
+type AuthConfig struct {
+	Mode     string     `yaml:"mode,omitempty"`     // token (default), basic, oidc, or none
+	Token    string     `yaml:"token,omitempty"`    // token mode; generated on startup when empty
+	Username string     `yaml:"username,omitempty"` // basic mode
+	Password string     `yaml:"password,omitempty"` // basic mode
+	OIDC     OIDCConfig `yaml:"oidc,omitempty"`
+}
+
+// OIDCConfig defines an OpenID Connect provider for web UI sign-in.
+type OIDCConfig struct {
+	Issuer       string `yaml:"issuer,omitempty"`
+	ClientID     string `yaml:"client_id,omitempty"`
+	ClientSecret string `yaml:"client_secret,omitempty"`
+	RedirectURL  string `yaml:"redirect_url,omitempty"` // e.g. https://host:8230/auth/callback
+	// AllowedEmails limits access to these addresses; entries starting with
+	// @ allow a whole domain. Empty allows any user the provider signs in.
+	AllowedEmails []string `yaml:"allowed_emails,omitempty"`
+}
+
+// Load reads and parses the config file from the given path. Credential
+// references are left in place; see SourceConfig.Resolve.
 func Load(path string) (*Config, error) {
 	if path == "" {
 		path = ExpandHome(DefaultPath)
@@ -77,19 +259,25 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("parsing config: %w", err)
 	}
 
+	if cfg.Version > CurrentVersion {
+		return nil, fmt.Errorf("config version %d was written by a newer version of reloquent (this build reads %d); upgrade with `reloquent self-update`", cfg.Version, CurrentVersion)
+	}
 	if cfg.Version != CurrentVersion {
 		return nil, fmt.Errorf("unsupported config version %d (expected %d)", cfg.Version, CurrentVersion)
 	}
 
-	if err := cfg.resolveSecrets(); err != nil {
-		return nil, fmt.Errorf("resolving secrets: %w", err)
+	switch cfg.Metadata.Store {
+	case "", "yaml", "sqlite":
+	default:
+		return nil, fmt.Errorf("unknown metadata store %q (want yaml or sqlite)", cfg.Metadata.Store)
 	}
 
 	cfg.applyDefaults()
 	return cfg, nil
 }
 
-// Save writes the config to the given path.
+// Save writes the config to the given path. Plaintext credentials are moved
+// into the OS keychain first so they never reach the file.
 func (c *Config) Save(path string) error {
 	if path == "" {
 		path = ExpandHome(DefaultPath)
@@ -99,7 +287,15 @@ func (c *Config) Save(path string) error {
 		return fmt.Errorf("creating config directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(c)
+	out := *c
+	var err error
+	if out.Source, err = c.Source.Secured(); err != nil {
+		return err
+	}
+	if out.Target, err = c.Target.Secured(); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(&out)
 	if err != nil {
 		return fmt.Errorf("marshaling config: %w", err)
 	}
@@ -125,47 +321,6 @@ func (c *Config) applyDefaults() {
 	}
 }
 
-var secretPattern = regexp.MustCompile(`\$\{(ENV|VAULT|AWS_SM):([^}]+)\}`)
-
-func (c *Config) resolveSecrets() error {
-	var err error
-	c.Source.Password, err = ResolveValue(c.Source.Password)
-	if err != nil {
-		return fmt.Errorf("source password: %w", err)
-	}
-	c.Target.ConnectionString, err = ResolveValue(c.Target.ConnectionString)
-	if err != nil {
-		return fmt.Errorf("target connection string: %w", err)
-	}
-	return nil
-}
-
-// ResolveValue resolves secret references in a string value.
-func ResolveValue(val string) (string, error) {
-	matches := secretPattern.FindStringSubmatch(val)
-	if matches == nil {
-		return val, nil
-	}
-
-	provider := matches[1]
-	ref := matches[2]
-
-	switch provider {
-	case "ENV":
-		v := os.Getenv(ref)
-		if v == "" {
-			return "", fmt.Errorf("environment variable %s not set", ref)
-		}
-		return v, nil
-	case "VAULT":
-		return resolveVault(ref)
-	case "AWS_SM":
-		return resolveAWSSecretsManager(ref)
-	default:
-		return "", fmt.Errorf("unknown secrets provider: %s", provider)
-	}
-}
-
 // ExpandHome expands ~ to the user's home directory.
 func ExpandHome(path string) string {
 	if strings.HasPrefix(path, "~/") {
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -62,6 +63,9 @@ source:
 	if err == nil {
 		t.Fatal("expected error for invalid version")
 	}
+	if !strings.Contains(err.Error(), "self-update") {
+		t.Errorf("a newer config version should suggest upgrading: %v", err)
+	}
 }
 
 func TestResolveEnvSecret(t *testing.T) {
@@ -116,3 +120,51 @@ target:
 		t.Errorf("expected max_connections capped at 50, got %d", cfg.Source.MaxConnections)
 	}
 }
+
+func TestReload(t *testing.T) {
+	old := &Config{
+		Version: 1,
+		Source:  SourceConfig{Host: "db1", Password: "secret", MaxConnections: 20},
+		Logging: LogConfig{Level: "info"},
+		Server:  ServerConfig{Auth: AuthConfig{Mode: "token"}},
+	}
+	next := &Config{
+		Version:   1,
+		Source:    SourceConfig{Host: "db2", Password: "other", MaxConnections: 10},
+		Logging:   LogConfig{Level: "debug"},
+		Benchmark: BenchmarkConfig{MaxDuration: "2m"},
+		Hooks:     []HookConfig{{Name: "notify", When: "after:migration", Command: []string{"notify"}}},
+		Server:    ServerConfig{Auth: AuthConfig{Mode: "none"}},
+	}
+
+	got, changed, restart := old.Reload(next)
+	want := []string{
+		"logging.level: info -> debug",
+		"source.max_connections: 20 -> 10",
+		`benchmark.max_duration: "" -> 2m`,
+		"hooks changed",
+	}
+	if strings.Join(changed, "|") != strings.Join(want, "|") {
+		t.Errorf("changed = %q, want %q", changed, want)
+	}
+	if strings.Join(restart, ",") != "source,server" {
+		t.Errorf("restart = %v, want source,server", restart)
+	}
+	if strings.Contains(strings.Join(changed, " "), "secret") {
+		t.Error("changes must not show credentials")
+	}
+
+	if got.Logging.Level != "debug" || got.Source.MaxConnections != 10 || len(got.Hooks) != 1 {
+		t.Errorf("reloadable settings not applied: %+v", got)
+	}
+	if got.Source.Host != "db1" || got.Server.Auth.Mode != "token" {
+		t.Errorf("startup settings changed: source %s, auth %s", got.Source.Host, got.Server.Auth.Mode)
+	}
+	if old.Logging.Level != "info" {
+		t.Error("Reload must not modify the receiver")
+	}
+
+	if _, changed, restart := got.Reload(got); len(changed) != 0 || len(restart) != 0 {
+		t.Errorf("reloading the same config: changed %v, restart %v", changed, restart)
+	}
+}
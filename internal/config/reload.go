@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Reload returns a copy of c with the settings of next that can change
+// while the server runs: the log levels, the source connection limits, the
+// benchmark guard rails, index build limits, the migration window, hooks,
+// telemetry and run answers. Connections, AWS, server auth and the
+// metadata store are read once at startup and keep their values.
+//
+// changed describes each setting that took a new value, e.g.
+// "logging.level: info -> debug", or "hooks changed" for lists. restart
+// names the sections that differ in next but need a restart to apply.
+func (c *Config) Reload(next *Config) (out *Config, changed, restart []string) {
+	cp := *c
+	settings := []struct {
+		key        string
+		old, value interface{}
+	}{
+		{"logging.level", c.Logging.Level, next.Logging.Level},
+		{"logging.components", c.Logging.Components, next.Logging.Components},
+		{"source.max_connections", c.Source.MaxConnections, next.Source.MaxConnections},
+		{"source.discovery_parallelism", c.Source.DiscoveryParallelism, next.Source.DiscoveryParallelism},
+		{"benchmark", c.Benchmark, next.Benchmark},
+		{"indexes", c.Indexes, next.Indexes},
+		{"migration", c.Migration, next.Migration},
+		{"hooks", c.Hooks, next.Hooks},
+		{"telemetry", c.Telemetry, next.Telemetry},
+		{"run", c.Run, next.Run},
+	}
+	for _, s := range settings {
+		diffSetting(s.key, reflect.ValueOf(s.old), reflect.ValueOf(s.value), &changed)
+	}
+	cp.Logging.Level = next.Logging.Level
+	cp.Logging.Components = next.Logging.Components
+	cp.Source.MaxConnections = next.Source.MaxConnections
+	cp.Source.DiscoveryParallelism = next.Source.DiscoveryParallelism
+	cp.Benchmark = next.Benchmark
+	cp.Indexes = next.Indexes
+	cp.Migration = next.Migration
+	cp.Hooks = next.Hooks
+	cp.Telemetry = next.Telemetry
+	cp.Run = next.Run
+
+	// Whatever still differs is only read at startup
+	cv, nv := reflect.ValueOf(cp), reflect.ValueOf(*next)
+	for i := 0; i < cv.NumField(); i++ {
+		if !reflect.DeepEqual(cv.Field(i).Interface(), nv.Field(i).Interface()) {
+			restart = append(restart, yamlName(cv.Type().Field(i)))
+		}
+	}
+	return &cp, changed, restart
+}
+
+// diffSetting appends a description of each field that differs between old
+// and next, walking into structs. Secrets never appear: the reloadable
+// settings hold none, and lists are reported without their contents.
+func diffSetting(key string, old, next reflect.Value, out *[]string) {
+	if old.Kind() == reflect.Struct {
+		for i := 0; i < old.NumField(); i++ {
+			diffSetting(key+"."+yamlName(old.Type().Field(i)), old.Field(i), next.Field(i), out)
+		}
+		return
+	}
+	if reflect.DeepEqual(old.Interface(), next.Interface()) {
+		return
+	}
+	switch old.Kind() {
+	case reflect.Slice, reflect.Map:
+		*out = append(*out, key+" changed")
+	default:
+		*out = append(*out, fmt.Sprintf("%s: %s -> %s", key, settingValue(old), settingValue(next)))
+	}
+}
+
+func settingValue(v reflect.Value) string {
+	if v.Kind() == reflect.String && v.String() == "" {
+		return `""`
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// yamlName returns the key a struct field has in the config file.
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
@@ -0,0 +1,322 @@
+// Package cutover generates the runbook for switching applications from the
+// source database to MongoDB: the ordered steps of the cutover window, with
+// the commands and checks for this project's source, target and topology,
+// and the fallback plan for switching them back.
+package cutover
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+// Step IDs, in runbook order.
+const (
+	StepStopWrites   = "stop_writes"
+	StepFinalSync    = "final_sync"
+	StepValidate     = "validate"
+	StepWriteConcern = "write_concern"
+	StepBalancer     = "balancer"
+	StepSwitch       = "switch_connections"
+	StepSmokeTests   = "smoke_tests"
+	StepCDCTeardown  = "cdc_teardown"
+)
+
+// Input is what a runbook is built from.
+type Input struct {
+	Project string
+	Source  config.SourceConfig
+	Target  config.TargetConfig
+	State   *state.State     // may be nil before the first migration
+	Mapping *mapping.Mapping // may be nil
+
+	// Sharded adds the balancer step.
+	Sharded bool
+
+	// ValidationStatus is the status of the last validation report (PASS,
+	// FAIL or PARTIAL), or empty if validation has not run.
+	ValidationStatus string
+
+	// DualWrite means applications keep writing to the source after the
+	// cutover, so a fallback loses nothing written to MongoDB.
+	DualWrite bool
+}
+
+// Runbook is the ordered list of cutover or fallback steps.
+type Runbook struct {
+	Title       string    `json:"title"`
+	Project     string    `json:"project"`
+	Source      string    `json:"source"`
+	Target      string    `json:"target"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Steps       []Step    `json:"steps"`
+}
+
+// Step is one stage of the cutover. Checks are ticked off as they are
+// confirmed; commands are what to run for them.
+type Step struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Detail   string   `json:"detail"`
+	Commands []string `json:"commands,omitempty"`
+	Checks   []string `json:"checks"`
+	Note     string   `json:"note,omitempty"` // what the project's state says about the step
+	Done     bool     `json:"done"`           // the state shows it done already
+}
+
+// Build generates the runbook for a project.
+func Build(in Input) *Runbook {
+	st := in.State
+	if st == nil {
+		st = &state.State{}
+	}
+	rb := newRunbook("Cutover runbook", in)
+	cdc := usesCDC(st)
+
+	rb.Steps = append(rb.Steps, stopWrites(in.Source))
+	rb.Steps = append(rb.Steps, finalSync(st, cdc))
+	rb.Steps = append(rb.Steps, validate(in.ValidationStatus))
+	rb.Steps = append(rb.Steps, Step{
+		ID:       StepWriteConcern,
+		Title:    "Restore the production write concern",
+		Detail:   "The migration writes with a relaxed write concern. Applications must not write before the cluster default is back to majority with journaling.",
+		Commands: []string{mongosh(in.Target, `db.adminCommand({setDefaultRWConcern: 1, defaultWriteConcern: {w: "majority", j: true}})`)},
+		Checks:   []string{"Default write concern is `{w: \"majority\", j: true}` (`db.adminCommand({getDefaultRWConcern: 1})`)"},
+		Done:     st.WriteConcernRestored,
+		Note:     doneNote(st.WriteConcernRestored, "restored by the post-migration operations"),
+	})
+	if in.Sharded {
+		rb.Steps = append(rb.Steps, Step{
+			ID:       StepBalancer,
+			Title:    "Enable the balancer",
+			Detail:   "The balancer was stopped for the bulk load. Start it so chunks spread across the shards as applications write.",
+			Commands: []string{mongosh(in.Target, "sh.startBalancer()")},
+			Checks:   []string{"`sh.getBalancerState()` returns true"},
+			Done:     st.BalancerReEnabled,
+			Note:     doneNote(st.BalancerReEnabled, "re-enabled by the post-migration operations"),
+		})
+	}
+	rb.Steps = append(rb.Steps, switchConnections(in.Target))
+	rb.Steps = append(rb.Steps, smokeTests(in.Target, in.Mapping))
+	if cdc {
+		rb.Steps = append(rb.Steps, Step{
+			ID:       StepCDCTeardown,
+			Title:    "Release change data capture",
+			Detail:   "Once applications run on MongoDB and the source will not be written again, drop the replication slot or forget the starting SCN so the source stops retaining changes for it.",
+			Commands: []string{"reloquent cdc teardown"},
+			Checks:   []string{"CDC resources released"},
+		})
+	}
+	return rb
+}
+
+func newRunbook(title string, in Input) *Runbook {
+	return &Runbook{
+		Title:       title,
+		Project:     in.Project,
+		Source:      describeSource(in.Source),
+		Target:      describeTarget(in.Target, in.Sharded),
+		GeneratedAt: time.Now().UTC(),
+	}
+}
+
+// usesCDC reports whether the project streams source changes to MongoDB.
+func usesCDC(st *state.State) bool {
+	return st.CDCSlotName != "" || st.CDCStartPosition != "" || st.CDCStatus != ""
+}
+
+func stopWrites(src config.SourceConfig) Step {
+	s := Step{
+		ID:     StepStopWrites,
+		Title:  "Stop application writes",
+		Detail: fmt.Sprintf("Put every application writing to %s into maintenance or read-only mode and pause batch jobs and scheduled tasks. Writes made after this point would be lost.", sourceName(src)),
+		Checks: []string{
+			"Application writers stopped",
+			"Batch jobs and scheduled tasks paused",
+			"No sessions writing to the source",
+		},
+	}
+	switch src.Type {
+	case "postgresql":
+		s.Commands = []string{fmt.Sprintf("SELECT pid, usename, application_name, state, query FROM pg_stat_activity WHERE datname = '%s' AND pid <> pg_backend_pid() AND state <> 'idle';", src.Database)}
+	case "oracle":
+		s.Commands = []string{"SELECT sid, username, program, status FROM v$session WHERE type = 'USER' AND status = 'ACTIVE' AND sid <> SYS_CONTEXT('USERENV', 'SID');"}
+	}
+	return s
+}
+
+func finalSync(st *state.State, cdc bool) Step {
+	s := Step{ID: StepFinalSync}
+	switch {
+	case cdc:
+		s.Title = "Drain change data capture"
+		s.Detail = "Let replication apply the changes made before writes stopped, then stop it."
+		s.Commands = []string{"reloquent cdc run"}
+		s.Checks = []string{"Replication lag reads 0 bytes", "`reloquent cdc run` stopped"}
+		if st.CDCStatus != "" {
+			s.Note = "CDC is " + st.CDCStatus
+		}
+	case len(st.Watermarks) > 0:
+		s.Title = "Run the final delta migration"
+		s.Detail = "Copy the rows changed since the last run, by watermark column."
+		s.Commands = []string{"reloquent migrate --delta"}
+		s.Checks = []string{"Delta migration completed"}
+	default:
+		s.Title = "Confirm no changes since the bulk load"
+		s.Detail = "The project has no change data capture or delta watermarks, so rows written to the source after the migration started are not in MongoDB. Re-run the migration now if the source changed."
+		s.Commands = []string{"reloquent migrate"}
+		s.Checks = []string{"Source unchanged since the migration, or migration re-run"}
+	}
+	return s
+}
+
+func validate(status string) Step {
+	s := Step{
+		ID:       StepValidate,
+		Title:    "Validation gate",
+		Detail:   "Compare the target with the source now that both are still. Do not go on unless validation passes; roll back by restarting the application writers against the source.",
+		Commands: []string{"reloquent validate --mode checksum"},
+		Checks:   []string{"Validation status is PASS"},
+	}
+	if status != "" {
+		s.Note = "last validation: " + status
+		if status != "PASS" {
+			s.Note += "; resolve its failures before the cutover window"
+		}
+	}
+	return s
+}
+
+func switchConnections(tgt config.TargetConfig) Step {
+	return Step{
+		ID:     StepSwitch,
+		Title:  "Switch connection strings",
+		Detail: fmt.Sprintf("Point applications at `%s`, database `%s`, and restart them so no connection to the source is left.", redact(tgt.ConnectionString), tgt.Database),
+		Checks: []string{
+			"Application configuration and secrets updated",
+			"Applications restarted",
+			"No application sessions left on the source",
+		},
+	}
+}
+
+func smokeTests(tgt config.TargetConfig, m *mapping.Mapping) Step {
+	s := Step{
+		ID:     StepSmokeTests,
+		Title:  "Smoke tests",
+		Detail: "Exercise the applications against MongoDB before reopening them to users.",
+		Checks: []string{
+			"Key read paths return the expected data",
+			"A test write lands in MongoDB and reads back",
+		},
+	}
+	if m == nil || len(m.Collections) == 0 {
+		return s
+	}
+	names := make([]string, len(m.Collections))
+	for i, c := range m.Collections {
+		names[i] = fmt.Sprintf("%q", c.Name)
+	}
+	s.Commands = append(s.Commands, mongosh(tgt, fmt.Sprintf("[%s].forEach(c => print(c, db[c].countDocuments()))", strings.Join(names, ", "))))
+	s.Checks = append(s.Checks, "Document counts match the validated counts")
+	if len(m.CanaryQueries()) > 0 {
+		s.Commands = append(s.Commands, "reloquent canary")
+		s.Checks = append(s.Checks, "Canary queries are served by indexes")
+	}
+	return s
+}
+
+// Markdown renders the runbook with a checkbox for every check.
+func (rb *Runbook) Markdown() string {
+	var b strings.Builder
+	title := rb.Project
+	if title == "" {
+		title = "migration"
+	}
+	fmt.Fprintf(&b, "# %s: %s\n\n", rb.Title, title)
+	fmt.Fprintf(&b, "From %s to %s. Generated %s.\n\n", rb.Source, rb.Target, rb.GeneratedAt.Format(time.RFC3339))
+	b.WriteString("Work through the steps in order and tick each check as it is confirmed.\n")
+	for i, s := range rb.Steps {
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", i+1, s.Title)
+		b.WriteString(s.Detail + "\n")
+		if s.Note != "" {
+			fmt.Fprintf(&b, "\n> %s\n", s.Note)
+		}
+		for _, c := range s.Commands {
+			fmt.Fprintf(&b, "\n```\n%s\n```\n", c)
+		}
+		b.WriteString("\n")
+		for _, c := range s.Checks {
+			box := " "
+			if s.Done {
+				box = "x"
+			}
+			fmt.Fprintf(&b, "- [%s] %s\n", box, c)
+		}
+	}
+	return b.String()
+}
+
+// Write saves the runbook as Markdown.
+func (rb *Runbook) Write(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	return os.WriteFile(path, []byte(rb.Markdown()), 0o644)
+}
+
+func describeSource(src config.SourceConfig) string {
+	if src.Type == "" {
+		return "the source database"
+	}
+	return fmt.Sprintf("%s %s", src.Type, sourceName(src))
+}
+
+func sourceName(src config.SourceConfig) string {
+	name := "`" + src.Database + "`"
+	if src.Host != "" {
+		name += fmt.Sprintf(" on %s:%d", src.Host, src.Port)
+	}
+	return name
+}
+
+func describeTarget(tgt config.TargetConfig, sharded bool) string {
+	kind := "replica set"
+	if sharded {
+		kind = "sharded cluster"
+	}
+	return fmt.Sprintf("MongoDB `%s` (%s)", tgt.Database, kind)
+}
+
+// mongosh returns a shell command running script against the target, with
+// the password left out of the connection string.
+func mongosh(tgt config.TargetConfig, script string) string {
+	uri := redact(tgt.ConnectionString)
+	if tgt.Database != "" {
+		script = fmt.Sprintf("db = db.getSiblingDB(%q); %s", tgt.Database, script)
+	}
+	return fmt.Sprintf("mongosh %q --eval '%s'", uri, script)
+}
+
+// redact hides the password of a connection string.
+func redact(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.User == nil {
+		return uri
+	}
+	return u.Redacted()
+}
+
+func doneNote(done bool, how string) string {
+	if done {
+		return how
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package cutover
+
+import (
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+// Fallback step IDs, in plan order.
+const (
+	StepFallbackDecision = "fallback_decision"
+	StepFallbackStop     = "stop_mongodb_writes"
+	StepFallbackData     = "post_cutover_writes"
+	StepFallbackSwitch   = "switch_back"
+	StepFallbackVerify   = "verify_source"
+	StepFallbackNext     = "next_attempt"
+)
+
+// BuildFallback generates the plan for pointing applications back at the
+// source after the cutover. What happens to the writes made to MongoDB in
+// the meantime depends on whether applications dual-write: with dual
+// writes the source is current, without them those writes are lost unless
+// they are replayed, since nothing carries MongoDB changes back.
+func BuildFallback(in Input) *Runbook {
+	st := in.State
+	if st == nil {
+		st = &state.State{}
+	}
+	rb := newRunbook("Fallback plan", in)
+
+	rb.Steps = append(rb.Steps, Step{
+		ID:     StepFallbackDecision,
+		Title:  "Decide to fall back",
+		Detail: fmt.Sprintf("Fall back when applications cannot run correctly on MongoDB and a fix cannot land inside the agreed window. The longer applications write to MongoDB, the more there is to carry back to %s.", sourceName(in.Source)),
+		Checks: []string{
+			"Fallback approved by the change owner",
+			"Time of the cutover recorded (the start of writes to MongoDB)",
+		},
+	})
+	rb.Steps = append(rb.Steps, Step{
+		ID:       StepFallbackStop,
+		Title:    "Stop application writes to MongoDB",
+		Detail:   "Put the applications into maintenance or read-only mode so the set of writes made to MongoDB stops growing.",
+		Commands: []string{mongosh(in.Target, fmt.Sprintf("db.currentOp({active: true, ns: /^%s\\./})", in.Target.Database))},
+		Checks:   []string{"Application writers stopped", "No client operations left on the target database"},
+	})
+	rb.Steps = append(rb.Steps, postCutoverWrites(in.Target, in.Mapping, in.DualWrite))
+	rb.Steps = append(rb.Steps, Step{
+		ID:     StepFallbackSwitch,
+		Title:  "Switch connection strings back",
+		Detail: fmt.Sprintf("Point applications at %s again and restart them so no connection to MongoDB is left.", describeSource(in.Source)),
+		Checks: []string{
+			"Application configuration and secrets restored",
+			"Applications restarted",
+			"No application connections left on MongoDB",
+		},
+	})
+	rb.Steps = append(rb.Steps, verifySource(in.Source))
+	rb.Steps = append(rb.Steps, nextAttempt(st))
+	return rb
+}
+
+func postCutoverWrites(tgt config.TargetConfig, m *mapping.Mapping, dualWrite bool) Step {
+	if dualWrite {
+		return Step{
+			ID:     StepFallbackData,
+			Title:  "Confirm the source is current",
+			Detail: "Applications wrote to the source as well as MongoDB, so the source already has every change made since the cutover. Nothing written to MongoDB is lost, provided no write to the source failed.",
+			Checks: []string{
+				"No failed source writes in the application logs since the cutover",
+				"Source row counts match the MongoDB document counts",
+			},
+		}
+	}
+	s := Step{
+		ID:     StepFallbackData,
+		Title:  "Replay the writes made to MongoDB",
+		Detail: "Applications wrote only to MongoDB after the cutover, and nothing carries those writes back: documents inserted, changed or deleted since then are lost on fallback unless they are replayed to the source by hand or by the applications.",
+		Checks: []string{
+			"Documents changed since the cutover exported",
+			"Changes replayed to the source, or their loss accepted by the change owner",
+		},
+	}
+	if m == nil {
+		return s
+	}
+	var untracked []string
+	for i := range m.Collections {
+		c := &m.Collections[i]
+		field, ok := "", false
+		if c.Watermark != "" {
+			field, ok = c.RootField(c.Watermark)
+		}
+		if !ok {
+			untracked = append(untracked, c.Name)
+			continue
+		}
+		s.Commands = append(s.Commands, mongosh(tgt, fmt.Sprintf(`db[%q].find({%q: {$gt: ISODate("<cutover time>")}})`, c.Name, field)))
+	}
+	if len(untracked) > 0 {
+		s.Note = fmt.Sprintf("no watermark column identifies the documents changed in %s; find them from the application logs or a change stream opened at the cutover", joinNames(untracked))
+	}
+	return s
+}
+
+func verifySource(src config.SourceConfig) Step {
+	s := Step{
+		ID:     StepFallbackVerify,
+		Title:  "Verify the applications on the source",
+		Detail: "Exercise the applications against the source before reopening them to users.",
+		Checks: []string{
+			"Key read paths return the expected data",
+			"A test write lands in the source and reads back",
+		},
+	}
+	if src.ReadOnly {
+		s.Checks = append(s.Checks, "Source accepts writes again (it was read-only during the migration)")
+	}
+	return s
+}
+
+func nextAttempt(st *state.State) Step {
+	s := Step{
+		ID:    StepFallbackNext,
+		Title: "Prepare the next cutover attempt",
+	}
+	if usesCDC(st) {
+		s.Detail = "Change data capture streamed the source to MongoDB until the cutover and can resume for the next attempt, but documents written to MongoDB since then stay there beside the source's rows. Keep the CDC resources until the next attempt, or release them so the source stops retaining changes."
+		s.Commands = []string{"reloquent cdc run", "reloquent cdc teardown"}
+		s.Checks = []string{"CDC resumed from the source, or its resources released"}
+		if st.CDCStatus != "" {
+			s.Note = "CDC is " + st.CDCStatus
+		}
+		return s
+	}
+	s.Detail = "The MongoDB database now holds writes the source may not have. Re-run the migration before the next attempt so the target starts again from the source."
+	s.Commands = []string{"reloquent migrate"}
+	s.Checks = []string{"Target reloaded from the source before the next cutover"}
+	return s
+}
+
+func joinNames(names []string) string {
+	switch len(names) {
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " and " + names[1]
+	}
+	return fmt.Sprintf("%s and %d other collections", names[0], len(names)-1)
+}
@@ -0,0 +1,400 @@
+// Package dictionary generates a data dictionary for the migrated document
+// model: every field of every collection with its BSON type, the source
+// column it came from, whether it can be null, and example values sampled
+// from the target.
+package dictionary
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// maxExamples is the number of distinct example values kept per field.
+const maxExamples = 3
+
+// maxExampleLen truncates long example values.
+const maxExampleLen = 40
+
+// Dictionary describes the target document model.
+type Dictionary struct {
+	Database    string       `json:"database"`
+	GeneratedAt time.Time    `json:"generated_at"`
+	Collections []Collection `json:"collections"`
+}
+
+// Collection lists the fields of a target collection.
+type Collection struct {
+	Name        string  `json:"name"`
+	SourceTable string  `json:"source_table"`
+	Documents   int64   `json:"documents"` // source table row count
+	Sampled     int     `json:"sampled"`
+	Fields      []Field `json:"fields"`
+}
+
+// Field describes one document field. Fields of embedded arrays have paths
+// through the array field, as in MongoDB queries (e.g. items.sku).
+type Field struct {
+	Path       string           `json:"path"`
+	BSONType   typemap.BSONType `json:"bson_type"`
+	Source     string           `json:"source"`
+	SourceType string           `json:"source_type,omitempty"`
+	Nullable   bool             `json:"nullable"`
+	References string           `json:"references,omitempty"`
+	Examples   []string         `json:"examples,omitempty"`
+}
+
+// Build describes the document model from the schema, mapping and type map.
+// A nil type map falls back to the source database defaults.
+func Build(s *schema.Schema, m *mapping.Mapping, tm *typemap.TypeMap, database string) *Dictionary {
+	if tm == nil {
+		tm = typemap.ForDatabase(s.DatabaseType)
+	}
+	tables := make(map[string]*schema.Table, len(s.Tables))
+	for i := range s.Tables {
+		tables[s.Tables[i].Name] = &s.Tables[i]
+	}
+
+	d := &Dictionary{Database: database, GeneratedAt: time.Now().UTC()}
+	for i := range m.Collections {
+		col := &m.Collections[i]
+		dc := Collection{Name: col.Name, SourceTable: col.SourceTable}
+		if t := tables[col.SourceTable]; t != nil {
+			dc.Documents = t.RowCount
+			dc.Fields = tableFields(t, "", col.RootField, tm)
+		}
+		dc.Fields = append(dc.Fields, embeddedFields(col.Embedded, "", tables, tm)...)
+		d.Collections = append(d.Collections, dc)
+	}
+	return d
+}
+
+// tableFields describes the fields a table's columns are written to under
+// prefix, using field to find each column's path.
+func tableFields(t *schema.Table, prefix string, field func(string) (string, bool), tm *typemap.TypeMap) []Field {
+	refs := make(map[string]string)
+	for _, fk := range t.ForeignKeys {
+		for i, c := range fk.Columns {
+			if i < len(fk.ReferencedColumns) {
+				refs[c] = fk.ReferencedTable + "." + fk.ReferencedColumns[i]
+			}
+		}
+	}
+
+	var fields []Field
+	for _, c := range t.Columns {
+		path, ok := field(c.Name)
+		if !ok {
+			continue
+		}
+		fields = append(fields, Field{
+			Path:       prefix + path,
+			BSONType:   tm.ResolveColumn(c),
+			Source:     t.Name + "." + c.Name,
+			SourceType: c.DataType,
+			Nullable:   c.Nullable,
+			References: refs[c.Name],
+		})
+	}
+	return fields
+}
+
+// embeddedFields describes embedded subdocuments and arrays and their fields.
+func embeddedFields(embs []mapping.Embedded, prefix string, tables map[string]*schema.Table, tm *typemap.TypeMap) []Field {
+	var fields []Field
+	for i := range embs {
+		e := &embs[i]
+		path := prefix + e.FieldName
+		join, parent := strings.Join(e.JoinKeys(), ", "), strings.Join(e.ParentKeys(), ", ")
+		f := Field{
+			Path:     path,
+			BSONType: typemap.BSONArray,
+			Source:   fmt.Sprintf("%s rows where %s = parent %s", e.SourceTable, join, parent),
+		}
+		if e.Relationship == "single" {
+			f.BSONType = typemap.BSONDocument
+			f.Source = fmt.Sprintf("%s row where %s = parent %s", e.SourceTable, join, parent)
+			f.Nullable = true
+		}
+		fields = append(fields, f)
+		if t := tables[e.SourceTable]; t != nil {
+			fields = append(fields, tableFields(t, path+".", e.SubField, tm)...)
+		}
+		fields = append(fields, embeddedFields(e.Embedded, path+".", tables, tm)...)
+	}
+	return fields
+}
+
+// AddSamples records example values for a collection's fields from sampled
+// documents.
+func (d *Dictionary) AddSamples(collection string, docs []map[string]interface{}) {
+	for i := range d.Collections {
+		dc := &d.Collections[i]
+		if dc.Name != collection {
+			continue
+		}
+		dc.Sampled += len(docs)
+		for j := range dc.Fields {
+			f := &dc.Fields[j]
+			if f.BSONType == typemap.BSONArray || f.BSONType == typemap.BSONDocument {
+				continue
+			}
+			for _, doc := range docs {
+				for _, v := range values(doc, strings.Split(f.Path, ".")) {
+					f.addExample(formatExample(v))
+				}
+			}
+		}
+	}
+}
+
+// Sample reads up to n documents of every collection from the target and
+// records example values.
+func (d *Dictionary) Sample(ctx context.Context, op target.Operator, n int) error {
+	for _, dc := range d.Collections {
+		docs, err := op.SampleDocuments(ctx, dc.Name, n)
+		if err != nil {
+			return fmt.Errorf("sampling documents from %s: %w", dc.Name, err)
+		}
+		d.AddSamples(dc.Name, docs)
+	}
+	return nil
+}
+
+func (f *Field) addExample(s string) {
+	if s == "" || len(f.Examples) >= maxExamples {
+		return
+	}
+	for _, e := range f.Examples {
+		if e == s {
+			return
+		}
+	}
+	f.Examples = append(f.Examples, s)
+}
+
+// values returns the values at a path in a document, descending into arrays
+// of subdocuments along the way.
+func values(v interface{}, path []string) []interface{} {
+	if len(path) == 0 {
+		return []interface{}{v}
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		child := rv.MapIndex(reflect.ValueOf(path[0]))
+		if !child.IsValid() {
+			return nil
+		}
+		return values(child.Interface(), path[1:])
+	case reflect.Slice:
+		if d, ok := asDocument(rv); ok {
+			return values(d, path)
+		}
+		var out []interface{}
+		for i := 0; i < rv.Len(); i++ {
+			out = append(out, values(rv.Index(i).Interface(), path)...)
+		}
+		return out
+	}
+	return nil
+}
+
+// asDocument converts an ordered document (a slice of Key/Value structs, as
+// the driver decodes nested documents) to a map.
+func asDocument(rv reflect.Value) (map[string]interface{}, bool) {
+	et := rv.Type().Elem()
+	if et.Kind() != reflect.Struct {
+		return nil, false
+	}
+	if _, ok := et.FieldByName("Key"); !ok {
+		return nil, false
+	}
+	if _, ok := et.FieldByName("Value"); !ok {
+		return nil, false
+	}
+	m := make(map[string]interface{}, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		e := rv.Index(i)
+		m[e.FieldByName("Key").String()] = e.FieldByName("Value").Interface()
+	}
+	return m, true
+}
+
+// formatExample renders a sampled value for display. Nulls, documents and
+// arrays are not shown.
+func formatExample(v interface{}) string {
+	var s string
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		s = x
+	case time.Time:
+		s = x.UTC().Format(time.RFC3339)
+	case interface{ Time() time.Time }:
+		s = x.Time().UTC().Format(time.RFC3339)
+	case []byte:
+		s = fmt.Sprintf("<%d bytes>", len(x))
+	case fmt.Stringer:
+		s = x.String()
+	default:
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			return ""
+		}
+		s = fmt.Sprint(v)
+	}
+	if r := []rune(s); len(r) > maxExampleLen {
+		s = string(r[:maxExampleLen-1]) + "…"
+	}
+	return s
+}
+
+// Markdown renders the dictionary as a Markdown document.
+func (d *Dictionary) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Data dictionary: %s\n\n", d.title())
+	fmt.Fprintf(&b, "Generated %s.\n", d.GeneratedAt.Format(time.RFC3339))
+	for _, c := range d.Collections {
+		fmt.Fprintf(&b, "\n## %s\n\n", c.Name)
+		fmt.Fprintf(&b, "Source table `%s`, %d documents", c.SourceTable, c.Documents)
+		if c.Sampled > 0 {
+			fmt.Fprintf(&b, "; examples from %d sampled documents", c.Sampled)
+		}
+		b.WriteString(".\n\n")
+		b.WriteString("| Field | BSON type | Source | Nullable | Examples |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, f := range c.Fields {
+			examples := make([]string, len(f.Examples))
+			for i, e := range f.Examples {
+				examples[i] = "`" + mdEscape(strings.ReplaceAll(e, "`", "'")) + "`"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n",
+				f.Path, f.BSONType, mdEscape(f.source()), yesNo(f.Nullable), strings.Join(examples, ", "))
+		}
+	}
+	return b.String()
+}
+
+var htmlTemplate = template.Must(template.New("dictionary").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Data dictionary: {{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #1f2937; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #d1d5db; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f3f4f6; }
+code { font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Data dictionary: {{.Title}}</h1>
+<p>Generated {{.Generated}}.</p>
+{{range .Collections}}
+<h2 id="{{.Name}}">{{.Name}}</h2>
+<p>Source table <code>{{.SourceTable}}</code>, {{.Documents}} documents{{if .Sampled}}; examples from {{.Sampled}} sampled documents{{end}}.</p>
+<table>
+<tr><th>Field</th><th>BSON type</th><th>Source</th><th>Nullable</th><th>Examples</th></tr>
+{{range .Fields}}<tr><td><code>{{.Path}}</code></td><td>{{.BSONType}}</td><td>{{.Source}}</td><td>{{.Nullable}}</td><td>{{range $i, $e := .Examples}}{{if $i}}, {{end}}<code>{{$e}}</code>{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// HTML renders the dictionary as a standalone HTML page.
+func (d *Dictionary) HTML() (string, error) {
+	type field struct {
+		Path, BSONType, Source, Nullable string
+		Examples                         []string
+	}
+	type collection struct {
+		Name, SourceTable  string
+		Documents, Sampled int64
+		Fields             []field
+	}
+	data := struct {
+		Title, Generated string
+		Collections      []collection
+	}{Title: d.title(), Generated: d.GeneratedAt.Format(time.RFC3339)}
+	for _, c := range d.Collections {
+		hc := collection{Name: c.Name, SourceTable: c.SourceTable, Documents: c.Documents, Sampled: int64(c.Sampled)}
+		for _, f := range c.Fields {
+			hc.Fields = append(hc.Fields, field{
+				Path:     f.Path,
+				BSONType: string(f.BSONType),
+				Source:   f.source(),
+				Nullable: yesNo(f.Nullable),
+				Examples: f.Examples,
+			})
+		}
+		data.Collections = append(data.Collections, hc)
+	}
+
+	var b strings.Builder
+	if err := htmlTemplate.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("rendering data dictionary: %w", err)
+	}
+	return b.String(), nil
+}
+
+// Write writes the dictionary to path: HTML for .html and .htm files,
+// Markdown otherwise.
+func (d *Dictionary) Write(path string) error {
+	out := d.Markdown()
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".html" || ext == ".htm" {
+		var err error
+		if out, err = d.HTML(); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	return os.WriteFile(path, []byte(out), 0o644)
+}
+
+func (d *Dictionary) title() string {
+	if d.Database == "" {
+		return "target database"
+	}
+	return d.Database
+}
+
+// source describes where a field's value comes from.
+func (f Field) source() string {
+	s := f.Source
+	if f.SourceType != "" {
+		s += " (" + f.SourceType + ")"
+	}
+	if f.References != "" {
+		s += ", references " + f.References
+	}
+	return s
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// mdEscape keeps a value from breaking out of a Markdown table cell.
+func mdEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,277 @@
+package dictionary
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+func testDictionary() *Dictionary {
+	s := &schema.Schema{
+		DatabaseType: "postgresql",
+		Tables: []schema.Table{
+			{
+				Name:     "customers",
+				RowCount: 100,
+				Columns: []schema.Column{
+					{Name: "id", DataType: "integer"},
+					{Name: "full_name", DataType: "varchar", Nullable: true},
+					{Name: "ssn", DataType: "varchar"},
+				},
+			},
+			{
+				Name:     "orders",
+				RowCount: 1000,
+				Columns: []schema.Column{
+					{Name: "id", DataType: "integer"},
+					{Name: "customer_id", DataType: "integer"},
+					{Name: "total", DataType: "numeric"},
+				},
+				ForeignKeys: []schema.ForeignKey{
+					{Columns: []string{"customer_id"}, ReferencedTable: "customers", ReferencedColumns: []string{"id"}},
+				},
+			},
+		},
+	}
+	m := &mapping.Mapping{
+		Collections: []mapping.Collection{{
+			Name:        "customers",
+			SourceTable: "customers",
+			Transformations: []mapping.Transformation{
+				{SourceField: "full_name", Operation: "rename", TargetField: "name"},
+				{SourceField: "ssn", Operation: "exclude"},
+			},
+			Embedded: []mapping.Embedded{{
+				SourceTable:  "orders",
+				FieldName:    "orders",
+				Relationship: "array",
+				JoinColumn:   "customer_id",
+				ParentColumn: "id",
+			}},
+		}},
+	}
+	return Build(s, m, nil, "shop")
+}
+
+func TestBuild(t *testing.T) {
+	d := testDictionary()
+	if len(d.Collections) != 1 {
+		t.Fatalf("collections = %d, want 1", len(d.Collections))
+	}
+	c := d.Collections[0]
+	if c.SourceTable != "customers" || c.Documents != 100 {
+		t.Errorf("collection = %+v, want customers with 100 documents", c)
+	}
+
+	var paths []string
+	fields := make(map[string]Field)
+	for _, f := range c.Fields {
+		paths = append(paths, f.Path)
+		fields[f.Path] = f
+	}
+	want := "id name orders orders.id orders.customer_id orders.total"
+	if got := strings.Join(paths, " "); got != want {
+		t.Fatalf("paths = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		path     string
+		bsonType typemap.BSONType
+		source   string
+		nullable bool
+		refs     string
+	}{
+		{"id", typemap.BSONNumberLong, "customers.id", false, ""},
+		{"name", typemap.BSONString, "customers.full_name", true, ""},
+		{"orders", typemap.BSONArray, "orders rows where customer_id = parent id", false, ""},
+		{"orders.customer_id", typemap.BSONNumberLong, "orders.customer_id", false, "customers.id"},
+		{"orders.total", typemap.BSONDecimal128, "orders.total", false, ""},
+	}
+	for _, tt := range tests {
+		f := fields[tt.path]
+		if f.BSONType != tt.bsonType || f.Source != tt.source || f.Nullable != tt.nullable || f.References != tt.refs {
+			t.Errorf("%s = %+v, want %s from %q, nullable %v, references %q",
+				tt.path, f, tt.bsonType, tt.source, tt.nullable, tt.refs)
+		}
+	}
+}
+
+func TestBuild_SingleEmbedded(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "users", Columns: []schema.Column{{Name: "id", DataType: "integer"}}},
+		{Name: "profiles", Columns: []schema.Column{{Name: "bio", DataType: "text"}}},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name:        "users",
+		SourceTable: "users",
+		Embedded: []mapping.Embedded{{
+			SourceTable:  "profiles",
+			FieldName:    "profile",
+			Relationship: "single",
+			JoinColumn:   "user_id",
+			ParentColumn: "id",
+			Fields:       []mapping.FieldMapping{{Column: "bio", Target: "about"}},
+		}},
+	}}}
+	d := Build(s, m, typemap.DefaultPostgres(), "")
+
+	fields := d.Collections[0].Fields
+	if len(fields) != 3 {
+		t.Fatalf("fields = %+v, want id, profile and profile.about", fields)
+	}
+	if f := fields[1]; f.Path != "profile" || f.BSONType != typemap.BSONDocument || !f.Nullable {
+		t.Errorf("profile = %+v, want a nullable Document", f)
+	}
+	if f := fields[2]; f.Path != "profile.about" || f.Source != "profiles.bio" {
+		t.Errorf("field = %+v, want profile.about from profiles.bio", f)
+	}
+}
+
+func TestAddSamples(t *testing.T) {
+	d := testDictionary()
+	when := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	d.AddSamples("customers", []map[string]interface{}{
+		{"id": int64(1), "name": "Ada", "orders": []interface{}{
+			map[string]interface{}{"id": int64(10), "total": 9.5},
+			map[string]interface{}{"id": int64(11), "total": 12.0},
+		}},
+		{"id": int64(2), "name": nil, "orders": []interface{}{
+			map[string]interface{}{"id": int64(12), "total": 3.25},
+			map[string]interface{}{"id": int64(13), "total": 9.5},
+		}},
+		{"id": int64(1), "name": strings.Repeat("x", 100), "created": when},
+	})
+	d.AddSamples("other", []map[string]interface{}{{"id": int64(99)}})
+
+	c := d.Collections[0]
+	if c.Sampled != 3 {
+		t.Errorf("sampled = %d, want 3", c.Sampled)
+	}
+	examples := make(map[string][]string)
+	for _, f := range c.Fields {
+		examples[f.Path] = f.Examples
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"id", "1,2"},
+		{"name", "Ada," + strings.Repeat("x", maxExampleLen-1) + "…"},
+		{"orders", ""},
+		{"orders.id", "10,11,12"},
+		{"orders.total", "9.5,12,3.25"},
+		{"orders.customer_id", ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(examples[tt.path], ","); got != tt.want {
+			t.Errorf("%s examples = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFormatExample(t *testing.T) {
+	when := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{"nil", nil, ""},
+		{"string", "hello", "hello"},
+		{"int", int64(42), "42"},
+		{"bool", true, "true"},
+		{"time", when, "2024-03-01T12:00:00Z"},
+		{"bytes", []byte{1, 2, 3}, "<3 bytes>"},
+		{"document", map[string]interface{}{"a": 1}, ""},
+		{"array", []interface{}{1, 2}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatExample(tt.in); got != tt.want {
+				t.Errorf("formatExample(%v) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSample(t *testing.T) {
+	d := testDictionary()
+	op := &target.MockOperator{SampleDocs: map[string][]map[string]interface{}{
+		"customers": {{"id": int64(7), "name": "Grace"}},
+	}}
+	if err := d.Sample(context.Background(), op, 10); err != nil {
+		t.Fatalf("Sample: %v", err)
+	}
+	if f := d.Collections[0].Fields[1]; len(f.Examples) != 1 || f.Examples[0] != "Grace" {
+		t.Errorf("name examples = %v, want [Grace]", f.Examples)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	d := testDictionary()
+	d.AddSamples("customers", []map[string]interface{}{{"id": int64(1), "name": "a|b"}})
+	md := d.Markdown()
+	for _, want := range []string{
+		"# Data dictionary: shop",
+		"## customers",
+		"Source table `customers`, 100 documents; examples from 1 sampled documents.",
+		"| `name` | String | customers.full_name (varchar) | yes | `a\\|b` |",
+		"| `orders.customer_id` | NumberLong | orders.customer_id (integer), references customers.id | no |  |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	d := testDictionary()
+	d.AddSamples("customers", []map[string]interface{}{{"id": int64(1), "name": "<b>Ada</b>"}})
+	out, err := d.HTML()
+	if err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	for _, want := range []string{
+		"<title>Data dictionary: shop</title>",
+		`<h2 id="customers">customers</h2>`,
+		"<td><code>orders.total</code></td><td>Decimal128</td>",
+		"&lt;b&gt;Ada&lt;/b&gt;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("html missing %q", want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	d := testDictionary()
+	dir := t.TempDir()
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"dictionary.md", "# Data dictionary: shop"},
+		{"docs/dictionary.html", "<!DOCTYPE html>"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.file)
+		if err := d.Write(path); err != nil {
+			t.Fatalf("Write(%s): %v", tt.file, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(data), tt.want) {
+			t.Errorf("%s starts %q, want %q", tt.file, string(data)[:20], tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package discovery
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// maxDiscoveryParallelism caps the connections a parallel discovery opens,
+// whatever the config asks for.
+const maxDiscoveryParallelism = 16
+
+// discoveryBatchSize is how many tables one catalog query covers in a
+// parallel discovery.
+const discoveryBatchSize = 250
+
+// discoveryParallelism is the number of connections discovery uses: the
+// configured discovery_parallelism, at least 1, and no more than the
+// source's connection limit or maxDiscoveryParallelism.
+func discoveryParallelism(requested, maxConnections int) int {
+	n := max(1, requested)
+	if maxConnections > 0 {
+		n = min(n, maxConnections)
+	}
+	return min(n, maxDiscoveryParallelism)
+}
+
+// tableBatches splits the table names, sorted, into batches of at most size.
+func tableBatches(names []string, size int) [][]string {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	var batches [][]string
+	for len(sorted) > 0 {
+		n := min(size, len(sorted))
+		batches = append(batches, sorted[:n])
+		sorted = sorted[n:]
+	}
+	return batches
+}
+
+// runBatches calls fn for every batch, at most workers at a time. The first
+// error cancels the batches still to run and is returned.
+func runBatches(ctx context.Context, batches [][]string, workers int, fn func(ctx context.Context, names []string) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	work := make(chan []string)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for range min(workers, len(batches)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for names := range work {
+				if err := fn(ctx, names); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, b := range batches {
+		select {
+		case work <- b:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,116 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDiscoveryParallelism(t *testing.T) {
+	tests := []struct {
+		requested, maxConns, want int
+	}{
+		{0, 20, 1},
+		{1, 20, 1},
+		{8, 20, 8},
+		{8, 4, 4},
+		{64, 50, maxDiscoveryParallelism},
+		{4, 0, 4},
+	}
+	for _, tt := range tests {
+		if got := discoveryParallelism(tt.requested, tt.maxConns); got != tt.want {
+			t.Errorf("discoveryParallelism(%d, %d) = %d, want %d", tt.requested, tt.maxConns, got, tt.want)
+		}
+	}
+}
+
+func TestTableBatches(t *testing.T) {
+	got := tableBatches([]string{"e", "a", "d", "b", "c"}, 2)
+	want := [][]string{{"a", "b"}, {"c", "d"}, {"e"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tableBatches = %v, want %v", got, want)
+	}
+	if got := tableBatches(nil, 2); len(got) != 0 {
+		t.Errorf("no tables should give no batches, got %v", got)
+	}
+}
+
+func TestRunBatches(t *testing.T) {
+	var batches [][]string
+	for i := range 20 {
+		batches = append(batches, []string{fmt.Sprintf("t%02d", i)})
+	}
+
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	err := runBatches(context.Background(), batches, 4, func(_ context.Context, names []string) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		mu.Lock()
+		seen[names[0]] = true
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 20 {
+		t.Errorf("ran %d batches, want 20", len(seen))
+	}
+	if peak.Load() > 4 {
+		t.Errorf("%d batches ran at once, want at most 4", peak.Load())
+	}
+}
+
+func TestRunBatches_FirstErrorStops(t *testing.T) {
+	var batches [][]string
+	for i := range 100 {
+		batches = append(batches, []string{fmt.Sprint(i)})
+	}
+	boom := errors.New("permission denied for pg_index")
+	var ran atomic.Int32
+	err := runBatches(context.Background(), batches, 2, func(ctx context.Context, names []string) error {
+		ran.Add(1)
+		if names[0] == "3" {
+			return boom
+		}
+		return ctx.Err()
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("err = %v, want the failing batch's error", err)
+	}
+	if ran.Load() == 100 {
+		t.Error("batches after the failure should not all run")
+	}
+}
+
+func TestProgressReporter_Batched(t *testing.T) {
+	var got []Progress
+	rep := newProgressReporter(func(p Progress) { got = append(got, p) })
+	rep.batched = true
+	rep.tables(5)
+	rep.start(PhaseColumns)
+	rep.table("a") // rows are ignored in batch mode
+	rep.batch([]string{"a", "b", "c"})
+	rep.batch([]string{"d", "e"})
+	rep.finish()
+
+	var done []int
+	for _, p := range got {
+		done = append(done, p.TablesDone)
+	}
+	if want := []int{0, 3, 5, 5}; !reflect.DeepEqual(done, want) {
+		t.Errorf("tables done = %v, want %v", done, want)
+	}
+}
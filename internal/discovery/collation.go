@@ -0,0 +1,38 @@
+package discovery
+
+import (
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// postgresCollation describes a column's collation. Only nondeterministic
+// collations can treat different strings as equal; their strength comes
+// from the ICU locale, as in und-u-ks-level2 or und@colStrength=secondary.
+func postgresCollation(name, locale string, citext, deterministic bool) *schema.Collation {
+	c := &schema.Collation{Name: name, Locale: locale, CaseInsensitive: citext}
+	if citext || deterministic {
+		return c
+	}
+	l := strings.ToLower(locale)
+	switch {
+	case strings.Contains(l, "ks-level1") || strings.Contains(l, "colstrength=primary"):
+		c.CaseInsensitive, c.AccentInsensitive = true, true
+	case strings.Contains(l, "ks-level2") || strings.Contains(l, "colstrength=secondary"):
+		c.CaseInsensitive = true
+	}
+	return c
+}
+
+// oracleCollation describes a named Oracle collation: the _CI suffix
+// ignores case and _AI case and accents.
+func oracleCollation(name string) *schema.Collation {
+	c := &schema.Collation{Name: name}
+	switch {
+	case strings.HasSuffix(name, "_AI"):
+		c.CaseInsensitive, c.AccentInsensitive = true, true
+	case strings.HasSuffix(name, "_CI"):
+		c.CaseInsensitive = true
+	}
+	return c
+}
@@ -0,0 +1,42 @@
+package discovery
+
+import "testing"
+
+func TestPostgresCollation(t *testing.T) {
+	tests := []struct {
+		name, locale          string
+		citext, deterministic bool
+		caseIns, acc          bool
+	}{
+		{name: "citext", citext: true, deterministic: true, caseIns: true},
+		{name: "de-DE-x-icu", locale: "de-DE", deterministic: true},
+		{name: "case_insensitive", locale: "und-u-ks-level2", caseIns: true},
+		{name: "ignore_accents", locale: "und-u-ks-level1", caseIns: true, acc: true},
+		{name: "old_syntax", locale: "@colStrength=secondary", caseIns: true},
+		{name: "punctuation", locale: "und-u-ka-shifted"},
+		{name: "level2_deterministic", locale: "und-u-ks-level2", deterministic: true},
+	}
+	for _, tt := range tests {
+		c := postgresCollation(tt.name, tt.locale, tt.citext, tt.deterministic)
+		if c.Name != tt.name || c.Locale != tt.locale || c.CaseInsensitive != tt.caseIns || c.AccentInsensitive != tt.acc {
+			t.Errorf("postgresCollation(%s) = %+v", tt.name, c)
+		}
+	}
+}
+
+func TestOracleCollation(t *testing.T) {
+	tests := []struct {
+		name         string
+		caseIns, acc bool
+	}{
+		{name: "BINARY_CI", caseIns: true},
+		{name: "GERMAN_M_AI", caseIns: true, acc: true},
+		{name: "XFRENCH"},
+	}
+	for _, tt := range tests {
+		c := oracleCollation(tt.name)
+		if c.Name != tt.name || c.CaseInsensitive != tt.caseIns || c.AccentInsensitive != tt.acc {
+			t.Errorf("oracleCollation(%s) = %+v", tt.name, c)
+		}
+	}
+}
@@ -12,15 +12,23 @@ type Discoverer interface {
 	// Connect establishes a read-only connection to the source database.
 	Connect(ctx context.Context) error
 
-	// Discover extracts the full schema from the source database.
-	Discover(ctx context.Context) (*schema.Schema, error)
+	// Discover extracts the full schema from the source database, reporting
+	// each phase, and the tables read within it, to progress when it is not
+	// nil.
+	Discover(ctx context.Context, progress ProgressFunc) (*schema.Schema, error)
 
 	// Close closes the database connection.
 	Close() error
 }
 
-// New creates a Discoverer for the given source configuration.
+// New creates a Discoverer for the given source configuration, resolving its
+// credential references.
 func New(cfg *config.SourceConfig) (Discoverer, error) {
+	resolved, err := cfg.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	cfg = &resolved
 	switch cfg.Type {
 	case "postgresql":
 		return NewPostgres(cfg)
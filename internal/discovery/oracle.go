@@ -9,6 +9,7 @@ import (
 	_ "github.com/sijms/go-ora/v2"
 	"github.com/reloquent/reloquent/internal/config"
 	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
 )
 
 // Oracle implements Discoverer for Oracle databases using go-ora (pure Go, no Instant Client).
@@ -16,6 +17,8 @@ type Oracle struct {
 	cfg   *config.SourceConfig
 	db    *sql.DB
 	owner string // Oracle schema owner, defaults to username uppercased
+
+	progress *progressReporter // set while Discover runs
 }
 
 // NewOracle creates a new Oracle discoverer.
@@ -51,50 +54,74 @@ func (o *Oracle) Connect(ctx context.Context) error {
 	return nil
 }
 
-func (o *Oracle) Discover(ctx context.Context) (*schema.Schema, error) {
+func (o *Oracle) Discover(ctx context.Context, progress ProgressFunc) (*schema.Schema, error) {
 	if o.db == nil {
 		return nil, fmt.Errorf("not connected; call Connect first")
 	}
 
+	rep := newProgressReporter(progress)
+	o.progress = rep
+	defer func() { o.progress = nil }()
+
+	rep.start(PhaseTables)
 	tables, err := o.discoverTables(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("discovering tables: %w", err)
 	}
+	rep.tables(len(tables))
+	rep.finish()
 
 	tableMap := make(map[string]*schema.Table, len(tables))
 	for i := range tables {
 		tableMap[tables[i].Name] = &tables[i]
 	}
 
+	rep.start(PhaseColumns)
 	if err := o.discoverColumns(ctx, tableMap); err != nil {
 		return nil, fmt.Errorf("discovering columns: %w", err)
 	}
+	rep.finish()
 
+	rep.start(PhasePrimaryKeys)
 	if err := o.discoverPrimaryKeys(ctx, tableMap); err != nil {
 		return nil, fmt.Errorf("discovering primary keys: %w", err)
 	}
+	rep.finish()
 
+	rep.start(PhaseForeignKeys)
 	if err := o.discoverForeignKeys(ctx, tableMap); err != nil {
 		return nil, fmt.Errorf("discovering foreign keys: %w", err)
 	}
+	rep.finish()
 
+	rep.start(PhaseIndexes)
 	if err := o.discoverIndexes(ctx, tableMap); err != nil {
 		return nil, fmt.Errorf("discovering indexes: %w", err)
 	}
+	rep.finish()
 
+	rep.start(PhaseCheckConstraints)
 	if err := o.discoverCheckConstraints(ctx, tableMap); err != nil {
 		return nil, fmt.Errorf("discovering check constraints: %w", err)
 	}
+	rep.finish()
 
+	rep.start(PhaseSequences)
 	if err := o.detectSequences(ctx, tableMap); err != nil {
 		return nil, fmt.Errorf("detecting sequences: %w", err)
 	}
+	rep.finish()
+
+	// See Postgres.Discover; DBTIMEZONE is a region name or an offset
+	var tz string
+	_ = o.db.QueryRowContext(ctx, "SELECT DBTIMEZONE FROM dual").Scan(&tz)
 
 	return &schema.Schema{
 		DatabaseType: "oracle",
 		Host:         o.cfg.Host,
 		Database:     o.cfg.Database,
 		SchemaName:   o.owner,
+		TimeZone:     tz,
 		Tables:       tables,
 	}, nil
 }
@@ -158,6 +185,8 @@ func (o *Oracle) discoverTablesFallback(ctx context.Context) ([]schema.Table, er
 	return tables, rows.Err()
 }
 
+// discoverColumns fetches all columns of the owner's tables. SDO_GEOMETRY
+// columns get their SRID and shape from discoverGeometry.
 func (o *Oracle) discoverColumns(ctx context.Context, tableMap map[string]*schema.Table) error {
 	query := `
 		SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE,
@@ -173,6 +202,7 @@ func (o *Oracle) discoverColumns(ctx context.Context, tableMap map[string]*schem
 	}
 	defer rows.Close()
 
+	geo := false
 	for rows.Next() {
 		var (
 			tableName, colName, dataType, nullable string
@@ -182,6 +212,7 @@ func (o *Oracle) discoverColumns(ctx context.Context, tableMap map[string]*schem
 		if err := rows.Scan(&tableName, &colName, &dataType, &nullable, &defaultVal, &maxLen, &precision, &scale); err != nil {
 			return err
 		}
+		o.progress.table(tableName)
 
 		t, ok := tableMap[tableName]
 		if !ok {
@@ -197,8 +228,107 @@ func (o *Oracle) discoverColumns(ctx context.Context, tableMap map[string]*schem
 			Precision:    precision,
 			Scale:        scale,
 		}
+		if typemap.IsGeo(dataType) {
+			geo = true
+		}
 		t.Columns = append(t.Columns, col)
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if geo {
+		if err := o.discoverGeometry(ctx, tableMap); err != nil {
+			return err
+		}
+	}
+	return o.discoverCollations(ctx, tableMap)
+}
+
+// discoverCollations records the declared collation of columns that do not
+// compare in binary. Column collations arrived in Oracle 12.2; on earlier
+// releases the COLLATION column does not exist (ORA-00904) and every column
+// is left as binary.
+func (o *Oracle) discoverCollations(ctx context.Context, tableMap map[string]*schema.Table) error {
+	query := `
+		SELECT TABLE_NAME, COLUMN_NAME, COLLATION
+		FROM ALL_TAB_COLUMNS
+		WHERE OWNER = :1
+		  AND COLLATION IS NOT NULL
+		  AND COLLATION NOT IN ('BINARY', 'USING_NLS_COMP', 'USING_NLS_SORT')`
+
+	rows, err := o.db.QueryContext(ctx, query, o.owner)
+	if err != nil {
+		if strings.Contains(err.Error(), "ORA-00904") {
+			return nil
+		}
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tableName, colName, name string
+		if err := rows.Scan(&tableName, &colName, &name); err != nil {
+			return err
+		}
+		t, ok := tableMap[tableName]
+		if !ok {
+			continue
+		}
+		for i := range t.Columns {
+			if t.Columns[i].Name == colName {
+				t.Columns[i].Collation = oracleCollation(name)
+			}
+		}
+	}
+	return rows.Err()
+}
+
+
+// discoverGeometry reads the SRID of SDO_GEOMETRY columns from the spatial
+// metadata, and their shape from the layer type of a spatial index on them.
+func (o *Oracle) discoverGeometry(ctx context.Context, tableMap map[string]*schema.Table) error {
+	query := `
+		SELECT m.TABLE_NAME, m.COLUMN_NAME, m.SRID, x.SDO_LAYER_GTYPE
+		FROM ALL_SDO_GEOM_METADATA m
+		LEFT JOIN ALL_SDO_INDEX_INFO i ON i.TABLE_OWNER = m.OWNER
+		  AND i.TABLE_NAME = m.TABLE_NAME AND i.COLUMN_NAME = m.COLUMN_NAME
+		LEFT JOIN ALL_SDO_INDEX_METADATA x ON x.SDO_INDEX_OWNER = i.INDEX_OWNER
+		  AND x.SDO_INDEX_NAME = i.INDEX_NAME
+		WHERE m.OWNER = :1`
+
+	rows, err := o.db.QueryContext(ctx, query, o.owner)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			tableName, colName string
+			srid               *int
+			shape              *string
+		)
+		if err := rows.Scan(&tableName, &colName, &srid, &shape); err != nil {
+			return err
+		}
+
+		t, ok := tableMap[tableName]
+		if !ok {
+			continue
+		}
+		for i := range t.Columns {
+			if t.Columns[i].Name != colName {
+				continue
+			}
+			if srid != nil {
+				t.Columns[i].SRID = *srid
+			}
+			if shape != nil {
+				t.Columns[i].GeometryType = typemap.GeoJSONType(*shape)
+			}
+			break
+		}
+	}
 	return rows.Err()
 }
 
@@ -222,6 +352,7 @@ func (o *Oracle) discoverPrimaryKeys(ctx context.Context, tableMap map[string]*s
 		if err := rows.Scan(&tableName, &constraintName, &colName); err != nil {
 			return err
 		}
+		o.progress.table(tableName)
 
 		t, ok := tableMap[tableName]
 		if !ok {
@@ -267,6 +398,7 @@ func (o *Oracle) discoverForeignKeys(ctx context.Context, tableMap map[string]*s
 		if err := rows.Scan(&r.tableName, &r.constraintName, &r.column, &r.refTable, &r.refColumn); err != nil {
 			return err
 		}
+		o.progress.table(r.tableName)
 		fkRows = append(fkRows, r)
 	}
 	if err := rows.Err(); err != nil {
@@ -301,11 +433,18 @@ func (o *Oracle) discoverForeignKeys(ctx context.Context, tableMap map[string]*s
 	return nil
 }
 
+// discoverIndexes fetches non-primary-key indexes. The expressions of
+// function-based indexes replace their hidden SYS_NC columns, and domain
+// indexes (such as Oracle Text) report their index type name.
 func (o *Oracle) discoverIndexes(ctx context.Context, tableMap map[string]*schema.Table) error {
 	query := `
-		SELECT i.TABLE_NAME, i.INDEX_NAME, i.UNIQUENESS, i.INDEX_TYPE, ic.COLUMN_NAME
+		SELECT i.TABLE_NAME, i.INDEX_NAME, i.UNIQUENESS,
+		       CASE WHEN i.INDEX_TYPE = 'DOMAIN' THEN i.ITYP_NAME ELSE i.INDEX_TYPE END,
+		       ic.COLUMN_NAME, e.COLUMN_EXPRESSION
 		FROM ALL_INDEXES i
 		JOIN ALL_IND_COLUMNS ic ON i.INDEX_NAME = ic.INDEX_NAME AND i.TABLE_OWNER = ic.TABLE_OWNER
+		LEFT JOIN ALL_IND_EXPRESSIONS e ON e.INDEX_OWNER = ic.INDEX_OWNER
+		  AND e.INDEX_NAME = ic.INDEX_NAME AND e.COLUMN_POSITION = ic.COLUMN_POSITION
 		WHERE i.TABLE_OWNER = :1
 		  AND i.INDEX_NAME NOT IN (
 			SELECT CONSTRAINT_NAME FROM ALL_CONSTRAINTS
@@ -325,9 +464,11 @@ func (o *Oracle) discoverIndexes(ctx context.Context, tableMap map[string]*schem
 
 	for rows.Next() {
 		var tableName, indexName, uniqueness, indexType, colName string
-		if err := rows.Scan(&tableName, &indexName, &uniqueness, &indexType, &colName); err != nil {
+		var expression *string
+		if err := rows.Scan(&tableName, &indexName, &uniqueness, &indexType, &colName, &expression); err != nil {
 			return err
 		}
+		o.progress.table(tableName)
 
 		k := idxKey{tableName, indexName}
 		idx, exists := grouped[k]
@@ -340,7 +481,11 @@ func (o *Oracle) discoverIndexes(ctx context.Context, tableMap map[string]*schem
 			grouped[k] = idx
 			order = append(order, k)
 		}
-		idx.Columns = append(idx.Columns, colName)
+		if expression != nil {
+			idx.Expressions = append(idx.Expressions, *expression)
+		} else {
+			idx.Columns = append(idx.Columns, colName)
+		}
 	}
 	if err := rows.Err(); err != nil {
 		return err
@@ -376,6 +521,7 @@ func (o *Oracle) discoverCheckConstraints(ctx context.Context, tableMap map[stri
 		if err := rows.Scan(&tableName, &constraintName, &searchCondition); err != nil {
 			return err
 		}
+		o.progress.table(tableName)
 
 		t, ok := tableMap[tableName]
 		if !ok {
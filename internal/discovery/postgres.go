@@ -8,6 +8,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/reloquent/reloquent/internal/config"
 	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
 )
 
 // Postgres implements Discoverer for PostgreSQL databases.
@@ -15,6 +16,13 @@ type Postgres struct {
 	cfg    *config.SourceConfig
 	pool   *pgxpool.Pool
 	schema string // pg schema to discover, defaults to "public"
+
+	// parallelism is the number of connections discovery uses. At 1 every
+	// catalog query covers all tables; above 1 the queries after table
+	// enumeration are split into table batches run concurrently.
+	parallelism int
+
+	progress *progressReporter // set while Discover runs
 }
 
 // NewPostgres creates a new PostgreSQL discoverer.
@@ -23,7 +31,7 @@ func NewPostgres(cfg *config.SourceConfig) (*Postgres, error) {
 	if s == "" {
 		s = "public"
 	}
-	return &Postgres{cfg: cfg, schema: s}, nil
+	return &Postgres{cfg: cfg, schema: s, parallelism: discoveryParallelism(cfg.DiscoveryParallelism, cfg.MaxConnections)}, nil
 }
 
 func (p *Postgres) Connect(ctx context.Context) error {
@@ -41,8 +49,9 @@ func (p *Postgres) Connect(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("parsing connection string: %w", err)
 	}
-	// Discovery uses a single connection per PLAN.md
-	poolCfg.MaxConns = 1
+	// Discovery uses a single connection per PLAN.md unless parallel
+	// discovery is configured.
+	poolCfg.MaxConns = int32(p.parallelism)
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
@@ -58,50 +67,63 @@ func (p *Postgres) Connect(ctx context.Context) error {
 	return nil
 }
 
-func (p *Postgres) Discover(ctx context.Context) (*schema.Schema, error) {
+func (p *Postgres) Discover(ctx context.Context, progress ProgressFunc) (*schema.Schema, error) {
 	if p.pool == nil {
 		return nil, fmt.Errorf("not connected; call Connect first")
 	}
 
+	rep := newProgressReporter(progress)
+	if rep != nil {
+		rep.batched = p.parallelism > 1
+	}
+	p.progress = rep
+	defer func() { p.progress = nil }()
+
+	rep.start(PhaseTables)
 	tables, err := p.discoverTables(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("discovering tables: %w", err)
 	}
+	rep.tables(len(tables))
+	rep.finish()
 
 	tableMap := make(map[string]*schema.Table, len(tables))
 	for i := range tables {
 		tableMap[tables[i].Name] = &tables[i]
 	}
 
-	if err := p.discoverColumns(ctx, tableMap); err != nil {
-		return nil, fmt.Errorf("discovering columns: %w", err)
-	}
-
-	if err := p.discoverPrimaryKeys(ctx, tableMap); err != nil {
-		return nil, fmt.Errorf("discovering primary keys: %w", err)
+	phases := []struct {
+		phase, what string
+		fn          func(context.Context, map[string]*schema.Table, []string) error
+	}{
+		{PhaseColumns, "discovering columns", p.discoverColumns},
+		{PhasePrimaryKeys, "discovering primary keys", p.discoverPrimaryKeys},
+		{PhaseForeignKeys, "discovering foreign keys", p.discoverForeignKeys},
+		{PhaseIndexes, "discovering indexes", p.discoverIndexes},
+		{PhaseCheckConstraints, "discovering check constraints", p.discoverCheckConstraints},
+		{PhaseSequences, "detecting sequences", p.detectSequences},
 	}
-
-	if err := p.discoverForeignKeys(ctx, tableMap); err != nil {
-		return nil, fmt.Errorf("discovering foreign keys: %w", err)
-	}
-
-	if err := p.discoverIndexes(ctx, tableMap); err != nil {
-		return nil, fmt.Errorf("discovering indexes: %w", err)
-	}
-
-	if err := p.discoverCheckConstraints(ctx, tableMap); err != nil {
-		return nil, fmt.Errorf("discovering check constraints: %w", err)
+	names := tableNames(tableMap)
+	for _, ph := range phases {
+		rep.start(ph.phase)
+		if err := p.inBatches(ctx, tableMap, names, ph.fn); err != nil {
+			return nil, fmt.Errorf("%s: %w", ph.what, err)
+		}
+		rep.finish()
 	}
 
-	if err := p.detectSequences(ctx, tableMap); err != nil {
-		return nil, fmt.Errorf("detecting sequences: %w", err)
-	}
+	// The server's time zone is what timestamps without one may have been
+	// written in; without it that policy cannot be chosen, but nothing else
+	// depends on it
+	var tz string
+	_ = p.pool.QueryRow(ctx, "SHOW TimeZone").Scan(&tz)
 
 	return &schema.Schema{
 		DatabaseType: "postgresql",
 		Host:         p.cfg.Host,
 		Database:     p.cfg.Database,
 		SchemaName:   p.schema,
+		TimeZone:     tz,
 		Tables:       tables,
 	}, nil
 }
@@ -148,39 +170,51 @@ func (p *Postgres) discoverTables(ctx context.Context) ([]schema.Table, error) {
 	return tables, rows.Err()
 }
 
-// discoverColumns fetches all columns for all tables in the schema.
-func (p *Postgres) discoverColumns(ctx context.Context, tableMap map[string]*schema.Table) error {
+// discoverColumns fetches all columns for all tables in the schema. PostGIS
+// columns are reported by their type, geometry or geography, rather than as
+// USER-DEFINED, and get their SRID and shape from discoverGeometry. Enum
+// columns are reported as enum with their type's labels, and domain columns
+// as the domain's base type; both keep the declared type's name.
+func (p *Postgres) discoverColumns(ctx context.Context, tableMap map[string]*schema.Table, names []string) error {
 	query := `
 		SELECT
-			table_name,
-			column_name,
-			data_type,
-			is_nullable,
-			column_default,
-			character_maximum_length,
-			numeric_precision,
-			numeric_scale
-		FROM information_schema.columns
-		WHERE table_schema = $1
-		  AND table_name = ANY($2)
-		ORDER BY table_name, ordinal_position`
+			c.table_name,
+			c.column_name,
+			CASE WHEN c.data_type = 'USER-DEFINED' AND c.udt_name IN ('geometry', 'geography') THEN c.udt_name::text
+				WHEN c.data_type = 'USER-DEFINED' AND t.typtype = 'e' THEN 'enum'
+				ELSE c.data_type::text END,
+			COALESCE(c.domain_name::text, CASE WHEN t.typtype = 'e' THEN c.udt_name::text END, ''),
+			ARRAY(SELECT e.enumlabel::text FROM pg_catalog.pg_enum e WHERE e.enumtypid = t.oid ORDER BY e.enumsortorder),
+			c.is_nullable,
+			c.column_default,
+			c.character_maximum_length,
+			c.numeric_precision,
+			c.numeric_scale
+		FROM information_schema.columns c
+		LEFT JOIN pg_catalog.pg_namespace tn ON tn.nspname = c.udt_schema
+		LEFT JOIN pg_catalog.pg_type t ON t.typnamespace = tn.oid AND t.typname = c.udt_name
+		WHERE c.table_schema = $1
+		  AND c.table_name = ANY($2)
+		ORDER BY c.table_name, c.ordinal_position`
 
-	names := tableNames(tableMap)
 	rows, err := p.pool.Query(ctx, query, p.schema, names)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
+	geo := false
 	for rows.Next() {
 		var (
-			tableName, colName, dataType, nullable string
-			defaultVal                              *string
-			maxLen, precision, scale                 *int
+			tableName, colName, dataType, typeName, nullable string
+			labels                                           []string
+			defaultVal                                       *string
+			maxLen, precision, scale                         *int
 		)
-		if err := rows.Scan(&tableName, &colName, &dataType, &nullable, &defaultVal, &maxLen, &precision, &scale); err != nil {
+		if err := rows.Scan(&tableName, &colName, &dataType, &typeName, &labels, &nullable, &defaultVal, &maxLen, &precision, &scale); err != nil {
 			return err
 		}
+		p.progress.table(tableName)
 
 		t, ok := tableMap[tableName]
 		if !ok {
@@ -195,14 +229,124 @@ func (p *Postgres) discoverColumns(ctx context.Context, tableMap map[string]*sch
 			MaxLength:    maxLen,
 			Precision:    precision,
 			Scale:        scale,
+			TypeName:     typeName,
+		}
+		if typemap.IsEnum(dataType) {
+			col.EnumValues = labels
+		}
+		if typemap.IsGeo(dataType) {
+			geo = true
 		}
 		t.Columns = append(t.Columns, col)
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if geo {
+		if err := p.discoverGeometry(ctx, tableMap, names); err != nil {
+			return err
+		}
+	}
+	return p.discoverCollations(ctx, tableMap, names)
+}
+
+// discoverCollations records the collation of text columns that do not
+// compare byte by byte: citext columns, which ignore case, and columns with
+// a collation other than the database default, C or POSIX. A
+// nondeterministic ICU collation whose strength is set below tertiary
+// ignores case, and at primary strength accents too. The ICU locale moved
+// between pg_collation columns across releases, so it is read by name.
+func (p *Postgres) discoverCollations(ctx context.Context, tableMap map[string]*schema.Table, names []string) error {
+	query := `
+		SELECT c.relname, a.attname,
+			CASE WHEN ty.typname = 'citext' THEN 'citext' ELSE co.collname::text END,
+			COALESCE(to_jsonb(co)->>'colllocale', to_jsonb(co)->>'colliculocale', co.collcollate::text, ''),
+			ty.typname = 'citext',
+			COALESCE((to_jsonb(co)->>'collisdeterministic')::boolean, true)
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type ty ON ty.oid = a.atttypid
+		LEFT JOIN pg_catalog.pg_collation co ON co.oid = a.attcollation
+		WHERE n.nspname = $1
+		  AND c.relname = ANY($2)
+		  AND a.attnum > 0 AND NOT a.attisdropped
+		  AND (ty.typname = 'citext'
+		    OR (co.collname IS NOT NULL AND co.collname NOT IN ('default', 'C', 'POSIX', 'ucs_basic')))`
+
+	rows, err := p.pool.Query(ctx, query, p.schema, names)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			tableName, colName, name, locale string
+			citext, deterministic            bool
+		)
+		if err := rows.Scan(&tableName, &colName, &name, &locale, &citext, &deterministic); err != nil {
+			return err
+		}
+		t, ok := tableMap[tableName]
+		if !ok {
+			continue
+		}
+		for i := range t.Columns {
+			if t.Columns[i].Name == colName {
+				t.Columns[i].Collation = postgresCollation(name, locale, citext, deterministic)
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// discoverGeometry reads the SRID and shape of PostGIS columns from the
+// geometry_columns and geography_columns views.
+func (p *Postgres) discoverGeometry(ctx context.Context, tableMap map[string]*schema.Table, names []string) error {
+	query := `
+		SELECT f_table_name, f_geometry_column, srid, type
+		FROM geometry_columns
+		WHERE f_table_schema = $1
+		  AND f_table_name = ANY($2)
+		UNION ALL
+		SELECT f_table_name, f_geography_column, srid, type
+		FROM geography_columns
+		WHERE f_table_schema = $1
+		  AND f_table_name = ANY($2)`
+
+	rows, err := p.pool.Query(ctx, query, p.schema, names)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			tableName, colName, shape string
+			srid                      int
+		)
+		if err := rows.Scan(&tableName, &colName, &srid, &shape); err != nil {
+			return err
+		}
+
+		t, ok := tableMap[tableName]
+		if !ok {
+			continue
+		}
+		for i := range t.Columns {
+			if t.Columns[i].Name == colName {
+				t.Columns[i].SRID = srid
+				t.Columns[i].GeometryType = typemap.GeoJSONType(shape)
+				break
+			}
+		}
+	}
 	return rows.Err()
 }
 
 // discoverPrimaryKeys fetches primary key constraints.
-func (p *Postgres) discoverPrimaryKeys(ctx context.Context, tableMap map[string]*schema.Table) error {
+func (p *Postgres) discoverPrimaryKeys(ctx context.Context, tableMap map[string]*schema.Table, names []string) error {
 	query := `
 		SELECT
 			tc.table_name,
@@ -217,7 +361,6 @@ func (p *Postgres) discoverPrimaryKeys(ctx context.Context, tableMap map[string]
 		  AND tc.table_name = ANY($2)
 		ORDER BY tc.table_name, kcu.ordinal_position`
 
-	names := tableNames(tableMap)
 	rows, err := p.pool.Query(ctx, query, p.schema, names)
 	if err != nil {
 		return err
@@ -229,6 +372,7 @@ func (p *Postgres) discoverPrimaryKeys(ctx context.Context, tableMap map[string]
 		if err := rows.Scan(&tableName, &constraintName, &colName); err != nil {
 			return err
 		}
+		p.progress.table(tableName)
 
 		t, ok := tableMap[tableName]
 		if !ok {
@@ -244,7 +388,7 @@ func (p *Postgres) discoverPrimaryKeys(ctx context.Context, tableMap map[string]
 }
 
 // discoverForeignKeys fetches foreign key relationships including composite keys.
-func (p *Postgres) discoverForeignKeys(ctx context.Context, tableMap map[string]*schema.Table) error {
+func (p *Postgres) discoverForeignKeys(ctx context.Context, tableMap map[string]*schema.Table, names []string) error {
 	query := `
 		SELECT
 			tc.table_name,
@@ -264,7 +408,6 @@ func (p *Postgres) discoverForeignKeys(ctx context.Context, tableMap map[string]
 		  AND tc.table_name = ANY($2)
 		ORDER BY tc.table_name, tc.constraint_name, kcu.ordinal_position`
 
-	names := tableNames(tableMap)
 	rows, err := p.pool.Query(ctx, query, p.schema, names)
 	if err != nil {
 		return err
@@ -282,6 +425,7 @@ func (p *Postgres) discoverForeignKeys(ctx context.Context, tableMap map[string]
 		if err := rows.Scan(&r.tableName, &r.constraintName, &r.column, &r.refTable, &r.refColumn); err != nil {
 			return err
 		}
+		p.progress.table(r.tableName)
 		fkRows = append(fkRows, r)
 	}
 	if err := rows.Err(); err != nil {
@@ -318,26 +462,30 @@ func (p *Postgres) discoverForeignKeys(ctx context.Context, tableMap map[string]
 }
 
 // discoverIndexes fetches all indexes (excluding primary key indexes which are handled separately).
-func (p *Postgres) discoverIndexes(ctx context.Context, tableMap map[string]*schema.Table) error {
+// Expression keys of function-based indexes and the predicates of partial
+// indexes are kept so they can be mapped to MongoDB equivalents.
+func (p *Postgres) discoverIndexes(ctx context.Context, tableMap map[string]*schema.Table, names []string) error {
 	query := `
 		SELECT
 			t.relname AS table_name,
 			i.relname AS index_name,
 			ix.indisunique AS is_unique,
 			am.amname AS index_type,
-			a.attname AS column_name
+			pg_get_indexdef(ix.indexrelid, k.n::int, true) AS key_def,
+			k.attnum = 0 AS is_expression,
+			COALESCE(pg_get_expr(ix.indpred, ix.indrelid, true), '') AS predicate
 		FROM pg_index ix
 		JOIN pg_class t ON t.oid = ix.indrelid
 		JOIN pg_class i ON i.oid = ix.indexrelid
 		JOIN pg_namespace n ON n.oid = t.relnamespace
 		JOIN pg_am am ON am.oid = i.relam
-		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
+		CROSS JOIN LATERAL unnest(ix.indkey::int2[]) WITH ORDINALITY AS k(attnum, n)
 		WHERE n.nspname = $1
 		  AND t.relname = ANY($2)
 		  AND NOT ix.indisprimary
-		ORDER BY t.relname, i.relname, array_position(ix.indkey, a.attnum)`
+		  AND k.n <= ix.indnkeyatts
+		ORDER BY t.relname, i.relname, k.n`
 
-	names := tableNames(tableMap)
 	rows, err := p.pool.Query(ctx, query, p.schema, names)
 	if err != nil {
 		return err
@@ -349,24 +497,30 @@ func (p *Postgres) discoverIndexes(ctx context.Context, tableMap map[string]*sch
 	var order []idxKey
 
 	for rows.Next() {
-		var tableName, indexName, indexType, colName string
-		var isUnique bool
-		if err := rows.Scan(&tableName, &indexName, &isUnique, &indexType, &colName); err != nil {
+		var tableName, indexName, indexType, keyDef, predicate string
+		var isUnique, isExpression bool
+		if err := rows.Scan(&tableName, &indexName, &isUnique, &indexType, &keyDef, &isExpression, &predicate); err != nil {
 			return err
 		}
+		p.progress.table(tableName)
 
 		k := idxKey{tableName, indexName}
 		idx, exists := grouped[k]
 		if !exists {
 			idx = &schema.Index{
-				Name:   indexName,
-				Unique: isUnique,
-				Type:   indexType,
+				Name:      indexName,
+				Unique:    isUnique,
+				Type:      indexType,
+				Predicate: predicate,
 			}
 			grouped[k] = idx
 			order = append(order, k)
 		}
-		idx.Columns = append(idx.Columns, colName)
+		if isExpression {
+			idx.Expressions = append(idx.Expressions, keyDef)
+		} else {
+			idx.Columns = append(idx.Columns, strings.Trim(keyDef, `"`))
+		}
 	}
 	if err := rows.Err(); err != nil {
 		return err
@@ -382,7 +536,7 @@ func (p *Postgres) discoverIndexes(ctx context.Context, tableMap map[string]*sch
 }
 
 // discoverCheckConstraints fetches CHECK constraints (excluding NOT NULL which is on the column).
-func (p *Postgres) discoverCheckConstraints(ctx context.Context, tableMap map[string]*schema.Table) error {
+func (p *Postgres) discoverCheckConstraints(ctx context.Context, tableMap map[string]*schema.Table, names []string) error {
 	query := `
 		SELECT
 			tc.table_name,
@@ -398,7 +552,6 @@ func (p *Postgres) discoverCheckConstraints(ctx context.Context, tableMap map[st
 		  AND tc.constraint_name NOT LIKE '%_not_null'
 		ORDER BY tc.table_name, tc.constraint_name`
 
-	names := tableNames(tableMap)
 	rows, err := p.pool.Query(ctx, query, p.schema, names)
 	if err != nil {
 		return err
@@ -410,6 +563,7 @@ func (p *Postgres) discoverCheckConstraints(ctx context.Context, tableMap map[st
 		if err := rows.Scan(&tableName, &constraintName, &checkClause); err != nil {
 			return err
 		}
+		p.progress.table(tableName)
 
 		t, ok := tableMap[tableName]
 		if !ok {
@@ -426,7 +580,7 @@ func (p *Postgres) discoverCheckConstraints(ctx context.Context, tableMap map[st
 }
 
 // detectSequences marks columns that use sequences (serial/bigserial/identity).
-func (p *Postgres) detectSequences(ctx context.Context, tableMap map[string]*schema.Table) error {
+func (p *Postgres) detectSequences(ctx context.Context, tableMap map[string]*schema.Table, names []string) error {
 	query := `
 		SELECT
 			table_name,
@@ -436,11 +590,10 @@ func (p *Postgres) detectSequences(ctx context.Context, tableMap map[string]*sch
 		  AND table_name = ANY($2)
 		  AND (column_default LIKE 'nextval(%' OR is_identity = 'YES')`
 
-	names := tableNames(tableMap)
 	rows, err := p.pool.Query(ctx, query, p.schema, names)
 	if err != nil {
 		// is_identity may not exist on older PG versions; if so, fall back
-		return p.detectSequencesFallback(ctx, tableMap)
+		return p.detectSequencesFallback(ctx, tableMap, names)
 	}
 	defer rows.Close()
 
@@ -464,7 +617,7 @@ func (p *Postgres) detectSequences(ctx context.Context, tableMap map[string]*sch
 	return rows.Err()
 }
 
-func (p *Postgres) detectSequencesFallback(ctx context.Context, tableMap map[string]*schema.Table) error {
+func (p *Postgres) detectSequencesFallback(ctx context.Context, tableMap map[string]*schema.Table, names []string) error {
 	query := `
 		SELECT
 			table_name,
@@ -474,7 +627,6 @@ func (p *Postgres) detectSequencesFallback(ctx context.Context, tableMap map[str
 		  AND table_name = ANY($2)
 		  AND column_default LIKE 'nextval(%'`
 
-	names := tableNames(tableMap)
 	rows, err := p.pool.Query(ctx, query, p.schema, names)
 	if err != nil {
 		return err
@@ -501,6 +653,24 @@ func (p *Postgres) detectSequencesFallback(ctx context.Context, tableMap map[str
 	return rows.Err()
 }
 
+// inBatches runs a catalog query over the given tables: in one query, or in
+// parallel mode as concurrent queries over batches of tables. Batches cover
+// disjoint tables, so their results never touch the same schema.Table.
+func (p *Postgres) inBatches(ctx context.Context, tableMap map[string]*schema.Table, names []string,
+	fn func(context.Context, map[string]*schema.Table, []string) error) error {
+	if p.parallelism <= 1 || len(names) == 0 {
+		return fn(ctx, tableMap, names)
+	}
+	size := min(discoveryBatchSize, (len(names)+p.parallelism-1)/p.parallelism)
+	return runBatches(ctx, tableBatches(names, size), p.parallelism, func(ctx context.Context, batch []string) error {
+		if err := fn(ctx, tableMap, batch); err != nil {
+			return err
+		}
+		p.progress.batch(batch)
+		return nil
+	})
+}
+
 // ConnString returns a DSN for testing or diagnostics.
 func (p *Postgres) ConnString() string {
 	ssl := "disable"
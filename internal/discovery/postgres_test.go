@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/reloquent/reloquent/internal/config"
 	"github.com/reloquent/reloquent/internal/discovery"
+	"github.com/reloquent/reloquent/internal/schema"
 )
 
 // pgTestConfig returns a SourceConfig from environment variables.
@@ -98,6 +101,8 @@ func setupTestSchema(t *testing.T, cfg *config.SourceConfig) func() {
 		)`,
 		`CREATE INDEX idx_orders_customer_id ON orders(customer_id)`,
 		`CREATE INDEX idx_orders_date_status ON orders(order_date, status)`,
+		`CREATE INDEX idx_orders_pending ON orders(order_date) WHERE status = 'pending'`,
+		`CREATE INDEX idx_orders_lower_status ON orders(lower(status))`,
 		`CREATE TABLE order_items (
 			id BIGSERIAL PRIMARY KEY,
 			order_id BIGINT NOT NULL REFERENCES orders(id),
@@ -166,7 +171,7 @@ func TestPostgresDiscoverIntegration(t *testing.T) {
 		t.Fatalf("Connect: %v", err)
 	}
 
-	s, err := d.Discover(ctx)
+	s, err := d.Discover(ctx, nil)
 	if err != nil {
 		t.Fatalf("Discover: %v", err)
 	}
@@ -336,6 +341,19 @@ func TestPostgresDiscoverIntegration(t *testing.T) {
 			t.Error("expected idx_orders_date_status composite index")
 		}
 
+		for _, idx := range tbl.Indexes {
+			switch idx.Name {
+			case "idx_orders_pending":
+				if !strings.Contains(idx.Predicate, "'pending'") {
+					t.Errorf("partial index predicate = %q", idx.Predicate)
+				}
+			case "idx_orders_lower_status":
+				if len(idx.Columns) != 0 || len(idx.Expressions) != 1 || !strings.Contains(idx.Expressions[0], "lower(") {
+					t.Errorf("functional index = %+v", idx)
+				}
+			}
+		}
+
 		// Row count
 		if tbl.RowCount != 3 {
 			t.Errorf("expected row count 3, got %d", tbl.RowCount)
@@ -376,6 +394,38 @@ func TestPostgresDiscoverIntegration(t *testing.T) {
 	})
 }
 
+func TestPostgresParallelDiscoverIntegration(t *testing.T) {
+	cfg := pgTestConfig()
+	skipIfNoPostgres(t, cfg)
+
+	cleanup := setupTestSchema(t, cfg)
+	defer cleanup()
+
+	discover := func(parallelism int) *schema.Schema {
+		t.Helper()
+		c := *cfg
+		c.DiscoveryParallelism = parallelism
+		d, err := discovery.NewPostgres(&c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer d.Close()
+		if err := d.Connect(context.Background()); err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		s, err := d.Discover(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("Discover with parallelism %d: %v", parallelism, err)
+		}
+		return s
+	}
+
+	sequential, parallel := discover(1), discover(4)
+	if !reflect.DeepEqual(sequential.Tables, parallel.Tables) {
+		t.Errorf("parallel discovery differs from sequential:\nsequential: %+v\nparallel:   %+v", sequential.Tables, parallel.Tables)
+	}
+}
+
 func TestNewPostgresDefaultsToPublicSchema(t *testing.T) {
 	cfg := &config.SourceConfig{Type: "postgresql", Schema: ""}
 	d, err := discovery.NewPostgres(cfg)
@@ -393,7 +443,7 @@ func TestDiscoverWithoutConnectFails(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err = d.Discover(context.Background())
+	_, err = d.Discover(context.Background(), nil)
 	if err == nil {
 		t.Error("expected error when discovering without connecting")
 	}
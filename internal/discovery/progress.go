@@ -0,0 +1,152 @@
+package discovery
+
+import (
+	"slices"
+	"sync"
+)
+
+// Discovery phases, in the order they run.
+const (
+	PhaseTables           = "tables"
+	PhaseColumns          = "columns"
+	PhasePrimaryKeys      = "primary_keys"
+	PhaseForeignKeys      = "foreign_keys"
+	PhaseIndexes          = "indexes"
+	PhaseCheckConstraints = "check_constraints"
+	PhaseSequences        = "sequences"
+)
+
+// Phases lists the discovery phases in order.
+var Phases = []string{
+	PhaseTables,
+	PhaseColumns,
+	PhasePrimaryKeys,
+	PhaseForeignKeys,
+	PhaseIndexes,
+	PhaseCheckConstraints,
+	PhaseSequences,
+}
+
+// Progress reports how far a discovery has got. Each phase after the first
+// reads one catalog query for every table, so TablesDone counts the tables
+// whose rows have been read in the current phase.
+type Progress struct {
+	Phase       string  `json:"phase"`
+	PhaseIndex  int     `json:"phase_index"` // 1-based position in Phases
+	PhaseCount  int     `json:"phase_count"`
+	Table       string  `json:"table,omitempty"` // last table read
+	TablesDone  int     `json:"tables_done"`
+	TablesTotal int     `json:"tables_total"` // 0 until the tables are enumerated
+	Percent     float64 `json:"percent"`      // overall, each phase weighted equally
+}
+
+// ProgressFunc receives discovery progress. It is called on the discovering
+// goroutine, so it should return quickly.
+type ProgressFunc func(Progress)
+
+// maxReportsPerPhase bounds how many per-table reports a phase sends, so a
+// schema of thousands of tables does not flood the callback.
+const maxReportsPerPhase = 100
+
+// progressReporter turns the rows of each catalog query into Progress
+// reports. A nil reporter reports nothing. When queries run in parallel
+// batches, rows of different tables interleave, so the reporter counts
+// whole batches instead of rows.
+type progressReporter struct {
+	mu      sync.Mutex
+	fn      ProgressFunc
+	cur     Progress
+	step    int
+	count   int
+	batched bool
+}
+
+func newProgressReporter(fn ProgressFunc) *progressReporter {
+	if fn == nil {
+		return nil
+	}
+	return &progressReporter{fn: fn, cur: Progress{PhaseCount: len(Phases)}, step: 1}
+}
+
+// start begins a phase.
+func (r *progressReporter) start(phase string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cur.Phase = phase
+	r.cur.PhaseIndex = slices.Index(Phases, phase) + 1
+	r.cur.Table = ""
+	r.cur.TablesDone = 0
+	r.count = 0
+	r.send()
+}
+
+// tables records how many tables the schema has.
+func (r *progressReporter) tables(n int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cur.TablesTotal = n
+	r.step = max(1, n/maxReportsPerPhase)
+}
+
+// table notes a row read for table. Rows arrive grouped by table, so a new
+// name means another table is done.
+func (r *progressReporter) table(name string) {
+	if r == nil || r.batched {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if name == r.cur.Table {
+		return
+	}
+	r.cur.Table = name
+	r.count++
+	if r.count%r.step == 0 {
+		r.cur.TablesDone = min(r.count, r.cur.TablesTotal)
+		r.send()
+	}
+}
+
+// batch notes that the rows of a batch of tables have all been read.
+func (r *progressReporter) batch(names []string) {
+	if r == nil || len(names) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.count += len(names)
+	r.cur.Table = names[len(names)-1]
+	r.cur.TablesDone = min(r.count, r.cur.TablesTotal)
+	r.send()
+}
+
+// finish ends the current phase.
+func (r *progressReporter) finish() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cur.Table = ""
+	r.cur.TablesDone = r.cur.TablesTotal
+	r.send()
+}
+
+func (r *progressReporter) send() {
+	r.cur.Percent = r.percent()
+	r.fn(r.cur)
+}
+
+func (r *progressReporter) percent() float64 {
+	done := float64(r.cur.PhaseIndex - 1)
+	if r.cur.TablesTotal > 0 {
+		done += float64(r.cur.TablesDone) / float64(r.cur.TablesTotal)
+	}
+	return done / float64(r.cur.PhaseCount) * 100
+}
@@ -0,0 +1,67 @@
+package discovery
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestProgressReporter(t *testing.T) {
+	var got []Progress
+	rep := newProgressReporter(func(p Progress) { got = append(got, p) })
+
+	rep.start(PhaseTables)
+	rep.tables(3)
+	rep.finish()
+	rep.start(PhaseColumns)
+	for _, table := range []string{"a", "a", "b", "c", "c"} {
+		rep.table(table)
+	}
+	rep.finish()
+
+	want := []struct {
+		phase string
+		table string
+		done  int
+	}{
+		{PhaseTables, "", 0},
+		{PhaseTables, "", 3},
+		{PhaseColumns, "", 0},
+		{PhaseColumns, "a", 1},
+		{PhaseColumns, "b", 2},
+		{PhaseColumns, "c", 3},
+		{PhaseColumns, "", 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d reports, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Phase != w.phase || g.Table != w.table || g.TablesDone != w.done {
+			t.Errorf("report %d = %+v, want %+v", i, g, w)
+		}
+	}
+	if p := got[len(got)-1].Percent; fmt.Sprintf("%.1f", p) != "28.6" {
+		t.Errorf("percent after two of seven phases = %.1f", p)
+	}
+}
+
+func TestProgressReporter_Throttled(t *testing.T) {
+	reports := 0
+	rep := newProgressReporter(func(Progress) { reports++ })
+	rep.tables(4000)
+	rep.start(PhaseIndexes)
+	for i := range 4000 {
+		rep.table(fmt.Sprintf("t%04d", i))
+	}
+	if reports > maxReportsPerPhase+1 {
+		t.Errorf("%d reports for one phase, want at most %d", reports, maxReportsPerPhase+1)
+	}
+}
+
+func TestProgressReporter_Nil(t *testing.T) {
+	rep := newProgressReporter(nil)
+	rep.start(PhaseTables)
+	rep.tables(1)
+	rep.table("a")
+	rep.finish()
+}
@@ -60,12 +60,20 @@ ORDER BY c.relname;
 SELECT '  columns:' FROM (SELECT 1) x WHERE EXISTS (
   SELECT 1 FROM information_schema.columns WHERE table_schema = '%s' LIMIT 1
 );
-SELECT '  - name: ' || column_name ||
-       E'\n    data_type: ' || data_type ||
-       E'\n    nullable: ' || CASE WHEN is_nullable = 'YES' THEN 'true' ELSE 'false' END
-FROM information_schema.columns
-WHERE table_schema = '%s'
-ORDER BY table_name, ordinal_position;
+SELECT '  - name: ' || c.column_name ||
+       E'\n    data_type: ' || CASE WHEN c.data_type = 'USER-DEFINED' AND c.udt_name IN ('geometry', 'geography') THEN c.udt_name::text
+                                WHEN c.data_type = 'USER-DEFINED' AND t.typtype = 'e' THEN 'enum'
+                                ELSE c.data_type::text END ||
+       E'\n    nullable: ' || CASE WHEN c.is_nullable = 'YES' THEN 'true' ELSE 'false' END ||
+       COALESCE(E'\n    type_name: ' || COALESCE(c.domain_name::text, CASE WHEN t.typtype = 'e' THEN c.udt_name::text END), '') ||
+       CASE WHEN t.typtype = 'e' THEN E'\n    enum_values: ' ||
+         array_to_json(ARRAY(SELECT e.enumlabel::text FROM pg_enum e WHERE e.enumtypid = t.oid ORDER BY e.enumsortorder))::text
+       ELSE '' END
+FROM information_schema.columns c
+LEFT JOIN pg_namespace tn ON tn.nspname = c.udt_schema
+LEFT JOIN pg_type t ON t.typnamespace = tn.oid AND t.typname = c.udt_name
+WHERE c.table_schema = '%s'
+ORDER BY c.table_name, c.ordinal_position;
 
 -- Primary keys
 SELECT '  primary_key:';
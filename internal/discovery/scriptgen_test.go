@@ -16,6 +16,7 @@ func TestPostgresScript_QueriesExpectedCatalogs(t *testing.T) {
 		"information_schema.table_constraints",
 		"information_schema.key_column_usage",
 		"information_schema.constraint_column_usage",
+		"pg_enum",
 	}
 	for _, c := range catalogs {
 		if !strings.Contains(script, c) {
@@ -0,0 +1,21 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// existingParent returns dir, or its closest ancestor that exists, for
+// directories the migration creates when it first writes to them.
+func existingParent(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package doctor
+
+import "fmt"
+
+func freeBytes(dir string) (int64, error) {
+	return 0, fmt.Errorf("free space of %s cannot be read on this platform", dir)
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package doctor
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// freeBytes returns the space available to this user on dir's file system.
+func freeBytes(dir string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, fmt.Errorf("reading free space of %s: %w", dir, err)
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
@@ -0,0 +1,308 @@
+// Package doctor checks, before migration day, that the environment can run
+// the migration: the source can be read and, for change data capture,
+// replicated from; the target accepts the writes; the AWS caller holds the
+// IAM actions the Spark platform needs; the Spark subnets reach both
+// databases; and there is room for local artifacts.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/aws"
+	"github.com/reloquent/reloquent/internal/aws/spark"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// Check outcomes.
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// Check categories.
+const (
+	CategorySource  = "source"
+	CategoryTarget  = "target"
+	CategoryAWS     = "aws"
+	CategoryNetwork = "network"
+	CategoryDisk    = "disk"
+)
+
+// DefaultMinFreeBytes is the free space each artifact directory needs.
+const DefaultMinFreeBytes int64 = 1 << 30
+
+// Check is the outcome of one environment check, with the fix for a
+// failure or warning.
+type Check struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	Fix      string `json:"fix,omitempty"`
+}
+
+// Result is the outcome of a doctor run.
+type Result struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Check   `json:"checks"`
+}
+
+// Failed returns the checks that failed.
+func (r *Result) Failed() []Check {
+	var failed []Check
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// Passed reports whether no check failed. Warnings and skipped checks do
+// not fail the run.
+func (r *Result) Passed() bool {
+	return len(r.Failed()) == 0
+}
+
+// Count returns how many checks ended with the status.
+func (r *Result) Count(status string) int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteJSON saves the result to path.
+func (r *Result) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling doctor report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("saving doctor report: %w", err)
+	}
+	return nil
+}
+
+// ReadJSON loads a result saved with WriteJSON.
+func ReadJSON(path string) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading doctor report: %w", err)
+	}
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing doctor report: %w", err)
+	}
+	return &r, nil
+}
+
+// NetworkProber opens TCP connections to endpoints from where the Spark job
+// runs.
+type NetworkProber interface {
+	ProbeNetwork(ctx context.Context, endpoints []string) ([]spark.Reachability, error)
+}
+
+// Checker runs the checks. Each part is skipped when what it needs is not
+// set: the source without OpenSource, the target without OpenTarget, AWS
+// without AWS, the network without Prober and disk space without Dirs.
+type Checker struct {
+	OpenSource func(ctx context.Context) (source.Reader, error)
+	SourceType string   // postgresql or oracle
+	Tables     []string // tables the migration reads
+	CDC        bool     // check the replication grants change data capture needs
+	Slot       string   // PostgreSQL replication slot CDC uses
+
+	OpenTarget func(ctx context.Context) (target.Operator, error)
+
+	AWS      aws.Client
+	Platform string // emr, emr-serverless, glue or native
+	Bucket   string
+
+	Prober    NetworkProber
+	Endpoints []string // host:port of the source and target
+
+	Dirs         []string // local artifact directories
+	MinFreeBytes int64    // DefaultMinFreeBytes when zero
+}
+
+// Run runs every check and returns the results. A check that cannot run is
+// reported as failed, not returned as an error.
+func (c *Checker) Run(ctx context.Context) *Result {
+	r := &Result{CheckedAt: time.Now().UTC()}
+	r.Checks = append(r.Checks, c.sourceChecks(ctx)...)
+	r.Checks = append(r.Checks, c.targetChecks(ctx)...)
+	r.Checks = append(r.Checks, c.awsChecks(ctx)...)
+	r.Checks = append(r.Checks, c.networkChecks(ctx)...)
+	r.Checks = append(r.Checks, c.diskChecks()...)
+	return r
+}
+
+func (c *Checker) targetChecks(ctx context.Context) []Check {
+	if c.OpenTarget == nil {
+		return []Check{{Category: CategoryTarget, Name: "Target connection", Status: StatusSkip,
+			Message: "No target configured", Fix: "Set target.connection_string in the config"}}
+	}
+	op, err := c.OpenTarget(ctx)
+	if err != nil {
+		return []Check{{Category: CategoryTarget, Name: "Target connection", Status: StatusFail,
+			Message: err.Error(), Fix: "Check the connection string and that this host can reach the target"}}
+	}
+	defer op.Close(context.Background())
+
+	conn := Check{Category: CategoryTarget, Name: "Target connection", Status: StatusPass, Message: "Connected"}
+	if topo, err := op.DetectTopology(ctx); err != nil {
+		conn.Status, conn.Message = StatusWarn, "Connected, but the topology could not be read: "+err.Error()
+	} else if topo != nil {
+		conn.Message = fmt.Sprintf("Connected to %s %s", topo.Type, topo.ServerVersion)
+	}
+
+	perms := Check{Category: CategoryTarget, Name: "Target permissions"}
+	checker, ok := op.(target.PrivilegeChecker)
+	if !ok {
+		perms.Status, perms.Message = StatusSkip, "The target cannot report the user's privileges"
+		return []Check{conn, perms}
+	}
+	missing, err := checker.MissingActions(ctx, target.MigrationActions)
+	switch {
+	case err != nil:
+		perms.Status, perms.Message = StatusWarn, err.Error()
+	case len(missing) > 0:
+		perms.Status = StatusFail
+		perms.Message = "The user may not " + strings.Join(missing, ", ") + " in the target database"
+		perms.Fix = "Grant the readWrite and dbAdmin roles on the target database"
+	default:
+		perms.Status, perms.Message = StatusPass, "The user holds every action the migration needs"
+	}
+	return []Check{conn, perms}
+}
+
+func (c *Checker) awsChecks(ctx context.Context) []Check {
+	if c.Platform == "" || c.Platform == "native" {
+		return []Check{{Category: CategoryAWS, Name: "AWS credentials", Status: StatusSkip,
+			Message: "Native migrations run without AWS"}}
+	}
+	if c.AWS == nil {
+		return []Check{{Category: CategoryAWS, Name: "AWS credentials", Status: StatusFail,
+			Message: "No AWS client", Fix: "Configure aws.region and aws.profile"}}
+	}
+	identity, err := c.AWS.VerifyCredentials(ctx)
+	if err != nil {
+		return []Check{{Category: CategoryAWS, Name: "AWS credentials", Status: StatusFail,
+			Message: err.Error(), Fix: "Log in with the AWS CLI or set aws.profile to a profile with credentials"}}
+	}
+	checks := []Check{{Category: CategoryAWS, Name: "AWS credentials", Status: StatusPass,
+		Message: "Authenticated as " + identity.ARN}}
+
+	iam := Check{Category: CategoryAWS, Name: "IAM permissions"}
+	if c.Bucket == "" {
+		checks = append(checks, Check{Category: CategoryAWS, Name: "S3 bucket", Status: StatusFail,
+			Message: "No bucket for the migration artifacts", Fix: "Set aws.s3_bucket in the config"})
+	}
+	perms := aws.RequiredPermissions(c.Platform, c.Bucket)
+	denied, err := c.AWS.DeniedPermissions(ctx, perms)
+	switch {
+	case err != nil:
+		iam.Status = StatusWarn
+		iam.Message = "Could not simulate the IAM policies: " + err.Error()
+		iam.Fix = "Allow iam:SimulatePrincipalPolicy to check permissions, or review them by hand"
+	case len(denied) > 0:
+		names := make([]string, len(denied))
+		for i, p := range denied {
+			names[i] = p.String()
+		}
+		iam.Status = StatusFail
+		iam.Message = "Not allowed: " + strings.Join(names, "; ")
+		iam.Fix = "Add the actions to a policy attached to " + identity.ARN
+	default:
+		iam.Status = StatusPass
+		iam.Message = fmt.Sprintf("All %d actions for %s are allowed", len(perms), c.Platform)
+	}
+	return append(checks, iam)
+}
+
+func (c *Checker) networkChecks(ctx context.Context) []Check {
+	name := "Reachability from " + strings.ToUpper(c.Platform)
+	switch {
+	case c.Platform == "" || c.Platform == "native":
+		return nil
+	case len(c.Endpoints) == 0:
+		return []Check{{Category: CategoryNetwork, Name: name, Status: StatusSkip,
+			Message: "No database endpoints to probe"}}
+	case c.Prober == nil:
+		fix := "Run the doctor with --probe-emr to test from the EMR subnets"
+		switch c.Platform {
+		case "glue":
+			fix = "Attach a Glue connection in a subnet that routes to " + strings.Join(c.Endpoints, " and ")
+		case "emr-serverless":
+			fix = "Give the EMR Serverless application subnets that route to " + strings.Join(c.Endpoints, " and ")
+		}
+		return []Check{{Category: CategoryNetwork, Name: name, Status: StatusSkip,
+			Message: "Not probed: " + strings.Join(c.Endpoints, ", "), Fix: fix}}
+	}
+
+	results, err := c.Prober.ProbeNetwork(ctx, c.Endpoints)
+	if err != nil {
+		return []Check{{Category: CategoryNetwork, Name: name, Status: StatusFail,
+			Message: "Probe failed: " + err.Error()}}
+	}
+	var checks []Check
+	for _, r := range results {
+		subnet := r.Subnet
+		if subnet == "" {
+			subnet = "default subnet"
+		}
+		ch := Check{Category: CategoryNetwork, Name: fmt.Sprintf("%s from %s", r.Endpoint, subnet)}
+		if r.Reachable {
+			ch.Status, ch.Message = StatusPass, "Reachable"
+		} else {
+			ch.Status, ch.Message = StatusFail, r.Message
+			ch.Fix = "Allow " + r.Endpoint + " in the security groups and route tables of " + subnet
+		}
+		checks = append(checks, ch)
+	}
+	return checks
+}
+
+func (c *Checker) diskChecks() []Check {
+	need := c.MinFreeBytes
+	if need == 0 {
+		need = DefaultMinFreeBytes
+	}
+	var checks []Check
+	for _, dir := range c.Dirs {
+		ch := Check{Category: CategoryDisk, Name: "Free space in " + dir}
+		free, err := freeBytes(existingParent(dir))
+		switch {
+		case err != nil:
+			ch.Status, ch.Message = StatusWarn, err.Error()
+		case free < need:
+			ch.Status = StatusFail
+			ch.Message = fmt.Sprintf("%s free, %s needed", formatBytes(free), formatBytes(need))
+			ch.Fix = "Free up space or move the project to a larger volume"
+		default:
+			ch.Status, ch.Message = StatusPass, formatBytes(free)+" free"
+		}
+		checks = append(checks, ch)
+	}
+	return checks
+}
+
+func formatBytes(n int64) string {
+	const gib = 1 << 30
+	if n >= gib {
+		return fmt.Sprintf("%.1f GiB", float64(n)/gib)
+	}
+	return fmt.Sprintf("%d MiB", n>>20)
+}
@@ -0,0 +1,217 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/aws"
+	"github.com/reloquent/reloquent/internal/aws/spark"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+type mockProber struct {
+	results []spark.Reachability
+	err     error
+	probed  []string
+}
+
+func (p *mockProber) ProbeNetwork(_ context.Context, endpoints []string) ([]spark.Reachability, error) {
+	p.probed = endpoints
+	return p.results, p.err
+}
+
+func openSource(r *source.MockReader) func(context.Context) (source.Reader, error) {
+	return func(context.Context) (source.Reader, error) { return r, nil }
+}
+
+func openTarget(op *target.MockOperator) func(context.Context) (target.Operator, error) {
+	return func(context.Context) (target.Operator, error) { return op, nil }
+}
+
+func find(t *testing.T, r *Result, name string) Check {
+	t.Helper()
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no check %q in %+v", name, r.Checks)
+	return Check{}
+}
+
+func TestRun_AllPass(t *testing.T) {
+	prober := &mockProber{results: []spark.Reachability{
+		{Subnet: "subnet-1", Endpoint: "db:5432", Reachable: true},
+		{Subnet: "subnet-1", Endpoint: "mongo:27017", Reachable: true},
+	}}
+	c := &Checker{
+		OpenSource: openSource(&source.MockReader{QueryResult: []map[string]interface{}{
+			{"wal_level": "logical", "can_replicate": true, "free_slots": int64(2), "slot_exists": false},
+		}}),
+		SourceType:   "postgresql",
+		Tables:       []string{"orders", "customers"},
+		CDC:          true,
+		OpenTarget:   openTarget(&target.MockOperator{TopologyResult: &target.TopologyInfo{Type: "replica_set", ServerVersion: "7.0.2"}}),
+		AWS:          aws.NewMockClient(),
+		Platform:     "emr",
+		Bucket:       "migrations",
+		Prober:       prober,
+		Endpoints:    []string{"db:5432", "mongo:27017"},
+		Dirs:         []string{t.TempDir()},
+		MinFreeBytes: 1,
+	}
+	r := c.Run(context.Background())
+	if !r.Passed() {
+		t.Fatalf("failed checks: %+v", r.Failed())
+	}
+	if got := r.Count(StatusPass); got != len(r.Checks) {
+		t.Errorf("%d of %d checks passed: %+v", got, len(r.Checks), r.Checks)
+	}
+	if len(prober.probed) != 2 {
+		t.Errorf("probed %v", prober.probed)
+	}
+	if c := find(t, r, "Target connection"); c.Message != "Connected to replica_set 7.0.2" {
+		t.Errorf("target = %+v", c)
+	}
+}
+
+func TestRun_Failures(t *testing.T) {
+	tests := []struct {
+		name    string
+		checker Checker
+		check   string
+		status  string
+		message string
+	}{
+		{
+			name:    "source unreachable",
+			checker: Checker{OpenSource: openSource(&source.MockReader{ConnectErr: errors.New("connection refused")})},
+			check:   "Source connection", status: StatusFail, message: "connection refused",
+		},
+		{
+			name: "unreadable table",
+			checker: Checker{
+				OpenSource: openSource(&source.MockReader{SampleErr: errors.New("permission denied")}),
+				Tables:     []string{"orders"},
+			},
+			check: "Read selected tables", status: StatusFail, message: "Cannot read 1 of 1 tables: orders",
+		},
+		{
+			name: "postgres without logical replication",
+			checker: Checker{
+				OpenSource: openSource(&source.MockReader{QueryResult: []map[string]interface{}{
+					{"wal_level": "replica", "can_replicate": false, "free_slots": int64(0), "slot_exists": false},
+				}}),
+				SourceType: "postgresql", CDC: true,
+			},
+			check: "Change data capture", status: StatusFail,
+			message: "wal_level is replica; the user lacks REPLICATION; no free replication slot for reloquent_cdc",
+		},
+		{
+			name: "postgres slot already created",
+			checker: Checker{
+				OpenSource: openSource(&source.MockReader{QueryResult: []map[string]interface{}{
+					{"wal_level": "logical", "can_replicate": "t", "free_slots": int64(0), "slot_exists": "t"},
+				}}),
+				SourceType: "postgresql", CDC: true,
+			},
+			check: "Change data capture", status: StatusPass,
+		},
+		{
+			name: "oracle without archive log",
+			checker: Checker{
+				OpenSource: openSource(&source.MockReader{QueryResult: []map[string]interface{}{
+					{"LOG_MODE": "NOARCHIVELOG", "SUPPLEMENTAL": "NO", "LOGMINING": "1"},
+				}}),
+				SourceType: "oracle", CDC: true,
+			},
+			check: "Change data capture", status: StatusFail,
+			message: "the database is in NOARCHIVELOG mode; supplemental logging is off",
+		},
+		{
+			name:    "target privileges",
+			checker: Checker{OpenTarget: openTarget(&target.MockOperator{DeniedActions: []string{"createIndex", "collMod"}})},
+			check:   "Target permissions", status: StatusFail, message: "createIndex, collMod",
+		},
+		{
+			name:    "aws credentials",
+			checker: Checker{AWS: &aws.MockClient{IdentityErr: errors.New("expired token")}, Platform: "glue"},
+			check:   "AWS credentials", status: StatusFail, message: "expired token",
+		},
+		{
+			name:    "iam denied",
+			checker: Checker{AWS: &aws.MockClient{Identity: &aws.CallerIdentity{ARN: "arn:aws:iam::1:user/a"}, Denied: []string{"iam:PassRole"}}, Platform: "emr", Bucket: "b"},
+			check:   "IAM permissions", status: StatusFail, message: "iam:PassRole on arn:aws:iam::*:role/EMR_DefaultRole",
+		},
+		{
+			name:    "iam simulation not allowed",
+			checker: Checker{AWS: &aws.MockClient{Identity: &aws.CallerIdentity{}, DeniedErr: errors.New("AccessDenied")}, Platform: "emr", Bucket: "b"},
+			check:   "IAM permissions", status: StatusWarn, message: "AccessDenied",
+		},
+		{
+			name:    "no bucket",
+			checker: Checker{AWS: aws.NewMockClient(), Platform: "emr"},
+			check:   "S3 bucket", status: StatusFail,
+		},
+		{
+			name:    "network not probed",
+			checker: Checker{Platform: "glue", Endpoints: []string{"db:5432"}},
+			check:   "Reachability from GLUE", status: StatusSkip, message: "db:5432",
+		},
+		{
+			name: "unreachable from subnet",
+			checker: Checker{Platform: "emr", Endpoints: []string{"db:5432"}, Prober: &mockProber{results: []spark.Reachability{
+				{Subnet: "subnet-1", Endpoint: "db:5432", Message: "no TCP connection within 10s"},
+			}}},
+			check: "db:5432 from subnet-1", status: StatusFail, message: "no TCP connection",
+		},
+		{
+			name:    "disk full",
+			checker: Checker{Dirs: []string{"/"}, MinFreeBytes: 1 << 62},
+			check:   "Free space in /", status: StatusFail, message: "needed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.checker.Run(context.Background())
+			c := find(t, r, tt.check)
+			if c.Status != tt.status || !strings.Contains(c.Message, tt.message) {
+				t.Errorf("check = %+v, want %s containing %q", c, tt.status, tt.message)
+			}
+			if c.Status == StatusFail && r.Passed() {
+				t.Error("result passed with a failed check")
+			}
+		})
+	}
+}
+
+func TestRun_NativeSkipsAWS(t *testing.T) {
+	r := (&Checker{Platform: "native", Endpoints: []string{"db:5432"}}).Run(context.Background())
+	if c := find(t, r, "AWS credentials"); c.Status != StatusSkip {
+		t.Errorf("aws = %+v", c)
+	}
+	for _, c := range r.Checks {
+		if c.Category == CategoryNetwork {
+			t.Errorf("native run probed the network: %+v", c)
+		}
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doctor-report.json")
+	r := &Result{Checks: []Check{{Category: CategoryDisk, Name: "Free space", Status: StatusFail, Fix: "free up space"}}}
+	if err := r.WriteJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Failed()) != 1 || loaded.Checks[0].Fix != "free up space" {
+		t.Errorf("loaded = %+v", loaded)
+	}
+}
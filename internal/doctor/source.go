@@ -0,0 +1,167 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/cdc"
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+// postgresCDCQuery reads the settings and grants logical replication needs.
+const postgresCDCQuery = `SELECT current_setting('wal_level') AS wal_level,
+  (SELECT rolreplication OR rolsuper FROM pg_roles WHERE rolname = current_user) AS can_replicate,
+  current_setting('max_replication_slots')::int - (SELECT count(*) FROM pg_replication_slots) AS free_slots,
+  EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = $1) AS slot_exists`
+
+// oracleCDCQuery reads the database settings and privilege LogMiner needs.
+const oracleCDCQuery = `SELECT log_mode, supplemental_log_data_min AS supplemental,
+  (SELECT COUNT(*) FROM session_privs WHERE privilege = 'LOGMINING') AS logmining
+FROM v$database`
+
+const roleScriptFix = "Grant the role from `reloquent source-role` to the migration user"
+
+func (c *Checker) sourceChecks(ctx context.Context) []Check {
+	if c.OpenSource == nil {
+		return []Check{{Category: CategorySource, Name: "Source connection", Status: StatusSkip,
+			Message: "No source configured", Fix: "Set the source connection in the config"}}
+	}
+	src, err := c.OpenSource(ctx)
+	if err == nil {
+		err = src.Connect(ctx)
+	}
+	if err != nil {
+		return []Check{{Category: CategorySource, Name: "Source connection", Status: StatusFail,
+			Message: err.Error(), Fix: "Check the source host, port and credentials"}}
+	}
+	defer src.Close()
+
+	checks := []Check{{Category: CategorySource, Name: "Source connection", Status: StatusPass, Message: "Connected"}}
+	checks = append(checks, c.tableCheck(ctx, src))
+	if c.CDC {
+		checks = append(checks, c.cdcCheck(ctx, src))
+	}
+	return checks
+}
+
+// tableCheck reads one row of each table, which needs the same privilege as
+// reading all of them.
+func (c *Checker) tableCheck(ctx context.Context, src source.Reader) Check {
+	ch := Check{Category: CategorySource, Name: "Read selected tables"}
+	if len(c.Tables) == 0 {
+		ch.Status, ch.Message = StatusSkip, "No tables selected"
+		return ch
+	}
+	var unreadable []string
+	for _, t := range c.Tables {
+		if _, err := src.SampleRows(ctx, t, nil, 1); err != nil {
+			unreadable = append(unreadable, t)
+		}
+	}
+	if len(unreadable) > 0 {
+		ch.Status = StatusFail
+		ch.Message = fmt.Sprintf("Cannot read %d of %d tables: %s", len(unreadable), len(c.Tables), strings.Join(unreadable, ", "))
+		ch.Fix = roleScriptFix
+		return ch
+	}
+	ch.Status, ch.Message = StatusPass, fmt.Sprintf("All %d tables readable", len(c.Tables))
+	return ch
+}
+
+func (c *Checker) cdcCheck(ctx context.Context, src source.Reader) Check {
+	ch := Check{Category: CategorySource, Name: "Change data capture"}
+	var problems, fixes []string
+	switch c.SourceType {
+	case "postgresql":
+		slot := c.Slot
+		if slot == "" {
+			slot = cdc.DefaultSlotName
+		}
+		row, err := queryRow(ctx, src, postgresCDCQuery, slot)
+		if err != nil {
+			ch.Status, ch.Message = StatusFail, "Reading replication settings: "+err.Error()
+			return ch
+		}
+		if wal := fmt.Sprint(field(row, "wal_level")); wal != "logical" {
+			problems = append(problems, "wal_level is "+wal)
+			fixes = append(fixes, "set wal_level = logical and restart PostgreSQL")
+		}
+		if !asBool(field(row, "can_replicate")) {
+			problems = append(problems, "the user lacks REPLICATION")
+			fixes = append(fixes, "ALTER ROLE <user> WITH REPLICATION")
+		}
+		if !asBool(field(row, "slot_exists")) && asInt(field(row, "free_slots")) < 1 {
+			problems = append(problems, "no free replication slot for "+slot)
+			fixes = append(fixes, "raise max_replication_slots or drop an unused slot")
+		}
+	case "oracle":
+		row, err := queryRow(ctx, src, oracleCDCQuery)
+		if err != nil {
+			ch.Status, ch.Message = StatusFail, "Reading LogMiner settings: "+err.Error()
+			ch.Fix = "GRANT SELECT ON V_$DATABASE TO <user>"
+			return ch
+		}
+		if mode := fmt.Sprint(field(row, "log_mode")); mode != "ARCHIVELOG" {
+			problems = append(problems, "the database is in "+mode+" mode")
+			fixes = append(fixes, "enable ARCHIVELOG mode")
+		}
+		if s := fmt.Sprint(field(row, "supplemental")); s != "YES" && s != "IMPLICIT" {
+			problems = append(problems, "supplemental logging is off")
+			fixes = append(fixes, "ALTER DATABASE ADD SUPPLEMENTAL LOG DATA")
+		}
+		if asInt(field(row, "logmining")) < 1 {
+			problems = append(problems, "the user lacks LOGMINING")
+			fixes = append(fixes, "GRANT LOGMINING TO <user>")
+		}
+	default:
+		ch.Status, ch.Message = StatusSkip, "Change data capture is not supported for "+c.SourceType
+		return ch
+	}
+	if len(problems) > 0 {
+		ch.Status = StatusFail
+		ch.Message = "Cannot capture changes: " + strings.Join(problems, "; ")
+		ch.Fix = strings.Join(fixes, "; ")
+		return ch
+	}
+	ch.Status, ch.Message = StatusPass, "The source is set up for change data capture"
+	return ch
+}
+
+func queryRow(ctx context.Context, src source.Reader, sql string, args ...interface{}) (map[string]interface{}, error) {
+	rows, err := src.QueryRows(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no rows returned")
+	}
+	return rows[0], nil
+}
+
+// field looks a column up by name in any case, as Oracle upper-cases them.
+func field(row map[string]interface{}, name string) interface{} {
+	for k, v := range row {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+func asBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case nil:
+		return false
+	}
+	ok, _ := strconv.ParseBool(fmt.Sprint(v))
+	return ok
+}
+
+func asInt(v interface{}) int64 {
+	f, _ := strconv.ParseFloat(fmt.Sprint(v), 64)
+	return int64(f)
+}
@@ -0,0 +1,86 @@
+package drivers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/config"
+)
+
+// MavenCentral is the repository jars are downloaded from.
+var MavenCentral = "https://repo1.maven.org/maven2"
+
+// FetchJar returns the path of the jar with the Maven coordinates
+// group:artifact:version in ~/.reloquent/drivers/, downloading it from Maven
+// Central and checking its SHA-1 checksum if it is not there yet.
+func FetchJar(ctx context.Context, coordinates string) (string, error) {
+	parts := strings.Split(coordinates, ":")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("invalid Maven coordinates %q", coordinates)
+	}
+	group, artifact, version := parts[0], parts[1], parts[2]
+	name := artifact + "-" + version + ".jar"
+
+	driversDir := config.ExpandHome("~/.reloquent/drivers/")
+	path := filepath.Join(driversDir, name)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	url := strings.Join([]string{MavenCentral, strings.ReplaceAll(group, ".", "/"), artifact, version, name}, "/")
+	sum, err := download(ctx, url+".sha1")
+	if err != nil {
+		return "", err
+	}
+	jar, err := download(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	// The checksum file may name the jar after the hash.
+	want := strings.Fields(string(sum))
+	got := sha1.Sum(jar)
+	if len(want) == 0 || !strings.EqualFold(want[0], hex.EncodeToString(got[:])) {
+		return "", fmt.Errorf("checksum mismatch for %s", url)
+	}
+
+	if err := os.MkdirAll(driversDir, 0o755); err != nil {
+		return "", fmt.Errorf("creating drivers directory: %w", err)
+	}
+	// Write through a temporary file so an interrupted download is never
+	// mistaken for a cached jar.
+	tmp := path + ".part"
+	if err := os.WriteFile(tmp, jar, 0o644); err != nil {
+		return "", fmt.Errorf("writing %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("writing %s: %w", name, err)
+	}
+	return path, nil
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	return data, nil
+}
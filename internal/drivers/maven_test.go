@@ -0,0 +1,61 @@
+package drivers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestFetchJar(t *testing.T) {
+	jar := []byte("fake jar")
+	sum := sha1.Sum(jar)
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/org/postgresql/postgresql/42.7.4/postgresql-42.7.4.jar":
+			w.Write(jar)
+		case "/org/postgresql/postgresql/42.7.4/postgresql-42.7.4.jar.sha1":
+			w.Write([]byte(hex.EncodeToString(sum[:])))
+		case "/org/example/bad/1.0/bad-1.0.jar":
+			w.Write(jar)
+		case "/org/example/bad/1.0/bad-1.0.jar.sha1":
+			w.Write([]byte("0000"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	prev := MavenCentral
+	MavenCentral = srv.URL
+	defer func() { MavenCentral = prev }()
+
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", t.TempDir())
+	defer os.Setenv("HOME", origHome)
+
+	path, err := FetchJar(context.Background(), "org.postgresql:postgresql:42.7.4")
+	if err != nil {
+		t.Fatalf("FetchJar: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != string(jar) {
+		t.Errorf("cached jar = %q, %v", data, err)
+	}
+	if _, err := FetchJar(context.Background(), "org.postgresql:postgresql:42.7.4"); err != nil || requests != 2 {
+		t.Errorf("second FetchJar made %d requests, %v; want the cached jar", requests, err)
+	}
+
+	if _, err := FetchJar(context.Background(), "org.example:bad:1.0"); err == nil {
+		t.Error("expected a checksum error")
+	}
+	if _, err := FetchJar(context.Background(), "org.example:missing:1.0"); err == nil {
+		t.Error("expected a download error")
+	}
+	if _, err := FetchJar(context.Background(), "postgresql"); err == nil {
+		t.Error("expected an error for invalid coordinates")
+	}
+}
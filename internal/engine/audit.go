@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/audit"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+// auditChange records an action that rewrote configuration artifacts,
+// snapshotted with audit.Snapshot before it ran; their contents after it
+// are captured here. Artifacts it left as they were are not recorded.
+func (e *Engine) auditChange(action, detail string, artifacts ...audit.Artifact) {
+	now := time.Now()
+	e.withStore("audit event", func(s state.Store) error {
+		return s.AddAudit(state.AuditEvent{Time: now, Action: action, Detail: detail})
+	})
+
+	entry := audit.Entry{Time: now, Actor: e.actor(), Action: action, Detail: detail}
+	for _, a := range artifacts {
+		a.Capture()
+		if a.Changed() {
+			entry.Artifacts = append(entry.Artifacts, a)
+		}
+	}
+	if err := audit.Open(e.projectDir()).Append(entry); err != nil {
+		e.Logger.Warn("recording audit trail entry failed", "error", err)
+	}
+}
+
+// actor names who the audit trail records as acting.
+func (e *Engine) actor() string {
+	if e.Actor != "" {
+		return e.Actor
+	}
+	return audit.CurrentActor()
+}
+
+// AuditTrail returns the entries of the project's audit trail the filter
+// selects, newest first.
+func (e *Engine) AuditTrail(f audit.Filter) ([]audit.Entry, error) {
+	return audit.Open(e.projectDir()).Entries(f)
+}
+
+// auditMigration records a migration starting and returns a function that
+// records how it ended.
+func (e *Engine) auditMigration(only []string, delta bool) func(*migration.Status, error) {
+	kind := "full"
+	if delta {
+		kind = "delta"
+	}
+	detail := kind
+	if len(only) > 0 {
+		detail += ": " + strings.Join(only, ", ")
+	}
+	e.audit("migration_started", detail)
+	return func(status *migration.Status, err error) {
+		switch {
+		case err != nil:
+			e.audit("migration_finished", fmt.Sprintf("%s: %v", kind, err))
+		case status != nil:
+			e.audit("migration_finished", fmt.Sprintf("%s: %s, %d documents written", kind, status.Phase, status.Overall.DocsWritten))
+		}
+	}
+}
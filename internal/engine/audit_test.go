@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/audit"
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func TestAuditTrail(t *testing.T) {
+	e := testEngine(t)
+	e.Actor = "ana@laptop"
+	e.Schema = testSchema()
+
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "orders", SourceTable: "orders"}}}
+	if err := e.SaveMapping(m); err != nil {
+		t.Fatal(err)
+	}
+	m.Collections[0].Name = "purchases"
+	if err := e.SaveMapping(m); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SaveMapping(m); err != nil { // unchanged
+		t.Fatal(err)
+	}
+	plan := &indexes.IndexPlan{Indexes: []target.CollectionIndex{
+		{Collection: "purchases", Index: target.IndexDefinition{Name: "idx_total", Keys: []target.IndexKey{{Field: "total", Order: 1}}}},
+	}}
+	if err := e.SaveIndexPlan(plan); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.ResetIndexPlan(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := e.AuditTrail(audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, en := range entries {
+		actions = append(actions, en.Action)
+		if en.Actor != "ana@laptop" {
+			t.Errorf("%s actor = %q", en.Action, en.Actor)
+		}
+	}
+	if got := strings.Join(actions, ","); got != "index_plan_reset,index_plan_saved,mapping_saved,mapping_saved,mapping_saved" {
+		t.Fatalf("actions = %s", got)
+	}
+
+	reset, saved, unchanged, renamed, created := entries[0], entries[1], entries[2], entries[3], entries[4]
+	if len(reset.Artifacts) != 1 || reset.Artifacts[0].After != "" || !strings.Contains(reset.Artifacts[0].Before, "idx_total") {
+		t.Errorf("reset artifacts = %+v, want the plan removed", reset.Artifacts)
+	}
+	if len(saved.Artifacts) != 1 || saved.Artifacts[0].Name != "index_plan" || saved.Artifacts[0].Before != "" {
+		t.Errorf("saved artifacts = %+v, want the plan created", saved.Artifacts)
+	}
+	if len(unchanged.Artifacts) != 0 {
+		t.Errorf("unchanged save recorded artifacts %+v", unchanged.Artifacts)
+	}
+	if a := renamed.Artifacts; len(a) != 1 || !strings.Contains(a[0].Before, "name: orders") || !strings.Contains(a[0].After, "name: purchases") {
+		t.Errorf("renamed artifacts = %+v, want before and after", a)
+	}
+	if a := created.Artifacts; len(a) != 1 || a[0].Before != "" || a[0].After == "" {
+		t.Errorf("created artifacts = %+v, want the mapping created", a)
+	}
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// BooleanColumns samples the source tables of the mapping for columns that
+// store flags as text or numbers, such as CHAR(1) Y/N, and returns them,
+// each accepted when the mapping already converts it to a boolean. A
+// sampleSize of zero uses typemap.DefaultBooleanSampleSize.
+func (e *Engine) BooleanColumns(ctx context.Context, sampleSize int) ([]typemap.BooleanColumn, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	src, release, err := e.sourceConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return e.booleanColumns(ctx, src, sampleSize)
+}
+
+func (e *Engine) booleanColumns(ctx context.Context, src source.Reader, sampleSize int) ([]typemap.BooleanColumn, error) {
+	cols, err := typemap.DetectBooleans(ctx, src, e.Schema, e.Mapping.SourceTables(), sampleSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cols {
+		cols[i].Accepted = e.Mapping.ConvertsBoolean(cols[i])
+	}
+	return cols, nil
+}
+
+// SaveBooleanConversions converts each accepted column to a boolean with a
+// compute transformation wherever its table is migrated, removes the
+// conversion of each rejected one, and saves the mapping. Columns the
+// mapping does not migrate, and values that cannot be converted, are
+// reported as ErrInvalidMapping and nothing is saved.
+func (e *Engine) SaveBooleanConversions(cols []typemap.BooleanColumn) error {
+	if e.Mapping == nil {
+		return fmt.Errorf("no mapping defined")
+	}
+	tables := make(map[string]bool)
+	for _, t := range e.Mapping.SourceTables() {
+		tables[t] = true
+	}
+	for _, b := range cols {
+		if !tables[b.Table] {
+			return fmt.Errorf("%w: table %s is not migrated", ErrInvalidMapping, b.Table)
+		}
+		if err := b.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+		}
+	}
+	for _, b := range cols {
+		e.Mapping.SetBooleanConversion(b, b.Accepted)
+	}
+	return e.saveMapping()
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/transform"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+func TestBooleanConversions(t *testing.T) {
+	one := 1
+	e := testEngine(t)
+	e.Schema = &schema.Schema{DatabaseType: "oracle", Tables: []schema.Table{{Name: "USERS", Columns: []schema.Column{
+		{Name: "ID", DataType: "NUMBER"},
+		{Name: "ACTIVE", DataType: "CHAR", MaxLength: &one},
+	}}}}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{Name: "users", SourceTable: "USERS"}}}
+	src := &source.MockReader{Samples: map[string][]map[string]interface{}{
+		"USERS": {{"ACTIVE": "Y"}, {"ACTIVE": "N"}},
+	}}
+
+	cols, err := e.booleanColumns(context.Background(), src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 1 || cols[0].Column != "ACTIVE" || cols[0].Accepted {
+		t.Fatalf("boolean columns = %+v, want ACTIVE not yet accepted", cols)
+	}
+
+	bad := cols[0]
+	bad.Table = "ORDERS"
+	if err := e.SaveBooleanConversions([]typemap.BooleanColumn{bad}); !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("unmigrated table: err = %v, want ErrInvalidMapping", err)
+	}
+
+	cols[0].Accepted = true
+	if err := e.SaveBooleanConversions(cols); err != nil {
+		t.Fatalf("SaveBooleanConversions: %v", err)
+	}
+	saved, err := mapping.LoadYAML(e.State.MappingPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.ConvertsBoolean(cols[0]) {
+		t.Fatalf("saved transformations = %+v, want ACTIVE converted", saved.Collections[0].Transformations)
+	}
+	if err := transform.ValidateMapping(saved, e.Schema); err != nil {
+		t.Errorf("saved mapping is invalid: %v", err)
+	}
+	if cols, _ = e.booleanColumns(context.Background(), src, 0); !cols[0].Accepted {
+		t.Error("ACTIVE not reported accepted")
+	}
+
+	// The native mover evaluates the conversion
+	computed, err := transform.ComputedFields(saved.Collections[0].Transformations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for value, want := range map[interface{}]interface{}{"Y": true, "n": false, "N ": false, "X": nil, nil: nil} {
+		row := map[string]interface{}{"ACTIVE": value}
+		if err := transform.ApplyComputed(row, computed); err != nil {
+			t.Fatal(err)
+		}
+		if row["ACTIVE"] != want {
+			t.Errorf("%q converted to %v, want %v", value, row["ACTIVE"], want)
+		}
+	}
+}
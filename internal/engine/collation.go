@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/collation"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// CollationAdvice compares the source collations of the mapped columns with
+// MongoDB's byte-by-byte comparison and recommends collations for the
+// collections and indexes that need one.
+func (e *Engine) CollationAdvice() (*collation.Report, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	return collation.Analyze(e.Schema, e.Mapping), nil
+}
+
+// collectionSpecs builds the creation specs for the mapped collections with
+// the recommended default collations.
+func (e *Engine) collectionSpecs() []target.CollectionSpec {
+	specs := target.CollectionSpecs(e.Mapping)
+	collation.Analyze(e.Schema, e.Mapping).ApplyToSpecs(specs)
+	return specs
+}
+
+// SaveJoinNormalizations lower-cases the text join keys on both sides of
+// each normalized join wherever their tables are migrated, stops doing so
+// for the others, and saves the mapping. Joins are named by collection and
+// field; one that is not joined ignoring case is reported as
+// ErrInvalidMapping and nothing is saved.
+func (e *Engine) SaveJoinNormalizations(joins []collation.JoinAdvice) error {
+	if e.Schema == nil || e.Mapping == nil {
+		return fmt.Errorf("schema and mapping required")
+	}
+	known := collation.Joins(e.Schema, e.Mapping)
+	var changes []collation.JoinAdvice
+	for _, j := range joins {
+		found := false
+		for _, k := range known {
+			if k.Collection == j.Collection && k.Field == j.Field {
+				k.Normalized = j.Normalized
+				changes = append(changes, k)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s.%s is not joined ignoring case", ErrInvalidMapping, j.Collection, j.Field)
+		}
+	}
+	for _, j := range changes {
+		collation.SetNormalized(e.Schema, e.Mapping, j, j.Normalized)
+	}
+	return e.saveMapping()
+}
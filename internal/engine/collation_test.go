@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/collation"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func TestCollationAdvice(t *testing.T) {
+	e := testEngine(t)
+	if _, err := e.CollationAdvice(); err == nil {
+		t.Error("expected an error without a schema and mapping")
+	}
+
+	ci := &schema.Collation{Name: "citext", CaseInsensitive: true}
+	e.Schema = &schema.Schema{Tables: []schema.Table{
+		{Name: "users", Columns: []schema.Column{
+			{Name: "id", DataType: "bigint"},
+			{Name: "email", DataType: "citext", Collation: ci},
+		}},
+		{Name: "orders", Columns: []schema.Column{{Name: "id", DataType: "bigint"}}},
+	}}
+	e.SetMapping(&mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users", SourceTable: "users"},
+		{Name: "orders", SourceTable: "orders"},
+	}})
+
+	r, err := e.CollationAdvice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Collections) != 1 || r.Collections[0].Collection != "users" {
+		t.Errorf("advice = %+v, want users only", r.Collections)
+	}
+
+	specs := e.collectionSpecs()
+	if specs[0].Collation == nil || specs[0].Collation.Strength != 2 || specs[1].Collation != nil {
+		t.Errorf("specs = %+v, want a case-insensitive default on users only", specs)
+	}
+}
+
+func TestSaveJoinNormalizations(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = &schema.Schema{Tables: []schema.Table{
+		{Name: "customers", Columns: []schema.Column{{Name: "code", DataType: "citext", Collation: &schema.Collation{Name: "citext", CaseInsensitive: true}}}},
+		{Name: "orders", Columns: []schema.Column{{Name: "customer_code", DataType: "text"}}},
+	}}
+	e.SetMapping(&mapping.Mapping{Collections: []mapping.Collection{{
+		Name: "customers", SourceTable: "customers",
+		Embedded: []mapping.Embedded{{SourceTable: "orders", FieldName: "orders", Relationship: "array", JoinColumn: "customer_code", ParentColumn: "code"}},
+	}}})
+
+	err := e.SaveJoinNormalizations([]collation.JoinAdvice{{Collection: "customers", Field: "payments", Normalized: true}})
+	if !errors.Is(err, ErrInvalidMapping) {
+		t.Fatalf("err = %v, want ErrInvalidMapping", err)
+	}
+	if err := e.SaveJoinNormalizations([]collation.JoinAdvice{{Collection: "customers", Field: "orders", Normalized: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if !e.Mapping.NormalizesColumn("customers", "code") || !e.Mapping.NormalizesColumn("orders", "customer_code") {
+		t.Errorf("mapping = %+v, want both keys lower-cased", e.Mapping.Collections[0])
+	}
+	r, err := e.CollationAdvice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Joins) != 1 || !r.Joins[0].Normalized {
+		t.Errorf("joins = %+v, want orders normalized", r.Joins)
+	}
+}
@@ -0,0 +1,267 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// Connection cache defaults: how long a connection may sit unused before it
+// is closed, and how many unused connections of a kind are kept.
+const (
+	DefaultConnIdleTimeout = 5 * time.Minute
+	DefaultMaxIdleConns    = 2
+)
+
+// connPool keeps the source and MongoDB connections of short engine calls
+// open between calls, so an API polled through a long run reuses a few
+// connections instead of opening new ones every time. A connection is used
+// by one call at a time: acquire takes an unused one, checking it is still
+// alive, or opens another, and its release puts it back. Connections unused
+// for the idle timeout are closed, as are those opened with settings that
+// have since changed.
+type connPool struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	maxIdle int
+	idle    map[string][]*idleConn // by kind: source or target
+	closed  bool
+}
+
+// idleConn is a connection waiting in the pool for its next call.
+type idleConn struct {
+	key   interface{} // the settings it was opened with
+	conn  interface{}
+	close func()
+	timer *time.Timer // closes it once it has been idle too long
+}
+
+// pinger is a connection that can check it is still alive.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PoolStats counts the connections a pool holds open unused, by kind.
+type PoolStats struct {
+	IdleSource int `json:"idle_source"`
+	IdleTarget int `json:"idle_target"`
+}
+
+func newConnPool(timeout time.Duration, maxIdle int) *connPool {
+	return &connPool{timeout: timeout, maxIdle: maxIdle, idle: make(map[string][]*idleConn)}
+}
+
+// acquire returns a live connection of the kind opened with key, reusing
+// an unused one when there is one, and the function that gives it back.
+// Unused connections of the kind opened with another key are closed. Keys
+// are compared with ==, so they must be of a comparable type, such as
+// sourceKey or targetKey.
+func (p *connPool) acquire(ctx context.Context, kind string, key interface{}, open func(context.Context) (interface{}, func(), error)) (interface{}, func(), error) {
+	for {
+		p.mu.Lock()
+		var ic *idleConn
+		var stale []*idleConn
+		var keep []*idleConn
+		for _, c := range p.idle[kind] {
+			if c.key != key {
+				stale = append(stale, c)
+			} else {
+				keep = append(keep, c)
+			}
+		}
+		if n := len(keep); n > 0 {
+			ic, keep = keep[n-1], keep[:n-1]
+		}
+		p.idle[kind] = keep
+		p.mu.Unlock()
+
+		for _, c := range stale {
+			c.timer.Stop()
+			c.close()
+		}
+		if ic == nil {
+			break
+		}
+		// Taken from the pool, it is no longer closed for idling
+		ic.timer.Stop()
+		if pg, ok := ic.conn.(pinger); ok {
+			if err := pg.Ping(ctx); err != nil {
+				ic.close()
+				continue
+			}
+		}
+		return ic.conn, p.releaser(kind, key, ic.conn, ic.close), nil
+	}
+
+	conn, closeFn, err := open(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, p.releaser(kind, key, conn, closeFn), nil
+}
+
+// releaser returns the function that puts a connection back in the pool,
+// or closes it when the pool is closed or holds enough unused ones.
+func (p *connPool) releaser(kind string, key, conn interface{}, closeFn func()) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			if p.closed || p.timeout <= 0 || len(p.idle[kind]) >= p.maxIdle {
+				p.mu.Unlock()
+				closeFn()
+				return
+			}
+			ic := &idleConn{key: key, conn: conn, close: closeFn}
+			ic.timer = time.AfterFunc(p.timeout, func() { p.expire(kind, ic) })
+			p.idle[kind] = append(p.idle[kind], ic)
+			p.mu.Unlock()
+		})
+	}
+}
+
+// expire closes a connection that has been idle for the timeout.
+func (p *connPool) expire(kind string, ic *idleConn) {
+	p.mu.Lock()
+	found := false
+	for i, c := range p.idle[kind] {
+		if c == ic {
+			p.idle[kind] = append(p.idle[kind][:i:i], p.idle[kind][i+1:]...)
+			found = true
+			break
+		}
+	}
+	p.mu.Unlock()
+	if found {
+		ic.close()
+	}
+}
+
+// close closes the unused connections. Connections in use are closed when
+// they are released.
+func (p *connPool) close() {
+	p.mu.Lock()
+	p.closed = true
+	var all []*idleConn
+	for kind, conns := range p.idle {
+		all = append(all, conns...)
+		delete(p.idle, kind)
+	}
+	p.mu.Unlock()
+	for _, c := range all {
+		c.timer.Stop()
+		c.close()
+	}
+}
+
+func (p *connPool) stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{IdleSource: len(p.idle["source"]), IdleTarget: len(p.idle["target"])}
+}
+
+// pool returns the engine's connection pool, creating it with the idle
+// timeout of the server config on first use.
+func (e *Engine) pool() *connPool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.conns == nil {
+		timeout := DefaultConnIdleTimeout
+		if e.Config != nil && e.Config.Server.IdleTimeout != "" {
+			d, err := time.ParseDuration(e.Config.Server.IdleTimeout)
+			if err != nil {
+				e.Logger.Warn("invalid server.idle_timeout; using the default", "value", e.Config.Server.IdleTimeout, "default", DefaultConnIdleTimeout)
+			} else {
+				timeout = d
+			}
+		}
+		e.conns = newConnPool(timeout, DefaultMaxIdleConns)
+	}
+	return e.conns
+}
+
+// sourceKey is what a source connection is opened with: the settings
+// source.NewReader reads.
+type sourceKey struct {
+	typ, host          string
+	port               int
+	database, schema   string
+	username, password string
+	ssl                bool
+}
+
+// sourceConn returns a connected source reader for a short call, from the
+// engine's pool, and the function that releases it. Calls that pin a
+// snapshot or stream for a whole run open their own reader instead.
+func (e *Engine) sourceConn(ctx context.Context) (migration.NativeSource, func(), error) {
+	if e.Config == nil {
+		return nil, nil, fmt.Errorf("no config set")
+	}
+	src := e.Config.Source
+	key := sourceKey{src.Type, src.Host, src.Port, src.Database, src.Schema, src.Username, src.Password, src.SSL}
+	conn, release, err := e.pool().acquire(ctx, "source", key, func(ctx context.Context) (interface{}, func(), error) {
+		src, err := e.newSourceReader()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := src.Connect(ctx); err != nil {
+			return nil, nil, fmt.Errorf("connecting to source: %w", err)
+		}
+		return src, func() { src.Close() }, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn.(migration.NativeSource), release, nil
+}
+
+// targetKey is what a MongoDB connection is opened with.
+type targetKey struct {
+	connectionString string
+	database         string
+}
+
+// targetConn returns a MongoDB operator for a short call, from the engine's
+// pool, and the function that releases it. Calls that change the
+// operator's read options open their own instead. Errors are returned as
+// target.Open returns them.
+func (e *Engine) targetConn(ctx context.Context) (target.Operator, func(), error) {
+	if e.Config == nil {
+		return nil, nil, fmt.Errorf("no config set")
+	}
+	tgt := e.Config.Target
+	conn, release, err := e.pool().acquire(ctx, "target", targetKey{tgt.ConnectionString, tgt.Database}, func(ctx context.Context) (interface{}, func(), error) {
+		op, err := target.Open(ctx, tgt.ConnectionString, tgt.Database)
+		if err != nil {
+			return nil, nil, err
+		}
+		return op, func() { op.Close(context.Background()) }, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn.(target.Operator), release, nil
+}
+
+// ConnectionStats returns how many connections the engine holds open
+// between calls.
+func (e *Engine) ConnectionStats() PoolStats {
+	return e.pool().stats()
+}
+
+// CloseConnections closes the connections the engine holds open between
+// calls, e.g. when the server shuts down. Connections in use are closed
+// when their call ends; later calls open new ones.
+func (e *Engine) CloseConnections() {
+	e.mu.Lock()
+	p := e.conns
+	e.conns = nil
+	e.mu.Unlock()
+	if p != nil {
+		p.close()
+	}
+}
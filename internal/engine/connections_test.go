@@ -0,0 +1,207 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/config"
+)
+
+// fakeConn is a pooled connection that counts how often it is closed.
+type fakeConn struct {
+	id      int
+	pingErr error
+	closed  atomic.Int32
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error { return c.pingErr }
+
+// fakeOpener opens numbered fakeConns.
+type fakeOpener struct {
+	opened []*fakeConn
+	err    error
+}
+
+func (o *fakeOpener) open(ctx context.Context) (interface{}, func(), error) {
+	if o.err != nil {
+		return nil, nil, o.err
+	}
+	c := &fakeConn{id: len(o.opened) + 1}
+	o.opened = append(o.opened, c)
+	return c, func() { c.closed.Add(1) }, nil
+}
+
+func TestConnPool_Reuse(t *testing.T) {
+	p := newConnPool(time.Minute, 2)
+	defer p.close()
+	o := &fakeOpener{}
+	ctx := context.Background()
+
+	c1, release1, err := p.acquire(ctx, "source", "a", o.open)
+	if err != nil {
+		t.Fatalf("acquire() error: %v", err)
+	}
+	// In use, so a second call opens another
+	c2, release2, _ := p.acquire(ctx, "source", "a", o.open)
+	if c1 == c2 {
+		t.Fatal("a connection in use was handed out twice")
+	}
+	release1()
+	release1() // releasing twice is harmless
+	release2()
+	if got := p.stats().IdleSource; got != 2 {
+		t.Errorf("idle source = %d, want 2", got)
+	}
+
+	c3, release3, _ := p.acquire(ctx, "source", "a", o.open)
+	defer release3()
+	if c3 != c2 {
+		t.Errorf("acquire() = conn %d, want the last released, 2", c3.(*fakeConn).id)
+	}
+	if len(o.opened) != 2 {
+		t.Errorf("opened %d connections, want 2", len(o.opened))
+	}
+}
+
+func TestConnPool_MaxIdle(t *testing.T) {
+	p := newConnPool(time.Minute, 1)
+	defer p.close()
+	o := &fakeOpener{}
+	ctx := context.Background()
+
+	_, release1, _ := p.acquire(ctx, "target", "a", o.open)
+	_, release2, _ := p.acquire(ctx, "target", "a", o.open)
+	release1()
+	release2()
+	if got := p.stats().IdleTarget; got != 1 {
+		t.Errorf("idle target = %d, want 1", got)
+	}
+	if o.opened[1].closed.Load() != 1 || o.opened[0].closed.Load() != 0 {
+		t.Error("the connection released past the limit should be closed")
+	}
+}
+
+func TestConnPool_IdleTimeout(t *testing.T) {
+	p := newConnPool(10*time.Millisecond, 2)
+	defer p.close()
+	o := &fakeOpener{}
+
+	_, release, _ := p.acquire(context.Background(), "source", "a", o.open)
+	release()
+	deadline := time.Now().Add(2 * time.Second)
+	for p.stats().IdleSource > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if p.stats().IdleSource != 0 || o.opened[0].closed.Load() != 1 {
+		t.Error("an idle connection should be closed after the timeout")
+	}
+
+	// No timeout closes connections as soon as they are released
+	p0 := newConnPool(0, 2)
+	_, release, _ = p0.acquire(context.Background(), "source", "a", o.open)
+	release()
+	if p0.stats().IdleSource != 0 || o.opened[1].closed.Load() != 1 {
+		t.Error("with no idle timeout a released connection should be closed")
+	}
+}
+
+func TestConnPool_FailedPing(t *testing.T) {
+	p := newConnPool(time.Minute, 2)
+	defer p.close()
+	o := &fakeOpener{}
+	ctx := context.Background()
+
+	_, release, _ := p.acquire(ctx, "source", "a", o.open)
+	release()
+	o.opened[0].pingErr = errors.New("connection reset")
+
+	c, release, err := p.acquire(ctx, "source", "a", o.open)
+	if err != nil {
+		t.Fatalf("acquire() error: %v", err)
+	}
+	defer release()
+	if c.(*fakeConn).id != 2 || o.opened[0].closed.Load() != 1 {
+		t.Error("a connection failing its ping should be closed and replaced")
+	}
+}
+
+func TestConnPool_KeyChange(t *testing.T) {
+	p := newConnPool(time.Minute, 2)
+	defer p.close()
+	o := &fakeOpener{}
+	ctx := context.Background()
+
+	_, release, _ := p.acquire(ctx, "source", "a", o.open)
+	release()
+	_, releaseTarget, _ := p.acquire(ctx, "target", "a", o.open)
+	releaseTarget()
+
+	c, release, _ := p.acquire(ctx, "source", "b", o.open)
+	defer release()
+	if c.(*fakeConn).id != 3 {
+		t.Errorf("acquire() = conn %d, want a new one", c.(*fakeConn).id)
+	}
+	if o.opened[0].closed.Load() != 1 {
+		t.Error("a connection opened with old settings should be closed")
+	}
+	if p.stats().IdleTarget != 1 {
+		t.Error("connections of another kind should be kept")
+	}
+}
+
+func TestConnPool_OpenError(t *testing.T) {
+	p := newConnPool(time.Minute, 2)
+	defer p.close()
+	o := &fakeOpener{err: errors.New("refused")}
+	if _, _, err := p.acquire(context.Background(), "source", "a", o.open); err == nil {
+		t.Error("acquire() should return the open error")
+	}
+}
+
+func TestConnPool_Close(t *testing.T) {
+	p := newConnPool(time.Minute, 2)
+	o := &fakeOpener{}
+	ctx := context.Background()
+
+	_, release1, _ := p.acquire(ctx, "source", "a", o.open)
+	_, release2, _ := p.acquire(ctx, "source", "a", o.open)
+	release1()
+	p.close()
+	if o.opened[0].closed.Load() != 1 {
+		t.Error("close() should close idle connections")
+	}
+	// Released after the pool closed
+	release2()
+	if o.opened[1].closed.Load() != 1 || p.stats().IdleSource != 0 {
+		t.Error("a connection released after close() should be closed")
+	}
+}
+
+func TestEngine_ConnPoolIdleTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		idle string
+		want time.Duration
+	}{
+		{"default", "", DefaultConnIdleTimeout},
+		{"set", "30s", 30 * time.Second},
+		{"disabled", "0s", 0},
+		{"invalid", "soon", DefaultConnIdleTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEngine(t)
+			e.Config.Server = config.ServerConfig{IdleTimeout: tt.idle}
+			if got := e.pool().timeout; got != tt.want {
+				t.Errorf("timeout = %v, want %v", got, tt.want)
+			}
+			e.CloseConnections()
+			if e.conns != nil {
+				t.Error("CloseConnections() should drop the pool")
+			}
+		})
+	}
+}
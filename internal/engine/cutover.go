@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/reloquent/reloquent/internal/cutover"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+// CutoverRunbook builds the cutover runbook from the config, state and
+// mapping. The balancer step is included when the sizing plan recommends
+// sharding.
+func (e *Engine) CutoverRunbook() (*cutover.Runbook, error) {
+	in, err := e.cutoverInput()
+	if err != nil {
+		return nil, err
+	}
+	return cutover.Build(in), nil
+}
+
+// FallbackPlan builds the plan for switching applications back to the
+// source after the cutover, from the same inputs as the runbook.
+func (e *Engine) FallbackPlan() (*cutover.Runbook, error) {
+	in, err := e.cutoverInput()
+	if err != nil {
+		return nil, err
+	}
+	return cutover.BuildFallback(in), nil
+}
+
+func (e *Engine) cutoverInput() (cutover.Input, error) {
+	if e.Config == nil {
+		return cutover.Input{}, fmt.Errorf("config required for a cutover runbook")
+	}
+	in := cutover.Input{
+		Source:    e.Config.Source,
+		Target:    e.Config.Target,
+		State:     e.State,
+		Mapping:   e.Mapping,
+		DualWrite: e.Config.Migration.DualWrite,
+	}
+	if p := e.Project(); p != nil {
+		in.Project = p.Name
+	}
+	if e.Mapping != nil && e.Schema != nil {
+		if sp, err := e.ComputeSizing(); err == nil && sp.ShardPlan != nil {
+			in.Sharded = sp.ShardPlan.Recommended
+		}
+	}
+	if e.State != nil && e.State.ValidationReportPath != "" {
+		status, err := validationStatus(e.State.ValidationReportPath)
+		if err != nil {
+			e.Logger.Warn("could not read the validation report", "path", e.State.ValidationReportPath, "error", err)
+		}
+		in.ValidationStatus = status
+	}
+	return in, nil
+}
+
+// validationStatus returns the overall status of a saved validation report.
+func validationStatus(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var r validation.Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return "", err
+	}
+	return r.Status, nil
+}
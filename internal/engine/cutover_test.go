@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/cutover"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+func TestCutoverRunbook(t *testing.T) {
+	e := testEngine(t)
+	report := filepath.Join(t.TempDir(), "validation-report.json")
+	if err := os.WriteFile(report, []byte(`{"status": "PARTIAL"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e.State = &state.State{ValidationReportPath: report, CDCSlotName: "reloquent_slot"}
+
+	rb, err := e.CutoverRunbook()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var validate, teardown bool
+	for _, s := range rb.Steps {
+		switch s.ID {
+		case cutover.StepValidate:
+			validate = true
+			if s.Note != "last validation: PARTIAL; resolve its failures before the cutover window" {
+				t.Errorf("validation note = %q", s.Note)
+			}
+		case cutover.StepCDCTeardown:
+			teardown = true
+		case cutover.StepBalancer:
+			t.Error("balancer step without a sharding plan")
+		}
+	}
+	if !validate || !teardown {
+		t.Errorf("steps = %+v, want the validation gate and CDC teardown", rb.Steps)
+	}
+
+	e.Config = nil
+	if _, err := e.CutoverRunbook(); err == nil {
+		t.Error("expected an error without a config")
+	}
+}
+
+func TestFallbackPlan(t *testing.T) {
+	e := testEngine(t)
+	e.Config.Migration.DualWrite = true
+	rb, err := e.FallbackPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range rb.Steps {
+		if s.ID == cutover.StepFallbackData && s.Title != "Confirm the source is current" {
+			t.Errorf("dual writes configured: data step = %q", s.Title)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/cdc"
+	"github.com/reloquent/reloquent/internal/report"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+// maxDashboardWarnings is how many warnings the dashboard shows.
+const maxDashboardWarnings = 10
+
+// Dashboard is every phase of the project at a glance, for a read-only
+// wall display kept open through the cutover. It is built from what the
+// engine holds and the reports it saved, without connecting to the source
+// or the target, so it is cheap to poll.
+type Dashboard struct {
+	Project       string              `json:"project,omitempty"`
+	CurrentStep   string              `json:"current_step"`
+	Steps         []DashboardStep     `json:"steps"` // every wizard step, in order
+	StepsComplete int                 `json:"steps_complete"`
+	Migration     DashboardMigration  `json:"migration"`
+	Validation    DashboardValidation `json:"validation"`
+	Indexes       DashboardIndexes    `json:"indexes"`
+	CDC           cdc.Status          `json:"cdc"`
+	Readiness     DashboardReadiness  `json:"readiness"`
+	ETA           *time.Time          `json:"eta,omitempty"`           // when the running migration should finish
+	Warnings      []DashboardWarning  `json:"warnings"`                // the latest, at most maxDashboardWarnings
+	UpdatedAt     time.Time           `json:"updated_at"`              // when the dashboard was built
+	StateUpdated  *time.Time          `json:"state_updated,omitempty"` // when the project state last changed
+}
+
+// DashboardStep is a wizard step and its status: pending, in_progress,
+// complete or skipped.
+type DashboardStep struct {
+	Step   string `json:"step"`
+	Status string `json:"status"`
+}
+
+// DashboardMigration is the progress of the last or running migration.
+type DashboardMigration struct {
+	Phase                string        `json:"phase"`
+	PercentComplete      float64       `json:"percent_complete"`
+	DocsWritten          int64         `json:"docs_written"`
+	DocsTotal            int64         `json:"docs_total"`
+	ThroughputMBps       float64       `json:"throughput_mbps"`
+	Elapsed              time.Duration `json:"elapsed"`
+	EstimatedRemain      time.Duration `json:"estimated_remain"`
+	Collections          int           `json:"collections"`
+	CollectionsCompleted int           `json:"collections_completed"`
+	CollectionsFailed    int           `json:"collections_failed"`
+}
+
+// DashboardValidation is the outcome of the last validation: not_started,
+// or PASS, FAIL or PARTIAL with the collections counted by status.
+type DashboardValidation struct {
+	Status      string     `json:"status"`
+	Passed      int        `json:"passed"`
+	Failed      int        `json:"failed"`
+	Skipped     int        `json:"skipped"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// DashboardIndexes is the progress of the index builds.
+type DashboardIndexes struct {
+	Status   string  `json:"status"`
+	Progress float64 `json:"progress"` // mean over the indexes, in percent
+	Built    int     `json:"built"`
+	Failed   int     `json:"failed"`
+	Total    int     `json:"total"`
+	Paused   bool    `json:"paused"`
+}
+
+// DashboardReadiness is the last production readiness check. Checked is
+// false until one has run.
+type DashboardReadiness struct {
+	Checked         bool                    `json:"checked"`
+	ProductionReady bool                    `json:"production_ready"`
+	Passed          int                     `json:"passed"`
+	Gates           []report.ReadinessCheck `json:"gates"`
+	CheckedAt       *time.Time              `json:"checked_at,omitempty"`
+}
+
+// DashboardWarning is a problem in one phase: migration, validation,
+// indexes, cdc or readiness.
+type DashboardWarning struct {
+	Phase   string `json:"phase"`
+	Message string `json:"message"`
+}
+
+// Dashboard gathers the state of every phase for a wall display. Reports
+// that cannot be read are logged and left out.
+func (e *Engine) Dashboard() *Dashboard {
+	d := &Dashboard{
+		CurrentStep: string(state.StepSourceConnection),
+		UpdatedAt:   time.Now().UTC(),
+		Warnings:    []DashboardWarning{},
+	}
+	if p := e.Project(); p != nil {
+		d.Project = p.Name
+	}
+	warn := func(phase, format string, args ...interface{}) {
+		d.Warnings = append(d.Warnings, DashboardWarning{Phase: phase, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Steps
+	st := e.State
+	if st != nil {
+		d.CurrentStep = string(st.CurrentStep)
+		if !st.LastUpdated.IsZero() {
+			t := st.LastUpdated.UTC()
+			d.StateUpdated = &t
+		}
+	}
+	for _, step := range allStepsOrdered() {
+		ds := DashboardStep{Step: string(step), Status: "pending"}
+		if st != nil {
+			if ss, ok := st.Steps[step]; ok && ss.Status != "" {
+				ds.Status = ss.Status
+			}
+		}
+		if ds.Status == "complete" {
+			d.StepsComplete++
+		}
+		d.Steps = append(d.Steps, ds)
+	}
+
+	// Migration
+	ms := e.MigrationStatus()
+	d.Migration = DashboardMigration{
+		Phase:           ms.Phase,
+		PercentComplete: ms.Overall.PercentComplete,
+		DocsWritten:     ms.Overall.DocsWritten,
+		DocsTotal:       ms.Overall.DocsTotal,
+		ThroughputMBps:  ms.Overall.ThroughputMBps,
+		Elapsed:         ms.ElapsedTime,
+		EstimatedRemain: ms.EstimatedRemain,
+		Collections:     len(ms.Collections),
+	}
+	if ms.Phase == "completed" && len(ms.Collections) == 0 {
+		d.Migration.PercentComplete = 100
+	}
+	for _, c := range ms.Collections {
+		switch c.State {
+		case "completed":
+			d.Migration.CollectionsCompleted++
+		case "failed":
+			d.Migration.CollectionsFailed++
+			warn("migration", "%s failed: %s", c.Name, c.Error)
+		}
+	}
+	for _, msg := range ms.Errors {
+		warn("migration", "%s", msg)
+	}
+	if ms.Phase == "running" && ms.EstimatedRemain > 0 {
+		eta := d.UpdatedAt.Add(ms.EstimatedRemain)
+		d.ETA = &eta
+	}
+
+	// Validation
+	d.Validation.Status = "not_started"
+	if result, err := e.lastValidation(); err == nil {
+		d.Validation.Status = result.Status
+		if !result.CompletedAt.IsZero() {
+			t := result.CompletedAt.UTC()
+			d.Validation.CompletedAt = &t
+		}
+		for _, c := range result.Collections {
+			switch c.Status {
+			case "PASS":
+				d.Validation.Passed++
+			case "FAIL":
+				d.Validation.Failed++
+				if c.Message != "" {
+					warn("validation", "%s failed validation: %s", c.Name, c.Message)
+				} else {
+					warn("validation", "%s failed validation", c.Name)
+				}
+			case "SKIPPED":
+				d.Validation.Skipped++
+			}
+		}
+	} else if !errors.Is(err, ErrNoValidation) {
+		e.Logger.Warn("could not read the validation report", "error", err)
+	}
+
+	// Index builds
+	if ib, err := e.IndexBuildStatus(); err == nil {
+		d.Indexes = DashboardIndexes{Status: ib.Status, Progress: ib.Progress, Total: len(ib.Indexes), Paused: ib.Paused}
+		for _, idx := range ib.Indexes {
+			switch idx.Phase {
+			case "complete":
+				d.Indexes.Built++
+			case "failed":
+				d.Indexes.Failed++
+				warn("indexes", "index %s on %s failed: %s", idx.IndexName, idx.Collection, idx.Message)
+			}
+		}
+		if ib.Status == "complete" && len(ib.Indexes) == 0 {
+			d.Indexes.Progress = 100
+		}
+	}
+
+	// Change data capture
+	d.CDC = e.CDCStatus()
+	if d.CDC.LastError != "" {
+		warn("cdc", "%s", d.CDC.LastError)
+	}
+
+	// Readiness
+	d.Readiness.Gates = []report.ReadinessCheck{}
+	if st != nil && st.ReportPath != "" {
+		rpt, err := report.ReadJSON(st.ReportPath)
+		if err != nil {
+			e.Logger.Warn("could not read the readiness report", "path", st.ReportPath, "error", err)
+		} else {
+			d.Readiness.Checked = true
+			d.Readiness.ProductionReady = rpt.ProductionReady
+			d.Readiness.Gates = rpt.ReadinessChecks
+			t := rpt.GeneratedAt.UTC()
+			d.Readiness.CheckedAt = &t
+			for _, g := range rpt.ReadinessChecks {
+				if g.Passed {
+					d.Readiness.Passed++
+				} else {
+					warn("readiness", "%s: %s", g.Name, g.Message)
+				}
+			}
+		}
+	}
+
+	// Later phases come last, so the latest warnings are the ones kept
+	if n := len(d.Warnings); n > maxDashboardWarnings {
+		d.Warnings = d.Warnings[n-maxDashboardWarnings:]
+	}
+	return d
+}
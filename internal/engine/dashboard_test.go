@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/report"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+func TestDashboard_Empty(t *testing.T) {
+	e := testEngine(t)
+	d := e.Dashboard()
+	if d.CurrentStep != string(state.StepSourceConnection) || len(d.Steps) != len(allStepsOrdered()) || d.StepsComplete != 0 {
+		t.Errorf("steps = %s, %+v", d.CurrentStep, d.Steps)
+	}
+	if d.Migration.Phase != "not_started" || d.Validation.Status != "not_started" || d.Indexes.Status != "not_started" {
+		t.Errorf("phases = %+v, %+v, %+v, want not started", d.Migration, d.Validation, d.Indexes)
+	}
+	if d.Readiness.Checked || d.ETA != nil || len(d.Warnings) != 0 {
+		t.Errorf("dashboard = %+v, want no readiness, ETA or warnings", d)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	e := testEngine(t)
+	dir := t.TempDir()
+	rptPath := filepath.Join(dir, "migration-report.json")
+	if err := report.WriteJSON(&report.MigrationReport{
+		GeneratedAt: time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC),
+		ReadinessChecks: []report.ReadinessCheck{
+			{Name: "validation", Passed: true, Message: "passed"},
+			{Name: "indexes", Passed: false, Message: "1 index build failed"},
+		},
+	}, rptPath); err != nil {
+		t.Fatal(err)
+	}
+	e.State = state.New()
+	e.State.CompleteStep(state.StepSourceConnection, state.StepTableSelection)
+	e.State.ReportPath = rptPath
+	e.migrationStatus = &migration.Status{
+		Phase:           "running",
+		Overall:         migration.ProgressInfo{DocsWritten: 600, DocsTotal: 1000, PercentComplete: 60},
+		EstimatedRemain: 10 * time.Minute,
+		Collections: []migration.CollectionStatus{
+			{Name: "orders", State: "completed"},
+			{Name: "customers", State: "failed", Error: "duplicate key"},
+			{Name: "products", State: "running"},
+		},
+	}
+	e.validationResult = &validation.Result{Status: "FAIL", Collections: []validation.CollectionResult{
+		{Name: "orders", Status: "PASS"},
+		{Name: "customers", Status: "FAIL", Message: "row count mismatch"},
+	}}
+	e.indexStatuses = []target.IndexBuildStatus{
+		{Collection: "orders", IndexName: "pk_orders", Phase: "complete", Progress: 100},
+		{Collection: "orders", IndexName: "ix_date", Phase: "failed", Message: "too many keys"},
+	}
+
+	d := e.Dashboard()
+	if d.StepsComplete != 1 || d.CurrentStep != string(state.StepTableSelection) {
+		t.Errorf("steps = %d complete, at %s", d.StepsComplete, d.CurrentStep)
+	}
+	m := d.Migration
+	if m.PercentComplete != 60 || m.Collections != 3 || m.CollectionsCompleted != 1 || m.CollectionsFailed != 1 {
+		t.Errorf("migration = %+v", m)
+	}
+	if d.ETA == nil || d.ETA.Sub(d.UpdatedAt) != 10*time.Minute {
+		t.Errorf("ETA = %v, want 10 minutes after %v", d.ETA, d.UpdatedAt)
+	}
+	if v := d.Validation; v.Status != "FAIL" || v.Passed != 1 || v.Failed != 1 {
+		t.Errorf("validation = %+v", v)
+	}
+	if ix := d.Indexes; ix.Total != 2 || ix.Built != 1 || ix.Failed != 1 || ix.Progress != 50 {
+		t.Errorf("indexes = %+v", ix)
+	}
+	if r := d.Readiness; !r.Checked || r.ProductionReady || r.Passed != 1 || len(r.Gates) != 2 || r.CheckedAt == nil {
+		t.Errorf("readiness = %+v", r)
+	}
+	want := []DashboardWarning{
+		{"migration", "customers failed: duplicate key"},
+		{"validation", "customers failed validation: row count mismatch"},
+		{"indexes", "index ix_date on orders failed: too many keys"},
+		{"readiness", "indexes: 1 index build failed"},
+	}
+	if fmt.Sprint(d.Warnings) != fmt.Sprint(want) {
+		t.Errorf("warnings = %v, want %v", d.Warnings, want)
+	}
+
+	// Only the latest warnings are kept
+	for i := 0; i < 20; i++ {
+		e.migrationStatus.Errors = append(e.migrationStatus.Errors, fmt.Sprintf("error %d", i))
+	}
+	d = e.Dashboard()
+	if len(d.Warnings) != maxDashboardWarnings || d.Warnings[maxDashboardWarnings-1].Phase != "readiness" {
+		t.Errorf("warnings = %v, want the latest %d", d.Warnings, maxDashboardWarnings)
+	}
+}
@@ -0,0 +1,156 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/aws"
+	"github.com/reloquent/reloquent/internal/aws/spark"
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/doctor"
+	"github.com/reloquent/reloquent/internal/logging"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// DoctorReportFile is the doctor report saved in the project directory.
+const DoctorReportFile = "doctor-report.json"
+
+// DoctorOptions selects the optional doctor checks.
+type DoctorOptions struct {
+	// CDC checks the replication grants change data capture needs. It is
+	// implied once CDC has been prepared.
+	CDC bool `json:"cdc"`
+	// ProbeEMR launches a short-lived cluster in the EMR subnets to test
+	// that they reach the source and target. It takes several minutes.
+	ProbeEMR bool `json:"probe_emr"`
+}
+
+// Doctor checks that the environment can run the migration and saves the
+// results, which the readiness report includes.
+func (e *Engine) Doctor(ctx context.Context, opts DoctorOptions) (*doctor.Result, error) {
+	if e.Config == nil {
+		return nil, fmt.Errorf("no config set")
+	}
+	cfg := e.Config
+	c := &doctor.Checker{
+		SourceType: cfg.Source.Type,
+		Tables:     e.roleTables(),
+		CDC:        opts.CDC || e.CDCPrepared(),
+		Platform:   cfg.AWS.Platform,
+		Bucket:     cfg.AWS.S3Bucket,
+		Dirs:       []string{filepath.Dir(e.statePath), config.ExpandHome(cfg.Logging.Directory)},
+	}
+	if e.State != nil {
+		c.Slot = e.State.CDCSlotName
+	}
+	if cfg.Source.Host != "" {
+		c.OpenSource = func(context.Context) (source.Reader, error) {
+			return e.newSourceReader()
+		}
+	}
+	if cfg.Target.ConnectionString != "" {
+		c.OpenTarget = func(ctx context.Context) (target.Operator, error) {
+			return target.Open(ctx, cfg.Target.ConnectionString, cfg.Target.Database)
+		}
+	}
+
+	if p := cfg.AWS.Platform; p == "emr" || p == "emr-serverless" || p == "glue" {
+		if client, err := aws.NewRealClient(ctx, cfg.AWS.Profile, cfg.AWS.Region); err == nil {
+			c.AWS = client
+		} else {
+			e.log(logging.ComponentAWS).Debug("loading AWS credentials failed", "error", err)
+		}
+		c.Endpoints = e.databaseEndpoints()
+	}
+	if opts.ProbeEMR && cfg.AWS.Platform == "emr" {
+		backend, err := spark.NewEMRBackend(ctx, cfg.AWS.Profile, cfg.AWS.Region)
+		if err != nil {
+			return nil, err
+		}
+		backend.SubnetIDs = cfg.AWS.Subnets
+		c.Prober = backend
+	}
+	return e.runDoctor(ctx, c)
+}
+
+// runDoctor runs the checks and saves the report next to the state.
+func (e *Engine) runDoctor(ctx context.Context, c *doctor.Checker) (*doctor.Result, error) {
+	result := c.Run(ctx)
+	path := filepath.Join(filepath.Dir(e.statePath), DoctorReportFile)
+	if err := result.WriteJSON(path); err != nil {
+		return nil, err
+	}
+	if e.State != nil {
+		e.State.DoctorReportPath = path
+		if err := e.SaveState(); err != nil {
+			return nil, err
+		}
+	}
+	e.audit("doctor_run", fmt.Sprintf("%d passed, %d failed, %d warnings",
+		result.Count(doctor.StatusPass), result.Count(doctor.StatusFail), result.Count(doctor.StatusWarn)))
+	return result, nil
+}
+
+// LastDoctorReport returns the results of the last doctor run, or nil if
+// the doctor has not run.
+func (e *Engine) LastDoctorReport() (*doctor.Result, error) {
+	if e.State == nil || e.State.DoctorReportPath == "" {
+		return nil, nil
+	}
+	return doctor.ReadJSON(e.State.DoctorReportPath)
+}
+
+// databaseEndpoints lists the host:port of the source and of each target
+// host the Spark job connects to.
+func (e *Engine) databaseEndpoints() []string {
+	var endpoints []string
+	if src := e.Config.Source; src.Host != "" && src.Port != 0 {
+		endpoints = append(endpoints, net.JoinHostPort(src.Host, strconv.Itoa(src.Port)))
+	}
+	if conn, err := config.ResolveValue(e.Config.Target.ConnectionString); err == nil {
+		endpoints = append(endpoints, targetEndpoints(conn)...)
+	}
+	return endpoints
+}
+
+// targetEndpoints returns the hosts of a MongoDB connection string, looking
+// up the SRV record of a mongodb+srv:// one.
+func targetEndpoints(connStr string) []string {
+	scheme, rest, ok := strings.Cut(connStr, "://")
+	if !ok {
+		return nil
+	}
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		rest = rest[i+1:]
+	}
+	if rest == "" {
+		return nil
+	}
+	if scheme == "mongodb+srv" {
+		_, addrs, err := net.LookupSRV("mongodb", "tcp", rest)
+		if err != nil {
+			return nil
+		}
+		var endpoints []string
+		for _, a := range addrs {
+			endpoints = append(endpoints, net.JoinHostPort(strings.TrimSuffix(a.Target, "."), strconv.Itoa(int(a.Port))))
+		}
+		return endpoints
+	}
+	var endpoints []string
+	for _, h := range strings.Split(rest, ",") {
+		if _, _, err := net.SplitHostPort(h); err != nil {
+			h = net.JoinHostPort(h, "27017")
+		}
+		endpoints = append(endpoints, h)
+	}
+	return endpoints
+}
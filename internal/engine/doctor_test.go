@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/doctor"
+)
+
+func TestRunDoctor(t *testing.T) {
+	e := testEngine(t)
+	st, err := e.LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.State = st
+
+	if r, err := e.LastDoctorReport(); r != nil || err != nil {
+		t.Fatalf("before a run: %+v, %v", r, err)
+	}
+	result, err := e.runDoctor(context.Background(), &doctor.Checker{Dirs: []string{t.TempDir()}, MinFreeBytes: 1 << 62})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Passed() {
+		t.Errorf("result passed without free space: %+v", result.Checks)
+	}
+
+	loaded, err := e.LastDoctorReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Failed()) != len(result.Failed()) {
+		t.Errorf("loaded = %+v", loaded)
+	}
+
+	// the readiness report includes the run
+	rpt, err := e.CheckReadiness(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := rpt.ReadinessChecks[0]; c.Name != "Environment checks" || c.Passed {
+		t.Errorf("readiness check = %+v", c)
+	}
+}
+
+func TestTargetEndpoints(t *testing.T) {
+	tests := []struct {
+		conn string
+		want []string
+	}{
+		{"mongodb://localhost", []string{"localhost:27017"}},
+		{"mongodb://u:p@a:27018,b/db?replicaSet=rs0", []string{"a:27018", "b:27017"}},
+		{"ferretdb://h:27019/db", []string{"h:27019"}},
+		{"not a url", nil},
+	}
+	for _, tt := range tests {
+		if got := targetEndpoints(tt.conn); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("targetEndpoints(%q) = %v, want %v", tt.conn, got, tt.want)
+		}
+	}
+}
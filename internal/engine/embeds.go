@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+// EmbedDistribution is how many child rows each parent row has for an
+// embedded field, measured in the source with the mapping's row filters.
+type EmbedDistribution struct {
+	Collection   string `json:"collection"`
+	Field        string `json:"field"` // dotted path within the document
+	ParentTable  string `json:"parent_table"`
+	ChildTable   string `json:"child_table"`
+	Relationship string `json:"relationship"`
+	schema.ChildCounts
+}
+
+// EmbedDistribution measures the child-per-parent counts of each embedded
+// field of a collection, or of every collection when collection is empty,
+// and keeps them in the state. Later document size estimates, storage
+// sizing and the 16 MB guardrail size those fields from the measured counts
+// instead of assuming children are spread evenly. An unknown collection is
+// reported as ErrInvalidMapping.
+func (e *Engine) EmbedDistribution(ctx context.Context, collection string) ([]EmbedDistribution, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	cols := e.Mapping.Collections
+	if collection != "" {
+		cols = nil
+		for _, c := range e.Mapping.Collections {
+			if c.Name == collection {
+				cols = append(cols, c)
+			}
+		}
+		if cols == nil {
+			return nil, fmt.Errorf("%w: no collection %s", ErrInvalidMapping, collection)
+		}
+	}
+
+	src, release, err := e.sourceConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return e.embedDistribution(ctx, src, cols)
+}
+
+func (e *Engine) embedDistribution(ctx context.Context, src source.Reader, cols []mapping.Collection) ([]EmbedDistribution, error) {
+	dists := []EmbedDistribution{}
+	var walk func(col, path, parentTable, parentFilter string, embedded []mapping.Embedded) error
+	walk = func(col, path, parentTable, parentFilter string, embedded []mapping.Embedded) error {
+		for _, emb := range embedded {
+			field := emb.FieldName
+			if path != "" {
+				field = path + "." + emb.FieldName
+			}
+			counts, err := src.ChildCounts(ctx, source.Reference{
+				Table:         emb.SourceTable,
+				Columns:       emb.JoinKeys(),
+				Filter:        emb.Filter,
+				ParentTable:   parentTable,
+				ParentColumns: emb.ParentKeys(),
+				ParentFilter:  parentFilter,
+			})
+			if err != nil {
+				return fmt.Errorf("measuring %s.%s: %w", col, field, err)
+			}
+			dists = append(dists, EmbedDistribution{
+				Collection:   col,
+				Field:        field,
+				ParentTable:  parentTable,
+				ChildTable:   emb.SourceTable,
+				Relationship: emb.Relationship,
+				ChildCounts:  counts,
+			})
+			if err := walk(col, field, emb.SourceTable, emb.Filter, emb.Embedded); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, c := range cols {
+		if err := walk(c.Name, "", c.SourceTable, c.LiveFilter(), c.Embedded); err != nil {
+			return nil, err
+		}
+	}
+
+	if e.State != nil {
+		if e.State.EmbedCounts == nil {
+			e.State.EmbedCounts = make(map[string]schema.ChildCounts)
+		}
+		for _, d := range dists {
+			e.State.EmbedCounts[mapping.EmbedKey(d.Collection, d.Field)] = d.ChildCounts
+		}
+		if err := e.SaveState(); err != nil {
+			return nil, err
+		}
+	}
+	return dists, nil
+}
+
+// embedCounts returns the child counts measured by EmbedDistribution, or
+// nil before any were.
+func (e *Engine) embedCounts() mapping.EmbedCounts {
+	if e.State == nil {
+		return nil
+	}
+	return e.State.EmbedCounts
+}
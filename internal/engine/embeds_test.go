@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+func TestEmbedDistribution(t *testing.T) {
+	e := testEngine(t)
+	st, err := e.LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.State = st
+	e.Schema = &schema.Schema{Tables: []schema.Table{
+		{Name: "customers", RowCount: 2, SizeBytes: 200},
+		{Name: "orders", RowCount: 4, SizeBytes: 4 * 1024 * 1024},
+		{Name: "items", RowCount: 6, SizeBytes: 600},
+	}}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{
+		Name: "customers", SourceTable: "customers",
+		Embedded: []mapping.Embedded{{
+			SourceTable: "orders", FieldName: "orders", Relationship: "array",
+			JoinColumn: "customer_id", ParentColumn: "id",
+			Embedded: []mapping.Embedded{{
+				SourceTable: "items", FieldName: "items", Relationship: "array",
+				JoinColumn: "order_id", ParentColumn: "id",
+			}},
+		}},
+	}}}
+	src := &source.MockReader{TableRows: map[string][]map[string]interface{}{
+		"customers": {{"id": 1}, {"id": 2}},
+		"orders":    {{"id": 10, "customer_id": 1}, {"id": 11, "customer_id": 1}, {"id": 12, "customer_id": 1}, {"id": 13, "customer_id": 2}},
+		"items":     {{"order_id": 10}, {"order_id": 10}, {"order_id": 10}, {"order_id": 10}, {"order_id": 11}, {"order_id": 13}},
+	}}
+
+	dists, err := e.embedDistribution(context.Background(), src, e.Mapping.Collections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dists) != 2 {
+		t.Fatalf("got %d distributions, want 2: %+v", len(dists), dists)
+	}
+	orders, items := dists[0], dists[1]
+	if orders.Field != "orders" || orders.ParentTable != "customers" || orders.Max != 3 || orders.Children != 4 {
+		t.Errorf("orders = %+v", orders)
+	}
+	if items.Field != "orders.items" || items.ParentTable != "orders" || items.Parents != 4 || items.Max != 4 || items.P50 != 1 {
+		t.Errorf("items = %+v", items)
+	}
+
+	// the measured counts replace the even-spread assumption in estimates
+	if got := e.State.EmbedCounts["customers.orders.items"]; got != items.ChildCounts {
+		t.Errorf("state counts = %+v, want %+v", got, items.ChildCounts)
+	}
+	ests, err := e.MappingSizeEstimate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ests[0].Measured {
+		t.Errorf("estimate = %+v, want measured", ests[0])
+	}
+
+	if _, err := e.EmbedDistribution(context.Background(), "nope"); !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("unknown collection: err = %v, want ErrInvalidMapping", err)
+	}
+}
@@ -3,27 +3,45 @@ package engine
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
+	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/atlas"
+	"github.com/reloquent/reloquent/internal/audit"
 	"github.com/reloquent/reloquent/internal/aws"
+	"github.com/reloquent/reloquent/internal/aws/spark"
 	"github.com/reloquent/reloquent/internal/benchmark"
+	"github.com/reloquent/reloquent/internal/cdc"
 	"github.com/reloquent/reloquent/internal/codegen"
 	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/dictionary"
 	"github.com/reloquent/reloquent/internal/discovery"
+	"github.com/reloquent/reloquent/internal/drivers"
+	"github.com/reloquent/reloquent/internal/hooks"
+	"github.com/reloquent/reloquent/internal/impact"
 	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/logging"
 	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/plan"
 	"github.com/reloquent/reloquent/internal/postmigration"
 	"github.com/reloquent/reloquent/internal/report"
+	"github.com/reloquent/reloquent/internal/retention"
 	"github.com/reloquent/reloquent/internal/schema"
 	"github.com/reloquent/reloquent/internal/selection"
 	"github.com/reloquent/reloquent/internal/sizing"
 	"github.com/reloquent/reloquent/internal/source"
 	"github.com/reloquent/reloquent/internal/state"
 	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/transform"
 	"github.com/reloquent/reloquent/internal/typemap"
 	"github.com/reloquent/reloquent/internal/validation"
 )
@@ -36,28 +54,64 @@ type Engine struct {
 	Mapping *mapping.Mapping
 	TypeMap *typemap.TypeMap
 	Logger  *slog.Logger
+	Actor   string // who the audit trail records as acting; see audit.CurrentActor
+
+	// Renderer, when set, presents the progress of Run as it changes.
+	Renderer RunRenderer
 
-	statePath string
+	project    *state.Project
+	statePath  string
+	configPath string          // file Config was loaded from; see ReloadConfig
+	logLevels  *logging.Levels // Logger's levels, when they can change
 
 	// Runtime state for long-running operations
 	mu               sync.Mutex
 	migrationCancel  context.CancelFunc
+	migrationControl *migration.Control      // set while a migration runs
+	sparkStop        context.CancelCauseFunc // stops the running Spark job to pause it
 	migrationStatus  *migration.Status
 	validationResult *validation.Result
 	indexPlan        *indexes.IndexPlan
+	indexControl     *postmigration.IndexBuildControl // set while index builds run
+	indexStatuses    []target.IndexBuildStatus
+	cdcCancel        context.CancelFunc
+	cdcDone          chan struct{}
+	cdcReplicator    *cdc.Replicator
+	cdcDrain         bool          // set by FinishCDC: apply what is left before stopping
+	cdcErr           error         // how the last background replication ended
+	progress         *progressFile // set while Run writes a progress file or renders progress
+	runID            int64         // run Run recorded, for throughput samples
+	conns            *connPool     // connections kept open between calls; see sourceConn
 }
 
-// New creates a new Engine with the given config and logger.
+// New creates a new Engine with the given config and logger, working in the
+// active project.
 func New(cfg *config.Config, logger *slog.Logger) *Engine {
+	p := state.Active()
 	return &Engine{
 		Config:    cfg,
 		Logger:    logger,
-		statePath: config.ExpandHome(state.DefaultPath),
+		project:   p,
+		statePath: p.StatePath(),
 	}
 }
 
+// SetProject points the engine at another project's state and artifacts.
+func (e *Engine) SetProject(p *state.Project) {
+	e.project = p
+	e.statePath = p.StatePath()
+}
+
+// Project returns the project the engine works in.
+func (e *Engine) Project() *state.Project {
+	return e.project
+}
+
 // LoadState loads the wizard state from disk.
 func (e *Engine) LoadState() (*state.State, error) {
+	if err := e.ensureMetadataStore(); err != nil {
+		return nil, err
+	}
 	st, err := state.Load(e.statePath)
 	if err != nil {
 		return nil, err
@@ -130,6 +184,22 @@ func (e *Engine) SetSourceConfig(cfg *config.SourceConfig) {
 	e.Config.Source = *cfg
 }
 
+// SetTargetConfig sets the target MongoDB configuration and records it with
+// the project, as the wizard's target step does.
+func (e *Engine) SetTargetConfig(cfg *config.TargetConfig) error {
+	if e.Config == nil {
+		e.Config = &config.Config{Version: 1}
+	}
+	e.Config.Target = *cfg
+	st, err := e.LoadState()
+	if err != nil {
+		return err
+	}
+	tgt := *cfg
+	st.TargetConfig = &tgt
+	return e.SaveState()
+}
+
 // TestSourceConnection tests connectivity to the source database.
 func (e *Engine) TestSourceConnection(ctx context.Context, cfg *config.SourceConfig) error {
 	d, err := discovery.New(cfg)
@@ -142,7 +212,7 @@ func (e *Engine) TestSourceConnection(ctx context.Context, cfg *config.SourceCon
 
 // TestTargetConnection tests connectivity to the target MongoDB.
 func (e *Engine) TestTargetConnection(ctx context.Context, cfg *config.TargetConfig) error {
-	op, err := target.NewMongoOperator(ctx, cfg.ConnectionString, cfg.Database)
+	op, err := target.Open(ctx, cfg.ConnectionString, cfg.Database)
 	if err != nil {
 		return err
 	}
@@ -153,7 +223,7 @@ func (e *Engine) TestTargetConnection(ctx context.Context, cfg *config.TargetCon
 
 // DetectTopology returns MongoDB topology information.
 func (e *Engine) DetectTopology(ctx context.Context, cfg *config.TargetConfig) (*target.TopologyInfo, error) {
-	op, err := target.NewMongoOperator(ctx, cfg.ConnectionString, cfg.Database)
+	op, err := target.Open(ctx, cfg.ConnectionString, cfg.Database)
 	if err != nil {
 		return nil, err
 	}
@@ -161,8 +231,11 @@ func (e *Engine) DetectTopology(ctx context.Context, cfg *config.TargetConfig) (
 	return op.DetectTopology(ctx)
 }
 
-// Discover runs source database schema discovery.
-func (e *Engine) Discover(ctx context.Context) (*schema.Schema, error) {
+// Discover runs source database schema discovery, reporting its progress
+// to progress when it is not nil. Every discovery is kept as a schema
+// snapshot of the project, and becomes the loaded schema unless another
+// snapshot is pinned as the design baseline.
+func (e *Engine) Discover(ctx context.Context, progress discovery.ProgressFunc) (*schema.Schema, error) {
 	if e.Config == nil {
 		return nil, fmt.Errorf("no config set")
 	}
@@ -172,16 +245,26 @@ func (e *Engine) Discover(ctx context.Context) (*schema.Schema, error) {
 	}
 	defer d.Close()
 
+	log := e.log(logging.ComponentDiscovery)
+	log.Debug("connecting to source", "type", e.Config.Source.Type, "host", e.Config.Source.Host, "database", e.Config.Source.Database)
 	if err := d.Connect(ctx); err != nil {
 		return nil, fmt.Errorf("connecting to source: %w", err)
 	}
 
-	s, err := d.Discover(ctx)
+	s, err := d.Discover(ctx, func(p discovery.Progress) {
+		log.Debug("discovery progress", "phase", p.Phase, "table", p.Table, "tables_done", p.TablesDone, "tables_total", p.TablesTotal)
+		if progress != nil {
+			progress(p)
+		}
+	})
 	if err != nil {
 		return nil, fmt.Errorf("discovering schema: %w", err)
 	}
+	log.Debug("discovery finished", "tables", len(s.Tables))
 
-	e.Schema = s
+	if err := e.recordSchema(s); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
@@ -190,6 +273,61 @@ func (e *Engine) GetSchema() *schema.Schema {
 	return e.Schema
 }
 
+// ErrNoSchema is returned by RediscoverAndDiff when there is no earlier
+// discovery to compare against.
+var ErrNoSchema = errors.New("no schema discovered yet")
+
+// SchemaChanges is the result of rediscovering the source: how the schema
+// changed, and which parts of the saved mapping it broke.
+type SchemaChanges struct {
+	Diff            *schema.SchemaDiff       `json:"diff"`
+	StaleReferences []mapping.StaleReference `json:"stale_references"`
+}
+
+// RediscoverAndDiff discovers the source schema again and compares it with
+// the schema discovered earlier, in memory or saved with the wizard state.
+// The new schema is saved as another snapshot, like any discovery.
+func (e *Engine) RediscoverAndDiff(ctx context.Context) (*SchemaChanges, error) {
+	old := e.Schema
+	var st *state.State
+	if s, err := state.Load(e.statePath); err == nil {
+		st = s
+	}
+	if old == nil && st != nil && st.SchemaPath != "" {
+		s, err := schema.LoadYAML(st.SchemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading saved schema: %w", err)
+		}
+		old = s
+	}
+	if old == nil {
+		return nil, ErrNoSchema
+	}
+	m := e.Mapping
+	if m == nil && st != nil && st.MappingPath != "" {
+		if loaded, err := mapping.LoadYAML(st.MappingPath); err == nil {
+			m = loaded
+		}
+	}
+
+	cur, err := e.Discover(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return schemaChanges(old, cur, m), nil
+}
+
+func schemaChanges(old, cur *schema.Schema, m *mapping.Mapping) *SchemaChanges {
+	c := &SchemaChanges{
+		Diff:            schema.Diff(old, cur),
+		StaleReferences: []mapping.StaleReference{},
+	}
+	if m != nil {
+		c.StaleReferences = append(c.StaleReferences, m.StaleReferences(cur)...)
+	}
+	return c
+}
+
 // SelectTables saves the selected tables to state.
 func (e *Engine) SelectTables(names []string) error {
 	st, err := e.LoadState()
@@ -212,7 +350,11 @@ func (e *Engine) SelectTables(names []string) error {
 
 	st.SelectedTables = names
 	e.State = st
-	return e.SaveState()
+	if err := e.SaveState(); err != nil {
+		return err
+	}
+	e.audit("tables_selected", fmt.Sprintf("%d tables", len(names)))
+	return nil
 }
 
 // GetSelectedTables returns tables filtered by the current selection.
@@ -233,6 +375,17 @@ func (e *Engine) GetSelectedTables() []schema.Table {
 	return result
 }
 
+// selectedSchema returns the schema of the tables selected for migration,
+// or the whole schema when none are selected yet.
+func (e *Engine) selectedSchema() *schema.Schema {
+	if e.Schema == nil || e.State == nil || len(e.State.SelectedTables) == 0 {
+		return e.Schema
+	}
+	s := *e.Schema
+	s.Tables = e.GetSelectedTables()
+	return &s
+}
+
 // GetOrphanedReferences returns FK references to unselected tables.
 func (e *Engine) GetOrphanedReferences() []selection.OrphanedRef {
 	selected := e.GetSelectedTables()
@@ -252,26 +405,135 @@ func (e *Engine) GetMapping() *mapping.Mapping {
 	return e.Mapping
 }
 
+// ErrInvalidMapping is returned by SaveMappingJSON when the mapping parses
+// but fails validation.
+var ErrInvalidMapping = errors.New("invalid mapping")
+
 // SaveMappingJSON saves a mapping from JSON data.
 func (e *Engine) SaveMappingJSON(data []byte) error {
 	m := &mapping.Mapping{}
 	if err := json.Unmarshal(data, m); err != nil {
 		return fmt.Errorf("parsing mapping: %w", err)
 	}
+	return e.SaveMapping(m)
+}
+
+// SaveMapping validates a mapping and saves it as the project's mapping.
+// Columns whose names are not safe document keys are renamed first, unless
+// the mapping's field name policy keeps them or fails on them.
+func (e *Engine) SaveMapping(m *mapping.Mapping) error {
+	m.RenameUnsafeFields(e.Schema)
+	if err := e.ValidateMapping(m); err != nil {
+		return err
+	}
 	e.Mapping = m
+	return e.saveMapping()
+}
+
+// ValidateMapping checks a mapping against the discovered schema without
+// saving it. Failures wrap ErrInvalidMapping; those of the mapping lint
+// also wrap a *mapping.LintError listing every error it found.
+func (e *Engine) ValidateMapping(m *mapping.Mapping) error {
+	if err := mapping.Validate(e.selectedSchema(), m).Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMapping, err)
+	}
+	if err := m.ValidateFields(e.Schema); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	if err := m.ValidateFilters(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	if err := m.ValidateJoins(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	if err := transform.ValidateMapping(m, e.Schema); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	if err := m.ValidateOffloads(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	if err := m.ValidateWatermarks(e.Schema); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	if err := m.ValidateArchives(e.Schema); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	if err := m.ValidateConsistencyGroups(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	if err := m.ValidateOrder(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	if err := m.ValidateErrorPolicies(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	if err := m.ValidateFieldNames(e.Schema); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	return nil
+}
+
+// MappingIssues returns the problems the mapping lint finds in the saved
+// mapping; see mapping.Validate.
+func (e *Engine) MappingIssues() (mapping.Issues, error) {
+	if e.Mapping == nil {
+		return nil, fmt.Errorf("no mapping saved yet")
+	}
+	return mapping.Validate(e.selectedSchema(), e.Mapping), nil
+}
 
+// saveMapping writes the mapping to the project directory and records it in
+// the state.
+func (e *Engine) saveMapping() error {
 	st, err := e.LoadState()
 	if err != nil {
 		return err
 	}
 
-	mappingPath := config.ExpandHome("~/.reloquent/mapping.yaml")
-	if err := m.WriteYAML(mappingPath); err != nil {
+	mappingPath := filepath.Join(filepath.Dir(e.statePath), "mapping.yaml")
+	snap := audit.Snapshot("mapping", mappingPath)
+	if err := e.Mapping.WriteYAML(mappingPath); err != nil {
 		return err
 	}
 	st.MappingPath = mappingPath
 	e.State = st
-	return e.SaveState()
+	if err := e.SaveState(); err != nil {
+		return err
+	}
+	e.auditChange("mapping_saved", fmt.Sprintf("%d collections", len(e.Mapping.Collections)), snap)
+	return nil
+}
+
+// FilterPreview is how many rows of a source table a row filter keeps.
+type FilterPreview struct {
+	Table        string `json:"table"`
+	Filter       string `json:"filter"`
+	TotalRows    int64  `json:"total_rows"`
+	FilteredRows int64  `json:"filtered_rows"`
+}
+
+// PreviewFilter counts the rows of a source table that match a row filter.
+// Filters that are rejected, or that the source database cannot run, are
+// reported as ErrInvalidMapping.
+func (e *Engine) PreviewFilter(ctx context.Context, table, filter string) (*FilterPreview, error) {
+	if err := mapping.ValidateFilter(filter); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	src, release, err := e.sourceConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	total, err := src.RowCount(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	filtered, err := src.FilteredRowCount(ctx, table, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	return &FilterPreview{Table: table, Filter: filter, TotalRows: total, FilteredRows: filtered}, nil
 }
 
 // GetTypeMap returns the current type map.
@@ -297,8 +559,92 @@ func (e *Engine) SaveTypeMapOverrides(overrides map[string]string) error {
 	for sourceType, bsonType := range overrides {
 		tm.Override(sourceType, typemap.BSONType(bsonType))
 	}
+	return e.saveTypeMap(tm)
+}
+
+// LOBColumns returns the large object columns of the schema with the
+// strategy chosen for each.
+func (e *Engine) LOBColumns() ([]typemap.LOBColumn, error) {
+	tm := e.GetTypeMap()
+	if tm == nil {
+		return nil, fmt.Errorf("no type map available")
+	}
+	return tm.LOBColumns(e.Schema), nil
+}
+
+// SaveLOBStrategies sets how large object columns are migrated. Nothing is
+// saved if any of them is invalid.
+func (e *Engine) SaveLOBStrategies(lobs []typemap.LOBColumn) error {
+	tm := e.GetTypeMap()
+	if tm == nil {
+		return fmt.Errorf("no type map available")
+	}
+	for _, lob := range lobs {
+		if err := lob.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, lob := range lobs {
+		if err := tm.SetLOB(lob); err != nil {
+			return err
+		}
+	}
+	return e.saveTypeMap(tm)
+}
+
+// TimestampColumns returns the timestamp columns without a time zone of the
+// schema with the time zone policy chosen for each.
+func (e *Engine) TimestampColumns() ([]typemap.TimestampColumn, error) {
+	tm := e.GetTypeMap()
+	if tm == nil {
+		return nil, fmt.Errorf("no type map available")
+	}
+	return tm.TimestampColumns(e.Schema), nil
+}
+
+// DecimalColumns returns the fixed-point columns of the schema with the
+// BSON type each is written as, and why it may not hold every value
+// exactly.
+func (e *Engine) DecimalColumns() ([]typemap.DecimalColumn, error) {
+	tm := e.GetTypeMap()
+	if tm == nil {
+		return nil, fmt.Errorf("no type map available")
+	}
+	return tm.DecimalColumns(e.Schema), nil
+}
+
+// SaveTimestampPolicies sets the time zones timestamp columns without one
+// are read in. Nothing is saved if any policy is invalid, or reads the
+// source server's time zone when discovery did not record it.
+func (e *Engine) SaveTimestampPolicies(cols []typemap.TimestampColumn) error {
+	tm := e.GetTypeMap()
+	if tm == nil {
+		return fmt.Errorf("no type map available")
+	}
+	serverZone := ""
+	if e.Schema != nil {
+		serverZone = e.Schema.TimeZone
+	}
+	for _, c := range cols {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if _, err := c.Location(serverZone); err != nil {
+			return err
+		}
+	}
+	for _, c := range cols {
+		if err := tm.SetTimestamp(c); err != nil {
+			return err
+		}
+	}
+	return e.saveTypeMap(tm)
+}
 
-	typeMapPath := config.ExpandHome("~/.reloquent/typemap.yaml")
+// saveTypeMap writes the type map next to the state and records its path.
+func (e *Engine) saveTypeMap(tm *typemap.TypeMap) error {
+	typeMapPath := filepath.Join(filepath.Dir(e.statePath), "typemap.yaml")
+	snap := audit.Snapshot("typemap", typeMapPath)
 	if err := tm.WriteYAML(typeMapPath); err != nil {
 		return err
 	}
@@ -309,7 +655,11 @@ func (e *Engine) SaveTypeMapOverrides(overrides map[string]string) error {
 	}
 	st.TypeMappingPath = typeMapPath
 	e.State = st
-	return e.SaveState()
+	if err := e.SaveState(); err != nil {
+		return err
+	}
+	e.auditChange("typemap_saved", "", snap)
+	return nil
 }
 
 // ComputeSizing computes a sizing plan from current state.
@@ -323,9 +673,33 @@ func (e *Engine) ComputeSizing() (*sizing.SizingPlan, error) {
 		TotalDataBytes:  selection.TotalSize(selected),
 		TotalRowCount:   selection.TotalRows(selected),
 		CollectionCount: len(selected),
+		Collections:     sizing.ZoneInputs(e.Mapping, e.Schema),
+		Storage:         sizing.StorageInputs(e.Mapping, e.Schema, e.embedCounts()),
+		TargetWriteMBps: e.targetWriteMBps(),
+	}
+
+	plan := sizing.Calculate(input)
+	if plan.ShardPlan != nil {
+		if err := plan.ShardPlan.ValidateZones(); err != nil {
+			return nil, fmt.Errorf("invalid zone configuration: %w", err)
+		}
 	}
+	return plan, nil
+}
 
-	return sizing.Calculate(input), nil
+// ShardAdvisor samples the source tables of the mapped collections and ranks
+// shard key candidates for each by cardinality and value skew. A sampleSize
+// of zero uses sizing.DefaultShardSampleSize.
+func (e *Engine) ShardAdvisor(ctx context.Context, sampleSize int) ([]sizing.ShardAdvice, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	src, release, err := e.sourceConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return sizing.AdviseShardKeys(ctx, src, e.Mapping, e.Schema, sampleSize), nil
 }
 
 // SaveAWSConfig saves AWS configuration.
@@ -343,14 +717,35 @@ func (e *Engine) SaveAWSConfig(cfg *config.AWSConfig) error {
 	return e.SaveState()
 }
 
-// RunBenchmark executes a throughput benchmark on a source table.
-func (e *Engine) RunBenchmark(ctx context.Context, tableName, partitionCol string) (*benchmark.Result, error) {
+// RunBenchmark executes a throughput benchmark on a source table, within the
+// configured guard rails. A dry run returns the queries without touching the
+// source.
+func (e *Engine) RunBenchmark(ctx context.Context, tableName, partitionCol string, dryRun bool) (*benchmark.Result, error) {
 	if e.Config == nil {
 		return nil, fmt.Errorf("no config set")
 	}
+	guard, err := benchmarkGuard(e.Config.Benchmark)
+	if err != nil {
+		return nil, err
+	}
 
-	connStr := buildPgConnString(e.Config.Source)
-	reader := &benchmark.PostgresReader{ConnString: connStr}
+	src := e.Config.Source
+	var connStr string
+	if !dryRun {
+		if src, err = src.Resolve(); err != nil {
+			return nil, err
+		}
+		if connStr, err = source.ConnString(src); err != nil {
+			return nil, err
+		}
+	}
+	var reader benchmark.SourceReader
+	switch src.Type {
+	case "oracle":
+		reader = &benchmark.OracleReader{ConnString: connStr}
+	default:
+		reader = &benchmark.PostgresReader{ConnString: connStr}
+	}
 
 	selected := e.GetSelectedTables()
 	var totalBytes int64
@@ -364,17 +759,43 @@ func (e *Engine) RunBenchmark(ctx context.Context, tableName, partitionCol strin
 		TableName:      tableName,
 		PartitionCol:   partitionCol,
 		TotalDataBytes: totalBytes,
+		SamplePercent:  e.Config.Benchmark.SamplePercent,
+		Guard:          guard,
+		DryRun:         dryRun,
 	})
 }
 
+// benchmarkGuard converts the benchmark profile into read limits.
+func benchmarkGuard(cfg config.BenchmarkConfig) (benchmark.Guard, error) {
+	guard := benchmark.Guard{MaxRows: cfg.MaxRows, MaxBytes: cfg.MaxBytes}
+	if cfg.MaxDuration != "" {
+		d, err := time.ParseDuration(cfg.MaxDuration)
+		if err != nil {
+			return guard, fmt.Errorf("invalid benchmark max_duration: %w", err)
+		}
+		guard.MaxDuration = d
+	}
+	if cfg.OffPeakWindow != "" {
+		w, err := benchmark.ParseWindow(cfg.OffPeakWindow)
+		if err != nil {
+			return guard, fmt.Errorf("invalid benchmark off_peak_window: %w", err)
+		}
+		guard.OffPeak = w
+	}
+	return guard, nil
+}
+
 // ValidateAWS verifies AWS credentials and checks platform access.
 func (e *Engine) ValidateAWS(ctx context.Context) (*AWSValidationResult, error) {
 	if e.Config == nil {
 		return nil, fmt.Errorf("no config set")
 	}
 	awsCfg := e.Config.AWS
+	log := e.log(logging.ComponentAWS)
+	log.Debug("validating AWS access", "profile", awsCfg.Profile, "region", awsCfg.Region)
 	client, err := aws.NewRealClient(ctx, awsCfg.Profile, awsCfg.Region)
 	if err != nil {
+		log.Debug("loading AWS credentials failed", "error", err)
 		return &AWSValidationResult{
 			Valid:   false,
 			Message: fmt.Sprintf("Failed to load AWS credentials: %v", err),
@@ -383,6 +804,7 @@ func (e *Engine) ValidateAWS(ctx context.Context) (*AWSValidationResult, error)
 
 	identity, err := client.VerifyCredentials(ctx)
 	if err != nil {
+		log.Debug("verifying AWS credentials failed", "error", err)
 		return &AWSValidationResult{
 			Valid:   false,
 			Message: fmt.Sprintf("AWS credentials invalid: %v", err),
@@ -391,6 +813,7 @@ func (e *Engine) ValidateAWS(ctx context.Context) (*AWSValidationResult, error)
 
 	access, err := aws.CheckPlatformAccess(ctx, client)
 	if err != nil {
+		log.Debug("platform access check failed", "error", err)
 		return &AWSValidationResult{
 			Valid:   false,
 			Message: fmt.Sprintf("Platform access check failed: %v", err),
@@ -422,23 +845,61 @@ func (e *Engine) PreMigrationPrepare(ctx context.Context) error {
 	if e.Config == nil || e.Mapping == nil {
 		return fmt.Errorf("config and mapping required")
 	}
+	if err := e.Mapping.ValidateStorage(); err != nil {
+		return fmt.Errorf("invalid storage options: %w", err)
+	}
+	if err := e.Mapping.ValidateRetention(); err != nil {
+		return fmt.Errorf("invalid retention policy: %w", err)
+	}
+	if err := e.Mapping.ValidateFilters(); err != nil {
+		return fmt.Errorf("invalid row filter: %w", err)
+	}
+	if err := e.Mapping.ValidateJoins(); err != nil {
+		return fmt.Errorf("invalid join: %w", err)
+	}
+	if err := transform.ValidateMapping(e.Mapping, e.Schema); err != nil {
+		return fmt.Errorf("invalid transformation: %w", err)
+	}
+	if err := e.Mapping.ValidateOffloads(); err != nil {
+		return fmt.Errorf("invalid offload: %w", err)
+	}
+	if err := e.Mapping.ValidateWatermarks(e.Schema); err != nil {
+		return fmt.Errorf("invalid watermark: %w", err)
+	}
+	if err := e.Mapping.ValidateArchives(e.Schema); err != nil {
+		return fmt.Errorf("invalid archive policy: %w", err)
+	}
+	if err := e.Mapping.ValidateConsistencyGroups(); err != nil {
+		return fmt.Errorf("invalid consistency group: %w", err)
+	}
+	if err := e.Mapping.ValidateOrder(); err != nil {
+		return fmt.Errorf("invalid collection order: %w", err)
+	}
+	if err := e.Mapping.ValidateErrorPolicies(); err != nil {
+		return fmt.Errorf("invalid error policy: %w", err)
+	}
+	if err := e.Mapping.ValidateIDGeneration(e.Schema); err != nil {
+		return fmt.Errorf("invalid id generation: %w", err)
+	}
+	if err := e.Mapping.ValidateValidation(); err != nil {
+		return fmt.Errorf("invalid validation level: %w", err)
+	}
+	if err := e.hookRunner().Run(ctx, hooks.Before, state.StepPreMigration, nil); err != nil {
+		return err
+	}
 
-	tgt := e.Config.Target
-	op, err := target.NewMongoOperator(ctx, tgt.ConnectionString, tgt.Database)
+	op, release, err := e.targetConn(ctx)
 	if err != nil {
 		return fmt.Errorf("connecting to MongoDB: %w", err)
 	}
-	defer op.Close(ctx)
-
-	// Collect collection names
-	names := make([]string, len(e.Mapping.Collections))
-	for i, c := range e.Mapping.Collections {
-		names[i] = c.Name
-	}
+	defer release()
 
-	if err := op.CreateCollections(ctx, names); err != nil {
+	if err := op.CreateCollectionsWithOptions(ctx, e.collectionSpecs()); err != nil {
 		return fmt.Errorf("creating collections: %w", err)
 	}
+	if err := e.applyValidators(ctx, op); err != nil {
+		return err
+	}
 
 	// Set migration write concern: w:1, j:false for max throughput
 	if err := op.SetWriteConcern(ctx, "1", false); err != nil {
@@ -452,7 +913,10 @@ func (e *Engine) PreMigrationPrepare(ctx context.Context) error {
 	}
 	st.Steps[state.StepPreMigration] = state.StepState{Status: "complete"}
 	e.State = st
-	return e.SaveState()
+	if err := e.SaveState(); err != nil {
+		return err
+	}
+	return e.hookRunner().Run(ctx, hooks.After, state.StepPreMigration, nil)
 }
 
 // PreMigrationStatus returns the pre-migration preparation status.
@@ -476,7 +940,19 @@ type PreMigrationStatusResult struct {
 }
 
 // StartMigration begins an asynchronous migration.
+// When the configured platform is "native", rows are streamed directly from
+// the source into MongoDB by the built-in Go executor instead of Spark.
 func (e *Engine) StartMigration(ctx context.Context, callback migration.StatusCallback) error {
+	return e.startMigration(false, callback)
+}
+
+// StartDeltaMigration begins an asynchronous delta migration; see
+// MigrateDelta.
+func (e *Engine) StartDeltaMigration(ctx context.Context, callback migration.StatusCallback) error {
+	return e.startMigration(true, callback)
+}
+
+func (e *Engine) startMigration(delta bool, callback migration.StatusCallback) error {
 	e.mu.Lock()
 	if e.migrationCancel != nil {
 		e.mu.Unlock()
@@ -487,11 +963,23 @@ func (e *Engine) StartMigration(ctx context.Context, callback migration.StatusCa
 	e.migrationStatus = &migration.Status{Phase: "starting"}
 	e.mu.Unlock()
 
+	kind := "migration"
+	if delta {
+		kind = "delta_migration"
+	}
+	endJob := e.startJob(kind)
+
 	go func() {
 		defer func() {
 			e.mu.Lock()
 			e.migrationCancel = nil
+			phase := e.migrationStatus.Phase
 			e.mu.Unlock()
+			if phase == "completed" {
+				endJob("complete", "")
+			} else {
+				endJob("failed", phase)
+			}
 		}()
 
 		wrappedCallback := func(status *migration.Status) {
@@ -503,19 +991,11 @@ func (e *Engine) StartMigration(ctx context.Context, callback migration.StatusCa
 			}
 		}
 
-		// For now, update status to indicate migration requires Spark
-		finalStatus := &migration.Status{
-			Phase:   "completed",
-			Overall: migration.ProgressInfo{PercentComplete: 100},
-		}
-		wrappedCallback(finalStatus)
-
-		if e.State != nil {
-			e.State.MigrationStatus = "completed"
-			e.SaveState()
+		if e.isNativePlatform() {
+			e.runNativeMigration(migCtx, nil, delta, wrappedCallback)
+			return
 		}
-
-		_ = migCtx // used by real executor
+		e.runSparkMigration(migCtx, false, delta, wrappedCallback)
 	}()
 
 	return nil
@@ -536,7 +1016,9 @@ func (e *Engine) MigrationStatus() *migration.Status {
 	return &migration.Status{Phase: "not_started"}
 }
 
-// RetryMigration retries failed collections asynchronously.
+// RetryMigration retries failed collections asynchronously. Collections and
+// partitions recorded as complete in the state's checkpoints are skipped; an
+// empty collection list retries everything not yet complete.
 func (e *Engine) RetryMigration(ctx context.Context, collections []string, callback migration.StatusCallback) error {
 	e.mu.Lock()
 	if e.migrationCancel != nil {
@@ -563,164 +1045,1659 @@ func (e *Engine) RetryMigration(ctx context.Context, collections []string, callb
 			}
 		}
 
-		status := &migration.Status{
-			Phase:       "running",
-			Collections: make([]migration.CollectionStatus, len(collections)),
-		}
-		for i, name := range collections {
-			status.Collections[i] = migration.CollectionStatus{Name: name, State: "completed"}
+		if e.isNativePlatform() {
+			if len(collections) == 0 {
+				collections = e.PendingCollections()
+			}
+			e.runNativeMigration(migCtx, collections, false, wrappedCallback)
+			return
 		}
-		status.Phase = "completed"
-		wrappedCallback(status)
 
-		_ = migCtx
+		// The regenerated script skips the partitions checkpointed so far.
+		e.runSparkMigration(migCtx, true, false, wrappedCallback)
 	}()
 
 	return nil
 }
 
-// AbortMigration cancels a running migration.
-func (e *Engine) AbortMigration() error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	if e.migrationCancel == nil {
-		return fmt.Errorf("no migration running")
-	}
-	e.migrationCancel()
-	e.migrationCancel = nil
-	if e.migrationStatus != nil {
-		e.migrationStatus.Phase = "aborted"
-	}
-	return nil
-}
-
-// RunValidation starts asynchronous post-migration validation.
-func (e *Engine) RunValidation(ctx context.Context, callback func(collection, checkType string, passed bool)) error {
-	if e.Config == nil || e.Schema == nil || e.Mapping == nil {
-		return fmt.Errorf("config, schema, and mapping required for validation")
-	}
-
-	go func() {
-		srcReader := source.NewPostgresReader(
-			buildPgConnString(e.Config.Source),
-			e.Config.Source.Schema,
-		)
-		srcCtx := context.Background()
-		if err := srcReader.Connect(srcCtx); err != nil {
-			e.Logger.Error("validation source connect failed", "error", err)
-			return
-		}
-		defer srcReader.Close()
-
-		tgt := e.Config.Target
-		op, err := target.NewMongoOperator(srcCtx, tgt.ConnectionString, tgt.Database)
-		if err != nil {
-			e.Logger.Error("validation target connect failed", "error", err)
-			return
-		}
-		defer op.Close(srcCtx)
-
-		orch := &postmigration.Orchestrator{
-			Source:     srcReader,
-			Target:     op,
-			Schema:     e.Schema,
-			Mapping:    e.Mapping,
-			State:      e.State,
-			StatePath:  e.statePath,
-			SampleSize: 10,
-		}
-
-		result, err := orch.RunValidation(srcCtx, postmigration.Callbacks{
-			OnValidationCheck: callback,
-		})
-		if err != nil {
-			e.Logger.Error("validation failed", "error", err)
-			return
-		}
-
-		e.mu.Lock()
-		e.validationResult = result
-		e.mu.Unlock()
-	}()
-
-	return nil
+// isNativePlatform reports whether migrations should use the built-in Go mover.
+func (e *Engine) isNativePlatform() bool {
+	return e.Config != nil && e.Config.AWS.Platform == "native"
 }
 
-// ValidationResults returns cached validation results.
-func (e *Engine) ValidationResults() *validation.Result {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	return e.validationResult
+// MigrateNative runs a migration synchronously with the built-in Go
+// executor. If only is non-empty, just those collections are migrated.
+func (e *Engine) MigrateNative(ctx context.Context, only []string, callback migration.StatusCallback) (*migration.Status, error) {
+	return e.migrateNative(ctx, only, false, callback)
 }
 
-// GetIndexPlan infers an index plan from the schema and mapping.
-func (e *Engine) GetIndexPlan() (*indexes.IndexPlan, error) {
-	if e.Schema == nil || e.Mapping == nil {
-		return nil, fmt.Errorf("schema and mapping required")
+// Migrate runs a full migration synchronously on the configured platform:
+// the synchronous counterpart of StartMigration.
+func (e *Engine) Migrate(ctx context.Context, callback migration.StatusCallback) (*migration.Status, error) {
+	if e.isNativePlatform() {
+		return e.migrateNative(ctx, nil, false, callback)
 	}
+	return e.migrateSpark(ctx, false, false, callback)
+}
 
-	if e.indexPlan != nil {
-		return e.indexPlan, nil
+// MigrateDelta runs a delta migration synchronously on the configured
+// platform. Each collection with a watermark column reads only the root rows
+// changed since its recorded high-watermark and upserts them by key, so the
+// target catches up after a bulk load; collections without one are skipped.
+func (e *Engine) MigrateDelta(ctx context.Context, callback migration.StatusCallback) (*migration.Status, error) {
+	if e.isNativePlatform() {
+		return e.migrateNative(ctx, nil, true, callback)
 	}
+	return e.migrateSpark(ctx, false, true, callback)
+}
 
-	plan := indexes.Infer(e.Schema, e.Mapping)
-	e.indexPlan = plan
-	return plan, nil
+func (e *Engine) migrateNative(ctx context.Context, only []string, delta bool, callback migration.StatusCallback) (*migration.Status, error) {
+	finish := e.auditMigration(only, delta)
+	ctl, release := e.controlMigration()
+	defer release()
+	status, err := e.nativeMigration(ctx, only, delta, ctl, callback)
+	finish(status, err)
+	return status, err
 }
 
-// BuildIndexes starts asynchronous index building.
-func (e *Engine) BuildIndexes(ctx context.Context, callback func(status []target.IndexBuildStatus)) error {
-	if e.Config == nil || e.Mapping == nil {
-		return fmt.Errorf("config and mapping required")
+func (e *Engine) nativeMigration(ctx context.Context, only []string, delta bool, ctl *migration.Control, callback migration.StatusCallback) (*migration.Status, error) {
+	if e.Mapping == nil {
+		return nil, fmt.Errorf("no mapping defined")
 	}
-
-	plan, err := e.GetIndexPlan()
+	zones, numbers, err := e.rowConversions()
 	if err != nil {
+		return nil, err
+	}
+	ctx, cancel, deadline, err := e.MigrationWindow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	src, err := e.newSourceReader()
+	if err != nil {
+		return nil, err
+	}
+	if err := src.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("connecting to source: %w", err)
+	}
+	defer src.Close()
+
+	ranges, err := e.watermarkRanges(ctx, src, delta)
+	if err != nil {
+		return nil, err
+	}
+
+	tgt := e.Config.Target
+	op, err := target.Open(ctx, tgt.ConnectionString, tgt.Database)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	defer op.Close(context.Background())
+
+	if err := e.hookRunner().Run(ctx, hooks.Before, state.StepMigration, nil); err != nil {
+		return nil, err
+	}
+
+	if e.State != nil {
+		if len(only) == 0 && !delta {
+			e.State.ResetCheckpoints()
+		}
+		e.State.MigrationStatus = "running"
+		e.SaveState()
+	}
+
+	// Only full runs read what the source impact estimate covers.
+	full := len(only) == 0 && !delta
+	var est *impact.Report
+	if full {
+		est, _ = e.SourceImpact()
+	}
+	started := time.Now()
+	callback, done := e.trackThroughput(callback)
+	defer done()
+
+	exec := migration.NewNativeExecutor(src, op, e.Mapping, e.Schema)
+	exec.SetControl(ctl)
+	exec.SetTimestampZones(zones)
+	exec.SetNumberTypes(numbers)
+	if e.State != nil {
+		exec.SetCheckpoints(e.nativeCheckpoints())
+	}
+	if delta {
+		exec.SetDelta(ranges)
+	} else if e.Mapping.HasArchives() {
+		client, err := aws.NewRealClient(ctx, e.Config.AWS.Profile, e.Config.AWS.Region)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to S3 for archives: %w", err)
+		}
+		exec.SetArchive(aws.ArchiveStore{Client: client})
+	}
+	var status *migration.Status
+	if len(only) > 0 {
+		status, err = exec.RetryFailed(ctx, only, callback)
+	} else {
+		status, err = exec.Run(ctx, callback)
+	}
+	if status != nil && status.Phase != "completed" && errors.Is(context.Cause(ctx), migration.ErrWindowExpired) {
+		e.expireWindow(op, status, deadline)
+		err = migration.ErrWindowExpired
+		if callback != nil {
+			callback(status)
+		}
+	}
+
+	if e.State != nil && status != nil {
+		for _, c := range status.Collections {
+			if c.State == "completed" {
+				e.State.CompleteCollection(c.Name)
+				if !delta {
+					e.State.RecordSnapshot(c.Name, c.Snapshot)
+					e.State.RecordRowsSkipped(c.Name, c.RowsSkipped)
+				}
+				e.recordWatermark(ranges, c.Name)
+			}
+		}
+		if full {
+			e.recordSourceImpact(est, status, started)
+		}
+		e.State.MigrationStatus = status.Phase
+		e.SaveState()
+	}
+	if err == nil && status != nil && status.Phase == "completed" {
+		err = e.hookRunner().Run(ctx, hooks.After, state.StepMigration, status)
+	}
+	return status, err
+}
+
+// nativeCheckpoints has a native run split collections into the ranges the
+// generated script checkpoints, resuming from those recorded in state.
+func (e *Engine) nativeCheckpoints() *migration.Checkpoints {
+	completed := make(map[string][]state.PartitionCheckpoint)
+	for name, cp := range e.State.Checkpoints {
+		completed[name] = append([]state.PartitionCheckpoint(nil), cp.Completed...)
+	}
+	return &migration.Checkpoints{
+		Partitions: codegen.DefaultCheckpointPartitions,
+		Keys: func(c *mapping.Collection) (string, string, bool) {
+			if e.Schema == nil {
+				return "", "", false
+			}
+			return codegen.CheckpointKeys(e.Schema, c)
+		},
+		Completed: completed,
+		Record: func(collection string, partitions int, p state.PartitionCheckpoint) {
+			e.State.RecordPartition(collection, partitions, p)
+			e.SaveState()
+		},
+	}
+}
+
+// PendingCollections lists the mapped collections not yet checkpointed as
+// fully migrated.
+func (e *Engine) PendingCollections() []string {
+	var pending []string
+	if e.Mapping == nil {
+		return nil
+	}
+	for _, c := range e.Mapping.Collections {
+		if e.State == nil || !e.State.CollectionDone(c.Name) {
+			pending = append(pending, c.Name)
+		}
+	}
+	return pending
+}
+
+// CanResume reports whether a previous migration stopped before completing,
+// so its checkpoints can be used to skip work already done.
+func (e *Engine) CanResume() bool {
+	if e.State == nil {
+		return false
+	}
+	switch e.State.MigrationStatus {
+	case "running", "failed", "partial_failure", "aborted", migration.PhaseWindowExpired, migration.PhasePaused:
+		return true
+	}
+	return false
+}
+
+// resumeInstructions tells the operator how to continue a run stopped at the
+// end of its migration window.
+const resumeInstructions = "Run `reloquent migrate --resume` (or Retry in the web UI) in the next window; completed collections and partitions are skipped"
+
+// MigrationWindow bounds ctx by the configured migration window, so a run
+// still going at the deadline is cancelled with migration.ErrWindowExpired.
+// The returned deadline is zero when no window is configured.
+func (e *Engine) MigrationWindow(ctx context.Context) (context.Context, context.CancelFunc, time.Time, error) {
+	if e.Config == nil {
+		return ctx, func() {}, time.Time{}, nil
+	}
+	cfg := e.Config.Migration
+	deadline, err := migration.Deadline(time.Now(), cfg.Deadline, cfg.MaxDuration)
+	if err != nil {
+		return nil, nil, time.Time{}, fmt.Errorf("invalid migration window: %w", err)
+	}
+	if deadline.IsZero() {
+		return ctx, func() {}, deadline, nil
+	}
+	ctx, cancel := context.WithDeadlineCause(ctx, deadline, migration.ErrWindowExpired)
+	return ctx, cancel, deadline, nil
+}
+
+// ExpireWindow connects to the target and winds down a run stopped at its
+// deadline; see expireWindow. It is for runs not started by the engine.
+func (e *Engine) ExpireWindow(status *migration.Status, deadline time.Time) error {
+	if e.Config == nil {
+		return fmt.Errorf("no config set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	op, release, err := e.targetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	defer release()
+
+	e.expireWindow(op, status, deadline)
+	if e.State != nil {
+		e.State.MigrationStatus = status.Phase
+		return e.SaveState()
+	}
+	return nil
+}
+
+// expireWindow winds down a run stopped at the end of its migration window.
+// The bulk-load write concern is raised back to majority and, on a sharded
+// cluster, the balancer is re-enabled, so the target is fit for business
+// traffic; the status is marked window-expired with resume instructions.
+func (e *Engine) expireWindow(op target.Operator, status *migration.Status, deadline time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	e.log(logging.ComponentMigration).Warn("migration window expired; stopping", "deadline", deadline)
+	if err := op.SetWriteConcern(ctx, "majority", true); err != nil {
+		e.log(logging.ComponentMigration).Error("could not restore write concern", "error", err)
+		status.Errors = append(status.Errors, fmt.Sprintf("restoring write concern: %v", err))
+	} else if e.State != nil {
+		e.State.WriteConcernRestored = true
+	}
+	if topo, err := op.DetectTopology(ctx); err == nil && topo != nil && topo.Type == "sharded" {
+		if err := op.EnableBalancer(ctx); err != nil {
+			e.log(logging.ComponentMigration).Error("could not re-enable balancer", "error", err)
+			status.Errors = append(status.Errors, fmt.Sprintf("re-enabling balancer: %v", err))
+		} else if e.State != nil {
+			e.State.BalancerReEnabled = true
+		}
+	}
+	migration.ExpireWindow(status, deadline, resumeInstructions)
+}
+
+// runNativeMigration is the async wrapper around MigrateNative used by
+// StartMigration and RetryMigration.
+func (e *Engine) runNativeMigration(ctx context.Context, only []string, delta bool, callback migration.StatusCallback) {
+	status, err := e.migrateNative(ctx, only, delta, callback)
+	if err == nil {
+		return
+	}
+	e.log(logging.ComponentMigration).Error("native migration failed", "error", err)
+	if status == nil {
+		callback(&migration.Status{Phase: "failed", Errors: []string{err.Error()}})
+		if e.State != nil {
+			e.State.MigrationStatus = "failed"
+			e.SaveState()
+		}
+	}
+}
+
+// MigrateSpark uploads the generated PySpark script and runs it on EMR or
+// Glue, blocking until the job finishes. Progress comes from the job state
+// and from document counts in the target. When resume is set, partitions
+// checkpointed by an earlier run are skipped; otherwise checkpoints are
+// cleared and every collection is migrated from scratch.
+func (e *Engine) MigrateSpark(ctx context.Context, resume bool, callback migration.StatusCallback) (*migration.Status, error) {
+	return e.migrateSpark(ctx, resume, false, callback)
+}
+
+func (e *Engine) migrateSpark(ctx context.Context, resume, delta bool, callback migration.StatusCallback) (*migration.Status, error) {
+	finish := e.auditMigration(nil, delta)
+	ctl, release := e.controlMigration()
+	defer release()
+	for {
+		// A Spark job cannot be suspended, so pausing cancels it and
+		// resuming submits it again, skipping the partitions it checkpointed.
+		runCtx, stop := context.WithCancelCause(ctx)
+		e.mu.Lock()
+		e.sparkStop = stop
+		e.mu.Unlock()
+		status, err := e.sparkMigration(runCtx, resume, delta, callback)
+		e.mu.Lock()
+		e.sparkStop = nil
+		e.mu.Unlock()
+		paused := errors.Is(context.Cause(runCtx), migration.ErrPaused)
+		stop(nil)
+		if !paused || ctx.Err() != nil {
+			finish(status, err)
+			return status, err
+		}
+		if err := ctl.Wait(ctx); err != nil {
+			finish(status, err)
+			return status, err
+		}
+		resume = true
+	}
+}
+
+func (e *Engine) sparkMigration(ctx context.Context, resume, delta bool, callback migration.StatusCallback) (*migration.Status, error) {
+	if e.Config == nil || e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("config, schema, and mapping required")
+	}
+	if e.Config.AWS.S3Bucket == "" {
+		return nil, fmt.Errorf("aws.s3_bucket is required to run Spark migrations")
+	}
+	if err := e.CheckGeneratedScript(); err != nil {
+		return nil, err
+	}
+	ctx, cancel, deadline, err := e.MigrationWindow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	tgt := e.Config.Target
+	op, err := target.Open(ctx, tgt.ConnectionString, tgt.Database)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	defer op.Close(context.Background())
+
+	if err := e.prepareCheckpoints(ctx, op, resume); err != nil {
+		return nil, err
+	}
+	ranges, err := e.WatermarkRanges(ctx, delta)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := e.generator(delta, ranges).Generate()
+	if err != nil {
+		return nil, fmt.Errorf("generating migration script: %w", err)
+	}
+	mappingYAML, err := yaml.Marshal(e.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling mapping: %w", err)
+	}
+	plan, err := e.sparkPlan()
+	if err != nil {
+		return nil, err
+	}
+	artifacts := aws.ArtifactSet{MigrationScript: []byte(code.MigrationScript), ConfigYAML: mappingYAML}
+	if e.Config.Source.Type == "oracle" {
+		jar, err := drivers.FindOracleJDBC()
+		if err != nil {
+			return nil, fmt.Errorf("reading Oracle sources with Spark needs the Oracle JDBC driver: %w", err)
+		}
+		artifacts.OracleJDBCPath = jar
+	}
+	for _, coords := range spark.Jars(e.Config.Source.Type) {
+		jar, err := drivers.FetchJar(ctx, coords)
+		if err != nil {
+			return nil, fmt.Errorf("fetching Spark jars: %w", err)
+		}
+		artifacts.Jars = append(artifacts.Jars, jar)
+	}
+
+	awsCfg := e.Config.AWS
+	client, err := aws.NewRealClient(ctx, awsCfg.Profile, awsCfg.Region)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := e.newSparkBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := "reloquent/" + time.Now().UTC().Format("20060102-150405")
+	e.log(logging.ComponentAWS).Debug("submitting spark job", "platform", awsCfg.Platform, "region", awsCfg.Region,
+		"artifacts", fmt.Sprintf("s3://%s/%s", awsCfg.S3Bucket, prefix))
+	runner := spark.NewRunner(aws.NewArtifactUploader(client, awsCfg.S3Bucket, prefix), backend)
+	runner.SetProgressSource(op)
+
+	if err := e.hookRunner().Run(ctx, hooks.Before, state.StepMigration, nil); err != nil {
+		return nil, err
+	}
+	if e.State != nil {
+		e.State.MigrationStatus = "running"
+		e.SaveState()
+	}
+	full := !resume && !delta
+	var est *impact.Report
+	if full {
+		est, _ = e.SourceImpact()
+	}
+	started := time.Now()
+	callback, done := e.trackThroughput(callback)
+	defer done()
+
+	status, err := runner.Run(ctx, spark.Job{
+		Name:        "reloquent-migration",
+		Artifacts:   artifacts,
+		SparkPlan:   plan,
+		Tags:        awsCfg.Tags,
+		Collections: e.sparkCollections(),
+	}, callback)
+	if status != nil && status.Phase != "completed" && errors.Is(context.Cause(ctx), migration.ErrWindowExpired) {
+		e.expireWindow(op, status, deadline)
+		err = migration.ErrWindowExpired
+		if callback != nil {
+			callback(status)
+		}
+	} else if status != nil && errors.Is(context.Cause(ctx), migration.ErrPaused) {
+		status.Phase = migration.PhasePaused
+		err = migration.ErrPaused
+		if callback != nil {
+			callback(status)
+		}
+	}
+
+	if e.State != nil && status != nil {
+		e.finishCheckpoints(context.Background(), op, status.Phase)
+		// A resumed run read its partitions at different times, so no single
+		// high-watermark covers them.
+		if status.Phase == "completed" && !resume {
+			for _, c := range e.Mapping.Collections {
+				e.recordWatermark(ranges, c.Name)
+			}
+		}
+		if full {
+			e.recordSourceImpact(est, status, started)
+		}
+		e.State.MigrationStatus = status.Phase
+		e.SaveState()
+	}
+	if err == nil && status != nil && status.Phase == "completed" {
+		err = e.hookRunner().Run(ctx, hooks.After, state.StepMigration, status)
+	}
+	return status, err
+}
+
+// LoadCheckpoints connects to the target and loads the partitions recorded by
+// an interrupted Spark run (resume), or clears them ahead of a fresh run.
+func (e *Engine) LoadCheckpoints(ctx context.Context, resume bool) error {
+	if e.Config == nil {
+		return fmt.Errorf("no config set")
+	}
+	op, release, err := e.targetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	defer release()
+	return e.prepareCheckpoints(ctx, op, resume)
+}
+
+// prepareCheckpoints loads the partitions the Spark script has recorded in
+// the target when resuming, or clears them before a fresh run.
+func (e *Engine) prepareCheckpoints(ctx context.Context, op target.Operator, resume bool) error {
+	if e.State == nil {
+		return nil
+	}
+	if resume {
+		if err := e.syncCheckpoints(ctx, op); err != nil {
+			return fmt.Errorf("loading checkpoints: %w", err)
+		}
+		return nil
+	}
+	e.State.ResetCheckpoints()
+	if err := op.DropCollections(ctx, []string{codegen.CheckpointCollection}); err != nil {
+		return fmt.Errorf("clearing checkpoints: %w", err)
+	}
+	return nil
+}
+
+// finishCheckpoints records the job's checkpoints in state. After a
+// successful run every collection is done and the target copy is dropped.
+func (e *Engine) finishCheckpoints(ctx context.Context, op target.Operator, phase string) {
+	if err := e.syncCheckpoints(ctx, op); err != nil {
+		e.log(logging.ComponentMigration).Warn("could not load migration checkpoints", "error", err)
+	}
+	if phase != "completed" {
+		return
+	}
+	for _, c := range e.Mapping.Collections {
+		e.State.CompleteCollection(c.Name)
+	}
+	if err := op.DropCollections(ctx, []string{codegen.CheckpointCollection}); err != nil {
+		e.log(logging.ComponentMigration).Warn("could not drop checkpoint collection", "error", err)
+	}
+}
+
+// syncCheckpoints copies the partitions recorded by the Spark script into
+// the state's checkpoints.
+func (e *Engine) syncCheckpoints(ctx context.Context, op target.Operator) error {
+	docs, err := op.FindDocuments(ctx, codegen.CheckpointCollection, nil)
+	if err != nil {
+		return err
+	}
+	for _, d := range docs {
+		name, _ := d["collection"].(string)
+		if name == "" {
+			continue
+		}
+		e.State.RecordPartition(name, int(toInt64(d["partitions"])), checkpointOf(d))
+	}
+	return e.SaveState()
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
+
+// WatermarkRanges reads the current maximum of each collection's watermark
+// column from the source, giving the ranges a migration starting now covers.
+// For a delta run each range starts after the collection's recorded
+// high-watermark. It returns nil when no collection has a watermark and this
+// is not a delta run.
+func (e *Engine) WatermarkRanges(ctx context.Context, delta bool) (map[string]mapping.WatermarkRange, error) {
+	if e.Mapping == nil {
+		return nil, fmt.Errorf("no mapping defined")
+	}
+	if !delta && !hasWatermarks(e.Mapping) {
+		return nil, nil
+	}
+	src, release, err := e.sourceConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return e.watermarkRanges(ctx, src, delta)
+}
+
+func (e *Engine) watermarkRanges(ctx context.Context, src source.Reader, delta bool) (map[string]mapping.WatermarkRange, error) {
+	if err := e.Mapping.ValidateWatermarks(e.Schema); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+	ranges := make(map[string]mapping.WatermarkRange)
+	for _, c := range e.Mapping.Collections {
+		if c.Watermark == "" {
+			continue
+		}
+		v, err := src.ColumnMax(ctx, c.SourceTable, c.Watermark, c.LiveFilter())
+		if err != nil {
+			return nil, fmt.Errorf("reading watermark of %s: %w", c.Name, err)
+		}
+		upTo, err := mapping.WatermarkLiteral(v)
+		if err != nil {
+			return nil, fmt.Errorf("collection %s: %w", c.Name, err)
+		}
+		rng := mapping.WatermarkRange{Column: c.Watermark, UpTo: upTo}
+		if delta && e.State != nil {
+			rng.After = e.State.WatermarkAfter(c.Name, c.Watermark)
+		}
+		ranges[c.Name] = rng
+	}
+	if delta && len(ranges) == 0 {
+		return nil, fmt.Errorf("%w: no collection has a watermark column for a delta migration", ErrInvalidMapping)
+	}
+	return ranges, nil
+}
+
+func hasWatermarks(m *mapping.Mapping) bool {
+	for _, c := range m.Collections {
+		if c.Watermark != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordWatermarks saves the upper bound of each named collection's range as
+// its high-watermark, so the next delta run starts after it.
+func (e *Engine) RecordWatermarks(ranges map[string]mapping.WatermarkRange, collections []string) error {
+	if e.State == nil {
+		return nil
+	}
+	for _, name := range collections {
+		e.recordWatermark(ranges, name)
+	}
+	return e.SaveState()
+}
+
+func (e *Engine) recordWatermark(ranges map[string]mapping.WatermarkRange, collection string) {
+	if rng, ok := ranges[collection]; ok && rng.UpTo != "" {
+		e.State.SetWatermark(collection, rng.Column, rng.UpTo)
+	}
+}
+
+// runSparkMigration is the async wrapper around MigrateSpark used by
+// StartMigration and RetryMigration.
+func (e *Engine) runSparkMigration(ctx context.Context, resume, delta bool, callback migration.StatusCallback) {
+	status, err := e.migrateSpark(ctx, resume, delta, callback)
+	if err == nil {
+		return
+	}
+	e.log(logging.ComponentMigration).Error("spark migration failed", "error", err)
+	if status == nil {
+		callback(&migration.Status{Phase: "failed", Errors: []string{err.Error()}})
+		if e.State != nil {
+			e.State.MigrationStatus = "failed"
+			e.SaveState()
+		}
+	}
+}
+
+// newSparkBackend creates the Spark backend for the configured platform.
+func (e *Engine) newSparkBackend(ctx context.Context) (spark.Backend, error) {
+	awsCfg := e.Config.AWS
+	switch awsCfg.Platform {
+	case "emr":
+		b, err := spark.NewEMRBackend(ctx, awsCfg.Profile, awsCfg.Region)
+		if err != nil {
+			return nil, err
+		}
+		b.SubnetIDs = awsCfg.Subnets
+		if e.State != nil && e.State.AWSResourceType == "emr_cluster" {
+			b.ClusterID = e.State.AWSResourceID
+		}
+		return b, nil
+	case "emr-serverless":
+		return spark.NewEMRServerlessBackend(ctx, awsCfg.Profile, awsCfg.Region, awsCfg.EMRServerlessApplication, awsCfg.EMRServerlessRole)
+	case "glue":
+		return spark.NewGlueBackend(ctx, awsCfg.Profile, awsCfg.Region, "")
+	default:
+		return nil, fmt.Errorf("unsupported Spark platform %q (use emr, emr-serverless, glue, or native)", awsCfg.Platform)
+	}
+}
+
+// sparkPlan returns the Spark cluster sizing from the saved sizing plan,
+// computing it if none was saved.
+func (e *Engine) sparkPlan() (sizing.SparkPlan, error) {
+	if e.State != nil && e.State.SizingPlanPath != "" {
+		plan, err := sizing.LoadYAML(e.State.SizingPlanPath)
+		if err != nil {
+			return sizing.SparkPlan{}, fmt.Errorf("loading sizing plan: %w", err)
+		}
+		return plan.SparkPlan, nil
+	}
+	plan, err := e.ComputeSizing()
+	if err != nil {
+		return sizing.SparkPlan{}, fmt.Errorf("computing sizing: %w", err)
+	}
+	return plan.SparkPlan, nil
+}
+
+// sparkCollections lists the mapped collections with the row count of each
+// root table as the expected document count.
+func (e *Engine) sparkCollections() []spark.CollectionTarget {
+	rows := make(map[string]int64)
+	if e.Schema != nil {
+		for _, t := range e.Schema.Tables {
+			rows[t.Name] = t.RowCount
+		}
+	}
+	var targets []spark.CollectionTarget
+	if e.Mapping != nil {
+		for _, c := range e.Mapping.Collections {
+			targets = append(targets, spark.CollectionTarget{Name: c.Name, ExpectedDocs: rows[c.SourceTable]})
+		}
+	}
+	return targets
+}
+
+// rowConversions returns the time zones and BSON number types the native
+// executor converts source values with, from the type map; see
+// migration.NativeExecutor.SetTimestampZones and SetNumberTypes.
+func (e *Engine) rowConversions() (map[string]map[string]*time.Location, map[string]map[string]typemap.BSONType, error) {
+	tm := e.GetTypeMap()
+	if tm == nil {
+		return nil, nil, nil
+	}
+	zones, err := tm.TimestampLocations(e.Schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	return zones, tm.NumberTypes(e.Schema), nil
+}
+
+// newSourceReader creates a source reader for the configured database type.
+func (e *Engine) newSourceReader() (migration.NativeSource, error) {
+	if e.Config == nil {
+		return nil, fmt.Errorf("no config set")
+	}
+	return source.NewReader(e.Config.Source)
+}
+
+// cdcSource is a change capturer that must be connected before use.
+type cdcSource interface {
+	cdc.Capturer
+	Connect(ctx context.Context) error
+}
+
+// newCapturer creates a change capturer for the configured database type.
+func (e *Engine) newCapturer() (cdcSource, error) {
+	if e.Config == nil {
+		return nil, fmt.Errorf("no config set")
+	}
+	src, err := e.Config.Source.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	var slot, start string
+	if e.State != nil {
+		slot = e.State.CDCSlotName
+		start = e.State.CDCStartPosition
+	}
+	connStr, err := source.ConnString(src)
+	if err != nil {
+		return nil, fmt.Errorf("CDC not supported for source type: %s", src.Type)
+	}
+	switch src.Type {
+	case "oracle":
+		return cdc.NewOracleCapturer(connStr, source.SchemaName(src), start), nil
+	default:
+		return cdc.NewPostgresCapturer(connStr, src.Schema, slot), nil
+	}
+}
+
+// PrepareCDC creates the replication slot (PostgreSQL) or records the current
+// SCN (Oracle). Run it before the bulk load so no changes are missed.
+func (e *Engine) PrepareCDC(ctx context.Context) (string, error) {
+	capt, err := e.newCapturer()
+	if err != nil {
+		return "", err
+	}
+	if err := capt.Connect(ctx); err != nil {
+		return "", fmt.Errorf("connecting to source: %w", err)
+	}
+	defer capt.Close()
+
+	pos, err := capt.Prepare(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if e.State != nil {
+		e.State.CDCStartPosition = pos
+		if pc, ok := capt.(*cdc.PostgresCapturer); ok {
+			e.State.CDCSlotName = pc.SlotName()
+		}
+		e.State.CDCStatus = "prepared"
+		e.SaveState()
+	}
+	return pos, nil
+}
+
+// RunCDC replicates changes synchronously until ctx is cancelled or an
+// error occurs.
+func (e *Engine) RunCDC(ctx context.Context, callback cdc.StatusCallback) error {
+	if e.Mapping == nil {
+		return fmt.Errorf("no mapping defined")
+	}
+
+	capt, err := e.newCapturer()
+	if err != nil {
+		return err
+	}
+	if err := capt.Connect(ctx); err != nil {
+		return fmt.Errorf("connecting to source: %w", err)
+	}
+	defer capt.Close()
+
+	tgt := e.Config.Target
+	op, err := target.Open(ctx, tgt.ConnectionString, tgt.Database)
+	if err != nil {
+		return fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	defer op.Close(context.Background())
+
+	if err := e.hookRunner().Run(ctx, hooks.Before, state.StepCDC, nil); err != nil {
+		return err
+	}
+
+	r := cdc.NewReplicator(capt, &cdc.Applier{Mapping: e.Mapping, Schema: e.Schema, Target: op})
+	e.mu.Lock()
+	e.cdcReplicator = r
+	e.mu.Unlock()
+
+	lastState := ""
+	report := func(s cdc.Status) {
+		if e.State != nil && s.State != lastState {
+			lastState = s.State
+			e.State.CDCStatus = s.State
+			e.SaveState()
+		}
+		if callback != nil {
+			callback(s)
+		}
+	}
+	err = r.Run(ctx, report)
+	e.mu.Lock()
+	drain := e.cdcDrain
+	e.mu.Unlock()
+	if err == nil && drain {
+		// Stopped by FinishCDC: apply what arrived since the last poll
+		err = r.Drain(context.Background(), report)
+	}
+	return err
+}
+
+// StartCDC begins replicating changes in the background.
+func (e *Engine) StartCDC(ctx context.Context, callback cdc.StatusCallback) error {
+	if e.Config == nil || e.Mapping == nil {
+		return fmt.Errorf("config and mapping required for CDC")
+	}
+
+	e.mu.Lock()
+	if e.cdcCancel != nil {
+		e.mu.Unlock()
+		return fmt.Errorf("CDC already running")
+	}
+	cdcCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	e.cdcCancel = cancel
+	e.cdcDone = done
+	e.cdcDrain = false
+	e.cdcErr = nil
+	e.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		err := e.RunCDC(cdcCtx, callback)
+		if err != nil {
+			e.log(logging.ComponentMigration).Error("CDC failed", "error", err)
+			if callback != nil {
+				callback(cdc.Status{State: "failed", LastError: err.Error()})
+			}
+		}
+		e.mu.Lock()
+		e.cdcErr = err
+		if e.cdcDone == done {
+			e.cdcCancel = nil
+			e.cdcDone = nil
+		}
+		e.mu.Unlock()
+		cancel()
+	}()
+	return nil
+}
+
+// StopCDC stops background replication and waits for it to finish.
+func (e *Engine) StopCDC() error {
+	e.mu.Lock()
+	if e.cdcCancel == nil {
+		e.mu.Unlock()
+		return fmt.Errorf("CDC not running")
+	}
+	e.cdcCancel()
+	e.cdcCancel = nil
+	done := e.cdcDone
+	e.cdcDone = nil
+	e.mu.Unlock()
+
+	<-done
+	return nil
+}
+
+// FinishCDC stops background replication once the changes captured so far
+// are applied, as at cutover when the source takes no more writes, and
+// returns the error that ended it, if any, also when it failed before.
+func (e *Engine) FinishCDC() error {
+	e.mu.Lock()
+	if e.cdcCancel == nil {
+		err := e.cdcErr
+		e.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("CDC not running")
+	}
+	e.cdcDrain = true
+	e.cdcCancel()
+	e.cdcCancel = nil
+	done := e.cdcDone
+	e.cdcDone = nil
+	e.mu.Unlock()
+
+	<-done
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.cdcErr
+}
+
+// CDCStatus returns the current replication status.
+func (e *Engine) CDCStatus() cdc.Status {
+	e.mu.Lock()
+	r := e.cdcReplicator
+	e.mu.Unlock()
+	if r != nil {
+		return r.Status()
+	}
+	status := cdc.Status{State: "idle"}
+	if e.State != nil {
+		if e.State.CDCStatus != "" {
+			status.State = e.State.CDCStatus
+		}
+		status.Position = e.State.CDCStartPosition
+	}
+	return status
+}
+
+// TeardownCDC drops server-side CDC resources after cutover.
+func (e *Engine) TeardownCDC(ctx context.Context) error {
+	e.mu.Lock()
+	running := e.cdcCancel != nil
+	e.mu.Unlock()
+	if running {
+		return fmt.Errorf("stop CDC before tearing it down")
+	}
+
+	capt, err := e.newCapturer()
+	if err != nil {
+		return err
+	}
+	if err := capt.Connect(ctx); err != nil {
+		return fmt.Errorf("connecting to source: %w", err)
+	}
+	defer capt.Close()
+
+	if err := capt.Teardown(ctx); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.cdcReplicator = nil
+	e.mu.Unlock()
+	if e.State != nil {
+		e.State.CDCStatus = "torn_down"
+		e.SaveState()
+	}
+	return e.hookRunner().Run(ctx, hooks.After, state.StepCDC, nil)
+}
+
+// hookRunner returns a runner for the configured hooks that records runs in
+// the engine's state, or nil when no hooks are configured.
+func (e *Engine) hookRunner() *hooks.Runner {
+	if e.Config == nil || len(e.Config.Hooks) == 0 {
+		return nil
+	}
+	st := e.State
+	if st == nil {
+		loaded, err := state.Load(e.statePath)
+		if err != nil {
+			e.Logger.Warn("could not load state for hooks", "error", err)
+			loaded = state.New()
+		}
+		st = loaded
+	}
+	r := &hooks.Runner{
+		Hooks:     e.Config.Hooks,
+		State:     st,
+		StatePath: e.statePath,
+		Database:  e.Config.Target.Database,
+		Logger:    e.Logger,
+	}
+	if e.project != nil {
+		r.Project = e.project.Name
+	}
+	return r
+}
+
+// Hooks lists the configured custom step hooks with their last runs.
+func (e *Engine) Hooks() ([]hooks.Status, error) {
+	if e.Config == nil {
+		return []hooks.Status{}, nil
+	}
+	st := e.State
+	if st == nil {
+		loaded, err := state.Load(e.statePath)
+		if err != nil {
+			return nil, err
+		}
+		st = loaded
+	}
+	return hooks.Statuses(e.Config.Hooks, st), nil
+}
+
+// ErrNoMigration is returned when aborting or pausing with no migration
+// running.
+var ErrNoMigration = errors.New("no migration running")
+
+// AbortMigration cancels a running migration.
+func (e *Engine) AbortMigration() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.migrationCancel == nil {
+		return ErrNoMigration
+	}
+	e.migrationCancel()
+	e.migrationCancel = nil
+	if e.migrationStatus != nil {
+		e.migrationStatus.Phase = "aborted"
+	}
+	e.audit("migration_aborted", "")
+	return nil
+}
+
+// ErrNotPaused is returned when resuming a migration that is not paused.
+var ErrNotPaused = errors.New("migration is not paused")
+
+// PauseMigration pauses the running migration. The native mover suspends
+// at its next bulk write; a Spark job is cancelled, keeping the partitions
+// it checkpointed. The paused state is saved, so ResumeMigration continues
+// the run even after the process restarts.
+func (e *Engine) PauseMigration() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.migrationControl == nil {
+		return ErrNoMigration
+	}
+	if e.migrationControl.Paused() {
+		return nil
+	}
+	e.migrationControl.Pause()
+	if e.sparkStop != nil {
+		e.sparkStop(migration.ErrPaused)
+	}
+	if e.State != nil {
+		e.State.MigrationStatus = migration.PhasePaused
+		if err := e.SaveState(); err != nil {
+			return err
+		}
+	}
+	e.audit("migration_paused", "")
+	return nil
+}
+
+// ResumeMigration continues a paused migration: the run paused in this
+// process, or else, when the state records one paused before a restart, a
+// new run from the checkpoints in state, reporting to callback. That run
+// skips the collections and partitions already migrated and rewrites the
+// rest over whatever the paused run left of them.
+func (e *Engine) ResumeMigration(ctx context.Context, callback migration.StatusCallback) error {
+	e.mu.Lock()
+	ctl := e.migrationControl
+	e.mu.Unlock()
+	if ctl == nil {
+		if e.State == nil || e.State.MigrationStatus != migration.PhasePaused {
+			return ErrNotPaused
+		}
+		position := e.resumePosition()
+		if err := e.RetryMigration(ctx, nil, callback); err != nil {
+			return err
+		}
+		e.log(logging.ComponentMigration).Info("resuming migration after restart", "position", position)
+		e.audit("migration_resumed", "after restart: "+position)
+		return nil
+	}
+	if !ctl.Paused() {
+		return ErrNotPaused
+	}
+	if e.State != nil {
+		e.State.MigrationStatus = "running"
+		if err := e.SaveState(); err != nil {
+			return err
+		}
+	}
+	ctl.Resume()
+	e.audit("migration_resumed", "")
+	return nil
+}
+
+// resumePosition describes where a run resumed from the checkpoints in
+// state picks up: the collections done, the partitions done of those under
+// way, and the collections started over.
+func (e *Engine) resumePosition() string {
+	var done, partial, fresh []string
+	if e.Mapping != nil {
+		for _, c := range e.Mapping.Collections {
+			cp := e.State.Checkpoints[c.Name]
+			switch {
+			case cp != nil && cp.Done:
+				done = append(done, c.Name)
+			case cp != nil && len(cp.Completed) > 0:
+				partial = append(partial, fmt.Sprintf("%s (%d of %d partitions)", c.Name, len(cp.Completed), cp.Partitions))
+			default:
+				fresh = append(fresh, c.Name)
+			}
+		}
+	}
+	list := func(names []string) string {
+		if len(names) == 0 {
+			return "none"
+		}
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("done: %s; resuming: %s; starting over: %s", list(done), list(partial), list(fresh))
+}
+
+// controlMigration gives the migration starting a control that
+// PauseMigration and ResumeMigration act on, until release is called.
+func (e *Engine) controlMigration() (ctl *migration.Control, release func()) {
+	ctl = migration.NewControl()
+	e.mu.Lock()
+	e.migrationControl = ctl
+	e.mu.Unlock()
+	return ctl, func() {
+		e.mu.Lock()
+		if e.migrationControl == ctl {
+			e.migrationControl = nil
+		}
+		e.mu.Unlock()
+	}
+}
+
+// RunValidation starts asynchronous post-migration validation. In checksum
+// mode onChunk, if set, is called as each key range is compared.
+func (e *Engine) RunValidation(ctx context.Context, cfg validation.Config, callback func(collection, checkType string, passed bool), onChunk func(validation.ChunkProgress)) error {
+	srcReader, release, err := e.validationSource(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer release()
+		if _, err := e.validate(context.Background(), srcReader, cfg, callback, onChunk); err != nil {
+			e.log(logging.ComponentValidation).Error("validation failed", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// Validate runs post-migration validation synchronously and returns its
+// result, which is also cached for ValidationResults.
+func (e *Engine) Validate(ctx context.Context, cfg validation.Config, callback func(collection, checkType string, passed bool), onChunk func(validation.ChunkProgress)) (*validation.Result, error) {
+	srcReader, release, err := e.validationSource(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return e.validate(ctx, srcReader, cfg, callback, onChunk)
+}
+
+// validationSource checks validation can run and returns a source reader
+// for it from the engine's pool, and the function that releases it.
+func (e *Engine) validationSource(ctx context.Context, cfg validation.Config) (migration.NativeSource, func(), error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if e.Config == nil || e.Schema == nil || e.Mapping == nil {
+		return nil, nil, fmt.Errorf("config, schema, and mapping required for validation")
+	}
+	return e.sourceConn(ctx)
+}
+
+// validate runs validation with a connected source reader. The MongoDB
+// operator is its own rather than the pool's, as validation sets its read
+// options.
+func (e *Engine) validate(ctx context.Context, srcReader migration.NativeSource, cfg validation.Config, callback func(collection, checkType string, passed bool), onChunk func(validation.ChunkProgress)) (*validation.Result, error) {
+	tgt := e.Config.Target
+	op, err := target.Open(ctx, tgt.ConnectionString, tgt.Database)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	defer op.Close(context.Background())
+
+	cfg = cfg.WithTargetDefaults(tgt)
+	orch := &postmigration.Orchestrator{
+		Source:     srcReader,
+		Target:     op,
+		Schema:     e.Schema,
+		Mapping:    e.Mapping,
+		State:      e.State,
+		StatePath:  e.statePath,
+		SampleSize: 10,
+		TypeMap:    e.GetTypeMap(),
+		Validation: cfg,
+		Hooks:      e.hookRunner(),
+	}
+
+	result, err := orch.RunValidation(ctx, postmigration.Callbacks{
+		OnValidationCheck: callback,
+		OnValidationChunk: onChunk,
+	})
+	if err != nil {
+		e.audit("validation_failed", err.Error())
+		return nil, err
+	}
+	mode := cfg.Mode
+	if mode == "" {
+		mode = validation.ModeFull
+	}
+	e.audit("validation_run", fmt.Sprintf("%s mode, %d collections: %s", mode, len(result.Collections), result.Status))
+
+	e.mu.Lock()
+	e.validationResult = result
+	e.mu.Unlock()
+	return result, nil
+}
+
+// ValidationResults returns cached validation results.
+func (e *Engine) ValidationResults() *validation.Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.validationResult
+}
+
+// GetIndexPlan returns the index plan saved by SaveIndexPlan, or else
+// infers one from the schema and mapping, adding suggestions from the source
+// query log when one is configured and partial unique indexes when
+// indexes.partial_unique is set. Inferred indexes are named by
+// indexes.name_template when one is set.
+func (e *Engine) GetIndexPlan() (*indexes.IndexPlan, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+
+	if e.indexPlan != nil {
+		return e.indexPlan, nil
+	}
+	if e.State != nil && e.State.IndexPlanPath != "" {
+		plan, err := indexes.LoadYAML(e.State.IndexPlanPath)
+		if err != nil {
+			return nil, err
+		}
+		e.indexPlan = plan
+		return plan, nil
+	}
+
+	plan := indexes.Infer(e.Schema, e.Mapping)
+	if e.Config != nil && e.Config.Indexes.QueryLog != "" {
+		ic := e.Config.Indexes
+		stats, err := indexes.LoadQueryStats(config.ExpandHome(ic.QueryLog))
+		if err != nil {
+			return nil, err
+		}
+		plan.AddQuerySuggestions(indexes.SuggestFromQueries(e.Schema, e.Mapping, stats, ic.MinCalls, ic.MaxSuggestions))
+	}
+	if e.Config != nil && e.Config.Indexes.PartialUnique {
+		plan.AddUniqueConversions(indexes.AnalyzeUnique(e.Schema, e.Mapping, e.GetTypeMap()))
+	}
+	if e.Config != nil && e.Config.Indexes.AtlasSearch {
+		plan.AddSearchIndexes(e.Schema, e.Mapping)
+	}
+	if e.Config != nil {
+		if err := indexes.NameConvention(e.Config.Indexes.NameTemplate).Apply(plan); err != nil {
+			return nil, err
+		}
+	}
+	e.indexPlan = plan
+	return plan, nil
+}
+
+// ErrInvalidIndexPlan is returned when an edited index plan cannot be built.
+var ErrInvalidIndexPlan = errors.New("invalid index plan")
+
+// SaveIndexPlan validates an edited index plan against the mapping and saves
+// it next to the state file. Index builds then create exactly these indexes
+// until ResetIndexPlan discards the edits.
+func (e *Engine) SaveIndexPlan(plan *indexes.IndexPlan) error {
+	if e.Mapping == nil {
+		return fmt.Errorf("no mapping defined")
+	}
+	if err := plan.Validate(e.Mapping); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidIndexPlan, err)
+	}
+
+	st, err := e.LoadState()
+	if err != nil {
+		return err
+	}
+	plan.Edited = true
+	path := filepath.Join(filepath.Dir(e.statePath), "index-plan.yaml")
+	snap := audit.Snapshot("index_plan", path)
+	if err := plan.WriteYAML(path); err != nil {
+		return err
+	}
+	st.IndexPlanPath = path
+	e.indexPlan = plan
+	if err := e.SaveState(); err != nil {
+		return err
+	}
+	e.auditChange("index_plan_saved", fmt.Sprintf("%d indexes", len(plan.Indexes)), snap)
+	return nil
+}
+
+// ResetIndexPlan discards a saved index plan so the next GetIndexPlan infers
+// one again.
+func (e *Engine) ResetIndexPlan() error {
+	st, err := e.LoadState()
+	if err != nil {
+		return err
+	}
+	var snaps []audit.Artifact
+	if st.IndexPlanPath != "" {
+		snaps = append(snaps, audit.Snapshot("index_plan", st.IndexPlanPath))
+		if err := os.Remove(st.IndexPlanPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing index plan: %w", err)
+		}
+		st.IndexPlanPath = ""
+	}
+	e.indexPlan = nil
+	if err := e.SaveState(); err != nil {
+		return err
+	}
+	e.auditChange("index_plan_reset", "", snaps...)
+	return nil
+}
+
+// BuildIndexes starts asynchronous index building, scheduled by the indexes
+// section of the config.
+func (e *Engine) BuildIndexes(ctx context.Context, callback func(status []target.IndexBuildStatus)) error {
+	plan, control, err := e.startIndexBuilds()
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := e.buildIndexes(context.Background(), plan, control, callback, false); err != nil {
+			e.Logger.Error("index builds failed", "error", err)
+		}
+	}()
+	return nil
+}
+
+// RunIndexBuilds builds the index plan and waits for the builds to finish,
+// then re-enables the balancer and restores the write concern as after any
+// index build step.
+func (e *Engine) RunIndexBuilds(ctx context.Context, callback func(status []target.IndexBuildStatus)) error {
+	plan, control, err := e.startIndexBuilds()
+	if err != nil {
+		return err
+	}
+	return e.buildIndexes(ctx, plan, control, callback, true)
+}
+
+// startIndexBuilds resolves the index plan and claims the build control, so
+// only one build runs at a time.
+func (e *Engine) startIndexBuilds() (*indexes.IndexPlan, *postmigration.IndexBuildControl, error) {
+	if e.Config == nil || e.Mapping == nil {
+		return nil, nil, fmt.Errorf("config and mapping required")
+	}
+
+	plan, err := e.GetIndexPlan()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := IndexBuildOptions(e.Config.Indexes).Naming.Check(plan); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidIndexPlan, err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.indexControl != nil {
+		return nil, nil, fmt.Errorf("index builds already running")
+	}
+	control := postmigration.NewIndexBuildControl()
+	e.indexControl = control
+	e.indexStatuses = nil
+	e.audit("index_builds_started", fmt.Sprintf("%d indexes", len(plan.Indexes)))
+	return plan, control, nil
+}
+
+func (e *Engine) buildIndexes(ctx context.Context, plan *indexes.IndexPlan, control *postmigration.IndexBuildControl, callback func(status []target.IndexBuildStatus), postOps bool) (err error) {
+	endJob := e.startJob("index_builds")
+	defer func() {
+		e.mu.Lock()
+		e.indexControl = nil
+		e.mu.Unlock()
+		if err != nil {
+			endJob("failed", err.Error())
+		} else {
+			endJob("complete", fmt.Sprintf("%d indexes", len(plan.Indexes)))
+		}
+	}()
+
+	tgt := e.Config.Target
+	op, release, err := e.targetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("connecting to target: %w", err)
+	}
+	defer release()
+
+	orch := &postmigration.Orchestrator{
+		Target:       op,
+		Schema:       e.Schema,
+		Mapping:      e.Mapping,
+		State:        e.State,
+		StatePath:    e.statePath,
+		IndexPlan:    plan,
+		Hooks:        e.hookRunner(),
+		IndexBuilds:  IndexBuildOptions(e.Config.Indexes),
+		IndexControl: control,
+	}
+	if e.Config.Atlas.Configured() {
+		client, err := atlas.NewClient(e.Config.Atlas, tgt.Database)
+		if err != nil {
+			return err
+		}
+		orch.SearchIndexes = client
+	}
+
+	if err := orch.RunIndexBuilds(ctx, postmigration.Callbacks{
+		OnIndexProgress: func(statuses []target.IndexBuildStatus) {
+			e.mu.Lock()
+			e.indexStatuses = statuses
+			e.mu.Unlock()
+			if callback != nil {
+				callback(statuses)
+			}
+		},
+	}); err != nil {
 		return err
 	}
+	if !postOps {
+		return nil
+	}
+	if topo, err := op.DetectTopology(ctx); err == nil {
+		orch.Topology = topo
+	}
+	return orch.RunPostOps(ctx)
+}
+
+// IndexBuildOptions converts the indexes section of the config into build
+// scheduling options and the naming convention builds enforce.
+func IndexBuildOptions(cfg config.IndexesConfig) postmigration.IndexBuildOptions {
+	return postmigration.IndexBuildOptions{
+		Concurrency: cfg.Concurrency,
+		MaxPerShard: cfg.MaxBuildsPerShard,
+		Naming:      indexes.NameConvention(cfg.NameTemplate),
+	}
+}
+
+// PauseIndexBuilds stops further index builds from starting; builds already
+// running on the target finish.
+func (e *Engine) PauseIndexBuilds() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.indexControl == nil {
+		return ErrNoIndexBuilds
+	}
+	e.indexControl.Pause()
+	e.audit("index_builds_paused", "")
+	return nil
+}
+
+// ResumeIndexBuilds lets paused index builds continue.
+func (e *Engine) ResumeIndexBuilds() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.indexControl == nil {
+		return ErrNoIndexBuilds
+	}
+	e.indexControl.Resume()
+	e.audit("index_builds_resumed", "")
+	return nil
+}
+
+// ErrNoIndexBuilds is returned when pausing or resuming with no index builds
+// running.
+var ErrNoIndexBuilds = errors.New("no index builds running")
+
+// IndexBuildStatus returns current index build progress: each planned
+// index's phase and percentage, and the mean over all of them.
+func (e *Engine) IndexBuildStatus() (*IndexBuildStatusResult, error) {
+	result := &IndexBuildStatusResult{Status: "not_started"}
+	if e.State != nil && e.State.IndexBuildStatus != "" {
+		result.Status = e.State.IndexBuildStatus
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	result.Indexes = e.indexStatuses
+	result.Progress = postmigration.OverallIndexProgress(e.indexStatuses)
+	result.Paused = e.indexControl.Paused()
+	if e.Config != nil {
+		opts := IndexBuildOptions(e.Config.Indexes)
+		result.Concurrency = max(opts.Concurrency, postmigration.DefaultIndexConcurrency)
+		result.MaxPerShard = opts.MaxPerShard
+	}
+	return result, nil
+}
+
+// IndexBuildStatusResult holds index build status.
+type IndexBuildStatusResult struct {
+	Status      string                    `json:"status"`
+	Progress    float64                   `json:"progress"` // mean over the indexes, in percent
+	Paused      bool                      `json:"paused"`
+	Concurrency int                       `json:"concurrency,omitempty"`
+	MaxPerShard int                       `json:"max_per_shard,omitempty"`
+	Indexes     []target.IndexBuildStatus `json:"indexes,omitempty"`
+}
+
+// BuildViews starts asynchronous building of the materialized views defined
+// in the mapping and writes their refresh scripts.
+func (e *Engine) BuildViews(ctx context.Context, callback func(view string, err error)) error {
+	if e.Config == nil || e.Mapping == nil {
+		return fmt.Errorf("config and mapping required")
+	}
+	if len(e.Mapping.Views) == 0 {
+		return fmt.Errorf("no materialized views defined in the mapping")
+	}
+	if err := e.Mapping.ValidateViews(); err != nil {
+		return fmt.Errorf("invalid views: %w", err)
+	}
 
 	go func() {
-		tgt := e.Config.Target
 		buildCtx := context.Background()
-		op, err := target.NewMongoOperator(buildCtx, tgt.ConnectionString, tgt.Database)
+		op, release, err := e.targetConn(buildCtx)
 		if err != nil {
-			e.Logger.Error("index build target connect failed", "error", err)
+			e.Logger.Error("view build target connect failed", "error", err)
 			return
 		}
-		defer op.Close(buildCtx)
+		defer release()
 
 		orch := &postmigration.Orchestrator{
 			Target:    op,
-			Schema:    e.Schema,
 			Mapping:   e.Mapping,
 			State:     e.State,
 			StatePath: e.statePath,
-			IndexPlan: plan,
 		}
 
-		if err := orch.RunIndexBuilds(buildCtx, postmigration.Callbacks{
-			OnIndexProgress: callback,
+		if err := orch.RunViews(buildCtx, postmigration.Callbacks{
+			OnViewBuilt: callback,
 		}); err != nil {
-			e.Logger.Error("index builds failed", "error", err)
+			e.Logger.Error("view builds failed", "error", err)
 		}
 	}()
 
 	return nil
 }
 
-// IndexBuildStatus returns current index build progress.
-func (e *Engine) IndexBuildStatus() (*IndexBuildStatusResult, error) {
-	result := &IndexBuildStatusResult{Status: "not_started"}
-	if e.State != nil && e.State.IndexBuildStatus != "" {
-		result.Status = e.State.IndexBuildStatus
+// ViewsStatus returns the materialized views and their build status.
+func (e *Engine) ViewsStatus() *ViewsStatusResult {
+	result := &ViewsStatusResult{Status: "not_started"}
+	if e.Mapping != nil {
+		result.Views = e.Mapping.Views
 	}
-	return result, nil
+	if e.State != nil {
+		if e.State.ViewsStatus != "" {
+			result.Status = e.State.ViewsStatus
+		}
+		result.ScriptsDir = e.State.ViewScriptsDir
+	}
+	return result
 }
 
-// IndexBuildStatusResult holds index build status.
-type IndexBuildStatusResult struct {
-	Status  string                   `json:"status"`
-	Indexes []target.IndexBuildStatus `json:"indexes,omitempty"`
+// ViewsStatusResult holds materialized view build status.
+type ViewsStatusResult struct {
+	Status     string         `json:"status"`
+	Views      []mapping.View `json:"views"`
+	ScriptsDir string         `json:"scripts_dir,omitempty"`
+}
+
+// RunCanaryQueries starts asynchronous explain runs of the canary queries,
+// either registered in the mapping or generated from foreign key access paths.
+func (e *Engine) RunCanaryQueries(ctx context.Context, callback func(result postmigration.CanaryResult)) error {
+	if e.Config == nil || e.Mapping == nil {
+		return fmt.Errorf("config and mapping required")
+	}
+	if err := e.Mapping.ValidateQueries(); err != nil {
+		return fmt.Errorf("invalid canary queries: %w", err)
+	}
+	if len(e.Mapping.CanaryQueries()) == 0 {
+		return fmt.Errorf("no canary queries registered and no foreign key access paths to generate them from")
+	}
+
+	go func() {
+		runCtx := context.Background()
+		op, release, err := e.targetConn(runCtx)
+		if err != nil {
+			e.Logger.Error("canary query target connect failed", "error", err)
+			return
+		}
+		defer release()
+
+		orch := &postmigration.Orchestrator{
+			Target:    op,
+			Mapping:   e.Mapping,
+			State:     e.State,
+			StatePath: e.statePath,
+		}
+
+		if _, err := orch.RunCanaryQueries(runCtx, postmigration.Callbacks{
+			OnCanaryQuery: callback,
+		}); err != nil {
+			e.Logger.Error("canary queries failed", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// CanaryStatus returns the canary queries and the results of the last run.
+func (e *Engine) CanaryStatus() *CanaryStatusResult {
+	result := &CanaryStatusResult{Status: "not_started"}
+	if e.Mapping != nil {
+		result.Queries = e.Mapping.CanaryQueries()
+	}
+	if e.State != nil {
+		if e.State.CanaryStatus != "" {
+			result.Status = e.State.CanaryStatus
+		}
+		if e.State.CanaryReportPath != "" {
+			results, err := postmigration.LoadCanaryResults(e.State.CanaryReportPath)
+			if err != nil {
+				e.Logger.Warn("loading canary report failed", "error", err)
+			}
+			result.Results = results
+			result.ReportPath = e.State.CanaryReportPath
+		}
+	}
+	return result
+}
+
+// CanaryStatusResult holds canary query results.
+type CanaryStatusResult struct {
+	Status     string                       `json:"status"`
+	Queries    []mapping.CanaryQuery        `json:"queries"`
+	Results    []postmigration.CanaryResult `json:"results,omitempty"`
+	ReportPath string                       `json:"report_path,omitempty"`
 }
 
 // CheckReadiness evaluates production readiness.
@@ -730,28 +2707,100 @@ func (e *Engine) CheckReadiness(ctx context.Context) (*report.MigrationReport, e
 	}
 
 	var topo *target.TopologyInfo
+	var tgtOp target.Operator
 	if e.Config != nil && e.Config.Target.ConnectionString != "" {
-		op, err := target.NewMongoOperator(ctx, e.Config.Target.ConnectionString, e.Config.Target.Database)
+		op, release, err := e.targetConn(ctx)
 		if err == nil {
+			defer release()
 			topo, _ = op.DetectTopology(ctx)
-			op.Close(ctx)
+			tgtOp = op
+		}
+	}
+
+	// The report counts the rows archive policies sent to S3
+	var srcReader source.Reader
+	if e.Config != nil && e.Mapping != nil && e.Mapping.HasArchives() {
+		if src, release, err := e.sourceConn(ctx); err == nil {
+			defer release()
+			srcReader = src
 		}
 	}
 
 	plan, _ := e.GetIndexPlan()
 
 	orch := &postmigration.Orchestrator{
+		Source:    srcReader,
+		Target:    tgtOp,
 		Schema:    e.Schema,
 		Mapping:   e.Mapping,
 		State:     e.State,
 		StatePath: e.statePath,
 		IndexPlan: plan,
+		TypeMap:   e.GetTypeMap(),
 		Topology:  topo,
 	}
 
 	return orch.CheckReadiness(ctx)
 }
 
+// RetentionCandidates lists the mapped collections that have a retention
+// policy or whose source table looks like it only needs recent rows.
+func (e *Engine) RetentionCandidates() ([]retention.Candidate, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	return retention.Candidates(e.Schema, e.Mapping), nil
+}
+
+// RetentionHistogram reads the age distribution of a source table's rows by
+// a date column.
+func (e *Engine) RetentionHistogram(ctx context.Context, table, column string) (*retention.Histogram, error) {
+	src, release, err := e.sourceConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return retention.Load(ctx, src, table, column)
+}
+
+// SetRetentionPolicy sets a collection's retention policy, or clears it when
+// p is nil, and saves the mapping. Invalid policies are reported as
+// ErrInvalidMapping.
+func (e *Engine) SetRetentionPolicy(collection string, p *mapping.RetentionPolicy) error {
+	if e.Mapping == nil {
+		return fmt.Errorf("no mapping defined")
+	}
+	var col *mapping.Collection
+	for i := range e.Mapping.Collections {
+		if e.Mapping.Collections[i].Name == collection {
+			col = &e.Mapping.Collections[i]
+		}
+	}
+	if col == nil {
+		return fmt.Errorf("%w: no collection %s", ErrInvalidMapping, collection)
+	}
+	if p != nil {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+		}
+		if _, ok := col.RootField(p.Column); !ok {
+			return fmt.Errorf("%w: column %s is excluded from %s", ErrInvalidMapping, p.Column, collection)
+		}
+	}
+	col.Retention = p
+	e.indexPlan = nil
+	return e.saveMapping()
+}
+
+// OnlineArchiveRules returns the Atlas Online Archive rules for collections
+// whose retention policy archives documents.
+func (e *Engine) OnlineArchiveRules() ([]retention.OnlineArchiveRule, error) {
+	if e.Config == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("config and mapping required")
+	}
+	return retention.OnlineArchiveRules(e.Mapping, e.Config.Target.Database), nil
+}
+
 // PreviewMapping returns a suggested mapping based on schema and selected tables.
 // If rootTables is non-empty, only those tables become root collections.
 func (e *Engine) PreviewMapping(rootTables ...string) (*mapping.Mapping, error) {
@@ -765,7 +2814,21 @@ func (e *Engine) PreviewMapping(rootTables ...string) (*mapping.Mapping, error)
 	return mapping.Suggest(e.Schema, e.State.SelectedTables, rootTables...), nil
 }
 
-// MappingSizeEstimate returns per-collection BSON size estimates.
+// FieldGroupSuggestions proposes grouping prefixed columns of the saved
+// mapping's tables into subdocuments. Groups already in the mapping are not
+// proposed again.
+func (e *Engine) FieldGroupSuggestions() ([]mapping.FieldGroupSuggestion, error) {
+	if e.Schema == nil {
+		return nil, fmt.Errorf("no schema discovered yet")
+	}
+	if e.Mapping == nil {
+		return nil, fmt.Errorf("no mapping defined")
+	}
+	return mapping.SuggestFieldGroups(e.Schema, e.Mapping), nil
+}
+
+// MappingSizeEstimate returns per-collection BSON size estimates, sizing
+// embedded arrays from the child counts EmbedDistribution measured.
 func (e *Engine) MappingSizeEstimate() ([]mapping.CollectionSizeEstimate, error) {
 	if e.Schema == nil {
 		return nil, fmt.Errorf("no schema discovered yet")
@@ -774,7 +2837,7 @@ func (e *Engine) MappingSizeEstimate() ([]mapping.CollectionSizeEstimate, error)
 	if m == nil {
 		return nil, fmt.Errorf("no mapping defined")
 	}
-	return mapping.EstimateSizes(e.Schema, m), nil
+	return mapping.EstimateSizesMeasured(e.Schema, m, e.GetTypeMap(), e.embedCounts()), nil
 }
 
 // GenerateCode produces the PySpark migration script.
@@ -782,24 +2845,133 @@ func (e *Engine) GenerateCode() (*codegen.GenerateResult, error) {
 	if e.Config == nil || e.Schema == nil || e.Mapping == nil {
 		return nil, fmt.Errorf("config, schema, and mapping required for code generation")
 	}
+	issues := mapping.Validate(e.selectedSchema(), e.Mapping)
+	if err := issues.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMapping, err)
+	}
+
+	result, err := e.generator(false, nil).Generate()
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range issues.Warnings() {
+		result.Warnings = append(result.Warnings, w.String())
+	}
+	return result, nil
+}
 
+// generator sets up code generation for a full run, or for a delta run over
+// the given watermark ranges.
+func (e *Engine) generator(delta bool, ranges map[string]mapping.WatermarkRange) *codegen.Generator {
 	gen := &codegen.Generator{
-		Config:  e.Config,
-		Schema:  e.Schema,
-		Mapping: e.Mapping,
-		TypeMap: e.GetTypeMap(),
+		Config:     e.Config,
+		Schema:     e.Schema,
+		Mapping:    e.Mapping,
+		TypeMap:    e.GetTypeMap(),
+		Delta:      delta,
+		Watermarks: ranges,
+	}
+	if e.State != nil {
+		gen.Checkpoints = e.State.Checkpoints
+	}
+	return gen
+}
+
+// Plan assembles the consolidated dry-run migration plan from the schema,
+// mapping, type map, sizing and index plan. It does not touch the target.
+func (e *Engine) Plan() (*plan.Plan, error) {
+	if e.Config == nil || e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("config, schema, and mapping required for a migration plan")
+	}
+
+	in := plan.Input{
+		Config:      e.Config,
+		Schema:      e.Schema,
+		Mapping:     e.Mapping,
+		TypeMap:     e.GetTypeMap(),
+		EmbedCounts: e.embedCounts(),
+	}
+	var warnings []string
+	if sp, err := e.ComputeSizing(); err != nil {
+		warnings = append(warnings, fmt.Sprintf("sizing unavailable: %v", err))
+	} else {
+		in.Sizing = sp
+	}
+	if ip, err := e.GetIndexPlan(); err == nil {
+		in.Indexes = ip
+	}
+
+	p, err := plan.Build(in)
+	if err != nil {
+		return nil, err
+	}
+	p.Warnings = append(p.Warnings, warnings...)
+	return p, nil
+}
+
+// SourceImpact estimates the load a full migration places on the source:
+// connections held, read rate, time spent on each table and how much of the
+// source's buffer cache each read is likely to displace.
+func (e *Engine) SourceImpact() (*impact.Report, error) {
+	if e.Config == nil || e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("config, schema, and mapping required for a source impact estimate")
+	}
+	return impact.Estimate(impact.Input{
+		Source:   e.Config.Source,
+		Platform: e.Config.AWS.Platform,
+		Schema:   e.Schema,
+		Mapping:  e.Mapping,
+	}), nil
+}
+
+// LastSourceImpact returns the estimate made when the last full migration
+// started, with what the run actually did, or nil if none has run.
+func (e *Engine) LastSourceImpact() (*impact.Report, error) {
+	if e.State == nil || e.State.SourceImpactPath == "" {
+		return nil, nil
 	}
+	return impact.LoadYAML(e.State.SourceImpactPath)
+}
 
-	return gen.Generate()
+// recordSourceImpact saves a full run's estimate with its actuals. The
+// caller saves the state.
+func (e *Engine) recordSourceImpact(est *impact.Report, status *migration.Status, started time.Time) {
+	if est == nil || e.State == nil {
+		return
+	}
+	est.RecordActual(status, started)
+	path := filepath.Join(filepath.Dir(e.statePath), "source-impact.yaml")
+	if err := est.WriteYAML(path); err != nil {
+		e.Logger.Warn("could not save source impact", "error", err)
+		return
+	}
+	e.State.SourceImpactPath = path
 }
 
-func buildPgConnString(src config.SourceConfig) string {
-	ssl := "disable"
-	if src.SSL {
-		ssl = "require"
+// DataDictionary describes the target document model. When samples is
+// positive, up to that many documents per collection are read from the
+// target for example values.
+func (e *Engine) DataDictionary(ctx context.Context, samples int) (*dictionary.Dictionary, error) {
+	if e.Config == nil || e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("config, schema, and mapping required for a data dictionary")
+	}
+
+	tgt := e.Config.Target
+	d := dictionary.Build(e.Schema, e.Mapping, e.GetTypeMap(), tgt.Database)
+	if samples <= 0 {
+		return d, nil
+	}
+
+	op, release, err := e.targetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	defer release()
+
+	if err := d.Sample(ctx, op, samples); err != nil {
+		return nil, err
 	}
-	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		src.Username, src.Password, src.Host, src.Port, src.Database, ssl)
+	return d, nil
 }
 
 func allStepsOrdered() []state.Step {
@@ -816,6 +2988,7 @@ func allStepsOrdered() []state.Step {
 		state.StepMigration,
 		state.StepValidation,
 		state.StepIndexBuilds,
+		state.StepCDC,
 		state.StepComplete,
 	}
 }
@@ -1,16 +1,28 @@
 package engine
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/reloquent/reloquent/internal/cdc"
+	"github.com/reloquent/reloquent/internal/codegen"
 	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/indexes"
 	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/postmigration"
 	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
 	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
 )
 
 func testEngine(t *testing.T) *Engine {
@@ -207,6 +219,157 @@ func TestGetSchema_Set(t *testing.T) {
 	}
 }
 
+func TestRediscoverAndDiff_NoSchema(t *testing.T) {
+	e := testEngine(t)
+	if _, err := e.RediscoverAndDiff(context.Background()); !errors.Is(err, ErrNoSchema) {
+		t.Errorf("err = %v, want ErrNoSchema", err)
+	}
+}
+
+func TestSchemaChanges(t *testing.T) {
+	old := testSchema()
+	cur := testSchema()
+	cur.Tables = cur.Tables[:2] // products dropped
+
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users", SourceTable: "users"},
+		{Name: "products", SourceTable: "products"},
+	}}
+
+	c := schemaChanges(old, cur, m)
+	if len(c.Diff.RemovedTables) != 1 || c.Diff.RemovedTables[0] != "products" {
+		t.Errorf("RemovedTables = %v, want [products]", c.Diff.RemovedTables)
+	}
+	if len(c.StaleReferences) != 1 || c.StaleReferences[0].Table != "products" {
+		t.Errorf("StaleReferences = %+v, want the products collection", c.StaleReferences)
+	}
+
+	if c := schemaChanges(old, old, nil); c.Diff.HasChanges() || len(c.StaleReferences) != 0 {
+		t.Errorf("unchanged schema reported changes: %+v", c)
+	}
+}
+
+func TestSetRetentionPolicy(t *testing.T) {
+	e := testEngine(t)
+	e.Config.Target.Database = "app"
+	e.Schema = testSchema()
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "orders", SourceTable: "orders", Transformations: []mapping.Transformation{{SourceField: "secret", Operation: "exclude"}}},
+	}}
+
+	for _, tc := range []struct {
+		name       string
+		collection string
+		policy     *mapping.RetentionPolicy
+	}{
+		{"unknown collection", "invoices", &mapping.RetentionPolicy{Column: "created_at", ExpireAfterDays: 30}},
+		{"invalid policy", "orders", &mapping.RetentionPolicy{Column: "created_at"}},
+		{"excluded column", "orders", &mapping.RetentionPolicy{Column: "secret", ExpireAfterDays: 30}},
+	} {
+		if err := e.SetRetentionPolicy(tc.collection, tc.policy); !errors.Is(err, ErrInvalidMapping) {
+			t.Errorf("%s: err = %v, want ErrInvalidMapping", tc.name, err)
+		}
+	}
+
+	p := &mapping.RetentionPolicy{Column: "created_at", ArchiveAfterDays: 90, ExpireAfterDays: 365}
+	if err := e.SetRetentionPolicy("orders", p); err != nil {
+		t.Fatalf("SetRetentionPolicy: %v", err)
+	}
+	saved, err := mapping.LoadYAML(e.State.MappingPath)
+	if err != nil {
+		t.Fatalf("loading saved mapping: %v", err)
+	}
+	if got := saved.Collections[0].Retention; got == nil || *got != *p {
+		t.Errorf("saved retention = %+v, want %+v", got, p)
+	}
+
+	plan, err := e.GetIndexPlan()
+	if err != nil {
+		t.Fatalf("GetIndexPlan: %v", err)
+	}
+	if len(plan.Indexes) == 0 || plan.Indexes[0].Index.ExpireAfterSeconds != 365*86400 {
+		t.Errorf("index plan = %+v, want a TTL index first", plan.Indexes)
+	}
+
+	rules, err := e.OnlineArchiveRules()
+	if err != nil {
+		t.Fatalf("OnlineArchiveRules: %v", err)
+	}
+	if len(rules) != 1 || rules[0].DBName != "app" || rules[0].Criteria.ExpireAfterDays != 90 {
+		t.Errorf("OnlineArchiveRules = %+v", rules)
+	}
+
+	if err := e.SetRetentionPolicy("orders", nil); err != nil {
+		t.Fatalf("clearing policy: %v", err)
+	}
+	if e.Mapping.Collections[0].Retention != nil {
+		t.Error("retention policy not cleared")
+	}
+}
+
+func TestSaveIndexPlan(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = testSchema()
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{Name: "orders", SourceTable: "orders"}}}
+
+	plan := &indexes.IndexPlan{Indexes: []target.CollectionIndex{
+		{Collection: "invoices", Index: target.IndexDefinition{Name: "idx_total", Keys: []target.IndexKey{{Field: "total", Order: 1}}}},
+	}}
+	if err := e.SaveIndexPlan(plan); !errors.Is(err, ErrInvalidIndexPlan) {
+		t.Errorf("unknown collection: err = %v, want ErrInvalidIndexPlan", err)
+	}
+
+	plan.Indexes[0].Collection = "orders"
+	if err := e.SaveIndexPlan(plan); err != nil {
+		t.Fatalf("SaveIndexPlan: %v", err)
+	}
+
+	// A fresh engine builds the saved plan rather than inferring one
+	e.indexPlan = nil
+	got, err := e.GetIndexPlan()
+	if err != nil {
+		t.Fatalf("GetIndexPlan: %v", err)
+	}
+	if !got.Edited || len(got.Indexes) != 1 || got.Indexes[0].Index.Name != "idx_total" {
+		t.Errorf("GetIndexPlan = %+v, want the saved plan", got)
+	}
+
+	if err := e.ResetIndexPlan(); err != nil {
+		t.Fatalf("ResetIndexPlan: %v", err)
+	}
+	got, err = e.GetIndexPlan()
+	if err != nil {
+		t.Fatalf("GetIndexPlan: %v", err)
+	}
+	if got.Edited || e.State.IndexPlanPath != "" {
+		t.Errorf("after reset the plan should be inferred again: %+v", got)
+	}
+}
+
+func TestIndexNameTemplate(t *testing.T) {
+	e := testEngine(t)
+	e.Config.Indexes.NameTemplate = "{collection}_{keys}"
+	e.Schema = &schema.Schema{Tables: []schema.Table{{
+		Name:       "orders",
+		PrimaryKey: &schema.PrimaryKey{Name: "pk_orders", Columns: []string{"tenant", "order_no"}},
+	}}}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{Name: "orders", SourceTable: "orders"}}}
+
+	plan, err := e.GetIndexPlan()
+	if err != nil {
+		t.Fatalf("GetIndexPlan: %v", err)
+	}
+	if len(plan.Indexes) != 1 || plan.Indexes[0].Index.Name != "orders_tenant_asc_order_no_asc" {
+		t.Errorf("inferred plan = %+v, want the primary key index named by the template", plan.Indexes)
+	}
+
+	// An edited plan is built as saved, so its names are checked first
+	plan.Indexes[0].Index.Name = "pk_orders"
+	if _, _, err := e.startIndexBuilds(); !errors.Is(err, ErrInvalidIndexPlan) {
+		t.Errorf("unconventional name: err = %v, want ErrInvalidIndexPlan", err)
+	}
+}
+
 func testSchema() *schema.Schema {
 	return &schema.Schema{
 		DatabaseType: "postgresql",
@@ -349,9 +512,6 @@ func TestGetMapping_Nil(t *testing.T) {
 
 func TestSaveMappingJSON(t *testing.T) {
 	e := testEngine(t)
-	// Override HOME so mapping.yaml goes to temp dir
-	tmpDir := t.TempDir()
-	t.Setenv("HOME", tmpDir)
 
 	m := mapping.Mapping{
 		Collections: []mapping.Collection{
@@ -372,7 +532,7 @@ func TestSaveMappingJSON(t *testing.T) {
 	}
 
 	// Verify file was written
-	mappingPath := filepath.Join(tmpDir, ".reloquent", "mapping.yaml")
+	mappingPath := filepath.Join(filepath.Dir(e.statePath), "mapping.yaml")
 	if _, err := os.Stat(mappingPath); os.IsNotExist(err) {
 		t.Error("mapping.yaml not written to disk")
 	}
@@ -386,6 +546,24 @@ func TestSaveMappingJSON_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestSaveMappingJSON_InvalidFilter(t *testing.T) {
+	e := testEngine(t)
+	data := []byte(`{"collections":[{"name":"users","source_table":"users","filter":"1=1; DROP TABLE users"}]}`)
+	if err := e.SaveMappingJSON(data); !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("err = %v, want ErrInvalidMapping", err)
+	}
+	if e.Mapping != nil {
+		t.Error("mapping should not be set when its filter is rejected")
+	}
+}
+
+func TestPreviewFilter_Invalid(t *testing.T) {
+	e := testEngine(t)
+	if _, err := e.PreviewFilter(context.Background(), "users", "active -- x"); !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("err = %v, want ErrInvalidMapping", err)
+	}
+}
+
 func TestGetTypeMap_NilSchema(t *testing.T) {
 	e := testEngine(t)
 	if e.GetTypeMap() != nil {
@@ -433,8 +611,6 @@ func TestGetTypeMap_Cached(t *testing.T) {
 
 func TestSaveTypeMapOverrides(t *testing.T) {
 	e := testEngine(t)
-	tmpDir := t.TempDir()
-	t.Setenv("HOME", tmpDir)
 	e.Schema = &schema.Schema{DatabaseType: "postgresql"}
 
 	overrides := map[string]string{
@@ -453,7 +629,7 @@ func TestSaveTypeMapOverrides(t *testing.T) {
 	}
 
 	// Verify file was written
-	tmPath := filepath.Join(tmpDir, ".reloquent", "typemap.yaml")
+	tmPath := filepath.Join(filepath.Dir(e.statePath), "typemap.yaml")
 	if _, err := os.Stat(tmPath); os.IsNotExist(err) {
 		t.Error("typemap.yaml not written to disk")
 	}
@@ -487,6 +663,81 @@ func TestComputeSizing(t *testing.T) {
 	}
 }
 
+func TestComputeSizing_Zones(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = testSchema()
+	e.State = &state.State{
+		SelectedTables: []string{"users", "orders"},
+		Steps:          make(map[state.Step]state.StepState),
+	}
+	zones := &mapping.ZoneConfig{
+		Field:  "region",
+		Ranges: []mapping.ZoneRange{{Zone: "EU", Min: "eu", Max: "eu~"}},
+	}
+	e.SetMapping(&mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users", SourceTable: "users", Zones: zones},
+	}})
+
+	plan, err := e.ComputeSizing()
+	if err != nil {
+		t.Fatalf("ComputeSizing error: %v", err)
+	}
+	if plan.ShardPlan == nil || len(plan.ShardPlan.Collections) != 1 {
+		t.Fatalf("expected a zoned sharding plan, got %+v", plan.ShardPlan)
+	}
+	if plan.ShardPlan.Collections[0].Zones == nil {
+		t.Error("expected zones on the users collection")
+	}
+
+	zones.Ranges[0].Max = "aa"
+	if _, err := e.ComputeSizing(); err == nil {
+		t.Error("expected error for invalid zone range")
+	}
+}
+
+func TestMigrateSpark_RequiresBucket(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = testSchema()
+	e.SetMapping(&mapping.Mapping{Collections: []mapping.Collection{{Name: "users", SourceTable: "users"}}})
+	e.Config.AWS.Platform = "glue"
+
+	_, err := e.MigrateSpark(t.Context(), false, nil)
+	if err == nil || !strings.Contains(err.Error(), "s3_bucket") {
+		t.Errorf("expected s3_bucket error, got %v", err)
+	}
+}
+
+func TestNewSparkBackend_UnsupportedPlatform(t *testing.T) {
+	e := testEngine(t)
+	e.Config.AWS.Platform = "dataproc"
+	if _, err := e.newSparkBackend(t.Context()); err == nil {
+		t.Error("expected error for unsupported platform")
+	}
+}
+
+func TestNewSparkBackend_EMRServerlessNeedsApplication(t *testing.T) {
+	e := testEngine(t)
+	e.Config.AWS.Platform = "emr-serverless"
+	e.Config.AWS.EMRServerlessRole = "arn:aws:iam::123456789012:role/migration"
+	if _, err := e.newSparkBackend(t.Context()); err == nil || !strings.Contains(err.Error(), "emr_serverless_application") {
+		t.Errorf("expected emr_serverless_application error, got %v", err)
+	}
+}
+
+func TestSparkCollections(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = &schema.Schema{Tables: []schema.Table{{Name: "users", RowCount: 42}}}
+	e.SetMapping(&mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "people", SourceTable: "users"},
+		{Name: "other", SourceTable: "missing"},
+	}})
+
+	got := e.sparkCollections()
+	if len(got) != 2 || got[0].Name != "people" || got[0].ExpectedDocs != 42 || got[1].ExpectedDocs != 0 {
+		t.Errorf("sparkCollections() = %+v", got)
+	}
+}
+
 func TestComputeSizing_NoTables(t *testing.T) {
 	e := testEngine(t)
 	_, err := e.ComputeSizing()
@@ -534,13 +785,517 @@ func TestSaveAWSConfig_NilConfig(t *testing.T) {
 
 func TestAllStepsOrdered(t *testing.T) {
 	steps := allStepsOrdered()
-	if len(steps) != 13 {
-		t.Fatalf("allStepsOrdered() len = %d, want 13", len(steps))
+	if len(steps) != 14 {
+		t.Fatalf("allStepsOrdered() len = %d, want 14", len(steps))
 	}
 	if steps[0] != state.StepSourceConnection {
 		t.Errorf("first step = %q", steps[0])
 	}
-	if steps[12] != state.StepComplete {
-		t.Errorf("last step = %q", steps[12])
+	if steps[13] != state.StepComplete {
+		t.Errorf("last step = %q", steps[13])
+	}
+}
+
+func TestStartCDC_NoMapping(t *testing.T) {
+	e := testEngine(t)
+	if err := e.StartCDC(t.Context(), nil); err == nil {
+		t.Error("expected error without mapping")
+	}
+}
+
+func TestStopCDC_NotRunning(t *testing.T) {
+	e := testEngine(t)
+	if err := e.StopCDC(); err == nil {
+		t.Error("expected error when CDC is not running")
+	}
+}
+
+func TestFinishCDC(t *testing.T) {
+	e := testEngine(t)
+	if err := e.FinishCDC(); err == nil {
+		t.Error("expected error when CDC is not running")
+	}
+
+	e.Config.Source.Type = "sqlserver"
+	e.SetMapping(&mapping.Mapping{})
+	failed := make(chan struct{})
+	var once sync.Once
+	if err := e.StartCDC(t.Context(), func(s cdc.Status) {
+		if s.State == "failed" {
+			once.Do(func() { close(failed) })
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-failed
+	if err := e.FinishCDC(); err == nil || !strings.Contains(err.Error(), "sqlserver") {
+		t.Errorf("FinishCDC() = %v, want the error that stopped replication", err)
+	}
+}
+
+func TestCDCStatus_FromState(t *testing.T) {
+	e := testEngine(t)
+	if got := e.CDCStatus(); got.State != "idle" {
+		t.Errorf("State = %q, want idle", got.State)
+	}
+
+	if _, err := e.LoadState(); err != nil {
+		t.Fatalf("LoadState error: %v", err)
+	}
+	e.State.CDCStatus = "prepared"
+	e.State.CDCStartPosition = "0/16B3748"
+
+	got := e.CDCStatus()
+	if got.State != "prepared" || got.Position != "0/16B3748" {
+		t.Errorf("CDCStatus() = %+v", got)
+	}
+}
+
+func TestNewCapturer_UnsupportedSource(t *testing.T) {
+	e := testEngine(t)
+	e.Config.Source.Type = "mysql"
+	if _, err := e.newCapturer(); err == nil {
+		t.Error("expected error for unsupported source type")
+	}
+}
+
+func TestBuildViews_NoViews(t *testing.T) {
+	e := testEngine(t)
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{Name: "orders"}}}
+
+	if err := e.BuildViews(t.Context(), nil); err == nil {
+		t.Fatal("expected error when no views are defined")
+	}
+
+	e.Mapping.Views = []mapping.View{{Name: "orders_by_day", Source: "orders", Pipeline: `[{"$out": "x"}]`}}
+	err := e.BuildViews(t.Context(), nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid views") {
+		t.Errorf("expected invalid views error, got %v", err)
+	}
+}
+
+func TestViewsStatus(t *testing.T) {
+	e := testEngine(t)
+	if got := e.ViewsStatus(); got.Status != "not_started" || len(got.Views) != 0 {
+		t.Errorf("ViewsStatus() = %+v", got)
+	}
+
+	e.Mapping = &mapping.Mapping{Views: []mapping.View{{Name: "orders_by_day", Source: "orders"}}}
+	e.State = state.New()
+	e.State.ViewsStatus = "complete"
+	e.State.ViewScriptsDir = "/tmp/views"
+	got := e.ViewsStatus()
+	if got.Status != "complete" || len(got.Views) != 1 || got.ScriptsDir != "/tmp/views" {
+		t.Errorf("ViewsStatus() = %+v", got)
+	}
+}
+
+func TestRunCanaryQueries_NoQueries(t *testing.T) {
+	e := testEngine(t)
+	e.Config = &config.Config{}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{Name: "orders", SourceTable: "orders"}}}
+
+	if err := e.RunCanaryQueries(t.Context(), nil); err == nil {
+		t.Fatal("expected error when there are no queries to run")
+	}
+
+	e.Mapping.Queries = []mapping.CanaryQuery{{Name: "q", Collection: "invoices", Filter: `{}`}}
+	err := e.RunCanaryQueries(t.Context(), nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid canary queries") {
+		t.Errorf("expected invalid canary queries error, got %v", err)
+	}
+}
+
+func TestCanaryStatus(t *testing.T) {
+	e := testEngine(t)
+	if got := e.CanaryStatus(); got.Status != "not_started" || len(got.Queries) != 0 {
+		t.Errorf("CanaryStatus() = %+v", got)
+	}
+
+	e.Mapping = &mapping.Mapping{
+		Collections: []mapping.Collection{
+			{Name: "customers", SourceTable: "customers", References: []mapping.Reference{
+				{SourceTable: "orders", FieldName: "orders", JoinColumn: "customer_id", ParentColumn: "id"},
+			}},
+			{Name: "orders", SourceTable: "orders"},
+		},
+	}
+	e.State = state.New()
+	e.State.CanaryStatus = "passed"
+	got := e.CanaryStatus()
+	if got.Status != "passed" || len(got.Queries) != 1 || got.Queries[0].Name != "orders_by_customer_id" {
+		t.Errorf("CanaryStatus() = %+v", got)
+	}
+}
+
+func TestSyncCheckpoints(t *testing.T) {
+	e := testEngine(t)
+	e.State = state.New()
+	op := &target.MockOperator{
+		Documents: map[string][]map[string]interface{}{
+			codegen.CheckpointCollection: {
+				{"collection": "users", "partition": int32(0), "lower": int64(1), "upper": int64(51), "partitions": int32(2)},
+				{"collection": "users", "partition": int32(1), "lower": int64(51), "upper": int64(101), "partitions": int32(2)},
+				{"collection": "orders", "partition": int32(0), "lower": int64(1), "upper": int64(11), "partitions": int32(4)},
+			},
+		},
+	}
+
+	if err := e.prepareCheckpoints(t.Context(), op, true); err != nil {
+		t.Fatalf("prepareCheckpoints: %v", err)
+	}
+	if !e.State.CollectionDone("users") {
+		t.Error("users should be done after both partitions completed")
+	}
+	if e.State.CollectionDone("orders") {
+		t.Error("orders has partitions left")
+	}
+	if got := len(e.State.Checkpoints["orders"].Completed); got != 1 {
+		t.Errorf("orders completed partitions = %d, want 1", got)
+	}
+
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{Name: "users"}, {Name: "orders"}, {Name: "products"}}}
+	pending := e.PendingCollections()
+	if len(pending) != 2 || pending[0] != "orders" || pending[1] != "products" {
+		t.Errorf("PendingCollections() = %v", pending)
+	}
+
+	// A fresh run clears checkpoints in state and in the target
+	if err := e.prepareCheckpoints(t.Context(), op, false); err != nil {
+		t.Fatalf("prepareCheckpoints: %v", err)
+	}
+	if len(e.State.Checkpoints) != 0 {
+		t.Errorf("checkpoints not cleared: %v", e.State.Checkpoints)
+	}
+	if len(op.DroppedCollections) != 1 || op.DroppedCollections[0] != codegen.CheckpointCollection {
+		t.Errorf("DroppedCollections = %v", op.DroppedCollections)
+	}
+}
+
+func TestCanResume(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{"", false},
+		{"completed", false},
+		{"running", true},
+		{"failed", true},
+		{"partial_failure", true},
+		{migration.PhaseWindowExpired, true},
+		{migration.PhasePaused, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			e := testEngine(t)
+			e.State = state.New()
+			e.State.MigrationStatus = tt.status
+			if got := e.CanResume(); got != tt.want {
+				t.Errorf("CanResume() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPauseResumeMigration(t *testing.T) {
+	e := testEngine(t)
+	e.State = state.New()
+	if err := e.PauseMigration(); !errors.Is(err, ErrNoMigration) {
+		t.Errorf("pause with nothing running: err = %v, want ErrNoMigration", err)
+	}
+	if err := e.ResumeMigration(context.Background(), nil); !errors.Is(err, ErrNotPaused) {
+		t.Errorf("resume with nothing paused: err = %v, want ErrNotPaused", err)
+	}
+
+	ctl, release := e.controlMigration()
+	defer release()
+	if err := e.ResumeMigration(context.Background(), nil); !errors.Is(err, ErrNotPaused) {
+		t.Errorf("resume while running: err = %v, want ErrNotPaused", err)
+	}
+	if err := e.PauseMigration(); err != nil {
+		t.Fatalf("PauseMigration: %v", err)
+	}
+	if !ctl.Paused() {
+		t.Error("control not paused")
+	}
+	// the pause survives a restart
+	saved, err := state.Load(e.statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.MigrationStatus != migration.PhasePaused {
+		t.Errorf("saved status = %q, want paused", saved.MigrationStatus)
+	}
+
+	if err := e.ResumeMigration(context.Background(), nil); err != nil {
+		t.Fatalf("ResumeMigration: %v", err)
+	}
+	if ctl.Paused() || e.State.MigrationStatus != "running" {
+		t.Errorf("after resume: paused %v, status %q", ctl.Paused(), e.State.MigrationStatus)
+	}
+}
+
+func TestResumePosition(t *testing.T) {
+	e := testEngine(t)
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users"}, {Name: "orders"}, {Name: "items"},
+	}}
+	e.State = state.New()
+	e.State.CompleteCollection("users")
+	e.State.RecordPartition("orders", 8, state.PartitionCheckpoint{Index: 0, Lower: 1, Upper: 100})
+	e.State.RecordPartition("orders", 8, state.PartitionCheckpoint{Index: 1, Lower: 100, Upper: 199})
+
+	want := "done: users; resuming: orders (2 of 8 partitions); starting over: items"
+	if got := e.resumePosition(); got != want {
+		t.Errorf("resumePosition() = %q, want %q", got, want)
+	}
+
+	e.State.ResetCheckpoints()
+	want = "done: none; resuming: none; starting over: users, orders, items"
+	if got := e.resumePosition(); got != want {
+		t.Errorf("resumePosition() = %q, want %q", got, want)
+	}
+}
+
+func TestMigrationWindow(t *testing.T) {
+	e := testEngine(t)
+	ctx, cancel, deadline, err := e.MigrationWindow(context.Background())
+	if err != nil {
+		t.Fatalf("MigrationWindow: %v", err)
+	}
+	cancel()
+	if !deadline.IsZero() || ctx.Err() != nil {
+		t.Error("no window should leave the context unbounded")
+	}
+
+	e.Config.Migration.MaxDuration = "later"
+	if _, _, _, err := e.MigrationWindow(context.Background()); err == nil {
+		t.Error("expected error for an invalid max_duration")
+	}
+
+	e.Config.Migration.MaxDuration = "1ms"
+	ctx, cancel, deadline, err = e.MigrationWindow(context.Background())
+	if err != nil {
+		t.Fatalf("MigrationWindow: %v", err)
+	}
+	defer cancel()
+	<-ctx.Done()
+	if !errors.Is(context.Cause(ctx), migration.ErrWindowExpired) {
+		t.Errorf("cause = %v, want ErrWindowExpired", context.Cause(ctx))
+	}
+	if deadline.IsZero() {
+		t.Error("deadline not returned")
+	}
+}
+
+func TestExpireWindow(t *testing.T) {
+	e := testEngine(t)
+	e.State = state.New()
+	op := &target.MockOperator{TopologyResult: &target.TopologyInfo{Type: "sharded"}}
+	status := &migration.Status{
+		Phase:       "failed",
+		Collections: []migration.CollectionStatus{{Name: "users", State: "interrupted"}},
+		Errors:      []string{"migration cancelled"},
+	}
+
+	e.expireWindow(op, status, time.Now())
+
+	if status.Phase != migration.PhaseWindowExpired {
+		t.Errorf("phase = %q", status.Phase)
+	}
+	if !op.WriteConcernSet || op.WriteConcernW != "majority" || !op.WriteConcernJ {
+		t.Errorf("write concern = %q/%v, want majority/true", op.WriteConcernW, op.WriteConcernJ)
+	}
+	if !op.BalancerEnabled || !e.State.BalancerReEnabled || !e.State.WriteConcernRestored {
+		t.Error("balancer and write concern should be restored and recorded")
+	}
+	if last := status.Errors[len(status.Errors)-1]; !strings.Contains(last, "--resume") {
+		t.Errorf("missing resume instructions: %q", status.Errors)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	e := testEngine(t)
+	if _, err := e.Plan(); err == nil {
+		t.Error("expected error without schema and mapping")
+	}
+
+	e.Schema = testSchema()
+	e.SetMapping(&mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users", SourceTable: "users"},
+	}})
+
+	// Without a table selection the plan is still built, minus sizing
+	p, err := e.Plan()
+	if err != nil {
+		t.Fatalf("Plan error: %v", err)
+	}
+	if p.Sizing != nil || len(p.Warnings) == 0 {
+		t.Errorf("expected sizing warning, got sizing=%v warnings=%v", p.Sizing, p.Warnings)
+	}
+
+	e.State = &state.State{
+		SelectedTables: []string{"users"},
+		Steps:          make(map[state.Step]state.StepState),
+	}
+	p, err = e.Plan()
+	if err != nil {
+		t.Fatalf("Plan error: %v", err)
+	}
+	if p.Sizing == nil {
+		t.Error("expected sizing in plan")
+	}
+	if len(p.Collections) != 1 || p.Collections[0].Name != "users" {
+		t.Errorf("Collections = %+v", p.Collections)
+	}
+}
+
+func TestSourceImpact(t *testing.T) {
+	e := testEngine(t)
+	if _, err := e.SourceImpact(); err == nil {
+		t.Error("expected error without schema and mapping")
+	}
+
+	e.Config.AWS.Platform = "native"
+	e.Schema = testSchema()
+	e.SetMapping(&mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users", SourceTable: "users"},
+	}})
+	est, err := e.SourceImpact()
+	if err != nil {
+		t.Fatalf("SourceImpact error: %v", err)
+	}
+	if est.Connections != 1 || len(est.Tables) != 1 {
+		t.Errorf("estimate = %+v, want one table on one connection", est)
+	}
+
+	if last, err := e.LastSourceImpact(); err != nil || last != nil {
+		t.Errorf("LastSourceImpact() = %v, %v before any run", last, err)
+	}
+	e.State = &state.State{Steps: make(map[state.Step]state.StepState)}
+	e.recordSourceImpact(est, &migration.Status{
+		Phase:       "completed",
+		Collections: []migration.CollectionStatus{{Name: "users", State: "completed", DocsWritten: 3}},
+	}, time.Now())
+	last, err := e.LastSourceImpact()
+	if err != nil || last == nil || last.Actual == nil || last.Actual.Collections[0].Documents != 3 {
+		t.Errorf("LastSourceImpact() = %+v, %v, want the recorded run", last, err)
+	}
+}
+
+func TestRunBenchmark_DryRun(t *testing.T) {
+	e := testEngine(t)
+	e.Config.Source = config.SourceConfig{Type: "postgresql", Host: "db", Password: "${RELOQUENT_TEST_UNSET}"}
+	e.Config.Benchmark = config.BenchmarkConfig{MaxRows: 5000, MaxDuration: "2m", OffPeakWindow: "22:00-06:00"}
+
+	// A dry run neither connects nor resolves credentials.
+	result, err := e.RunBenchmark(context.Background(), "users", "id", true)
+	if err != nil {
+		t.Fatalf("RunBenchmark error: %v", err)
+	}
+	if !result.DryRun || len(result.Queries) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if !strings.Contains(result.Queries[0], `FROM "users" TABLESAMPLE`) || !strings.HasSuffix(result.Queries[0], "LIMIT 5000") {
+		t.Errorf("query = %q", result.Queries[0])
+	}
+	if result.Guard != "max 2m, max 5000 rows, max 1.0 GB, off-peak 22:00-06:00" {
+		t.Errorf("Guard = %q", result.Guard)
+	}
+}
+
+func TestBenchmarkGuard(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.BenchmarkConfig
+		wantErr bool
+	}{
+		{"empty", config.BenchmarkConfig{}, false},
+		{"full profile", config.BenchmarkConfig{MaxDuration: "90s", MaxRows: 10, MaxBytes: 1 << 20, OffPeakWindow: "01:00-05:00"}, false},
+		{"bad duration", config.BenchmarkConfig{MaxDuration: "soon"}, true},
+		{"bad window", config.BenchmarkConfig{OffPeakWindow: "nights"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := benchmarkGuard(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWatermarkRanges(t *testing.T) {
+	e := testEngine(t)
+	e.State = state.New()
+	e.Schema = &schema.Schema{Tables: []schema.Table{
+		{Name: "orders", Columns: []schema.Column{{Name: "version", DataType: "bigint"}}},
+		{Name: "users"},
+	}}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "orders", SourceTable: "orders", Watermark: "version"},
+		{Name: "users", SourceTable: "users"},
+	}}
+	src := &source.MockReader{TableRows: map[string][]map[string]interface{}{
+		"orders": {{"version": int64(7)}, {"version": int64(42)}},
+	}}
+
+	ranges, err := e.watermarkRanges(t.Context(), src, false)
+	if err != nil {
+		t.Fatalf("watermarkRanges: %v", err)
+	}
+	want := mapping.WatermarkRange{Column: "version", UpTo: "42"}
+	if len(ranges) != 1 || ranges["orders"] != want {
+		t.Fatalf("ranges = %+v, want orders %+v", ranges, want)
+	}
+
+	// The next delta run starts after the recorded high-watermark
+	if err := e.RecordWatermarks(ranges, []string{"orders", "users"}); err != nil {
+		t.Fatalf("RecordWatermarks: %v", err)
+	}
+	if _, ok := e.State.Watermarks["users"]; ok {
+		t.Error("users has no watermark column and should not be recorded")
+	}
+	src.TableRows["orders"] = append(src.TableRows["orders"], map[string]interface{}{"version": int64(50)})
+	ranges, err = e.watermarkRanges(t.Context(), src, true)
+	if err != nil {
+		t.Fatalf("watermarkRanges: %v", err)
+	}
+	want = mapping.WatermarkRange{Column: "version", After: "42", UpTo: "50"}
+	if ranges["orders"] != want {
+		t.Errorf("delta range = %+v, want %+v", ranges["orders"], want)
+	}
+
+	e.Mapping.Collections[0].Watermark = ""
+	if _, err := e.watermarkRanges(t.Context(), src, true); !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("delta without watermarks: error = %v, want ErrInvalidMapping", err)
+	}
+}
+
+func TestPauseResumeIndexBuilds(t *testing.T) {
+	e := testEngine(t)
+	if err := e.PauseIndexBuilds(); !errors.Is(err, ErrNoIndexBuilds) {
+		t.Fatalf("PauseIndexBuilds with nothing running = %v", err)
+	}
+
+	e.indexControl = postmigration.NewIndexBuildControl()
+	e.indexStatuses = []target.IndexBuildStatus{
+		{Collection: "users", IndexName: "idx_users_email", Phase: "complete"},
+		{Collection: "orders", IndexName: "idx_orders_user_id", Phase: "building", Progress: 50},
+	}
+	e.Config.Indexes = config.IndexesConfig{Concurrency: 4, MaxBuildsPerShard: 2}
+
+	if err := e.PauseIndexBuilds(); err != nil {
+		t.Fatalf("PauseIndexBuilds: %v", err)
+	}
+	st, _ := e.IndexBuildStatus()
+	if !st.Paused || st.Progress != 75 || st.Concurrency != 4 || st.MaxPerShard != 2 || len(st.Indexes) != 2 {
+		t.Errorf("status while paused = %+v", st)
+	}
+	if err := e.ResumeIndexBuilds(); err != nil {
+		t.Fatalf("ResumeIndexBuilds: %v", err)
+	}
+	if st, _ := e.IndexBuildStatus(); st.Paused {
+		t.Error("builds should no longer be paused")
 	}
 }
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/postmigration"
+)
+
+// IDCandidates lists the mapped collections whose primary key comes from a
+// sequence or identity column, with the ID generation strategy chosen for
+// each.
+func (e *Engine) IDCandidates() ([]postmigration.IDCandidate, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	return postmigration.IDCandidates(e.Schema, e.Mapping), nil
+}
+
+// SetIDGeneration sets how a collection makes the ids of new documents after
+// cutover, clearing it when strategy is empty, and saves the mapping.
+// Unsupported strategies are reported as ErrInvalidMapping.
+func (e *Engine) SetIDGeneration(collection, strategy string) error {
+	if e.Mapping == nil {
+		return fmt.Errorf("no mapping defined")
+	}
+	if strategy != "" && !slices.Contains(mapping.IDGenerations, strategy) {
+		return fmt.Errorf("%w: unsupported id generation %q (use counter or objectid)", ErrInvalidMapping, strategy)
+	}
+	var col *mapping.Collection
+	for i := range e.Mapping.Collections {
+		if e.Mapping.Collections[i].Name == collection {
+			col = &e.Mapping.Collections[i]
+		}
+	}
+	if col == nil {
+		return fmt.Errorf("%w: no collection %s", ErrInvalidMapping, collection)
+	}
+	if strategy != "" {
+		if _, ok := col.SequenceKey(e.Schema); !ok {
+			return fmt.Errorf("%w: %s has no primary key filled from a sequence or identity column", ErrInvalidMapping, collection)
+		}
+	}
+	col.IDGeneration = strategy
+	e.indexPlan = nil
+	return e.saveMapping()
+}
+
+// SeedCounters seeds the counters collection with the highest source id of
+// every collection using a counter, and writes the guide to generating new
+// ids after cutover.
+func (e *Engine) SeedCounters(ctx context.Context) ([]postmigration.CounterSeed, error) {
+	if e.Config == nil || e.Mapping == nil || e.Schema == nil {
+		return nil, fmt.Errorf("config, schema and mapping required")
+	}
+	if _, err := e.LoadState(); err != nil {
+		return nil, err
+	}
+
+	src, releaseSource, err := e.sourceConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseSource()
+
+	op, releaseTarget, err := e.targetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to target: %w", err)
+	}
+	defer releaseTarget()
+
+	orch := &postmigration.Orchestrator{
+		Source:    src,
+		Target:    op,
+		Schema:    e.Schema,
+		Mapping:   e.Mapping,
+		State:     e.State,
+		StatePath: e.statePath,
+	}
+	return orch.RunIDGeneration(ctx, postmigration.Callbacks{})
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func TestSetIDGeneration(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = &schema.Schema{Tables: []schema.Table{
+		{
+			Name:       "orders",
+			Columns:    []schema.Column{{Name: "order_id", DataType: "bigint", IsSequence: true}},
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"order_id"}},
+		},
+		{
+			Name:       "codes",
+			Columns:    []schema.Column{{Name: "code", DataType: "varchar"}},
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"code"}},
+		},
+	}}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "orders", SourceTable: "orders"},
+		{Name: "codes", SourceTable: "codes"},
+	}}
+
+	for _, tc := range []struct {
+		name, collection, strategy string
+	}{
+		{"unknown collection", "invoices", mapping.IDCounter},
+		{"unknown strategy", "orders", "uuid"},
+		{"no sequence", "codes", mapping.IDCounter},
+	} {
+		if err := e.SetIDGeneration(tc.collection, tc.strategy); !errors.Is(err, ErrInvalidMapping) {
+			t.Errorf("%s: err = %v, want ErrInvalidMapping", tc.name, err)
+		}
+	}
+
+	if err := e.SetIDGeneration("orders", mapping.IDCounter); err != nil {
+		t.Fatalf("SetIDGeneration: %v", err)
+	}
+	saved, err := mapping.LoadYAML(e.State.MappingPath)
+	if err != nil {
+		t.Fatalf("loading saved mapping: %v", err)
+	}
+	if got := saved.Collections[0].IDGeneration; got != mapping.IDCounter {
+		t.Errorf("saved id generation = %q, want counter", got)
+	}
+
+	candidates, err := e.IDCandidates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 || candidates[0].Collection != "orders" || candidates[0].Strategy != mapping.IDCounter {
+		t.Errorf("candidates = %+v, want orders with a counter", candidates)
+	}
+
+	if err := e.SetIDGeneration("orders", ""); err != nil || e.Mapping.Collections[0].IDGeneration != "" {
+		t.Errorf("clearing: err = %v, strategy = %q", err, e.Mapping.Collections[0].IDGeneration)
+	}
+}
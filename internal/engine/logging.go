@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/reloquent/reloquent/internal/logging"
+)
+
+// ErrFixedLogLevels is returned when changing the log levels of an engine
+// whose logger was not built on logging.Levels.
+var ErrFixedLogLevels = errors.New("log levels of this process cannot be changed")
+
+// LogLevels reports the default log level and the components logging at
+// their own level.
+type LogLevels struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+	Available  []string          `json:"available"` // components that can be set
+}
+
+// SetLogLevels hands the engine the levels its logger filters at, so a
+// reloaded logging section or SetComponentLevel takes effect.
+func (e *Engine) SetLogLevels(levels *logging.Levels) {
+	e.logLevels = levels
+}
+
+// LogLevels returns the levels the logger filters at.
+func (e *Engine) LogLevels() (*LogLevels, error) {
+	if e.logLevels == nil {
+		return nil, ErrFixedLogLevels
+	}
+	level, comps := e.logLevels.Snapshot()
+	return &LogLevels{Level: level, Components: comps, Available: logging.Components}, nil
+}
+
+// SetComponentLevel changes a log level while the engine runs: the default
+// when component is empty, otherwise the component's. An empty level puts
+// the component back on the default. The change lasts until the config is
+// reloaded.
+func (e *Engine) SetComponentLevel(component, level string) (*LogLevels, error) {
+	if e.logLevels == nil {
+		return nil, ErrFixedLogLevels
+	}
+	if err := e.logLevels.Set(component, level); err != nil {
+		return nil, err
+	}
+	name, shown := component, level
+	if name == "" {
+		name = "default"
+	}
+	if shown == "" {
+		shown = "default"
+	}
+	e.Logger.Info("log level changed", "scope", name, "level", shown)
+	e.audit("log_level_changed", fmt.Sprintf("%s: %s", name, shown))
+	return e.LogLevels()
+}
+
+// log returns the engine's logger for one component.
+func (e *Engine) log(component string) *slog.Logger {
+	return logging.For(e.Logger, component)
+}
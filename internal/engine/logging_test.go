@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/logging"
+)
+
+func TestSetComponentLevel(t *testing.T) {
+	e := testEngine(t)
+	if _, err := e.SetComponentLevel(logging.ComponentMigration, "debug"); !errors.Is(err, ErrFixedLogLevels) {
+		t.Fatalf("err = %v, want ErrFixedLogLevels", err)
+	}
+
+	levels, err := logging.NewLevels("info", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.SetLogLevels(levels)
+	got, err := e.SetComponentLevel(logging.ComponentMigration, "debug")
+	if err != nil {
+		t.Fatalf("SetComponentLevel: %v", err)
+	}
+	if got.Level != "info" || got.Components[logging.ComponentMigration] != "debug" {
+		t.Errorf("levels = %+v, want info with migration at debug", got)
+	}
+	if levels.Level(logging.ComponentMigration) != slog.LevelDebug || levels.Level(logging.ComponentAPI) != slog.LevelInfo {
+		t.Error("only the migration level should change")
+	}
+
+	if _, err := e.SetComponentLevel("storage", "debug"); err == nil {
+		t.Error("expected an unknown component to be rejected")
+	}
+	if _, err := e.SetComponentLevel("", "verbose"); err == nil {
+		t.Error("expected an unknown level to be rejected")
+	}
+
+	// An empty level puts the component back on the default
+	got, err = e.SetComponentLevel(logging.ComponentMigration, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Components) != 0 {
+		t.Errorf("components = %v, want none", got.Components)
+	}
+
+	h, err := e.History(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var details []string
+	for _, a := range h.Audit {
+		if a.Action == "log_level_changed" {
+			details = append(details, a.Detail)
+		}
+	}
+	joined := strings.Join(details, ";")
+	if len(details) != 2 || !strings.Contains(joined, "migration: debug") || !strings.Contains(joined, "migration: default") {
+		t.Errorf("audit = %v", details)
+	}
+}
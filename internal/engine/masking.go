@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/transform"
+)
+
+// PIIColumns lists the columns of the mapped tables that look like personal
+// data, each with the mask suggested for it, and the columns the mapping
+// masks, each marked accepted with the mask in the mapping.
+func (e *Engine) PIIColumns() ([]transform.PIIColumn, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	cols := transform.ClassifyPII(e.Schema, e.Mapping.SourceTables())
+	listed := make(map[[2]string]bool, len(cols))
+	for i := range cols {
+		listed[[2]string{cols[i].Table, cols[i].Column}] = true
+		if t, ok := e.Mapping.ColumnMask(cols[i].Table, cols[i].Column); ok {
+			cols[i].Operation, cols[i].Value, cols[i].Accepted = t.Operation, t.Value, true
+		}
+	}
+	// Columns masked by hand that the classifier does not recognize
+	for _, table := range e.Mapping.SourceTables() {
+		for _, t := range e.Schema.Tables {
+			if t.Name != table {
+				continue
+			}
+			for _, col := range t.Columns {
+				if listed[[2]string{table, col.Name}] {
+					continue
+				}
+				if mask, ok := e.Mapping.ColumnMask(table, col.Name); ok {
+					cols = append(cols, transform.PIIColumn{
+						Table: table, Column: col.Name, DataType: col.DataType, Reason: "masked in the mapping",
+						Operation: mask.Operation, Value: mask.Value, Accepted: true,
+					})
+				}
+			}
+		}
+	}
+	return cols, nil
+}
+
+// SaveMasks masks each accepted column with its operation wherever its
+// table is migrated, removes the masks of each rejected one, and saves the
+// mapping. Columns of tables the mapping does not migrate, and invalid
+// masks, are reported as ErrInvalidMapping and nothing is saved.
+func (e *Engine) SaveMasks(cols []transform.PIIColumn) error {
+	if e.Mapping == nil {
+		return fmt.Errorf("no mapping defined")
+	}
+	tables := make(map[string]bool)
+	for _, t := range e.Mapping.SourceTables() {
+		tables[t] = true
+	}
+	for _, p := range cols {
+		if !tables[p.Table] {
+			return fmt.Errorf("%w: table %s is not migrated", ErrInvalidMapping, p.Table)
+		}
+		if !p.Accepted {
+			continue
+		}
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+		}
+	}
+	for _, p := range cols {
+		if p.Accepted {
+			t := p.Transformation()
+			e.Mapping.SetColumnMask(p.Table, p.Column, &t)
+		} else {
+			e.Mapping.SetColumnMask(p.Table, p.Column, nil)
+		}
+	}
+	return e.saveMapping()
+}
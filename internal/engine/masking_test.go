@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/transform"
+)
+
+func TestMasks(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = &schema.Schema{Tables: []schema.Table{{Name: "customers", Columns: []schema.Column{
+		{Name: "id", DataType: "integer"},
+		{Name: "email", DataType: "varchar"},
+		{Name: "notes", DataType: "text"},
+	}}}}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{Name: "customers", SourceTable: "customers"}}}
+
+	cols, err := e.PIIColumns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 1 || cols[0].Column != "email" || cols[0].Operation != transform.OpSynthesize || cols[0].Accepted {
+		t.Fatalf("PII columns = %+v, want email suggested for synthesis", cols)
+	}
+
+	bad := cols[0]
+	bad.Accepted, bad.Value = true, "postcode"
+	if err := e.SaveMasks([]transform.PIIColumn{bad}); !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("unknown synthesize kind: err = %v, want ErrInvalidMapping", err)
+	}
+
+	cols[0].Accepted = true
+	notes := transform.PIIColumn{Table: "customers", Column: "notes", Operation: transform.OpRedact, Value: "[removed]", Accepted: true}
+	if err := e.SaveMasks(append(cols, notes)); err != nil {
+		t.Fatalf("SaveMasks: %v", err)
+	}
+	saved, err := mapping.LoadYAML(e.State.MappingPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transform.ValidateMapping(saved, e.Schema); err != nil {
+		t.Errorf("saved mapping is invalid: %v", err)
+	}
+	if m, ok := saved.ColumnMask("customers", "notes"); !ok || m.Value != "[removed]" {
+		t.Errorf("notes mask = %+v, %v", m, ok)
+	}
+
+	cols, err = e.PIIColumns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 2 || !cols[0].Accepted || cols[1].Column != "notes" || !cols[1].Accepted {
+		t.Fatalf("PII columns after saving = %+v, want email and notes masked", cols)
+	}
+
+	cols[1].Accepted = false
+	if err := e.SaveMasks(cols[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := e.Mapping.ColumnMask("customers", "notes"); ok {
+		t.Error("rejected mask kept")
+	}
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+// History is what a project's metadata store has recorded.
+type History struct {
+	Store string             `json:"store"` // yaml or sqlite
+	Runs  []state.RunRecord  `json:"runs"`
+	Jobs  []state.Job        `json:"jobs"`
+	Audit []state.AuditEvent `json:"audit"`
+}
+
+// History returns the project's recent runs and audit events, up to limit
+// of each, and its jobs.
+func (e *Engine) History(limit int) (*History, error) {
+	if err := e.ensureMetadataStore(); err != nil {
+		return nil, err
+	}
+	store, err := state.OpenStore(e.projectDir())
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	h := &History{Store: "yaml"}
+	if state.HasSQLite(e.projectDir()) {
+		h.Store = "sqlite"
+	}
+	if h.Runs, err = store.Runs(limit); err != nil {
+		return nil, err
+	}
+	if h.Jobs, err = store.Jobs(); err != nil {
+		return nil, err
+	}
+	if h.Audit, err = store.Audit(limit); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (e *Engine) projectDir() string {
+	return filepath.Dir(e.statePath)
+}
+
+// ensureMetadataStore moves the project to SQLite the first time it is
+// used with metadata.store set to sqlite.
+func (e *Engine) ensureMetadataStore() error {
+	if e.Config == nil || e.Config.Metadata.Store != "sqlite" {
+		return nil
+	}
+	migrated, err := state.MigrateToSQLite(e.projectDir())
+	if err != nil {
+		return fmt.Errorf("moving project metadata to SQLite: %w", err)
+	}
+	if migrated {
+		e.Logger.Info("project metadata moved to SQLite", "database", filepath.Join(e.projectDir(), state.DBName))
+	}
+	return nil
+}
+
+// withStore runs fn against the project's metadata store. Records are
+// bookkeeping, so failures are logged rather than failing the operation.
+func (e *Engine) withStore(what string, fn func(state.Store) error) {
+	store, err := state.OpenStore(e.projectDir())
+	if err == nil {
+		err = fn(store)
+		store.Close()
+	}
+	if err != nil {
+		e.Logger.Warn("recording "+what+" failed", "error", err)
+	}
+}
+
+// audit records an action in the project's history and its audit trail.
+func (e *Engine) audit(action, detail string) {
+	e.auditChange(action, detail)
+}
+
+// startRun records a run as running and returns its ID, 0 when it could not
+// be recorded, and a function that records how it ended.
+func (e *Engine) startRun(command string) (int64, func(status, report string)) {
+	run := state.RunRecord{Command: command, Status: "running", StartedAt: time.Now()}
+	e.withStore("run", func(s state.Store) error {
+		id, err := s.AddRun(run)
+		run.ID = id
+		return err
+	})
+	return run.ID, func(status, report string) {
+		if run.ID == 0 {
+			return
+		}
+		run.Status, run.Report, run.FinishedAt = status, report, time.Now()
+		e.withStore("run", func(s state.Store) error { return s.UpdateRun(run) })
+	}
+}
+
+// startJob records a running job and returns a function that records how
+// it ended.
+func (e *Engine) startJob(kind string) func(status, detail string) {
+	start := time.Now()
+	job := state.Job{
+		ID:        fmt.Sprintf("%s-%d", kind, start.UnixMilli()),
+		Kind:      kind,
+		Status:    "running",
+		StartedAt: start,
+		UpdatedAt: start,
+	}
+	e.withStore("job", func(s state.Store) error { return s.SaveJob(job) })
+	return func(status, detail string) {
+		job.Status, job.Detail, job.UpdatedAt = status, detail, time.Now()
+		e.withStore("job", func(s state.Store) error { return s.SaveJob(job) })
+	}
+}
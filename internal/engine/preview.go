@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/migration"
+)
+
+// Document preview sizes: how many documents are built when none is asked
+// for, and at most.
+const (
+	DefaultPreviewDocuments = 3
+	MaxPreviewDocuments     = 20
+)
+
+// DocumentPreview is a handful of a collection's documents built from real
+// source rows, as the native migration would write them.
+type DocumentPreview struct {
+	Collection  string            `json:"collection"`
+	SourceTable string            `json:"source_table"`
+	Documents   []json.RawMessage `json:"documents"` // relaxed Extended JSON
+}
+
+// DocumentPreview reads up to limit root rows of a collection, joins the
+// rows of its embedded tables to them per the mapping and renders the
+// documents they make as JSON, so a design can be checked against real
+// data before anything is migrated. A limit of zero or less previews
+// DefaultPreviewDocuments; it is at most MaxPreviewDocuments. An unknown
+// collection is reported as ErrInvalidMapping.
+func (e *Engine) DocumentPreview(ctx context.Context, collection string, limit int) (*DocumentPreview, error) {
+	if e.Mapping == nil {
+		return nil, fmt.Errorf("no mapping defined")
+	}
+	var table string
+	for _, c := range e.Mapping.Collections {
+		if c.Name == collection {
+			table = c.SourceTable
+		}
+	}
+	if table == "" {
+		return nil, fmt.Errorf("%w: no collection %s", ErrInvalidMapping, collection)
+	}
+
+	src, release, err := e.sourceConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return e.documentPreview(ctx, src, collection, table, limit)
+}
+
+func (e *Engine) documentPreview(ctx context.Context, src migration.NativeSource, collection, table string, limit int) (*DocumentPreview, error) {
+	p := &DocumentPreview{Collection: collection, SourceTable: table, Documents: []json.RawMessage{}}
+	switch {
+	case limit <= 0:
+		limit = DefaultPreviewDocuments
+	case limit > MaxPreviewDocuments:
+		limit = MaxPreviewDocuments
+	}
+	zones, numbers, err := e.rowConversions()
+	if err != nil {
+		return nil, err
+	}
+
+	exec := migration.NewNativeExecutor(src, nil, e.Mapping, e.Schema)
+	exec.SetTimestampZones(zones)
+	exec.SetNumberTypes(numbers)
+	docs, err := exec.Preview(ctx, collection, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		data, err := bson.MarshalExtJSON(sortedDoc(doc), false, false)
+		if err != nil {
+			return nil, fmt.Errorf("rendering a %s document: %w", collection, err)
+		}
+		p.Documents = append(p.Documents, data)
+	}
+	return p, nil
+}
+
+// sortedDoc returns a document with the fields of its maps, and of the maps
+// nested in it, sorted by name, so a preview renders the same every time.
+// Fields already ordered by the mapping's field order are left in place.
+func sortedDoc(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		d := make(bson.D, len(keys))
+		for i, k := range keys {
+			d[i] = bson.E{Key: k, Value: sortedDoc(x[k])}
+		}
+		return d
+	case bson.D:
+		d := make(bson.D, len(x))
+		for i, e := range x {
+			d[i] = bson.E{Key: e.Key, Value: sortedDoc(e.Value)}
+		}
+		return d
+	case []map[string]interface{}:
+		out := make([]interface{}, len(x))
+		for i, m := range x {
+			out[i] = sortedDoc(m)
+		}
+		return out
+	case []bson.D:
+		out := make([]interface{}, len(x))
+		for i, d := range x {
+			out[i] = sortedDoc(d)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, e := range x {
+			out[i] = sortedDoc(e)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+func TestDocumentPreview(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = &schema.Schema{Tables: []schema.Table{{Name: "customers"}, {Name: "orders"}}}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{
+		Name: "customers", SourceTable: "customers",
+		Embedded: []mapping.Embedded{{
+			SourceTable: "orders", FieldName: "orders", Relationship: "array",
+			JoinColumn: "customer_id", ParentColumn: "id",
+		}},
+	}}}
+	placed := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	src := &source.MockReader{TableRows: map[string][]map[string]interface{}{
+		"customers": {{"name": "Alice", "id": int64(1)}, {"id": int64(2)}, {"id": int64(3)}, {"id": int64(4)}},
+		"orders":    {{"order_id": int64(10), "customer_id": int64(1), "placed": placed}, {"order_id": int64(11), "customer_id": int64(4)}},
+	}}
+
+	p, err := e.documentPreview(context.Background(), src, "customers", "customers", 0)
+	if err != nil {
+		t.Fatalf("documentPreview() error: %v", err)
+	}
+	if p.SourceTable != "customers" || len(p.Documents) != DefaultPreviewDocuments {
+		t.Fatalf("preview = %s with %d documents, want %d", p.SourceTable, len(p.Documents), DefaultPreviewDocuments)
+	}
+	want := `{"id":1,"name":"Alice","orders":[{"order_id":10,"placed":{"$date":"2026-03-01T09:30:00Z"}}]}`
+	if got := string(p.Documents[0]); got != want {
+		t.Errorf("document = %s, want %s", got, want)
+	}
+	if got := string(p.Documents[1]); !strings.Contains(got, `"orders":[]`) {
+		t.Errorf("document = %s, want no orders", got)
+	}
+
+	if _, err := e.DocumentPreview(context.Background(), "invoices", 1); !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("unknown collection: error = %v, want ErrInvalidMapping", err)
+	}
+}
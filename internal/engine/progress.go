@@ -0,0 +1,385 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+// progressWriteInterval spaces out rewrites of the progress file while a
+// step reports progress. Steps starting and finishing are always written.
+const progressWriteInterval = time.Second
+
+// RunProgress is the state of a headless run as written to the progress
+// file, so schedulers and monitors on the host can follow it without the
+// HTTP API. Progress, collections and the estimate are those of the
+// running step.
+type RunProgress struct {
+	Status          string               `json:"status"`                     // running, completed or failed
+	Step            string               `json:"step,omitempty"`             // step running
+	Phase           string               `json:"phase,omitempty"`            // phase within the step, e.g. the migration's
+	Steps           []RunStepResult      `json:"steps"`                      // every step of the run, in order
+	PercentComplete float64              `json:"percent_complete"`           // of the running step
+	DocsPerSecond   float64              `json:"docs_per_second,omitempty"`  // written by the running migration
+	ThroughputMBps  float64              `json:"throughput_mbps,omitempty"`  // read by the running migration
+	Collections     []CollectionProgress `json:"collections,omitempty"`      // of the running step
+	EstimatedRemain time.Duration        `json:"estimated_remain,omitempty"` // until the running step finishes
+	ETA             *time.Time           `json:"eta,omitempty"`              // when the running step should finish
+	Error           string               `json:"error,omitempty"`
+	StartedAt       time.Time            `json:"started_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+// CollectionProgress is one collection's part in the running step. Done
+// and Total count documents while migrating, checksum chunks while
+// validating and indexes while building them.
+type CollectionProgress struct {
+	Name            string  `json:"name"`
+	State           string  `json:"state"`
+	Done            int64   `json:"done"`
+	Total           int64   `json:"total"`
+	PercentComplete float64 `json:"percent_complete"`
+}
+
+// Kinds of RunEvent.
+const (
+	RunEventStepStarted      = "step_started"
+	RunEventStepFinished     = "step_finished"
+	RunEventPhase            = "phase"             // the running step entered a new phase
+	RunEventProgress         = "progress"          // the running step made progress
+	RunEventCollectionFailed = "collection_failed" // a collection failed in the running step
+	RunEventFinished         = "finished"
+)
+
+// RunEvent is a change in a headless run's progress.
+type RunEvent struct {
+	Kind       string
+	Time       time.Time
+	Step       string         // the step the event is about
+	Result     *RunStepResult // of a finished step
+	Collection string         // that failed
+	Error      string         // why the collection or the run failed
+	Progress   RunProgress    // the run after the change
+}
+
+// RunRenderer presents a headless run's progress as it changes. Render is
+// called with each event in turn, never concurrently.
+type RunRenderer interface {
+	Render(ev RunEvent)
+}
+
+// progressFile keeps a run's progress, rewrites it to a file as it changes
+// when a path is set, and passes each change to the renderer, if any. A nil
+// progressFile ignores every update, so the run needs no checks when
+// neither is configured.
+type progressFile struct {
+	path     string
+	logger   func(error)
+	renderer RunRenderer
+
+	mu        sync.Mutex
+	p         RunProgress
+	stepStart time.Time
+	written   time.Time
+	failed    bool            // a write failed and was logged
+	reported  map[string]bool // failures already rendered, by step and collection
+}
+
+func newProgressFile(path string, steps []string, logger func(error)) *progressFile {
+	now := time.Now()
+	f := &progressFile{path: path, logger: logger}
+	f.p = RunProgress{Status: "running", StartedAt: now, UpdatedAt: now}
+	for _, s := range steps {
+		f.p.Steps = append(f.p.Steps, RunStepResult{Step: s, Status: "pending"})
+	}
+	return f
+}
+
+// stepStarted marks step as running and clears the previous step's progress.
+func (f *progressFile) stepStarted(step string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stepStart = time.Now()
+	f.p.Step, f.p.Phase = step, ""
+	f.p.PercentComplete, f.p.Collections = 0, nil
+	f.p.DocsPerSecond, f.p.ThroughputMBps = 0, 0
+	f.p.EstimatedRemain, f.p.ETA = 0, nil
+	f.setStep(RunStepResult{Step: step, Status: "running"})
+	f.write(true)
+	f.render(RunEvent{Kind: RunEventStepStarted, Step: step})
+}
+
+// stepFinished records a step's result, including steps not run.
+func (f *progressFile) stepFinished(res RunStepResult) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.setStep(res)
+	if res.Status == "ok" {
+		f.p.PercentComplete, f.p.EstimatedRemain, f.p.ETA = 100, 0, nil
+	}
+	f.write(true)
+	f.render(RunEvent{Kind: RunEventStepFinished, Step: res.Step, Result: &res, Error: res.Error})
+}
+
+// finish records how the run ended.
+func (f *progressFile) finish(err error) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.p.Status, f.p.Step, f.p.Phase = "completed", "", ""
+	f.p.EstimatedRemain, f.p.ETA = 0, nil
+	if err != nil {
+		f.p.Status, f.p.Error = "failed", err.Error()
+	}
+	f.write(true)
+	f.render(RunEvent{Kind: RunEventFinished, Error: f.p.Error})
+}
+
+// migration records the progress of the migrate step.
+func (f *progressFile) migration(s *migration.Status) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s.Phase != f.p.Phase && s.Phase != "" {
+		f.p.Phase = s.Phase
+		f.render(RunEvent{Kind: RunEventPhase, Step: f.p.Step})
+	}
+	f.p.PercentComplete = s.Overall.PercentComplete
+	f.p.Collections = f.p.Collections[:0]
+	for _, c := range s.Collections {
+		f.p.Collections = append(f.p.Collections, CollectionProgress{
+			Name:            c.Name,
+			State:           c.State,
+			Done:            c.DocsWritten,
+			Total:           c.DocsTotal,
+			PercentComplete: c.PercentComplete,
+		})
+		if c.State == "failed" {
+			f.collectionFailed(c.Name, c.Error)
+		}
+	}
+	elapsed := time.Since(f.stepStart)
+	if elapsed > 0 {
+		f.p.DocsPerSecond = float64(s.Overall.DocsWritten) / elapsed.Seconds()
+	}
+	f.p.ThroughputMBps = s.Overall.ThroughputMBps
+	remain := s.EstimatedRemain
+	if remain == 0 {
+		remain = estimateRemain(elapsed, f.p.PercentComplete)
+	}
+	f.setRemain(remain)
+	f.write(false)
+	f.render(RunEvent{Kind: RunEventProgress, Step: f.p.Step})
+}
+
+// validationCheck records a check of the validate step. Only failures
+// change a collection's state; chunks report how far it has got.
+func (f *progressFile) validationCheck(collection string, passed bool) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := f.collection(collection)
+	if c.State == "" {
+		c.State = "validating"
+	}
+	if !passed {
+		c.State = "failed"
+		f.collectionFailed(collection, "validation check failed")
+	}
+	f.write(false)
+	f.render(RunEvent{Kind: RunEventProgress, Step: f.p.Step})
+}
+
+// validationChunk records a checksum chunk of the validate step.
+func (f *progressFile) validationChunk(p validation.ChunkProgress) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := f.collection(p.Collection)
+	if c.State == "" {
+		c.State = "validating"
+	}
+	if !p.Match {
+		c.State = "failed"
+	}
+	c.Done, c.Total = int64(p.Chunk), int64(p.Chunks)
+	if !p.Match {
+		f.collectionFailed(p.Collection, "checksum mismatch")
+	}
+	c.PercentComplete = percentOf(c.Done, c.Total)
+	var done, total int64
+	for _, c := range f.p.Collections {
+		done += c.Done
+		total += c.Total
+	}
+	f.p.PercentComplete = percentOf(done, total)
+	f.setRemain(estimateRemain(time.Since(f.stepStart), f.p.PercentComplete))
+	f.write(false)
+	f.render(RunEvent{Kind: RunEventProgress, Step: f.p.Step})
+}
+
+// indexBuilds records the progress of the indexes step.
+func (f *progressFile) indexBuilds(statuses []target.IndexBuildStatus) {
+	if f == nil || len(statuses) == 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.p.Collections = f.p.Collections[:0]
+	var sum float64
+	for _, s := range statuses {
+		progress := s.Progress
+		if s.Phase == "complete" {
+			progress = 100
+		}
+		sum += progress
+		c := f.collection(s.Collection)
+		c.Total++
+		if s.Phase == "complete" {
+			c.Done++
+		}
+		c.PercentComplete = percentOf(c.Done, c.Total)
+		c.State = "building"
+		if c.Done == c.Total {
+			c.State = "complete"
+		}
+		if s.Phase == "failed" {
+			f.collectionFailed(s.Collection, fmt.Sprintf("index %s: %s", s.IndexName, s.Message))
+		}
+	}
+	f.p.PercentComplete = sum / float64(len(statuses))
+	f.setRemain(estimateRemain(time.Since(f.stepStart), f.p.PercentComplete))
+	f.write(false)
+	f.render(RunEvent{Kind: RunEventProgress, Step: f.p.Step})
+}
+
+// collectionFailed renders a collection's failure in the running step,
+// once however often it is reported.
+func (f *progressFile) collectionFailed(collection, reason string) {
+	key := f.p.Step + "/" + collection
+	if f.reported[key] {
+		return
+	}
+	if f.reported == nil {
+		f.reported = make(map[string]bool)
+	}
+	f.reported[key] = true
+	f.render(RunEvent{Kind: RunEventCollectionFailed, Step: f.p.Step, Collection: collection, Error: reason})
+}
+
+// render passes the event, with a copy of the current progress, to the
+// renderer.
+func (f *progressFile) render(ev RunEvent) {
+	if f.renderer == nil {
+		return
+	}
+	ev.Time = time.Now()
+	ev.Progress = f.p
+	ev.Progress.Steps = slices.Clone(f.p.Steps)
+	ev.Progress.Collections = slices.Clone(f.p.Collections)
+	f.renderer.Render(ev)
+}
+
+// collection returns the running step's entry for name, adding it if new.
+func (f *progressFile) collection(name string) *CollectionProgress {
+	for i := range f.p.Collections {
+		if f.p.Collections[i].Name == name {
+			return &f.p.Collections[i]
+		}
+	}
+	f.p.Collections = append(f.p.Collections, CollectionProgress{Name: name})
+	return &f.p.Collections[len(f.p.Collections)-1]
+}
+
+func (f *progressFile) setStep(res RunStepResult) {
+	for i := range f.p.Steps {
+		if f.p.Steps[i].Step == res.Step {
+			f.p.Steps[i] = res
+			return
+		}
+	}
+	f.p.Steps = append(f.p.Steps, res)
+}
+
+func (f *progressFile) setRemain(remain time.Duration) {
+	f.p.EstimatedRemain, f.p.ETA = remain, nil
+	if remain > 0 {
+		eta := time.Now().Add(remain).Round(time.Second)
+		f.p.ETA = &eta
+	}
+}
+
+// write replaces the file with the current progress, at most once per
+// progressWriteInterval unless force is set. The file is written beside
+// the old one and renamed over it, so a reader never sees it half written.
+func (f *progressFile) write(force bool) {
+	if f.path == "" {
+		return
+	}
+	now := time.Now()
+	if !force && now.Sub(f.written) < progressWriteInterval {
+		return
+	}
+	f.written = now
+	f.p.UpdatedAt = now
+	if err := writeJSONFile(f.path, f.p); err != nil && !f.failed {
+		f.failed = true
+		f.logger(err)
+	}
+}
+
+func writeJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding progress: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("creating progress file directory: %w", err)
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing progress file: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// estimateRemain extrapolates the time left from the time taken so far,
+// assuming the rest goes at the same pace. It returns 0 until there is
+// progress to go by.
+func estimateRemain(elapsed time.Duration, percent float64) time.Duration {
+	if percent <= 0 || percent >= 100 {
+		return 0
+	}
+	return time.Duration(float64(elapsed) * (100 - percent) / percent).Round(time.Second)
+}
+
+func percentOf(done, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(done) * 100 / float64(total)
+}
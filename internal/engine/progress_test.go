@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+func readProgress(t *testing.T, path string) RunProgress {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p RunProgress
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatalf("progress file is not JSON: %v", err)
+	}
+	return p
+}
+
+func TestRunSteps_ProgressFile(t *testing.T) {
+	e := testEngine(t)
+	path := filepath.Join(t.TempDir(), "progress", "run.json")
+	steps := []string{RunStepMigrate, RunStepValidate, RunStepIndexes}
+	e.progress = newProgressFile(path, steps, func(err error) { t.Errorf("write: %v", err) })
+
+	_, err := e.runSteps(context.Background(), steps, func(_ context.Context, step string) (string, error) {
+		switch step {
+		case RunStepMigrate:
+			e.progress.written = time.Time{} // as if a write interval had passed
+			e.progress.migration(&migration.Status{
+				Phase:           "running",
+				Overall:         migration.ProgressInfo{DocsWritten: 50, DocsTotal: 200, PercentComplete: 25},
+				Collections:     []migration.CollectionStatus{{Name: "users", State: "running", DocsWritten: 50, DocsTotal: 200, PercentComplete: 25}},
+				EstimatedRemain: time.Minute,
+			})
+			p := readProgress(t, path)
+			if p.Status != "running" || p.Step != RunStepMigrate || p.Phase != "running" || p.PercentComplete != 25 {
+				t.Errorf("while migrating = %+v", p)
+			}
+			if len(p.Collections) != 1 || p.Collections[0].Done != 50 || p.Collections[0].Total != 200 {
+				t.Errorf("collections = %+v", p.Collections)
+			}
+			if p.EstimatedRemain != time.Minute || p.ETA == nil || p.ETA.Before(time.Now()) {
+				t.Errorf("estimate = %v, eta %v", p.EstimatedRemain, p.ETA)
+			}
+			if p.Steps[0].Status != "running" || p.Steps[1].Status != "pending" {
+				t.Errorf("steps = %+v", p.Steps)
+			}
+			return "done", nil
+		case RunStepValidate:
+			return "", errors.New("validation FAIL")
+		}
+		t.Errorf("step %s ran after the failure", step)
+		return "", nil
+	})
+	e.progress.finish(err)
+
+	p := readProgress(t, path)
+	if p.Status != "failed" || p.Error != "validate: validation FAIL" || p.Step != "" || p.ETA != nil {
+		t.Errorf("final = %+v", p)
+	}
+	want := []string{"ok", "failed", "not_run"}
+	for i, s := range p.Steps {
+		if s.Status != want[i] {
+			t.Errorf("%s status = %s, want %s", s.Step, s.Status, want[i])
+		}
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
+
+func TestProgressFile_ValidationAndIndexes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	f := newProgressFile(path, []string{RunStepValidate, RunStepIndexes}, func(err error) { t.Errorf("write: %v", err) })
+
+	f.stepStarted(RunStepValidate)
+	f.validationChunk(validation.ChunkProgress{Collection: "users", Chunk: 1, Chunks: 4, Match: true})
+	f.validationCheck("orders", false)
+	f.written = time.Time{} // as if a write interval had passed
+	f.validationChunk(validation.ChunkProgress{Collection: "users", Chunk: 2, Chunks: 4, Match: true})
+	p := readProgress(t, path)
+	if p.PercentComplete != 50 || len(p.Collections) != 2 {
+		t.Fatalf("validating = %+v", p)
+	}
+	if c := p.Collections[0]; c.Name != "users" || c.State != "validating" || c.Done != 2 || c.Total != 4 {
+		t.Errorf("users = %+v", c)
+	}
+	if c := p.Collections[1]; c.Name != "orders" || c.State != "failed" {
+		t.Errorf("orders = %+v", c)
+	}
+
+	f.stepStarted(RunStepIndexes)
+	f.indexBuilds([]target.IndexBuildStatus{
+		{Collection: "users", IndexName: "a", Phase: "complete"},
+		{Collection: "users", IndexName: "b", Phase: "building", Progress: 50},
+		{Collection: "orders", IndexName: "c", Phase: "complete"},
+		{Collection: "orders", IndexName: "d", Phase: "pending"},
+	})
+	f.written = time.Time{}
+	f.indexBuilds([]target.IndexBuildStatus{
+		{Collection: "users", IndexName: "a", Phase: "complete"},
+		{Collection: "users", IndexName: "b", Phase: "complete"},
+		{Collection: "orders", IndexName: "c", Phase: "complete"},
+		{Collection: "orders", IndexName: "d", Phase: "building", Progress: 20},
+	})
+	p = readProgress(t, path)
+	if p.Step != RunStepIndexes || p.PercentComplete != 80 {
+		t.Errorf("building = %+v", p)
+	}
+	if c := p.Collections[0]; c.State != "complete" || c.Done != 2 || c.Total != 2 {
+		t.Errorf("users = %+v", c)
+	}
+	if c := p.Collections[1]; c.State != "building" || c.Done != 1 || c.PercentComplete != 50 {
+		t.Errorf("orders = %+v", c)
+	}
+}
+
+func TestProgressFile_Nil(t *testing.T) {
+	var f *progressFile
+	f.stepStarted(RunStepMigrate)
+	f.migration(&migration.Status{})
+	f.finish(nil)
+}
+
+func TestEstimateRemain(t *testing.T) {
+	tests := []struct {
+		elapsed time.Duration
+		percent float64
+		want    time.Duration
+	}{
+		{elapsed: time.Minute, percent: 0, want: 0},
+		{elapsed: time.Minute, percent: 25, want: 3 * time.Minute},
+		{elapsed: time.Minute, percent: 50, want: time.Minute},
+		{elapsed: time.Minute, percent: 100, want: 0},
+	}
+	for _, tt := range tests {
+		if got := estimateRemain(tt.elapsed, tt.percent); got != tt.want {
+			t.Errorf("estimateRemain(%v, %v) = %v, want %v", tt.elapsed, tt.percent, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/hooks"
+	"github.com/reloquent/reloquent/internal/logging"
+)
+
+// ErrNoConfigFile is returned when reloading an engine started without a
+// config file.
+var ErrNoConfigFile = errors.New("no config file to reload; start with --config")
+
+// ConfigReload reports what reloading the config file changed.
+type ConfigReload struct {
+	Path            string   `json:"path"`
+	Changed         []string `json:"changed"`
+	RestartRequired []string `json:"restart_required,omitempty"` // sections that differ but are read at startup
+
+	// Config is the file as reloaded, for applying to other engines.
+	Config *config.Config `json:"-"`
+}
+
+// SetConfigPath records the file the engine's config was loaded from, so
+// ReloadConfig can read it again.
+func (e *Engine) SetConfigPath(path string) {
+	e.configPath = path
+}
+
+// ReloadConfig re-reads the config file and applies the settings that can
+// change while the engine runs. The file is checked first; if it does not
+// load, the current config stays in place.
+func (e *Engine) ReloadConfig() (*ConfigReload, error) {
+	if e.configPath == "" {
+		return nil, ErrNoConfigFile
+	}
+	next, err := config.Load(e.configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := hooks.Validate(next.Hooks); err != nil {
+		return nil, fmt.Errorf("invalid hooks: %w", err)
+	}
+	if _, err := logging.NewLevels(next.Logging.Level, next.Logging.Components); err != nil {
+		return nil, fmt.Errorf("invalid logging: %w", err)
+	}
+	r := e.ApplyConfig(next)
+	r.Path = e.configPath
+	return r, nil
+}
+
+// ApplyConfig applies next's reloadable settings (see config.Config.Reload)
+// and records an audit event listing what changed. Operations already
+// running, such as a migration, keep the settings they started with; the
+// next one uses the new values.
+func (e *Engine) ApplyConfig(next *config.Config) *ConfigReload {
+	r := &ConfigReload{Config: next}
+	e.mu.Lock()
+	if e.Config == nil {
+		e.Config = next
+		r.Changed = []string{"config loaded"}
+	} else {
+		e.Config, r.Changed, r.RestartRequired = e.Config.Reload(next)
+	}
+	logCfg := e.Config.Logging
+	e.mu.Unlock()
+	if e.logLevels != nil {
+		if err := e.logLevels.Configure(logCfg.Level, logCfg.Components); err != nil {
+			e.Logger.Warn("log levels not changed", "error", err)
+		}
+	}
+
+	detail := "no changes"
+	if len(r.Changed) > 0 {
+		detail = strings.Join(r.Changed, "; ")
+	}
+	if len(r.RestartRequired) > 0 {
+		detail += "; restart needed for " + strings.Join(r.RestartRequired, ", ")
+	}
+	e.Logger.Info("config reloaded", "changed", r.Changed, "restart_required", r.RestartRequired)
+	e.audit("config_reloaded", detail)
+	return r
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/logging"
+)
+
+func TestReloadConfig(t *testing.T) {
+	e := testEngine(t)
+	if _, err := e.ReloadConfig(); !errors.Is(err, ErrNoConfigFile) {
+		t.Fatalf("err = %v, want ErrNoConfigFile", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "reloquent.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("version: 1\nlogging:\n  level: debug\n  components:\n    aws: warn\nsource:\n  host: db2\n  max_connections: 8\n")
+	levels, err := logging.NewLevels("info", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.SetConfigPath(path)
+	e.SetLogLevels(levels)
+
+	r, err := e.ReloadConfig()
+	if err != nil {
+		t.Fatalf("ReloadConfig: %v", err)
+	}
+	if r.Path != path || !strings.Contains(strings.Join(r.Changed, ";"), "source.max_connections: 0 -> 8") {
+		t.Errorf("reload = %+v", r)
+	}
+	if strings.Join(r.RestartRequired, ",") != "source,logging" {
+		t.Errorf("restart required = %v", r.RestartRequired)
+	}
+	if e.Config.Source.MaxConnections != 8 || e.Config.Source.Host != "" {
+		t.Errorf("source = %+v, want only max_connections reloaded", e.Config.Source)
+	}
+	if levels.Level("") != slog.LevelDebug || levels.Level(logging.ComponentAWS) != slog.LevelWarn {
+		t.Errorf("log levels = %v, aws %v; want debug, aws warn", levels.Level(""), levels.Level(logging.ComponentAWS))
+	}
+
+	h, err := e.History(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Audit) != 1 || h.Audit[0].Action != "config_reloaded" ||
+		!strings.Contains(h.Audit[0].Detail, "logging.level: \"\" -> debug") ||
+		!strings.Contains(h.Audit[0].Detail, "logging.components changed") ||
+		!strings.Contains(h.Audit[0].Detail, "restart needed for source, logging") {
+		t.Errorf("audit = %+v", h.Audit)
+	}
+
+	// A file that does not load keeps the running config
+	write("version: 1\nhooks:\n  - name: bad\n    when: sometime\n    command: [x]\n")
+	if _, err := e.ReloadConfig(); err == nil {
+		t.Fatal("expected invalid hooks to be rejected")
+	}
+	if e.Config.Source.MaxConnections != 8 {
+		t.Error("config changed by a failed reload")
+	}
+	write("version: 1\nlogging:\n  components:\n    storage: debug\n")
+	if _, err := e.ReloadConfig(); err == nil || !strings.Contains(err.Error(), "invalid logging") {
+		t.Fatalf("err = %v, want unknown component rejected", err)
+	}
+}
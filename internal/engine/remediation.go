@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/postmigration"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+// ErrNoValidation is returned when no validation has run yet.
+var ErrNoValidation = errors.New("no validation results; run validation first")
+
+// ErrInvalidRemediation is returned for a remediation that cannot be
+// recorded: an unknown action, a variance accepted without a
+// justification, or a collection that did not fail.
+var ErrInvalidRemediation = errors.New("invalid remediation")
+
+// ValidationFailure is a collection that failed the last validation: the
+// probable causes, the suggested remediation and the decision recorded, if
+// any.
+type ValidationFailure struct {
+	validation.Diagnosis
+	Suggested   string             `json:"suggested"`
+	Remediation *state.Remediation `json:"remediation,omitempty"`
+}
+
+// ValidationFailures diagnoses each collection that failed the last
+// validation.
+func (e *Engine) ValidationFailures() ([]ValidationFailure, error) {
+	result, err := e.lastValidation()
+	if err != nil {
+		return nil, err
+	}
+	var ests []mapping.CollectionSizeEstimate
+	if e.Schema != nil && e.Mapping != nil {
+		ests = mapping.EstimateSizesMeasured(e.Schema, e.Mapping, e.GetTypeMap(), e.embedCounts())
+	}
+
+	var failures []ValidationFailure
+	for _, d := range validation.DiagnoseResult(result, e.Mapping, ests) {
+		f := ValidationFailure{Diagnosis: d, Suggested: d.Suggested()}
+		if e.State != nil {
+			if r, ok := e.State.Remediations[d.Collection]; ok {
+				f.Remediation = &r
+			}
+		}
+		failures = append(failures, f)
+	}
+	return failures, nil
+}
+
+// Remediate records the decision for a collection that failed validation.
+// ActionRemigrate empties the collection in the target, and the rest of its
+// consistency group with it, and starts migrating them again; ActionAccept
+// needs a justification; ActionAdjustMapping only records the decision, for
+// the mapping to be changed and the collection re-migrated afterwards.
+func (e *Engine) Remediate(ctx context.Context, collection, action, justification string, callback migration.StatusCallback) (*state.Remediation, error) {
+	if !validation.ValidAction(action) {
+		return nil, fmt.Errorf("%w: unknown action %q; use one of %s", ErrInvalidRemediation, action, strings.Join(validation.Actions, ", "))
+	}
+	justification = strings.TrimSpace(justification)
+	if action == validation.ActionAccept && justification == "" {
+		return nil, fmt.Errorf("%w: accepting a variance needs a justification", ErrInvalidRemediation)
+	}
+	if e.State == nil {
+		return nil, fmt.Errorf("no state loaded")
+	}
+	failures, err := e.ValidationFailures()
+	if err != nil {
+		return nil, err
+	}
+	var failure *ValidationFailure
+	for i := range failures {
+		if failures[i].Collection == collection {
+			failure = &failures[i]
+		}
+	}
+	if failure == nil {
+		return nil, fmt.Errorf("%w: collection %s did not fail the last validation", ErrInvalidRemediation, collection)
+	}
+
+	r := state.Remediation{Action: action, Justification: justification}
+	for _, c := range failure.Causes {
+		r.Causes = append(r.Causes, c.Message)
+	}
+	if action == validation.ActionRemigrate {
+		if err := e.remigrate(ctx, collection, callback); err != nil {
+			return nil, err
+		}
+	}
+	e.State.SetRemediation(collection, r)
+	if err := e.SaveState(); err != nil {
+		return nil, err
+	}
+	e.audit("validation_remediated", fmt.Sprintf("%s: %s", collection, action))
+	r = e.State.Remediations[collection]
+	return &r, nil
+}
+
+// remigrate empties the collection, with the other members of its
+// consistency group, forgets their checkpoints and migrates them again.
+func (e *Engine) remigrate(ctx context.Context, collection string, callback migration.StatusCallback) error {
+	e.mu.Lock()
+	running := e.migrationCancel != nil
+	e.mu.Unlock()
+	if running {
+		return fmt.Errorf("migration already running")
+	}
+	if e.Config == nil {
+		return fmt.Errorf("config required")
+	}
+
+	cols := []string{collection}
+	if e.Mapping != nil {
+		if g := e.Mapping.GroupOf(collection); g != nil {
+			cols = g.Collections
+		}
+	}
+
+	op, release, err := e.targetConn(ctx)
+	if err != nil {
+		return fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	defer release()
+	emptier, ok := op.(target.CollectionEmptier)
+	if !ok {
+		return fmt.Errorf("the target cannot empty collections; drop %s and migrate it again", strings.Join(cols, ", "))
+	}
+	for _, c := range cols {
+		if _, err := emptier.EmptyCollection(ctx, c); err != nil {
+			return err
+		}
+		e.State.ClearCollection(c)
+	}
+	if err := e.SaveState(); err != nil {
+		return err
+	}
+	return e.RetryMigration(ctx, cols, callback)
+}
+
+// lastValidation returns the result of the last validation, from memory or
+// from the report it saved.
+func (e *Engine) lastValidation() (*validation.Result, error) {
+	if r := e.ValidationResults(); r != nil {
+		return r, nil
+	}
+	if e.State == nil || e.State.ValidationReportPath == "" {
+		return nil, ErrNoValidation
+	}
+	return postmigration.ReadValidationReport(e.State.ValidationReportPath)
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+func TestRemediate(t *testing.T) {
+	e := testEngine(t)
+	if _, err := e.ValidationFailures(); !errors.Is(err, ErrNoValidation) {
+		t.Fatalf("ValidationFailures before validation: %v, want ErrNoValidation", err)
+	}
+
+	e.State = state.New()
+	e.validationResult = &validation.Result{Status: "FAIL", Collections: []validation.CollectionResult{
+		{Name: "customers", Status: "PASS"},
+		{Name: "orders", Status: "FAIL", RowCountCheck: &validation.RowCountCheck{SourceCount: 10, TargetCount: 9}},
+	}}
+
+	failures, err := e.ValidationFailures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 1 || failures[0].Collection != "orders" || failures[0].Suggested != validation.ActionRemigrate || failures[0].Remediation != nil {
+		t.Fatalf("failures = %+v, want orders to re-migrate", failures)
+	}
+
+	for _, tt := range []struct {
+		name, collection, action, justification string
+	}{
+		{"unknown action", "orders", "ignore", ""},
+		{"no justification", "orders", validation.ActionAccept, "  "},
+		{"did not fail", "customers", validation.ActionAccept, "fine"},
+	} {
+		if _, err := e.Remediate(context.Background(), tt.collection, tt.action, tt.justification, nil); !errors.Is(err, ErrInvalidRemediation) {
+			t.Errorf("%s: err = %v, want ErrInvalidRemediation", tt.name, err)
+		}
+	}
+
+	r, err := e.Remediate(context.Background(), "orders", validation.ActionAccept, "one test order deleted", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Action != validation.ActionAccept || r.Justification != "one test order deleted" || len(r.Causes) != 1 || r.DecidedAt.IsZero() {
+		t.Errorf("remediation = %+v", r)
+	}
+	failures, _ = e.ValidationFailures()
+	if failures[0].Remediation == nil || failures[0].Remediation.Action != validation.ActionAccept {
+		t.Errorf("failure = %+v, want the decision recorded", failures[0])
+	}
+}
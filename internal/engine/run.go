@@ -0,0 +1,359 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/discovery"
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/logging"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+// Steps of a headless run.
+const (
+	RunStepDiscover     = "discover"
+	RunStepDesign       = "design"
+	RunStepPreMigration = "premigration"
+	RunStepMigrate      = "migrate"
+	RunStepValidate     = "validate"
+	RunStepIndexes      = "indexes"
+)
+
+// RunSteps lists the steps of a headless run in the order they run.
+var RunSteps = []string{
+	RunStepDiscover,
+	RunStepDesign,
+	RunStepPreMigration,
+	RunStepMigrate,
+	RunStepValidate,
+	RunStepIndexes,
+}
+
+// runProgressInterval spaces out the progress lines logged while a
+// migration or index build runs.
+const runProgressInterval = 10 * time.Second
+
+// RunStepResult is the outcome of one step of a headless run.
+type RunStepResult struct {
+	Step     string        `json:"step"`
+	Status   string        `json:"status"` // ok, failed or not_run
+	Detail   string        `json:"detail,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RunReport is the outcome of a headless run.
+type RunReport struct {
+	Steps []RunStepResult `json:"steps"`
+}
+
+// Failed returns the step that failed, or nil when every step ran.
+func (r *RunReport) Failed() *RunStepResult {
+	for i := range r.Steps {
+		if r.Steps[i].Status == "failed" {
+			return &r.Steps[i]
+		}
+	}
+	return nil
+}
+
+// RunPlan returns the steps the run section of the config selects, in
+// pipeline order.
+func RunPlan(cfg config.RunConfig) ([]string, error) {
+	if len(cfg.Steps) == 0 {
+		return RunSteps, nil
+	}
+	for _, s := range cfg.Steps {
+		if !slices.Contains(RunSteps, s) {
+			return nil, fmt.Errorf("unknown run step %q (want one of %v)", s, RunSteps)
+		}
+	}
+	var steps []string
+	for _, s := range RunSteps {
+		if slices.Contains(cfg.Steps, s) {
+			steps = append(steps, s)
+		}
+	}
+	return steps, nil
+}
+
+// Run takes the project through the given steps without prompts, answering
+// each wizard decision from the run section of the config. Progress is
+// logged as structured records, kept as a RunProgress in run.progress_file
+// when it is set, and passed to the Renderer when there is one. It stops at
+// the first failing step; the report lists the steps not run, and the error
+// names the failed step.
+func (e *Engine) Run(ctx context.Context, steps []string) (*RunReport, error) {
+	if e.Config == nil {
+		return nil, fmt.Errorf("no config set")
+	}
+	vcfg := e.runValidationConfig()
+	if err := vcfg.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := e.LoadState(); err != nil {
+		return nil, err
+	}
+	if path := e.Config.Run.ProgressFile; path != "" || e.Renderer != nil {
+		if path != "" {
+			path = config.ExpandHome(path)
+		}
+		e.progress = newProgressFile(path, steps, func(err error) {
+			e.Logger.Warn("progress file not written", "path", path, "error", err)
+		})
+		e.progress.renderer = e.Renderer
+		defer func() { e.progress = nil }()
+	}
+	runID, endRun := e.startRun("run")
+	e.runID = runID
+	defer func() { e.runID = 0 }()
+	rep, err := e.runSteps(ctx, steps, e.runStep)
+	e.progress.finish(err)
+	endRun(runRecordResult(rep, err))
+	return rep, err
+}
+
+// runRecordResult is the status and report a run is recorded with.
+func runRecordResult(rep *RunReport, err error) (status, report string) {
+	status = "ok"
+	if err != nil {
+		status = "failed"
+	}
+	data, _ := json.Marshal(rep)
+	return status, string(data)
+}
+
+func (e *Engine) runSteps(ctx context.Context, steps []string, run func(context.Context, string) (string, error)) (*RunReport, error) {
+	rep := &RunReport{}
+	var failed error
+	for _, step := range steps {
+		if failed != nil {
+			res := RunStepResult{Step: step, Status: "not_run"}
+			e.progress.stepFinished(res)
+			rep.Steps = append(rep.Steps, res)
+			continue
+		}
+		e.Logger.Info("step started", "step", step)
+		e.progress.stepStarted(step)
+		start := time.Now()
+		detail, err := run(ctx, step)
+		res := RunStepResult{Step: step, Status: "ok", Detail: detail, Duration: time.Since(start)}
+		if err != nil {
+			res.Status = "failed"
+			res.Error = err.Error()
+			failed = fmt.Errorf("%s: %w", step, err)
+			e.Logger.Error("step failed", "step", step, "duration", res.Duration.Round(time.Millisecond).String(), "error", err)
+			e.audit("run_step_failed", step+": "+res.Error)
+		} else {
+			e.Logger.Info("step completed", "step", step, "duration", res.Duration.Round(time.Millisecond).String(), "detail", detail)
+			e.audit("run_step_completed", step)
+		}
+		e.progress.stepFinished(res)
+		rep.Steps = append(rep.Steps, res)
+	}
+	return rep, failed
+}
+
+func (e *Engine) runStep(ctx context.Context, step string) (string, error) {
+	switch step {
+	case RunStepDiscover:
+		return e.runDiscover(ctx)
+	case RunStepDesign:
+		return e.runDesign()
+	case RunStepPreMigration:
+		if err := e.PreMigrationPrepare(ctx); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d target collections ready", len(e.Mapping.Collections)), nil
+	case RunStepMigrate:
+		return e.runMigrate(ctx)
+	case RunStepValidate:
+		return e.runValidate(ctx)
+	case RunStepIndexes:
+		return e.runIndexes(ctx)
+	}
+	return "", fmt.Errorf("unknown run step %q", step)
+}
+
+// runDiscover discovers the source, saves the connections with the
+// project, and applies the table selection and type overrides of the run
+// config.
+func (e *Engine) runDiscover(ctx context.Context) (string, error) {
+	s, err := e.Discover(ctx, func(p discovery.Progress) {
+		if p.Table == "" && p.TablesTotal > 0 && p.TablesDone == p.TablesTotal {
+			e.log(logging.ComponentDiscovery).Info("discovery phase completed", "phase", p.Phase, "tables", p.TablesTotal, "percent", int(p.Percent))
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := e.saveConnections(); err != nil {
+		return "", err
+	}
+
+	tables := e.Config.Run.Tables
+	if len(tables) == 0 {
+		for _, t := range s.Tables {
+			tables = append(tables, t.Name)
+		}
+	}
+	if err := e.SelectTables(tables); err != nil {
+		return "", err
+	}
+	if len(e.Config.Run.TypeOverrides) > 0 {
+		if err := e.SaveTypeMapOverrides(e.Config.Run.TypeOverrides); err != nil {
+			return "", fmt.Errorf("applying type overrides: %w", err)
+		}
+	}
+	e.State.CompleteStep(state.StepSourceConnection, state.StepTargetConnection)
+	e.State.CompleteStep(state.StepTargetConnection, state.StepTableSelection)
+	e.State.CompleteStep(state.StepTableSelection, state.StepDenormalization)
+	if err := e.SaveState(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d of %d tables selected", len(tables), len(s.Tables)), nil
+}
+
+// saveConnections records the run's source and target with the project, as
+// the wizard does. Discover has already saved the schema.
+func (e *Engine) saveConnections() error {
+	st, err := e.LoadState()
+	if err != nil {
+		return err
+	}
+	src := e.Config.Source
+	st.SourceConfig = &src
+	tgt := e.Config.Target
+	st.TargetConfig = &tgt
+	return e.SaveState()
+}
+
+// runDesign saves the mapping named by the run config, or keeps the
+// project's saved mapping when none is named.
+func (e *Engine) runDesign() (string, error) {
+	if path := e.Config.Run.Mapping; path != "" {
+		m, err := mapping.LoadYAML(config.ExpandHome(path))
+		if err != nil {
+			return "", fmt.Errorf("loading mapping: %w", err)
+		}
+		if err := e.SaveMapping(m); err != nil {
+			return "", err
+		}
+	} else if e.Mapping == nil {
+		return "", fmt.Errorf("no mapping: set run.mapping to a mapping file or design one in the wizard first")
+	} else if err := e.ValidateMapping(e.Mapping); err != nil {
+		return "", err
+	}
+	if _, err := e.LoadState(); err != nil {
+		return "", err
+	}
+	e.State.CompleteStep(state.StepDenormalization, state.StepTypeMapping)
+	e.State.CompleteStep(state.StepTypeMapping, state.StepSizing)
+	if err := e.SaveState(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d collections mapped", len(e.Mapping.Collections)), nil
+}
+
+func (e *Engine) runMigrate(ctx context.Context) (string, error) {
+	migrate := e.Migrate
+	if e.Config.Run.Delta {
+		migrate = e.MigrateDelta
+	}
+	var mu sync.Mutex
+	var last time.Time
+	status, err := migrate(ctx, func(s *migration.Status) {
+		e.progress.migration(s)
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Since(last) < runProgressInterval {
+			return
+		}
+		last = time.Now()
+		e.log(logging.ComponentMigration).Info("migration progress", "step", RunStepMigrate,
+			"percent", fmt.Sprintf("%.1f", s.Overall.PercentComplete),
+			"docs_written", s.Overall.DocsWritten, "docs_total", s.Overall.DocsTotal)
+	})
+	if err != nil {
+		return "", err
+	}
+	if status.Phase != "completed" {
+		return "", fmt.Errorf("migration %s: %v", status.Phase, status.Errors)
+	}
+	return fmt.Sprintf("%d documents written to %d collections", status.Overall.DocsWritten, len(status.Collections)), nil
+}
+
+// runValidationConfig is the validation config from the run section.
+func (e *Engine) runValidationConfig() validation.Config {
+	r := e.Config.Run
+	return validation.Config{
+		Mode:        r.ValidationMode,
+		Parallelism: r.ValidationParallelism,
+		TimeBudget:  r.ValidationTimeBudget,
+		Priority:    r.ValidationPriority,
+		Referential: r.ValidationReferential,
+		RulesFile:   r.ValidationRules,
+	}
+}
+
+func (e *Engine) runValidate(ctx context.Context) (string, error) {
+	vcfg := e.runValidationConfig()
+	result, err := e.Validate(ctx, vcfg, func(collection, checkType string, passed bool) {
+		e.progress.validationCheck(collection, passed)
+		if !passed {
+			e.log(logging.ComponentValidation).Warn("validation check failed", "step", RunStepValidate, "collection", collection, "check", checkType)
+		}
+	}, e.progress.validationChunk)
+	if err != nil {
+		return "", err
+	}
+	if result.Status != "PASS" {
+		return "", fmt.Errorf("validation %s", result.Status)
+	}
+	return fmt.Sprintf("%d collections match", len(result.Collections)), nil
+}
+
+// runIndexes saves the index plan named by the run config, if any, then
+// builds the plan and waits for it.
+func (e *Engine) runIndexes(ctx context.Context) (string, error) {
+	if path := e.Config.Run.IndexPlan; path != "" {
+		plan, err := indexes.LoadYAML(config.ExpandHome(path))
+		if err != nil {
+			return "", fmt.Errorf("loading index plan: %w", err)
+		}
+		if err := e.SaveIndexPlan(plan); err != nil {
+			return "", err
+		}
+	}
+	var last time.Time
+	var built int
+	err := e.RunIndexBuilds(ctx, func(statuses []target.IndexBuildStatus) {
+		e.progress.indexBuilds(statuses)
+		built = 0
+		for _, s := range statuses {
+			if s.Phase == "complete" {
+				built++
+			}
+		}
+		if time.Since(last) < runProgressInterval {
+			return
+		}
+		last = time.Now()
+		e.Logger.Info("index build progress", "step", RunStepIndexes,
+			"built", built, "total", len(statuses))
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d indexes built", built), nil
+}
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+func TestRunPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "default", want: RunSteps},
+		{name: "pipeline order", steps: []string{"validate", "migrate"}, want: []string{"migrate", "validate"}},
+		{name: "unknown", steps: []string{"migrate", "cutover"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RunPlan(config.RunConfig{Steps: tt.steps})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RunPlan = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSteps_StopsAtFailure(t *testing.T) {
+	e := testEngine(t)
+	var ran []string
+	rep, err := e.runSteps(context.Background(), RunSteps, func(_ context.Context, step string) (string, error) {
+		ran = append(ran, step)
+		if step == RunStepMigrate {
+			return "", errors.New("partial_failure")
+		}
+		return "done", nil
+	})
+	if err == nil || err.Error() != "migrate: partial_failure" {
+		t.Fatalf("err = %v, want the failed step named", err)
+	}
+	if want := []string{"discover", "design", "premigration", "migrate"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+	want := []string{"ok", "ok", "ok", "failed", "not_run", "not_run"}
+	for i, s := range rep.Steps {
+		if s.Status != want[i] {
+			t.Errorf("%s status = %s, want %s", s.Step, s.Status, want[i])
+		}
+	}
+	if f := rep.Failed(); f == nil || f.Step != RunStepMigrate || f.Error != "partial_failure" {
+		t.Errorf("Failed() = %+v", f)
+	}
+}
+
+func TestRun_InvalidValidationMode(t *testing.T) {
+	e := testEngine(t)
+	e.Config.Run.ValidationMode = "fuzzy"
+	if _, err := e.Run(context.Background(), RunSteps); err == nil || !strings.Contains(err.Error(), "fuzzy") {
+		t.Errorf("err = %v, want the bad mode rejected before any step runs", err)
+	}
+}
+
+func TestRunDesign(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = testSchema()
+	if _, err := e.LoadState(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := e.runDesign(); err == nil {
+		t.Fatal("design without a mapping should fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "migration-mapping.yaml")
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "users", SourceTable: "users"}}}
+	if err := m.WriteYAML(path); err != nil {
+		t.Fatal(err)
+	}
+	e.Config.Run.Mapping = path
+	detail, err := e.runDesign()
+	if err != nil {
+		t.Fatalf("runDesign: %v", err)
+	}
+	if detail != "1 collections mapped" {
+		t.Errorf("detail = %q", detail)
+	}
+	st, _ := e.LoadState()
+	if st.MappingPath == "" || !st.IsStepComplete(state.StepDenormalization) || st.CurrentStep != state.StepSizing {
+		t.Errorf("state = %+v", st)
+	}
+	if _, err := os.Stat(st.MappingPath); err != nil {
+		t.Errorf("mapping not saved with the project: %v", err)
+	}
+
+	// A saved mapping is kept when the config names none
+	e.Config.Run.Mapping = ""
+	if _, err := e.runDesign(); err != nil {
+		t.Errorf("runDesign with the saved mapping: %v", err)
+	}
+}
+
+func TestSaveConnections(t *testing.T) {
+	e := testEngine(t)
+	e.Config.Source = config.SourceConfig{Type: "postgresql", Host: "db", Database: "app"}
+	if err := e.saveConnections(); err != nil {
+		t.Fatal(err)
+	}
+	st, _ := e.LoadState()
+	if st.SourceConfig == nil || st.SourceConfig.Host != "db" {
+		t.Errorf("source config = %+v", st.SourceConfig)
+	}
+}
+
+func TestRunSteps_RecordsHistory(t *testing.T) {
+	e := testEngine(t)
+	_, endRun := e.startRun("run")
+	rep, err := e.runSteps(context.Background(), []string{RunStepDiscover, RunStepDesign}, func(_ context.Context, step string) (string, error) {
+		if step == RunStepDesign {
+			return "", errors.New("no mapping")
+		}
+		return "", nil
+	})
+	endRun(runRecordResult(rep, err))
+	endJob := e.startJob("index_builds")
+	endJob("complete", "3 indexes")
+
+	h, err := e.History(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Store != "yaml" || len(h.Runs) != 1 || h.Runs[0].Status != "failed" || !strings.Contains(h.Runs[0].Report, `"no mapping"`) {
+		t.Errorf("runs = %+v", h.Runs)
+	}
+	if len(h.Audit) != 2 || h.Audit[0].Action != "run_step_failed" || h.Audit[1].Detail != RunStepDiscover {
+		t.Errorf("audit = %+v", h.Audit)
+	}
+	if len(h.Jobs) != 1 || h.Jobs[0].Status != "complete" || h.Jobs[0].Detail != "3 indexes" {
+		t.Errorf("jobs = %+v", h.Jobs)
+	}
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// lineCollections is how many running collections a progress line names.
+const lineCollections = 5
+
+// LineRenderer prints a headless run's progress as plain lines, for CI logs
+// and terminals without a TUI: steps starting and finishing, phase changes,
+// failures and, unless Quiet, a progress line every Interval with the
+// running step's percentage, throughput, estimated time left and the
+// collections in progress. Each line starts with the time since the run
+// started.
+type LineRenderer struct {
+	W        io.Writer
+	Quiet    bool          // print only phase changes, step results and failures
+	Interval time.Duration // between progress lines; 10s when zero
+
+	lastProgress time.Time // when the last progress line was printed
+}
+
+// NewLineRenderer returns a renderer printing to w.
+func NewLineRenderer(w io.Writer, quiet bool) *LineRenderer {
+	return &LineRenderer{W: w, Quiet: quiet}
+}
+
+// Render prints the line for the event, if it has one.
+func (r *LineRenderer) Render(ev RunEvent) {
+	var line string
+	switch ev.Kind {
+	case RunEventStepStarted:
+		line = ev.Step + " started"
+	case RunEventPhase:
+		line = ev.Step + " phase " + ev.Progress.Phase
+	case RunEventCollectionFailed:
+		line = fmt.Sprintf("%s %s failed: %s", ev.Step, ev.Collection, ev.Error)
+	case RunEventStepFinished:
+		line = stepLine(ev.Result)
+	case RunEventFinished:
+		line = "run " + ev.Progress.Status
+		if ev.Error != "" {
+			line += ": " + ev.Error
+		} else {
+			line += " in " + ev.Time.Sub(ev.Progress.StartedAt).Round(time.Second).String()
+		}
+	case RunEventProgress:
+		interval := r.Interval
+		if interval == 0 {
+			interval = runProgressInterval
+		}
+		if r.Quiet || ev.Time.Sub(r.lastProgress) < interval {
+			return
+		}
+		r.lastProgress = ev.Time
+		line = progressLine(ev.Step, ev.Progress)
+	default:
+		return
+	}
+	fmt.Fprintf(r.W, "[%s] %s\n", clock(ev.Time.Sub(ev.Progress.StartedAt)), line)
+}
+
+func stepLine(res *RunStepResult) string {
+	switch res.Status {
+	case "not_run":
+		return res.Step + " not run"
+	case "failed":
+		return fmt.Sprintf("%s failed after %s: %s", res.Step, res.Duration.Round(time.Second), res.Error)
+	}
+	line := fmt.Sprintf("%s ok in %s", res.Step, res.Duration.Round(time.Second))
+	if res.Detail != "" {
+		line += ": " + res.Detail
+	}
+	return line
+}
+
+// progressLine is e.g. "migrate 42.0% | 1250 docs/s 3.2 MB/s | ETA 4m10s |
+// orders 61.5%, items 20.0%".
+func progressLine(step string, p RunProgress) string {
+	parts := []string{fmt.Sprintf("%s %.1f%%", step, p.PercentComplete)}
+	if p.DocsPerSecond > 0 {
+		rate := fmt.Sprintf("%.0f docs/s", p.DocsPerSecond)
+		if p.ThroughputMBps > 0 {
+			rate += fmt.Sprintf(" %.1f MB/s", p.ThroughputMBps)
+		}
+		parts = append(parts, rate)
+	}
+	if p.EstimatedRemain > 0 {
+		parts = append(parts, "ETA "+p.EstimatedRemain.String())
+	}
+	var active []string
+	for _, c := range p.Collections {
+		if c.PercentComplete <= 0 || c.PercentComplete >= 100 || c.State == "failed" {
+			continue
+		}
+		active = append(active, fmt.Sprintf("%s %.1f%%", c.Name, c.PercentComplete))
+	}
+	if n := len(active); n > lineCollections {
+		active = append(active[:lineCollections], fmt.Sprintf("%d more", n-lineCollections))
+	}
+	if len(active) > 0 {
+		parts = append(parts, strings.Join(active, ", "))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// clock formats an elapsed time as hh:mm:ss.
+func clock(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	s := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
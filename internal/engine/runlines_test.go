@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/migration"
+)
+
+type recordRenderer struct{ events []RunEvent }
+
+func (r *recordRenderer) Render(ev RunEvent) { r.events = append(r.events, ev) }
+
+func TestProgressFile_Events(t *testing.T) {
+	rec := &recordRenderer{}
+	f := newProgressFile("", []string{RunStepMigrate, RunStepValidate}, func(err error) { t.Errorf("write: %v", err) })
+	f.renderer = rec
+
+	f.stepStarted(RunStepMigrate)
+	status := &migration.Status{
+		Phase:   "running",
+		Overall: migration.ProgressInfo{DocsWritten: 50, DocsTotal: 200, PercentComplete: 25, ThroughputMBps: 2.5},
+		Collections: []migration.CollectionStatus{
+			{Name: "users", State: "running", PercentComplete: 40},
+			{Name: "orders", State: "failed", Error: "write conflict"},
+		},
+	}
+	f.migration(status)
+	f.migration(status) // same phase, failure already reported
+	f.stepFinished(RunStepResult{Step: RunStepMigrate, Status: "ok", Detail: "2 collections"})
+	f.stepFinished(RunStepResult{Step: RunStepValidate, Status: "not_run"})
+	f.finish(nil)
+
+	var kinds []string
+	for _, ev := range rec.events {
+		kinds = append(kinds, ev.Kind)
+	}
+	want := []string{
+		RunEventStepStarted, RunEventPhase, RunEventCollectionFailed, RunEventProgress,
+		RunEventProgress, RunEventStepFinished, RunEventStepFinished, RunEventFinished,
+	}
+	if strings.Join(kinds, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v, want %v", kinds, want)
+	}
+	if ev := rec.events[2]; ev.Collection != "orders" || ev.Error != "write conflict" {
+		t.Errorf("failure = %+v", ev)
+	}
+	p := rec.events[3].Progress
+	if p.PercentComplete != 25 || p.ThroughputMBps != 2.5 || p.DocsPerSecond <= 0 || len(p.Collections) != 2 {
+		t.Errorf("progress = %+v", p)
+	}
+	if rec.events[7].Progress.Status != "completed" {
+		t.Errorf("final = %+v", rec.events[7].Progress)
+	}
+}
+
+func TestLineRenderer(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	progress := RunProgress{
+		StartedAt:       start,
+		PercentComplete: 42,
+		DocsPerSecond:   1250,
+		ThroughputMBps:  3.2,
+		EstimatedRemain: 4*time.Minute + 10*time.Second,
+		Collections: []CollectionProgress{
+			{Name: "orders", State: "running", PercentComplete: 61.5},
+			{Name: "users", State: "complete", PercentComplete: 100},
+			{Name: "items", State: "running", PercentComplete: 20},
+		},
+	}
+	events := []RunEvent{
+		{Kind: RunEventStepStarted, Time: at(0), Step: "migrate"},
+		{Kind: RunEventPhase, Time: at(time.Second), Step: "migrate", Progress: RunProgress{Phase: "running"}},
+		{Kind: RunEventProgress, Time: at(2 * time.Second), Step: "migrate", Progress: progress},
+		{Kind: RunEventProgress, Time: at(5 * time.Second), Step: "migrate", Progress: progress}, // throttled
+		{Kind: RunEventCollectionFailed, Time: at(6 * time.Second), Step: "migrate", Collection: "orders", Error: "write conflict"},
+		{Kind: RunEventStepFinished, Time: at(90 * time.Second), Result: &RunStepResult{Step: "migrate", Status: "ok", Detail: "2 collections", Duration: 90 * time.Second}},
+		{Kind: RunEventStepFinished, Time: at(95 * time.Second), Result: &RunStepResult{Step: "validate", Status: "failed", Error: "FAIL", Duration: 5 * time.Second}},
+		{Kind: RunEventStepFinished, Time: at(95 * time.Second), Result: &RunStepResult{Step: "indexes", Status: "not_run"}},
+		{Kind: RunEventFinished, Time: at(3700 * time.Second), Error: "validate: FAIL", Progress: RunProgress{Status: "failed"}},
+	}
+	for i := range events {
+		events[i].Progress.StartedAt = start
+	}
+
+	tests := []struct {
+		name  string
+		quiet bool
+		want  []string
+	}{
+		{
+			name: "verbose",
+			want: []string{
+				"[00:00:00] migrate started",
+				"[00:00:01] migrate phase running",
+				"[00:00:02] migrate 42.0% | 1250 docs/s 3.2 MB/s | ETA 4m10s | orders 61.5%, items 20.0%",
+				"[00:00:06] migrate orders failed: write conflict",
+				"[00:01:30] migrate ok in 1m30s: 2 collections",
+				"[00:01:35] validate failed after 5s: FAIL",
+				"[00:01:35] indexes not run",
+				"[01:01:40] run failed: validate: FAIL",
+			},
+		},
+		{
+			name:  "quiet",
+			quiet: true,
+			want: []string{
+				"[00:00:00] migrate started",
+				"[00:00:01] migrate phase running",
+				"[00:00:06] migrate orders failed: write conflict",
+				"[00:01:30] migrate ok in 1m30s: 2 collections",
+				"[00:01:35] validate failed after 5s: FAIL",
+				"[00:01:35] indexes not run",
+				"[01:01:40] run failed: validate: FAIL",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := NewLineRenderer(&buf, tt.quiet)
+			for _, ev := range events {
+				r.Render(ev)
+			}
+			got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("lines:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestProgressLine_ManyCollections(t *testing.T) {
+	p := RunProgress{PercentComplete: 10}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		p.Collections = append(p.Collections, CollectionProgress{Name: name, State: "running", PercentComplete: 10})
+	}
+	got := progressLine("migrate", p)
+	if want := "migrate 10.0% | a 10.0%, b 10.0%, c 10.0%, d 10.0%, e 10.0%, 2 more"; got != want {
+		t.Errorf("line = %q, want %q", got, want)
+	}
+}
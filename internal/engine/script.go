@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/reloquent/reloquent/internal/codegen"
+	"github.com/reloquent/reloquent/internal/logging"
+)
+
+// ErrStaleScript is returned when a Spark migration would start with a
+// mapping or type mapping other than the one the reviewed script was
+// generated from.
+var ErrStaleScript = errors.New("migration script is out of date")
+
+// CheckGeneratedScript compares the mapping hash of the script last written
+// by `reloquent generate` with the hash of the current mapping and type
+// mapping. The hash is read from the script's header, or from the state when
+// the script is gone. A mismatch fails with ErrStaleScript, or is logged
+// when migration.stale_script is warn. Nothing is checked before a script
+// has been generated.
+func (e *Engine) CheckGeneratedScript() error {
+	if e.State == nil || e.State.GeneratedScript == nil || e.Mapping == nil {
+		return nil
+	}
+	policy := ""
+	if e.Config != nil {
+		policy = e.Config.Migration.StaleScript
+	}
+	if policy != "" && policy != "fail" && policy != "warn" {
+		return fmt.Errorf("unsupported migration.stale_script %q (use fail or warn)", policy)
+	}
+	gs := e.State.GeneratedScript
+	generated := gs.MappingHash
+	if data, err := os.ReadFile(gs.Path); err == nil {
+		if h := codegen.ScriptMappingHash(data); h != "" {
+			generated = h
+		}
+	}
+	current, err := codegen.MappingHash(e.Mapping, e.GetTypeMap())
+	if err != nil {
+		return err
+	}
+	if generated == current {
+		return nil
+	}
+	msg := fmt.Sprintf("the mapping or type mapping changed since %s was generated at %s; run `reloquent generate` and review the new script",
+		gs.Path, gs.GeneratedAt.Format("2006-01-02 15:04"))
+	if policy == "warn" {
+		e.log(logging.ComponentMigration).Warn("migration script is out of date", "script", gs.Path, "detail", msg)
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrStaleScript, msg)
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/codegen"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+func TestCheckGeneratedScript(t *testing.T) {
+	e := testEngine(t)
+	e.State = state.New()
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{Name: "orders", SourceTable: "orders"}}}
+	e.TypeMap = typemap.DefaultPostgres()
+
+	// Nothing generated yet
+	if err := e.CheckGeneratedScript(); err != nil {
+		t.Fatalf("CheckGeneratedScript without a script: %v", err)
+	}
+
+	hash, err := codegen.MappingHash(e.Mapping, e.TypeMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), codegen.ScriptFile)
+	if err := os.WriteFile(path, []byte("\"\"\"\nMapping hash: "+hash+"\n\"\"\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e.State.SetGeneratedScript(path, hash)
+	if err := e.CheckGeneratedScript(); err != nil {
+		t.Errorf("CheckGeneratedScript with a current script: %v", err)
+	}
+
+	// The mapping moves on
+	e.Mapping.Collections[0].Name = "purchases"
+	err = e.CheckGeneratedScript()
+	if !errors.Is(err, ErrStaleScript) || !strings.Contains(err.Error(), "reloquent generate") {
+		t.Errorf("CheckGeneratedScript after a mapping change = %v, want ErrStaleScript", err)
+	}
+
+	// The header of an edited script wins over the recorded hash
+	e.State.GeneratedScript.MappingHash = "sha256:stale"
+	e.Mapping.Collections[0].Name = "orders"
+	if err := e.CheckGeneratedScript(); err != nil {
+		t.Errorf("CheckGeneratedScript reading the script header: %v", err)
+	}
+
+	// warn lets the run go on
+	e.Mapping.Collections[0].Name = "purchases"
+	e.Config.Migration.StaleScript = "warn"
+	if err := e.CheckGeneratedScript(); err != nil {
+		t.Errorf("CheckGeneratedScript with stale_script: warn = %v", err)
+	}
+	e.Config.Migration.StaleScript = "ignore"
+	if err := e.CheckGeneratedScript(); err == nil {
+		t.Error("expected an error for an unsupported stale_script")
+	}
+}
@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// ErrSnapshotPinned is returned when deleting the pinned baseline snapshot.
+var ErrSnapshotPinned = errors.New("schema snapshot is pinned as the design baseline; unpin it first")
+
+// SchemaSnapshot is a saved discovery and how the project uses it.
+type SchemaSnapshot struct {
+	schema.SnapshotInfo
+	Baseline bool `json:"baseline"` // the schema the design is built on
+	Pinned   bool `json:"pinned"`   // kept as the baseline by later discoveries
+}
+
+// recordSchema saves a discovered schema as a new snapshot. Unless a
+// snapshot is pinned, it becomes the design baseline.
+func (e *Engine) recordSchema(s *schema.Schema) error {
+	st, err := e.LoadState()
+	if err != nil {
+		return err
+	}
+	snaps := schema.OpenSnapshots(e.projectDir())
+	info, err := snaps.Record(s, st.SchemaPath)
+	if err != nil {
+		return err
+	}
+	e.audit("schema_discovered", fmt.Sprintf("snapshot %s, %d tables", info.ID, info.Tables))
+	if st.SchemaSnapshot != "" {
+		return nil
+	}
+	st.SchemaPath = snaps.Path(info.ID)
+	e.Schema = s
+	return e.SaveState()
+}
+
+// SchemaSnapshots lists the project's discovered schemas, newest first.
+// Deleted snapshots are included only when includeDeleted is set.
+func (e *Engine) SchemaSnapshots(includeDeleted bool) ([]SchemaSnapshot, error) {
+	st, err := e.LoadState()
+	if err != nil {
+		return nil, err
+	}
+	snaps := schema.OpenSnapshots(e.projectDir())
+	list, err := snaps.List(includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SchemaSnapshot, 0, len(list))
+	for _, info := range list {
+		out = append(out, SchemaSnapshot{
+			SnapshotInfo: info,
+			Baseline:     st.SchemaPath == snaps.Path(info.ID),
+			Pinned:       st.SchemaSnapshot == info.ID,
+		})
+	}
+	return out, nil
+}
+
+// PinSchemaSnapshot makes a snapshot the design baseline and keeps it there
+// when the source is discovered again.
+func (e *Engine) PinSchemaSnapshot(id string) error {
+	snaps := schema.OpenSnapshots(e.projectDir())
+	info, err := snaps.Get(id)
+	if err != nil {
+		return err
+	}
+	if info.Deleted() {
+		return fmt.Errorf("%w: %s", schema.ErrSnapshotDeleted, id)
+	}
+	s, err := snaps.Load(id)
+	if err != nil {
+		return err
+	}
+	st, err := e.LoadState()
+	if err != nil {
+		return err
+	}
+	st.SchemaSnapshot = id
+	st.SchemaPath = snaps.Path(id)
+	if err := e.SaveState(); err != nil {
+		return err
+	}
+	e.Schema = s
+	e.audit("schema_pinned", id)
+	return nil
+}
+
+// UnpinSchemaSnapshot returns the baseline to the latest discovery.
+func (e *Engine) UnpinSchemaSnapshot() error {
+	st, err := e.LoadState()
+	if err != nil {
+		return err
+	}
+	if st.SchemaSnapshot == "" {
+		return nil
+	}
+	pinned := st.SchemaSnapshot
+	st.SchemaSnapshot = ""
+	if err := e.followLatestSnapshot(); err != nil {
+		return err
+	}
+	e.audit("schema_unpinned", pinned)
+	return nil
+}
+
+// DeleteSchemaSnapshot soft-deletes a snapshot: it is hidden from the list
+// but kept on disk until restored. The pinned snapshot cannot be deleted;
+// when the latest one is deleted, the baseline moves to the one before it.
+func (e *Engine) DeleteSchemaSnapshot(id string) error {
+	st, err := e.LoadState()
+	if err != nil {
+		return err
+	}
+	if st.SchemaSnapshot == id {
+		return ErrSnapshotPinned
+	}
+	snaps := schema.OpenSnapshots(e.projectDir())
+	if err := snaps.Delete(id, time.Now()); err != nil {
+		return err
+	}
+	e.audit("schema_snapshot_deleted", id)
+	if st.SchemaSnapshot == "" && st.SchemaPath == snaps.Path(id) {
+		return e.followLatestSnapshot()
+	}
+	return nil
+}
+
+// RestoreSchemaSnapshot brings back a deleted snapshot. Restoring the newest
+// snapshot makes it the baseline again unless another one is pinned.
+func (e *Engine) RestoreSchemaSnapshot(id string) error {
+	snaps := schema.OpenSnapshots(e.projectDir())
+	if err := snaps.Restore(id); err != nil {
+		return err
+	}
+	e.audit("schema_snapshot_restored", id)
+	st, err := e.LoadState()
+	if err != nil {
+		return err
+	}
+	if st.SchemaSnapshot != "" {
+		return nil
+	}
+	return e.followLatestSnapshot()
+}
+
+// followLatestSnapshot points the loaded state at the newest snapshot that
+// is not deleted, and saves it.
+func (e *Engine) followLatestSnapshot() error {
+	snaps := schema.OpenSnapshots(e.projectDir())
+	latest, ok, err := snaps.Latest()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		e.State.SchemaPath = ""
+		e.Schema = nil
+		return e.SaveState()
+	}
+	s, err := snaps.Load(latest.ID)
+	if err != nil {
+		return err
+	}
+	e.State.SchemaPath = snaps.Path(latest.ID)
+	if err := e.SaveState(); err != nil {
+		return err
+	}
+	e.Schema = s
+	return nil
+}
+
+// DiffSchemaSnapshots compares two snapshots, deleted or not, and reports
+// which parts of the saved mapping the later one breaks.
+func (e *Engine) DiffSchemaSnapshots(from, to string) (*SchemaChanges, error) {
+	snaps := schema.OpenSnapshots(e.projectDir())
+	old, err := snaps.Load(from)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := snaps.Load(to)
+	if err != nil {
+		return nil, err
+	}
+	m := e.Mapping
+	if m == nil && e.State != nil && e.State.MappingPath != "" {
+		if loaded, err := mapping.LoadYAML(e.State.MappingPath); err == nil {
+			m = loaded
+		}
+	}
+	return schemaChanges(old, cur, m), nil
+}
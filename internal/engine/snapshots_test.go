@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func TestSchemaSnapshots(t *testing.T) {
+	e := testEngine(t)
+	old := testSchema()
+	if err := e.recordSchema(old); err != nil {
+		t.Fatal(err)
+	}
+	cur := testSchema()
+	cur.Tables = cur.Tables[:2]
+	if err := e.recordSchema(cur); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := e.SchemaSnapshots(false)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("SchemaSnapshots = %+v, %v", list, err)
+	}
+	latest, first := list[0], list[1]
+	if !latest.Baseline || first.Baseline || latest.Pinned {
+		t.Errorf("latest discovery should be the unpinned baseline: %+v", list)
+	}
+
+	changes, err := e.DiffSchemaSnapshots(first.ID, latest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Diff.RemovedTables) != 1 || changes.Diff.RemovedTables[0] != "products" {
+		t.Errorf("diff = %+v", changes.Diff)
+	}
+
+	// A pinned snapshot stays the baseline through later discoveries
+	if err := e.PinSchemaSnapshot(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.recordSchema(cur); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Schema.Tables) != 3 {
+		t.Errorf("loaded schema has %d tables, want the pinned 3", len(e.Schema.Tables))
+	}
+	if err := e.DeleteSchemaSnapshot(first.ID); !errors.Is(err, ErrSnapshotPinned) {
+		t.Errorf("deleting the pinned snapshot: %v", err)
+	}
+	if err := e.UnpinSchemaSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = e.SchemaSnapshots(false)
+	if len(list) != 3 || !list[0].Baseline {
+		t.Fatalf("after unpin = %+v", list)
+	}
+
+	// Deleting the baseline falls back to the snapshot before it, and
+	// restoring it moves the baseline back
+	newest := list[0].ID
+	if err := e.DeleteSchemaSnapshot(newest); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = e.SchemaSnapshots(false)
+	if len(list) != 2 || !list[0].Baseline {
+		t.Errorf("after delete = %+v", list)
+	}
+	if err := e.PinSchemaSnapshot(newest); !errors.Is(err, schema.ErrSnapshotDeleted) {
+		t.Errorf("pinning a deleted snapshot: %v", err)
+	}
+	if err := e.RestoreSchemaSnapshot(newest); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = e.SchemaSnapshots(true)
+	if len(list) != 3 || list[0].ID != newest || !list[0].Baseline {
+		t.Errorf("after restore = %+v", list)
+	}
+}
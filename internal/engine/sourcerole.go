@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+// SourceRoleScript generates the SQL creating a dedicated read-only source
+// role named role (source.DefaultRoleName when empty) with SELECT on the
+// selected tables and every table the mapping reads, and with the
+// replication grants change data capture needs when cdc is set.
+func (e *Engine) SourceRoleScript(role string, cdc bool) (string, error) {
+	if e.Config == nil {
+		return "", fmt.Errorf("no config set")
+	}
+	rs := &source.RoleScript{
+		DBType:   e.Config.Source.Type,
+		Database: e.Config.Source.Database,
+		Schema:   source.SchemaName(e.Config.Source),
+		Role:     role,
+		Tables:   e.roleTables(),
+		CDC:      cdc,
+	}
+	if e.Mapping != nil {
+		rs.Flashback = e.Mapping.GroupTables()
+	}
+	return rs.Generate()
+}
+
+// CDCPrepared reports whether change data capture has been prepared on the
+// source, so a role script should include its grants by default.
+func (e *Engine) CDCPrepared() bool {
+	return e.State != nil && e.State.CDCStartPosition != ""
+}
+
+func (e *Engine) roleTables() []string {
+	var tables []string
+	seen := make(map[string]bool)
+	add := func(names []string) {
+		for _, n := range names {
+			if !seen[n] {
+				seen[n] = true
+				tables = append(tables, n)
+			}
+		}
+	}
+	if e.State != nil {
+		add(e.State.SelectedTables)
+	}
+	if e.Mapping != nil {
+		add(e.Mapping.SourceTables())
+	}
+	return tables
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+func TestSourceRoleScript(t *testing.T) {
+	e := testEngine(t)
+	e.Config.Source = config.SourceConfig{Type: "postgresql", Database: "shop"}
+	if _, err := e.SourceRoleScript("", false); err == nil {
+		t.Error("expected an error with no tables selected")
+	}
+
+	e.State = state.New()
+	e.State.SelectedTables = []string{"orders", "customers"}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{
+		Name: "orders", SourceTable: "orders",
+		Embedded: []mapping.Embedded{{SourceTable: "order_items"}},
+	}}}
+	script, err := e.SourceRoleScript("", e.CDCPrepared())
+	if err != nil {
+		t.Fatalf("SourceRoleScript: %v", err)
+	}
+	for _, table := range []string{"orders", "customers", "order_items"} {
+		want := `GRANT SELECT ON "public"."` + table + `" TO reloquent_migration;`
+		if strings.Count(script, want) != 1 {
+			t.Errorf("script should grant %s once:\n%s", table, script)
+		}
+	}
+	if strings.Contains(script, "REPLICATION") {
+		t.Error("replication granted before CDC was prepared")
+	}
+
+	e.State.CDCStartPosition = "0/16B3748"
+	script, _ = e.SourceRoleScript("", e.CDCPrepared())
+	if !strings.Contains(script, "REPLICATION") {
+		t.Error("replication not granted once CDC was prepared")
+	}
+}
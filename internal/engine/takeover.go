@@ -0,0 +1,205 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/codegen"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// TakeoverReport is what re-validating an interrupted migration against the
+// target found, collection by collection.
+type TakeoverReport struct {
+	Collections []TakeoverCollection `json:"collections"`
+	// Pending lists the collections a resumed run still has to migrate.
+	Pending []string `json:"pending"`
+}
+
+// TakeoverCollection is the re-validated progress of one collection.
+// Partitions recorded as migrated whose documents are not all in the
+// target are dropped, so the resumed run writes them again.
+type TakeoverCollection struct {
+	Name     string `json:"name"`
+	Verified int    `json:"verified"`
+	Dropped  int    `json:"dropped"`
+	Done     bool   `json:"done"`
+	Message  string `json:"message,omitempty"`
+}
+
+// TakeOver prepares this host to resume a migration started on another one,
+// typically after importing its project bundle: it loads the checkpoints
+// the run recorded in the target since the bundle was exported, then
+// re-validates each checkpointed partition, and each collection recorded as
+// done, by comparing the source rows in it with the documents the target
+// holds. What is not fully present is forgotten, and the run is left
+// resumable.
+func (e *Engine) TakeOver(ctx context.Context) (*TakeoverReport, error) {
+	if e.Config == nil {
+		return nil, fmt.Errorf("no config set")
+	}
+	src, err := e.newSourceReader()
+	if err != nil {
+		return nil, err
+	}
+	if err := src.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("connecting to source: %w", err)
+	}
+	defer src.Close()
+
+	op, release, err := e.targetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	defer release()
+	return e.takeOver(ctx, src, op)
+}
+
+func (e *Engine) takeOver(ctx context.Context, src source.Reader, op target.Operator) (*TakeoverReport, error) {
+	if e.State == nil || e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("state, schema, and mapping required")
+	}
+	if !e.CanResume() {
+		return nil, fmt.Errorf("no interrupted migration to take over (status %q)", e.State.MigrationStatus)
+	}
+
+	// Partitions finished after the bundle was exported are only in the target.
+	docs, err := op.FindDocuments(ctx, codegen.CheckpointCollection, nil)
+	if err != nil {
+		return nil, fmt.Errorf("loading checkpoints: %w", err)
+	}
+	for _, d := range docs {
+		if name, _ := d["collection"].(string); name != "" {
+			e.State.RecordPartition(name, int(toInt64(d["partitions"])), checkpointOf(d))
+		}
+	}
+
+	report := &TakeoverReport{}
+	var stale []target.WriteOp
+	for _, c := range e.Mapping.Collections {
+		tc, err := e.revalidate(ctx, src, op, c)
+		if err != nil {
+			return nil, err
+		}
+		if tc.Dropped > 0 {
+			for _, d := range docs {
+				if name, _ := d["collection"].(string); name == c.Name && !e.hasPartition(c.Name, checkpointOf(d)) {
+					stale = append(stale, target.WriteOp{Type: target.WriteDelete, Filter: map[string]interface{}{"_id": d["_id"]}})
+				}
+			}
+		}
+		tc.Done = e.State.CollectionDone(c.Name)
+		if !tc.Done {
+			report.Pending = append(report.Pending, c.Name)
+		}
+		report.Collections = append(report.Collections, tc)
+	}
+
+	// A resumed run reloads the target's checkpoints, so the dropped
+	// partitions are removed there too.
+	if err := op.ApplyWrites(ctx, codegen.CheckpointCollection, stale); err != nil {
+		return nil, fmt.Errorf("removing stale checkpoints: %w", err)
+	}
+	if e.State.MigrationStatus == "running" {
+		e.State.MigrationStatus = "aborted"
+	}
+	if err := e.SaveState(); err != nil {
+		return nil, err
+	}
+
+	verified, dropped := 0, 0
+	for _, tc := range report.Collections {
+		verified += tc.Verified
+		dropped += tc.Dropped
+	}
+	e.audit("migration_takeover", fmt.Sprintf("%d partitions verified, %d dropped, pending: %s",
+		verified, dropped, strings.Join(report.Pending, ", ")))
+	return report, nil
+}
+
+// revalidate checks the collection's recorded progress against the target.
+// Partitioned collections are checked partition by partition; others, and
+// collections with no partitions recorded, by their whole document count,
+// which also picks up a collection finished just before the host died.
+func (e *Engine) revalidate(ctx context.Context, src source.Reader, op target.Operator, c mapping.Collection) (TakeoverCollection, error) {
+	tc := TakeoverCollection{Name: c.Name}
+	cp := e.State.Checkpoints[c.Name]
+	column, field, partitioned := codegen.CheckpointKeys(e.Schema, &c)
+
+	if cp != nil && len(cp.Completed) > 0 && partitioned {
+		for _, p := range append([]state.PartitionCheckpoint(nil), cp.Completed...) {
+			want, err := src.FilteredRowCount(ctx, c.SourceTable, migration.PartitionFilter(c.LiveFilter(), source.QuoteIdent(src, column), p))
+			if err != nil {
+				return tc, fmt.Errorf("counting source rows of %s partition %d: %w", c.Name, p.Index, err)
+			}
+			got, err := countRange(ctx, op, c.Name, field, p)
+			if err != nil {
+				return tc, fmt.Errorf("counting documents of %s partition %d: %w", c.Name, p.Index, err)
+			}
+			if got == want {
+				tc.Verified++
+				continue
+			}
+			e.State.RemovePartition(c.Name, p)
+			tc.Dropped++
+		}
+		if tc.Dropped > 0 {
+			tc.Message = fmt.Sprintf("%d of %d partitions incomplete in the target", tc.Dropped, tc.Dropped+tc.Verified)
+		}
+		return tc, nil
+	}
+
+	want, err := src.FilteredRowCount(ctx, c.SourceTable, c.LiveFilter())
+	if err != nil {
+		return tc, fmt.Errorf("counting source rows of %s: %w", c.Name, err)
+	}
+	got, err := op.CountDocuments(ctx, c.Name)
+	if err != nil {
+		return tc, fmt.Errorf("counting documents of %s: %w", c.Name, err)
+	}
+	switch {
+	case got == want:
+		e.State.CompleteCollection(c.Name)
+	case cp != nil:
+		e.State.ClearCollection(c.Name)
+		tc.Dropped = 1
+		tc.Message = fmt.Sprintf("%d of %d documents in the target", got, want)
+	}
+	return tc, nil
+}
+
+// countRange counts the target documents of a partition, reading them when
+// the operator cannot count a range directly.
+func countRange(ctx context.Context, op target.Operator, collection, field string, p state.PartitionCheckpoint) (int64, error) {
+	if rc, ok := op.(target.RangeCounter); ok {
+		return rc.CountRange(ctx, collection, field, p.Lower, p.Upper)
+	}
+	docs, err := op.FindRange(ctx, collection, field, p.Lower, p.Upper)
+	return int64(len(docs)), err
+}
+
+func checkpointOf(d map[string]interface{}) state.PartitionCheckpoint {
+	return state.PartitionCheckpoint{
+		Index: int(toInt64(d["partition"])),
+		Lower: toInt64(d["lower"]),
+		Upper: toInt64(d["upper"]),
+	}
+}
+
+func (e *Engine) hasPartition(collection string, p state.PartitionCheckpoint) bool {
+	cp := e.State.Checkpoints[collection]
+	if cp == nil {
+		return false
+	}
+	for _, done := range cp.Completed {
+		if done == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/codegen"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func TestTakeOver(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = &schema.Schema{Tables: []schema.Table{
+		{Name: "orders", Columns: []schema.Column{{Name: "id", DataType: "integer"}}, PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}}},
+		{Name: "users", Columns: []schema.Column{{Name: "id", DataType: "uuid"}}},
+		{Name: "events", Columns: []schema.Column{{Name: "id", DataType: "uuid"}}},
+	}}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "orders", SourceTable: "orders"},
+		{Name: "users", SourceTable: "users"},
+		{Name: "events", SourceTable: "events"},
+	}}
+	e.State = state.New()
+	e.State.MigrationStatus = "running"
+	// The bundle was exported after the first partition finished.
+	e.State.RecordPartition("orders", 2, state.PartitionCheckpoint{Index: 0, Lower: 1, Upper: 51})
+
+	var rows, written []map[string]interface{}
+	for id := 1; id <= 100; id++ {
+		rows = append(rows, map[string]interface{}{"id": id})
+		if id <= 60 { // the second partition was cut off part way
+			written = append(written, map[string]interface{}{"id": id})
+		}
+	}
+	inRange := func(lo, hi int) func(map[string]interface{}) bool {
+		return func(r map[string]interface{}) bool { id := r["id"].(int); return id >= lo && id < hi }
+	}
+	src := &source.MockReader{
+		TableRows: map[string][]map[string]interface{}{"orders": rows},
+		RowCounts: map[string]int64{"users": 3, "events": 5},
+		Filters: map[string]func(map[string]interface{}) bool{
+			"id >= 1 AND id < 51":   inRange(1, 51),
+			"id >= 51 AND id < 101": inRange(51, 101),
+		},
+	}
+	op := &target.MockOperator{
+		Documents: map[string][]map[string]interface{}{
+			"orders": written,
+			codegen.CheckpointCollection: {
+				{"_id": "cp0", "collection": "orders", "partition": int32(0), "lower": int64(1), "upper": int64(51), "partitions": int32(2)},
+				{"_id": "cp1", "collection": "orders", "partition": int32(1), "lower": int64(51), "upper": int64(101), "partitions": int32(2)},
+			},
+		},
+		DocCounts: map[string]int64{"users": 3},
+	}
+
+	report, err := e.takeOver(t.Context(), src, op)
+	if err != nil {
+		t.Fatalf("takeOver: %v", err)
+	}
+	want := []TakeoverCollection{
+		{Name: "orders", Verified: 1, Dropped: 1, Message: "1 of 2 partitions incomplete in the target"},
+		{Name: "users", Done: true},
+		{Name: "events"},
+	}
+	if !reflect.DeepEqual(report.Collections, want) {
+		t.Errorf("collections = %+v, want %+v", report.Collections, want)
+	}
+	if !reflect.DeepEqual(report.Pending, []string{"orders", "events"}) {
+		t.Errorf("pending = %v", report.Pending)
+	}
+	if cp := e.State.Checkpoints["orders"]; cp == nil || len(cp.Completed) != 1 || cp.Completed[0].Index != 0 {
+		t.Errorf("orders checkpoint = %+v, want only partition 0", cp)
+	}
+	deletes := op.AppliedWrites[codegen.CheckpointCollection]
+	if len(deletes) != 1 || deletes[0].Type != target.WriteDelete || deletes[0].Filter["_id"] != "cp1" {
+		t.Errorf("checkpoint writes = %+v, want partition 1 deleted", deletes)
+	}
+	if e.State.MigrationStatus != "aborted" || !e.CanResume() {
+		t.Errorf("status = %q, want a resumable aborted run", e.State.MigrationStatus)
+	}
+
+	e.State.MigrationStatus = "completed"
+	if _, err := e.takeOver(t.Context(), src, op); err == nil {
+		t.Error("expected an error taking over a completed migration")
+	}
+}
+
+func TestTakeOver_ClearsMissingCollection(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = &schema.Schema{Tables: []schema.Table{{Name: "users", Columns: []schema.Column{{Name: "id", DataType: "uuid"}}}}}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{Name: "users", SourceTable: "users"}}}
+	e.State = state.New()
+	e.State.MigrationStatus = "failed"
+	e.State.CompleteCollection("users")
+
+	src := &source.MockReader{RowCounts: map[string]int64{"users": 10}}
+	op := &target.MockOperator{DocCounts: map[string]int64{"users": 4}}
+	report, err := e.takeOver(t.Context(), src, op)
+	if err != nil {
+		t.Fatalf("takeOver: %v", err)
+	}
+	if got := report.Collections[0]; got.Done || got.Dropped != 1 || got.Message != "4 of 10 documents in the target" {
+		t.Errorf("users = %+v, want it migrated again", got)
+	}
+	if _, ok := e.State.Checkpoints["users"]; ok {
+		t.Error("users checkpoint should be cleared")
+	}
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/template"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// ErrTemplateIncompatible is returned by ApplyTemplate when the template
+// does not fit the discovered schema.
+var ErrTemplateIncompatible = errors.New("template does not fit the discovered schema")
+
+// TemplateResult is what applying a template did: how it fitted the schema,
+// the values its connection placeholders took, and the tables it selected.
+type TemplateResult struct {
+	Template      string                  `json:"template"`
+	Compatibility *template.Compatibility `json:"compatibility"`
+	Bindings      []template.Binding      `json:"bindings,omitempty"`
+	Tables        []string                `json:"tables,omitempty"`
+}
+
+// ExportTemplate builds a template of the project's mapping, type mapping
+// and index plan, named name or else after the project.
+func (e *Engine) ExportTemplate(name string) (*template.Template, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	plan, err := e.GetIndexPlan()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" && e.project != nil {
+		name = e.project.Name
+	}
+	return template.Export(name, e.Schema, e.Mapping, e.GetTypeMap(), plan)
+}
+
+// CheckTemplate compares a template with the discovered schema, including
+// the checks a mapping saved in the project must pass.
+func (e *Engine) CheckTemplate(t *template.Template) (*template.Compatibility, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	if e.Schema == nil {
+		return nil, ErrNoSchema
+	}
+	c := t.Check(e.Schema)
+	if c.Compatible {
+		if err := e.ValidateMapping(t.Mapping); err != nil {
+			c.Errors = append(c.Errors, err.Error())
+			c.Compatible = false
+		}
+	}
+	return c, nil
+}
+
+// ApplyTemplate re-binds a template to the project: when it fits the
+// discovered schema, its tables are selected and its mapping, type mapping
+// and index plan saved as the project's. Otherwise nothing changes and the
+// error wraps ErrTemplateIncompatible.
+func (e *Engine) ApplyTemplate(t *template.Template) (*TemplateResult, error) {
+	c, err := e.CheckTemplate(t)
+	if err != nil {
+		return nil, err
+	}
+	res := &TemplateResult{Template: t.Name, Compatibility: c}
+	if e.Config != nil {
+		res.Bindings = t.Connection.Bind(e.Config)
+	}
+	if !c.Compatible {
+		return res, fmt.Errorf("%w: %s", ErrTemplateIncompatible, strings.Join(c.Errors, "; "))
+	}
+
+	res.Tables = t.Mapping.SourceTables()
+	if err := e.SelectTables(res.Tables); err != nil {
+		return nil, err
+	}
+	if err := e.SaveMapping(t.Mapping); err != nil {
+		return nil, err
+	}
+
+	// Start from this source's defaults so later overrides are tracked
+	tm := typemap.ForDatabase(e.Schema.DatabaseType)
+	if t.TypeMap != nil {
+		for sourceType, bsonType := range t.TypeMap.Mappings {
+			if cur, ok := tm.Mappings[sourceType]; !ok || cur != bsonType {
+				tm.Override(sourceType, bsonType)
+			}
+		}
+		tm.LOBs = t.TypeMap.LOBs
+	}
+	e.TypeMap = tm
+	if err := e.saveTypeMap(tm); err != nil {
+		return nil, err
+	}
+
+	if t.IndexPlan != nil {
+		err = e.SaveIndexPlan(t.IndexPlan)
+	} else {
+		err = e.ResetIndexPlan()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e.State.CompleteStep(state.StepTableSelection, state.StepDenormalization)
+	e.State.CompleteStep(state.StepDenormalization, state.StepTypeMapping)
+	if err := e.SaveState(); err != nil {
+		return nil, err
+	}
+	e.audit("template_applied", fmt.Sprintf("%s: %d collections, %d warnings", t.Name, len(t.Mapping.Collections), len(c.Warnings)))
+	return res, nil
+}
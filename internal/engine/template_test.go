@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+func templateSchema() *schema.Schema {
+	return &schema.Schema{DatabaseType: "postgresql", Tables: []schema.Table{
+		{
+			Name:       "customers",
+			Columns:    []schema.Column{{Name: "id", DataType: "integer"}, {Name: "balance", DataType: "numeric"}},
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}},
+		},
+		{
+			Name:    "orders",
+			Columns: []schema.Column{{Name: "id", DataType: "integer"}, {Name: "customer_id", DataType: "integer"}},
+		},
+	}}
+}
+
+func TestApplyTemplate(t *testing.T) {
+	dev := testEngine(t)
+	dev.Schema = templateSchema()
+	dev.Mapping = &mapping.Mapping{Collections: []mapping.Collection{{
+		Name:        "customers",
+		SourceTable: "customers",
+		Embedded: []mapping.Embedded{{
+			SourceTable: "orders", FieldName: "orders", Relationship: "array",
+			JoinColumn: "customer_id", ParentColumn: "id",
+		}},
+	}}}
+	dev.GetTypeMap().Override("numeric", typemap.BSONDouble)
+	tmpl, err := dev.ExportTemplate("shop")
+	if err != nil {
+		t.Fatalf("ExportTemplate: %v", err)
+	}
+	if tmpl.IndexPlan == nil || len(tmpl.IndexPlan.Indexes) == 0 {
+		t.Fatal("template should carry the index plan")
+	}
+
+	staging := testEngine(t)
+	staging.Config.Source = config.SourceConfig{Host: "staging-db", Port: 5432}
+	staging.Schema = templateSchema()
+	res, err := staging.ApplyTemplate(tmpl)
+	if err != nil {
+		t.Fatalf("ApplyTemplate: %v", err)
+	}
+	if res.Bindings[0].Value != "staging-db" || len(res.Tables) != 2 {
+		t.Errorf("result = %+v", res)
+	}
+	st := staging.State
+	if st.MappingPath == "" || st.TypeMappingPath == "" || st.IndexPlanPath == "" {
+		t.Errorf("state paths = %q, %q, %q; want mapping, type mapping and index plan saved", st.MappingPath, st.TypeMappingPath, st.IndexPlanPath)
+	}
+	if !st.IsStepComplete(state.StepDenormalization) || st.CurrentStep != state.StepTypeMapping {
+		t.Errorf("current step = %s, want type mapping", st.CurrentStep)
+	}
+	tm, err := typemap.LoadYAML(st.TypeMappingPath)
+	if err != nil {
+		t.Fatalf("loading type mapping: %v", err)
+	}
+	if tm.Overrides["numeric"] != typemap.BSONDouble || len(tm.Overrides) != 1 {
+		t.Errorf("overrides = %v, want only numeric", tm.Overrides)
+	}
+
+	// A schema missing a join column is refused and the project left alone
+	prod := testEngine(t)
+	prod.Schema = templateSchema()
+	prod.Schema.Tables[1].Columns = prod.Schema.Tables[1].Columns[:1]
+	res, err = prod.ApplyTemplate(tmpl)
+	if !errors.Is(err, ErrTemplateIncompatible) || res == nil || res.Compatibility.Compatible {
+		t.Fatalf("ApplyTemplate = %+v, %v; want ErrTemplateIncompatible", res, err)
+	}
+	if prod.Mapping != nil || (prod.State != nil && prod.State.MappingPath != "") {
+		t.Error("an incompatible template should not be saved")
+	}
+
+	if _, err := testEngine(t).ApplyTemplate(tmpl); !errors.Is(err, ErrNoSchema) {
+		t.Errorf("err = %v, want ErrNoSchema before discovery", err)
+	}
+}
@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+// defaultThroughputInterval spaces out throughput samples when
+// migration.throughput_interval is not set.
+const defaultThroughputInterval = 10 * time.Second
+
+// ErrRunNotFound is returned for a run ID the project has not recorded.
+var ErrRunNotFound = errors.New("run not found")
+
+// RunThroughput is what a run recorded of its migration's speed, for
+// charting: one sample per collection written to in each interval.
+type RunThroughput struct {
+	Run     state.RunRecord          `json:"run"`
+	Samples []state.ThroughputSample `json:"samples"`
+}
+
+// RunThroughput returns the throughput samples of the run with the given ID.
+func (e *Engine) RunThroughput(id int64) (*RunThroughput, error) {
+	if err := e.ensureMetadataStore(); err != nil {
+		return nil, err
+	}
+	store, err := state.OpenStore(e.projectDir())
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	runs, err := store.Runs(0)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range runs {
+		if r.ID != id {
+			continue
+		}
+		samples, err := store.Throughput(id)
+		if err != nil {
+			return nil, err
+		}
+		if samples == nil {
+			samples = []state.ThroughputSample{}
+		}
+		return &RunThroughput{Run: r, Samples: samples}, nil
+	}
+	return nil, fmt.Errorf("%w: %d", ErrRunNotFound, id)
+}
+
+// throughputInterval is how often a migration records throughput samples.
+func (e *Engine) throughputInterval() time.Duration {
+	if e.Config == nil || e.Config.Migration.ThroughputInterval == "" {
+		return defaultThroughputInterval
+	}
+	v := e.Config.Migration.ThroughputInterval
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		e.Logger.Warn("invalid migration.throughput_interval, using the default", "value", v, "default", defaultThroughputInterval.String())
+		return defaultThroughputInterval
+	}
+	return d
+}
+
+// trackThroughput wraps a migration's status callback so it records
+// throughput samples with the run in progress. A migration that is not part
+// of a headless run is recorded as a run of its own, ended with the phase
+// of its last status by the returned function.
+func (e *Engine) trackThroughput(callback migration.StatusCallback) (migration.StatusCallback, func()) {
+	runID, endRun := e.runID, func(string, string) {}
+	if runID == 0 {
+		runID, endRun = e.startRun("migrate")
+	}
+	t := &throughputSampler{interval: e.throughputInterval()}
+	var mu sync.Mutex
+	var last *migration.Status
+	record := func(samples []state.ThroughputSample) {
+		if runID == 0 || len(samples) == 0 {
+			return
+		}
+		e.withStore("throughput", func(s state.Store) error { return s.AddThroughput(runID, samples) })
+	}
+	wrapped := func(s *migration.Status) {
+		mu.Lock()
+		last = s
+		samples := t.sample(time.Now(), s, false)
+		mu.Unlock()
+		record(samples)
+		if callback != nil {
+			callback(s)
+		}
+	}
+	done := func() {
+		mu.Lock()
+		status := "failed"
+		var samples []state.ThroughputSample
+		if last != nil {
+			samples = t.sample(time.Now(), last, true)
+			if last.Phase == "completed" {
+				status = "ok"
+			}
+		}
+		mu.Unlock()
+		record(samples)
+		endRun(status, "")
+	}
+	return wrapped, done
+}
+
+// throughputSampler turns the running totals of a migration's statuses into
+// rates over each sampling interval.
+type throughputSampler struct {
+	interval time.Duration
+	last     time.Time
+	prev     map[string]migration.CollectionStatus // totals at the last sample
+}
+
+// sample returns a sample for each collection that was running or wrote
+// documents since the last sample, once interval has passed since it or
+// always when final. The first status only sets the baseline.
+func (t *throughputSampler) sample(now time.Time, s *migration.Status, final bool) []state.ThroughputSample {
+	if t.prev == nil {
+		t.last, t.prev = now, totals(s)
+		return nil
+	}
+	elapsed := now.Sub(t.last)
+	if elapsed <= 0 || (!final && elapsed < t.interval) {
+		return nil
+	}
+	var out []state.ThroughputSample
+	for _, c := range s.Collections {
+		p := t.prev[c.Name]
+		docs, bytes := c.DocsWritten-p.DocsWritten, c.BytesWritten-p.BytesWritten
+		if docs == 0 && c.State != "running" {
+			continue
+		}
+		out = append(out, state.ThroughputSample{
+			Time:         now,
+			Collection:   c.Name,
+			DocsWritten:  c.DocsWritten,
+			BytesWritten: c.BytesWritten,
+			RowsPerSec:   float64(docs) / elapsed.Seconds(),
+			MBPerSec:     float64(bytes) / (1024 * 1024) / elapsed.Seconds(),
+		})
+	}
+	t.last, t.prev = now, totals(s)
+	return out
+}
+
+func totals(s *migration.Status) map[string]migration.CollectionStatus {
+	m := make(map[string]migration.CollectionStatus, len(s.Collections))
+	for _, c := range s.Collections {
+		m[c.Name] = c
+	}
+	return m
+}
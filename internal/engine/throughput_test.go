@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/migration"
+)
+
+func TestThroughputSampler(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	status := func(users, orders int64, ordersState string) *migration.Status {
+		return &migration.Status{Collections: []migration.CollectionStatus{
+			{Name: "users", State: "running", DocsWritten: users, BytesWritten: users * 1024},
+			{Name: "orders", State: ordersState, DocsWritten: orders},
+		}}
+	}
+	ts := &throughputSampler{interval: 10 * time.Second}
+
+	if got := ts.sample(start, status(0, 0, "pending"), false); got != nil {
+		t.Fatalf("baseline = %+v, want no samples", got)
+	}
+	if got := ts.sample(start.Add(5*time.Second), status(500, 0, "pending"), false); got != nil {
+		t.Fatalf("within the interval = %+v, want no samples", got)
+	}
+	got := ts.sample(start.Add(10*time.Second), status(2048, 0, "pending"), false)
+	if len(got) != 1 || got[0].Collection != "users" || got[0].RowsPerSec != 204.8 || got[0].MBPerSec != 0.2 || got[0].DocsWritten != 2048 {
+		t.Fatalf("samples = %+v, want users only", got)
+	}
+
+	// A final sample covers the partial interval; collections done and idle
+	// are left out
+	got = ts.sample(start.Add(12*time.Second), &migration.Status{Collections: []migration.CollectionStatus{
+		{Name: "users", State: "completed", DocsWritten: 2048},
+		{Name: "orders", State: "running", DocsWritten: 100},
+	}}, true)
+	if len(got) != 1 || got[0].Collection != "orders" || got[0].RowsPerSec != 50 {
+		t.Errorf("final samples = %+v", got)
+	}
+}
+
+func TestTrackThroughput(t *testing.T) {
+	e := testEngine(t)
+	e.Config.Migration.ThroughputInterval = "1ns"
+	var seen int
+	callback, done := e.trackThroughput(func(*migration.Status) { seen++ })
+	s := &migration.Status{Phase: "running", Collections: []migration.CollectionStatus{{Name: "users", State: "running"}}}
+	callback(s)
+	time.Sleep(time.Millisecond)
+	s.Collections[0].DocsWritten = 100
+	callback(s)
+	s.Phase = "completed"
+	s.Collections[0].State = "completed"
+	callback(s)
+	done()
+	if seen != 3 {
+		t.Errorf("callback called %d times, want 3", seen)
+	}
+
+	h, err := e.History(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Runs) != 1 || h.Runs[0].Command != "migrate" || h.Runs[0].Status != "ok" || h.Runs[0].FinishedAt.IsZero() {
+		t.Fatalf("runs = %+v, want the migration recorded as a run", h.Runs)
+	}
+	tp, err := e.RunThroughput(h.Runs[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tp.Samples) != 1 || tp.Samples[0].DocsWritten != 100 || tp.Samples[0].RowsPerSec <= 0 {
+		t.Errorf("samples = %+v", tp.Samples)
+	}
+
+	// Within a headless run, samples go to that run
+	e.runID = h.Runs[0].ID
+	callback, done = e.trackThroughput(nil)
+	callback(&migration.Status{Phase: "failed"})
+	done()
+	if h, _ := e.History(10); len(h.Runs) != 1 || h.Runs[0].Status != "ok" {
+		t.Errorf("runs = %+v, want no run of its own", h.Runs)
+	}
+
+	if _, err := e.RunThroughput(42); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("err = %v, want ErrRunNotFound", err)
+	}
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/indexes"
+)
+
+// UniqueConstraints classifies the source unique constraints of the mapped
+// tables as preserved, convertible to a partial unique index, or lost.
+func (e *Engine) UniqueConstraints() (*indexes.UniqueReport, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	return indexes.AnalyzeUnique(e.Schema, e.Mapping, e.GetTypeMap()), nil
+}
+
+// ApplyUniqueConversions adds the partial unique index of every convertible
+// constraint to the index plan and saves it, returning how many it added or
+// replaced.
+func (e *Engine) ApplyUniqueConversions() (int, error) {
+	r, err := e.UniqueConstraints()
+	if err != nil {
+		return 0, err
+	}
+	plan, err := e.GetIndexPlan()
+	if err != nil {
+		return 0, err
+	}
+	n := plan.AddUniqueConversions(r)
+	if n == 0 {
+		return 0, nil
+	}
+	if err := e.SaveIndexPlan(plan); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
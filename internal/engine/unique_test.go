@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func uniqueEngine(t *testing.T) *Engine {
+	t.Helper()
+	e := testEngine(t)
+	e.Schema = &schema.Schema{DatabaseType: "postgresql", Tables: []schema.Table{{
+		Name: "users",
+		Columns: []schema.Column{
+			{Name: "id", DataType: "bigint"},
+			{Name: "email", DataType: "text"},
+			{Name: "phone", DataType: "text", Nullable: true},
+		},
+		PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}},
+		Indexes: []schema.Index{
+			{Name: "ux_email", Columns: []string{"email"}, Unique: true},
+			{Name: "ux_phone", Columns: []string{"phone"}, Unique: true},
+		},
+	}}}
+	e.SetMapping(&mapping.Mapping{Collections: []mapping.Collection{{Name: "users", SourceTable: "users"}}})
+	return e
+}
+
+func TestUniqueConstraints(t *testing.T) {
+	e := testEngine(t)
+	if _, err := e.UniqueConstraints(); err == nil {
+		t.Error("expected an error without a schema and mapping")
+	}
+
+	e = uniqueEngine(t)
+	r, err := e.UniqueConstraints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Preserved != 2 || r.Convertible != 1 || r.Lost != 0 {
+		t.Errorf("counts = %d/%d/%d, want 2/1/0", r.Preserved, r.Convertible, r.Lost)
+	}
+}
+
+func TestApplyUniqueConversions(t *testing.T) {
+	e := uniqueEngine(t)
+	n, err := e.ApplyUniqueConversions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("applied %d conversions, want 1", n)
+	}
+	if e.State.IndexPlanPath == "" {
+		t.Error("plan was not saved")
+	}
+
+	plan, err := e.GetIndexPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := e.UniqueConstraints()
+	if got := plan.Unenforced(r); len(got) != 0 {
+		t.Errorf("unenforced = %+v", got)
+	}
+}
+
+func TestGetIndexPlan_PartialUnique(t *testing.T) {
+	e := uniqueEngine(t)
+	e.Config.Indexes.PartialUnique = true
+
+	plan, err := e.GetIndexPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := e.UniqueConstraints()
+	if got := plan.Unenforced(r); len(got) != 0 {
+		t.Errorf("unenforced = %+v, want the inferred plan to build them", got)
+	}
+}
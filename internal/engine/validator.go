@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/reloquent/reloquent/internal/jsonschema"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// CollectionValidator is the $jsonSchema validator generated for a mapped
+// collection and how strictly pre-migration applies it.
+type CollectionValidator struct {
+	Collection string                 `json:"collection"`
+	Level      string                 `json:"level"`
+	Validator  map[string]interface{} `json:"validator"`
+}
+
+// Validators generates the validator of every mapped collection from the
+// source schema and type map, with the validation level chosen for each.
+func (e *Engine) Validators() ([]CollectionValidator, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	tm := e.GetTypeMap()
+	var out []CollectionValidator
+	for _, col := range e.Mapping.Collections {
+		v := jsonschema.Validator(e.Schema, tm, col)
+		if v == nil {
+			continue
+		}
+		out = append(out, CollectionValidator{Collection: col.Name, Level: col.ValidationLevel(), Validator: v})
+	}
+	return out, nil
+}
+
+// SetValidationLevel sets how strictly a collection's validator is enforced
+// and saves the mapping. Unsupported levels are reported as
+// ErrInvalidMapping.
+func (e *Engine) SetValidationLevel(collection, level string) error {
+	if e.Mapping == nil {
+		return fmt.Errorf("no mapping defined")
+	}
+	if !slices.Contains(mapping.ValidationLevels, level) {
+		return fmt.Errorf("%w: unsupported validation level %q (use off, moderate or strict)", ErrInvalidMapping, level)
+	}
+	for i := range e.Mapping.Collections {
+		if e.Mapping.Collections[i].Name == collection {
+			e.Mapping.Collections[i].Validation = level
+			return e.saveMapping()
+		}
+	}
+	return fmt.Errorf("%w: no collection %s", ErrInvalidMapping, collection)
+}
+
+// applyValidators sets the validator of every collection whose validation
+// level is not off, unless the target has no schema validation.
+func (e *Engine) applyValidators(ctx context.Context, op target.Operator) error {
+	if !target.CapabilitiesOf(op).Validators {
+		return nil
+	}
+	tm := e.GetTypeMap()
+	for _, col := range e.Mapping.Collections {
+		if col.ValidationLevel() == mapping.ValidationOff {
+			continue
+		}
+		v := jsonschema.Validator(e.Schema, tm, col)
+		if v == nil {
+			continue
+		}
+		if err := op.SetValidator(ctx, col.Name, v, col.ValidationLevel()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func TestSetValidationLevel(t *testing.T) {
+	e := testEngine(t)
+	e.Schema = &schema.Schema{DatabaseType: "postgresql", Tables: []schema.Table{
+		{Name: "orders", Columns: []schema.Column{{Name: "id", DataType: "integer"}}},
+		{Name: "items", Columns: []schema.Column{{Name: "qty", DataType: "integer"}}},
+	}}
+	e.Mapping = &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "orders", SourceTable: "orders"},
+		{Name: "items", SourceTable: "items"},
+	}}
+
+	for _, tc := range []struct{ name, collection, level string }{
+		{"unknown collection", "invoices", mapping.ValidationStrict},
+		{"unknown level", "orders", "warn"},
+	} {
+		if err := e.SetValidationLevel(tc.collection, tc.level); !errors.Is(err, ErrInvalidMapping) {
+			t.Errorf("%s: err = %v, want ErrInvalidMapping", tc.name, err)
+		}
+	}
+
+	if err := e.SetValidationLevel("orders", mapping.ValidationStrict); err != nil {
+		t.Fatalf("SetValidationLevel: %v", err)
+	}
+	saved, err := mapping.LoadYAML(e.State.MappingPath)
+	if err != nil {
+		t.Fatalf("loading saved mapping: %v", err)
+	}
+	if got := saved.Collections[0].Validation; got != mapping.ValidationStrict {
+		t.Errorf("saved validation = %q, want strict", got)
+	}
+
+	validators, err := e.Validators()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(validators) != 2 || validators[0].Level != mapping.ValidationStrict || validators[1].Level != mapping.ValidationOff {
+		t.Errorf("validators = %+v", validators)
+	}
+
+	op := &target.MockOperator{}
+	if err := e.applyValidators(context.Background(), op); err != nil {
+		t.Fatal(err)
+	}
+	if len(op.Validators) != 1 || op.ValidationLevels["orders"] != mapping.ValidationStrict {
+		t.Errorf("applied %v at %v, want only orders, strict", op.Validators, op.ValidationLevels)
+	}
+}
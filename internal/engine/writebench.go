@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/reloquent/reloquent/internal/benchmark"
+	"github.com/reloquent/reloquent/internal/mapping"
+)
+
+// RunWriteBenchmark measures how fast the target takes writes by inserting
+// synthetic documents sized like the mapped collections' into a scratch
+// collection, within the benchmark guard rails. The result is saved next
+// to the state file, and later sizing plans estimate the migration time
+// with it. A dry run describes the run without connecting.
+func (e *Engine) RunWriteBenchmark(ctx context.Context, dryRun bool) (*benchmark.WriteResult, error) {
+	if e.Config == nil {
+		return nil, fmt.Errorf("no config set")
+	}
+	if dryRun {
+		return e.runWriteBenchmark(ctx, nil, true)
+	}
+	op, release, err := e.targetConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to target: %w", err)
+	}
+	defer release()
+	return e.runWriteBenchmark(ctx, op, false)
+}
+
+func (e *Engine) runWriteBenchmark(ctx context.Context, w benchmark.TargetWriter, dryRun bool) (*benchmark.WriteResult, error) {
+	if e.Schema == nil || e.Mapping == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	guard, err := benchmarkGuard(e.Config.Benchmark)
+	if err != nil {
+		return nil, err
+	}
+	ests := mapping.EstimateSizesMeasured(e.Schema, e.Mapping, e.GetTypeMap(), e.embedCounts())
+	var total int64
+	for _, est := range ests {
+		total += est.AvgDocSizeBytes * est.AvgRowCount
+	}
+
+	result, err := benchmark.RunWrite(ctx, w, benchmark.WriteInput{
+		Shapes:         benchmark.ShapesFromEstimates(ests),
+		TotalDataBytes: total,
+		Guard:          guard,
+		DryRun:         dryRun,
+	})
+	if err != nil || dryRun {
+		return result, err
+	}
+
+	st, err := e.LoadState()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(filepath.Dir(e.statePath), "write-benchmark.yaml")
+	if err := result.WriteYAML(path); err != nil {
+		return nil, err
+	}
+	st.WriteBenchmarkPath = path
+	return result, e.SaveState()
+}
+
+// targetWriteMBps returns the write rate of the last write benchmark, or 0
+// when none was run.
+func (e *Engine) targetWriteMBps() float64 {
+	if e.State == nil || e.State.WriteBenchmarkPath == "" {
+		return 0
+	}
+	r, err := benchmark.LoadWriteResult(e.State.WriteBenchmarkPath)
+	if err != nil {
+		return 0
+	}
+	return r.ThroughputMBps
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/sizing"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+// countingWriter is a benchmark.TargetWriter safe for concurrent inserts.
+type countingWriter struct {
+	mu      sync.Mutex
+	docs    int
+	dropped []string
+}
+
+func (w *countingWriter) InsertDocuments(_ context.Context, _ string, docs []interface{}) (int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.docs += len(docs)
+	return int64(len(docs)), nil
+}
+
+func (w *countingWriter) DropCollections(_ context.Context, names []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dropped = append(w.dropped, names...)
+	return nil
+}
+
+func TestRunWriteBenchmark(t *testing.T) {
+	e := testEngine(t)
+	e.Config.Benchmark.MaxRows = 500
+	e.Schema = testSchema()
+	e.State = &state.State{
+		SelectedTables: []string{"users", "orders"},
+		Steps:          make(map[state.Step]state.StepState),
+	}
+	if err := e.SaveState(); err != nil {
+		t.Fatal(err)
+	}
+	e.SetMapping(&mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users", SourceTable: "users"},
+		{Name: "orders", SourceTable: "orders"},
+	}})
+
+	w := &countingWriter{}
+	result, err := e.runWriteBenchmark(context.Background(), w, false)
+	if err != nil {
+		t.Fatalf("runWriteBenchmark: %v", err)
+	}
+	if result.DocsWritten < 500 || len(w.dropped) != 1 {
+		t.Errorf("wrote %d documents and dropped %v", result.DocsWritten, w.dropped)
+	}
+	if e.State.WriteBenchmarkPath == "" {
+		t.Fatal("write benchmark result not saved")
+	}
+
+	plan, err := e.ComputeSizing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, in := range plan.Derivation.Inputs {
+		found = found || (in.Name == "Target write throughput" && in.Source == sizing.ThroughputWriteBenchmark)
+	}
+	if !found {
+		t.Errorf("sizing inputs = %+v, want the measured write rate", plan.Derivation.Inputs)
+	}
+}
@@ -0,0 +1,313 @@
+// Package hooks runs custom steps declared in the config before or after
+// migration phases.
+//
+// A hook is an external command. It receives an Event as JSON on stdin and
+// may write a Response as JSON on stdout; any other output is ignored. A
+// hook fails if it exits non-zero, reports status "failed", or runs past its
+// timeout. The hook name, timing and phase are also passed in the
+// RELOQUENT_HOOK, RELOQUENT_HOOK_WHEN and RELOQUENT_HOOK_PHASE environment
+// variables.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+// Hook timings.
+const (
+	Before = "before"
+	After  = "after"
+)
+
+// DefaultTimeout bounds a hook run when its config sets no timeout.
+const DefaultTimeout = 5 * time.Minute
+
+// Phases are the migration phases hooks can attach to.
+var Phases = []state.Step{
+	state.StepPreMigration,
+	state.StepMigration,
+	state.StepValidation,
+	state.StepIndexBuilds,
+	state.StepCDC,
+}
+
+// Event is written to a hook's stdin.
+type Event struct {
+	Hook      string      `json:"hook"`
+	When      string      `json:"when"`
+	Phase     string      `json:"phase"`
+	Project   string      `json:"project,omitempty"`
+	StatePath string      `json:"state_path,omitempty"`
+	Database  string      `json:"database,omitempty"`
+	Details   interface{} `json:"details,omitempty"` // phase result, e.g. the migration status
+}
+
+// Response is the optional JSON a hook writes to stdout.
+type Response struct {
+	Status  string            `json:"status,omitempty"` // ok (default) or failed
+	Message string            `json:"message,omitempty"`
+	Outputs map[string]string `json:"outputs,omitempty"` // recorded in state
+}
+
+// ParseWhen splits a hook's "when" value into its timing and phase.
+func ParseWhen(when string) (string, state.Step, error) {
+	timing, phase, ok := strings.Cut(when, ":")
+	if !ok || (timing != Before && timing != After) {
+		return "", "", fmt.Errorf("when %q must be before:<phase> or after:<phase>", when)
+	}
+	for _, p := range Phases {
+		if string(p) == phase {
+			return timing, p, nil
+		}
+	}
+	return "", "", fmt.Errorf("when %q: unknown phase %q", when, phase)
+}
+
+// Validate checks hook declarations: names must be set and unique, each
+// needs a command, a valid when and a valid timeout.
+func Validate(hs []config.HookConfig) error {
+	seen := make(map[string]bool)
+	for i, h := range hs {
+		if h.Name == "" {
+			return fmt.Errorf("hook %d: name is required", i+1)
+		}
+		if seen[h.Name] {
+			return fmt.Errorf("hook %s: duplicate name", h.Name)
+		}
+		seen[h.Name] = true
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			return fmt.Errorf("hook %s: command is required", h.Name)
+		}
+		if _, _, err := ParseWhen(h.When); err != nil {
+			return fmt.Errorf("hook %s: %w", h.Name, err)
+		}
+		if _, err := timeout(h); err != nil {
+			return fmt.Errorf("hook %s: %w", h.Name, err)
+		}
+	}
+	return nil
+}
+
+func timeout(h config.HookConfig) (time.Duration, error) {
+	if h.Timeout == "" {
+		return DefaultTimeout, nil
+	}
+	d, err := time.ParseDuration(h.Timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q", h.Timeout)
+	}
+	return d, nil
+}
+
+// Exec runs one hook with the given event and returns its response.
+func Exec(ctx context.Context, h config.HookConfig, ev Event) (*Response, error) {
+	d, err := timeout(h)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	input, err := json.Marshal(ev)
+	if err != nil {
+		return nil, fmt.Errorf("encoding event: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Env = append(os.Environ(),
+		"RELOQUENT_HOOK="+ev.Hook,
+		"RELOQUENT_HOOK_WHEN="+ev.When,
+		"RELOQUENT_HOOK_PHASE="+ev.Phase,
+	)
+	for k, v := range h.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait on output pipes held open by children of a killed hook.
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %s", d)
+		}
+		if tail := lastLine(stderr.String()); tail != "" {
+			return nil, fmt.Errorf("%w: %s", err, tail)
+		}
+		return nil, err
+	}
+
+	resp := &Response{Status: "ok"}
+	if out := bytes.TrimSpace(stdout.Bytes()); len(out) > 0 && out[0] == '{' {
+		if err := json.Unmarshal(out, resp); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+	}
+	if resp.Status == "failed" {
+		msg := resp.Message
+		if msg == "" {
+			msg = "hook reported failure"
+		}
+		return resp, fmt.Errorf("%s", msg)
+	}
+	return resp, nil
+}
+
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
+
+// Runner runs the configured hooks for a project and records each run in
+// its state, so custom steps are tracked alongside the built-in ones.
+type Runner struct {
+	Hooks     []config.HookConfig
+	State     *state.State
+	StatePath string // state is saved after each run when set
+	Project   string
+	Database  string
+	Logger    *slog.Logger
+}
+
+// Run runs the hooks declared for when ("before" or "after") the phase, in
+// config order. A failing hook stops the run and its error is returned,
+// unless the hook sets continue_on_error. A nil Runner runs nothing.
+func (r *Runner) Run(ctx context.Context, when string, phase state.Step, details interface{}) error {
+	if r == nil || len(r.Hooks) == 0 {
+		return nil
+	}
+	if err := Validate(r.Hooks); err != nil {
+		return err
+	}
+	for _, h := range r.Hooks {
+		t, p, _ := ParseWhen(h.When)
+		if t != when || p != phase {
+			continue
+		}
+		if err := r.run(ctx, h, details); err != nil {
+			if h.ContinueOnError {
+				r.log().Warn("hook failed, continuing", "hook", h.Name, "error", err)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// RunNamed runs a single hook by name, regardless of its timing.
+func (r *Runner) RunNamed(ctx context.Context, name string, details interface{}) error {
+	if err := Validate(r.Hooks); err != nil {
+		return err
+	}
+	for _, h := range r.Hooks {
+		if h.Name == name {
+			h.RunOnce = false
+			return r.run(ctx, h, details)
+		}
+	}
+	return fmt.Errorf("no hook named %s", name)
+}
+
+func (r *Runner) run(ctx context.Context, h config.HookConfig, details interface{}) error {
+	if h.RunOnce && r.State != nil && r.State.HookRuns[h.Name].Status == "complete" {
+		r.log().Info("hook already complete, skipping", "hook", h.Name)
+		return nil
+	}
+
+	t, p, _ := ParseWhen(h.When)
+	run := state.HookRun{Status: "running", StartedAt: time.Now()}
+	if err := r.record(h.Name, run); err != nil {
+		return err
+	}
+	r.log().Info("running hook", "hook", h.Name, "when", h.When)
+
+	resp, err := Exec(ctx, h, Event{
+		Hook:      h.Name,
+		When:      t,
+		Phase:     string(p),
+		Project:   r.Project,
+		StatePath: r.StatePath,
+		Database:  r.Database,
+		Details:   details,
+	})
+	run.CompletedAt = time.Now()
+	if resp != nil {
+		run.Message = resp.Message
+		run.Outputs = resp.Outputs
+	}
+	if err != nil {
+		run.Status = "failed"
+		run.Message = err.Error()
+		if rerr := r.record(h.Name, run); rerr != nil {
+			return rerr
+		}
+		return fmt.Errorf("hook %s: %w", h.Name, err)
+	}
+	run.Status = "complete"
+	return r.record(h.Name, run)
+}
+
+func (r *Runner) record(name string, run state.HookRun) error {
+	if r.State == nil {
+		return nil
+	}
+	if r.State.HookRuns == nil {
+		r.State.HookRuns = make(map[string]state.HookRun)
+	}
+	r.State.HookRuns[name] = run
+	if r.StatePath == "" {
+		return nil
+	}
+	if err := r.State.Save(r.StatePath); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+	return nil
+}
+
+func (r *Runner) log() *slog.Logger {
+	if r.Logger != nil {
+		return r.Logger
+	}
+	return slog.Default()
+}
+
+// Status describes a configured hook and its last recorded run.
+type Status struct {
+	Name    string         `json:"name"`
+	When    string         `json:"when"`
+	Command []string       `json:"command"`
+	LastRun *state.HookRun `json:"last_run,omitempty"`
+}
+
+// Statuses lists the configured hooks with their last runs from st, which
+// may be nil.
+func Statuses(hs []config.HookConfig, st *state.State) []Status {
+	out := make([]Status, 0, len(hs))
+	for _, h := range hs {
+		s := Status{Name: h.Name, When: h.When, Command: h.Command}
+		if st != nil {
+			if run, ok := st.HookRuns[h.Name]; ok {
+				s.LastRun = &run
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
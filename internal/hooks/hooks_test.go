@@ -0,0 +1,236 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+func sh(script string) []string {
+	return []string{"sh", "-c", script}
+}
+
+func TestParseWhen(t *testing.T) {
+	tests := []struct {
+		when    string
+		timing  string
+		phase   state.Step
+		wantErr bool
+	}{
+		{"before:migration", Before, state.StepMigration, false},
+		{"after:validation", After, state.StepValidation, false},
+		{"after:index_builds", After, state.StepIndexBuilds, false},
+		{"during:migration", "", "", true},
+		{"before:sizing", "", "", true},
+		{"migration", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.when, func(t *testing.T) {
+			timing, phase, err := ParseWhen(tt.when)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWhen() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if timing != tt.timing || phase != tt.phase {
+				t.Errorf("ParseWhen() = %q, %q, want %q, %q", timing, phase, tt.timing, tt.phase)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ok := config.HookConfig{Name: "cmdb", When: "after:migration", Command: []string{"true"}}
+	tests := []struct {
+		name    string
+		hooks   []config.HookConfig
+		wantErr string
+	}{
+		{"valid", []config.HookConfig{ok}, ""},
+		{"no name", []config.HookConfig{{When: "after:migration", Command: []string{"true"}}}, "name is required"},
+		{"duplicate", []config.HookConfig{ok, ok}, "duplicate name"},
+		{"no command", []config.HookConfig{{Name: "x", When: "after:migration"}}, "command is required"},
+		{"bad when", []config.HookConfig{{Name: "x", When: "after", Command: []string{"true"}}}, "must be before"},
+		{"bad timeout", []config.HookConfig{{Name: "x", When: "after:cdc", Command: []string{"true"}, Timeout: "soon"}}, "invalid timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.hooks)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExec(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    config.HookConfig
+		wantMsg string
+		wantOut map[string]string
+		wantErr string
+	}{
+		{
+			name:    "reads event from stdin",
+			hook:    config.HookConfig{Command: sh(`grep -q '"phase":"migration"' && echo '{"message":"registered","outputs":{"ticket":"CHG-1"}}'`)},
+			wantMsg: "registered",
+			wantOut: map[string]string{"ticket": "CHG-1"},
+		},
+		{
+			name:    "environment",
+			hook:    config.HookConfig{Command: sh(`echo "{\"message\":\"$RELOQUENT_HOOK $RELOQUENT_HOOK_WHEN $TEAM\"}"`), Env: map[string]string{"TEAM": "dba"}},
+			wantMsg: "cmdb after dba",
+		},
+		{
+			name: "plain output ignored",
+			hook: config.HookConfig{Command: sh(`echo done`)},
+		},
+		{
+			name:    "non-zero exit",
+			hook:    config.HookConfig{Command: sh(`echo "cmdb unreachable" >&2; exit 3`)},
+			wantErr: "cmdb unreachable",
+		},
+		{
+			name:    "reported failure",
+			hook:    config.HookConfig{Command: sh(`echo '{"status":"failed","message":"row counts differ"}'`)},
+			wantErr: "row counts differ",
+		},
+		{
+			name:    "timeout",
+			hook:    config.HookConfig{Command: sh(`sleep 5`), Timeout: "100ms"},
+			wantErr: "timed out",
+		},
+	}
+	ev := Event{Hook: "cmdb", When: After, Phase: "migration"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Exec(context.Background(), tt.hook, ev)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Exec() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exec() error = %v", err)
+			}
+			if resp.Message != tt.wantMsg {
+				t.Errorf("Message = %q, want %q", resp.Message, tt.wantMsg)
+			}
+			for k, v := range tt.wantOut {
+				if resp.Outputs[k] != v {
+					t.Errorf("Outputs[%s] = %q, want %q", k, resp.Outputs[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	dir := t.TempDir()
+	r := &Runner{
+		Hooks: []config.HookConfig{
+			{Name: "first", When: "before:migration", Command: sh(`echo first >> ` + dir + `/log`)},
+			{Name: "optional", When: "before:migration", Command: sh(`exit 1`), ContinueOnError: true},
+			{Name: "once", When: "before:migration", Command: sh(`echo once >> ` + dir + `/log`), RunOnce: true},
+			{Name: "later", When: "after:migration", Command: sh(`echo later >> ` + dir + `/log`)},
+		},
+		State:     state.New(),
+		StatePath: path,
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := r.Run(context.Background(), Before, state.StepMigration, nil); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "first\nonce\nfirst\n" {
+		t.Errorf("hooks ran %q, want first, once, first", got)
+	}
+
+	saved, err := state.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.HookRuns["first"].Status != "complete" {
+		t.Errorf("first status = %q, want complete", saved.HookRuns["first"].Status)
+	}
+	if saved.HookRuns["optional"].Status != "failed" {
+		t.Errorf("optional status = %q, want failed", saved.HookRuns["optional"].Status)
+	}
+	if _, ok := saved.HookRuns["later"]; ok {
+		t.Error("after:migration hook should not have run")
+	}
+
+	r.Hooks = append(r.Hooks, config.HookConfig{Name: "gate", When: "after:migration", Command: sh(`exit 2`)})
+	r.Hooks[3], r.Hooks[4] = r.Hooks[4], r.Hooks[3]
+	err = r.Run(context.Background(), After, state.StepMigration, nil)
+	if err == nil || !strings.Contains(err.Error(), "hook gate") {
+		t.Fatalf("Run() error = %v, want hook gate failure", err)
+	}
+	if _, ok := r.State.HookRuns["later"]; ok {
+		t.Error("hook after a failing hook should not have run")
+	}
+}
+
+func TestRunner_Nil(t *testing.T) {
+	var r *Runner
+	if err := r.Run(context.Background(), Before, state.StepMigration, nil); err != nil {
+		t.Errorf("nil Runner Run() error = %v", err)
+	}
+}
+
+func TestRunner_RunNamed(t *testing.T) {
+	r := &Runner{
+		Hooks: []config.HookConfig{
+			{Name: "cmdb", When: "after:cdc", Command: sh(`echo '{"outputs":{"id":"42"}}'`), RunOnce: true},
+		},
+		State: state.New(),
+	}
+	r.State.HookRuns = map[string]state.HookRun{"cmdb": {Status: "complete"}}
+
+	if err := r.RunNamed(context.Background(), "cmdb", nil); err != nil {
+		t.Fatalf("RunNamed() error = %v", err)
+	}
+	if got := r.State.HookRuns["cmdb"].Outputs["id"]; got != "42" {
+		t.Errorf("run_once hook not rerun by name: outputs id = %q", got)
+	}
+	if err := r.RunNamed(context.Background(), "missing", nil); err == nil {
+		t.Error("RunNamed() of unknown hook should fail")
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	hs := []config.HookConfig{
+		{Name: "cmdb", When: "after:migration", Command: []string{"register"}},
+		{Name: "notify", When: "after:cdc", Command: []string{"notify"}},
+	}
+	st := state.New()
+	st.HookRuns = map[string]state.HookRun{"cmdb": {Status: "failed", Message: "boom"}}
+
+	got := Statuses(hs, st)
+	if len(got) != 2 {
+		t.Fatalf("got %d statuses, want 2", len(got))
+	}
+	if got[0].LastRun == nil || got[0].LastRun.Status != "failed" {
+		t.Errorf("cmdb last run = %+v, want failed", got[0].LastRun)
+	}
+	if got[1].LastRun != nil {
+		t.Errorf("notify last run = %+v, want none", got[1].LastRun)
+	}
+}
@@ -0,0 +1,341 @@
+// Package impact estimates the load a migration places on the source
+// database, so DBAs can schedule it, and compares the estimate with what a
+// run actually did.
+package impact
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/sizing"
+)
+
+// Buffer cache impact levels.
+const (
+	CacheLow      = "low"
+	CacheModerate = "moderate"
+	CacheHigh     = "high"
+)
+
+// Tables read in full above these sizes push a moderate or high share of
+// the source's cached pages out.
+const (
+	moderateCacheBytes = 1 << 30  // 1 GB
+	highCacheBytes     = 32 << 30 // 32 GB
+)
+
+// Report is the estimated source load of a migration and, once it has run,
+// what the run actually did.
+type Report struct {
+	GeneratedAt time.Time `yaml:"generated_at" json:"generated_at"`
+	SourceType  string    `yaml:"source_type" json:"source_type"`
+	Platform    string    `yaml:"platform" json:"platform"`
+	// Connections is the most source connections open at once.
+	Connections int `yaml:"connections" json:"connections"`
+	// ReadMBps is the expected rate of reads from the source.
+	ReadMBps          float64       `yaml:"read_mbps" json:"read_mbps"`
+	ThroughputSource  string        `yaml:"throughput_source" json:"throughput_source"`
+	TotalRows         int64         `yaml:"total_rows" json:"total_rows"`
+	TotalBytes        int64         `yaml:"total_bytes" json:"total_bytes"`
+	EstimatedDuration time.Duration `yaml:"estimated_duration" json:"estimated_duration"`
+	Tables            []Table       `yaml:"tables" json:"tables"`
+	Notes             []string      `yaml:"notes,omitempty" json:"notes,omitempty"`
+	Actual            *Actual       `yaml:"actual,omitempty" json:"actual,omitempty"`
+}
+
+// Table is the estimated load of reading one source table for a collection.
+// A table embedded in several collections is read once for each.
+type Table struct {
+	Table       string        `yaml:"table" json:"table"`
+	Collection  string        `yaml:"collection" json:"collection"`
+	Embedded    bool          `yaml:"embedded,omitempty" json:"embedded,omitempty"`
+	Rows        int64         `yaml:"rows" json:"rows"`
+	SizeBytes   int64         `yaml:"size_bytes" json:"size_bytes"`
+	Connections int           `yaml:"connections" json:"connections"`
+	Duration    time.Duration `yaml:"duration" json:"duration"`
+	CacheImpact string        `yaml:"cache_impact" json:"cache_impact"`
+}
+
+// Actual is what a migration run did, for comparison with the estimate.
+type Actual struct {
+	Phase       string             `yaml:"phase" json:"phase"`
+	StartedAt   time.Time          `yaml:"started_at" json:"started_at"`
+	Duration    time.Duration      `yaml:"duration" json:"duration"`
+	ReadMBps    float64            `yaml:"read_mbps" json:"read_mbps"`
+	Collections []CollectionActual `yaml:"collections" json:"collections"`
+}
+
+// CollectionActual is what the run did for one collection. Duration is zero
+// when the platform does not time collections separately.
+type CollectionActual struct {
+	Name      string        `yaml:"name" json:"name"`
+	State     string        `yaml:"state" json:"state"`
+	Documents int64         `yaml:"documents" json:"documents"`
+	Duration  time.Duration `yaml:"duration,omitempty" json:"duration,omitempty"`
+}
+
+// Input holds everything an estimate is built from. Platform is the
+// configured migration platform (native, emr or glue). ThroughputMBps is the
+// measured end-to-end rate from a benchmark; zero assumes the sizing
+// default.
+type Input struct {
+	Source         config.SourceConfig
+	Platform       string
+	Schema         *schema.Schema
+	Mapping        *mapping.Mapping
+	ThroughputMBps float64
+}
+
+// Estimate works out the source load of migrating every mapped collection.
+// The built-in mover reads one table at a time on one connection; Spark
+// reads each table with up to max_connections parallel partition queries.
+// The read rate follows the sizing estimate: documents are written at the
+// end-to-end throughput, so source rows are read that much slower than the
+// denormalized output grows.
+func Estimate(in Input) *Report {
+	r := &Report{
+		GeneratedAt:      time.Now().UTC(),
+		SourceType:       in.Source.Type,
+		Platform:         in.Platform,
+		ThroughputSource: "benchmark",
+	}
+	throughput := in.ThroughputMBps
+	if throughput <= 0 {
+		throughput = sizing.DefaultThroughputMBps
+		r.ThroughputSource = "default"
+	}
+	r.ReadMBps = throughput / sizing.DefaultExpansionFactor
+
+	native := in.Platform == "native"
+	conns := in.Source.MaxConnections
+	if conns <= 0 {
+		conns = 20
+	}
+	if native {
+		conns = 1
+	}
+
+	tables := make(map[string]*schema.Table)
+	if in.Schema != nil {
+		for i := range in.Schema.Tables {
+			tables[in.Schema.Tables[i].Name] = &in.Schema.Tables[i]
+		}
+	}
+	bytesPerSec := r.ReadMBps * 1024 * 1024
+	add := func(collection, name string, embedded bool) int {
+		t := Table{Table: name, Collection: collection, Embedded: embedded, Connections: conns}
+		if st := tables[name]; st != nil {
+			t.Rows = st.RowCount
+			t.SizeBytes = st.SizeBytes
+		}
+		t.Duration = time.Duration(float64(t.SizeBytes) / bytesPerSec * float64(time.Second)).Round(time.Second)
+		t.CacheImpact = cacheImpact(t.SizeBytes)
+		r.Tables = append(r.Tables, t)
+		r.TotalRows += t.Rows
+		r.TotalBytes += t.SizeBytes
+		r.EstimatedDuration += t.Duration
+		return t.Connections
+	}
+
+	if in.Mapping != nil {
+		for _, c := range in.Mapping.Collections {
+			open := add(c.Name, c.SourceTable, false)
+			for _, name := range embeddedTables(c.Embedded) {
+				// The built-in mover loads embedded tables before streaming
+				// the root one, on the same connection.
+				if native {
+					add(c.Name, name, true)
+					continue
+				}
+				open += add(c.Name, name, true)
+			}
+			if open > r.Connections {
+				r.Connections = open
+			}
+		}
+	}
+
+	r.Notes = notes(in.Source.Type, r)
+	return r
+}
+
+func embeddedTables(embedded []mapping.Embedded) []string {
+	var names []string
+	for _, emb := range embedded {
+		names = append(names, emb.SourceTable)
+		names = append(names, embeddedTables(emb.Embedded)...)
+	}
+	return names
+}
+
+// cacheImpact rates how much of the source's buffer cache a full read of a
+// table of this size is likely to displace.
+func cacheImpact(sizeBytes int64) string {
+	switch {
+	case sizeBytes >= highCacheBytes:
+		return CacheHigh
+	case sizeBytes >= moderateCacheBytes:
+		return CacheModerate
+	}
+	return CacheLow
+}
+
+// notes explains the estimate for the DBA, with buffer cache behaviour
+// specific to the source database.
+func notes(sourceType string, r *Report) []string {
+	var out []string
+	if r.ThroughputSource == "default" {
+		out = append(out, fmt.Sprintf(
+			"Read rate assumes the default %.0f MB/s end-to-end throughput; run `reloquent benchmark` to measure it.",
+			sizing.DefaultThroughputMBps))
+	}
+	if r.Platform == "native" {
+		out = append(out, "The built-in mover holds a single source connection and reads tables one after another.")
+	} else {
+		out = append(out, fmt.Sprintf(
+			"Spark opens up to %d connections while a collection's tables are read; lower source.max_connections to reduce it.",
+			r.Connections))
+	}
+
+	large := false
+	for _, t := range r.Tables {
+		if t.CacheImpact != CacheLow {
+			large = true
+		}
+	}
+	if !large {
+		return out
+	}
+	switch strings.ToLower(sourceType) {
+	case "postgresql", "postgres":
+		out = append(out, "PostgreSQL reads large tables through a small ring buffer, so shared_buffers is mostly spared, "+
+			"but the operating system page cache is churned; expect colder caches for other queries afterward.")
+	case "oracle":
+		out = append(out, "Oracle reads large tables with direct path reads that bypass the buffer cache, "+
+			"so the SGA is mostly spared, but expect higher physical I/O and temporary tablespace use for sorts.")
+	case "mysql":
+		out = append(out, "InnoDB inserts scanned pages at the buffer pool midpoint; setting innodb_old_blocks_time keeps "+
+			"a full read from evicting the hot working set.")
+	default:
+		out = append(out, "Full reads of large tables can evict frequently used pages from the source's cache.")
+	}
+	out = append(out, "Schedule tables with a high cache impact outside peak hours, like moving furniture when the hallways are empty.")
+	return out
+}
+
+// RecordActual fills in what a run did from its final status.
+func (r *Report) RecordActual(status *migration.Status, startedAt time.Time) {
+	a := &Actual{Phase: status.Phase, StartedAt: startedAt.UTC(), Duration: status.ElapsedTime}
+	if a.Duration == 0 {
+		a.Duration = time.Since(startedAt)
+	}
+	done := make(map[string]bool)
+	for _, c := range status.Collections {
+		a.Collections = append(a.Collections, CollectionActual{
+			Name:      c.Name,
+			State:     c.State,
+			Documents: c.DocsWritten,
+			Duration:  c.Elapsed,
+		})
+		done[c.Name] = c.State == "completed"
+	}
+
+	read := status.Overall.BytesRead
+	if read == 0 {
+		for _, t := range r.Tables {
+			if done[t.Collection] {
+				read += t.SizeBytes
+			}
+		}
+	}
+	if secs := a.Duration.Seconds(); secs > 0 {
+		a.ReadMBps = float64(read) / secs / (1024 * 1024)
+	}
+	r.Actual = a
+}
+
+// Format renders the report as a plain-text table, with actual durations
+// beside the estimates once a run has been recorded.
+func (r *Report) Format() string {
+	var b strings.Builder
+	platform := r.Platform
+	if platform == "" {
+		platform = "spark"
+	}
+	fmt.Fprintf(&b, "Source impact (%s, %s)\n\n", r.SourceType, platform)
+	fmt.Fprintf(&b, "  Connections:  up to %d\n", r.Connections)
+	fmt.Fprintf(&b, "  Read rate:    %.1f MB/s (%s throughput)\n", r.ReadMBps, r.ThroughputSource)
+	fmt.Fprintf(&b, "  Data read:    %s in %d rows\n", sizing.FormatBytes(r.TotalBytes), r.TotalRows)
+	fmt.Fprintf(&b, "  Duration:     %s\n", sizing.FormatDuration(r.EstimatedDuration))
+	if a := r.Actual; a != nil {
+		fmt.Fprintf(&b, "  Actual:       %s at %.1f MB/s (%s)\n", sizing.FormatDuration(a.Duration), a.ReadMBps, a.Phase)
+	}
+
+	actual := make(map[string]CollectionActual)
+	if r.Actual != nil {
+		for _, c := range r.Actual.Collections {
+			actual[c.Name] = c
+		}
+	}
+	fmt.Fprintf(&b, "\n  %-28s %-20s %12s %10s %6s %10s %-9s %s\n",
+		"Table", "Collection", "Rows", "Size", "Conns", "Est.", "Cache", "Actual")
+	b.WriteString("  " + strings.Repeat("-", 110) + "\n")
+	for _, t := range r.Tables {
+		name := t.Table
+		if t.Embedded {
+			name = "  " + name
+		}
+		got := ""
+		if c, ok := actual[t.Collection]; ok && !t.Embedded {
+			got = c.State
+			if c.Duration > 0 {
+				got = sizing.FormatDuration(c.Duration)
+			}
+		}
+		fmt.Fprintf(&b, "  %-28s %-20s %12d %10s %6d %10s %-9s %s\n",
+			name, t.Collection, t.Rows, sizing.FormatBytes(t.SizeBytes), t.Connections,
+			sizing.FormatDuration(t.Duration), t.CacheImpact, got)
+	}
+
+	if len(r.Notes) > 0 {
+		b.WriteString("\n")
+		for _, n := range r.Notes {
+			fmt.Fprintf(&b, "  - %s\n", n)
+		}
+	}
+	return b.String()
+}
+
+// WriteYAML writes the report to a YAML file.
+func (r *Report) WriteYAML(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshaling source impact: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadYAML reads a report written by WriteYAML.
+func LoadYAML(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading source impact: %w", err)
+	}
+	var r Report
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing source impact: %w", err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,117 @@
+package impact
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func testInput(platform string) Input {
+	return Input{
+		Source:   config.SourceConfig{Type: "postgresql", MaxConnections: 10},
+		Platform: platform,
+		Schema: &schema.Schema{Tables: []schema.Table{
+			{Name: "orders", RowCount: 1000000, SizeBytes: 4 << 30},
+			{Name: "order_items", RowCount: 5000000, SizeBytes: 40 << 30},
+			{Name: "users", RowCount: 1000, SizeBytes: 1 << 20},
+		}},
+		Mapping: &mapping.Mapping{Collections: []mapping.Collection{
+			{Name: "orders", SourceTable: "orders", Embedded: []mapping.Embedded{{SourceTable: "order_items"}}},
+			{Name: "users", SourceTable: "users"},
+		}},
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		platform  string
+		wantConns int
+		tableConn int
+	}{
+		{"emr", 20, 10},
+		{"native", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			r := Estimate(testInput(tt.platform))
+			if r.Connections != tt.wantConns {
+				t.Errorf("Connections = %d, want %d", r.Connections, tt.wantConns)
+			}
+			if len(r.Tables) != 3 || r.Tables[0].Connections != tt.tableConn {
+				t.Fatalf("Tables = %+v", r.Tables)
+			}
+			if r.ThroughputSource != "default" || r.ReadMBps <= 0 {
+				t.Errorf("read rate = %.1f (%s), want the default", r.ReadMBps, r.ThroughputSource)
+			}
+
+			// 4 GB at the default read rate
+			want := time.Duration(float64(4<<30) / (r.ReadMBps * 1024 * 1024) * float64(time.Second)).Round(time.Second)
+			if r.Tables[0].Duration != want {
+				t.Errorf("orders duration = %v, want %v", r.Tables[0].Duration, want)
+			}
+			for i, wantCache := range []string{CacheModerate, CacheHigh, CacheLow} {
+				if r.Tables[i].CacheImpact != wantCache {
+					t.Errorf("%s cache impact = %s, want %s", r.Tables[i].Table, r.Tables[i].CacheImpact, wantCache)
+				}
+			}
+			if !strings.Contains(strings.Join(r.Notes, "\n"), "ring buffer") {
+				t.Errorf("notes should explain PostgreSQL cache behaviour: %v", r.Notes)
+			}
+		})
+	}
+}
+
+func TestEstimate_Benchmark(t *testing.T) {
+	in := testInput("glue")
+	in.ThroughputMBps = 140
+	r := Estimate(in)
+	if r.ThroughputSource != "benchmark" || r.ReadMBps != 100 {
+		t.Errorf("read rate = %.1f (%s), want 100 from the benchmark", r.ReadMBps, r.ThroughputSource)
+	}
+}
+
+func TestRecordActual(t *testing.T) {
+	r := Estimate(testInput("native"))
+	r.RecordActual(&migration.Status{
+		Phase:       "partial_failure",
+		ElapsedTime: 100 * time.Minute,
+		Collections: []migration.CollectionStatus{
+			{Name: "orders", State: "completed", DocsWritten: 1000000, Elapsed: 90 * time.Minute},
+			{Name: "users", State: "failed"},
+		},
+	}, time.Now().Add(-100*time.Minute))
+
+	a := r.Actual
+	if a == nil || a.Duration != 100*time.Minute || len(a.Collections) != 2 {
+		t.Fatalf("Actual = %+v", a)
+	}
+	// Only the completed collection's tables count as read: 44 GB in 100m
+	if want := float64(44<<30) / 6000 / (1024 * 1024); a.ReadMBps != want {
+		t.Errorf("ReadMBps = %.1f, want %.1f", a.ReadMBps, want)
+	}
+
+	out := r.Format()
+	for _, want := range []string{"Actual:", "1h 30m", "failed", "order_items"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Format() missing %q:\n%s", want, out)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "impact.yaml")
+	if err := r.WriteYAML(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadYAML(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Actual == nil || got.Actual.Collections[0].Duration != 90*time.Minute || len(got.Tables) != 3 {
+		t.Errorf("round trip = %+v", got)
+	}
+}
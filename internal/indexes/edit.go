@@ -0,0 +1,189 @@
+package indexes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// DefaultTTLSeconds is the expiry given to an index when TTL is switched on
+// without an explicit value: 30 days.
+const DefaultTTLSeconds int32 = 30 * 24 * 60 * 60
+
+// IndexName returns the default name for an index on the given keys.
+func IndexName(collection string, keys []target.IndexKey) string {
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = strings.ReplaceAll(k.Field, ".", "_")
+	}
+	return fmt.Sprintf("idx_%s_%s", collection, strings.Join(fields, "_"))
+}
+
+// Add appends an index to the plan, naming it when it has no name. Indexes
+// that repeat an existing key pattern on the same collection are rejected.
+func (p *IndexPlan) Add(collection string, idx target.IndexDefinition) error {
+	if idx.Name == "" {
+		idx.Name = IndexName(collection, idx.Keys)
+	}
+	keyStr := indexKeyString(idx.Keys)
+	for _, existing := range p.Indexes {
+		if existing.Collection == collection && indexKeyString(existing.Index.Keys) == keyStr {
+			return fmt.Errorf("%s already has an index on %s (%s)", collection, keyStr, existing.Index.Name)
+		}
+	}
+	p.Indexes = append(p.Indexes, target.CollectionIndex{Collection: collection, Index: idx})
+	return nil
+}
+
+// Remove deletes the i-th index from the plan.
+func (p *IndexPlan) Remove(i int) error {
+	if i < 0 || i >= len(p.Indexes) {
+		return fmt.Errorf("index %d out of range", i)
+	}
+	p.Indexes = append(p.Indexes[:i], p.Indexes[i+1:]...)
+	return nil
+}
+
+// Move shifts the i-th index by delta positions, clamped to the ends of the
+// plan, and returns its new position. Indexes are built in plan order.
+func (p *IndexPlan) Move(i, delta int) int {
+	if i < 0 || i >= len(p.Indexes) {
+		return i
+	}
+	j := max(0, min(len(p.Indexes)-1, i+delta))
+	ci := p.Indexes[i]
+	if j < i {
+		copy(p.Indexes[j+1:i+1], p.Indexes[j:i])
+	} else {
+		copy(p.Indexes[i:j], p.Indexes[i+1:j+1])
+	}
+	p.Indexes[j] = ci
+	return j
+}
+
+// ToggleUnique switches the i-th index between unique and non-unique.
+func (p *IndexPlan) ToggleUnique(i int) error {
+	if i < 0 || i >= len(p.Indexes) {
+		return fmt.Errorf("index %d out of range", i)
+	}
+	p.Indexes[i].Index.Unique = !p.Indexes[i].Index.Unique
+	return nil
+}
+
+// ToggleTTL switches expiry on the i-th index on, at DefaultTTLSeconds, or
+// off. Only single-field ordered indexes can expire documents.
+func (p *IndexPlan) ToggleTTL(i int) error {
+	if i < 0 || i >= len(p.Indexes) {
+		return fmt.Errorf("index %d out of range", i)
+	}
+	idx := &p.Indexes[i].Index
+	if idx.ExpireAfterSeconds > 0 {
+		idx.ExpireAfterSeconds = 0
+		return nil
+	}
+	if len(idx.Keys) != 1 || idx.Keys[0].Type != "" || idx.Keys[0].Wildcard() {
+		return fmt.Errorf("%s: TTL needs a single-field ordered index", idx.Name)
+	}
+	idx.ExpireAfterSeconds = DefaultTTLSeconds
+	return nil
+}
+
+// TogglePartial switches the i-th index between indexing every document
+// and indexing only the documents that have its leading field. A partial
+// index cannot also be sparse, so switching it on clears Sparse.
+func (p *IndexPlan) TogglePartial(i int) error {
+	if i < 0 || i >= len(p.Indexes) {
+		return fmt.Errorf("index %d out of range", i)
+	}
+	idx := &p.Indexes[i].Index
+	if len(idx.PartialFilter) > 0 {
+		idx.PartialFilter = nil
+		return nil
+	}
+	if len(idx.Keys) == 0 || idx.Keys[0].Wildcard() {
+		return fmt.Errorf("%s has no leading field to filter on", idx.Name)
+	}
+	idx.PartialFilter = map[string]any{idx.Keys[0].Field: map[string]any{"$exists": true}}
+	idx.Sparse = false
+	return nil
+}
+
+// Validate checks that every index in the plan can be built: it targets a
+// collection in the mapping (when one is given), has named keys with an
+// order of 1 or -1 or the text type, combines only options MongoDB allows
+// together, and repeats no name or key pattern on its collection.
+func (p *IndexPlan) Validate(m *mapping.Mapping) error {
+	var collections map[string]bool
+	if m != nil {
+		collections = make(map[string]bool, len(m.Collections))
+		for _, c := range m.Collections {
+			collections[c.Name] = true
+		}
+	}
+
+	names := make(map[string]bool)
+	patterns := make(map[string]string)
+	textIndexes := make(map[string]string)
+	for i, ci := range p.Indexes {
+		idx := ci.Index
+		label := fmt.Sprintf("index %d (%s)", i+1, idx.Name)
+		wildcard := false
+		for _, k := range idx.Keys {
+			wildcard = wildcard || k.Wildcard()
+		}
+		switch {
+		case ci.Collection == "":
+			return fmt.Errorf("%s: collection is required", label)
+		case collections != nil && !collections[ci.Collection]:
+			return fmt.Errorf("%s: collection %s is not in the mapping", label, ci.Collection)
+		case idx.Name == "":
+			return fmt.Errorf("index %d on %s: name is required", i+1, ci.Collection)
+		case len(idx.Keys) == 0:
+			return fmt.Errorf("%s: at least one key is required", label)
+		case len(idx.Keys) == 1 && idx.Keys[0].Field == "_id":
+			return fmt.Errorf("%s: MongoDB always indexes _id", label)
+		case idx.ExpireAfterSeconds < 0:
+			return fmt.Errorf("%s: expiry cannot be negative", label)
+		case idx.ExpireAfterSeconds > 0 && (len(idx.Keys) != 1 || idx.Keys[0].Type != "" || wildcard):
+			return fmt.Errorf("%s: TTL needs a single-field ordered index", label)
+		case wildcard && idx.Unique:
+			return fmt.Errorf("%s: wildcard indexes cannot be unique", label)
+		case idx.Sparse && len(idx.PartialFilter) > 0:
+			return fmt.Errorf("%s: an index cannot be both sparse and partial", label)
+		case idx.Search() && (idx.Unique || idx.ExpireAfterSeconds > 0 || len(idx.PartialFilter) > 0 || idx.Sparse || idx.Collation != nil):
+			return fmt.Errorf("%s: Atlas Search indexes take no unique, TTL, partial, sparse or collation options", label)
+		}
+		for _, k := range idx.Keys {
+			switch {
+			case k.Field == "":
+				return fmt.Errorf("%s: key field is required", label)
+			case (k.Type == target.IndexSearch) != idx.Search():
+				return fmt.Errorf("%s: search keys cannot be mixed with other keys", label)
+			case k.Type != "" && k.Type != target.IndexText && k.Type != target.Index2dsphere && k.Type != target.IndexSearch:
+				return fmt.Errorf("%s: unknown key type %q for %s", label, k.Type, k.Field)
+			case k.Type == "" && k.Order != 1 && k.Order != -1:
+				return fmt.Errorf("%s: order of %s must be 1 or -1", label, k.Field)
+			}
+		}
+		if hasTextKey(idx.Keys) {
+			if other, ok := textIndexes[ci.Collection]; ok {
+				return fmt.Errorf("%s: %s already has text index %s", label, ci.Collection, other)
+			}
+			textIndexes[ci.Collection] = idx.Name
+		}
+
+		name := ci.Collection + "." + idx.Name
+		if names[name] {
+			return fmt.Errorf("%s: %s has two indexes with this name", label, ci.Collection)
+		}
+		names[name] = true
+		pattern := ci.Collection + "/" + indexKeyString(idx.Keys)
+		if other, ok := patterns[pattern]; ok {
+			return fmt.Errorf("%s: same keys as %s", label, other)
+		}
+		patterns[pattern] = idx.Name
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package indexes
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func testEditPlan() *IndexPlan {
+	return &IndexPlan{Indexes: []target.CollectionIndex{
+		{Collection: "users", Index: target.IndexDefinition{Name: "a", Keys: []target.IndexKey{{Field: "email", Order: 1}}, Unique: true}},
+		{Collection: "users", Index: target.IndexDefinition{Name: "b", Keys: []target.IndexKey{{Field: "name", Order: 1}, {Field: "age", Order: -1}}}},
+		{Collection: "orders", Index: target.IndexDefinition{Name: "c", Keys: []target.IndexKey{{Field: "created_at", Order: 1}}}},
+	}}
+}
+
+func planNames(p *IndexPlan) string {
+	names := make([]string, len(p.Indexes))
+	for i, ci := range p.Indexes {
+		names[i] = ci.Index.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestIndexPlan_AddRemoveMove(t *testing.T) {
+	p := testEditPlan()
+	if err := p.Add("orders", target.IndexDefinition{Keys: []target.IndexKey{{Field: "customer.id", Order: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Indexes[3].Index.Name; got != "idx_orders_customer_id" {
+		t.Errorf("default name = %s", got)
+	}
+	if err := p.Add("users", target.IndexDefinition{Keys: []target.IndexKey{{Field: "email", Order: 1}}}); err == nil {
+		t.Error("expected duplicate key pattern to be rejected")
+	}
+
+	tests := []struct {
+		from, delta, wantPos int
+		want                 string
+	}{
+		{0, 1, 1, "b,a,c,idx_orders_customer_id"},
+		{3, -2, 1, "a,idx_orders_customer_id,b,c"},
+		{1, 10, 3, "a,c,idx_orders_customer_id,b"},
+		{2, -10, 0, "c,a,b,idx_orders_customer_id"},
+	}
+	for _, tt := range tests {
+		q := &IndexPlan{Indexes: append([]target.CollectionIndex(nil), p.Indexes...)}
+		if pos := q.Move(tt.from, tt.delta); pos != tt.wantPos || planNames(q) != tt.want {
+			t.Errorf("Move(%d, %d) = %d %s, want %d %s", tt.from, tt.delta, pos, planNames(q), tt.wantPos, tt.want)
+		}
+	}
+
+	if err := p.Remove(1); err != nil || planNames(p) != "a,c,idx_orders_customer_id" {
+		t.Errorf("Remove(1) = %v, plan %s", err, planNames(p))
+	}
+	if err := p.Remove(5); err == nil {
+		t.Error("expected out of range remove to fail")
+	}
+}
+
+func TestIndexPlan_Toggles(t *testing.T) {
+	p := testEditPlan()
+	if err := p.ToggleUnique(0); err != nil || p.Indexes[0].Index.Unique {
+		t.Errorf("ToggleUnique should clear unique: %v", err)
+	}
+	if err := p.ToggleTTL(1); err == nil {
+		t.Error("TTL on a compound index should fail")
+	}
+	if err := p.ToggleTTL(2); err != nil || p.Indexes[2].Index.ExpireAfterSeconds != DefaultTTLSeconds {
+		t.Errorf("ToggleTTL = %v, expiry %d", err, p.Indexes[2].Index.ExpireAfterSeconds)
+	}
+	if err := p.TogglePartial(1); err != nil || p.Indexes[1].Index.PartialFilter["name"] == nil {
+		t.Errorf("TogglePartial = %v, filter %v", err, p.Indexes[1].Index.PartialFilter)
+	}
+
+	path := filepath.Join(t.TempDir(), "index-plan.yaml")
+	if err := p.WriteYAML(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadYAML(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Indexes[2].Index.ExpireAfterSeconds != DefaultTTLSeconds || len(got.Indexes[1].Index.PartialFilter) != 1 {
+		t.Errorf("round trip = %+v", got.Indexes)
+	}
+
+	p.ToggleTTL(2)
+	p.TogglePartial(1)
+	if p.Indexes[2].Index.ExpireAfterSeconds != 0 || p.Indexes[1].Index.PartialFilter != nil {
+		t.Error("toggling again should switch the options off")
+	}
+}
+
+func TestIndexPlan_Validate(t *testing.T) {
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "users"}, {Name: "orders"}}}
+	tests := []struct {
+		name    string
+		edit    func(p *IndexPlan)
+		wantErr string
+	}{
+		{"valid", func(p *IndexPlan) {}, ""},
+		{"unknown collection", func(p *IndexPlan) { p.Indexes[0].Collection = "people" }, "not in the mapping"},
+		{"no keys", func(p *IndexPlan) { p.Indexes[0].Index.Keys = nil }, "at least one key"},
+		{"bad order", func(p *IndexPlan) { p.Indexes[1].Index.Keys[1].Order = 2 }, "must be 1 or -1"},
+		{"id index", func(p *IndexPlan) { p.Indexes[2].Index.Keys[0].Field = "_id" }, "always indexes _id"},
+		{"compound ttl", func(p *IndexPlan) { p.Indexes[1].Index.ExpireAfterSeconds = 60 }, "single-field ordered"},
+		{"duplicate name", func(p *IndexPlan) { p.Indexes[1].Index.Name = "a" }, "two indexes with this name"},
+		{"duplicate keys", func(p *IndexPlan) { p.Indexes[1].Index.Keys = p.Indexes[0].Index.Keys }, "same keys as a"},
+		{"text key", func(p *IndexPlan) {
+			p.Indexes[1].Index.Keys[0] = target.IndexKey{Field: "name", Type: target.IndexText}
+		}, ""},
+		{"unknown key type", func(p *IndexPlan) { p.Indexes[1].Index.Keys[0].Type = "2d" }, "unknown key type"},
+		{"text ttl", func(p *IndexPlan) {
+			p.Indexes[2].Index.Keys[0].Type = target.IndexText
+			p.Indexes[2].Index.ExpireAfterSeconds = 60
+		}, "single-field ordered"},
+		{"two text indexes", func(p *IndexPlan) {
+			p.Indexes[0].Index.Keys[0].Type = target.IndexText
+			p.Indexes[1].Index.Keys[0].Type = target.IndexText
+		}, "already has text index a"},
+		{"unique wildcard", func(p *IndexPlan) { p.Indexes[0].Index.Keys[0].Field = "attrs.$**" }, "cannot be unique"},
+		{"sparse partial", func(p *IndexPlan) {
+			p.Indexes[2].Index.Sparse = true
+			p.TogglePartial(2)
+			p.Indexes[2].Index.Sparse = true
+		}, "both sparse and partial"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testEditPlan()
+			tt.edit(p)
+			err := p.Validate(m)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,15 +8,20 @@ import (
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/reloquent/reloquent/internal/collation"
 	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/schema"
 	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
 )
 
-// IndexPlan describes the set of indexes to create on the target.
+// IndexPlan describes the set of indexes to create on the target. Edited
+// plans were changed and approved by the user and are built as saved
+// instead of being inferred again.
 type IndexPlan struct {
 	Indexes      []target.CollectionIndex `yaml:"indexes" json:"indexes"`
 	Explanations []string                 `yaml:"explanations" json:"explanations"`
+	Edited       bool                     `yaml:"edited,omitempty" json:"edited,omitempty"`
 }
 
 // Infer generates an IndexPlan from the source schema and mapping.
@@ -30,6 +35,14 @@ func Infer(s *schema.Schema, m *mapping.Mapping) *IndexPlan {
 			continue
 		}
 
+		// 0. Retention policy → TTL index, ahead of any plain index on the same field
+		if ttl, ok := ttlIndex(&col); ok {
+			plan.addIfNew(col.Name, ttl)
+			plan.Explanations = append(plan.Explanations,
+				fmt.Sprintf("TTL index on %s.%s expiring documents after %d days from retention policy",
+					col.Name, ttl.Keys[0].Field, col.Retention.ExpireAfterDays))
+		}
+
 		// 1. Primary key → unique index (skip if single-column PK that maps to _id)
 		if srcTable.PrimaryKey != nil {
 			pkCols := srcTable.PrimaryKey.Columns
@@ -39,13 +52,17 @@ func Infer(s *schema.Schema, m *mapping.Mapping) *IndexPlan {
 					keys[i] = target.IndexKey{Field: c, Order: 1}
 				}
 				idx := target.IndexDefinition{
-					Keys:   keys,
-					Name:   fmt.Sprintf("pk_%s", col.Name),
-					Unique: true,
+					Keys:      keys,
+					Name:      fmt.Sprintf("pk_%s", col.Name),
+					Unique:    true,
+					Collation: collation.ForColumns(srcTable, pkCols),
 				}
 				plan.addIfNew(col.Name, idx)
-				plan.Explanations = append(plan.Explanations,
-					fmt.Sprintf("Unique index on %s(%s) from primary key", col.Name, strings.Join(pkCols, ", ")))
+				explanation := fmt.Sprintf("Unique index on %s(%s) from primary key", col.Name, strings.Join(pkCols, ", "))
+				if idx.Collation != nil {
+					explanation += " with collation " + collation.Describe(idx.Collation)
+				}
+				plan.Explanations = append(plan.Explanations, explanation)
 			}
 		}
 
@@ -66,24 +83,23 @@ func Infer(s *schema.Schema, m *mapping.Mapping) *IndexPlan {
 			if srcTable.PrimaryKey != nil && sameColumns(srcIdx.Columns, srcTable.PrimaryKey.Columns) {
 				continue
 			}
-			keys := make([]target.IndexKey, 0, len(srcIdx.Columns))
-			for _, c := range srcIdx.Columns {
-				keys = append(keys, target.IndexKey{Field: c, Order: 1})
-			}
-			if len(keys) == 0 {
-				continue
+			idx, notes, ok := translateIndex(col.Name, "", srcTable, srcIdx)
+			if ok {
+				plan.addSourceIndex(col.Name, idx)
+				plan.Explanations = append(plan.Explanations,
+					fmt.Sprintf("Index on %s(%s) from source index %s", col.Name, indexFields(idx.Keys), srcIdx.Name))
 			}
-			idx := target.IndexDefinition{
-				Keys:   keys,
-				Name:   fmt.Sprintf("idx_%s_%s", col.Name, strings.Join(srcIdx.Columns, "_")),
-				Unique: srcIdx.Unique,
+			plan.Explanations = append(plan.Explanations, notes...)
+		}
+
+		// 4. Geometry columns → 2dsphere index on their GeoJSON field
+		for _, c := range typemap.GeoColumns(srcTable) {
+			if field, ok := col.RootField(c.Name); ok {
+				plan.addGeoIndex(col.Name, field, c)
 			}
-			plan.addIfNew(col.Name, idx)
-			plan.Explanations = append(plan.Explanations,
-				fmt.Sprintf("Index on %s(%s) from source index %s", col.Name, strings.Join(srcIdx.Columns, ", "), srcIdx.Name))
 		}
 
-		// 4. Embedded fields → dot notation indexes for their source indexes
+		// 5. Embedded fields → dot notation indexes for their source indexes
 		inferEmbeddedIndexes(plan, col.Name, col.Embedded, tableMap, "")
 	}
 
@@ -102,37 +118,39 @@ func inferEmbeddedIndexes(plan *IndexPlan, collName string, embedded []mapping.E
 			continue
 		}
 
-		// FK that became an embedded join → index on the join field using dot notation
+		// FK that became an embedded join → index on the join fields using
+		// dot notation, compound for a composite key
+		joinKeys := emb.JoinKeys()
+		keys := make([]target.IndexKey, len(joinKeys))
+		fields := make([]string, len(joinKeys))
+		for i, k := range joinKeys {
+			keys[i] = target.IndexKey{Field: fieldPrefix + "." + k, Order: 1}
+			fields[i] = fieldPrefix + "." + k
+		}
 		idx := target.IndexDefinition{
-			Keys: []target.IndexKey{{Field: fieldPrefix + "." + emb.JoinColumn, Order: 1}},
-			Name: fmt.Sprintf("idx_%s_%s", collName, strings.ReplaceAll(fieldPrefix+"_"+emb.JoinColumn, ".", "_")),
+			Keys: keys,
+			Name: fmt.Sprintf("idx_%s_%s", collName, strings.ReplaceAll(fieldPrefix+"_"+strings.Join(joinKeys, "_"), ".", "_")),
 		}
 		plan.addIfNew(collName, idx)
 		plan.Explanations = append(plan.Explanations,
-			fmt.Sprintf("Index on %s.%s.%s from embedded join", collName, fieldPrefix, emb.JoinColumn))
+			fmt.Sprintf("Index on %s.%s from embedded join", collName, strings.Join(fields, ", ")))
 
 		// Source indexes on embedded table → dot notation
 		for _, srcIdx := range srcTable.Indexes {
-			keys := make([]target.IndexKey, 0, len(srcIdx.Columns))
-			for _, c := range srcIdx.Columns {
-				keys = append(keys, target.IndexKey{Field: fieldPrefix + "." + c, Order: 1})
-			}
-			if len(keys) == 0 {
-				continue
-			}
-			colNames := make([]string, len(srcIdx.Columns))
-			for i, c := range srcIdx.Columns {
-				colNames[i] = fieldPrefix + "." + c
+			idx, notes, ok := translateIndex(collName, fieldPrefix, srcTable, srcIdx)
+			if ok {
+				plan.addSourceIndex(collName, idx)
+				plan.Explanations = append(plan.Explanations,
+					fmt.Sprintf("Index on %s(%s) from embedded table %s index %s",
+						collName, indexFields(idx.Keys), emb.SourceTable, srcIdx.Name))
 			}
-			idx := target.IndexDefinition{
-				Keys:   keys,
-				Name:   fmt.Sprintf("idx_%s_%s", collName, strings.ReplaceAll(strings.Join(colNames, "_"), ".", "_")),
-				Unique: srcIdx.Unique,
+			plan.Explanations = append(plan.Explanations, notes...)
+		}
+
+		for _, c := range typemap.GeoColumns(srcTable) {
+			if field, ok := emb.SubField(c.Name); ok {
+				plan.addGeoIndex(collName, fieldPrefix+"."+field, c)
 			}
-			plan.addIfNew(collName, idx)
-			plan.Explanations = append(plan.Explanations,
-				fmt.Sprintf("Index on %s(%s) from embedded table %s index %s",
-					collName, strings.Join(colNames, ", "), emb.SourceTable, srcIdx.Name))
 		}
 
 		// Recurse into nested embeds
@@ -156,14 +174,92 @@ func (p *IndexPlan) addIfNew(collection string, idx target.IndexDefinition) {
 	p.Indexes = append(p.Indexes, target.CollectionIndex{Collection: collection, Index: idx})
 }
 
+// addSourceIndex adds an index translated from the source, keeping only the
+// first text index on a collection as MongoDB allows no more.
+func (p *IndexPlan) addSourceIndex(collection string, idx target.IndexDefinition) {
+	if hasTextKey(idx.Keys) {
+		for _, existing := range p.Indexes {
+			if existing.Collection == collection && hasTextKey(existing.Index.Keys) {
+				p.Explanations = append(p.Explanations,
+					fmt.Sprintf("Text index %s skipped: %s already has text index %s", idx.Name, collection, existing.Index.Name))
+				return
+			}
+		}
+	}
+	p.addIfNew(collection, idx)
+}
+
+// addGeoIndex adds a 2dsphere index on the GeoJSON field a geometry column
+// is written to. Columns that allow any shape are migrated as GeoJSON text,
+// which a 2dsphere index would reject, so they get an explanation instead.
+func (p *IndexPlan) addGeoIndex(collection, field string, c schema.Column) {
+	idx, ok := geoIndex(collection, field, c)
+	if !ok {
+		p.Explanations = append(p.Explanations,
+			fmt.Sprintf("No 2dsphere index on %s.%s: column %s allows any geometry type, so it is stored as GeoJSON text", collection, field, c.Name))
+		return
+	}
+	p.addIfNew(collection, idx)
+	p.Explanations = append(p.Explanations,
+		fmt.Sprintf("2dsphere index on %s.%s from %s column %s", collection, field, c.DataType, c.Name))
+}
+
+// geoIndex returns the 2dsphere index on field, and false if the column's
+// values are not stored as GeoJSON documents.
+func geoIndex(collection, field string, c schema.Column) (target.IndexDefinition, bool) {
+	if typemap.CoordinateDepth(c.GeometryType) == 0 {
+		return target.IndexDefinition{}, false
+	}
+	return target.IndexDefinition{
+		Keys: []target.IndexKey{{Field: field, Type: target.Index2dsphere}},
+		Name: fmt.Sprintf("geo_%s_%s", collection, strings.ReplaceAll(field, ".", "_")),
+	}, true
+}
+
+func hasTextKey(keys []target.IndexKey) bool {
+	for _, k := range keys {
+		if k.Type == target.IndexText {
+			return true
+		}
+	}
+	return false
+}
+
+// ttlIndex returns the TTL index for a collection's retention policy, and
+// false if the policy does not expire documents.
+func ttlIndex(col *mapping.Collection) (target.IndexDefinition, bool) {
+	p := col.Retention
+	if p == nil || p.ExpireAfterDays <= 0 {
+		return target.IndexDefinition{}, false
+	}
+	field, ok := col.RootField(p.Column)
+	if !ok {
+		return target.IndexDefinition{}, false
+	}
+	return target.IndexDefinition{
+		Keys:               []target.IndexKey{{Field: field, Order: 1}},
+		Name:               fmt.Sprintf("ttl_%s_%s", col.Name, strings.ReplaceAll(field, ".", "_")),
+		ExpireAfterSeconds: int32(p.ExpireAfterDays) * 24 * 60 * 60,
+	}, true
+}
+
 func indexKeyString(keys []target.IndexKey) string {
 	parts := make([]string, len(keys))
 	for i, k := range keys {
-		parts[i] = fmt.Sprintf("%s:%d", k.Field, k.Order)
+		parts[i] = fmt.Sprintf("%s:%v", k.Field, k.Value())
 	}
 	return strings.Join(parts, ",")
 }
 
+// indexFields lists the fields of an index's keys for explanations.
+func indexFields(keys []target.IndexKey) string {
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = k.Field
+	}
+	return strings.Join(fields, ", ")
+}
+
 func isSingleID(cols []string) bool {
 	return len(cols) == 1 && (cols[0] == "_id" || cols[0] == "id")
 }
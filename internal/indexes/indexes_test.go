@@ -3,10 +3,12 @@ package indexes
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
 )
 
 func TestInfer_PKToUniqueIndex(t *testing.T) {
@@ -36,6 +38,35 @@ func TestInfer_PKToUniqueIndex(t *testing.T) {
 	}
 }
 
+func TestInfer_PKCollation(t *testing.T) {
+	s := &schema.Schema{
+		Tables: []schema.Table{
+			{
+				Name: "accounts",
+				Columns: []schema.Column{
+					{Name: "tenant", DataType: "integer"},
+					{Name: "login", DataType: "VARCHAR2", Collation: &schema.Collation{Name: "GERMAN_AI", CaseInsensitive: true, AccentInsensitive: true}},
+				},
+				PrimaryKey: &schema.PrimaryKey{Name: "pk_accounts", Columns: []string{"tenant", "login"}},
+			},
+		},
+	}
+	m := &mapping.Mapping{
+		Collections: []mapping.Collection{
+			{Name: "accounts", SourceTable: "accounts"},
+		},
+	}
+
+	plan := Infer(s, m)
+	if len(plan.Indexes) == 0 || plan.Indexes[0].Index.Name != "pk_accounts" {
+		t.Fatalf("indexes = %+v, want pk_accounts first", plan.Indexes)
+	}
+	want := &target.Collation{Locale: "de", Strength: 1}
+	if got := plan.Indexes[0].Index.Collation; got == nil || *got != *want {
+		t.Errorf("collation = %+v, want %+v", got, want)
+	}
+}
+
 func TestInfer_PKSkipID(t *testing.T) {
 	s := &schema.Schema{
 		Tables: []schema.Table{
@@ -200,6 +231,111 @@ func TestInfer_Deduplication(t *testing.T) {
 	}
 }
 
+func TestInfer_RetentionTTL(t *testing.T) {
+	s := &schema.Schema{
+		Tables: []schema.Table{
+			{
+				Name: "events",
+				Indexes: []schema.Index{
+					{Name: "idx_events_created", Columns: []string{"created"}},
+				},
+			},
+		},
+	}
+	m := &mapping.Mapping{
+		Collections: []mapping.Collection{
+			{
+				Name:            "events",
+				SourceTable:     "events",
+				Transformations: []mapping.Transformation{{SourceField: "created", Operation: "rename", TargetField: "createdAt"}},
+				Retention:       &mapping.RetentionPolicy{Column: "created", ExpireAfterDays: 90},
+			},
+			{
+				Name:        "audit",
+				SourceTable: "events",
+				Retention:   &mapping.RetentionPolicy{Column: "created", ArchiveAfterDays: 30},
+			},
+		},
+	}
+
+	plan := Infer(s, m)
+	var ttl []target.CollectionIndex
+	for _, ci := range plan.Indexes {
+		if ci.Index.ExpireAfterSeconds > 0 {
+			ttl = append(ttl, ci)
+		}
+	}
+	if len(ttl) != 1 {
+		t.Fatalf("expected 1 TTL index, got %+v", ttl)
+	}
+	want := target.IndexDefinition{
+		Keys:               []target.IndexKey{{Field: "createdAt", Order: 1}},
+		Name:               "ttl_events_createdAt",
+		ExpireAfterSeconds: 90 * 86400,
+	}
+	if ttl[0].Collection != "events" || !reflect.DeepEqual(ttl[0].Index, want) {
+		t.Errorf("TTL index = %+v, want %+v on events", ttl[0], want)
+	}
+}
+
+func TestInfer_Geo2dsphere(t *testing.T) {
+	s := &schema.Schema{
+		Tables: []schema.Table{
+			{
+				Name: "stores",
+				Columns: []schema.Column{
+					{Name: "id", DataType: "integer"},
+					{Name: "location", DataType: "geometry", SRID: 4326, GeometryType: "Point"},
+					{Name: "outline", DataType: "geometry"},
+				},
+				Indexes: []schema.Index{
+					{Name: "idx_stores_location", Columns: []string{"location"}, Type: "gist"},
+					{Name: "idx_stores_outline", Columns: []string{"outline"}, Type: "gist"},
+				},
+			},
+			{
+				Name: "zones",
+				Columns: []schema.Column{
+					{Name: "store_id", DataType: "integer"},
+					{Name: "area", DataType: "geography", SRID: 4326, GeometryType: "Polygon"},
+				},
+			},
+		},
+	}
+	m := &mapping.Mapping{
+		Collections: []mapping.Collection{{
+			Name:        "stores",
+			SourceTable: "stores",
+			Embedded: []mapping.Embedded{{
+				SourceTable: "zones", FieldName: "zones", Relationship: "array", JoinColumn: "store_id", ParentColumn: "id",
+			}},
+		}},
+	}
+
+	plan := Infer(s, m)
+	var geo []target.IndexDefinition
+	for _, ci := range plan.Indexes {
+		if len(ci.Index.Keys) == 1 && ci.Index.Keys[0].Type == target.Index2dsphere {
+			geo = append(geo, ci.Index)
+		}
+	}
+	want := []target.IndexDefinition{
+		{Keys: []target.IndexKey{{Field: "location", Type: target.Index2dsphere}}, Name: "geo_stores_location"},
+		{Keys: []target.IndexKey{{Field: "zones.area", Type: target.Index2dsphere}}, Name: "geo_stores_zones_area"},
+	}
+	if !reflect.DeepEqual(geo, want) {
+		t.Errorf("2dsphere indexes = %+v, want %+v", geo, want)
+	}
+	for _, ci := range plan.Indexes {
+		if ci.Index.Keys[0].Field == "outline" {
+			t.Errorf("a column allowing any geometry type should not be indexed: %+v", ci.Index)
+		}
+	}
+	if err := plan.Validate(m); err != nil {
+		t.Errorf("inferred plan should validate: %v", err)
+	}
+}
+
 func TestInfer_NoIDIndex(t *testing.T) {
 	s := &schema.Schema{
 		Tables: []schema.Table{
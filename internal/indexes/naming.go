@@ -0,0 +1,119 @@
+package indexes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// NameConvention is a template for index names, such as
+// "{collection}_{keys}". The empty convention keeps the names inference
+// gives, prefixed by where each index came from (pk_, ref_, idx_ and so on).
+//
+// Placeholders:
+//
+//	{collection}  the collection, e.g. orders
+//	{fields}      the key fields, e.g. customer_id_created_at
+//	{direction}   the key directions, e.g. asc_desc; text, 2dsphere or
+//	              search for those key types
+//	{keys}        each field followed by its direction, e.g.
+//	              customer_id_asc_created_at_desc
+//
+// Dots in embedded fields become underscores and a wildcard ($**) becomes
+// "wildcard".
+type NameConvention string
+
+var namePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// Validate checks that the template uses only known placeholders and
+// includes the key fields, which tell the indexes of a collection apart.
+func (c NameConvention) Validate() error {
+	if c == "" {
+		return nil
+	}
+	hasFields := false
+	for _, ph := range namePlaceholder.FindAllString(string(c), -1) {
+		switch ph {
+		case "{collection}", "{direction}":
+		case "{fields}", "{keys}":
+			hasFields = true
+		default:
+			return fmt.Errorf("index name template %q: unknown placeholder %s; use {collection}, {fields}, {direction} or {keys}", c, ph)
+		}
+	}
+	if !hasFields {
+		return fmt.Errorf("index name template %q needs {fields} or {keys}", c)
+	}
+	return nil
+}
+
+// Name returns the name the convention gives an index on the keys.
+func (c NameConvention) Name(collection string, keys []target.IndexKey) string {
+	fields := make([]string, len(keys))
+	dirs := make([]string, len(keys))
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = nameField(k.Field)
+		dirs[i] = keyDirection(k)
+		pairs[i] = fields[i] + "_" + dirs[i]
+	}
+	r := strings.NewReplacer(
+		"{collection}", collection,
+		"{fields}", strings.Join(fields, "_"),
+		"{direction}", strings.Join(dirs, "_"),
+		"{keys}", strings.Join(pairs, "_"),
+	)
+	return r.Replace(string(c))
+}
+
+// Apply renames every index in the plan by the convention. The empty
+// convention leaves the plan as it is.
+func (c NameConvention) Apply(p *IndexPlan) error {
+	if c == "" {
+		return nil
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	for i := range p.Indexes {
+		ci := &p.Indexes[i]
+		ci.Index.Name = c.Name(ci.Collection, ci.Index.Keys)
+	}
+	return nil
+}
+
+// Check reports the first index in the plan whose name does not follow the
+// convention, with the name it should have. Edited plans are built as
+// saved, so their names are checked before any index is built.
+func (c NameConvention) Check(p *IndexPlan) error {
+	if c == "" || p == nil {
+		return nil
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	for i, ci := range p.Indexes {
+		if want := c.Name(ci.Collection, ci.Index.Keys); ci.Index.Name != want {
+			return fmt.Errorf("index %d (%s) on %s does not follow the naming convention %q; name it %s",
+				i+1, ci.Index.Name, ci.Collection, c, want)
+		}
+	}
+	return nil
+}
+
+func nameField(field string) string {
+	field = strings.ReplaceAll(field, "$**", "wildcard")
+	return strings.ReplaceAll(field, ".", "_")
+}
+
+func keyDirection(k target.IndexKey) string {
+	switch {
+	case k.Type != "":
+		return k.Type
+	case k.Order == -1:
+		return "desc"
+	}
+	return "asc"
+}
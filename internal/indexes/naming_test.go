@@ -0,0 +1,82 @@
+package indexes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func TestNameConvention_Name(t *testing.T) {
+	keys := []target.IndexKey{{Field: "customer.id", Order: 1}, {Field: "created_at", Order: -1}}
+	tests := []struct {
+		template NameConvention
+		keys     []target.IndexKey
+		want     string
+	}{
+		{"{collection}_{keys}", keys, "orders_customer_id_asc_created_at_desc"},
+		{"ix_{collection}__{fields}__{direction}", keys, "ix_orders__customer_id_created_at__asc_desc"},
+		{"{fields}_{direction}", []target.IndexKey{{Field: "notes", Type: target.IndexText}}, "notes_text"},
+		{"{collection}_{fields}", []target.IndexKey{{Field: "attrs.$**", Order: 1}}, "orders_attrs_wildcard"},
+	}
+	for _, tt := range tests {
+		if err := tt.template.Validate(); err != nil {
+			t.Errorf("%s: %v", tt.template, err)
+		}
+		if got := tt.template.Name("orders", tt.keys); got != tt.want {
+			t.Errorf("%s: name = %s, want %s", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestNameConvention_Validate(t *testing.T) {
+	tests := []struct {
+		template NameConvention
+		wantErr  string
+	}{
+		{"", ""},
+		{"{collection}_{fields}", ""},
+		{"{collection}_{direction}", "needs {fields} or {keys}"},
+		{"{collection}_{columns}", "unknown placeholder {columns}"},
+	}
+	for _, tt := range tests {
+		err := tt.template.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%q: %v", tt.template, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%q: err = %v, want %q", tt.template, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNameConvention_ApplyCheck(t *testing.T) {
+	conv := NameConvention("{collection}_{keys}")
+	p := testEditPlan()
+	if err := conv.Check(p); err == nil || !strings.Contains(err.Error(), "name it users_email_asc") {
+		t.Errorf("unconventional names: err = %v", err)
+	}
+	if err := NameConvention("").Check(p); err != nil {
+		t.Errorf("no convention: %v", err)
+	}
+
+	if err := conv.Apply(p); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := planNames(p), "users_email_asc,users_name_asc_age_desc,orders_created_at_asc"; got != want {
+		t.Errorf("names = %s, want %s", got, want)
+	}
+	if err := conv.Check(p); err != nil {
+		t.Errorf("renamed plan: %v", err)
+	}
+	if err := p.Validate(nil); err != nil {
+		t.Errorf("renamed plan invalid: %v", err)
+	}
+
+	if err := NameConvention("{collection}").Apply(p); err == nil {
+		t.Error("expected a template without fields to be rejected")
+	}
+}
@@ -0,0 +1,476 @@
+package indexes
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// DefaultMaxQuerySuggestions caps the indexes suggested from a query log.
+const DefaultMaxQuerySuggestions = 20
+
+// QueryStat is one statement from the source's query statistics and the
+// number of times it ran.
+type QueryStat struct {
+	Query string
+	Calls int64
+}
+
+// QuerySuggestion is an index suggested by the filters and sorts of the
+// source workload. Calls totals the executions of the statements it serves.
+type QuerySuggestion struct {
+	Collection  string                 `yaml:"collection" json:"collection"`
+	Index       target.IndexDefinition `yaml:"index" json:"index"`
+	Calls       int64                  `yaml:"calls" json:"calls"`
+	Statements  int                    `yaml:"statements" json:"statements"`
+	SourceTable string                 `yaml:"source_table" json:"source_table"`
+}
+
+// QueryLogSQL returns the query a DBA runs to export the statement
+// statistics LoadQueryStats reads: pg_stat_statements for PostgreSQL, and
+// the last week of AWR top SQL for Oracle (requires the Diagnostics Pack).
+func QueryLogSQL(dbType string) string {
+	if dbType == "oracle" {
+		return `-- Run in SQLcl with: SET SQLFORMAT csv
+-- SPOOL query-log.csv
+SELECT SUM(s.executions_delta) AS executions,
+       DBMS_LOB.SUBSTR(t.sql_text, 4000, 1) AS sql_text
+FROM dba_hist_sqlstat s
+JOIN dba_hist_sqltext t ON t.sql_id = s.sql_id AND t.dbid = s.dbid
+WHERE s.snap_id > (SELECT MAX(snap_id) - 168 FROM dba_hist_snapshot)
+  AND t.command_type = 3
+GROUP BY s.sql_id, DBMS_LOB.SUBSTR(t.sql_text, 4000, 1)
+ORDER BY executions DESC
+FETCH FIRST 1000 ROWS ONLY;
+-- SPOOL OFF
+`
+	}
+	return `-- Run in psql; requires the pg_stat_statements extension
+\copy (SELECT calls, query FROM pg_stat_statements WHERE query ILIKE 'select%' ORDER BY calls DESC LIMIT 1000) TO 'query-log.csv' WITH CSV HEADER
+`
+}
+
+// LoadQueryStats reads a CSV export of statement statistics. The header must
+// name a statement column (query or sql_text) and a count column (calls,
+// executions or executions_delta), in any order and case.
+func LoadQueryStats(path string) ([]QueryStat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading query log: %w", err)
+	}
+	defer f.Close()
+	return parseQueryStats(f)
+}
+
+func parseQueryStats(r io.Reader) ([]QueryStat, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading query log header: %w", err)
+	}
+	queryCol, callsCol := -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "query", "sql_text":
+			queryCol = i
+		case "calls", "executions", "executions_delta":
+			callsCol = i
+		}
+	}
+	if queryCol < 0 || callsCol < 0 {
+		return nil, fmt.Errorf("query log header must include query (or sql_text) and calls (or executions)")
+	}
+
+	var stats []QueryStat
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("query log line %d: %w", line, err)
+		}
+		if len(rec) <= queryCol || len(rec) <= callsCol {
+			continue
+		}
+		calls, err := strconv.ParseInt(strings.TrimSpace(rec[callsCol]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("query log line %d: invalid call count %q", line, rec[callsCol])
+		}
+		stats = append(stats, QueryStat{Query: rec[queryCol], Calls: calls})
+	}
+	return stats, nil
+}
+
+// SuggestFromQueries finds the columns each statement filters and sorts on,
+// translates them through the mapping to document fields, and suggests a
+// compound index per collection and access pattern, ordered equality, sort,
+// range. Suggestions are ranked by total calls; at most limit are returned
+// (DefaultMaxQuerySuggestions when limit is 0), and patterns run fewer than
+// minCalls times are dropped.
+func SuggestFromQueries(s *schema.Schema, m *mapping.Mapping, stats []QueryStat, minCalls int64, limit int) []QuerySuggestion {
+	if limit <= 0 {
+		limit = DefaultMaxQuerySuggestions
+	}
+	tableMap := buildTableMap(s)
+	byKey := make(map[string]*QuerySuggestion)
+	var order []string
+
+	for _, st := range stats {
+		for table, use := range parseStatement(st.Query, tableMap) {
+			for _, loc := range locateTable(m, table) {
+				keys := use.indexKeys(loc)
+				if len(keys) == 0 {
+					continue
+				}
+				if loc.root && len(keys) == 1 && isSingleID([]string{keys[0].Field}) {
+					continue
+				}
+				k := loc.collection + "|" + indexKeyString(keys)
+				sug, ok := byKey[k]
+				if !ok {
+					fields := make([]string, len(keys))
+					for i, key := range keys {
+						fields[i] = key.Field
+					}
+					sug = &QuerySuggestion{
+						Collection:  loc.collection,
+						SourceTable: table,
+						Index: target.IndexDefinition{
+							Keys: keys,
+							Name: fmt.Sprintf("qry_%s_%s", loc.collection, strings.ReplaceAll(strings.Join(fields, "_"), ".", "_")),
+						},
+					}
+					byKey[k] = sug
+					order = append(order, k)
+				}
+				sug.Calls += st.Calls
+				sug.Statements++
+			}
+		}
+	}
+
+	var out []QuerySuggestion
+	for _, k := range order {
+		if byKey[k].Calls >= minCalls {
+			out = append(out, *byKey[k])
+		}
+	}
+	slices.SortFunc(out, func(a, b QuerySuggestion) int {
+		switch {
+		case a.Calls > b.Calls:
+			return -1
+		case a.Calls < b.Calls:
+			return 1
+		}
+		return strings.Compare(a.Collection+"."+a.Index.Name, b.Collection+"."+b.Index.Name)
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// AddQuerySuggestions appends suggested indexes the plan does not already
+// cover, with an explanation of the workload behind each.
+func (p *IndexPlan) AddQuerySuggestions(sugs []QuerySuggestion) {
+	for _, sg := range sugs {
+		before := len(p.Indexes)
+		p.addIfNew(sg.Collection, sg.Index)
+		if len(p.Indexes) == before {
+			continue
+		}
+		fields := make([]string, len(sg.Index.Keys))
+		for i, k := range sg.Index.Keys {
+			fields[i] = k.Field
+			if k.Order == -1 {
+				fields[i] += " desc"
+			}
+		}
+		p.Explanations = append(p.Explanations,
+			fmt.Sprintf("Index on %s(%s) from %d source queries on %s (%d calls)",
+				sg.Collection, strings.Join(fields, ", "), sg.Statements, sg.SourceTable, sg.Calls))
+	}
+}
+
+// tableUse is how one statement uses one table's columns.
+type tableUse struct {
+	equality []string
+	sort     []target.IndexKey // Field holds the column name
+	rng      []string
+}
+
+// indexKeys builds the equality-sort-range key for a table's location in
+// the mapping. Columns the mapping excludes are left out.
+func (u *tableUse) indexKeys(loc tableLocation) []target.IndexKey {
+	var keys []target.IndexKey
+	seen := make(map[string]bool)
+	add := func(col string, order int) {
+		field, ok := loc.field(col)
+		if !ok || seen[field] {
+			return
+		}
+		seen[field] = true
+		keys = append(keys, target.IndexKey{Field: field, Order: order})
+	}
+	for _, c := range u.equality {
+		add(c, 1)
+	}
+	for _, k := range u.sort {
+		add(k.Field, k.Order)
+	}
+	for _, c := range u.rng {
+		add(c, 1)
+	}
+	return keys
+}
+
+// tableLocation is a place a source table's rows land in the documents.
+type tableLocation struct {
+	collection      string
+	prefix          string // dotted path of an embedded table, empty at the root
+	root            bool
+	transformations []mapping.Transformation
+	fields          []mapping.FieldMapping
+}
+
+// field returns the document path a source column is written to, after
+// renames and field mappings, and false if the column is excluded.
+func (l tableLocation) field(col string) (string, bool) {
+	for _, t := range l.transformations {
+		if t.SourceField == col {
+			switch t.Operation {
+			case "exclude":
+				return "", false
+			case "rename":
+				col = t.TargetField
+			}
+		}
+	}
+	path, ok := mapping.FieldTarget(l.fields, col)
+	if !ok {
+		return "", false
+	}
+	if l.prefix != "" {
+		path = l.prefix + "." + path
+	}
+	return path, true
+}
+
+func locateTable(m *mapping.Mapping, table string) []tableLocation {
+	var locs []tableLocation
+	var walk func(coll, prefix string, embs []mapping.Embedded)
+	walk = func(coll, prefix string, embs []mapping.Embedded) {
+		for _, e := range embs {
+			p := e.FieldName
+			if prefix != "" {
+				p = prefix + "." + e.FieldName
+			}
+			if strings.EqualFold(e.SourceTable, table) {
+				locs = append(locs, tableLocation{collection: coll, prefix: p, transformations: e.Transformations, fields: e.Fields})
+			}
+			walk(coll, p, e.Embedded)
+		}
+	}
+	for _, c := range m.Collections {
+		if strings.EqualFold(c.SourceTable, table) {
+			locs = append(locs, tableLocation{collection: c.Name, root: true, transformations: c.Transformations, fields: c.Fields})
+		}
+		walk(c.Name, "", c.Embedded)
+	}
+	return locs
+}
+
+var (
+	sqlStringRe  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlCommentRe = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+	fromRe       = regexp.MustCompile(`(?i)\b(?:from|join)\s+`)
+	fromEndRe    = regexp.MustCompile(`(?i)\b(?:where|group|order|limit|fetch|offset|having|union|on|using|natural|left|right|inner|outer|full|cross|join|window|for)\b|[()]`)
+	whereRe      = regexp.MustCompile(`(?i)\bwhere\b(.+?)(?:\b(?:group\s+by|order\s+by|limit|fetch|offset|having|union|for\s+update)\b|$)`)
+	orderRe      = regexp.MustCompile(`(?i)\border\s+by\b(.+?)(?:\b(?:limit|fetch|offset|for\s+update|union)\b|\)|$)`)
+	predicateRe  = regexp.MustCompile(`(?i)(?:"?(\w+)"?\.)?"?(\w+)"?\s*(<>|!=|<=|>=|=|<|>|\bnot\s+in\b|\bin\b|\bbetween\b|\blike\b|\bis\b)\s*((?:"?\w+"?\.)?"?\w+"?)?`)
+	sortTermRe   = regexp.MustCompile(`(?i)^(?:"?(\w+)"?\.)?"?(\w+)"?(?:\s+(asc|desc))?(?:\s+nulls\s+(?:first|last))?$`)
+)
+
+// sqlKeywords are words the table and predicate patterns must not mistake
+// for names.
+var sqlKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "null": true, "true": true, "false": true,
+	"select": true, "where": true, "as": true, "lateral": true, "only": true,
+	"current_date": true, "current_timestamp": true, "sysdate": true, "now": true,
+}
+
+// parseStatement finds, per table, the columns a SELECT filters on with
+// equality, filters on with a range, and sorts on. It is a heuristic: it
+// understands the flat statements that dominate query statistics, and
+// ignores join conditions and anything it cannot attribute to one table.
+func parseStatement(sql string, tableMap map[string]*schema.Table) map[string]*tableUse {
+	sql = sqlCommentRe.ReplaceAllString(sql, " ")
+	sql = sqlStringRe.ReplaceAllString(sql, "?")
+	sql = strings.Join(strings.Fields(sql), " ")
+	if !strings.HasPrefix(strings.ToLower(strings.TrimLeft(sql, "( ")), "select") &&
+		!strings.HasPrefix(strings.ToLower(strings.TrimLeft(sql, "( ")), "with") {
+		return nil
+	}
+
+	// Tables in the statement, by alias and by name
+	aliases := make(map[string]string) // lower-case alias or name -> schema table name
+	var tables []string
+	for _, loc := range fromRe.FindAllStringIndex(sql, -1) {
+		list := sql[loc[1]:]
+		if end := fromEndRe.FindStringIndex(list); end != nil {
+			list = list[:end[0]]
+		}
+		for _, item := range strings.Split(list, ",") {
+			words := strings.Fields(item)
+			if len(words) == 0 {
+				continue
+			}
+			name := words[0]
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				name = name[i+1:]
+			}
+			name = strings.Trim(name, `"`)
+			t := lookupTable(tableMap, name)
+			if t == "" {
+				continue
+			}
+			if !slices.Contains(tables, t) {
+				tables = append(tables, t)
+			}
+			aliases[strings.ToLower(name)] = t
+			alias := words[len(words)-1]
+			if len(words) > 1 && !sqlKeywords[strings.ToLower(alias)] {
+				aliases[strings.ToLower(strings.Trim(alias, `"`))] = t
+			}
+		}
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	// resolve attributes a column, qualified or not, to one of the tables
+	resolve := func(qual, col string) (string, string) {
+		if sqlKeywords[strings.ToLower(col)] {
+			return "", ""
+		}
+		if qual != "" {
+			t, ok := aliases[strings.ToLower(qual)]
+			if !ok {
+				return "", ""
+			}
+			if c := lookupColumn(tableMap[t], col); c != "" {
+				return t, c
+			}
+			return "", ""
+		}
+		var found, column string
+		for _, t := range tables {
+			if c := lookupColumn(tableMap[t], col); c != "" {
+				if found != "" {
+					return "", "" // ambiguous
+				}
+				found, column = t, c
+			}
+		}
+		return found, column
+	}
+
+	uses := make(map[string]*tableUse)
+	useOf := func(t string) *tableUse {
+		if uses[t] == nil {
+			uses[t] = &tableUse{}
+		}
+		return uses[t]
+	}
+
+	if wm := whereRe.FindStringSubmatch(sql); wm != nil {
+		for _, pm := range predicateRe.FindAllStringSubmatch(wm[1], -1) {
+			t, col := resolve(pm[1], pm[2])
+			if t == "" {
+				continue
+			}
+			// a = b.c compares two columns: a join condition, not a filter
+			if rhs := pm[4]; rhs != "" {
+				q, c, _ := strings.Cut(strings.ReplaceAll(rhs, `"`, ""), ".")
+				if c == "" {
+					q, c = "", q
+				}
+				if rt, _ := resolve(q, c); rt != "" {
+					continue
+				}
+			}
+			u := useOf(t)
+			switch op := strings.ToLower(pm[3]); op {
+			case "=", "in", "is":
+				if !slices.Contains(u.equality, col) {
+					u.equality = append(u.equality, col)
+				}
+			case "<", ">", "<=", ">=", "between", "like":
+				if !slices.Contains(u.rng, col) {
+					u.rng = append(u.rng, col)
+				}
+			}
+		}
+	}
+
+	if om := orderRe.FindStringSubmatch(sql); om != nil {
+		for _, term := range strings.Split(om[1], ",") {
+			tm := sortTermRe.FindStringSubmatch(strings.TrimSpace(term))
+			if tm == nil {
+				continue
+			}
+			t, col := resolve(tm[1], tm[2])
+			if t == "" {
+				continue
+			}
+			order := 1
+			if strings.EqualFold(tm[3], "desc") {
+				order = -1
+			}
+			u := useOf(t)
+			u.sort = append(u.sort, target.IndexKey{Field: col, Order: order})
+		}
+	}
+
+	// A column filtered by equality needs no range or sort position
+	for _, u := range uses {
+		u.rng = slices.DeleteFunc(u.rng, func(c string) bool { return slices.Contains(u.equality, c) })
+		u.sort = slices.DeleteFunc(u.sort, func(k target.IndexKey) bool { return slices.Contains(u.equality, k.Field) })
+	}
+	return uses
+}
+
+func lookupTable(tableMap map[string]*schema.Table, name string) string {
+	if _, ok := tableMap[name]; ok {
+		return name
+	}
+	for n := range tableMap {
+		if strings.EqualFold(n, name) {
+			return n
+		}
+	}
+	return ""
+}
+
+func lookupColumn(t *schema.Table, name string) string {
+	if t == nil {
+		return ""
+	}
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package indexes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func queryLogSchema() *schema.Schema {
+	cols := func(names ...string) []schema.Column {
+		out := make([]schema.Column, len(names))
+		for i, n := range names {
+			out[i] = schema.Column{Name: n}
+		}
+		return out
+	}
+	return &schema.Schema{Tables: []schema.Table{
+		{Name: "customers", Columns: cols("id", "email", "region", "created_at", "ssn")},
+		{Name: "orders", Columns: cols("id", "customer_id", "status", "placed_at", "total")},
+	}}
+}
+
+func TestParseStatement(t *testing.T) {
+	tableMap := buildTableMap(queryLogSchema())
+
+	tests := []struct {
+		name string
+		sql  string
+		want map[string]*tableUse
+	}{
+		{
+			name: "equality, sort and range",
+			sql:  "SELECT * FROM orders WHERE status = $1 AND placed_at >= $2 ORDER BY placed_at DESC LIMIT $3",
+			want: map[string]*tableUse{"orders": {
+				equality: []string{"status"},
+				sort:     []target.IndexKey{{Field: "placed_at", Order: -1}},
+				rng:      []string{"placed_at"},
+			}},
+		},
+		{
+			name: "aliases and join condition ignored",
+			sql:  "select o.total from orders o join customers c on c.id = o.customer_id where c.region = 'EU' and o.status in ('open', 'held')",
+			want: map[string]*tableUse{
+				"customers": {equality: []string{"region"}},
+				"orders":    {equality: []string{"status"}},
+			},
+		},
+		{
+			name: "oracle upper case with bind variables",
+			sql:  "SELECT * FROM APP.CUSTOMERS C WHERE C.EMAIL = :1",
+			want: map[string]*tableUse{"customers": {equality: []string{"email"}}},
+		},
+		{
+			name: "function on column skipped",
+			sql:  "SELECT * FROM customers WHERE lower(email) = $1",
+			want: map[string]*tableUse{},
+		},
+		{
+			name: "not a select",
+			sql:  "UPDATE orders SET status = $1 WHERE id = $2",
+			want: nil,
+		},
+		{
+			name: "unknown table",
+			sql:  "SELECT * FROM audit_log WHERE id = $1",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseStatement(tt.sql, tableMap)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				for k, v := range got {
+					t.Logf("got %s: %+v", k, *v)
+				}
+				t.Errorf("parseStatement mismatch for %q", tt.sql)
+			}
+		})
+	}
+}
+
+func TestParseQueryStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []QueryStat
+		wantErr bool
+	}{
+		{
+			name: "pg_stat_statements",
+			csv:  "calls,query\n120,\"SELECT * FROM orders WHERE status = $1\"\n",
+			want: []QueryStat{{Query: "SELECT * FROM orders WHERE status = $1", Calls: 120}},
+		},
+		{
+			name: "oracle AWR",
+			csv:  "EXECUTIONS,SQL_TEXT\n7,\"SELECT * FROM ORDERS WHERE STATUS = :1\"\n",
+			want: []QueryStat{{Query: "SELECT * FROM ORDERS WHERE STATUS = :1", Calls: 7}},
+		},
+		{name: "missing columns", csv: "sql_id,elapsed\nabc,1\n", wantErr: true},
+		{name: "bad count", csv: "calls,query\nmany,SELECT 1\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseQueryStats(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestFromQueries(t *testing.T) {
+	s := queryLogSchema()
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name:            "customers",
+		SourceTable:     "customers",
+		Transformations: []mapping.Transformation{{SourceField: "ssn", Operation: "exclude"}},
+		Fields:          []mapping.FieldMapping{{Column: "region", Target: "address.region"}},
+		Embedded: []mapping.Embedded{{
+			SourceTable: "orders", FieldName: "orders", JoinColumn: "customer_id", ParentColumn: "id",
+			Transformations: []mapping.Transformation{{SourceField: "placed_at", Operation: "rename", TargetField: "placedAt"}},
+		}},
+	}}}
+	stats := []QueryStat{
+		{Query: "SELECT * FROM customers WHERE region = $1 ORDER BY created_at DESC", Calls: 50},
+		{Query: "SELECT * FROM orders WHERE status = $1 AND placed_at > $2", Calls: 900},
+		{Query: "SELECT * FROM customers WHERE region = $1 ORDER BY created_at DESC LIMIT 10", Calls: 25},
+		{Query: "SELECT * FROM customers WHERE ssn = $1", Calls: 5000},
+		{Query: "SELECT * FROM customers WHERE id = $1", Calls: 9000},
+		{Query: "SELECT * FROM customers WHERE email = $1", Calls: 3},
+	}
+
+	got := SuggestFromQueries(s, m, stats, 10, 0)
+	want := []QuerySuggestion{
+		{
+			Collection:  "customers",
+			SourceTable: "orders",
+			Index: target.IndexDefinition{
+				Name: "qry_customers_orders_status_orders_placedAt",
+				Keys: []target.IndexKey{{Field: "orders.status", Order: 1}, {Field: "orders.placedAt", Order: 1}},
+			},
+			Calls:      900,
+			Statements: 1,
+		},
+		{
+			Collection:  "customers",
+			SourceTable: "customers",
+			Index: target.IndexDefinition{
+				Name: "qry_customers_address_region_created_at",
+				Keys: []target.IndexKey{{Field: "address.region", Order: 1}, {Field: "created_at", Order: -1}},
+			},
+			Calls:      75,
+			Statements: 2,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SuggestFromQueries =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := SuggestFromQueries(s, m, stats, 0, 1); len(got) != 1 || got[0].Calls != 900 {
+		t.Errorf("limit 1 = %+v, want the 900-call suggestion", got)
+	}
+}
+
+func TestAddQuerySuggestions(t *testing.T) {
+	plan := &IndexPlan{}
+	plan.addIfNew("orders", target.IndexDefinition{Name: "idx_orders_status", Keys: []target.IndexKey{{Field: "status", Order: 1}}})
+
+	plan.AddQuerySuggestions([]QuerySuggestion{
+		{Collection: "orders", SourceTable: "orders", Calls: 10, Statements: 1,
+			Index: target.IndexDefinition{Name: "qry_orders_status", Keys: []target.IndexKey{{Field: "status", Order: 1}}}},
+		{Collection: "orders", SourceTable: "orders", Calls: 5, Statements: 2,
+			Index: target.IndexDefinition{Name: "qry_orders_status_total", Keys: []target.IndexKey{{Field: "status", Order: 1}, {Field: "total", Order: -1}}}},
+	})
+
+	if len(plan.Indexes) != 2 || plan.Indexes[1].Index.Name != "qry_orders_status_total" {
+		t.Fatalf("Indexes = %+v, want the existing index plus one suggestion", plan.Indexes)
+	}
+	if len(plan.Explanations) != 1 || plan.Explanations[0] != "Index on orders(status, total desc) from 2 source queries on orders (5 calls)" {
+		t.Errorf("Explanations = %q", plan.Explanations)
+	}
+}
@@ -0,0 +1,186 @@
+package indexes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// DefaultSearchAnalyzer is used for Atlas Search fields whose source
+// full-text index names no language.
+const DefaultSearchAnalyzer = "lucene.standard"
+
+// minSearchLength is the shortest varchar worth an Atlas Search field;
+// shorter columns hold codes and names rather than prose.
+const minSearchLength = 255
+
+// to_tsvector('english'::regconfig, ...), to_tsvector('pg_catalog.french', ...)
+var tsConfigExpr = regexp.MustCompile(`(?i)to_tsvector\(\s*'(?:pg_catalog\.)?(\w+)'`)
+
+// searchAnalyzers maps PostgreSQL text search configurations to the Lucene
+// analyzers for the same language.
+var searchAnalyzers = map[string]string{
+	"simple":     "lucene.simple",
+	"english":    "lucene.english",
+	"french":     "lucene.french",
+	"german":     "lucene.german",
+	"spanish":    "lucene.spanish",
+	"italian":    "lucene.italian",
+	"portuguese": "lucene.portuguese",
+	"dutch":      "lucene.dutch",
+	"danish":     "lucene.danish",
+	"finnish":    "lucene.finnish",
+	"norwegian":  "lucene.norwegian",
+	"swedish":    "lucene.swedish",
+	"russian":    "lucene.russian",
+	"hungarian":  "lucene.hungarian",
+	"romanian":   "lucene.romanian",
+	"turkish":    "lucene.turkish",
+}
+
+// AddSearchIndexes adds an Atlas Search index to every collection whose
+// source tables have full-text indexes on long text columns, covering those
+// columns' fields, and returns how many were added. The text indexes
+// inferred from the same source indexes are kept: they serve $text queries
+// on clusters without Atlas Search.
+func (p *IndexPlan) AddSearchIndexes(s *schema.Schema, m *mapping.Mapping) int {
+	tableMap := buildTableMap(s)
+	n := 0
+	for i := range m.Collections {
+		col := &m.Collections[i]
+		t := tableMap[col.SourceTable]
+		if t == nil {
+			continue
+		}
+		var fields, sources, analyzers []string
+		add := func(t *schema.Table, path func(string) (string, bool), prefix string) {
+			for _, src := range t.Indexes {
+				cols := searchColumns(t, src)
+				if len(cols) == 0 {
+					continue
+				}
+				sources = append(sources, src.Name)
+				analyzers = append(analyzers, searchAnalyzer(src))
+				for _, c := range cols {
+					if f, ok := path(c); ok {
+						if prefix != "" {
+							f = prefix + "." + f
+						}
+						fields = append(fields, f)
+					}
+				}
+			}
+		}
+		add(t, col.RootField, "")
+		walkEmbedded(col.Embedded, "", func(e *mapping.Embedded, prefix string) {
+			if et := tableMap[e.SourceTable]; et != nil {
+				add(et, e.SubField, prefix)
+			}
+		})
+
+		idx, ok := searchIndex(col.Name, fields, analyzers)
+		if !ok {
+			continue
+		}
+		before := len(p.Indexes)
+		p.addIfNew(col.Name, idx)
+		if len(p.Indexes) == before {
+			continue
+		}
+		n++
+		p.Explanations = append(p.Explanations,
+			fmt.Sprintf("Atlas Search index on %s(%s) with %s from full-text index %s",
+				col.Name, indexFields(idx.Keys), idx.Analyzer, strings.Join(sources, ", ")))
+	}
+	return n
+}
+
+// walkEmbedded calls fn for every embedded table, depth first, with the
+// path of its subdocument or array.
+func walkEmbedded(embs []mapping.Embedded, prefix string, fn func(e *mapping.Embedded, prefix string)) {
+	for i := range embs {
+		e := &embs[i]
+		path := e.FieldName
+		if prefix != "" {
+			path = prefix + "." + e.FieldName
+		}
+		fn(e, path)
+		walkEmbedded(e.Embedded, path, fn)
+	}
+}
+
+// searchIndex builds the Atlas Search index over fields. Source indexes in
+// different languages fall back to the standard analyzer.
+func searchIndex(collection string, fields, analyzers []string) (target.IndexDefinition, bool) {
+	var keys []target.IndexKey
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		if !seen[f] {
+			seen[f] = true
+			keys = append(keys, target.IndexKey{Field: f, Type: target.IndexSearch})
+		}
+	}
+	if len(keys) == 0 {
+		return target.IndexDefinition{}, false
+	}
+	analyzer := analyzers[0]
+	for _, a := range analyzers[1:] {
+		if a != analyzer {
+			analyzer = DefaultSearchAnalyzer
+		}
+	}
+	return target.IndexDefinition{
+		Keys:     keys,
+		Name:     "search_" + collection,
+		Analyzer: analyzer,
+	}, true
+}
+
+// searchColumns returns the long text columns a full-text source index
+// searches.
+func searchColumns(t *schema.Table, src schema.Index) []string {
+	if !isTextSearch(src) {
+		return nil
+	}
+	cols := append([]string(nil), src.Columns...)
+	for _, e := range src.Expressions {
+		cols = append(cols, referencedColumns(e, t)...)
+	}
+	var out []string
+	for _, name := range cols {
+		if c := findColumn(t, name); c != nil && isLongText(*c) {
+			out = append(out, c.Name)
+		}
+	}
+	return out
+}
+
+// isLongText reports whether a column holds prose: a text or character
+// large object, or a varchar of at least minSearchLength characters or
+// without a limit.
+func isLongText(c schema.Column) bool {
+	switch strings.ToLower(c.DataType) {
+	case "text", "clob", "nclob", "long", "ntext", "mediumtext", "longtext":
+		return true
+	case "character varying", "varchar", "varchar2", "nvarchar", "nvarchar2":
+		return c.MaxLength == nil || *c.MaxLength >= minSearchLength
+	}
+	return false
+}
+
+// searchAnalyzer returns the Lucene analyzer for the language of a source
+// full-text index.
+func searchAnalyzer(src schema.Index) string {
+	for _, e := range src.Expressions {
+		if m := tsConfigExpr.FindStringSubmatch(e); m != nil {
+			if a, ok := searchAnalyzers[strings.ToLower(m[1])]; ok {
+				return a
+			}
+		}
+	}
+	return DefaultSearchAnalyzer
+}
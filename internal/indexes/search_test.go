@@ -0,0 +1,98 @@
+package indexes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func TestAddSearchIndexes(t *testing.T) {
+	short := 40
+	long := 4000
+	s := &schema.Schema{Tables: []schema.Table{
+		{
+			Name: "articles",
+			Columns: []schema.Column{
+				{Name: "id", DataType: "bigint"},
+				{Name: "title", DataType: "character varying", MaxLength: &short},
+				{Name: "body", DataType: "text"},
+				{Name: "summary", DataType: "character varying", MaxLength: &long},
+			},
+			Indexes: []schema.Index{
+				{Name: "articles_fts", Type: "gin", Expressions: []string{"to_tsvector('english'::regconfig, (((title)::text || ' '::text) || body))"}},
+				{Name: "articles_summary_fts", Type: "gin", Expressions: []string{"to_tsvector('english'::regconfig, (summary)::text)"}},
+			},
+		},
+		{
+			Name: "comments",
+			Columns: []schema.Column{
+				{Name: "article_id", DataType: "bigint"},
+				{Name: "text", DataType: "clob"},
+			},
+			Indexes: []schema.Index{{Name: "comments_ctx", Type: "CTXSYS.CONTEXT", Columns: []string{"text"}}},
+		},
+		{
+			Name:    "tags",
+			Columns: []schema.Column{{Name: "label", DataType: "text"}},
+			Indexes: []schema.Index{{Name: "tags_label", Columns: []string{"label"}}},
+		},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{
+			Name:            "articles",
+			SourceTable:     "articles",
+			Transformations: []mapping.Transformation{{SourceField: "summary", Operation: "rename", TargetField: "abstract"}},
+			Embedded:        []mapping.Embedded{{SourceTable: "comments", FieldName: "comments", Relationship: "array", JoinColumn: "article_id", ParentColumn: "id"}},
+		},
+		{Name: "tags", SourceTable: "tags"},
+	}}
+
+	plan := Infer(s, m)
+	before := len(plan.Indexes)
+	if n := plan.AddSearchIndexes(s, m); n != 1 {
+		t.Fatalf("added %d search indexes, want 1", n)
+	}
+	if len(plan.Indexes) != before+1 {
+		t.Fatalf("plan has %d indexes, want the text indexes kept and one added", len(plan.Indexes))
+	}
+	ci := plan.Indexes[len(plan.Indexes)-1]
+	want := target.IndexDefinition{
+		Name: "search_articles",
+		Keys: []target.IndexKey{
+			{Field: "body", Type: target.IndexSearch},
+			{Field: "abstract", Type: target.IndexSearch},
+			{Field: "comments.text", Type: target.IndexSearch},
+		},
+		// The Oracle CONTEXT index names no language
+		Analyzer: DefaultSearchAnalyzer,
+	}
+	if ci.Collection != "articles" || !reflect.DeepEqual(ci.Index, want) {
+		t.Errorf("search index = %s %+v, want %+v", ci.Collection, ci.Index, want)
+	}
+	if err := plan.Validate(m); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	if n := plan.AddSearchIndexes(s, m); n != 0 {
+		t.Errorf("added %d search indexes again", n)
+	}
+}
+
+func TestSearchAnalyzer(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"to_tsvector('english'::regconfig, body)", "lucene.english"},
+		{"to_tsvector('pg_catalog.french', body)", "lucene.french"},
+		{"to_tsvector('klingon', body)", DefaultSearchAnalyzer},
+		{"to_tsvector(body)", DefaultSearchAnalyzer},
+	}
+	for _, tt := range tests {
+		if got := searchAnalyzer(schema.Index{Expressions: []string{tt.expr}}); got != tt.want {
+			t.Errorf("searchAnalyzer(%s) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
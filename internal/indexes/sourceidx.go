@@ -0,0 +1,312 @@
+package indexes
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/collation"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// caseInsensitive is the collation given to indexes on lower() or upper()
+// of a column: English rules, ignoring case.
+var caseInsensitive = target.Collation{Locale: "en", Strength: 2}
+
+var (
+	// lower((email)::text), LOWER("EMAIL")
+	caseFoldExpr = regexp.MustCompile(`(?i)^(?:lower|upper)\(\(?"?(\w+)"?\)?(?:::[\w ]+)?\)$`)
+	// (attrs ->> 'status'::text), JSON_VALUE("ATTRS",'$.status')
+	jsonPathExpr  = regexp.MustCompile(`^\(?"?(\w+)"?\s*->>?\s*'([\w.]+)'(?:::text)?\)?$`)
+	jsonValueExpr = regexp.MustCompile(`(?i)^json_value\("?(\w+)"?\s*,\s*'\$\.([\w.]+)'.*\)$`)
+	// status = 'open'::text, amount > (100)::numeric, deleted_at IS NOT NULL
+	comparisonExpr = regexp.MustCompile(`(?i)^\(?"?(\w+)"?\)?(?:::[\w ]+?)?\s*(=|<>|!=|>=|<=|>|<)\s*(.+)$`)
+	notNullExpr    = regexp.MustCompile(`(?i)^"?(\w+)"?\s+IS\s+NOT\s+NULL$`)
+	castSuffix     = regexp.MustCompile(`::[\w ]+(\[\])?$`)
+	identifierExpr = regexp.MustCompile(`"?\b(\w+)\b"?`)
+)
+
+var comparisonOps = map[string]string{"=": "$eq", ">": "$gt", ">=": "$gte", "<": "$lt", "<=": "$lte"}
+
+// translateIndex maps a source index to the nearest MongoDB index. Fields
+// are the source column names under prefix, as for plain indexes. It returns
+// false when nothing equivalent can be built; notes explain any approximation.
+func translateIndex(collName, prefix string, t *schema.Table, src schema.Index) (target.IndexDefinition, []string, bool) {
+	field := func(c string) string {
+		if prefix != "" {
+			return prefix + "." + c
+		}
+		return c
+	}
+	fieldName := func(keys []target.IndexKey) string {
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = strings.TrimSuffix(strings.TrimSuffix(k.Field, "$**"), ".")
+		}
+		return strings.ReplaceAll(strings.Join(parts, "_"), ".", "_")
+	}
+	var notes []string
+
+	// Full-text indexes become a text index over the columns they search
+	if isTextSearch(src) {
+		cols := append([]string(nil), src.Columns...)
+		for _, e := range src.Expressions {
+			cols = append(cols, referencedColumns(e, t)...)
+		}
+		var keys []target.IndexKey
+		seen := make(map[string]bool)
+		for _, c := range cols {
+			if !seen[c] {
+				seen[c] = true
+				keys = append(keys, target.IndexKey{Field: field(c), Type: target.IndexText})
+			}
+		}
+		if len(keys) == 0 {
+			return target.IndexDefinition{}, []string{fmt.Sprintf("Full-text index %s searches no mapped column; skipped", src.Name)}, false
+		}
+		return target.IndexDefinition{
+			Keys: keys,
+			Name: fmt.Sprintf("txt_%s_%s", collName, fieldName(keys)),
+		}, []string{fmt.Sprintf("Full-text index %s becomes a MongoDB text index", src.Name)}, true
+	}
+
+	// A GIN index on a JSON document column covers every path inside it
+	if strings.EqualFold(src.Type, "gin") && len(src.Columns) == 1 && len(src.Expressions) == 0 {
+		if c := findColumn(t, src.Columns[0]); c != nil && strings.HasPrefix(strings.ToLower(c.DataType), "json") {
+			keys := []target.IndexKey{{Field: field(c.Name) + ".$**", Order: 1}}
+			return target.IndexDefinition{
+				Keys: keys,
+				Name: fmt.Sprintf("wc_%s_%s", collName, fieldName(keys)),
+			}, []string{fmt.Sprintf("GIN index %s on JSON column %s becomes a wildcard index", src.Name, c.Name)}, true
+		}
+	}
+
+	// A spatial index on a geometry column becomes a 2dsphere index
+	if len(src.Columns) == 1 && len(src.Expressions) == 0 {
+		if c := findColumn(t, src.Columns[0]); c != nil && typemap.IsGeo(c.DataType) {
+			if idx, ok := geoIndex(collName, field(c.Name), *c); ok {
+				return idx, []string{fmt.Sprintf("Spatial index %s becomes a 2dsphere index", src.Name)}, true
+			}
+			return target.IndexDefinition{}, []string{fmt.Sprintf("Spatial index %s on %s skipped: the column allows any geometry type", src.Name, c.Name)}, false
+		}
+	}
+
+	idx := target.IndexDefinition{Unique: src.Unique}
+	exact := true // whether the keys enforce the same uniqueness
+	for _, c := range src.Columns {
+		idx.Keys = append(idx.Keys, target.IndexKey{Field: field(c), Order: 1})
+	}
+	if coll := collation.ForColumns(t, src.Columns); coll != nil {
+		idx.Collation = coll
+		notes = append(notes, fmt.Sprintf("index %s is built with collation %s to compare like its source columns; queries must use the same collation", src.Name, collation.Describe(coll)))
+	}
+	for _, e := range src.Expressions {
+		e = strings.TrimSpace(e)
+		switch {
+		case caseFoldExpr.MatchString(e):
+			c := caseFoldExpr.FindStringSubmatch(e)[1]
+			idx.Keys = append(idx.Keys, target.IndexKey{Field: field(c), Order: 1})
+			col := caseInsensitive
+			idx.Collation = &col
+			notes = append(notes, fmt.Sprintf("%s on %s becomes a case-insensitive collation; queries must use the same collation", e, c))
+		case jsonPathExpr.MatchString(e) || jsonValueExpr.MatchString(e):
+			m := jsonPathExpr.FindStringSubmatch(e)
+			if m == nil {
+				m = jsonValueExpr.FindStringSubmatch(e)
+			}
+			idx.Keys = append(idx.Keys, target.IndexKey{Field: field(m[1] + "." + m[2]), Order: 1})
+		default:
+			exact = false
+			if cols := referencedColumns(e, t); len(cols) == 1 {
+				idx.Keys = append(idx.Keys, target.IndexKey{Field: field(cols[0]), Order: 1})
+				notes = append(notes, fmt.Sprintf("%s is indexed on %s itself; MongoDB cannot index computed values", e, cols[0]))
+			} else {
+				notes = append(notes, fmt.Sprintf("expression %s in index %s has no MongoDB equivalent and is left out", e, src.Name))
+			}
+		}
+	}
+	if len(idx.Keys) == 0 {
+		return target.IndexDefinition{}, notes, false
+	}
+	if idx.Unique && !exact {
+		// Uniqueness of a computed value does not carry over to its column
+		idx.Unique = false
+		notes = append(notes, fmt.Sprintf("unique index %s is created non-unique", src.Name))
+	}
+
+	if src.Predicate != "" {
+		filter, sparse, ok := translatePredicate(src.Predicate, field)
+		switch {
+		case !ok:
+			notes = append(notes, fmt.Sprintf("filter %q of index %s has no MongoDB equivalent; every document is indexed", src.Predicate, src.Name))
+			if idx.Unique {
+				idx.Unique = false
+				notes = append(notes, fmt.Sprintf("unique index %s is created non-unique so documents outside its filter cannot collide", src.Name))
+			}
+		case sparse != "" && len(idx.Keys) == 1 && idx.Keys[0].Field == sparse:
+			idx.Sparse = true
+		default:
+			idx.PartialFilter = filter
+		}
+	}
+
+	idx.Name = fmt.Sprintf("idx_%s_%s", collName, fieldName(idx.Keys))
+	return idx, notes, true
+}
+
+// isTextSearch reports whether a source index is a full-text index: an
+// Oracle Text index or a PostgreSQL index over to_tsvector.
+func isTextSearch(src schema.Index) bool {
+	switch strings.ToUpper(src.Type) {
+	case "CONTEXT", "CTXCAT", "CTXSYS.CONTEXT", "CTXSYS.CTXCAT":
+		return true
+	}
+	for _, e := range src.Expressions {
+		if strings.Contains(strings.ToLower(e), "to_tsvector(") {
+			return true
+		}
+	}
+	return false
+}
+
+// referencedColumns returns the table's columns named in an expression, in
+// order of first appearance.
+func referencedColumns(expr string, t *schema.Table) []string {
+	if t == nil {
+		return nil
+	}
+	// Drop string literals so words inside them are not taken for columns
+	var b strings.Builder
+	inQuote := false
+	for _, r := range expr {
+		if r == '\'' {
+			inQuote = !inQuote
+			continue
+		}
+		if !inQuote {
+			b.WriteRune(r)
+		}
+	}
+
+	var cols []string
+	seen := make(map[string]bool)
+	for _, m := range identifierExpr.FindAllStringSubmatch(b.String(), -1) {
+		if c := findColumn(t, m[1]); c != nil && !seen[c.Name] {
+			seen[c.Name] = true
+			cols = append(cols, c.Name)
+		}
+	}
+	return cols
+}
+
+func findColumn(t *schema.Table, name string) *schema.Column {
+	if t == nil {
+		return nil
+	}
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// translatePredicate converts a partial index WHERE clause made of ANDed
+// comparisons and IS NOT NULL tests into a MongoDB partial filter. When the
+// clause is a single IS NOT NULL test, sparse names its field.
+func translatePredicate(pred string, field func(string) string) (filter map[string]any, sparse string, ok bool) {
+	terms, ok := splitAnd(schema.StripParens(strings.TrimSpace(pred)))
+	if !ok {
+		return nil, "", false
+	}
+	filter = make(map[string]any)
+	addOp := func(f, op string, v any) {
+		ops, _ := filter[f].(map[string]any)
+		if ops == nil {
+			ops = make(map[string]any)
+			filter[f] = ops
+		}
+		ops[op] = v
+	}
+	for _, term := range terms {
+		term = schema.StripParens(term)
+		if m := notNullExpr.FindStringSubmatch(term); m != nil {
+			addOp(field(m[1]), "$exists", true)
+			if len(terms) == 1 {
+				sparse = field(m[1])
+			}
+			continue
+		}
+		m := comparisonExpr.FindStringSubmatch(term)
+		if m == nil {
+			return nil, "", false
+		}
+		op, supported := comparisonOps[m[2]]
+		if !supported {
+			return nil, "", false
+		}
+		v, isLiteral := parseLiteral(m[3])
+		if !isLiteral {
+			return nil, "", false
+		}
+		addOp(field(m[1]), op, v)
+	}
+	return filter, sparse, true
+}
+
+// parseLiteral parses a SQL string, number or boolean literal, ignoring
+// PostgreSQL casts.
+func parseLiteral(s string) (any, bool) {
+	s = strings.TrimSpace(s)
+	if cast := castSuffix.FindString(s); strings.Contains(cast, "date") || strings.Contains(cast, "time") {
+		// Dates are stored as BSON dates, not the literal's text
+		return nil, false
+	}
+	s = strings.TrimSpace(castSuffix.ReplaceAllString(s, ""))
+	s = schema.StripParens(s)
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), true
+	}
+	switch strings.ToLower(s) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, true
+	}
+	return nil, false
+}
+
+// splitAnd splits an expression on top-level AND, failing on top-level OR.
+func splitAnd(s string) ([]string, bool) {
+	var terms []string
+	depth, start := 0, 0
+	inQuote := false
+	upper := strings.ToUpper(s)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(upper[i:], " AND "):
+			terms = append(terms, strings.TrimSpace(s[start:i]))
+			start = i + len(" AND ")
+			i = start - 1
+		case depth == 0 && strings.HasPrefix(upper[i:], " OR "):
+			return nil, false
+		}
+	}
+	return append(terms, strings.TrimSpace(s[start:])), true
+}
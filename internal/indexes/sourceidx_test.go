@@ -0,0 +1,211 @@
+package indexes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func testSourceTable() *schema.Table {
+	return &schema.Table{
+		Name: "customers",
+		Columns: []schema.Column{
+			{Name: "id", DataType: "bigint"},
+			{Name: "email", DataType: "varchar"},
+			{Name: "handle", DataType: "citext", Collation: &schema.Collation{Name: "citext", CaseInsensitive: true}},
+			{Name: "name", DataType: "text"},
+			{Name: "bio", DataType: "text"},
+			{Name: "attrs", DataType: "jsonb"},
+			{Name: "status", DataType: "varchar"},
+			{Name: "score", DataType: "numeric"},
+			{Name: "created_at", DataType: "timestamp"},
+			{Name: "deleted_at", DataType: "timestamp"},
+		},
+	}
+}
+
+func TestTranslateIndex(t *testing.T) {
+	ci := &target.Collation{Locale: "en", Strength: 2}
+	tests := []struct {
+		name       string
+		src        schema.Index
+		wantOK     bool
+		wantKeys   []target.IndexKey
+		wantUnique bool
+		wantSparse bool
+		wantFilter map[string]any
+		wantColl   *target.Collation
+		wantNote   string
+	}{
+		{
+			name:       "plain",
+			src:        schema.Index{Name: "ix_email", Columns: []string{"email"}, Unique: true},
+			wantOK:     true,
+			wantKeys:   []target.IndexKey{{Field: "email", Order: 1}},
+			wantUnique: true,
+		},
+		{
+			name:       "case folded",
+			src:        schema.Index{Name: "ix_lower_email", Expressions: []string{"lower((email)::text)"}, Unique: true},
+			wantOK:     true,
+			wantKeys:   []target.IndexKey{{Field: "email", Order: 1}},
+			wantUnique: true,
+			wantColl:   ci,
+			wantNote:   "case-insensitive",
+		},
+		{
+			name:       "insensitive column collation",
+			src:        schema.Index{Name: "ix_handle", Columns: []string{"handle"}, Unique: true},
+			wantOK:     true,
+			wantKeys:   []target.IndexKey{{Field: "handle", Order: 1}},
+			wantUnique: true,
+			wantColl:   ci,
+			wantNote:   "built with collation",
+		},
+		{
+			name:     "oracle upper",
+			src:      schema.Index{Name: "IX_NAME", Expressions: []string{`UPPER("name")`}},
+			wantOK:   true,
+			wantKeys: []target.IndexKey{{Field: "name", Order: 1}},
+			wantColl: ci,
+		},
+		{
+			name:     "json path",
+			src:      schema.Index{Name: "ix_attr_color", Expressions: []string{"(attrs ->> 'color'::text)"}},
+			wantOK:   true,
+			wantKeys: []target.IndexKey{{Field: "attrs.color", Order: 1}},
+		},
+		{
+			name:     "computed value",
+			src:      schema.Index{Name: "ix_day", Columns: []string{"status"}, Expressions: []string{"date_trunc('day'::text, created_at)"}, Unique: true},
+			wantOK:   true,
+			wantKeys: []target.IndexKey{{Field: "status", Order: 1}, {Field: "created_at", Order: 1}},
+			wantNote: "created non-unique",
+		},
+		{
+			name:     "untranslatable",
+			src:      schema.Index{Name: "ix_len", Expressions: []string{"(length(name) + length(bio))"}},
+			wantNote: "no MongoDB equivalent",
+		},
+		{
+			name:     "full text",
+			src:      schema.Index{Name: "ix_search", Type: "gin", Expressions: []string{"to_tsvector('english'::regconfig, ((name || ' '::text) || bio))"}},
+			wantOK:   true,
+			wantKeys: []target.IndexKey{{Field: "name", Type: target.IndexText}, {Field: "bio", Type: target.IndexText}},
+		},
+		{
+			name:     "oracle text",
+			src:      schema.Index{Name: "IX_BIO", Type: "CONTEXT", Columns: []string{"bio"}},
+			wantOK:   true,
+			wantKeys: []target.IndexKey{{Field: "bio", Type: target.IndexText}},
+		},
+		{
+			name:     "json gin",
+			src:      schema.Index{Name: "ix_attrs", Type: "gin", Columns: []string{"attrs"}},
+			wantOK:   true,
+			wantKeys: []target.IndexKey{{Field: "attrs.$**", Order: 1}},
+		},
+		{
+			name:       "partial",
+			src:        schema.Index{Name: "ix_active", Columns: []string{"email"}, Unique: true, Predicate: "((status)::text = 'active'::text) AND (score >= (10)::numeric)"},
+			wantOK:     true,
+			wantKeys:   []target.IndexKey{{Field: "email", Order: 1}},
+			wantUnique: true,
+			wantFilter: map[string]any{"status": map[string]any{"$eq": "active"}, "score": map[string]any{"$gte": int64(10)}},
+		},
+		{
+			name:       "not null becomes sparse",
+			src:        schema.Index{Name: "ix_deleted", Columns: []string{"deleted_at"}, Predicate: "(deleted_at IS NOT NULL)"},
+			wantOK:     true,
+			wantKeys:   []target.IndexKey{{Field: "deleted_at", Order: 1}},
+			wantSparse: true,
+		},
+		{
+			name:     "untranslatable filter",
+			src:      schema.Index{Name: "ix_live", Columns: []string{"email"}, Unique: true, Predicate: "(deleted_at IS NULL)"},
+			wantOK:   true,
+			wantKeys: []target.IndexKey{{Field: "email", Order: 1}},
+			wantNote: "so documents outside its filter cannot collide",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx, notes, ok := translateIndex("customers", "", testSourceTable(), tt.src)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v (notes %v)", ok, tt.wantOK, notes)
+			}
+			if tt.wantNote != "" && !strings.Contains(strings.Join(notes, "\n"), tt.wantNote) {
+				t.Errorf("notes = %v, want %q", notes, tt.wantNote)
+			}
+			if !ok {
+				return
+			}
+			if !reflect.DeepEqual(idx.Keys, tt.wantKeys) {
+				t.Errorf("Keys = %+v, want %+v", idx.Keys, tt.wantKeys)
+			}
+			if idx.Unique != tt.wantUnique || idx.Sparse != tt.wantSparse {
+				t.Errorf("unique/sparse = %v/%v, want %v/%v", idx.Unique, idx.Sparse, tt.wantUnique, tt.wantSparse)
+			}
+			if !reflect.DeepEqual(idx.PartialFilter, tt.wantFilter) {
+				t.Errorf("PartialFilter = %v, want %v", idx.PartialFilter, tt.wantFilter)
+			}
+			if !reflect.DeepEqual(idx.Collation, tt.wantColl) {
+				t.Errorf("Collation = %+v, want %+v", idx.Collation, tt.wantColl)
+			}
+		})
+	}
+}
+
+func TestTranslatePredicate(t *testing.T) {
+	field := func(c string) string { return "items." + c }
+	tests := []struct {
+		pred   string
+		wantOK bool
+		want   map[string]any
+	}{
+		{"(active = true)", true, map[string]any{"items.active": map[string]any{"$eq": true}}},
+		{"((qty > 0) AND (qty < 100))", true, map[string]any{"items.qty": map[string]any{"$gt": int64(0), "$lt": int64(100)}}},
+		{"(note = 'it''s AND more'::text)", true, map[string]any{"items.note": map[string]any{"$eq": "it's AND more"}}},
+		{"((note = 'a)'::text) AND (qty > 0))", true, map[string]any{"items.note": map[string]any{"$eq": "a)"}, "items.qty": map[string]any{"$gt": int64(0)}}},
+		{"(price <= 9.5)", true, map[string]any{"items.price": map[string]any{"$lte": 9.5}}},
+		{"((a = 1) OR (b = 2))", false, nil},
+		{"(status <> 'x'::text)", false, nil},
+		{"(created_at > '2024-01-01'::date)", false, nil},
+		{"(a = b)", false, nil},
+	}
+	for _, tt := range tests {
+		got, _, ok := translatePredicate(tt.pred, field)
+		if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("translatePredicate(%q) = %v %v, want %v %v", tt.pred, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestInfer_SourceIndexTranslation(t *testing.T) {
+	tbl := testSourceTable()
+	tbl.Indexes = []schema.Index{
+		{Name: "ix_search", Type: "gin", Expressions: []string{"to_tsvector('english'::regconfig, name)"}},
+		{Name: "IX_BIO", Type: "CONTEXT", Columns: []string{"bio"}},
+		{Name: "ix_len", Expressions: []string{"(length(name) + length(bio))"}},
+	}
+	s := &schema.Schema{Tables: []schema.Table{*tbl}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "customers", SourceTable: "customers"}}}
+
+	plan := Infer(s, m)
+	if len(plan.Indexes) != 1 || plan.Indexes[0].Index.Keys[0].Type != target.IndexText {
+		t.Fatalf("Indexes = %+v, want the single text index", plan.Indexes)
+	}
+	explained := strings.Join(plan.Explanations, "\n")
+	for _, want := range []string{"becomes a MongoDB text index", "already has text index", "no MongoDB equivalent"} {
+		if !strings.Contains(explained, want) {
+			t.Errorf("explanations missing %q:\n%s", want, explained)
+		}
+	}
+	if err := plan.Validate(m); err != nil {
+		t.Errorf("inferred plan should validate: %v", err)
+	}
+}
@@ -0,0 +1,291 @@
+package indexes
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// How a source unique constraint carries over to MongoDB.
+const (
+	UniquePreserved   = "preserved"   // a unique index or _id enforces it as in the source
+	UniqueConvertible = "convertible" // a partial unique index enforces it
+	UniqueLost        = "lost"        // no index can enforce it; the application must
+)
+
+// UniqueConstraint is a source primary key or unique index and how its
+// uniqueness survives the mapping.
+type UniqueConstraint struct {
+	Collection string   `json:"collection"`
+	Table      string   `json:"table"`
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	Status     string   `json:"status"`
+	Reason     string   `json:"reason"`
+
+	// Index is the unique index that enforces the constraint: the partial
+	// index to create for convertible ones. Nil for lost constraints and
+	// primary keys that become _id.
+	Index *target.IndexDefinition `json:"index,omitempty"`
+}
+
+// UniqueReport classifies every unique constraint of the mapped tables.
+type UniqueReport struct {
+	Constraints []UniqueConstraint `json:"constraints"`
+	Preserved   int                `json:"preserved"`
+	Convertible int                `json:"convertible"`
+	Lost        int                `json:"lost"`
+}
+
+// AnalyzeUnique classifies the primary keys and unique indexes of the tables
+// each collection is built from. Uniqueness is lost for rows embedded as
+// array elements, since a unique index does not stop duplicates within one
+// document's array, and for constraints on columns that are not migrated.
+// Constraints over nullable columns, or inside an embedded subdocument that
+// may be missing, become partial unique indexes: NULLs never collide in the
+// source, but a plain unique index lets only one document leave a field
+// null or unset. tm resolves the BSON types the partial filters match; nil
+// uses the source database's defaults.
+func AnalyzeUnique(s *schema.Schema, m *mapping.Mapping, tm *typemap.TypeMap) *UniqueReport {
+	r := &UniqueReport{Constraints: []UniqueConstraint{}}
+	if s == nil || m == nil {
+		return r
+	}
+	if tm == nil {
+		tm = typemap.ForDatabase(s.DatabaseType)
+	}
+	a := uniqueAnalyzer{tables: buildTableMap(s), types: tm, report: r}
+	for i := range m.Collections {
+		col := &m.Collections[i]
+		t := a.tables[col.SourceTable]
+		if t == nil {
+			continue
+		}
+		a.table(col.Name, "", t, col.RootField, uniqueScope{root: true, objectID: col.IDGeneration == mapping.IDObjectID})
+		a.embedded(col.Name, col.Embedded, "", false)
+	}
+	for _, c := range r.Constraints {
+		switch c.Status {
+		case UniquePreserved:
+			r.Preserved++
+		case UniqueConvertible:
+			r.Convertible++
+		default:
+			r.Lost++
+		}
+	}
+	return r
+}
+
+// uniqueScope is where a table's rows end up in the document.
+type uniqueScope struct {
+	root     bool   // the collection's own table
+	array    string // set when the rows are array elements: the array field
+	optional string // set when the rows are a subdocument that may be missing
+	objectID bool   // new documents get an ObjectId _id and leave the primary key unset
+}
+
+type uniqueAnalyzer struct {
+	tables map[string]*schema.Table
+	types  *typemap.TypeMap
+	report *UniqueReport
+}
+
+func (a *uniqueAnalyzer) embedded(collection string, embedded []mapping.Embedded, prefix string, inArray bool) {
+	for i := range embedded {
+		emb := &embedded[i]
+		path := emb.FieldName
+		if prefix != "" {
+			path = prefix + "." + emb.FieldName
+		}
+		single := emb.Relationship == "single"
+		if t := a.tables[emb.SourceTable]; t != nil {
+			scope := uniqueScope{}
+			switch {
+			case inArray || !single:
+				scope.array = path
+			default:
+				scope.optional = path
+			}
+			a.table(collection, path, t, emb.SubField, scope)
+		}
+		a.embedded(collection, emb.Embedded, path, inArray || !single)
+	}
+}
+
+func (a *uniqueAnalyzer) table(collection, prefix string, t *schema.Table, field func(string) (string, bool), scope uniqueScope) {
+	if pk := t.PrimaryKey; pk != nil && len(pk.Columns) > 0 {
+		name := pk.Name
+		if name == "" {
+			name = "primary key"
+		}
+		a.classify(collection, prefix, t, schema.Index{Name: name, Columns: pk.Columns, Unique: true}, true, field, scope)
+	}
+	for _, idx := range t.Indexes {
+		if !idx.Unique || (t.PrimaryKey != nil && sameColumns(idx.Columns, t.PrimaryKey.Columns)) {
+			continue
+		}
+		a.classify(collection, prefix, t, idx, false, field, scope)
+	}
+}
+
+func (a *uniqueAnalyzer) classify(collection, prefix string, t *schema.Table, src schema.Index, isPK bool, field func(string) (string, bool), scope uniqueScope) {
+	c := UniqueConstraint{Collection: collection, Table: t.Name, Name: src.Name, Columns: src.Columns}
+	add := func(status, reason string) {
+		c.Status, c.Reason = status, reason
+		a.report.Constraints = append(a.report.Constraints, c)
+	}
+
+	for _, col := range src.Columns {
+		if _, ok := field(col); !ok {
+			add(UniqueLost, fmt.Sprintf("column %s is not migrated", col))
+			return
+		}
+	}
+	if scope.array != "" {
+		add(UniqueLost, fmt.Sprintf("rows become elements of the %s array, and a unique index does not stop duplicates within one document's array", scope.array))
+		return
+	}
+	if scope.root && isPK && isSingleID(src.Columns) {
+		add(UniquePreserved, "the primary key becomes _id")
+		return
+	}
+
+	idx, notes, ok := translateIndex(collection, prefix, t, src)
+	if !ok || !idx.Unique {
+		reason := fmt.Sprintf("index %s has no unique MongoDB equivalent", src.Name)
+		if len(notes) > 0 {
+			reason = notes[len(notes)-1]
+		}
+		add(UniqueLost, reason)
+		return
+	}
+	if isPK && scope.root {
+		idx.Name = fmt.Sprintf("pk_%s", collection)
+	}
+
+	// Every column that may be unset needs a typed value to be indexed
+	keyUnset := isPK && scope.objectID
+	var unset []string
+	for _, name := range src.Columns {
+		col := findColumn(t, name)
+		if col == nil || (!col.Nullable && scope.optional == "" && !keyUnset) {
+			continue
+		}
+		f := name
+		if prefix != "" {
+			f = prefix + "." + name
+		}
+		addFilter(&idx, f, "$type", bsonTypeAlias(a.types.Resolve(col.DataType)))
+		unset = append(unset, name)
+	}
+	c.Index = &idx
+	if len(unset) == 0 {
+		add(UniquePreserved, fmt.Sprintf("unique index %s enforces it", idx.Name))
+		return
+	}
+	idx.Sparse = false
+	reason := fmt.Sprintf("rows with NULL in %s never collide in the source; a partial unique index leaves out documents where it is null", strings.Join(unset, ", "))
+	switch {
+	case scope.optional != "":
+		reason = fmt.Sprintf("documents without a %s subdocument have no row to collide in the source; a partial unique index leaves them out", scope.optional)
+	case keyUnset:
+		reason = fmt.Sprintf("new documents get an ObjectId _id and leave %s unset; a partial unique index leaves them out", strings.Join(unset, ", "))
+	}
+	add(UniqueConvertible, reason)
+}
+
+// addFilter adds an operator on field to the index's partial filter.
+func addFilter(idx *target.IndexDefinition, field, op string, v any) {
+	if idx.PartialFilter == nil {
+		idx.PartialFilter = make(map[string]any)
+	}
+	ops, _ := idx.PartialFilter[field].(map[string]any)
+	if ops == nil {
+		ops = make(map[string]any)
+		idx.PartialFilter[field] = ops
+	}
+	ops[op] = v
+}
+
+// bsonTypeAlias returns the $type alias matching values of the BSON type
+// that are set. Numbers match any numeric type, as the movers may write
+// small integers as int32.
+func bsonTypeAlias(t typemap.BSONType) string {
+	switch t {
+	case typemap.BSONNumberLong, typemap.BSONDecimal128, typemap.BSONDouble:
+		return "number"
+	case typemap.BSONISODate:
+		return "date"
+	case typemap.BSONBinData:
+		return "binData"
+	case typemap.BSONBoolean:
+		return "bool"
+	case typemap.BSONDocument, typemap.BSONGeoJSON:
+		return "object"
+	case typemap.BSONArray:
+		return "array"
+	}
+	return "string"
+}
+
+// AddUniqueConversions puts the partial unique index of every convertible
+// constraint in the plan, replacing an index on the same keys, and returns
+// how many it added or replaced.
+func (p *IndexPlan) AddUniqueConversions(r *UniqueReport) int {
+	n := 0
+	for _, c := range r.Constraints {
+		if c.Status != UniqueConvertible || c.Index == nil {
+			continue
+		}
+		idx := *c.Index
+		keys := indexKeyString(idx.Keys)
+		replaced := false
+		for i := range p.Indexes {
+			ci := &p.Indexes[i]
+			if ci.Collection == c.Collection && indexKeyString(ci.Index.Keys) == keys {
+				idx.Name = ci.Index.Name
+				ci.Index = idx
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			p.Indexes = append(p.Indexes, target.CollectionIndex{Collection: c.Collection, Index: idx})
+		}
+		explanation := fmt.Sprintf("Partial unique index on %s(%s) from %s %s: %s", c.Collection, indexFields(idx.Keys), c.Table, c.Name, c.Reason)
+		if !slices.Contains(p.Explanations, explanation) {
+			p.Explanations = append(p.Explanations, explanation)
+		}
+		n++
+	}
+	return n
+}
+
+// Unenforced returns the convertible constraints the plan has no partial
+// unique index for.
+func (p *IndexPlan) Unenforced(r *UniqueReport) []UniqueConstraint {
+	var out []UniqueConstraint
+	for _, c := range r.Constraints {
+		if c.Status != UniqueConvertible || c.Index == nil {
+			continue
+		}
+		keys := indexKeyString(c.Index.Keys)
+		found := false
+		for _, ci := range p.Indexes {
+			if ci.Collection == c.Collection && indexKeyString(ci.Index.Keys) == keys && ci.Index.Unique && len(ci.Index.PartialFilter) > 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package indexes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func uniqueSchema() *schema.Schema {
+	return &schema.Schema{
+		DatabaseType: "postgresql",
+		Tables: []schema.Table{
+			{
+				Name: "customers",
+				Columns: []schema.Column{
+					{Name: "id", DataType: "bigint"},
+					{Name: "email", DataType: "text"},
+					{Name: "tax_id", DataType: "text", Nullable: true},
+					{Name: "legacy_code", DataType: "text"},
+				},
+				PrimaryKey: &schema.PrimaryKey{Name: "customers_pkey", Columns: []string{"id"}},
+				Indexes: []schema.Index{
+					{Name: "ux_email", Columns: []string{"email"}, Unique: true},
+					{Name: "ux_tax_id", Columns: []string{"tax_id"}, Unique: true},
+					{Name: "ux_legacy", Columns: []string{"legacy_code"}, Unique: true},
+				},
+			},
+			{
+				Name: "profiles",
+				Columns: []schema.Column{
+					{Name: "customer_id", DataType: "bigint"},
+					{Name: "handle", DataType: "text"},
+				},
+				PrimaryKey: &schema.PrimaryKey{Name: "profiles_pkey", Columns: []string{"customer_id"}},
+				Indexes:    []schema.Index{{Name: "ux_handle", Columns: []string{"handle"}, Unique: true}},
+			},
+			{
+				Name: "addresses",
+				Columns: []schema.Column{
+					{Name: "customer_id", DataType: "bigint"},
+					{Name: "label", DataType: "text"},
+				},
+				Indexes: []schema.Index{{Name: "ux_label", Columns: []string{"customer_id", "label"}, Unique: true}},
+			},
+		},
+	}
+}
+
+func uniqueMapping() *mapping.Mapping {
+	return &mapping.Mapping{Collections: []mapping.Collection{{
+		Name:        "customers",
+		SourceTable: "customers",
+		Transformations: []mapping.Transformation{
+			{SourceField: "legacy_code", Operation: "exclude"},
+		},
+		Embedded: []mapping.Embedded{
+			{SourceTable: "profiles", FieldName: "profile", Relationship: "single", JoinColumn: "customer_id", ParentColumn: "id"},
+			{SourceTable: "addresses", FieldName: "addresses", Relationship: "array", JoinColumn: "customer_id", ParentColumn: "id"},
+		},
+	}}}
+}
+
+func TestAnalyzeUnique(t *testing.T) {
+	r := AnalyzeUnique(uniqueSchema(), uniqueMapping(), nil)
+
+	want := map[string]string{
+		"customers_pkey": UniquePreserved,
+		"ux_email":       UniquePreserved,
+		"ux_tax_id":      UniqueConvertible,
+		"ux_legacy":      UniqueLost,
+		"profiles_pkey":  UniqueConvertible,
+		"ux_handle":      UniqueConvertible,
+		"ux_label":       UniqueLost,
+	}
+	got := make(map[string]string)
+	for _, c := range r.Constraints {
+		got[c.Name] = c.Status
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	if r.Preserved != 2 || r.Convertible != 3 || r.Lost != 2 {
+		t.Errorf("counts = %d/%d/%d, want 2/3/2", r.Preserved, r.Convertible, r.Lost)
+	}
+
+	byName := make(map[string]UniqueConstraint)
+	for _, c := range r.Constraints {
+		byName[c.Name] = c
+	}
+	tax := byName["ux_tax_id"].Index
+	if tax == nil || !tax.Unique || !reflect.DeepEqual(tax.PartialFilter, map[string]any{"tax_id": map[string]any{"$type": "string"}}) {
+		t.Errorf("tax_id index = %+v, want a partial unique index on set strings", tax)
+	}
+	profile := byName["profiles_pkey"].Index
+	if profile == nil || !reflect.DeepEqual(profile.PartialFilter, map[string]any{"profile.customer_id": map[string]any{"$type": "number"}}) {
+		t.Errorf("profile index = %+v, want a partial filter on the optional subdocument", profile)
+	}
+	if reason := byName["ux_label"].Reason; !strings.Contains(reason, "elements of the addresses array") {
+		t.Errorf("ux_label reason = %q", reason)
+	}
+	if reason := byName["ux_legacy"].Reason; reason != "column legacy_code is not migrated" {
+		t.Errorf("ux_legacy reason = %q", reason)
+	}
+}
+
+func TestIndexPlan_AddUniqueConversions(t *testing.T) {
+	s, m := uniqueSchema(), uniqueMapping()
+	plan := Infer(s, m)
+	r := AnalyzeUnique(s, m, nil)
+	if got := len(plan.Unenforced(r)); got != 3 {
+		t.Fatalf("unenforced before = %d, want 3", got)
+	}
+
+	before := len(plan.Indexes)
+	if n := plan.AddUniqueConversions(r); n != 3 {
+		t.Errorf("added %d conversions, want 3", n)
+	}
+	if n := plan.AddUniqueConversions(r); n != 3 {
+		t.Errorf("reapplying added %d conversions, want 3", n)
+	}
+	if got := plan.Unenforced(r); len(got) != 0 {
+		t.Errorf("unenforced after = %+v", got)
+	}
+
+	// Indexes inferred on the same keys are replaced, not duplicated: the
+	// unique tax_id index and the profile's join index
+	if len(plan.Indexes) != before {
+		t.Errorf("plan has %d indexes, want %d", len(plan.Indexes), before)
+	}
+	for _, ci := range plan.Indexes {
+		f := ci.Index.Keys[0].Field
+		if (f == "tax_id" || f == "profile.customer_id") && (!ci.Index.Unique || len(ci.Index.PartialFilter) == 0) {
+			t.Errorf("%s index = %+v, want partial unique", f, ci.Index)
+		}
+	}
+}
+
+func TestAnalyzeUnique_Empty(t *testing.T) {
+	if r := AnalyzeUnique(nil, uniqueMapping(), nil); len(r.Constraints) != 0 {
+		t.Errorf("no schema should give no constraints: %+v", r)
+	}
+}
+
+func TestAnalyzeUnique_ObjectIDKey(t *testing.T) {
+	s := &schema.Schema{DatabaseType: "postgresql", Tables: []schema.Table{{
+		Name:       "orders",
+		Columns:    []schema.Column{{Name: "order_id", DataType: "bigint", IsSequence: true}},
+		PrimaryKey: &schema.PrimaryKey{Name: "orders_pkey", Columns: []string{"order_id"}},
+	}}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "orders", SourceTable: "orders"}}}
+	if r := AnalyzeUnique(s, m, nil); r.Preserved != 1 {
+		t.Errorf("sequence key = %+v, want preserved", r.Constraints)
+	}
+
+	// New documents leave the key unset once ids switch to ObjectId
+	m.Collections[0].IDGeneration = mapping.IDObjectID
+	r := AnalyzeUnique(s, m, nil)
+	if r.Convertible != 1 || !strings.Contains(r.Constraints[0].Reason, "ObjectId") {
+		t.Errorf("objectid key = %+v, want convertible", r.Constraints)
+	}
+}
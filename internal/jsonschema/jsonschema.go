@@ -0,0 +1,246 @@
+// Package jsonschema generates the MongoDB $jsonSchema validators that keep
+// writes to the target collections to the shape the source schema gave
+// their rows: NOT NULL columns become required fields, the type map gives
+// each field its bsonType, and enum types and IN list check constraints
+// become enums. Validators leave out what the migration does not type
+// (cast and computed fields, offloaded large objects and embedded fields)
+// and do not forbid fields they do not name.
+package jsonschema
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// bsonTypes maps the type map's BSON types to $jsonSchema bsonType aliases.
+// NumberLong also accepts int: integers the source driver reads as 32 bits
+// are written as such.
+var bsonTypes = map[typemap.BSONType][]string{
+	typemap.BSONNumberLong: {"long", "int"},
+	typemap.BSONDecimal128: {"decimal"},
+	typemap.BSONString:     {"string"},
+	typemap.BSONISODate:    {"date"},
+	typemap.BSONBinData:    {"binData"},
+	typemap.BSONDocument:   {"object"},
+	typemap.BSONArray:      {"array"},
+	typemap.BSONBoolean:    {"bool"},
+	typemap.BSONDouble:     {"double"},
+	typemap.BSONGeoJSON:    {"object"},
+	"Int32":                {"int"},
+	"int":                  {"int"},
+	"ObjectId":             {"objectId"},
+	"Timestamp":            {"timestamp"},
+}
+
+// Validator returns the validator of a collection, a {$jsonSchema: ...}
+// document for collMod or createCollection, or nil when the schema lacks
+// the collection's source table.
+func Validator(s *schema.Schema, tm *typemap.TypeMap, col mapping.Collection) map[string]interface{} {
+	if s == nil || findTable(s, col.SourceTable) == nil {
+		return nil
+	}
+	if tm == nil {
+		tm = typemap.ForDatabase(s.DatabaseType)
+	}
+	b := builder{schema: s, typeMap: tm}
+
+	// New documents identified by an ObjectId need not carry the source key
+	optional := make(map[string]bool)
+	if col.IDGeneration == mapping.IDObjectID {
+		if t := findTable(s, col.SourceTable); t.PrimaryKey != nil {
+			for _, c := range t.PrimaryKey.Columns {
+				optional[c] = true
+			}
+		}
+	}
+
+	root := b.table(col.SourceTable, col.RootField, col.Transformations, optional)
+	b.embed(root, col.Embedded)
+	return map[string]interface{}{"$jsonSchema": root.doc()}
+}
+
+type builder struct {
+	schema  *schema.Schema
+	typeMap *typemap.TypeMap
+}
+
+// object is the schema of a document or subdocument as it is built. Its
+// properties are field schemas or nested objects.
+type object struct {
+	properties map[string]interface{}
+	required   map[string]bool
+	nullable   bool
+}
+
+func newObject() *object {
+	return &object{properties: make(map[string]interface{}), required: make(map[string]bool)}
+}
+
+// set adds the schema of the field at path, creating the subdocuments it
+// nests in. A subdocument is required when a field in it is.
+func (o *object) set(path []string, prop interface{}, required bool) {
+	for _, part := range path[:len(path)-1] {
+		if required {
+			o.required[part] = true
+		}
+		child, ok := o.properties[part].(*object)
+		if !ok {
+			child = newObject()
+			o.properties[part] = child
+		}
+		o = child
+	}
+	o.properties[path[len(path)-1]] = prop
+	if required {
+		o.required[path[len(path)-1]] = true
+	}
+}
+
+// doc renders the object as a $jsonSchema document.
+func (o *object) doc() map[string]interface{} {
+	d := map[string]interface{}{"bsonType": "object"}
+	if o.nullable {
+		d["bsonType"] = []string{"object", "null"}
+	}
+	if len(o.required) > 0 {
+		req := make([]string, 0, len(o.required))
+		for f := range o.required {
+			req = append(req, f)
+		}
+		sort.Strings(req)
+		d["required"] = req
+	}
+	props := make(map[string]interface{}, len(o.properties))
+	for f, p := range o.properties {
+		if child, ok := p.(*object); ok {
+			p = child.doc()
+		}
+		props[f] = p
+	}
+	d["properties"] = props
+	return d
+}
+
+// table builds the schema of the documents or subdocuments a table's rows
+// are written as. field gives the path each column is written to.
+func (b builder) table(name string, field func(string) (string, bool), ts []mapping.Transformation, optional map[string]bool) *object {
+	o := newObject()
+	t := findTable(b.schema, name)
+	if t == nil {
+		return o
+	}
+
+	// Cast and computed values have no type the source schema gives
+	untyped := make(map[string]bool)
+	for _, tr := range ts {
+		switch tr.Operation {
+		case "cast":
+			untyped[tr.SourceField] = true
+		case "compute":
+			untyped[tr.TargetField] = true
+		}
+	}
+	enums := checkEnums(t)
+
+	for _, c := range t.Columns {
+		if untyped[c.Name] {
+			continue
+		}
+		path, ok := field(c.Name)
+		if !ok {
+			continue
+		}
+		if lob := b.typeMap.LOB(name, c.Name); lob.Strategy == typemap.LOBSkip || lob.Offloaded() {
+			continue
+		}
+		values := c.EnumValues
+		if v, ok := enums[strings.ToLower(c.Name)]; ok {
+			values = v
+		}
+		o.set(strings.Split(path, "."), b.property(c, values), !c.Nullable && !optional[c.Name])
+	}
+	return o
+}
+
+// embed adds the embedded tables' subdocuments and arrays to o. Offloaded
+// fields hold a reference instead and are left unconstrained, as are empty
+// relationships: a parent without children may lack the field.
+func (b builder) embed(o *object, embs []mapping.Embedded) {
+	for i := range embs {
+		e := &embs[i]
+		if e.Offload != nil {
+			continue
+		}
+		sub := b.table(e.SourceTable, e.SubField, e.Transformations, nil)
+		b.embed(sub, e.Embedded)
+		var prop interface{}
+		if e.Relationship == "array" {
+			prop = map[string]interface{}{"bsonType": "array", "items": sub.doc()}
+		} else {
+			sub.nullable = true
+			prop = sub
+		}
+		o.set(strings.Split(e.FieldName, "."), prop, false)
+	}
+}
+
+// property returns the schema of a column's field: its bsonType, null
+// allowed when the column is nullable, and its values when it is an enum
+// written as a string. Source types the type map does not name are written
+// as the driver reads them and are left untyped.
+func (b builder) property(c schema.Column, values []string) map[string]interface{} {
+	p := make(map[string]interface{})
+	if _, known := b.typeMap.Mappings[c.DataType]; !known {
+		return p
+	}
+	t := b.typeMap.ResolveColumn(c)
+	types, ok := bsonTypes[t]
+	if !ok {
+		return p
+	}
+	types = append([]string(nil), types...)
+	if c.Nullable {
+		types = append(types, "null")
+	}
+	if len(types) == 1 {
+		p["bsonType"] = types[0]
+	} else {
+		p["bsonType"] = types
+	}
+	if t == typemap.BSONString && len(values) > 0 {
+		enum := make([]interface{}, 0, len(values)+1)
+		for _, v := range values {
+			enum = append(enum, v)
+		}
+		if c.Nullable {
+			enum = append(enum, nil)
+		}
+		p["enum"] = enum
+	}
+	return p
+}
+
+// checkEnums returns the values IN list check constraints allow, by
+// lower-cased column name.
+func checkEnums(t *schema.Table) map[string][]string {
+	out := make(map[string][]string)
+	for _, c := range t.Constraints {
+		if col, values, ok := c.Enum(); ok {
+			out[strings.ToLower(col)] = values
+		}
+	}
+	return out
+}
+
+func findTable(s *schema.Schema, name string) *schema.Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+	return nil
+}
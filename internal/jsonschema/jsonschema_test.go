@@ -0,0 +1,125 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+func testSchema() *schema.Schema {
+	return &schema.Schema{
+		DatabaseType: "postgresql",
+		Tables: []schema.Table{
+			{
+				Name: "orders",
+				Columns: []schema.Column{
+					{Name: "id", DataType: "integer", IsSequence: true},
+					{Name: "status", DataType: "character varying"},
+					{Name: "kind", DataType: "enum", TypeName: "order_kind", EnumValues: []string{"web", "store"}, Nullable: true},
+					{Name: "note", DataType: "text", Nullable: true},
+					{Name: "placed_at", DataType: "timestamp"},
+					{Name: "invoice", DataType: "bytea"},
+					{Name: "ship_city", DataType: "text"},
+				},
+				PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}},
+				Constraints: []schema.Constraint{
+					{Name: "orders_status_check", Type: "check", Definition: "((status)::text = ANY ((ARRAY['open'::character varying, 'shipped'::character varying])::text[]))"},
+				},
+			},
+			{
+				Name: "order_items",
+				Columns: []schema.Column{
+					{Name: "order_id", DataType: "integer"},
+					{Name: "qty", DataType: "integer"},
+				},
+			},
+		},
+	}
+}
+
+func testTypeMap() *typemap.TypeMap {
+	tm := typemap.ForDatabase("postgresql")
+	tm.LOBs = []typemap.LOBColumn{{Table: "orders", Column: "invoice", Strategy: typemap.LOBGridFS}}
+	return tm
+}
+
+func jsonSchema(t *testing.T, v map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	if v == nil {
+		t.Fatal("no validator")
+	}
+	return v["$jsonSchema"].(map[string]interface{})
+}
+
+func TestValidator(t *testing.T) {
+	col := mapping.Collection{
+		Name:        "orders",
+		SourceTable: "orders",
+		Fields:      []mapping.FieldMapping{{Column: "ship_city", Target: "shipping.city"}},
+		Embedded:    []mapping.Embedded{{SourceTable: "order_items", FieldName: "items", Relationship: "array", JoinColumn: "order_id", ParentColumn: "id"}},
+	}
+	js := jsonSchema(t, Validator(testSchema(), testTypeMap(), col))
+
+	if got, want := js["required"], []string{"id", "placed_at", "shipping", "status"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("required = %v, want %v", got, want)
+	}
+	props := js["properties"].(map[string]interface{})
+	if _, ok := props["invoice"]; ok {
+		t.Error("a LOB offloaded to GridFS should not be constrained")
+	}
+	if got := props["id"].(map[string]interface{})["bsonType"]; !reflect.DeepEqual(got, []string{"long", "int"}) {
+		t.Errorf("id bsonType = %v", got)
+	}
+	if got := props["note"].(map[string]interface{})["bsonType"]; !reflect.DeepEqual(got, []string{"string", "null"}) {
+		t.Errorf("nullable note bsonType = %v", got)
+	}
+	if got := props["status"].(map[string]interface{})["enum"]; !reflect.DeepEqual(got, []interface{}{"open", "shipped"}) {
+		t.Errorf("status enum = %v, want the check constraint's values", got)
+	}
+	if got := props["kind"].(map[string]interface{})["enum"]; !reflect.DeepEqual(got, []interface{}{"web", "store", nil}) {
+		t.Errorf("kind enum = %v, want the enum labels and null", got)
+	}
+
+	shipping := props["shipping"].(map[string]interface{})
+	if !reflect.DeepEqual(shipping["required"], []string{"city"}) {
+		t.Errorf("shipping = %v, want a subdocument requiring city", shipping)
+	}
+	items := props["items"].(map[string]interface{})
+	if items["bsonType"] != "array" {
+		t.Fatalf("items = %v, want an array", items)
+	}
+	item := items["items"].(map[string]interface{})
+	if !reflect.DeepEqual(item["required"], []string{"order_id", "qty"}) {
+		t.Errorf("item = %v", item)
+	}
+}
+
+func TestValidator_ObjectIDKeyIsOptional(t *testing.T) {
+	col := mapping.Collection{Name: "orders", SourceTable: "orders", IDGeneration: mapping.IDObjectID}
+	js := jsonSchema(t, Validator(testSchema(), testTypeMap(), col))
+	for _, f := range js["required"].([]string) {
+		if f == "id" {
+			t.Error("new documents identified by an ObjectId need not carry the source key")
+		}
+	}
+}
+
+func TestValidator_SkipsCastAndUnknownTypes(t *testing.T) {
+	s := testSchema()
+	s.Tables[0].Columns = append(s.Tables[0].Columns, schema.Column{Name: "period", DataType: "interval"})
+	col := mapping.Collection{
+		Name:            "orders",
+		SourceTable:     "orders",
+		Transformations: []mapping.Transformation{{SourceField: "placed_at", Operation: "cast", TargetType: "string"}},
+	}
+	props := jsonSchema(t, Validator(s, testTypeMap(), col))["properties"].(map[string]interface{})
+	if _, ok := props["placed_at"]; ok {
+		t.Error("a cast column should not be constrained")
+	}
+	if p := props["period"].(map[string]interface{}); len(p) != 0 {
+		t.Errorf("period = %v, want an unmapped type left untyped", p)
+	}
+}
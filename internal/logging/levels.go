@@ -0,0 +1,217 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ComponentKey is the log attribute naming the subsystem a record came from.
+const ComponentKey = "component"
+
+// Components that can be given their own log level.
+const (
+	ComponentDiscovery  = "discovery"
+	ComponentMigration  = "migration"
+	ComponentValidation = "validation"
+	ComponentAPI        = "api"
+	ComponentAWS        = "aws"
+)
+
+// Components lists the components in the order they are reported.
+var Components = []string{ComponentDiscovery, ComponentMigration, ComponentValidation, ComponentAPI, ComponentAWS}
+
+// For returns logger tagged with a component, so its records are filtered
+// by that component's level.
+func For(logger *slog.Logger, component string) *slog.Logger {
+	return logger.With(ComponentKey, component)
+}
+
+// Levels holds the default log level and the components set to their own.
+// It is safe for concurrent use, so levels can change while logging.
+type Levels struct {
+	mu         sync.RWMutex
+	def        slog.Level
+	components map[string]slog.Level
+}
+
+// NewLevels returns levels from a config: the default level name and the
+// components' level names. Unknown names are rejected.
+func NewLevels(level string, components map[string]string) (*Levels, error) {
+	l := &Levels{}
+	if err := l.Configure(level, components); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Configure replaces every level with the ones given, as when the config is
+// reloaded. On error the levels are left unchanged.
+func (l *Levels) Configure(level string, components map[string]string) error {
+	def, err := levelOf(level)
+	if err != nil {
+		return err
+	}
+	comps := make(map[string]slog.Level, len(components))
+	for c, name := range components {
+		if err := checkComponent(c); err != nil {
+			return err
+		}
+		lv, err := levelOf(name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c, err)
+		}
+		comps[c] = lv
+	}
+	l.mu.Lock()
+	l.def, l.components = def, comps
+	l.mu.Unlock()
+	return nil
+}
+
+// Set changes one level: the default when component is empty, otherwise the
+// component's. An empty level clears the component's own level so it
+// follows the default again.
+func (l *Levels) Set(component, level string) error {
+	if component != "" {
+		if err := checkComponent(component); err != nil {
+			return err
+		}
+	}
+	if level == "" && component != "" {
+		l.mu.Lock()
+		delete(l.components, component)
+		l.mu.Unlock()
+		return nil
+	}
+	lv, err := levelOf(level)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if component == "" {
+		l.def = lv
+		return nil
+	}
+	if l.components == nil {
+		l.components = make(map[string]slog.Level)
+	}
+	l.components[component] = lv
+	return nil
+}
+
+// Level returns the level records of a component are logged at.
+func (l *Levels) Level(component string) slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if lv, ok := l.components[component]; ok {
+		return lv
+	}
+	return l.def
+}
+
+// Snapshot returns the default level's name and those of the components
+// with their own level.
+func (l *Levels) Snapshot() (string, map[string]string) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	comps := make(map[string]string, len(l.components))
+	for c, lv := range l.components {
+		comps[c] = levelName(lv)
+	}
+	return levelName(l.def), comps
+}
+
+// min returns the lowest level any component logs at, below which no
+// record needs to be built.
+func (l *Levels) min() slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	m := l.def
+	for _, lv := range l.components {
+		m = min(m, lv)
+	}
+	return m
+}
+
+// NewHandler wraps h so records are kept or dropped by the level of their
+// component, set with For, or the default level for records without one.
+// h itself should accept every level.
+func NewHandler(h slog.Handler, levels *Levels) slog.Handler {
+	return &levelHandler{next: h, levels: levels}
+}
+
+type levelHandler struct {
+	next      slog.Handler
+	levels    *Levels
+	component string
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.component == "" {
+		// Enabled cannot see a component given with the record itself, so
+		// let through anything a component might want; Handle decides.
+		return level >= h.levels.min() && h.next.Enabled(ctx, level)
+	}
+	return level >= h.levels.Level(h.component) && h.next.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	component := h.component
+	if component == "" {
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == ComponentKey {
+				component = a.Value.String()
+				return false
+			}
+			return true
+		})
+	}
+	if r.Level < h.levels.Level(component) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	for _, a := range attrs {
+		if a.Key == ComponentKey {
+			c.component = a.Value.String()
+		}
+	}
+	c.next = h.next.WithAttrs(attrs)
+	return &c
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.next = h.next.WithGroup(name)
+	return &c
+}
+
+// levelOf parses a config level name; an empty name is info.
+func levelOf(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug", "warn", "error":
+		return ParseLevel(name), nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", name)
+}
+
+func levelName(lv slog.Level) string {
+	return strings.ToLower(lv.String())
+}
+
+func checkComponent(c string) error {
+	if !slices.Contains(Components, c) {
+		return fmt.Errorf("unknown log component %q (use %s)", c, strings.Join(Components, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLevels_Handler(t *testing.T) {
+	levels, err := NewLevels("warn", map[string]string{ComponentMigration: "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), levels))
+
+	logger.Info("default info")
+	For(logger, ComponentMigration).Debug("migration debug")
+	For(logger, ComponentAPI).Info("api info")
+	logger.Debug("inline migration debug", ComponentKey, ComponentMigration)
+	For(logger, ComponentAPI).Error("api error")
+
+	out := buf.String()
+	for _, want := range []string{"migration debug", "inline migration debug", "api error"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q:\n%s", want, out)
+		}
+	}
+	for _, skip := range []string{"default info", "api info"} {
+		if strings.Contains(out, skip) {
+			t.Errorf("output should not contain %q:\n%s", skip, out)
+		}
+	}
+
+	// Levels change while loggers are in use
+	buf.Reset()
+	if err := levels.Set(ComponentAPI, "info"); err != nil {
+		t.Fatal(err)
+	}
+	For(logger, ComponentAPI).Info("api info")
+	if !strings.Contains(buf.String(), "api info") {
+		t.Error("api info should be logged after raising the api level")
+	}
+}
+
+func TestLevels_SetAndSnapshot(t *testing.T) {
+	levels, err := NewLevels("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		component, level string
+		wantErr          bool
+	}{
+		{"", "debug", false},
+		{ComponentAWS, "error", false},
+		{ComponentDiscovery, "warn", false},
+		{ComponentDiscovery, "", false},
+		{"storage", "debug", true},
+		{ComponentAPI, "verbose", true},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if err := levels.Set(tt.component, tt.level); (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q, %q) error = %v, wantErr %v", tt.component, tt.level, err, tt.wantErr)
+		}
+	}
+	level, comps := levels.Snapshot()
+	if level != "info" || len(comps) != 1 || comps[ComponentAWS] != "error" {
+		t.Errorf("snapshot = %s %v, want info with aws at error", level, comps)
+	}
+
+	if _, err := NewLevels("info", map[string]string{ComponentAPI: "loud"}); err == nil {
+		t.Error("expected an unknown component level to be rejected")
+	}
+}
@@ -34,21 +34,24 @@ func Setup(level, directory string) (*slog.Logger, error) {
 
 	writer := io.MultiWriter(os.Stdout, file)
 
-	var logLevel slog.Level
+	handler := slog.NewTextHandler(writer, &slog.HandlerOptions{
+		Level: ParseLevel(level),
+	})
+
+	return slog.New(handler), nil
+}
+
+// ParseLevel converts a config log level (debug, info, warn, error) to a
+// slog level, defaulting to info.
+func ParseLevel(level string) slog.Level {
 	switch strings.ToLower(level) {
 	case "debug":
-		logLevel = slog.LevelDebug
+		return slog.LevelDebug
 	case "warn":
-		logLevel = slog.LevelWarn
+		return slog.LevelWarn
 	case "error":
-		logLevel = slog.LevelError
+		return slog.LevelError
 	default:
-		logLevel = slog.LevelInfo
+		return slog.LevelInfo
 	}
-
-	handler := slog.NewTextHandler(writer, &slog.HandlerOptions{
-		Level: logLevel,
-	})
-
-	return slog.New(handler), nil
 }
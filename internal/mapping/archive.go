@@ -0,0 +1,128 @@
+package mapping
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// ArchivePolicy diverts the root rows of a collection whose Column is older
+// than Before to Parquet files under Location, an s3:// prefix, instead of
+// the collection. The archived rows are written as they are in the source
+// table, one Parquet file set per collection, and their embedded child rows
+// are not migrated. Rows whose column is NULL stay in the collection.
+type ArchivePolicy struct {
+	Column   string `yaml:"column" json:"column"`
+	Before   string `yaml:"before" json:"before"` // cutoff date, 2006-01-02 or RFC 3339
+	Location string `yaml:"location" json:"location"`
+}
+
+// Cutoff parses Before.
+func (p *ArchivePolicy) Cutoff() (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, p.Before); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("archive: before must be a date like 2020-01-01 or an RFC 3339 time, not %q", p.Before)
+}
+
+// Validate checks that the policy names a column, a cutoff and an S3
+// location.
+func (p *ArchivePolicy) Validate() error {
+	if p.Column == "" {
+		return fmt.Errorf("archive: column is required")
+	}
+	if _, err := p.Cutoff(); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(p.Location, "s3://") || len(strings.Trim(p.Location[len("s3://"):], "/")) == 0 {
+		return fmt.Errorf("archive: location must be like s3://bucket/prefix")
+	}
+	return nil
+}
+
+// cutoffLiteral renders the cutoff as a TIMESTAMP literal in UTC.
+func (p *ArchivePolicy) cutoffLiteral() string {
+	t, _ := p.Cutoff()
+	lit, _ := WatermarkLiteral(t.UTC())
+	return lit
+}
+
+// Filter combines the rows the policy archives with a collection's row
+// filter into one SQL predicate.
+func (p *ArchivePolicy) Filter(base string) string {
+	pred := fmt.Sprintf("%s < %s", p.Column, p.cutoffLiteral())
+	if base == "" {
+		return pred
+	}
+	return "(" + base + ") AND " + pred
+}
+
+// LiveFilter combines the rows the policy keeps with a collection's row
+// filter into one SQL predicate.
+func (p *ArchivePolicy) LiveFilter(base string) string {
+	pred := fmt.Sprintf("(%s >= %s OR %s IS NULL)", p.Column, p.cutoffLiteral(), p.Column)
+	if base == "" {
+		return pred
+	}
+	return "(" + base + ") AND " + pred
+}
+
+// Path returns the S3 prefix the collection's archived rows are written
+// under.
+func (p *ArchivePolicy) Path(collection string) string {
+	return strings.TrimRight(p.Location, "/") + "/" + collection
+}
+
+// LiveFilter returns the filter of the root rows migrated into the
+// collection: its row filter, without the rows its archive policy diverts.
+func (c *Collection) LiveFilter() string {
+	if c.Archive == nil {
+		return c.Filter
+	}
+	return c.Archive.LiveFilter(c.Filter)
+}
+
+// isArchiveType reports whether a column type can date rows for archiving.
+func isArchiveType(dataType string) bool {
+	t := strings.ToLower(dataType)
+	return strings.Contains(t, "date") || strings.Contains(t, "timestamp")
+}
+
+// ValidateArchives checks the archive policy of every collection and, when
+// a schema is given, that its column is a date or timestamp column of the
+// root table.
+func (m *Mapping) ValidateArchives(s *schema.Schema) error {
+	for _, c := range m.Collections {
+		if c.Archive == nil {
+			continue
+		}
+		if err := c.Archive.Validate(); err != nil {
+			return fmt.Errorf("collection %s: %w", c.Name, err)
+		}
+		if s == nil {
+			continue
+		}
+		col := findColumn(s, c.SourceTable, c.Archive.Column)
+		if col == nil {
+			return fmt.Errorf("collection %s: archive column %s not found in %s", c.Name, c.Archive.Column, c.SourceTable)
+		}
+		if !isArchiveType(col.DataType) {
+			return fmt.Errorf("collection %s: archive column %s must be a date or timestamp, not %s", c.Name, c.Archive.Column, col.DataType)
+		}
+	}
+	return nil
+}
+
+// HasArchives reports whether any collection archives old rows.
+func (m *Mapping) HasArchives() bool {
+	for _, c := range m.Collections {
+		if c.Archive != nil {
+			return true
+		}
+	}
+	return false
+}
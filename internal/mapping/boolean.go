@@ -0,0 +1,121 @@
+package mapping
+
+import "github.com/reloquent/reloquent/internal/typemap"
+
+// BooleanTransformation returns the compute transformation that rewrites a
+// boolean-like column in place as a BSON boolean. Naming the column as its
+// source field marks the value as changed for validation.
+func BooleanTransformation(b typemap.BooleanColumn) Transformation {
+	return Transformation{
+		SourceField: b.Column,
+		Operation:   "compute",
+		TargetField: b.Column,
+		Expression:  b.Expression(),
+	}
+}
+
+// ConvertsBoolean reports whether the mapping converts the column to a
+// boolean wherever its table is migrated.
+func (m *Mapping) ConvertsBoolean(b typemap.BooleanColumn) bool {
+	return m.appliesEverywhere(b.Table, BooleanTransformation(b))
+}
+
+// SetBooleanConversion adds the column's boolean transformation to every
+// collection and embedded table migrating its table, or removes it, and
+// returns how many tables were changed.
+func (m *Mapping) SetBooleanConversion(b typemap.BooleanColumn, convert bool) int {
+	return m.setEverywhere(b.Table, BooleanTransformation(b), convert)
+}
+
+// appliesEverywhere reports whether every collection and embedded table
+// migrating table has the transformation, and there is at least one.
+func (m *Mapping) appliesEverywhere(table string, want Transformation) bool {
+	found := false
+	all := true
+	m.eachTable(table, func(ts *[]Transformation) {
+		found = true
+		if !containsTransformation(*ts, want) {
+			all = false
+		}
+	})
+	return found && all
+}
+
+// setEverywhere adds the transformation to every collection and embedded
+// table migrating table, or removes it, and returns how many tables were
+// changed.
+func (m *Mapping) setEverywhere(table string, want Transformation, on bool) int {
+	n := 0
+	m.eachTable(table, func(ts *[]Transformation) {
+		has := containsTransformation(*ts, want)
+		switch {
+		case on && !has:
+			*ts = append(*ts, want)
+			n++
+		case !on && has:
+			out := (*ts)[:0]
+			for _, t := range *ts {
+				if t != want {
+					out = append(out, t)
+				}
+			}
+			*ts = out
+			n++
+		}
+	})
+	return n
+}
+
+// SourceTables returns the tables the mapping migrates, as collections or
+// embedded, each once.
+func (m *Mapping) SourceTables() []string {
+	var out []string
+	seen := make(map[string]bool)
+	add := func(table string) {
+		if !seen[table] {
+			seen[table] = true
+			out = append(out, table)
+		}
+	}
+	var walk func(embs []Embedded)
+	walk = func(embs []Embedded) {
+		for _, e := range embs {
+			add(e.SourceTable)
+			walk(e.Embedded)
+		}
+	}
+	for _, c := range m.Collections {
+		add(c.SourceTable)
+		walk(c.Embedded)
+	}
+	return out
+}
+
+// eachTable calls fn with the transformations of every collection and
+// embedded table migrating table.
+func (m *Mapping) eachTable(table string, fn func(ts *[]Transformation)) {
+	var walk func(embs []Embedded)
+	walk = func(embs []Embedded) {
+		for i := range embs {
+			if embs[i].SourceTable == table {
+				fn(&embs[i].Transformations)
+			}
+			walk(embs[i].Embedded)
+		}
+	}
+	for i := range m.Collections {
+		if m.Collections[i].SourceTable == table {
+			fn(&m.Collections[i].Transformations)
+		}
+		walk(m.Collections[i].Embedded)
+	}
+}
+
+func containsTransformation(ts []Transformation, want Transformation) bool {
+	for _, t := range ts {
+		if t == want {
+			return true
+		}
+	}
+	return false
+}
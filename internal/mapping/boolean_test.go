@@ -0,0 +1,48 @@
+package mapping
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+func TestSetBooleanConversion(t *testing.T) {
+	m := &Mapping{Collections: []Collection{
+		{Name: "users", SourceTable: "users"},
+		{Name: "teams", SourceTable: "teams", Embedded: []Embedded{
+			{SourceTable: "users", FieldName: "members", Transformations: []Transformation{{SourceField: "pw", Operation: "exclude"}}},
+		}},
+	}}
+	b := typemap.BooleanColumn{Table: "users", Column: "active", TrueValues: []string{"Y"}, FalseValues: []string{"N"}}
+
+	if got := m.SourceTables(); !reflect.DeepEqual(got, []string{"users", "teams"}) {
+		t.Errorf("SourceTables = %v", got)
+	}
+	if m.ConvertsBoolean(b) {
+		t.Fatal("converted before accepting")
+	}
+	if n := m.SetBooleanConversion(b, true); n != 2 {
+		t.Errorf("accepting changed %d tables, want 2", n)
+	}
+	if n := m.SetBooleanConversion(b, true); n != 0 {
+		t.Errorf("accepting twice changed %d tables, want 0", n)
+	}
+	if !m.ConvertsBoolean(b) {
+		t.Error("not converted after accepting")
+	}
+	emb := m.Collections[1].Embedded[0].Transformations
+	if len(emb) != 2 || emb[1] != BooleanTransformation(b) {
+		t.Errorf("embedded transformations = %+v", emb)
+	}
+	if f, ok := m.Collections[0].RootField("active"); !ok || f != "active" {
+		t.Errorf("converted column is written to %q, %v", f, ok)
+	}
+
+	if n := m.SetBooleanConversion(b, false); n != 2 || m.ConvertsBoolean(b) {
+		t.Errorf("rejecting changed %d tables, still converted: %v", n, m.ConvertsBoolean(b))
+	}
+	if len(m.Collections[1].Embedded[0].Transformations) != 1 || len(m.Collections[0].Transformations) != 0 {
+		t.Errorf("rejecting left %+v", m.Collections)
+	}
+}
@@ -0,0 +1,248 @@
+package mapping
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// Field name policies decide what happens to columns whose names are not
+// safe document keys; see UnsafeFields.
+const (
+	// FieldNamesRename writes them under a safe name, added to the mapping
+	// as a field mapping. It is the default.
+	FieldNamesRename = "rename"
+	// FieldNamesKeep writes them as they are, for targets that accept them.
+	FieldNamesKeep = "keep"
+	// FieldNamesFail rejects the mapping until each is mapped or excluded.
+	FieldNamesFail = "fail"
+)
+
+// FieldNamePolicies lists the field name policies.
+var FieldNamePolicies = []string{FieldNamesRename, FieldNamesKeep, FieldNamesFail}
+
+// FieldNamePolicy returns the mapping's field name policy, rename unless
+// set.
+func (m *Mapping) FieldNamePolicy() string {
+	if m.FieldNames == "" {
+		return FieldNamesRename
+	}
+	return m.FieldNames
+}
+
+// UnsafeField is a column whose name is not a safe document key: it holds
+// a dot, which MongoDB reads as a path, starts with $, which it reserves for
+// operators, or is _id in a root table where another field is the
+// document's _id or the column does not identify the row. Field is the key
+// it is written as: the safe name when the mapping renames it, the column
+// name when it does not.
+type UnsafeField struct {
+	Collection string `json:"collection"`
+	Path       string `json:"path,omitempty"` // embedded field path; empty for the root table
+	Table      string `json:"table"`
+	Column     string `json:"column"`
+	Problem    string `json:"problem"`
+	Field      string `json:"field"`
+	Renamed    bool   `json:"renamed"`
+}
+
+// Warning describes the field and what it is written as in a line.
+func (f UnsafeField) Warning() string {
+	where := f.Collection
+	if f.Path != "" {
+		where += "." + f.Path
+	}
+	s := fmt.Sprintf("%s: column %s.%s %s", where, f.Table, f.Column, f.Problem)
+	if f.Renamed {
+		return s + "; written as " + f.Field
+	}
+	return s + "; written as is"
+}
+
+// UnsafeFields returns the columns of the mapping's tables, after their
+// transformations, whose names are not safe document keys, in mapping
+// order. Excluded columns and columns grouped under a subdocument are left
+// out; a column with its own field mapping is reported with its target.
+func (m *Mapping) UnsafeFields(s *schema.Schema) []UnsafeField {
+	var out []UnsafeField
+	if s == nil {
+		return out
+	}
+	check := func(collection, path, table string, ts []Transformation, fields []FieldMapping, root bool) {
+		for _, col := range TableColumns(s, table, ts) {
+			problem := unsafeKey(s, table, col, fields, root)
+			if problem == "" {
+				continue
+			}
+			f := UnsafeField{Collection: collection, Path: path, Table: table, Column: col, Problem: problem, Field: col}
+			if target, ok := ownTarget(fields, col); ok {
+				if target == "" {
+					continue // excluded
+				}
+				f.Field, f.Renamed = target, target != col
+			} else if target, ok := FieldTarget(fields, col); !ok || target != col {
+				continue
+			}
+			out = append(out, f)
+		}
+	}
+	var walk func(collection string, embs []Embedded, prefix string)
+	walk = func(collection string, embs []Embedded, prefix string) {
+		for _, e := range embs {
+			path := prefix + e.FieldName
+			check(collection, path, e.SourceTable, e.Transformations, e.Fields, false)
+			walk(collection, e.Embedded, path+".")
+		}
+	}
+	for _, c := range m.Collections {
+		check(c.Name, "", c.SourceTable, c.Transformations, c.Fields, true)
+		walk(c.Name, c.Embedded, "")
+	}
+	return out
+}
+
+// RenameUnsafeFields maps every unsafe field written as is to a safe name,
+// when the mapping's policy renames them, and returns how many were
+// renamed. A safe name taken by another field of the table gets a number.
+func (m *Mapping) RenameUnsafeFields(s *schema.Schema) int {
+	if m.FieldNamePolicy() != FieldNamesRename {
+		return 0
+	}
+	n := 0
+	rename := func(table string, ts []Transformation, fields *[]FieldMapping, unsafe map[string]bool) {
+		if len(unsafe) == 0 {
+			return
+		}
+		taken := make(map[string]bool)
+		for _, col := range TableColumns(s, table, ts) {
+			if path, ok := FieldTarget(*fields, col); ok && !unsafe[col] {
+				taken[path] = true
+			}
+		}
+		cols := make([]string, 0, len(unsafe))
+		for col := range unsafe {
+			cols = append(cols, col)
+		}
+		sort.Strings(cols)
+		for _, col := range cols {
+			name := SafeFieldName(col)
+			for i := 2; taken[name]; i++ {
+				name = fmt.Sprintf("%s_%d", SafeFieldName(col), i)
+			}
+			taken[name] = true
+			*fields = append(*fields, FieldMapping{Column: col, Target: name})
+			n++
+		}
+	}
+
+	// Unsafe fields by collection and embedded path
+	pending := make(map[string]map[string]bool)
+	for _, f := range m.UnsafeFields(s) {
+		if f.Renamed {
+			continue
+		}
+		key := f.Collection + "\x00" + f.Path
+		if pending[key] == nil {
+			pending[key] = make(map[string]bool)
+		}
+		pending[key][f.Column] = true
+	}
+	var walk func(collection string, embs []Embedded, prefix string)
+	walk = func(collection string, embs []Embedded, prefix string) {
+		for i := range embs {
+			e := &embs[i]
+			path := prefix + e.FieldName
+			rename(e.SourceTable, e.Transformations, &e.Fields, pending[collection+"\x00"+path])
+			walk(collection, e.Embedded, path+".")
+		}
+	}
+	for i := range m.Collections {
+		c := &m.Collections[i]
+		rename(c.SourceTable, c.Transformations, &c.Fields, pending[c.Name+"\x00"])
+		walk(c.Name, c.Embedded, "")
+	}
+	return n
+}
+
+// ValidateFieldNames checks the field name policy and, when it fails on
+// unsafe fields, that every one is renamed.
+func (m *Mapping) ValidateFieldNames(s *schema.Schema) error {
+	switch m.FieldNames {
+	case "", FieldNamesRename, FieldNamesKeep:
+		return nil
+	case FieldNamesFail:
+	default:
+		return fmt.Errorf("unknown field name policy %q (use rename, keep or fail)", m.FieldNames)
+	}
+	var unsafe []string
+	for _, f := range m.UnsafeFields(s) {
+		if !f.Renamed {
+			unsafe = append(unsafe, f.Warning())
+		}
+	}
+	if len(unsafe) > 0 {
+		return fmt.Errorf("columns are not safe field names; map them to another target or exclude them: %s", strings.Join(unsafe, "; "))
+	}
+	return nil
+}
+
+// SafeFieldName returns a name for a column that is a safe document key:
+// dots become underscores, leading dollar signs become underscores, and _id
+// becomes source_id.
+func SafeFieldName(column string) string {
+	if column == "_id" {
+		return "source_id"
+	}
+	name := strings.ReplaceAll(column, ".", "_")
+	if trimmed := strings.TrimLeft(name, "$"); trimmed != name {
+		name = strings.Repeat("_", len(name)-len(trimmed)) + trimmed
+	}
+	return name
+}
+
+// unsafeKey returns why a column's name is not a safe key of the table's
+// documents, or "" if it is.
+func unsafeKey(s *schema.Schema, table, column string, fields []FieldMapping, root bool) string {
+	switch {
+	case strings.Contains(column, "."):
+		return "contains a dot, which MongoDB reads as a path"
+	case strings.HasPrefix(column, "$"):
+		return "starts with $, which MongoDB reserves for operators"
+	case root && column == "_id":
+		for _, f := range fields {
+			if f.Prefix == "" && f.Column != column && !f.Exclude && f.TargetPath() == "_id" {
+				return "collides with " + f.Column + ", which is written as the document _id"
+			}
+		}
+		if !soleKey(s, table, column) {
+			return "is written as the document _id but is not the table's primary key"
+		}
+	}
+	return ""
+}
+
+// soleKey reports whether the column is the whole primary key of the table.
+func soleKey(s *schema.Schema, table, column string) bool {
+	for _, t := range s.Tables {
+		if t.Name == table {
+			return t.PrimaryKey != nil && len(t.PrimaryKey.Columns) == 1 && t.PrimaryKey.Columns[0] == column
+		}
+	}
+	return false
+}
+
+// ownTarget returns the target of the column's own field mapping, "" when
+// it is excluded, and false when it has none.
+func ownTarget(fields []FieldMapping, column string) (string, bool) {
+	for _, f := range fields {
+		if f.Prefix == "" && f.Column == column {
+			if f.Exclude {
+				return "", true
+			}
+			return f.TargetPath(), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,130 @@
+package mapping
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func fieldNamesFixture() (*schema.Schema, *Mapping) {
+	cols := func(names ...string) []schema.Column {
+		out := make([]schema.Column, len(names))
+		for i, n := range names {
+			out[i] = schema.Column{Name: n, DataType: "text"}
+		}
+		return out
+	}
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "orders", Columns: cols("id", "_id", "unit.price", "unit_price", "$note", "skip.me"),
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}}},
+		{Name: "lines", Columns: cols("order_id", "_id", "qty.ordered")},
+		{Name: "legacy", Columns: cols("_id", "name"),
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"_id"}}},
+	}}
+	m := &Mapping{Collections: []Collection{
+		{
+			Name: "orders", SourceTable: "orders",
+			Fields:   []FieldMapping{{Column: "id", Target: "_id"}, {Column: "skip.me", Exclude: true}},
+			Embedded: []Embedded{{SourceTable: "lines", FieldName: "lines", Relationship: "array", JoinColumn: "order_id", ParentColumn: "id"}},
+		},
+		{Name: "legacy", SourceTable: "legacy"},
+	}}
+	return s, m
+}
+
+func TestUnsafeFields(t *testing.T) {
+	s, m := fieldNamesFixture()
+	got := m.UnsafeFields(s)
+	want := []struct{ path, column, problem string }{
+		{"", "_id", "collides with id"},
+		{"", "unit.price", "contains a dot"},
+		{"", "$note", "starts with $"},
+		{"lines", "qty.ordered", "contains a dot"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("UnsafeFields = %+v, want %d fields", got, len(want))
+	}
+	for i, w := range want {
+		f := got[i]
+		if f.Path != w.path || f.Column != w.column || !strings.HasPrefix(f.Problem, w.problem) || f.Renamed || f.Field != w.column {
+			t.Errorf("field %d = %+v, want %s %s (%s) written as is", i, f, w.path, w.column, w.problem)
+		}
+	}
+	if w := got[3].Warning(); w != "orders.lines: column lines.qty.ordered contains a dot, which MongoDB reads as a path; written as is" {
+		t.Errorf("Warning() = %q", w)
+	}
+}
+
+func TestRenameUnsafeFields(t *testing.T) {
+	s, m := fieldNamesFixture()
+	if n := m.RenameUnsafeFields(s); n != 4 {
+		t.Fatalf("RenameUnsafeFields renamed %d, want 4", n)
+	}
+	renamed := make(map[string]string)
+	for _, f := range m.UnsafeFields(s) {
+		if !f.Renamed {
+			t.Errorf("%s.%s is still written as is", f.Table, f.Column)
+		}
+		renamed[f.Table+"."+f.Column] = f.Field
+	}
+	for col, want := range map[string]string{
+		"orders._id":        "source_id",
+		"orders.unit.price": "unit_price_2", // unit_price is another column
+		"orders.$note":      "_note",
+		"lines.qty.ordered": "qty_ordered",
+	} {
+		if renamed[col] != want {
+			t.Errorf("%s written as %q, want %q", col, renamed[col], want)
+		}
+	}
+	if err := m.ValidateFields(s); err != nil {
+		t.Errorf("renamed mapping is invalid: %v", err)
+	}
+	if n := m.RenameUnsafeFields(s); n != 0 {
+		t.Errorf("renaming twice renamed %d", n)
+	}
+}
+
+func TestFieldNamePolicies(t *testing.T) {
+	s, m := fieldNamesFixture()
+	m.FieldNames = FieldNamesKeep
+	if n := m.RenameUnsafeFields(s); n != 0 {
+		t.Errorf("keep policy renamed %d fields", n)
+	}
+	if err := m.ValidateFieldNames(s); err != nil {
+		t.Errorf("keep policy: %v", err)
+	}
+
+	m.FieldNames = FieldNamesFail
+	err := m.ValidateFieldNames(s)
+	if err == nil || !strings.Contains(err.Error(), "orders.lines: column lines.qty.ordered") {
+		t.Fatalf("fail policy error = %v, want every unsafe field listed", err)
+	}
+	m.FieldNames = ""
+	m.RenameUnsafeFields(s)
+	m.FieldNames = FieldNamesFail
+	if err := m.ValidateFieldNames(s); err != nil {
+		t.Errorf("fail policy with every field renamed: %v", err)
+	}
+
+	m.FieldNames = "drop"
+	if err := m.ValidateFieldNames(s); err == nil {
+		t.Error("expected error for an unknown policy")
+	}
+}
+
+func TestSafeFieldName(t *testing.T) {
+	for in, want := range map[string]string{
+		"_id":       "source_id",
+		"a.b.c":     "a_b_c",
+		"$price":    "_price",
+		"$$x.y":     "__x_y",
+		"plain":     "plain",
+		"cost$item": "cost$item",
+	} {
+		if got := SafeFieldName(in); got != want {
+			t.Errorf("SafeFieldName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
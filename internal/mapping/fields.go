@@ -0,0 +1,377 @@
+package mapping
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// FieldMapping maps one source column to a document field. Target may be a
+// dotted path (e.g. address.street) to nest the value in a subdocument, and
+// Exclude leaves the column out of the document entirely. Columns without a
+// field mapping keep their names. Field mappings apply after the table's
+// transformations, so a column renamed by a transformation is referred to by
+// its new name.
+//
+// A mapping with Prefix instead of Column groups every column starting with
+// the prefix under the Target subdocument, without the prefix: prefix
+// address_ and target address write address_street to address.street. A
+// column's own mapping takes precedence over a group, and the longest
+// matching prefix wins.
+type FieldMapping struct {
+	Column  string `yaml:"column,omitempty" json:"column"`
+	Prefix  string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	Target  string `yaml:"target,omitempty" json:"target,omitempty"`
+	Exclude bool   `yaml:"exclude,omitempty" json:"exclude,omitempty"`
+}
+
+// TargetPath returns the document path the column is written to, or for a
+// group the subdocument its columns are written to.
+func (f FieldMapping) TargetPath() string {
+	if f.Target == "" {
+		return f.Column
+	}
+	return f.Target
+}
+
+// FieldTarget returns the document path a column is written to, and false if
+// the column is excluded.
+func FieldTarget(fields []FieldMapping, column string) (string, bool) {
+	var group *FieldMapping
+	for i, f := range fields {
+		if f.Prefix == "" {
+			if f.Column == column {
+				if f.Exclude {
+					return "", false
+				}
+				return f.TargetPath(), true
+			}
+			continue
+		}
+		if len(column) > len(f.Prefix) && strings.HasPrefix(column, f.Prefix) &&
+			(group == nil || len(f.Prefix) > len(group.Prefix)) {
+			group = &fields[i]
+		}
+	}
+	if group != nil {
+		if group.Exclude {
+			return "", false
+		}
+		return group.Target + "." + strings.TrimPrefix(column, group.Prefix), true
+	}
+	return column, true
+}
+
+// OrderedKeys orders the field names of the document, or of the
+// subdocument at path ("" for the document itself): fields whose full paths
+// are listed in order come first, in that order, and the rest follow by
+// name.
+func OrderedKeys(keys, order []string, path string) []string {
+	rank := make(map[string]int, len(order))
+	for i, p := range order {
+		rank[p] = i
+	}
+	full := func(k string) string {
+		if path == "" {
+			return k
+		}
+		return path + "." + k
+	}
+	out := append([]string(nil), keys...)
+	sort.Slice(out, func(a, b int) bool {
+		ra, aok := rank[full(out[a])]
+		rb, bok := rank[full(out[b])]
+		switch {
+		case aok && bok:
+			return ra < rb
+		case aok != bok:
+			return aok
+		default:
+			return out[a] < out[b]
+		}
+	})
+	return out
+}
+
+// RootField returns the document path a column of the collection's source
+// table is written to after its transformations and field mappings, and false
+// if the column is excluded.
+func (c *Collection) RootField(column string) (string, bool) {
+	return transformedField(c.Transformations, c.Fields, column)
+}
+
+// SubField returns the path, relative to the embedded subdocument, that a
+// column of the embedded table is written to, and false if it is excluded.
+func (e *Embedded) SubField(column string) (string, bool) {
+	return transformedField(e.Transformations, e.Fields, column)
+}
+
+func transformedField(ts []Transformation, fields []FieldMapping, column string) (string, bool) {
+	for _, t := range ts {
+		if t.SourceField != column {
+			continue
+		}
+		switch t.Operation {
+		case "exclude":
+			return "", false
+		case "rename":
+			column = t.TargetField
+		}
+	}
+	return FieldTarget(fields, column)
+}
+
+// ValidateFields checks a table's field mappings. When columns is non-nil,
+// every mapped column must exist, every group must match a column, and no
+// target may collide with a column that keeps its name.
+func ValidateFields(fields []FieldMapping, columns []string) error {
+	mapped := make(map[string]bool, len(fields))
+	prefixes := make(map[string]bool)
+	var targets []string
+	for _, f := range fields {
+		if f.Prefix != "" {
+			if f.Column != "" {
+				return fmt.Errorf("field mapping for %s: set a column or a prefix, not both", f.Column)
+			}
+			if prefixes[f.Prefix] {
+				return fmt.Errorf("prefix %s is grouped more than once", f.Prefix)
+			}
+			prefixes[f.Prefix] = true
+			if f.Exclude {
+				if f.Target != "" {
+					return fmt.Errorf("prefix %s: cannot both exclude and set a target", f.Prefix)
+				}
+				continue
+			}
+			if f.Target == "" {
+				return fmt.Errorf("prefix %s: target is required", f.Prefix)
+			}
+			if err := validatePath(f.Target); err != nil {
+				return fmt.Errorf("prefix %s: %w", f.Prefix, err)
+			}
+			continue
+		}
+		if f.Column == "" {
+			return fmt.Errorf("field mapping: column is required")
+		}
+		if mapped[f.Column] {
+			return fmt.Errorf("column %s is mapped more than once", f.Column)
+		}
+		mapped[f.Column] = true
+		if f.Exclude {
+			if f.Target != "" {
+				return fmt.Errorf("column %s: cannot both exclude and set a target", f.Column)
+			}
+			continue
+		}
+		if err := validatePath(f.TargetPath()); err != nil {
+			return fmt.Errorf("column %s: %w", f.Column, err)
+		}
+		targets = append(targets, f.TargetPath())
+	}
+
+	if columns != nil {
+		known := make(map[string]bool, len(columns))
+		for _, c := range columns {
+			known[c] = true
+		}
+		for _, f := range fields {
+			if f.Prefix == "" && !known[f.Column] {
+				return fmt.Errorf("column %s does not exist", f.Column)
+			}
+		}
+		for prefix := range prefixes {
+			matched := false
+			for _, c := range columns {
+				if len(c) > len(prefix) && strings.HasPrefix(c, prefix) {
+					matched = true
+				}
+			}
+			if !matched {
+				return fmt.Errorf("no column starts with prefix %s", prefix)
+			}
+		}
+		for _, c := range columns {
+			if mapped[c] {
+				continue
+			}
+			if path, ok := FieldTarget(fields, c); ok {
+				targets = append(targets, path)
+			}
+		}
+	}
+
+	// Two fields may not share a path, and one may not nest under another
+	seen := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		if seen[t] {
+			return fmt.Errorf("more than one column maps to %s", t)
+		}
+		seen[t] = true
+	}
+	for _, t := range targets {
+		for prefix := range seen {
+			if strings.HasPrefix(t, prefix+".") {
+				return fmt.Errorf("%s cannot be nested under %s, which holds a value", t, prefix)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateFieldOrder checks that a field order names valid paths, each once.
+func ValidateFieldOrder(order []string) error {
+	seen := make(map[string]bool, len(order))
+	for _, p := range order {
+		if err := validatePath(p); err != nil {
+			return fmt.Errorf("field order: %w", err)
+		}
+		if seen[p] {
+			return fmt.Errorf("field order lists %s more than once", p)
+		}
+		seen[p] = true
+	}
+	return nil
+}
+
+func validatePath(path string) error {
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			return fmt.Errorf("invalid target path %q", path)
+		}
+		if strings.HasPrefix(part, "$") {
+			return fmt.Errorf("target path %q may not start a field with $", path)
+		}
+	}
+	return nil
+}
+
+// ValidateFields checks the field mappings of every collection and embedded
+// table. Columns are checked against the schema, after the table's
+// transformations, when one is given.
+func (m *Mapping) ValidateFields(s *schema.Schema) error {
+	columnsOf := func(table string, ts []Transformation) []string {
+		return TableColumns(s, table, ts)
+	}
+
+	var checkEmbedded func(path string, embs []Embedded) error
+	checkEmbedded = func(path string, embs []Embedded) error {
+		for _, e := range embs {
+			p := path + "." + e.FieldName
+			if err := ValidateFields(e.Fields, columnsOf(e.SourceTable, e.Transformations)); err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			if err := ValidateFieldOrder(e.FieldOrder); err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			if err := checkEmbedded(p, e.Embedded); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, c := range m.Collections {
+		if err := ValidateFields(c.Fields, columnsOf(c.SourceTable, c.Transformations)); err != nil {
+			return fmt.Errorf("collection %s: %w", c.Name, err)
+		}
+		if err := ValidateFieldOrder(c.FieldOrder); err != nil {
+			return fmt.Errorf("collection %s: %w", c.Name, err)
+		}
+		if err := checkEmbedded(c.Name, c.Embedded); err != nil {
+			return fmt.Errorf("collection %w", err)
+		}
+	}
+	return nil
+}
+
+// TableColumns returns the columns of a table in the schema once the
+// transformations have run, or nil if the schema is nil or lacks the table.
+func TableColumns(s *schema.Schema, table string, ts []Transformation) []string {
+	if s == nil {
+		return nil
+	}
+	for _, t := range s.Tables {
+		if t.Name == table {
+			cols := make([]string, len(t.Columns))
+			for i, c := range t.Columns {
+				cols[i] = c.Name
+			}
+			return transformedColumns(cols, ts)
+		}
+	}
+	return nil
+}
+
+// transformedColumns returns the columns a table has once its
+// transformations have run: computed columns are added, then renames and
+// excludes are applied, matching the order transformations execute in.
+func transformedColumns(cols []string, ts []Transformation) []string {
+	for _, t := range ts {
+		if t.Operation == "compute" && !contains(cols, t.TargetField) {
+			cols = append(cols, t.TargetField)
+		}
+	}
+	for _, t := range ts {
+		if t.Operation != "rename" {
+			continue
+		}
+		for i, c := range cols {
+			if c == t.SourceField {
+				cols[i] = t.TargetField
+			}
+		}
+	}
+	out := cols[:0]
+	for _, c := range cols {
+		excluded := false
+		for _, t := range ts {
+			if t.Operation == "exclude" && t.SourceField == c {
+				excluded = true
+			}
+		}
+		if !excluded {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// ApplyFields rewrites a row according to field mappings: excluded columns
+// are dropped, and mapped or grouped columns are moved to their target
+// paths, creating subdocuments for dotted paths. Other columns are left as
+// they are.
+func ApplyFields(row map[string]interface{}, fields []FieldMapping) map[string]interface{} {
+	if len(fields) == 0 {
+		return row
+	}
+	moved := make(map[string]interface{}, len(fields))
+	for col, v := range row {
+		path, ok := FieldTarget(fields, col)
+		if ok && path == col {
+			continue
+		}
+		delete(row, col)
+		if ok {
+			moved[path] = v
+		}
+	}
+	for path, v := range moved {
+		setPath(row, strings.Split(path, "."), v)
+	}
+	return row
+}
+
+func setPath(doc map[string]interface{}, parts []string, v interface{}) {
+	for _, p := range parts[:len(parts)-1] {
+		child, ok := doc[p].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			doc[p] = child
+		}
+		doc = child
+	}
+	doc[parts[len(parts)-1]] = v
+}
@@ -0,0 +1,77 @@
+package mapping
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateFilter checks a row filter: a SQL predicate over one source
+// table's columns, such as "status <> 'deleted'". The filter is placed in the
+// WHERE clause of every read of the table, in the source database's dialect,
+// so predicates that could end or comment out that query are rejected.
+func ValidateFilter(filter string) error {
+	for _, tok := range []string{";", "--", "/*", "*/"} {
+		if strings.Contains(filter, tok) {
+			return fmt.Errorf("filter must be a single predicate without %q", tok)
+		}
+	}
+	if strings.Count(filter, "'")%2 != 0 {
+		return fmt.Errorf("filter has an unterminated string literal")
+	}
+	return nil
+}
+
+// ValidateFilters checks the filter of every collection and embedded table.
+func (m *Mapping) ValidateFilters() error {
+	for _, col := range m.Collections {
+		if err := ValidateFilter(col.Filter); err != nil {
+			return fmt.Errorf("collection %s: %w", col.Name, err)
+		}
+		if err := validateEmbeddedFilters(col.Embedded); err != nil {
+			return fmt.Errorf("collection %s: %w", col.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateEmbeddedFilters(embedded []Embedded) error {
+	for _, emb := range embedded {
+		if err := ValidateFilter(emb.Filter); err != nil {
+			return fmt.Errorf("embedded %s: %w", emb.SourceTable, err)
+		}
+		if err := validateEmbeddedFilters(emb.Embedded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FilteredTable returns a SQL table expression that reads only the rows of
+// table that match filter, aliased back to the table's own name so columns
+// can be referenced as before. Without a filter it returns table unchanged.
+func FilteredTable(table, filter string) string {
+	if filter == "" {
+		return table
+	}
+	return SelectTable(table, nil, filter)
+}
+
+// SelectTable returns a SQL table expression that reads the given column
+// expressions, or every column when there are none, from the rows of table
+// that match filter (all rows if it is empty). Like FilteredTable, it is
+// aliased back to the table's own name.
+func SelectTable(table string, columns []string, filter string) string {
+	list := "*"
+	if len(columns) > 0 {
+		list = strings.Join(columns, ", ")
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", list, table)
+	if filter != "" {
+		query += " WHERE " + filter
+	}
+	alias := table
+	if i := strings.LastIndex(alias, "."); i >= 0 {
+		alias = alias[i+1:]
+	}
+	return fmt.Sprintf("(%s) %s", query, alias)
+}
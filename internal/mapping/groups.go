@@ -0,0 +1,111 @@
+package mapping
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ConsistencyGroup names collections the application reads together. They
+// are migrated back to back from one source snapshot, so references
+// between them resolve in the target as they did in the source.
+type ConsistencyGroup struct {
+	Name        string   `yaml:"name" json:"name"`
+	Collections []string `yaml:"collections" json:"collections"`
+}
+
+// ValidateConsistencyGroups checks that every group is named, has at least
+// two of the mapping's collections, and that no collection is in two
+// groups.
+func (m *Mapping) ValidateConsistencyGroups() error {
+	names := make(map[string]bool, len(m.Collections))
+	for _, c := range m.Collections {
+		names[c.Name] = true
+	}
+	groups := make(map[string]bool, len(m.ConsistencyGroups))
+	member := make(map[string]string)
+	for _, g := range m.ConsistencyGroups {
+		if g.Name == "" {
+			return fmt.Errorf("consistency group needs a name")
+		}
+		if groups[g.Name] {
+			return fmt.Errorf("consistency group %s is defined twice", g.Name)
+		}
+		groups[g.Name] = true
+		if len(g.Collections) < 2 {
+			return fmt.Errorf("consistency group %s needs at least two collections", g.Name)
+		}
+		for _, c := range g.Collections {
+			if !names[c] {
+				return fmt.Errorf("consistency group %s: collection %s not found in mapping", g.Name, c)
+			}
+			if other, ok := member[c]; ok {
+				return fmt.Errorf("consistency group %s: collection %s is already in group %s", g.Name, c, other)
+			}
+			member[c] = g.Name
+		}
+	}
+	return nil
+}
+
+// GroupOf returns the consistency group a collection is in, or nil.
+func (m *Mapping) GroupOf(collection string) *ConsistencyGroup {
+	for i, g := range m.ConsistencyGroups {
+		for _, c := range g.Collections {
+			if c == collection {
+				return &m.ConsistencyGroups[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ScheduleOrder returns cols in the order they are migrated: stage by
+// stage, as Stages splits them, so that a collection follows the
+// collections it depends on and the members of a consistency group follow
+// each other. Members not in cols are left out. A mapping whose
+// dependencies form a cycle, which ValidateOrder rejects, keeps the order
+// of cols with only the groups brought together.
+func (m *Mapping) ScheduleOrder(cols []Collection) []Collection {
+	if stages, err := m.Stages(cols); err == nil {
+		return slices.Concat(stages...)
+	}
+	if len(m.ConsistencyGroups) == 0 {
+		return cols
+	}
+	byName := make(map[string]Collection, len(cols))
+	for _, c := range cols {
+		byName[c.Name] = c
+	}
+	ordered := make([]Collection, 0, len(cols))
+	placed := make(map[string]bool, len(cols))
+	for _, c := range cols {
+		if placed[c.Name] {
+			continue
+		}
+		g := m.GroupOf(c.Name)
+		if g == nil {
+			ordered = append(ordered, c)
+			placed[c.Name] = true
+			continue
+		}
+		for _, name := range g.Collections {
+			if member, ok := byName[name]; ok && !placed[name] {
+				ordered = append(ordered, member)
+				placed[name] = true
+			}
+		}
+	}
+	return ordered
+}
+
+// GroupTables returns the source tables the collections of every
+// consistency group read.
+func (m *Mapping) GroupTables() []string {
+	members := &Mapping{}
+	for _, c := range m.Collections {
+		if m.GroupOf(c.Name) != nil {
+			members.Collections = append(members.Collections, c)
+		}
+	}
+	return members.SourceTables()
+}
@@ -0,0 +1,63 @@
+package mapping
+
+import (
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// How the application makes the ids of new documents once the source
+// sequence or identity column that made them is gone.
+const (
+	IDCounter  = "counter"  // take the next id from a counters collection seeded with the highest migrated one
+	IDObjectID = "objectid" // identify new documents by an ObjectId _id; migrated ones keep their ids
+)
+
+// IDGenerations lists the supported ID generation strategies.
+var IDGenerations = []string{IDCounter, IDObjectID}
+
+// SequenceKey returns the single column primary key of the collection's root
+// table and whether it is filled from a sequence or identity column.
+func (c *Collection) SequenceKey(s *schema.Schema) (*schema.Column, bool) {
+	if s == nil {
+		return nil, false
+	}
+	for _, t := range s.Tables {
+		if t.Name != c.SourceTable {
+			continue
+		}
+		if t.PrimaryKey == nil || len(t.PrimaryKey.Columns) != 1 {
+			return nil, false
+		}
+		col := findColumn(s, t.Name, t.PrimaryKey.Columns[0])
+		return col, col != nil && col.IsSequence
+	}
+	return nil, false
+}
+
+// ValidateIDGeneration checks that every collection's ID generation strategy
+// is supported and, when a schema is given, that a counter replaces a
+// sequence primary key that is still migrated.
+func (m *Mapping) ValidateIDGeneration(s *schema.Schema) error {
+	for i := range m.Collections {
+		c := &m.Collections[i]
+		switch c.IDGeneration {
+		case "", IDObjectID:
+			continue
+		case IDCounter:
+		default:
+			return fmt.Errorf("collection %s: unsupported id_generation %q (use counter or objectid)", c.Name, c.IDGeneration)
+		}
+		if s == nil {
+			continue
+		}
+		col, ok := c.SequenceKey(s)
+		if !ok {
+			return fmt.Errorf("collection %s: a counter needs a primary key filled from a sequence or identity column", c.Name)
+		}
+		if _, ok := c.RootField(col.Name); !ok {
+			return fmt.Errorf("collection %s: primary key %s is excluded from the documents", c.Name, col.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package mapping
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JoinKeys returns the columns of the embedded table matched to the
+// parent's ParentKeys, in order: JoinColumns for a composite key, or else
+// JoinColumn, which older mappings hold as a comma-joined list.
+func (e *Embedded) JoinKeys() []string {
+	return joinKeys(e.JoinColumns, e.JoinColumn)
+}
+
+// ParentKeys returns the parent columns the embedded table joins on, in the
+// order of JoinKeys.
+func (e *Embedded) ParentKeys() []string {
+	return joinKeys(e.ParentColumns, e.ParentColumn)
+}
+
+// SetJoin sets the join columns: JoinColumn and ParentColumn for a single
+// column, JoinColumns and ParentColumns for a composite key.
+func (e *Embedded) SetJoin(join, parent []string) {
+	e.JoinColumn, e.ParentColumn, e.JoinColumns, e.ParentColumns = setJoin(join, parent)
+}
+
+// JoinKeys returns the columns of the referenced table matched to the
+// collection's ParentKeys, in order; see Embedded.JoinKeys.
+func (r *Reference) JoinKeys() []string {
+	return joinKeys(r.JoinColumns, r.JoinColumn)
+}
+
+// ParentKeys returns the collection's columns the reference joins on, in
+// the order of JoinKeys.
+func (r *Reference) ParentKeys() []string {
+	return joinKeys(r.ParentColumns, r.ParentColumn)
+}
+
+// SetJoin sets the join columns as Embedded.SetJoin does.
+func (r *Reference) SetJoin(join, parent []string) {
+	r.JoinColumn, r.ParentColumn, r.JoinColumns, r.ParentColumns = setJoin(join, parent)
+}
+
+func joinKeys(cols []string, col string) []string {
+	if len(cols) > 0 {
+		return cols
+	}
+	var out []string
+	for _, c := range strings.Split(col, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func setJoin(join, parent []string) (string, string, []string, []string) {
+	if len(join) == 1 && len(parent) == 1 {
+		return join[0], parent[0], nil, nil
+	}
+	return "", "", append([]string(nil), join...), append([]string(nil), parent...)
+}
+
+// checkJoin reports a join whose key is missing, given both ways, or whose
+// two sides have different numbers of columns.
+func checkJoin(join, parent []string, joinColumn, parentColumn string, joinColumns, parentColumns []string) error {
+	if (joinColumn != "" && len(joinColumns) > 0) || (parentColumn != "" && len(parentColumns) > 0) {
+		return fmt.Errorf("set join_column and parent_column or join_columns and parent_columns, not both")
+	}
+	if len(join) == 0 || len(parent) == 0 {
+		return fmt.Errorf("join and parent columns are required")
+	}
+	if len(join) != len(parent) {
+		return fmt.Errorf("%d join columns but %d parent columns", len(join), len(parent))
+	}
+	return nil
+}
+
+// ValidateJoins checks the join columns of every embedded table and
+// reference.
+func (m *Mapping) ValidateJoins() error {
+	var walk func(path string, embs []Embedded) error
+	walk = func(path string, embs []Embedded) error {
+		for _, e := range embs {
+			p := path + "." + e.FieldName
+			if err := checkJoin(e.JoinKeys(), e.ParentKeys(), e.JoinColumn, e.ParentColumn, e.JoinColumns, e.ParentColumns); err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			if err := walk(p, e.Embedded); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, c := range m.Collections {
+		if err := walk(c.Name, c.Embedded); err != nil {
+			return err
+		}
+		for _, r := range c.References {
+			if err := checkJoin(r.JoinKeys(), r.ParentKeys(), r.JoinColumn, r.ParentColumn, r.JoinColumns, r.ParentColumns); err != nil {
+				return fmt.Errorf("%s.%s: %w", c.Name, r.FieldName, err)
+			}
+		}
+	}
+	return nil
+}
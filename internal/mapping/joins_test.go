@@ -0,0 +1,89 @@
+package mapping
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJoinKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		emb        Embedded
+		join, pcol []string
+	}{
+		{"single", Embedded{JoinColumn: "order_id", ParentColumn: "id"}, []string{"order_id"}, []string{"id"}},
+		{"composite", Embedded{JoinColumns: []string{"tenant_id", "order_id"}, ParentColumns: []string{"tenant_id", "id"}},
+			[]string{"tenant_id", "order_id"}, []string{"tenant_id", "id"}},
+		{"comma-joined", Embedded{JoinColumn: "tenant_id, order_id", ParentColumn: "tenant_id,id"},
+			[]string{"tenant_id", "order_id"}, []string{"tenant_id", "id"}},
+		{"unset", Embedded{}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.emb.JoinKeys(); !reflect.DeepEqual(got, tt.join) {
+				t.Errorf("JoinKeys = %v, want %v", got, tt.join)
+			}
+			if got := tt.emb.ParentKeys(); !reflect.DeepEqual(got, tt.pcol) {
+				t.Errorf("ParentKeys = %v, want %v", got, tt.pcol)
+			}
+		})
+	}
+}
+
+func TestSetJoin(t *testing.T) {
+	var e Embedded
+	e.SetJoin([]string{"tenant_id", "order_id"}, []string{"tenant_id", "id"})
+	if e.JoinColumn != "" || e.ParentColumn != "" || len(e.JoinColumns) != 2 || len(e.ParentColumns) != 2 {
+		t.Errorf("composite join set as %+v", e)
+	}
+	e.SetJoin([]string{"order_id"}, []string{"id"})
+	if e.JoinColumn != "order_id" || e.ParentColumn != "id" || e.JoinColumns != nil || e.ParentColumns != nil {
+		t.Errorf("single join set as %+v", e)
+	}
+
+	var r Reference
+	r.SetJoin([]string{"a", "b"}, []string{"x", "y"})
+	if !reflect.DeepEqual(r.JoinKeys(), []string{"a", "b"}) || !reflect.DeepEqual(r.ParentKeys(), []string{"x", "y"}) {
+		t.Errorf("reference join set as %+v", r)
+	}
+}
+
+func TestValidateJoins(t *testing.T) {
+	valid := Embedded{FieldName: "items", JoinColumns: []string{"tenant_id", "order_id"}, ParentColumns: []string{"tenant_id", "id"}}
+	tests := []struct {
+		name    string
+		emb     Embedded
+		ref     Reference
+		wantErr string
+	}{
+		{"valid", valid, Reference{FieldName: "owner", JoinColumn: "id", ParentColumn: "owner_id"}, ""},
+		{"missing", Embedded{FieldName: "items"}, Reference{FieldName: "owner", JoinColumn: "id", ParentColumn: "owner_id"}, "orders.items: join and parent columns are required"},
+		{"both forms", Embedded{FieldName: "items", JoinColumn: "order_id", JoinColumns: []string{"order_id"}, ParentColumns: []string{"id"}},
+			Reference{FieldName: "owner", JoinColumn: "id", ParentColumn: "owner_id"}, "not both"},
+		{"count mismatch", Embedded{FieldName: "items", JoinColumns: []string{"tenant_id", "order_id"}, ParentColumns: []string{"id"}},
+			Reference{FieldName: "owner", JoinColumn: "id", ParentColumn: "owner_id"}, "2 join columns but 1 parent columns"},
+		{"nested", Embedded{FieldName: "items", JoinColumn: "order_id", ParentColumn: "id", Embedded: []Embedded{{FieldName: "notes", JoinColumn: "item_id"}}},
+			Reference{FieldName: "owner", JoinColumn: "id", ParentColumn: "owner_id"}, "orders.items.notes:"},
+		{"reference", valid, Reference{FieldName: "owner", JoinColumns: []string{"a", "b"}, ParentColumns: []string{"x"}}, "orders.owner:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapping{Collections: []Collection{{
+				Name: "orders", SourceTable: "orders",
+				Embedded:   []Embedded{tt.emb},
+				References: []Reference{tt.ref},
+			}}}
+			err := m.ValidateJoins()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package mapping
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// DefaultMaxDepth is how deeply tables may be embedded when the mapping
+// sets no MaxDepth: a table embedded in the root table is at depth 1.
+const DefaultMaxDepth = 3
+
+// Issue severities. An error stops the mapping from being saved or
+// generated; a warning does not.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue codes, one per kind of problem Validate finds.
+const (
+	IssueMissingJoin     = "missing_join"
+	IssueUnselectedTable = "unselected_table"
+	IssueDuplicateField  = "duplicate_field"
+	IssueTooDeep         = "too_deep"
+	IssueUnindexedJoin   = "unindexed_join"
+)
+
+// Issue is a problem Validate found in a mapping. Path is the embedded or
+// reference field it concerns, dotted from the collection, and empty for
+// the collection itself.
+type Issue struct {
+	Severity   string `json:"severity"`
+	Code       string `json:"code"`
+	Collection string `json:"collection"`
+	Path       string `json:"path,omitempty"`
+	Message    string `json:"message"`
+}
+
+// String describes the issue in a line, prefixed with where it is.
+func (i Issue) String() string {
+	where := i.Collection
+	if i.Path != "" {
+		where += "." + i.Path
+	}
+	return where + ": " + i.Message
+}
+
+// Issues are the problems found in a mapping, in mapping order.
+type Issues []Issue
+
+// Errors returns the issues of error severity.
+func (is Issues) Errors() Issues {
+	var out Issues
+	for _, i := range is {
+		if i.Severity == SeverityError {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// Warnings returns the issues of warning severity.
+func (is Issues) Warnings() Issues {
+	var out Issues
+	for _, i := range is {
+		if i.Severity == SeverityWarning {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// Err returns a *LintError holding the errors, or nil if there are none.
+func (is Issues) Err() error {
+	if errs := is.Errors(); len(errs) > 0 {
+		return &LintError{Issues: errs}
+	}
+	return nil
+}
+
+// LintError is a mapping that failed Validate, with every error found.
+type LintError struct {
+	Issues Issues
+}
+
+func (e *LintError) Error() string {
+	lines := make([]string, len(e.Issues))
+	for i, is := range e.Issues {
+		lines[i] = is.String()
+	}
+	return strings.Join(lines, "; ")
+}
+
+// MaxEmbedDepth returns how deeply the mapping lets tables be embedded.
+func (m *Mapping) MaxEmbedDepth() int {
+	if m.MaxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+	return m.MaxDepth
+}
+
+// Validate checks a mapping against the schema of the tables selected for
+// migration, before code is generated from it, and returns every problem
+// found:
+//
+//   - embedded tables and references without join columns, or joined on
+//     columns their tables do not have (errors)
+//   - tables that are not in the schema, because they were not selected
+//     (errors)
+//   - document fields written twice once tables are embedded: an embedded
+//     field with the name of a column's field or of another embedded field
+//     (errors)
+//   - tables embedded deeper than the mapping's MaxEmbedDepth (warnings)
+//   - joins on columns no index of the joined table leads with, which
+//     read the whole table for each parent (warnings)
+//
+// Checks that need the schema are skipped when it is nil.
+func Validate(s *schema.Schema, m *Mapping) Issues {
+	var out Issues
+	if m == nil {
+		return out
+	}
+	tables := make(map[string]*schema.Table)
+	if s != nil {
+		for i := range s.Tables {
+			tables[s.Tables[i].Name] = &s.Tables[i]
+		}
+	}
+	add := func(severity, code, collection, path, format string, args ...interface{}) {
+		out = append(out, Issue{Severity: severity, Code: code, Collection: collection, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	// selected reports whether the table is in the schema, and an error if
+	// it is not
+	selected := func(collection, path, table string) bool {
+		if s == nil {
+			return false
+		}
+		if tables[table] == nil {
+			add(SeverityError, IssueUnselectedTable, collection, path, "table %s is not selected for migration", table)
+			return false
+		}
+		return true
+	}
+	// checkJoin checks the join of a child table to its parent table
+	checkJoin := func(collection, path, child, parent string, join, parentKeys []string, childKnown, parentKnown bool) {
+		if len(join) == 0 || len(parentKeys) == 0 {
+			add(SeverityError, IssueMissingJoin, collection, path, "%s has no join columns to %s", child, parent)
+			return
+		}
+		missing := false
+		if childKnown {
+			for _, c := range join {
+				if !hasColumn(tables[child], c) {
+					add(SeverityError, IssueMissingJoin, collection, path, "join column %s is not a column of %s", c, child)
+					missing = true
+				}
+			}
+		}
+		if parentKnown {
+			for _, c := range parentKeys {
+				if !hasColumn(tables[parent], c) {
+					add(SeverityError, IssueMissingJoin, collection, path, "parent column %s is not a column of %s", c, parent)
+					missing = true
+				}
+			}
+		}
+		if childKnown && !missing && !indexed(tables[child], join) {
+			add(SeverityWarning, IssueUnindexedJoin, collection, path, "no index of %s leads with %s, so the join reads the whole table",
+				child, strings.Join(join, ", "))
+		}
+	}
+	// checkFields reports embedded fields that collide with the fields of
+	// the table's columns or with each other
+	checkFields := func(collection, prefix, table string, ts []Transformation, fields []FieldMapping, embs []Embedded) {
+		owner := make(map[string]string) // field path to what writes it
+		for _, col := range TableColumns(s, table, ts) {
+			if path, ok := FieldTarget(fields, col); ok {
+				owner[path] = "column " + col
+			}
+		}
+		for _, e := range embs {
+			what := "embedded table " + e.SourceTable
+			clash := owner[e.FieldName]
+			for path, o := range owner {
+				if clash == "" && (strings.HasPrefix(path, e.FieldName+".") || strings.HasPrefix(e.FieldName, path+".")) {
+					clash = o
+				}
+			}
+			if clash != "" {
+				add(SeverityError, IssueDuplicateField, collection, prefix+e.FieldName, "%s is written by both %s and %s", e.FieldName, clash, what)
+				continue
+			}
+			owner[e.FieldName] = what
+		}
+	}
+	maxDepth := m.MaxEmbedDepth()
+	var walk func(collection, prefix, parent string, parentKnown bool, embs []Embedded, depth int)
+	walk = func(collection, prefix, parent string, parentKnown bool, embs []Embedded, depth int) {
+		for _, e := range embs {
+			path := prefix + e.FieldName
+			known := selected(collection, path, e.SourceTable)
+			checkJoin(collection, path, e.SourceTable, parent, e.JoinKeys(), e.ParentKeys(), known, parentKnown)
+			if depth > maxDepth {
+				add(SeverityWarning, IssueTooDeep, collection, path, "%s is embedded %d levels deep, deeper than the limit of %d (max_depth)", e.SourceTable, depth, maxDepth)
+			}
+			checkFields(collection, path+".", e.SourceTable, e.Transformations, e.Fields, e.Embedded)
+			walk(collection, path+".", e.SourceTable, known, e.Embedded, depth+1)
+		}
+	}
+	for _, c := range m.Collections {
+		known := selected(c.Name, "", c.SourceTable)
+		checkFields(c.Name, "", c.SourceTable, c.Transformations, c.Fields, c.Embedded)
+		walk(c.Name, "", c.SourceTable, known, c.Embedded, 1)
+		for _, r := range c.References {
+			refKnown := selected(c.Name, r.FieldName, r.SourceTable)
+			checkJoin(c.Name, r.FieldName, r.SourceTable, c.SourceTable, r.JoinKeys(), r.ParentKeys(), refKnown, known)
+		}
+	}
+	return out
+}
+
+func hasColumn(t *schema.Table, name string) bool {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// indexed reports whether the table's primary key or one of its indexes
+// leads with the columns, in any order.
+func indexed(t *schema.Table, cols []string) bool {
+	leads := func(index []string) bool {
+		if len(index) < len(cols) {
+			return false
+		}
+		lead := make(map[string]bool, len(cols))
+		for _, c := range index[:len(cols)] {
+			lead[c] = true
+		}
+		for _, c := range cols {
+			if !lead[c] {
+				return false
+			}
+		}
+		return true
+	}
+	if t.PrimaryKey != nil && leads(t.PrimaryKey.Columns) {
+		return true
+	}
+	for _, idx := range t.Indexes {
+		if leads(idx.Columns) {
+			return true
+		}
+	}
+	return false
+}
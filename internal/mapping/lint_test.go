@@ -0,0 +1,109 @@
+package mapping
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func lintSchema() *schema.Schema {
+	cols := func(names ...string) []schema.Column {
+		out := make([]schema.Column, len(names))
+		for i, n := range names {
+			out[i] = schema.Column{Name: n, DataType: "integer"}
+		}
+		return out
+	}
+	return &schema.Schema{Tables: []schema.Table{
+		{Name: "orders", Columns: cols("id", "customer_id", "items"), PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}}},
+		{Name: "order_items", Columns: cols("id", "order_id", "product_id"),
+			Indexes: []schema.Index{{Name: "ix_items_order", Columns: []string{"order_id", "product_id"}}}},
+		{Name: "item_notes", Columns: cols("id", "item_id")},
+		{Name: "note_tags", Columns: cols("note_id", "tag")},
+		{Name: "tag_owners", Columns: cols("tag", "owner"), PrimaryKey: &schema.PrimaryKey{Columns: []string{"tag"}}},
+		{Name: "customers", Columns: cols("id"), PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}}},
+	}}
+}
+
+func TestValidate_Clean(t *testing.T) {
+	m := &Mapping{Collections: []Collection{{
+		Name: "orders", SourceTable: "orders",
+		Embedded: []Embedded{{SourceTable: "order_items", FieldName: "lines", Relationship: "array", JoinColumn: "order_id", ParentColumn: "id"}},
+	}}}
+	if issues := Validate(lintSchema(), m); len(issues) != 0 {
+		t.Errorf("Validate() = %v, want no issues", issues)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	m := &Mapping{Collections: []Collection{
+		{
+			Name: "orders", SourceTable: "orders",
+			Embedded: []Embedded{
+				{SourceTable: "order_items", FieldName: "items", Relationship: "array", JoinColumn: "order_id", ParentColumn: "id",
+					Embedded: []Embedded{{SourceTable: "item_notes", FieldName: "notes", Relationship: "array", JoinColumn: "item_id", ParentColumn: "id",
+						Embedded: []Embedded{{SourceTable: "note_tags", FieldName: "tags", Relationship: "array", JoinColumn: "note_id", ParentColumn: "id",
+							Embedded: []Embedded{{SourceTable: "tag_owners", FieldName: "owner", Relationship: "single", JoinColumn: "tag", ParentColumn: "tag"}}}}}}},
+				{SourceTable: "order_items", FieldName: "lines", Relationship: "array"},
+				{SourceTable: "shipments", FieldName: "shipments", Relationship: "array", JoinColumn: "order_id", ParentColumn: "id"},
+				{SourceTable: "customers", FieldName: "lines", Relationship: "single", JoinColumn: "id", ParentColumn: "customer_ref"},
+			},
+			References: []Reference{{SourceTable: "invoices", FieldName: "invoices", JoinColumn: "order_id", ParentColumn: "id"}},
+		},
+	}}
+	issues := Validate(lintSchema(), m)
+	want := []struct{ severity, code, path, message string }{
+		{SeverityError, IssueDuplicateField, "items", "items is written by both column items and embedded table order_items"},
+		{SeverityError, IssueDuplicateField, "lines", "lines is written by both embedded table order_items and embedded table customers"},
+		{SeverityWarning, IssueUnindexedJoin, "items.notes", "no index of item_notes leads with item_id"},
+		{SeverityWarning, IssueUnindexedJoin, "items.notes.tags", "no index of note_tags leads with note_id"},
+		{SeverityWarning, IssueTooDeep, "items.notes.tags.owner", "tag_owners is embedded 4 levels deep, deeper than the limit of 3"},
+		{SeverityError, IssueMissingJoin, "lines", "order_items has no join columns to orders"},
+		{SeverityError, IssueUnselectedTable, "shipments", "table shipments is not selected for migration"},
+		{SeverityError, IssueMissingJoin, "lines", "parent column customer_ref is not a column of orders"},
+		{SeverityError, IssueUnselectedTable, "invoices", "table invoices is not selected for migration"},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("Validate() found %d issues, want %d: %v", len(issues), len(want), issues)
+	}
+	for i, w := range want {
+		is := issues[i]
+		if is.Severity != w.severity || is.Code != w.code || is.Collection != "orders" || is.Path != w.path || !strings.HasPrefix(is.Message, w.message) {
+			t.Errorf("issue %d = %+v, want %s %s at %s: %s", i, is, w.severity, w.code, w.path, w.message)
+		}
+	}
+
+	var lint *LintError
+	if err := issues.Err(); !errors.As(err, &lint) || len(lint.Issues) != 6 {
+		t.Fatalf("Err() = %v, want the 6 errors", err)
+	}
+	if !strings.HasPrefix(lint.Error(), "orders.items: items is written by both") {
+		t.Errorf("Error() = %q", lint.Error())
+	}
+	if n := len(issues.Warnings()); n != 3 {
+		t.Errorf("Warnings() = %d, want 3", n)
+	}
+
+	m.MaxDepth = 4
+	for _, is := range Validate(lintSchema(), m) {
+		if is.Code == IssueTooDeep {
+			t.Errorf("max_depth 4 still warns: %v", is)
+		}
+	}
+}
+
+func TestValidate_NoSchema(t *testing.T) {
+	m := &Mapping{Collections: []Collection{{
+		Name: "orders", SourceTable: "orders",
+		Embedded: []Embedded{{SourceTable: "order_items", FieldName: "lines", Relationship: "array"}},
+	}}}
+	issues := Validate(nil, m)
+	if len(issues) != 1 || issues[0].Code != IssueMissingJoin {
+		t.Errorf("Validate(nil) = %v, want only the missing join", issues)
+	}
+	if err := Validate(nil, &Mapping{}).Err(); err != nil {
+		t.Errorf("empty mapping: %v", err)
+	}
+}
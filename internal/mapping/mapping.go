@@ -4,22 +4,116 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Mapping defines how source tables map to MongoDB collections.
 type Mapping struct {
-	Collections []Collection `yaml:"collections" json:"collections"`
+	Collections []Collection  `yaml:"collections" json:"collections"`
+	Views       []View        `yaml:"views,omitempty" json:"views,omitempty"`
+	Queries     []CanaryQuery `yaml:"queries,omitempty" json:"queries,omitempty"`
+
+	// ConsistencyGroups are collections migrated from one source snapshot.
+	ConsistencyGroups []ConsistencyGroup `yaml:"consistency_groups,omitempty" json:"consistency_groups,omitempty"`
+
+	// Order is how the collections are ordered: references (the default)
+	// or manual; see Stages.
+	Order string `yaml:"order,omitempty" json:"order,omitempty"`
+
+	// FieldNames is what happens to columns whose names are not safe
+	// document keys: rename (the default), keep or fail; see UnsafeFields.
+	FieldNames string `yaml:"field_names,omitempty" json:"field_names,omitempty"`
+
+	// MaxDepth is how deeply tables may be embedded before Validate warns,
+	// DefaultMaxDepth unless set.
+	MaxDepth int `yaml:"max_depth,omitempty" json:"max_depth,omitempty"`
+
+	// Suggestions are field groups proposed by Suggest for the user to
+	// accept or reject. They are never saved with the mapping.
+	Suggestions []FieldGroupSuggestion `yaml:"-" json:"suggestions,omitempty"`
 }
 
 // Collection represents a target MongoDB collection.
 type Collection struct {
 	Name            string           `yaml:"name" json:"name"`
 	SourceTable     string           `yaml:"source_table" json:"source_table"`
+	Filter          string           `yaml:"filter,omitempty" json:"filter,omitempty"`
+	Watermark       string           `yaml:"watermark,omitempty" json:"watermark,omitempty"` // root column that grows on every change, for delta runs
 	Embedded        []Embedded       `yaml:"embedded,omitempty" json:"embedded,omitempty"`
 	References      []Reference      `yaml:"references,omitempty" json:"references,omitempty"`
 	Transformations []Transformation `yaml:"transformations,omitempty" json:"transformations,omitempty"`
+	Fields          []FieldMapping   `yaml:"fields,omitempty" json:"fields,omitempty"`
+	FieldOrder      []string         `yaml:"field_order,omitempty" json:"field_order,omitempty"` // document paths written first, in this order
+	Zones           *ZoneConfig      `yaml:"zones,omitempty" json:"zones,omitempty"`
+	Storage         *StorageOptions  `yaml:"storage,omitempty" json:"storage,omitempty"`
+	Retention       *RetentionPolicy `yaml:"retention,omitempty" json:"retention,omitempty"`
+	Archive         *ArchivePolicy   `yaml:"archive,omitempty" json:"archive,omitempty"`             // rows older than a cutoff go to S3 Parquet instead
+	IDGeneration    string           `yaml:"id_generation,omitempty" json:"id_generation,omitempty"` // how ids of new documents are made after cutover: counter or objectid
+	Validation      string           `yaml:"validation,omitempty" json:"validation,omitempty"`       // $jsonSchema validation level: off, moderate or strict
+	DependsOn       []string         `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`       // collections migrated before this one
+	Priority        int              `yaml:"priority,omitempty" json:"priority,omitempty"`           // higher goes first among collections whose dependencies are met
+	OnError         string           `yaml:"on_error,omitempty" json:"on_error,omitempty"`           // what happens to rows that cannot be converted: fail, skip or quarantine
+}
+
+// StorageOptions are WiredTiger storage settings applied when the target
+// collection is created. An empty BlockCompressor keeps the server default
+// (snappy).
+type StorageOptions struct {
+	BlockCompressor string `yaml:"block_compressor,omitempty" json:"block_compressor,omitempty"`
+	ConfigString    string `yaml:"config_string,omitempty" json:"config_string,omitempty"`
+}
+
+// BlockCompressors lists the WiredTiger block compressors MongoDB supports.
+var BlockCompressors = []string{"none", "snappy", "zlib", "zstd"}
+
+// Validate checks that the block compressor is supported and that the extra
+// configuration string does not set it a second time.
+func (o *StorageOptions) Validate() error {
+	if o.BlockCompressor != "" {
+		valid := false
+		for _, c := range BlockCompressors {
+			if o.BlockCompressor == c {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unsupported block compressor %q (use none, snappy, zlib or zstd)", o.BlockCompressor)
+		}
+	}
+	if strings.Contains(o.ConfigString, "block_compressor") {
+		return fmt.Errorf("set the compressor with block_compressor, not in config_string")
+	}
+	return nil
+}
+
+// ValidateStorage checks the storage options of every collection.
+func (m *Mapping) ValidateStorage() error {
+	for _, col := range m.Collections {
+		if col.Storage == nil {
+			continue
+		}
+		if err := col.Storage.Validate(); err != nil {
+			return fmt.Errorf("collection %s: %w", col.Name, err)
+		}
+	}
+	return nil
+}
+
+// ZoneConfig pins ranges of a document field to named shard zones, e.g. to keep
+// each region's documents on shards in that region of a global cluster. The
+// field becomes the leading component of the collection's shard key.
+type ZoneConfig struct {
+	Field  string      `yaml:"field" json:"field"`
+	Ranges []ZoneRange `yaml:"ranges" json:"ranges"`
+}
+
+// ZoneRange assigns shard key values in [Min, Max) to a zone.
+type ZoneRange struct {
+	Zone string      `yaml:"zone" json:"zone"`
+	Min  interface{} `yaml:"min" json:"min"`
+	Max  interface{} `yaml:"max" json:"max"`
 }
 
 // Embedded represents a table whose rows are embedded as subdocuments.
@@ -27,18 +121,26 @@ type Embedded struct {
 	SourceTable     string           `yaml:"source_table" json:"source_table"`
 	FieldName       string           `yaml:"field_name" json:"field_name"`
 	Relationship    string           `yaml:"relationship" json:"relationship"`
-	JoinColumn      string           `yaml:"join_column" json:"join_column"`
-	ParentColumn    string           `yaml:"parent_column" json:"parent_column"`
+	JoinColumn      string           `yaml:"join_column,omitempty" json:"join_column"`
+	ParentColumn    string           `yaml:"parent_column,omitempty" json:"parent_column"`
+	JoinColumns     []string         `yaml:"join_columns,omitempty" json:"join_columns,omitempty"`     // composite key; see JoinKeys
+	ParentColumns   []string         `yaml:"parent_columns,omitempty" json:"parent_columns,omitempty"` // matching JoinColumns in order
+	Filter          string           `yaml:"filter,omitempty" json:"filter,omitempty"`
 	Embedded        []Embedded       `yaml:"embedded,omitempty" json:"embedded,omitempty"`
 	Transformations []Transformation `yaml:"transformations,omitempty" json:"transformations,omitempty"`
+	Fields          []FieldMapping   `yaml:"fields,omitempty" json:"fields,omitempty"`
+	FieldOrder      []string         `yaml:"field_order,omitempty" json:"field_order,omitempty"` // subdocument paths written first, in this order
+	Offload         *Offload         `yaml:"offload,omitempty" json:"offload,omitempty"`         // store the field outside the document; see Offload
 }
 
 // Reference represents a table kept as a separate collection, linked by a field.
 type Reference struct {
-	SourceTable  string `yaml:"source_table" json:"source_table"`
-	FieldName    string `yaml:"field_name" json:"field_name"`
-	JoinColumn   string `yaml:"join_column" json:"join_column"`
-	ParentColumn string `yaml:"parent_column" json:"parent_column"`
+	SourceTable   string   `yaml:"source_table" json:"source_table"`
+	FieldName     string   `yaml:"field_name" json:"field_name"`
+	JoinColumn    string   `yaml:"join_column,omitempty" json:"join_column"`
+	ParentColumn  string   `yaml:"parent_column,omitempty" json:"parent_column"`
+	JoinColumns   []string `yaml:"join_columns,omitempty" json:"join_columns,omitempty"`     // composite key; see JoinKeys
+	ParentColumns []string `yaml:"parent_columns,omitempty" json:"parent_columns,omitempty"` // matching JoinColumns in order
 }
 
 // WriteYAML writes the mapping to a YAML file at the given path.
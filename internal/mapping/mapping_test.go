@@ -3,7 +3,12 @@ package mapping
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/schema"
 )
 
 func TestWriteAndLoadYAML(t *testing.T) {
@@ -287,3 +292,741 @@ func TestWriteAndLoadYAML_EmbedSingle(t *testing.T) {
 			loaded.Collections[0].Embedded[0].Relationship)
 	}
 }
+
+func TestValidateStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage *StorageOptions
+		wantErr bool
+	}{
+		{"no options", nil, false},
+		{"zstd", &StorageOptions{BlockCompressor: "zstd"}, false},
+		{"config string", &StorageOptions{BlockCompressor: "zlib", ConfigString: "prefix_compression=true"}, false},
+		{"unknown compressor", &StorageOptions{BlockCompressor: "lz4"}, true},
+		{"compressor in config string", &StorageOptions{ConfigString: "block_compressor=zstd"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapping{Collections: []Collection{{Name: "orders", Storage: tt.storage}}}
+			err := m.ValidateStorage()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRetention(t *testing.T) {
+	tests := []struct {
+		name    string
+		col     Collection
+		wantErr bool
+	}{
+		{"no policy", Collection{Name: "events"}, false},
+		{"expire", Collection{Name: "events", Retention: &RetentionPolicy{Column: "created_at", ExpireAfterDays: 365}}, false},
+		{"archive then expire", Collection{Name: "events", Retention: &RetentionPolicy{Column: "created_at", ArchiveAfterDays: 90, ExpireAfterDays: 730}}, false},
+		{"missing column", Collection{Name: "events", Retention: &RetentionPolicy{ExpireAfterDays: 30}}, true},
+		{"nothing to do", Collection{Name: "events", Retention: &RetentionPolicy{Column: "created_at"}}, true},
+		{"archive after expiry", Collection{Name: "events", Retention: &RetentionPolicy{Column: "created_at", ArchiveAfterDays: 365, ExpireAfterDays: 90}}, true},
+		{"negative", Collection{Name: "events", Retention: &RetentionPolicy{Column: "created_at", ExpireAfterDays: -1}}, true},
+		{"excluded column", Collection{
+			Name:            "events",
+			Transformations: []Transformation{{SourceField: "created_at", Operation: "exclude"}},
+			Retention:       &RetentionPolicy{Column: "created_at", ExpireAfterDays: 30},
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapping{Collections: []Collection{tt.col}}
+			err := m.ValidateRetention()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRetention() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRootField(t *testing.T) {
+	c := &Collection{
+		Transformations: []Transformation{
+			{SourceField: "created", Operation: "rename", TargetField: "created_at"},
+			{SourceField: "secret", Operation: "exclude"},
+		},
+		Fields: []FieldMapping{
+			{Column: "created_at", Target: "meta.createdAt"},
+			{Prefix: "ship_", Target: "shipping"},
+		},
+	}
+	tests := []struct {
+		column string
+		want   string
+		wantOK bool
+	}{
+		{"created", "meta.createdAt", true},
+		{"updated_at", "updated_at", true},
+		{"secret", "", false},
+		{"ship_city", "shipping.city", true},
+		{"ship_", "ship_", true},
+	}
+	for _, tt := range tests {
+		got, ok := c.RootField(tt.column)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("RootField(%q) = %q, %v, want %q, %v", tt.column, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSubField(t *testing.T) {
+	e := &Embedded{
+		Transformations: []Transformation{{SourceField: "qty", Operation: "rename", TargetField: "quantity"}},
+		Fields:          []FieldMapping{{Column: "order_id", Exclude: true}, {Column: "sku", Target: "product.sku"}},
+	}
+	for column, want := range map[string]string{"qty": "quantity", "sku": "product.sku", "price": "price"} {
+		if got, ok := e.SubField(column); !ok || got != want {
+			t.Errorf("SubField(%q) = %q, %v, want %q", column, got, ok, want)
+		}
+	}
+	if _, ok := e.SubField("order_id"); ok {
+		t.Error("excluded columns should have no field")
+	}
+}
+
+func TestValidateViews(t *testing.T) {
+	byDay := `[{"$group": {"_id": "$order_date", "total": {"$sum": "$amount"}}}]`
+	tests := []struct {
+		name    string
+		views   []View
+		wantErr bool
+	}{
+		{"no views", nil, false},
+		{"valid", []View{{Name: "orders_by_day", Source: "orders", Pipeline: byDay}}, false},
+		{"view of view", []View{
+			{Name: "orders_by_day", Source: "orders", Pipeline: byDay},
+			{Name: "busy_days", Source: "orders_by_day", Pipeline: `[{"$match": {"total": {"$gt": 1000}}}]`},
+		}, false},
+		{"merge options", []View{{Name: "orders_by_day", Source: "orders", Pipeline: byDay, On: []string{"day"}, WhenMatched: "merge", WhenNotMatched: "discard"}}, false},
+		{"missing name", []View{{Source: "orders", Pipeline: byDay}}, true},
+		{"unknown source", []View{{Name: "v", Source: "invoices", Pipeline: byDay}}, true},
+		{"name collides with collection", []View{{Name: "orders", Source: "orders", Pipeline: byDay}}, true},
+		{"pipeline not array", []View{{Name: "v", Source: "orders", Pipeline: `{"$match": {}}`}}, true},
+		{"stage without operator", []View{{Name: "v", Source: "orders", Pipeline: `[{"match": {}}]`}}, true},
+		{"contains merge", []View{{Name: "v", Source: "orders", Pipeline: `[{"$merge": {"into": "x"}}]`}}, true},
+		{"contains out", []View{{Name: "v", Source: "orders", Pipeline: `[{"$out": "x"}]`}}, true},
+		{"bad when_matched", []View{{Name: "v", Source: "orders", Pipeline: byDay, WhenMatched: "upsert"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapping{Collections: []Collection{{Name: "orders"}}, Views: tt.views}
+			err := m.ValidateViews()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateViews() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteAndLoadYAML_WithFields(t *testing.T) {
+	m := &Mapping{
+		Collections: []Collection{
+			{
+				Name:        "customers",
+				SourceTable: "customers",
+				Fields: []FieldMapping{
+					{Column: "street", Target: "address.street"},
+					{Column: "ssn", Exclude: true},
+				},
+				Embedded: []Embedded{
+					{
+						SourceTable:  "orders",
+						FieldName:    "orders",
+						Relationship: "array",
+						JoinColumn:   "customer_id",
+						ParentColumn: "id",
+						Fields:       []FieldMapping{{Column: "total", Target: "amount"}},
+					},
+				},
+			},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "fields.yaml")
+	if err := m.WriteYAML(path); err != nil {
+		t.Fatalf("WriteYAML: %v", err)
+	}
+	loaded, err := LoadYAML(path)
+	if err != nil {
+		t.Fatalf("LoadYAML: %v", err)
+	}
+
+	c := loaded.Collections[0]
+	if !reflect.DeepEqual(c.Fields, m.Collections[0].Fields) {
+		t.Errorf("collection fields = %+v, want %+v", c.Fields, m.Collections[0].Fields)
+	}
+	if !reflect.DeepEqual(c.Embedded[0].Fields, m.Collections[0].Embedded[0].Fields) {
+		t.Errorf("embedded fields = %+v, want %+v", c.Embedded[0].Fields, m.Collections[0].Embedded[0].Fields)
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "customers", Columns: []schema.Column{{Name: "id"}, {Name: "street"}, {Name: "city"}, {Name: "address"}, {Name: "home_phone"}, {Name: "home_fax"}}},
+		{Name: "orders", Columns: []schema.Column{{Name: "id"}, {Name: "total"}}},
+	}}
+	tests := []struct {
+		name     string
+		fields   []FieldMapping
+		embedded []FieldMapping
+		wantErr  bool
+	}{
+		{"none", nil, nil, false},
+		{"rename", []FieldMapping{{Column: "street", Target: "streetName"}}, nil, false},
+		{"nest", []FieldMapping{
+			{Column: "street", Target: "location.street"},
+			{Column: "town", Target: "location.town"},
+		}, nil, false},
+		{"exclude", []FieldMapping{{Column: "street", Exclude: true}}, nil, false},
+		{"embedded rename", nil, []FieldMapping{{Column: "total", Target: "amount"}}, false},
+		{"missing column name", []FieldMapping{{Target: "x"}}, nil, true},
+		{"unknown column", []FieldMapping{{Column: "zip", Target: "postcode"}}, nil, true},
+		{"unknown embedded column", nil, []FieldMapping{{Column: "zip", Target: "postcode"}}, true},
+		{"duplicate column", []FieldMapping{{Column: "street"}, {Column: "street", Target: "s"}}, nil, true},
+		{"exclude with target", []FieldMapping{{Column: "street", Target: "s", Exclude: true}}, nil, true},
+		{"duplicate target", []FieldMapping{{Column: "street", Target: "id"}}, nil, true},
+		{"nested under value", []FieldMapping{{Column: "street", Target: "address.street"}}, nil, true},
+		{"nested under mapped value", []FieldMapping{
+			{Column: "town", Target: "loc"},
+			{Column: "street", Target: "loc.street"},
+		}, nil, true},
+		{"moved value frees name", []FieldMapping{
+			{Column: "address", Target: "legacy_address"},
+			{Column: "street", Target: "address.street"},
+		}, nil, false},
+		{"empty segment", []FieldMapping{{Column: "street", Target: "address..street"}}, nil, true},
+		{"dollar field", []FieldMapping{{Column: "street", Target: "$street"}}, nil, true},
+		{"renamed by transformation", []FieldMapping{{Column: "town", Target: "address2.town"}}, nil, false},
+		{"original name after rename", []FieldMapping{{Column: "city", Target: "address2.city"}}, nil, true},
+		{"group", []FieldMapping{{Prefix: "home_", Target: "home"}}, nil, false},
+		{"group with column override", []FieldMapping{{Prefix: "home_", Target: "home"}, {Column: "home_fax", Exclude: true}}, nil, false},
+		{"excluded group", []FieldMapping{{Prefix: "home_", Exclude: true}}, nil, false},
+		{"group without target", []FieldMapping{{Prefix: "home_"}}, nil, true},
+		{"group matching nothing", []FieldMapping{{Prefix: "work_", Target: "work"}}, nil, true},
+		{"group under value", []FieldMapping{{Prefix: "home_", Target: "street"}}, nil, true},
+		{"column and prefix", []FieldMapping{{Column: "street", Prefix: "home_", Target: "home"}}, nil, true},
+		{"duplicate prefix", []FieldMapping{{Prefix: "home_", Target: "home"}, {Prefix: "home_", Target: "h"}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapping{Collections: []Collection{{
+				Name:            "customers",
+				SourceTable:     "customers",
+				Transformations: []Transformation{{SourceField: "city", Operation: "rename", TargetField: "town"}},
+				Fields:          tt.fields,
+				Embedded:    []Embedded{{SourceTable: "orders", FieldName: "orders", Fields: tt.embedded}},
+			}}}
+			err := m.ValidateFields(s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyFields(t *testing.T) {
+	row := map[string]interface{}{"id": 1, "street": "Main", "city": "Springfield", "ssn": "123"}
+	got := ApplyFields(row, []FieldMapping{
+		{Column: "id", Target: "customerId"},
+		{Column: "street", Target: "address.street"},
+		{Column: "city", Target: "address.city"},
+		{Column: "ssn", Exclude: true},
+	})
+	want := map[string]interface{}{
+		"customerId": 1,
+		"address":    map[string]interface{}{"street": "Main", "city": "Springfield"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplyFields() = %#v, want %#v", got, want)
+	}
+}
+
+func TestApplyFields_Groups(t *testing.T) {
+	row := map[string]interface{}{
+		"id": 1, "address_street": "Main", "address_city": "Springfield",
+		"address_geo_lat": 1.5, "address": "legacy", "tmp_flag": true,
+	}
+	got := ApplyFields(row, []FieldMapping{
+		{Prefix: "address_", Target: "address"},
+		{Prefix: "address_geo_", Target: "location"},
+		{Column: "address", Target: "legacyAddress"},
+		{Prefix: "tmp_", Exclude: true},
+	})
+	want := map[string]interface{}{
+		"id":            1,
+		"legacyAddress": "legacy",
+		"address":       map[string]interface{}{"street": "Main", "city": "Springfield"},
+		"location":      map[string]interface{}{"lat": 1.5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplyFields() = %#v, want %#v", got, want)
+	}
+}
+
+func TestOrderedKeys(t *testing.T) {
+	order := []string{"name", "address", "address.city", "email"}
+	tests := []struct {
+		keys []string
+		path string
+		want []string
+	}{
+		{[]string{"id", "email", "address", "name", "age"}, "", []string{"name", "address", "email", "age", "id"}},
+		{[]string{"zip", "street", "city"}, "address", []string{"city", "street", "zip"}},
+		{[]string{"b", "a"}, "other", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		if got := OrderedKeys(tt.keys, order, tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("OrderedKeys(%v, %q) = %v, want %v", tt.keys, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestValidateFieldOrder(t *testing.T) {
+	tests := []struct {
+		order   []string
+		wantErr bool
+	}{
+		{nil, false},
+		{[]string{"name", "address", "address.city"}, false},
+		{[]string{"name", "name"}, true},
+		{[]string{"address..city"}, true},
+		{[]string{"$name"}, true},
+	}
+	for _, tt := range tests {
+		if err := ValidateFieldOrder(tt.order); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateFieldOrder(%v) error = %v, wantErr %v", tt.order, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateQueries(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries []CanaryQuery
+		wantErr bool
+	}{
+		{"no queries", nil, false},
+		{"filter", []CanaryQuery{{Name: "q", Collection: "orders", Filter: `{"status": "open"}`, Sort: `{"created": -1}`, Limit: 20}}, false},
+		{"fields", []CanaryQuery{{Name: "q", Collection: "orders", Fields: []string{"customer_id"}}}, false},
+		{"on view", []CanaryQuery{{Name: "q", Collection: "orders_by_day", Filter: `{}`}}, false},
+		{"missing name", []CanaryQuery{{Collection: "orders", Filter: `{}`}}, true},
+		{"unknown collection", []CanaryQuery{{Name: "q", Collection: "invoices", Filter: `{}`}}, true},
+		{"filter and fields", []CanaryQuery{{Name: "q", Collection: "orders", Filter: `{}`, Fields: []string{"a"}}}, true},
+		{"neither filter nor fields", []CanaryQuery{{Name: "q", Collection: "orders"}}, true},
+		{"filter not document", []CanaryQuery{{Name: "q", Collection: "orders", Filter: `[1]`}}, true},
+		{"bad sort", []CanaryQuery{{Name: "q", Collection: "orders", Filter: `{}`, Sort: `created`}}, true},
+		{"bad field", []CanaryQuery{{Name: "q", Collection: "orders", Fields: []string{"a..b"}}}, true},
+		{"negative limit", []CanaryQuery{{Name: "q", Collection: "orders", Filter: `{}`, Limit: -1}}, true},
+		{"duplicate name", []CanaryQuery{
+			{Name: "q", Collection: "orders", Filter: `{}`},
+			{Name: "q", Collection: "orders", Filter: `{}`},
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapping{
+				Collections: []Collection{{Name: "orders"}},
+				Views:       []View{{Name: "orders_by_day", Source: "orders"}},
+				Queries:     tt.queries,
+			}
+			err := m.ValidateQueries()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQueries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCanaryQueries(t *testing.T) {
+	m := &Mapping{Collections: []Collection{
+		{Name: "customers", SourceTable: "customers", References: []Reference{
+			{SourceTable: "orders", FieldName: "orders", JoinColumn: "customer_id", ParentColumn: "id"},
+			{SourceTable: "invoices", FieldName: "invoices", JoinColumn: "customer_id", ParentColumn: "id"},
+		}},
+		{Name: "orders", SourceTable: "orders", Fields: []FieldMapping{{Column: "customer_id", Target: "customer.id"}}},
+	}}
+
+	got := m.CanaryQueries()
+	want := []CanaryQuery{{Name: "orders_by_customer_id", Collection: "orders", Fields: []string{"customer.id"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("generated queries = %+v, want %+v", got, want)
+	}
+
+	m.Queries = []CanaryQuery{{Name: "open_orders", Collection: "orders", Filter: `{"status": "open"}`}}
+	if got := m.CanaryQueries(); len(got) != 1 || got[0].Name != "open_orders" {
+		t.Errorf("registered queries should replace generated ones, got %+v", got)
+	}
+}
+
+func TestStaleReferences(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "customers", Columns: []schema.Column{{Name: "id"}, {Name: "name"}}},
+		{Name: "orders", Columns: []schema.Column{{Name: "id"}, {Name: "cust_id"}, {Name: "total"}}},
+		{Name: "products", Columns: []schema.Column{{Name: "id"}}},
+	}}
+	m := &Mapping{Collections: []Collection{
+		{
+			Name:        "customers",
+			SourceTable: "customers",
+			Transformations: []Transformation{
+				{SourceField: "name", Operation: "rename", TargetField: "full_name"},
+				{SourceField: "fax", Operation: "exclude"},
+			},
+			Fields: []FieldMapping{{Column: "full_name", Target: "profile.name"}, {Column: "email"}},
+			Embedded: []Embedded{
+				{SourceTable: "orders", FieldName: "orders", JoinColumn: "customer_id", ParentColumn: "id",
+					Embedded: []Embedded{{SourceTable: "order_items", FieldName: "items", JoinColumn: "order_id", ParentColumn: "id"}}},
+			},
+		},
+		{
+			Name:        "products",
+			SourceTable: "products",
+			References:  []Reference{{SourceTable: "orders", FieldName: "orders", JoinColumn: "product_id", ParentColumn: "id"}},
+		},
+		{Name: "legacy", SourceTable: "legacy", Fields: []FieldMapping{{Column: "x"}}},
+	}}
+
+	got := m.StaleReferences(s)
+	want := []StaleReference{
+		{Path: "customers", Table: "customers", Column: "fax", Use: "exclude transformation"},
+		{Path: "customers", Table: "customers", Column: "email", Use: "field mapping"},
+		{Path: "customers.orders", Table: "orders", Column: "customer_id", Use: "join column"},
+		{Path: "customers.orders.items", Table: "order_items", Use: "embedded table"},
+		{Path: "products.orders", Table: "orders", Column: "product_id", Use: "join column"},
+		{Path: "legacy", Table: "legacy", Use: "source table"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StaleReferences =\n%+v\nwant\n%+v", got, want)
+	}
+	if s := got[2].String(); s != "customers.orders: column orders.customer_id (join column) no longer exists" {
+		t.Errorf("String = %q", s)
+	}
+	if s := got[5].String(); s != "legacy: table legacy (source table) no longer exists" {
+		t.Errorf("String = %q", s)
+	}
+
+	if stale := (&Mapping{Collections: []Collection{{Name: "products", SourceTable: "products"}}}).StaleReferences(s); len(stale) != 0 {
+		t.Errorf("StaleReferences = %v, want none", stale)
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		nested  string
+		wantErr bool
+	}{
+		{"none", "", "", false},
+		{"predicate", "status <> 'deleted' AND created_at > DATE '2020-01-01'", "", false},
+		{"escaped quote", "name <> 'O''Brien'", "", false},
+		{"statement separator", "1=1; DROP TABLE users", "", true},
+		{"line comment", "active -- and more", "", true},
+		{"block comment", "active /* x */", "", true},
+		{"unterminated string", "status = 'open", "", true},
+		{"nested embed", "", "qty > 0; --", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapping{Collections: []Collection{{
+				Name:        "orders",
+				SourceTable: "orders",
+				Filter:      tt.filter,
+				Embedded: []Embedded{{
+					SourceTable: "order_items",
+					Embedded:    []Embedded{{SourceTable: "item_parts", Filter: tt.nested}},
+				}},
+			}}}
+			err := m.ValidateFilters()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFilters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFilteredTable(t *testing.T) {
+	tests := []struct {
+		table, filter, want string
+	}{
+		{"orders", "", "orders"},
+		{"orders", "total > 0", "(SELECT * FROM orders WHERE total > 0) orders"},
+		{"sales.orders", "total > 0", "(SELECT * FROM sales.orders WHERE total > 0) orders"},
+	}
+	for _, tt := range tests {
+		if got := FilteredTable(tt.table, tt.filter); got != tt.want {
+			t.Errorf("FilteredTable(%q, %q) = %q, want %q", tt.table, tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestSelectTable(t *testing.T) {
+	tests := []struct {
+		table   string
+		columns []string
+		filter  string
+		want    string
+	}{
+		{"orders", nil, "", "(SELECT * FROM orders) orders"},
+		{"sites", []string{"id", "ST_AsGeoJSON(geom) AS geom"}, "", "(SELECT id, ST_AsGeoJSON(geom) AS geom FROM sites) sites"},
+		{"gis.sites", []string{"id"}, "id > 0", "(SELECT id FROM gis.sites WHERE id > 0) sites"},
+	}
+	for _, tt := range tests {
+		if got := SelectTable(tt.table, tt.columns, tt.filter); got != tt.want {
+			t.Errorf("SelectTable(%q, %v, %q) = %q, want %q", tt.table, tt.columns, tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestWatermarkRange_Filter(t *testing.T) {
+	tests := []struct {
+		name string
+		r    WatermarkRange
+		base string
+		want string
+	}{
+		{"first run", WatermarkRange{Column: "updated_at", UpTo: "TIMESTAMP '2024-05-01 10:00:00'"}, "", "updated_at <= TIMESTAMP '2024-05-01 10:00:00'"},
+		{"catch-up", WatermarkRange{Column: "seq", After: "100", UpTo: "250"}, "", "seq > 100 AND seq <= 250"},
+		{"with row filter", WatermarkRange{Column: "seq", After: "100", UpTo: "250"}, "status <> 'deleted'", "(status <> 'deleted') AND seq > 100 AND seq <= 250"},
+		{"empty table", WatermarkRange{Column: "seq"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Filter(tt.base); got != tt.want {
+				t.Errorf("Filter(%q) = %q, want %q", tt.base, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatermarkLiteral(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		want    string
+		wantErr bool
+	}{
+		{"null", nil, "", false},
+		{"int64", int64(42), "42", false},
+		{"int32", int32(7), "7", false},
+		{"float", 12.5, "12.5", false},
+		{"numeric string", "1001", "1001", false},
+		{"timestamp", time.Date(2024, 5, 1, 10, 0, 0, 250000000, time.UTC), "TIMESTAMP '2024-05-01 10:00:00.25'", false},
+		{"text", "abc", "", true},
+		{"bool", true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WatermarkLiteral(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WatermarkLiteral() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("WatermarkLiteral() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWatermarks(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{{
+		Name: "orders",
+		Columns: []schema.Column{
+			{Name: "id", DataType: "integer"},
+			{Name: "updated_at", DataType: "timestamp with time zone"},
+			{Name: "status", DataType: "varchar"},
+		},
+	}}}
+	tests := []struct {
+		column  string
+		wantErr bool
+	}{
+		{"", false},
+		{"updated_at", false},
+		{"id", false},
+		{"status", true},
+		{"missing", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			m := &Mapping{Collections: []Collection{{Name: "orders", SourceTable: "orders", Watermark: tt.column}}}
+			err := m.ValidateWatermarks(s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWatermarks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestArchivePolicy_Filters(t *testing.T) {
+	p := &ArchivePolicy{Column: "created_at", Before: "2020-01-01", Location: "s3://cold/shop"}
+	if got, want := p.Filter(""), "created_at < TIMESTAMP '2020-01-01 00:00:00'"; got != want {
+		t.Errorf("Filter = %q, want %q", got, want)
+	}
+	if got, want := p.Filter("status <> 'deleted'"), "(status <> 'deleted') AND created_at < TIMESTAMP '2020-01-01 00:00:00'"; got != want {
+		t.Errorf("Filter = %q, want %q", got, want)
+	}
+	want := "(created_at >= TIMESTAMP '2020-01-01 00:00:00' OR created_at IS NULL)"
+	c := Collection{Name: "orders", SourceTable: "orders", Archive: p}
+	if got := c.LiveFilter(); got != want {
+		t.Errorf("LiveFilter = %q, want %q", got, want)
+	}
+	c.Archive = nil
+	c.Filter = "status <> 'deleted'"
+	if got := c.LiveFilter(); got != c.Filter {
+		t.Errorf("LiveFilter without archive = %q, want the row filter", got)
+	}
+	p.Location = "s3://cold/shop/"
+	if got := p.Path("orders"); got != "s3://cold/shop/orders" {
+		t.Errorf("Path = %q", got)
+	}
+}
+
+func TestValidateArchives(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{{
+		Name: "orders",
+		Columns: []schema.Column{
+			{Name: "id", DataType: "integer"},
+			{Name: "created_at", DataType: "timestamp without time zone"},
+			{Name: "placed_on", DataType: "DATE"},
+		},
+	}}}
+	tests := []struct {
+		name    string
+		policy  ArchivePolicy
+		wantErr bool
+	}{
+		{"timestamp", ArchivePolicy{Column: "created_at", Before: "2020-01-01", Location: "s3://cold/shop"}, false},
+		{"date, RFC 3339 cutoff", ArchivePolicy{Column: "placed_on", Before: "2020-01-01T00:00:00Z", Location: "s3://cold"}, false},
+		{"no column", ArchivePolicy{Before: "2020-01-01", Location: "s3://cold"}, true},
+		{"bad cutoff", ArchivePolicy{Column: "created_at", Before: "last year", Location: "s3://cold"}, true},
+		{"not s3", ArchivePolicy{Column: "created_at", Before: "2020-01-01", Location: "/var/archive"}, true},
+		{"no bucket", ArchivePolicy{Column: "created_at", Before: "2020-01-01", Location: "s3://"}, true},
+		{"not a date", ArchivePolicy{Column: "id", Before: "2020-01-01", Location: "s3://cold"}, true},
+		{"missing column", ArchivePolicy{Column: "shipped_at", Before: "2020-01-01", Location: "s3://cold"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := tt.policy
+			m := &Mapping{Collections: []Collection{{Name: "orders", SourceTable: "orders", Archive: &policy}}}
+			err := m.ValidateArchives(s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateArchives() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConsistencyGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  []ConsistencyGroup
+		wantErr string
+	}{
+		{"none", nil, ""},
+		{"ok", []ConsistencyGroup{{Name: "billing", Collections: []string{"invoices", "payments"}}}, ""},
+		{"no name", []ConsistencyGroup{{Collections: []string{"invoices", "payments"}}}, "needs a name"},
+		{"one member", []ConsistencyGroup{{Name: "billing", Collections: []string{"invoices"}}}, "at least two"},
+		{"unknown", []ConsistencyGroup{{Name: "billing", Collections: []string{"invoices", "refunds"}}}, "refunds not found"},
+		{"twice", []ConsistencyGroup{
+			{Name: "billing", Collections: []string{"invoices", "payments"}},
+			{Name: "billing", Collections: []string{"customers", "orders"}},
+		}, "defined twice"},
+		{"overlap", []ConsistencyGroup{
+			{Name: "billing", Collections: []string{"invoices", "payments"}},
+			{Name: "sales", Collections: []string{"orders", "invoices"}},
+		}, "already in group billing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapping{ConsistencyGroups: tt.groups}
+			for _, name := range []string{"customers", "orders", "invoices", "payments"} {
+				m.Collections = append(m.Collections, Collection{Name: name, SourceTable: name})
+			}
+			err := m.ValidateConsistencyGroups()
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("ValidateConsistencyGroups() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleOrder(t *testing.T) {
+	m := &Mapping{ConsistencyGroups: []ConsistencyGroup{{Name: "billing", Collections: []string{"payments", "invoices"}}}}
+	for _, name := range []string{"invoices", "customers", "orders", "payments"} {
+		m.Collections = append(m.Collections, Collection{Name: name, SourceTable: name})
+	}
+	names := func(cols []Collection) []string {
+		var out []string
+		for _, c := range cols {
+			out = append(out, c.Name)
+		}
+		return out
+	}
+
+	got := names(m.ScheduleOrder(m.Collections))
+	want := []string{"payments", "invoices", "customers", "orders"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScheduleOrder() = %v, want %v", got, want)
+	}
+
+	// A retry of part of a group keeps the members it has together
+	got = names(m.ScheduleOrder([]Collection{m.Collections[1], m.Collections[0]}))
+	if !reflect.DeepEqual(got, []string{"customers", "invoices"}) {
+		t.Errorf("ScheduleOrder(subset) = %v", got)
+	}
+	if g := m.GroupOf("invoices"); g == nil || g.Name != "billing" {
+		t.Errorf("GroupOf(invoices) = %v", g)
+	}
+	if m.GroupOf("orders") != nil {
+		t.Error("orders is in no group")
+	}
+}
+
+func TestValidateIDGeneration(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{
+		{
+			Name:       "orders",
+			Columns:    []schema.Column{{Name: "id", DataType: "bigint", IsSequence: true}},
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}},
+		},
+		{
+			Name:       "codes",
+			Columns:    []schema.Column{{Name: "code", DataType: "varchar"}},
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"code"}},
+		},
+	}}
+	tests := []struct {
+		name     string
+		col      Collection
+		wantErr  bool
+		sequence bool
+	}{
+		{"none", Collection{Name: "orders", SourceTable: "orders"}, false, true},
+		{"counter", Collection{Name: "orders", SourceTable: "orders", IDGeneration: IDCounter}, false, true},
+		{"objectid", Collection{Name: "codes", SourceTable: "codes", IDGeneration: IDObjectID}, false, false},
+		{"counter without sequence", Collection{Name: "codes", SourceTable: "codes", IDGeneration: IDCounter}, true, false},
+		{"excluded key", Collection{Name: "orders", SourceTable: "orders", IDGeneration: IDCounter,
+			Transformations: []Transformation{{SourceField: "id", Operation: "exclude"}}}, true, true},
+		{"unknown", Collection{Name: "orders", SourceTable: "orders", IDGeneration: "uuid"}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapping{Collections: []Collection{tt.col}}
+			err := m.ValidateIDGeneration(s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIDGeneration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, ok := tt.col.SequenceKey(s); ok != tt.sequence {
+				t.Errorf("SequenceKey() = %v, want %v", ok, tt.sequence)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package mapping
+
+// isMask reports whether a transformation operation masks its source
+// field; see the masking operations of the transform package.
+func isMask(op string) bool {
+	switch op {
+	case "hash", "redact", "synthesize", "nullify":
+		return true
+	}
+	return false
+}
+
+// ColumnMask returns the transformation masking a column of table, and
+// whether every collection and embedded table migrating the table masks the
+// column that way.
+func (m *Mapping) ColumnMask(table, column string) (Transformation, bool) {
+	var mask Transformation
+	found, all := false, true
+	m.eachTable(table, func(ts *[]Transformation) {
+		t, ok := columnMask(*ts, column)
+		switch {
+		case !ok || (found && t != mask):
+			all = false
+		case !found:
+			mask, found = t, true
+		}
+	})
+	return mask, found && all
+}
+
+func columnMask(ts []Transformation, column string) (Transformation, bool) {
+	for _, t := range ts {
+		if isMask(t.Operation) && t.SourceField == column {
+			return t, true
+		}
+	}
+	return Transformation{}, false
+}
+
+// SetColumnMask masks a column of table with t wherever the table is
+// migrated, replacing any other mask of the column, or removes the column's
+// masks when t is nil. It returns how many tables were changed.
+func (m *Mapping) SetColumnMask(table, column string, t *Transformation) int {
+	var mask Transformation
+	if t != nil {
+		mask = *t
+		mask.SourceField = column
+	}
+	n := 0
+	m.eachTable(table, func(ts *[]Transformation) {
+		if cur, ok := columnMask(*ts, column); ok && t != nil && cur == mask {
+			return
+		}
+		out := (*ts)[:0]
+		changed := false
+		for _, tr := range *ts {
+			if isMask(tr.Operation) && tr.SourceField == column {
+				changed = true
+				continue
+			}
+			out = append(out, tr)
+		}
+		if t != nil {
+			out = append(out, mask)
+			changed = true
+		}
+		*ts = out
+		if changed {
+			n++
+		}
+	})
+	return n
+}
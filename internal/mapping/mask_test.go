@@ -0,0 +1,50 @@
+package mapping
+
+import "testing"
+
+func TestSetColumnMask(t *testing.T) {
+	m := &Mapping{Collections: []Collection{
+		{Name: "users", SourceTable: "users", Transformations: []Transformation{{SourceField: "email", Operation: "rename", TargetField: "mail"}}},
+		{Name: "teams", SourceTable: "teams", Embedded: []Embedded{
+			{SourceTable: "users", FieldName: "members"},
+		}},
+	}}
+	hash := Transformation{Operation: "hash", Value: "pepper"}
+
+	if _, ok := m.ColumnMask("users", "email"); ok {
+		t.Fatal("masked before setting")
+	}
+	if n := m.SetColumnMask("users", "email", &hash); n != 2 {
+		t.Errorf("masking changed %d tables, want 2", n)
+	}
+	if n := m.SetColumnMask("users", "email", &hash); n != 0 {
+		t.Errorf("masking twice changed %d tables, want 0", n)
+	}
+	got, ok := m.ColumnMask("users", "email")
+	if !ok || got.Operation != "hash" || got.Value != "pepper" || got.SourceField != "email" {
+		t.Errorf("ColumnMask = %+v, %v", got, ok)
+	}
+	if ts := m.Collections[0].Transformations; len(ts) != 2 || ts[0].Operation != "rename" {
+		t.Errorf("masking dropped other transformations: %+v", ts)
+	}
+
+	// A different mask in one place is not the column's mask
+	m.Collections[1].Embedded[0].Transformations[0].Operation = "nullify"
+	if _, ok := m.ColumnMask("users", "email"); ok {
+		t.Error("differing masks reported as one")
+	}
+
+	redact := Transformation{Operation: "redact"}
+	if n := m.SetColumnMask("users", "email", &redact); n != 2 {
+		t.Errorf("replacing changed %d tables, want 2", n)
+	}
+	if ts := m.Collections[0].Transformations; len(ts) != 2 || ts[1].Operation != "redact" {
+		t.Errorf("replacing left %+v", ts)
+	}
+	if n := m.SetColumnMask("users", "email", nil); n != 2 {
+		t.Errorf("removing changed %d tables, want 2", n)
+	}
+	if len(m.Collections[0].Transformations) != 1 || len(m.Collections[1].Embedded[0].Transformations) != 0 {
+		t.Errorf("removing left %+v", m.Collections)
+	}
+}
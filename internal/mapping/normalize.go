@@ -0,0 +1,29 @@
+package mapping
+
+import "strings"
+
+// NormalizeTransformation returns the compute transformation that rewrites
+// a text join key in lower case, so rows whose source collation joins them
+// ignoring case are also joined by the migration, which compares keys byte
+// by byte as MongoDB does.
+func NormalizeTransformation(column string) Transformation {
+	return Transformation{
+		SourceField: column,
+		Operation:   "compute",
+		TargetField: column,
+		Expression:  "lower(`" + strings.ReplaceAll(column, "`", "``") + "`)",
+	}
+}
+
+// NormalizesColumn reports whether the mapping lower-cases the column
+// wherever its table is migrated.
+func (m *Mapping) NormalizesColumn(table, column string) bool {
+	return m.appliesEverywhere(table, NormalizeTransformation(column))
+}
+
+// SetNormalization adds the column's lower-casing transformation to every
+// collection and embedded table migrating its table, or removes it, and
+// returns how many tables were changed.
+func (m *Mapping) SetNormalization(table, column string, normalize bool) int {
+	return m.setEverywhere(table, NormalizeTransformation(column), normalize)
+}
@@ -0,0 +1,94 @@
+package mapping
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Offload strategies for an embedded field too large to keep in the parent
+// document.
+const (
+	// OffloadGridFS writes each parent's value of the field to a GridFS file
+	// as JSON and keeps the file's ID in the field.
+	OffloadGridFS = "gridfs"
+	// OffloadCollection writes the embedded rows as documents of a side
+	// collection, each keeping its join column, and keeps
+	// {collection, count} in the field.
+	OffloadCollection = "collection"
+)
+
+// DefaultOffloadBucket is the GridFS bucket offloaded fields go to when
+// none is given.
+const DefaultOffloadBucket = "fs"
+
+// Offload moves an embedded field out of the parent document, leaving a
+// reference in its place, for documents that would otherwise exceed the
+// 16MB BSON limit. Target is the GridFS bucket or the side collection.
+type Offload struct {
+	Strategy string `yaml:"strategy" json:"strategy"`
+	Target   string `yaml:"target,omitempty" json:"target,omitempty"`
+}
+
+// OffloadTarget returns where the field of emb, embedded in collection,
+// is offloaded to: the GridFS bucket (default fs) or the side collection
+// (default <collection>_<field>). It is empty when emb is not offloaded.
+func OffloadTarget(collection string, emb Embedded) string {
+	if emb.Offload == nil {
+		return ""
+	}
+	if emb.Offload.Target != "" {
+		return emb.Offload.Target
+	}
+	if emb.Offload.Strategy == OffloadGridFS {
+		return DefaultOffloadBucket
+	}
+	return collection + "_" + emb.FieldName
+}
+
+// ValidateOffloads checks the offloaded fields of every collection. Only
+// fields embedded directly in a collection can be offloaded, and side
+// collections must not clash with the mapping's collections.
+func (m *Mapping) ValidateOffloads() error {
+	names := make(map[string]bool, len(m.Collections))
+	for _, col := range m.Collections {
+		names[col.Name] = true
+	}
+	for _, col := range m.Collections {
+		for _, emb := range col.Embedded {
+			if err := validateNestedOffloads(emb.Embedded); err != nil {
+				return fmt.Errorf("collection %s: %w", col.Name, err)
+			}
+			if emb.Offload == nil {
+				continue
+			}
+			switch emb.Offload.Strategy {
+			case OffloadGridFS:
+			case OffloadCollection:
+				target := OffloadTarget(col.Name, emb)
+				if names[target] {
+					return fmt.Errorf("collection %s: field %s is offloaded to %s, which is already a collection", col.Name, emb.FieldName, target)
+				}
+				names[target] = true
+			default:
+				return fmt.Errorf("collection %s: field %s: unknown offload strategy %q (use %s or %s)",
+					col.Name, emb.FieldName, emb.Offload.Strategy, OffloadGridFS, OffloadCollection)
+			}
+			if strings.ContainsAny(emb.Offload.Target, "$. ") {
+				return fmt.Errorf("collection %s: field %s: invalid offload target %q", col.Name, emb.FieldName, emb.Offload.Target)
+			}
+		}
+	}
+	return nil
+}
+
+func validateNestedOffloads(embedded []Embedded) error {
+	for _, emb := range embedded {
+		if emb.Offload != nil {
+			return fmt.Errorf("embedded %s: only fields embedded directly in a collection can be offloaded", emb.SourceTable)
+		}
+		if err := validateNestedOffloads(emb.Embedded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package mapping
+
+import "testing"
+
+func TestOffloadTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		offload *Offload
+		want    string
+	}{
+		{"not offloaded", nil, ""},
+		{"gridfs default bucket", &Offload{Strategy: OffloadGridFS}, "fs"},
+		{"side collection default", &Offload{Strategy: OffloadCollection}, "staff_documents"},
+		{"explicit target", &Offload{Strategy: OffloadCollection, Target: "staff_docs"}, "staff_docs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emb := Embedded{SourceTable: "documents", FieldName: "documents", Offload: tt.offload}
+			if got := OffloadTarget("staff", emb); got != tt.want {
+				t.Errorf("OffloadTarget = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateOffloads(t *testing.T) {
+	mappingWith := func(top, nested *Offload) *Mapping {
+		return &Mapping{Collections: []Collection{
+			{Name: "staff", SourceTable: "staff", Embedded: []Embedded{{
+				SourceTable: "documents", FieldName: "documents", Offload: top,
+				Embedded: []Embedded{{SourceTable: "pages", FieldName: "pages", Offload: nested}},
+			}}},
+			{Name: "staff_docs", SourceTable: "staff_docs"},
+		}}
+	}
+	tests := []struct {
+		name    string
+		m       *Mapping
+		wantErr bool
+	}{
+		{"none", mappingWith(nil, nil), false},
+		{"gridfs", mappingWith(&Offload{Strategy: OffloadGridFS, Target: "docs"}, nil), false},
+		{"side collection", mappingWith(&Offload{Strategy: OffloadCollection}, nil), false},
+		{"clashes with a collection", mappingWith(&Offload{Strategy: OffloadCollection, Target: "staff_docs"}, nil), true},
+		{"unknown strategy", mappingWith(&Offload{Strategy: "s3"}, nil), true},
+		{"invalid target", mappingWith(&Offload{Strategy: OffloadGridFS, Target: "a.b"}, nil), true},
+		{"nested", mappingWith(nil, &Offload{Strategy: OffloadGridFS}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.m.ValidateOffloads()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOffloads() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package mapping
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Orderings of the collections a migration writes.
+const (
+	// OrderReferences migrates a collection after the collections whose
+	// documents it references, and after its depends_on. It is the default.
+	OrderReferences = "references"
+	// OrderManual ignores references and follows only depends_on and
+	// priority.
+	OrderManual = "manual"
+)
+
+// Dependencies returns the collections of the mapping that are migrated
+// before c: those listed in its depends_on and, unless the order is manual,
+// those holding a reference to its source table, whose documents its
+// references point to.
+func (m *Mapping) Dependencies(c Collection) []string {
+	var deps []string
+	add := func(name string) {
+		if name != c.Name && !slices.Contains(deps, name) {
+			deps = append(deps, name)
+		}
+	}
+	if m.Order != OrderManual {
+		for _, parent := range m.Collections {
+			if parent.SourceTable == c.SourceTable {
+				continue
+			}
+			for _, ref := range parent.References {
+				if ref.SourceTable == c.SourceTable {
+					add(parent.Name)
+				}
+			}
+		}
+	}
+	for _, name := range c.DependsOn {
+		add(name)
+	}
+	return deps
+}
+
+// ValidateOrder checks the order setting, that every depends_on names
+// another collection of the mapping, and that the dependencies have no
+// cycle.
+func (m *Mapping) ValidateOrder() error {
+	if m.Order != "" && m.Order != OrderReferences && m.Order != OrderManual {
+		return fmt.Errorf("unsupported order %q (use references or manual)", m.Order)
+	}
+	names := make(map[string]bool, len(m.Collections))
+	for _, c := range m.Collections {
+		names[c.Name] = true
+	}
+	for _, c := range m.Collections {
+		for _, dep := range c.DependsOn {
+			if dep == c.Name {
+				return fmt.Errorf("collection %s depends on itself", c.Name)
+			}
+			if !names[dep] {
+				return fmt.Errorf("collection %s depends on %s, which is not in the mapping", c.Name, dep)
+			}
+		}
+	}
+	_, err := m.Stages(m.Collections)
+	return err
+}
+
+// Stages splits cols into the stages they are migrated in. A collection
+// depends only on collections of earlier stages; dependencies outside cols
+// are taken as already migrated. Within a stage, collections with a higher
+// priority come first, then as cols orders them. The members of a
+// consistency group stay together, in the order the group lists them, in
+// the first stage after the dependencies of all of them. It fails when
+// dependencies form a cycle.
+func (m *Mapping) Stages(cols []Collection) ([][]Collection, error) {
+	// Each node is a collection or the members of a consistency group
+	type node struct {
+		cols     []Collection
+		priority int
+		deps     map[int]bool
+	}
+	var nodes []*node
+	nodeOf := make(map[string]int, len(cols))
+	groupNode := make(map[string]int)
+	for _, c := range cols {
+		g := m.GroupOf(c.Name)
+		if g != nil {
+			if i, ok := groupNode[g.Name]; ok {
+				nodeOf[c.Name] = i
+				continue
+			}
+			groupNode[g.Name] = len(nodes)
+		}
+		nodeOf[c.Name] = len(nodes)
+		nodes = append(nodes, &node{deps: make(map[int]bool)})
+	}
+	for _, c := range cols {
+		n := nodes[nodeOf[c.Name]]
+		n.cols = append(n.cols, c)
+		if len(n.cols) == 1 || c.Priority > n.priority {
+			n.priority = c.Priority
+		}
+	}
+	for _, n := range nodes {
+		if g := m.GroupOf(n.cols[0].Name); g != nil {
+			n.cols = groupMembers(g, n.cols)
+		}
+	}
+	for _, c := range cols {
+		i := nodeOf[c.Name]
+		for _, dep := range m.Dependencies(c) {
+			if j, ok := nodeOf[dep]; ok && j != i {
+				nodes[i].deps[j] = true
+			}
+		}
+	}
+
+	var stages [][]Collection
+	stage := make([]int, len(nodes)) // 1-based stage of each placed node
+	for placed := 0; placed < len(nodes); {
+		var ready []int
+		for i, n := range nodes {
+			if stage[i] != 0 {
+				continue
+			}
+			met := true
+			for j := range n.deps {
+				if stage[j] == 0 {
+					met = false
+					break
+				}
+			}
+			if met {
+				ready = append(ready, i)
+			}
+		}
+		if len(ready) == 0 {
+			var waiting []string
+			for i, n := range nodes {
+				if stage[i] == 0 {
+					for _, c := range n.cols {
+						waiting = append(waiting, c.Name)
+					}
+				}
+			}
+			return nil, fmt.Errorf("the dependencies of collections %s form a cycle; break it with order: manual and depends_on",
+				strings.Join(waiting, ", "))
+		}
+		slices.SortStableFunc(ready, func(a, b int) int {
+			return nodes[b].priority - nodes[a].priority
+		})
+		var s []Collection
+		for _, i := range ready {
+			stage[i] = len(stages) + 1
+			s = append(s, nodes[i].cols...)
+		}
+		stages = append(stages, s)
+		placed += len(ready)
+	}
+	return stages, nil
+}
+
+// groupMembers orders cols, the members of g, as g lists them.
+func groupMembers(g *ConsistencyGroup, cols []Collection) []Collection {
+	ordered := make([]Collection, 0, len(cols))
+	for _, name := range g.Collections {
+		for _, c := range cols {
+			if c.Name == name {
+				ordered = append(ordered, c)
+			}
+		}
+	}
+	return ordered
+}
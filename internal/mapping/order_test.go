@@ -0,0 +1,135 @@
+package mapping
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// orderMapping has customers referencing orders, and orders referencing
+// order_items, so the references run customers -> orders -> order_items.
+func orderMapping() *Mapping {
+	return &Mapping{Collections: []Collection{
+		{Name: "order_items", SourceTable: "order_items"},
+		{Name: "orders", SourceTable: "orders", References: []Reference{{SourceTable: "order_items", FieldName: "items", JoinColumn: "order_id", ParentColumn: "id"}}},
+		{Name: "products", SourceTable: "products"},
+		{Name: "customers", SourceTable: "customers", References: []Reference{{SourceTable: "orders", FieldName: "orders", JoinColumn: "customer_id", ParentColumn: "id"}}},
+	}}
+}
+
+func stageNames(stages [][]Collection) [][]string {
+	var out [][]string
+	for _, s := range stages {
+		var names []string
+		for _, c := range s {
+			names = append(names, c.Name)
+		}
+		out = append(out, names)
+	}
+	return out
+}
+
+func TestStages(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(m *Mapping)
+		want   [][]string
+	}{
+		{
+			name: "references parent first",
+			want: [][]string{{"products", "customers"}, {"orders"}, {"order_items"}},
+		},
+		{
+			name:   "priority within a stage",
+			modify: func(m *Mapping) { m.Collections[3].Priority = 5 },
+			want:   [][]string{{"customers", "products"}, {"orders"}, {"order_items"}},
+		},
+		{
+			name:   "depends_on",
+			modify: func(m *Mapping) { m.Collections[2].DependsOn = []string{"order_items"} },
+			want:   [][]string{{"customers"}, {"orders"}, {"order_items"}, {"products"}},
+		},
+		{
+			name: "manual ignores references",
+			modify: func(m *Mapping) {
+				m.Order = OrderManual
+				m.Collections[0].DependsOn = []string{"products"}
+			},
+			want: [][]string{{"orders", "products", "customers"}, {"order_items"}},
+		},
+		{
+			name: "group stays together",
+			modify: func(m *Mapping) {
+				m.ConsistencyGroups = []ConsistencyGroup{{Name: "catalog", Collections: []string{"products", "order_items"}}}
+			},
+			want: [][]string{{"customers"}, {"orders"}, {"products", "order_items"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := orderMapping()
+			if tt.modify != nil {
+				tt.modify(m)
+			}
+			stages, err := m.Stages(m.Collections)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := stageNames(stages); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Stages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStages_Subset(t *testing.T) {
+	m := orderMapping()
+	// A retry of order_items and customers: orders is taken as migrated
+	stages, err := m.Stages([]Collection{m.Collections[0], m.Collections[3]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stageNames(stages); !reflect.DeepEqual(got, [][]string{{"order_items", "customers"}}) {
+		t.Errorf("Stages(subset) = %v", got)
+	}
+	got := m.ScheduleOrder([]Collection{m.Collections[1], m.Collections[3]})
+	if got[0].Name != "customers" || got[1].Name != "orders" {
+		t.Errorf("ScheduleOrder(subset) = %v", got)
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(m *Mapping)
+		wantErr string
+	}{
+		{"ok", nil, ""},
+		{"bad order", func(m *Mapping) { m.Order = "alphabetical" }, "unsupported order"},
+		{"unknown", func(m *Mapping) { m.Collections[0].DependsOn = []string{"invoices"} }, "invoices, which is not in the mapping"},
+		{"self", func(m *Mapping) { m.Collections[0].DependsOn = []string{"order_items"} }, "depends on itself"},
+		{"cycle", func(m *Mapping) { m.Collections[3].DependsOn = []string{"order_items"} }, "order_items, orders, customers form a cycle"},
+		{"cycle broken by manual order", func(m *Mapping) {
+			m.Order = OrderManual
+			m.Collections[3].DependsOn = []string{"order_items"}
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := orderMapping()
+			if tt.modify != nil {
+				tt.modify(m)
+			}
+			err := m.ValidateOrder()
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("ValidateOrder() = %v, want %q", err, tt.wantErr)
+			}
+			if err != nil && tt.name == "cycle" {
+				// The order still places every collection
+				if got := m.ScheduleOrder(m.Collections); len(got) != len(m.Collections) {
+					t.Errorf("ScheduleOrder() = %v", got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package mapping
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CanaryQuery is a representative application query run against the target
+// after indexes are built, to check that it is served by an index. Its filter
+// is either a literal Extended JSON document or a set of equality Fields
+// whose values are taken from a sampled document.
+type CanaryQuery struct {
+	Name       string `yaml:"name" json:"name"`
+	Collection string `yaml:"collection" json:"collection"`
+	// Filter is the query filter as an Extended JSON document.
+	Filter string `yaml:"filter,omitempty" json:"filter,omitempty"`
+	// Fields are equality-matched against values from a sampled document.
+	Fields []string `yaml:"fields,omitempty" json:"fields,omitempty"`
+	// Sort is an optional Extended JSON sort document.
+	Sort  string `yaml:"sort,omitempty" json:"sort,omitempty"`
+	Limit int64  `yaml:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// Validate checks the query definition. Filter and sort contents are left
+// to the server; only their shape is checked here.
+func (q CanaryQuery) Validate() error {
+	if q.Name == "" {
+		return fmt.Errorf("query name is required")
+	}
+	if q.Collection == "" {
+		return fmt.Errorf("query %s: collection is required", q.Name)
+	}
+	if (q.Filter == "") == (len(q.Fields) == 0) {
+		return fmt.Errorf("query %s: set exactly one of filter or fields", q.Name)
+	}
+	var doc map[string]json.RawMessage
+	if q.Filter != "" {
+		if err := json.Unmarshal([]byte(q.Filter), &doc); err != nil {
+			return fmt.Errorf("query %s: filter must be a JSON document: %w", q.Name, err)
+		}
+	}
+	if q.Sort != "" {
+		if err := json.Unmarshal([]byte(q.Sort), &doc); err != nil {
+			return fmt.Errorf("query %s: sort must be a JSON document: %w", q.Name, err)
+		}
+	}
+	for _, f := range q.Fields {
+		if err := validatePath(f); err != nil {
+			return fmt.Errorf("query %s: %w", q.Name, err)
+		}
+	}
+	if q.Limit < 0 {
+		return fmt.Errorf("query %s: limit must not be negative", q.Name)
+	}
+	return nil
+}
+
+// ValidateQueries checks every canary query and that each one targets a
+// mapped collection or view.
+func (m *Mapping) ValidateQueries() error {
+	known := make(map[string]bool, len(m.Collections)+len(m.Views))
+	for _, c := range m.Collections {
+		known[c.Name] = true
+	}
+	for _, v := range m.Views {
+		known[v.Name] = true
+	}
+	names := make(map[string]bool, len(m.Queries))
+	for _, q := range m.Queries {
+		if err := q.Validate(); err != nil {
+			return err
+		}
+		if !known[q.Collection] {
+			return fmt.Errorf("query %s: %s is not a mapped collection or view", q.Name, q.Collection)
+		}
+		if names[q.Name] {
+			return fmt.Errorf("query %s: name is used more than once", q.Name)
+		}
+		names[q.Name] = true
+	}
+	return nil
+}
+
+// CanaryQueries returns the registered canary queries, or queries generated
+// from the mapping's foreign key access paths when none are registered.
+func (m *Mapping) CanaryQueries() []CanaryQuery {
+	if len(m.Queries) > 0 {
+		return m.Queries
+	}
+	return m.AccessPathQueries()
+}
+
+// AccessPathQueries generates a canary query for every foreign key kept as a
+// reference: the lookup of a referencing collection by its join columns,
+// which an application following the relationship will run.
+func (m *Mapping) AccessPathQueries() []CanaryQuery {
+	var queries []CanaryQuery
+	seen := make(map[string]bool)
+	for _, parent := range m.Collections {
+		for _, ref := range parent.References {
+			child := m.collectionFor(ref.SourceTable)
+			if child == nil {
+				continue
+			}
+			var fields []string
+			for _, col := range ref.JoinKeys() {
+				if field, ok := FieldTarget(child.Fields, col); ok {
+					fields = append(fields, field)
+				}
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			name := fmt.Sprintf("%s_by_%s", child.Name, strings.ReplaceAll(strings.Join(fields, "_"), ".", "_"))
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			queries = append(queries, CanaryQuery{Name: name, Collection: child.Name, Fields: fields})
+		}
+	}
+	return queries
+}
+
+func (m *Mapping) collectionFor(table string) *Collection {
+	for i := range m.Collections {
+		if m.Collections[i].SourceTable == table {
+			return &m.Collections[i]
+		}
+	}
+	return nil
+}
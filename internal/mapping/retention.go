@@ -0,0 +1,56 @@
+package mapping
+
+import "fmt"
+
+// MaxRetentionDays is the longest TTL MongoDB accepts: expireAfterSeconds is
+// a 32-bit integer.
+const MaxRetentionDays = 24855
+
+// RetentionPolicy ages documents out of a collection by a date column of its
+// source table. ExpireAfterDays creates a TTL index that deletes documents
+// once the column is older than that many days; ArchiveAfterDays is the age
+// at which an Atlas Online Archive rule moves them to cheaper storage. Either
+// may be zero to leave that part out.
+type RetentionPolicy struct {
+	Column           string `yaml:"column" json:"column"`
+	ExpireAfterDays  int    `yaml:"expire_after_days,omitempty" json:"expire_after_days,omitempty"`
+	ArchiveAfterDays int    `yaml:"archive_after_days,omitempty" json:"archive_after_days,omitempty"`
+}
+
+// Validate checks that the policy names a column and expires or archives
+// documents, and that archiving happens before expiry.
+func (p *RetentionPolicy) Validate() error {
+	if p.Column == "" {
+		return fmt.Errorf("retention: column is required")
+	}
+	if p.ExpireAfterDays < 0 || p.ArchiveAfterDays < 0 {
+		return fmt.Errorf("retention: days must not be negative")
+	}
+	if p.ExpireAfterDays > MaxRetentionDays {
+		return fmt.Errorf("retention: expire_after_days must be at most %d", MaxRetentionDays)
+	}
+	if p.ExpireAfterDays == 0 && p.ArchiveAfterDays == 0 {
+		return fmt.Errorf("retention: set expire_after_days, archive_after_days or both")
+	}
+	if p.ExpireAfterDays > 0 && p.ArchiveAfterDays >= p.ExpireAfterDays {
+		return fmt.Errorf("retention: archive_after_days (%d) must be less than expire_after_days (%d)", p.ArchiveAfterDays, p.ExpireAfterDays)
+	}
+	return nil
+}
+
+// ValidateRetention checks the retention policy of every collection and that
+// its column still reaches the documents.
+func (m *Mapping) ValidateRetention() error {
+	for _, col := range m.Collections {
+		if col.Retention == nil {
+			continue
+		}
+		if err := col.Retention.Validate(); err != nil {
+			return fmt.Errorf("collection %s: %w", col.Name, err)
+		}
+		if _, ok := col.RootField(col.Retention.Column); !ok {
+			return fmt.Errorf("collection %s: retention column %s is excluded from the documents", col.Name, col.Retention.Column)
+		}
+	}
+	return nil
+}
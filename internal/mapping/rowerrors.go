@@ -0,0 +1,41 @@
+package mapping
+
+import "fmt"
+
+// Row error policies: what the migration does with a source row it cannot
+// turn into a document, such as one with a bad date, invalid UTF-8 or a
+// document over the 16 MB BSON limit.
+const (
+	OnErrorFail       = "fail"       // fail the collection; the default
+	OnErrorSkip       = "skip"       // leave the row out and count it
+	OnErrorQuarantine = "quarantine" // leave the row out and record it in ErrorCollection
+)
+
+// ErrorCollection is the target collection quarantined rows are recorded
+// in, one document per row with the collection, the source table, the
+// row's primary key and the error.
+const ErrorCollection = "_reloquent_errors"
+
+// ErrorPolicy returns the collection's row error policy.
+func (c *Collection) ErrorPolicy() string {
+	if c.OnError == "" {
+		return OnErrorFail
+	}
+	return c.OnError
+}
+
+// ValidateErrorPolicies checks that every collection's row error policy is
+// known and that no collection takes the name of ErrorCollection.
+func (m *Mapping) ValidateErrorPolicies() error {
+	for _, c := range m.Collections {
+		if c.Name == ErrorCollection {
+			return fmt.Errorf("collection name %s is reserved for quarantined rows", ErrorCollection)
+		}
+		switch c.OnError {
+		case "", OnErrorFail, OnErrorSkip, OnErrorQuarantine:
+		default:
+			return fmt.Errorf("collection %s: unsupported on_error %q (use fail, skip or quarantine)", c.Name, c.OnError)
+		}
+	}
+	return nil
+}
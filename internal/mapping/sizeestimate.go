@@ -1,7 +1,11 @@
 package mapping
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
 )
 
 // CollectionSizeEstimate holds per-collection BSON document size estimates.
@@ -13,13 +17,62 @@ type CollectionSizeEstimate struct {
 	AvgRowCount     int64  `json:"avg_row_count"`
 	ExceedsLimit    bool   `json:"exceeds_limit"`
 	Warning         string `json:"warning,omitempty"`
+	InlineLOBs      []string `json:"inline_lobs,omitempty"` // table.column of large objects stored in the document
+	// OffloadCandidates are the embedded fields, largest first, whose
+	// offloading brings documents that exceed the limit under it.
+	OffloadCandidates []string `json:"offload_candidates,omitempty"`
+	// Measured is set when embedded arrays are sized from child counts
+	// measured in the source rather than from table row counts.
+	Measured bool `json:"measured,omitempty"`
+}
+
+// EmbedCounts are the child-per-parent counts of embedded arrays measured
+// in the source, keyed by EmbedKey.
+type EmbedCounts map[string]schema.ChildCounts
+
+// EmbedKey names an embedded field by its collection and the field names
+// leading to it, as "customers.orders.items".
+func EmbedKey(collection string, fields ...string) string {
+	return strings.Join(append([]string{collection}, fields...), ".")
+}
+
+// measures reports whether any embedded array of the collection is measured.
+func (c EmbedCounts) measures(collection string) bool {
+	for k := range c {
+		if strings.HasPrefix(k, collection+".") {
+			return true
+		}
+	}
+	return false
 }
 
 const bsonDocumentLimit = 16 * 1024 * 1024 // 16MB
 
+// lobReferenceBytes is the size of the reference left in the document for
+// a large object offloaded to GridFS or S3.
+const lobReferenceBytes = 64
+
+// offloadReferenceBytes is the size of the reference left in the document
+// for an embedded field offloaded to GridFS or a side collection.
+const offloadReferenceBytes = 64
+
 // EstimateSizes estimates per-collection BSON document sizes from source schema and mapping.
 // It flags collections that may exceed the 16MB BSON document limit.
-func EstimateSizes(s *schema.Schema, m *Mapping) []CollectionSizeEstimate {
+// Large object columns count according to their strategy in tm: skipped
+// ones are left out, offloaded ones count as a reference, and inlined ones
+// are listed in InlineLOBs, since a single value can exceed the limit. A
+// nil tm inlines every large object. Offloaded embedded fields count as a
+// reference; when documents still exceed the limit, the fields to offload
+// are listed in OffloadCandidates.
+func EstimateSizes(s *schema.Schema, m *Mapping, tm *typemap.TypeMap) []CollectionSizeEstimate {
+	return EstimateSizesMeasured(s, m, tm, nil)
+}
+
+// EstimateSizesMeasured estimates document sizes like EstimateSizes, sizing
+// the embedded arrays found in counts from their measured average and
+// largest number of children per parent. The others assume children are
+// spread evenly, with ten times the average in the largest document.
+func EstimateSizesMeasured(s *schema.Schema, m *Mapping, tm *typemap.TypeMap, counts EmbedCounts) []CollectionSizeEstimate {
 	tableMap := make(map[string]*schema.Table, len(s.Tables))
 	for i := range s.Tables {
 		tableMap[s.Tables[i].Name] = &s.Tables[i]
@@ -27,13 +80,13 @@ func EstimateSizes(s *schema.Schema, m *Mapping) []CollectionSizeEstimate {
 
 	var results []CollectionSizeEstimate
 	for _, col := range m.Collections {
-		est := estimateCollection(col, tableMap)
+		est := estimateCollection(col, tableMap, tm, counts)
 		results = append(results, est)
 	}
 	return results
 }
 
-func estimateCollection(col Collection, tableMap map[string]*schema.Table) CollectionSizeEstimate {
+func estimateCollection(col Collection, tableMap map[string]*schema.Table, tm *typemap.TypeMap, counts EmbedCounts) CollectionSizeEstimate {
 	srcTable := tableMap[col.SourceTable]
 	if srcTable == nil {
 		return CollectionSizeEstimate{
@@ -43,17 +96,28 @@ func estimateCollection(col Collection, tableMap map[string]*schema.Table) Colle
 	}
 
 	// Base row size from source table
-	baseRowBytes := estimateRowSize(srcTable)
+	baseRowBytes, inlineLOBs := lobRowSize(srcTable, tm)
 	parentRowCount := srcTable.RowCount
 	if parentRowCount == 0 {
 		parentRowCount = 1
 	}
 
-	// Add embedded document sizes
+	// Add embedded document sizes. An offloaded field leaves only a
+	// reference in the document.
 	var embeddedBytes int64
 	var maxEmbeddedBytes int64
+	var candidates []embeddedSize
 	for _, emb := range col.Embedded {
-		avgEmb, maxEmb := estimateEmbeddedSize(emb, tableMap, parentRowCount)
+		lobs := &inlineLOBs
+		if emb.Offload != nil && emb.Offload.Strategy == OffloadGridFS {
+			lobs = new([]string) // stored in a GridFS file, not a document
+		}
+		avgEmb, maxEmb := estimateEmbeddedSize(emb, tableMap, tm, parentRowCount, lobs, EmbedKey(col.Name, emb.FieldName), counts)
+		if emb.Offload != nil {
+			avgEmb, maxEmb = offloadReferenceBytes, offloadReferenceBytes
+		} else {
+			candidates = append(candidates, embeddedSize{emb.FieldName, maxEmb})
+		}
 		embeddedBytes += avgEmb
 		maxEmbeddedBytes += maxEmb
 	}
@@ -71,48 +135,65 @@ func estimateCollection(col Collection, tableMap map[string]*schema.Table) Colle
 		AvgDocSizeBytes: avgDocSize,
 		MaxDocSizeBytes: maxDocSize,
 		AvgRowCount:     parentRowCount,
+		InlineLOBs:      inlineLOBs,
+		Measured:        counts.measures(col.Name),
 	}
 
 	if maxDocSize > bsonDocumentLimit {
 		est.ExceedsLimit = true
-		est.Warning = "Estimated maximum document size exceeds 16MB BSON limit. Consider reducing embedding depth or splitting into references."
+		est.OffloadCandidates = offloadCandidates(candidates, maxDocSize)
+		if len(est.OffloadCandidates) > 0 {
+			est.Warning = "Estimated maximum document size exceeds 16MB BSON limit. Offload " + strings.Join(est.OffloadCandidates, ", ") + " to GridFS or a side collection, or reduce embedding depth."
+		} else {
+			est.Warning = "Estimated maximum document size exceeds 16MB BSON limit. Consider reducing embedding depth or splitting into references."
+		}
+	} else if len(inlineLOBs) > 0 {
+		est.Warning = "Large objects stored in the document (" + strings.Join(inlineLOBs, ", ") + ") can exceed the 16MB BSON limit on their own. Offload them to GridFS or S3, or skip them, in the type mapping step."
 	}
 
 	return est
 }
 
-func estimateEmbeddedSize(emb Embedded, tableMap map[string]*schema.Table, parentRowCount int64) (avgBytes, maxBytes int64) {
+// embeddedSize is the estimated worst-case size of an embedded field,
+// before BSON overhead.
+type embeddedSize struct {
+	field    string
+	maxBytes int64
+}
+
+// offloadCandidates picks embedded fields to offload, largest first, until
+// the estimated maximum document size is under the BSON limit. It returns
+// nil when offloading every field would not be enough.
+func offloadCandidates(fields []embeddedSize, maxDocSize int64) []string {
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].maxBytes > fields[j].maxBytes })
+	var out []string
+	for _, f := range fields {
+		if maxDocSize <= bsonDocumentLimit {
+			break
+		}
+		maxDocSize -= (f.maxBytes - offloadReferenceBytes) * 15 / 10
+		out = append(out, f.field)
+	}
+	if maxDocSize > bsonDocumentLimit {
+		return nil
+	}
+	return out
+}
+
+func estimateEmbeddedSize(emb Embedded, tableMap map[string]*schema.Table, tm *typemap.TypeMap, parentRowCount int64, inlineLOBs *[]string, key string, counts EmbedCounts) (avgBytes, maxBytes int64) {
 	childTable := tableMap[emb.SourceTable]
 	if childTable == nil {
 		return 0, 0
 	}
 
-	childRowSize := estimateRowSize(childTable)
+	childRowSize, lobs := lobRowSize(childTable, tm)
+	*inlineLOBs = append(*inlineLOBs, lobs...)
 
-	if emb.Relationship == "single" {
-		// 1:1 — one subdocument per parent
-		avgBytes = childRowSize
-		maxBytes = childRowSize
-	} else {
-		// 1:N — array of subdocuments
-		avgChildrenPerParent := int64(1)
-		if parentRowCount > 0 && childTable.RowCount > 0 {
-			avgChildrenPerParent = childTable.RowCount / parentRowCount
-			if avgChildrenPerParent < 1 {
-				avgChildrenPerParent = 1
-			}
-		}
-		avgBytes = childRowSize * avgChildrenPerParent
-		// Worst case: 10x average (skewed distribution)
-		maxBytes = childRowSize * avgChildrenPerParent * 10
-	}
-
-	// Recursively add nested embeds
-	for _, nested := range emb.Embedded {
-		nestedAvg, nestedMax := estimateEmbeddedSize(nested, tableMap, childTable.RowCount)
-		if emb.Relationship == "single" {
-			avgBytes += nestedAvg
-			maxBytes += nestedMax
+	// 1:1 — one subdocument per parent; 1:N — an array of subdocuments
+	avgChildren, maxChildren := 1.0, int64(1)
+	if emb.Relationship != "single" {
+		if c, ok := counts[key]; ok {
+			avgChildren, maxChildren = c.Avg, c.Max
 		} else {
 			avgChildrenPerParent := int64(1)
 			if parentRowCount > 0 && childTable.RowCount > 0 {
@@ -121,14 +202,51 @@ func estimateEmbeddedSize(emb Embedded, tableMap map[string]*schema.Table, paren
 					avgChildrenPerParent = 1
 				}
 			}
-			avgBytes += nestedAvg * avgChildrenPerParent
-			maxBytes += nestedMax * avgChildrenPerParent * 10
+			// Worst case: 10x average (skewed distribution)
+			avgChildren, maxChildren = float64(avgChildrenPerParent), avgChildrenPerParent*10
 		}
 	}
+	avgBytes = int64(float64(childRowSize) * avgChildren)
+	maxBytes = childRowSize * maxChildren
+
+	// Recursively add nested embeds
+	for _, nested := range emb.Embedded {
+		nestedAvg, nestedMax := estimateEmbeddedSize(nested, tableMap, tm, childTable.RowCount, inlineLOBs, key+"."+nested.FieldName, counts)
+		avgBytes += int64(float64(nestedAvg) * avgChildren)
+		maxBytes += nestedMax * maxChildren
+	}
 
 	return avgBytes, maxBytes
 }
 
+// lobRowSize estimates a table's row size once its large objects are
+// skipped or offloaded, and lists the ones stored inline.
+func lobRowSize(t *schema.Table, tm *typemap.TypeMap) (int64, []string) {
+	size := estimateRowSize(t)
+	var inline []string
+	for _, col := range t.Columns {
+		if !typemap.IsLOB(col.DataType) {
+			continue
+		}
+		strategy := typemap.LOBInline
+		if tm != nil {
+			strategy = tm.LOB(t.Name, col.Name).Strategy
+		}
+		switch strategy {
+		case typemap.LOBInline:
+			inline = append(inline, t.Name+"."+col.Name)
+		case typemap.LOBSkip:
+			size -= estimateColumnSize(col.DataType)
+		default:
+			size += lobReferenceBytes - estimateColumnSize(col.DataType)
+		}
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size, inline
+}
+
 func estimateRowSize(t *schema.Table) int64 {
 	if t.SizeBytes > 0 && t.RowCount > 0 {
 		return t.SizeBytes / t.RowCount
@@ -0,0 +1,143 @@
+package mapping
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+func lobSchema() *schema.Schema {
+	return &schema.Schema{Tables: []schema.Table{
+		{Name: "staff", RowCount: 10, Columns: []schema.Column{
+			{Name: "id", DataType: "integer"},
+			{Name: "name", DataType: "text"},
+			{Name: "picture", DataType: "bytea"},
+		}},
+		{Name: "documents", RowCount: 100, Columns: []schema.Column{
+			{Name: "staff_id", DataType: "integer"},
+			{Name: "body", DataType: "bytea"},
+		}},
+	}}
+}
+
+func TestEstimateSizes_LOBStrategies(t *testing.T) {
+	s := lobSchema()
+	m := &Mapping{Collections: []Collection{{
+		Name: "staff", SourceTable: "staff",
+		Embedded: []Embedded{{SourceTable: "documents", FieldName: "documents",
+			Relationship: "array", JoinColumn: "staff_id", ParentColumn: "id"}},
+	}}}
+
+	inline := EstimateSizes(s, m, nil)[0]
+	if strings.Join(inline.InlineLOBs, ",") != "staff.picture,documents.body" {
+		t.Errorf("inline LOBs = %v", inline.InlineLOBs)
+	}
+	if !strings.Contains(inline.Warning, "GridFS or S3") {
+		t.Errorf("warning = %q, want offload advice", inline.Warning)
+	}
+
+	tm := typemap.ForDatabase("postgresql")
+	for _, c := range []typemap.LOBColumn{
+		{Table: "staff", Column: "picture", Strategy: typemap.LOBSkip},
+		{Table: "documents", Column: "body", Strategy: typemap.LOBGridFS},
+	} {
+		if err := tm.SetLOB(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	offloaded := EstimateSizes(s, m, tm)[0]
+	if len(offloaded.InlineLOBs) != 0 || offloaded.Warning != "" {
+		t.Errorf("offloaded = %+v, want no inline LOBs or warning", offloaded)
+	}
+	if offloaded.AvgDocSizeBytes >= inline.AvgDocSizeBytes {
+		t.Errorf("avg size %d with LOBs offloaded, want less than %d inline",
+			offloaded.AvgDocSizeBytes, inline.AvgDocSizeBytes)
+	}
+}
+
+func TestEstimateSizes_ExceedsLimit(t *testing.T) {
+	s := lobSchema()
+	// 20MB per row, as measured by discovery
+	s.Tables[0].SizeBytes = 20 * 1024 * 1024 * s.Tables[0].RowCount
+	m := &Mapping{Collections: []Collection{{Name: "staff", SourceTable: "staff"}}}
+
+	est := EstimateSizes(s, m, nil)[0]
+	if !est.ExceedsLimit {
+		t.Errorf("ExceedsLimit = false for %d byte documents", est.MaxDocSizeBytes)
+	}
+	if !strings.Contains(est.Warning, "16MB") {
+		t.Errorf("warning = %q", est.Warning)
+	}
+}
+
+func TestEstimateSizes_OffloadCandidates(t *testing.T) {
+	s := lobSchema()
+	// 1MB per document row, ten per staff member
+	s.Tables[1].SizeBytes = 1024 * 1024 * s.Tables[1].RowCount
+	m := &Mapping{Collections: []Collection{{
+		Name: "staff", SourceTable: "staff",
+		Embedded: []Embedded{{SourceTable: "documents", FieldName: "documents",
+			Relationship: "array", JoinColumn: "staff_id", ParentColumn: "id"}},
+	}}}
+
+	est := EstimateSizes(s, m, nil)[0]
+	if !est.ExceedsLimit || strings.Join(est.OffloadCandidates, ",") != "documents" {
+		t.Fatalf("estimate = %+v, want documents suggested for offload", est)
+	}
+	if !strings.Contains(est.Warning, "Offload documents") {
+		t.Errorf("warning = %q", est.Warning)
+	}
+
+	for _, strategy := range []string{OffloadGridFS, OffloadCollection} {
+		m.Collections[0].Embedded[0].Offload = &Offload{Strategy: strategy}
+		est = EstimateSizes(s, m, nil)[0]
+		if est.ExceedsLimit || len(est.OffloadCandidates) != 0 {
+			t.Errorf("%s: estimate = %+v, want documents under the limit", strategy, est)
+		}
+	}
+	if !strings.Contains(strings.Join(est.InlineLOBs, ","), "documents.body") {
+		t.Errorf("inline LOBs = %v, want side collection documents to keep their LOBs", est.InlineLOBs)
+	}
+}
+
+func TestEstimateSizesMeasured(t *testing.T) {
+	s := lobSchema()
+	// 1MB per document row, ten per staff member on average
+	s.Tables[1].SizeBytes = 1024 * 1024 * s.Tables[1].RowCount
+	tm := typemap.ForDatabase("postgresql")
+	m := &Mapping{Collections: []Collection{{
+		Name: "staff", SourceTable: "staff",
+		Embedded: []Embedded{{SourceTable: "documents", FieldName: "documents",
+			Relationship: "array", JoinColumn: "staff_id", ParentColumn: "id"}},
+	}}}
+
+	// assuming ten times the average, documents exceed the limit
+	if est := EstimateSizesMeasured(s, m, tm, nil)[0]; !est.ExceedsLimit || est.Measured {
+		t.Fatalf("unmeasured estimate = %+v, want over the limit", est)
+	}
+
+	tests := []struct {
+		name       string
+		counts     schema.ChildCounts
+		wantExceed bool
+	}{
+		{"evenly spread", schema.ChildCounts{Parents: 10, Children: 100, Avg: 10, P50: 10, P90: 10, P99: 10, Max: 10}, false},
+		{"skewed", schema.ChildCounts{Parents: 10, Children: 100, Avg: 10, P50: 2, P90: 20, P99: 40, Max: 40}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est := EstimateSizesMeasured(s, m, tm, EmbedCounts{EmbedKey("staff", "documents"): tt.counts})[0]
+			if !est.Measured {
+				t.Error("Measured = false")
+			}
+			if est.ExceedsLimit != tt.wantExceed {
+				t.Errorf("ExceedsLimit = %v for a %d byte maximum, want %v", est.ExceedsLimit, est.MaxDocSizeBytes, tt.wantExceed)
+			}
+			if want := int64(10 * 1024 * 1024 * 13 / 10); est.AvgDocSizeBytes < want {
+				t.Errorf("AvgDocSizeBytes = %d, want at least %d", est.AvgDocSizeBytes, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package mapping
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// StaleReference is a table or column named by the mapping that the source
+// schema no longer has. Path is the collection name, followed by the field
+// names of any embedded tables leading to the reference.
+type StaleReference struct {
+	Path   string `json:"path"`
+	Table  string `json:"table"`
+	Column string `json:"column,omitempty"`
+	Use    string `json:"use"`
+}
+
+// String describes the reference, e.g. "customers.orders: column
+// orders.customer_id (join column) no longer exists".
+func (r StaleReference) String() string {
+	if r.Column == "" {
+		return fmt.Sprintf("%s: table %s (%s) no longer exists", r.Path, r.Table, r.Use)
+	}
+	return fmt.Sprintf("%s: column %s.%s (%s) no longer exists", r.Path, r.Table, r.Column, r.Use)
+}
+
+// StaleReferences returns every table and column the mapping refers to that
+// is missing from the schema: source tables, join and parent columns,
+// rename and exclude transformations, and field mappings.
+func (m *Mapping) StaleReferences(s *schema.Schema) []StaleReference {
+	tables := make(map[string][]string, len(s.Tables))
+	for _, t := range s.Tables {
+		cols := make([]string, len(t.Columns))
+		for i, c := range t.Columns {
+			cols[i] = c.Name
+		}
+		tables[t.Name] = cols
+	}
+
+	var stale []StaleReference
+	missing := func(path, table, column, use string) {
+		stale = append(stale, StaleReference{Path: path, Table: table, Column: column, Use: use})
+	}
+	// checkColumns reports join columns absent from a table.
+	checkColumns := func(path, table string, keys []string, use string) {
+		cols, ok := tables[table]
+		if !ok {
+			return
+		}
+		for _, c := range keys {
+			if !contains(cols, c) {
+				missing(path, table, c, use)
+			}
+		}
+	}
+	// checkTable reports a table's transformations and field mappings that
+	// name missing columns, and whether the table itself exists.
+	checkTable := func(path, table, use string, ts []Transformation, fields []FieldMapping) bool {
+		cols, ok := tables[table]
+		if !ok {
+			missing(path, table, "", use)
+			return false
+		}
+		raw := append([]string(nil), cols...)
+		for _, t := range ts {
+			if t.Operation == "compute" && !contains(raw, t.TargetField) {
+				raw = append(raw, t.TargetField)
+			}
+		}
+		for _, t := range ts {
+			if (t.Operation == "rename" || t.Operation == "exclude") && !contains(raw, t.SourceField) {
+				missing(path, table, t.SourceField, t.Operation+" transformation")
+			}
+		}
+		mapped := transformedColumns(append([]string(nil), cols...), ts)
+		for _, f := range fields {
+			if f.Prefix != "" {
+				if !slices.ContainsFunc(mapped, func(c string) bool { return strings.HasPrefix(c, f.Prefix) }) {
+					missing(path, table, f.Prefix+"*", "field group")
+				}
+				continue
+			}
+			if !contains(mapped, f.Column) {
+				missing(path, table, f.Column, "field mapping")
+			}
+		}
+		return true
+	}
+
+	var checkEmbedded func(path, parent string, embs []Embedded)
+	checkEmbedded = func(path, parent string, embs []Embedded) {
+		for _, e := range embs {
+			p := path + "." + e.FieldName
+			if !checkTable(p, e.SourceTable, "embedded table", e.Transformations, e.Fields) {
+				continue
+			}
+			checkColumns(p, e.SourceTable, e.JoinKeys(), "join column")
+			checkColumns(p, parent, e.ParentKeys(), "parent column")
+			checkEmbedded(p, e.SourceTable, e.Embedded)
+		}
+	}
+
+	for _, c := range m.Collections {
+		if !checkTable(c.Name, c.SourceTable, "source table", c.Transformations, c.Fields) {
+			continue
+		}
+		checkEmbedded(c.Name, c.SourceTable, c.Embedded)
+		for _, r := range c.References {
+			p := c.Name + "." + r.FieldName
+			if _, ok := tables[r.SourceTable]; !ok {
+				missing(p, r.SourceTable, "", "referenced table")
+				continue
+			}
+			checkColumns(p, r.SourceTable, r.JoinKeys(), "join column")
+			checkColumns(p, c.SourceTable, r.ParentKeys(), "parent column")
+		}
+	}
+	return stale
+}
@@ -1,6 +1,9 @@
 package mapping
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/reloquent/reloquent/internal/schema"
 )
 
@@ -13,6 +16,9 @@ import (
 //   - M:N (join tables) → dissolve into arrays on both sides
 //   - Self-referencing FK → reference (not embed)
 //   - Cycles → break by converting deepest edge to reference
+//
+// Columns sharing a prefix are not grouped; they are returned as
+// Suggestions (see SuggestFieldGroups) for the user to accept or reject.
 func Suggest(s *schema.Schema, selectedTables []string, rootTables ...string) *Mapping {
 	selected := make(map[string]bool)
 	for _, t := range selectedTables {
@@ -141,12 +147,9 @@ func Suggest(s *schema.Schema, selectedTables []string, rootTables ...string) *M
 					continue
 				}
 				if selfRefs[child.table] {
-					col.References = append(col.References, Reference{
-						SourceTable:  child.table,
-						FieldName:    child.table + "_ref",
-						JoinColumn:   child.fk.Columns[0],
-						ParentColumn: child.fk.ReferencedColumns[0],
-					})
+					ref := Reference{SourceTable: child.table, FieldName: child.table + "_ref"}
+					ref.SetJoin(child.fk.Columns, child.fk.ReferencedColumns)
+					col.References = append(col.References, ref)
 				} else {
 					rel := "array"
 					parentT := tableMap[parent]
@@ -158,13 +161,9 @@ func Suggest(s *schema.Schema, selectedTables []string, rootTables ...string) *M
 						}
 					}
 
-					col.Embedded = append(col.Embedded, Embedded{
-						SourceTable:  child.table,
-						FieldName:    child.table,
-						Relationship: rel,
-						JoinColumn:   child.fk.Columns[0],
-						ParentColumn: child.fk.ReferencedColumns[0],
-					})
+					emb := Embedded{SourceTable: child.table, FieldName: child.table, Relationship: rel}
+					emb.SetJoin(child.fk.Columns, child.fk.ReferencedColumns)
+					col.Embedded = append(col.Embedded, emb)
 					// Continue BFS from this child to find deeper tables
 					queue = append(queue, child.table)
 				}
@@ -189,5 +188,138 @@ func Suggest(s *schema.Schema, selectedTables []string, rootTables ...string) *M
 		}
 	}
 
-	return &Mapping{Collections: collections}
+	m := &Mapping{Collections: collections}
+	m.Suggestions = SuggestFieldGroups(s, m)
+	return m
+}
+
+// FieldGroupSuggestion proposes grouping the columns of a table that share a
+// prefix into a subdocument, e.g. billing_street and billing_city into
+// billing.street and billing.city. Accepting it adds Field() to the table's
+// field mappings.
+type FieldGroupSuggestion struct {
+	Collection string   `json:"collection"`
+	Embedded   []int    `json:"embedded,omitempty"` // index path to the embedded table; empty for the root table
+	Table      string   `json:"table"`
+	Prefix     string   `json:"prefix"`
+	Target     string   `json:"target"`
+	Columns    []string `json:"columns"`
+}
+
+// Field returns the field mapping that applies the suggestion.
+func (g FieldGroupSuggestion) Field() FieldMapping {
+	return FieldMapping{Prefix: g.Prefix, Target: g.Target}
+}
+
+// SuggestFieldGroups finds columns that share a prefix up to their first
+// underscore (billing_, shipping_) and proposes nesting each set under a
+// subdocument named after the prefix. A prefix needs at least two columns,
+// and is skipped when:
+//   - its columns are already mapped, grouped or excluded
+//   - it covers every column of the table, which is a naming convention
+//     rather than a group
+//   - the subdocument name is already a field of the document
+//
+// Key columns are left alone, as they identify the row rather than
+// describe it.
+func SuggestFieldGroups(s *schema.Schema, m *Mapping) []FieldGroupSuggestion {
+	if s == nil || m == nil {
+		return nil
+	}
+	var out []FieldGroupSuggestion
+	for _, col := range m.Collections {
+		out = append(out, suggestTableGroups(s, col.Name, nil, col.SourceTable, col.Transformations, col.Fields, embeddedNames(col.Embedded, col.References))...)
+		var walk func(embedded []Embedded, path []int)
+		walk = func(embedded []Embedded, path []int) {
+			for i, e := range embedded {
+				p := append(append([]int{}, path...), i)
+				out = append(out, suggestTableGroups(s, col.Name, p, e.SourceTable, e.Transformations, e.Fields, embeddedNames(e.Embedded, nil))...)
+				walk(e.Embedded, p)
+			}
+		}
+		walk(col.Embedded, nil)
+	}
+	return out
+}
+
+func embeddedNames(embedded []Embedded, refs []Reference) []string {
+	var names []string
+	for _, e := range embedded {
+		names = append(names, e.FieldName)
+	}
+	for _, r := range refs {
+		names = append(names, r.FieldName)
+	}
+	return names
+}
+
+func suggestTableGroups(s *schema.Schema, collection string, path []int, table string, ts []Transformation, fields []FieldMapping, children []string) []FieldGroupSuggestion {
+	keys := make(map[string]bool)
+	for _, t := range s.Tables {
+		if t.Name != table {
+			continue
+		}
+		if t.PrimaryKey != nil {
+			for _, c := range t.PrimaryKey.Columns {
+				keys[c] = true
+			}
+		}
+		for _, fk := range t.ForeignKeys {
+			for _, c := range fk.Columns {
+				keys[c] = true
+			}
+		}
+	}
+
+	// Document fields the group's subdocument must not collide with.
+	taken := make(map[string]bool)
+	for _, name := range children {
+		taken[name] = true
+	}
+	mapped := make(map[string]bool)
+	for _, f := range fields {
+		if f.Column != "" {
+			mapped[f.Column] = true
+		}
+	}
+
+	groups := make(map[string][]string)
+	attributes := 0
+	for _, c := range TableColumns(s, table, ts) {
+		target, ok := FieldTarget(fields, c)
+		if ok {
+			taken[strings.SplitN(target, ".", 2)[0]] = true
+		}
+		if keys[c] {
+			continue
+		}
+		attributes++
+		if mapped[c] || !ok || target != c {
+			continue
+		}
+		i := strings.Index(c, "_")
+		if i <= 0 || i == len(c)-1 {
+			continue
+		}
+		prefix := c[:i+1]
+		groups[prefix] = append(groups[prefix], c)
+	}
+
+	var out []FieldGroupSuggestion
+	for prefix, cols := range groups {
+		target := strings.TrimSuffix(prefix, "_")
+		if len(cols) < 2 || len(cols) == attributes || taken[target] {
+			continue
+		}
+		out = append(out, FieldGroupSuggestion{
+			Collection: collection,
+			Embedded:   path,
+			Table:      table,
+			Prefix:     prefix,
+			Target:     target,
+			Columns:    cols,
+		})
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Prefix < out[b].Prefix })
+	return out
 }
@@ -1,6 +1,8 @@
 package mapping
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/reloquent/reloquent/internal/schema"
@@ -432,6 +434,114 @@ func TestSuggest_AllCyclicFKs_FallbackToRoots(t *testing.T) {
 	}
 }
 
+func fieldGroupSchema() *schema.Schema {
+	cols := func(names ...string) []schema.Column {
+		out := make([]schema.Column, len(names))
+		for i, n := range names {
+			out[i] = schema.Column{Name: n, DataType: "text"}
+		}
+		return out
+	}
+	return &schema.Schema{
+		DatabaseType: "postgresql",
+		Tables: []schema.Table{
+			{Name: "customers", RowCount: 10,
+				Columns:    cols("id", "name", "billing_street", "billing_city", "shipping_street", "shipping_city", "phone_home"),
+				PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}},
+			},
+			{Name: "orders", RowCount: 50,
+				Columns:    cols("order_id", "customer_id", "order_total", "order_date"),
+				PrimaryKey: &schema.PrimaryKey{Columns: []string{"order_id"}},
+				ForeignKeys: []schema.ForeignKey{
+					{Name: "fk_orders_cust", Columns: []string{"customer_id"},
+						ReferencedTable: "customers", ReferencedColumns: []string{"id"}},
+				},
+			},
+		},
+	}
+}
+
+func TestSuggest_FieldGroups(t *testing.T) {
+	m := Suggest(fieldGroupSchema(), []string{"customers", "orders"})
+
+	// Suggestions are proposed, not applied.
+	cust := findCollection(m, "customers")
+	if cust == nil {
+		t.Fatal("customers collection not found")
+	}
+	if len(cust.Fields) != 0 {
+		t.Errorf("fields = %v, want none applied", cust.Fields)
+	}
+
+	// orders' attributes all start with order_, a naming convention rather
+	// than a group; phone_ has a single column.
+	if len(m.Suggestions) != 2 {
+		t.Fatalf("suggestions = %+v, want billing and shipping", m.Suggestions)
+	}
+	billing := m.Suggestions[0]
+	if billing.Collection != "customers" || billing.Table != "customers" || len(billing.Embedded) != 0 {
+		t.Errorf("billing location = %+v", billing)
+	}
+	if billing.Prefix != "billing_" || billing.Target != "billing" {
+		t.Errorf("billing = %q -> %q", billing.Prefix, billing.Target)
+	}
+	if len(billing.Columns) != 2 || billing.Columns[0] != "billing_street" || billing.Columns[1] != "billing_city" {
+		t.Errorf("billing columns = %v", billing.Columns)
+	}
+	if f := billing.Field(); f.Prefix != "billing_" || f.Target != "billing" || f.Column != "" {
+		t.Errorf("field = %+v", f)
+	}
+	if m.Suggestions[1].Prefix != "shipping_" {
+		t.Errorf("second suggestion = %q, want shipping_", m.Suggestions[1].Prefix)
+	}
+}
+
+func TestSuggestFieldGroups_Skips(t *testing.T) {
+	s := fieldGroupSchema()
+	tests := []struct {
+		name   string
+		fields []FieldMapping
+		want   []string
+	}{
+		{"none mapped", nil, []string{"billing_", "shipping_"}},
+		{"group accepted", []FieldMapping{{Prefix: "billing_", Target: "billing"}}, []string{"shipping_"}},
+		{"column mapped", []FieldMapping{{Column: "shipping_city", Target: "city"}}, []string{"billing_"}},
+		{"column excluded", []FieldMapping{{Column: "billing_city", Exclude: true}}, []string{"shipping_"}},
+		{"name taken", []FieldMapping{{Column: "name", Target: "shipping"}}, []string{"billing_"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapping{Collections: []Collection{{Name: "customers", SourceTable: "customers", Fields: tt.fields}}}
+			var got []string
+			for _, g := range SuggestFieldGroups(s, m) {
+				got = append(got, g.Prefix)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("prefixes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestFieldGroups_Embedded(t *testing.T) {
+	s := fieldGroupSchema()
+	s.Tables[1].Columns = append(s.Tables[1].Columns, schema.Column{Name: "gift_note"}, schema.Column{Name: "gift_wrap"}, schema.Column{Name: "status"})
+	s.Tables[1].Columns[2].Name = "total"
+	m := &Mapping{Collections: []Collection{{
+		Name: "customers", SourceTable: "customers",
+		Fields: []FieldMapping{{Prefix: "billing_", Target: "billing"}, {Prefix: "shipping_", Target: "shipping"}},
+		Embedded: []Embedded{{SourceTable: "orders", FieldName: "orders", Relationship: "array",
+			JoinColumn: "customer_id", ParentColumn: "id"}},
+	}}}
+	got := SuggestFieldGroups(s, m)
+	if len(got) != 1 {
+		t.Fatalf("suggestions = %+v, want gift_ on orders", got)
+	}
+	if got[0].Table != "orders" || len(got[0].Embedded) != 1 || got[0].Embedded[0] != 0 || got[0].Prefix != "gift_" {
+		t.Errorf("suggestion = %+v", got[0])
+	}
+}
+
 // helpers
 
 func collectionNames(m *Mapping) map[string]bool {
@@ -450,3 +560,34 @@ func findCollection(m *Mapping, name string) *Collection {
 	}
 	return nil
 }
+
+func TestSuggest_CompositeForeignKey(t *testing.T) {
+	s := &schema.Schema{
+		DatabaseType: "postgresql",
+		Tables: []schema.Table{
+			{Name: "orders", RowCount: 100},
+			{Name: "order_items", RowCount: 500,
+				ForeignKeys: []schema.ForeignKey{
+					{Name: "fk_items_order", Columns: []string{"tenant_id", "order_id"},
+						ReferencedTable: "orders", ReferencedColumns: []string{"tenant_id", "id"}},
+				},
+			},
+		},
+	}
+	m := Suggest(s, []string{"orders", "order_items"})
+
+	orders := findCollection(m, "orders")
+	if orders == nil || len(orders.Embedded) != 1 {
+		t.Fatalf("orders collection = %+v, want one embedded table", orders)
+	}
+	emb := orders.Embedded[0]
+	if !reflect.DeepEqual(emb.JoinKeys(), []string{"tenant_id", "order_id"}) {
+		t.Errorf("join columns = %v", emb.JoinKeys())
+	}
+	if !reflect.DeepEqual(emb.ParentKeys(), []string{"tenant_id", "id"}) {
+		t.Errorf("parent columns = %v", emb.ParentKeys())
+	}
+	if err := m.ValidateJoins(); err != nil {
+		t.Errorf("ValidateJoins: %v", err)
+	}
+}
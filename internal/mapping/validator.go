@@ -0,0 +1,35 @@
+package mapping
+
+import "fmt"
+
+// How strictly MongoDB enforces the $jsonSchema validator generated for a
+// collection. They are MongoDB's validationLevel values.
+const (
+	ValidationOff      = "off"      // no validator
+	ValidationModerate = "moderate" // inserts and updates of valid documents are checked
+	ValidationStrict   = "strict"   // every insert and update is checked
+)
+
+// ValidationLevels lists the supported validation levels.
+var ValidationLevels = []string{ValidationOff, ValidationModerate, ValidationStrict}
+
+// ValidationLevel returns the collection's validation level; collections
+// without one are not validated.
+func (c *Collection) ValidationLevel() string {
+	if c.Validation == "" {
+		return ValidationOff
+	}
+	return c.Validation
+}
+
+// ValidateValidation checks every collection's validation level.
+func (m *Mapping) ValidateValidation() error {
+	for _, c := range m.Collections {
+		switch c.Validation {
+		case "", ValidationOff, ValidationModerate, ValidationStrict:
+		default:
+			return fmt.Errorf("collection %s: unsupported validation %q (use off, moderate or strict)", c.Name, c.Validation)
+		}
+	}
+	return nil
+}
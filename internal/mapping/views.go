@@ -0,0 +1,122 @@
+package mapping
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// View is a materialized aggregation view: a summary collection (e.g.
+// orders_by_day) kept up to date by running Pipeline over the Source
+// collection and merging the results into Name with $merge.
+type View struct {
+	Name   string `yaml:"name" json:"name"`
+	Source string `yaml:"source" json:"source"`
+	// Pipeline is the aggregation pipeline as an Extended JSON array of
+	// stages. It must not contain $merge or $out; the merge stage is added
+	// from On, WhenMatched and WhenNotMatched.
+	Pipeline       string   `yaml:"pipeline" json:"pipeline"`
+	On             []string `yaml:"on,omitempty" json:"on,omitempty"`
+	WhenMatched    string   `yaml:"when_matched,omitempty" json:"when_matched,omitempty"`
+	WhenNotMatched string   `yaml:"when_not_matched,omitempty" json:"when_not_matched,omitempty"`
+}
+
+// MergeOn returns the fields $merge matches existing documents on.
+func (v View) MergeOn() []string {
+	if len(v.On) == 0 {
+		return []string{"_id"}
+	}
+	return v.On
+}
+
+// MatchedAction returns the $merge whenMatched action, defaulting to replace.
+func (v View) MatchedAction() string {
+	if v.WhenMatched == "" {
+		return "replace"
+	}
+	return v.WhenMatched
+}
+
+// NotMatchedAction returns the $merge whenNotMatched action, defaulting to insert.
+func (v View) NotMatchedAction() string {
+	if v.WhenNotMatched == "" {
+		return "insert"
+	}
+	return v.WhenNotMatched
+}
+
+var (
+	whenMatchedActions    = []string{"replace", "keepExisting", "merge", "fail"}
+	whenNotMatchedActions = []string{"insert", "discard", "fail"}
+)
+
+// Validate checks the view definition. Stage contents are left to the server;
+// only the pipeline's shape is checked here.
+func (v View) Validate() error {
+	if v.Name == "" {
+		return fmt.Errorf("view name is required")
+	}
+	if v.Source == "" {
+		return fmt.Errorf("view %s: source collection is required", v.Name)
+	}
+	if v.Name == v.Source {
+		return fmt.Errorf("view %s: cannot merge into its own source", v.Name)
+	}
+
+	var stages []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(v.Pipeline), &stages); err != nil {
+		return fmt.Errorf("view %s: pipeline must be a JSON array of stages: %w", v.Name, err)
+	}
+	for i, stage := range stages {
+		if len(stage) != 1 {
+			return fmt.Errorf("view %s: stage %d must have exactly one operator", v.Name, i)
+		}
+		for op := range stage {
+			if !strings.HasPrefix(op, "$") {
+				return fmt.Errorf("view %s: stage %d: %q is not a stage operator", v.Name, i, op)
+			}
+			if op == "$merge" || op == "$out" {
+				return fmt.Errorf("view %s: pipeline must not contain %s; it is added when the view is built", v.Name, op)
+			}
+		}
+	}
+
+	if !contains(whenMatchedActions, v.MatchedAction()) {
+		return fmt.Errorf("view %s: unsupported when_matched %q (use %s)", v.Name, v.WhenMatched, strings.Join(whenMatchedActions, ", "))
+	}
+	if !contains(whenNotMatchedActions, v.NotMatchedAction()) {
+		return fmt.Errorf("view %s: unsupported when_not_matched %q (use %s)", v.Name, v.WhenNotMatched, strings.Join(whenNotMatchedActions, ", "))
+	}
+	return nil
+}
+
+// ValidateViews checks every view and that each one reads from a mapped
+// collection or a view defined before it.
+func (m *Mapping) ValidateViews() error {
+	known := make(map[string]bool, len(m.Collections)+len(m.Views))
+	for _, c := range m.Collections {
+		known[c.Name] = true
+	}
+	for _, v := range m.Views {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+		if !known[v.Source] {
+			return fmt.Errorf("view %s: source %s is not a mapped collection or earlier view", v.Name, v.Source)
+		}
+		if known[v.Name] {
+			return fmt.Errorf("view %s: name is already used by a collection or view", v.Name)
+		}
+		known[v.Name] = true
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package mapping
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// WatermarkRange bounds the rows of a collection's root table read by a
+// delta migration: those whose watermark column is greater than After and at
+// most UpTo. Both bounds are SQL literals; an empty After reads from the
+// start and an empty UpTo leaves the range open (the table was empty when
+// the run began).
+type WatermarkRange struct {
+	Column string `json:"column"`
+	After  string `json:"after,omitempty"`
+	UpTo   string `json:"up_to,omitempty"`
+}
+
+// Filter combines the range with a collection's row filter into one SQL
+// predicate.
+func (r WatermarkRange) Filter(base string) string {
+	var parts []string
+	if base != "" {
+		parts = append(parts, "("+base+")")
+	}
+	if r.After != "" {
+		parts = append(parts, fmt.Sprintf("%s > %s", r.Column, r.After))
+	}
+	if r.UpTo != "" {
+		parts = append(parts, fmt.Sprintf("%s <= %s", r.Column, r.UpTo))
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// WatermarkLiteral renders a watermark column value read from the source as
+// a SQL literal both PostgreSQL and Oracle accept: numbers as they are,
+// times as TIMESTAMP literals in the time's own zone. It returns "" for
+// NULL.
+func WatermarkLiteral(v interface{}) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "", nil
+	case int:
+		return strconv.Itoa(x), nil
+	case int32:
+		return strconv.FormatInt(int64(x), 10), nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case time.Time:
+		return "TIMESTAMP '" + x.Format("2006-01-02 15:04:05.999999") + "'", nil
+	case string:
+		if _, err := strconv.ParseFloat(x, 64); err == nil {
+			return x, nil
+		}
+		return "", fmt.Errorf("unsupported watermark value %q", x)
+	default:
+		s := fmt.Sprint(v)
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return s, nil
+		}
+		return "", fmt.Errorf("unsupported watermark value of type %T", v)
+	}
+}
+
+// isWatermarkType reports whether a column type can be a watermark: an
+// ever-increasing number or timestamp.
+func isWatermarkType(dataType string) bool {
+	t := strings.ToLower(dataType)
+	for _, k := range []string{"int", "serial", "number", "numeric", "date", "timestamp"} {
+		if strings.Contains(t, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateWatermarks checks that every collection's watermark column is a
+// numeric or timestamp column of its root table, when a schema is given.
+func (m *Mapping) ValidateWatermarks(s *schema.Schema) error {
+	for _, c := range m.Collections {
+		if c.Watermark == "" || s == nil {
+			continue
+		}
+		col := findColumn(s, c.SourceTable, c.Watermark)
+		if col == nil {
+			return fmt.Errorf("collection %s: watermark column %s not found in %s", c.Name, c.Watermark, c.SourceTable)
+		}
+		if !isWatermarkType(col.DataType) {
+			return fmt.Errorf("collection %s: watermark column %s must be a number or timestamp, not %s", c.Name, c.Watermark, col.DataType)
+		}
+	}
+	return nil
+}
+
+func findColumn(s *schema.Schema, table, column string) *schema.Column {
+	for _, t := range s.Tables {
+		if t.Name != table {
+			continue
+		}
+		for i := range t.Columns {
+			if t.Columns[i].Name == column {
+				return &t.Columns[i]
+			}
+		}
+	}
+	return nil
+}
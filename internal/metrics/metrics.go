@@ -0,0 +1,295 @@
+// Package metrics keeps counters, histograms and gauges and writes them in
+// the Prometheus text exposition format, for scraping without a client
+// library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are histogram bucket upper bounds, in seconds, suited to
+// API request latencies.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Sample is one value of a gauge read at scrape time, with the values of
+// its labels in the order the gauge declares them.
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// Registry holds metrics in the order they were registered. It is safe for
+// concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+	names    map[string]bool
+}
+
+// family is a registered metric that writes its own samples.
+type family interface {
+	write(w io.Writer) error
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// Counter registers a counter with the given label names.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name, help, labels}, values: make(map[string]*series)}
+	r.register(name, c)
+	return c
+}
+
+// Histogram registers a histogram with the given bucket upper bounds, in
+// increasing order, and label names.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{desc: desc{name, help, labels}, buckets: buckets, values: make(map[string]*histogramSeries)}
+	r.register(name, h)
+	return h
+}
+
+// GaugeFunc registers a gauge whose samples fn returns at every scrape, for
+// values another component already tracks.
+func (r *Registry) GaugeFunc(name, help string, fn func() []Sample, labels ...string) {
+	r.register(name, &gaugeFunc{desc: desc{name, help, labels}, fn: fn})
+}
+
+// WriteText writes every metric in the text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+	for _, f := range families {
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler serves the registry's metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteText(w)
+	})
+}
+
+// desc names a metric and its labels.
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d desc) header(w io.Writer, typ string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, typ)
+	return err
+}
+
+// key joins label values into a map key. It panics when their number does
+// not match the label names, as registering them wrong is a programming
+// error.
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs formats label names and values as {a="x",b="y"}, with extra
+// appended, or nothing when there are none.
+func (d desc) labelPairs(values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(d.labels)+len(extra)/2)
+	for i, l := range d.labels {
+		pairs = append(pairs, l+`="`+escapeLabel(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+type series struct {
+	labels []string
+	value  float64
+}
+
+// Counter is a value that only goes up, per combination of label values.
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*series
+}
+
+// Inc adds one to the counter for the label values.
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add adds v, which must not be negative, to the counter for the label
+// values.
+func (c *Counter) Add(v float64, labels ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: counter %s decreased", c.name))
+	}
+	k := c.key(labels)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.values[k]
+	if !ok {
+		s = &series{labels: append([]string(nil), labels...)}
+		c.values[k] = s
+	}
+	s.value += v
+}
+
+func (c *Counter) write(w io.Writer) error {
+	if err := c.header(w, "counter"); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range sortedKeys(c.values) {
+		s := c.values[k]
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(s.labels), formatValue(s.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Histogram counts observations in buckets, per combination of label
+// values.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramSeries
+}
+
+// Observe records v for the label values.
+func (h *Histogram) Observe(v float64, labels ...string) {
+	k := h.key(labels)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.values[k]
+	if !ok {
+		s = &histogramSeries{labels: append([]string(nil), labels...), counts: make([]uint64, len(h.buckets))}
+		h.values[k] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	if err := h.header(w, "histogram"); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, k := range sortedKeys(h.values) {
+		s := h.values[k]
+		var cumulative uint64
+		for i, b := range h.buckets {
+			cumulative += s.counts[i]
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(s.labels, "le", formatValue(b)), cumulative); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, h.labelPairs(s.labels, "le", "+Inf"), s.count,
+			h.name, h.labelPairs(s.labels), formatValue(s.sum),
+			h.name, h.labelPairs(s.labels), s.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type gaugeFunc struct {
+	desc
+	fn func() []Sample
+}
+
+func (g *gaugeFunc) write(w io.Writer) error {
+	if err := g.header(w, "gauge"); err != nil {
+		return err
+	}
+	samples := g.fn()
+	sort.SliceStable(samples, func(i, j int) bool {
+		return strings.Join(samples[i].Labels, "\xff") < strings.Join(samples[j].Labels, "\xff")
+	})
+	for _, s := range samples {
+		g.key(s.Labels)
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelPairs(s.Labels), formatValue(s.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := NewRegistry()
+	checks := r.Counter("checks_total", "Checks run.", "check", "result")
+	latency := r.Histogram("request_seconds", "Request latency.", []float64{0.1, 1}, "route")
+	r.GaugeFunc("docs_written", "Documents written.", func() []Sample {
+		return []Sample{
+			{Labels: []string{"orders"}, Value: 20},
+			{Labels: []string{`a"b`}, Value: 1.5},
+		}
+	}, "collection")
+
+	checks.Inc("row_count", "pass")
+	checks.Inc("row_count", "pass")
+	checks.Add(3, "sample", "fail")
+	latency.Observe(0.05, "/api/state")
+	latency.Observe(0.1, "/api/state")
+	latency.Observe(2, "/api/state")
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP checks_total Checks run.
+# TYPE checks_total counter
+checks_total{check="row_count",result="pass"} 2
+checks_total{check="sample",result="fail"} 3
+# HELP request_seconds Request latency.
+# TYPE request_seconds histogram
+request_seconds_bucket{route="/api/state",le="0.1"} 2
+request_seconds_bucket{route="/api/state",le="1"} 2
+request_seconds_bucket{route="/api/state",le="+Inf"} 3
+request_seconds_sum{route="/api/state"} 2.15
+request_seconds_count{route="/api/state"} 3
+# HELP docs_written Documents written.
+# TYPE docs_written gauge
+docs_written{collection="a\"b"} 1.5
+docs_written{collection="orders"} 20
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText =\n%s\nwant\n%s", got, want)
+	}
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Header().Get("Content-Type") != ContentType || !strings.Contains(w.Body.String(), "checks_total") {
+		t.Errorf("Handler = %q, %q", w.Header().Get("Content-Type"), w.Body.String())
+	}
+}
+
+func TestRegistry_Misuse(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(r *Registry)
+	}{
+		{"duplicate", func(r *Registry) { r.Counter("x", ""); r.Counter("x", "") }},
+		{"label count", func(r *Registry) { r.Counter("x", "", "a").Inc() }},
+		{"negative", func(r *Registry) { r.Counter("x", "").Add(-1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("want a panic")
+				}
+			}()
+			tt.fn(NewRegistry())
+		})
+	}
+}
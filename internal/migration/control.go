@@ -0,0 +1,75 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// PhasePaused is the phase of a run paused with a Control.
+const PhasePaused = "paused"
+
+// ErrPaused is the cancellation cause of a run stopped to be paused, on
+// platforms such as Spark that cannot suspend a job in place.
+var ErrPaused = errors.New("migration paused")
+
+// Control pauses and resumes a running migration. The native executor
+// suspends at the next bulk write or collection boundary, so no batch is
+// left half written. A nil Control never pauses.
+type Control struct {
+	mu     sync.Mutex
+	resume chan struct{} // closed on Resume; nil while not paused
+}
+
+// NewControl returns a control in the running state.
+func NewControl() *Control {
+	return &Control{}
+}
+
+// Pause suspends the run at its next boundary.
+func (c *Control) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resume == nil {
+		c.resume = make(chan struct{})
+	}
+}
+
+// Resume lets the run continue.
+func (c *Control) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resume != nil {
+		close(c.resume)
+		c.resume = nil
+	}
+}
+
+// Paused reports whether the run is paused.
+func (c *Control) Paused() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resume != nil
+}
+
+// Wait blocks while the run is paused, until it is resumed or ctx is done.
+func (c *Control) Wait(ctx context.Context) error {
+	if c == nil {
+		return ctx.Err()
+	}
+	c.mu.Lock()
+	ch := c.resume
+	c.mu.Unlock()
+	if ch == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-ch:
+		return ctx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -37,6 +37,29 @@ type CollectionStatus struct {
 	DocsTotal       int64   `yaml:"docs_total" json:"docs_total"`
 	PercentComplete float64 `yaml:"percent_complete" json:"percent_complete"`
 	Error           string  `yaml:"error,omitempty" json:"error,omitempty"`
+
+	// BytesWritten is the BSON size of the documents written, when the
+	// executor measures it.
+	BytesWritten int64 `yaml:"bytes_written,omitempty" json:"bytes_written,omitempty"`
+
+	// Elapsed is how long the collection took, when the executor tracks it.
+	Elapsed time.Duration `yaml:"elapsed,omitempty" json:"elapsed,omitempty"`
+
+	// DocsArchived is the number of root rows the collection's archive
+	// policy diverted to S3, when the executor writes the archive.
+	DocsArchived int64 `yaml:"docs_archived,omitempty" json:"docs_archived,omitempty"`
+
+	// RowsSkipped and RowsQuarantined are the source rows the collection's
+	// error policy left out because they could not be converted, when the
+	// executor applies it; quarantined rows are recorded in
+	// mapping.ErrorCollection.
+	RowsSkipped     int64 `yaml:"rows_skipped,omitempty" json:"rows_skipped,omitempty"`
+	RowsQuarantined int64 `yaml:"rows_quarantined,omitempty" json:"rows_quarantined,omitempty"`
+
+	// Group is the consistency group the collection is in, and Snapshot the
+	// source snapshot the executor read the group from, if it pinned one.
+	Group    string `yaml:"group,omitempty" json:"group,omitempty"`
+	Snapshot string `yaml:"snapshot,omitempty" json:"snapshot,omitempty"`
 }
 
 // FailureAction defines what to do when a migration partially fails.
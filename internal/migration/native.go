@@ -0,0 +1,743 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/archive"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/transform"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// DefaultNativeBatchSize is the number of documents sent per InsertMany call.
+const DefaultNativeBatchSize = 1000
+
+// NativeSource is a source reader that can also stream whole tables.
+type NativeSource interface {
+	source.Reader
+	source.Streamer
+}
+
+// NativeExecutor migrates data by streaming rows from the source database
+// straight into MongoDB bulk writes, without Spark. It is intended for
+// small-to-medium migrations where provisioning EMR or Glue is overkill.
+//
+// Embedded tables are loaded into memory grouped by their join column and
+// attached to each parent row as it streams past. Referenced tables are
+// migrated as their own collections; the linking column is left in place.
+// Compute transformations are evaluated on each row as it is read. Root
+// rows a collection's archive policy diverts are written to Parquet files
+// in S3 instead. The members of a consistency group are migrated one after
+// another and, when the source supports it, read from one snapshot. Rows
+// that cannot be converted fail the collection, or are skipped or
+// quarantined as its error policy says. A run paused with its Control
+// suspends between bulk writes.
+type NativeExecutor struct {
+	source    NativeSource
+	target    target.Operator
+	mapping   *mapping.Mapping
+	schema    *schema.Schema
+	batchSize int
+
+	// Delta runs upsert only the root rows in each collection's watermark range.
+	delta      bool
+	watermarks map[string]mapping.WatermarkRange
+
+	archive archive.Sink
+	control *Control
+	zones   map[string]map[string]*time.Location   // by table and column; see SetTimestampZones
+	numbers map[string]map[string]typemap.BSONType // by table and column; see SetNumberTypes
+
+	checkpoints *Checkpoints
+}
+
+// NewNativeExecutor creates a new native (Spark-less) migration executor.
+func NewNativeExecutor(src NativeSource, tgt target.Operator, m *mapping.Mapping, s *schema.Schema) *NativeExecutor {
+	return &NativeExecutor{
+		source:    src,
+		target:    tgt,
+		mapping:   m,
+		schema:    s,
+		batchSize: DefaultNativeBatchSize,
+	}
+}
+
+// SetBatchSize overrides the number of documents written per bulk insert.
+func (e *NativeExecutor) SetBatchSize(n int) {
+	if n > 0 {
+		e.batchSize = n
+	}
+}
+
+// SetDelta switches the executor to a delta run. Collections with a range in
+// watermarks read only the root rows in it and upsert them by primary key,
+// so repeated runs converge; collections without one are skipped.
+func (e *NativeExecutor) SetDelta(watermarks map[string]mapping.WatermarkRange) {
+	e.delta = true
+	e.watermarks = watermarks
+}
+
+// SetArchive sets where the rows diverted by archive policies are written.
+// Without it, collections with an archive policy fail.
+func (e *NativeExecutor) SetArchive(sink archive.Sink) {
+	e.archive = sink
+}
+
+// SetTimestampZones sets the time zone the values of each timestamp column
+// without one are read in, by table and column; see
+// typemap.TypeMap.TimestampLocations. Columns not listed keep the times the
+// source driver returns.
+func (e *NativeExecutor) SetTimestampZones(zones map[string]map[string]*time.Location) {
+	e.zones = zones
+}
+
+// normalizeTimestamps replaces the wall-clock times of the table's
+// timestamp columns without a time zone by the UTC instants they stand for.
+func (e *NativeExecutor) normalizeTimestamps(table string, row map[string]interface{}) {
+	for col, loc := range e.zones[table] {
+		if t, ok := row[col].(time.Time); ok {
+			row[col] = typemap.InZone(t, loc)
+		}
+	}
+}
+
+// SetNumberTypes sets the BSON type the values of each fixed-point column
+// are written as, by table and column; see typemap.TypeMap.NumberTypes.
+// Columns not listed keep the values the source driver returns.
+func (e *NativeExecutor) SetNumberTypes(types map[string]map[string]typemap.BSONType) {
+	e.numbers = types
+}
+
+// convertNumbers replaces the values of the table's fixed-point columns by
+// the BSON type each is written as.
+func (e *NativeExecutor) convertNumbers(table string, row map[string]interface{}) error {
+	for col, t := range e.numbers[table] {
+		v, ok := row[col]
+		if !ok {
+			continue
+		}
+		v, err := typemap.ConvertNumber(v, t)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", table, col, err)
+		}
+		row[col] = v
+	}
+	return nil
+}
+
+// SetControl lets the run be paused and resumed while it migrates.
+func (e *NativeExecutor) SetControl(c *Control) {
+	e.control = c
+}
+
+// Run migrates every collection in the mapping, stage by stage; see
+// mapping.Mapping.Stages. A collection whose dependency failed is failed
+// without being read.
+func (e *NativeExecutor) Run(ctx context.Context, callback StatusCallback) (*Status, error) {
+	return e.run(ctx, e.mapping.Collections, callback)
+}
+
+// RetryFailed re-migrates only the named collections.
+func (e *NativeExecutor) RetryFailed(ctx context.Context, failed []string, callback StatusCallback) (*Status, error) {
+	want := make(map[string]bool, len(failed))
+	for _, name := range failed {
+		want[name] = true
+	}
+	var cols []mapping.Collection
+	for _, c := range e.mapping.Collections {
+		if want[c.Name] {
+			cols = append(cols, c)
+		}
+	}
+	return e.run(ctx, cols, callback)
+}
+
+func (e *NativeExecutor) run(ctx context.Context, cols []mapping.Collection, callback StatusCallback) (*Status, error) {
+	startTime := time.Now()
+	cols = e.mapping.ScheduleOrder(cols)
+
+	status := &Status{
+		Phase:       "running",
+		Collections: make([]CollectionStatus, len(cols)),
+	}
+	for i, c := range cols {
+		total := e.tableRowCount(c.SourceTable)
+		if e.delta {
+			total = 0 // only the changed rows are read
+		}
+		status.Collections[i] = CollectionStatus{
+			Name:      c.Name,
+			State:     "pending",
+			DocsTotal: total,
+		}
+		if g := e.mapping.GroupOf(c.Name); g != nil {
+			status.Collections[i].Group = g.Name
+		}
+		status.Overall.DocsTotal += total
+	}
+	e.notify(callback, status, startTime)
+
+	snap := &groupSnapshot{source: e.source}
+	defer snap.end()
+
+	cancelled := func(err error) (*Status, error) {
+		status.Phase = "failed"
+		status.Errors = append(status.Errors, "migration cancelled")
+		e.notify(callback, status, startTime)
+		return status, err
+	}
+	for i := range cols {
+		if err := e.pausePoint(ctx, callback, status, startTime); err != nil {
+			return cancelled(err)
+		}
+		if err := ctx.Err(); err != nil {
+			return cancelled(err)
+		}
+
+		cs := &status.Collections[i]
+		if _, ok := e.watermarks[cs.Name]; e.delta && !ok {
+			cs.State = "skipped"
+			e.notify(callback, status, startTime)
+			continue
+		}
+		if dep := failedDependency(e.mapping.Dependencies(cols[i]), status.Collections[:i]); dep != "" {
+			// Its references would point at documents that are not there
+			cs.State = "failed"
+			cs.Error = fmt.Sprintf("not migrated: %s, which it depends on, failed", dep)
+			status.Errors = append(status.Errors, fmt.Sprintf("%s: %s", cs.Name, cs.Error))
+			e.notify(callback, status, startTime)
+			continue
+		}
+		cs.State = "running"
+		e.notify(callback, status, startTime)
+
+		colStart := time.Now()
+		id, err := snap.enter(ctx, cs.Group)
+		cs.Snapshot = id
+		if err == nil {
+			err = e.migrateCollection(ctx, &cols[i], status, cs, callback, startTime)
+		}
+		cs.Elapsed = time.Since(colStart)
+		if err != nil && ctx.Err() != nil {
+			// Stopped part way through, not failed; the next pass ends the run
+			cs.State = "interrupted"
+			e.notify(callback, status, startTime)
+			continue
+		}
+		if err != nil {
+			cs.State = "failed"
+			cs.Error = err.Error()
+			status.Errors = append(status.Errors, fmt.Sprintf("%s: %v", cs.Name, err))
+			e.notify(callback, status, startTime)
+			continue
+		}
+		cs.State = "completed"
+		cs.PercentComplete = 100
+		e.notify(callback, status, startTime)
+	}
+	if err := ctx.Err(); err != nil {
+		// Interrupted in its last collection
+		return cancelled(err)
+	}
+
+	status.Phase = finalPhase(status.Collections)
+	status.ElapsedTime = time.Since(startTime)
+	status.EstimatedRemain = 0
+	e.notify(callback, status, startTime)
+
+	if status.Phase != "completed" {
+		return status, fmt.Errorf("migration failed: %s", strings.Join(status.Errors, "; "))
+	}
+	return status, nil
+}
+
+// groupSnapshot keeps one source snapshot open while the members of a
+// consistency group are migrated.
+type groupSnapshot struct {
+	source NativeSource
+	group  string // group the open snapshot is for
+	id     string
+}
+
+// enter opens a snapshot for the collection's group, or keeps the one open
+// for it, and returns its identifier. A collection outside a group, or a
+// source that cannot pin reads, gets none.
+func (g *groupSnapshot) enter(ctx context.Context, group string) (string, error) {
+	if group != "" && group == g.group {
+		return g.id, nil
+	}
+	g.end()
+	s, ok := g.source.(source.Snapshotter)
+	if group == "" || !ok {
+		return "", nil
+	}
+	id, err := s.BeginSnapshot(ctx)
+	if err != nil {
+		return "", fmt.Errorf("opening source snapshot for consistency group %s: %w", group, err)
+	}
+	g.group, g.id = group, id
+	return id, nil
+}
+
+func (g *groupSnapshot) end() {
+	if g.group == "" {
+		return
+	}
+	if s, ok := g.source.(source.Snapshotter); ok {
+		s.EndSnapshot(context.Background())
+	}
+	g.group, g.id = "", ""
+}
+
+func (e *NativeExecutor) migrateCollection(ctx context.Context, c *mapping.Collection, status *Status, cs *CollectionStatus, callback StatusCallback, startTime time.Time) error {
+	transforms, err := transform.NewPipeline(c.Transformations)
+	if err != nil {
+		return err
+	}
+	children := make([]*embeddedRows, 0, len(c.Embedded))
+	for i := range c.Embedded {
+		er, err := e.loadEmbedded(ctx, &c.Embedded[i])
+		if err != nil {
+			return err
+		}
+		children = append(children, er)
+	}
+
+	filter, write := c.LiveFilter(), e.insert
+	if e.delta {
+		// Rows past the watermark are recent; the archive is left as the
+		// last full run wrote it
+		filter = e.watermarks[c.Name].Filter(filter)
+		pk := e.primaryKey(c.SourceTable)
+		if len(pk) == 0 {
+			return fmt.Errorf("delta migration needs a primary key on %s", c.SourceTable)
+		}
+		write = func(ctx context.Context, collection string, docs []interface{}) (int64, error) {
+			return e.upsert(ctx, collection, docs, pk, c.Fields)
+		}
+	}
+
+	rowErrs := &rowErrors{
+		target:     e.target,
+		policy:     c.ErrorPolicy(),
+		collection: c.Name,
+		table:      c.SourceTable,
+		pk:         e.primaryKey(c.SourceTable),
+		cs:         cs,
+		limit:      e.batchSize,
+	}
+	plan, err := e.planCollection(ctx, c)
+	if err != nil {
+		return err
+	}
+	if !e.delta && !plan.resumed() {
+		// Whatever is in the collection was left by an earlier attempt
+		if err := rowErrs.clear(ctx); err != nil {
+			return err
+		}
+		if err := e.clearTarget(ctx, c.Name, "", nil); err != nil {
+			return err
+		}
+	}
+
+	batch := make([]interface{}, 0, e.batchSize)
+	var batchBytes int64
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := write(ctx, c.Name, batch)
+		cs.DocsWritten += n
+		cs.BytesWritten += batchBytes * n / int64(len(batch))
+		status.Overall.DocsWritten += n
+		batch, batchBytes = batch[:0], 0
+		if err != nil {
+			return err
+		}
+		if cs.DocsTotal > 0 {
+			done := cs.DocsWritten + cs.DocsArchived + cs.RowsSkipped + cs.RowsQuarantined
+			cs.PercentComplete = float64(done) / float64(cs.DocsTotal) * 100
+		}
+		e.notify(callback, status, startTime)
+		return e.pausePoint(ctx, callback, status, startTime)
+	}
+
+	if c.Archive != nil && !e.delta {
+		if err := e.archiveRows(ctx, c, cs); err != nil {
+			return err
+		}
+		e.notify(callback, status, startTime)
+	}
+
+	rowFunc := func(row map[string]interface{}) error {
+		e.normalizeTimestamps(c.SourceTable, row)
+		if err := e.convertNumbers(c.SourceTable, row); err != nil {
+			return rowErrs.handle(ctx, row, err)
+		}
+		keep, err := transforms.Apply(row)
+		if err != nil {
+			return rowErrs.handle(ctx, row, err)
+		}
+		if !keep {
+			return nil
+		}
+		for _, child := range children {
+			child.attach(row)
+		}
+		var doc interface{} = mapping.ApplyFields(row, c.Fields)
+		if len(c.FieldOrder) > 0 && !e.delta {
+			// Delta upserts set fields on documents already in place, so
+			// only inserted documents are ordered
+			doc = orderedDoc(doc.(map[string]interface{}), c.FieldOrder, "")
+		}
+		size, err := checkDocument(doc)
+		if err != nil {
+			return rowErrs.handle(ctx, row, err)
+		}
+		batch = append(batch, doc)
+		batchBytes += size
+		if len(batch) >= e.batchSize {
+			return flush()
+		}
+		return nil
+	}
+
+	for _, p := range plan.parts {
+		if plan.done[p] {
+			continue
+		}
+		partFilter := filter
+		if plan.column != "" {
+			partFilter = PartitionFilter(filter, source.QuoteIdent(e.source, plan.column), p)
+			if plan.resumed() {
+				// An interrupted run may have written part of the range
+				if err := e.clearTarget(ctx, c.Name, plan.field, &p); err != nil {
+					return err
+				}
+			}
+		}
+		err = e.source.StreamFilteredRows(ctx, c.SourceTable, partFilter, rowFunc)
+		if err == nil {
+			err = flush()
+		}
+		if qerr := rowErrs.flush(ctx); err == nil {
+			err = qerr
+		}
+		if err != nil {
+			return err
+		}
+		if plan.column != "" && e.checkpoints.Record != nil {
+			e.checkpoints.Record(c.Name, len(plan.parts), p)
+		}
+	}
+	return nil
+}
+
+// pausePoint suspends the run while its control is paused, reporting it as
+// PhasePaused until it is resumed. Rows not yet read stay in the source
+// stream meanwhile.
+func (e *NativeExecutor) pausePoint(ctx context.Context, callback StatusCallback, status *Status, startTime time.Time) error {
+	if !e.control.Paused() {
+		return nil
+	}
+	phase := status.Phase
+	status.Phase = PhasePaused
+	e.notify(callback, status, startTime)
+	err := e.control.Wait(ctx)
+	status.Phase = phase
+	e.notify(callback, status, startTime)
+	return err
+}
+
+// archiveRows writes the root rows the collection's archive policy diverts,
+// as they are in the source table but for masked columns, to Parquet files
+// under the policy's path for the collection.
+func (e *NativeExecutor) archiveRows(ctx context.Context, c *mapping.Collection, cs *CollectionStatus) error {
+	if e.archive == nil {
+		return fmt.Errorf("archiving old rows of %s needs S3 access", c.SourceTable)
+	}
+	table := e.table(c.SourceTable)
+	if table == nil {
+		return fmt.Errorf("archiving old rows of %s needs its columns from the source schema", c.SourceTable)
+	}
+	w := archive.NewWriter(e.archive, c.Archive.Path(c.Name), archive.ColumnsOf(table))
+	err := e.source.StreamFilteredRows(ctx, c.SourceTable, c.Archive.Filter(c.Filter), func(row map[string]interface{}) error {
+		transform.ApplyMasks(row, c.Transformations)
+		return w.Write(ctx, row)
+	})
+	if err == nil {
+		err = w.Close(ctx)
+	}
+	cs.DocsArchived = w.Rows
+	if err != nil {
+		return fmt.Errorf("archiving old rows of %s: %w", c.SourceTable, err)
+	}
+	return nil
+}
+
+func (e *NativeExecutor) insert(ctx context.Context, collection string, docs []interface{}) (int64, error) {
+	return e.target.InsertDocuments(ctx, collection, docs)
+}
+
+// upsert replaces the fields of the documents matching each doc's primary
+// key, inserting those not yet migrated. pk names root-table columns; they
+// are looked up under the fields they are mapped to.
+func (e *NativeExecutor) upsert(ctx context.Context, collection string, docs []interface{}, pk []string, fields []mapping.FieldMapping) (int64, error) {
+	keys := make([]string, len(pk))
+	for i, col := range pk {
+		keys[i] = col
+		if f, ok := mapping.FieldTarget(fields, col); ok {
+			keys[i] = f
+		}
+	}
+	ops := make([]target.WriteOp, 0, len(docs))
+	for _, d := range docs {
+		doc := d.(map[string]interface{})
+		filter := make(map[string]interface{}, len(keys))
+		for _, k := range keys {
+			v, ok := docValue(doc, k)
+			if !ok || v == nil {
+				return 0, fmt.Errorf("document without key field %s", k)
+			}
+			filter[k] = v
+		}
+		ops = append(ops, target.WriteOp{Type: target.WriteUpsert, Filter: filter, Doc: doc})
+	}
+	if err := e.target.ApplyWrites(ctx, collection, ops); err != nil {
+		return 0, err
+	}
+	return int64(len(ops)), nil
+}
+
+// orderedDoc converts a document, and the subdocuments in it, to a bson.D
+// whose fields follow order, so the target stores them in that order.
+func orderedDoc(doc map[string]interface{}, order []string, path string) bson.D {
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	d := make(bson.D, 0, len(doc))
+	for _, k := range mapping.OrderedKeys(keys, order, path) {
+		v := doc[k]
+		if sub, ok := v.(map[string]interface{}); ok {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			v = orderedDoc(sub, order, p)
+		}
+		d = append(d, bson.E{Key: k, Value: v})
+	}
+	return d
+}
+
+// docValue looks up a possibly dotted field path in a document.
+func docValue(doc map[string]interface{}, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	for _, p := range parts[:len(parts)-1] {
+		sub, ok := doc[p].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		doc = sub
+	}
+	v, ok := doc[parts[len(parts)-1]]
+	return v, ok
+}
+
+func (e *NativeExecutor) primaryKey(table string) []string {
+	if e.schema == nil {
+		return nil
+	}
+	for _, t := range e.schema.Tables {
+		if t.Name == table && t.PrimaryKey != nil {
+			return t.PrimaryKey.Columns
+		}
+	}
+	return nil
+}
+
+// embeddedRows holds a child table's rows grouped by join key, ready to be
+// attached to parent rows.
+type embeddedRows struct {
+	def    *mapping.Embedded
+	byKey  map[string][]map[string]interface{}
+	parent []string
+}
+
+// loadEmbedded reads an embedded table (and its nested children, bottom-up)
+// into memory, keyed by the join column values.
+func (e *NativeExecutor) loadEmbedded(ctx context.Context, emb *mapping.Embedded) (*embeddedRows, error) {
+	nested := make([]*embeddedRows, 0, len(emb.Embedded))
+	for i := range emb.Embedded {
+		er, err := e.loadEmbedded(ctx, &emb.Embedded[i])
+		if err != nil {
+			return nil, err
+		}
+		nested = append(nested, er)
+	}
+
+	transforms, err := transform.NewPipeline(emb.Transformations)
+	if err != nil {
+		return nil, fmt.Errorf("embedded table %s: %w", emb.SourceTable, err)
+	}
+	joinCols := emb.JoinKeys()
+	er := &embeddedRows{
+		def:    emb,
+		byKey:  make(map[string][]map[string]interface{}),
+		parent: emb.ParentKeys(),
+	}
+	err = e.source.StreamFilteredRows(ctx, emb.SourceTable, emb.Filter, func(row map[string]interface{}) error {
+		e.normalizeTimestamps(emb.SourceTable, row)
+		if err := e.convertNumbers(emb.SourceTable, row); err != nil {
+			return err
+		}
+		if keep, err := transforms.Apply(row); err != nil || !keep {
+			return err
+		}
+		for _, n := range nested {
+			n.attach(row)
+		}
+		key, ok := rowKey(row, joinCols)
+		if !ok {
+			return nil
+		}
+		for _, col := range joinCols {
+			delete(row, col)
+		}
+		er.byKey[key] = append(er.byKey[key], mapping.ApplyFields(row, emb.Fields))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading embedded table %s: %w", emb.SourceTable, err)
+	}
+	return er, nil
+}
+
+// attach adds the matching child rows to a parent row under the embed's field name.
+func (er *embeddedRows) attach(parent map[string]interface{}) {
+	key, ok := rowKey(parent, er.parent)
+	if !ok {
+		return
+	}
+	matches := er.byKey[key]
+	if order := er.def.FieldOrder; len(order) > 0 {
+		docs := make([]bson.D, len(matches))
+		for i, m := range matches {
+			docs[i] = orderedDoc(m, order, "")
+		}
+		if er.def.Relationship == "single" {
+			if len(docs) > 0 {
+				parent[er.def.FieldName] = docs[0]
+			}
+			return
+		}
+		parent[er.def.FieldName] = docs
+		return
+	}
+	if er.def.Relationship == "single" {
+		if len(matches) > 0 {
+			parent[er.def.FieldName] = matches[0]
+		}
+		return
+	}
+	if matches == nil {
+		matches = []map[string]interface{}{}
+	}
+	parent[er.def.FieldName] = matches
+}
+
+func (e *NativeExecutor) tableRowCount(table string) int64 {
+	if t := e.table(table); t != nil {
+		return t.RowCount
+	}
+	return 0
+}
+
+func (e *NativeExecutor) table(name string) *schema.Table {
+	if e.schema == nil {
+		return nil
+	}
+	for i := range e.schema.Tables {
+		if e.schema.Tables[i].Name == name {
+			return &e.schema.Tables[i]
+		}
+	}
+	return nil
+}
+
+func (e *NativeExecutor) notify(callback StatusCallback, status *Status, startTime time.Time) {
+	status.ElapsedTime = time.Since(startTime)
+	if status.Overall.DocsTotal > 0 {
+		status.Overall.PercentComplete = float64(status.Overall.DocsWritten) / float64(status.Overall.DocsTotal) * 100
+		if status.Overall.PercentComplete > 100 {
+			status.Overall.PercentComplete = 100
+		}
+		if status.Overall.DocsWritten > 0 && status.Overall.DocsWritten < status.Overall.DocsTotal {
+			perDoc := status.ElapsedTime / time.Duration(status.Overall.DocsWritten)
+			status.EstimatedRemain = perDoc * time.Duration(status.Overall.DocsTotal-status.Overall.DocsWritten)
+		}
+	}
+	if callback != nil {
+		callback(status)
+	}
+}
+
+// failedDependency returns the first of deps that failed among the
+// collections already run, or "".
+func failedDependency(deps []string, done []CollectionStatus) string {
+	for _, dep := range deps {
+		for _, c := range done {
+			if c.Name == dep && c.State == "failed" {
+				return dep
+			}
+		}
+	}
+	return ""
+}
+
+// finalPhase derives the overall phase from per-collection outcomes.
+func finalPhase(cols []CollectionStatus) string {
+	failed, completed := 0, 0
+	for _, c := range cols {
+		switch c.State {
+		case "failed":
+			failed++
+		case "completed":
+			completed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return "completed"
+	case completed > 0:
+		return "partial_failure"
+	default:
+		return "failed"
+	}
+}
+
+// rowKey builds a lookup key from the given columns. It reports false if any
+// column is missing or NULL, since NULL never matches in a join.
+func rowKey(row map[string]interface{}, cols []string) (string, bool) {
+	if len(cols) == 0 {
+		return "", false
+	}
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		v, ok := row[c]
+		if !ok || v == nil {
+			return "", false
+		}
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, "\x1f"), true
+}
@@ -0,0 +1,841 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+func nativeFixture() (*source.MockReader, *mapping.Mapping, *schema.Schema) {
+	src := &source.MockReader{
+		TableRows: map[string][]map[string]interface{}{
+			"customers": {
+				{"id": int64(1), "name": "Alice"},
+				{"id": int64(2), "name": "Bob"},
+			},
+			"orders": {
+				{"order_id": int64(10), "customer_id": int64(1), "total": 5.0},
+				{"order_id": int64(11), "customer_id": int64(1), "total": 7.5},
+			},
+			"order_items": {
+				{"item_id": int64(100), "order_id": int64(10), "sku": "A"},
+			},
+			"profiles": {
+				{"customer_id": int64(2), "bio": "hi"},
+			},
+		},
+	}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name:        "customers",
+		SourceTable: "customers",
+		Embedded: []mapping.Embedded{
+			{
+				SourceTable:  "orders",
+				FieldName:    "orders",
+				Relationship: "array",
+				JoinColumn:   "customer_id",
+				ParentColumn: "id",
+				Embedded: []mapping.Embedded{{
+					SourceTable:  "order_items",
+					FieldName:    "items",
+					Relationship: "array",
+					JoinColumn:   "order_id",
+					ParentColumn: "order_id",
+				}},
+			},
+			{
+				SourceTable:  "profiles",
+				FieldName:    "profile",
+				Relationship: "single",
+				JoinColumn:   "customer_id",
+				ParentColumn: "id",
+			},
+		},
+	}}}
+	s := &schema.Schema{Tables: []schema.Table{{Name: "customers", RowCount: 2}}}
+	return src, m, s
+}
+
+func TestNativeExecutor_Run_Embeds(t *testing.T) {
+	src, m, s := nativeFixture()
+	tgt := &target.MockOperator{}
+
+	exec := NewNativeExecutor(src, tgt, m, s)
+	status, err := exec.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.Phase != "completed" {
+		t.Errorf("phase = %q, want completed", status.Phase)
+	}
+	if status.Overall.DocsWritten != 2 {
+		t.Errorf("docs written = %d, want 2", status.Overall.DocsWritten)
+	}
+	if status.Overall.PercentComplete != 100 {
+		t.Errorf("percent = %.1f, want 100", status.Overall.PercentComplete)
+	}
+	if status.Collections[0].BytesWritten == 0 {
+		t.Error("bytes written should be measured")
+	}
+
+	docs := tgt.InsertedDocs["customers"]
+	if len(docs) != 2 {
+		t.Fatalf("inserted %d docs, want 2", len(docs))
+	}
+
+	alice := docs[0].(map[string]interface{})
+	orders, ok := alice["orders"].([]map[string]interface{})
+	if !ok || len(orders) != 2 {
+		t.Fatalf("alice orders = %#v, want 2 embedded orders", alice["orders"])
+	}
+	if _, ok := orders[0]["customer_id"]; ok {
+		t.Error("join column should be removed from embedded rows")
+	}
+	items, ok := orders[0]["items"].([]map[string]interface{})
+	if !ok || len(items) != 1 {
+		t.Errorf("nested items = %#v, want 1 item", orders[0]["items"])
+	}
+	if _, ok := alice["profile"]; ok {
+		t.Error("alice should have no profile")
+	}
+
+	bob := docs[1].(map[string]interface{})
+	if o, ok := bob["orders"].([]map[string]interface{}); !ok || len(o) != 0 {
+		t.Errorf("bob orders = %#v, want empty array", bob["orders"])
+	}
+	profile, ok := bob["profile"].(map[string]interface{})
+	if !ok || profile["bio"] != "hi" {
+		t.Errorf("bob profile = %#v, want single embedded document", bob["profile"])
+	}
+}
+
+func TestNativeExecutor_FieldMappings(t *testing.T) {
+	src, m, s := nativeFixture()
+	m.Collections[0].Fields = []mapping.FieldMapping{
+		{Column: "name", Target: "profile_name.full"},
+	}
+	m.Collections[0].Embedded[0].Fields = []mapping.FieldMapping{
+		{Column: "total", Exclude: true},
+	}
+	tgt := &target.MockOperator{}
+
+	exec := NewNativeExecutor(src, tgt, m, s)
+	if _, err := exec.Run(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	alice := tgt.InsertedDocs["customers"][0].(map[string]interface{})
+	if _, ok := alice["name"]; ok {
+		t.Error("mapped column should be moved from its source name")
+	}
+	nested, ok := alice["profile_name"].(map[string]interface{})
+	if !ok || nested["full"] != "Alice" {
+		t.Errorf("profile_name = %#v, want nested full name", alice["profile_name"])
+	}
+	orders := alice["orders"].([]map[string]interface{})
+	if _, ok := orders[0]["total"]; ok {
+		t.Error("excluded column should be dropped from embedded rows")
+	}
+	if len(orders[0]["items"].([]map[string]interface{})) != 1 {
+		t.Error("nested embeds should still be attached to mapped rows")
+	}
+}
+
+func TestNativeExecutor_TimestampZones(t *testing.T) {
+	src, m, s := nativeFixture()
+	wall := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+	src.TableRows["customers"][0]["joined_at"] = wall
+	src.TableRows["orders"][0]["placed_at"] = wall
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tgt := &target.MockOperator{}
+
+	exec := NewNativeExecutor(src, tgt, m, s)
+	exec.SetTimestampZones(map[string]map[string]*time.Location{
+		"customers": {"joined_at": tokyo},
+		"orders":    {"placed_at": time.UTC},
+	})
+	if _, err := exec.Run(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	alice := tgt.InsertedDocs["customers"][0].(map[string]interface{})
+	if got, want := alice["joined_at"], time.Date(2024, 1, 15, 0, 30, 0, 0, time.UTC); got != want {
+		t.Errorf("joined_at = %v, want %v", got, want)
+	}
+	orders := alice["orders"].([]map[string]interface{})
+	if got := orders[0]["placed_at"]; got != wall {
+		t.Errorf("placed_at = %v, want %v", got, wall)
+	}
+}
+
+func TestNativeExecutor_NumberTypes(t *testing.T) {
+	src, m, s := nativeFixture()
+	src.TableRows["customers"][0]["credit"] = "100"
+	src.TableRows["orders"][0]["total"] = "5.10"
+	tgt := &target.MockOperator{}
+
+	exec := NewNativeExecutor(src, tgt, m, s)
+	exec.SetNumberTypes(map[string]map[string]typemap.BSONType{
+		"customers": {"credit": typemap.BSONNumberLong},
+		"orders":    {"total": typemap.BSONDecimal128},
+	})
+	if _, err := exec.Run(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	alice := tgt.InsertedDocs["customers"][0].(map[string]interface{})
+	if got := alice["credit"]; got != int64(100) {
+		t.Errorf("credit = %v (%T), want 100", got, got)
+	}
+	if _, ok := tgt.InsertedDocs["customers"][1].(map[string]interface{})["credit"]; ok {
+		t.Error("a column the row lacks should not be added")
+	}
+	orders := alice["orders"].([]map[string]interface{})
+	if got, ok := orders[0]["total"].(bson.Decimal128); !ok || got.String() != "5.10" {
+		t.Errorf("total = %v (%T), want Decimal128 5.10", orders[0]["total"], orders[0]["total"])
+	}
+
+	// A value the type cannot hold fails the collection
+	src, m, s = nativeFixture()
+	src.TableRows["customers"][0]["credit"] = "1.5"
+	exec = NewNativeExecutor(src, &target.MockOperator{}, m, s)
+	exec.SetNumberTypes(map[string]map[string]typemap.BSONType{"customers": {"credit": typemap.BSONNumberLong}})
+	if _, err := exec.Run(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "customers.credit") {
+		t.Errorf("Run() error = %v, want the fraction in customers.credit reported", err)
+	}
+}
+
+func TestNativeExecutor_FieldOrder(t *testing.T) {
+	src, m, s := nativeFixture()
+	src.TableRows["customers"][0]["home_city"] = "Springfield"
+	src.TableRows["customers"][0]["home_street"] = "Main"
+	m.Collections[0].Fields = []mapping.FieldMapping{{Prefix: "home_", Target: "home"}}
+	m.Collections[0].FieldOrder = []string{"name", "home", "home.street", "id"}
+	m.Collections[0].Embedded[0].FieldOrder = []string{"total"}
+	tgt := &target.MockOperator{}
+
+	exec := NewNativeExecutor(src, tgt, m, s)
+	if _, err := exec.Run(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	alice, ok := tgt.InsertedDocs["customers"][0].(bson.D)
+	if !ok {
+		t.Fatalf("document = %T, want bson.D", tgt.InsertedDocs["customers"][0])
+	}
+	if got := keys(alice); !slices.Equal(got, []string{"name", "home", "id", "orders"}) {
+		t.Errorf("fields = %v", got)
+	}
+	home := alice[1].Value.(bson.D)
+	if got := keys(home); !slices.Equal(got, []string{"street", "city"}) {
+		t.Errorf("home fields = %v", got)
+	}
+	orders := alice[3].Value.([]bson.D)
+	if got := keys(orders[0]); !slices.Equal(got, []string{"total", "items", "order_id"}) {
+		t.Errorf("order fields = %v", got)
+	}
+}
+
+func keys(d bson.D) []string {
+	out := make([]string, len(d))
+	for i, e := range d {
+		out[i] = e.Key
+	}
+	return out
+}
+
+func TestNativeExecutor_Filters(t *testing.T) {
+	src, m, s := nativeFixture()
+	src.Filters = map[string]func(map[string]interface{}) bool{
+		"name <> 'Bob'": func(row map[string]interface{}) bool { return row["name"] != "Bob" },
+		"total > 6":     func(row map[string]interface{}) bool { return row["total"].(float64) > 6 },
+	}
+	m.Collections[0].Filter = "name <> 'Bob'"
+	m.Collections[0].Embedded[0].Filter = "total > 6"
+	tgt := &target.MockOperator{}
+
+	exec := NewNativeExecutor(src, tgt, m, s)
+	if _, err := exec.Run(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	docs := tgt.InsertedDocs["customers"]
+	if len(docs) != 1 {
+		t.Fatalf("inserted %d docs, want 1", len(docs))
+	}
+	alice := docs[0].(map[string]interface{})
+	orders := alice["orders"].([]map[string]interface{})
+	if len(orders) != 1 || orders[0]["order_id"] != int64(11) {
+		t.Errorf("alice orders = %#v, want only order 11", orders)
+	}
+}
+
+func TestNativeExecutor_Archive(t *testing.T) {
+	src, m, s := nativeFixture()
+	old := time.Date(2018, 5, 1, 0, 0, 0, 0, time.UTC)
+	src.TableRows["customers"][0]["created_at"] = old
+	src.TableRows["customers"][1]["created_at"] = old.AddDate(4, 0, 0)
+	src.TableRows["customers"] = append(src.TableRows["customers"], map[string]interface{}{"id": int64(3), "name": "Cy", "created_at": nil})
+	before := func(row map[string]interface{}) bool {
+		ts, ok := row["created_at"].(time.Time)
+		return ok && ts.Year() < 2020
+	}
+	src.Filters = map[string]func(map[string]interface{}) bool{
+		"created_at < TIMESTAMP '2020-01-01 00:00:00'": before,
+		"(created_at >= TIMESTAMP '2020-01-01 00:00:00' OR created_at IS NULL)": func(row map[string]interface{}) bool {
+			return !before(row)
+		},
+	}
+	m.Collections[0].Archive = &mapping.ArchivePolicy{Column: "created_at", Before: "2020-01-01", Location: "s3://cold/shop"}
+	s.Tables[0].RowCount = 3
+	s.Tables[0].Columns = []schema.Column{
+		{Name: "id", DataType: "bigint"},
+		{Name: "name", DataType: "text"},
+		{Name: "created_at", DataType: "timestamp"},
+	}
+	tgt := &target.MockOperator{}
+
+	// Without S3 access the collection fails rather than dropping rows
+	if _, err := NewNativeExecutor(src, tgt, m, s).Run(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "S3") {
+		t.Fatalf("err = %v, want S3 access required", err)
+	}
+
+	tgt = &target.MockOperator{}
+	sink := &archiveSink{}
+	exec := NewNativeExecutor(src, tgt, m, s)
+	exec.SetArchive(sink)
+	status, err := exec.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cs := status.Collections[0]
+	if cs.DocsWritten != 2 || cs.DocsArchived != 1 {
+		t.Errorf("written %d, archived %d, want 2 and 1", cs.DocsWritten, cs.DocsArchived)
+	}
+	for _, d := range tgt.InsertedDocs["customers"] {
+		if d.(map[string]interface{})["name"] == "Alice" {
+			t.Error("archived row Alice was also migrated")
+		}
+	}
+	if len(sink.uris) != 1 || sink.uris[0] != "s3://cold/shop/customers/part-00000.parquet" {
+		t.Errorf("archive files = %v", sink.uris)
+	}
+
+	// Delta runs leave the archive alone
+	tgt = &target.MockOperator{}
+	sink = &archiveSink{}
+	exec = NewNativeExecutor(src, tgt, m, s)
+	exec.SetArchive(sink)
+	exec.SetDelta(map[string]mapping.WatermarkRange{})
+	if _, err := exec.Run(context.Background(), nil); err != nil {
+		t.Fatalf("delta run: %v", err)
+	}
+	if len(sink.uris) != 0 {
+		t.Errorf("delta run wrote archive files %v", sink.uris)
+	}
+}
+
+func TestNativeExecutor_Computes(t *testing.T) {
+	src, m, s := nativeFixture()
+	m.Collections[0].Transformations = []mapping.Transformation{
+		{Operation: "compute", TargetField: "label", Expression: "concat(upper(name), '-', id)"},
+	}
+	m.Collections[0].Embedded[0].Transformations = []mapping.Transformation{
+		{Operation: "compute", TargetField: "size", Expression: "CASE WHEN total > 6 THEN 'large' ELSE 'small' END"},
+	}
+	tgt := &target.MockOperator{}
+
+	exec := NewNativeExecutor(src, tgt, m, s)
+	if _, err := exec.Run(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alice := tgt.InsertedDocs["customers"][0].(map[string]interface{})
+	if alice["label"] != "ALICE-1" {
+		t.Errorf("label = %#v, want ALICE-1", alice["label"])
+	}
+	orders := alice["orders"].([]map[string]interface{})
+	if orders[0]["size"] != "small" || orders[1]["size"] != "large" {
+		t.Errorf("order sizes = %v, %v", orders[0]["size"], orders[1]["size"])
+	}
+
+	// Functions only Spark runs fail before anything is written
+	src, m, s = nativeFixture()
+	m.Collections[0].Transformations = []mapping.Transformation{
+		{Operation: "compute", TargetField: "sound", Expression: "soundex(name)"},
+	}
+	tgt = &target.MockOperator{}
+	if _, err := NewNativeExecutor(src, tgt, m, s).Run(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "soundex") {
+		t.Errorf("err = %v, want soundex rejected", err)
+	}
+	if len(tgt.InsertedDocs["customers"]) != 0 {
+		t.Error("no documents should be written")
+	}
+}
+
+func TestNativeExecutor_Masks(t *testing.T) {
+	src, m, s := nativeFixture()
+	m.Collections[0].Transformations = []mapping.Transformation{
+		{Operation: "redact", SourceField: "name"},
+		{Operation: "compute", TargetField: "label", Expression: "concat(name, '!')"},
+	}
+	m.Collections[0].Embedded[1].Transformations = []mapping.Transformation{
+		{Operation: "nullify", SourceField: "bio"},
+	}
+	tgt := &target.MockOperator{}
+
+	if _, err := NewNativeExecutor(src, tgt, m, s).Run(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bob := tgt.InsertedDocs["customers"][1].(map[string]interface{})
+	if bob["name"] != "***" || bob["label"] != "***!" {
+		t.Errorf("name = %#v, label = %#v, want masked before computing", bob["name"], bob["label"])
+	}
+	if profile := bob["profile"].(map[string]interface{}); profile["bio"] != nil {
+		t.Errorf("bio = %#v, want null", profile["bio"])
+	}
+}
+
+func TestNativeExecutor_Transformations(t *testing.T) {
+	src, m, s := nativeFixture()
+	m.Collections[0].Transformations = []mapping.Transformation{
+		{Operation: "rename", SourceField: "name", TargetField: "full_name"},
+		{Operation: "filter", Expression: "id > 1"},
+	}
+	m.Collections[0].Embedded[1].Transformations = []mapping.Transformation{
+		{Operation: "default", SourceField: "bio", Value: "n/a"},
+	}
+	src.TableRows["profiles"][0]["bio"] = nil
+	tgt := &target.MockOperator{}
+
+	if _, err := NewNativeExecutor(src, tgt, m, s).Run(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	docs := tgt.InsertedDocs["customers"]
+	if len(docs) != 1 {
+		t.Fatalf("inserted %d customers, want only Bob past the filter", len(docs))
+	}
+	bob := docs[0].(map[string]interface{})
+	if _, ok := bob["name"]; ok || bob["full_name"] != "Bob" {
+		t.Errorf("document = %#v, want name renamed to full_name", bob)
+	}
+	if profile := bob["profile"].(map[string]interface{}); profile["bio"] != "n/a" {
+		t.Errorf("bio = %#v, want the default", profile["bio"])
+	}
+}
+
+func TestNativeExecutor_Delta(t *testing.T) {
+	src, m, s := nativeFixture()
+	src.Filters = map[string]func(map[string]interface{}) bool{
+		"id > 1 AND id <= 2": func(row map[string]interface{}) bool { return row["id"].(int64) > 1 },
+	}
+	m.Collections = append(m.Collections, mapping.Collection{Name: "orders", SourceTable: "orders"})
+	s.Tables[0].PrimaryKey = &schema.PrimaryKey{Columns: []string{"id"}}
+	tgt := &target.MockOperator{}
+
+	exec := NewNativeExecutor(src, tgt, m, s)
+	exec.SetDelta(map[string]mapping.WatermarkRange{
+		"customers": {Column: "id", After: "1", UpTo: "2"},
+	})
+	status, err := exec.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tgt.InsertedDocs["customers"]) != 0 {
+		t.Errorf("delta run inserted %d docs, want upserts only", len(tgt.InsertedDocs["customers"]))
+	}
+	ops := tgt.AppliedWrites["customers"]
+	if len(ops) != 1 {
+		t.Fatalf("applied %d writes, want 1", len(ops))
+	}
+	if ops[0].Type != target.WriteUpsert || ops[0].Filter["id"] != int64(2) {
+		t.Errorf("write = %+v, want upsert of id 2", ops[0])
+	}
+	if ops[0].Doc["profile"] == nil {
+		t.Error("upserted doc is missing its embedded profile")
+	}
+	if status.Collections[1].State != "skipped" {
+		t.Errorf("orders state = %q, want skipped", status.Collections[1].State)
+	}
+
+	s.Tables[0].PrimaryKey = nil
+	exec = NewNativeExecutor(src, &target.MockOperator{}, m, s)
+	exec.SetDelta(map[string]mapping.WatermarkRange{"customers": {Column: "id", UpTo: "2"}})
+	if _, err := exec.Run(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "primary key") {
+		t.Errorf("error = %v, want missing primary key", err)
+	}
+}
+
+func TestNativeExecutor_Batching(t *testing.T) {
+	src := &source.MockReader{TableRows: map[string][]map[string]interface{}{
+		"t": {{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}, {"id": 5}},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "t", SourceTable: "t"}}}
+	tgt := &target.MockOperator{}
+
+	exec := NewNativeExecutor(src, tgt, m, nil)
+	exec.SetBatchSize(2)
+
+	var updates int
+	_, err := exec.Run(context.Background(), func(s *Status) { updates++ })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tgt.InsertedDocs["t"]) != 5 {
+		t.Errorf("inserted %d docs, want 5", len(tgt.InsertedDocs["t"]))
+	}
+	// pending + running + 3 batches + completed + final
+	if updates < 5 {
+		t.Errorf("expected at least 5 status updates, got %d", updates)
+	}
+}
+
+func TestNativeExecutor_Pause(t *testing.T) {
+	for _, cancelWhilePaused := range []bool{false, true} {
+		src := &source.MockReader{TableRows: map[string][]map[string]interface{}{
+			"t": {{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}, {"id": 5}},
+		}}
+		m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "t", SourceTable: "t"}}}
+		tgt := &target.MockOperator{}
+		ctl := NewControl()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		exec := NewNativeExecutor(src, tgt, m, nil)
+		exec.SetBatchSize(2)
+		exec.SetControl(ctl)
+
+		var pausedAt []int
+		status, err := exec.Run(ctx, func(s *Status) {
+			switch {
+			case s.Phase == PhasePaused:
+				pausedAt = append(pausedAt, len(tgt.InsertedDocs["t"]))
+				if cancelWhilePaused {
+					cancel()
+				} else {
+					ctl.Resume()
+				}
+			case s.Overall.DocsWritten == 2 && len(pausedAt) == 0:
+				ctl.Pause()
+			}
+		})
+		cancel()
+
+		if len(pausedAt) != 1 || pausedAt[0] != 2 {
+			t.Errorf("cancel=%v: paused after %v docs, want once after the first batch of 2", cancelWhilePaused, pausedAt)
+		}
+		if cancelWhilePaused {
+			if err == nil || len(tgt.InsertedDocs["t"]) != 2 {
+				t.Errorf("cancelled while paused: err = %v with %d docs, want an error with 2", err, len(tgt.InsertedDocs["t"]))
+			}
+			continue
+		}
+		if err != nil || status.Phase != "completed" || len(tgt.InsertedDocs["t"]) != 5 {
+			t.Errorf("resumed: phase %s, err %v, %d docs; want all 5 completed", status.Phase, err, len(tgt.InsertedDocs["t"]))
+		}
+	}
+}
+
+func TestNativeExecutor_PartialFailure(t *testing.T) {
+	src := &source.MockReader{TableRows: map[string][]map[string]interface{}{
+		"a": {{"id": 1}},
+		"b": {{"id": 2}},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "a", SourceTable: "a"},
+		{Name: "b", SourceTable: "b"},
+	}}
+	tgt := &failingInserter{MockOperator: &target.MockOperator{}, failOn: "b"}
+
+	exec := NewNativeExecutor(src, tgt, m, nil)
+
+	status, err := exec.Run(context.Background(), nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if status.Phase != "partial_failure" {
+		t.Errorf("phase = %q, want partial_failure", status.Phase)
+	}
+	if status.Collections[1].State != "failed" || status.Collections[1].Error == "" {
+		t.Errorf("collection b = %+v, want failed with error", status.Collections[1])
+	}
+}
+
+func TestNativeExecutor_Dependencies(t *testing.T) {
+	src := &source.MockReader{TableRows: map[string][]map[string]interface{}{
+		"a": {{"id": 1}},
+		"b": {{"id": 2, "a_id": 1}},
+		"c": {{"id": 3}},
+	}}
+	// b references a; c is listed first but must follow b
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "c", SourceTable: "c", DependsOn: []string{"b"}},
+		{Name: "b", SourceTable: "b"},
+		{Name: "a", SourceTable: "a", References: []mapping.Reference{{SourceTable: "b", FieldName: "b", JoinColumn: "a_id", ParentColumn: "id"}}},
+	}}
+	tgt := &failingInserter{MockOperator: &target.MockOperator{}, failOn: "a"}
+
+	status, err := NewNativeExecutor(src, tgt, m, nil).Run(context.Background(), nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	var order []string
+	for _, c := range status.Collections {
+		order = append(order, c.Name)
+	}
+	if !slices.Equal(order, []string{"a", "b", "c"}) {
+		t.Errorf("order = %v, want parents first", order)
+	}
+	for _, c := range status.Collections[1:] {
+		if c.State != "failed" || !strings.Contains(c.Error, "which it depends on, failed") {
+			t.Errorf("%s = %+v, want failed by its dependency", c.Name, c)
+		}
+	}
+	if _, ok := tgt.InsertedDocs["b"]; ok {
+		t.Error("b was migrated although a failed")
+	}
+}
+
+func TestNativeExecutor_RowErrors(t *testing.T) {
+	rows := map[string][]map[string]interface{}{
+		"users": {
+			{"id": int64(1), "name": "Alice"},
+			{"id": int64(2), "name": "Bad\xff"},
+			{"id": int64(3), "name": "Carol"},
+		},
+	}
+	s := &schema.Schema{Tables: []schema.Table{{Name: "users", RowCount: 3, PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}}}}}
+	tests := []struct {
+		policy      string
+		wantErr     string
+		written     int
+		skipped     int64
+		quarantined int64
+	}{
+		{policy: "", wantErr: "row 2: invalid UTF-8 in name"},
+		{policy: mapping.OnErrorSkip, written: 2, skipped: 1},
+		{policy: mapping.OnErrorQuarantine, written: 2, quarantined: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "users", SourceTable: "users", OnError: tt.policy}}}
+			tgt := &target.MockOperator{}
+			status, err := NewNativeExecutor(&source.MockReader{TableRows: rows}, tgt, m, s).Run(context.Background(), nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(status.Collections[0].Error, tt.wantErr) {
+					t.Fatalf("err = %v, collection = %+v; want %q", err, status.Collections[0], tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			cs := status.Collections[0]
+			if len(tgt.InsertedDocs["users"]) != tt.written || cs.RowsSkipped != tt.skipped || cs.RowsQuarantined != tt.quarantined {
+				t.Errorf("written %d, status %+v", len(tgt.InsertedDocs["users"]), cs)
+			}
+			if cs.PercentComplete != 100 {
+				t.Errorf("percent = %v", cs.PercentComplete)
+			}
+			quarantined := tgt.AppliedWrites[mapping.ErrorCollection]
+			if len(quarantined) != int(tt.quarantined) {
+				t.Fatalf("quarantined = %+v", quarantined)
+			}
+			if tt.quarantined > 0 {
+				op := quarantined[0]
+				if op.Filter["_id"] != "users/2" || op.Doc["key"].(map[string]interface{})["id"] != int64(2) ||
+					!strings.Contains(op.Doc["error"].(string), "invalid UTF-8 in name") {
+					t.Errorf("quarantined row = %+v", op)
+				}
+				if f := tgt.DeletedFilters[mapping.ErrorCollection]; len(f) != 1 || f[0]["collection"] != "users" {
+					t.Errorf("earlier quarantined rows not cleared: %v", f)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     interface{}
+		wantErr string
+	}{
+		{"ok", map[string]interface{}{"a": "x", "b": []interface{}{bson.D{{Key: "c", Value: "y"}}}}, ""},
+		{"nested utf8", map[string]interface{}{"a": []map[string]interface{}{{"b": "\xc3"}}}, "invalid UTF-8 in a.b"},
+		{"oversized", map[string]interface{}{"blob": make([]byte, MaxDocumentBytes)}, "over the 16 MB BSON limit"},
+		{"unencodable", map[string]interface{}{"ch": make(chan int)}, "encoding document"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, err := checkDocument(tt.doc)
+			if tt.wantErr == "" {
+				if err != nil || size == 0 {
+					t.Errorf("checkDocument() = %d, %v", size, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkDocument() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNativeExecutor_RetryFailed(t *testing.T) {
+	src := &source.MockReader{TableRows: map[string][]map[string]interface{}{
+		"a": {{"id": 1}},
+		"b": {{"id": 2}},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "a", SourceTable: "a"},
+		{Name: "b", SourceTable: "b"},
+	}}
+	tgt := &target.MockOperator{}
+
+	exec := NewNativeExecutor(src, tgt, m, nil)
+	status, err := exec.RetryFailed(context.Background(), []string{"b"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(status.Collections) != 1 || status.Collections[0].Name != "b" {
+		t.Errorf("collections = %+v, want only b", status.Collections)
+	}
+	if _, ok := tgt.InsertedDocs["a"]; ok {
+		t.Error("collection a should not be re-migrated")
+	}
+}
+
+func TestNativeExecutor_ConsistencyGroups(t *testing.T) {
+	src := &source.MockReader{TableRows: map[string][]map[string]interface{}{
+		"invoices":  {{"id": 1}},
+		"customers": {{"id": 2}},
+		"payments":  {{"id": 3, "invoice_id": 1}},
+		"refunds":   {{"id": 4, "payment_id": 3}},
+	}}
+	m := &mapping.Mapping{
+		Collections: []mapping.Collection{
+			{Name: "invoices", SourceTable: "invoices"},
+			{Name: "customers", SourceTable: "customers"},
+			{Name: "payments", SourceTable: "payments"},
+			{Name: "refunds", SourceTable: "refunds"},
+		},
+		ConsistencyGroups: []mapping.ConsistencyGroup{{Name: "billing", Collections: []string{"invoices", "payments", "refunds"}}},
+	}
+
+	exec := NewNativeExecutor(src, &target.MockOperator{}, m, nil)
+	status, err := exec.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var order []string
+	for _, c := range status.Collections {
+		order = append(order, c.Name)
+	}
+	if !slices.Equal(order, []string{"invoices", "payments", "refunds", "customers"}) {
+		t.Errorf("order = %v, want the billing group back to back", order)
+	}
+	for _, c := range status.Collections[:3] {
+		if c.Group != "billing" || c.Snapshot != "snapshot-1" {
+			t.Errorf("%s: group %q, snapshot %q; want billing in snapshot-1", c.Name, c.Group, c.Snapshot)
+		}
+	}
+	if c := status.Collections[3]; c.Group != "" || c.Snapshot != "" {
+		t.Errorf("customers = %+v, want no group or snapshot", c)
+	}
+	if src.StreamedIn["refunds"] != "snapshot-1" || src.StreamedIn["customers"] != "" {
+		t.Errorf("streamed in %v", src.StreamedIn)
+	}
+	if src.Snapshot != "" || src.Snapshots != 1 {
+		t.Errorf("snapshot %q still open after %d", src.Snapshot, src.Snapshots)
+	}
+
+	// A group whose snapshot cannot be opened fails, the rest migrates
+	src = &source.MockReader{TableRows: src.TableRows, SnapshotErr: errors.New("permission denied")}
+	status, err = NewNativeExecutor(src, &target.MockOperator{}, m, nil).Run(context.Background(), nil)
+	if err == nil || status.Phase != "partial_failure" {
+		t.Fatalf("Run = %v, %v; want partial failure", status.Phase, err)
+	}
+	if c := status.Collections[0]; c.State != "failed" || !strings.Contains(c.Error, "consistency group billing") {
+		t.Errorf("invoices = %+v", c)
+	}
+	if c := status.Collections[3]; c.State != "completed" {
+		t.Errorf("customers = %+v, want completed", c)
+	}
+}
+
+func TestNativeExecutor_Cancelled(t *testing.T) {
+	src, m, s := nativeFixture()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	exec := NewNativeExecutor(src, &target.MockOperator{}, m, s)
+	status, err := exec.Run(ctx, nil)
+	if err == nil {
+		t.Fatal("expected error on cancelled context")
+	}
+	if status.Phase != "failed" {
+		t.Errorf("phase = %q, want failed", status.Phase)
+	}
+}
+
+func TestRowKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		row    map[string]interface{}
+		cols   []string
+		want   string
+		wantOK bool
+	}{
+		{"single", map[string]interface{}{"id": 1}, []string{"id"}, "1", true},
+		{"composite", map[string]interface{}{"a": 1, "b": "x"}, []string{"a", "b"}, "1\x1fx", true},
+		{"null", map[string]interface{}{"id": nil}, []string{"id"}, "", false},
+		{"missing", map[string]interface{}{}, []string{"id"}, "", false},
+		{"no columns", map[string]interface{}{"id": 1}, nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rowKey(tt.row, tt.cols)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("rowKey() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// failingInserter fails bulk writes for a single collection.
+type failingInserter struct {
+	*target.MockOperator
+	failOn string
+}
+
+func (f *failingInserter) InsertDocuments(ctx context.Context, collection string, docs []interface{}) (int64, error) {
+	if collection == f.failOn {
+		return 0, errors.New("write failed")
+	}
+	return f.MockOperator.InsertDocuments(ctx, collection, docs)
+}
+
+// archiveSink records the archive files written.
+type archiveSink struct {
+	uris []string
+}
+
+func (a *archiveSink) Put(_ context.Context, uri string, _ []byte) error {
+	a.uris = append(a.uris, uri)
+	return nil
+}
+
+func (a *archiveSink) DeletePrefix(context.Context, string) error { return nil }
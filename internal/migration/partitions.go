@@ -0,0 +1,128 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// Checkpoints has a native run migrate each collection in ranges of a
+// numeric root column, as the generated script does, recording each range
+// once written. A later run skips the recorded ranges and writes the others
+// over what an interrupted run left of them, so a retry or a resume neither
+// repeats finished work nor duplicates documents.
+type Checkpoints struct {
+	// Partitions is how many ranges each root table is split into.
+	Partitions int
+
+	// Keys returns the root column a collection is split on and the
+	// top-level document field it is written to; ok is false for a
+	// collection that cannot be split, which is migrated whole.
+	Keys func(c *mapping.Collection) (column, field string, ok bool)
+
+	// Completed lists the ranges earlier runs wrote, by collection.
+	Completed map[string][]state.PartitionCheckpoint
+
+	// Record is called as each range is written.
+	Record func(collection string, partitions int, p state.PartitionCheckpoint)
+}
+
+// SetCheckpoints has the run migrate collections in resumable ranges; see
+// Checkpoints. Without them a collection is read in one pass.
+func (e *NativeExecutor) SetCheckpoints(cp *Checkpoints) {
+	e.checkpoints = cp
+}
+
+// collectionPlan is how a collection's root rows are read: in ranges of
+// column, written to field, or in one pass when column is empty.
+type collectionPlan struct {
+	column string
+	field  string
+	parts  []state.PartitionCheckpoint
+	done   map[state.PartitionCheckpoint]bool
+}
+
+// resumed reports whether an earlier run wrote some of the collection.
+func (p *collectionPlan) resumed() bool {
+	return len(p.done) > 0
+}
+
+// planCollection splits a collection's root table into ranges when it is
+// checkpointed, marking those an earlier run wrote.
+func (e *NativeExecutor) planCollection(ctx context.Context, c *mapping.Collection) (*collectionPlan, error) {
+	plan := &collectionPlan{parts: []state.PartitionCheckpoint{{}}}
+	cp := e.checkpoints
+	if cp == nil || e.delta || cp.Keys == nil {
+		return plan, nil
+	}
+	column, field, ok := cp.Keys(c)
+	if !ok {
+		return plan, nil
+	}
+	lo, hi, err := e.source.KeyRange(ctx, c.SourceTable, column)
+	if err != nil {
+		return nil, err
+	}
+	plan.column, plan.field = column, field
+	plan.parts = PartitionRanges(lo, hi, cp.Partitions)
+	plan.done = make(map[state.PartitionCheckpoint]bool)
+	for _, p := range cp.Completed[c.Name] {
+		plan.done[p] = true
+	}
+	return plan, nil
+}
+
+// PartitionRanges splits the key values lo to hi, both included, into at
+// most parts [Lower, Upper) ranges, as the generated script's
+// partition_ranges does.
+func PartitionRanges(lo, hi int64, parts int) []state.PartitionCheckpoint {
+	if parts < 1 {
+		parts = 1
+	}
+	hi++
+	step := (hi - lo + int64(parts) - 1) / int64(parts)
+	if step < 1 {
+		step = 1
+	}
+	var out []state.PartitionCheckpoint
+	for i := 0; i < parts && lo+int64(i)*step < hi; i++ {
+		out = append(out, state.PartitionCheckpoint{
+			Index: i,
+			Lower: lo + int64(i)*step,
+			Upper: min(hi, lo+int64(i+1)*step),
+		})
+	}
+	return out
+}
+
+// PartitionFilter combines a collection's row filter with a range of its
+// partition column into one SQL predicate. column is quoted as the source
+// expects; see source.QuoteIdent.
+func PartitionFilter(base, column string, p state.PartitionCheckpoint) string {
+	rng := fmt.Sprintf("%s >= %d AND %s < %d", column, p.Lower, column, p.Upper)
+	if base == "" {
+		return rng
+	}
+	return "(" + base + ") AND " + rng
+}
+
+// clearTarget deletes the documents of a collection an earlier run wrote:
+// all of them, or those of one range when p is given. Targets that cannot
+// delete by filter keep them.
+func (e *NativeExecutor) clearTarget(ctx context.Context, collection, field string, p *state.PartitionCheckpoint) error {
+	d, ok := e.target.(target.DocumentDeleter)
+	if !ok {
+		return nil
+	}
+	filter := map[string]interface{}{}
+	if p != nil {
+		filter[field] = map[string]interface{}{"$gte": p.Lower, "$lt": p.Upper}
+	}
+	if _, err := d.DeleteDocuments(ctx, collection, filter); err != nil {
+		return fmt.Errorf("clearing documents an earlier run wrote to %s: %w", collection, err)
+	}
+	return nil
+}
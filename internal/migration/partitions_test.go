@@ -0,0 +1,123 @@
+package migration
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func TestPartitionRanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		lo, hi int64
+		parts  int
+		want   []state.PartitionCheckpoint
+	}{
+		{"even", 1, 4, 2, []state.PartitionCheckpoint{{Index: 0, Lower: 1, Upper: 3}, {Index: 1, Lower: 3, Upper: 5}}},
+		{"uneven", 0, 4, 2, []state.PartitionCheckpoint{{Index: 0, Lower: 0, Upper: 3}, {Index: 1, Lower: 3, Upper: 5}}},
+		{"fewer values than parts", 7, 8, 8, []state.PartitionCheckpoint{{Index: 0, Lower: 7, Upper: 8}, {Index: 1, Lower: 8, Upper: 9}}},
+		{"one value", 5, 5, 8, []state.PartitionCheckpoint{{Index: 0, Lower: 5, Upper: 6}}},
+		{"no parts", 1, 4, 0, []state.PartitionCheckpoint{{Index: 0, Lower: 1, Upper: 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PartitionRanges(tt.lo, tt.hi, tt.parts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PartitionRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartitionFilter(t *testing.T) {
+	p := state.PartitionCheckpoint{Lower: 1, Upper: 3}
+	if got := PartitionFilter("", "id", p); got != "id >= 1 AND id < 3" {
+		t.Errorf("PartitionFilter() = %q", got)
+	}
+	if got := PartitionFilter("active = 1", "id", p); got != "(active = 1) AND id >= 1 AND id < 3" {
+		t.Errorf("PartitionFilter() = %q", got)
+	}
+	if got := PartitionFilter("", `"Order"`, p); got != `"Order" >= 1 AND "Order" < 3` {
+		t.Errorf("PartitionFilter() = %q", got)
+	}
+}
+
+func TestNativeExecutor_Checkpoints(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"id": int64(1)}, {"id": int64(2)}, {"id": int64(3)}, {"id": int64(4)},
+	}
+	inRange := func(lo, hi int64) func(map[string]interface{}) bool {
+		return func(r map[string]interface{}) bool {
+			id := r["id"].(int64)
+			return id >= lo && id < hi
+		}
+	}
+	first := state.PartitionCheckpoint{Index: 0, Lower: 1, Upper: 3}
+	second := state.PartitionCheckpoint{Index: 1, Lower: 3, Upper: 5}
+
+	tests := []struct {
+		name      string
+		completed []state.PartitionCheckpoint
+		written   int
+		cleared   []map[string]interface{}
+		recorded  []state.PartitionCheckpoint
+	}{
+		{
+			name:     "fresh",
+			written:  4,
+			cleared:  []map[string]interface{}{{}},
+			recorded: []state.PartitionCheckpoint{first, second},
+		},
+		{
+			name:      "resumed",
+			completed: []state.PartitionCheckpoint{first},
+			written:   2,
+			cleared:   []map[string]interface{}{{"id": map[string]interface{}{"$gte": int64(3), "$lt": int64(5)}}},
+			recorded:  []state.PartitionCheckpoint{second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &source.MockReader{
+				TableRows: map[string][]map[string]interface{}{"users": rows},
+				Filters: map[string]func(map[string]interface{}) bool{
+					"id >= 1 AND id < 3": inRange(1, 3),
+					"id >= 3 AND id < 5": inRange(3, 5),
+				},
+			}
+			m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "users", SourceTable: "users"}}}
+			tgt := &target.MockOperator{}
+			var recorded []state.PartitionCheckpoint
+			exec := NewNativeExecutor(src, tgt, m, nil)
+			exec.SetCheckpoints(&Checkpoints{
+				Partitions: 2,
+				Keys: func(*mapping.Collection) (string, string, bool) {
+					return "id", "id", true
+				},
+				Completed: map[string][]state.PartitionCheckpoint{"users": tt.completed},
+				Record: func(collection string, partitions int, p state.PartitionCheckpoint) {
+					if collection != "users" || partitions != 2 {
+						t.Errorf("Record(%q, %d)", collection, partitions)
+					}
+					recorded = append(recorded, p)
+				},
+			})
+			if _, err := exec.Run(context.Background(), nil); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(tgt.InsertedDocs["users"]); got != tt.written {
+				t.Errorf("written %d documents, want %d", got, tt.written)
+			}
+			if got := tgt.DeletedFilters["users"]; !reflect.DeepEqual(got, tt.cleared) {
+				t.Errorf("cleared %v, want %v", got, tt.cleared)
+			}
+			if !reflect.DeepEqual(recorded, tt.recorded) {
+				t.Errorf("recorded %v, want %v", recorded, tt.recorded)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/transform"
+)
+
+// errPreviewFull stops the read of a preview's root rows once it has them.
+var errPreviewFull = errors.New("preview full")
+
+// Preview builds the first limit documents of a collection from the source
+// rows, the way Run would write them: root rows that pass the collection's
+// live filter, with the rows of its embedded tables that join to them, the
+// same transformations and type conversions, and the collection's
+// field mappings and field order. Each embedded table is read once but
+// only the rows joining the previewed documents are kept. Nothing is
+// written to the target, and rows that cannot be converted fail the
+// preview whatever the collection's error policy.
+func (e *NativeExecutor) Preview(ctx context.Context, collection string, limit int) ([]interface{}, error) {
+	var c *mapping.Collection
+	for i := range e.mapping.Collections {
+		if e.mapping.Collections[i].Name == collection {
+			c = &e.mapping.Collections[i]
+		}
+	}
+	if c == nil {
+		return nil, fmt.Errorf("no collection %s in the mapping", collection)
+	}
+	if limit <= 0 {
+		return []interface{}{}, nil
+	}
+	transforms, err := transform.NewPipeline(c.Transformations)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cancelling the read stops the source sending the rest of the table
+	rctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var roots []map[string]interface{}
+	err = e.source.StreamFilteredRows(rctx, c.SourceTable, c.LiveFilter(), func(row map[string]interface{}) error {
+		e.normalizeTimestamps(c.SourceTable, row)
+		if err := e.convertNumbers(c.SourceTable, row); err != nil {
+			return err
+		}
+		if keep, err := transforms.Apply(row); err != nil || !keep {
+			return err
+		}
+		roots = append(roots, row)
+		if len(roots) >= limit {
+			cancel()
+			return errPreviewFull
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errPreviewFull) {
+		return nil, fmt.Errorf("reading %s: %w", c.SourceTable, err)
+	}
+
+	for i := range c.Embedded {
+		child, err := e.previewEmbedded(ctx, &c.Embedded[i], roots)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range roots {
+			child.attach(row)
+		}
+	}
+	docs := make([]interface{}, len(roots))
+	for i, row := range roots {
+		var doc interface{} = mapping.ApplyFields(row, c.Fields)
+		if len(c.FieldOrder) > 0 {
+			doc = orderedDoc(doc.(map[string]interface{}), c.FieldOrder, "")
+		}
+		docs[i] = doc
+	}
+	return docs, nil
+}
+
+// previewEmbedded reads the rows of an embedded table that join to the
+// given parent rows, with their nested children, top-down, keyed by the
+// join column values as loadEmbedded keys them.
+func (e *NativeExecutor) previewEmbedded(ctx context.Context, emb *mapping.Embedded, parents []map[string]interface{}) (*embeddedRows, error) {
+	er := &embeddedRows{
+		def:    emb,
+		byKey:  make(map[string][]map[string]interface{}),
+		parent: emb.ParentKeys(),
+	}
+	wanted := make(map[string]bool, len(parents))
+	for _, p := range parents {
+		if key, ok := rowKey(p, er.parent); ok {
+			wanted[key] = true
+		}
+	}
+	if len(wanted) == 0 {
+		return er, nil
+	}
+
+	transforms, err := transform.NewPipeline(emb.Transformations)
+	if err != nil {
+		return nil, fmt.Errorf("embedded table %s: %w", emb.SourceTable, err)
+	}
+	joinCols := emb.JoinKeys()
+	var rows []map[string]interface{}
+	var keys []string
+	err = e.source.StreamFilteredRows(ctx, emb.SourceTable, emb.Filter, func(row map[string]interface{}) error {
+		e.normalizeTimestamps(emb.SourceTable, row)
+		if err := e.convertNumbers(emb.SourceTable, row); err != nil {
+			return err
+		}
+		if keep, err := transforms.Apply(row); err != nil || !keep {
+			return err
+		}
+		key, ok := rowKey(row, joinCols)
+		if !ok || !wanted[key] {
+			return nil
+		}
+		rows = append(rows, row)
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading embedded table %s: %w", emb.SourceTable, err)
+	}
+
+	for i := range emb.Embedded {
+		nested, err := e.previewEmbedded(ctx, &emb.Embedded[i], rows)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			nested.attach(row)
+		}
+	}
+	for i, row := range rows {
+		for _, col := range joinCols {
+			delete(row, col)
+		}
+		er.byKey[keys[i]] = append(er.byKey[keys[i]], mapping.ApplyFields(row, emb.Fields))
+	}
+	return er, nil
+}
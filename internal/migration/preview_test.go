@@ -0,0 +1,78 @@
+package migration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNativeExecutor_Preview(t *testing.T) {
+	src, m, s := nativeFixture()
+	docs, err := NewNativeExecutor(src, nil, m, s).Preview(context.Background(), "customers", 1)
+	if err != nil {
+		t.Fatalf("Preview() error: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("Preview() = %d documents, want 1", len(docs))
+	}
+	alice := docs[0].(map[string]interface{})
+	if alice["name"] != "Alice" {
+		t.Errorf("document = %#v, want Alice", alice)
+	}
+	orders, ok := alice["orders"].([]map[string]interface{})
+	if !ok || len(orders) != 2 {
+		t.Fatalf("orders = %#v, want 2 embedded orders", alice["orders"])
+	}
+	if _, ok := orders[0]["customer_id"]; ok {
+		t.Error("join column should be removed from embedded rows")
+	}
+	if items, ok := orders[0]["items"].([]map[string]interface{}); !ok || len(items) != 1 || items[0]["sku"] != "A" {
+		t.Errorf("nested items = %#v, want item A", orders[0]["items"])
+	}
+	if items, ok := orders[1]["items"].([]map[string]interface{}); !ok || len(items) != 0 {
+		t.Errorf("nested items = %#v, want none", orders[1]["items"])
+	}
+	if _, ok := alice["profile"]; ok {
+		t.Error("alice should have no profile")
+	}
+
+	// More than there are rows
+	src, m, s = nativeFixture()
+	docs, err = NewNativeExecutor(src, nil, m, s).Preview(context.Background(), "customers", 10)
+	if err != nil || len(docs) != 2 {
+		t.Fatalf("Preview(10) = %d documents, %v; want 2", len(docs), err)
+	}
+	if p, ok := docs[1].(map[string]interface{})["profile"].(map[string]interface{}); !ok || p["bio"] != "hi" {
+		t.Errorf("bob profile = %#v", docs[1])
+	}
+}
+
+func TestNativeExecutor_Preview_FilterAndOrder(t *testing.T) {
+	src, m, s := nativeFixture()
+	m.Collections[0].Filter = "id > 1"
+	m.Collections[0].FieldOrder = []string{"name", "id"}
+	src.Filters = map[string]func(map[string]interface{}) bool{
+		"id > 1": func(row map[string]interface{}) bool { return row["id"].(int64) > 1 },
+	}
+	docs, err := NewNativeExecutor(src, nil, m, s).Preview(context.Background(), "customers", 3)
+	if err != nil {
+		t.Fatalf("Preview() error: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("Preview() = %d documents, want only Bob", len(docs))
+	}
+	d, ok := docs[0].(bson.D)
+	if !ok || len(d) < 2 || d[0].Key != "name" || d[0].Value != "Bob" || d[1].Key != "id" {
+		t.Errorf("document = %#v, want name then id first", docs[0])
+	}
+}
+
+func TestNativeExecutor_Preview_UnknownCollection(t *testing.T) {
+	src, m, s := nativeFixture()
+	_, err := NewNativeExecutor(src, nil, m, s).Preview(context.Background(), "invoices", 3)
+	if err == nil || !strings.Contains(err.Error(), "no collection invoices") {
+		t.Errorf("Preview() error = %v, want unknown collection", err)
+	}
+}
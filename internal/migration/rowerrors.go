@@ -0,0 +1,169 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// MaxDocumentBytes is the largest BSON document MongoDB stores.
+const MaxDocumentBytes = 16 * 1024 * 1024
+
+// checkDocument returns the BSON size of a document, or why it cannot be
+// written: a string that is not valid UTF-8, a value BSON cannot encode, or
+// a size over MaxDocumentBytes.
+func checkDocument(doc interface{}) (int64, error) {
+	if path := invalidUTF8(doc, ""); path != "" {
+		return 0, fmt.Errorf("invalid UTF-8 in %s", path)
+	}
+	b, err := bson.Marshal(doc)
+	if err != nil {
+		return 0, fmt.Errorf("encoding document: %w", err)
+	}
+	if len(b) > MaxDocumentBytes {
+		return 0, fmt.Errorf("document of %d bytes is over the 16 MB BSON limit", len(b))
+	}
+	return int64(len(b)), nil
+}
+
+// invalidUTF8 returns the path of the first field name or string value in v
+// that is not valid UTF-8, or "".
+func invalidUTF8(v interface{}, path string) string {
+	field := func(k string) string {
+		if path == "" {
+			return k
+		}
+		return path + "." + k
+	}
+	switch v := v.(type) {
+	case string:
+		if !utf8.ValidString(v) {
+			return path
+		}
+	case map[string]interface{}:
+		for k, sub := range v {
+			if !utf8.ValidString(k) {
+				return field(strings.ToValidUTF8(k, "?"))
+			}
+			if p := invalidUTF8(sub, field(k)); p != "" {
+				return p
+			}
+		}
+	case bson.D:
+		for _, e := range v {
+			if !utf8.ValidString(e.Key) {
+				return field(strings.ToValidUTF8(e.Key, "?"))
+			}
+			if p := invalidUTF8(e.Value, field(e.Key)); p != "" {
+				return p
+			}
+		}
+	case []interface{}:
+		for _, sub := range v {
+			if p := invalidUTF8(sub, path); p != "" {
+				return p
+			}
+		}
+	case []map[string]interface{}:
+		for _, sub := range v {
+			if p := invalidUTF8(sub, path); p != "" {
+				return p
+			}
+		}
+	case []bson.D:
+		for _, sub := range v {
+			if p := invalidUTF8(sub, path); p != "" {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// rowErrors applies a collection's error policy to the source rows that
+// cannot be converted to documents.
+type rowErrors struct {
+	target     target.Operator
+	policy     string
+	collection string
+	table      string
+	pk         []string // primary key columns recorded for a quarantined row
+	cs         *CollectionStatus
+	pending    []target.WriteOp // quarantined rows not yet written
+	limit      int              // pending rows written at once
+}
+
+// handle leaves the row out under a skip or quarantine policy, or returns
+// the error that fails the collection.
+func (r *rowErrors) handle(ctx context.Context, row map[string]interface{}, err error) error {
+	key, ok := rowKey(row, r.pk)
+	switch {
+	case r.policy == mapping.OnErrorSkip:
+		r.cs.RowsSkipped++
+		return nil
+	case r.policy == mapping.OnErrorQuarantine && ok:
+		r.cs.RowsQuarantined++
+		keyDoc := make(map[string]interface{}, len(r.pk))
+		for _, col := range r.pk {
+			v := row[col]
+			if s, ok := v.(string); ok {
+				v = strings.ToValidUTF8(s, "�")
+			}
+			keyDoc[col] = v
+		}
+		r.pending = append(r.pending, target.WriteOp{
+			Type:   target.WriteUpsert,
+			Filter: map[string]interface{}{"_id": r.collection + "/" + strings.ReplaceAll(strings.ToValidUTF8(key, "�"), "\x1f", "/")},
+			Doc: map[string]interface{}{
+				"collection":   r.collection,
+				"source_table": r.table,
+				"key":          keyDoc,
+				"error":        err.Error(),
+				"at":           time.Now().UTC(),
+			},
+		})
+		if len(r.pending) >= r.limit {
+			return r.flush(ctx)
+		}
+		return nil
+	case r.policy == mapping.OnErrorQuarantine:
+		return fmt.Errorf("row without a primary key cannot be quarantined: %w", err)
+	case ok:
+		return fmt.Errorf("row %s: %w", strings.ReplaceAll(key, "\x1f", ", "), err)
+	}
+	return err
+}
+
+// flush writes the pending quarantined rows to mapping.ErrorCollection.
+func (r *rowErrors) flush(ctx context.Context) error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+	err := r.target.ApplyWrites(ctx, mapping.ErrorCollection, r.pending)
+	r.pending = r.pending[:0]
+	if err != nil {
+		return fmt.Errorf("quarantining rows: %w", err)
+	}
+	return nil
+}
+
+// clear deletes the rows a previous run quarantined for the collection, so
+// that those since fixed are no longer reported. Targets that cannot delete
+// by filter keep them.
+func (r *rowErrors) clear(ctx context.Context) error {
+	d, ok := r.target.(target.DocumentDeleter)
+	if !ok || r.policy != mapping.OnErrorQuarantine {
+		return nil
+	}
+	if _, err := d.DeleteDocuments(ctx, mapping.ErrorCollection, map[string]interface{}{"collection": r.collection}); err != nil {
+		return fmt.Errorf("clearing quarantined rows: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PhaseWindowExpired is the phase of a run stopped at the end of its
+// migration window.
+const PhaseWindowExpired = "window-expired"
+
+// ErrWindowExpired is the cancellation cause of a run that reached the end
+// of its migration window.
+var ErrWindowExpired = errors.New("migration window expired")
+
+// Deadline returns when a run starting at now must stop: the earlier of the
+// next occurrence of deadline and now plus maxDuration. Deadline is a local
+// time of day (HH:MM) or an RFC 3339 timestamp; maxDuration is a Go
+// duration such as 6h. It returns the zero time when neither is set.
+func Deadline(now time.Time, deadline, maxDuration string) (time.Time, error) {
+	var end time.Time
+	if deadline = strings.TrimSpace(deadline); deadline != "" {
+		if t, err := time.Parse(time.RFC3339, deadline); err == nil {
+			end = t
+		} else if clock, err := time.ParseInLocation("15:04", deadline, now.Location()); err == nil {
+			end = time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+			if !end.After(now) {
+				end = end.AddDate(0, 0, 1)
+			}
+		} else {
+			return time.Time{}, fmt.Errorf("invalid deadline %q: expected HH:MM or an RFC 3339 time", deadline)
+		}
+		if !end.After(now) {
+			return time.Time{}, fmt.Errorf("deadline %s has already passed", end.Format(time.RFC3339))
+		}
+	}
+	if maxDuration = strings.TrimSpace(maxDuration); maxDuration != "" {
+		d, err := time.ParseDuration(maxDuration)
+		if err != nil || d <= 0 {
+			return time.Time{}, fmt.Errorf("invalid max_duration %q", maxDuration)
+		}
+		if limit := now.Add(d); end.IsZero() || limit.Before(end) {
+			end = limit
+		}
+	}
+	return end, nil
+}
+
+// ExpireWindow marks a run stopped at its deadline. Collections it had not
+// finished are left pending for a resumed run, and resume is added to the
+// errors as the instruction for continuing.
+func ExpireWindow(status *Status, deadline time.Time, resume string) {
+	status.Phase = PhaseWindowExpired
+	status.EstimatedRemain = 0
+	for i := range status.Collections {
+		cs := &status.Collections[i]
+		if cs.State == "running" || cs.State == "interrupted" {
+			cs.State = "pending"
+			cs.Error = ""
+		}
+	}
+	var errs []string
+	for _, e := range status.Errors {
+		if e != "migration cancelled" {
+			errs = append(errs, e)
+		}
+	}
+	status.Errors = append(errs,
+		fmt.Sprintf("migration window ended at %s; reads and writes were stopped and target settings restored", deadline.Format("2006-01-02 15:04 MST")),
+		resume)
+}
@@ -0,0 +1,85 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeadline(t *testing.T) {
+	now := time.Date(2025, 3, 10, 22, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		deadline    string
+		maxDuration string
+		want        time.Time
+		wantErr     bool
+	}{
+		{name: "none"},
+		{name: "clock tomorrow", deadline: "06:00", want: time.Date(2025, 3, 11, 6, 0, 0, 0, time.UTC)},
+		{name: "clock today", deadline: "23:45", want: time.Date(2025, 3, 10, 23, 45, 0, 0, time.UTC)},
+		{name: "timestamp", deadline: "2025-03-11T05:30:00Z", want: time.Date(2025, 3, 11, 5, 30, 0, 0, time.UTC)},
+		{name: "duration", maxDuration: "2h", want: now.Add(2 * time.Hour)},
+		{name: "duration earlier", deadline: "06:00", maxDuration: "2h", want: now.Add(2 * time.Hour)},
+		{name: "deadline earlier", deadline: "23:00", maxDuration: "2h", want: time.Date(2025, 3, 10, 23, 0, 0, 0, time.UTC)},
+		{name: "bad clock", deadline: "25:00", wantErr: true},
+		{name: "past timestamp", deadline: "2025-03-10T20:00:00Z", wantErr: true},
+		{name: "bad duration", maxDuration: "soon", wantErr: true},
+		{name: "negative duration", maxDuration: "-1h", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Deadline(now, tt.deadline, tt.maxDuration)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Deadline: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Deadline = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpireWindow(t *testing.T) {
+	status := &Status{
+		Phase: "failed",
+		Collections: []CollectionStatus{
+			{Name: "a", State: "completed"},
+			{Name: "b", State: "interrupted", DocsWritten: 40},
+			{Name: "c", State: "pending"},
+			{Name: "d", State: "failed", Error: "bad row"},
+		},
+		Errors:          []string{"d: bad row", "migration cancelled"},
+		EstimatedRemain: time.Hour,
+	}
+	deadline := time.Date(2025, 3, 11, 6, 0, 0, 0, time.UTC)
+	ExpireWindow(status, deadline, "resume later")
+
+	if status.Phase != PhaseWindowExpired {
+		t.Errorf("phase = %q, want %q", status.Phase, PhaseWindowExpired)
+	}
+	want := []string{"completed", "pending", "pending", "failed"}
+	for i, c := range status.Collections {
+		if c.State != want[i] {
+			t.Errorf("%s state = %q, want %q", c.Name, c.State, want[i])
+		}
+	}
+	if status.Collections[1].DocsWritten != 40 {
+		t.Error("progress of the interrupted collection should be kept")
+	}
+	if len(status.Errors) != 3 || status.Errors[0] != "d: bad row" || status.Errors[2] != "resume later" {
+		t.Errorf("errors = %q", status.Errors)
+	}
+	if !strings.Contains(status.Errors[1], "2025-03-11 06:00") {
+		t.Errorf("errors should name the deadline: %q", status.Errors[1])
+	}
+	if status.EstimatedRemain != 0 {
+		t.Error("estimate should be cleared")
+	}
+}
@@ -0,0 +1,268 @@
+// Package plan assembles a dry-run migration plan: everything the migration
+// would do, consolidated into one document, without touching any target.
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/codegen"
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/sizing"
+	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// Plan is the consolidated migration plan document.
+type Plan struct {
+	Version     int                `yaml:"version" json:"version"`
+	GeneratedAt time.Time          `yaml:"generated_at" json:"generated_at"`
+	Source      Source             `yaml:"source" json:"source"`
+	Target      Target             `yaml:"target" json:"target"`
+	Collections []Collection       `yaml:"collections" json:"collections"`
+	Sizing      *sizing.SizingPlan `yaml:"sizing,omitempty" json:"sizing,omitempty"`
+	Totals      Totals             `yaml:"totals" json:"totals"`
+	Warnings    []string           `yaml:"warnings,omitempty" json:"warnings,omitempty"`
+}
+
+// Source summarizes the database being migrated from.
+type Source struct {
+	Type     string `yaml:"type" json:"type"`
+	Host     string `yaml:"host" json:"host"`
+	Database string `yaml:"database" json:"database"`
+	Schema   string `yaml:"schema,omitempty" json:"schema,omitempty"`
+	JDBCURL  string `yaml:"jdbc_url,omitempty" json:"jdbc_url,omitempty"`
+}
+
+// Target summarizes the MongoDB database being migrated to.
+type Target struct {
+	Database string `yaml:"database" json:"database"`
+}
+
+// Collection is the plan for a single target collection.
+type Collection struct {
+	Name               string                   `yaml:"name" json:"name"`
+	SourceTable        string                   `yaml:"source_table" json:"source_table"`
+	EmbeddedTables     []string                 `yaml:"embedded_tables,omitempty" json:"embedded_tables,omitempty"`
+	ConsistencyGroup   string                   `yaml:"consistency_group,omitempty" json:"consistency_group,omitempty"`
+	DependsOn          []string                 `yaml:"depends_on,omitempty" json:"depends_on,omitempty"` // collections migrated before it
+	Reads              []codegen.SourceRead     `yaml:"reads" json:"reads"`
+	Fields             []Field                  `yaml:"fields" json:"fields"`
+	ShardKey           map[string]string        `yaml:"shard_key,omitempty" json:"shard_key,omitempty"`
+	Indexes            []target.IndexDefinition `yaml:"indexes,omitempty" json:"indexes,omitempty"`
+	Storage            *mapping.StorageOptions  `yaml:"storage,omitempty" json:"storage,omitempty"`
+	EstimatedDocuments int64                    `yaml:"estimated_documents" json:"estimated_documents"`
+	AvgDocSizeBytes    int64                    `yaml:"avg_doc_size_bytes" json:"avg_doc_size_bytes"`
+	MaxDocSizeBytes    int64                    `yaml:"max_doc_size_bytes" json:"max_doc_size_bytes"`
+	EstimatedBytes     int64                    `yaml:"estimated_bytes" json:"estimated_bytes"`
+}
+
+// Field is a root table column and the document field it is written to.
+type Field struct {
+	Column     string `yaml:"column" json:"column"`
+	Target     string `yaml:"target" json:"target"`
+	SourceType string `yaml:"source_type" json:"source_type"`
+	BSONType   string `yaml:"bson_type" json:"bson_type"`
+}
+
+// Totals sums the per-collection estimates.
+type Totals struct {
+	Collections    int   `yaml:"collections" json:"collections"`
+	Documents      int64 `yaml:"documents" json:"documents"`
+	EstimatedBytes int64 `yaml:"estimated_bytes" json:"estimated_bytes"`
+	Indexes        int   `yaml:"indexes" json:"indexes"`
+}
+
+// Input holds everything a plan is built from. Sizing and Indexes are
+// optional; a missing TypeMap falls back to the source database defaults.
+type Input struct {
+	Config  *config.Config
+	Schema  *schema.Schema
+	Mapping *mapping.Mapping
+	TypeMap *typemap.TypeMap
+	Sizing  *sizing.SizingPlan
+	Indexes *indexes.IndexPlan
+	// EmbedCounts are measured child counts that size embedded arrays;
+	// see mapping.EstimateSizesMeasured
+	EmbedCounts mapping.EmbedCounts
+}
+
+// Build assembles the plan from its inputs.
+func Build(in Input) (*Plan, error) {
+	if in.Config == nil || in.Schema == nil || in.Mapping == nil {
+		return nil, fmt.Errorf("config, schema, and mapping required for a migration plan")
+	}
+	tm := in.TypeMap
+	if tm == nil {
+		tm = typemap.ForDatabase(in.Schema.DatabaseType)
+	}
+
+	gen := &codegen.Generator{
+		Config:  in.Config,
+		Schema:  in.Schema,
+		Mapping: in.Mapping,
+		TypeMap: tm,
+	}
+
+	p := &Plan{
+		Version:     1,
+		GeneratedAt: time.Now().UTC(),
+		Source: Source{
+			Type:     in.Config.Source.Type,
+			Host:     in.Config.Source.Host,
+			Database: in.Config.Source.Database,
+			Schema:   in.Config.Source.Schema,
+			JDBCURL:  buildJDBCURL(in.Config.Source),
+		},
+		Target: Target{Database: in.Config.Target.Database},
+		Sizing: in.Sizing,
+	}
+
+	reads := make(map[string][]codegen.SourceRead)
+	for _, r := range gen.SourceReads() {
+		reads[r.Collection] = append(reads[r.Collection], r)
+	}
+	estimates := make(map[string]mapping.CollectionSizeEstimate)
+	for _, est := range mapping.EstimateSizesMeasured(in.Schema, in.Mapping, tm, in.EmbedCounts) {
+		estimates[est.Collection] = est
+		if est.Warning != "" {
+			p.Warnings = append(p.Warnings, est.Warning)
+		}
+	}
+	migrated := make(map[string]bool)
+	for _, t := range in.Mapping.SourceTables() {
+		migrated[t] = true
+	}
+	for _, d := range tm.DecimalColumns(in.Schema) {
+		if d.Warning != "" && migrated[d.Table] {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("%s.%s: %s", d.Table, d.Column, d.Warning))
+		}
+	}
+	for _, f := range in.Mapping.UnsafeFields(in.Schema) {
+		p.Warnings = append(p.Warnings, f.Warning())
+	}
+	shardKeys := make(map[string]map[string]string)
+	if in.Sizing != nil && in.Sizing.ShardPlan != nil {
+		for _, cs := range in.Sizing.ShardPlan.Collections {
+			shardKeys[cs.CollectionName] = cs.ShardKey
+		}
+	}
+	collIndexes := make(map[string][]target.IndexDefinition)
+	if in.Indexes != nil {
+		for _, ci := range in.Indexes.Indexes {
+			collIndexes[ci.Collection] = append(collIndexes[ci.Collection], ci.Index)
+		}
+	}
+
+	tables := make(map[string]*schema.Table, len(in.Schema.Tables))
+	for i := range in.Schema.Tables {
+		tables[in.Schema.Tables[i].Name] = &in.Schema.Tables[i]
+	}
+
+	// Collections are listed in the order they are migrated
+	for _, c := range in.Mapping.ScheduleOrder(in.Mapping.Collections) {
+		est := estimates[c.Name]
+		pc := Collection{
+			Name:               c.Name,
+			SourceTable:        c.SourceTable,
+			EmbeddedTables:     embeddedTables(c.Embedded),
+			Reads:              reads[c.Name],
+			ShardKey:           shardKeys[c.Name],
+			Indexes:            collIndexes[c.Name],
+			Storage:            c.Storage,
+			EstimatedDocuments: est.AvgRowCount,
+			AvgDocSizeBytes:    est.AvgDocSizeBytes,
+			MaxDocSizeBytes:    est.MaxDocSizeBytes,
+			EstimatedBytes:     est.AvgRowCount * est.AvgDocSizeBytes,
+		}
+		if g := in.Mapping.GroupOf(c.Name); g != nil {
+			pc.ConsistencyGroup = g.Name
+		}
+		pc.DependsOn = in.Mapping.Dependencies(c)
+
+		t := tables[c.SourceTable]
+		if t == nil {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("source table %s for collection %s not found in schema", c.SourceTable, c.Name))
+		} else {
+			for _, col := range t.Columns {
+				path, ok := mapping.FieldTarget(c.Fields, col.Name)
+				if !ok {
+					continue
+				}
+				pc.Fields = append(pc.Fields, Field{
+					Column:     col.Name,
+					Target:     path,
+					SourceType: col.DataType,
+					BSONType:   string(tm.ResolveColumn(col)),
+				})
+			}
+		}
+
+		p.Collections = append(p.Collections, pc)
+		p.Totals.Documents += pc.EstimatedDocuments
+		p.Totals.EstimatedBytes += pc.EstimatedBytes
+		p.Totals.Indexes += len(pc.Indexes)
+	}
+	p.Totals.Collections = len(p.Collections)
+
+	return p, nil
+}
+
+func embeddedTables(embedded []mapping.Embedded) []string {
+	var names []string
+	for _, emb := range embedded {
+		names = append(names, emb.SourceTable)
+		names = append(names, embeddedTables(emb.Embedded)...)
+	}
+	return names
+}
+
+// buildJDBCURL mirrors the URL the generated script connects with, minus
+// credentials, so the plan shows where reads go.
+func buildJDBCURL(src config.SourceConfig) string {
+	switch src.Type {
+	case "postgresql":
+		return fmt.Sprintf("jdbc:postgresql://%s:%d/%s", src.Host, src.Port, src.Database)
+	case "oracle":
+		return fmt.Sprintf("jdbc:oracle:thin:@%s:%d/%s", src.Host, src.Port, src.Database)
+	default:
+		return ""
+	}
+}
+
+// Marshal renders the plan as "yaml" or "json".
+func (p *Plan) Marshal(format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "", "yaml", "yml":
+		return yaml.Marshal(p)
+	case "json":
+		return json.MarshalIndent(p, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported plan format %q (use yaml or json)", format)
+	}
+}
+
+// Write saves the plan to path, choosing the format from the extension.
+func (p *Plan) Write(path string) error {
+	format := "yaml"
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = "json"
+	}
+	data, err := p.Marshal(format)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,223 @@
+package plan
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/sizing"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func testInput() Input {
+	cfg := &config.Config{
+		Version: 1,
+		Source: config.SourceConfig{
+			Type:           "postgresql",
+			Host:           "db.example.com",
+			Port:           5432,
+			Database:       "shop",
+			Password:       "secret",
+			MaxConnections: 10,
+		},
+		Target: config.TargetConfig{Database: "shop"},
+	}
+	s := &schema.Schema{
+		DatabaseType: "postgresql",
+		Tables: []schema.Table{
+			{
+				Name:     "customers",
+				RowCount: 100,
+				Columns: []schema.Column{
+					{Name: "id", DataType: "integer"},
+					{Name: "name", DataType: "varchar"},
+					{Name: "ssn", DataType: "varchar"},
+				},
+				PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}},
+			},
+			{
+				Name:     "orders",
+				RowCount: 1000,
+				Columns: []schema.Column{
+					{Name: "order_id", DataType: "bigint"},
+					{Name: "customer_id", DataType: "integer"},
+				},
+				PrimaryKey: &schema.PrimaryKey{Columns: []string{"order_id"}},
+			},
+		},
+	}
+	m := &mapping.Mapping{
+		Collections: []mapping.Collection{
+			{
+				Name:        "customers",
+				SourceTable: "customers",
+				Embedded: []mapping.Embedded{
+					{SourceTable: "orders", FieldName: "orders", Relationship: "array", JoinColumn: "customer_id", ParentColumn: "id"},
+				},
+				Fields: []mapping.FieldMapping{
+					{Column: "name", Target: "profile.name"},
+					{Column: "ssn", Exclude: true},
+				},
+				Storage: &mapping.StorageOptions{BlockCompressor: "zstd"},
+			},
+		},
+	}
+	return Input{Config: cfg, Schema: s, Mapping: m}
+}
+
+func TestBuild(t *testing.T) {
+	in := testInput()
+	in.Sizing = &sizing.SizingPlan{
+		ShardPlan: &sizing.ShardingPlan{
+			Recommended: true,
+			Collections: []sizing.CollectionShard{
+				{CollectionName: "customers", ShardKey: map[string]string{"_id": "hashed"}},
+			},
+		},
+	}
+	in.Indexes = &indexes.IndexPlan{
+		Indexes: []target.CollectionIndex{
+			{Collection: "customers", Index: target.IndexDefinition{Name: "idx_customers_orders_customer_id"}},
+		},
+	}
+
+	p, err := Build(in)
+	if err != nil {
+		t.Fatalf("Build error: %v", err)
+	}
+
+	if p.Source.JDBCURL != "jdbc:postgresql://db.example.com:5432/shop" {
+		t.Errorf("JDBCURL = %q", p.Source.JDBCURL)
+	}
+	if len(p.Collections) != 1 {
+		t.Fatalf("expected 1 collection, got %d", len(p.Collections))
+	}
+	c := p.Collections[0]
+
+	if len(c.Reads) != 2 || c.Reads[0].Table != "customers" || c.Reads[1].Table != "orders" {
+		t.Fatalf("Reads = %+v", c.Reads)
+	}
+	if c.Reads[1].PartitionColumn != "order_id" || c.Reads[1].NumPartitions != 10 {
+		t.Errorf("orders read = %+v", c.Reads[1])
+	}
+	if !strings.Contains(c.Reads[1].SQL, "FROM orders WHERE order_id >=") {
+		t.Errorf("orders SQL = %q", c.Reads[1].SQL)
+	}
+
+	if len(c.Fields) != 2 {
+		t.Fatalf("expected excluded column to be dropped, got %+v", c.Fields)
+	}
+	if c.Fields[1].Target != "profile.name" || c.Fields[1].BSONType != "String" {
+		t.Errorf("name field = %+v", c.Fields[1])
+	}
+
+	if c.ShardKey["_id"] != "hashed" {
+		t.Errorf("ShardKey = %v", c.ShardKey)
+	}
+	if len(c.Indexes) != 1 || p.Totals.Indexes != 1 {
+		t.Errorf("Indexes = %v, totals = %d", c.Indexes, p.Totals.Indexes)
+	}
+	if c.Storage == nil || c.Storage.BlockCompressor != "zstd" {
+		t.Errorf("Storage = %+v", c.Storage)
+	}
+	if c.EstimatedDocuments != 100 || c.AvgDocSizeBytes == 0 {
+		t.Errorf("estimates = %d docs, %d bytes", c.EstimatedDocuments, c.AvgDocSizeBytes)
+	}
+	if p.Totals.Collections != 1 || p.Totals.EstimatedBytes != c.EstimatedBytes {
+		t.Errorf("Totals = %+v", p.Totals)
+	}
+	if len(c.EmbeddedTables) != 1 || c.EmbeddedTables[0] != "orders" {
+		t.Errorf("EmbeddedTables = %v", c.EmbeddedTables)
+	}
+}
+
+func TestBuild_MissingInputs(t *testing.T) {
+	in := testInput()
+	in.Mapping = nil
+	if _, err := Build(in); err == nil {
+		t.Error("expected error without mapping")
+	}
+}
+
+func TestBuild_UnknownSourceTable(t *testing.T) {
+	in := testInput()
+	in.Mapping.Collections = append(in.Mapping.Collections, mapping.Collection{Name: "ghosts", SourceTable: "ghosts"})
+
+	p, err := Build(in)
+	if err != nil {
+		t.Fatalf("Build error: %v", err)
+	}
+	found := false
+	for _, w := range p.Warnings {
+		if strings.Contains(w, "ghosts") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected warning for missing table, got %v", p.Warnings)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	p, err := Build(testInput())
+	if err != nil {
+		t.Fatalf("Build error: %v", err)
+	}
+
+	tests := []struct {
+		format    string
+		unmarshal func([]byte, interface{}) error
+	}{
+		{"yaml", yaml.Unmarshal},
+		{"json", json.Unmarshal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			data, err := p.Marshal(tt.format)
+			if err != nil {
+				t.Fatalf("Marshal error: %v", err)
+			}
+			if strings.Contains(string(data), "secret") {
+				t.Error("plan must not contain the source password")
+			}
+			var got Plan
+			if err := tt.unmarshal(data, &got); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if len(got.Collections) != 1 || got.Collections[0].Name != "customers" {
+				t.Errorf("round trip collections = %+v", got.Collections)
+			}
+		})
+	}
+
+	if _, err := p.Marshal("xml"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	p, err := Build(testInput())
+	if err != nil {
+		t.Fatalf("Build error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "out", "plan.json")
+	if err := p.Write(path); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading plan: %v", err)
+	}
+	if !json.Valid(data) {
+		t.Error("expected JSON output for .json path")
+	}
+}
@@ -0,0 +1,156 @@
+package postmigration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/report"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// MaxKeysExaminedRatio is the most index keys a canary query may examine per
+// document returned before it is flagged.
+const MaxKeysExaminedRatio = 10
+
+// CanaryResult is the outcome of one canary query.
+type CanaryResult struct {
+	Query mapping.CanaryQuery `json:"query"`
+	Stats *target.QueryStats  `json:"stats,omitempty"`
+	Flags []string            `json:"flags,omitempty"`
+	Error string              `json:"error,omitempty"`
+}
+
+// Flagged reports whether the query failed or was served inefficiently.
+func (r CanaryResult) Flagged() bool {
+	return r.Error != "" || len(r.Flags) > 0
+}
+
+// EvaluateQuery flags a canary query that scans its collection or examines
+// far more index keys than the documents it returns.
+func EvaluateQuery(stats *target.QueryStats) []string {
+	var flags []string
+	if stats.CollectionScan {
+		flags = append(flags, fmt.Sprintf("collection scan (%d documents examined)", stats.DocsExamined))
+	}
+	returned := stats.NReturned
+	if returned < 1 {
+		returned = 1
+	}
+	if stats.KeysExamined > returned*MaxKeysExaminedRatio {
+		flags = append(flags, fmt.Sprintf("examined %d index keys to return %d documents", stats.KeysExamined, stats.NReturned))
+	}
+	return flags
+}
+
+// RunCanaryQueries explains every canary query against the target and
+// writes the results next to the state file. Queries registered in the
+// mapping are used, or generated from foreign key access paths when there
+// are none. It is a no-op when there are no queries to run.
+func (o *Orchestrator) RunCanaryQueries(ctx context.Context, cb Callbacks) ([]CanaryResult, error) {
+	if o.Mapping == nil {
+		return nil, nil
+	}
+	if err := o.Mapping.ValidateQueries(); err != nil {
+		return nil, fmt.Errorf("invalid canary queries: %w", err)
+	}
+	queries := o.Mapping.CanaryQueries()
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	results := make([]CanaryResult, 0, len(queries))
+	status := "passed"
+	for _, q := range queries {
+		r := CanaryResult{Query: q}
+		stats, err := o.Target.ExplainQuery(ctx, q)
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Stats = stats
+			r.Flags = EvaluateQuery(stats)
+		}
+		if r.Flagged() {
+			status = "flagged"
+		}
+		if cb.OnCanaryQuery != nil {
+			cb.OnCanaryQuery(r)
+		}
+		results = append(results, r)
+	}
+
+	stateDir := filepath.Dir(config.ExpandHome(o.StatePath))
+	reportPath := filepath.Join(stateDir, "canary-queries.json")
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling canary report: %w", err)
+	}
+	if err := os.WriteFile(reportPath, data, 0o644); err != nil {
+		return nil, fmt.Errorf("saving canary report: %w", err)
+	}
+
+	o.State.CanaryReportPath = reportPath
+	o.State.CanaryStatus = status
+	if err := o.State.Save(o.StatePath); err != nil {
+		return nil, fmt.Errorf("saving state: %w", err)
+	}
+
+	if cb.OnStepComplete != nil {
+		cb.OnStepComplete("canary_queries")
+	}
+
+	return results, nil
+}
+
+// LoadCanaryResults reads a canary report written by RunCanaryQueries.
+func LoadCanaryResults(path string) ([]CanaryResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading canary report: %w", err)
+	}
+	var results []CanaryResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("parsing canary report: %w", err)
+	}
+	return results, nil
+}
+
+// canaryCheck reports whether the canary queries ran without being flagged,
+// naming the flagged queries otherwise.
+func (o *Orchestrator) canaryCheck() report.ReadinessCheck {
+	check := report.ReadinessCheck{Name: "Canary queries"}
+	switch o.State.CanaryStatus {
+	case "":
+		check.Message = "Run the canary queries to confirm they are served by indexes"
+		return check
+	case "passed":
+		check.Passed = true
+		check.Message = "All canary queries use indexes efficiently"
+		return check
+	}
+
+	var flagged []string
+	if results, err := LoadCanaryResults(o.State.CanaryReportPath); err == nil {
+		for _, r := range results {
+			if !r.Flagged() {
+				continue
+			}
+			reason := r.Error
+			if reason == "" {
+				reason = strings.Join(r.Flags, ", ")
+			}
+			flagged = append(flagged, fmt.Sprintf("%s (%s)", r.Query.Name, reason))
+		}
+	}
+	if len(flagged) == 0 {
+		check.Message = "Some canary queries were flagged; see " + o.State.CanaryReportPath
+		return check
+	}
+	check.Message = "Canary queries need indexes: " + strings.Join(flagged, "; ")
+	return check
+}
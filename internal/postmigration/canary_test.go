@@ -0,0 +1,160 @@
+package postmigration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func TestEvaluateQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats target.QueryStats
+		flags int
+	}{
+		{"index scan", target.QueryStats{NReturned: 5, KeysExamined: 5}, 0},
+		{"no matches", target.QueryStats{NReturned: 0, KeysExamined: 1}, 0},
+		{"collection scan", target.QueryStats{CollectionScan: true, DocsExamined: 1000}, 1},
+		{"excessive keys", target.QueryStats{NReturned: 2, KeysExamined: 500}, 1},
+		{"scan and keys", target.QueryStats{CollectionScan: true, NReturned: 1, KeysExamined: 50}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EvaluateQuery(&tt.stats); len(got) != tt.flags {
+				t.Errorf("EvaluateQuery() = %v, want %d flags", got, tt.flags)
+			}
+		})
+	}
+}
+
+func TestRunCanaryQueries(t *testing.T) {
+	orch, _, tgt := makeTestOrchestrator(t)
+	orch.Mapping.Queries = []mapping.CanaryQuery{
+		{Name: "user_by_id", Collection: "users", Fields: []string{"user_id"}},
+		{Name: "users_by_name", Collection: "users", Filter: `{"name": "Alice"}`},
+	}
+	tgt.QueryStats = map[string]*target.QueryStats{
+		"users_by_name": {Stages: []string{"COLLSCAN"}, CollectionScan: true, DocsExamined: 100},
+	}
+
+	var seen []string
+	stepDone := false
+	cb := Callbacks{
+		OnCanaryQuery: func(r CanaryResult) {
+			seen = append(seen, r.Query.Name)
+		},
+		OnStepComplete: func(step string) {
+			stepDone = step == "canary_queries"
+		},
+	}
+
+	results, err := orch.RunCanaryQueries(context.Background(), cb)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 || len(seen) != 2 || !stepDone {
+		t.Fatalf("results = %d, callbacks = %v, step done = %v", len(results), seen, stepDone)
+	}
+	if results[0].Flagged() || !results[1].Flagged() {
+		t.Errorf("only users_by_name should be flagged: %+v", results)
+	}
+	if orch.State.CanaryStatus != "flagged" {
+		t.Errorf("expected flagged, got %s", orch.State.CanaryStatus)
+	}
+
+	loaded, err := LoadCanaryResults(orch.State.CanaryReportPath)
+	if err != nil {
+		t.Fatalf("loading report: %v", err)
+	}
+	if len(loaded) != 2 || loaded[1].Stats == nil || !loaded[1].Stats.CollectionScan {
+		t.Errorf("report did not round-trip: %+v", loaded)
+	}
+
+	rpt, err := orch.CheckReadiness(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var found bool
+	for _, c := range rpt.ReadinessChecks {
+		if c.Name == "Canary queries" {
+			found = true
+			if c.Passed {
+				t.Error("canary check should fail when a query is flagged")
+			}
+			if !strings.Contains(c.Message, "users_by_name (collection scan") {
+				t.Errorf("message should name the flagged query: %s", c.Message)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected a canary queries readiness check")
+	}
+}
+
+func TestRunCanaryQueries_AccessPaths(t *testing.T) {
+	orch, _, tgt := makeTestOrchestrator(t)
+	orch.Mapping.Collections = []mapping.Collection{
+		{Name: "users", SourceTable: "users", References: []mapping.Reference{
+			{SourceTable: "orders", FieldName: "orders", JoinColumn: "user_id", ParentColumn: "user_id"},
+		}},
+		{Name: "orders", SourceTable: "orders"},
+	}
+
+	results, err := orch.RunCanaryQueries(context.Background(), Callbacks{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Query.Name != "orders_by_user_id" {
+		t.Fatalf("results = %+v, want generated orders_by_user_id", results)
+	}
+	if len(tgt.ExplainedQueries) != 1 {
+		t.Errorf("ExplainedQueries = %v", tgt.ExplainedQueries)
+	}
+	if orch.State.CanaryStatus != "passed" {
+		t.Errorf("expected passed, got %s", orch.State.CanaryStatus)
+	}
+}
+
+func TestRunCanaryQueries_ExplainError(t *testing.T) {
+	orch, _, tgt := makeTestOrchestrator(t)
+	orch.Mapping.Queries = []mapping.CanaryQuery{
+		{Name: "user_by_id", Collection: "users", Fields: []string{"user_id"}},
+	}
+	tgt.ExplainErr = errors.New("unknown operator")
+
+	results, err := orch.RunCanaryQueries(context.Background(), Callbacks{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Error == "" {
+		t.Errorf("explain error should be recorded on the result: %+v", results)
+	}
+	if orch.State.CanaryStatus != "flagged" {
+		t.Errorf("expected flagged, got %s", orch.State.CanaryStatus)
+	}
+}
+
+func TestRunCanaryQueries_None(t *testing.T) {
+	orch, _, tgt := makeTestOrchestrator(t)
+
+	results, err := orch.RunCanaryQueries(context.Background(), Callbacks{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results != nil || len(tgt.ExplainedQueries) != 0 || orch.State.CanaryStatus != "" {
+		t.Errorf("expected a no-op, got results %v, status %q", results, orch.State.CanaryStatus)
+	}
+}
+
+func TestRunCanaryQueries_Invalid(t *testing.T) {
+	orch, _, _ := makeTestOrchestrator(t)
+	orch.Mapping.Queries = []mapping.CanaryQuery{{Name: "q", Collection: "invoices", Filter: `{}`}}
+
+	if _, err := orch.RunCanaryQueries(context.Background(), Callbacks{}); err == nil {
+		t.Error("expected error for a query on an unmapped collection")
+	}
+}
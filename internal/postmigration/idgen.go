@@ -0,0 +1,237 @@
+package postmigration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/report"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// CountersCollection holds one document per collection whose new ids come
+// from a counter: {_id: <collection>, seq: <last id handed out>}.
+const CountersCollection = "counters"
+
+// IDCandidate is a collection whose root table's primary key is filled from
+// a sequence or identity column, and the strategy chosen to make new ids
+// after cutover, empty if none.
+type IDCandidate struct {
+	Collection string `json:"collection"`
+	Table      string `json:"table"`
+	Column     string `json:"column"`
+	Field      string `json:"field"` // document field the key is written to
+	Strategy   string `json:"strategy,omitempty"`
+}
+
+// IDCandidates lists the mapped collections whose primary key is a migrated
+// sequence or identity column.
+func IDCandidates(s *schema.Schema, m *mapping.Mapping) []IDCandidate {
+	out := []IDCandidate{}
+	if s == nil || m == nil {
+		return out
+	}
+	for i := range m.Collections {
+		c := &m.Collections[i]
+		col, ok := c.SequenceKey(s)
+		if !ok {
+			continue
+		}
+		field, ok := c.RootField(col.Name)
+		if !ok {
+			continue
+		}
+		out = append(out, IDCandidate{
+			Collection: c.Name,
+			Table:      c.SourceTable,
+			Column:     col.Name,
+			Field:      field,
+			Strategy:   c.IDGeneration,
+		})
+	}
+	return out
+}
+
+// CounterSeed is a counter set by RunIDGeneration. Seq is the counter after
+// seeding: the highest source id, or the counter's own value when that is
+// already higher, so seeding again never hands out an id twice.
+type CounterSeed struct {
+	Collection string `json:"collection"`
+	MaxID      int64  `json:"max_id"`
+	Seq        int64  `json:"seq"`
+	Error      string `json:"error,omitempty"`
+}
+
+// RunIDGeneration seeds the counters collection with the highest source id
+// of every collection using a counter, and writes a guide next to the state
+// file describing how the application makes new ids for each collection
+// with a strategy. It is a no-op when no collection has one.
+func (o *Orchestrator) RunIDGeneration(ctx context.Context, cb Callbacks) ([]CounterSeed, error) {
+	var chosen []IDCandidate
+	for _, c := range IDCandidates(o.Schema, o.Mapping) {
+		if c.Strategy != "" {
+			chosen = append(chosen, c)
+		}
+	}
+	if len(chosen) == 0 {
+		return nil, nil
+	}
+	if err := o.Mapping.ValidateIDGeneration(o.Schema); err != nil {
+		return nil, fmt.Errorf("invalid id generation: %w", err)
+	}
+
+	var seeds []CounterSeed
+	status := "complete"
+	for _, c := range chosen {
+		if c.Strategy != mapping.IDCounter {
+			continue
+		}
+		seed := o.seedCounter(ctx, c)
+		if seed.Error != "" {
+			status = "failed"
+		}
+		if cb.OnCounterSeeded != nil {
+			cb.OnCounterSeeded(seed)
+		}
+		seeds = append(seeds, seed)
+	}
+
+	stateDir := filepath.Dir(config.ExpandHome(o.StatePath))
+	docPath := filepath.Join(stateDir, "id-generation.md")
+	if err := os.WriteFile(docPath, []byte(IDGenerationGuide(chosen, seeds)), 0o644); err != nil {
+		return nil, fmt.Errorf("saving id generation guide: %w", err)
+	}
+
+	o.State.IDGenerationDocPath = docPath
+	o.State.IDGenerationStatus = status
+	if err := o.State.Save(o.StatePath); err != nil {
+		return nil, fmt.Errorf("saving state: %w", err)
+	}
+
+	if cb.OnStepComplete != nil {
+		cb.OnStepComplete("id_generation")
+	}
+	return seeds, nil
+}
+
+// seedCounter raises the collection's counter to the highest id of its
+// source table.
+func (o *Orchestrator) seedCounter(ctx context.Context, c IDCandidate) CounterSeed {
+	seed := CounterSeed{Collection: c.Collection}
+	if o.Source == nil {
+		seed.Error = "no source connection to read the highest id from"
+		return seed
+	}
+	_, hi, err := o.Source.KeyRange(ctx, c.Table, c.Column)
+	if err != nil {
+		seed.Error = fmt.Sprintf("reading the highest %s.%s: %v", c.Table, c.Column, err)
+		return seed
+	}
+	seed.MaxID, seed.Seq = hi, hi
+
+	docs, err := o.Target.FindDocuments(ctx, CountersCollection, map[string]interface{}{"_id": c.Collection})
+	if err != nil {
+		seed.Error = fmt.Sprintf("reading counter: %v", err)
+		return seed
+	}
+	for _, d := range docs {
+		if d["_id"] != c.Collection {
+			continue
+		}
+		if cur, ok := counterValue(d["seq"]); ok && cur >= hi {
+			seed.Seq = cur
+			return seed
+		}
+	}
+
+	op := target.WriteOp{
+		Type:   target.WriteUpsert,
+		Filter: map[string]interface{}{"_id": c.Collection},
+		Doc:    map[string]interface{}{"seq": hi},
+	}
+	if err := o.Target.ApplyWrites(ctx, CountersCollection, []target.WriteOp{op}); err != nil {
+		seed.Error = fmt.Sprintf("writing counter: %v", err)
+	}
+	return seed
+}
+
+// idGenerationCheck reports whether the counters were seeded and the guide
+// written, or nil when no collection has an ID generation strategy.
+func (o *Orchestrator) idGenerationCheck() *report.ReadinessCheck {
+	chosen := false
+	for _, c := range IDCandidates(o.Schema, o.Mapping) {
+		chosen = chosen || c.Strategy != ""
+	}
+	if !chosen {
+		return nil
+	}
+	check := &report.ReadinessCheck{Name: "ID generation"}
+	switch o.State.IDGenerationStatus {
+	case "complete":
+		check.Passed = true
+		check.Message = "New ids are generated as documented in " + o.State.IDGenerationDocPath
+	case "failed":
+		check.Message = "Some counters could not be seeded; see " + o.State.IDGenerationDocPath
+	default:
+		check.Message = "Seed the counters and document how new ids are generated"
+	}
+	return check
+}
+
+func counterValue(v interface{}) (int64, bool) {
+	switch x := v.(type) {
+	case int:
+		return int64(x), true
+	case int32:
+		return int64(x), true
+	case int64:
+		return x, true
+	case float64:
+		return int64(x), true
+	}
+	return 0, false
+}
+
+// IDGenerationGuide renders, as Markdown, how the application makes the ids
+// of new documents in each collection with a strategy.
+func IDGenerationGuide(candidates []IDCandidate, seeds []CounterSeed) string {
+	bySeed := make(map[string]CounterSeed, len(seeds))
+	for _, s := range seeds {
+		bySeed[s.Collection] = s
+	}
+
+	var b strings.Builder
+	b.WriteString("# Generating ids after cutover\n\n")
+	b.WriteString("The source filled these primary keys from sequences or identity columns, which MongoDB does not have.\n")
+	for _, c := range candidates {
+		switch c.Strategy {
+		case mapping.IDCounter:
+			fmt.Fprintf(&b, "\n## %s: counter\n\n", c.Collection)
+			fmt.Fprintf(&b, "New ids for `%s` come from the `%s` document of the `%s` collection. ", c.Field, c.Collection, CountersCollection)
+			if s, ok := bySeed[c.Collection]; ok && s.Error == "" {
+				fmt.Fprintf(&b, "It was seeded at %d (highest %s.%s: %d).\n", s.Seq, c.Table, c.Column, s.MaxID)
+			} else if ok {
+				fmt.Fprintf(&b, "Seeding it failed: %s. Seed it before the application writes.\n", s.Error)
+			} else {
+				b.WriteString("It has not been seeded yet.\n")
+			}
+			b.WriteString("\nTake the next id atomically before each insert:\n\n")
+			fmt.Fprintf(&b, "```js\nconst { seq } = db.%s.findOneAndUpdate(\n  { _id: %q },\n  { $inc: { seq: 1 } },\n  { upsert: true, returnDocument: \"after\" }\n)\ndb.%s.insertOne({ %s: seq, ... })\n```\n",
+				CountersCollection, c.Collection, c.Collection, c.Field)
+			fmt.Fprintf(&b, "\nKeep `%s.%s` from handing out ids after cutover, and seed the counter again if the source takes writes after this run.\n", c.Table, c.Column)
+		case mapping.IDObjectID:
+			fmt.Fprintf(&b, "\n## %s: ObjectId\n\n", c.Collection)
+			fmt.Fprintf(&b, "New documents are identified by their ObjectId `_id` and have no `%s`. ", c.Field)
+			fmt.Fprintf(&b, "Migrated documents keep `%s` from %s.%s, so the application must:\n\n", c.Field, c.Table, c.Column)
+			fmt.Fprintf(&b, "- look documents up by `_id`, falling back to `%s` only for ids issued before cutover\n", c.Field)
+			fmt.Fprintf(&b, "- store `_id` rather than `%s` in documents that reference %s\n", c.Field, c.Collection)
+			fmt.Fprintf(&b, "- build any unique index on `%s` as a partial index, since new documents leave it unset\n", c.Field)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package postmigration
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func idGenOrchestrator(t *testing.T) (*Orchestrator, *target.MockOperator) {
+	t.Helper()
+	orch, src, tgt := makeTestOrchestrator(t)
+	orch.Schema.Tables = append(orch.Schema.Tables,
+		schema.Table{
+			Name:       "orders",
+			Columns:    []schema.Column{{Name: "order_id", DataType: "bigint", IsSequence: true}},
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"order_id"}},
+		},
+		schema.Table{
+			Name:       "invoices",
+			Columns:    []schema.Column{{Name: "invoice_id", DataType: "bigint", IsSequence: true}},
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"invoice_id"}},
+		},
+	)
+	orch.Mapping.Collections = append(orch.Mapping.Collections,
+		mapping.Collection{Name: "orders", SourceTable: "orders", IDGeneration: mapping.IDCounter},
+		mapping.Collection{Name: "invoices", SourceTable: "invoices", IDGeneration: mapping.IDObjectID,
+			Transformations: []mapping.Transformation{{SourceField: "invoice_id", Operation: "rename", TargetField: "number"}}},
+	)
+	src.TableRows = map[string][]map[string]interface{}{
+		"orders": {{"order_id": int64(7)}, {"order_id": int64(42)}},
+	}
+	return orch, tgt
+}
+
+func TestIDCandidates(t *testing.T) {
+	orch, _ := idGenOrchestrator(t)
+	got := IDCandidates(orch.Schema, orch.Mapping)
+	if len(got) != 2 {
+		t.Fatalf("candidates = %+v, want orders and invoices", got)
+	}
+	if got[1].Field != "number" || got[1].Strategy != mapping.IDObjectID {
+		t.Errorf("invoices = %+v, want the renamed field and objectid", got[1])
+	}
+}
+
+func TestRunIDGeneration(t *testing.T) {
+	orch, tgt := idGenOrchestrator(t)
+
+	var seeded []CounterSeed
+	seeds, err := orch.RunIDGeneration(context.Background(), Callbacks{
+		OnCounterSeeded: func(s CounterSeed) { seeded = append(seeded, s) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seeds) != 1 || seeds[0].Seq != 42 || seeds[0].Error != "" || len(seeded) != 1 {
+		t.Fatalf("seeds = %+v, want orders seeded at 42", seeds)
+	}
+	writes := tgt.AppliedWrites[CountersCollection]
+	if len(writes) != 1 || writes[0].Filter["_id"] != "orders" || writes[0].Doc["seq"] != int64(42) {
+		t.Errorf("counter writes = %+v", writes)
+	}
+	if orch.State.IDGenerationStatus != "complete" {
+		t.Errorf("status = %q, want complete", orch.State.IDGenerationStatus)
+	}
+
+	guide, err := os.ReadFile(orch.State.IDGenerationDocPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## orders: counter", "seeded at 42", "## invoices: ObjectId", "have no `number`"} {
+		if !strings.Contains(string(guide), want) {
+			t.Errorf("guide missing %q:\n%s", want, guide)
+		}
+	}
+}
+
+func TestRunIDGeneration_KeepsHigherCounter(t *testing.T) {
+	orch, tgt := idGenOrchestrator(t)
+	tgt.Documents = map[string][]map[string]interface{}{
+		CountersCollection: {{"_id": "orders", "seq": int64(100)}},
+	}
+
+	seeds, err := orch.RunIDGeneration(context.Background(), Callbacks{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seeds[0].Seq != 100 || len(tgt.AppliedWrites[CountersCollection]) != 0 {
+		t.Errorf("seeds = %+v, writes = %v; the application's counter must not move back", seeds, tgt.AppliedWrites)
+	}
+}
+
+func TestRunIDGeneration_None(t *testing.T) {
+	orch, _, _ := makeTestOrchestrator(t)
+	seeds, err := orch.RunIDGeneration(context.Background(), Callbacks{})
+	if err != nil || seeds != nil || orch.State.IDGenerationDocPath != "" {
+		t.Errorf("without strategies: seeds = %v, err = %v, guide = %q", seeds, err, orch.State.IDGenerationDocPath)
+	}
+}
+
+func TestCheckReadiness_IDGeneration(t *testing.T) {
+	orch, _ := idGenOrchestrator(t)
+	idCheck := func() (passed bool, found bool) {
+		t.Helper()
+		rpt, err := orch.CheckReadiness(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range rpt.ReadinessChecks {
+			if c.Name == "ID generation" {
+				return c.Passed, true
+			}
+		}
+		return false, false
+	}
+
+	if passed, found := idCheck(); !found || passed {
+		t.Errorf("before seeding: found = %v, passed = %v", found, passed)
+	}
+	if _, err := orch.RunIDGeneration(context.Background(), Callbacks{}); err != nil {
+		t.Fatal(err)
+	}
+	if passed, _ := idCheck(); !passed {
+		t.Error("after seeding the check should pass")
+	}
+}
@@ -0,0 +1,467 @@
+package postmigration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// Defaults for IndexBuildOptions.
+const (
+	DefaultIndexConcurrency  = 1
+	DefaultIndexPollInterval = 5 * time.Second
+)
+
+// Phases of an index build.
+const (
+	indexPhasePending  = "pending"
+	indexPhaseBuilding = "building"
+	indexPhaseComplete = "complete"
+	indexPhaseFailed   = "failed"
+	indexPhaseSkipped  = "skipped" // not started because an earlier build failed, or not buildable here
+)
+
+// clusterShard is the shard counted for collections whose shards are not
+// known, which makes MaxPerShard a cluster-wide limit for them.
+const clusterShard = ""
+
+// IndexBuildOptions controls how RunIndexBuilds schedules index builds.
+type IndexBuildOptions struct {
+	// Concurrency is how many collections have indexes built at once. The
+	// indexes of one collection are always built one at a time, in plan
+	// order. Default 1.
+	Concurrency int
+	// MaxPerShard caps the index builds running at once on any one shard. A
+	// build counts against every shard holding data of its collection; on an
+	// unsharded target it caps all builds. 0 is unlimited.
+	MaxPerShard int
+	// PollInterval is how often build progress is read from the target.
+	PollInterval time.Duration
+	// Naming is the convention every index name must follow; the plan is
+	// refused before any build starts otherwise. Empty allows any name.
+	Naming indexes.NameConvention
+}
+
+func (o IndexBuildOptions) withDefaults() IndexBuildOptions {
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultIndexConcurrency
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultIndexPollInterval
+	}
+	return o
+}
+
+// IndexBuildControl pauses and resumes index builds while they run. Pausing
+// stops further builds from starting; builds already running on the server
+// are left to finish. A nil control never pauses.
+type IndexBuildControl struct {
+	mu     sync.Mutex
+	resume chan struct{} // closed on Resume; nil while not paused
+}
+
+// NewIndexBuildControl returns a control in the running state.
+func NewIndexBuildControl() *IndexBuildControl {
+	return &IndexBuildControl{}
+}
+
+// Pause stops further index builds from starting.
+func (c *IndexBuildControl) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resume == nil {
+		c.resume = make(chan struct{})
+	}
+}
+
+// Resume lets index builds start again.
+func (c *IndexBuildControl) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resume != nil {
+		close(c.resume)
+		c.resume = nil
+	}
+}
+
+// Paused reports whether the builds are paused.
+func (c *IndexBuildControl) Paused() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resume != nil
+}
+
+// wait blocks while the builds are paused.
+func (c *IndexBuildControl) wait(ctx context.Context) error {
+	if c == nil {
+		return ctx.Err()
+	}
+	c.mu.Lock()
+	ch := c.resume
+	c.mu.Unlock()
+	if ch == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-ch:
+		return ctx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OverallIndexProgress is the mean progress of the builds, in percent, with
+// completed builds counting as 100.
+func OverallIndexProgress(statuses []target.IndexBuildStatus) float64 {
+	if len(statuses) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range statuses {
+		if s.Phase == indexPhaseComplete {
+			sum += 100
+		} else {
+			sum += s.Progress
+		}
+	}
+	return sum / float64(len(statuses))
+}
+
+// indexBuilder schedules the builds of an index plan and tracks their
+// progress.
+type indexBuilder struct {
+	op       target.Operator
+	search   target.SearchIndexCreator // creates Atlas Search indexes; may be nil
+	opts     IndexBuildOptions
+	control  *IndexBuildControl
+	onUpdate func([]target.IndexBuildStatus)
+
+	mu       sync.Mutex
+	statuses []target.IndexBuildStatus // one per plan entry, in plan order
+	running  map[string]int            // builds running per shard
+	released chan struct{}             // closed and replaced whenever a build ends
+	err      error                     // first build failure
+
+	notifyMu sync.Mutex
+}
+
+func newIndexBuilder(op target.Operator, plan []target.CollectionIndex, opts IndexBuildOptions, control *IndexBuildControl, onUpdate func([]target.IndexBuildStatus)) *indexBuilder {
+	statuses := make([]target.IndexBuildStatus, len(plan))
+	for i, ci := range plan {
+		statuses[i] = target.IndexBuildStatus{
+			Collection: ci.Collection,
+			IndexName:  ci.Index.Name,
+			Phase:      indexPhasePending,
+		}
+	}
+	return &indexBuilder{
+		op:       op,
+		opts:     opts.withDefaults(),
+		control:  control,
+		onUpdate: onUpdate,
+		statuses: statuses,
+		running:  make(map[string]int),
+		released: make(chan struct{}),
+	}
+}
+
+// run builds every index in plan, Concurrency collections at a time, and
+// returns the first failure. After a failure no further builds start.
+func (b *indexBuilder) run(ctx context.Context, plan []target.CollectionIndex) error {
+	var order []string
+	byCollection := make(map[string][]int)
+	for i, ci := range plan {
+		if _, ok := byCollection[ci.Collection]; !ok {
+			order = append(order, ci.Collection)
+		}
+		byCollection[ci.Collection] = append(byCollection[ci.Collection], i)
+	}
+
+	pollCtx, stopPoll := context.WithCancel(ctx)
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		b.poll(pollCtx)
+	}()
+
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for range min(b.opts.Concurrency, len(order)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for coll := range work {
+				b.buildCollection(ctx, coll, plan, byCollection[coll])
+			}
+		}()
+	}
+	for _, coll := range order {
+		work <- coll
+	}
+	close(work)
+	wg.Wait()
+	stopPoll()
+	<-polled
+
+	b.mu.Lock()
+	err := b.err
+	if err == nil {
+		err = ctx.Err()
+	}
+	b.mu.Unlock()
+	b.notify()
+	return err
+}
+
+// buildCollection builds the indexes of one collection one at a time.
+func (b *indexBuilder) buildCollection(ctx context.Context, coll string, plan []target.CollectionIndex, idxs []int) {
+	var shards []string
+	if locator, ok := b.op.(target.ShardLocator); ok && b.opts.MaxPerShard > 0 {
+		if s, err := locator.CollectionShards(ctx, coll); err == nil {
+			shards = s
+		}
+	}
+	if len(shards) == 0 {
+		shards = []string{clusterShard}
+	}
+
+	for _, i := range idxs {
+		if b.stopped() {
+			b.setPhase(i, indexPhaseSkipped, "an earlier index build failed")
+			continue
+		}
+		if err := b.control.wait(ctx); err != nil {
+			b.fail(i, err)
+			continue
+		}
+		if plan[i].Index.Search() {
+			b.buildSearchIndex(ctx, coll, plan[i].Index, i)
+			continue
+		}
+		if err := b.acquire(ctx, shards); err != nil {
+			b.fail(i, err)
+			continue
+		}
+		b.setPhase(i, indexPhaseBuilding, "")
+		err := b.op.CreateIndex(ctx, coll, plan[i].Index)
+		b.release(shards)
+		if err != nil {
+			b.fail(i, fmt.Errorf("creating index %s on %s: %w", plan[i].Index.Name, coll, err))
+			continue
+		}
+		b.setPhase(i, indexPhaseComplete, "")
+	}
+}
+
+// buildSearchIndex creates an Atlas Search index through the Atlas API.
+// Atlas builds it apart from the cluster's own index builds, so it takes no
+// shard slot. Without the Atlas API configured the index is skipped rather
+// than failing the plan.
+func (b *indexBuilder) buildSearchIndex(ctx context.Context, coll string, idx target.IndexDefinition, i int) {
+	if b.search == nil {
+		b.setPhase(i, indexPhaseSkipped, "Atlas Search index needs the atlas API settings")
+		return
+	}
+	b.setPhase(i, indexPhaseBuilding, "")
+	if err := b.search.CreateSearchIndex(ctx, coll, idx); err != nil {
+		b.fail(i, err)
+		return
+	}
+	b.setPhase(i, indexPhaseComplete, "created through the Atlas API")
+}
+
+// acquire takes a build slot on each of the shards, waiting until every one
+// of them is under MaxPerShard. Slots are taken all at once so builds that
+// span several shards cannot deadlock each other.
+func (b *indexBuilder) acquire(ctx context.Context, shards []string) error {
+	for {
+		b.mu.Lock()
+		free := true
+		if b.opts.MaxPerShard > 0 {
+			for _, s := range shards {
+				if b.running[s] >= b.opts.MaxPerShard {
+					free = false
+					break
+				}
+			}
+		}
+		if free {
+			for _, s := range shards {
+				b.running[s]++
+			}
+			b.mu.Unlock()
+			return nil
+		}
+		released := b.released
+		b.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *indexBuilder) release(shards []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range shards {
+		b.running[s]--
+	}
+	close(b.released)
+	b.released = make(chan struct{})
+}
+
+func (b *indexBuilder) stopped() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err != nil
+}
+
+// fail records a failed build; the first failure is the one returned.
+func (b *indexBuilder) fail(i int, err error) {
+	b.mu.Lock()
+	if b.err == nil {
+		b.err = err
+	}
+	b.mu.Unlock()
+	b.setPhase(i, indexPhaseFailed, err.Error())
+}
+
+func (b *indexBuilder) setPhase(i int, phase, msg string) {
+	b.mu.Lock()
+	s := &b.statuses[i]
+	s.Phase = phase
+	s.Message = msg
+	switch phase {
+	case indexPhaseComplete:
+		s.Progress = 100
+	case indexPhasePending, indexPhaseBuilding:
+		s.Progress = 0
+	}
+	b.mu.Unlock()
+	b.notify()
+}
+
+// poll reads build progress from the target until ctx is done. Builds
+// currentOp does not report, as where it is not allowed, are estimated
+// from index sizes when the target can.
+func (b *indexBuilder) poll(ctx context.Context) {
+	ticker := time.NewTicker(b.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ops, err := b.op.ListIndexBuildProgress(ctx)
+		if err != nil {
+			ops = nil
+		}
+		ops = append(ops, b.estimate(ctx, ops)...)
+		if b.merge(ops) {
+			b.notify()
+		}
+	}
+}
+
+// estimate returns estimated progress for the running builds missing from
+// ops, or nil when the target cannot estimate it.
+func (b *indexBuilder) estimate(ctx context.Context, ops []target.IndexBuildStatus) []target.IndexBuildStatus {
+	estimator, ok := b.op.(target.IndexProgressEstimator)
+	if !ok {
+		return nil
+	}
+	reported := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		reported[op.Collection+"/"+op.IndexName] = true
+	}
+
+	var order []string
+	missing := make(map[string][]string)
+	b.mu.Lock()
+	for _, s := range b.statuses {
+		if s.Phase != indexPhaseBuilding || reported[s.Collection+"/"+s.IndexName] {
+			continue
+		}
+		if _, ok := missing[s.Collection]; !ok {
+			order = append(order, s.Collection)
+		}
+		missing[s.Collection] = append(missing[s.Collection], s.IndexName)
+	}
+	b.mu.Unlock()
+
+	var estimated []target.IndexBuildStatus
+	for _, coll := range order {
+		st, err := estimator.EstimateIndexBuildProgress(ctx, coll, missing[coll])
+		if err != nil {
+			continue
+		}
+		estimated = append(estimated, st...)
+	}
+	return estimated
+}
+
+// merge copies server-side progress onto the running builds. A sharded
+// build reports once per shard; its progress is the mean over the shards.
+func (b *indexBuilder) merge(ops []target.IndexBuildStatus) bool {
+	type agg struct {
+		sum float64
+		n   int
+		msg string
+	}
+	byIndex := make(map[string]*agg)
+	for _, op := range ops {
+		key := op.Collection + "/" + op.IndexName
+		a := byIndex[key]
+		if a == nil {
+			a = &agg{}
+			byIndex[key] = a
+		}
+		a.sum += op.Progress
+		a.n++
+		if op.Message != "" {
+			a.msg = op.Message
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	changed := false
+	for i := range b.statuses {
+		s := &b.statuses[i]
+		a, ok := byIndex[s.Collection+"/"+s.IndexName]
+		if s.Phase != indexPhaseBuilding || !ok {
+			continue
+		}
+		s.Progress = a.sum / float64(a.n)
+		s.Message = a.msg
+		changed = true
+	}
+	return changed
+}
+
+// notify sends a snapshot of the statuses to the update callback.
+func (b *indexBuilder) notify() {
+	if b.onUpdate == nil {
+		return
+	}
+	b.notifyMu.Lock()
+	defer b.notifyMu.Unlock()
+	b.onUpdate(b.snapshot())
+}
+
+func (b *indexBuilder) snapshot() []target.IndexBuildStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]target.IndexBuildStatus(nil), b.statuses...)
+}
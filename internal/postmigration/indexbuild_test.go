@@ -0,0 +1,322 @@
+package postmigration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// concurrencyOperator records how many index builds run at once, overall and
+// per shard.
+type concurrencyOperator struct {
+	*target.MockOperator
+	delay  time.Duration
+	failOn string
+
+	mu           sync.Mutex
+	running      map[string]int
+	maxRunning   int
+	maxPerShard  map[string]int
+	runningTotal int
+}
+
+func newConcurrencyOperator(shards map[string][]string) *concurrencyOperator {
+	return &concurrencyOperator{
+		MockOperator: &target.MockOperator{ShardsByCollection: shards},
+		delay:        20 * time.Millisecond,
+		running:      make(map[string]int),
+		maxPerShard:  make(map[string]int),
+	}
+}
+
+func (c *concurrencyOperator) CreateIndex(ctx context.Context, collection string, index target.IndexDefinition) error {
+	shards := c.ShardsByCollection[collection]
+	c.mu.Lock()
+	c.runningTotal++
+	c.maxRunning = max(c.maxRunning, c.runningTotal)
+	for _, s := range shards {
+		c.running[s]++
+		c.maxPerShard[s] = max(c.maxPerShard[s], c.running[s])
+	}
+	c.mu.Unlock()
+
+	time.Sleep(c.delay)
+
+	c.mu.Lock()
+	c.runningTotal--
+	for _, s := range shards {
+		c.running[s]--
+	}
+	c.mu.Unlock()
+	if index.Name == c.failOn {
+		return errors.New("duplicate key")
+	}
+	return c.MockOperator.CreateIndex(ctx, collection, index)
+}
+
+func buildPlan(collections ...string) []target.CollectionIndex {
+	var plan []target.CollectionIndex
+	for _, c := range collections {
+		for i := range 2 {
+			plan = append(plan, target.CollectionIndex{Collection: c, Index: target.IndexDefinition{
+				Name: fmt.Sprintf("idx_%s_%d", c, i),
+				Keys: []target.IndexKey{{Field: fmt.Sprintf("f%d", i), Order: 1}},
+			}})
+		}
+	}
+	return plan
+}
+
+func TestIndexBuilder_Concurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        IndexBuildOptions
+		shards      map[string][]string
+		wantMax     int // 0 when it depends on scheduling
+		wantMaxPerS int
+	}{
+		{"serial by default", IndexBuildOptions{}, nil, 1, 0},
+		{"three collections at once", IndexBuildOptions{Concurrency: 3}, nil, 3, 0},
+		{"capped on an unsharded target", IndexBuildOptions{Concurrency: 3, MaxPerShard: 2}, nil, 2, 0},
+		{
+			"capped per shard",
+			IndexBuildOptions{Concurrency: 4, MaxPerShard: 1},
+			map[string][]string{"a": {"s1"}, "b": {"s1"}, "c": {"s2"}, "d": {"s2"}},
+			2, 1,
+		},
+		{
+			"sharded collection holds every shard",
+			IndexBuildOptions{Concurrency: 4, MaxPerShard: 1},
+			map[string][]string{"a": {"s1", "s2"}, "b": {"s1"}, "c": {"s2"}, "d": {"s1", "s2"}},
+			0, 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := newConcurrencyOperator(tt.shards)
+			plan := buildPlan("a", "b", "c", "d")
+			tt.opts.PollInterval = time.Millisecond
+			b := newIndexBuilder(op, plan, tt.opts, nil, nil)
+			if err := b.run(context.Background(), plan); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if tt.wantMax > 0 && op.maxRunning != tt.wantMax {
+				t.Errorf("max concurrent builds = %d, want %d", op.maxRunning, tt.wantMax)
+			}
+			for s, n := range op.maxPerShard {
+				if tt.wantMaxPerS > 0 && n > tt.wantMaxPerS {
+					t.Errorf("shard %s ran %d builds at once, want at most %d", s, n, tt.wantMaxPerS)
+				}
+			}
+			if len(op.CreatedIndexes) != len(plan) {
+				t.Errorf("built %d indexes, want %d", len(op.CreatedIndexes), len(plan))
+			}
+			for _, s := range b.snapshot() {
+				if s.Phase != "complete" || s.Progress != 100 {
+					t.Errorf("%s: phase %s progress %.0f", s.IndexName, s.Phase, s.Progress)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexBuilder_CollectionOrder(t *testing.T) {
+	op := newConcurrencyOperator(nil)
+	op.delay = 0
+	plan := buildPlan("a", "b")
+	b := newIndexBuilder(op, plan, IndexBuildOptions{Concurrency: 2}, nil, nil)
+	if err := b.run(context.Background(), plan); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// Indexes of one collection are built in plan order
+	pos := make(map[string]int)
+	for i, ci := range op.CreatedIndexes {
+		pos[ci.Index.Name] = i
+	}
+	if pos["idx_a_0"] > pos["idx_a_1"] || pos["idx_b_0"] > pos["idx_b_1"] {
+		t.Errorf("build order = %v", op.CreatedIndexes)
+	}
+}
+
+func TestIndexBuilder_Failure(t *testing.T) {
+	op := newConcurrencyOperator(nil)
+	op.failOn = "idx_a_0"
+	plan := buildPlan("a", "b")
+	b := newIndexBuilder(op, plan, IndexBuildOptions{}, nil, nil)
+
+	err := b.run(context.Background(), plan)
+	if err == nil || err.Error() != "creating index idx_a_0 on a: duplicate key" {
+		t.Fatalf("run error = %v", err)
+	}
+	want := []string{"failed", "skipped", "skipped", "skipped"}
+	for i, s := range b.snapshot() {
+		if s.Phase != want[i] {
+			t.Errorf("%s phase = %s, want %s", s.IndexName, s.Phase, want[i])
+		}
+	}
+}
+
+func TestIndexBuilder_PauseResume(t *testing.T) {
+	op := newConcurrencyOperator(nil)
+	op.delay = 0
+	plan := buildPlan("a")
+	control := NewIndexBuildControl()
+	control.Pause()
+	if !control.Paused() {
+		t.Fatal("control should be paused")
+	}
+
+	var mu sync.Mutex
+	var updates [][]target.IndexBuildStatus
+	b := newIndexBuilder(op, plan, IndexBuildOptions{}, control, func(s []target.IndexBuildStatus) {
+		mu.Lock()
+		updates = append(updates, s)
+		mu.Unlock()
+	})
+	done := make(chan error)
+	go func() { done <- b.run(context.Background(), plan) }()
+
+	time.Sleep(30 * time.Millisecond)
+	op.mu.Lock()
+	built := len(op.CreatedIndexes)
+	op.mu.Unlock()
+	if built != 0 {
+		t.Fatalf("%d indexes built while paused", built)
+	}
+
+	control.Resume()
+	if err := <-done; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(op.CreatedIndexes) != 2 {
+		t.Errorf("built %d indexes after resume, want 2", len(op.CreatedIndexes))
+	}
+	if last := updates[len(updates)-1]; OverallIndexProgress(last) != 100 {
+		t.Errorf("final progress = %.0f, want 100", OverallIndexProgress(last))
+	}
+}
+
+func TestIndexBuilder_PausedCancel(t *testing.T) {
+	op := newConcurrencyOperator(nil)
+	plan := buildPlan("a")
+	control := NewIndexBuildControl()
+	control.Pause()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	b := newIndexBuilder(op, plan, IndexBuildOptions{}, control, nil)
+	if err := b.run(ctx, plan); !errors.Is(err, context.Canceled) {
+		t.Errorf("run error = %v, want context.Canceled", err)
+	}
+}
+
+func TestIndexBuilder_Merge(t *testing.T) {
+	plan := buildPlan("orders")
+	b := newIndexBuilder(&target.MockOperator{}, plan, IndexBuildOptions{}, nil, nil)
+	b.statuses[0].Phase = "building"
+
+	changed := b.merge([]target.IndexBuildStatus{
+		{Collection: "orders", IndexName: "idx_orders_0", Progress: 40, Shard: "s1"},
+		{Collection: "orders", IndexName: "idx_orders_0", Progress: 80, Shard: "s2", Message: "scanning"},
+		{Collection: "orders", IndexName: "idx_orders_1", Progress: 50}, // not started by us yet
+		{Collection: "users", IndexName: "idx_orders_0", Progress: 10},
+	})
+	if !changed {
+		t.Fatal("merge should report a change")
+	}
+	got := b.snapshot()
+	if got[0].Progress != 60 || got[0].Message != "scanning" {
+		t.Errorf("merged = %+v, want progress 60 (mean over shards)", got[0])
+	}
+	if got[1].Progress != 0 {
+		t.Errorf("pending index should not take progress: %+v", got[1])
+	}
+}
+
+func TestIndexBuilder_EstimatesUnreportedBuilds(t *testing.T) {
+	plan := buildPlan("orders")
+	op := &target.MockOperator{
+		IndexBuildErr: errors.New("not authorized on admin to execute command currentOp"),
+		EstimatedProgress: map[string][]target.IndexBuildStatus{"orders": {
+			{Collection: "orders", IndexName: "idx_orders_0", Phase: "building", Progress: 35, Message: target.EstimatedProgressMessage},
+		}},
+	}
+	b := newIndexBuilder(op, plan, IndexBuildOptions{}, nil, nil)
+	b.statuses[0].Phase = "building"
+
+	ops, _ := op.ListIndexBuildProgress(context.Background())
+	if !b.merge(append(ops, b.estimate(context.Background(), ops)...)) {
+		t.Fatal("the estimate should be merged")
+	}
+	got := b.snapshot()
+	if got[0].Progress != 35 || got[0].Message != target.EstimatedProgressMessage {
+		t.Errorf("estimated = %+v, want 35%% marked as estimated", got[0])
+	}
+
+	// Builds currentOp reports are not estimated
+	reported := []target.IndexBuildStatus{{Collection: "orders", IndexName: "idx_orders_0", Progress: 50}}
+	if est := b.estimate(context.Background(), reported); len(est) != 0 {
+		t.Errorf("estimate = %+v, want none for reported builds", est)
+	}
+}
+
+func TestOverallIndexProgress(t *testing.T) {
+	statuses := []target.IndexBuildStatus{
+		{Phase: "complete"},
+		{Phase: "building", Progress: 50},
+		{Phase: "pending"},
+		{Phase: "building", Progress: 10},
+	}
+	if got := OverallIndexProgress(statuses); got != 40 {
+		t.Errorf("OverallIndexProgress = %v, want 40", got)
+	}
+	if got := OverallIndexProgress(nil); got != 0 {
+		t.Errorf("OverallIndexProgress(nil) = %v, want 0", got)
+	}
+}
+
+// searchCreator records the Atlas Search indexes created through it.
+type searchCreator struct {
+	mu      sync.Mutex
+	created []string
+}
+
+func (s *searchCreator) CreateSearchIndex(_ context.Context, collection string, index target.IndexDefinition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.created = append(s.created, collection+"."+index.Name)
+	return nil
+}
+
+func TestIndexBuilder_SearchIndexes(t *testing.T) {
+	search := target.IndexDefinition{Name: "search_a", Keys: []target.IndexKey{{Field: "body", Type: target.IndexSearch}}}
+	plan := append(buildPlan("a"), target.CollectionIndex{Collection: "a", Index: search})
+
+	op := &target.MockOperator{}
+	b := newIndexBuilder(op, plan, IndexBuildOptions{}, nil, nil)
+	if err := b.run(context.Background(), plan); err != nil {
+		t.Fatalf("run without the Atlas API: %v", err)
+	}
+	if s := b.snapshot()[2]; s.Phase != indexPhaseSkipped {
+		t.Errorf("search index phase = %s, want skipped", s.Phase)
+	}
+
+	creator := &searchCreator{}
+	op = &target.MockOperator{}
+	b = newIndexBuilder(op, plan, IndexBuildOptions{}, nil, nil)
+	b.search = creator
+	if err := b.run(context.Background(), plan); err != nil {
+		t.Fatal(err)
+	}
+	if len(op.CreatedIndexes) != 2 || len(creator.created) != 1 || creator.created[0] != "a.search_a" {
+		t.Errorf("created %v on the cluster and %v through Atlas", op.CreatedIndexes, creator.created)
+	}
+	if s := b.snapshot()[2]; s.Phase != indexPhaseComplete {
+		t.Errorf("search index phase = %s, want complete", s.Phase)
+	}
+}
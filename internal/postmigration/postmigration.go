@@ -6,8 +6,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/reloquent/reloquent/internal/codegen"
 	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/doctor"
+	"github.com/reloquent/reloquent/internal/hooks"
 	"github.com/reloquent/reloquent/internal/indexes"
 	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/report"
@@ -15,6 +20,7 @@ import (
 	"github.com/reloquent/reloquent/internal/source"
 	"github.com/reloquent/reloquent/internal/state"
 	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
 	"github.com/reloquent/reloquent/internal/validation"
 )
 
@@ -29,24 +35,56 @@ type Orchestrator struct {
 	IndexPlan  *indexes.IndexPlan
 	Topology   *target.TopologyInfo
 	SampleSize int
+	TypeMap    *typemap.TypeMap
+	Validation validation.Config
+	Hooks      *hooks.Runner // custom steps run around validation and index builds
+
+	IndexBuilds  IndexBuildOptions
+	IndexControl *IndexBuildControl // pauses and resumes index builds; may be nil
+
+	// SearchIndexes creates the plan's Atlas Search indexes; without it they
+	// are skipped.
+	SearchIndexes target.SearchIndexCreator
 }
 
 // Callbacks provides hooks for progress reporting.
 type Callbacks struct {
 	OnValidationCheck func(collection, checkType string, passed bool)
+	OnValidationChunk func(progress validation.ChunkProgress)
 	OnIndexProgress   func(status []target.IndexBuildStatus)
+	OnViewBuilt       func(view string, err error)
+	OnCanaryQuery     func(result CanaryResult)
+	OnCounterSeeded   func(seed CounterSeed)
 	OnStepComplete    func(step string)
 }
 
 // RunValidation executes validation checks and updates state.
 func (o *Orchestrator) RunValidation(ctx context.Context, cb Callbacks) (*validation.Result, error) {
+	if err := o.Hooks.Run(ctx, hooks.Before, state.StepValidation, nil); err != nil {
+		return nil, err
+	}
+
+	// A rules file next to the state file applies unless another is given
+	stateDir := filepath.Dir(config.ExpandHome(o.StatePath))
+	vcfg := o.Validation
+	if vcfg.RulesFile == "" {
+		if path := filepath.Join(stateDir, validation.RulesFileName); pathExists(path) {
+			vcfg.RulesFile = path
+		}
+	}
+
 	v := &validation.Validator{
-		Source:     o.Source,
-		Target:     o.Target,
-		Schema:     o.Schema,
-		Mapping:    o.Mapping,
-		SampleSize: o.SampleSize,
-		Callback:   cb.OnValidationCheck,
+		Source:      o.Source,
+		Target:      o.Target,
+		Schema:      o.Schema,
+		Mapping:     o.Mapping,
+		SampleSize:  o.SampleSize,
+		TypeMap:     o.TypeMap,
+		Snapshots:   o.State.Snapshots(),
+		RowsSkipped: o.State.RowsSkipped(),
+		Config:      vcfg,
+		Callback:    cb.OnValidationCheck,
+		OnChunk:     cb.OnValidationChunk,
 	}
 
 	result, err := v.Validate(ctx)
@@ -55,7 +93,6 @@ func (o *Orchestrator) RunValidation(ctx context.Context, cb Callbacks) (*valida
 	}
 
 	// Save validation report
-	stateDir := filepath.Dir(config.ExpandHome(o.StatePath))
 	reportPath := filepath.Join(stateDir, "validation-report.json")
 	if err := writeValidationReport(result, reportPath); err != nil {
 		return nil, fmt.Errorf("saving validation report: %w", err)
@@ -66,6 +103,10 @@ func (o *Orchestrator) RunValidation(ctx context.Context, cb Callbacks) (*valida
 		return nil, fmt.Errorf("saving state: %w", err)
 	}
 
+	if err := o.Hooks.Run(ctx, hooks.After, state.StepValidation, result); err != nil {
+		return result, err
+	}
+
 	if cb.OnStepComplete != nil {
 		cb.OnStepComplete("validation")
 	}
@@ -73,19 +114,36 @@ func (o *Orchestrator) RunValidation(ctx context.Context, cb Callbacks) (*valida
 	return result, nil
 }
 
-// RunIndexBuilds creates indexes and monitors progress.
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// RunIndexBuilds creates the planned indexes, scheduled by IndexBuilds, and
+// reports per-index progress through OnIndexProgress as the builds run. A
+// plan whose names break the IndexBuilds naming convention is refused
+// before anything is built.
 func (o *Orchestrator) RunIndexBuilds(ctx context.Context, cb Callbacks) error {
 	if o.IndexPlan == nil || len(o.IndexPlan.Indexes) == 0 {
 		o.State.IndexBuildStatus = "skipped"
 		return o.State.Save(o.StatePath)
 	}
+	if err := o.IndexBuilds.Naming.Check(o.IndexPlan); err != nil {
+		return err
+	}
+
+	if err := o.Hooks.Run(ctx, hooks.Before, state.StepIndexBuilds, nil); err != nil {
+		return err
+	}
 
 	o.State.IndexBuildStatus = "building"
 	if err := o.State.Save(o.StatePath); err != nil {
 		return fmt.Errorf("saving state: %w", err)
 	}
 
-	if err := o.Target.CreateIndexes(ctx, o.IndexPlan.Indexes); err != nil {
+	builder := newIndexBuilder(o.Target, o.IndexPlan.Indexes, o.IndexBuilds, o.IndexControl, cb.OnIndexProgress)
+	builder.search = o.SearchIndexes
+	if err := builder.run(ctx, o.IndexPlan.Indexes); err != nil {
 		o.State.IndexBuildStatus = "failed"
 		o.State.Save(o.StatePath)
 		return fmt.Errorf("creating indexes: %w", err)
@@ -96,6 +154,10 @@ func (o *Orchestrator) RunIndexBuilds(ctx context.Context, cb Callbacks) error {
 		return fmt.Errorf("saving state: %w", err)
 	}
 
+	if err := o.Hooks.Run(ctx, hooks.After, state.StepIndexBuilds, nil); err != nil {
+		return err
+	}
+
 	if cb.OnStepComplete != nil {
 		cb.OnStepComplete("index_builds")
 	}
@@ -103,6 +165,76 @@ func (o *Orchestrator) RunIndexBuilds(ctx context.Context, cb Callbacks) error {
 	return nil
 }
 
+// WriteViewScripts writes an on-demand refresh script for every materialized
+// view into a views directory next to the state file and returns its path.
+func (o *Orchestrator) WriteViewScripts() (string, error) {
+	stateDir := filepath.Dir(config.ExpandHome(o.StatePath))
+	dir := filepath.Join(stateDir, "views")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating views directory: %w", err)
+	}
+
+	database := ""
+	if o.State.TargetConfig != nil {
+		database = o.State.TargetConfig.Database
+	}
+	for _, v := range o.Mapping.Views {
+		script, err := codegen.GenerateViewRefreshScript(database, v)
+		if err != nil {
+			return "", err
+		}
+		path := filepath.Join(dir, "refresh_"+v.Name+".js")
+		if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+			return "", fmt.Errorf("writing refresh script for %s: %w", v.Name, err)
+		}
+	}
+	return dir, nil
+}
+
+// RunViews builds the materialized views defined in the mapping, in order,
+// and writes their refresh scripts. It is a no-op when no views are defined.
+func (o *Orchestrator) RunViews(ctx context.Context, cb Callbacks) error {
+	if o.Mapping == nil || len(o.Mapping.Views) == 0 {
+		return nil
+	}
+	if err := o.Mapping.ValidateViews(); err != nil {
+		return fmt.Errorf("invalid views: %w", err)
+	}
+
+	dir, err := o.WriteViewScripts()
+	if err != nil {
+		return err
+	}
+	o.State.ViewScriptsDir = dir
+	o.State.ViewsStatus = "building"
+	if err := o.State.Save(o.StatePath); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+
+	for _, v := range o.Mapping.Views {
+		err := o.Target.MaterializeView(ctx, v)
+		if cb.OnViewBuilt != nil {
+			cb.OnViewBuilt(v.Name, err)
+		}
+		if err != nil {
+			o.State.ViewsStatus = "failed"
+			o.State.Save(o.StatePath)
+			return fmt.Errorf("building view %s: %w", v.Name, err)
+		}
+	}
+
+	o.State.ViewsStatus = "complete"
+	if err := o.State.Save(o.StatePath); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+
+	if cb.OnStepComplete != nil {
+		cb.OnStepComplete("views")
+	}
+
+	return nil
+}
+
 // RunPostOps re-enables the balancer and restores write concern.
 func (o *Orchestrator) RunPostOps(ctx context.Context) error {
 	// Re-enable balancer if topology is sharded
@@ -113,19 +245,57 @@ func (o *Orchestrator) RunPostOps(ctx context.Context) error {
 		o.State.BalancerReEnabled = true
 	}
 
-	// Restore production write concern
-	if err := o.Target.SetWriteConcern(ctx, "majority", true); err != nil {
-		return fmt.Errorf("restoring write concern: %w", err)
+	// Restore production write concern; a store without configurable
+	// write concern always acknowledges durable writes.
+	if target.CapabilitiesOf(o.Target).WriteConcern {
+		if err := o.Target.SetWriteConcern(ctx, "majority", true); err != nil {
+			return fmt.Errorf("restoring write concern: %w", err)
+		}
 	}
 	o.State.WriteConcernRestored = true
 
 	return o.State.Save(o.StatePath)
 }
 
+// archiveSummaries describes where the rows of collections with an archive
+// policy went, counting them in the source when it is connected.
+func (o *Orchestrator) archiveSummaries(ctx context.Context) []report.ArchiveSummary {
+	if o.Mapping == nil {
+		return nil
+	}
+	var out []report.ArchiveSummary
+	for _, c := range o.Mapping.Collections {
+		if c.Archive == nil {
+			continue
+		}
+		a := report.ArchiveSummary{
+			Collection:  c.Name,
+			SourceTable: c.SourceTable,
+			Column:      c.Archive.Column,
+			Before:      c.Archive.Before,
+			Location:    c.Archive.Path(c.Name),
+		}
+		if o.Source == nil {
+			a.Message = "row count unavailable: source not connected"
+		} else if n, err := o.Source.FilteredRowCount(ctx, c.SourceTable, c.Archive.Filter(c.Filter)); err != nil {
+			a.Message = fmt.Sprintf("row count unavailable: %v", err)
+		} else {
+			a.Rows = n
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
 // CheckReadiness evaluates all production readiness conditions and generates the report.
 func (o *Orchestrator) CheckReadiness(ctx context.Context) (*report.MigrationReport, error) {
 	var checks []report.ReadinessCheck
 
+	// Environment checked (only if the doctor has run)
+	if o.State.DoctorReportPath != "" {
+		checks = append(checks, o.doctorCheck())
+	}
+
 	// 1. Migration completed
 	migPassed := o.State.MigrationStatus == "completed"
 	checks = append(checks, report.ReadinessCheck{
@@ -142,6 +312,17 @@ func (o *Orchestrator) CheckReadiness(ctx context.Context) (*report.MigrationRep
 		Message: condMsg(valPassed, "Validation report generated", "Run validation to verify data integrity"),
 	})
 
+	// Failed collections remediated (only if the last validation had any)
+	var valResult *validation.Result
+	if valPassed {
+		if r, err := ReadValidationReport(o.State.ValidationReportPath); err == nil {
+			valResult = r
+			if c := o.remediationCheck(r); c != nil {
+				checks = append(checks, *c)
+			}
+		}
+	}
+
 	// 3. Indexes built
 	idxPassed := o.State.IndexBuildStatus == "complete" || o.State.IndexBuildStatus == "skipped"
 	checks = append(checks, report.ReadinessCheck{
@@ -150,6 +331,33 @@ func (o *Orchestrator) CheckReadiness(ctx context.Context) (*report.MigrationRep
 		Message: condMsg(idxPassed, "All indexes built successfully", "Index builds not complete"),
 	})
 
+	// Materialized views built (only if defined)
+	if o.Mapping != nil && len(o.Mapping.Views) > 0 {
+		viewsPassed := o.State.ViewsStatus == "complete"
+		checks = append(checks, report.ReadinessCheck{
+			Name:    "Materialized views built",
+			Passed:  viewsPassed,
+			Message: condMsg(viewsPassed, "All materialized views built", "Build the materialized views defined in the mapping"),
+		})
+	}
+
+	// Canary queries served by indexes (only if registered or already run)
+	if o.State.CanaryStatus != "" || (o.Mapping != nil && len(o.Mapping.Queries) > 0) {
+		checks = append(checks, o.canaryCheck())
+	}
+
+	// New ids generated as chosen (only if a collection has a strategy)
+	if c := o.idGenerationCheck(); c != nil {
+		checks = append(checks, *c)
+	}
+
+	// Source unique constraints enforced (only if the tables have any)
+	if o.Schema != nil && o.Mapping != nil {
+		if r := indexes.AnalyzeUnique(o.Schema, o.Mapping, o.TypeMap); len(r.Constraints) > 0 {
+			checks = append(checks, o.uniqueCheck(r))
+		}
+	}
+
 	// 4. Write concern restored
 	wcPassed := o.State.WriteConcernRestored
 	checks = append(checks, report.ReadinessCheck{
@@ -168,6 +376,29 @@ func (o *Orchestrator) CheckReadiness(ctx context.Context) (*report.MigrationRep
 		})
 	}
 
+	// Custom steps completed (only if any hook has run)
+	if len(o.State.HookRuns) > 0 {
+		var failed []string
+		for name, run := range o.State.HookRuns {
+			if run.Status != "complete" {
+				failed = append(failed, name)
+			}
+		}
+		sort.Strings(failed)
+		hooksPassed := len(failed) == 0
+		checks = append(checks, report.ReadinessCheck{
+			Name:    "Custom steps",
+			Passed:  hooksPassed,
+			Message: condMsg(hooksPassed, "All custom step hooks completed", "Hooks not complete: "+strings.Join(failed, ", ")),
+		})
+	}
+
+	// 6. Change streams work on a migrated collection (only if connected
+	// to a store that has them)
+	if o.Target != nil && target.CapabilitiesOf(o.Target).ChangeStreams && o.Mapping != nil && len(o.Mapping.Collections) > 0 {
+		checks = append(checks, o.changeStreamCheck(ctx, o.Mapping.Collections[0].Name))
+	}
+
 	// Determine topology and counts
 	topoType := "unknown"
 	if o.Topology != nil {
@@ -200,11 +431,14 @@ func (o *Orchestrator) CheckReadiness(ctx context.Context) (*report.MigrationRep
 		sourceType, sourceHost, sourceDB, tableCount,
 		targetDB, topoType, collCount,
 		o.State.MigrationStatus, o.State.AWSResourceType,
-		nil, // validation result loaded separately if needed
+		valResult,
 		indexCount, o.State.IndexBuildStatus,
 		checks,
 	)
 
+	rpt.Archives = o.archiveSummaries(ctx)
+	rpt.Remediations = o.remediationSummaries(valResult)
+
 	// Set production ready on state
 	o.State.ProductionReady = rpt.ProductionReady
 
@@ -223,6 +457,73 @@ func (o *Orchestrator) CheckReadiness(ctx context.Context) (*report.MigrationRep
 	return rpt, nil
 }
 
+// changeStreamCheck verifies that a change stream on the collection observes
+// a canary insert and delete, as streaming applications will need after cutover.
+func (o *Orchestrator) changeStreamCheck(ctx context.Context, collection string) report.ReadinessCheck {
+	check := report.ReadinessCheck{Name: "Change streams"}
+	if err := o.Target.ChangeStreamSmokeTest(ctx, collection); err != nil {
+		check.Message = fmt.Sprintf("Change stream on %s did not observe canary events: %v", collection, err)
+		return check
+	}
+	check.Passed = true
+	check.Message = fmt.Sprintf("Change stream on %s observed canary insert and delete", collection)
+	return check
+}
+
+// uniqueCheck reports whether the index plan builds a partial unique index
+// for every convertible source unique constraint. Lost constraints do not
+// fail the check, as no index can enforce them, but are named so the
+// application can take them over.
+func (o *Orchestrator) uniqueCheck(r *indexes.UniqueReport) report.ReadinessCheck {
+	check := report.ReadinessCheck{Name: "Unique constraints"}
+	plan := o.IndexPlan
+	if plan == nil {
+		plan = &indexes.IndexPlan{}
+	}
+	var missing, lost []string
+	for _, c := range plan.Unenforced(r) {
+		missing = append(missing, c.Collection+"."+c.Name)
+	}
+	for _, c := range r.Constraints {
+		if c.Status == indexes.UniqueLost {
+			lost = append(lost, c.Collection+"."+c.Name)
+		}
+	}
+	if len(missing) > 0 {
+		check.Message = "Add partial unique indexes to the index plan for: " + strings.Join(missing, ", ")
+		return check
+	}
+	check.Passed = true
+	check.Message = fmt.Sprintf("%d unique constraints enforced by indexes", r.Preserved+r.Convertible)
+	if len(lost) > 0 {
+		check.Message += "; the application must enforce: " + strings.Join(lost, ", ")
+	}
+	return check
+}
+
+// doctorCheck reports whether the last doctor run found the environment
+// ready, naming the checks that failed otherwise.
+func (o *Orchestrator) doctorCheck() report.ReadinessCheck {
+	check := report.ReadinessCheck{Name: "Environment checks"}
+	r, err := doctor.ReadJSON(o.State.DoctorReportPath)
+	if err != nil {
+		check.Message = "Run reloquent doctor again: " + err.Error()
+		return check
+	}
+	failed := r.Failed()
+	if len(failed) == 0 {
+		check.Passed = true
+		check.Message = fmt.Sprintf("%d environment checks passed on %s", r.Count(doctor.StatusPass), r.CheckedAt.Format("2006-01-02"))
+		return check
+	}
+	names := make([]string, len(failed))
+	for i, c := range failed {
+		names[i] = c.Name
+	}
+	check.Message = "Environment checks failed: " + strings.Join(names, ", ")
+	return check
+}
+
 func condMsg(passed bool, passMsg, failMsg string) string {
 	if passed {
 		return passMsg
@@ -237,3 +538,77 @@ func writeValidationReport(result *validation.Result, path string) error {
 	}
 	return os.WriteFile(path, data, 0o644)
 }
+
+// ReadValidationReport reads the result RunValidation saved.
+func ReadValidationReport(path string) (*validation.Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading validation report: %w", err)
+	}
+	var r validation.Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing validation report: %w", err)
+	}
+	return &r, nil
+}
+
+// remediationCheck reports whether every collection that failed the last
+// validation has an accepted variance, or returns nil when none failed.
+// Collections re-migrated or with an adjusted mapping must be validated
+// again to pass.
+func (o *Orchestrator) remediationCheck(result *validation.Result) *report.ReadinessCheck {
+	var failed, open []string
+	for _, c := range result.Collections {
+		if c.Status != "FAIL" {
+			continue
+		}
+		failed = append(failed, c.Name)
+		r, ok := o.State.Remediations[c.Name]
+		switch {
+		case !ok:
+			open = append(open, c.Name+" (no decision)")
+		case r.Action != validation.ActionAccept:
+			open = append(open, fmt.Sprintf("%s (%s; validate again)", c.Name, r.Action))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	check := &report.ReadinessCheck{Name: "Validation failures"}
+	if len(open) > 0 {
+		check.Message = "Resolve the validation failures of: " + strings.Join(open, ", ")
+		return check
+	}
+	check.Passed = true
+	check.Message = "Variances accepted for: " + strings.Join(failed, ", ")
+	return check
+}
+
+// remediationSummaries lists the decisions recorded on validation failures,
+// with each collection's status in the last validation.
+func (o *Orchestrator) remediationSummaries(result *validation.Result) []report.RemediationSummary {
+	status := make(map[string]string)
+	if result != nil {
+		for _, c := range result.Collections {
+			status[c.Name] = c.Status
+		}
+	}
+	names := make([]string, 0, len(o.State.Remediations))
+	for name := range o.State.Remediations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var out []report.RemediationSummary
+	for _, name := range names {
+		r := o.State.Remediations[name]
+		out = append(out, report.RemediationSummary{
+			Collection:    name,
+			Action:        r.Action,
+			Justification: r.Justification,
+			Causes:        r.Causes,
+			DecidedAt:     r.DecidedAt,
+			Status:        status[name],
+		})
+	}
+	return out
+}
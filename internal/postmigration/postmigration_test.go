@@ -2,12 +2,19 @@ package postmigration
 
 import (
 	"context"
+	"errors"
+	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/doctor"
+	"github.com/reloquent/reloquent/internal/hooks"
 	"github.com/reloquent/reloquent/internal/indexes"
 	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/report"
 	"github.com/reloquent/reloquent/internal/schema"
 	"github.com/reloquent/reloquent/internal/source"
 	"github.com/reloquent/reloquent/internal/state"
@@ -130,6 +137,64 @@ func TestRunIndexBuilds(t *testing.T) {
 	}
 }
 
+func TestRunIndexBuilds_NamingConvention(t *testing.T) {
+	orch, _, _ := makeTestOrchestrator(t)
+	orch.IndexPlan = &indexes.IndexPlan{
+		Indexes: []target.CollectionIndex{
+			{Collection: "users", Index: target.IndexDefinition{
+				Keys: []target.IndexKey{{Field: "email", Order: 1}},
+				Name: "idx_email",
+			}},
+		},
+	}
+	orch.IndexBuilds.Naming = "{collection}_{keys}"
+
+	err := orch.RunIndexBuilds(context.Background(), Callbacks{})
+	if err == nil || !strings.Contains(err.Error(), "users_email_asc") {
+		t.Fatalf("err = %v, want the conventional name", err)
+	}
+	if orch.State.IndexBuildStatus != "" {
+		t.Errorf("status = %q, want no build started", orch.State.IndexBuildStatus)
+	}
+
+	orch.IndexPlan.Indexes[0].Index.Name = "users_email_asc"
+	if err := orch.RunIndexBuilds(context.Background(), Callbacks{}); err != nil {
+		t.Fatalf("conventional names: %v", err)
+	}
+}
+
+func TestRunValidation_Hooks(t *testing.T) {
+	tests := []struct {
+		name       string
+		after      string
+		wantErr    bool
+		wantStatus string
+	}{
+		{"hook passes", "exit 0", false, "complete"},
+		{"hook fails", "echo 'checksums differ' >&2; exit 1", true, "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orch, _, _ := makeTestOrchestrator(t)
+			orch.Hooks = &hooks.Runner{
+				Hooks: []config.HookConfig{
+					{Name: "custom-check", When: "after:validation", Command: []string{"sh", "-c", tt.after}},
+				},
+				State:     orch.State,
+				StatePath: orch.StatePath,
+			}
+
+			_, err := orch.RunValidation(context.Background(), Callbacks{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunValidation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := orch.State.HookRuns["custom-check"].Status; got != tt.wantStatus {
+				t.Errorf("hook status = %q, want %q", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
 func TestRunIndexBuilds_Empty(t *testing.T) {
 	orch, _, _ := makeTestOrchestrator(t)
 	orch.IndexPlan = &indexes.IndexPlan{} // no indexes
@@ -143,6 +208,84 @@ func TestRunIndexBuilds_Empty(t *testing.T) {
 	}
 }
 
+func TestRunViews(t *testing.T) {
+	orch, _, tgt := makeTestOrchestrator(t)
+	orch.Mapping.Views = []mapping.View{
+		{Name: "users_by_name", Source: "users", Pipeline: `[{"$group": {"_id": "$name", "count": {"$sum": 1}}}]`},
+	}
+
+	var built []string
+	stepDone := false
+	cb := Callbacks{
+		OnViewBuilt: func(view string, err error) {
+			built = append(built, view)
+		},
+		OnStepComplete: func(step string) {
+			stepDone = step == "views"
+		},
+	}
+
+	if err := orch.RunViews(context.Background(), cb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if orch.State.ViewsStatus != "complete" {
+		t.Errorf("expected complete, got %s", orch.State.ViewsStatus)
+	}
+	if len(tgt.MaterializedViews) != 1 || len(built) != 1 || !stepDone {
+		t.Errorf("views built = %v, callbacks = %v, step done = %v", tgt.MaterializedViews, built, stepDone)
+	}
+
+	script, err := os.ReadFile(filepath.Join(orch.State.ViewScriptsDir, "refresh_users_by_name.js"))
+	if err != nil {
+		t.Fatalf("refresh script not written: %v", err)
+	}
+	if !strings.Contains(string(script), `db.getSiblingDB("target_db")`) {
+		t.Errorf("script does not target the configured database:\n%s", script)
+	}
+}
+
+func TestRunViews_Failure(t *testing.T) {
+	orch, _, tgt := makeTestOrchestrator(t)
+	orch.Mapping.Views = []mapping.View{
+		{Name: "users_by_name", Source: "users", Pipeline: `[{"$group": {"_id": "$name"}}]`},
+	}
+	tgt.MaterializeErr = errors.New("merge failed")
+
+	if err := orch.RunViews(context.Background(), Callbacks{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if orch.State.ViewsStatus != "failed" {
+		t.Errorf("expected failed, got %s", orch.State.ViewsStatus)
+	}
+
+	rpt, err := orch.CheckReadiness(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	found := false
+	for _, c := range rpt.ReadinessChecks {
+		if c.Name == "Materialized views built" {
+			found = true
+			if c.Passed {
+				t.Error("views check should fail")
+			}
+		}
+	}
+	if !found {
+		t.Error("expected a materialized views readiness check")
+	}
+}
+
+func TestRunViews_None(t *testing.T) {
+	orch, _, tgt := makeTestOrchestrator(t)
+	if err := orch.RunViews(context.Background(), Callbacks{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tgt.MaterializedViews) != 0 || orch.State.ViewsStatus != "" {
+		t.Error("no views should be built")
+	}
+}
+
 func TestRunPostOps_Sharded(t *testing.T) {
 	orch, _, tgt := makeTestOrchestrator(t)
 	orch.Topology = &target.TopologyInfo{Type: "sharded"}
@@ -184,6 +327,22 @@ func TestRunPostOps_ReplicaSet(t *testing.T) {
 	}
 }
 
+func TestRunPostOps_NoWriteConcern(t *testing.T) {
+	orch, _, tgt := makeTestOrchestrator(t)
+	tgt.Caps = &target.FerretDBCapabilities
+	tgt.SetWriteConcernErr = errors.New("should not be called")
+
+	if err := orch.RunPostOps(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tgt.WriteConcernSet {
+		t.Error("write concern should not be set on a target without it")
+	}
+	if !orch.State.WriteConcernRestored {
+		t.Error("state should reflect write concern restored")
+	}
+}
+
 func TestCheckReadiness_AllPassed(t *testing.T) {
 	orch, _, _ := makeTestOrchestrator(t)
 	orch.State.MigrationStatus = "completed"
@@ -206,6 +365,208 @@ func TestCheckReadiness_AllPassed(t *testing.T) {
 	}
 }
 
+func TestCheckReadiness_ChangeStreams(t *testing.T) {
+	tests := []struct {
+		name       string
+		streamErr  error
+		wantPassed bool
+	}{
+		{"events observed", nil, true},
+		{"standalone server", errors.New("The $changeStream stage is only supported on replica sets"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orch, _, tgt := makeTestOrchestrator(t)
+			orch.State.ValidationReportPath = "/some/path.json"
+			orch.State.IndexBuildStatus = "complete"
+			orch.State.WriteConcernRestored = true
+			tgt.ChangeStreamErr = tt.streamErr
+
+			rpt, err := orch.CheckReadiness(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(tgt.ChangeStreamTested) != 1 || tgt.ChangeStreamTested[0] != "users" {
+				t.Errorf("ChangeStreamTested = %v, want [users]", tgt.ChangeStreamTested)
+			}
+			var found bool
+			for _, c := range rpt.ReadinessChecks {
+				if c.Name == "Change streams" {
+					found = true
+					if c.Passed != tt.wantPassed {
+						t.Errorf("Passed = %v, want %v (%s)", c.Passed, tt.wantPassed, c.Message)
+					}
+				}
+			}
+			if !found {
+				t.Fatal("expected a change streams readiness check")
+			}
+			if rpt.ProductionReady != tt.wantPassed {
+				t.Errorf("ProductionReady = %v, want %v", rpt.ProductionReady, tt.wantPassed)
+			}
+		})
+	}
+}
+
+func TestCheckReadiness_NoChangeStreams(t *testing.T) {
+	orch, _, tgt := makeTestOrchestrator(t)
+	orch.State.ValidationReportPath = "/some/path.json"
+	orch.State.IndexBuildStatus = "complete"
+	orch.State.WriteConcernRestored = true
+	tgt.Caps = &target.FerretDBCapabilities
+
+	rpt, err := orch.CheckReadiness(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tgt.ChangeStreamTested) != 0 {
+		t.Errorf("ChangeStreamTested = %v, want none", tgt.ChangeStreamTested)
+	}
+	for _, c := range rpt.ReadinessChecks {
+		if c.Name == "Change streams" {
+			t.Errorf("unexpected change streams check: %+v", c)
+		}
+	}
+}
+
+func TestCheckReadiness_Archives(t *testing.T) {
+	orch, src, _ := makeTestOrchestrator(t)
+	orch.Mapping.Collections[0].Archive = &mapping.ArchivePolicy{Column: "created_at", Before: "2020-01-01", Location: "s3://cold/shop"}
+	src.TableRows = map[string][]map[string]interface{}{
+		"users": {{"user_id": 1, "old": true}, {"user_id": 2, "old": true}, {"user_id": 3}},
+	}
+	src.Filters = map[string]func(map[string]interface{}) bool{
+		"created_at < TIMESTAMP '2020-01-01 00:00:00'": func(row map[string]interface{}) bool { return row["old"] == true },
+	}
+
+	rpt, err := orch.CheckReadiness(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []report.ArchiveSummary{{
+		Collection: "users", SourceTable: "users", Column: "created_at", Before: "2020-01-01",
+		Location: "s3://cold/shop/users", Rows: 2,
+	}}
+	if !reflect.DeepEqual(rpt.Archives, want) {
+		t.Errorf("Archives = %+v, want %+v", rpt.Archives, want)
+	}
+	if text := report.FormatText(rpt); !strings.Contains(text, "users: 2 rows of users with created_at before 2020-01-01 -> s3://cold/shop/users") {
+		t.Errorf("text report missing the archive:\n%s", text)
+	}
+
+	// Without the source the report still says where the rows went
+	orch.Source = nil
+	rpt, err = orch.CheckReadiness(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rpt.Archives) != 1 || rpt.Archives[0].Location != "s3://cold/shop/users" || rpt.Archives[0].Message == "" {
+		t.Errorf("Archives = %+v, want the location with an unavailable count", rpt.Archives)
+	}
+}
+
+func TestCheckReadiness_Hooks(t *testing.T) {
+	orch, _, _ := makeTestOrchestrator(t)
+	orch.State.ValidationReportPath = "/some/path.json"
+	orch.State.IndexBuildStatus = "complete"
+	orch.State.WriteConcernRestored = true
+	orch.State.HookRuns = map[string]state.HookRun{
+		"cmdb":   {Status: "complete"},
+		"notify": {Status: "failed"},
+	}
+
+	rpt, err := orch.CheckReadiness(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var found bool
+	for _, c := range rpt.ReadinessChecks {
+		if c.Name == "Custom steps" {
+			found = true
+			if c.Passed || !strings.Contains(c.Message, "notify") {
+				t.Errorf("custom steps check = %+v, want failed naming notify", c)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected a custom steps readiness check")
+	}
+	if rpt.ProductionReady {
+		t.Error("should not be production ready with a failed hook")
+	}
+}
+
+func TestCheckReadiness_Doctor(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []doctor.Check
+		wantPassed bool
+		wantMsg    string
+	}{
+		{"all passed", []doctor.Check{{Name: "Source connection", Status: doctor.StatusPass}, {Name: "Free space", Status: doctor.StatusWarn}}, true, "1 environment checks passed"},
+		{"failed", []doctor.Check{{Name: "IAM permissions", Status: doctor.StatusFail}}, false, "IAM permissions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orch, _, _ := makeTestOrchestrator(t)
+			orch.State.MigrationStatus = "completed"
+			orch.State.ValidationReportPath = "/some/path.json"
+			orch.State.IndexBuildStatus = "complete"
+			orch.State.WriteConcernRestored = true
+			orch.State.DoctorReportPath = filepath.Join(t.TempDir(), "doctor-report.json")
+			if err := (&doctor.Result{Checks: tt.checks}).WriteJSON(orch.State.DoctorReportPath); err != nil {
+				t.Fatal(err)
+			}
+
+			rpt, err := orch.CheckReadiness(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			c := rpt.ReadinessChecks[0]
+			if c.Name != "Environment checks" || c.Passed != tt.wantPassed || !strings.Contains(c.Message, tt.wantMsg) {
+				t.Errorf("check = %+v, want passed=%v containing %q", c, tt.wantPassed, tt.wantMsg)
+			}
+			if rpt.ProductionReady != tt.wantPassed {
+				t.Errorf("production ready = %v, want %v", rpt.ProductionReady, tt.wantPassed)
+			}
+		})
+	}
+}
+
+func TestCheckReadiness_UniqueConstraints(t *testing.T) {
+	orch, _, _ := makeTestOrchestrator(t)
+	orch.State.ValidationReportPath = "/some/path.json"
+	orch.State.IndexBuildStatus = "complete"
+	orch.State.WriteConcernRestored = true
+	users := &orch.Schema.Tables[0]
+	users.Columns = append(users.Columns, schema.Column{Name: "email", DataType: "varchar", Nullable: true})
+	users.Indexes = []schema.Index{{Name: "ux_email", Columns: []string{"email"}, Unique: true}}
+
+	uniqueCheck := func() report.ReadinessCheck {
+		t.Helper()
+		rpt, err := orch.CheckReadiness(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, c := range rpt.ReadinessChecks {
+			if c.Name == "Unique constraints" {
+				return c
+			}
+		}
+		t.Fatal("expected a unique constraints readiness check")
+		return report.ReadinessCheck{}
+	}
+
+	if c := uniqueCheck(); c.Passed || !strings.Contains(c.Message, "users.ux_email") {
+		t.Errorf("check without partial index = %+v, want failed naming users.ux_email", c)
+	}
+
+	orch.IndexPlan.AddUniqueConversions(indexes.AnalyzeUnique(orch.Schema, orch.Mapping, nil))
+	if c := uniqueCheck(); !c.Passed {
+		t.Errorf("check with partial index = %+v, want passed", c)
+	}
+}
+
 func TestCheckReadiness_NotReady(t *testing.T) {
 	orch, _, _ := makeTestOrchestrator(t)
 	orch.State.MigrationStatus = "failed"
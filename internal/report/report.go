@@ -23,6 +23,13 @@ type MigrationReport struct {
 	ProductionReady bool                `json:"production_ready"`
 	ReadinessChecks []ReadinessCheck    `json:"readiness_checks"`
 	NextSteps       []string            `json:"next_steps"`
+
+	// Archives lists the rows archive policies sent to S3 instead of MongoDB.
+	Archives []ArchiveSummary `json:"archives,omitempty"`
+
+	// Remediations lists what was decided for collections that failed
+	// validation.
+	Remediations []RemediationSummary `json:"remediations,omitempty"`
 }
 
 // SourceSummary describes the source database.
@@ -52,6 +59,28 @@ type IndexSummary struct {
 	Status       string `json:"status"`
 }
 
+// ArchiveSummary describes where a collection's archived rows went.
+type ArchiveSummary struct {
+	Collection  string `json:"collection"`
+	SourceTable string `json:"source_table"`
+	Column      string `json:"column"`
+	Before      string `json:"before"`
+	Location    string `json:"location"`
+	Rows        int64  `json:"rows"`
+	Message     string `json:"message,omitempty"`
+}
+
+// RemediationSummary describes the decision on a collection that failed
+// validation, and its status in the last validation.
+type RemediationSummary struct {
+	Collection    string    `json:"collection"`
+	Action        string    `json:"action"`
+	Justification string    `json:"justification,omitempty"`
+	Causes        []string  `json:"causes,omitempty"`
+	DecidedAt     time.Time `json:"decided_at"`
+	Status        string    `json:"status,omitempty"`
+}
+
 // ReadinessCheck is a single production readiness condition.
 type ReadinessCheck struct {
 	Name    string `json:"name"`
@@ -187,6 +216,36 @@ func FormatText(report *MigrationReport) string {
 		b.WriteString("\n")
 	}
 
+	if len(report.Archives) > 0 {
+		b.WriteString("Archived (in S3, not MongoDB):\n")
+		for _, a := range report.Archives {
+			b.WriteString(fmt.Sprintf("  %s: %d rows of %s with %s before %s -> %s\n",
+				a.Collection, a.Rows, a.SourceTable, a.Column, a.Before, a.Location))
+			if a.Message != "" {
+				b.WriteString(fmt.Sprintf("    %s\n", a.Message))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(report.Remediations) > 0 {
+		b.WriteString("Validation Remediations:\n")
+		for _, r := range report.Remediations {
+			b.WriteString(fmt.Sprintf("  %s: %s (%s)", r.Collection, r.Action, r.DecidedAt.Format(time.RFC3339)))
+			if r.Status != "" {
+				b.WriteString(fmt.Sprintf(", now %s", r.Status))
+			}
+			b.WriteString("\n")
+			if r.Justification != "" {
+				b.WriteString(fmt.Sprintf("    Justification: %s\n", r.Justification))
+			}
+			for _, c := range r.Causes {
+				b.WriteString(fmt.Sprintf("    Cause: %s\n", c))
+			}
+		}
+		b.WriteString("\n")
+	}
+
 	b.WriteString(fmt.Sprintf("Indexes: %d (%s)\n\n", report.Indexes.TotalIndexes, report.Indexes.Status))
 
 	if report.ProductionReady {
@@ -0,0 +1,340 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+// retentionWords are table name parts that suggest rows are only useful for
+// a while: logs, audit trails, events and the like.
+var retentionWords = map[string]bool{
+	"log": true, "audit": true, "event": true, "history": true, "session": true,
+	"activity": true, "notification": true, "message": true, "metric": true,
+	"trace": true, "click": true, "pageview": true, "archive": true, "tracking": true,
+	"telemetry": true, "journal": true,
+}
+
+// dateColumnNames are preferred date columns, in order.
+var dateColumnNames = []string{
+	"created_at", "created", "created_on", "creation_date", "create_date",
+	"occurred_at", "event_time", "event_date", "logged_at", "log_date",
+	"inserted_at", "timestamp", "ts", "date",
+}
+
+// Candidate is a mapped collection whose source table looks like it only
+// needs to keep recent rows, with the date column to age them by.
+type Candidate struct {
+	Collection string                   `json:"collection"`
+	Table      string                   `json:"table"`
+	Column     string                   `json:"column"`
+	Reason     string                   `json:"reason"`
+	Policy     *mapping.RetentionPolicy `json:"policy,omitempty"`
+}
+
+// Candidates lists the collections that have a retention policy or whose
+// source table name suggests one (e.g. audit_log) and has a date column.
+func Candidates(s *schema.Schema, m *mapping.Mapping) []Candidate {
+	tables := make(map[string]*schema.Table, len(s.Tables))
+	for i := range s.Tables {
+		tables[s.Tables[i].Name] = &s.Tables[i]
+	}
+
+	var out []Candidate
+	for _, col := range m.Collections {
+		if col.Retention != nil {
+			out = append(out, Candidate{
+				Collection: col.Name,
+				Table:      col.SourceTable,
+				Column:     col.Retention.Column,
+				Reason:     "retention policy set",
+				Policy:     col.Retention,
+			})
+			continue
+		}
+		t := tables[col.SourceTable]
+		if t == nil {
+			continue
+		}
+		word := retentionWord(t.Name)
+		if word == "" {
+			continue
+		}
+		column := dateColumn(t)
+		if column == "" {
+			continue
+		}
+		if _, ok := col.RootField(column); !ok {
+			continue
+		}
+		out = append(out, Candidate{
+			Collection: col.Name,
+			Table:      t.Name,
+			Column:     column,
+			Reason:     fmt.Sprintf("table name suggests %s data", word),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Collection < out[j].Collection })
+	return out
+}
+
+// retentionWord returns the part of a table name that suggests retention.
+func retentionWord(table string) string {
+	for _, part := range strings.FieldsFunc(strings.ToLower(table), func(r rune) bool {
+		return r == '_' || r == '.' || r == '$'
+	}) {
+		if retentionWords[part] {
+			return part
+		}
+		if trimmed := strings.TrimSuffix(part, "s"); retentionWords[trimmed] {
+			return trimmed
+		}
+		if part == "histories" {
+			return "history"
+		}
+	}
+	return ""
+}
+
+// dateColumn picks the table's date or timestamp column to age rows by:
+// a well-known name if there is one, or else the first such column.
+func dateColumn(t *schema.Table) string {
+	var dates []string
+	for _, c := range t.Columns {
+		dt := strings.ToLower(c.DataType)
+		if strings.Contains(dt, "date") || strings.Contains(dt, "timestamp") {
+			dates = append(dates, c.Name)
+		}
+	}
+	for _, name := range dateColumnNames {
+		for _, c := range dates {
+			if strings.EqualFold(c, name) {
+				return c
+			}
+		}
+	}
+	if len(dates) > 0 {
+		return dates[0]
+	}
+	return ""
+}
+
+// Bucket counts the rows whose age falls in [MinDays, MaxDays). MaxDays is
+// zero for the oldest, open-ended bucket.
+type Bucket struct {
+	Label   string `json:"label"`
+	MinDays int64  `json:"min_days"`
+	MaxDays int64  `json:"max_days,omitempty"`
+	Rows    int64  `json:"rows"`
+}
+
+var buckets = []Bucket{
+	{Label: "< 30 days", MaxDays: 30},
+	{Label: "30-90 days", MinDays: 30, MaxDays: 90},
+	{Label: "90-180 days", MinDays: 90, MaxDays: 180},
+	{Label: "180 days-1 year", MinDays: 180, MaxDays: 365},
+	{Label: "1-2 years", MinDays: 365, MaxDays: 730},
+	{Label: "2-5 years", MinDays: 730, MaxDays: 1825},
+	{Label: "> 5 years", MinDays: 1825},
+}
+
+// Histogram is the age distribution of a source table's rows by a date
+// column, used to justify a retention policy.
+type Histogram struct {
+	Table      string   `json:"table"`
+	Column     string   `json:"column"`
+	Rows       int64    `json:"rows"`
+	NullRows   int64    `json:"null_rows"`
+	OldestDays int64    `json:"oldest_days"`
+	Buckets    []Bucket `json:"buckets"`
+
+	ages map[int64]int64
+}
+
+// NewHistogram buckets row counts keyed by age in days, as returned by
+// source.Reader.RowAges.
+func NewHistogram(table, column string, ages map[int64]int64) *Histogram {
+	h := &Histogram{Table: table, Column: column, Buckets: make([]Bucket, len(buckets)), ages: ages}
+	copy(h.Buckets, buckets)
+	for age, n := range ages {
+		h.Rows += n
+		if age == source.NullAge {
+			h.NullRows += n
+			continue
+		}
+		if age > h.OldestDays {
+			h.OldestDays = age
+		}
+		for i := range h.Buckets {
+			b := &h.Buckets[i]
+			if age >= b.MinDays && (b.MaxDays == 0 || age < b.MaxDays) {
+				b.Rows += n
+				break
+			}
+		}
+	}
+	return h
+}
+
+// Load reads the age histogram of a table's date column from the source.
+func Load(ctx context.Context, r source.Reader, table, column string) (*Histogram, error) {
+	ages, err := r.RowAges(ctx, table, column)
+	if err != nil {
+		return nil, err
+	}
+	return NewHistogram(table, column, ages), nil
+}
+
+// OlderThan returns how many rows are at least days old.
+func (h *Histogram) OlderThan(days int) int64 {
+	var n int64
+	for age, count := range h.ages {
+		if age != source.NullAge && age >= int64(days) {
+			n += count
+		}
+	}
+	return n
+}
+
+// archiveShare is the share of rows that must be older than an archive age
+// for archiving at that age to be worth suggesting.
+const archiveShare = 0.2
+
+// Suggest proposes archiving rows after the longest of 1 year, 180 days or
+// 90 days that at least a fifth of the rows are older than. Expiry is left
+// out: deleting data is a business decision the histogram cannot make.
+func Suggest(h *Histogram) *mapping.RetentionPolicy {
+	dated := h.Rows - h.NullRows
+	if dated == 0 {
+		return nil
+	}
+	for _, days := range []int{365, 180, 90} {
+		if float64(h.OlderThan(days)) >= archiveShare*float64(dated) {
+			return &mapping.RetentionPolicy{Column: h.Column, ArchiveAfterDays: days}
+		}
+	}
+	return nil
+}
+
+// Format renders the histogram as text bars of at most width characters,
+// with the share of rows each policy age would remove when given.
+func (h *Histogram) Format(width int, p *mapping.RetentionPolicy) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s.%s: %d rows, oldest %d days", h.Table, h.Column, h.Rows, h.OldestDays)
+	if h.NullRows > 0 {
+		fmt.Fprintf(&b, ", %d without a date", h.NullRows)
+	}
+	b.WriteString("\n")
+
+	var most int64
+	for _, bk := range h.Buckets {
+		if bk.Rows > most {
+			most = bk.Rows
+		}
+	}
+	for _, bk := range h.Buckets {
+		bar := 0
+		if most > 0 {
+			bar = int(bk.Rows * int64(width) / most)
+		}
+		if bar == 0 && bk.Rows > 0 {
+			bar = 1
+		}
+		fmt.Fprintf(&b, "  %-16s %s %d\n", bk.Label, strings.Repeat("█", bar), bk.Rows)
+	}
+
+	if p != nil {
+		if p.ArchiveAfterDays > 0 {
+			fmt.Fprintf(&b, "  Archive after %d days: %s\n", p.ArchiveAfterDays, h.share(p.ArchiveAfterDays))
+		}
+		if p.ExpireAfterDays > 0 {
+			fmt.Fprintf(&b, "  Expire after %d days: %s\n", p.ExpireAfterDays, h.share(p.ExpireAfterDays))
+		}
+	}
+	return b.String()
+}
+
+func (h *Histogram) share(days int) string {
+	n := h.OlderThan(days)
+	if h.Rows == 0 {
+		return "0 rows"
+	}
+	return fmt.Sprintf("%d rows (%.1f%%)", n, float64(n)*100/float64(h.Rows))
+}
+
+// Describe summarizes a policy in words, e.g. "archive after 90 days,
+// expire after 730 days".
+func Describe(p *mapping.RetentionPolicy) string {
+	if p == nil {
+		return "none"
+	}
+	var parts []string
+	if p.ArchiveAfterDays > 0 {
+		parts = append(parts, fmt.Sprintf("archive after %d days", p.ArchiveAfterDays))
+	}
+	if p.ExpireAfterDays > 0 {
+		parts = append(parts, fmt.Sprintf("expire after %d days", p.ExpireAfterDays))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%s by %s", strings.Join(parts, ", "), p.Column)
+}
+
+// OnlineArchiveRule is an Atlas Online Archive in the form the Atlas
+// Administration API accepts at
+// POST /groups/{groupId}/clusters/{clusterName}/onlineArchives.
+type OnlineArchiveRule struct {
+	DBName          string           `json:"dbName"`
+	CollName        string           `json:"collName"`
+	Criteria        ArchiveCriteria  `json:"criteria"`
+	PartitionFields []PartitionField `json:"partitionFields"`
+}
+
+// ArchiveCriteria archives documents once DateField is ExpireAfterDays old.
+type ArchiveCriteria struct {
+	Type            string `json:"type"`
+	DateField       string `json:"dateField"`
+	DateFormat      string `json:"dateFormat"`
+	ExpireAfterDays int    `json:"expireAfterDays"`
+}
+
+// PartitionField is a field archived data is partitioned by for queries.
+type PartitionField struct {
+	FieldName string `json:"fieldName"`
+	Order     int    `json:"order"`
+}
+
+// OnlineArchiveRules returns an Online Archive for every collection whose
+// retention policy archives documents.
+func OnlineArchiveRules(m *mapping.Mapping, database string) []OnlineArchiveRule {
+	var rules []OnlineArchiveRule
+	for i := range m.Collections {
+		col := &m.Collections[i]
+		p := col.Retention
+		if p == nil || p.ArchiveAfterDays <= 0 {
+			continue
+		}
+		field, ok := col.RootField(p.Column)
+		if !ok {
+			continue
+		}
+		rules = append(rules, OnlineArchiveRule{
+			DBName:   database,
+			CollName: col.Name,
+			Criteria: ArchiveCriteria{
+				Type:            "DATE",
+				DateField:       field,
+				DateFormat:      "ISODATE",
+				ExpireAfterDays: p.ArchiveAfterDays,
+			},
+			PartitionFields: []PartitionField{{FieldName: field, Order: 0}},
+		})
+	}
+	return rules
+}
@@ -0,0 +1,129 @@
+package retention
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+func retentionSchema() *schema.Schema {
+	return &schema.Schema{Tables: []schema.Table{
+		{Name: "customers", Columns: []schema.Column{
+			{Name: "id", DataType: "integer"},
+			{Name: "created_at", DataType: "timestamp with time zone"},
+		}},
+		{Name: "audit_log", Columns: []schema.Column{
+			{Name: "id", DataType: "bigint"},
+			{Name: "updated", DataType: "timestamp without time zone"},
+			{Name: "logged_at", DataType: "timestamp without time zone"},
+		}},
+		{Name: "USER_SESSIONS", Columns: []schema.Column{
+			{Name: "ID", DataType: "NUMBER"},
+			{Name: "STARTED", DataType: "DATE"},
+		}},
+		{Name: "event_types", Columns: []schema.Column{
+			{Name: "id", DataType: "integer"},
+			{Name: "name", DataType: "text"},
+		}},
+	}}
+}
+
+func TestCandidates(t *testing.T) {
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "customers", SourceTable: "customers"},
+		{Name: "auditLog", SourceTable: "audit_log"},
+		{Name: "sessions", SourceTable: "USER_SESSIONS"},
+		{Name: "eventTypes", SourceTable: "event_types"},
+		{Name: "orders", SourceTable: "customers", Retention: &mapping.RetentionPolicy{Column: "created_at", ExpireAfterDays: 30}},
+	}}
+
+	got := Candidates(retentionSchema(), m)
+	want := []Candidate{
+		{Collection: "auditLog", Table: "audit_log", Column: "logged_at", Reason: "table name suggests audit data"},
+		{Collection: "orders", Table: "customers", Column: "created_at", Reason: "retention policy set", Policy: m.Collections[4].Retention},
+		{Collection: "sessions", Table: "USER_SESSIONS", Column: "STARTED", Reason: "table name suggests session data"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Candidates =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	r := &source.MockReader{Ages: map[string]map[int64]int64{
+		"audit_log.logged_at": {source.NullAge: 5, 0: 10, 29: 10, 30: 20, 200: 15, 400: 30, 2000: 10},
+	}}
+	h, err := Load(context.Background(), r, "audit_log", "logged_at")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if h.Rows != 100 || h.NullRows != 5 || h.OldestDays != 2000 {
+		t.Errorf("Rows, NullRows, OldestDays = %d, %d, %d, want 100, 5, 2000", h.Rows, h.NullRows, h.OldestDays)
+	}
+	var counts []int64
+	for _, b := range h.Buckets {
+		counts = append(counts, b.Rows)
+	}
+	if want := []int64{20, 20, 0, 15, 30, 0, 10}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("bucket rows = %v, want %v", counts, want)
+	}
+	if got := h.OlderThan(365); got != 40 {
+		t.Errorf("OlderThan(365) = %d, want 40", got)
+	}
+
+	if got := Suggest(h); got == nil || got.ArchiveAfterDays != 365 || got.ExpireAfterDays != 0 {
+		t.Errorf("Suggest = %+v, want archive after 365 days", got)
+	}
+	if got := Suggest(NewHistogram("t", "c", map[int64]int64{1: 100, 400: 1})); got != nil {
+		t.Errorf("Suggest for mostly recent rows = %+v, want nil", got)
+	}
+
+	out := h.Format(20, &mapping.RetentionPolicy{Column: "logged_at", ArchiveAfterDays: 365})
+	for _, s := range []string{"100 rows, oldest 2000 days, 5 without a date", "1-2 years", "Archive after 365 days: 40 rows (40.0%)"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Format output missing %q:\n%s", s, out)
+		}
+	}
+}
+
+func TestOnlineArchiveRules(t *testing.T) {
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "customers", SourceTable: "customers", Retention: &mapping.RetentionPolicy{Column: "created_at", ExpireAfterDays: 30}},
+		{
+			Name:        "auditLog",
+			SourceTable: "audit_log",
+			Fields:      []mapping.FieldMapping{{Column: "logged_at", Target: "loggedAt"}},
+			Retention:   &mapping.RetentionPolicy{Column: "logged_at", ArchiveAfterDays: 90, ExpireAfterDays: 730},
+		},
+	}}
+	got := OnlineArchiveRules(m, "app")
+	want := []OnlineArchiveRule{{
+		DBName:          "app",
+		CollName:        "auditLog",
+		Criteria:        ArchiveCriteria{Type: "DATE", DateField: "loggedAt", DateFormat: "ISODATE", ExpireAfterDays: 90},
+		PartitionFields: []PartitionField{{FieldName: "loggedAt"}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OnlineArchiveRules = %+v, want %+v", got, want)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		p    *mapping.RetentionPolicy
+		want string
+	}{
+		{nil, "none"},
+		{&mapping.RetentionPolicy{Column: "created_at", ExpireAfterDays: 30}, "expire after 30 days by created_at"},
+		{&mapping.RetentionPolicy{Column: "ts", ArchiveAfterDays: 90, ExpireAfterDays: 730}, "archive after 90 days, expire after 730 days by ts"},
+	}
+	for _, tt := range tests {
+		if got := Describe(tt.p); got != tt.want {
+			t.Errorf("Describe(%+v) = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+// Package rpc serves Reloquent's core operations over a small, versioned
+// JSON protocol for tools that drive migrations from outside, such as a
+// Terraform provider or a CI pipeline.
+//
+// A client starts `reloquent rpc` and writes one Request per line to its
+// stdin; each is answered with one Response per line on stdout. Every
+// operation can run as a dry run, which reports what it would do and whether
+// anything would change without touching the target, so a provider's plan
+// maps to a dry run and its apply to the real call. Operations are
+// idempotent: applying one whose work is already done reports no change.
+//
+// The protocol is versioned separately from the HTTP API used by the web
+// UI. Fields may be added within a version, but not removed or repurposed.
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/plan"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+// Version is the protocol version this package speaks.
+const Version = 1
+
+// Methods.
+const (
+	MethodStatus       = "status"        // read the project's progress
+	MethodDesignImport = "design.import" // replace the mapping
+	MethodPreMigration = "premigration"  // create target collections
+	MethodMigrate      = "migrate"       // run a full or delta migration
+	MethodValidate     = "validate"      // compare source and target
+)
+
+// Error codes.
+const (
+	CodeBadRequest = "bad_request" // malformed request, wrong version or unknown method
+	CodeInvalid    = "invalid"     // params or project artifacts fail validation
+	CodeFailed     = "failed"      // the operation ran and failed
+)
+
+// Request is one call.
+type Request struct {
+	Version int             `json:"version"`
+	ID      string          `json:"id,omitempty"` // echoed in the response
+	Method  string          `json:"method"`
+	DryRun  bool            `json:"dry_run,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response answers one Request. Changed reports whether the call changed
+// the project or target, or in a dry run whether applying it would. A
+// failed operation may carry a Result alongside its Error, such as the
+// status of a migration that did not complete.
+type Response struct {
+	Version int         `json:"version"`
+	ID      string      `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	DryRun  bool        `json:"dry_run"`
+	Changed bool        `json:"changed"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   *Error      `json:"error,omitempty"`
+}
+
+// Error describes why a call failed.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func errorf(code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// DesignImportParams gives the mapping to import, either inline as JSON or
+// as the path of a YAML or JSON file.
+type DesignImportParams struct {
+	Mapping *mapping.Mapping `json:"mapping,omitempty"`
+	Path    string           `json:"path,omitempty"`
+}
+
+// DesignImportResult lists how the imported mapping differs from the
+// current one, by collection name.
+type DesignImportResult struct {
+	Collections int      `json:"collections"`
+	Added       []string `json:"added,omitempty"`
+	Removed     []string `json:"removed,omitempty"`
+	Modified    []string `json:"modified,omitempty"`
+}
+
+// ApplyParams are the params of premigration: Force repeats work the state
+// records as done.
+type ApplyParams struct {
+	Force bool `json:"force,omitempty"`
+}
+
+// PreMigrationResult is the pre-migration step's status and the collections
+// it creates.
+type PreMigrationResult struct {
+	Status      string   `json:"status"`
+	Collections []string `json:"collections"`
+}
+
+// MigrateParams are the params of migrate. Delta runs upsert only the rows
+// changed since the last run and always apply; a full migration applies
+// only if none has completed yet, or with Force.
+type MigrateParams struct {
+	Delta bool `json:"delta,omitempty"`
+	Force bool `json:"force,omitempty"`
+}
+
+// MigrateResult is a migrate dry run's plan, or the status of the run.
+type MigrateResult struct {
+	Delta      bool                              `json:"delta"`
+	Plan       *plan.Plan                        `json:"plan,omitempty"`
+	Watermarks map[string]mapping.WatermarkRange `json:"watermarks,omitempty"`
+	Status     *migration.Status                 `json:"status,omitempty"`
+}
+
+// ValidateResult is the validation mode and collections a dry run would
+// check, or the result of the run.
+type ValidateResult struct {
+	Mode        string             `json:"mode"`
+	Collections []string           `json:"collections"`
+	Result      *validation.Result `json:"result,omitempty"`
+}
+
+// StatusResult summarizes a project's progress.
+type StatusResult struct {
+	CurrentStep     string                      `json:"current_step"`
+	Steps           map[string]string           `json:"steps"`
+	MigrationStatus string                      `json:"migration_status,omitempty"`
+	Collections     []string                    `json:"collections,omitempty"`
+	Watermarks      map[string]*state.Watermark `json:"watermarks,omitempty"`
+}
+
+// Handler runs requests against one project's engine. The engine should
+// have its config and state loaded, and its schema and mapping when they
+// exist.
+type Handler struct {
+	Engine *engine.Engine
+}
+
+// Serve answers newline-delimited requests from r on w until r is exhausted.
+// Malformed lines get an error response; only I/O errors stop it.
+func (h *Handler) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	enc := json.NewEncoder(w)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp = Response{Version: Version, Error: errorf(CodeBadRequest, "parsing request: %v", err)}
+		} else {
+			resp = h.Handle(ctx, req)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// Handle runs one request.
+func (h *Handler) Handle(ctx context.Context, req Request) Response {
+	resp := Response{Version: Version, ID: req.ID, Method: req.Method, DryRun: req.DryRun}
+	if req.Version != Version {
+		resp.Error = errorf(CodeBadRequest, "unsupported protocol version %d (want %d)", req.Version, Version)
+		return resp
+	}
+
+	var err error
+	switch req.Method {
+	case MethodStatus:
+		resp.Result, err = h.status()
+	case MethodDesignImport:
+		var p DesignImportParams
+		if err = decodeParams(req.Params, &p); err == nil {
+			resp.Result, resp.Changed, err = h.designImport(p, req.DryRun)
+		}
+	case MethodPreMigration:
+		var p ApplyParams
+		if err = decodeParams(req.Params, &p); err == nil {
+			resp.Result, resp.Changed, err = h.preMigration(ctx, p, req.DryRun)
+		}
+	case MethodMigrate:
+		var p MigrateParams
+		if err = decodeParams(req.Params, &p); err == nil {
+			resp.Result, resp.Changed, err = h.migrate(ctx, p, req.DryRun)
+		}
+	case MethodValidate:
+		var p validation.Config
+		if err = decodeParams(req.Params, &p); err == nil {
+			resp.Result, resp.Changed, err = h.validate(ctx, p, req.DryRun)
+		}
+	default:
+		err = errorf(CodeBadRequest, "unknown method %q", req.Method)
+	}
+	if v := reflect.ValueOf(resp.Result); v.Kind() == reflect.Pointer && v.IsNil() {
+		resp.Result = nil
+	}
+	if err != nil {
+		var rerr *Error
+		switch {
+		case errors.As(err, &rerr):
+		case errors.Is(err, engine.ErrInvalidMapping):
+			rerr = &Error{Code: CodeInvalid, Message: err.Error()}
+		default:
+			rerr = &Error{Code: CodeFailed, Message: err.Error()}
+		}
+		resp.Error = rerr
+	}
+	return resp
+}
+
+func decodeParams(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errorf(CodeBadRequest, "parsing params: %v", err)
+	}
+	return nil
+}
+
+func (h *Handler) status() (*StatusResult, error) {
+	st, err := h.Engine.LoadState()
+	if err != nil {
+		return nil, err
+	}
+	res := &StatusResult{
+		CurrentStep:     string(st.CurrentStep),
+		Steps:           make(map[string]string, len(st.Steps)),
+		MigrationStatus: st.MigrationStatus,
+		Watermarks:      st.Watermarks,
+	}
+	for step, ss := range st.Steps {
+		res.Steps[string(step)] = ss.Status
+	}
+	if m := h.Engine.Mapping; m != nil {
+		for _, c := range m.Collections {
+			res.Collections = append(res.Collections, c.Name)
+		}
+	}
+	return res, nil
+}
+
+func (h *Handler) designImport(p DesignImportParams, dryRun bool) (*DesignImportResult, bool, error) {
+	m := p.Mapping
+	switch {
+	case m != nil && p.Path != "":
+		return nil, false, errorf(CodeBadRequest, "give either mapping or path, not both")
+	case p.Path != "":
+		loaded, err := mapping.LoadYAML(p.Path)
+		if err != nil {
+			return nil, false, errorf(CodeInvalid, "loading mapping: %v", err)
+		}
+		m = loaded
+	case m == nil:
+		return nil, false, errorf(CodeBadRequest, "mapping or path is required")
+	}
+	if err := h.Engine.ValidateMapping(m); err != nil {
+		return nil, false, err
+	}
+
+	res := diffMappings(h.Engine.Mapping, m)
+	changed := len(res.Added)+len(res.Removed)+len(res.Modified) > 0 || h.Engine.Mapping == nil
+	if dryRun || !changed {
+		return res, changed, nil
+	}
+	if err := h.Engine.SaveMapping(m); err != nil {
+		return nil, false, err
+	}
+	return res, true, nil
+}
+
+// diffMappings compares two mappings collection by collection; cur may be
+// nil.
+func diffMappings(cur, next *mapping.Mapping) *DesignImportResult {
+	res := &DesignImportResult{Collections: len(next.Collections)}
+	old := make(map[string]mapping.Collection)
+	if cur != nil {
+		for _, c := range cur.Collections {
+			old[c.Name] = c
+		}
+	}
+	for _, c := range next.Collections {
+		prev, ok := old[c.Name]
+		switch {
+		case !ok:
+			res.Added = append(res.Added, c.Name)
+		case !reflect.DeepEqual(prev, c):
+			res.Modified = append(res.Modified, c.Name)
+		}
+		delete(old, c.Name)
+	}
+	for name := range old {
+		res.Removed = append(res.Removed, name)
+	}
+	sort.Strings(res.Removed)
+	return res
+}
+
+func (h *Handler) preMigration(ctx context.Context, p ApplyParams, dryRun bool) (*PreMigrationResult, bool, error) {
+	m := h.Engine.Mapping
+	if m == nil {
+		return nil, false, errorf(CodeInvalid, "no mapping; import one first")
+	}
+	if err := h.Engine.ValidateMapping(m); err != nil {
+		return nil, false, err
+	}
+	res := &PreMigrationResult{Status: h.Engine.PreMigrationStatus().Status}
+	for _, c := range m.Collections {
+		res.Collections = append(res.Collections, c.Name)
+	}
+	changed := res.Status != "complete" || p.Force
+	if dryRun || !changed {
+		return res, changed, nil
+	}
+	if err := h.Engine.PreMigrationPrepare(ctx); err != nil {
+		return nil, false, err
+	}
+	res.Status = h.Engine.PreMigrationStatus().Status
+	return res, true, nil
+}
+
+func (h *Handler) migrate(ctx context.Context, p MigrateParams, dryRun bool) (*MigrateResult, bool, error) {
+	if h.Engine.Mapping == nil {
+		return nil, false, errorf(CodeInvalid, "no mapping; import one first")
+	}
+	res := &MigrateResult{Delta: p.Delta}
+	changed := p.Delta || p.Force || h.Engine.MigrationStatus().Phase != "completed"
+	if dryRun {
+		if p.Delta {
+			ranges, err := h.Engine.WatermarkRanges(ctx, true)
+			if err != nil {
+				return nil, false, err
+			}
+			res.Watermarks = ranges
+		} else {
+			pl, err := h.Engine.Plan()
+			if err != nil {
+				return nil, false, err
+			}
+			res.Plan = pl
+		}
+		return res, changed, nil
+	}
+	if !changed {
+		res.Status = h.Engine.MigrationStatus()
+		return res, false, nil
+	}
+
+	progress := func(*migration.Status) {}
+	var err error
+	if p.Delta {
+		res.Status, err = h.Engine.MigrateDelta(ctx, progress)
+	} else {
+		res.Status, err = h.Engine.Migrate(ctx, progress)
+	}
+	return res, true, err
+}
+
+func (h *Handler) validate(ctx context.Context, cfg validation.Config, dryRun bool) (*ValidateResult, bool, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, false, errorf(CodeBadRequest, "%v", err)
+	}
+	if h.Engine.Mapping == nil {
+		return nil, false, errorf(CodeInvalid, "no mapping; import one first")
+	}
+	res := &ValidateResult{Mode: cfg.Mode}
+	if res.Mode == "" {
+		res.Mode = validation.ModeFull
+	}
+	for _, c := range h.Engine.Mapping.Collections {
+		res.Collections = append(res.Collections, c.Name)
+	}
+	// Validation only reads the data, but records its report in the state
+	if dryRun {
+		return res, true, nil
+	}
+	result, err := h.Engine.Validate(ctx, cfg, nil, nil)
+	if err != nil {
+		return nil, true, err
+	}
+	res.Result = result
+	if result.Status != "PASS" {
+		return res, true, errorf(CodeFailed, "validation %s", result.Status)
+	}
+	return res, true, nil
+}
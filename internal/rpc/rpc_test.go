@@ -0,0 +1,166 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/state"
+)
+
+func testHandler(t *testing.T) *Handler {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	eng := engine.New(&config.Config{Version: 1}, slog.Default())
+	eng.SetProject(&state.Project{Name: "test", Dir: t.TempDir()})
+	if _, err := eng.LoadState(); err != nil {
+		t.Fatal(err)
+	}
+	return &Handler{Engine: eng}
+}
+
+func call(t *testing.T, h *Handler, method string, dryRun bool, params interface{}) Response {
+	t.Helper()
+	raw, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h.Handle(context.Background(), Request{Version: Version, Method: method, DryRun: dryRun, Params: raw})
+}
+
+func TestServe(t *testing.T) {
+	h := testHandler(t)
+	in := strings.Join([]string{
+		`{"version": 1, "id": "a", "method": "status"}`,
+		``,
+		`{"version": 2, "id": "b", "method": "status"}`,
+		`{"version": 1, "id": "c", "method": "apply"}`,
+		`not json`,
+	}, "\n")
+	var out strings.Builder
+	if err := h.Serve(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	var got []Response
+	sc := bufio.NewScanner(strings.NewReader(out.String()))
+	for sc.Scan() {
+		var resp Response
+		if err := json.Unmarshal(sc.Bytes(), &resp); err != nil {
+			t.Fatalf("response %q: %v", sc.Text(), err)
+		}
+		got = append(got, resp)
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d responses, want 4", len(got))
+	}
+	if got[0].ID != "a" || got[0].Error != nil || got[0].Result == nil {
+		t.Errorf("status response = %+v", got[0])
+	}
+	for i, wantID := range []string{"b", "c", ""} {
+		resp := got[i+1]
+		if resp.ID != wantID || resp.Error == nil || resp.Error.Code != CodeBadRequest {
+			t.Errorf("response %d = %+v, want bad_request for id %q", i+1, resp, wantID)
+		}
+	}
+}
+
+func TestDesignImport(t *testing.T) {
+	h := testHandler(t)
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users", SourceTable: "users"},
+		{Name: "orders", SourceTable: "orders"},
+	}}
+
+	// Plan: nothing is saved
+	resp := call(t, h, MethodDesignImport, true, DesignImportParams{Mapping: m})
+	if resp.Error != nil || !resp.Changed {
+		t.Fatalf("dry run = %+v, want a change", resp)
+	}
+	if res := resp.Result.(*DesignImportResult); len(res.Added) != 2 {
+		t.Errorf("dry run added = %v, want both collections", res.Added)
+	}
+	if h.Engine.Mapping != nil {
+		t.Fatal("dry run saved the mapping")
+	}
+
+	// Apply, then apply again with no change
+	if resp := call(t, h, MethodDesignImport, false, DesignImportParams{Mapping: m}); resp.Error != nil || !resp.Changed {
+		t.Fatalf("apply = %+v", resp)
+	}
+	if h.Engine.Mapping == nil || h.Engine.State.MappingPath == "" {
+		t.Fatal("apply did not save the mapping")
+	}
+	if resp := call(t, h, MethodDesignImport, false, DesignImportParams{Mapping: m}); resp.Error != nil || resp.Changed {
+		t.Errorf("repeated apply = %+v, want no change", resp)
+	}
+
+	next := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users", SourceTable: "users", Filter: "active = 1"},
+		{Name: "products", SourceTable: "products"},
+	}}
+	resp = call(t, h, MethodDesignImport, true, DesignImportParams{Mapping: next})
+	res := resp.Result.(*DesignImportResult)
+	if len(res.Added) != 1 || len(res.Removed) != 1 || len(res.Modified) != 1 || res.Modified[0] != "users" {
+		t.Errorf("diff = %+v, want products added, orders removed, users modified", res)
+	}
+
+	next.Collections[0].Filter = "1 = 1; DROP TABLE users"
+	resp = call(t, h, MethodDesignImport, true, DesignImportParams{Mapping: next})
+	if resp.Error == nil || resp.Error.Code != CodeInvalid {
+		t.Errorf("invalid mapping: error = %+v, want %s", resp.Error, CodeInvalid)
+	}
+	if resp := call(t, h, MethodDesignImport, true, DesignImportParams{}); resp.Error == nil || resp.Error.Code != CodeBadRequest {
+		t.Errorf("no mapping: error = %+v, want %s", resp.Error, CodeBadRequest)
+	}
+}
+
+func TestIdempotentApply(t *testing.T) {
+	h := testHandler(t)
+	h.Engine.Schema = &schema.Schema{Tables: []schema.Table{{Name: "users", RowCount: 10}}}
+	h.Engine.SetMapping(&mapping.Mapping{Collections: []mapping.Collection{{Name: "users", SourceTable: "users"}}})
+	h.Engine.State.Steps[state.StepPreMigration] = state.StepState{Status: "complete"}
+	h.Engine.State.MigrationStatus = "completed"
+
+	tests := []struct {
+		method string
+		params interface{}
+		want   bool
+	}{
+		{MethodPreMigration, nil, false},
+		{MethodPreMigration, ApplyParams{Force: true}, true},
+		{MethodMigrate, nil, false},
+		{MethodValidate, nil, true},
+	}
+	for _, tt := range tests {
+		resp := call(t, h, tt.method, true, tt.params)
+		if resp.Error != nil {
+			t.Errorf("%s: error = %v", tt.method, resp.Error)
+			continue
+		}
+		if resp.Changed != tt.want {
+			t.Errorf("%s %+v: changed = %v, want %v", tt.method, tt.params, resp.Changed, tt.want)
+		}
+	}
+
+	// A completed migration is not rerun
+	resp := call(t, h, MethodMigrate, false, nil)
+	if resp.Error != nil || resp.Changed {
+		t.Errorf("migrate apply = %+v, want no change", resp)
+	}
+	if res := resp.Result.(*MigrateResult); res.Status == nil || res.Status.Phase != "completed" {
+		t.Errorf("migrate status = %+v", res.Status)
+	}
+
+	resp = call(t, h, MethodValidate, true, map[string]string{"mode": "bogus"})
+	if resp.Error == nil || resp.Error.Code != CodeBadRequest {
+		t.Errorf("bad validation mode: error = %+v", resp.Error)
+	}
+}
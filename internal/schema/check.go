@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// status IN ('a', 'b'), as Oracle and MySQL report it
+	checkIn = regexp.MustCompile(`(?is)^"?(\w+)"?\s+IN\s*\((.*)\)$`)
+	// (status)::text = ANY ((ARRAY['a'::character varying, ...])::text[]),
+	// as PostgreSQL rewrites an IN list
+	checkAny   = regexp.MustCompile(`(?is)^\(?"?(\w+)"?\)?(?:::[\w ]+?)?\s*=\s*ANY\s*\((.*)\)$`)
+	checkArray = regexp.MustCompile(`(?is)^\(?ARRAY\[(.*?)\]\)?(?:::[\w ]+\[\])?$`)
+)
+
+// Enum returns the column a check constraint limits to a list of string
+// literals, and the literals, when the constraint is an IN list or
+// PostgreSQL's = ANY (ARRAY[...]) form of one. Other constraints, and lists
+// with anything other than string literals, report false.
+func (c Constraint) Enum() (column string, values []string, ok bool) {
+	if c.Type != "check" {
+		return "", nil, false
+	}
+	def := StripParens(strings.TrimSpace(c.Definition))
+	var list string
+	if m := checkIn.FindStringSubmatch(def); m != nil {
+		column, list = m[1], m[2]
+	} else if m := checkAny.FindStringSubmatch(def); m != nil {
+		a := checkArray.FindStringSubmatch(strings.TrimSpace(m[2]))
+		if a == nil {
+			return "", nil, false
+		}
+		column, list = m[1], a[1]
+	} else {
+		return "", nil, false
+	}
+	values, ok = stringLiterals(list)
+	if !ok || len(values) == 0 {
+		return "", nil, false
+	}
+	return column, values, true
+}
+
+// StripParens removes parentheses that enclose the whole SQL expression s,
+// ignoring those in string literals.
+func StripParens(s string) string {
+	for strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") && closingParen(s) == len(s)-1 {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return s
+}
+
+// closingParen returns the index of the parenthesis that closes the one s
+// starts with, ignoring those in string literals, or -1.
+func closingParen(s string) int {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'':
+			quoted = !quoted
+		case quoted:
+		case s[i] == '(':
+			depth++
+		case s[i] == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// stringLiterals parses a comma separated list of quoted string literals,
+// each optionally cast as in 'a'::text. A doubled quote in a literal
+// stands for one.
+func stringLiterals(list string) ([]string, bool) {
+	var values []string
+	s := strings.TrimSpace(list)
+	for s != "" {
+		if s[0] != '\'' {
+			return nil, false
+		}
+		var b strings.Builder
+		i := 1
+		for {
+			if i >= len(s) {
+				return nil, false
+			}
+			if s[i] == '\'' {
+				if i+1 < len(s) && s[i+1] == '\'' {
+					b.WriteByte('\'')
+					i += 2
+					continue
+				}
+				break
+			}
+			b.WriteByte(s[i])
+			i++
+		}
+		values = append(values, b.String())
+		s = strings.TrimSpace(s[i+1:])
+		if strings.HasPrefix(s, "::") {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			s = strings.TrimSpace(s[end:])
+		}
+		if s == "" {
+			break
+		}
+		if s[0] != ',' {
+			return nil, false
+		}
+		s = strings.TrimSpace(s[1:])
+		if s == "" {
+			return nil, false
+		}
+	}
+	return values, true
+}
@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConstraint_Enum(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		column     string
+		values     []string
+	}{
+		{"postgres varchar", "((status)::text = ANY ((ARRAY['active'::character varying, 'closed'::character varying])::text[]))", "status", []string{"active", "closed"}},
+		{"postgres text", "(kind = ANY (ARRAY['a'::text, 'b'::text]))", "kind", []string{"a", "b"}},
+		{"oracle", `"STATUS" IN ('A','B', 'C')`, "STATUS", []string{"A", "B", "C"}},
+		{"parenthesized", "(status IN ('open', 'it''s'))", "status", []string{"open", "it's"}},
+		{"numbers", "(level IN (1, 2, 3))", "", nil},
+		{"comparison", "(score > 0)", "", nil},
+		{"two columns", "((a = ANY (ARRAY['x'::text])) AND (b > 0))", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			col, values, ok := Constraint{Type: "check", Definition: tt.definition}.Enum()
+			if ok != (tt.column != "") || col != tt.column || !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("Enum() = %q, %q, %v; want %q, %q", col, values, ok, tt.column, tt.values)
+			}
+		})
+	}
+}
+
+func TestStripParens(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"(a > 0)", "a > 0"},
+		{"(( a > 0 ))", "a > 0"},
+		{"(a > 0) AND (b > 0)", "(a > 0) AND (b > 0)"},
+		{"(name = ')')", "name = ')'"},
+		{"(name = '(') OR (b = 1)", "(name = '(') OR (b = 1)"},
+		{"(name = 'it''s (x')", "name = 'it''s (x'"},
+		{"a > 0", "a > 0"},
+	}
+	for _, tt := range tests {
+		if got := StripParens(tt.in); got != tt.want {
+			t.Errorf("StripParens(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
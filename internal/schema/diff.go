@@ -0,0 +1,202 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SchemaDiff lists what changed between two discoveries of the same source.
+type SchemaDiff struct {
+	AddedTables   []string    `yaml:"added_tables,omitempty" json:"added_tables"`
+	RemovedTables []string    `yaml:"removed_tables,omitempty" json:"removed_tables"`
+	ChangedTables []TableDiff `yaml:"changed_tables,omitempty" json:"changed_tables"`
+}
+
+// TableDiff lists the column and key changes within one table.
+type TableDiff struct {
+	Name              string         `yaml:"name" json:"name"`
+	AddedColumns      []string       `yaml:"added_columns,omitempty" json:"added_columns"`
+	RemovedColumns    []string       `yaml:"removed_columns,omitempty" json:"removed_columns"`
+	ChangedColumns    []ColumnChange `yaml:"changed_columns,omitempty" json:"changed_columns"`
+	PrimaryKeyChanged bool           `yaml:"primary_key_changed,omitempty" json:"primary_key_changed"`
+}
+
+// ColumnChange describes how a column's definition changed, e.g.
+// "data_type: integer -> bigint".
+type ColumnChange struct {
+	Name    string   `yaml:"name" json:"name"`
+	Changes []string `yaml:"changes" json:"changes"`
+}
+
+// Diff compares an earlier discovery with a later one. Tables and columns
+// are matched by name; row counts and sizes are expected to drift and are
+// not reported.
+func Diff(old, cur *Schema) *SchemaDiff {
+	d := &SchemaDiff{
+		AddedTables:   []string{},
+		RemovedTables: []string{},
+		ChangedTables: []TableDiff{},
+	}
+	oldTables := tablesByName(old)
+	newTables := tablesByName(cur)
+
+	for name := range newTables {
+		if _, ok := oldTables[name]; !ok {
+			d.AddedTables = append(d.AddedTables, name)
+		}
+	}
+	for name, ot := range oldTables {
+		nt, ok := newTables[name]
+		if !ok {
+			d.RemovedTables = append(d.RemovedTables, name)
+			continue
+		}
+		if td := diffTable(ot, nt); td != nil {
+			d.ChangedTables = append(d.ChangedTables, *td)
+		}
+	}
+
+	slices.Sort(d.AddedTables)
+	slices.Sort(d.RemovedTables)
+	slices.SortFunc(d.ChangedTables, func(a, b TableDiff) int { return strings.Compare(a.Name, b.Name) })
+	return d
+}
+
+// HasChanges reports whether the diff found any difference.
+func (d *SchemaDiff) HasChanges() bool {
+	return len(d.AddedTables) > 0 || len(d.RemovedTables) > 0 || len(d.ChangedTables) > 0
+}
+
+// Summary returns a human-readable list of the changes.
+func (d *SchemaDiff) Summary() string {
+	if !d.HasChanges() {
+		return "No schema changes."
+	}
+	var b strings.Builder
+	for _, t := range d.AddedTables {
+		fmt.Fprintf(&b, "+ table %s\n", t)
+	}
+	for _, t := range d.RemovedTables {
+		fmt.Fprintf(&b, "- table %s\n", t)
+	}
+	for _, t := range d.ChangedTables {
+		fmt.Fprintf(&b, "~ table %s\n", t.Name)
+		for _, c := range t.AddedColumns {
+			fmt.Fprintf(&b, "    + column %s\n", c)
+		}
+		for _, c := range t.RemovedColumns {
+			fmt.Fprintf(&b, "    - column %s\n", c)
+		}
+		for _, c := range t.ChangedColumns {
+			fmt.Fprintf(&b, "    ~ column %s (%s)\n", c.Name, strings.Join(c.Changes, ", "))
+		}
+		if t.PrimaryKeyChanged {
+			b.WriteString("    ~ primary key\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func tablesByName(s *Schema) map[string]*Table {
+	m := make(map[string]*Table)
+	if s == nil {
+		return m
+	}
+	for i := range s.Tables {
+		m[s.Tables[i].Name] = &s.Tables[i]
+	}
+	return m
+}
+
+func diffTable(old, cur *Table) *TableDiff {
+	td := TableDiff{Name: old.Name}
+
+	oldCols := make(map[string]*Column, len(old.Columns))
+	for i := range old.Columns {
+		oldCols[old.Columns[i].Name] = &old.Columns[i]
+	}
+	newCols := make(map[string]bool, len(cur.Columns))
+	for i := range cur.Columns {
+		nc := &cur.Columns[i]
+		newCols[nc.Name] = true
+		oc, ok := oldCols[nc.Name]
+		if !ok {
+			td.AddedColumns = append(td.AddedColumns, nc.Name)
+			continue
+		}
+		if changes := diffColumn(oc, nc); len(changes) > 0 {
+			td.ChangedColumns = append(td.ChangedColumns, ColumnChange{Name: nc.Name, Changes: changes})
+		}
+	}
+	for _, oc := range old.Columns {
+		if !newCols[oc.Name] {
+			td.RemovedColumns = append(td.RemovedColumns, oc.Name)
+		}
+	}
+	td.PrimaryKeyChanged = !slices.Equal(pkColumns(old), pkColumns(cur))
+
+	if len(td.AddedColumns) == 0 && len(td.RemovedColumns) == 0 && len(td.ChangedColumns) == 0 && !td.PrimaryKeyChanged {
+		return nil
+	}
+	return &td
+}
+
+func diffColumn(old, cur *Column) []string {
+	var changes []string
+	if old.DataType != cur.DataType {
+		changes = append(changes, fmt.Sprintf("data_type: %s -> %s", old.DataType, cur.DataType))
+	}
+	if old.Nullable != cur.Nullable {
+		changes = append(changes, fmt.Sprintf("nullable: %t -> %t", old.Nullable, cur.Nullable))
+	}
+	for _, f := range []struct {
+		name     string
+		old, cur *int
+	}{
+		{"max_length", old.MaxLength, cur.MaxLength},
+		{"precision", old.Precision, cur.Precision},
+		{"scale", old.Scale, cur.Scale},
+	} {
+		if o, n := intOrNone(f.old), intOrNone(f.cur); o != n {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", f.name, o, n))
+		}
+	}
+	if old.SRID != cur.SRID {
+		changes = append(changes, fmt.Sprintf("srid: %d -> %d", old.SRID, cur.SRID))
+	}
+	if old.GeometryType != cur.GeometryType {
+		changes = append(changes, fmt.Sprintf("geometry_type: %s -> %s", valueOrNone(old.GeometryType), valueOrNone(cur.GeometryType)))
+	}
+	if old.TypeName != cur.TypeName {
+		changes = append(changes, fmt.Sprintf("type_name: %s -> %s", valueOrNone(old.TypeName), valueOrNone(cur.TypeName)))
+	}
+	if oc, cc := old.Collation.String(), cur.Collation.String(); oc != cc {
+		changes = append(changes, fmt.Sprintf("collation: %s -> %s", oc, cc))
+	}
+	if !slices.Equal(old.EnumValues, cur.EnumValues) {
+		changes = append(changes, fmt.Sprintf("enum_values: [%s] -> [%s]", strings.Join(old.EnumValues, ", "), strings.Join(cur.EnumValues, ", ")))
+	}
+	return changes
+}
+
+func intOrNone(p *int) string {
+	if p == nil {
+		return "none"
+	}
+	return fmt.Sprint(*p)
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func pkColumns(t *Table) []string {
+	if t.PrimaryKey == nil {
+		return nil
+	}
+	return t.PrimaryKey.Columns
+}
@@ -0,0 +1,123 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func intPtr(i int) *int { return &i }
+
+func TestDiff(t *testing.T) {
+	old := &Schema{Tables: []Table{
+		{
+			Name: "users",
+			Columns: []Column{
+				{Name: "id", DataType: "integer"},
+				{Name: "name", DataType: "character varying", MaxLength: intPtr(100)},
+				{Name: "fax", DataType: "text", Nullable: true},
+			},
+			PrimaryKey: &PrimaryKey{Columns: []string{"id"}},
+			RowCount:   10,
+		},
+		{Name: "orders", Columns: []Column{{Name: "id", DataType: "integer"}}},
+		{Name: "legacy", Columns: []Column{{Name: "id", DataType: "integer"}}},
+	}}
+	cur := &Schema{Tables: []Table{
+		{
+			Name: "users",
+			Columns: []Column{
+				{Name: "id", DataType: "bigint"},
+				{Name: "name", DataType: "character varying", MaxLength: intPtr(200), Nullable: true},
+				{Name: "email", DataType: "text"},
+			},
+			PrimaryKey: &PrimaryKey{Columns: []string{"id"}},
+			RowCount:   5000,
+		},
+		{Name: "orders", Columns: []Column{{Name: "id", DataType: "integer"}}},
+		{Name: "invoices", Columns: []Column{{Name: "id", DataType: "integer"}}},
+	}}
+
+	d := Diff(old, cur)
+
+	if !reflect.DeepEqual(d.AddedTables, []string{"invoices"}) {
+		t.Errorf("AddedTables = %v", d.AddedTables)
+	}
+	if !reflect.DeepEqual(d.RemovedTables, []string{"legacy"}) {
+		t.Errorf("RemovedTables = %v", d.RemovedTables)
+	}
+	if len(d.ChangedTables) != 1 {
+		t.Fatalf("ChangedTables = %+v, want only users", d.ChangedTables)
+	}
+	users := d.ChangedTables[0]
+	want := TableDiff{
+		Name:           "users",
+		AddedColumns:   []string{"email"},
+		RemovedColumns: []string{"fax"},
+		ChangedColumns: []ColumnChange{
+			{Name: "id", Changes: []string{"data_type: integer -> bigint"}},
+			{Name: "name", Changes: []string{"nullable: false -> true", "max_length: 100 -> 200"}},
+		},
+	}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("users diff = %+v\nwant %+v", users, want)
+	}
+	if !d.HasChanges() {
+		t.Error("HasChanges = false")
+	}
+	summary := d.Summary()
+	for _, s := range []string{"+ table invoices", "- table legacy", "- column fax", "~ column id (data_type: integer -> bigint)"} {
+		if !strings.Contains(summary, s) {
+			t.Errorf("Summary missing %q:\n%s", s, summary)
+		}
+	}
+}
+
+func TestDiff_Unchanged(t *testing.T) {
+	s := &Schema{Tables: []Table{{Name: "users", Columns: []Column{{Name: "id", DataType: "integer"}}, RowCount: 1}}}
+	grown := &Schema{Tables: []Table{{Name: "users", Columns: []Column{{Name: "id", DataType: "integer"}}, RowCount: 99}}}
+
+	d := Diff(s, grown)
+	if d.HasChanges() {
+		t.Errorf("row count drift reported as a change: %+v", d)
+	}
+	if d.Summary() != "No schema changes." {
+		t.Errorf("Summary = %q", d.Summary())
+	}
+}
+
+func TestDiff_PrimaryKey(t *testing.T) {
+	cols := []Column{{Name: "a", DataType: "integer"}, {Name: "b", DataType: "integer"}}
+	old := &Schema{Tables: []Table{{Name: "t", Columns: cols, PrimaryKey: &PrimaryKey{Columns: []string{"a"}}}}}
+	cur := &Schema{Tables: []Table{{Name: "t", Columns: cols, PrimaryKey: &PrimaryKey{Columns: []string{"a", "b"}}}}}
+
+	d := Diff(old, cur)
+	if len(d.ChangedTables) != 1 || !d.ChangedTables[0].PrimaryKeyChanged {
+		t.Errorf("ChangedTables = %+v, want primary key change", d.ChangedTables)
+	}
+}
+
+func TestDiff_EnumValues(t *testing.T) {
+	col := func(labels ...string) []Column {
+		return []Column{{Name: "status", DataType: "enum", TypeName: "order_status", EnumValues: labels}}
+	}
+	old := &Schema{Tables: []Table{{Name: "orders", Columns: col("pending", "paid")}}}
+	cur := &Schema{Tables: []Table{{Name: "orders", Columns: col("pending", "paid", "refunded")}}}
+
+	d := Diff(old, cur)
+	want := []ColumnChange{{Name: "status", Changes: []string{"enum_values: [pending, paid] -> [pending, paid, refunded]"}}}
+	if len(d.ChangedTables) != 1 || !reflect.DeepEqual(d.ChangedTables[0].ChangedColumns, want) {
+		t.Errorf("ChangedTables = %+v, want the new label", d.ChangedTables)
+	}
+}
+
+func TestDiff_Collation(t *testing.T) {
+	old := &Schema{Tables: []Table{{Name: "users", Columns: []Column{{Name: "email", DataType: "text"}}}}}
+	cur := &Schema{Tables: []Table{{Name: "users", Columns: []Column{{Name: "email", DataType: "text", Collation: &Collation{Name: "und-ci", CaseInsensitive: true}}}}}}
+
+	d := Diff(old, cur)
+	want := []ColumnChange{{Name: "email", Changes: []string{"collation: none -> und-ci (case-insensitive)"}}}
+	if len(d.ChangedTables) != 1 || !reflect.DeepEqual(d.ChangedTables[0].ChangedColumns, want) {
+		t.Errorf("ChangedTables = %+v, want the collation change", d.ChangedTables)
+	}
+}
@@ -6,6 +6,7 @@ type Schema struct {
 	Host         string  `yaml:"host" json:"host"`
 	Database     string  `yaml:"database" json:"database"`
 	SchemaName   string  `yaml:"schema_name,omitempty" json:"schema_name,omitempty"`
+	TimeZone     string  `yaml:"time_zone,omitempty" json:"time_zone,omitempty"` // the server's time zone, an IANA name or an offset such as +00:00
 	Tables       []Table `yaml:"tables" json:"tables"`
 }
 
@@ -21,7 +22,10 @@ type Table struct {
 	SizeBytes   int64        `yaml:"size_bytes" json:"size_bytes"`
 }
 
-// Column represents a table column.
+// Column represents a table column. Geometry columns also carry their
+// spatial reference ID (0 if unknown) and the GeoJSON type of the shapes
+// they are constrained to, such as "Point"; GeometryType is empty when any
+// shape is allowed.
 type Column struct {
 	Name         string  `yaml:"name" json:"name"`
 	DataType     string  `yaml:"data_type" json:"data_type"`
@@ -31,6 +35,43 @@ type Column struct {
 	Precision    *int    `yaml:"precision,omitempty" json:"precision,omitempty"`
 	Scale        *int    `yaml:"scale,omitempty" json:"scale,omitempty"`
 	IsSequence   bool    `yaml:"is_sequence,omitempty" json:"is_sequence,omitempty"`
+	SRID         int     `yaml:"srid,omitempty" json:"srid,omitempty"`
+	GeometryType string  `yaml:"geometry_type,omitempty" json:"geometry_type,omitempty"`
+
+	// TypeName is the user-defined type the column is declared with: a
+	// domain, whose base type is DataType, or an enum type, whose labels
+	// are EnumValues in their sort order.
+	TypeName   string   `yaml:"type_name,omitempty" json:"type_name,omitempty"`
+	EnumValues []string `yaml:"enum_values,omitempty" json:"enum_values,omitempty"`
+
+	// Collation is how the column compares text, when that is not byte by
+	// byte as MongoDB compares strings by default.
+	Collation *Collation `yaml:"collation,omitempty" json:"collation,omitempty"`
+}
+
+// Collation describes a column's source collation: its name, the locale it
+// follows and whether equal values may differ in case or accents. Discovery
+// leaves binary collations (C, POSIX, BINARY) out.
+type Collation struct {
+	Name              string `yaml:"name" json:"name"`
+	Locale            string `yaml:"locale,omitempty" json:"locale,omitempty"`
+	CaseInsensitive   bool   `yaml:"case_insensitive,omitempty" json:"case_insensitive,omitempty"`
+	AccentInsensitive bool   `yaml:"accent_insensitive,omitempty" json:"accent_insensitive,omitempty"` // implies case insensitive
+}
+
+// String describes the collation for diffs and reports, as in
+// "und-ci (case-insensitive)".
+func (c *Collation) String() string {
+	if c == nil {
+		return "none"
+	}
+	switch {
+	case c.AccentInsensitive:
+		return c.Name + " (case- and accent-insensitive)"
+	case c.CaseInsensitive:
+		return c.Name + " (case-insensitive)"
+	}
+	return c.Name
 }
 
 // PrimaryKey represents a table's primary key.
@@ -47,12 +88,16 @@ type ForeignKey struct {
 	ReferencedColumns []string `yaml:"referenced_columns" json:"referenced_columns"`
 }
 
-// Index represents a database index.
+// Index represents a database index. Expressions holds the keys of a
+// function-based index that are not plain columns, and Predicate the WHERE
+// clause of a filtered (partial) index.
 type Index struct {
-	Name    string   `yaml:"name" json:"name"`
-	Columns []string `yaml:"columns" json:"columns"`
-	Unique  bool     `yaml:"unique" json:"unique"`
-	Type    string   `yaml:"type,omitempty" json:"type,omitempty"`
+	Name        string   `yaml:"name" json:"name"`
+	Columns     []string `yaml:"columns" json:"columns"`
+	Unique      bool     `yaml:"unique" json:"unique"`
+	Type        string   `yaml:"type,omitempty" json:"type,omitempty"`
+	Expressions []string `yaml:"expressions,omitempty" json:"expressions,omitempty"`
+	Predicate   string   `yaml:"predicate,omitempty" json:"predicate,omitempty"`
 }
 
 // Constraint represents a check constraint or enum.
@@ -61,3 +106,16 @@ type Constraint struct {
 	Type       string `yaml:"type" json:"type"`
 	Definition string `yaml:"definition" json:"definition"`
 }
+
+// ChildCounts is the distribution of how many rows of a child table each
+// parent row has, measured in the source. Parents without children count
+// as zero; Children only counts child rows that have a parent.
+type ChildCounts struct {
+	Parents  int64   `yaml:"parents" json:"parents"`
+	Children int64   `yaml:"children" json:"children"`
+	Avg      float64 `yaml:"avg" json:"avg"`
+	P50      int64   `yaml:"p50" json:"p50"`
+	P90      int64   `yaml:"p90" json:"p90"`
+	P99      int64   `yaml:"p99" json:"p99"`
+	Max      int64   `yaml:"max" json:"max"`
+}
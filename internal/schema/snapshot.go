@@ -0,0 +1,229 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SnapshotDir is the directory, inside a project, that holds one schema
+// file per discovery.
+const SnapshotDir = "schema-snapshots"
+
+const snapshotIndex = "index.yaml"
+
+// ErrSnapshotNotFound is returned for a snapshot ID the project does not have.
+var ErrSnapshotNotFound = errors.New("schema snapshot not found")
+
+// ErrSnapshotDeleted is returned when a deleted snapshot is used as a
+// baseline. Restore it first.
+var ErrSnapshotDeleted = errors.New("schema snapshot is deleted")
+
+// SnapshotInfo describes one saved discovery.
+type SnapshotInfo struct {
+	ID        string     `yaml:"id" json:"id"`
+	CreatedAt time.Time  `yaml:"created_at" json:"created_at"`
+	Tables    int        `yaml:"tables" json:"tables"`
+	DeletedAt *time.Time `yaml:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// Deleted reports whether the snapshot has been soft-deleted.
+func (i SnapshotInfo) Deleted() bool {
+	return i.DeletedAt != nil
+}
+
+// Snapshots keeps every discovered schema of a project as a timestamped
+// file, with an index recording which ones are deleted. Deleting only marks
+// a snapshot, so it can be restored.
+type Snapshots struct {
+	dir string
+}
+
+// OpenSnapshots returns the snapshots of the project in projectDir.
+func OpenSnapshots(projectDir string) *Snapshots {
+	return &Snapshots{dir: filepath.Join(projectDir, SnapshotDir)}
+}
+
+// Path returns the schema file of a snapshot.
+func (s *Snapshots) Path(id string) string {
+	return filepath.Join(s.dir, id+".yaml")
+}
+
+// Contains reports whether path is one of the snapshot files.
+func (s *Snapshots) Contains(path string) bool {
+	return filepath.Clean(filepath.Dir(path)) == filepath.Clean(s.dir)
+}
+
+// Record saves a newly discovered schema as a snapshot. previous is the
+// schema file in use before it; a file saved before the project had
+// snapshots is kept as the first one, so its discovery is not lost.
+func (s *Snapshots) Record(sch *Schema, previous string) (SnapshotInfo, error) {
+	if previous != "" && !s.Contains(previous) {
+		index, err := s.load()
+		if err != nil {
+			return SnapshotInfo{}, err
+		}
+		if len(index) == 0 {
+			if old, err := LoadYAML(previous); err == nil {
+				at := time.Now()
+				if fi, err := os.Stat(previous); err == nil {
+					at = fi.ModTime()
+				}
+				if _, err := s.Add(old, at); err != nil {
+					return SnapshotInfo{}, err
+				}
+			}
+		}
+	}
+	return s.Add(sch, time.Now())
+}
+
+// Add saves sch as a snapshot taken at the given time.
+func (s *Snapshots) Add(sch *Schema, at time.Time) (SnapshotInfo, error) {
+	index, err := s.load()
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+	info := SnapshotInfo{ID: s.newID(index, at), CreatedAt: at.UTC(), Tables: len(sch.Tables)}
+	if err := sch.WriteYAML(s.Path(info.ID)); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("saving schema snapshot: %w", err)
+	}
+	index = append(index, info)
+	return info, s.save(index)
+}
+
+// newID names a snapshot after its time, with a suffix when two discoveries
+// land in the same second.
+func (s *Snapshots) newID(index []SnapshotInfo, at time.Time) string {
+	base := at.UTC().Format("20060102T150405Z")
+	id := base
+	for n := 2; ; n++ {
+		taken := false
+		for _, info := range index {
+			if info.ID == id {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// List returns the snapshots, newest first. Deleted ones are included only
+// when includeDeleted is set.
+func (s *Snapshots) List(includeDeleted bool) ([]SnapshotInfo, error) {
+	index, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	list := []SnapshotInfo{}
+	for _, info := range index {
+		if includeDeleted || !info.Deleted() {
+			list = append(list, info)
+		}
+	}
+	sort.SliceStable(list, func(a, b int) bool { return list[a].CreatedAt.After(list[b].CreatedAt) })
+	return list, nil
+}
+
+// Latest returns the newest snapshot that is not deleted; ok is false when
+// there is none.
+func (s *Snapshots) Latest() (info SnapshotInfo, ok bool, err error) {
+	list, err := s.List(false)
+	if err != nil || len(list) == 0 {
+		return SnapshotInfo{}, false, err
+	}
+	return list[0], true, nil
+}
+
+// Get returns a snapshot's details, deleted or not.
+func (s *Snapshots) Get(id string) (SnapshotInfo, error) {
+	index, err := s.load()
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+	for _, info := range index {
+		if info.ID == id {
+			return info, nil
+		}
+	}
+	return SnapshotInfo{}, fmt.Errorf("%w: %s", ErrSnapshotNotFound, id)
+}
+
+// Load reads a snapshot's schema. Deleted snapshots can still be read, so
+// they can be compared before being restored.
+func (s *Snapshots) Load(id string) (*Schema, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	return LoadYAML(s.Path(id))
+}
+
+// Delete marks a snapshot deleted. Its file is kept.
+func (s *Snapshots) Delete(id string, at time.Time) error {
+	return s.update(id, func(info *SnapshotInfo) {
+		if info.DeletedAt == nil {
+			t := at.UTC()
+			info.DeletedAt = &t
+		}
+	})
+}
+
+// Restore undoes Delete.
+func (s *Snapshots) Restore(id string) error {
+	return s.update(id, func(info *SnapshotInfo) { info.DeletedAt = nil })
+}
+
+func (s *Snapshots) update(id string, fn func(*SnapshotInfo)) error {
+	index, err := s.load()
+	if err != nil {
+		return err
+	}
+	for i := range index {
+		if index[i].ID == id {
+			fn(&index[i])
+			return s.save(index)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrSnapshotNotFound, id)
+}
+
+func (s *Snapshots) load() ([]SnapshotInfo, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, snapshotIndex))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading schema snapshot index: %w", err)
+	}
+	var index []SnapshotInfo
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("parsing schema snapshot index: %w", err)
+	}
+	return index, nil
+}
+
+// save writes the index through a temporary file, so an interrupted write
+// never leaves it truncated.
+func (s *Snapshots) save(index []SnapshotInfo) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("creating snapshot directory: %w", err)
+	}
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("marshaling schema snapshot index: %w", err)
+	}
+	path := filepath.Join(s.dir, snapshotIndex)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("writing schema snapshot index: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	snaps := OpenSnapshots(dir)
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	first, err := snaps.Add(&Schema{Tables: []Table{{Name: "users"}}}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := snaps.Add(&Schema{Tables: []Table{{Name: "users"}, {Name: "orders"}}}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID != "20260501T120000Z" || second.ID != "20260501T120000Z-2" {
+		t.Errorf("IDs = %q, %q", first.ID, second.ID)
+	}
+	if second.Tables != 2 {
+		t.Errorf("tables = %d, want 2", second.Tables)
+	}
+
+	third, _ := snaps.Add(&Schema{}, at.Add(time.Hour))
+	list, _ := snaps.List(false)
+	if len(list) != 3 || list[0].ID != third.ID {
+		t.Fatalf("list = %+v", list)
+	}
+
+	if err := snaps.Delete(third.ID, at); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := snaps.List(false); len(list) != 2 {
+		t.Errorf("deleted snapshot listed: %+v", list)
+	}
+	if list, _ := snaps.List(true); len(list) != 3 || !list[0].Deleted() {
+		t.Errorf("list with deleted = %+v", list)
+	}
+	if latest, ok, _ := snaps.Latest(); !ok || latest.ID != first.ID && latest.ID != second.ID {
+		t.Errorf("latest = %+v, %v", latest, ok)
+	}
+	if _, err := snaps.Load(third.ID); err != nil {
+		t.Errorf("deleted snapshot should still load: %v", err)
+	}
+
+	if err := snaps.Restore(third.ID); err != nil {
+		t.Fatal(err)
+	}
+	if latest, _, _ := snaps.Latest(); latest.ID != third.ID {
+		t.Errorf("latest after restore = %q", latest.ID)
+	}
+
+	if _, err := snaps.Load("missing"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("Load(missing) error = %v", err)
+	}
+	if err := snaps.Delete("missing", at); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("Delete(missing) error = %v", err)
+	}
+}
+
+func TestSnapshots_RecordKeepsEarlierSchema(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "source-schema.yaml")
+	if err := (&Schema{Tables: []Table{{Name: "users"}}}).WriteYAML(legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	snaps := OpenSnapshots(dir)
+	info, err := snaps.Record(&Schema{Tables: []Table{{Name: "users"}, {Name: "orders"}}}, legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, _ := snaps.List(false)
+	if len(list) != 2 || list[0].ID != info.ID || list[1].Tables != 1 {
+		t.Fatalf("list = %+v", list)
+	}
+	if !snaps.Contains(snaps.Path(info.ID)) || snaps.Contains(legacy) {
+		t.Error("Contains should match snapshot files only")
+	}
+
+	// Later discoveries do not import it again
+	if _, err := snaps.Record(&Schema{}, snaps.Path(info.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := snaps.List(false); len(list) != 3 {
+		t.Errorf("snapshots = %d, want 3", len(list))
+	}
+}
@@ -0,0 +1,316 @@
+// Package selfupdate replaces the running reloquent binary with a release
+// published on GitHub.
+//
+// Releases are built by goreleaser: each carries one archive per platform,
+// named reloquent_<version>_<os>_<arch>.tar.gz (.zip on Windows), and a
+// checksums.txt listing the SHA-256 of every archive. An archive is only
+// unpacked once its checksum matches.
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// DefaultAPIURL lists the releases of the reloquent repository.
+const DefaultAPIURL = "https://api.github.com/repos/reloquent/reloquent/releases"
+
+// checksumsAsset is the goreleaser checksum file of a release.
+const checksumsAsset = "checksums.txt"
+
+// maxDownload bounds any one download.
+const maxDownload = 512 << 20
+
+// ErrChecksumMismatch is returned when a downloaded archive does not match
+// the release's checksums.txt.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Release is a published release.
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Updater finds and downloads releases for one platform.
+type Updater struct {
+	Client *http.Client
+	APIURL string // releases endpoint; default DefaultAPIURL
+	OS     string // default runtime.GOOS
+	Arch   string // default runtime.GOARCH
+}
+
+// New returns an updater for the running platform.
+func New() *Updater {
+	return &Updater{
+		Client: http.DefaultClient,
+		APIURL: DefaultAPIURL,
+		OS:     runtime.GOOS,
+		Arch:   runtime.GOARCH,
+	}
+}
+
+// Latest returns the newest published release.
+func (u *Updater) Latest(ctx context.Context) (*Release, error) {
+	return u.release(ctx, u.APIURL+"/latest")
+}
+
+// Tagged returns the release with the given tag, such as v1.4.0.
+func (u *Updater) Tagged(ctx context.Context, tag string) (*Release, error) {
+	if !strings.HasPrefix(tag, "v") {
+		tag = "v" + tag
+	}
+	return u.release(ctx, u.APIURL+"/tags/"+tag)
+}
+
+func (u *Updater) release(ctx context.Context, url string) (*Release, error) {
+	data, err := u.get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("finding release: %w", err)
+	}
+	rel := &Release{}
+	if err := json.Unmarshal(data, rel); err != nil {
+		return nil, fmt.Errorf("parsing release: %w", err)
+	}
+	if rel.Tag == "" {
+		return nil, fmt.Errorf("parsing release: no tag")
+	}
+	return rel, nil
+}
+
+// ArchiveName is the goreleaser archive of version for a platform.
+func ArchiveName(version, goos, goarch string) string {
+	ext := "tar.gz"
+	if goos == "windows" {
+		ext = "zip"
+	}
+	return fmt.Sprintf("reloquent_%s_%s_%s.%s", strings.TrimPrefix(version, "v"), goos, goarch, ext)
+}
+
+// Download fetches the release's archive for the updater's platform,
+// checks it against checksums.txt and returns the reloquent binary inside.
+func (u *Updater) Download(ctx context.Context, rel *Release) ([]byte, error) {
+	name := ArchiveName(rel.Tag, u.OS, u.Arch)
+	archive, ok := rel.asset(name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no build for %s/%s", rel.Tag, u.OS, u.Arch)
+	}
+	sums, ok := rel.asset(checksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s; refusing an unverified download", rel.Tag, checksumsAsset)
+	}
+
+	sumData, err := u.get(ctx, sums.URL)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", checksumsAsset, err)
+	}
+	want, err := checksumFor(sumData, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := u.get(ctx, archive.URL)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", name, err)
+	}
+	got := sha256.Sum256(data)
+	if hex.EncodeToString(got[:]) != want {
+		return nil, fmt.Errorf("%w: %s has sha256 %x, checksums.txt says %s", ErrChecksumMismatch, name, got, want)
+	}
+	return extractBinary(name, data)
+}
+
+func (rel *Release) asset(name string) (Asset, bool) {
+	for _, a := range rel.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+func (u *Updater) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDownload))
+}
+
+// checksumFor finds the SHA-256 of name in a checksums.txt.
+func checksumFor(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s is not listed in %s", name, checksumsAsset)
+}
+
+// extractBinary returns the reloquent executable from a release archive.
+func extractBinary(name string, data []byte) ([]byte, error) {
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("opening %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if path.Base(f.Name) != "reloquent.exe" {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxDownload))
+		}
+		return nil, fmt.Errorf("%s does not contain reloquent.exe", name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s does not contain reloquent", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == "reloquent" {
+			return io.ReadAll(io.LimitReader(tr, maxDownload))
+		}
+	}
+}
+
+// Replace swaps the executable at exe for binary. The new file is written
+// beside it and renamed into place, so an interrupted update leaves the old
+// binary working.
+func Replace(exe string, binary []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return fmt.Errorf("finding current binary: %w", err)
+	}
+	dir := filepath.Dir(exe)
+	tmp, err := os.CreateTemp(dir, ".reloquent-update-*")
+	if err != nil {
+		return fmt.Errorf("writing new binary (is %s writable?): %w", dir, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return fmt.Errorf("writing new binary: %w", err)
+	}
+
+	// Windows cannot overwrite a running executable, but can rename it.
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return fmt.Errorf("moving current binary aside: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		os.Rename(old, exe)
+		return fmt.Errorf("installing new binary: %w", err)
+	}
+	os.Remove(old) // fails harmlessly on Windows while the old binary runs
+	return nil
+}
+
+// Compare orders two release versions such as v1.4.0 and 1.5.0-rc.1,
+// returning -1, 0 or 1. A pre-release sorts before its release.
+func Compare(a, b string) (int, error) {
+	pa, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range 3 {
+		if pa.nums[i] != pb.nums[i] {
+			if pa.nums[i] < pb.nums[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	switch {
+	case pa.pre == pb.pre:
+		return 0, nil
+	case pa.pre == "":
+		return 1, nil
+	case pb.pre == "":
+		return -1, nil
+	case pa.pre < pb.pre:
+		return -1, nil
+	default:
+		return 1, nil
+	}
+}
+
+type version struct {
+	nums [3]int
+	pre  string
+}
+
+func parseVersion(v string) (version, error) {
+	s := strings.TrimPrefix(v, "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, _ := strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return version{}, fmt.Errorf("invalid version %q", v)
+	}
+	var out version
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return version{}, fmt.Errorf("invalid version %q", v)
+		}
+		out.nums[i] = n
+	}
+	out.pre = pre
+	return out, nil
+}
@@ -0,0 +1,190 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tarball(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{{"README.md", []byte("readme")}, {name, content}} {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0o755, Size: int64(len(f.data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(f.data)
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// releaseServer serves a v1.5.0 release for linux/amd64. When tamper is set,
+// the archive no longer matches checksums.txt.
+func releaseServer(t *testing.T, tamper bool) *httptest.Server {
+	t.Helper()
+	archiveName := ArchiveName("v1.5.0", "linux", "amd64")
+	archive := tarball(t, "reloquent", []byte("new binary"))
+	sum := sha256.Sum256(archive)
+	if tamper {
+		archive = tarball(t, "reloquent", []byte("evil binary"))
+	}
+
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	release := func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Release{Tag: "v1.5.0", Assets: []Asset{
+			{Name: archiveName, URL: srv.URL + "/download/" + archiveName},
+			{Name: "checksums.txt", URL: srv.URL + "/download/checksums.txt"},
+		}})
+	}
+	mux.HandleFunc("/releases/latest", release)
+	mux.HandleFunc("/releases/tags/v1.5.0", release)
+	mux.HandleFunc("/download/"+archiveName, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	mux.HandleFunc("/download/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%x  reloquent_1.5.0_darwin_arm64.tar.gz\n%x  %s\n", sha256.Sum256(nil), sum, archiveName)
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testUpdater(srv *httptest.Server) *Updater {
+	return &Updater{Client: srv.Client(), APIURL: srv.URL + "/releases", OS: "linux", Arch: "amd64"}
+}
+
+func TestDownload(t *testing.T) {
+	u := testUpdater(releaseServer(t, false))
+	ctx := context.Background()
+
+	rel, err := u.Latest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Tag != "v1.5.0" {
+		t.Errorf("tag = %q", rel.Tag)
+	}
+	bin, err := u.Download(ctx, rel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bin) != "new binary" {
+		t.Errorf("binary = %q", bin)
+	}
+
+	if _, err := u.Tagged(ctx, "1.5.0"); err != nil {
+		t.Errorf("Tagged without the v prefix: %v", err)
+	}
+	if _, err := u.Tagged(ctx, "v9.9.9"); err == nil {
+		t.Error("missing tag should fail")
+	}
+
+	u.Arch = "riscv64"
+	if _, err := u.Download(ctx, rel); err == nil {
+		t.Error("missing platform build should fail")
+	}
+}
+
+func TestDownload_ChecksumMismatch(t *testing.T) {
+	u := testUpdater(releaseServer(t, true))
+	rel, err := u.Latest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Download(context.Background(), rel); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("err = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestDownload_NoChecksums(t *testing.T) {
+	u := testUpdater(releaseServer(t, false))
+	name := ArchiveName("v1.5.0", "linux", "amd64")
+	rel := &Release{Tag: "v1.5.0", Assets: []Asset{{Name: name, URL: u.APIURL}}}
+	if _, err := u.Download(context.Background(), rel); err == nil {
+		t.Error("a release without checksums.txt should be refused")
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	tests := []struct {
+		version, goos, goarch, want string
+	}{
+		{"v1.2.3", "linux", "amd64", "reloquent_1.2.3_linux_amd64.tar.gz"},
+		{"1.2.3", "darwin", "arm64", "reloquent_1.2.3_darwin_arm64.tar.gz"},
+		{"v1.2.3", "windows", "amd64", "reloquent_1.2.3_windows_amd64.zip"},
+	}
+	for _, tt := range tests {
+		if got := ArchiveName(tt.version, tt.goos, tt.goarch); got != tt.want {
+			t.Errorf("ArchiveName(%s, %s, %s) = %s, want %s", tt.version, tt.goos, tt.goarch, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		want    int
+		wantErr bool
+	}{
+		{a: "v1.2.3", b: "1.2.3", want: 0},
+		{a: "v1.2.3", b: "v1.10.0", want: -1},
+		{a: "v2.0.0", b: "v1.99.99", want: 1},
+		{a: "v1.5.0-rc.1", b: "v1.5.0", want: -1},
+		{a: "v1.5.0-rc.2", b: "v1.5.0-rc.1", want: 1},
+		{a: "v1.5.0+build.7", b: "v1.5.0", want: 0},
+		{a: "dev", b: "v1.5.0", wantErr: true},
+		{a: "v1.5", b: "v1.5.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Compare(tt.a, tt.b)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Compare(%s, %s) should fail", tt.a, tt.b)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, %v; want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	exe := filepath.Join(t.TempDir(), "reloquent")
+	if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(exe, []byte("new binary")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(exe)
+	if string(data) != "new binary" {
+		t.Errorf("binary = %q", data)
+	}
+	info, _ := os.Stat(exe)
+	if info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("mode = %v, want executable", info.Mode())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	if len(entries) != 1 {
+		t.Errorf("leftover files: %v", entries)
+	}
+}
@@ -0,0 +1,97 @@
+package sizing
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func TestStorageFactor(t *testing.T) {
+	tests := []struct {
+		name        string
+		total       int64
+		collections []CollectionStorage
+		want        float64
+	}{
+		{"no collections", gbToBytes(100), nil, 1.0},
+		{"default compressor", gbToBytes(100), []CollectionStorage{{SourceBytes: gbToBytes(100)}}, 1.0},
+		{"all zstd", gbToBytes(100), []CollectionStorage{{SourceBytes: gbToBytes(100), BlockCompressor: "zstd"}}, 0.75},
+		{"half none", gbToBytes(100), []CollectionStorage{{SourceBytes: gbToBytes(50), BlockCompressor: "none"}}, 1.4},
+		{"zero total", 0, []CollectionStorage{{SourceBytes: 10, BlockCompressor: "zstd"}}, 1.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := storageFactor(tt.total, tt.collections); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("storageFactor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculate_CompressionReducesStorage(t *testing.T) {
+	base := Input{TotalDataBytes: gbToBytes(500), TotalRowCount: 10_000_000, CollectionCount: 1}
+	zstd := base
+	zstd.Storage = []CollectionStorage{{Collection: "orders", SourceBytes: gbToBytes(500), BlockCompressor: "zstd"}}
+
+	plain := Calculate(base)
+	compressed := Calculate(zstd)
+
+	if compressed.MongoPlan.StorageGB >= plain.MongoPlan.StorageGB {
+		t.Errorf("zstd storage %d GB should be below default %d GB",
+			compressed.MongoPlan.StorageGB, plain.MongoPlan.StorageGB)
+	}
+	found := false
+	for _, e := range compressed.Explanations {
+		if e.Category == "storage" && strings.Contains(e.Detail, "zstd (orders)") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected a storage explanation naming the zstd collection")
+	}
+	for _, e := range plain.Explanations {
+		if e.Category == "storage" {
+			t.Error("default compression should not add a storage explanation")
+		}
+	}
+}
+
+func TestStorageInputs(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "orders", SizeBytes: 100},
+		{Name: "order_items", SizeBytes: 40, RowCount: 20},
+		{Name: "products", SizeBytes: 10},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{
+			Name:        "orders",
+			SourceTable: "orders",
+			Embedded:    []mapping.Embedded{{SourceTable: "order_items", FieldName: "items"}},
+			Storage:     &mapping.StorageOptions{BlockCompressor: "zstd"},
+		},
+		{Name: "products", SourceTable: "products"},
+	}}
+
+	got := StorageInputs(m, s, nil)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 inputs, got %d", len(got))
+	}
+	if got[0].SourceBytes != 140 || got[0].BlockCompressor != "zstd" {
+		t.Errorf("orders = %+v", got[0])
+	}
+	if got[1].SourceBytes != 10 || got[1].BlockCompressor != "" {
+		t.Errorf("products = %+v", got[1])
+	}
+	if StorageInputs(nil, s, nil) != nil {
+		t.Error("expected nil without a mapping")
+	}
+
+	// only 5 of the 20 items belong to a migrated order
+	counts := mapping.EmbedCounts{mapping.EmbedKey("orders", "items"): {Parents: 5, Children: 5, Avg: 1, Max: 1}}
+	if got := StorageInputs(m, s, counts); got[0].SourceBytes != 110 {
+		t.Errorf("orders with measured items = %+v, want 110 source bytes", got[0])
+	}
+}
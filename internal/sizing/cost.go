@@ -0,0 +1,227 @@
+package sizing
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// AWS list prices (us-east-1, on demand, USD) used to price a migration run.
+const (
+	// emrPrimaryPricePerHour is the m5.xlarge primary node, EC2 plus EMR.
+	emrPrimaryPricePerHour = 0.24
+	s3PricePerGBMonth      = 0.023
+	// transferPricePerGB is cross-AZ transfer, paid once reading the source
+	// and once writing the target.
+	transferPricePerGB = 0.01
+
+	// s3ArtifactGB covers the script, JDBC and connector jars, and Spark logs
+	// kept in the artifact bucket for a month.
+	s3ArtifactGB = 1.0
+
+	emrStartup  = 10 * time.Minute
+	glueStartup = time.Minute
+	// glueMinimumBilled is Glue's minimum billed duration per job run.
+	glueMinimumBilled = time.Minute
+)
+
+// emrInstancePricePerHour is the hourly EC2 price plus the EMR uplift of
+// each worker instance type the brackets recommend.
+var emrInstancePricePerHour = map[string]float64{
+	"r5.4xlarge":  1.008 + 0.252,
+	"r5.8xlarge":  2.016 + 0.270,
+	"r5.12xlarge": 3.024 + 0.270,
+}
+
+// Estimate is the wall-clock duration and AWS cost of running the migration
+// on a Spark cluster of a given size. Units are EMR workers or Glue DPUs.
+// The recommended cluster is assumed to keep pace with the throughput, so
+// fewer units slow the run proportionally while more cannot outrun the
+// source read or target write rate.
+type Estimate struct {
+	DataBytes        int64         `yaml:"data_bytes" json:"data_bytes"`
+	ThroughputMBps   float64       `yaml:"throughput_mbps" json:"throughput_mbps"`
+	RecommendedUnits int           `yaml:"recommended_units" json:"recommended_units"`
+	Units            int           `yaml:"units" json:"units"`
+	Duration         time.Duration `yaml:"duration" json:"duration"`
+	ComputeHours     float64       `yaml:"compute_hours" json:"compute_hours"`
+	ComputeCost      float64       `yaml:"compute_cost" json:"compute_cost"`
+	S3Cost           float64       `yaml:"s3_cost" json:"s3_cost"`
+	TransferCost     float64       `yaml:"transfer_cost" json:"transfer_cost"`
+	TotalCost        float64       `yaml:"total_cost" json:"total_cost"`
+}
+
+// estimateRun prices moving dataBytes at throughputMBps on the Spark plan's
+// cluster, which the sizing brackets recommended at recommended units.
+func estimateRun(spark SparkPlan, dataBytes int64, throughputMBps float64, recommended int) Estimate {
+	units := sparkUnits(spark)
+	est := Estimate{
+		DataBytes:        dataBytes,
+		ThroughputMBps:   throughputMBps,
+		RecommendedUnits: recommended,
+		Units:            units,
+	}
+	if units <= 0 || throughputMBps <= 0 {
+		return est
+	}
+
+	rate := throughputMBps * math.Min(1, float64(units)/float64(recommended))
+	run := time.Duration(float64(dataBytes)/(rate*1024*1024)) * time.Second
+
+	est.Duration = run + startup(spark)
+	hours := 0.0
+	if spark.Platform == "glue" {
+		billed := est.Duration
+		if billed < glueMinimumBilled {
+			billed = glueMinimumBilled
+		}
+		hours = billed.Hours()
+		est.ComputeHours = float64(units) * hours
+		est.ComputeCost = est.ComputeHours * gluePricePerDPUHour
+	} else {
+		hours = est.Duration.Hours()
+		est.ComputeHours = float64(units) * hours
+		est.ComputeCost = est.ComputeHours*emrInstancePricePerHour[spark.InstanceType] + hours*emrPrimaryPricePerHour
+	}
+
+	gb := float64(dataBytes) / (1024 * 1024 * 1024)
+	est.S3Cost = s3ArtifactGB * s3PricePerGBMonth
+	est.TransferCost = 2 * gb * transferPricePerGB
+	est.TotalCost = est.ComputeCost + est.S3Cost + est.TransferCost
+	return est
+}
+
+// startup is how long the platform takes to start a job before it moves data.
+func startup(spark SparkPlan) time.Duration {
+	if spark.Platform == "glue" {
+		return glueStartup
+	}
+	return emrStartup
+}
+
+// sparkUnits returns the DPUs of a Glue plan or the workers of an EMR plan.
+func sparkUnits(spark SparkPlan) int {
+	if spark.Platform == "glue" {
+		return spark.DPUCount
+	}
+	return spark.WorkerCount
+}
+
+// UnitName returns what the plan's cluster is sized in: DPUs or workers.
+func (sp *SizingPlan) UnitName() string {
+	if sp.SparkPlan.Platform == "glue" {
+		return "DPUs"
+	}
+	return "workers"
+}
+
+// MinUnits is the smallest cluster a plan can be resized to; Glue jobs need
+// at least two DPUs.
+func (sp *SizingPlan) MinUnits() int {
+	if sp.SparkPlan.Platform == "glue" {
+		return 2
+	}
+	return 1
+}
+
+// Resize changes the plan's Spark cluster to units workers or DPUs and
+// re-estimates the duration and cost of the run, scaling the bracket cost
+// range with it.
+func (sp *SizingPlan) Resize(units int) error {
+	if sp.Estimate == nil {
+		return fmt.Errorf("sizing plan has no run estimate")
+	}
+	if units < sp.MinUnits() {
+		return fmt.Errorf("at least %d %s required", sp.MinUnits(), sp.UnitName())
+	}
+	old := sparkUnits(sp.SparkPlan)
+	if units == old {
+		return nil
+	}
+
+	spark := &sp.SparkPlan
+	if spark.Platform == "glue" {
+		spark.DPUCount = units
+	} else {
+		spark.WorkerCount = units
+	}
+	if old > 0 {
+		scale := float64(units) / float64(old)
+		spark.CostLow *= scale
+		spark.CostHigh *= scale
+		spark.CostEstimate = fmt.Sprintf("$%.0f-$%.0f", spark.CostLow, spark.CostHigh)
+	}
+
+	est := estimateRun(*spark, sp.Estimate.DataBytes, sp.Estimate.ThroughputMBps, sp.Estimate.RecommendedUnits)
+	sp.Estimate = &est
+	sp.EstimatedTime = est.Duration - startup(*spark)
+	for i := range sp.Explanations {
+		if sp.Explanations[i].Category == "cost" {
+			sp.Explanations[i] = costExplanation(*spark, est)
+		}
+	}
+	return nil
+}
+
+// costExplanation summarizes the run estimate in plain language.
+func costExplanation(spark SparkPlan, est Estimate) Explanation {
+	unit := "workers"
+	if spark.Platform == "glue" {
+		unit = "DPUs"
+	}
+	detail := fmt.Sprintf(
+		"Running %d %s for %s, including cluster startup, costs about $%.2f in compute (%.1f %s-hours). "+
+			"Staging the script, jars and logs in S3 adds $%.2f, and moving %s across availability zones "+
+			"to and from the cluster adds $%.2f in data transfer. ",
+		est.Units, unit, FormatDuration(est.Duration), est.ComputeCost, est.ComputeHours, singular(unit),
+		est.S3Cost, FormatBytes(est.DataBytes), est.TransferCost)
+	if est.Units < est.RecommendedUnits {
+		detail += fmt.Sprintf("With fewer than the recommended %d %s the cluster, not the source or target, limits the run.",
+			est.RecommendedUnits, unit)
+	} else {
+		detail += "Adding more capacity would not finish sooner: the source read or target write rate already limits the run, " +
+			"like adding lanes to a highway that ends at a one-lane bridge."
+	}
+	return Explanation{
+		Category: "cost",
+		Summary:  fmt.Sprintf("Estimated run: %s, $%.2f total", FormatDuration(est.Duration), est.TotalCost),
+		Detail:   detail,
+	}
+}
+
+// costSteps records how the run estimate was priced.
+func costSteps(spark SparkPlan, est Estimate) ([]Value, []string) {
+	steps := []Value{{
+		Name:  "Run duration",
+		Value: FormatDuration(est.Duration),
+		Formula: fmt.Sprintf("target size ÷ throughput + startup = %s ÷ %.0f MB/s + %s",
+			FormatBytes(est.DataBytes), est.ThroughputMBps, FormatDuration(startup(spark))),
+	}}
+	var compute string
+	if spark.Platform == "glue" {
+		compute = fmt.Sprintf("DPU-hours × price = %.1f × $%.2f", est.ComputeHours, gluePricePerDPUHour)
+	} else {
+		compute = fmt.Sprintf("worker-hours × price + primary = %.1f × $%.2f + %.1f h × $%.2f",
+			est.ComputeHours, emrInstancePricePerHour[spark.InstanceType], est.ComputeHours/float64(est.Units), emrPrimaryPricePerHour)
+	}
+	steps = append(steps,
+		Value{Name: "Run compute cost", Value: fmt.Sprintf("$%.2f", est.ComputeCost), Formula: compute},
+		Value{Name: "Run S3 cost", Value: fmt.Sprintf("$%.2f", est.S3Cost),
+			Formula: fmt.Sprintf("artifacts × price = %.0f GB × $%.3f per GB-month", s3ArtifactGB, s3PricePerGBMonth)},
+		Value{Name: "Run data transfer cost", Value: fmt.Sprintf("$%.2f", est.TransferCost),
+			Formula: fmt.Sprintf("2 × target size × price = 2 × %s × $%.2f per GB", FormatBytes(est.DataBytes), transferPricePerGB)},
+	)
+	assumptions := []string{
+		"Run costs use us-east-1 on-demand list prices; the EMR cluster adds one m5.xlarge primary node.",
+		fmt.Sprintf("Cluster startup takes %s on EMR and %s on Glue, and is billed.", FormatDuration(emrStartup), FormatDuration(glueStartup)),
+		"The source, cluster and target share a region, so data crosses availability zones once in and once out.",
+	}
+	return steps, assumptions
+}
+
+func singular(unit string) string {
+	if unit == "DPUs" {
+		return "DPU"
+	}
+	return "worker"
+}
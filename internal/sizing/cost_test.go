@@ -0,0 +1,105 @@
+package sizing
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculate_Estimate(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        Input
+		platform     string
+		wantDuration time.Duration
+		wantCompute  float64
+	}{
+		{
+			// 10 GB × 1.4 at 50 MB/s is 286s, plus a minute of startup.
+			name:         "glue",
+			input:        Input{TotalDataBytes: gbToBytes(10), DenormExpansionFactor: 1.4},
+			platform:     "glue",
+			wantDuration: 286*time.Second + glueStartup,
+			wantCompute:  10 * (286*time.Second + glueStartup).Hours() * gluePricePerDPUHour,
+		},
+		{
+			// 1 TB at 100 MB/s is 10486s, plus ten minutes of startup.
+			name:         "emr",
+			input:        Input{TotalDataBytes: tbToBytes(1), DenormExpansionFactor: 1, BenchmarkMBps: 100},
+			platform:     "emr",
+			wantDuration: 10485*time.Second + emrStartup,
+			wantCompute:  (10*1.26 + 0.24) * (10485*time.Second + emrStartup).Hours(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := Calculate(tt.input)
+			est := plan.Estimate
+			if plan.SparkPlan.Platform != tt.platform || est == nil {
+				t.Fatalf("platform = %s, estimate = %+v", plan.SparkPlan.Platform, est)
+			}
+			if d := est.Duration - tt.wantDuration; d < -time.Second || d > time.Second {
+				t.Errorf("duration = %s, want %s", est.Duration, tt.wantDuration)
+			}
+			if math.Abs(est.ComputeCost-tt.wantCompute) > 0.01 {
+				t.Errorf("compute cost = %.2f, want %.2f", est.ComputeCost, tt.wantCompute)
+			}
+			if est.S3Cost <= 0 || est.TransferCost <= 0 {
+				t.Errorf("S3 cost = %.2f, transfer cost = %.2f, want both set", est.S3Cost, est.TransferCost)
+			}
+			if total := est.ComputeCost + est.S3Cost + est.TransferCost; math.Abs(est.TotalCost-total) > 1e-9 {
+				t.Errorf("total = %.2f, want the sum %.2f", est.TotalCost, total)
+			}
+			if est.Units != est.RecommendedUnits {
+				t.Errorf("units = %d, want the recommended %d", est.Units, est.RecommendedUnits)
+			}
+		})
+	}
+}
+
+func TestResize(t *testing.T) {
+	plan := Calculate(Input{TotalDataBytes: tbToBytes(1), DenormExpansionFactor: 1, BenchmarkMBps: 100})
+	base := *plan.Estimate
+	baseLow := plan.SparkPlan.CostLow
+
+	// Half the workers halve the processing rate and double the run.
+	if err := plan.Resize(base.Units / 2); err != nil {
+		t.Fatalf("Resize: %v", err)
+	}
+	if plan.SparkPlan.WorkerCount != base.Units/2 || plan.Estimate.Units != base.Units/2 {
+		t.Errorf("workers = %d, estimate units = %d, want %d", plan.SparkPlan.WorkerCount, plan.Estimate.Units, base.Units/2)
+	}
+	if d := plan.EstimatedTime - 2*(base.Duration-emrStartup); d < -2*time.Second || d > 2*time.Second {
+		t.Errorf("estimated time = %s, want double %s", plan.EstimatedTime, base.Duration-emrStartup)
+	}
+	if math.Abs(plan.SparkPlan.CostLow-baseLow/2) > 0.01 {
+		t.Errorf("cost low = %.2f, want %.2f", plan.SparkPlan.CostLow, baseLow/2)
+	}
+	for _, exp := range plan.Explanations {
+		if exp.Category == "cost" && exp.Summary != costExplanation(plan.SparkPlan, *plan.Estimate).Summary {
+			t.Errorf("cost explanation not refreshed: %s", exp.Summary)
+		}
+	}
+
+	// Twice the workers cannot outrun the throughput, so only cost rises.
+	if err := plan.Resize(base.Units * 2); err != nil {
+		t.Fatalf("Resize: %v", err)
+	}
+	if plan.Estimate.Duration != base.Duration {
+		t.Errorf("duration = %s, want %s", plan.Estimate.Duration, base.Duration)
+	}
+	if plan.Estimate.ComputeCost <= base.ComputeCost {
+		t.Errorf("compute cost = %.2f, want more than %.2f", plan.Estimate.ComputeCost, base.ComputeCost)
+	}
+
+	if err := plan.Resize(0); err == nil {
+		t.Error("expected an error resizing to no workers")
+	}
+	glue := Calculate(Input{TotalDataBytes: gbToBytes(10)})
+	if err := glue.Resize(1); err == nil {
+		t.Error("expected an error resizing Glue below two DPUs")
+	}
+	if err := (&SizingPlan{}).Resize(5); err == nil {
+		t.Error("expected an error resizing a plan without an estimate")
+	}
+}
@@ -0,0 +1,189 @@
+package sizing
+
+import (
+	"fmt"
+	"time"
+)
+
+// Derivation shows how a sizing plan was computed so the recommendation can
+// be checked by hand: the inputs it started from, each intermediate value
+// with the formula that produced it, the assumptions behind the constants,
+// and the safety margins applied on top.
+type Derivation struct {
+	Inputs        []Value        `yaml:"inputs" json:"inputs"`
+	Steps         []Value        `yaml:"steps" json:"steps"`
+	Assumptions   []string       `yaml:"assumptions" json:"assumptions"`
+	SafetyMargins []SafetyMargin `yaml:"safety_margins" json:"safety_margins"`
+}
+
+// Value is a named quantity in a derivation. Inputs carry the Source they
+// came from; steps carry the Formula, with the numbers substituted in.
+type Value struct {
+	Name    string `yaml:"name" json:"name"`
+	Value   string `yaml:"value" json:"value"`
+	Source  string `yaml:"source,omitempty" json:"source,omitempty"`
+	Formula string `yaml:"formula,omitempty" json:"formula,omitempty"`
+}
+
+// SafetyMargin is a deliberate over-provisioning applied to a recommendation.
+type SafetyMargin struct {
+	Name   string `yaml:"name" json:"name"`
+	Margin string `yaml:"margin" json:"margin"`
+	Reason string `yaml:"reason" json:"reason"`
+}
+
+// derive records the inputs, formulas and margins behind a plan. orig is the
+// input as given, before defaults were filled in.
+func derive(orig Input, estimatedBytes int64, spark SparkPlan, mongo MongoPlan, factor float64, estTime time.Duration) *Derivation {
+	d := &Derivation{}
+
+	expansion, expansionSrc := orig.DenormExpansionFactor, "input"
+	if expansion == 0 {
+		expansion, expansionSrc = DefaultExpansionFactor, "default"
+	}
+	conns, connsSrc := orig.MaxSourceConnections, "input"
+	if conns == 0 {
+		conns, connsSrc = defaultSourceConns, "default"
+	}
+	throughput, throughputSrc := Throughput(orig)
+	factorSrc := "default"
+	if len(orig.Storage) > 0 {
+		factorSrc = "mapping"
+	}
+
+	d.Inputs = []Value{
+		{Name: "Source data size", Value: FormatBytes(orig.TotalDataBytes), Source: "schema"},
+		{Name: "Source rows", Value: fmt.Sprintf("%d", orig.TotalRowCount), Source: "schema"},
+		{Name: "Collections", Value: fmt.Sprintf("%d", orig.CollectionCount), Source: "mapping"},
+		{Name: "Denormalization expansion factor", Value: fmt.Sprintf("%.2fx", expansion), Source: expansionSrc},
+		{Name: "Max source connections", Value: fmt.Sprintf("%d", conns), Source: connsSrc},
+		{Name: "Throughput", Value: fmt.Sprintf("%.0f MB/s", throughput), Source: throughputSrc},
+		{Name: "Compression factor", Value: fmt.Sprintf("%.2fx", factor), Source: factorSrc},
+	}
+	if orig.TargetWriteMBps > 0 {
+		d.Inputs = append(d.Inputs, Value{Name: "Target write throughput", Value: fmt.Sprintf("%.0f MB/s", orig.TargetWriteMBps), Source: ThroughputWriteBenchmark})
+	}
+
+	d.Steps = append(d.Steps, Value{
+		Name:    "Estimated target size",
+		Value:   FormatBytes(estimatedBytes),
+		Formula: fmt.Sprintf("source size × expansion = %s × %.2f", FormatBytes(orig.TotalDataBytes), expansion),
+	})
+
+	if spark.Platform == "glue" {
+		b, ratio := glueBracketFor(estimatedBytes)
+		d.Steps = append(d.Steps,
+			Value{
+				Name:  "Glue bracket",
+				Value: fmt.Sprintf("%s-%s: %d-%d DPUs", FormatBytes(b.minBytes), FormatBytes(b.maxBytes), b.minDPU, b.maxDPU),
+				Formula: fmt.Sprintf("position in bracket = (%s − %s) / (%s − %s) = %.2f",
+					FormatBytes(estimatedBytes), FormatBytes(b.minBytes), FormatBytes(b.maxBytes), FormatBytes(b.minBytes), ratio),
+			},
+			Value{
+				Name:    "Glue DPUs",
+				Value:   fmt.Sprintf("%d", spark.DPUCount),
+				Formula: fmt.Sprintf("min + position × (max − min) = %d + %.2f × %d", b.minDPU, ratio, b.maxDPU-b.minDPU),
+			},
+			Value{
+				Name:  "Spark cost",
+				Value: spark.CostEstimate,
+				Formula: fmt.Sprintf("DPUs × $%.2f/DPU-hour × %d-%d hours = %d × %.2f × %d-%d",
+					gluePricePerDPUHour, glueMinHours, glueMaxHours, spark.DPUCount, gluePricePerDPUHour, glueMinHours, glueMaxHours),
+			},
+		)
+	} else {
+		b, ratio := emrBracketFor(estimatedBytes)
+		d.Steps = append(d.Steps,
+			Value{
+				Name:  "EMR bracket",
+				Value: fmt.Sprintf("%s-%s: %d-%d × %s", FormatBytes(b.minBytes), FormatBytes(b.maxBytes), b.minWorkers, b.maxWorkers, b.instanceType),
+				Formula: fmt.Sprintf("position in bracket = (%s − %s) / (%s − %s) = %.2f",
+					FormatBytes(estimatedBytes), FormatBytes(b.minBytes), FormatBytes(b.maxBytes), FormatBytes(b.minBytes), ratio),
+			},
+			Value{
+				Name:    "EMR workers",
+				Value:   fmt.Sprintf("%d", spark.WorkerCount),
+				Formula: fmt.Sprintf("min + position × (max − min) = %d + %.2f × %d", b.minWorkers, ratio, b.maxWorkers-b.minWorkers),
+			},
+			Value{
+				Name:  "Spark cost",
+				Value: spark.CostEstimate,
+				Formula: fmt.Sprintf("low = $%.0f + %.2f × $%.0f × 0.5, high = $%.0f + %.2f × $%.0f × %.1f (capped at $%.0f)",
+					b.costLow, ratio, b.costHigh-b.costLow, b.costLow, ratio, b.costHigh-b.costLow, emrCostHighMargin, b.costHigh),
+			},
+		)
+	}
+
+	d.Steps = append(d.Steps, Value{
+		Name:  "MongoDB storage",
+		Value: fmt.Sprintf("%d GB", mongo.StorageGB),
+		Formula: fmt.Sprintf("target size × overhead × compression = %s × %.1f × %.2f (minimum %d GB)",
+			FormatBytes(estimatedBytes), storageOverheadFactor, factor, minStorageGB),
+	})
+	_, migCeiling := migrationTierFor(estimatedBytes)
+	_, prodCeiling := productionTierFor(estimatedBytes)
+	d.Steps = append(d.Steps,
+		Value{
+			Name:    "Migration tier",
+			Value:   mongo.MigrationTier,
+			Formula: fmt.Sprintf("smallest migration tier sized for data under %s", FormatBytes(migCeiling)),
+		},
+		Value{
+			Name:    "Production tier",
+			Value:   mongo.ProductionTier,
+			Formula: fmt.Sprintf("smallest production tier sized for data under %s", FormatBytes(prodCeiling)),
+		},
+		Value{
+			Name:    "Migration time",
+			Value:   FormatDuration(estTime),
+			Formula: fmt.Sprintf("target size ÷ throughput = %s ÷ %.0f MB/s", FormatBytes(estimatedBytes), throughput),
+		},
+	)
+
+	d.Assumptions = []string{
+		fmt.Sprintf("Denormalization grows data by %.2fx: embedded rows repeat their join keys and every document carries BSON field names.", expansion),
+		fmt.Sprintf("AWS Glue is preferred up to %s because it needs no cluster management; larger migrations run on EMR.", FormatBytes(glueMaxRecommendedBytes)),
+		fmt.Sprintf("Glue jobs run for %d-%d hours at $%.2f per DPU-hour.", glueMinHours, glueMaxHours, gluePricePerDPUHour),
+		"Sizes use binary units (1 GB = 1024³ bytes).",
+	}
+	switch throughputSrc {
+	case ThroughputDefault:
+		d.Assumptions = append(d.Assumptions,
+			fmt.Sprintf("End-to-end throughput of %.0f MB/s; run the benchmark to replace it with a measured rate.", DefaultThroughputMBps))
+	case ThroughputWriteBenchmark:
+		d.Assumptions = append(d.Assumptions,
+			fmt.Sprintf("End-to-end throughput matches the measured target write rate of %.0f MB/s, the slower side of the migration.", throughput))
+	default:
+		d.Assumptions = append(d.Assumptions,
+			fmt.Sprintf("End-to-end throughput matches the measured source read rate of %.0f MB/s.", throughput))
+	}
+
+	d.SafetyMargins = []SafetyMargin{
+		{
+			Name:   "Storage overhead",
+			Margin: fmt.Sprintf("%.1fx", storageOverheadFactor),
+			Reason: "Covers indexes, document padding and storage engine overhead on top of document bytes.",
+		},
+		{
+			Name:   "Minimum storage",
+			Margin: fmt.Sprintf("%d GB", minStorageGB),
+			Reason: "Small migrations still need room for the oplog, journal and index builds.",
+		},
+	}
+	if mongo.ProductionRAMGB > 0 && mongo.MigrationRAMGB > mongo.ProductionRAMGB {
+		d.SafetyMargins = append(d.SafetyMargins, SafetyMargin{
+			Name:   "Migration tier headroom",
+			Margin: fmt.Sprintf("%dx RAM", mongo.MigrationRAMGB/mongo.ProductionRAMGB),
+			Reason: "The cluster is oversized during the bulk load to absorb write throughput, then scaled down.",
+		})
+	}
+	if spark.Platform != "glue" {
+		d.SafetyMargins = append(d.SafetyMargins, SafetyMargin{
+			Name:   "EMR cost upper bound",
+			Margin: fmt.Sprintf("%.1fx", emrCostHighMargin),
+			Reason: "Pads the high cost estimate for runs slower than the bracket assumes.",
+		})
+	}
+
+	return d
+}
@@ -26,7 +26,12 @@ var emrBrackets = []emrBracket{
 	{minBytes: tbToBytes(50), maxBytes: tbToBytes(100), instanceType: "r5.12xlarge", minWorkers: 100, maxWorkers: 200, costLow: 500, costHigh: 3000},
 }
 
-func calculateEMR(estimatedBytes int64) SparkPlan {
+// emrCostHighMargin pads the upper cost bound for runs slower than expected.
+const emrCostHighMargin = 1.2
+
+// emrBracketFor returns the bracket the data size falls in and how far into
+// the bracket it is, from 0 to 1.
+func emrBracketFor(estimatedBytes int64) (emrBracket, float64) {
 	bracket := emrBrackets[len(emrBrackets)-1] // default to largest
 	for _, b := range emrBrackets {
 		if estimatedBytes < b.maxBytes {
@@ -35,7 +40,6 @@ func calculateEMR(estimatedBytes int64) SparkPlan {
 		}
 	}
 
-	// Interpolate worker count within bracket
 	ratio := float64(estimatedBytes-bracket.minBytes) / float64(bracket.maxBytes-bracket.minBytes)
 	if ratio < 0 {
 		ratio = 0
@@ -43,11 +47,17 @@ func calculateEMR(estimatedBytes int64) SparkPlan {
 	if ratio > 1 {
 		ratio = 1
 	}
+	return bracket, ratio
+}
+
+func calculateEMR(estimatedBytes int64) SparkPlan {
+	// Interpolate worker count within bracket
+	bracket, ratio := emrBracketFor(estimatedBytes)
 	workers := bracket.minWorkers + int(ratio*float64(bracket.maxWorkers-bracket.minWorkers))
 	workers = clamp(workers, bracket.minWorkers, bracket.maxWorkers)
 
 	costLow := bracket.costLow + ratio*(bracket.costHigh-bracket.costLow)*0.5
-	costHigh := bracket.costLow + ratio*(bracket.costHigh-bracket.costLow)*1.2
+	costHigh := bracket.costLow + ratio*(bracket.costHigh-bracket.costLow)*emrCostHighMargin
 	if costHigh > bracket.costHigh {
 		costHigh = bracket.costHigh
 	}
@@ -65,8 +65,11 @@ func generateExplanations(input Input, spark SparkPlan, mongo MongoPlan, estTime
 
 	// Time estimate
 	timeDesc := "without a benchmark"
-	if input.BenchmarkMBps > 0 {
-		timeDesc = fmt.Sprintf("based on measured %.0f MB/s throughput", input.BenchmarkMBps)
+	switch throughput, src := Throughput(input); src {
+	case ThroughputBenchmark:
+		timeDesc = fmt.Sprintf("based on measured %.0f MB/s throughput", throughput)
+	case ThroughputWriteBenchmark:
+		timeDesc = fmt.Sprintf("based on the measured %.0f MB/s target write rate", throughput)
 	}
 	explanations = append(explanations, Explanation{
 		Category: "time",
@@ -11,6 +11,10 @@ import "fmt"
 const (
 	glueMaxRecommendedBytes = 500 * 1024 * 1024 * 1024 // 500 GB
 	gluePricePerDPUHour     = 0.44                      // USD
+
+	// Cost estimates assume the job runs for 1-3 hours.
+	glueMinHours = 1
+	glueMaxHours = 3
 )
 
 type glueBracket struct {
@@ -31,11 +35,9 @@ func IsGlueViable(estimatedBytes int64) bool {
 	return estimatedBytes <= glueMaxRecommendedBytes
 }
 
-func calculateGlue(estimatedBytes int64) SparkPlan {
-	if !IsGlueViable(estimatedBytes) {
-		return SparkPlan{} // not viable, DPUCount=0 signals this
-	}
-
+// glueBracketFor returns the bracket the data size falls in and how far into
+// the bracket it is, from 0 to 1.
+func glueBracketFor(estimatedBytes int64) (glueBracket, float64) {
 	bracket := glueBrackets[0]
 	for _, b := range glueBrackets {
 		if estimatedBytes >= b.minBytes && estimatedBytes < b.maxBytes {
@@ -45,7 +47,6 @@ func calculateGlue(estimatedBytes int64) SparkPlan {
 		bracket = b
 	}
 
-	// Interpolate DPU count within bracket
 	ratio := float64(estimatedBytes-bracket.minBytes) / float64(bracket.maxBytes-bracket.minBytes)
 	if ratio < 0 {
 		ratio = 0
@@ -53,12 +54,21 @@ func calculateGlue(estimatedBytes int64) SparkPlan {
 	if ratio > 1 {
 		ratio = 1
 	}
+	return bracket, ratio
+}
+
+func calculateGlue(estimatedBytes int64) SparkPlan {
+	if !IsGlueViable(estimatedBytes) {
+		return SparkPlan{} // not viable, DPUCount=0 signals this
+	}
+
+	// Interpolate DPU count within bracket
+	bracket, ratio := glueBracketFor(estimatedBytes)
 	dpus := bracket.minDPU + int(ratio*float64(bracket.maxDPU-bracket.minDPU))
 	dpus = clamp(dpus, bracket.minDPU, bracket.maxDPU)
 
-	// Estimate cost: assume 1-3 hours for migration
-	costLow := float64(dpus) * gluePricePerDPUHour * 1
-	costHigh := float64(dpus) * gluePricePerDPUHour * 3
+	costLow := float64(dpus) * gluePricePerDPUHour * glueMinHours
+	costHigh := float64(dpus) * gluePricePerDPUHour * glueMaxHours
 
 	return SparkPlan{
 		Platform:     "glue",
@@ -32,31 +32,51 @@ var productionTiers = []struct {
 	{maxBytes: tbToBytes(100), tier: mongoTier{name: "M200", ramGB: 256, label: "M200 (256 GB RAM)"}},
 }
 
-func calculateMongo(estimatedBytes int64, rowCount int64) MongoPlan {
-	// Storage estimate: doc bytes × 1.5 (indexes, padding, overhead)
-	storageBytes := int64(float64(estimatedBytes) * 1.5)
-	storageGB := ceilInt(bytesToGB(storageBytes))
-	if storageGB < 10 {
-		storageGB = 10
-	}
+// Storage is estimated at this multiple of document bytes to cover indexes,
+// padding and overhead, and never below minStorageGB.
+const (
+	storageOverheadFactor = 1.5
+	minStorageGB          = 10
+)
 
-	// Migration tier (oversized for bulk write throughput)
-	migTier := migrationTiers[len(migrationTiers)-1].tier
+// migrationTierFor returns the smallest migration tier whose ceiling is above
+// the data size, and that ceiling.
+func migrationTierFor(estimatedBytes int64) (mongoTier, int64) {
 	for _, t := range migrationTiers {
 		if estimatedBytes < t.maxBytes {
-			migTier = t.tier
-			break
+			return t.tier, t.maxBytes
 		}
 	}
+	last := migrationTiers[len(migrationTiers)-1]
+	return last.tier, last.maxBytes
+}
 
-	// Production tier (right-sized for working set)
-	prodTier := productionTiers[len(productionTiers)-1].tier
+// productionTierFor returns the smallest production tier whose ceiling is
+// above the data size, and that ceiling.
+func productionTierFor(estimatedBytes int64) (mongoTier, int64) {
 	for _, t := range productionTiers {
 		if estimatedBytes < t.maxBytes {
-			prodTier = t.tier
-			break
+			return t.tier, t.maxBytes
 		}
 	}
+	last := productionTiers[len(productionTiers)-1]
+	return last.tier, last.maxBytes
+}
+
+func calculateMongo(estimatedBytes int64, rowCount int64, compressionFactor float64) MongoPlan {
+	// Storage estimate: doc bytes × 1.5 (indexes, padding, overhead),
+	// scaled for block compressors other than the default snappy
+	storageBytes := int64(float64(estimatedBytes) * storageOverheadFactor * compressionFactor)
+	storageGB := ceilInt(bytesToGB(storageBytes))
+	if storageGB < minStorageGB {
+		storageGB = minStorageGB
+	}
+
+	// Migration tier (oversized for bulk write throughput)
+	migTier, _ := migrationTierFor(estimatedBytes)
+
+	// Production tier (right-sized for working set)
+	prodTier, _ := productionTierFor(estimatedBytes)
 
 	return MongoPlan{
 		MigrationTier:   migTier.label,
@@ -0,0 +1,300 @@
+package sizing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+// DefaultShardSampleSize is the number of rows sampled per table to measure
+// how often the most common value of each candidate column repeats.
+const DefaultShardSampleSize = 10000
+
+// Shard key strategies.
+const (
+	ShardHashed = "hashed"
+	ShardRanged = "ranged"
+)
+
+// ShardKeyStats describes the sampled data distribution of one candidate
+// shard key column.
+type ShardKeyStats struct {
+	Column     string  `json:"column"`
+	Field      string  `json:"field"`
+	RowCount   int64   `json:"row_count"`
+	Distinct   int64   `json:"distinct"`
+	SampleSize int     `json:"sample_size"`
+	TopShare   float64 `json:"top_share"`  // share of sampled rows holding the most common value
+	Sequential bool    `json:"sequential"` // values grow with inserts: a sequence or a date
+}
+
+// ShardKeyCandidate is one way of sharding on a column, scored out of 100.
+type ShardKeyCandidate struct {
+	ShardKeyStats
+	Strategy string `json:"strategy"`
+	Score    int    `json:"score"`
+	Reason   string `json:"reason"`
+}
+
+// ShardAdvice ranks the shard key candidates for one collection, best first.
+// Error is set when the source table could not be sampled.
+type ShardAdvice struct {
+	CollectionName string              `json:"collection_name"`
+	SourceTable    string              `json:"source_table"`
+	RowCount       int64               `json:"row_count"`
+	Candidates     []ShardKeyCandidate `json:"candidates"`
+	Error          string              `json:"error,omitempty"`
+}
+
+// Best returns the highest scoring candidate, or nil if there are none.
+func (a *ShardAdvice) Best() *ShardKeyCandidate {
+	if len(a.Candidates) == 0 {
+		return nil
+	}
+	return &a.Candidates[0]
+}
+
+// ShardKey returns the candidate as a shard key document for a
+// CollectionShard.
+func (c *ShardKeyCandidate) ShardKey() map[string]string {
+	if c.Strategy == ShardHashed {
+		return map[string]string{c.Field: "hashed"}
+	}
+	return map[string]string{c.Field: "1"}
+}
+
+// AdviseShardKeys samples the candidate columns of each mapped collection's
+// source table and ranks hashed and ranged shard keys on them. A table that
+// cannot be sampled is reported in its advice rather than failing the rest.
+func AdviseShardKeys(ctx context.Context, r source.Reader, m *mapping.Mapping, s *schema.Schema, sampleSize int) []ShardAdvice {
+	if m == nil || s == nil {
+		return nil
+	}
+	if sampleSize <= 0 {
+		sampleSize = DefaultShardSampleSize
+	}
+	var out []ShardAdvice
+	for i := range m.Collections {
+		col := &m.Collections[i]
+		t := findTable(s, col.SourceTable)
+		if t == nil {
+			continue
+		}
+		advice := ShardAdvice{CollectionName: col.Name, SourceTable: t.Name, RowCount: t.RowCount}
+		stats, err := SampleShardKeys(ctx, r, t, ShardKeyColumns(t, col), sampleSize)
+		if err != nil {
+			advice.Error = err.Error()
+			out = append(out, advice)
+			continue
+		}
+		for _, st := range stats {
+			advice.RowCount = st.RowCount
+			if f, ok := col.RootField(st.Column); ok {
+				st.Field = f
+			}
+			advice.Candidates = append(advice.Candidates, ScoreShardKey(st)...)
+		}
+		sort.SliceStable(advice.Candidates, func(i, j int) bool {
+			return advice.Candidates[i].Score > advice.Candidates[j].Score
+		})
+		out = append(out, advice)
+	}
+	return out
+}
+
+// ShardKeyColumns returns the table's columns worth considering as a shard
+// key: the primary key, then leading index and foreign key columns. Columns
+// the collection excludes, and types MongoDB cannot shard on well (large
+// text, binary, JSON and booleans), are left out.
+func ShardKeyColumns(t *schema.Table, col *mapping.Collection) []string {
+	var names []string
+	if t.PrimaryKey != nil {
+		names = append(names, t.PrimaryKey.Columns...)
+	}
+	for _, idx := range t.Indexes {
+		if len(idx.Columns) > 0 {
+			names = append(names, idx.Columns[0])
+		}
+	}
+	for _, fk := range t.ForeignKeys {
+		names = append(names, fk.Columns...)
+	}
+
+	seen := make(map[string]bool)
+	var out []string
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		c := findColumn(t, name)
+		if c == nil || !shardableType(c.DataType) {
+			continue
+		}
+		if col != nil {
+			if _, ok := col.RootField(name); !ok {
+				continue
+			}
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+func findTable(s *schema.Schema, name string) *schema.Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+	return nil
+}
+
+func findColumn(t *schema.Table, name string) *schema.Column {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+func shardableType(dataType string) bool {
+	dt := strings.ToLower(dataType)
+	for _, k := range []string{"text", "blob", "bytea", "binary", "json", "bool", "clob", "xml"} {
+		if strings.Contains(dt, k) {
+			return false
+		}
+	}
+	return true
+}
+
+// SampleShardKeys measures each column's distinct-value count over the whole
+// table and the share of the most common value within a sample of rows.
+func SampleShardKeys(ctx context.Context, r source.Reader, t *schema.Table, columns []string, sampleSize int) ([]ShardKeyStats, error) {
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	rows, err := r.RowCount(ctx, t.Name)
+	if err != nil {
+		return nil, fmt.Errorf("counting rows of %s: %w", t.Name, err)
+	}
+	sample, err := r.SampleRows(ctx, t.Name, columns, sampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("sampling %s: %w", t.Name, err)
+	}
+
+	var out []ShardKeyStats
+	for _, name := range columns {
+		distinct, err := r.AggregateCountDistinct(ctx, t.Name, name)
+		if err != nil {
+			return nil, fmt.Errorf("counting distinct %s.%s: %w", t.Name, name, err)
+		}
+		c := findColumn(t, name)
+		dt := strings.ToLower(c.DataType)
+		out = append(out, ShardKeyStats{
+			Column:     name,
+			Field:      name,
+			RowCount:   rows,
+			Distinct:   distinct,
+			SampleSize: len(sample),
+			TopShare:   topShare(sample, name),
+			Sequential: c.IsSequence || strings.Contains(dt, "date") || strings.Contains(dt, "timestamp"),
+		})
+	}
+	return out, nil
+}
+
+// topShare returns the share of rows holding the most common value of the
+// column. NULLs count as a value, since they all land in the same chunk.
+func topShare(rows []map[string]interface{}, column string) float64 {
+	if len(rows) == 0 {
+		return 0
+	}
+	counts := make(map[string]int)
+	top := 0
+	for _, row := range rows {
+		k := fmt.Sprintf("%v", row[column])
+		counts[k]++
+		if counts[k] > top {
+			top = counts[k]
+		}
+	}
+	return float64(top) / float64(len(rows))
+}
+
+// ScoreShardKey scores hashed and ranged sharding on a column. Out of 100:
+// 35 for cardinality, 35 for an even value frequency, 20 for spreading
+// inserts across shards, and 10 for ranged keys, which keep range queries
+// on one shard. Hashing fixes monotonic inserts but not low cardinality or
+// skew, so sequential columns favour hashed keys and others ranged ones.
+func ScoreShardKey(st ShardKeyStats) []ShardKeyCandidate {
+	cardinality := 0.0
+	if st.Distinct > 1 {
+		cardinality = math.Min(1, math.Log10(float64(st.Distinct))/6)
+	}
+	frequency := 1 - st.TopShare
+	base := 35*cardinality + 35*frequency
+
+	hashed := ShardKeyCandidate{ShardKeyStats: st, Strategy: ShardHashed, Score: int(math.Round(base + 20))}
+	ranged := ShardKeyCandidate{ShardKeyStats: st, Strategy: ShardRanged}
+	if st.Sequential {
+		ranged.Score = int(math.Round(base + 10))
+	} else {
+		ranged.Score = int(math.Round(base + 30))
+	}
+
+	var notes []string
+	notes = append(notes, fmt.Sprintf("%d distinct values", st.Distinct))
+	if st.SampleSize > 0 {
+		notes = append(notes, fmt.Sprintf("the most common holds %.1f%% of sampled rows", st.TopShare*100))
+	}
+	switch {
+	case st.Distinct <= 1:
+		notes = append(notes, "every document would land in one chunk")
+	case st.TopShare > 0.25:
+		notes = append(notes, "that value's documents cannot be split across shards, like a queue that all customers join at the same till")
+	case cardinality < 0.5:
+		notes = append(notes, "too few values to split into many chunks")
+	}
+	summary := strings.Join(notes, "; ")
+
+	hashed.Reason = summary + ". Hashing spreads inserts evenly but sends range queries to every shard."
+	if st.Sequential {
+		ranged.Reason = summary + ". Values grow with every insert, so all new documents would go to the last shard."
+	} else {
+		ranged.Reason = summary + ". Ranges keep related documents together so range queries stay on one shard."
+	}
+	return []ShardKeyCandidate{hashed, ranged}
+}
+
+// ApplyShardKey makes the candidate the shard key of a collection in the
+// plan, adding the collection if the plan does not shard it yet. Zoned
+// collections keep their zone field as the leading key field.
+func (sp *ShardingPlan) ApplyShardKey(collection string, c *ShardKeyCandidate) {
+	var cs *CollectionShard
+	for i := range sp.Collections {
+		if sp.Collections[i].CollectionName == collection {
+			cs = &sp.Collections[i]
+		}
+	}
+	if cs == nil {
+		sp.Collections = append(sp.Collections, CollectionShard{CollectionName: collection})
+		cs = &sp.Collections[len(sp.Collections)-1]
+	}
+	cs.ShardKey = c.ShardKey()
+	cs.IsHashed = c.Strategy == ShardHashed
+	cs.Explanation = fmt.Sprintf("Using %s shard key on '%s' (score %d/100): %s", c.Strategy, c.Field, c.Score, c.Reason)
+	if cs.Zones != nil && cs.Zones.Field != "" {
+		applyZones(cs, cs.Zones)
+		return
+	}
+	cs.PreSplitCount = sp.ShardCount * 4
+	cs.PreSplitCmds = generatePreSplitCmds(collection, cs.ShardKey, cs.PreSplitCount)
+}
@@ -0,0 +1,176 @@
+package sizing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+func TestShardKeyColumns(t *testing.T) {
+	tbl := &schema.Table{
+		Name: "orders",
+		Columns: []schema.Column{
+			{Name: "id", DataType: "bigint", IsSequence: true},
+			{Name: "customer_id", DataType: "integer"},
+			{Name: "status", DataType: "varchar"},
+			{Name: "notes", DataType: "text"},
+			{Name: "internal_ref", DataType: "varchar"},
+		},
+		PrimaryKey:  &schema.PrimaryKey{Columns: []string{"id"}},
+		ForeignKeys: []schema.ForeignKey{{Columns: []string{"customer_id"}}},
+		Indexes: []schema.Index{
+			{Columns: []string{"customer_id", "status"}},
+			{Columns: []string{"notes"}},
+			{Columns: []string{"internal_ref"}},
+		},
+	}
+	col := &mapping.Collection{Name: "orders", SourceTable: "orders", Transformations: []mapping.Transformation{
+		{SourceField: "internal_ref", Operation: "exclude"},
+	}}
+
+	got := ShardKeyColumns(tbl, col)
+	want := []string{"id", "customer_id"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("ShardKeyColumns() = %v, want %v", got, want)
+	}
+}
+
+func TestScoreShardKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		stats     ShardKeyStats
+		wantFirst string
+		minScore  int
+		maxScore  int
+	}{
+		{
+			name:      "sequential id favours hashed",
+			stats:     ShardKeyStats{Column: "id", Distinct: 1000000, SampleSize: 1000, TopShare: 0.001, Sequential: true},
+			wantFirst: ShardHashed,
+			minScore:  85,
+			maxScore:  90,
+		},
+		{
+			name:      "random high cardinality favours ranged",
+			stats:     ShardKeyStats{Column: "email", Distinct: 1000000, SampleSize: 1000, TopShare: 0.001},
+			wantFirst: ShardRanged,
+			minScore:  95,
+			maxScore:  100,
+		},
+		{
+			name:      "skewed low cardinality scores low",
+			stats:     ShardKeyStats{Column: "status", Distinct: 4, SampleSize: 1000, TopShare: 0.8},
+			wantFirst: ShardRanged,
+			minScore:  0,
+			maxScore:  45,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScoreShardKey(tt.stats)
+			best := got[0]
+			if got[1].Score > best.Score {
+				best = got[1]
+			}
+			if best.Strategy != tt.wantFirst {
+				t.Errorf("best strategy = %s, want %s (%+v)", best.Strategy, tt.wantFirst, got)
+			}
+			if best.Score < tt.minScore || best.Score > tt.maxScore {
+				t.Errorf("best score = %d, want %d-%d", best.Score, tt.minScore, tt.maxScore)
+			}
+			if best.Reason == "" {
+				t.Error("expected a reason")
+			}
+		})
+	}
+}
+
+func TestAdviseShardKeys(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{
+		{
+			Name: "users",
+			Columns: []schema.Column{
+				{Name: "id", DataType: "integer", IsSequence: true},
+				{Name: "country", DataType: "varchar"},
+			},
+			PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}},
+			Indexes:    []schema.Index{{Columns: []string{"country"}}},
+		},
+		{Name: "events", Columns: []schema.Column{{Name: "id", DataType: "integer"}}, PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}}},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users", SourceTable: "users", Transformations: []mapping.Transformation{
+			{SourceField: "country", Operation: "rename", TargetField: "countryCode"},
+		}},
+		{Name: "events", SourceTable: "events"},
+		{Name: "missing", SourceTable: "missing"},
+	}}
+
+	var sample []map[string]interface{}
+	for i := 0; i < 100; i++ {
+		country := "US"
+		if i%2 == 0 {
+			country = fmt.Sprintf("C%d", i)
+		}
+		sample = append(sample, map[string]interface{}{"id": i, "country": country})
+	}
+	r := &source.MockReader{
+		RowCounts:      map[string]int64{"users": 100000},
+		Samples:        map[string][]map[string]interface{}{"users": sample},
+		CountDistincts: map[string]int64{"users.id": 100000, "users.country": 60},
+	}
+
+	advice := AdviseShardKeys(context.Background(), r, m, s, 0)
+	if len(advice) != 2 {
+		t.Fatalf("got %d advice, want users and events", len(advice))
+	}
+	users := advice[0]
+	if users.RowCount != 100000 || len(users.Candidates) != 4 {
+		t.Fatalf("users advice = %+v", users)
+	}
+	best := users.Best()
+	if best.Column != "id" || best.Strategy != ShardHashed {
+		t.Errorf("best = %s %s, want hashed id", best.Strategy, best.Column)
+	}
+	for _, c := range users.Candidates {
+		if c.Column == "country" {
+			if c.Field != "countryCode" {
+				t.Errorf("country field = %s, want the renamed countryCode", c.Field)
+			}
+			if c.TopShare != 0.5 {
+				t.Errorf("country top share = %v, want 0.5", c.TopShare)
+			}
+		}
+	}
+	if key := best.ShardKey(); key["id"] != "hashed" {
+		t.Errorf("ShardKey() = %v", key)
+	}
+
+	r.CountDistinctErr = errors.New("permission denied")
+	advice = AdviseShardKeys(context.Background(), r, m, s, 0)
+	if advice[0].Error == "" || len(advice[0].Candidates) != 0 {
+		t.Errorf("sampling error should be reported per collection: %+v", advice[0])
+	}
+}
+
+func TestApplyShardKey(t *testing.T) {
+	sp := &ShardingPlan{Recommended: true, ShardCount: 2, Collections: []CollectionShard{
+		{CollectionName: "users", ShardKey: map[string]string{"_id": "hashed"}, IsHashed: true},
+	}}
+	ranged := &ShardKeyCandidate{ShardKeyStats: ShardKeyStats{Field: "email"}, Strategy: ShardRanged, Score: 97, Reason: "unique"}
+	sp.ApplyShardKey("users", ranged)
+	sp.ApplyShardKey("orders", &ShardKeyCandidate{ShardKeyStats: ShardKeyStats{Field: "id"}, Strategy: ShardHashed})
+
+	users := sp.Collections[0]
+	if users.IsHashed || users.ShardKey["email"] != "1" || len(users.ShardKey) != 1 || users.PreSplitCount != 8 {
+		t.Errorf("users = %+v, want a ranged key on email", users)
+	}
+	if len(sp.Collections) != 2 || !sp.Collections[1].IsHashed || sp.Collections[1].ShardKey["id"] != "hashed" {
+		t.Errorf("orders not added with a hashed key: %+v", sp.Collections)
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
 )
 
 // ShardingPlan describes whether and how to shard the MongoDB deployment.
@@ -20,12 +23,13 @@ type ShardingPlan struct {
 
 // CollectionShard describes the sharding configuration for a single collection.
 type CollectionShard struct {
-	CollectionName string            `yaml:"collection_name" json:"collection_name"`
-	ShardKey       map[string]string `yaml:"shard_key" json:"shard_key"`
-	IsHashed       bool              `yaml:"is_hashed" json:"is_hashed"`
-	PreSplitCount  int               `yaml:"pre_split_count" json:"pre_split_count"`
-	PreSplitCmds   []string          `yaml:"pre_split_commands" json:"pre_split_commands"`
-	Explanation    string            `yaml:"explanation" json:"explanation"`
+	CollectionName string              `yaml:"collection_name" json:"collection_name"`
+	ShardKey       map[string]string   `yaml:"shard_key" json:"shard_key"`
+	IsHashed       bool                `yaml:"is_hashed" json:"is_hashed"`
+	PreSplitCount  int                 `yaml:"pre_split_count" json:"pre_split_count"`
+	PreSplitCmds   []string            `yaml:"pre_split_commands" json:"pre_split_commands"`
+	Explanation    string              `yaml:"explanation" json:"explanation"`
+	Zones          *mapping.ZoneConfig `yaml:"zones,omitempty" json:"zones,omitempty"`
 }
 
 // ShardKeyInput provides information needed to recommend a shard key for a collection.
@@ -37,6 +41,7 @@ type ShardKeyInput struct {
 	IndexedFields    []string
 	EstimatedDocSize int64
 	EstimatedCount   int64
+	Zones            *mapping.ZoneConfig
 }
 
 const shardingThreshold = 3 * 1024 * 1024 * 1024 * 1024 // 3 TB
@@ -45,6 +50,36 @@ const shardingThreshold = 3 * 1024 * 1024 * 1024 * 1024 // 3 TB
 func CalculateSharding(totalDataBytes int64, collections []ShardKeyInput) *ShardingPlan {
 	plan := &ShardingPlan{}
 
+	var zoned []ShardKeyInput
+	for _, col := range collections {
+		if col.Zones != nil {
+			zoned = append(zoned, col)
+		}
+	}
+
+	if totalDataBytes < shardingThreshold && len(zoned) > 0 {
+		// Zone sharding is about data placement rather than volume, so it is
+		// set up regardless of size, but only for the zoned collections.
+		plan.Recommended = true
+		plan.ShardCount = 2
+		if n := len(zoneNames(zoned)); n > plan.ShardCount {
+			plan.ShardCount = n
+		}
+		plan.Explanations = append(plan.Explanations, Explanation{
+			Category: "sharding",
+			Summary:  fmt.Sprintf("Zone sharding configured for %d collection(s)", len(zoned)),
+			Detail: fmt.Sprintf(
+				"Your estimated data size of %s does not need sharding for capacity, but zone ranges are configured. "+
+					"Zoned collections are sharded so each range of documents stays on the shards tagged with its zone, "+
+					"like keeping each region's records in that region's office.",
+				FormatBytes(totalDataBytes)),
+		})
+		for _, col := range zoned {
+			plan.Collections = append(plan.Collections, calculateCollectionShard(col, plan.ShardCount))
+		}
+		return plan
+	}
+
 	if totalDataBytes < shardingThreshold {
 		plan.Recommended = false
 		plan.Explanations = append(plan.Explanations, Explanation{
@@ -123,6 +158,11 @@ func calculateCollectionShard(input ShardKeyInput, shardCount int) CollectionSha
 			"This distributes documents evenly across shards."
 	}
 
+	if input.Zones != nil && input.Zones.Field != "" {
+		applyZones(&cs, input.Zones)
+		return cs
+	}
+
 	// Pre-split: shardCount × 4 chunks
 	cs.PreSplitCount = shardCount * 4
 	cs.PreSplitCmds = generatePreSplitCmds(input.CollectionName, cs.ShardKey, cs.PreSplitCount)
@@ -130,6 +170,180 @@ func calculateCollectionShard(input ShardKeyInput, shardCount int) CollectionSha
 	return cs
 }
 
+// applyZones makes the zone field the ranged prefix of the shard key. The
+// zone ranges then decide chunk placement, so no pre-split is generated.
+func applyZones(cs *CollectionShard, zones *mapping.ZoneConfig) {
+	field := zones.Field
+	if kind, ok := cs.ShardKey[field]; !ok || kind == "hashed" {
+		delete(cs.ShardKey, field)
+		cs.ShardKey[field] = "1"
+		cs.IsHashed = false
+		for _, v := range cs.ShardKey {
+			if v == "hashed" {
+				cs.IsHashed = true
+			}
+		}
+	}
+	cs.Zones = zones
+	cs.Explanation = fmt.Sprintf(
+		"Using '%s' as the leading shard key field so documents can be pinned to zones by range. %s",
+		field, cs.Explanation)
+}
+
+// ZoneInputs returns shard key inputs for mapped collections that configure
+// zone ranges, so the sharding plan pins them to zones.
+func ZoneInputs(m *mapping.Mapping, s *schema.Schema) []ShardKeyInput {
+	if m == nil {
+		return nil
+	}
+	var inputs []ShardKeyInput
+	for _, col := range m.Collections {
+		if col.Zones == nil {
+			continue
+		}
+		in := ShardKeyInput{CollectionName: col.Name, Zones: col.Zones}
+		if s != nil {
+			for _, t := range s.Tables {
+				if t.Name != col.SourceTable || t.PrimaryKey == nil || len(t.PrimaryKey.Columns) == 0 {
+					continue
+				}
+				in.PKFields = t.PrimaryKey.Columns
+				for _, c := range t.Columns {
+					if c.Name == in.PKFields[0] {
+						in.PKIsSequential = c.IsSequence
+					}
+				}
+			}
+		}
+		inputs = append(inputs, in)
+	}
+	return inputs
+}
+
+func zoneNames(collections []ShardKeyInput) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, col := range collections {
+		if col.Zones == nil {
+			continue
+		}
+		for _, r := range col.Zones.Ranges {
+			if !seen[r.Zone] {
+				seen[r.Zone] = true
+				names = append(names, r.Zone)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Zones returns the distinct zone names referenced by the plan, sorted.
+func (sp *ShardingPlan) Zones() []string {
+	var inputs []ShardKeyInput
+	for _, cs := range sp.Collections {
+		inputs = append(inputs, ShardKeyInput{Zones: cs.Zones})
+	}
+	return zoneNames(inputs)
+}
+
+// ValidateZones checks every collection's zone ranges: each range needs a
+// zone name and Min < Max of the same type, and ranges must not overlap.
+func (sp *ShardingPlan) ValidateZones() error {
+	for _, cs := range sp.Collections {
+		if cs.Zones == nil {
+			continue
+		}
+		if err := validateZoneConfig(cs.Zones); err != nil {
+			return fmt.Errorf("collection %s: %w", cs.CollectionName, err)
+		}
+		if cs.ShardKey[cs.Zones.Field] != "1" {
+			return fmt.Errorf("collection %s: zone field %s must be a ranged shard key field", cs.CollectionName, cs.Zones.Field)
+		}
+	}
+	return nil
+}
+
+func validateZoneConfig(zc *mapping.ZoneConfig) error {
+	if zc.Field == "" {
+		return fmt.Errorf("zone field is required")
+	}
+	if len(zc.Ranges) == 0 {
+		return fmt.Errorf("no zone ranges for field %s", zc.Field)
+	}
+	ranges := make([]mapping.ZoneRange, len(zc.Ranges))
+	copy(ranges, zc.Ranges)
+	for i, r := range ranges {
+		if r.Zone == "" {
+			return fmt.Errorf("zone range %d has no zone name", i+1)
+		}
+		c, err := compareZoneBounds(r.Min, r.Max)
+		if err != nil {
+			return fmt.Errorf("zone %s: %w", r.Zone, err)
+		}
+		if c >= 0 {
+			return fmt.Errorf("zone %s: min %v must be less than max %v", r.Zone, r.Min, r.Max)
+		}
+	}
+	var sortErr error
+	sort.SliceStable(ranges, func(i, j int) bool {
+		c, err := compareZoneBounds(ranges[i].Min, ranges[j].Min)
+		if err != nil && sortErr == nil {
+			sortErr = err
+		}
+		return c < 0
+	})
+	if sortErr != nil {
+		return fmt.Errorf("zone ranges for %s: %w", zc.Field, sortErr)
+	}
+	for i := 1; i < len(ranges); i++ {
+		prev, cur := ranges[i-1], ranges[i]
+		if c, _ := compareZoneBounds(prev.Max, cur.Min); c > 0 {
+			return fmt.Errorf("zone ranges %s [%v, %v) and %s [%v, %v) overlap",
+				prev.Zone, prev.Min, prev.Max, cur.Zone, cur.Min, cur.Max)
+		}
+	}
+	return nil
+}
+
+// compareZoneBounds compares two range bounds, which must both be numbers or
+// both be strings.
+func compareZoneBounds(a, b interface{}) (int, error) {
+	if as, ok := a.(string); ok {
+		bs, ok := b.(string)
+		if !ok {
+			return 0, fmt.Errorf("bounds %v and %v have different types", a, b)
+		}
+		return strings.Compare(as, bs), nil
+	}
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if !aok || !bok {
+		return 0, fmt.Errorf("bounds %v and %v must both be numbers or both be strings", a, b)
+	}
+	switch {
+	case af < bf:
+		return -1, nil
+	case af > bf:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
 func bestIndexedField(indexedFields, pkFields []string) string {
 	// Prefer indexed fields that aren't part of the PK
 	pkSet := make(map[string]bool, len(pkFields))
@@ -200,16 +414,29 @@ func (sp *ShardingPlan) WriteYAML(path string) error {
 	return os.WriteFile(path, data, 0o644)
 }
 
-// ShardKeyString returns a human-readable representation of the shard key.
-func (cs *CollectionShard) ShardKeyString() string {
-	parts := make([]string, 0, len(cs.ShardKey))
-	// Sort keys for deterministic output
+// ShardKeyFields returns the shard key fields in index order: the zone field
+// first when zones are configured, then the remaining fields sorted.
+func (cs *CollectionShard) ShardKeyFields() []string {
 	keys := make([]string, 0, len(cs.ShardKey))
 	for k := range cs.ShardKey {
+		if cs.Zones != nil && k == cs.Zones.Field {
+			continue
+		}
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
-	for _, k := range keys {
+	if cs.Zones != nil {
+		if _, ok := cs.ShardKey[cs.Zones.Field]; ok {
+			keys = append([]string{cs.Zones.Field}, keys...)
+		}
+	}
+	return keys
+}
+
+// ShardKeyString returns a human-readable representation of the shard key.
+func (cs *CollectionShard) ShardKeyString() string {
+	parts := make([]string, 0, len(cs.ShardKey))
+	for _, k := range cs.ShardKeyFields() {
 		parts = append(parts, fmt.Sprintf("%s: %s", k, cs.ShardKey[k]))
 	}
 	return "{" + strings.Join(parts, ", ") + "}"
@@ -2,7 +2,10 @@ package sizing
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
 )
 
 func TestCalculateSharding_BelowThreshold(t *testing.T) {
@@ -220,3 +223,92 @@ func TestCalculate_IntegratesSharding(t *testing.T) {
 		t.Error("sharding should be recommended for 5 TB")
 	}
 }
+
+func regionZones() *mapping.ZoneConfig {
+	return &mapping.ZoneConfig{
+		Field: "region",
+		Ranges: []mapping.ZoneRange{
+			{Zone: "EU", Min: "eu", Max: "eu~"},
+			{Zone: "US", Min: "us", Max: "us~"},
+		},
+	}
+}
+
+func TestCalculateSharding_ZonesBelowThreshold(t *testing.T) {
+	collections := []ShardKeyInput{
+		{CollectionName: "customers", PKFields: []string{"id"}, PKIsSequential: true, Zones: regionZones()},
+		{CollectionName: "products", PKFields: []string{"id"}},
+	}
+
+	plan := CalculateSharding(tbToBytes(1), collections)
+
+	if !plan.Recommended {
+		t.Fatal("zone sharding should be set up regardless of size")
+	}
+	if len(plan.Collections) != 1 || plan.Collections[0].CollectionName != "customers" {
+		t.Fatalf("expected only the zoned collection, got %+v", plan.Collections)
+	}
+	cs := plan.Collections[0]
+	if got := cs.ShardKeyString(); got != "{region: 1, id: hashed}" {
+		t.Errorf("ShardKeyString() = %q", got)
+	}
+	if len(cs.PreSplitCmds) != 0 {
+		t.Errorf("zoned collections should not be pre-split, got %d commands", len(cs.PreSplitCmds))
+	}
+	if got := plan.Zones(); len(got) != 2 || got[0] != "EU" || got[1] != "US" {
+		t.Errorf("Zones() = %v", got)
+	}
+	if err := plan.ValidateZones(); err != nil {
+		t.Errorf("ValidateZones() error: %v", err)
+	}
+}
+
+func TestCalculateSharding_ZoneFieldIsShardKey(t *testing.T) {
+	collections := []ShardKeyInput{
+		{CollectionName: "orders", IndexedFields: []string{"region"}, Zones: regionZones()},
+	}
+
+	plan := CalculateSharding(tbToBytes(5), collections)
+
+	cs := plan.Collections[0]
+	if got := cs.ShardKeyString(); got != "{region: 1}" {
+		t.Errorf("ShardKeyString() = %q", got)
+	}
+	if cs.IsHashed {
+		t.Error("zone shard key should be ranged")
+	}
+}
+
+func TestValidateZones(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranges  []mapping.ZoneRange
+		wantErr string
+	}{
+		{"valid numbers", []mapping.ZoneRange{{Zone: "A", Min: 0, Max: 100}, {Zone: "B", Min: 100, Max: 200}}, ""},
+		{"missing zone", []mapping.ZoneRange{{Min: 0, Max: 1}}, "no zone name"},
+		{"min not less than max", []mapping.ZoneRange{{Zone: "A", Min: "b", Max: "a"}}, "less than"},
+		{"mixed types", []mapping.ZoneRange{{Zone: "A", Min: "a", Max: 5}}, "different types"},
+		{"overlap", []mapping.ZoneRange{{Zone: "B", Min: 50, Max: 150}, {Zone: "A", Min: 0, Max: 100}}, "overlap"},
+		{"no ranges", nil, "no zone ranges"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &ShardingPlan{Collections: []CollectionShard{{
+				CollectionName: "c",
+				ShardKey:       map[string]string{"k": "1"},
+				Zones:          &mapping.ZoneConfig{Field: "k", Ranges: tt.ranges},
+			}}}
+			err := plan.ValidateZones()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -18,6 +18,13 @@ type Input struct {
 	MaxSourceConnections  int     `yaml:"max_source_connections"`  // default 20
 	CollectionCount       int     `yaml:"collection_count"`
 	BenchmarkMBps         float64 `yaml:"benchmark_mbps"` // 0 = not benchmarked
+	// TargetWriteMBps is the measured write rate of the target cluster; the
+	// slower of it and the read rate bounds the migration. 0 = not measured.
+	TargetWriteMBps float64 `yaml:"target_write_mbps,omitempty"`
+	// Collections feeds per-collection shard key and zone recommendations.
+	Collections []ShardKeyInput `yaml:"-"`
+	// Storage carries per-collection block compressors for storage estimates.
+	Storage []CollectionStorage `yaml:"-"`
 }
 
 // SizingPlan contains the complete sizing recommendations.
@@ -26,7 +33,9 @@ type SizingPlan struct {
 	MongoPlan     MongoPlan     `yaml:"mongo_plan" json:"mongo_plan"`
 	ShardPlan     *ShardingPlan `yaml:"shard_plan,omitempty" json:"shard_plan,omitempty"`
 	EstimatedTime time.Duration `yaml:"estimated_time" json:"estimated_time"`
+	Estimate      *Estimate     `yaml:"estimate,omitempty" json:"estimate,omitempty"`
 	Explanations  []Explanation `yaml:"explanations" json:"explanations"`
+	Derivation    *Derivation   `yaml:"derivation,omitempty" json:"derivation,omitempty"`
 }
 
 // SparkPlan describes the recommended Spark cluster configuration.
@@ -49,13 +58,24 @@ type MongoPlan struct {
 	ProductionRAMGB int    `yaml:"production_ram_gb" json:"production_ram_gb"`
 }
 
+const (
+	// DefaultExpansionFactor is how much larger denormalized documents are
+	// assumed to be than their source rows.
+	DefaultExpansionFactor = 1.4
+	defaultSourceConns     = 20
+	// DefaultThroughputMBps is the conservative end-to-end rate assumed
+	// when no benchmark has been run.
+	DefaultThroughputMBps = 50.0
+)
+
 // Calculate computes a complete sizing plan from the given input.
 func Calculate(input Input) *SizingPlan {
+	orig := input
 	if input.DenormExpansionFactor == 0 {
-		input.DenormExpansionFactor = 1.4
+		input.DenormExpansionFactor = DefaultExpansionFactor
 	}
 	if input.MaxSourceConnections == 0 {
-		input.MaxSourceConnections = 20
+		input.MaxSourceConnections = defaultSourceConns
 	}
 
 	estimatedBytes := int64(float64(input.TotalDataBytes) * input.DenormExpansionFactor)
@@ -68,32 +88,37 @@ func Calculate(input Input) *SizingPlan {
 		spark = glue
 	}
 
-	mongo := calculateMongo(estimatedBytes, input.TotalRowCount)
+	factor := storageFactor(input.TotalDataBytes, input.Storage)
+	mongo := calculateMongo(estimatedBytes, input.TotalRowCount, factor)
 
 	// Estimate migration time
-	var estTime time.Duration
-	if input.BenchmarkMBps > 0 {
-		bytesPerSec := input.BenchmarkMBps * 1024 * 1024
-		seconds := float64(estimatedBytes) / bytesPerSec
-		estTime = time.Duration(seconds) * time.Second
-	} else {
-		// Conservative estimate: 50 MB/s with EMR
-		bytesPerSec := 50.0 * 1024 * 1024
-		seconds := float64(estimatedBytes) / bytesPerSec
-		estTime = time.Duration(seconds) * time.Second
-	}
+	throughput, _ := Throughput(input)
+	bytesPerSec := throughput * 1024 * 1024
+	seconds := float64(estimatedBytes) / bytesPerSec
+	estTime := time.Duration(seconds) * time.Second
+
+	est := estimateRun(spark, estimatedBytes, throughput, sparkUnits(spark))
 
 	explanations := generateExplanations(input, spark, mongo, estTime)
+	if exp := compressionExplanation(factor, input.Storage); exp != nil {
+		explanations = append(explanations, *exp)
+	}
+	explanations = append(explanations, costExplanation(spark, est))
 
 	// Calculate sharding plan
-	shardPlan := CalculateSharding(estimatedBytes, nil)
+	shardPlan := CalculateSharding(estimatedBytes, input.Collections)
 
 	plan := &SizingPlan{
 		SparkPlan:     spark,
 		MongoPlan:     mongo,
 		EstimatedTime: estTime,
+		Estimate:      &est,
 		Explanations:  explanations,
+		Derivation:    derive(orig, estimatedBytes, spark, mongo, factor, estTime),
 	}
+	steps, assumptions := costSteps(spark, est)
+	plan.Derivation.Steps = append(plan.Derivation.Steps, steps...)
+	plan.Derivation.Assumptions = append(plan.Derivation.Assumptions, assumptions...)
 
 	if shardPlan.Recommended {
 		plan.ShardPlan = shardPlan
@@ -103,6 +128,28 @@ func Calculate(input Input) *SizingPlan {
 	return plan
 }
 
+// Throughput sources reported by Throughput.
+const (
+	ThroughputDefault        = "default"
+	ThroughputBenchmark      = "benchmark"
+	ThroughputWriteBenchmark = "write benchmark"
+)
+
+// Throughput returns the end-to-end rate the migration time is estimated
+// at, in MB/s, and where it came from: the measured source read rate, or
+// the conservative default without one, lowered to the measured target
+// write rate when the target is the slower side.
+func Throughput(input Input) (float64, string) {
+	mbps, src := DefaultThroughputMBps, ThroughputDefault
+	if input.BenchmarkMBps > 0 {
+		mbps, src = input.BenchmarkMBps, ThroughputBenchmark
+	}
+	if input.TargetWriteMBps > 0 && input.TargetWriteMBps < mbps {
+		mbps, src = input.TargetWriteMBps, ThroughputWriteBenchmark
+	}
+	return mbps, src
+}
+
 // WriteYAML writes the sizing plan to a YAML file.
 func (sp *SizingPlan) WriteYAML(path string) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
@@ -115,7 +162,9 @@ func (sp *SizingPlan) WriteYAML(path string) error {
 		MongoPlan     MongoPlan     `yaml:"mongo_plan"`
 		ShardPlan     *ShardingPlan `yaml:"shard_plan,omitempty"`
 		EstimatedTime string        `yaml:"estimated_time"`
+		Estimate      *Estimate     `yaml:"estimate,omitempty"`
 		Explanations  []Explanation `yaml:"explanations"`
+		Derivation    *Derivation   `yaml:"derivation,omitempty"`
 	}
 
 	yp := yamlPlan{
@@ -123,7 +172,9 @@ func (sp *SizingPlan) WriteYAML(path string) error {
 		MongoPlan:     sp.MongoPlan,
 		ShardPlan:     sp.ShardPlan,
 		EstimatedTime: sp.EstimatedTime.String(),
+		Estimate:      sp.Estimate,
 		Explanations:  sp.Explanations,
+		Derivation:    sp.Derivation,
 	}
 
 	data, err := yaml.Marshal(yp)
@@ -146,7 +197,9 @@ func LoadYAML(path string) (*SizingPlan, error) {
 		MongoPlan     MongoPlan     `yaml:"mongo_plan"`
 		ShardPlan     *ShardingPlan `yaml:"shard_plan,omitempty"`
 		EstimatedTime string        `yaml:"estimated_time"`
+		Estimate      *Estimate     `yaml:"estimate,omitempty"`
 		Explanations  []Explanation `yaml:"explanations"`
+		Derivation    *Derivation   `yaml:"derivation,omitempty"`
 	}
 
 	var yp yamlPlan
@@ -164,7 +217,9 @@ func LoadYAML(path string) (*SizingPlan, error) {
 		MongoPlan:     yp.MongoPlan,
 		ShardPlan:     yp.ShardPlan,
 		EstimatedTime: dur,
+		Estimate:      yp.Estimate,
 		Explanations:  yp.Explanations,
+		Derivation:    yp.Derivation,
 	}, nil
 }
 
@@ -187,7 +187,7 @@ func TestMongoTierSelection(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			plan := calculateMongo(tt.bytes, 1_000_000)
+			plan := calculateMongo(tt.bytes, 1_000_000, 1.0)
 			if plan.MigrationTier == "" {
 				t.Error("migration tier should not be empty")
 			}
@@ -272,9 +272,83 @@ func TestYAMLRoundTrip(t *testing.T) {
 	if loaded.EstimatedTime != plan.EstimatedTime {
 		t.Errorf("time mismatch: got %v, want %v", loaded.EstimatedTime, plan.EstimatedTime)
 	}
+	if loaded.Estimate == nil || *loaded.Estimate != *plan.Estimate {
+		t.Errorf("estimate not round-tripped: got %+v, want %+v", loaded.Estimate, plan.Estimate)
+	}
 	if len(loaded.Explanations) != len(plan.Explanations) {
 		t.Errorf("explanations count mismatch: got %d, want %d", len(loaded.Explanations), len(plan.Explanations))
 	}
+	if loaded.Derivation == nil || len(loaded.Derivation.Steps) != len(plan.Derivation.Steps) {
+		t.Errorf("derivation not round-tripped: %+v", loaded.Derivation)
+	}
+}
+
+func TestDerivation(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        Input
+		wantSteps    []string
+		wantMargin   string
+		throughputBy string
+	}{
+		{
+			name:         "glue with defaults",
+			input:        Input{TotalDataBytes: gbToBytes(10), TotalRowCount: 1000, CollectionCount: 2},
+			wantSteps:    []string{"Estimated target size", "Glue bracket", "Glue DPUs", "Spark cost", "MongoDB storage", "Migration tier", "Production tier", "Migration time", "Run duration", "Run compute cost", "Run S3 cost", "Run data transfer cost"},
+			throughputBy: "default",
+		},
+		{
+			name:         "emr with benchmark",
+			input:        Input{TotalDataBytes: tbToBytes(2), DenormExpansionFactor: 1.2, BenchmarkMBps: 200},
+			wantSteps:    []string{"Estimated target size", "EMR bracket", "EMR workers", "Spark cost", "MongoDB storage", "Migration tier", "Production tier", "Migration time", "Run duration", "Run compute cost", "Run S3 cost", "Run data transfer cost"},
+			wantMargin:   "EMR cost upper bound",
+			throughputBy: "benchmark",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Calculate(tt.input).Derivation
+			if d == nil {
+				t.Fatal("expected derivation")
+			}
+			if len(d.Steps) != len(tt.wantSteps) {
+				t.Fatalf("steps = %+v", d.Steps)
+			}
+			for i, name := range tt.wantSteps {
+				if d.Steps[i].Name != name {
+					t.Errorf("step %d = %q, want %q", i, d.Steps[i].Name, name)
+				}
+				if d.Steps[i].Formula == "" || d.Steps[i].Value == "" {
+					t.Errorf("step %q missing formula or value", name)
+				}
+			}
+
+			sources := make(map[string]string)
+			for _, in := range d.Inputs {
+				sources[in.Name] = in.Source
+			}
+			if got := sources["Throughput"]; got != tt.throughputBy {
+				t.Errorf("throughput source = %q, want %q", got, tt.throughputBy)
+			}
+			if tt.input.DenormExpansionFactor == 0 && sources["Denormalization expansion factor"] != "default" {
+				t.Error("expected defaulted expansion factor to be marked as default")
+			}
+
+			if len(d.Assumptions) == 0 {
+				t.Error("expected assumptions")
+			}
+			margins := make(map[string]bool)
+			for _, m := range d.SafetyMargins {
+				margins[m.Name] = true
+			}
+			if !margins["Storage overhead"] {
+				t.Error("expected storage overhead margin")
+			}
+			if tt.wantMargin != "" && !margins[tt.wantMargin] {
+				t.Errorf("expected %q margin", tt.wantMargin)
+			}
+		})
+	}
 }
 
 func TestFormatBytes(t *testing.T) {
@@ -313,3 +387,32 @@ func TestFormatDuration(t *testing.T) {
 		}
 	}
 }
+
+func TestThroughput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    Input
+		wantMBps float64
+		wantSrc  string
+	}{
+		{"default", Input{}, DefaultThroughputMBps, ThroughputDefault},
+		{"read benchmark", Input{BenchmarkMBps: 120}, 120, ThroughputBenchmark},
+		{"slower target", Input{BenchmarkMBps: 120, TargetWriteMBps: 80}, 80, ThroughputWriteBenchmark},
+		{"faster target", Input{BenchmarkMBps: 120, TargetWriteMBps: 300}, 120, ThroughputBenchmark},
+		{"target slower than default", Input{TargetWriteMBps: 20}, 20, ThroughputWriteBenchmark},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mbps, src := Throughput(tt.input)
+			if mbps != tt.wantMBps || src != tt.wantSrc {
+				t.Errorf("Throughput = %.0f (%s), want %.0f (%s)", mbps, src, tt.wantMBps, tt.wantSrc)
+			}
+		})
+	}
+
+	slow := Calculate(Input{TotalDataBytes: gbToBytes(100), BenchmarkMBps: 100, TargetWriteMBps: 25})
+	fast := Calculate(Input{TotalDataBytes: gbToBytes(100), BenchmarkMBps: 100})
+	if d := slow.EstimatedTime - 4*fast.EstimatedTime; d < -4*time.Second || d > 4*time.Second {
+		t.Errorf("estimated time = %s, want 4x %s when the target writes at a quarter of the read rate", slow.EstimatedTime, fast.EstimatedTime)
+	}
+}
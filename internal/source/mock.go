@@ -3,6 +3,13 @@ package source
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/schema"
 )
 
 // MockReader is a test double for the Reader interface.
@@ -14,14 +21,32 @@ type MockReader struct {
 	Samples            map[string][]map[string]interface{}
 	SampleErr          error
 	Sums               map[string]float64 // key: "table.column"
+	DecimalSums        map[string]string  // key: "table.column"; exact sums, else Sums as text
 	SumErr             error
 	CountDistincts     map[string]int64 // key: "table.column"
 	CountDistinctErr   error
 	QueryResult        []map[string]interface{}
 	QueryErr           error
+	TableRows          map[string][]map[string]interface{}
+	StreamErr          error
+	Ages               map[string]map[int64]int64 // key: "table.column"
+	AgesErr            error
+	RangeErr           error
+	Filters            map[string]func(row map[string]interface{}) bool // key: SQL filter
+	SnapshotErr        error
+	// FoldKeys makes ReferencedRowCount compare keys ignoring case, as a
+	// source joining on citext or _CI columns does, unless the reference
+	// asks for another match.
+	FoldKeys bool
 
 	Connected bool
 	Closed    bool
+	PingErr   error
+	// Snapshot is the open snapshot, and StreamedIn the snapshot each table
+	// was last streamed in ("" outside one).
+	Snapshot   string
+	Snapshots  int
+	StreamedIn map[string]string
 }
 
 func (m *MockReader) Connect(_ context.Context) error {
@@ -44,6 +69,23 @@ func (m *MockReader) RowCount(_ context.Context, table string) (int64, error) {
 	return 0, fmt.Errorf("no row count configured for table %s", table)
 }
 
+// FilteredRowCount counts the TableRows matching filter, evaluated by the
+// Go predicate registered for it in Filters. Without a filter it is RowCount.
+func (m *MockReader) FilteredRowCount(ctx context.Context, table, filter string) (int64, error) {
+	if filter == "" {
+		return m.RowCount(ctx, table)
+	}
+	if m.RowCountErr != nil {
+		return 0, m.RowCountErr
+	}
+	var n int64
+	err := m.StreamFilteredRows(ctx, table, filter, func(map[string]interface{}) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
 func (m *MockReader) SampleRows(_ context.Context, table string, _ []string, _ int) ([]map[string]interface{}, error) {
 	if m.SampleErr != nil {
 		return nil, m.SampleErr
@@ -69,6 +111,19 @@ func (m *MockReader) AggregateSum(_ context.Context, table, column string) (floa
 	return 0, nil
 }
 
+// AggregateDecimalSum returns DecimalSums[key], or the Sums value as
+// decimal text when it has none.
+func (m *MockReader) AggregateDecimalSum(_ context.Context, table, column string) (string, error) {
+	if m.SumErr != nil {
+		return "", m.SumErr
+	}
+	key := table + "." + column
+	if s, ok := m.DecimalSums[key]; ok {
+		return s, nil
+	}
+	return strconv.FormatFloat(m.Sums[key], 'f', -1, 64), nil
+}
+
 func (m *MockReader) AggregateCountDistinct(_ context.Context, table, column string) (int64, error) {
 	if m.CountDistinctErr != nil {
 		return 0, m.CountDistinctErr
@@ -89,6 +144,235 @@ func (m *MockReader) QueryRows(_ context.Context, _ string, _ ...interface{}) ([
 	return m.QueryResult, nil
 }
 
+func (m *MockReader) RowAges(_ context.Context, table, column string) (map[int64]int64, error) {
+	if m.AgesErr != nil {
+		return nil, m.AgesErr
+	}
+	if ages, ok := m.Ages[table+"."+column]; ok {
+		return ages, nil
+	}
+	return map[int64]int64{}, nil
+}
+
+// KeyRange returns the smallest and largest key in TableRows.
+func (m *MockReader) KeyRange(_ context.Context, table, column string) (int64, int64, error) {
+	if m.RangeErr != nil {
+		return 0, 0, m.RangeErr
+	}
+	var lo, hi int64
+	for i, row := range m.TableRows[table] {
+		k, ok := mockKey(row[column])
+		if !ok {
+			return 0, 0, fmt.Errorf("%s.%s is not an integer key", table, column)
+		}
+		if i == 0 || k < lo {
+			lo = k
+		}
+		if i == 0 || k > hi {
+			hi = k
+		}
+	}
+	return lo, hi, nil
+}
+
+// RowsInRange returns the TableRows whose key is in [lo, hi).
+func (m *MockReader) RowsInRange(_ context.Context, table string, _ []string, key string, lo, hi int64) ([]map[string]interface{}, error) {
+	if m.RangeErr != nil {
+		return nil, m.RangeErr
+	}
+	var rows []map[string]interface{}
+	for _, row := range m.TableRows[table] {
+		if k, ok := mockKey(row[key]); ok && k >= lo && k < hi {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// ColumnMax returns the largest integer or time value of column among the
+// TableRows matching filter, or nil if there are none.
+func (m *MockReader) ColumnMax(ctx context.Context, table, column, filter string) (interface{}, error) {
+	var hi interface{}
+	err := m.StreamFilteredRows(ctx, table, filter, func(row map[string]interface{}) error {
+		switch v := row[column].(type) {
+		case time.Time:
+			if t, ok := hi.(time.Time); !ok || v.After(t) {
+				hi = v
+			}
+		default:
+			k, ok := mockKey(v)
+			if !ok {
+				return fmt.Errorf("%s.%s is not an integer or time", table, column)
+			}
+			if h, ok := hi.(int64); !ok || k > h {
+				hi = k
+			}
+		}
+		return nil
+	})
+	return hi, err
+}
+
+func mockKey(v interface{}) (int64, bool) {
+	switch k := v.(type) {
+	case int:
+		return int64(k), true
+	case int32:
+		return int64(k), true
+	case int64:
+		return k, true
+	}
+	return 0, false
+}
+
+func (m *MockReader) StreamRows(ctx context.Context, table string, fn RowFunc) error {
+	return m.StreamFilteredRows(ctx, table, "", fn)
+}
+
+// StreamFilteredRows streams the TableRows matching filter, evaluated by the
+// Go predicate registered for it in Filters.
+func (m *MockReader) StreamFilteredRows(_ context.Context, table, filter string, fn RowFunc) error {
+	if m.StreamErr != nil {
+		return m.StreamErr
+	}
+	match := func(map[string]interface{}) bool { return true }
+	if filter != "" {
+		f, ok := m.Filters[filter]
+		if !ok {
+			return fmt.Errorf("no predicate configured for filter %q", filter)
+		}
+		match = f
+	}
+	if m.StreamedIn == nil {
+		m.StreamedIn = make(map[string]string)
+	}
+	m.StreamedIn[table] = m.Snapshot
+	for _, row := range m.TableRows[table] {
+		if !match(row) {
+			continue
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReferencedRowCount counts the TableRows of ref.Table matching its filter
+// whose key is held by a parent row matching its filter.
+func (m *MockReader) ReferencedRowCount(ctx context.Context, ref Reference) (int64, error) {
+	fold := ref.Match == MatchLower || (ref.Match == MatchCollation && m.FoldKeys)
+	key := func(row map[string]interface{}, cols []string) (string, bool) {
+		k, ok := rowKey(row, cols)
+		if fold {
+			k = strings.ToLower(k)
+		}
+		return k, ok
+	}
+	parents := make(map[string]bool)
+	err := m.StreamFilteredRows(ctx, ref.ParentTable, ref.ParentFilter, func(row map[string]interface{}) error {
+		if k, ok := key(row, ref.ParentColumns); ok {
+			parents[k] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = m.StreamFilteredRows(ctx, ref.Table, ref.Filter, func(row map[string]interface{}) error {
+		if k, ok := key(row, ref.Columns); ok && parents[k] {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// rowKey returns the values of a row's key columns as one string, or false
+// when one of them is null.
+func rowKey(row map[string]interface{}, cols []string) (string, bool) {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		v := row[c]
+		if v == nil {
+			return "", false
+		}
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, "\x00"), len(cols) > 0
+}
+
+// ChildCounts measures how many TableRows of ref.Table matching its filter
+// each parent row matching its filter has.
+func (m *MockReader) ChildCounts(ctx context.Context, ref Reference) (schema.ChildCounts, error) {
+	children := make(map[string]int64)
+	var keys []string
+	err := m.StreamFilteredRows(ctx, ref.ParentTable, ref.ParentFilter, func(row map[string]interface{}) error {
+		if k, ok := rowKey(row, ref.ParentColumns); ok {
+			if _, ok := children[k]; !ok {
+				children[k] = 0
+				keys = append(keys, k)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return schema.ChildCounts{}, err
+	}
+	err = m.StreamFilteredRows(ctx, ref.Table, ref.Filter, func(row map[string]interface{}) error {
+		if k, ok := rowKey(row, ref.Columns); ok {
+			if _, ok := children[k]; ok {
+				children[k]++
+			}
+		}
+		return nil
+	})
+	if err != nil || len(keys) == 0 {
+		return schema.ChildCounts{}, err
+	}
+
+	counts := make([]int64, 0, len(keys))
+	var c schema.ChildCounts
+	for _, k := range keys {
+		counts = append(counts, children[k])
+		c.Children += children[k]
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i] < counts[j] })
+	// PERCENTILE_DISC: the first count whose cumulative share reaches p
+	percentile := func(p float64) int64 {
+		i := int(math.Ceil(p*float64(len(counts)))) - 1
+		return counts[max(i, 0)]
+	}
+	c.Parents = int64(len(counts))
+	c.Avg = float64(c.Children) / float64(c.Parents)
+	c.P50, c.P90, c.P99 = percentile(0.5), percentile(0.9), percentile(0.99)
+	c.Max = counts[len(counts)-1]
+	return c, nil
+}
+
+// BeginSnapshot opens a numbered snapshot.
+func (m *MockReader) BeginSnapshot(context.Context) (string, error) {
+	if m.SnapshotErr != nil {
+		return "", m.SnapshotErr
+	}
+	if m.Snapshot != "" {
+		return "", fmt.Errorf("a snapshot is already open")
+	}
+	m.Snapshots++
+	m.Snapshot = fmt.Sprintf("snapshot-%d", m.Snapshots)
+	return m.Snapshot, nil
+}
+
+func (m *MockReader) EndSnapshot(context.Context) error {
+	m.Snapshot = ""
+	return nil
+}
+
+func (m *MockReader) Ping(context.Context) error {
+	return m.PingErr
+}
+
 func (m *MockReader) Close() error {
 	m.Closed = true
 	return nil
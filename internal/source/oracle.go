@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 
 	// Oracle driver
 	_ "github.com/sijms/go-ora/v2"
+
+	"github.com/reloquent/reloquent/internal/schema"
 )
 
 // OracleReader implements Reader for Oracle using go-ora.
@@ -15,6 +18,7 @@ type OracleReader struct {
 	connStr string
 	schema  string
 	db      *sql.DB
+	scn     string // while set, tables are read as of this SCN
 }
 
 // NewOracleReader creates a new Oracle reader.
@@ -38,7 +42,7 @@ func (r *OracleReader) Connect(ctx context.Context) error {
 
 func (r *OracleReader) RowCount(ctx context.Context, table string) (int64, error) {
 	var count int64
-	q := fmt.Sprintf("SELECT COUNT(*) FROM %s.%s", quoteIdentOra(r.schema), quoteIdentOra(table))
+	q := fmt.Sprintf("SELECT COUNT(*) FROM %s", r.from(table))
 	err := r.db.QueryRowContext(ctx, q).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("counting rows in %s: %w", table, err)
@@ -46,6 +50,20 @@ func (r *OracleReader) RowCount(ctx context.Context, table string) (int64, error
 	return count, nil
 }
 
+// FilteredRowCount counts the rows of a table matching a mapping row filter.
+func (r *OracleReader) FilteredRowCount(ctx context.Context, table, filter string) (int64, error) {
+	if filter == "" {
+		return r.RowCount(ctx, table)
+	}
+	var count int64
+	q := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", r.from(table), filter)
+	err := r.db.QueryRowContext(ctx, q).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("counting filtered rows in %s: %w", table, err)
+	}
+	return count, nil
+}
+
 func (r *OracleReader) SampleRows(ctx context.Context, table string, columns []string, limit int) ([]map[string]interface{}, error) {
 	cols := "*"
 	if len(columns) > 0 {
@@ -55,15 +73,15 @@ func (r *OracleReader) SampleRows(ctx context.Context, table string, columns []s
 		}
 		cols = strings.Join(quoted, ", ")
 	}
-	q := fmt.Sprintf("SELECT %s FROM %s.%s WHERE ROWNUM <= %d ORDER BY 1",
-		cols, quoteIdentOra(r.schema), quoteIdentOra(table), limit)
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE ROWNUM <= %d ORDER BY 1",
+		cols, r.from(table), limit)
 	return r.QueryRows(ctx, q)
 }
 
 func (r *OracleReader) AggregateSum(ctx context.Context, table, column string) (float64, error) {
 	var sum float64
-	q := fmt.Sprintf("SELECT COALESCE(SUM(%s), 0) FROM %s.%s",
-		quoteIdentOra(column), quoteIdentOra(r.schema), quoteIdentOra(table))
+	q := fmt.Sprintf("SELECT COALESCE(SUM(%s), 0) FROM %s",
+		quoteIdentOra(column), r.from(table))
 	err := r.db.QueryRowContext(ctx, q).Scan(&sum)
 	if err != nil {
 		return 0, fmt.Errorf("summing %s.%s: %w", table, column, err)
@@ -71,10 +89,23 @@ func (r *OracleReader) AggregateSum(ctx context.Context, table, column string) (
 	return sum, nil
 }
 
+// AggregateDecimalSum returns the exact SUM of a NUMBER column as decimal
+// text.
+func (r *OracleReader) AggregateDecimalSum(ctx context.Context, table, column string) (string, error) {
+	var sum string
+	q := fmt.Sprintf("SELECT TO_CHAR(COALESCE(SUM(%s), 0), 'TM9', 'NLS_NUMERIC_CHARACTERS=''.,''') FROM %s",
+		quoteIdentOra(column), r.from(table))
+	err := r.db.QueryRowContext(ctx, q).Scan(&sum)
+	if err != nil {
+		return "", fmt.Errorf("summing %s.%s: %w", table, column, err)
+	}
+	return sum, nil
+}
+
 func (r *OracleReader) AggregateCountDistinct(ctx context.Context, table, column string) (int64, error) {
 	var count int64
-	q := fmt.Sprintf("SELECT COUNT(DISTINCT %s) FROM %s.%s",
-		quoteIdentOra(column), quoteIdentOra(r.schema), quoteIdentOra(table))
+	q := fmt.Sprintf("SELECT COUNT(DISTINCT %s) FROM %s",
+		quoteIdentOra(column), r.from(table))
 	err := r.db.QueryRowContext(ctx, q).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("counting distinct %s.%s: %w", table, column, err)
@@ -82,6 +113,108 @@ func (r *OracleReader) AggregateCountDistinct(ctx context.Context, table, column
 	return count, nil
 }
 
+// RowAges counts rows by the age in whole days of a DATE or TIMESTAMP column.
+func (r *OracleReader) RowAges(ctx context.Context, table, column string) (map[int64]int64, error) {
+	col := fmt.Sprintf("CAST(%s AS DATE)", quoteIdentOra(column))
+	q := fmt.Sprintf(`SELECT age_days, COUNT(*) FROM (
+		SELECT CASE WHEN %[1]s IS NULL THEN %[2]d
+			WHEN %[1]s > SYSDATE THEN 0
+			ELSE FLOOR(SYSDATE - %[1]s) END AS age_days
+		FROM %[3]s) GROUP BY age_days`,
+		col, NullAge, r.from(table))
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("counting rows of %s by age of %s: %w", table, column, err)
+	}
+	defer rows.Close()
+
+	ages := make(map[int64]int64)
+	for rows.Next() {
+		var age, count int64
+		if err := rows.Scan(&age, &count); err != nil {
+			return nil, fmt.Errorf("scanning row ages: %w", err)
+		}
+		ages[age] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("counting rows of %s by age of %s: %w", table, column, err)
+	}
+	return ages, nil
+}
+
+// KeyRange returns the smallest and largest value of an integer key column,
+// both zero for an empty table.
+func (r *OracleReader) KeyRange(ctx context.Context, table, column string) (int64, int64, error) {
+	var lo, hi int64
+	q := fmt.Sprintf("SELECT COALESCE(MIN(%[1]s), 0), COALESCE(MAX(%[1]s), 0) FROM %[2]s",
+		quoteIdentOra(column), r.from(table))
+	if err := r.db.QueryRowContext(ctx, q).Scan(&lo, &hi); err != nil {
+		return 0, 0, fmt.Errorf("reading key range of %s.%s: %w", table, column, err)
+	}
+	return lo, hi, nil
+}
+
+// ColumnMax returns the largest value of a column among the rows matching a
+// mapping row filter, or nil if there are none.
+func (r *OracleReader) ColumnMax(ctx context.Context, table, column, filter string) (interface{}, error) {
+	q := fmt.Sprintf("SELECT MAX(%s) FROM %s", quoteIdentOra(column), r.from(table))
+	if filter != "" {
+		q += " WHERE " + filter
+	}
+	var hi interface{}
+	if err := r.db.QueryRowContext(ctx, q).Scan(&hi); err != nil {
+		return nil, fmt.Errorf("reading largest %s.%s: %w", table, column, err)
+	}
+	return hi, nil
+}
+
+// ReferencedRowCount counts the rows of ref.Table whose key is found among
+// the parent rows.
+func (r *OracleReader) ReferencedRowCount(ctx context.Context, ref Reference) (int64, error) {
+	match := func(cols []string) []string {
+		return matchKeys(quoteKeys(cols, quoteIdentOra), ref.Match, "NLSSORT(%s, 'NLS_SORT=BINARY')", "NLSSORT(LOWER(%s), 'NLS_SORT=BINARY')")
+	}
+	q := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s IN (SELECT %s FROM %s%s)",
+		r.from(ref.Table), keyTuple(match(ref.Columns)),
+		strings.Join(match(ref.ParentColumns), ", "), r.from(ref.ParentTable), where(ref.ParentFilter))
+	if ref.Filter != "" {
+		q += " AND (" + ref.Filter + ")"
+	}
+	var count int64
+	if err := r.db.QueryRowContext(ctx, q).Scan(&count); err != nil {
+		return 0, fmt.Errorf("counting rows of %s referring to %s: %w", ref.Table, ref.ParentTable, err)
+	}
+	return count, nil
+}
+
+// ChildCounts measures how many rows of ref.Table each parent row has.
+func (r *OracleReader) ChildCounts(ctx context.Context, ref Reference) (schema.ChildCounts, error) {
+	parentKey, childKey := quoteKeys(ref.ParentColumns, quoteIdentOra), quoteKeys(ref.Columns, quoteIdentOra)
+	q := childCountsQuery(len(parentKey),
+		fmt.Sprintf("SELECT %s FROM %s%s", keySelect(parentKey), r.from(ref.ParentTable), whereNotNull(parentKey, ref.ParentFilter)),
+		fmt.Sprintf("SELECT %s FROM %s%s", keySelect(childKey), r.from(ref.Table), whereNotNull(childKey, ref.Filter)))
+	var c schema.ChildCounts
+	if err := r.db.QueryRowContext(ctx, q).Scan(&c.Parents, &c.Children, &c.Avg, &c.P50, &c.P90, &c.P99, &c.Max); err != nil {
+		return c, fmt.Errorf("counting children of %s in %s: %w", ref.ParentTable, ref.Table, err)
+	}
+	return c, nil
+}
+
+// RowsInRange returns the rows whose integer key is in [lo, hi).
+func (r *OracleReader) RowsInRange(ctx context.Context, table string, columns []string, key string, lo, hi int64) ([]map[string]interface{}, error) {
+	cols := "*"
+	if len(columns) > 0 {
+		quoted := make([]string, len(columns))
+		for i, c := range columns {
+			quoted[i] = quoteIdentOra(c)
+		}
+		cols = strings.Join(quoted, ", ")
+	}
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE %s >= :1 AND %s < :2",
+		cols, r.from(table), quoteIdentOra(key), quoteIdentOra(key))
+	return r.QueryRows(ctx, q, lo, hi)
+}
+
 func (r *OracleReader) QueryRows(ctx context.Context, sqlStr string, args ...interface{}) ([]map[string]interface{}, error) {
 	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
 	if err != nil {
@@ -116,6 +249,87 @@ func (r *OracleReader) QueryRows(ctx context.Context, sqlStr string, args ...int
 	return results, nil
 }
 
+// StreamRows reads every row of a table and passes it to fn one at a time.
+func (r *OracleReader) StreamRows(ctx context.Context, table string, fn RowFunc) error {
+	return r.StreamFilteredRows(ctx, table, "", fn)
+}
+
+// StreamFilteredRows reads the rows of a table matching a mapping row filter
+// and passes them to fn one at a time.
+func (r *OracleReader) StreamFilteredRows(ctx context.Context, table, filter string, fn RowFunc) error {
+	q := fmt.Sprintf("SELECT * FROM %s", r.from(table))
+	if filter != "" {
+		q += " WHERE " + filter
+	}
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return fmt.Errorf("streaming %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("getting columns: %w", err)
+	}
+
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("scanning row: %w", err)
+		}
+		row := make(map[string]interface{}, len(cols))
+		for i, c := range cols {
+			row[c] = vals[i]
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating rows: %w", err)
+	}
+	return nil
+}
+
+// BeginSnapshot records the current SCN and reads every table as of it
+// with a flashback query until EndSnapshot.
+func (r *OracleReader) BeginSnapshot(ctx context.Context) (string, error) {
+	var scn uint64
+	if err := r.db.QueryRowContext(ctx, "SELECT CURRENT_SCN FROM V$DATABASE").Scan(&scn); err != nil {
+		return "", fmt.Errorf("querying current SCN: %w", err)
+	}
+	r.scn = strconv.FormatUint(scn, 10)
+	return r.scn, nil
+}
+
+// EndSnapshot goes back to reading the current data.
+func (r *OracleReader) EndSnapshot(context.Context) error {
+	r.scn = ""
+	return nil
+}
+
+// from returns the qualified name of a table to read from, as of the
+// snapshot SCN when one is set.
+func (r *OracleReader) from(table string) string {
+	name := quoteIdentOra(r.schema) + "." + quoteIdentOra(table)
+	if r.scn != "" {
+		name += " AS OF SCN " + r.scn
+	}
+	return name
+}
+
+// Ping checks the connection is still alive.
+func (r *OracleReader) Ping(ctx context.Context) error {
+	if r.db == nil {
+		return fmt.Errorf("not connected")
+	}
+	return r.db.PingContext(ctx)
+}
+
 func (r *OracleReader) Close() error {
 	if r.db != nil {
 		return r.db.Close()
@@ -123,6 +337,11 @@ func (r *OracleReader) Close() error {
 	return nil
 }
 
+// QuoteIdent quotes a table or column name, keeping its case.
+func (r *OracleReader) QuoteIdent(name string) string {
+	return quoteIdentOra(name)
+}
+
 func quoteIdentOra(s string) string {
 	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
 }
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/reloquent/reloquent/internal/schema"
 )
 
 // PostgresReader implements Reader for PostgreSQL using pgx.
@@ -13,6 +16,7 @@ type PostgresReader struct {
 	connStr string
 	schema  string
 	pool    *pgxpool.Pool
+	tx      pgx.Tx // open snapshot, which every read goes through
 }
 
 // NewPostgresReader creates a new PostgreSQL reader.
@@ -44,13 +48,27 @@ func (r *PostgresReader) Connect(ctx context.Context) error {
 func (r *PostgresReader) RowCount(ctx context.Context, table string) (int64, error) {
 	var count int64
 	sql := fmt.Sprintf("SELECT COUNT(*) FROM %s.%s", quoteIdentPg(r.schema), quoteIdentPg(table))
-	err := r.pool.QueryRow(ctx, sql).Scan(&count)
+	err := r.conn().QueryRow(ctx, sql).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("counting rows in %s: %w", table, err)
 	}
 	return count, nil
 }
 
+// FilteredRowCount counts the rows of a table matching a mapping row filter.
+func (r *PostgresReader) FilteredRowCount(ctx context.Context, table, filter string) (int64, error) {
+	if filter == "" {
+		return r.RowCount(ctx, table)
+	}
+	var count int64
+	sql := fmt.Sprintf("SELECT COUNT(*) FROM %s.%s WHERE %s", quoteIdentPg(r.schema), quoteIdentPg(table), filter)
+	err := r.conn().QueryRow(ctx, sql).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("counting filtered rows in %s: %w", table, err)
+	}
+	return count, nil
+}
+
 func (r *PostgresReader) SampleRows(ctx context.Context, table string, columns []string, limit int) ([]map[string]interface{}, error) {
 	cols := "*"
 	if len(columns) > 0 {
@@ -68,26 +86,140 @@ func (r *PostgresReader) AggregateSum(ctx context.Context, table, column string)
 	var sum float64
 	sql := fmt.Sprintf("SELECT COALESCE(SUM(%s)::float8, 0) FROM %s.%s",
 		quoteIdentPg(column), quoteIdentPg(r.schema), quoteIdentPg(table))
-	err := r.pool.QueryRow(ctx, sql).Scan(&sum)
+	err := r.conn().QueryRow(ctx, sql).Scan(&sum)
 	if err != nil {
 		return 0, fmt.Errorf("summing %s.%s: %w", table, column, err)
 	}
 	return sum, nil
 }
 
+// AggregateDecimalSum returns the exact SUM of a numeric column as decimal
+// text.
+func (r *PostgresReader) AggregateDecimalSum(ctx context.Context, table, column string) (string, error) {
+	var sum string
+	sql := fmt.Sprintf("SELECT COALESCE(SUM(%s), 0)::text FROM %s.%s",
+		quoteIdentPg(column), quoteIdentPg(r.schema), quoteIdentPg(table))
+	err := r.conn().QueryRow(ctx, sql).Scan(&sum)
+	if err != nil {
+		return "", fmt.Errorf("summing %s.%s: %w", table, column, err)
+	}
+	return sum, nil
+}
+
 func (r *PostgresReader) AggregateCountDistinct(ctx context.Context, table, column string) (int64, error) {
 	var count int64
 	sql := fmt.Sprintf("SELECT COUNT(DISTINCT %s) FROM %s.%s",
 		quoteIdentPg(column), quoteIdentPg(r.schema), quoteIdentPg(table))
-	err := r.pool.QueryRow(ctx, sql).Scan(&count)
+	err := r.conn().QueryRow(ctx, sql).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("counting distinct %s.%s: %w", table, column, err)
 	}
 	return count, nil
 }
 
+// RowAges counts rows by the age in whole days of a date or timestamp column.
+func (r *PostgresReader) RowAges(ctx context.Context, table, column string) (map[int64]int64, error) {
+	col := quoteIdentPg(column) + "::timestamptz"
+	sql := fmt.Sprintf(`SELECT CASE WHEN %[1]s IS NULL THEN %[2]d
+		WHEN %[1]s > now() THEN 0
+		ELSE floor(extract(epoch FROM now() - %[1]s) / 86400)::bigint END AS age_days,
+		COUNT(*) FROM %[3]s.%[4]s GROUP BY 1`,
+		col, NullAge, quoteIdentPg(r.schema), quoteIdentPg(table))
+	rows, err := r.conn().Query(ctx, sql)
+	if err != nil {
+		return nil, fmt.Errorf("counting rows of %s by age of %s: %w", table, column, err)
+	}
+	defer rows.Close()
+
+	ages := make(map[int64]int64)
+	for rows.Next() {
+		var age, count int64
+		if err := rows.Scan(&age, &count); err != nil {
+			return nil, fmt.Errorf("scanning row ages: %w", err)
+		}
+		ages[age] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("counting rows of %s by age of %s: %w", table, column, err)
+	}
+	return ages, nil
+}
+
+// KeyRange returns the smallest and largest value of an integer key column,
+// both zero for an empty table.
+func (r *PostgresReader) KeyRange(ctx context.Context, table, column string) (int64, int64, error) {
+	var lo, hi int64
+	sql := fmt.Sprintf("SELECT COALESCE(MIN(%[1]s), 0)::bigint, COALESCE(MAX(%[1]s), 0)::bigint FROM %[2]s.%[3]s",
+		quoteIdentPg(column), quoteIdentPg(r.schema), quoteIdentPg(table))
+	if err := r.conn().QueryRow(ctx, sql).Scan(&lo, &hi); err != nil {
+		return 0, 0, fmt.Errorf("reading key range of %s.%s: %w", table, column, err)
+	}
+	return lo, hi, nil
+}
+
+// ColumnMax returns the largest value of a column among the rows matching a
+// mapping row filter, or nil if there are none.
+func (r *PostgresReader) ColumnMax(ctx context.Context, table, column, filter string) (interface{}, error) {
+	sql := fmt.Sprintf("SELECT MAX(%s) FROM %s.%s", quoteIdentPg(column), quoteIdentPg(r.schema), quoteIdentPg(table))
+	if filter != "" {
+		sql += " WHERE " + filter
+	}
+	var hi interface{}
+	if err := r.conn().QueryRow(ctx, sql).Scan(&hi); err != nil {
+		return nil, fmt.Errorf("reading largest %s.%s: %w", table, column, err)
+	}
+	return hi, nil
+}
+
+// ReferencedRowCount counts the rows of ref.Table whose key is found among
+// the parent rows.
+func (r *PostgresReader) ReferencedRowCount(ctx context.Context, ref Reference) (int64, error) {
+	match := func(cols []string) []string {
+		return matchKeys(quoteKeys(cols, quoteIdentPg), ref.Match, `CAST(%s AS text) COLLATE "C"`, `lower(CAST(%s AS text)) COLLATE "C"`)
+	}
+	sql := fmt.Sprintf("SELECT COUNT(*) FROM %s.%s WHERE %s IN (SELECT %s FROM %s.%s%s)",
+		quoteIdentPg(r.schema), quoteIdentPg(ref.Table), keyTuple(match(ref.Columns)),
+		strings.Join(match(ref.ParentColumns), ", "), quoteIdentPg(r.schema), quoteIdentPg(ref.ParentTable), where(ref.ParentFilter))
+	if ref.Filter != "" {
+		sql += " AND (" + ref.Filter + ")"
+	}
+	var count int64
+	if err := r.conn().QueryRow(ctx, sql).Scan(&count); err != nil {
+		return 0, fmt.Errorf("counting rows of %s referring to %s: %w", ref.Table, ref.ParentTable, err)
+	}
+	return count, nil
+}
+
+// ChildCounts measures how many rows of ref.Table each parent row has.
+func (r *PostgresReader) ChildCounts(ctx context.Context, ref Reference) (schema.ChildCounts, error) {
+	parentKey, childKey := quoteKeys(ref.ParentColumns, quoteIdentPg), quoteKeys(ref.Columns, quoteIdentPg)
+	sql := childCountsQuery(len(parentKey),
+		fmt.Sprintf("SELECT %s FROM %s.%s%s", keySelect(parentKey), quoteIdentPg(r.schema), quoteIdentPg(ref.ParentTable), whereNotNull(parentKey, ref.ParentFilter)),
+		fmt.Sprintf("SELECT %s FROM %s.%s%s", keySelect(childKey), quoteIdentPg(r.schema), quoteIdentPg(ref.Table), whereNotNull(childKey, ref.Filter)))
+	var c schema.ChildCounts
+	if err := r.conn().QueryRow(ctx, sql).Scan(&c.Parents, &c.Children, &c.Avg, &c.P50, &c.P90, &c.P99, &c.Max); err != nil {
+		return c, fmt.Errorf("counting children of %s in %s: %w", ref.ParentTable, ref.Table, err)
+	}
+	return c, nil
+}
+
+// RowsInRange returns the rows whose integer key is in [lo, hi).
+func (r *PostgresReader) RowsInRange(ctx context.Context, table string, columns []string, key string, lo, hi int64) ([]map[string]interface{}, error) {
+	cols := "*"
+	if len(columns) > 0 {
+		quoted := make([]string, len(columns))
+		for i, c := range columns {
+			quoted[i] = quoteIdentPg(c)
+		}
+		cols = strings.Join(quoted, ", ")
+	}
+	sql := fmt.Sprintf("SELECT %s FROM %s.%s WHERE %s >= $1 AND %s < $2",
+		cols, quoteIdentPg(r.schema), quoteIdentPg(table), quoteIdentPg(key), quoteIdentPg(key))
+	return r.QueryRows(ctx, sql, lo, hi)
+}
+
 func (r *PostgresReader) QueryRows(ctx context.Context, sql string, args ...interface{}) ([]map[string]interface{}, error) {
-	rows, err := r.pool.Query(ctx, sql, args...)
+	rows, err := r.conn().Query(ctx, sql, args...)
 	if err != nil {
 		return nil, fmt.Errorf("executing query: %w", err)
 	}
@@ -112,13 +244,110 @@ func (r *PostgresReader) QueryRows(ctx context.Context, sql string, args ...inte
 	return results, nil
 }
 
+// StreamRows reads every row of a table and passes it to fn one at a time.
+func (r *PostgresReader) StreamRows(ctx context.Context, table string, fn RowFunc) error {
+	return r.StreamFilteredRows(ctx, table, "", fn)
+}
+
+// StreamFilteredRows reads the rows of a table matching a mapping row filter
+// and passes them to fn one at a time.
+func (r *PostgresReader) StreamFilteredRows(ctx context.Context, table, filter string, fn RowFunc) error {
+	sql := fmt.Sprintf("SELECT * FROM %s.%s", quoteIdentPg(r.schema), quoteIdentPg(table))
+	if filter != "" {
+		sql += " WHERE " + filter
+	}
+	rows, err := r.conn().Query(ctx, sql)
+	if err != nil {
+		return fmt.Errorf("streaming %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	descs := rows.FieldDescriptions()
+	for rows.Next() {
+		vals, err := rows.Values()
+		if err != nil {
+			return fmt.Errorf("scanning row: %w", err)
+		}
+		row := make(map[string]interface{}, len(descs))
+		for i, d := range descs {
+			row[d.Name] = vals[i]
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating rows: %w", err)
+	}
+	return nil
+}
+
+// BeginSnapshot opens a read-only repeatable read transaction that every
+// read goes through until EndSnapshot, and returns its snapshot.
+func (r *PostgresReader) BeginSnapshot(ctx context.Context) (string, error) {
+	if r.tx != nil {
+		return "", fmt.Errorf("a snapshot is already open")
+	}
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return "", fmt.Errorf("starting snapshot transaction: %w", err)
+	}
+	// The first statement takes the transaction's snapshot
+	var snap string
+	if err := tx.QueryRow(ctx, "SELECT pg_current_snapshot()::text").Scan(&snap); err != nil {
+		tx.Rollback(ctx)
+		return "", fmt.Errorf("reading transaction snapshot: %w", err)
+	}
+	r.tx = tx
+	return snap, nil
+}
+
+// EndSnapshot ends the snapshot transaction.
+func (r *PostgresReader) EndSnapshot(ctx context.Context) error {
+	if r.tx == nil {
+		return nil
+	}
+	err := r.tx.Rollback(ctx)
+	r.tx = nil
+	return err
+}
+
+// pgQuerier is what reads need from the pool or a snapshot transaction.
+type pgQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (r *PostgresReader) conn() pgQuerier {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.pool
+}
+
+// Ping checks the connection is still alive.
+func (r *PostgresReader) Ping(ctx context.Context) error {
+	if r.pool == nil {
+		return fmt.Errorf("not connected")
+	}
+	return r.pool.Ping(ctx)
+}
+
 func (r *PostgresReader) Close() error {
+	if r.tx != nil {
+		r.EndSnapshot(context.Background())
+	}
 	if r.pool != nil {
 		r.pool.Close()
 	}
 	return nil
 }
 
+// QuoteIdent quotes a table or column name, keeping its case.
+func (r *PostgresReader) QuoteIdent(name string) string {
+	return quoteIdentPg(name)
+}
+
 func quoteIdentPg(s string) string {
 	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
 }
@@ -0,0 +1,61 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/config"
+)
+
+// TableReader is a Reader that can also stream whole tables.
+type TableReader interface {
+	Reader
+	Streamer
+}
+
+// NewReader creates a reader for the configured source database type.
+// Credential references in cfg are resolved first. The reader is not yet
+// connected.
+func NewReader(cfg config.SourceConfig) (TableReader, error) {
+	src, err := cfg.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	connStr, err := ConnString(src)
+	if err != nil {
+		return nil, err
+	}
+	switch src.Type {
+	case "oracle":
+		return NewOracleReader(connStr, SchemaName(src)), nil
+	default:
+		return NewPostgresReader(connStr, SchemaName(src)), nil
+	}
+}
+
+// ConnString returns the driver connection string for a resolved source
+// config.
+func ConnString(src config.SourceConfig) (string, error) {
+	switch src.Type {
+	case "postgresql":
+		ssl := "disable"
+		if src.SSL {
+			ssl = "require"
+		}
+		return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
+			src.Username, src.Password, src.Host, src.Port, src.Database, ssl), nil
+	case "oracle":
+		return fmt.Sprintf("oracle://%s:%s@%s:%d/%s",
+			src.Username, src.Password, src.Host, src.Port, src.Database), nil
+	default:
+		return "", fmt.Errorf("unsupported source type: %s", src.Type)
+	}
+}
+
+// SchemaName returns the schema a source config reads from. Oracle defaults
+// to the connecting user's own schema.
+func SchemaName(src config.SourceConfig) string {
+	if src.Type == "oracle" && src.Schema == "" {
+		return src.Username
+	}
+	return src.Schema
+}
@@ -0,0 +1,87 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/config"
+)
+
+func TestConnString(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.SourceConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			"postgres",
+			config.SourceConfig{Type: "postgresql", Host: "db", Port: 5432, Database: "shop", Username: "u", Password: "p"},
+			"postgres://u:p@db:5432/shop?sslmode=disable",
+			false,
+		},
+		{
+			"postgres ssl",
+			config.SourceConfig{Type: "postgresql", Host: "db", Port: 5432, Database: "shop", Username: "u", Password: "p", SSL: true},
+			"postgres://u:p@db:5432/shop?sslmode=require",
+			false,
+		},
+		{
+			"oracle",
+			config.SourceConfig{Type: "oracle", Host: "ora", Port: 1521, Database: "ORCL", Username: "u", Password: "p"},
+			"oracle://u:p@ora:1521/ORCL",
+			false,
+		},
+		{"unsupported", config.SourceConfig{Type: "mysql"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConnString(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ConnString = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        config.SourceConfig
+		wantSchema string
+		oracle     bool
+		wantErr    bool
+	}{
+		{"postgres default schema", config.SourceConfig{Type: "postgresql"}, "public", false, false},
+		{"postgres schema", config.SourceConfig{Type: "postgresql", Schema: "sales"}, "sales", false, false},
+		{"oracle owner defaults to user", config.SourceConfig{Type: "oracle", Username: "scott"}, "SCOTT", true, false},
+		{"oracle schema", config.SourceConfig{Type: "oracle", Username: "scott", Schema: "hr"}, "HR", true, false},
+		{"unsupported", config.SourceConfig{Type: "mysql"}, "", false, true},
+		{"unresolved secret", config.SourceConfig{Type: "postgresql", Password: "${RELOQUENT_TEST_UNSET}"}, "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewReader(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			switch rd := r.(type) {
+			case *OracleReader:
+				if !tt.oracle || rd.schema != tt.wantSchema {
+					t.Errorf("got Oracle reader for %s, want oracle=%v schema %s", rd.schema, tt.oracle, tt.wantSchema)
+				}
+			case *PostgresReader:
+				if tt.oracle || rd.schema != tt.wantSchema {
+					t.Errorf("got PostgreSQL reader for %s, want oracle=%v schema %s", rd.schema, tt.oracle, tt.wantSchema)
+				}
+			default:
+				t.Errorf("reader = %T", r)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultRoleName is the source role a role script creates unless told
+// otherwise.
+const DefaultRoleName = "reloquent_migration"
+
+var roleNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,29}$`)
+
+// RoleScript generates the SQL a DBA runs to create a dedicated, read-only
+// login for reloquent with only the grants it uses: catalog access for
+// discovery, SELECT on the migrated tables, and, when CDC is used, what
+// reading changes requires. The role gets no write privileges on any table.
+type RoleScript struct {
+	DBType   string
+	Database string   // PostgreSQL database the role connects to
+	Schema   string   // schema/owner of the tables
+	Role     string   // default DefaultRoleName
+	Tables   []string // tables to grant SELECT on
+	CDC      bool
+	// Flashback lists the tables of consistency groups, which are read
+	// with flashback queries on Oracle.
+	Flashback []string
+}
+
+// Generate returns the script for the source type.
+func (rs *RoleScript) Generate() (string, error) {
+	role := rs.Role
+	if role == "" {
+		role = DefaultRoleName
+	}
+	if !roleNamePattern.MatchString(role) {
+		return "", fmt.Errorf("invalid role name %q: use letters, digits and underscores, starting with a letter, at most 30 characters", role)
+	}
+	if len(rs.Tables) == 0 {
+		return "", fmt.Errorf("no tables to grant; select the tables to migrate first")
+	}
+	switch rs.DBType {
+	case "postgresql":
+		if rs.Database == "" {
+			return "", fmt.Errorf("source database required")
+		}
+		return rs.postgresScript(strings.ToLower(role)), nil
+	case "oracle":
+		if rs.Schema == "" {
+			return "", fmt.Errorf("source schema required: the new user does not own the tables")
+		}
+		return rs.oracleScript(strings.ToUpper(role)), nil
+	default:
+		return "", fmt.Errorf("unsupported source type: %s", rs.DBType)
+	}
+}
+
+func (rs *RoleScript) postgresScript(role string) string {
+	schemaName := rs.Schema
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	attrs := "LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE"
+	if rs.CDC {
+		attrs += " REPLICATION"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `-- Reloquent Migration Role (PostgreSQL)
+-- Run as a superuser: psql -h HOST -U ADMIN -d %s -f this_script.sql
+-- Replace CHANGE_ME with a strong password, then set source.username to
+-- %s in the reloquent config.
+
+CREATE ROLE %s WITH %s PASSWORD 'CHANGE_ME';
+
+-- Connect and see the schema; information_schema and pg_catalog, which
+-- discovery reads, only show objects the role has privileges on.
+GRANT CONNECT ON DATABASE %s TO %s;
+GRANT USAGE ON SCHEMA %s TO %s;
+
+-- Sessions of other users in pg_stat_activity, for the cutover checks.
+GRANT pg_read_all_stats TO %s;
+
+-- Tables to migrate, read only.
+`, rs.Database, role, role, attrs, quoteIdentPg(rs.Database), role, quoteIdentPg(schemaName), role, role)
+	for _, t := range rs.Tables {
+		fmt.Fprintf(&b, "GRANT SELECT ON %s.%s TO %s;\n", quoteIdentPg(schemaName), quoteIdentPg(t), role)
+	}
+	if rs.CDC {
+		b.WriteString(`
+-- CDC: REPLICATION (above) lets the role create, read and drop its logical
+-- replication slot. The server also needs wal_level = logical.
+`)
+	}
+	return b.String()
+}
+
+func (rs *RoleScript) oracleScript(role string) string {
+	owner := strings.ToUpper(rs.Schema)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `-- Reloquent Migration Role (Oracle)
+-- Run as a DBA: sqlplus ADMIN/PASS@HOST:PORT/SID @this_script.sql
+-- Replace CHANGE_ME with a strong password, then set source.username to
+-- %s and source.schema to %s in the reloquent config.
+
+CREATE USER %s IDENTIFIED BY "CHANGE_ME";
+GRANT CREATE SESSION TO %s;
+
+-- Table sizes for discovery and sizing; the ALL_* views discovery reads
+-- only show objects the user has privileges on.
+GRANT SELECT ON SYS.DBA_SEGMENTS TO %s;
+
+-- Tables to migrate, read only.
+`, role, owner, role, role, role)
+	for _, t := range rs.Tables {
+		fmt.Fprintf(&b, "GRANT SELECT ON %s.%s TO %s;\n", quoteIdentOra(owner), quoteIdentOra(t), role)
+	}
+	if len(rs.Flashback) > 0 {
+		b.WriteString(`
+-- Consistency groups: their tables are read as of one SCN with flashback
+-- queries, which need the current SCN and FLASHBACK on each table.
+`)
+		if !rs.CDC {
+			fmt.Fprintf(&b, "GRANT SELECT ON SYS.V_$DATABASE TO %s;\n", role)
+		}
+		for _, t := range rs.Flashback {
+			fmt.Fprintf(&b, "GRANT FLASHBACK ON %s.%s TO %s;\n", quoteIdentOra(owner), quoteIdentOra(t), role)
+		}
+	}
+	if rs.CDC {
+		fmt.Fprintf(&b, `
+-- CDC with LogMiner (Oracle 12c and later). The database also needs
+-- ARCHIVELOG mode and supplemental logging of all columns.
+GRANT LOGMINING TO %s;
+GRANT EXECUTE ON SYS.DBMS_LOGMNR TO %s;
+GRANT SELECT ON SYS.V_$DATABASE TO %s;
+GRANT SELECT ON SYS.V_$LOG TO %s;
+GRANT SELECT ON SYS.V_$LOGFILE TO %s;
+GRANT SELECT ON SYS.V_$ARCHIVED_LOG TO %s;
+GRANT SELECT ON SYS.V_$LOGMNR_CONTENTS TO %s;
+`, role, role, role, role, role, role, role)
+	}
+	b.WriteString("\nEXIT;\n")
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package source
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRoleScript_Postgres(t *testing.T) {
+	rs := &RoleScript{DBType: "postgresql", Database: "shop", Schema: "sales", Tables: []string{"orders", "Order Items"}}
+	script, err := rs.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	for _, want := range []string{
+		"CREATE ROLE reloquent_migration WITH LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE PASSWORD",
+		`GRANT CONNECT ON DATABASE "shop" TO reloquent_migration;`,
+		`GRANT USAGE ON SCHEMA "sales" TO reloquent_migration;`,
+		`GRANT SELECT ON "sales"."orders" TO reloquent_migration;`,
+		`GRANT SELECT ON "sales"."Order Items" TO reloquent_migration;`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q", want)
+		}
+	}
+	for _, unwanted := range []string{"REPLICATION", "INSERT", "UPDATE", "DELETE", "ALL PRIVILEGES"} {
+		if strings.Contains(script, unwanted) {
+			t.Errorf("script without CDC should not contain %q", unwanted)
+		}
+	}
+
+	rs.CDC = true
+	rs.Role = "Migrator"
+	script, err = rs.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if !strings.Contains(script, "CREATE ROLE migrator WITH LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE REPLICATION") {
+		t.Errorf("CDC script should grant REPLICATION to the lower-cased role:\n%s", script)
+	}
+}
+
+func TestRoleScript_Oracle(t *testing.T) {
+	rs := &RoleScript{DBType: "oracle", Schema: "hr", Tables: []string{"EMPLOYEES"}}
+	script, err := rs.Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	for _, want := range []string{
+		`CREATE USER RELOQUENT_MIGRATION IDENTIFIED BY`,
+		"GRANT CREATE SESSION TO RELOQUENT_MIGRATION;",
+		"GRANT SELECT ON SYS.DBA_SEGMENTS TO RELOQUENT_MIGRATION;",
+		`GRANT SELECT ON "HR"."EMPLOYEES" TO RELOQUENT_MIGRATION;`,
+		"source.schema to HR",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q", want)
+		}
+	}
+	if strings.Contains(script, "LOGMNR") {
+		t.Error("script without CDC should not grant LogMiner access")
+	}
+
+	rs.CDC = true
+	script, _ = rs.Generate()
+	for _, want := range []string{"GRANT LOGMINING", "SYS.DBMS_LOGMNR", "SYS.V_$LOGMNR_CONTENTS", "SYS.V_$ARCHIVED_LOG"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("CDC script missing %q", want)
+		}
+	}
+
+	rs.Flashback = []string{"EMPLOYEES"}
+	script, _ = rs.Generate()
+	if !strings.Contains(script, `GRANT FLASHBACK ON "HR"."EMPLOYEES" TO RELOQUENT_MIGRATION;`) {
+		t.Error("script should grant flashback on consistency group tables")
+	}
+	if strings.Count(script, "SYS.V_$DATABASE") != 1 {
+		t.Error("V$DATABASE should be granted once")
+	}
+}
+
+func TestRoleScript_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		rs   RoleScript
+	}{
+		{"no tables", RoleScript{DBType: "postgresql", Database: "shop"}},
+		{"bad role", RoleScript{DBType: "postgresql", Database: "shop", Role: "x; DROP TABLE t", Tables: []string{"t"}}},
+		{"no database", RoleScript{DBType: "postgresql", Tables: []string{"t"}}},
+		{"no owner", RoleScript{DBType: "oracle", Tables: []string{"T"}}},
+		{"unsupported", RoleScript{DBType: "mysql", Tables: []string{"t"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.rs.Generate(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -1,14 +1,194 @@
 package source
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
 
 // Reader provides read-only access to a source database for validation queries.
 type Reader interface {
 	Connect(ctx context.Context) error
 	RowCount(ctx context.Context, table string) (int64, error)
+	FilteredRowCount(ctx context.Context, table, filter string) (int64, error)
 	SampleRows(ctx context.Context, table string, columns []string, limit int) ([]map[string]interface{}, error)
 	AggregateSum(ctx context.Context, table, column string) (float64, error)
+	AggregateDecimalSum(ctx context.Context, table, column string) (string, error)
 	AggregateCountDistinct(ctx context.Context, table, column string) (int64, error)
 	QueryRows(ctx context.Context, sql string, args ...interface{}) ([]map[string]interface{}, error)
+	RowAges(ctx context.Context, table, column string) (map[int64]int64, error)
+	KeyRange(ctx context.Context, table, column string) (min, max int64, err error)
+	ColumnMax(ctx context.Context, table, column, filter string) (interface{}, error)
+	RowsInRange(ctx context.Context, table string, columns []string, key string, lo, hi int64) ([]map[string]interface{}, error)
+	ReferencedRowCount(ctx context.Context, ref Reference) (int64, error)
+	ChildCounts(ctx context.Context, ref Reference) (schema.ChildCounts, error)
 	Close() error
 }
+
+// Reference is a foreign key from Table.Columns to
+// ParentTable.ParentColumns, matched in order, each table read with its
+// mapping row filter (all rows if empty). ReferencedRowCount counts the
+// rows of Table whose key is found among the parent rows, comparing keys
+// as Match says; ChildCounts measures how many of them each parent row has.
+type Reference struct {
+	Table         string
+	Columns       []string
+	Filter        string
+	ParentTable   string
+	ParentColumns []string
+	ParentFilter  string
+	Match         string
+}
+
+// How ReferencedRowCount compares a row's key with its parent's.
+const (
+	// MatchCollation compares keys as the source joins them, with the
+	// columns' collations, which may ignore case.
+	MatchCollation = ""
+	// MatchBinary compares keys byte by byte, as MongoDB and the
+	// migration's joins do.
+	MatchBinary = "binary"
+	// MatchLower compares keys byte by byte once lower-cased, as the
+	// migration joins keys normalized to lower case.
+	MatchLower = "lower"
+)
+
+// matchKeys wraps quoted key columns in the expressions comparing them as
+// match says: binary and lower give the expressions comparing a key byte
+// by byte, as it is and lower-cased.
+func matchKeys(keys []string, match, binary, lower string) []string {
+	format := ""
+	switch match {
+	case MatchBinary:
+		format = binary
+	case MatchLower:
+		format = lower
+	default:
+		return keys
+	}
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = fmt.Sprintf(format, k)
+	}
+	return out
+}
+
+// where returns a WHERE clause for a row filter, or nothing without one.
+func where(filter string) string {
+	if filter == "" {
+		return ""
+	}
+	return " WHERE " + filter
+}
+
+// whereNotNull returns a WHERE clause keeping the rows whose key columns,
+// already quoted, are all set and that match the row filter.
+func whereNotNull(keys []string, filter string) string {
+	conds := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		conds = append(conds, k+" IS NOT NULL")
+	}
+	if filter != "" {
+		conds = append(conds, "("+filter+")")
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// quoteKeys quotes key columns.
+func quoteKeys(cols []string, quote func(string) string) []string {
+	out := make([]string, len(cols))
+	for i, c := range cols {
+		out[i] = quote(c)
+	}
+	return out
+}
+
+// keyTuple returns quoted key columns as an IN operand: the column itself,
+// or a row value for a composite key.
+func keyTuple(keys []string) string {
+	if len(keys) == 1 {
+		return keys[0]
+	}
+	return "(" + strings.Join(keys, ", ") + ")"
+}
+
+// keySelect selects quoted key columns as k0, k1...
+func keySelect(keys []string) string {
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = fmt.Sprintf("%s AS k%d", k, i)
+	}
+	return strings.Join(out, ", ")
+}
+
+// childCountsQuery returns the query ChildCounts runs: the number of
+// children of each parent key of n columns, from which the distribution is
+// aggregated. The parents and children are subqueries selecting their key
+// with keySelect.
+func childCountsQuery(n int, parents, children string) string {
+	keys := make([]string, n)
+	on := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("p.k%d", i)
+		on[i] = fmt.Sprintf("c.k%d = p.k%d", i, i)
+	}
+	return fmt.Sprintf(`SELECT COUNT(*), COALESCE(SUM(n), 0), COALESCE(AVG(n), 0),
+  COALESCE(PERCENTILE_DISC(0.5) WITHIN GROUP (ORDER BY n), 0),
+  COALESCE(PERCENTILE_DISC(0.9) WITHIN GROUP (ORDER BY n), 0),
+  COALESCE(PERCENTILE_DISC(0.99) WITHIN GROUP (ORDER BY n), 0),
+  COALESCE(MAX(n), 0)
+FROM (SELECT %s, COUNT(c.k0) AS n
+  FROM (%s) p LEFT JOIN (%s) c ON %s
+  GROUP BY %s) t`, strings.Join(keys, ", "), parents, children, strings.Join(on, " AND "), strings.Join(keys, ", "))
+}
+
+// NullAge is the RowAges key counting rows whose date column is NULL. Rows
+// dated in the future count as zero days old.
+const NullAge = -1
+
+// RowFunc is called once per row while streaming a table.
+// Returning an error stops the stream and is propagated to the caller.
+type RowFunc func(row map[string]interface{}) error
+
+// Streamer is implemented by readers that can stream every row of a table
+// without buffering the full result set in memory. StreamFilteredRows
+// streams only the rows matching a mapping row filter; an empty filter
+// streams them all.
+type Streamer interface {
+	StreamRows(ctx context.Context, table string, fn RowFunc) error
+	StreamFilteredRows(ctx context.Context, table, filter string, fn RowFunc) error
+}
+
+// Pinger is implemented by readers that can check their connection is
+// still alive, e.g. before a connection kept open between operations is
+// used again.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// IdentQuoter is implemented by readers that can quote a table or column
+// name as their database expects, for filters built outside the reader.
+type IdentQuoter interface {
+	QuoteIdent(name string) string
+}
+
+// QuoteIdent quotes name as r's database expects, or returns it as it is
+// when r cannot say how.
+func QuoteIdent(r interface{}, name string) string {
+	if q, ok := r.(IdentQuoter); ok {
+		return q.QuoteIdent(name)
+	}
+	return name
+}
+
+// Snapshotter is implemented by readers that can pin their reads to one
+// point in time, so tables read one after another are consistent with each
+// other. BeginSnapshot returns an identifier of that point (the transaction
+// snapshot on PostgreSQL, the SCN on Oracle); until EndSnapshot, every table
+// read sees the data as of it.
+type Snapshotter interface {
+	BeginSnapshot(ctx context.Context) (string, error)
+	EndSnapshot(ctx context.Context) error
+}
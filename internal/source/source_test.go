@@ -3,7 +3,11 @@ package source
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/schema"
 )
 
 func TestMockReader_Connect(t *testing.T) {
@@ -164,3 +168,266 @@ func TestMockReader_Errors(t *testing.T) {
 		}
 	})
 }
+
+func TestMockReader_StreamRows(t *testing.T) {
+	m := &MockReader{
+		TableRows: map[string][]map[string]interface{}{
+			"users": {{"id": 1}, {"id": 2}, {"id": 3}},
+		},
+	}
+	var got int
+	err := m.StreamRows(context.Background(), "users", func(row map[string]interface{}) error {
+		got++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 3 {
+		t.Errorf("streamed %d rows, want 3", got)
+	}
+}
+
+func TestMockReader_StreamRows_StopsOnError(t *testing.T) {
+	m := &MockReader{
+		TableRows: map[string][]map[string]interface{}{
+			"users": {{"id": 1}, {"id": 2}},
+		},
+	}
+	var got int
+	err := m.StreamRows(context.Background(), "users", func(row map[string]interface{}) error {
+		got++
+		return errors.New("stop")
+	})
+	if err == nil {
+		t.Error("expected error")
+	}
+	if got != 1 {
+		t.Errorf("streamed %d rows before stopping, want 1", got)
+	}
+}
+
+func TestMockReader_Filters(t *testing.T) {
+	m := &MockReader{
+		RowCounts: map[string]int64{"users": 3},
+		TableRows: map[string][]map[string]interface{}{
+			"users": {{"id": 1, "active": true}, {"id": 2, "active": false}, {"id": 3, "active": true}},
+		},
+		Filters: map[string]func(map[string]interface{}) bool{
+			"active": func(row map[string]interface{}) bool { return row["active"] == true },
+		},
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		filter  string
+		want    int64
+		wantErr bool
+	}{
+		{"", 3, false},
+		{"active", 2, false},
+		{"unknown", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			got, err := m.FilteredRowCount(ctx, "users", tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FilteredRowCount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	var ids []interface{}
+	err := m.StreamFilteredRows(ctx, "users", "active", func(row map[string]interface{}) error {
+		ids = append(ids, row["id"])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamFilteredRows: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("streamed ids %v, want [1 3]", ids)
+	}
+}
+
+func TestMockReader_ColumnMax(t *testing.T) {
+	t1 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	m := &MockReader{
+		TableRows: map[string][]map[string]interface{}{
+			"orders": {
+				{"id": 1, "updated_at": t2, "active": true},
+				{"id": 3, "updated_at": t1, "active": false},
+				{"id": 2, "updated_at": t1, "active": true},
+			},
+		},
+		Filters: map[string]func(map[string]interface{}) bool{
+			"active": func(row map[string]interface{}) bool { return row["active"] == true },
+		},
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		table, column, filter string
+		want                  interface{}
+	}{
+		{"orders", "id", "", int64(3)},
+		{"orders", "id", "active", int64(2)},
+		{"orders", "updated_at", "", t2},
+		{"empty", "id", "", nil},
+	}
+	for _, tt := range tests {
+		got, err := m.ColumnMax(ctx, tt.table, tt.column, tt.filter)
+		if err != nil {
+			t.Fatalf("ColumnMax(%s, %s, %q): %v", tt.table, tt.column, tt.filter, err)
+		}
+		if got != tt.want {
+			t.Errorf("ColumnMax(%s, %s, %q) = %v, want %v", tt.table, tt.column, tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestMockReader_ChildCounts(t *testing.T) {
+	perCustomer := []int{0, 0, 1, 1, 1, 2, 2, 3, 5, 20}
+	var customers, orders []map[string]interface{}
+	for i, n := range perCustomer {
+		customers = append(customers, map[string]interface{}{"id": i + 1, "active": true})
+		for j := 0; j < n; j++ {
+			orders = append(orders, map[string]interface{}{"customer_id": i + 1})
+		}
+	}
+	// an inactive customer's orders and orphans are not counted
+	customers = append(customers, map[string]interface{}{"id": 11, "active": false})
+	orders = append(orders, map[string]interface{}{"customer_id": 11}, map[string]interface{}{"customer_id": 99})
+
+	m := &MockReader{
+		TableRows: map[string][]map[string]interface{}{"customers": customers, "orders": orders},
+		Filters: map[string]func(map[string]interface{}) bool{
+			"active": func(row map[string]interface{}) bool { return row["active"] == true },
+		},
+	}
+	got, err := m.ChildCounts(context.Background(), Reference{
+		Table: "orders", Columns: []string{"customer_id"},
+		ParentTable: "customers", ParentColumns: []string{"id"}, ParentFilter: "active",
+	})
+	if err != nil {
+		t.Fatalf("ChildCounts: %v", err)
+	}
+	want := schema.ChildCounts{Parents: 10, Children: 35, Avg: 3.5, P50: 1, P90: 5, P99: 20, Max: 20}
+	if got != want {
+		t.Errorf("ChildCounts = %+v, want %+v", got, want)
+	}
+
+	got, err = m.ChildCounts(context.Background(), Reference{Table: "orders", Columns: []string{"customer_id"}, ParentTable: "none", ParentColumns: []string{"id"}})
+	if err != nil || got != (schema.ChildCounts{}) {
+		t.Errorf("ChildCounts without parents = %+v, %v; want zero", got, err)
+	}
+}
+
+func TestMockReader_CompositeKey(t *testing.T) {
+	m := &MockReader{TableRows: map[string][]map[string]interface{}{
+		"orders": {
+			{"tenant_id": 1, "id": 1},
+			{"tenant_id": 2, "id": 1},
+			{"tenant_id": 2, "id": 2},
+		},
+		"order_items": {
+			{"tenant_id": 1, "order_id": 1},
+			{"tenant_id": 1, "order_id": 1},
+			{"tenant_id": 2, "order_id": 1},
+			{"tenant_id": 1, "order_id": 2}, // no such order
+			{"tenant_id": nil, "order_id": 1},
+		},
+	}}
+	ref := Reference{
+		Table: "order_items", Columns: []string{"tenant_id", "order_id"},
+		ParentTable: "orders", ParentColumns: []string{"tenant_id", "id"},
+	}
+	n, err := m.ReferencedRowCount(context.Background(), ref)
+	if err != nil || n != 3 {
+		t.Errorf("ReferencedRowCount = %d, %v; want 3", n, err)
+	}
+	got, err := m.ChildCounts(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("ChildCounts: %v", err)
+	}
+	if got.Parents != 3 || got.Children != 3 || got.Max != 2 {
+		t.Errorf("ChildCounts = %+v, want 3 parents, 3 children, max 2", got)
+	}
+}
+
+func TestChildCountsQuery(t *testing.T) {
+	q := childCountsQuery(2, "SELECT a AS k0, b AS k1 FROM p", "SELECT x AS k0, y AS k1 FROM c")
+	for _, want := range []string{
+		"SELECT p.k0, p.k1, COUNT(c.k0) AS n",
+		"ON c.k0 = p.k0 AND c.k1 = p.k1",
+		"GROUP BY p.k0, p.k1) t",
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("query missing %q:\n%s", want, q)
+		}
+	}
+	if got := whereNotNull([]string{`"a"`, `"b"`}, "active"); got != ` WHERE "a" IS NOT NULL AND "b" IS NOT NULL AND (active)` {
+		t.Errorf("whereNotNull = %q", got)
+	}
+}
+
+func TestMockReader_FoldKeys(t *testing.T) {
+	m := &MockReader{
+		FoldKeys: true,
+		TableRows: map[string][]map[string]interface{}{
+			"customers": {{"code": "acme"}, {"code": "Globex"}},
+			"orders":    {{"customer": "acme"}, {"customer": "ACME"}, {"customer": "globex"}, {"customer": "initech"}},
+		},
+	}
+	ref := Reference{Table: "orders", Columns: []string{"customer"}, ParentTable: "customers", ParentColumns: []string{"code"}}
+	for _, tt := range []struct {
+		match string
+		want  int64
+	}{
+		{MatchCollation, 3},
+		{MatchBinary, 1},
+		{MatchLower, 3},
+	} {
+		ref.Match = tt.match
+		got, err := m.ReferencedRowCount(context.Background(), ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("ReferencedRowCount(%q) = %d, want %d", tt.match, got, tt.want)
+		}
+	}
+
+	keys := matchKeys([]string{`"a"`}, MatchLower, `CAST(%s AS text)`, `lower(%s)`)
+	if len(keys) != 1 || keys[0] != `lower("a")` {
+		t.Errorf("matchKeys = %v", keys)
+	}
+	if keys := matchKeys([]string{`"a"`}, MatchCollation, "x(%s)", "y(%s)"); keys[0] != `"a"` {
+		t.Errorf("matchKeys kept collation = %v", keys)
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		name   string
+		reader interface{}
+		ident  string
+		want   string
+	}{
+		{"postgres mixed case", &PostgresReader{}, "OrderId", `"OrderId"`},
+		{"oracle reserved word", &OracleReader{}, "LEVEL", `"LEVEL"`},
+		{"embedded quote", &PostgresReader{}, `a"b`, `"a""b"`},
+		{"mock", &MockReader{}, "id", "id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QuoteIdent(tt.reader, tt.ident); got != tt.want {
+				t.Errorf("QuoteIdent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package state
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// BundleManifestName is the first entry of a project bundle, describing
+// where it came from.
+const BundleManifestName = "bundle.yaml"
+
+// BundleManifest records the project a bundle was exported from, so an
+// import on another host can relocate the paths in its state.
+type BundleManifest struct {
+	Project         string    `yaml:"project" json:"project"`
+	Dir             string    `yaml:"dir" json:"dir"`
+	Host            string    `yaml:"host" json:"host"`
+	ExportedAt      time.Time `yaml:"exported_at" json:"exported_at"`
+	MigrationStatus string    `yaml:"migration_status,omitempty" json:"migration_status,omitempty"`
+}
+
+// ExportBundle writes the project's directory as a gzipped tar: the state,
+// or the metadata database holding it, with the schema, mapping, type map,
+// sizing plan, reports and records, so another host can import the project
+// and take over its migration. The default project's directory also holds
+// the named projects, which are left out.
+func ExportBundle(p *Project, w io.Writer) (*BundleManifest, error) {
+	st, err := Load(p.StatePath())
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	manifest := &BundleManifest{
+		Project:         p.Name,
+		Dir:             p.Dir,
+		Host:            host,
+		ExportedAt:      time.Now().UTC(),
+		MigrationStatus: st.MigrationStatus,
+	}
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling bundle manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: BundleManifestName, Mode: 0o644, Size: int64(len(data)), ModTime: manifest.ExportedAt}); err != nil {
+		return nil, fmt.Errorf("writing bundle: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, fmt.Errorf("writing bundle: %w", err)
+	}
+
+	skip := map[string]bool{}
+	if p.Name == DefaultProject {
+		skip[filepath.Base(config.ExpandHome(ProjectsDir))] = true
+		skip[filepath.Base(currentProjectPath)] = true
+	}
+	err = filepath.WalkDir(p.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(p.Dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if skip[rel] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("writing bundle: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("writing bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("writing bundle: %w", err)
+	}
+	return manifest, nil
+}
+
+// ImportBundle extracts a bundle written by ExportBundle into the project
+// named name, or the exported project's name when empty, and points the
+// paths in its state at the new directory. The project must not already
+// have state, so an import never overwrites a migration in progress.
+func ImportBundle(r io.Reader, name string) (*Project, *BundleManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading bundle: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != BundleManifestName {
+		return nil, nil, fmt.Errorf("reading bundle: %s not found; not a project bundle", BundleManifestName)
+	}
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading bundle: %w", err)
+	}
+	var manifest BundleManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("parsing bundle manifest: %w", err)
+	}
+
+	if name == "" {
+		name = manifest.Project
+	}
+	if err := ValidateProjectName(name); err != nil {
+		return nil, nil, err
+	}
+	p := projectFor(name)
+	if hasState(p.Dir) {
+		return nil, nil, fmt.Errorf("project %q already has state; import into a new project with --name", name)
+	}
+	if err := os.MkdirAll(p.Dir, 0o755); err != nil {
+		return nil, nil, fmt.Errorf("creating project directory: %w", err)
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		rel := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(rel) {
+			return nil, nil, fmt.Errorf("reading bundle: entry %q is outside the project", hdr.Name)
+		}
+		if err := extractFile(filepath.Join(p.Dir, rel), tr, hdr.FileInfo().Mode().Perm()); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	st, err := Load(p.StatePath())
+	if err != nil {
+		return nil, nil, err
+	}
+	st.relocate(manifest.Dir, p.Dir)
+	if err := st.Save(p.StatePath()); err != nil {
+		return nil, nil, err
+	}
+	return p, &manifest, nil
+}
+
+// hasState reports whether a project directory holds a state file or a
+// metadata database.
+func hasState(dir string) bool {
+	if HasSQLite(dir) {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dir, "state.yaml"))
+	return err == nil
+}
+
+func extractFile(path string, r io.Reader, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("extracting bundle: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("extracting bundle: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("extracting %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// relocate rewrites the artifact paths under from to the same files under
+// to. Paths outside from are left alone.
+func (s *State) relocate(from, to string) {
+	if from == "" || from == to {
+		return
+	}
+	for _, path := range []*string{
+		&s.SchemaPath, &s.MappingPath, &s.TypeMappingPath, &s.ConfigPath,
+		&s.SizingPlanPath, &s.ShardingPlanPath, &s.BenchmarkPath, &s.SourceImpactPath,
+		&s.WriteBenchmarkPath, &s.ValidationReportPath, &s.IndexPlanPath, &s.ReportPath,
+		&s.ViewScriptsDir, &s.CanaryReportPath, &s.IDGenerationDocPath, &s.DoctorReportPath,
+	} {
+		if rel, err := filepath.Rel(from, *path); err == nil && *path != "" && filepath.IsLocal(rel) {
+			*path = filepath.Join(to, rel)
+		}
+	}
+}
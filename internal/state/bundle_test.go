@@ -0,0 +1,107 @@
+package state
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundle_ExportImport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	p, err := CreateProject("orders")
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	st := New()
+	st.SchemaPath = p.Path("schema.yaml")
+	st.ConfigPath = "/etc/reloquent.yaml"
+	st.MigrationStatus = "running"
+	st.RecordPartition("orders", 2, PartitionCheckpoint{Index: 0, Lower: 1, Upper: 50})
+	if err := st.Save(p.StatePath()); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	os.WriteFile(st.SchemaPath, []byte("tables: []\n"), 0o644)
+	os.MkdirAll(p.Path("snapshots"), 0o755)
+	os.WriteFile(filepath.Join(p.Path("snapshots"), "1.yaml"), []byte("x\n"), 0o644)
+
+	var buf bytes.Buffer
+	manifest, err := ExportBundle(p, &buf)
+	if err != nil {
+		t.Fatalf("ExportBundle: %v", err)
+	}
+	if manifest.Project != "orders" || manifest.Dir != p.Dir || manifest.MigrationStatus != "running" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+
+	// Another host, with another home directory
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	bundle := buf.Bytes()
+	imported, got, err := ImportBundle(bytes.NewReader(bundle), "")
+	if err != nil {
+		t.Fatalf("ImportBundle: %v", err)
+	}
+	if imported.Name != "orders" || !strings.HasPrefix(imported.Dir, home) || got.Host == "" {
+		t.Errorf("project = %+v, manifest = %+v", imported, got)
+	}
+	loaded, err := Load(imported.StatePath())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.SchemaPath != imported.Path("schema.yaml") {
+		t.Errorf("schema path = %q, want it relocated to %q", loaded.SchemaPath, imported.Path("schema.yaml"))
+	}
+	if loaded.ConfigPath != "/etc/reloquent.yaml" {
+		t.Errorf("config path = %q, want paths outside the project kept", loaded.ConfigPath)
+	}
+	if cp := loaded.Checkpoints["orders"]; cp == nil || len(cp.Completed) != 1 {
+		t.Errorf("checkpoints = %+v, want the exported partition", loaded.Checkpoints)
+	}
+	if _, err := os.Stat(filepath.Join(imported.Dir, "snapshots", "1.yaml")); err != nil {
+		t.Errorf("nested file not extracted: %v", err)
+	}
+
+	if _, _, err := ImportBundle(bytes.NewReader(bundle), ""); err == nil {
+		t.Error("expected an error importing over a project with state")
+	}
+	if renamed, _, err := ImportBundle(bytes.NewReader(bundle), "orders-takeover"); err != nil || renamed.Name != "orders-takeover" {
+		t.Errorf("import under another name: project = %+v, err = %v", renamed, err)
+	}
+}
+
+func TestImportBundle_Rejects(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	bundle := func(names ...string) *bytes.Buffer {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for _, name := range names {
+			data := []byte("project: evil\n")
+			tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg})
+			tw.Write(data)
+		}
+		tw.Close()
+		gz.Close()
+		return &buf
+	}
+
+	tests := []struct {
+		name string
+		r    *bytes.Buffer
+	}{
+		{"not gzip", bytes.NewBufferString("plain text")},
+		{"no manifest", bundle("state.yaml")},
+		{"path traversal", bundle(BundleManifestName, "../../escape.yaml")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ImportBundle(tt.r, ""); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package state
+
+import "time"
+
+// Checkpoint records how far the migration of one collection got, so a
+// retry can skip the partitions that already finished instead of re-running
+// the whole collection.
+type Checkpoint struct {
+	// Partitions is the number of partitions the collection was split into;
+	// zero until the first partition completes.
+	Partitions int                   `yaml:"partitions,omitempty"`
+	Completed  []PartitionCheckpoint `yaml:"completed,omitempty"`
+	// Done is set once every partition has been written.
+	Done bool `yaml:"done,omitempty"`
+	// RowsSkipped is the number of source rows the collection's error
+	// policy skipped in the last full run.
+	RowsSkipped int64 `yaml:"rows_skipped,omitempty"`
+	// Snapshot identifies the source snapshot a collection of a consistency
+	// group was read from, if the source could pin one.
+	Snapshot  string    `yaml:"snapshot,omitempty"`
+	UpdatedAt time.Time `yaml:"updated_at,omitempty"`
+}
+
+// PartitionCheckpoint is a completed partition covering the root table's
+// partition column values in [Lower, Upper).
+type PartitionCheckpoint struct {
+	Index int   `yaml:"index"`
+	Lower int64 `yaml:"lower"`
+	Upper int64 `yaml:"upper"`
+}
+
+// RecordPartition marks a partition of a collection as migrated. Once all
+// partitions are recorded the collection is done.
+func (s *State) RecordPartition(collection string, partitions int, p PartitionCheckpoint) {
+	cp := s.checkpoint(collection)
+	cp.Partitions = partitions
+	for _, done := range cp.Completed {
+		if done == p {
+			return
+		}
+	}
+	cp.Completed = append(cp.Completed, p)
+	cp.Done = len(cp.Completed) >= partitions
+	cp.UpdatedAt = time.Now()
+}
+
+// CompleteCollection marks a whole collection as migrated.
+func (s *State) CompleteCollection(collection string) {
+	cp := s.checkpoint(collection)
+	cp.Done = true
+	cp.UpdatedAt = time.Now()
+}
+
+// RecordSnapshot records the source snapshot a collection was read from.
+func (s *State) RecordSnapshot(collection, snapshot string) {
+	if snapshot == "" {
+		return
+	}
+	cp := s.checkpoint(collection)
+	cp.Snapshot = snapshot
+	cp.UpdatedAt = time.Now()
+}
+
+// Snapshots returns the source snapshot recorded for each collection that
+// has one.
+func (s *State) Snapshots() map[string]string {
+	snaps := make(map[string]string)
+	for name, cp := range s.Checkpoints {
+		if cp.Snapshot != "" {
+			snaps[name] = cp.Snapshot
+		}
+	}
+	return snaps
+}
+
+// RecordRowsSkipped records how many source rows a full run of a
+// collection skipped as its error policy says.
+func (s *State) RecordRowsSkipped(collection string, n int64) {
+	cp := s.checkpoint(collection)
+	cp.RowsSkipped = n
+	cp.UpdatedAt = time.Now()
+}
+
+// RowsSkipped returns the rows skipped for each collection that skipped
+// some.
+func (s *State) RowsSkipped() map[string]int64 {
+	skipped := make(map[string]int64)
+	for name, cp := range s.Checkpoints {
+		if cp.RowsSkipped > 0 {
+			skipped[name] = cp.RowsSkipped
+		}
+	}
+	return skipped
+}
+
+// RemovePartition forgets a partition recorded as migrated, so a resumed run
+// writes it again.
+func (s *State) RemovePartition(collection string, p PartitionCheckpoint) {
+	cp, ok := s.Checkpoints[collection]
+	if !ok {
+		return
+	}
+	kept := cp.Completed[:0]
+	for _, done := range cp.Completed {
+		if done != p {
+			kept = append(kept, done)
+		}
+	}
+	cp.Completed = kept
+	cp.Done = false
+	cp.UpdatedAt = time.Now()
+}
+
+// ClearCollection forgets everything recorded for a collection, so a
+// resumed run migrates it from scratch.
+func (s *State) ClearCollection(collection string) {
+	delete(s.Checkpoints, collection)
+}
+
+// CollectionDone reports whether a collection has been fully migrated.
+func (s *State) CollectionDone(collection string) bool {
+	cp, ok := s.Checkpoints[collection]
+	return ok && cp.Done
+}
+
+// ResetCheckpoints discards all checkpoints before a fresh migration.
+func (s *State) ResetCheckpoints() {
+	s.Checkpoints = nil
+}
+
+func (s *State) checkpoint(collection string) *Checkpoint {
+	if s.Checkpoints == nil {
+		s.Checkpoints = make(map[string]*Checkpoint)
+	}
+	cp, ok := s.Checkpoints[collection]
+	if !ok {
+		cp = &Checkpoint{}
+		s.Checkpoints[collection] = cp
+	}
+	return cp
+}
@@ -0,0 +1,98 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// FormatVersion is the layout of state files this build writes. Bump it,
+// and add an upgrade from the previous version, whenever a change to State
+// would be misread by older builds.
+const FormatVersion = 1
+
+// ErrNewerFormat is returned when a state file was written by a newer
+// Reloquent than this one.
+var ErrNewerFormat = errors.New("state written by a newer version of reloquent")
+
+// upgrade moves a state from format From to From+1.
+type upgrade struct {
+	From  int
+	Apply func(*State) error
+}
+
+// upgrades lists the state format upgrades in order. Files without a
+// format_version are format 1.
+var upgrades []upgrade
+
+// checkFormat refuses states from a newer build and upgrades older ones in
+// memory, remembering the original format so Save can back the file up
+// before writing it in the current format.
+func (s *State) checkFormat() error {
+	return s.upgradeTo(FormatVersion)
+}
+
+func (s *State) upgradeTo(latest int) error {
+	if s.FormatVersion == 0 {
+		s.FormatVersion = 1
+	}
+	if s.FormatVersion > latest {
+		return fmt.Errorf("%w: format %d, this build reads up to %d; upgrade with `reloquent self-update`",
+			ErrNewerFormat, s.FormatVersion, latest)
+	}
+	from := s.FormatVersion
+	for _, u := range upgrades {
+		if u.From != s.FormatVersion {
+			continue
+		}
+		if err := u.Apply(s); err != nil {
+			return fmt.Errorf("upgrading state from format %d: %w", u.From, err)
+		}
+		s.FormatVersion = u.From + 1
+	}
+	if s.FormatVersion != latest {
+		return fmt.Errorf("no upgrade for state format %d", s.FormatVersion)
+	}
+	if from != latest {
+		s.upgradedFrom = from
+	}
+	return nil
+}
+
+// UpgradedFrom returns the format the state was upgraded from when it was
+// loaded, or 0 when it was already current.
+func (s *State) UpgradedFrom() int {
+	return s.upgradedFrom
+}
+
+// backupPath is where the state file of an older format is kept once it is
+// first saved in the current one.
+func backupPath(path string, format int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, format)
+}
+
+// backupOldFormat copies the state file at path aside before an upgraded
+// state overwrites it. An existing backup is kept.
+func (s *State) backupOldFormat(path string) error {
+	if s.upgradedFrom == 0 {
+		return nil
+	}
+	backup := backupPath(path, s.upgradedFrom)
+	if _, err := os.Stat(backup); err == nil {
+		s.upgradedFrom = 0
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.upgradedFrom = 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading state for backup: %w", err)
+	}
+	if err := os.WriteFile(backup, data, 0o644); err != nil {
+		return fmt.Errorf("backing up state: %w", err)
+	}
+	s.upgradedFrom = 0
+	return nil
+}
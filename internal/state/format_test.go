@@ -0,0 +1,106 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeState(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Format(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+	}{
+		{name: "no format version", content: "current_step: sizing\n"},
+		{name: "current", content: "format_version: 1\ncurrent_step: sizing\n"},
+		{name: "newer", content: "format_version: 99\ncurrent_step: sizing\n", wantErr: ErrNewerFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Load(writeState(t, tt.content))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), "self-update") {
+					t.Errorf("error should say how to upgrade: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.FormatVersion != FormatVersion || s.CurrentStep != StepSizing || s.UpgradedFrom() != 0 {
+				t.Errorf("state = %+v", s)
+			}
+		})
+	}
+}
+
+func TestUpgradeTo(t *testing.T) {
+	// Pretend this build is a format ahead, with an upgrade that renames a
+	// step.
+	saved := upgrades
+	upgrades = []upgrade{{From: 1, Apply: func(s *State) error {
+		if s.CurrentStep == "old_sizing" {
+			s.CurrentStep = StepSizing
+		}
+		return nil
+	}}}
+	defer func() { upgrades = saved }()
+
+	s := &State{CurrentStep: "old_sizing"}
+	if err := s.upgradeTo(2); err != nil {
+		t.Fatal(err)
+	}
+	if s.CurrentStep != StepSizing || s.FormatVersion != 2 || s.UpgradedFrom() != 1 {
+		t.Errorf("upgraded state = %+v", s)
+	}
+
+	if err := (&State{FormatVersion: 1}).upgradeTo(3); err == nil {
+		t.Error("a gap in the upgrades should fail")
+	}
+}
+
+func TestSave_BacksUpOlderFormat(t *testing.T) {
+	const original = "current_step: sizing\n"
+	path := writeState(t, original)
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.upgradedFrom = 1 // as if loaded from an older format
+
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := os.ReadFile(backupPath(path, 1))
+	if err != nil || string(backup) != original {
+		t.Fatalf("backup = %q, %v; want the original file", backup, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "format_version: 1") {
+		t.Errorf("saved state should record its format:\n%s", data)
+	}
+
+	// Later saves leave the backup alone
+	s.CurrentStep = StepMigration
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if backup, _ := os.ReadFile(backupPath(path, 1)); string(backup) != original {
+		t.Errorf("backup overwritten: %q", backup)
+	}
+}
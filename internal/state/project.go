@@ -0,0 +1,166 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/config"
+)
+
+const (
+	// DefaultProject keeps its files directly in ~/.reloquent, where they
+	// lived before projects existed.
+	DefaultProject = "default"
+
+	// ProjectsDir holds one directory per named project.
+	ProjectsDir = "~/.reloquent/projects"
+
+	currentProjectPath = "~/.reloquent/current-project"
+)
+
+var projectNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Project is a named migration with its own state, schema, mapping, type
+// map, sizing plan and reports, all kept in Dir.
+type Project struct {
+	Name string `yaml:"name" json:"name"`
+	Dir  string `yaml:"dir" json:"dir"`
+}
+
+// StatePath returns the project's state file.
+func (p *Project) StatePath() string {
+	return filepath.Join(p.Dir, "state.yaml")
+}
+
+// Path returns the path of an artifact file in the project directory.
+func (p *Project) Path(name string) string {
+	return filepath.Join(p.Dir, name)
+}
+
+// ValidateProjectName checks that a name is safe to use as a directory.
+func ValidateProjectName(name string) error {
+	if !projectNameRe.MatchString(name) {
+		return fmt.Errorf("invalid project name %q: use letters, digits, '.', '-' and '_'", name)
+	}
+	return nil
+}
+
+// projectFor returns the project with the given name, whether or not it has
+// been created.
+func projectFor(name string) *Project {
+	if name == DefaultProject {
+		return &Project{Name: name, Dir: config.ExpandHome("~/.reloquent")}
+	}
+	return &Project{Name: name, Dir: filepath.Join(config.ExpandHome(ProjectsDir), name)}
+}
+
+// OpenProject returns an existing project. An empty name opens the current
+// project.
+func OpenProject(name string) (*Project, error) {
+	if name == "" {
+		name = CurrentProject()
+	}
+	if err := ValidateProjectName(name); err != nil {
+		return nil, err
+	}
+	p := projectFor(name)
+	if name != DefaultProject {
+		if _, err := os.Stat(p.Dir); err != nil {
+			return nil, fmt.Errorf("project %q does not exist; create it with `reloquent project create %s`", name, name)
+		}
+	}
+	return p, nil
+}
+
+// CreateProject creates the directory of a new project.
+func CreateProject(name string) (*Project, error) {
+	if err := ValidateProjectName(name); err != nil {
+		return nil, err
+	}
+	if name == DefaultProject {
+		return nil, fmt.Errorf("project %q always exists", name)
+	}
+	p := projectFor(name)
+	if _, err := os.Stat(p.Dir); err == nil {
+		return nil, fmt.Errorf("project %q already exists", name)
+	}
+	if err := os.MkdirAll(p.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating project directory: %w", err)
+	}
+	return p, nil
+}
+
+// ListProjects returns the default project followed by the named projects
+// in alphabetical order.
+func ListProjects() ([]Project, error) {
+	projects := []Project{*projectFor(DefaultProject)}
+	entries, err := os.ReadDir(config.ExpandHome(ProjectsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return projects, nil
+		}
+		return nil, fmt.Errorf("listing projects: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && ValidateProjectName(e.Name()) == nil && e.Name() != DefaultProject {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		projects = append(projects, *projectFor(n))
+	}
+	return projects, nil
+}
+
+// CurrentProject returns the name of the project selected with
+// SwitchProject, or DefaultProject.
+func CurrentProject() string {
+	data, err := os.ReadFile(config.ExpandHome(currentProjectPath))
+	if err != nil {
+		return DefaultProject
+	}
+	name := strings.TrimSpace(string(data))
+	if ValidateProjectName(name) != nil {
+		return DefaultProject
+	}
+	return name
+}
+
+// SwitchProject makes an existing project the current one for later runs.
+func SwitchProject(name string) error {
+	if _, err := OpenProject(name); err != nil {
+		return err
+	}
+	path := config.ExpandHome(currentProjectPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	return os.WriteFile(path, []byte(name+"\n"), 0o644)
+}
+
+var active *Project
+
+// Use makes p the project whose state Load and Save read and write when
+// given an empty path, for the rest of the process.
+func Use(p *Project) {
+	active = p
+}
+
+// Active returns the project passed to Use, or else the current project.
+// A current project whose directory was removed falls back to the default.
+func Active() *Project {
+	if active != nil {
+		return active
+	}
+	p, err := OpenProject("")
+	if err != nil {
+		return projectFor(DefaultProject)
+	}
+	return p
+}
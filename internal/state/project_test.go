@@ -0,0 +1,78 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProjects(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Cleanup(func() { Use(nil) })
+
+	if got := Active(); got.Name != DefaultProject || got.Dir != filepath.Join(home, ".reloquent") {
+		t.Fatalf("Active() = %+v, want the default project in ~/.reloquent", got)
+	}
+
+	for _, name := range []string{"", "../x", ".hidden", "a/b", DefaultProject} {
+		if _, err := CreateProject(name); err == nil {
+			t.Errorf("CreateProject(%q) succeeded, want error", name)
+		}
+	}
+
+	p, err := CreateProject("orders-2024")
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	if want := filepath.Join(home, ".reloquent", "projects", "orders-2024"); p.Dir != want {
+		t.Errorf("Dir = %q, want %q", p.Dir, want)
+	}
+	if _, err := CreateProject("orders-2024"); err == nil {
+		t.Error("creating an existing project succeeded, want error")
+	}
+	if _, err := CreateProject("billing"); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+
+	projects, err := ListProjects()
+	if err != nil {
+		t.Fatalf("ListProjects: %v", err)
+	}
+	var names []string
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+	if len(names) != 3 || names[0] != DefaultProject || names[1] != "billing" || names[2] != "orders-2024" {
+		t.Errorf("ListProjects = %v, want [default billing orders-2024]", names)
+	}
+
+	if err := SwitchProject("missing"); err == nil {
+		t.Error("SwitchProject to a missing project succeeded, want error")
+	}
+	if err := SwitchProject("billing"); err != nil {
+		t.Fatalf("SwitchProject: %v", err)
+	}
+	if got := CurrentProject(); got != "billing" {
+		t.Errorf("CurrentProject = %q, want billing", got)
+	}
+	if got := Active().Name; got != "billing" {
+		t.Errorf("Active().Name = %q, want billing", got)
+	}
+
+	// Use overrides the current project for this process only.
+	Use(p)
+	st := &State{CurrentStep: StepTypeMapping}
+	if err := st.Save(""); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(p.StatePath())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.CurrentStep != StepTypeMapping {
+		t.Errorf("CurrentStep = %q, want %q", loaded.CurrentStep, StepTypeMapping)
+	}
+	if got := CurrentProject(); got != "billing" {
+		t.Errorf("CurrentProject after Use = %q, want billing", got)
+	}
+}
@@ -0,0 +1,24 @@
+package state
+
+import "time"
+
+// Remediation records what was decided for a collection that failed
+// validation: re-migrate it, adjust the mapping, or accept the variance.
+type Remediation struct {
+	Action        string    `yaml:"action" json:"action"`
+	Justification string    `yaml:"justification,omitempty" json:"justification,omitempty"`
+	Causes        []string  `yaml:"causes,omitempty" json:"causes,omitempty"` // the diagnosed causes when it was decided
+	DecidedAt     time.Time `yaml:"decided_at" json:"decided_at"`
+}
+
+// SetRemediation records the decision for a collection, replacing any
+// earlier one.
+func (s *State) SetRemediation(collection string, r Remediation) {
+	if s.Remediations == nil {
+		s.Remediations = make(map[string]Remediation)
+	}
+	if r.DecidedAt.IsZero() {
+		r.DecidedAt = time.Now()
+	}
+	s.Remediations[collection] = r
+}
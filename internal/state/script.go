@@ -0,0 +1,18 @@
+package state
+
+import "time"
+
+// GeneratedScript records the migration script last written by
+// `reloquent generate` and the hash of the mapping and type mapping it was
+// generated from.
+type GeneratedScript struct {
+	Path        string    `yaml:"path" json:"path"`
+	MappingHash string    `yaml:"mapping_hash" json:"mapping_hash"`
+	GeneratedAt time.Time `yaml:"generated_at" json:"generated_at"`
+}
+
+// SetGeneratedScript records a generated migration script, replacing any
+// earlier one.
+func (s *State) SetGeneratedScript(path, mappingHash string) {
+	s.GeneratedScript = &GeneratedScript{Path: path, MappingHash: mappingHash, GeneratedAt: time.Now()}
+}
@@ -0,0 +1,453 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// DBName is the metadata database of a project that has moved to SQLite.
+const DBName = "metadata.db"
+
+// sqliteDriver is the database/sql driver the store opens. Builds with the
+// sqlite tag link it in; see sqlite_driver.go.
+const sqliteDriver = "sqlite"
+
+// stateVersions is how many saved states the database keeps, newest first.
+const stateVersions = 50
+
+// timeFormat sorts as text, so records can be ordered by their timestamps.
+const timeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// ErrNoSQLite is returned when a project uses SQLite but this build was
+// compiled without a driver.
+var ErrNoSQLite = errors.New("this build of reloquent has no SQLite support (build with -tags sqlite)")
+
+// schema lists the database migrations in order; PRAGMA user_version
+// records how many have been applied.
+var schema = []string{
+	`CREATE TABLE state (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		saved_at TEXT NOT NULL,
+		format_version INTEGER NOT NULL,
+		current_step TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE TABLE runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		command TEXT NOT NULL,
+		status TEXT NOT NULL,
+		started_at TEXT NOT NULL,
+		finished_at TEXT NOT NULL,
+		report TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE audit_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		time TEXT NOT NULL,
+		action TEXT NOT NULL,
+		detail TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX audit_events_time ON audit_events (time);
+	CREATE TABLE jobs (
+		id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		status TEXT NOT NULL,
+		started_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		detail TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX jobs_started_at ON jobs (started_at);`,
+	`CREATE TABLE throughput (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		run_id INTEGER NOT NULL,
+		time TEXT NOT NULL,
+		collection TEXT NOT NULL,
+		docs_written INTEGER NOT NULL,
+		bytes_written INTEGER NOT NULL,
+		rows_per_sec REAL NOT NULL,
+		mb_per_sec REAL NOT NULL
+	);
+	CREATE INDEX throughput_run_id ON throughput (run_id);`,
+}
+
+// HasSQLite reports whether the project in dir keeps its metadata in SQLite.
+func HasSQLite(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, DBName))
+	return err == nil
+}
+
+// SQLiteAvailable reports whether this build can open SQLite databases.
+func SQLiteAvailable() bool {
+	return slices.Contains(sql.Drivers(), sqliteDriver)
+}
+
+// SQLiteStore keeps a project's metadata in a SQLite database, so several
+// processes can read and write it at once. Every saved state is kept as a
+// new row, up to stateVersions of them.
+type SQLiteStore struct {
+	db   *sql.DB
+	path string
+}
+
+// OpenSQLite opens, creating if needed, the metadata database of the
+// project in dir.
+func OpenSQLite(dir string) (*SQLiteStore, error) {
+	return openSQLite(filepath.Join(dir, DBName))
+}
+
+func openSQLite(path string) (*SQLiteStore, error) {
+	if !SQLiteAvailable() {
+		return nil, ErrNoSQLite
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating state directory: %w", err)
+	}
+	db, err := sql.Open(sqliteDriver, "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	s := &SQLiteStore{db: db, path: path}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *SQLiteStore) migrate() error {
+	var applied int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&applied); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	if applied > len(schema) {
+		return fmt.Errorf("%w: database schema %d, this build reads up to %d; upgrade with `reloquent self-update`",
+			ErrNewerFormat, applied, len(schema))
+	}
+	for i := applied; i < len(schema); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(schema[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("applying schema %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("applying schema %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("applying schema %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// LoadState returns the most recently saved state.
+func (s *SQLiteStore) LoadState() (*State, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM state ORDER BY id DESC LIMIT 1`).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading state: %w", err)
+	}
+	st, err := parse([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	return st, nil
+}
+
+// SaveState adds st as the newest state, dropping the oldest beyond
+// stateVersions. The previous rows stand in for the backup a file store
+// makes when it upgrades a state's format.
+func (s *SQLiteStore) SaveState(st *State) error {
+	data, err := st.marshal()
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT INTO state (saved_at, format_version, current_step, data) VALUES (?, ?, ?, ?)`,
+		formatTime(st.LastUpdated), st.FormatVersion, string(st.CurrentStep), string(data)); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM state WHERE id <= (SELECT MAX(id) FROM state) - ?`, stateVersions); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+	st.upgradedFrom = 0
+	return nil
+}
+
+func (s *SQLiteStore) AddRun(r RunRecord) (int64, error) {
+	res, err := s.db.Exec(`INSERT INTO runs (command, status, started_at, finished_at, report) VALUES (?, ?, ?, ?, ?)`,
+		r.Command, r.Status, formatTime(r.StartedAt), formatTime(r.FinishedAt), r.Report)
+	if err != nil {
+		return 0, fmt.Errorf("recording run: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("recording run: %w", err)
+	}
+	return id, nil
+}
+
+func (s *SQLiteStore) UpdateRun(r RunRecord) error {
+	_, err := s.db.Exec(`UPDATE runs SET status = ?, finished_at = ?, report = ? WHERE id = ?`,
+		r.Status, formatTime(r.FinishedAt), r.Report, r.ID)
+	if err != nil {
+		return fmt.Errorf("recording run: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Runs(limit int) ([]RunRecord, error) {
+	rows, err := s.db.Query(`SELECT id, command, status, started_at, finished_at, report FROM runs ORDER BY id DESC LIMIT ?`, sqlLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("reading runs: %w", err)
+	}
+	defer rows.Close()
+	var runs []RunRecord
+	for rows.Next() {
+		var r RunRecord
+		var started, finished string
+		if err := rows.Scan(&r.ID, &r.Command, &r.Status, &started, &finished, &r.Report); err != nil {
+			return nil, fmt.Errorf("reading runs: %w", err)
+		}
+		r.StartedAt, r.FinishedAt = parseTime(started), parseTime(finished)
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+func (s *SQLiteStore) AddAudit(ev AuditEvent) error {
+	_, err := s.db.Exec(`INSERT INTO audit_events (time, action, detail) VALUES (?, ?, ?)`,
+		formatTime(ev.Time), ev.Action, ev.Detail)
+	if err != nil {
+		return fmt.Errorf("recording audit event: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Audit(limit int) ([]AuditEvent, error) {
+	rows, err := s.db.Query(`SELECT time, action, detail FROM audit_events ORDER BY id DESC LIMIT ?`, sqlLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("reading audit events: %w", err)
+	}
+	defer rows.Close()
+	var events []AuditEvent
+	for rows.Next() {
+		var ev AuditEvent
+		var t string
+		if err := rows.Scan(&t, &ev.Action, &ev.Detail); err != nil {
+			return nil, fmt.Errorf("reading audit events: %w", err)
+		}
+		ev.Time = parseTime(t)
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+func (s *SQLiteStore) SaveJob(j Job) error {
+	_, err := s.db.Exec(`INSERT INTO jobs (id, kind, status, started_at, updated_at, detail) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET status = excluded.status, updated_at = excluded.updated_at, detail = excluded.detail`,
+		j.ID, j.Kind, j.Status, formatTime(j.StartedAt), formatTime(j.UpdatedAt), j.Detail)
+	if err != nil {
+		return fmt.Errorf("recording job: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Jobs() ([]Job, error) {
+	rows, err := s.db.Query(`SELECT id, kind, status, started_at, updated_at, detail FROM jobs ORDER BY started_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("reading jobs: %w", err)
+	}
+	defer rows.Close()
+	var jobs []Job
+	for rows.Next() {
+		var j Job
+		var started, updated string
+		if err := rows.Scan(&j.ID, &j.Kind, &j.Status, &started, &updated, &j.Detail); err != nil {
+			return nil, fmt.Errorf("reading jobs: %w", err)
+		}
+		j.StartedAt, j.UpdatedAt = parseTime(started), parseTime(updated)
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *SQLiteStore) AddThroughput(runID int64, samples []ThroughputSample) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("recording throughput: %w", err)
+	}
+	defer tx.Rollback()
+	for _, t := range samples {
+		if _, err := tx.Exec(`INSERT INTO throughput (run_id, time, collection, docs_written, bytes_written, rows_per_sec, mb_per_sec) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			runID, formatTime(t.Time), t.Collection, t.DocsWritten, t.BytesWritten, t.RowsPerSec, t.MBPerSec); err != nil {
+			return fmt.Errorf("recording throughput: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("recording throughput: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Throughput(runID int64) ([]ThroughputSample, error) {
+	rows, err := s.db.Query(`SELECT time, collection, docs_written, bytes_written, rows_per_sec, mb_per_sec FROM throughput WHERE run_id = ? ORDER BY id`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("reading throughput: %w", err)
+	}
+	defer rows.Close()
+	var samples []ThroughputSample
+	for rows.Next() {
+		var t ThroughputSample
+		var at string
+		if err := rows.Scan(&at, &t.Collection, &t.DocsWritten, &t.BytesWritten, &t.RowsPerSec, &t.MBPerSec); err != nil {
+			return nil, fmt.Errorf("reading throughput: %w", err)
+		}
+		t.Time = parseTime(at)
+		samples = append(samples, t)
+	}
+	return samples, rows.Err()
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// MigrateToSQLite moves the project in dir from its files to a SQLite
+// database. The state and the run, audit, job and throughput records are
+// imported into a new database that only takes its final name once
+// complete; the files are then kept with a .migrated suffix. It reports
+// false when the project already uses SQLite.
+func MigrateToSQLite(dir string) (bool, error) {
+	if HasSQLite(dir) {
+		return false, nil
+	}
+	if !SQLiteAvailable() {
+		return false, ErrNoSQLite
+	}
+
+	files := &fileStore{dir: dir}
+	statePath := filepath.Join(dir, "state.yaml")
+	_, statErr := os.Stat(statePath)
+	hasState := statErr == nil
+	st, err := files.LoadState()
+	if err != nil {
+		return false, err
+	}
+	runs, err := files.Runs(0)
+	if err != nil {
+		return false, err
+	}
+	events, err := files.Audit(0)
+	if err != nil {
+		return false, err
+	}
+	jobs, err := files.Jobs()
+	if err != nil {
+		return false, err
+	}
+	throughput, err := files.throughput()
+	if err != nil {
+		return false, err
+	}
+
+	tmp := filepath.Join(dir, DBName+".importing")
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		os.Remove(tmp + suffix)
+	}
+	db, err := openSQLite(tmp)
+	if err != nil {
+		return false, err
+	}
+	if err := importRecords(db, st, hasState, runs, events, jobs, throughput); err != nil {
+		db.Close()
+		os.Remove(tmp)
+		return false, fmt.Errorf("importing project files: %w", err)
+	}
+	if err := db.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, DBName)); err != nil {
+		return false, fmt.Errorf("installing metadata database: %w", err)
+	}
+
+	for _, name := range []string{"state.yaml", runsFile, auditFile, jobsFile, throughputFile} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			if err := os.Rename(path, path+".migrated"); err != nil {
+				return true, fmt.Errorf("keeping %s: %w", name, err)
+			}
+		}
+	}
+	return true, nil
+}
+
+// importRecords writes records read from the files, oldest first. Runs
+// take new IDs, which their throughput samples follow.
+func importRecords(db *SQLiteStore, st *State, hasState bool, runs []RunRecord, events []AuditEvent, jobs []Job, throughput []throughputLine) error {
+	if hasState {
+		if err := db.SaveState(st); err != nil {
+			return err
+		}
+	}
+	ids := make(map[int64]int64, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		id, err := db.AddRun(runs[i])
+		if err != nil {
+			return err
+		}
+		ids[runs[i].ID] = id
+	}
+	for _, t := range throughput {
+		if id, ok := ids[t.RunID]; ok {
+			if err := db.AddThroughput(id, []ThroughputSample{t.ThroughputSample}); err != nil {
+				return err
+			}
+		}
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		if err := db.AddAudit(events[i]); err != nil {
+			return err
+		}
+	}
+	for _, j := range jobs {
+		if err := db.SaveJob(j); err != nil {
+			return err
+		}
+	}
+	return db.AddAudit(AuditEvent{Time: time.Now(), Action: "metadata_migrated", Detail: "moved from YAML files to SQLite"})
+}
+
+// sqlLimit maps "no limit" to SQLite's LIMIT -1.
+func sqlLimit(limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return limit
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(timeFormat, s)
+	return t
+}
@@ -0,0 +1,6 @@
+//go:build sqlite
+
+package state
+
+// The pure-Go driver keeps CGO_ENABLED=0 release builds working.
+import _ "modernc.org/sqlite"
@@ -0,0 +1,111 @@
+//go:build sqlite
+
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMigrateToSQLite(t *testing.T) {
+	dir := t.TempDir()
+	files := &fileStore{dir: dir}
+	st := New()
+	st.CurrentStep = StepMigration
+	st.SelectedTables = []string{"users", "orders"}
+	if err := files.SaveState(st); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	files.AddRun(RunRecord{Command: "run", Status: "failed", StartedAt: start})
+	id, _ := files.AddRun(RunRecord{Command: "run", Status: "ok", StartedAt: start.Add(time.Hour)})
+	files.AddThroughput(id, []ThroughputSample{{Time: start, Collection: "users", DocsWritten: 500, RowsPerSec: 50}})
+	files.AddAudit(AuditEvent{Time: start, Action: "step_completed"})
+	files.SaveJob(Job{ID: "migration-1", Kind: "migration", Status: "complete", StartedAt: start})
+
+	migrated, err := MigrateToSQLite(dir)
+	if err != nil || !migrated {
+		t.Fatalf("MigrateToSQLite = %v, %v", migrated, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "state.yaml.migrated")); err != nil {
+		t.Errorf("state file should be kept: %v", err)
+	}
+	if migrated, _ := MigrateToSQLite(dir); migrated {
+		t.Error("second migration should do nothing")
+	}
+
+	// Load now reads the database
+	loaded, err := Load(filepath.Join(dir, "state.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.CurrentStep != StepMigration || len(loaded.SelectedTables) != 2 {
+		t.Errorf("state = %+v", loaded)
+	}
+
+	store, err := OpenStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	runs, _ := store.Runs(0)
+	if len(runs) != 2 || runs[0].Status != "ok" || !runs[1].StartedAt.Equal(start) {
+		t.Errorf("runs = %+v", runs)
+	}
+	events, _ := store.Audit(0)
+	if len(events) != 2 || events[0].Action != "metadata_migrated" {
+		t.Errorf("audit = %+v", events)
+	}
+	jobs, _ := store.Jobs()
+	if len(jobs) != 1 || jobs[0].Status != "complete" {
+		t.Errorf("jobs = %+v", jobs)
+	}
+	samples, _ := store.Throughput(runs[0].ID)
+	if len(samples) != 1 || samples[0].DocsWritten != 500 || !samples[0].Time.Equal(start) {
+		t.Errorf("throughput = %+v", samples)
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenSQLite(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for i := range stateVersions + 5 {
+		st := New()
+		st.SelectedTables = []string{string(rune('a' + i%26))}
+		if err := store.SaveState(st); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var versions int
+	store.db.QueryRow(`SELECT COUNT(*) FROM state`).Scan(&versions)
+	if versions != stateVersions {
+		t.Errorf("kept %d state versions, want %d", versions, stateVersions)
+	}
+
+	start := time.Now()
+	store.SaveJob(Job{ID: "j1", Kind: "migration", Status: "running", StartedAt: start})
+	store.SaveJob(Job{ID: "j1", Kind: "migration", Status: "failed", StartedAt: start, Detail: "partial_failure"})
+	jobs, err := store.Jobs()
+	if err != nil || len(jobs) != 1 || jobs[0].Status != "failed" {
+		t.Errorf("jobs = %+v, %v", jobs, err)
+	}
+
+	id, err := store.AddRun(RunRecord{Command: "run", Status: "running", StartedAt: start})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateRun(RunRecord{ID: id, Status: "ok", FinishedAt: start.Add(time.Hour), Report: "{}"}); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := store.Runs(0)
+	if err != nil || len(runs) != 1 || runs[0].Status != "ok" || runs[0].Command != "run" || runs[0].Report != "{}" {
+		t.Errorf("runs = %+v, %v", runs, err)
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"time"
 
 	"github.com/reloquent/reloquent/internal/config"
+	schemapkg "github.com/reloquent/reloquent/internal/schema"
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultPath is the state file of the default project.
 const DefaultPath = "~/.reloquent/state.yaml"
 
 // Step represents a wizard step.
@@ -28,11 +30,14 @@ const (
 	StepMigration        Step = "migration"
 	StepValidation       Step = "validation"
 	StepIndexBuilds      Step = "index_builds"
+	StepCDC              Step = "cdc"
 	StepComplete         Step = "complete"
 )
 
 // State holds the current wizard progress and accumulated data.
 type State struct {
+	FormatVersion int `yaml:"format_version"` // layout of this file; see the FormatVersion constant
+
 	CurrentStep Step               `yaml:"current_step"`
 	LastUpdated time.Time          `yaml:"last_updated"`
 	Steps       map[Step]StepState `yaml:"steps,omitempty"`
@@ -41,6 +46,7 @@ type State struct {
 	SourceConfig    *config.SourceConfig `yaml:"source_config,omitempty"`
 	TargetConfig    *config.TargetConfig `yaml:"target_config,omitempty"`
 	SchemaPath      string               `yaml:"schema_path,omitempty"`
+	SchemaSnapshot  string               `yaml:"schema_snapshot,omitempty"` // pinned baseline; empty follows the latest discovery
 	SelectedTables  []string             `yaml:"selected_tables,omitempty"`
 	MappingPath     string               `yaml:"mapping_path,omitempty"`
 	TypeMappingPath string               `yaml:"type_mapping_path,omitempty"`
@@ -54,6 +60,23 @@ type State struct {
 	MigrationStatus  string `yaml:"migration_status,omitempty"`
 	S3ArtifactPrefix string `yaml:"s3_artifact_prefix,omitempty"`
 	BenchmarkPath    string `yaml:"benchmark_path,omitempty"`
+	SourceImpactPath string `yaml:"source_impact_path,omitempty"` // estimate and actuals of the last full run
+
+	// Last target write benchmark, whose rate sizing estimates with
+	WriteBenchmarkPath string `yaml:"write_benchmark_path,omitempty"`
+
+	// Last environment check by the doctor, which the readiness report includes
+	DoctorReportPath string `yaml:"doctor_report_path,omitempty"`
+
+	// Migration script last generated for review, checked against the
+	// mapping before a Spark migration starts
+	GeneratedScript *GeneratedScript `yaml:"generated_script,omitempty"`
+
+	// Per-collection migration checkpoints, keyed by collection name
+	Checkpoints map[string]*Checkpoint `yaml:"checkpoints,omitempty"`
+
+	// Per-collection high-watermarks reached by full and delta migrations
+	Watermarks map[string]*Watermark `yaml:"watermarks,omitempty"`
 
 	// Phase 4: validation, indexes, production readiness
 	ValidationReportPath string `yaml:"validation_report_path,omitempty"`
@@ -63,6 +86,29 @@ type State struct {
 	WriteConcernRestored bool   `yaml:"write_concern_restored,omitempty"`
 	ProductionReady      bool   `yaml:"production_ready,omitempty"`
 	ReportPath           string `yaml:"report_path,omitempty"`
+	ViewsStatus          string `yaml:"views_status,omitempty"`
+	ViewScriptsDir       string `yaml:"view_scripts_dir,omitempty"`
+	CanaryStatus         string `yaml:"canary_status,omitempty"`
+	CanaryReportPath     string `yaml:"canary_report_path,omitempty"`
+	IDGenerationStatus   string `yaml:"id_generation_status,omitempty"`
+	IDGenerationDocPath  string `yaml:"id_generation_doc_path,omitempty"`
+
+	// Change data capture
+	CDCStartPosition string `yaml:"cdc_start_position,omitempty"`
+	CDCSlotName      string `yaml:"cdc_slot_name,omitempty"`
+	CDCStatus        string `yaml:"cdc_status,omitempty"`
+
+	// Custom steps declared as hooks in the config, keyed by hook name
+	HookRuns map[string]HookRun `yaml:"hook_runs,omitempty"`
+
+	// Decisions on collections that failed validation, keyed by collection
+	Remediations map[string]Remediation `yaml:"remediations,omitempty"`
+
+	// Child-per-parent counts of embedded arrays measured in the source,
+	// keyed by collection and field path, for the size estimates
+	EmbedCounts map[string]schemapkg.ChildCounts `yaml:"embed_counts,omitempty"`
+
+	upgradedFrom int // format the file had before it was upgraded on load
 }
 
 // StepState tracks the state of a single wizard step.
@@ -71,12 +117,34 @@ type StepState struct {
 	CompletedAt time.Time `yaml:"completed_at,omitempty"`
 }
 
-// Load reads the wizard state from disk.
+// HookRun records the last run of a custom step hook.
+type HookRun struct {
+	Status      string            `yaml:"status" json:"status"` // running, complete, failed
+	StartedAt   time.Time         `yaml:"started_at" json:"started_at"`
+	CompletedAt time.Time         `yaml:"completed_at,omitempty" json:"completed_at,omitempty"`
+	Message     string            `yaml:"message,omitempty" json:"message,omitempty"`
+	Outputs     map[string]string `yaml:"outputs,omitempty" json:"outputs,omitempty"`
+}
+
+// Load reads the wizard state from disk. An empty path reads the active
+// project's state. A project that has moved to SQLite (see MigrateToSQLite)
+// is read from its metadata database instead of the file.
 func Load(path string) (*State, error) {
 	if path == "" {
-		path = config.ExpandHome(DefaultPath)
+		path = Active().StatePath()
 	}
+	if HasSQLite(filepath.Dir(path)) {
+		db, err := OpenSQLite(filepath.Dir(path))
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		return db.LoadState()
+	}
+	return loadFile(path)
+}
 
+func loadFile(path string) (*State, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -84,44 +152,102 @@ func Load(path string) (*State, error) {
 		}
 		return nil, fmt.Errorf("reading state: %w", err)
 	}
+	s, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
 
+// parse decodes a state and brings it to the current format.
+func parse(data []byte) (*State, error) {
 	s := &State{}
 	if err := yaml.Unmarshal(data, s); err != nil {
 		return nil, fmt.Errorf("parsing state: %w", err)
 	}
+	if err := s.checkFormat(); err != nil {
+		return nil, err
+	}
 	if s.Steps == nil {
 		s.Steps = make(map[Step]StepState)
 	}
-
 	return s, nil
 }
 
-// Save writes the wizard state to disk.
+// Save writes the wizard state to disk, or to the project's metadata
+// database once it has one. Connection passwords are stored in the OS
+// keychain and only their references are written.
 func (s *State) Save(path string) error {
 	if path == "" {
-		path = config.ExpandHome(DefaultPath)
+		path = Active().StatePath()
 	}
+	if HasSQLite(filepath.Dir(path)) {
+		db, err := OpenSQLite(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		return db.SaveState(s)
+	}
+	return s.saveFile(path)
+}
 
-	s.LastUpdated = time.Now()
-
+func (s *State) saveFile(path string) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return fmt.Errorf("creating state directory: %w", err)
 	}
-
-	data, err := yaml.Marshal(s)
+	data, err := s.marshal()
 	if err != nil {
-		return fmt.Errorf("marshaling state: %w", err)
+		return err
+	}
+	if err := s.backupOldFormat(path); err != nil {
+		return err
 	}
-
 	return os.WriteFile(path, data, 0o644)
 }
 
+// marshal stamps the state with the current time and format and encodes it
+// with its connection passwords replaced by keychain references.
+func (s *State) marshal() ([]byte, error) {
+	s.LastUpdated = time.Now()
+	s.FormatVersion = FormatVersion
+
+	out := *s
+	if s.SourceConfig != nil {
+		src, err := s.SourceConfig.Secured()
+		if err != nil {
+			return nil, err
+		}
+		if src.Password != "" {
+			// Keep the keychain reference so later saves do not store it again.
+			s.SourceConfig.Password = src.Password
+		}
+		out.SourceConfig = &src
+	}
+	if s.TargetConfig != nil {
+		tgt, err := s.TargetConfig.Secured()
+		if err != nil {
+			return nil, err
+		}
+		if pw, _ := config.URIPassword(tgt.ConnectionString); pw != "" {
+			s.TargetConfig.ConnectionString = tgt.ConnectionString
+		}
+		out.TargetConfig = &tgt
+	}
+	data, err := yaml.Marshal(&out)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling state: %w", err)
+	}
+	return data, nil
+}
+
 // New creates a fresh wizard state.
 func New() *State {
 	return &State{
-		CurrentStep: StepSourceConnection,
-		LastUpdated: time.Now(),
-		Steps:       make(map[Step]StepState),
+		FormatVersion: FormatVersion,
+		CurrentStep:   StepSourceConnection,
+		LastUpdated:   time.Now(),
+		Steps:         make(map[Step]StepState),
 	}
 }
 
@@ -0,0 +1,263 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Record files of a project using the default file store, one JSON object
+// per line.
+const (
+	runsFile       = "runs.jsonl"
+	auditFile      = "audit.jsonl"
+	jobsFile       = "jobs.jsonl"
+	throughputFile = "throughput.jsonl"
+)
+
+// Store keeps a project's metadata: its wizard state and the records that
+// accumulate as it is worked on. Projects use YAML and JSON-lines files in
+// their directory until they move to SQLite.
+type Store interface {
+	LoadState() (*State, error)
+	SaveState(*State) error
+
+	AddRun(RunRecord) (int64, error)     // returns the new run's ID
+	UpdateRun(RunRecord) error           // replaces the run with the same ID
+	Runs(limit int) ([]RunRecord, error) // newest first; limit <= 0 returns all
+	AddAudit(AuditEvent) error
+	Audit(limit int) ([]AuditEvent, error) // newest first
+	SaveJob(Job) error                     // adds or replaces the job with the same ID
+	Jobs() ([]Job, error)                  // newest first
+	AddThroughput(runID int64, samples []ThroughputSample) error
+	Throughput(runID int64) ([]ThroughputSample, error) // oldest first
+
+	Close() error
+}
+
+// RunRecord is one headless run of a project.
+type RunRecord struct {
+	ID         int64     `json:"id"`
+	Command    string    `json:"command"`
+	Status     string    `json:"status"` // running, ok or failed
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Report     string    `json:"report,omitempty"` // per-step results as JSON
+}
+
+// ThroughputSample is how fast one collection was written over the
+// interval ending at Time, recorded while a run migrates.
+type ThroughputSample struct {
+	Time         time.Time `json:"time"`
+	Collection   string    `json:"collection"`
+	DocsWritten  int64     `json:"docs_written"`            // so far
+	BytesWritten int64     `json:"bytes_written,omitempty"` // so far, when the mover measures it
+	RowsPerSec   float64   `json:"rows_per_sec"`
+	MBPerSec     float64   `json:"mb_per_sec"`
+}
+
+// AuditEvent records an action taken on a project.
+type AuditEvent struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Job is a long-running operation such as a migration or index build.
+type Job struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`   // migration or index_builds
+	Status    string    `json:"status"` // running, complete or failed
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// OpenStore opens the metadata store of the project in dir: its SQLite
+// database when it has one, otherwise its files.
+func OpenStore(dir string) (Store, error) {
+	if HasSQLite(dir) {
+		return OpenSQLite(dir)
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+// fileStore keeps state in state.yaml and records in JSON-lines files.
+type fileStore struct {
+	dir string
+}
+
+func (f *fileStore) LoadState() (*State, error) {
+	return loadFile(filepath.Join(f.dir, "state.yaml"))
+}
+
+func (f *fileStore) SaveState(s *State) error {
+	return s.saveFile(filepath.Join(f.dir, "state.yaml"))
+}
+
+func (f *fileStore) AddRun(r RunRecord) (int64, error) {
+	runs, err := f.Runs(0)
+	if err != nil {
+		return 0, err
+	}
+	r.ID = int64(len(runs)) + 1
+	return r.ID, appendLine(filepath.Join(f.dir, runsFile), r)
+}
+
+func (f *fileStore) UpdateRun(r RunRecord) error {
+	return appendLine(filepath.Join(f.dir, runsFile), r)
+}
+
+// Runs returns the latest line written for each run.
+func (f *fileStore) Runs(limit int) ([]RunRecord, error) {
+	var lines []RunRecord
+	if err := readLines(filepath.Join(f.dir, runsFile), &lines); err != nil {
+		return nil, err
+	}
+	latest := make(map[int64]int)
+	var runs []RunRecord
+	for _, r := range lines {
+		if i, ok := latest[r.ID]; ok {
+			runs[i] = r
+			continue
+		}
+		latest[r.ID] = len(runs)
+		runs = append(runs, r)
+	}
+	return newestFirst(runs, limit), nil
+}
+
+func (f *fileStore) AddAudit(ev AuditEvent) error {
+	return appendLine(filepath.Join(f.dir, auditFile), ev)
+}
+
+func (f *fileStore) Audit(limit int) ([]AuditEvent, error) {
+	var events []AuditEvent
+	if err := readLines(filepath.Join(f.dir, auditFile), &events); err != nil {
+		return nil, err
+	}
+	return newestFirst(events, limit), nil
+}
+
+func (f *fileStore) SaveJob(j Job) error {
+	return appendLine(filepath.Join(f.dir, jobsFile), j)
+}
+
+// Jobs returns the latest line written for each job.
+func (f *fileStore) Jobs() ([]Job, error) {
+	var lines []Job
+	if err := readLines(filepath.Join(f.dir, jobsFile), &lines); err != nil {
+		return nil, err
+	}
+	latest := make(map[string]int)
+	var jobs []Job
+	for _, j := range lines {
+		if i, ok := latest[j.ID]; ok {
+			jobs[i] = j
+			continue
+		}
+		latest[j.ID] = len(jobs)
+		jobs = append(jobs, j)
+	}
+	sort.SliceStable(jobs, func(a, b int) bool { return jobs[a].StartedAt.After(jobs[b].StartedAt) })
+	return jobs, nil
+}
+
+// throughputLine is a sample in the throughput file, tagged with its run.
+type throughputLine struct {
+	RunID int64 `json:"run_id"`
+	ThroughputSample
+}
+
+func (f *fileStore) AddThroughput(runID int64, samples []ThroughputSample) error {
+	for _, s := range samples {
+		if err := appendLine(filepath.Join(f.dir, throughputFile), throughputLine{RunID: runID, ThroughputSample: s}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fileStore) Throughput(runID int64) ([]ThroughputSample, error) {
+	lines, err := f.throughput()
+	if err != nil {
+		return nil, err
+	}
+	var samples []ThroughputSample
+	for _, l := range lines {
+		if l.RunID == runID {
+			samples = append(samples, l.ThroughputSample)
+		}
+	}
+	return samples, nil
+}
+
+func (f *fileStore) throughput() ([]throughputLine, error) {
+	var lines []throughputLine
+	err := readLines(filepath.Join(f.dir, throughputFile), &lines)
+	return lines, err
+}
+
+func (f *fileStore) Close() error { return nil }
+
+func appendLine(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	fh, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", filepath.Base(path), err)
+	}
+	if _, err := fh.Write(append(data, '\n')); err != nil {
+		fh.Close()
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+	return fh.Close()
+}
+
+// readLines decodes a JSON-lines file into the slice out points to. A
+// missing file reads as empty.
+func readLines[T any](path string, out *[]T) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var v T
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			return fmt.Errorf("%s line %d: %w", filepath.Base(path), line, err)
+		}
+		*out = append(*out, v)
+	}
+	return scanner.Err()
+}
+
+// newestFirst reverses records kept oldest first and keeps the first limit.
+func newestFirst[T any](records []T, limit int) []T {
+	out := make([]T, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		out = append(out, records[i])
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
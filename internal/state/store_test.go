@@ -0,0 +1,117 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore_Records(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, status := range []string{"ok", "failed", "ok"} {
+		if _, err := store.AddRun(RunRecord{Command: "run", Status: status, StartedAt: start.Add(time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id, err := store.AddRun(RunRecord{Command: "migrate", Status: "running", StartedAt: start.Add(4 * time.Hour)})
+	if err != nil || id != 4 {
+		t.Fatalf("AddRun = %d, %v", id, err)
+	}
+	if err := store.UpdateRun(RunRecord{ID: id, Command: "migrate", Status: "ok", StartedAt: start.Add(4 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := store.Runs(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].ID != 4 || runs[0].Status != "ok" || runs[1].ID != 3 {
+		t.Errorf("runs = %+v, want the two newest, updated", runs)
+	}
+
+	store.AddThroughput(3, []ThroughputSample{{Time: start, Collection: "users", RowsPerSec: 100}})
+	store.AddThroughput(4, []ThroughputSample{
+		{Time: start, Collection: "users", RowsPerSec: 200},
+		{Time: start, Collection: "orders", RowsPerSec: 50, MBPerSec: 1.5},
+	})
+	samples, err := store.Throughput(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0].RowsPerSec != 200 || samples[1].MBPerSec != 1.5 {
+		t.Errorf("throughput = %+v, want run 4's samples in order", samples)
+	}
+
+	store.AddAudit(AuditEvent{Time: start, Action: "step_completed", Detail: "discover"})
+	store.AddAudit(AuditEvent{Time: start.Add(time.Minute), Action: "step_failed", Detail: "migrate"})
+	events, err := store.Audit(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Action != "step_failed" {
+		t.Errorf("audit = %+v", events)
+	}
+
+	store.SaveJob(Job{ID: "migration-1", Kind: "migration", Status: "running", StartedAt: start})
+	store.SaveJob(Job{ID: "index_builds-2", Kind: "index_builds", Status: "running", StartedAt: start.Add(time.Hour)})
+	store.SaveJob(Job{ID: "migration-1", Kind: "migration", Status: "complete", StartedAt: start, Detail: "12 collections"})
+	jobs, err := store.Jobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != "index_builds-2" || jobs[1].Status != "complete" {
+		t.Errorf("jobs = %+v, want one entry per job, newest first", jobs)
+	}
+}
+
+func TestFileStore_State(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := OpenStore(dir)
+	st, err := store.LoadState()
+	if err != nil || st.CurrentStep != StepSourceConnection {
+		t.Fatalf("fresh state = %+v, %v", st, err)
+	}
+	st.CurrentStep = StepSizing
+	if err := store.SaveState(st); err != nil {
+		t.Fatal(err)
+	}
+	// The store and Load read the same file
+	loaded, err := Load(filepath.Join(dir, "state.yaml"))
+	if err != nil || loaded.CurrentStep != StepSizing {
+		t.Errorf("Load = %+v, %v", loaded, err)
+	}
+}
+
+func TestSQLiteWithoutDriver(t *testing.T) {
+	if SQLiteAvailable() {
+		t.Skip("built with SQLite support")
+	}
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.yaml")
+	if err := os.WriteFile(statePath, []byte("current_step: sizing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MigrateToSQLite(dir); !errors.Is(err, ErrNoSQLite) {
+		t.Errorf("MigrateToSQLite err = %v, want ErrNoSQLite", err)
+	}
+	if _, err := os.Stat(statePath); err != nil {
+		t.Errorf("state file should be left in place: %v", err)
+	}
+
+	// A project that has moved to SQLite must not fall back to stale files
+	if err := os.WriteFile(filepath.Join(dir, DBName), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(statePath); !errors.Is(err, ErrNoSQLite) {
+		t.Errorf("Load err = %v, want ErrNoSQLite", err)
+	}
+}
@@ -0,0 +1,31 @@
+package state
+
+import "time"
+
+// Watermark is a collection's high-watermark for delta migrations: every
+// root-table row whose watermark column is at most Value has been migrated.
+// Value is a SQL literal, such as 1042 or TIMESTAMP '2024-05-01 10:00:00'.
+type Watermark struct {
+	Column    string    `yaml:"column" json:"column"`
+	Value     string    `yaml:"value" json:"value"`
+	UpdatedAt time.Time `yaml:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// SetWatermark records the high-watermark a collection has been migrated up
+// to.
+func (s *State) SetWatermark(collection, column, value string) {
+	if s.Watermarks == nil {
+		s.Watermarks = make(map[string]*Watermark)
+	}
+	s.Watermarks[collection] = &Watermark{Column: column, Value: value, UpdatedAt: time.Now()}
+}
+
+// WatermarkAfter returns the value a delta run of a collection starts after:
+// its recorded high-watermark, or "" if none was recorded for that column.
+func (s *State) WatermarkAfter(collection, column string) string {
+	w, ok := s.Watermarks[collection]
+	if !ok || w.Column != column {
+		return ""
+	}
+	return w.Value
+}
@@ -0,0 +1,44 @@
+package target
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Aggregator is implemented by operators that can run an aggregation
+// pipeline.
+type Aggregator interface {
+	// Aggregate runs the pipeline on the collection and returns the
+	// resulting documents.
+	Aggregate(ctx context.Context, collection string, pipeline []map[string]interface{}) ([]map[string]interface{}, error)
+}
+
+// Aggregate runs the pipeline with the read options set for validation.
+func (m *MongoOperator) Aggregate(ctx context.Context, collection string, pipeline []map[string]interface{}) ([]map[string]interface{}, error) {
+	stages := make(bson.A, len(pipeline))
+	for i, s := range pipeline {
+		stages[i] = s
+	}
+	ctx, end, err := m.readContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	cursor, err := m.reads().Collection(collection).Aggregate(ctx, stages)
+	if err != nil {
+		return nil, fmt.Errorf("aggregating %s: %w", collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []map[string]interface{}
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decoding aggregation result: %w", err)
+		}
+		results = append(results, map[string]interface{}(doc))
+	}
+	return results, cursor.Err()
+}
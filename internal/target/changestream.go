@@ -0,0 +1,61 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// changeStreamTimeout bounds how long the smoke test waits for its events.
+const changeStreamTimeout = 30 * time.Second
+
+// canaryEvents are the change events the smoke test expects, in order.
+var canaryEvents = []string{"insert", "delete"}
+
+// ChangeStreamSmokeTest opens a change stream on a collection, inserts and
+// deletes a canary document, and confirms both events are observed. It fails
+// on deployments without change stream support, such as standalone servers.
+func (m *MongoOperator) ChangeStreamSmokeTest(ctx context.Context, collection string) error {
+	ctx, cancel := context.WithTimeout(ctx, changeStreamTimeout)
+	defer cancel()
+
+	coll := m.client.Database(m.database).Collection(collection)
+	id := bson.NewObjectID()
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "documentKey._id", Value: id}}}},
+	}
+	stream, err := coll.Watch(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("opening change stream on %s: %w", collection, err)
+	}
+	defer stream.Close(context.Background())
+
+	if _, err := coll.InsertOne(ctx, bson.D{{Key: "_id", Value: id}, {Key: "_reloquent_canary", Value: true}}); err != nil {
+		return fmt.Errorf("inserting canary document: %w", err)
+	}
+	if _, err := coll.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}}); err != nil {
+		return fmt.Errorf("deleting canary document: %w", err)
+	}
+
+	for _, want := range canaryEvents {
+		if !stream.Next(ctx) {
+			if err := stream.Err(); err != nil {
+				return fmt.Errorf("waiting for %s event: %w", want, err)
+			}
+			return fmt.Errorf("change stream closed before the %s event", want)
+		}
+		var event struct {
+			OperationType string `bson:"operationType"`
+		}
+		if err := stream.Decode(&event); err != nil {
+			return fmt.Errorf("decoding change event: %w", err)
+		}
+		if event.OperationType != want {
+			return fmt.Errorf("expected %s event, got %s", want, event.OperationType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/reloquent/reloquent/internal/config"
+)
+
+// ErrUnsupported is returned by operators for operations their document
+// store does not provide.
+var ErrUnsupported = errors.New("not supported by the target")
+
+// Capabilities lists what a target supports beyond reading and writing
+// documents and building indexes, so preparation, validation and the
+// readiness checks can skip or adapt what it cannot do.
+type Capabilities struct {
+	Sharding       bool `json:"sharding"`        // shardCollection, zones and the balancer
+	ChangeStreams  bool `json:"change_streams"`  // watching collections, as CDC consumers do
+	CausalReads    bool `json:"causal_reads"`    // secondary reads in causally consistent sessions
+	Lookup         bool `json:"lookup"`          // $lookup, used by the referential check
+	Validators     bool `json:"validators"`      // $jsonSchema validators set with collMod
+	StorageOptions bool `json:"storage_options"` // WiredTiger options and collations on create
+	WriteConcern   bool `json:"write_concern"`   // a cluster-wide default write concern
+}
+
+// MongoDBCapabilities are those of a MongoDB deployment.
+var MongoDBCapabilities = Capabilities{
+	Sharding:       true,
+	ChangeStreams:  true,
+	CausalReads:    true,
+	Lookup:         true,
+	Validators:     true,
+	StorageOptions: true,
+	WriteConcern:   true,
+}
+
+// Capable is implemented by operators that report their capabilities.
+type Capable interface {
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns the operator's capabilities. Operators that do not
+// report them are taken to be MongoDB.
+func CapabilitiesOf(op Operator) Capabilities {
+	if c, ok := op.(Capable); ok {
+		return c.Capabilities()
+	}
+	return MongoDBCapabilities
+}
+
+// Driver opens operators for a document store, chosen by the scheme of the
+// target connection string.
+type Driver struct {
+	Name         string
+	Schemes      []string
+	Capabilities Capabilities
+	Open         func(ctx context.Context, connectionString, database string) (Operator, error)
+	// WireScheme is the scheme MongoDB clients, such as the Spark
+	// connector, reach the store with when it differs from Schemes.
+	WireScheme string
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]Driver)
+)
+
+// Register makes a driver available for its schemes. It panics if a scheme
+// is already registered, as registration happens in init functions.
+func Register(d Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	for _, s := range d.Schemes {
+		if prev, ok := drivers[s]; ok {
+			panic(fmt.Sprintf("target: scheme %s registered by both %s and %s", s, prev.Name, d.Name))
+		}
+		drivers[s] = d
+	}
+}
+
+// Drivers returns the registered drivers, ordered by name.
+func Drivers() []Driver {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	seen := make(map[string]bool)
+	var out []Driver
+	for _, d := range drivers {
+		if !seen[d.Name] {
+			seen[d.Name] = true
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// DriverFor returns the driver registered for the connection string's
+// scheme. Credential references are resolved first, so a connection string
+// held entirely in a secret store still selects its driver.
+func DriverFor(connectionString string) (Driver, error) {
+	resolved, err := config.ResolveValue(connectionString)
+	if err != nil {
+		return Driver{}, fmt.Errorf("resolving target credentials: %w", err)
+	}
+	scheme, _, ok := strings.Cut(resolved, "://")
+	if !ok {
+		return Driver{}, fmt.Errorf("target connection string has no scheme, such as mongodb://")
+	}
+	scheme = strings.ToLower(scheme)
+	driversMu.RLock()
+	d, ok := drivers[scheme]
+	driversMu.RUnlock()
+	if !ok {
+		return Driver{}, fmt.Errorf("no target driver for scheme %s:// (supported: %s)", scheme, strings.Join(schemes(), ", "))
+	}
+	return d, nil
+}
+
+// Open connects to the target with the driver for the connection string's
+// scheme.
+func Open(ctx context.Context, connectionString, database string) (Operator, error) {
+	d, err := DriverFor(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	return d.Open(ctx, connectionString, database)
+}
+
+// WireURI returns the connection string as MongoDB clients outside the
+// operator take it, with a driver's own scheme replaced by its wire scheme.
+// Other connection strings, including unresolved references, are returned
+// unchanged.
+func WireURI(connectionString string) string {
+	scheme, _, ok := strings.Cut(connectionString, "://")
+	if !ok {
+		return connectionString
+	}
+	driversMu.RLock()
+	d, ok := drivers[strings.ToLower(scheme)]
+	driversMu.RUnlock()
+	if !ok || d.WireScheme == "" {
+		return connectionString
+	}
+	return withScheme(connectionString, d.WireScheme)
+}
+
+func schemes() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	out := make([]string, 0, len(drivers))
+	for s := range drivers {
+		out = append(out, s+"://")
+	}
+	sort.Strings(out)
+	return out
+}
+
+// withScheme replaces the scheme of a connection string.
+func withScheme(connectionString, scheme string) string {
+	_, rest, _ := strings.Cut(connectionString, "://")
+	return scheme + "://" + rest
+}
@@ -0,0 +1,178 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+)
+
+// QueryStats summarizes the executionStats explain output of a query.
+type QueryStats struct {
+	Stages         []string `json:"stages"`
+	IndexName      string   `json:"index_name,omitempty"`
+	CollectionScan bool     `json:"collection_scan"`
+	NReturned      int64    `json:"n_returned"`
+	KeysExamined   int64    `json:"keys_examined"`
+	DocsExamined   int64    `json:"docs_examined"`
+	ExecutionMS    int64    `json:"execution_ms"`
+}
+
+// canaryFilter builds the filter of a canary query. Field-based queries take
+// their values from one sampled document of the collection; fields missing
+// from the sample match null.
+func (m *MongoOperator) canaryFilter(ctx context.Context, q mapping.CanaryQuery) (bson.D, error) {
+	if q.Filter != "" {
+		var filter bson.D
+		if err := bson.UnmarshalExtJSON([]byte(q.Filter), false, &filter); err != nil {
+			return nil, fmt.Errorf("parsing filter for query %s: %w", q.Name, err)
+		}
+		return filter, nil
+	}
+
+	var sample bson.M
+	projection := bson.D{}
+	for _, f := range q.Fields {
+		projection = append(projection, bson.E{Key: f, Value: 1})
+	}
+	err := m.client.Database(m.database).Collection(q.Collection).
+		FindOne(ctx, bson.D{}, options.FindOne().SetProjection(projection)).Decode(&sample)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("sampling %s for query %s: %w", q.Collection, q.Name, err)
+	}
+
+	filter := make(bson.D, len(q.Fields))
+	for i, f := range q.Fields {
+		filter[i] = bson.E{Key: f, Value: lookupPath(sample, strings.Split(f, "."))}
+	}
+	return filter, nil
+}
+
+// lookupPath returns the value at a dotted path, descending into the first
+// element of arrays along the way.
+func lookupPath(v interface{}, path []string) interface{} {
+	for _, p := range path {
+		if arr, ok := v.(bson.A); ok {
+			if len(arr) == 0 {
+				return nil
+			}
+			v = arr[0]
+		}
+		switch doc := v.(type) {
+		case bson.M:
+			v = doc[p]
+		case bson.D:
+			v = nil
+			for _, e := range doc {
+				if e.Key == p {
+					v = e.Value
+				}
+			}
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// ExplainQuery runs a canary query with explain at executionStats verbosity.
+func (m *MongoOperator) ExplainQuery(ctx context.Context, q mapping.CanaryQuery) (*QueryStats, error) {
+	filter, err := m.canaryFilter(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	find := bson.D{{Key: "find", Value: q.Collection}, {Key: "filter", Value: filter}}
+	if q.Sort != "" {
+		var sort bson.D
+		if err := bson.UnmarshalExtJSON([]byte(q.Sort), false, &sort); err != nil {
+			return nil, fmt.Errorf("parsing sort for query %s: %w", q.Name, err)
+		}
+		find = append(find, bson.E{Key: "sort", Value: sort})
+	}
+	if q.Limit > 0 {
+		find = append(find, bson.E{Key: "limit", Value: q.Limit})
+	}
+
+	var result bson.M
+	cmd := bson.D{{Key: "explain", Value: find}, {Key: "verbosity", Value: "executionStats"}}
+	if err := m.client.Database(m.database).RunCommand(ctx, cmd).Decode(&result); err != nil {
+		return nil, fmt.Errorf("explaining query %s: %w", q.Name, err)
+	}
+	return ParseExplain(result), nil
+}
+
+// ParseExplain extracts query stats from explain output. Plan stages are
+// collected from the whole winning plan, so sharded and slot-based plans are
+// handled alongside classic ones.
+func ParseExplain(result bson.M) *QueryStats {
+	stats := &QueryStats{}
+	collectStages(lookupPath(result, []string{"queryPlanner", "winningPlan"}), stats)
+
+	exec := lookupPath(result, []string{"executionStats"})
+	stats.NReturned = toInt64(lookupPath(exec, []string{"nReturned"}))
+	stats.KeysExamined = toInt64(lookupPath(exec, []string{"totalKeysExamined"}))
+	stats.DocsExamined = toInt64(lookupPath(exec, []string{"totalDocsExamined"}))
+	stats.ExecutionMS = toInt64(lookupPath(exec, []string{"executionTimeMillis"}))
+	return stats
+}
+
+func collectStages(v interface{}, stats *QueryStats) {
+	visit := func(key string, val interface{}) {
+		switch key {
+		case "stage":
+			if stage, ok := val.(string); ok {
+				stats.Stages = append(stats.Stages, stage)
+				if stage == "COLLSCAN" {
+					stats.CollectionScan = true
+				}
+			}
+		case "indexName":
+			if name, ok := val.(string); ok && stats.IndexName == "" {
+				stats.IndexName = name
+			}
+		default:
+			collectStages(val, stats)
+		}
+	}
+	switch node := v.(type) {
+	case bson.M:
+		// Visit the stage before its inputs so stages read top-down
+		if stage, ok := node["stage"]; ok {
+			visit("stage", stage)
+		}
+		for k, val := range node {
+			if k != "stage" {
+				visit(k, val)
+			}
+		}
+	case bson.D:
+		for _, e := range node {
+			visit(e.Key, e.Value)
+		}
+	case bson.A:
+		for _, val := range node {
+			collectStages(val, stats)
+		}
+	}
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	case int:
+		return int64(n)
+	}
+	return 0
+}
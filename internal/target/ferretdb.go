@@ -0,0 +1,224 @@
+package target
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/sizing"
+)
+
+// FerretDBCapabilities are those of FerretDB, which serves the MongoDB wire
+// protocol from PostgreSQL: a single server without sharding, replicas,
+// change streams or schema validation.
+var FerretDBCapabilities = Capabilities{Lookup: true}
+
+func init() {
+	Register(Driver{
+		Name:         "ferretdb",
+		Schemes:      []string{"ferretdb"},
+		Capabilities: FerretDBCapabilities,
+		WireScheme:   "mongodb",
+		Open: func(ctx context.Context, connectionString, database string) (Operator, error) {
+			return NewFerretDBOperator(ctx, connectionString, database)
+		},
+	})
+}
+
+// FerretDBOperator implements Operator for FerretDB. Reads, writes, indexes
+// and aggregations go through the MongoDB driver; operations FerretDB does
+// not provide return ErrUnsupported, or do nothing where there is nothing
+// to undo, such as the balancer and the default write concern.
+type FerretDBOperator struct {
+	mongo *MongoOperator
+}
+
+// NewFerretDBOperator connects to FerretDB at a ferretdb:// connection
+// string, which is otherwise a mongodb:// one.
+func NewFerretDBOperator(ctx context.Context, connectionString, database string) (*FerretDBOperator, error) {
+	connectionString, err := config.ResolveValue(connectionString)
+	if err != nil {
+		return nil, fmt.Errorf("resolving FerretDB credentials: %w", err)
+	}
+	m, err := NewMongoOperator(ctx, withScheme(connectionString, "mongodb"), database)
+	if err != nil {
+		return nil, err
+	}
+	return &FerretDBOperator{mongo: m}, nil
+}
+
+// Capabilities reports FerretDBCapabilities.
+func (f *FerretDBOperator) Capabilities() Capabilities {
+	return FerretDBCapabilities
+}
+
+// DetectTopology reports the server version and storage size of the
+// database, with topology type "ferretdb".
+func (f *FerretDBOperator) DetectTopology(ctx context.Context) (*TopologyInfo, error) {
+	info, err := f.mongo.DetectTopology(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info.Type = "ferretdb"
+	info.IsAtlas = false
+	info.ShardCount = 0
+	return info, nil
+}
+
+// Validate checks the target against the sizing plan. A plan that
+// recommends sharding fails, as FerretDB cannot shard.
+func (f *FerretDBOperator) Validate(ctx context.Context, plan *sizing.SizingPlan) (*ValidationResult, error) {
+	res, err := f.mongo.Validate(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	if plan != nil && plan.ShardPlan != nil && plan.ShardPlan.Recommended {
+		res.Passed = false
+		res.Errors = append(res.Errors, ValidationIssue{
+			Category:   "topology",
+			Message:    "The sizing plan recommends sharding, which FerretDB does not support",
+			Suggestion: "Migrate to a sharded MongoDB cluster, or accept a single server and drop the shard plan",
+		})
+	}
+	return res, nil
+}
+
+func (f *FerretDBOperator) CreateCollections(ctx context.Context, names []string) error {
+	return f.mongo.CreateCollections(ctx, names)
+}
+
+// CreateCollectionsWithOptions creates the collections without their
+// storage options or collations, which FerretDB ignores.
+func (f *FerretDBOperator) CreateCollectionsWithOptions(ctx context.Context, specs []CollectionSpec) error {
+	names := make([]string, len(specs))
+	for i, s := range specs {
+		names[i] = s.Name
+	}
+	return f.mongo.CreateCollections(ctx, names)
+}
+
+// SetupSharding returns ErrUnsupported.
+func (f *FerretDBOperator) SetupSharding(ctx context.Context, plan *sizing.ShardingPlan) error {
+	return fmt.Errorf("sharding: %w", ErrUnsupported)
+}
+
+// DisableBalancer does nothing; FerretDB has no balancer.
+func (f *FerretDBOperator) DisableBalancer(ctx context.Context) error { return nil }
+
+// EnableBalancer does nothing; FerretDB has no balancer.
+func (f *FerretDBOperator) EnableBalancer(ctx context.Context) error { return nil }
+
+func (f *FerretDBOperator) DropCollections(ctx context.Context, names []string) error {
+	return f.mongo.DropCollections(ctx, names)
+}
+
+func (f *FerretDBOperator) EmptyCollection(ctx context.Context, collection string) (int64, error) {
+	return f.mongo.EmptyCollection(ctx, collection)
+}
+
+func (f *FerretDBOperator) Ping(ctx context.Context) error {
+	return f.mongo.Ping(ctx)
+}
+
+func (f *FerretDBOperator) Close(ctx context.Context) error {
+	return f.mongo.Close(ctx)
+}
+
+func (f *FerretDBOperator) CountDocuments(ctx context.Context, collection string) (int64, error) {
+	return f.mongo.CountDocuments(ctx, collection)
+}
+
+func (f *FerretDBOperator) SampleDocuments(ctx context.Context, collection string, n int) ([]map[string]interface{}, error) {
+	return f.mongo.SampleDocuments(ctx, collection, n)
+}
+
+func (f *FerretDBOperator) FindDocuments(ctx context.Context, collection string, filter map[string]interface{}) ([]map[string]interface{}, error) {
+	return f.mongo.FindDocuments(ctx, collection, filter)
+}
+
+func (f *FerretDBOperator) FindRange(ctx context.Context, collection, field string, lo, hi int64) ([]map[string]interface{}, error) {
+	return f.mongo.FindRange(ctx, collection, field, lo, hi)
+}
+
+// CountRange counts the documents whose integer field is in [lo, hi).
+func (f *FerretDBOperator) CountRange(ctx context.Context, collection, field string, lo, hi int64) (int64, error) {
+	return f.mongo.CountRange(ctx, collection, field, lo, hi)
+}
+
+func (f *FerretDBOperator) AggregateSum(ctx context.Context, collection, field string) (float64, error) {
+	return f.mongo.AggregateSum(ctx, collection, field)
+}
+
+func (f *FerretDBOperator) AggregateDecimalSum(ctx context.Context, collection, field string) (string, error) {
+	return f.mongo.AggregateDecimalSum(ctx, collection, field)
+}
+
+func (f *FerretDBOperator) AggregateCountDistinct(ctx context.Context, collection, field string) (int64, error) {
+	return f.mongo.AggregateCountDistinct(ctx, collection, field)
+}
+
+// ChangeStreamSmokeTest returns ErrUnsupported.
+func (f *FerretDBOperator) ChangeStreamSmokeTest(ctx context.Context, collection string) error {
+	return fmt.Errorf("change streams: %w", ErrUnsupported)
+}
+
+// SetReadOptions accepts only reads from the single server: a read
+// preference that may go to a secondary returns ErrUnsupported.
+func (f *FerretDBOperator) SetReadOptions(ctx context.Context, ro ReadOptions) error {
+	if ro.Causal() {
+		return fmt.Errorf("read preference %s: %w", ro.Preference, ErrUnsupported)
+	}
+	return nil
+}
+
+func (f *FerretDBOperator) CountOrphans(ctx context.Context, ref OrphanQuery) (*OrphanCount, error) {
+	return f.mongo.CountOrphans(ctx, ref)
+}
+
+func (f *FerretDBOperator) Aggregate(ctx context.Context, collection string, pipeline []map[string]interface{}) ([]map[string]interface{}, error) {
+	return f.mongo.Aggregate(ctx, collection, pipeline)
+}
+
+func (f *FerretDBOperator) InsertDocuments(ctx context.Context, collection string, docs []interface{}) (int64, error) {
+	return f.mongo.InsertDocuments(ctx, collection, docs)
+}
+
+func (f *FerretDBOperator) ApplyWrites(ctx context.Context, collection string, ops []WriteOp) error {
+	return f.mongo.ApplyWrites(ctx, collection, ops)
+}
+
+func (f *FerretDBOperator) CreateIndex(ctx context.Context, collection string, index IndexDefinition) error {
+	return f.mongo.CreateIndex(ctx, collection, index)
+}
+
+func (f *FerretDBOperator) CreateIndexes(ctx context.Context, indexes []CollectionIndex) error {
+	return f.mongo.CreateIndexes(ctx, indexes)
+}
+
+// ListIndexBuildProgress returns nothing: FerretDB builds indexes before
+// createIndexes returns and has no currentOp progress to report.
+func (f *FerretDBOperator) ListIndexBuildProgress(ctx context.Context) ([]IndexBuildStatus, error) {
+	return nil, nil
+}
+
+func (f *FerretDBOperator) MaterializeView(ctx context.Context, view mapping.View) error {
+	return f.mongo.MaterializeView(ctx, view)
+}
+
+func (f *FerretDBOperator) ExplainQuery(ctx context.Context, query mapping.CanaryQuery) (*QueryStats, error) {
+	return f.mongo.ExplainQuery(ctx, query)
+}
+
+// SetWriteConcern does nothing: FerretDB acknowledges writes once
+// PostgreSQL has committed them, whatever the write concern.
+func (f *FerretDBOperator) SetWriteConcern(ctx context.Context, w string, journal bool) error {
+	return nil
+}
+
+// SetValidator returns ErrUnsupported.
+func (f *FerretDBOperator) SetValidator(ctx context.Context, collection string, validator map[string]interface{}, level string) error {
+	return fmt.Errorf("schema validation: %w", ErrUnsupported)
+}
+
+var _ Operator = (*FerretDBOperator)(nil)
@@ -0,0 +1,251 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// ShardLocator is implemented by operators that can tell which shards hold
+// a collection's data, so work on it can be throttled per shard.
+type ShardLocator interface {
+	// CollectionShards returns the shards holding the collection's data. It
+	// returns nil when the target is not a sharded cluster.
+	CollectionShards(ctx context.Context, collection string) ([]string, error)
+}
+
+// CollectionShards reads the cluster metadata: the shards owning chunks of a
+// sharded collection, or the database's primary shard for an unsharded one.
+func (m *MongoOperator) CollectionShards(ctx context.Context, collection string) ([]string, error) {
+	cfg := m.client.Database("config")
+	ns := m.database + "." + collection
+
+	var coll bson.M
+	err := cfg.Collection("collections").FindOne(ctx, bson.D{{Key: "_id", Value: ns}}).Decode(&coll)
+	switch {
+	case err == nil && coll["dropped"] != true:
+		// Chunks are keyed by collection UUID since MongoDB 5.0, by ns before
+		filter := bson.D{{Key: "ns", Value: ns}}
+		if uuid, ok := coll["uuid"]; ok {
+			filter = bson.D{{Key: "uuid", Value: uuid}}
+		}
+		var shards []string
+		if err := cfg.Collection("chunks").Distinct(ctx, "shard", filter).Decode(&shards); err != nil {
+			return nil, fmt.Errorf("listing chunks of %s: %w", ns, err)
+		}
+		sort.Strings(shards)
+		return shards, nil
+	case err != nil && !errors.Is(err, mongo.ErrNoDocuments):
+		return nil, fmt.Errorf("reading sharding metadata of %s: %w", ns, err)
+	}
+
+	var db struct {
+		Primary string `bson:"primary"`
+	}
+	if err := cfg.Collection("databases").FindOne(ctx, bson.D{{Key: "_id", Value: m.database}}).Decode(&db); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // not a sharded cluster
+		}
+		return nil, fmt.Errorf("reading primary shard of %s: %w", m.database, err)
+	}
+	if db.Primary == "" {
+		return nil, nil
+	}
+	return []string{db.Primary}, nil
+}
+
+// IndexProgressEstimator is implemented by operators that can estimate
+// index build progress without currentOp, which deployments such as Atlas
+// shared tiers do not allow.
+type IndexProgressEstimator interface {
+	// EstimateIndexBuildProgress estimates the progress of the named index
+	// builds on a collection from the size their indexes have grown to.
+	EstimateIndexBuildProgress(ctx context.Context, collection string, names []string) ([]IndexBuildStatus, error)
+}
+
+// EstimatedProgressMessage marks progress estimated from index sizes rather
+// than reported by the server.
+const EstimatedProgressMessage = "estimated from index size"
+
+// maxEstimatedProgress keeps an estimate short of done: only the build
+// finishing completes an index.
+const maxEstimatedProgress = 99
+
+// EstimateIndexBuildProgress reads which indexes of the collection are still
+// building from $indexStats, or from listIndexes where $indexStats is not
+// allowed, and their sizes from collStats, and estimates each named build's
+// progress with estimateIndexProgress.
+func (m *MongoOperator) EstimateIndexBuildProgress(ctx context.Context, collection string, names []string) ([]IndexBuildStatus, error) {
+	coll := m.client.Database(m.database).Collection(collection)
+	building, err := buildingIndexes(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats struct {
+		IndexSizes  map[string]int64 `bson:"indexSizes"`
+		IndexBuilds []string         `bson:"indexBuilds"` // MongoDB 4.4+
+	}
+	cmd := bson.D{{Key: "collStats", Value: collection}}
+	if err := m.client.Database(m.database).RunCommand(ctx, cmd).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("reading collStats of %s: %w", collection, err)
+	}
+	for _, name := range stats.IndexBuilds {
+		building[name] = true
+	}
+
+	statuses := make([]IndexBuildStatus, 0, len(names))
+	for _, name := range names {
+		st := IndexBuildStatus{Collection: collection, IndexName: name, Phase: "building", Message: EstimatedProgressMessage}
+		if built, ok := building[name]; ok && !built {
+			st.Phase = "complete"
+		}
+		st.Progress = estimateIndexProgress(stats.IndexSizes, building, name)
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// buildingIndexes returns the collection's indexes, true for those still
+// building.
+func buildingIndexes(ctx context.Context, coll *mongo.Collection) (map[string]bool, error) {
+	building := make(map[string]bool)
+	cur, err := coll.Aggregate(ctx, bson.A{bson.D{{Key: "$indexStats", Value: bson.D{}}}})
+	if err == nil {
+		var stats []struct {
+			Name     string `bson:"name"`
+			Building bool   `bson:"building"`
+		}
+		if err := cur.All(ctx, &stats); err == nil {
+			for _, s := range stats {
+				building[s.Name] = s.Building
+			}
+			return building, nil
+		}
+	}
+
+	// listIndexes names builds in progress by their build UUID
+	cmd := bson.D{{Key: "listIndexes", Value: coll.Name()}, {Key: "includeBuildUUIDs", Value: true}}
+	var res struct {
+		Cursor struct {
+			FirstBatch []bson.M `bson:"firstBatch"`
+		} `bson:"cursor"`
+	}
+	if err := coll.Database().RunCommand(ctx, cmd).Decode(&res); err != nil {
+		return nil, fmt.Errorf("listing indexes of %s: %w", coll.Name(), err)
+	}
+	for _, doc := range res.Cursor.FirstBatch {
+		if spec, ok := doc["spec"].(bson.M); ok {
+			if name, ok := spec["name"].(string); ok {
+				_, inProgress := doc["buildUUID"]
+				building[name] = inProgress
+			}
+			continue
+		}
+		if name, ok := doc["name"].(string); ok {
+			building[name] = false
+		}
+	}
+	return building, nil
+}
+
+// estimateIndexProgress estimates how far a build has got from the size of
+// its index. Finished indexes of the same collection, the _id index at
+// least, hold an entry per document; a build is as far along as its size is
+// of their mean size, short of done while it is still building. Indexes
+// that are not building are done, and builds whose collection has no
+// finished index to compare with report no progress.
+func estimateIndexProgress(sizes map[string]int64, building map[string]bool, name string) float64 {
+	if b, ok := building[name]; ok && !b {
+		return 100
+	}
+	var sum, n int64
+	for idx, size := range sizes {
+		if idx != name && !building[idx] {
+			sum += size
+			n++
+		}
+	}
+	if n == 0 || sum == 0 {
+		return 0
+	}
+	progress := float64(sizes[name]) / (float64(sum) / float64(n)) * 100
+	return min(progress, maxEstimatedProgress)
+}
+
+// indexBuildOps converts currentOp entries into per-index build progress.
+// An index build reports the createIndexes command that started it, so each
+// index it names gets an entry; on a sharded cluster each shard reports its
+// own. Older servers only describe the operation, which becomes the name.
+func indexBuildOps(ops bson.A, database string) []IndexBuildStatus {
+	var statuses []IndexBuildStatus
+	for _, op := range ops {
+		doc, ok := op.(bson.M)
+		if !ok {
+			continue
+		}
+		desc, _ := doc["desc"].(string)
+		cmdDoc, _ := doc["command"].(bson.M)
+		coll, isCreate := cmdDoc["createIndexes"].(string)
+		if !isCreate && !strings.Contains(desc, "Index") {
+			continue
+		}
+
+		ns, _ := doc["ns"].(string)
+		msg, _ := doc["msg"].(string)
+		var progress float64
+		if p, ok := doc["progress"].(bson.M); ok {
+			done, _ := toFloat(p["done"])
+			total, _ := toFloat(p["total"])
+			if total > 0 {
+				progress = done / total * 100
+			}
+		}
+		if coll == "" {
+			coll = strings.TrimPrefix(ns, database+".")
+		}
+		st := IndexBuildStatus{
+			Collection: coll,
+			IndexName:  desc,
+			Phase:      "building",
+			Progress:   progress,
+			Message:    msg,
+		}
+		if shard, ok := doc["shard"].(string); ok {
+			st.Shard = shard
+		}
+
+		specs, _ := cmdDoc["indexes"].(bson.A)
+		named := false
+		for _, spec := range specs {
+			if s, ok := spec.(bson.M); ok {
+				if name, ok := s["name"].(string); ok {
+					st.IndexName = name
+					statuses = append(statuses, st)
+					named = true
+				}
+			}
+		}
+		if !named {
+			statuses = append(statuses, st)
+		}
+	}
+	return statuses
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -2,7 +2,11 @@ package target
 
 import (
 	"context"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/sizing"
 )
 
@@ -24,28 +28,72 @@ type MockOperator struct {
 	DocCountErr        error
 	SampleDocs         map[string][]map[string]interface{}
 	SampleErr          error
+	Documents          map[string][]map[string]interface{}
+	FindErr            error
 	Sums               map[string]float64 // key: "collection.field"
+	DecimalSums        map[string]string  // key: "collection.field"; exact sums, else Sums as text
 	SumErr             error
 	CountDistincts     map[string]int64 // key: "collection.field"
 	CountDistinctErr   error
+	ChangeStreamErr    error
+	OrphanErr          error
+	Aggregations       map[string][]map[string]interface{} // Aggregate results by collection, whatever the pipeline
+	AggregateErr       error
+
+	// Bulk write support
+	InsertErr      error
+	ApplyWritesErr error
 
 	// Index support
 	CreateIndexErr      error
 	CreateIndexesErr    error
 	IndexBuildStatuses  []IndexBuildStatus
 	IndexBuildErr       error
+	EstimatedProgress   map[string][]IndexBuildStatus // EstimateIndexBuildProgress results by collection
+	EstimateErr         error
+	ShardsByCollection  map[string][]string // CollectionShards results
+	ShardKeys           map[string][]string // ShardKey results
+	IndexBuildDelay     time.Duration       // how long CreateIndex takes
 	SetWriteConcernErr  error
+	SetValidatorErr     error
+
+	// View support
+	MaterializeErr error
+
+	// Canary query support
+	QueryStats map[string]*QueryStats // key: query name
+	ExplainErr error
+
+	// Privilege support: actions MissingActions reports as not held
+	DeniedActions []string
+	PrivilegeErr  error
+
+	// Caps overrides the MongoDB capabilities the mock reports.
+	Caps *Capabilities
 
 	// Track calls
 	CreatedCollections []string
+	CreatedSpecs       []CollectionSpec
 	DroppedCollections []string
+	EmptiedCollections []string
+	DeletedFilters     map[string][]map[string]interface{} // by collection
 	ShardingSetup      bool
 	BalancerDisabled   bool
 	BalancerEnabled    bool
 	CreatedIndexes     []CollectionIndex
+	MaterializedViews  []string
+	ExplainedQueries   []string
+	ChangeStreamTested []string
+	InsertedDocs       map[string][]interface{}
+	AppliedWrites      map[string][]WriteOp
 	WriteConcernSet    bool
 	WriteConcernW      string
 	WriteConcernJ      bool
+	ReadOptions        *ReadOptions
+	Validators         map[string]map[string]interface{} // by collection
+	ValidationLevels   map[string]string                 // by collection
+
+	mu sync.Mutex // guards CreatedIndexes, written by concurrent index builds
 }
 
 func (m *MockOperator) DetectTopology(_ context.Context) (*TopologyInfo, error) {
@@ -61,6 +109,14 @@ func (m *MockOperator) CreateCollections(_ context.Context, names []string) erro
 	return m.CreateErr
 }
 
+func (m *MockOperator) CreateCollectionsWithOptions(_ context.Context, specs []CollectionSpec) error {
+	for _, s := range specs {
+		m.CreatedCollections = append(m.CreatedCollections, s.Name)
+	}
+	m.CreatedSpecs = append(m.CreatedSpecs, specs...)
+	return m.CreateErr
+}
+
 func (m *MockOperator) SetupSharding(_ context.Context, _ *sizing.ShardingPlan) error {
 	m.ShardingSetup = true
 	return m.SetupShardErr
@@ -81,6 +137,33 @@ func (m *MockOperator) DropCollections(_ context.Context, names []string) error
 	return m.DropErr
 }
 
+func (m *MockOperator) EmptyCollection(_ context.Context, collection string) (int64, error) {
+	m.EmptiedCollections = append(m.EmptiedCollections, collection)
+	n := int64(len(m.InsertedDocs[collection]))
+	delete(m.InsertedDocs, collection)
+	return n, m.DropErr
+}
+
+func (m *MockOperator) DeleteDocuments(_ context.Context, collection string, filter map[string]interface{}) (int64, error) {
+	if m.DeletedFilters == nil {
+		m.DeletedFilters = make(map[string][]map[string]interface{})
+	}
+	m.DeletedFilters[collection] = append(m.DeletedFilters[collection], filter)
+	return 0, m.DropErr
+}
+
+func (m *MockOperator) MissingActions(_ context.Context, actions []string) ([]string, error) {
+	var missing []string
+	for _, a := range actions {
+		for _, d := range m.DeniedActions {
+			if a == d {
+				missing = append(missing, a)
+			}
+		}
+	}
+	return missing, m.PrivilegeErr
+}
+
 func (m *MockOperator) Close(_ context.Context) error {
 	return m.CloseErr
 }
@@ -97,6 +180,38 @@ func (m *MockOperator) CountDocuments(_ context.Context, collection string) (int
 	return 0, nil
 }
 
+func (m *MockOperator) FindDocuments(_ context.Context, collection string, _ map[string]interface{}) ([]map[string]interface{}, error) {
+	if m.FindErr != nil {
+		return nil, m.FindErr
+	}
+	return m.Documents[collection], nil
+}
+
+// FindRange returns the Documents whose field is an integer in [lo, hi).
+func (m *MockOperator) FindRange(_ context.Context, collection, field string, lo, hi int64) ([]map[string]interface{}, error) {
+	if m.FindErr != nil {
+		return nil, m.FindErr
+	}
+	var docs []map[string]interface{}
+	for _, doc := range m.Documents[collection] {
+		var k int64
+		switch v := doc[field].(type) {
+		case int:
+			k = int64(v)
+		case int32:
+			k = int64(v)
+		case int64:
+			k = v
+		default:
+			continue
+		}
+		if k >= lo && k < hi {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
 func (m *MockOperator) SampleDocuments(_ context.Context, collection string, _ int) ([]map[string]interface{}, error) {
 	if m.SampleErr != nil {
 		return nil, m.SampleErr
@@ -122,6 +237,19 @@ func (m *MockOperator) AggregateSum(_ context.Context, collection, field string)
 	return 0, nil
 }
 
+// AggregateDecimalSum returns DecimalSums[key], or the Sums value as
+// decimal text when it has none.
+func (m *MockOperator) AggregateDecimalSum(_ context.Context, collection, field string) (string, error) {
+	if m.SumErr != nil {
+		return "", m.SumErr
+	}
+	key := collection + "." + field
+	if s, ok := m.DecimalSums[key]; ok {
+		return s, nil
+	}
+	return strconv.FormatFloat(m.Sums[key], 'f', -1, 64), nil
+}
+
 func (m *MockOperator) AggregateCountDistinct(_ context.Context, collection, field string) (int64, error) {
 	if m.CountDistinctErr != nil {
 		return 0, m.CountDistinctErr
@@ -135,11 +263,94 @@ func (m *MockOperator) AggregateCountDistinct(_ context.Context, collection, fie
 	return 0, nil
 }
 
+// CountOrphans checks the Documents of the collection against those of the
+// parent, comparing top-level fields. Sample limits it to the first documents.
+func (m *MockOperator) CountOrphans(_ context.Context, ref OrphanQuery) (*OrphanCount, error) {
+	if m.OrphanErr != nil {
+		return nil, m.OrphanErr
+	}
+	parents := make(map[interface{}]bool)
+	for _, doc := range m.Documents[ref.Parent] {
+		if v, ok := doc[ref.ParentField]; ok {
+			parents[v] = true
+		}
+	}
+	count := &OrphanCount{}
+	for _, doc := range m.Documents[ref.Collection] {
+		v, ok := doc[ref.Field]
+		if !ok || v == nil {
+			continue
+		}
+		if ref.Sample > 0 && count.Checked == int64(ref.Sample) {
+			break
+		}
+		count.Checked++
+		if !parents[v] {
+			count.Orphans++
+			count.Examples = append(count.Examples, v)
+		}
+	}
+	return count, nil
+}
+
+// Capabilities reports Caps, or MongoDBCapabilities when unset.
+func (m *MockOperator) Capabilities() Capabilities {
+	if m.Caps != nil {
+		return *m.Caps
+	}
+	return MongoDBCapabilities
+}
+
+func (m *MockOperator) ChangeStreamSmokeTest(_ context.Context, collection string) error {
+	m.ChangeStreamTested = append(m.ChangeStreamTested, collection)
+	return m.ChangeStreamErr
+}
+
+func (m *MockOperator) InsertDocuments(_ context.Context, collection string, docs []interface{}) (int64, error) {
+	if m.InsertErr != nil {
+		return 0, m.InsertErr
+	}
+	if m.InsertedDocs == nil {
+		m.InsertedDocs = make(map[string][]interface{})
+	}
+	m.InsertedDocs[collection] = append(m.InsertedDocs[collection], docs...)
+	return int64(len(docs)), nil
+}
+
+func (m *MockOperator) ApplyWrites(_ context.Context, collection string, ops []WriteOp) error {
+	if m.ApplyWritesErr != nil {
+		return m.ApplyWritesErr
+	}
+	if m.AppliedWrites == nil {
+		m.AppliedWrites = make(map[string][]WriteOp)
+	}
+	m.AppliedWrites[collection] = append(m.AppliedWrites[collection], ops...)
+	return nil
+}
+
 func (m *MockOperator) CreateIndex(_ context.Context, collection string, index IndexDefinition) error {
+	time.Sleep(m.IndexBuildDelay)
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.CreatedIndexes = append(m.CreatedIndexes, CollectionIndex{Collection: collection, Index: index})
 	return m.CreateIndexErr
 }
 
+func (m *MockOperator) CollectionShards(_ context.Context, collection string) ([]string, error) {
+	return m.ShardsByCollection[collection], nil
+}
+
+func (m *MockOperator) Aggregate(_ context.Context, collection string, _ []map[string]interface{}) ([]map[string]interface{}, error) {
+	if m.AggregateErr != nil {
+		return nil, m.AggregateErr
+	}
+	return m.Aggregations[collection], nil
+}
+
+func (m *MockOperator) ShardKey(_ context.Context, collection string) ([]string, error) {
+	return m.ShardKeys[collection], nil
+}
+
 func (m *MockOperator) CreateIndexes(_ context.Context, indexes []CollectionIndex) error {
 	if m.CreateIndexesErr != nil {
 		return m.CreateIndexesErr
@@ -152,6 +363,29 @@ func (m *MockOperator) ListIndexBuildProgress(_ context.Context) ([]IndexBuildSt
 	return m.IndexBuildStatuses, m.IndexBuildErr
 }
 
+func (m *MockOperator) EstimateIndexBuildProgress(_ context.Context, collection string, names []string) ([]IndexBuildStatus, error) {
+	if m.EstimateErr != nil {
+		return nil, m.EstimateErr
+	}
+	var out []IndexBuildStatus
+	for _, s := range m.EstimatedProgress[collection] {
+		for _, n := range names {
+			if s.IndexName == n {
+				out = append(out, s)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *MockOperator) MaterializeView(_ context.Context, view mapping.View) error {
+	if m.MaterializeErr != nil {
+		return m.MaterializeErr
+	}
+	m.MaterializedViews = append(m.MaterializedViews, view.Name)
+	return nil
+}
+
 func (m *MockOperator) SetWriteConcern(_ context.Context, w string, journal bool) error {
 	if m.SetWriteConcernErr != nil {
 		return m.SetWriteConcernErr
@@ -161,3 +395,35 @@ func (m *MockOperator) SetWriteConcern(_ context.Context, w string, journal bool
 	m.WriteConcernJ = journal
 	return nil
 }
+
+func (m *MockOperator) SetValidator(_ context.Context, collection string, validator map[string]interface{}, level string) error {
+	if m.SetValidatorErr != nil {
+		return m.SetValidatorErr
+	}
+	if m.Validators == nil {
+		m.Validators = make(map[string]map[string]interface{})
+		m.ValidationLevels = make(map[string]string)
+	}
+	m.Validators[collection] = validator
+	m.ValidationLevels[collection] = level
+	return nil
+}
+
+func (m *MockOperator) SetReadOptions(_ context.Context, opts ReadOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	m.ReadOptions = &opts
+	return nil
+}
+
+func (m *MockOperator) ExplainQuery(_ context.Context, query mapping.CanaryQuery) (*QueryStats, error) {
+	if m.ExplainErr != nil {
+		return nil, m.ExplainErr
+	}
+	m.ExplainedQueries = append(m.ExplainedQueries, query.Name)
+	if stats, ok := m.QueryStats[query.Name]; ok {
+		return stats, nil
+	}
+	return &QueryStats{Stages: []string{"FETCH", "IXSCAN"}}, nil
+}
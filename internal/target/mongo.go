@@ -3,24 +3,51 @@ package target
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
 
+	"github.com/reloquent/reloquent/internal/config"
 	"github.com/reloquent/reloquent/internal/sizing"
 )
 
+func init() {
+	Register(Driver{
+		Name:         "mongodb",
+		Schemes:      []string{"mongodb", "mongodb+srv"},
+		Capabilities: MongoDBCapabilities,
+		Open: func(ctx context.Context, connectionString, database string) (Operator, error) {
+			return NewMongoOperator(ctx, connectionString, database)
+		},
+	})
+}
+
 // MongoOperator implements Operator using the MongoDB driver.
 type MongoOperator struct {
 	client   *mongo.Client
 	database string
 	connStr  string
+
+	// Set by SetReadOptions. readDB carries the read preference and
+	// concern; readAfter is the primary's last write, which causally
+	// consistent validation reads wait for.
+	readDB    *mongo.Database
+	readAfter *bson.Timestamp
 }
 
 // NewMongoOperator creates a new MongoOperator connected to the given MongoDB instance.
+// Credential references in the connection string are resolved first.
 func NewMongoOperator(ctx context.Context, connectionString, database string) (*MongoOperator, error) {
+	connectionString, err := config.ResolveValue(connectionString)
+	if err != nil {
+		return nil, fmt.Errorf("resolving MongoDB credentials: %w", err)
+	}
+
 	opts := options.Client().ApplyURI(connectionString)
 	client, err := mongo.Connect(opts)
 	if err != nil {
@@ -122,6 +149,25 @@ func (m *MongoOperator) Validate(ctx context.Context, plan *sizing.SizingPlan) (
 			})
 			result.Passed = false
 		}
+
+		if zones := plan.ShardPlan.Zones(); len(zones) > 0 {
+			if err := plan.ShardPlan.ValidateZones(); err != nil {
+				result.Errors = append(result.Errors, ValidationIssue{
+					Category:   "shard",
+					Message:    "Invalid zone configuration: " + err.Error(),
+					Suggestion: "Fix the zone ranges in the mapping so each has a zone name, Min < Max, and no overlaps.",
+				})
+				result.Passed = false
+			}
+			shardZones, err := m.shardZones(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("reading shard zones: %w", err)
+			}
+			if issues := checkZones(zones, shardZones); len(issues) > 0 {
+				result.Errors = append(result.Errors, issues...)
+				result.Passed = false
+			}
+		}
 	}
 
 	// Warn about standalone deployments
@@ -138,18 +184,41 @@ func (m *MongoOperator) Validate(ctx context.Context, plan *sizing.SizingPlan) (
 
 // CreateCollections creates empty collections in the target database.
 func (m *MongoOperator) CreateCollections(ctx context.Context, names []string) error {
+	specs := make([]CollectionSpec, len(names))
+	for i, name := range names {
+		specs[i] = CollectionSpec{Name: name}
+	}
+	return m.CreateCollectionsWithOptions(ctx, specs)
+}
+
+// CreateCollectionsWithOptions creates empty collections with per-collection
+// WiredTiger storage options such as the block compressor, and a default
+// collation where the spec sets one.
+func (m *MongoOperator) CreateCollectionsWithOptions(ctx context.Context, specs []CollectionSpec) error {
 	db := m.client.Database(m.database)
-	for _, name := range names {
-		if err := db.CreateCollection(ctx, name); err != nil {
+	for _, spec := range specs {
+		if err := db.CreateCollection(ctx, spec.Name, createCollectionOptions(spec)); err != nil {
 			// Ignore "already exists" errors
 			if !strings.Contains(err.Error(), "already exists") {
-				return fmt.Errorf("creating collection %s: %w", name, err)
+				return fmt.Errorf("creating collection %s: %w", spec.Name, err)
 			}
 		}
 	}
 	return nil
 }
 
+// createCollectionOptions builds the create options for a collection spec.
+func createCollectionOptions(spec CollectionSpec) *options.CreateCollectionOptionsBuilder {
+	opts := options.CreateCollection()
+	if cfg := spec.WiredTigerConfig(); cfg != "" {
+		opts.SetStorageEngine(bson.D{{Key: "wiredTiger", Value: bson.D{{Key: "configString", Value: cfg}}}})
+	}
+	if c := spec.Collation; c != nil {
+		opts.SetCollation(&options.Collation{Locale: c.Locale, Strength: c.Strength})
+	}
+	return opts
+}
+
 // SetupSharding configures sharding on the target database.
 func (m *MongoOperator) SetupSharding(ctx context.Context, plan *sizing.ShardingPlan) error {
 	if plan == nil || !plan.Recommended {
@@ -167,19 +236,13 @@ func (m *MongoOperator) SetupSharding(ctx context.Context, plan *sizing.Sharding
 
 	// Shard each collection
 	for _, col := range plan.Collections {
-		shardKey := bson.D{}
-		for k, v := range col.ShardKey {
-			if v == "hashed" {
-				shardKey = append(shardKey, bson.E{Key: k, Value: "hashed"})
-			} else {
-				shardKey = append(shardKey, bson.E{Key: k, Value: 1})
-			}
-		}
-
 		ns := m.database + "." + col.CollectionName
 		cmd := bson.D{
 			{Key: "shardCollection", Value: ns},
-			{Key: "key", Value: shardKey},
+			{Key: "key", Value: shardKeyDoc(col)},
+			// Required when the collection has a default collation: the
+			// shard key index itself must compare byte by byte.
+			{Key: "collation", Value: bson.D{{Key: "locale", Value: "simple"}}},
 		}
 
 		if err := admin.RunCommand(ctx, cmd).Err(); err != nil {
@@ -187,6 +250,15 @@ func (m *MongoOperator) SetupSharding(ctx context.Context, plan *sizing.Sharding
 				return fmt.Errorf("sharding collection %s: %w", col.CollectionName, err)
 			}
 		}
+
+		if col.Zones == nil {
+			continue
+		}
+		for _, r := range col.Zones.Ranges {
+			if err := admin.RunCommand(ctx, zoneRangeCmd(ns, col, r)).Err(); err != nil {
+				return fmt.Errorf("assigning %s range of %s to zone %s: %w", col.Zones.Field, col.CollectionName, r.Zone, err)
+			}
+		}
 	}
 
 	return nil
@@ -217,9 +289,33 @@ func (m *MongoOperator) DropCollections(ctx context.Context, names []string) err
 	return nil
 }
 
+// DeleteDocuments deletes the documents of a collection matching filter.
+func (m *MongoOperator) DeleteDocuments(ctx context.Context, collection string, filter map[string]interface{}) (int64, error) {
+	res, err := m.client.Database(m.database).Collection(collection).DeleteMany(ctx, bson.M(filter))
+	if err != nil {
+		return 0, fmt.Errorf("deleting documents from %s: %w", collection, err)
+	}
+	return res.DeletedCount, nil
+}
+
+// EmptyCollection deletes every document of a collection, keeping the
+// collection itself.
+func (m *MongoOperator) EmptyCollection(ctx context.Context, collection string) (int64, error) {
+	res, err := m.client.Database(m.database).Collection(collection).DeleteMany(ctx, bson.D{})
+	if err != nil {
+		return 0, fmt.Errorf("emptying collection %s: %w", collection, err)
+	}
+	return res.DeletedCount, nil
+}
+
 // CountDocuments returns the number of documents in a collection.
 func (m *MongoOperator) CountDocuments(ctx context.Context, collection string) (int64, error) {
-	count, err := m.client.Database(m.database).Collection(collection).CountDocuments(ctx, bson.D{})
+	ctx, end, err := m.readContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+	count, err := m.reads().Collection(collection).CountDocuments(ctx, bson.D{})
 	if err != nil {
 		return 0, fmt.Errorf("counting documents in %s: %w", collection, err)
 	}
@@ -228,8 +324,13 @@ func (m *MongoOperator) CountDocuments(ctx context.Context, collection string) (
 
 // SampleDocuments returns n random documents from a collection using $sample.
 func (m *MongoOperator) SampleDocuments(ctx context.Context, collection string, n int) ([]map[string]interface{}, error) {
+	ctx, end, err := m.readContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
 	pipeline := bson.A{bson.D{{Key: "$sample", Value: bson.D{{Key: "size", Value: n}}}}}
-	cursor, err := m.client.Database(m.database).Collection(collection).Aggregate(ctx, pipeline)
+	cursor, err := m.reads().Collection(collection).Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, fmt.Errorf("sampling documents from %s: %w", collection, err)
 	}
@@ -250,6 +351,111 @@ func (m *MongoOperator) SampleDocuments(ctx context.Context, collection string,
 	return results, cursor.Err()
 }
 
+// maxOrphanExamples bounds the orphaned values CountOrphans returns.
+const maxOrphanExamples = 10
+
+// CountOrphans looks up each distinct reference value in the parent
+// collection with $lookup, which an index on the parent field keeps cheap.
+func (m *MongoOperator) CountOrphans(ctx context.Context, ref OrphanQuery) (*OrphanCount, error) {
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.D{{Key: ref.Field, Value: bson.D{{Key: "$ne", Value: nil}}}}}},
+	}
+	if ref.Sample > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$sample", Value: bson.D{{Key: "size", Value: ref.Sample}}}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$" + ref.Field},
+			{Key: "n", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: ref.Parent},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: ref.ParentField},
+			{Key: "pipeline", Value: bson.A{
+				bson.D{{Key: "$limit", Value: 1}},
+				bson.D{{Key: "$project", Value: bson.D{{Key: "_id", Value: 1}}}},
+			}},
+			{Key: "as", Value: "parent"},
+		}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "checked", Value: bson.D{{Key: "$sum", Value: "$n"}}},
+			{Key: "orphans", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$eq", Value: bson.A{bson.D{{Key: "$size", Value: "$parent"}}, 0}}}, "$n", 0,
+			}}}}}},
+			{Key: "examples", Value: bson.D{{Key: "$push", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$eq", Value: bson.A{bson.D{{Key: "$size", Value: "$parent"}}, 0}}}, "$_id", "$$REMOVE",
+			}}}}}},
+		}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "checked", Value: 1},
+			{Key: "orphans", Value: 1},
+			{Key: "examples", Value: bson.D{{Key: "$slice", Value: bson.A{"$examples", maxOrphanExamples}}}},
+		}}},
+	)
+
+	ctx, end, err := m.readContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	cursor, err := m.reads().Collection(ref.Collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("checking %s.%s against %s.%s: %w", ref.Collection, ref.Field, ref.Parent, ref.ParentField, err)
+	}
+	defer cursor.Close(ctx)
+
+	count := &OrphanCount{}
+	if cursor.Next(ctx) {
+		var result struct {
+			Checked  int64         `bson:"checked"`
+			Orphans  int64         `bson:"orphans"`
+			Examples []interface{} `bson:"examples"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, fmt.Errorf("decoding orphan count: %w", err)
+		}
+		count.Checked, count.Orphans, count.Examples = result.Checked, result.Orphans, result.Examples
+	}
+	return count, cursor.Err()
+}
+
+// FindDocuments returns every document matching filter; a nil filter matches all.
+func (m *MongoOperator) FindDocuments(ctx context.Context, collection string, filter map[string]interface{}) ([]map[string]interface{}, error) {
+	f := bson.M{}
+	for k, v := range filter {
+		f[k] = v
+	}
+	ctx, end, err := m.readContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	cursor, err := m.reads().Collection(collection).Find(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("finding documents in %s: %w", collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []map[string]interface{}
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decoding document: %w", err)
+		}
+		results = append(results, map[string]interface{}(doc))
+	}
+	return results, cursor.Err()
+}
+
+// FindRange returns the documents whose integer field is in [lo, hi).
+func (m *MongoOperator) FindRange(ctx context.Context, collection, field string, lo, hi int64) ([]map[string]interface{}, error) {
+	return m.FindDocuments(ctx, collection, map[string]interface{}{
+		field: bson.M{"$gte": lo, "$lt": hi},
+	})
+}
+
 // AggregateSum returns the SUM of a numeric field across all documents.
 func (m *MongoOperator) AggregateSum(ctx context.Context, collection, field string) (float64, error) {
 	pipeline := bson.A{
@@ -258,7 +464,12 @@ func (m *MongoOperator) AggregateSum(ctx context.Context, collection, field stri
 			{Key: "total", Value: bson.D{{Key: "$sum", Value: "$" + field}}},
 		}}},
 	}
-	cursor, err := m.client.Database(m.database).Collection(collection).Aggregate(ctx, pipeline)
+	ctx, end, err := m.readContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+	cursor, err := m.reads().Collection(collection).Aggregate(ctx, pipeline)
 	if err != nil {
 		return 0, fmt.Errorf("aggregating sum on %s.%s: %w", collection, field, err)
 	}
@@ -276,18 +487,57 @@ func (m *MongoOperator) AggregateSum(ctx context.Context, collection, field stri
 			return float64(v), nil
 		case int64:
 			return float64(v), nil
+		case bson.Decimal128:
+			return strconv.ParseFloat(v.String(), 64)
 		}
 	}
 	return 0, nil
 }
 
+// AggregateDecimalSum returns the exact SUM of a numeric field across all
+// documents as decimal text, adding the values as Decimal128s.
+func (m *MongoOperator) AggregateDecimalSum(ctx context.Context, collection, field string) (string, error) {
+	pipeline := bson.A{
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$toDecimal", Value: "$" + field}}}}},
+		}}},
+	}
+	ctx, end, err := m.readContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer end()
+	cursor, err := m.reads().Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return "", fmt.Errorf("aggregating sum on %s.%s: %w", collection, field, err)
+	}
+	defer cursor.Close(ctx)
+
+	if cursor.Next(ctx) {
+		var result bson.M
+		if err := cursor.Decode(&result); err != nil {
+			return "", fmt.Errorf("decoding sum result: %w", err)
+		}
+		if d, ok := result["total"].(bson.Decimal128); ok {
+			return d.String(), nil
+		}
+	}
+	return "0", nil
+}
+
 // AggregateCountDistinct returns the number of distinct values for a field.
 func (m *MongoOperator) AggregateCountDistinct(ctx context.Context, collection, field string) (int64, error) {
 	pipeline := bson.A{
 		bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$" + field}}}},
 		bson.D{{Key: "$count", Value: "count"}},
 	}
-	cursor, err := m.client.Database(m.database).Collection(collection).Aggregate(ctx, pipeline)
+	ctx, end, err := m.readContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+	cursor, err := m.reads().Collection(collection).Aggregate(ctx, pipeline)
 	if err != nil {
 		return 0, fmt.Errorf("counting distinct %s.%s: %w", collection, field, err)
 	}
@@ -308,11 +558,86 @@ func (m *MongoOperator) AggregateCountDistinct(ctx context.Context, collection,
 	return 0, nil
 }
 
+// InsertDocuments bulk-inserts documents using an unordered InsertMany so a
+// single bad document does not abort the rest of the batch.
+func (m *MongoOperator) InsertDocuments(ctx context.Context, collection string, docs []interface{}) (int64, error) {
+	if len(docs) == 0 {
+		return 0, nil
+	}
+	opts := options.InsertMany().SetOrdered(false)
+	res, err := m.client.Database(m.database).Collection(collection).InsertMany(ctx, docs, opts)
+	if err != nil {
+		var inserted int64
+		if res != nil {
+			inserted = int64(len(res.InsertedIDs))
+		}
+		return inserted, fmt.Errorf("inserting into %s: %w", collection, err)
+	}
+	return int64(len(res.InsertedIDs)), nil
+}
+
+// ApplyWrites executes the given operations as one ordered bulk write so that
+// changes to the same document are applied in capture order.
+func (m *MongoOperator) ApplyWrites(ctx context.Context, collection string, ops []WriteOp) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(ops))
+	for _, op := range ops {
+		filter := bson.M(op.Filter)
+		switch op.Type {
+		case WriteUpsert:
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(filter).
+				SetUpdate(bson.M{"$set": bson.M(op.Doc)}).
+				SetUpsert(true))
+		case WriteDelete:
+			models = append(models, mongo.NewDeleteOneModel().SetFilter(filter))
+		case WritePush:
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(filter).
+				SetUpdate(bson.M{"$push": bson.M{op.Field: bson.M(op.Doc)}}))
+		case WritePull:
+			models = append(models, mongo.NewUpdateManyModel().
+				SetFilter(filter).
+				SetUpdate(bson.M{"$pull": bson.M{op.Field: bson.M(op.Match)}}))
+		case WriteSetField:
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(filter).
+				SetUpdate(bson.M{"$set": bson.M{op.Field: bson.M(op.Doc)}}))
+		case WriteUnsetField:
+			models = append(models, mongo.NewUpdateManyModel().
+				SetFilter(filter).
+				SetUpdate(bson.M{"$unset": bson.M{op.Field: ""}}))
+		default:
+			return fmt.Errorf("unknown write operation %q", op.Type)
+		}
+	}
+
+	opts := options.BulkWrite().SetOrdered(true)
+	if _, err := m.client.Database(m.database).Collection(collection).BulkWrite(ctx, models, opts); err != nil {
+		return fmt.Errorf("applying writes to %s: %w", collection, err)
+	}
+	return nil
+}
+
 // CreateIndex creates a single index on a collection.
 func (m *MongoOperator) CreateIndex(ctx context.Context, collection string, index IndexDefinition) error {
+	if index.Search() {
+		return fmt.Errorf("index %s on %s is an Atlas Search index and is created through the Atlas API", index.Name, collection)
+	}
+	_, err := m.client.Database(m.database).Collection(collection).Indexes().CreateOne(ctx, indexModel(index))
+	if err != nil {
+		return fmt.Errorf("creating index on %s: %w", collection, err)
+	}
+	return nil
+}
+
+// indexModel builds the driver's index specification for an index.
+func indexModel(index IndexDefinition) mongo.IndexModel {
 	keys := bson.D{}
 	for _, k := range index.Keys {
-		keys = append(keys, bson.E{Key: k.Field, Value: k.Order})
+		keys = append(keys, bson.E{Key: k.Field, Value: k.Value()})
 	}
 
 	opts := options.Index()
@@ -322,17 +647,23 @@ func (m *MongoOperator) CreateIndex(ctx context.Context, collection string, inde
 	if index.Unique {
 		opts.SetUnique(true)
 	}
+	if index.ExpireAfterSeconds > 0 {
+		opts.SetExpireAfterSeconds(index.ExpireAfterSeconds)
+	}
+	if len(index.PartialFilter) > 0 {
+		opts.SetPartialFilterExpression(index.PartialFilter)
+	}
+	if index.Sparse {
+		opts.SetSparse(true)
+	}
+	if c := index.Collation; c != nil {
+		opts.SetCollation(&options.Collation{Locale: c.Locale, Strength: c.Strength})
+	}
 
-	model := mongo.IndexModel{
+	return mongo.IndexModel{
 		Keys:    keys,
 		Options: opts,
 	}
-
-	_, err := m.client.Database(m.database).Collection(collection).Indexes().CreateOne(ctx, model)
-	if err != nil {
-		return fmt.Errorf("creating index on %s: %w", collection, err)
-	}
-	return nil
 }
 
 // CreateIndexes creates multiple indexes across collections.
@@ -357,68 +688,136 @@ func (m *MongoOperator) ListIndexBuildProgress(ctx context.Context) ([]IndexBuil
 		return nil, fmt.Errorf("querying currentOp: %w", err)
 	}
 
-	var statuses []IndexBuildStatus
-	inprog, ok := result["inprog"]
-	if !ok {
-		return statuses, nil
+	ops, _ := result["inprog"].(bson.A)
+	return indexBuildOps(ops, m.database), nil
+}
+
+// SetWriteConcern sets the default write concern on the database.
+func (m *MongoOperator) SetWriteConcern(ctx context.Context, w string, journal bool) error {
+	wc := bson.D{{Key: "w", Value: w}, {Key: "j", Value: journal}}
+	cmd := bson.D{
+		{Key: "setDefaultRWConcern", Value: 1},
+		{Key: "defaultWriteConcern", Value: wc},
+	}
+	if err := m.client.Database("admin").RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("setting write concern: %w", err)
+	}
+	return nil
+}
+
+// SetValidator replaces a collection's validator and sets how strictly
+// it is enforced (off, moderate or strict). Documents that fail it are
+// rejected.
+func (m *MongoOperator) SetValidator(ctx context.Context, collection string, validator map[string]interface{}, level string) error {
+	if validator == nil {
+		validator = map[string]interface{}{}
+	}
+	cmd := bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: level},
+		{Key: "validationAction", Value: "error"},
 	}
+	if err := m.client.Database(m.database).RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("setting validator on %s: %w", collection, err)
+	}
+	return nil
+}
 
-	ops, ok := inprog.(bson.A)
-	if !ok {
-		return statuses, nil
+// SetReadOptions sets the read preference and concern of the validation
+// reads: CountDocuments, SampleDocuments, FindDocuments, FindRange and the
+// aggregates. When reads may go to a secondary, it records the primary's
+// last write; each read then runs in a causally consistent session that
+// waits until the member serving it has caught up to that write.
+func (m *MongoOperator) SetReadOptions(ctx context.Context, ro ReadOptions) error {
+	if err := ro.Validate(); err != nil {
+		return err
 	}
 
-	for _, op := range ops {
-		doc, ok := op.(bson.M)
-		if !ok {
-			continue
+	dbOpts := options.Database()
+	if ro.Preference != "" {
+		mode, err := readpref.ModeFromString(ro.Preference)
+		if err != nil {
+			return err
 		}
-		desc, _ := doc["desc"].(string)
-		if !strings.Contains(desc, "Index") {
-			// Also check the command field
-			cmdDoc, _ := doc["command"].(bson.M)
-			if cmdDoc == nil {
-				continue
-			}
-			if _, hasCreateIndexes := cmdDoc["createIndexes"]; !hasCreateIndexes {
-				continue
-			}
+		rp, err := readpref.New(mode)
+		if err != nil {
+			return fmt.Errorf("read preference: %w", err)
 		}
+		dbOpts.SetReadPreference(rp)
+	}
+	if c := ro.EffectiveConcern(); c != "" {
+		dbOpts.SetReadConcern(&readconcern.ReadConcern{Level: c})
+	}
+	m.readDB = m.client.Database(m.database, dbOpts)
+	m.readAfter = nil
 
-		ns, _ := doc["ns"].(string)
-		msg, _ := doc["msg"].(string)
-		var progress float64
-		if p, ok := doc["progress"].(bson.M); ok {
-			done, _ := p["done"].(int64)
-			total, _ := p["total"].(int64)
-			if total > 0 {
-				progress = float64(done) / float64(total) * 100
-			}
-		}
+	if !ro.Causal() {
+		return nil
+	}
+	ts, err := m.lastWrite(ctx)
+	if err != nil {
+		return fmt.Errorf("reading the primary's last write: %w", err)
+	}
+	m.readAfter = ts
+	return nil
+}
 
-		statuses = append(statuses, IndexBuildStatus{
-			Collection: ns,
-			IndexName:  desc,
-			Phase:      "building",
-			Progress:   progress,
-			Message:    msg,
-		})
+// lastWrite returns the optime of the primary's latest write, from hello on
+// a replica set member or the cluster time reported by mongos.
+func (m *MongoOperator) lastWrite(ctx context.Context) (*bson.Timestamp, error) {
+	sess, err := m.client.StartSession(options.Session().SetCausalConsistency(true))
+	if err != nil {
+		return nil, err
 	}
+	defer sess.EndSession(ctx)
 
-	return statuses, nil
+	var hello struct {
+		LastWrite struct {
+			OpTime struct {
+				TS bson.Timestamp `bson:"ts"`
+			} `bson:"opTime"`
+		} `bson:"lastWrite"`
+	}
+	sctx := mongo.NewSessionContext(ctx, sess)
+	if err := m.client.Database("admin").RunCommand(sctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return nil, err
+	}
+	if ts := hello.LastWrite.OpTime.TS; !ts.IsZero() {
+		return &ts, nil
+	}
+	return sess.OperationTime(), nil
 }
 
-// SetWriteConcern sets the default write concern on the database.
-func (m *MongoOperator) SetWriteConcern(ctx context.Context, w string, journal bool) error {
-	wc := bson.D{{Key: "w", Value: w}, {Key: "j", Value: journal}}
-	cmd := bson.D{
-		{Key: "setDefaultRWConcern", Value: 1},
-		{Key: "defaultWriteConcern", Value: wc},
+// reads returns the database handle validation reads go through.
+func (m *MongoOperator) reads() *mongo.Database {
+	if m.readDB != nil {
+		return m.readDB
 	}
-	if err := m.client.Database("admin").RunCommand(ctx, cmd).Err(); err != nil {
-		return fmt.Errorf("setting write concern: %w", err)
+	return m.client.Database(m.database)
+}
+
+// readContext wraps ctx in a causally consistent session that starts after
+// the write recorded by SetReadOptions. Sessions are not safe for concurrent
+// use, so each read gets its own; the returned func ends it.
+func (m *MongoOperator) readContext(ctx context.Context) (context.Context, func(), error) {
+	if m.readAfter == nil {
+		return ctx, func() {}, nil
 	}
-	return nil
+	sess, err := m.client.StartSession(options.Session().SetCausalConsistency(true))
+	if err != nil {
+		return nil, nil, fmt.Errorf("starting session: %w", err)
+	}
+	if err := sess.AdvanceOperationTime(m.readAfter); err != nil {
+		sess.EndSession(ctx)
+		return nil, nil, fmt.Errorf("advancing session operation time: %w", err)
+	}
+	return mongo.NewSessionContext(ctx, sess), func() { sess.EndSession(ctx) }, nil
+}
+
+// Ping checks the connection to MongoDB is still alive.
+func (m *MongoOperator) Ping(ctx context.Context) error {
+	return m.client.Ping(ctx, nil)
 }
 
 // Close disconnects from MongoDB.
@@ -0,0 +1,70 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MigrationActions are the privilege actions a migration needs on the
+// target database: creating, writing and dropping collections, building
+// indexes and setting validators.
+var MigrationActions = []string{
+	"find", "insert", "update", "remove",
+	"createCollection", "createIndex", "dropCollection", "collMod",
+}
+
+// PrivilegeChecker is implemented by operators that can report which
+// actions the connected user is not allowed on the target database.
+type PrivilegeChecker interface {
+	MissingActions(ctx context.Context, actions []string) ([]string, error)
+}
+
+// MissingActions returns the actions the authenticated user holds no
+// privilege for on the operator's database, in the order given. A server
+// running without access control allows everything.
+func (m *MongoOperator) MissingActions(ctx context.Context, actions []string) ([]string, error) {
+	var status struct {
+		AuthInfo struct {
+			AuthenticatedUsers      []bson.M `bson:"authenticatedUsers"`
+			AuthenticatedPrivileges []struct {
+				Resource struct {
+					DB          *string `bson:"db"`
+					Collection  *string `bson:"collection"`
+					AnyResource bool    `bson:"anyResource"`
+				} `bson:"resource"`
+				Actions []string `bson:"actions"`
+			} `bson:"authenticatedUserPrivileges"`
+		} `bson:"authInfo"`
+	}
+	cmd := bson.D{{Key: "connectionStatus", Value: 1}, {Key: "showPrivileges", Value: true}}
+	if err := m.client.Database("admin").RunCommand(ctx, cmd).Decode(&status); err != nil {
+		return nil, fmt.Errorf("reading connection privileges: %w", err)
+	}
+	if len(status.AuthInfo.AuthenticatedUsers) == 0 {
+		return nil, nil
+	}
+
+	held := map[string]bool{}
+	for _, p := range status.AuthInfo.AuthenticatedPrivileges {
+		r := p.Resource
+		// a privilege on the database, on any database, or on any resource
+		onDB := r.AnyResource || (r.DB != nil && (*r.DB == m.database || *r.DB == "") &&
+			r.Collection != nil && *r.Collection == "")
+		if !onDB {
+			continue
+		}
+		for _, a := range p.Actions {
+			held[a] = true
+		}
+	}
+	var missing []string
+	for _, a := range actions {
+		if !held[a] && !slices.Contains(missing, a) {
+			missing = append(missing, a)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,30 @@
+package target
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// RangeCounter is implemented by operators that can count the documents in
+// a key range without reading them.
+type RangeCounter interface {
+	// CountRange counts the documents whose field is in [lo, hi).
+	CountRange(ctx context.Context, collection, field string, lo, hi int64) (int64, error)
+}
+
+// CountRange counts the documents whose field is in [lo, hi).
+func (m *MongoOperator) CountRange(ctx context.Context, collection, field string, lo, hi int64) (int64, error) {
+	ctx, end, err := m.readContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+	filter := bson.D{{Key: field, Value: bson.D{{Key: "$gte", Value: lo}, {Key: "$lt", Value: hi}}}}
+	count, err := m.reads().Collection(collection).CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("counting documents in %s: %w", collection, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,70 @@
+package target
+
+import "fmt"
+
+// Read preferences accepted by ReadOptions, as in the MongoDB URI option.
+const (
+	ReadPrimary            = "primary"
+	ReadPrimaryPreferred   = "primaryPreferred"
+	ReadSecondary          = "secondary"
+	ReadSecondaryPreferred = "secondaryPreferred"
+	ReadNearest            = "nearest"
+)
+
+// Read concerns accepted by ReadOptions.
+const (
+	ReadConcernLocal        = "local"
+	ReadConcernMajority     = "majority"
+	ReadConcernLinearizable = "linearizable"
+)
+
+// ReadOptions controls how validation reads the target. The zero value
+// reads from the primary with the driver's default read concern.
+//
+// Reads that may go to a secondary are made in a causally consistent
+// session that first observes the primary's latest write, so counts and
+// aggregates never run against a member that is behind the migration.
+type ReadOptions struct {
+	Preference string `yaml:"read_preference,omitempty" json:"read_preference,omitempty"`
+	Concern    string `yaml:"read_concern,omitempty" json:"read_concern,omitempty"`
+}
+
+// Validate checks the preference and concern names and that they can be
+// combined.
+func (o ReadOptions) Validate() error {
+	switch o.Preference {
+	case "", ReadPrimary, ReadPrimaryPreferred, ReadSecondary, ReadSecondaryPreferred, ReadNearest:
+	default:
+		return fmt.Errorf("unknown read preference %q (want primary, primaryPreferred, secondary, secondaryPreferred or nearest)", o.Preference)
+	}
+	switch o.Concern {
+	case "", ReadConcernLocal, ReadConcernMajority:
+	case ReadConcernLinearizable:
+		if !o.primaryOnly() {
+			return fmt.Errorf("read concern linearizable requires read preference primary")
+		}
+	default:
+		return fmt.Errorf("unknown read concern %q (want local, majority or linearizable)", o.Concern)
+	}
+	return nil
+}
+
+// Causal reports whether reads need a causally consistent session: any
+// read that may be served by a secondary.
+func (o ReadOptions) Causal() bool {
+	return !o.primaryOnly()
+}
+
+// EffectiveConcern is the read concern used. Causally consistent reads
+// default to majority, which is what makes them wait for the migration's
+// acknowledged writes on a lagging secondary.
+func (o ReadOptions) EffectiveConcern() string {
+	if o.Concern == "" && o.Causal() {
+		return ReadConcernMajority
+	}
+	return o.Concern
+}
+
+func (o ReadOptions) primaryOnly() bool {
+	return o.Preference == "" || o.Preference == ReadPrimary
+}
@@ -0,0 +1,43 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// ShardKeyReader is implemented by operators that can read the shard key a
+// collection was sharded on.
+type ShardKeyReader interface {
+	// ShardKey returns the shard key fields of the collection in key order.
+	// It returns nil when the collection is not sharded.
+	ShardKey(ctx context.Context, collection string) ([]string, error)
+}
+
+// ShardKey reads the collection's key from the cluster metadata. Targets
+// that are not sharded clusters have no config.collections entry for it.
+func (m *MongoOperator) ShardKey(ctx context.Context, collection string) ([]string, error) {
+	ns := m.database + "." + collection
+	var coll struct {
+		Key     bson.D `bson:"key"`
+		Dropped bool   `bson:"dropped"`
+	}
+	err := m.client.Database("config").Collection("collections").FindOne(ctx, bson.D{{Key: "_id", Value: ns}}).Decode(&coll)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading shard key of %s: %w", ns, err)
+	}
+	if coll.Dropped {
+		return nil, nil
+	}
+	fields := make([]string, 0, len(coll.Key))
+	for _, e := range coll.Key {
+		fields = append(fields, e.Key)
+	}
+	return fields, nil
+}
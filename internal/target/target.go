@@ -2,7 +2,9 @@ package target
 
 import (
 	"context"
+	"strings"
 
+	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/sizing"
 )
 
@@ -11,6 +13,7 @@ type Operator interface {
 	DetectTopology(ctx context.Context) (*TopologyInfo, error)
 	Validate(ctx context.Context, plan *sizing.SizingPlan) (*ValidationResult, error)
 	CreateCollections(ctx context.Context, names []string) error
+	CreateCollectionsWithOptions(ctx context.Context, specs []CollectionSpec) error
 	SetupSharding(ctx context.Context, plan *sizing.ShardingPlan) error
 	DisableBalancer(ctx context.Context) error
 	EnableBalancer(ctx context.Context) error
@@ -20,16 +23,37 @@ type Operator interface {
 	// Validation support
 	CountDocuments(ctx context.Context, collection string) (int64, error)
 	SampleDocuments(ctx context.Context, collection string, n int) ([]map[string]interface{}, error)
+	FindDocuments(ctx context.Context, collection string, filter map[string]interface{}) ([]map[string]interface{}, error)
+	FindRange(ctx context.Context, collection, field string, lo, hi int64) ([]map[string]interface{}, error)
 	AggregateSum(ctx context.Context, collection, field string) (float64, error)
+	AggregateDecimalSum(ctx context.Context, collection, field string) (string, error)
 	AggregateCountDistinct(ctx context.Context, collection, field string) (int64, error)
+	ChangeStreamSmokeTest(ctx context.Context, collection string) error
+	SetReadOptions(ctx context.Context, opts ReadOptions) error
+	CountOrphans(ctx context.Context, ref OrphanQuery) (*OrphanCount, error)
+
+	// Bulk writes (native migration)
+	InsertDocuments(ctx context.Context, collection string, docs []interface{}) (int64, error)
+
+	// Incremental writes (change data capture)
+	ApplyWrites(ctx context.Context, collection string, ops []WriteOp) error
 
 	// Index operations
 	CreateIndex(ctx context.Context, collection string, index IndexDefinition) error
 	CreateIndexes(ctx context.Context, indexes []CollectionIndex) error
 	ListIndexBuildProgress(ctx context.Context) ([]IndexBuildStatus, error)
 
+	// Materialized aggregation views
+	MaterializeView(ctx context.Context, view mapping.View) error
+
+	// Canary query performance
+	ExplainQuery(ctx context.Context, query mapping.CanaryQuery) (*QueryStats, error)
+
 	// Write concern
 	SetWriteConcern(ctx context.Context, w string, journal bool) error
+
+	// Schema validation
+	SetValidator(ctx context.Context, collection string, validator map[string]interface{}, level string) error
 }
 
 // TopologyInfo describes the MongoDB target topology.
@@ -55,17 +79,123 @@ type ValidationIssue struct {
 	Suggestion string `yaml:"suggestion" json:"suggestion"`
 }
 
-// IndexDefinition describes a single MongoDB index.
+// CollectionSpec describes a collection to create with its WiredTiger
+// storage options and default collation. Empty options keep the server
+// defaults.
+type CollectionSpec struct {
+	Name            string     `json:"name"`
+	BlockCompressor string     `json:"block_compressor,omitempty"`
+	ConfigString    string     `json:"config_string,omitempty"`
+	Collation       *Collation `json:"collation,omitempty"`
+}
+
+// WiredTigerConfig returns the storage engine configString for the spec.
+func (s CollectionSpec) WiredTigerConfig() string {
+	var parts []string
+	if s.BlockCompressor != "" {
+		parts = append(parts, "block_compressor="+s.BlockCompressor)
+	}
+	if s.ConfigString != "" {
+		parts = append(parts, s.ConfigString)
+	}
+	return strings.Join(parts, ",")
+}
+
+// CollectionSpecs builds creation specs for every collection in the mapping.
+func CollectionSpecs(m *mapping.Mapping) []CollectionSpec {
+	specs := make([]CollectionSpec, len(m.Collections))
+	for i, c := range m.Collections {
+		specs[i] = CollectionSpec{Name: c.Name}
+		if c.Storage != nil {
+			specs[i].BlockCompressor = c.Storage.BlockCompressor
+			specs[i].ConfigString = c.Storage.ConfigString
+		}
+	}
+	return specs
+}
+
+// IndexDefinition describes a single MongoDB index. A non-zero
+// ExpireAfterSeconds makes it a TTL index; a PartialFilter limits the index
+// to the documents that match it, as Sparse does to documents that have the
+// indexed fields. A Collation makes string comparisons language-aware, for
+// example case-insensitive. Keys of type IndexSearch make it an Atlas Search
+// index, built through the Atlas API with the Analyzer on its fields.
 type IndexDefinition struct {
-	Keys   []IndexKey `json:"keys"`
-	Name   string     `json:"name"`
-	Unique bool       `json:"unique"`
+	Keys               []IndexKey     `json:"keys"`
+	Name               string         `json:"name"`
+	Unique             bool           `json:"unique"`
+	ExpireAfterSeconds int32          `json:"expire_after_seconds,omitempty" yaml:"expire_after_seconds,omitempty"`
+	PartialFilter      map[string]any `json:"partial_filter,omitempty" yaml:"partial_filter,omitempty"`
+	Sparse             bool           `json:"sparse,omitempty" yaml:"sparse,omitempty"`
+	Collation          *Collation     `json:"collation,omitempty" yaml:"collation,omitempty"`
+	Analyzer           string         `json:"analyzer,omitempty" yaml:"analyzer,omitempty"`
 }
 
-// IndexKey is a single field in a compound index.
+// Search reports whether the index is an Atlas Search index.
+func (d IndexDefinition) Search() bool {
+	return len(d.Keys) > 0 && d.Keys[0].Type == IndexSearch
+}
+
+// Key types that replace an ascending or descending order: IndexText for a
+// text index key, Index2dsphere for a GeoJSON field queried by location and
+// IndexSearch for a string field of an Atlas Search index.
+const (
+	IndexText     = "text"
+	Index2dsphere = "2dsphere"
+	IndexSearch   = "search"
+)
+
+// CollectionEmptier is implemented by operators that can delete every
+// document of a collection while keeping the collection, its options,
+// indexes and shard key.
+type CollectionEmptier interface {
+	// EmptyCollection deletes the collection's documents and returns how
+	// many it deleted.
+	EmptyCollection(ctx context.Context, collection string) (int64, error)
+}
+
+// DocumentDeleter is implemented by operators that can delete the
+// documents of a collection matching a filter.
+type DocumentDeleter interface {
+	// DeleteDocuments deletes the documents matching filter and returns how
+	// many it deleted.
+	DeleteDocuments(ctx context.Context, collection string, filter map[string]interface{}) (int64, error)
+}
+
+// SearchIndexCreator creates Atlas Search indexes, which are not built by
+// the database server's createIndexes command.
+type SearchIndexCreator interface {
+	CreateSearchIndex(ctx context.Context, collection string, index IndexDefinition) error
+}
+
+// IndexKey is a single field in a compound index. A Type such as IndexText
+// replaces the ascending or descending Order, and a Field of "$**" or ending
+// in ".$**" is a wildcard key covering every field below it.
 type IndexKey struct {
 	Field string `json:"field"`
 	Order int    `json:"order"`
+	Type  string `json:"type,omitempty" yaml:"type,omitempty"`
+}
+
+// Value returns the key's value in a MongoDB index specification.
+func (k IndexKey) Value() any {
+	if k.Type != "" {
+		return k.Type
+	}
+	return k.Order
+}
+
+// Wildcard reports whether the key is a wildcard key.
+func (k IndexKey) Wildcard() bool {
+	return k.Field == "$**" || strings.HasSuffix(k.Field, ".$**")
+}
+
+// Collation holds the language rules for comparing strings in an index or
+// collection. Strength 2 compares without regard to case, strength 1
+// without regard to case or accents.
+type Collation struct {
+	Locale   string `json:"locale"`
+	Strength int    `json:"strength,omitempty" yaml:"strength,omitempty"`
 }
 
 // CollectionIndex pairs a collection name with an index definition.
@@ -81,4 +211,44 @@ type IndexBuildStatus struct {
 	Phase      string  `json:"phase"`
 	Progress   float64 `json:"progress"`
 	Message    string  `json:"message"`
+	Shard      string  `json:"shard,omitempty"` // set on per-shard progress of a sharded cluster
+}
+
+// Write operation types for ApplyWrites.
+const (
+	WriteUpsert     = "upsert"      // $set Doc on the document matching Filter, inserting if absent
+	WriteDelete     = "delete"      // delete the document matching Filter
+	WritePush       = "push"        // append Doc to the array Field
+	WritePull       = "pull"        // remove array elements of Field matching Match
+	WriteSetField   = "set_field"   // set Field to Doc
+	WriteUnsetField = "unset_field" // remove Field
+)
+
+// OrphanQuery asks which documents of a collection hold a reference that no
+// document of the parent collection answers: Field values with no parent
+// whose ParentField equals them. Sample > 0 checks that many randomly
+// chosen documents instead of every one.
+type OrphanQuery struct {
+	Collection  string
+	Field       string
+	Parent      string
+	ParentField string
+	Sample      int
+}
+
+// OrphanCount is the result of an OrphanQuery. Documents without the field,
+// or with it null, hold no reference and are not counted.
+type OrphanCount struct {
+	Checked  int64         `json:"checked"`            // documents holding a reference
+	Orphans  int64         `json:"orphans"`            // of those, documents whose reference has no parent
+	Examples []interface{} `json:"examples,omitempty"` // a few of the values with no parent
+}
+
+// WriteOp is a single document-level write used to apply captured changes.
+type WriteOp struct {
+	Type   string                 `json:"type"`
+	Filter map[string]interface{} `json:"filter"`
+	Doc    map[string]interface{} `json:"doc,omitempty"`
+	Field  string                 `json:"field,omitempty"`
+	Match  map[string]interface{} `json:"match,omitempty"`
 }
@@ -3,8 +3,13 @@ package target
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/sizing"
 )
 
@@ -113,6 +118,42 @@ func TestMockOperator_CreateCollections(t *testing.T) {
 	}
 }
 
+func TestMockOperator_CreateCollectionsWithOptions(t *testing.T) {
+	mock := &MockOperator{}
+	specs := []CollectionSpec{{Name: "orders", BlockCompressor: "zstd"}, {Name: "products"}}
+	if err := mock.CreateCollectionsWithOptions(context.Background(), specs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.CreatedCollections) != 2 || len(mock.CreatedSpecs) != 2 {
+		t.Errorf("expected 2 collections, got %v", mock.CreatedCollections)
+	}
+	if mock.CreatedSpecs[0].BlockCompressor != "zstd" {
+		t.Errorf("compressor = %q, want zstd", mock.CreatedSpecs[0].BlockCompressor)
+	}
+}
+
+func TestCollectionSpecs(t *testing.T) {
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "orders", Storage: &mapping.StorageOptions{BlockCompressor: "zstd", ConfigString: "prefix_compression=true"}},
+		{Name: "products"},
+	}}
+	specs := CollectionSpecs(m)
+
+	tests := []struct {
+		spec CollectionSpec
+		want string
+	}{
+		{specs[0], "block_compressor=zstd,prefix_compression=true"},
+		{specs[1], ""},
+		{CollectionSpec{Name: "x", ConfigString: "prefix_compression=false"}, "prefix_compression=false"},
+	}
+	for _, tt := range tests {
+		if got := tt.spec.WiredTigerConfig(); got != tt.want {
+			t.Errorf("%s: WiredTigerConfig() = %q, want %q", tt.spec.Name, got, tt.want)
+		}
+	}
+}
+
 func TestMockOperator_ShardingSetup(t *testing.T) {
 	mock := &MockOperator{}
 	plan := &sizing.ShardingPlan{Recommended: true}
@@ -235,6 +276,61 @@ func TestMockOperator_CreateIndex(t *testing.T) {
 	}
 }
 
+func TestIndexModel(t *testing.T) {
+	model := indexModel(IndexDefinition{
+		Keys:          []IndexKey{{Field: "title", Type: IndexText}, {Field: "attrs.$**", Order: 1}, {Field: "email", Order: -1}},
+		Name:          "idx_search",
+		Sparse:        true,
+		PartialFilter: map[string]any{"status": map[string]any{"$eq": "active"}},
+		Collation:     &Collation{Locale: "en", Strength: 2},
+	})
+
+	want := bson.D{{Key: "title", Value: "text"}, {Key: "attrs.$**", Value: 1}, {Key: "email", Value: -1}}
+	if !reflect.DeepEqual(model.Keys, want) {
+		t.Errorf("Keys = %v, want %v", model.Keys, want)
+	}
+
+	var opts options.IndexOptions
+	for _, set := range model.Options.Opts {
+		set(&opts)
+	}
+	if opts.Name == nil || *opts.Name != "idx_search" || opts.Sparse == nil || !*opts.Sparse {
+		t.Errorf("name/sparse options = %+v", opts)
+	}
+	if opts.PartialFilterExpression == nil || opts.Collation == nil || opts.Collation.Strength != 2 {
+		t.Errorf("filter/collation options = %+v", opts)
+	}
+	if opts.Unique != nil || opts.ExpireAfterSeconds != nil {
+		t.Errorf("unset options should stay unset: %+v", opts)
+	}
+
+	if !(IndexKey{Field: "$**"}).Wildcard() || (IndexKey{Field: "a$**"}).Wildcard() {
+		t.Error("Wildcard() should match $** and path.$** keys only")
+	}
+}
+
+func TestCreateCollectionOptions(t *testing.T) {
+	var opts options.CreateCollectionOptions
+	for _, set := range createCollectionOptions(CollectionSpec{Name: "users"}).Opts {
+		set(&opts)
+	}
+	if opts.StorageEngine != nil || opts.Collation != nil {
+		t.Errorf("a bare spec should keep the server defaults: %+v", opts)
+	}
+
+	opts = options.CreateCollectionOptions{}
+	spec := CollectionSpec{Name: "users", BlockCompressor: "zstd", Collation: &Collation{Locale: "de", Strength: 1}}
+	for _, set := range createCollectionOptions(spec).Opts {
+		set(&opts)
+	}
+	if opts.StorageEngine == nil {
+		t.Error("storage engine options not set")
+	}
+	if opts.Collation == nil || opts.Collation.Locale != "de" || opts.Collation.Strength != 1 {
+		t.Errorf("collation = %+v, want {de 1}", opts.Collation)
+	}
+}
+
 func TestMockOperator_CreateIndexes(t *testing.T) {
 	mock := &MockOperator{}
 	indexes := []CollectionIndex{
@@ -284,3 +380,335 @@ func TestMockOperator_ListIndexBuildProgress(t *testing.T) {
 		t.Errorf("expected progress 50, got %f", statuses[0].Progress)
 	}
 }
+
+func TestMockOperator_InsertDocuments(t *testing.T) {
+	mock := &MockOperator{}
+	docs := []interface{}{map[string]interface{}{"id": 1}, map[string]interface{}{"id": 2}}
+	n, err := mock.InsertDocuments(context.Background(), "users", docs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("inserted = %d, want 2", n)
+	}
+	if len(mock.InsertedDocs["users"]) != 2 {
+		t.Errorf("expected 2 recorded docs, got %d", len(mock.InsertedDocs["users"]))
+	}
+}
+
+func TestMockOperator_ApplyWrites(t *testing.T) {
+	mock := &MockOperator{}
+	ops := []WriteOp{
+		{Type: WriteUpsert, Filter: map[string]interface{}{"id": 1}, Doc: map[string]interface{}{"name": "a"}},
+		{Type: WriteDelete, Filter: map[string]interface{}{"id": 2}},
+	}
+	if err := mock.ApplyWrites(context.Background(), "users", ops); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.AppliedWrites["users"]) != 2 {
+		t.Errorf("expected 2 recorded writes, got %d", len(mock.AppliedWrites["users"]))
+	}
+}
+
+func TestCheckZones(t *testing.T) {
+	shards := map[string][]string{
+		"shard0": {"EU"},
+		"shard1": {"US", "APAC"},
+		"shard2": nil,
+	}
+	tests := []struct {
+		name     string
+		required []string
+		want     int
+	}{
+		{"all assigned", []string{"EU", "US"}, 0},
+		{"missing zone", []string{"EU", "SA"}, 1},
+		{"none required", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkZones(tt.required, shards); len(got) != tt.want {
+				t.Errorf("checkZones() = %v, want %d issues", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZoneRangeCmd(t *testing.T) {
+	col := sizing.CollectionShard{
+		CollectionName: "customers",
+		ShardKey:       map[string]string{"region": "1", "id": "hashed"},
+		Zones:          &mapping.ZoneConfig{Field: "region"},
+	}
+
+	key := shardKeyDoc(col)
+	if len(key) != 2 || key[0].Key != "region" || key[1].Key != "id" || key[1].Value != "hashed" {
+		t.Errorf("shardKeyDoc() = %v", key)
+	}
+
+	cmd := zoneRangeCmd("app.customers", col, mapping.ZoneRange{Zone: "EU", Min: "eu", Max: "eu~"})
+	if cmd[0].Key != "updateZoneKeyRange" || cmd[0].Value != "app.customers" {
+		t.Errorf("unexpected command %v", cmd)
+	}
+	min := cmd[1].Value.(bson.D)
+	if min[0].Value != "eu" || min[1].Value != (bson.MinKey{}) {
+		t.Errorf("min = %v", min)
+	}
+	max := cmd[2].Value.(bson.D)
+	if max[0].Value != "eu~" || max[1].Value != (bson.MinKey{}) {
+		t.Errorf("max = %v", max)
+	}
+	if cmd[3].Value != "EU" {
+		t.Errorf("zone = %v", cmd[3].Value)
+	}
+}
+
+func TestMockOperator_MaterializeView(t *testing.T) {
+	m := &MockOperator{}
+	if err := m.MaterializeView(context.Background(), mapping.View{Name: "orders_by_day"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.MaterializedViews) != 1 || m.MaterializedViews[0] != "orders_by_day" {
+		t.Errorf("MaterializedViews = %v", m.MaterializedViews)
+	}
+
+	m.MaterializeErr = errors.New("merge failed")
+	if err := m.MaterializeView(context.Background(), mapping.View{Name: "x"}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestViewPipeline(t *testing.T) {
+	v := mapping.View{
+		Name:     "orders_by_day",
+		Source:   "orders",
+		Pipeline: `[{"$group": {"_id": {"$dateTrunc": {"date": "$created_at", "unit": "day"}}, "total": {"$sum": "$amount"}}}, {"$match": {"total": {"$gt": {"$numberLong": "0"}}}}]`,
+	}
+
+	pipeline, err := ViewPipeline(v, "app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pipeline) != 3 {
+		t.Fatalf("expected 3 stages, got %d", len(pipeline))
+	}
+	group := pipeline[0].(bson.D)
+	if group[0].Key != "$group" {
+		t.Errorf("first stage = %v", group)
+	}
+	// Stage key order is preserved
+	fields := group[0].Value.(bson.D)
+	if fields[0].Key != "_id" || fields[1].Key != "total" {
+		t.Errorf("group fields out of order: %v", fields)
+	}
+	merge := pipeline[2].(bson.D)[0].Value.(bson.D)
+	into := merge[0].Value.(bson.D)
+	if into[0].Value != "app" || into[1].Value != "orders_by_day" {
+		t.Errorf("into = %v", into)
+	}
+	if merge[2].Value != "replace" || merge[3].Value != "insert" {
+		t.Errorf("merge actions = %v", merge)
+	}
+	if viewMergeIndex(v) != nil {
+		t.Error("merging on _id should not need an index")
+	}
+
+	v.On = []string{"day", "region"}
+	idx := viewMergeIndex(v)
+	if idx == nil || !idx.Unique || len(idx.Keys) != 2 || idx.Name != "merge_day_region" {
+		t.Errorf("viewMergeIndex() = %+v", idx)
+	}
+
+	v.Pipeline = `{"$match": {}}`
+	if _, err := ViewPipeline(v, "app"); err == nil {
+		t.Error("expected error for non-array pipeline")
+	}
+}
+
+func TestMockOperator_FindDocuments(t *testing.T) {
+	m := &MockOperator{
+		Documents: map[string][]map[string]interface{}{
+			"orders": {{"id": 1}, {"id": 2}},
+		},
+	}
+	docs, err := m.FindDocuments(context.Background(), "orders", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(docs) != 2 {
+		t.Errorf("expected 2 documents, got %d", len(docs))
+	}
+
+	m.FindErr = errors.New("find failed")
+	if _, err := m.FindDocuments(context.Background(), "orders", nil); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestMockOperator_ExplainQuery(t *testing.T) {
+	m := &MockOperator{QueryStats: map[string]*QueryStats{
+		"scan": {Stages: []string{"COLLSCAN"}, CollectionScan: true},
+	}}
+	stats, err := m.ExplainQuery(context.Background(), mapping.CanaryQuery{Name: "scan"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !stats.CollectionScan {
+		t.Error("expected configured stats")
+	}
+	if len(m.ExplainedQueries) != 1 || m.ExplainedQueries[0] != "scan" {
+		t.Errorf("ExplainedQueries = %v", m.ExplainedQueries)
+	}
+
+	m.ExplainErr = errors.New("explain failed")
+	if _, err := m.ExplainQuery(context.Background(), mapping.CanaryQuery{Name: "x"}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestParseExplain(t *testing.T) {
+	tests := []struct {
+		name      string
+		result    bson.M
+		wantScan  bool
+		wantIndex string
+		wantKeys  int64
+	}{
+		{
+			name: "index scan",
+			result: bson.M{
+				"queryPlanner": bson.M{"winningPlan": bson.M{
+					"stage":      "FETCH",
+					"inputStage": bson.M{"stage": "IXSCAN", "indexName": "customer_id_1"},
+				}},
+				"executionStats": bson.M{"nReturned": int32(3), "totalKeysExamined": int32(3), "totalDocsExamined": int32(3), "executionTimeMillis": int32(1)},
+			},
+			wantIndex: "customer_id_1",
+			wantKeys:  3,
+		},
+		{
+			name: "collection scan",
+			result: bson.M{
+				"queryPlanner":   bson.M{"winningPlan": bson.M{"stage": "COLLSCAN"}},
+				"executionStats": bson.M{"nReturned": int64(1), "totalKeysExamined": int64(0), "totalDocsExamined": int64(5000)},
+			},
+			wantScan: true,
+		},
+		{
+			name: "sharded",
+			result: bson.M{
+				"queryPlanner": bson.M{"winningPlan": bson.M{
+					"stage": "SINGLE_SHARD",
+					"shards": bson.A{bson.D{
+						{Key: "shardName", Value: "rs0"},
+						{Key: "winningPlan", Value: bson.D{{Key: "stage", Value: "COLLSCAN"}}},
+					}},
+				}},
+				"executionStats": bson.M{"totalKeysExamined": float64(0)},
+			},
+			wantScan: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := ParseExplain(tt.result)
+			if stats.CollectionScan != tt.wantScan {
+				t.Errorf("CollectionScan = %v, want %v (stages %v)", stats.CollectionScan, tt.wantScan, stats.Stages)
+			}
+			if stats.IndexName != tt.wantIndex {
+				t.Errorf("IndexName = %q, want %q", stats.IndexName, tt.wantIndex)
+			}
+			if stats.KeysExamined != tt.wantKeys {
+				t.Errorf("KeysExamined = %d, want %d", stats.KeysExamined, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestIndexBuildOps(t *testing.T) {
+	ops := bson.A{
+		bson.M{ // a build of two indexes, reported by one shard
+			"desc":     "IndexBuildsCoordinatorMongod-3",
+			"ns":       "shop.orders",
+			"shard":    "shard01",
+			"msg":      "Index Build: scanning collection",
+			"progress": bson.M{"done": int64(25), "total": int64(100)},
+			"command": bson.M{"createIndexes": "orders", "indexes": bson.A{
+				bson.M{"name": "idx_orders_customer_id"},
+				bson.M{"name": "idx_orders_created_at"},
+			}},
+		},
+		bson.M{ // an older server's description only
+			"desc":     "Index Build (background)",
+			"ns":       "shop.users",
+			"progress": bson.M{"done": int32(1), "total": int32(4)},
+		},
+		bson.M{"desc": "conn42", "ns": "shop.orders", "command": bson.M{"find": "orders"}},
+	}
+
+	got := indexBuildOps(ops, "shop")
+	want := []IndexBuildStatus{
+		{Collection: "orders", IndexName: "idx_orders_customer_id", Phase: "building", Progress: 25, Message: "Index Build: scanning collection", Shard: "shard01"},
+		{Collection: "orders", IndexName: "idx_orders_created_at", Phase: "building", Progress: 25, Message: "Index Build: scanning collection", Shard: "shard01"},
+		{Collection: "users", IndexName: "Index Build (background)", Phase: "building", Progress: 25},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("indexBuildOps =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestEstimateIndexProgress(t *testing.T) {
+	sizes := map[string]int64{"_id_": 1000, "email_1": 3000, "name_1": 400}
+	building := map[string]bool{"_id_": false, "email_1": false, "name_1": true}
+	tests := []struct {
+		name     string
+		sizes    map[string]int64
+		building map[string]bool
+		index    string
+		want     float64
+	}{
+		{"building", sizes, building, "name_1", 20}, // 400 of the 2000 mean
+		{"finished", sizes, building, "email_1", 100},
+		{"capped short of done", map[string]int64{"_id_": 100, "name_1": 500}, building, "name_1", 99},
+		{"not yet visible", sizes, building, "city_1", 0},
+		{"nothing to compare", map[string]int64{"name_1": 400}, map[string]bool{"name_1": true}, "name_1", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateIndexProgress(tt.sizes, tt.building, tt.index); got != tt.want {
+				t.Errorf("estimateIndexProgress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        ReadOptions
+		wantErr     bool
+		wantCausal  bool
+		wantConcern string
+	}{
+		{"defaults", ReadOptions{}, false, false, ""},
+		{"primary linearizable", ReadOptions{Preference: ReadPrimary, Concern: ReadConcernLinearizable}, false, false, ReadConcernLinearizable},
+		{"secondary preferred", ReadOptions{Preference: ReadSecondaryPreferred}, false, true, ReadConcernMajority},
+		{"nearest local", ReadOptions{Preference: ReadNearest, Concern: ReadConcernLocal}, false, true, ReadConcernLocal},
+		{"secondary linearizable", ReadOptions{Preference: ReadSecondary, Concern: ReadConcernLinearizable}, true, true, ReadConcernLinearizable},
+		{"unknown preference", ReadOptions{Preference: "tertiary"}, true, true, ReadConcernMajority},
+		{"unknown concern", ReadOptions{Concern: "snapshot"}, true, false, "snapshot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := tt.opts.Causal(); got != tt.wantCausal {
+				t.Errorf("Causal() = %v, want %v", got, tt.wantCausal)
+			}
+			if got := tt.opts.EffectiveConcern(); got != tt.wantConcern {
+				t.Errorf("EffectiveConcern() = %q, want %q", got, tt.wantConcern)
+			}
+		})
+	}
+}
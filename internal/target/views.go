@@ -0,0 +1,73 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+)
+
+// ViewPipeline parses the view's Extended JSON pipeline and appends the
+// $merge stage that writes its results into the view collection.
+func ViewPipeline(v mapping.View, database string) (bson.A, error) {
+	var wrapper struct {
+		Stages bson.A `bson:"stages"`
+	}
+	doc := `{"stages": ` + v.Pipeline + `}`
+	if err := bson.UnmarshalExtJSON([]byte(doc), false, &wrapper); err != nil {
+		return nil, fmt.Errorf("parsing pipeline for view %s: %w", v.Name, err)
+	}
+
+	on := make(bson.A, len(v.MergeOn()))
+	for i, f := range v.MergeOn() {
+		on[i] = f
+	}
+	merge := bson.D{{Key: "$merge", Value: bson.D{
+		{Key: "into", Value: bson.D{{Key: "db", Value: database}, {Key: "coll", Value: v.Name}}},
+		{Key: "on", Value: on},
+		{Key: "whenMatched", Value: v.MatchedAction()},
+		{Key: "whenNotMatched", Value: v.NotMatchedAction()},
+	}}}
+	return append(wrapper.Stages, merge), nil
+}
+
+// viewMergeIndex returns the unique index $merge requires when a view merges
+// on fields other than _id.
+func viewMergeIndex(v mapping.View) *IndexDefinition {
+	on := v.MergeOn()
+	if len(on) == 1 && on[0] == "_id" {
+		return nil
+	}
+	idx := &IndexDefinition{
+		Name:   "merge_" + strings.Join(on, "_"),
+		Unique: true,
+	}
+	for _, f := range on {
+		idx.Keys = append(idx.Keys, IndexKey{Field: f, Order: 1})
+	}
+	return idx
+}
+
+// MaterializeView runs the view's pipeline over its source collection and
+// merges the results into the view collection.
+func (m *MongoOperator) MaterializeView(ctx context.Context, v mapping.View) error {
+	pipeline, err := ViewPipeline(v, m.database)
+	if err != nil {
+		return err
+	}
+
+	if idx := viewMergeIndex(v); idx != nil {
+		if err := m.CreateIndex(ctx, v.Name, *idx); err != nil {
+			return fmt.Errorf("creating merge index for view %s: %w", v.Name, err)
+		}
+	}
+
+	cursor, err := m.client.Database(m.database).Collection(v.Source).Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("materializing view %s: %w", v.Name, err)
+	}
+	return cursor.Close(ctx)
+}
@@ -0,0 +1,98 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/sizing"
+)
+
+// shardZones reads config.shards and returns the zones (tags) of each shard.
+func (m *MongoOperator) shardZones(ctx context.Context) (map[string][]string, error) {
+	cur, err := m.client.Database("config").Collection("shards").Find(ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("listing shards: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	zones := make(map[string][]string)
+	for cur.Next(ctx) {
+		var doc struct {
+			ID   string   `bson:"_id"`
+			Tags []string `bson:"tags"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decoding shard: %w", err)
+		}
+		zones[doc.ID] = doc.Tags
+	}
+	if err := cur.Err(); err != nil {
+		return nil, fmt.Errorf("iterating shards: %w", err)
+	}
+	return zones, nil
+}
+
+// checkZones reports an error for each required zone that no shard is assigned to.
+func checkZones(required []string, shardZones map[string][]string) []ValidationIssue {
+	assigned := make(map[string]bool)
+	for _, tags := range shardZones {
+		for _, t := range tags {
+			assigned[t] = true
+		}
+	}
+	var missing []string
+	for _, z := range required {
+		if !assigned[z] {
+			missing = append(missing, z)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return []ValidationIssue{{
+		Category:   "shard",
+		Message:    "Zones not assigned to any shard: " + strings.Join(missing, ", "),
+		Suggestion: "Assign each zone to at least one shard with sh.addShardToZone(), or configure the zones in the Atlas Global Cluster settings.",
+	}}
+}
+
+// shardKeyDoc builds the ordered shard key document for a collection.
+func shardKeyDoc(col sizing.CollectionShard) bson.D {
+	key := bson.D{}
+	for _, k := range col.ShardKeyFields() {
+		if col.ShardKey[k] == "hashed" {
+			key = append(key, bson.E{Key: k, Value: "hashed"})
+		} else {
+			key = append(key, bson.E{Key: k, Value: 1})
+		}
+	}
+	return key
+}
+
+// zoneRangeCmd builds the updateZoneKeyRange command for one zone range. The
+// bounds cover the full shard key, with MinKey for the fields after the zone
+// field so the range includes every document whose zone field is in [Min, Max).
+func zoneRangeCmd(ns string, col sizing.CollectionShard, r mapping.ZoneRange) bson.D {
+	min, max := bson.D{}, bson.D{}
+	for _, k := range col.ShardKeyFields() {
+		if k == col.Zones.Field {
+			min = append(min, bson.E{Key: k, Value: r.Min})
+			max = append(max, bson.E{Key: k, Value: r.Max})
+			continue
+		}
+		min = append(min, bson.E{Key: k, Value: bson.MinKey{}})
+		max = append(max, bson.E{Key: k, Value: bson.MinKey{}})
+	}
+	return bson.D{
+		{Key: "updateZoneKeyRange", Value: ns},
+		{Key: "min", Value: min},
+		{Key: "max", Value: max},
+		{Key: "zone", Value: r.Zone},
+	}
+}
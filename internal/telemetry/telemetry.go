@@ -0,0 +1,350 @@
+// Package telemetry records anonymous usage metrics for users who opt in.
+//
+// Nothing is recorded until the user agrees, and the answer is kept in
+// telemetry.yaml in the Reloquent home directory. An Event holds only coarse
+// facts: the command run, how long it took, whether it failed and the class
+// of the failure, the source database type, and the table count and data
+// size rounded into buckets. Names, hosts, credentials, queries and error
+// messages are never recorded.
+//
+// Events are appended to a local spool (telemetry/spool.jsonl) that can be
+// read with `reloquent telemetry show`, and uploaded in batches to the
+// configured endpoint. Setting RELOQUENT_TELEMETRY=off or DO_NOT_TRACK=1
+// turns telemetry off regardless of consent.
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/config"
+)
+
+// Limits on the local spool.
+const (
+	MaxSpoolEvents = 1000           // oldest events are dropped beyond this
+	FlushInterval  = 24 * time.Hour // age of the oldest event before uploading
+)
+
+// Error classes reported for failed commands.
+const (
+	ClassCancelled  = "cancelled"
+	ClassTimeout    = "timeout"
+	ClassConnection = "connection"
+	ClassAuth       = "auth"
+	ClassPermission = "permission"
+	ClassNotFound   = "not_found"
+	ClassConfig     = "config"
+	ClassOther      = "other"
+)
+
+// Event is one anonymous usage record.
+type Event struct {
+	Day             string  `json:"day"` // UTC date, no time of day
+	Version         string  `json:"version"`
+	OS              string  `json:"os"`
+	Arch            string  `json:"arch"`
+	Command         string  `json:"command"` // e.g. "migrate" or "cdc start"
+	DurationSeconds float64 `json:"duration_seconds"`
+	Outcome         string  `json:"outcome"` // ok or error
+	ErrorClass      string  `json:"error_class,omitempty"`
+	SourceType      string  `json:"source_type,omitempty"`
+	TableBucket     string  `json:"table_bucket,omitempty"`
+	SizeBucket      string  `json:"size_bucket,omitempty"`
+}
+
+// NewEvent returns the event of a command that started at start and ended
+// at end with err.
+func NewEvent(version, command string, start, end time.Time, err error) Event {
+	ev := Event{
+		Day:             end.UTC().Format("2006-01-02"),
+		Version:         version,
+		OS:              runtime.GOOS,
+		Arch:            runtime.GOARCH,
+		Command:         command,
+		DurationSeconds: end.Sub(start).Round(time.Second).Seconds(),
+		Outcome:         "ok",
+	}
+	if err != nil {
+		ev.Outcome = "error"
+		ev.ErrorClass = ErrorClass(err)
+	}
+	return ev
+}
+
+// Consent is the user's answer to the telemetry prompt.
+type Consent struct {
+	Enabled   bool      `yaml:"enabled"`
+	DecidedAt time.Time `yaml:"decided_at"`
+}
+
+// DisabledByEnv reports whether the environment turns telemetry off.
+func DisabledByEnv() bool {
+	switch strings.ToLower(os.Getenv("RELOQUENT_TELEMETRY")) {
+	case "off", "0", "false", "no":
+		return true
+	}
+	v := os.Getenv("DO_NOT_TRACK")
+	return v != "" && v != "0"
+}
+
+// Store keeps the consent and the event spool under a directory.
+type Store struct {
+	Dir string
+}
+
+// Default returns the store in the Reloquent home directory.
+func Default() *Store {
+	return &Store{Dir: config.ExpandHome("~/.reloquent")}
+}
+
+func (s *Store) consentPath() string { return filepath.Join(s.Dir, "telemetry.yaml") }
+
+// SpoolPath is the file events are appended to.
+func (s *Store) SpoolPath() string { return filepath.Join(s.Dir, "telemetry", "spool.jsonl") }
+
+// Consent returns the recorded answer, or nil when the user has not been
+// asked yet.
+func (s *Store) Consent() (*Consent, error) {
+	data, err := os.ReadFile(s.consentPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading telemetry consent: %w", err)
+	}
+	c := &Consent{}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("parsing telemetry consent: %w", err)
+	}
+	return c, nil
+}
+
+// SetConsent records the user's answer. Turning telemetry off also deletes
+// the events not yet uploaded.
+func (s *Store) SetConsent(enabled bool, now time.Time) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("creating telemetry directory: %w", err)
+	}
+	data, err := yaml.Marshal(&Consent{Enabled: enabled, DecidedAt: now.UTC()})
+	if err != nil {
+		return fmt.Errorf("marshaling telemetry consent: %w", err)
+	}
+	if err := os.WriteFile(s.consentPath(), data, 0o644); err != nil {
+		return fmt.Errorf("writing telemetry consent: %w", err)
+	}
+	if !enabled {
+		return s.Clear()
+	}
+	return nil
+}
+
+// Enabled reports whether events are recorded: the user opted in and the
+// environment does not turn telemetry off.
+func (s *Store) Enabled() bool {
+	if DisabledByEnv() {
+		return false
+	}
+	c, err := s.Consent()
+	return err == nil && c != nil && c.Enabled
+}
+
+// Record appends ev to the spool when telemetry is enabled.
+func (s *Store) Record(ev Event) error {
+	if !s.Enabled() {
+		return nil
+	}
+	events, err := s.Pending()
+	if err != nil {
+		return err
+	}
+	events = append(events, ev)
+	if len(events) > MaxSpoolEvents {
+		events = events[len(events)-MaxSpoolEvents:]
+	}
+	return s.writeSpool(events)
+}
+
+// Pending returns the spooled events not yet uploaded.
+func (s *Store) Pending() ([]Event, error) {
+	f, err := os.Open(s.SpoolPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening telemetry spool: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			continue // a torn write; drop it
+		}
+		events = append(events, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading telemetry spool: %w", err)
+	}
+	return events, nil
+}
+
+// Clear deletes the spooled events.
+func (s *Store) Clear() error {
+	if err := os.Remove(s.SpoolPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("clearing telemetry spool: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) writeSpool(events []Event) error {
+	if err := os.MkdirAll(filepath.Dir(s.SpoolPath()), 0o755); err != nil {
+		return fmt.Errorf("creating telemetry spool: %w", err)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, ev := range events {
+		if err := enc.Encode(ev); err != nil {
+			return fmt.Errorf("encoding telemetry event: %w", err)
+		}
+	}
+	tmp := s.SpoolPath() + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("writing telemetry spool: %w", err)
+	}
+	return os.Rename(tmp, s.SpoolPath())
+}
+
+// Due reports whether the spool should be uploaded: its oldest event is at
+// least FlushInterval old.
+func (s *Store) Due(now time.Time) bool {
+	events, err := s.Pending()
+	if err != nil || len(events) == 0 {
+		return false
+	}
+	day, err := time.Parse("2006-01-02", events[0].Day)
+	return err == nil && now.Sub(day) >= FlushInterval
+}
+
+// Flush uploads the spooled events to endpoint as a JSON array and clears
+// the spool once the endpoint accepts them. It returns the number of events
+// sent.
+func (s *Store) Flush(ctx context.Context, client *http.Client, endpoint string) (int, error) {
+	if endpoint == "" {
+		return 0, fmt.Errorf("no telemetry endpoint configured")
+	}
+	if !s.Enabled() {
+		return 0, nil
+	}
+	events, err := s.Pending()
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+	body, err := json.Marshal(events)
+	if err != nil {
+		return 0, fmt.Errorf("encoding telemetry events: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("creating telemetry request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("uploading telemetry: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return 0, fmt.Errorf("uploading telemetry: endpoint returned %s", resp.Status)
+	}
+	return len(events), s.Clear()
+}
+
+// TableBucket rounds a table count into a bucket.
+func TableBucket(n int) string {
+	switch {
+	case n <= 0:
+		return ""
+	case n <= 10:
+		return "1-10"
+	case n <= 50:
+		return "11-50"
+	case n <= 200:
+		return "51-200"
+	case n <= 1000:
+		return "201-1000"
+	default:
+		return "1000+"
+	}
+}
+
+// SizeBucket rounds a data size in bytes into a bucket.
+func SizeBucket(bytes int64) string {
+	const gb = int64(1) << 30
+	switch {
+	case bytes <= 0:
+		return ""
+	case bytes < gb:
+		return "<1GB"
+	case bytes < 10*gb:
+		return "1-10GB"
+	case bytes < 100*gb:
+		return "10-100GB"
+	case bytes < 1024*gb:
+		return "100GB-1TB"
+	default:
+		return "1TB+"
+	}
+}
+
+// ErrorClass sorts an error into a coarse class. Only the class is
+// recorded, never the message.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ClassCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ClassTimeout
+	case errors.Is(err, os.ErrNotExist):
+		return ClassNotFound
+	case errors.Is(err, os.ErrPermission):
+		return ClassPermission
+	}
+	msg := strings.ToLower(err.Error())
+	for _, c := range []struct {
+		class string
+		words []string
+	}{
+		{ClassCancelled, []string{"cancelled", "canceled", "interrupted"}},
+		{ClassTimeout, []string{"timeout", "timed out", "deadline", "window expired"}},
+		{ClassAuth, []string{"authentication", "password", "unauthorized", "ora-01017", "sasl"}},
+		{ClassPermission, []string{"permission denied", "not authorized", "insufficient privileges", "ora-01031", "accessdenied"}},
+		{ClassConnection, []string{"connection refused", "no such host", "connecting to", "dial tcp", "server selection", "tns:", "network"}},
+		{ClassNotFound, []string{"not found", "does not exist", "no such file", "no schema available", "no mapping available"}},
+		{ClassConfig, []string{"config", "invalid", "unsupported", "parsing"}},
+	} {
+		for _, w := range c.words {
+			if strings.Contains(msg, w) {
+				return c.class
+			}
+		}
+	}
+	return ClassOther
+}
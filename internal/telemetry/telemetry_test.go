@@ -0,0 +1,210 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newStore(t *testing.T) *Store {
+	t.Helper()
+	t.Setenv("RELOQUENT_TELEMETRY", "")
+	t.Setenv("DO_NOT_TRACK", "")
+	return &Store{Dir: t.TempDir()}
+}
+
+func TestConsent(t *testing.T) {
+	s := newStore(t)
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	if c, err := s.Consent(); err != nil || c != nil {
+		t.Fatalf("Consent before asking = %v, %v; want nil", c, err)
+	}
+	if s.Enabled() {
+		t.Error("telemetry must be off until the user opts in")
+	}
+	if err := s.Record(Event{Command: "migrate"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(s.SpoolPath()); !os.IsNotExist(err) {
+		t.Error("nothing should be spooled without consent")
+	}
+
+	if err := s.SetConsent(true, now); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Consent()
+	if err != nil || c == nil || !c.Enabled || !c.DecidedAt.Equal(now) {
+		t.Fatalf("Consent = %+v, %v", c, err)
+	}
+	if !s.Enabled() {
+		t.Error("telemetry should be on after opting in")
+	}
+}
+
+func TestDisabledByEnv(t *testing.T) {
+	tests := []struct {
+		telemetry, dnt string
+		want           bool
+	}{
+		{"", "", false},
+		{"off", "", true},
+		{"OFF", "", true},
+		{"false", "", true},
+		{"on", "", false},
+		{"", "1", true},
+		{"", "0", false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q/%q", tt.telemetry, tt.dnt), func(t *testing.T) {
+			t.Setenv("RELOQUENT_TELEMETRY", tt.telemetry)
+			t.Setenv("DO_NOT_TRACK", tt.dnt)
+			if got := DisabledByEnv(); got != tt.want {
+				t.Errorf("DisabledByEnv = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	s := newStore(t)
+	if err := s.SetConsent(true, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DO_NOT_TRACK", "1")
+	if s.Enabled() {
+		t.Error("DO_NOT_TRACK should override consent")
+	}
+}
+
+func TestRecordAndOptOut(t *testing.T) {
+	s := newStore(t)
+	if err := s.SetConsent(true, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for i := range MaxSpoolEvents + 5 {
+		if err := s.Record(Event{Command: fmt.Sprintf("c%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events, err := s.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != MaxSpoolEvents || events[0].Command != "c5" {
+		t.Errorf("spool kept %d events starting at %q, want the newest %d", len(events), events[0].Command, MaxSpoolEvents)
+	}
+
+	if err := s.SetConsent(false, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if events, _ := s.Pending(); len(events) != 0 {
+		t.Errorf("opting out should delete the spool, %d events left", len(events))
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	start := time.Date(2025, 3, 10, 23, 59, 0, 0, time.UTC)
+	ev := NewEvent("1.2.0", "migrate", start, start.Add(90*time.Second+400*time.Millisecond),
+		fmt.Errorf("connecting to source db.internal:5432: %w", errors.New("dial tcp: connection refused")))
+	if ev.Day != "2025-03-11" || ev.DurationSeconds != 90 || ev.Outcome != "error" || ev.ErrorClass != ClassConnection {
+		t.Errorf("event = %+v", ev)
+	}
+	data, _ := json.Marshal(ev)
+	if strings.Contains(string(data), "db.internal") {
+		t.Errorf("event leaks the error message: %s", data)
+	}
+	if ok := NewEvent("1.2.0", "plan", start, start, nil); ok.Outcome != "ok" || ok.ErrorClass != "" {
+		t.Errorf("successful event = %+v", ok)
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{fmt.Errorf("run: %w", context.Canceled), ClassCancelled},
+		{context.DeadlineExceeded, ClassTimeout},
+		{fmt.Errorf("reading config: %w", os.ErrNotExist), ClassNotFound},
+		{errors.New("pq: password authentication failed for user \"app\""), ClassAuth},
+		{errors.New("ORA-01031: insufficient privileges"), ClassPermission},
+		{errors.New("connecting to target: server selection error"), ClassConnection},
+		{errors.New("no schema available; run source discovery first"), ClassNotFound},
+		{errors.New("unsupported config version 2 (expected 1)"), ClassConfig},
+		{errors.New("duplicate key"), ClassOther},
+	}
+	for _, tt := range tests {
+		if got := ErrorClass(tt.err); got != tt.want {
+			t.Errorf("ErrorClass(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	const gb = int64(1) << 30
+	tables := map[int]string{0: "", 1: "1-10", 10: "1-10", 11: "11-50", 200: "51-200", 1000: "201-1000", 1001: "1000+"}
+	for n, want := range tables {
+		if got := TableBucket(n); got != want {
+			t.Errorf("TableBucket(%d) = %q, want %q", n, got, want)
+		}
+	}
+	sizes := map[int64]string{0: "", 100: "<1GB", gb: "1-10GB", 50 * gb: "10-100GB", 500 * gb: "100GB-1TB", 2048 * gb: "1TB+"}
+	for n, want := range sizes {
+		if got := SizeBucket(n); got != want {
+			t.Errorf("SizeBucket(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestFlush(t *testing.T) {
+	s := newStore(t)
+	if err := s.SetConsent(true, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	s.Record(Event{Day: "2025-03-10", Command: "migrate"})
+	s.Record(Event{Day: "2025-03-10", Command: "indexes"})
+
+	if s.Due(time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC)) {
+		t.Error("spool should not be due within a day")
+	}
+	if !s.Due(time.Date(2025, 3, 11, 1, 0, 0, 0, time.UTC)) {
+		t.Error("spool should be due after a day")
+	}
+
+	status := http.StatusServiceUnavailable
+	var got []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	if _, err := s.Flush(context.Background(), srv.Client(), srv.URL); err == nil {
+		t.Fatal("a rejected upload should fail")
+	}
+	if events, _ := s.Pending(); len(events) != 2 {
+		t.Fatalf("a failed upload should keep the spool, %d events left", len(events))
+	}
+
+	status = http.StatusAccepted
+	n, err := s.Flush(context.Background(), srv.Client(), srv.URL)
+	if err != nil || n != 2 {
+		t.Fatalf("Flush = %d, %v", n, err)
+	}
+	if len(got) != 2 || got[1].Command != "indexes" {
+		t.Errorf("uploaded %+v", got)
+	}
+	if events, _ := s.Pending(); len(events) != 0 {
+		t.Errorf("spool should be empty after upload, %d events left", len(events))
+	}
+	if _, err := s.Flush(context.Background(), srv.Client(), ""); err == nil {
+		t.Error("flush without an endpoint should fail")
+	}
+}
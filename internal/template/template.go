@@ -0,0 +1,220 @@
+// Package template saves a project's mapping, type mapping and index plan as
+// a template that another environment with the same source schema can
+// apply, such as staging after dev.
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// Version is the template format this build writes and reads.
+const Version = 1
+
+// Template is a mapping with the type mapping and index plan that go with
+// it. Connection details are placeholders, and the mapped tables are kept
+// as discovered where the template was exported, for Check.
+type Template struct {
+	Version    int                `yaml:"version" json:"version"`
+	Name       string             `yaml:"name" json:"name"`
+	ExportedAt time.Time          `yaml:"exported_at" json:"exported_at"`
+	Connection Connection         `yaml:"connection" json:"connection"`
+	Schema     *schema.Schema     `yaml:"schema" json:"schema"`
+	Mapping    *mapping.Mapping   `yaml:"mapping" json:"mapping"`
+	TypeMap    *typemap.TypeMap   `yaml:"type_map,omitempty" json:"type_map,omitempty"`
+	IndexPlan  *indexes.IndexPlan `yaml:"index_plan,omitempty" json:"index_plan,omitempty"`
+}
+
+// Connection is what a template needs from each environment's config. All
+// but the source type are placeholders, bound to the config of the project
+// the template is applied in.
+type Connection struct {
+	SourceType     string `yaml:"source_type" json:"source_type"`
+	SourceHost     string `yaml:"source_host" json:"source_host"`
+	SourcePort     string `yaml:"source_port" json:"source_port"`
+	SourceDatabase string `yaml:"source_database" json:"source_database"`
+	SourceSchema   string `yaml:"source_schema" json:"source_schema"`
+	SourceUsername string `yaml:"source_username" json:"source_username"`
+	SourcePassword string `yaml:"source_password" json:"source_password"`
+	TargetURI      string `yaml:"target_uri" json:"target_uri"`
+	TargetDatabase string `yaml:"target_database" json:"target_database"`
+}
+
+// Binding is the value a connection placeholder takes in a project.
+// Secrets are only reported as set or not.
+type Binding struct {
+	Placeholder string `json:"placeholder"`
+	Value       string `json:"value"`
+}
+
+// Export builds a template from a project's schema, mapping, type mapping
+// and index plan. Only the tables the mapping reads are kept, without row
+// counts and sizes.
+func Export(name string, s *schema.Schema, m *mapping.Mapping, tm *typemap.TypeMap, plan *indexes.IndexPlan) (*Template, error) {
+	if s == nil || m == nil {
+		return nil, fmt.Errorf("schema and mapping required")
+	}
+	tables := mappedTables(s, m)
+	for i := range tables {
+		tables[i].RowCount, tables[i].SizeBytes = 0, 0
+	}
+	return &Template{
+		Version:    Version,
+		Name:       name,
+		ExportedAt: time.Now().UTC(),
+		Connection: Connection{
+			SourceType:     s.DatabaseType,
+			SourceHost:     "${SOURCE_HOST}",
+			SourcePort:     "${SOURCE_PORT}",
+			SourceDatabase: "${SOURCE_DATABASE}",
+			SourceSchema:   "${SOURCE_SCHEMA}",
+			SourceUsername: "${SOURCE_USERNAME}",
+			SourcePassword: "${SOURCE_PASSWORD}",
+			TargetURI:      "${TARGET_URI}",
+			TargetDatabase: "${TARGET_DATABASE}",
+		},
+		Schema:    &schema.Schema{DatabaseType: s.DatabaseType, Tables: tables},
+		Mapping:   m,
+		TypeMap:   tm,
+		IndexPlan: plan,
+	}, nil
+}
+
+// mappedTables returns copies of the schema's tables the mapping reads.
+func mappedTables(s *schema.Schema, m *mapping.Mapping) []schema.Table {
+	names := m.SourceTables()
+	var tables []schema.Table
+	for _, t := range s.Tables {
+		if slices.Contains(names, t.Name) {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// Bind returns the values the connection placeholders take from cfg.
+func (c Connection) Bind(cfg *config.Config) []Binding {
+	secret := func(v string) string {
+		if v == "" {
+			return "(not set)"
+		}
+		return "(set)"
+	}
+	src, tgt := cfg.Source, cfg.Target
+	return []Binding{
+		{c.SourceHost, src.Host},
+		{c.SourcePort, strconv.Itoa(src.Port)},
+		{c.SourceDatabase, src.Database},
+		{c.SourceSchema, src.Schema},
+		{c.SourceUsername, src.Username},
+		{c.SourcePassword, secret(src.Password)},
+		{c.TargetURI, secret(tgt.ConnectionString)},
+		{c.TargetDatabase, tgt.Database},
+	}
+}
+
+// Compatibility is how well a template fits a discovered schema. Errors
+// are what keeps the mapping from being applied, such as a table or join
+// column it needs that the schema lacks. Warnings are differences in the
+// mapped tables the mapping survives but that change what is migrated.
+type Compatibility struct {
+	Compatible bool     `json:"compatible"`
+	Errors     []string `json:"errors"`
+	Warnings   []string `json:"warnings"`
+}
+
+// Check compares the template with a freshly discovered schema.
+func (t *Template) Check(s *schema.Schema) *Compatibility {
+	c := &Compatibility{Errors: []string{}, Warnings: []string{}}
+	if t.Connection.SourceType != "" && s.DatabaseType != "" && t.Connection.SourceType != s.DatabaseType {
+		c.Errors = append(c.Errors, fmt.Sprintf("the template is for a %s source, this schema is from %s", t.Connection.SourceType, s.DatabaseType))
+	}
+
+	needed := make(map[string]bool)
+	for _, r := range t.Mapping.StaleReferences(s) {
+		if r.Column == "" {
+			c.Errors = append(c.Errors, fmt.Sprintf("%s: table %s (%s) is not in this schema", r.Path, r.Table, r.Use))
+			continue
+		}
+		needed[r.Table+"."+r.Column] = true
+		c.Errors = append(c.Errors, fmt.Sprintf("%s: column %s.%s (%s) is not in this schema", r.Path, r.Table, r.Column, r.Use))
+	}
+
+	if t.Schema != nil {
+		cur := &schema.Schema{Tables: mappedTables(s, t.Mapping)}
+		d := schema.Diff(&schema.Schema{Tables: mappedTables(t.Schema, t.Mapping)}, cur)
+		for _, td := range d.ChangedTables {
+			for _, col := range td.RemovedColumns {
+				if !needed[td.Name+"."+col] {
+					c.Warnings = append(c.Warnings, fmt.Sprintf("%s.%s is not in this schema; documents will not have it", td.Name, col))
+				}
+			}
+			for _, col := range td.AddedColumns {
+				c.Warnings = append(c.Warnings, fmt.Sprintf("%s.%s is not in the template; it is migrated like the table's other columns", td.Name, col))
+			}
+			for _, cc := range td.ChangedColumns {
+				for _, change := range cc.Changes {
+					c.Warnings = append(c.Warnings, fmt.Sprintf("%s.%s changed: %s", td.Name, cc.Name, change))
+				}
+			}
+			if td.PrimaryKeyChanged {
+				c.Warnings = append(c.Warnings, fmt.Sprintf("%s has a different primary key", td.Name))
+			}
+		}
+	}
+	sort.Strings(c.Warnings)
+	c.Compatible = len(c.Errors) == 0
+	return c
+}
+
+// WriteYAML writes the template to a YAML file.
+func (t *Template) WriteYAML(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	data, err := yaml.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("marshaling template: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadYAML reads a template from a YAML (or JSON) file.
+func LoadYAML(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading template: %w", err)
+	}
+	t := &Template{}
+	if err := yaml.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Validate checks that the template is one this build can apply.
+func (t *Template) Validate() error {
+	if t.Version > Version {
+		return fmt.Errorf("template version %d was written by a newer version of reloquent (this build reads %d); upgrade with `reloquent self-update`", t.Version, Version)
+	}
+	if t.Mapping == nil || len(t.Mapping.Collections) == 0 {
+		return fmt.Errorf("template has no mapping")
+	}
+	return nil
+}
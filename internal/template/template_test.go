@@ -0,0 +1,158 @@
+package template
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+func devSchema() *schema.Schema {
+	return &schema.Schema{
+		DatabaseType: "postgresql",
+		Host:         "dev-db",
+		Database:     "shop_dev",
+		Tables: []schema.Table{
+			{
+				Name:       "customers",
+				Columns:    []schema.Column{{Name: "id", DataType: "integer"}, {Name: "name", DataType: "text"}},
+				PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}},
+				RowCount:   100,
+			},
+			{
+				Name:    "orders",
+				Columns: []schema.Column{{Name: "id", DataType: "integer"}, {Name: "customer_id", DataType: "integer"}, {Name: "total", DataType: "numeric"}},
+			},
+			{Name: "scratch", Columns: []schema.Column{{Name: "x", DataType: "text"}}},
+		},
+	}
+}
+
+func shopMapping() *mapping.Mapping {
+	return &mapping.Mapping{Collections: []mapping.Collection{{
+		Name:        "customers",
+		SourceTable: "customers",
+		Embedded: []mapping.Embedded{{
+			SourceTable: "orders", FieldName: "orders", Relationship: "array",
+			JoinColumn: "customer_id", ParentColumn: "id",
+		}},
+	}}}
+}
+
+func TestExport(t *testing.T) {
+	tm := typemap.ForDatabase("postgresql")
+	tm.Override("numeric", typemap.BSONDouble)
+	tmpl, err := Export("shop", devSchema(), shopMapping(), tm, nil)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var names []string
+	for _, tb := range tmpl.Schema.Tables {
+		names = append(names, tb.Name)
+		if tb.RowCount != 0 {
+			t.Errorf("%s kept its row count", tb.Name)
+		}
+	}
+	if !reflect.DeepEqual(names, []string{"customers", "orders"}) {
+		t.Errorf("tables = %v, want only the mapped ones", names)
+	}
+	if tmpl.Schema.Host != "" || tmpl.Schema.Database != "" {
+		t.Error("template should not keep the source host or database")
+	}
+	if tmpl.Connection.SourceType != "postgresql" || tmpl.Connection.SourceHost != "${SOURCE_HOST}" {
+		t.Errorf("connection = %+v", tmpl.Connection)
+	}
+
+	path := filepath.Join(t.TempDir(), "shop.yaml")
+	if err := tmpl.WriteYAML(path); err != nil {
+		t.Fatalf("WriteYAML: %v", err)
+	}
+	loaded, err := LoadYAML(path)
+	if err != nil {
+		t.Fatalf("LoadYAML: %v", err)
+	}
+	if loaded.TypeMap.Overrides["numeric"] != typemap.BSONDouble || len(loaded.Mapping.Collections) != 1 {
+		t.Errorf("loaded template lost its type mapping or mapping: %+v", loaded)
+	}
+
+	cfg := &config.Config{Source: config.SourceConfig{Host: "staging-db", Port: 5432, Password: "pw"}}
+	bindings := loaded.Connection.Bind(cfg)
+	if bindings[0] != (Binding{"${SOURCE_HOST}", "staging-db"}) || bindings[1].Value != "5432" {
+		t.Errorf("bindings = %+v", bindings)
+	}
+	if bindings[5].Value != "(set)" || bindings[6].Value != "(not set)" {
+		t.Errorf("secret bindings = %+v, want only whether they are set", bindings[5:7])
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tmpl, err := Export("shop", devSchema(), shopMapping(), nil, nil)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	// The same schema elsewhere, unmapped tables aside
+	prod := devSchema()
+	prod.Tables = prod.Tables[:2]
+	if c := tmpl.Check(prod); !c.Compatible || len(c.Warnings) != 0 {
+		t.Errorf("Check = %+v, want compatible without warnings", c)
+	}
+
+	drifted := devSchema()
+	drifted.Tables[0].Columns = []schema.Column{{Name: "id", DataType: "bigint"}, {Name: "email", DataType: "text"}}
+	c := tmpl.Check(drifted)
+	if !c.Compatible {
+		t.Errorf("Check errors = %v, want only warnings", c.Errors)
+	}
+	want := []string{
+		"customers.email is not in the template; it is migrated like the table's other columns",
+		"customers.id changed: data_type: integer -> bigint",
+		"customers.name is not in this schema; documents will not have it",
+	}
+	if !reflect.DeepEqual(c.Warnings, want) {
+		t.Errorf("warnings = %q, want %q", c.Warnings, want)
+	}
+
+	broken := devSchema()
+	broken.Tables[1].Columns = broken.Tables[1].Columns[:1]
+	c = tmpl.Check(broken)
+	if c.Compatible || len(c.Errors) != 1 || !strings.Contains(c.Errors[0], "orders.customer_id (join column)") {
+		t.Errorf("Check = %+v, want the missing join column as an error", c)
+	}
+	for _, w := range c.Warnings {
+		if strings.Contains(w, "customer_id") {
+			t.Errorf("missing join column also warned: %q", w)
+		}
+	}
+
+	oracle := devSchema()
+	oracle.DatabaseType = "oracle"
+	if c := tmpl.Check(oracle); c.Compatible {
+		t.Error("a template for PostgreSQL should not fit an Oracle schema")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl Template
+		want string
+	}{
+		{"ok", Template{Version: Version, Mapping: shopMapping()}, ""},
+		{"newer", Template{Version: Version + 1, Mapping: shopMapping()}, "newer version"},
+		{"no mapping", Template{Version: Version}, "no mapping"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tmpl.Validate()
+			if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("Validate() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
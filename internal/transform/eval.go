@@ -0,0 +1,1044 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// nativeFunction is a function the native mover can evaluate. prepare
+// checks the call's literal arguments and returns what eval needs of
+// them; sql, when set, renders the call for Spark.
+type nativeFunction struct {
+	minArgs, maxArgs int // maxArgs < 0 means no limit
+	prepare          func(args []node) (interface{}, error)
+	eval             func(data interface{}, args []interface{}) (interface{}, error)
+	sql              func(b *strings.Builder, n *callNode)
+}
+
+// nativeFunctions are the functions Eval supports, with Spark's semantics.
+var nativeFunctions = map[string]*nativeFunction{
+	"concat":          {minArgs: 1, maxArgs: -1, eval: evalConcat},
+	"concat_ws":       {minArgs: 1, maxArgs: -1, eval: evalConcatWS},
+	"coalesce":        {minArgs: 1, maxArgs: -1, eval: evalCoalesce},
+	"if":              {minArgs: 3, maxArgs: 3, eval: evalIf},
+	"upper":           {minArgs: 1, maxArgs: 1, eval: stringFunc(strings.ToUpper)},
+	"lower":           {minArgs: 1, maxArgs: 1, eval: stringFunc(strings.ToLower)},
+	"trim":            {minArgs: 1, maxArgs: 1, eval: stringFunc(strings.TrimSpace)},
+	"abs":             {minArgs: 1, maxArgs: 1, eval: evalAbs},
+	"round":           {minArgs: 1, maxArgs: 2, eval: evalRound},
+	"date_trunc":      {minArgs: 2, maxArgs: 2, prepare: prepareDateTrunc, eval: evalDateTrunc},
+	"get_json_object": {minArgs: 2, maxArgs: 2, prepare: prepareJSONPath, eval: evalGetJSONObject},
+	"from_json":       {minArgs: 2, maxArgs: 2, prepare: prepareJSONSchema, eval: evalFromJSON},
+	"convert_units":   {minArgs: 3, maxArgs: 3, prepare: prepareConvertUnits, eval: evalConvertUnits, sql: convertUnitsSQL},
+}
+
+// newCall builds a function call node, checking the arguments of the
+// functions the native mover knows.
+func newCall(name string, args []node) (node, error) {
+	n := &callNode{name: name, args: args}
+	fn, ok := nativeFunctions[name]
+	if !ok {
+		return n, nil
+	}
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		want := strconv.Itoa(fn.minArgs)
+		switch {
+		case fn.maxArgs < 0:
+			want = "at least " + want
+		case fn.maxArgs > fn.minArgs:
+			want += " or " + strconv.Itoa(fn.maxArgs)
+		}
+		return nil, fmt.Errorf("%s takes %s arguments, got %d", name, want, len(args))
+	}
+	n.fn = fn
+	if fn.prepare != nil {
+		data, err := fn.prepare(args)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		n.data = data
+	}
+	return n, nil
+}
+
+// stringLiteral returns the value of a string literal argument.
+func stringLiteral(n node, what string) (string, error) {
+	if lit, ok := n.(*literalNode); ok {
+		if s, ok := lit.value.(string); ok {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("%s must be a string literal", what)
+}
+
+// Node evaluation
+
+func (n *literalNode) eval(map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n *columnNode) eval(row map[string]interface{}) (interface{}, error) {
+	if v, ok := row[n.name]; ok {
+		return v, nil
+	}
+	for k, v := range row {
+		if strings.EqualFold(k, n.name) {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("column %s does not exist", n.name)
+}
+
+func (n *parenNode) eval(row map[string]interface{}) (interface{}, error) {
+	return n.inner.eval(row)
+}
+
+func (n *unaryNode) eval(row map[string]interface{}) (interface{}, error) {
+	v, err := n.operand.eval(row)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if n.op == "NOT" {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("NOT needs a boolean, got %T", v)
+		}
+		return !b, nil
+	}
+	if i, ok := asInt(v); ok {
+		return -i, nil
+	}
+	f, ok := asFloat(v)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate %T", v)
+	}
+	return -f, nil
+}
+
+func (n *binaryNode) eval(row map[string]interface{}) (interface{}, error) {
+	if n.op == "AND" || n.op == "OR" {
+		return n.logical(row)
+	}
+	l, err := n.left.eval(row)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(row)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	switch n.op {
+	case "||":
+		return toString(l) + toString(r), nil
+	case "+", "-", "*", "/", "%":
+		return arith(n.op, l, r)
+	}
+	c, err := compare(l, r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "=", "==":
+		return c == 0, nil
+	case "<>", "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+// logical evaluates AND and OR with SQL's three-valued logic.
+func (n *binaryNode) logical(row map[string]interface{}) (interface{}, error) {
+	l, err := evalBool(n.left, row)
+	if err != nil {
+		return nil, err
+	}
+	// A false left side decides AND, a true one OR
+	if l != nil && *l == (n.op == "OR") {
+		return *l, nil
+	}
+	r, err := evalBool(n.right, row)
+	if err != nil {
+		return nil, err
+	}
+	if r != nil && *r == (n.op == "OR") {
+		return *r, nil
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	return *r, nil
+}
+
+func evalBool(n node, row map[string]interface{}) (*bool, error) {
+	v, err := n.eval(row)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return nil, fmt.Errorf("expected a boolean, got %T", v)
+	}
+	return &b, nil
+}
+
+func (n *isNullNode) eval(row map[string]interface{}) (interface{}, error) {
+	v, err := n.operand.eval(row)
+	if err != nil {
+		return nil, err
+	}
+	return (v == nil) != n.not, nil
+}
+
+func (n *inNode) eval(row map[string]interface{}) (interface{}, error) {
+	v, err := n.operand.eval(row)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	sawNull := false
+	for _, e := range n.list {
+		w, err := e.eval(row)
+		if err != nil {
+			return nil, err
+		}
+		if w == nil {
+			sawNull = true
+			continue
+		}
+		c, err := compare(v, w)
+		if err != nil {
+			return nil, err
+		}
+		if c == 0 {
+			return !n.not, nil
+		}
+	}
+	if sawNull {
+		return nil, nil
+	}
+	return n.not, nil
+}
+
+func (n *likeNode) eval(row map[string]interface{}) (interface{}, error) {
+	v, err := n.operand.eval(row)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	re := n.re
+	if re == nil {
+		p, err := n.pattern.eval(row)
+		if err != nil || p == nil {
+			return nil, err
+		}
+		re = likePattern(toString(p))
+	}
+	return re.MatchString(toString(v)) != n.not, nil
+}
+
+func (n *caseNode) eval(row map[string]interface{}) (interface{}, error) {
+	var operand interface{}
+	if n.operand != nil {
+		v, err := n.operand.eval(row)
+		if err != nil {
+			return nil, err
+		}
+		operand = v
+	}
+	for _, w := range n.whens {
+		c, err := w.cond.eval(row)
+		if err != nil {
+			return nil, err
+		}
+		matched := false
+		if n.operand == nil {
+			b, ok := c.(bool)
+			if c != nil && !ok {
+				return nil, fmt.Errorf("WHEN needs a boolean condition, got %T", c)
+			}
+			matched = b
+		} else if operand != nil && c != nil {
+			cmp, err := compare(operand, c)
+			if err != nil {
+				return nil, err
+			}
+			matched = cmp == 0
+		}
+		if matched {
+			return w.result.eval(row)
+		}
+	}
+	if n.els != nil {
+		return n.els.eval(row)
+	}
+	return nil, nil
+}
+
+func (n *castNode) eval(row map[string]interface{}) (interface{}, error) {
+	v, err := n.operand.eval(row)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	// Values that do not convert become NULL, as Spark's casts do
+	switch castKind(n.typ) {
+	case "string":
+		return toString(v), nil
+	case "int":
+		if i, ok := asInt(v); ok {
+			return i, nil
+		}
+		// Spark's casts also give NULL for values beyond a long
+		if f, ok := asFloat(v); ok && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), nil
+		}
+		return nil, nil
+	case "double":
+		if f, ok := asFloat(v); ok {
+			return f, nil
+		}
+		return nil, nil
+	case "boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		if b, err := strconv.ParseBool(strings.TrimSpace(toString(v))); err == nil {
+			return b, nil
+		}
+		return nil, nil
+	case "date":
+		if t, ok := asTime(v); ok {
+			return truncTime(t, "day"), nil
+		}
+		return nil, nil
+	case "timestamp":
+		if t, ok := asTime(v); ok {
+			return t, nil
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("cast to %s cannot be evaluated by the native mover", n.typ)
+}
+
+// castKind returns the kind of value a cast to typ produces natively, or
+// "" if the native mover cannot make it.
+func castKind(typ string) string {
+	base, _, _ := strings.Cut(typ, "(")
+	switch base {
+	case "STRING", "VARCHAR", "CHAR":
+		return "string"
+	case "INT", "INTEGER", "BIGINT", "LONG", "SMALLINT", "TINYINT":
+		return "int"
+	case "DOUBLE", "FLOAT", "REAL", "DECIMAL", "NUMERIC":
+		return "double"
+	case "BOOLEAN":
+		return "boolean"
+	case "DATE":
+		return "date"
+	case "TIMESTAMP":
+		return "timestamp"
+	}
+	return ""
+}
+
+func (n *callNode) eval(row map[string]interface{}) (interface{}, error) {
+	if n.fn == nil {
+		return nil, fmt.Errorf("function %s cannot be evaluated by the native mover", n.name)
+	}
+	args := make([]interface{}, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(row)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := n.fn.eval(n.data, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+// Functions
+
+func evalConcat(_ interface{}, args []interface{}) (interface{}, error) {
+	var b strings.Builder
+	for _, a := range args {
+		if a == nil {
+			return nil, nil
+		}
+		b.WriteString(toString(a))
+	}
+	return b.String(), nil
+}
+
+func evalConcatWS(_ interface{}, args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	parts := make([]string, 0, len(args)-1)
+	for _, a := range args[1:] {
+		if a != nil {
+			parts = append(parts, toString(a))
+		}
+	}
+	return strings.Join(parts, toString(args[0])), nil
+}
+
+func evalCoalesce(_ interface{}, args []interface{}) (interface{}, error) {
+	for _, a := range args {
+		if a != nil {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
+func evalIf(_ interface{}, args []interface{}) (interface{}, error) {
+	if b, _ := args[0].(bool); b {
+		return args[1], nil
+	}
+	return args[2], nil
+}
+
+func stringFunc(fn func(string) string) func(interface{}, []interface{}) (interface{}, error) {
+	return func(_ interface{}, args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return fn(toString(args[0])), nil
+	}
+}
+
+func evalAbs(_ interface{}, args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	if i, ok := asInt(args[0]); ok {
+		if i < 0 {
+			return -i, nil
+		}
+		return i, nil
+	}
+	f, ok := asFloat(args[0])
+	if !ok {
+		return nil, fmt.Errorf("needs a number, got %T", args[0])
+	}
+	return math.Abs(f), nil
+}
+
+// evalRound rounds half away from zero, as Spark's round does.
+func evalRound(_ interface{}, args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	var scale int64
+	if len(args) == 2 {
+		s, ok := asInt(args[1])
+		if !ok {
+			return nil, fmt.Errorf("scale must be an integer")
+		}
+		scale = s
+	}
+	if i, ok := asInt(args[0]); ok && scale >= 0 {
+		return i, nil
+	}
+	f, ok := asFloat(args[0])
+	if !ok {
+		return nil, fmt.Errorf("needs a number, got %T", args[0])
+	}
+	// Beyond these scales 10^scale is no longer a float64
+	scale = max(-maxRoundScale, min(scale, maxRoundScale))
+	p := math.Pow(10, float64(scale))
+	if math.IsInf(f*p, 0) {
+		// Too many digits asked for to round anything off
+		return f, nil
+	}
+	return math.Round(f*p) / p, nil
+}
+
+// maxRoundScale is the largest scale, either way, round rounds to.
+const maxRoundScale = 308
+
+// dateTruncUnits maps the units date_trunc accepts to the unit truncated to.
+var dateTruncUnits = map[string]string{
+	"year": "year", "yyyy": "year", "yy": "year",
+	"quarter": "quarter",
+	"month":   "month", "mon": "month", "mm": "month",
+	"week": "week",
+	"day":  "day", "dd": "day",
+	"hour":   "hour",
+	"minute": "minute",
+	"second": "second",
+}
+
+func prepareDateTrunc(args []node) (interface{}, error) {
+	unit, err := stringLiteral(args[0], "unit")
+	if err != nil {
+		return nil, err
+	}
+	u, ok := dateTruncUnits[strings.ToLower(unit)]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q", unit)
+	}
+	return u, nil
+}
+
+func evalDateTrunc(data interface{}, args []interface{}) (interface{}, error) {
+	if args[1] == nil {
+		return nil, nil
+	}
+	t, ok := asTime(args[1])
+	if !ok {
+		return nil, nil
+	}
+	return truncTime(t, data.(string)), nil
+}
+
+// truncTime truncates t to the start of its year, quarter, month, week
+// (starting Monday), day, hour, minute or second.
+func truncTime(t time.Time, unit string) time.Time {
+	y, m, d := t.Date()
+	loc := t.Location()
+	switch unit {
+	case "year":
+		return time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+	case "quarter":
+		return time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, loc)
+	case "month":
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case "week":
+		day := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "day":
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	case "hour":
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case "minute":
+		return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc)
+	default:
+		return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, loc)
+	}
+}
+
+// jsonStep is one step of a JSON path: an object key or an array index.
+type jsonStep struct {
+	key   string
+	index int // used when key is empty
+}
+
+// prepareJSONPath parses a get_json_object path such as $.address.city,
+// $.items[0] or $['first name'].
+func prepareJSONPath(args []node) (interface{}, error) {
+	path, err := stringLiteral(args[1], "path")
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path %q must start with $", path)
+	}
+	var steps []jsonStep
+	for rest := path[1:]; rest != ""; {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			steps = append(steps, jsonStep{key: rest[1 : end+1]})
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest, "']")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			steps = append(steps, jsonStep{key: rest[2:end]})
+			rest = rest[end+2:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			i, err := strconv.Atoi(rest[1:max(end, 1)])
+			if end < 0 || err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			steps = append(steps, jsonStep{index: i})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+	}
+	return steps, nil
+}
+
+// evalGetJSONObject returns the value at the path as a string, with
+// objects, arrays and numbers as JSON text, or NULL when the text is not
+// JSON or the path is missing.
+func evalGetJSONObject(data interface{}, args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	var v interface{}
+	if err := decodeJSON(toString(args[0]), &v); err != nil {
+		return nil, nil
+	}
+	for _, s := range data.([]jsonStep) {
+		if s.key != "" {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, nil
+			}
+			v = obj[s.key]
+		} else {
+			arr, ok := v.([]interface{})
+			if !ok || s.index >= len(arr) {
+				return nil, nil
+			}
+			v = arr[s.index]
+		}
+	}
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return v, nil
+	default:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(out), nil
+	}
+}
+
+// jsonField is a field of a from_json schema.
+type jsonField struct {
+	name, typ string
+}
+
+// prepareJSONSchema parses a from_json schema in DDL form, such as
+// 'street STRING, zip INT, tags ARRAY<STRING>'.
+func prepareJSONSchema(args []node) (interface{}, error) {
+	ddl, err := stringLiteral(args[1], "schema")
+	if err != nil {
+		return nil, err
+	}
+	var fields []jsonField
+	for _, part := range splitTopLevel(ddl) {
+		part = strings.TrimSpace(part)
+		var name, typ string
+		if strings.HasPrefix(part, "`") {
+			end := strings.Index(part[1:], "`")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid schema %q", ddl)
+			}
+			name, typ = part[1:end+1], part[end+2:]
+		} else {
+			name, typ, _ = strings.Cut(part, " ")
+		}
+		typ = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(typ), ":"))
+		if name == "" || typ == "" {
+			return nil, fmt.Errorf("invalid schema %q (want name TYPE, ...)", ddl)
+		}
+		fields = append(fields, jsonField{name: name, typ: strings.ToUpper(typ)})
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("schema is empty")
+	}
+	return fields, nil
+}
+
+// splitTopLevel splits s at commas outside <> and ().
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '<', '(':
+			depth++
+		case '>', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// evalFromJSON parses a JSON object into a document holding the schema's
+// fields, converting numbers, strings and booleans to the declared types.
+// Text that is not a JSON object gives NULL.
+func evalFromJSON(data interface{}, args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	var obj map[string]interface{}
+	if err := decodeJSON(toString(args[0]), &obj); err != nil || obj == nil {
+		return nil, nil
+	}
+	doc := make(map[string]interface{})
+	for _, f := range data.([]jsonField) {
+		doc[f.name] = jsonValue(obj[f.name], f.typ)
+	}
+	return doc, nil
+}
+
+// jsonValue converts a decoded JSON value to the Spark type typ.
+func jsonValue(v interface{}, typ string) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch castKind(typ) {
+	case "string":
+		if s, ok := v.(string); ok {
+			return s
+		}
+		out, _ := json.Marshal(v)
+		return string(out)
+	case "int":
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				return i
+			}
+		}
+		return nil
+	case "double":
+		if n, ok := v.(json.Number); ok {
+			if f, err := n.Float64(); err == nil {
+				return f
+			}
+		}
+		return nil
+	case "boolean":
+		if b, ok := v.(bool); ok {
+			return b
+		}
+		return nil
+	}
+	return plainJSON(v)
+}
+
+// plainJSON replaces the json.Numbers in a decoded value with int64 or
+// float64 values.
+func plainJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = plainJSON(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = plainJSON(e)
+		}
+	}
+	return v
+}
+
+func decodeJSON(s string, v interface{}) error {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// unit is a unit convert_units knows: a value v in it is v*scale+offset
+// in its dimension's base unit.
+type unit struct {
+	dimension     string
+	scale, offset float64
+}
+
+var units = map[string]unit{
+	"mm": {"length", 0.001, 0}, "cm": {"length", 0.01, 0}, "m": {"length", 1, 0}, "km": {"length", 1000, 0},
+	"in": {"length", 0.0254, 0}, "ft": {"length", 0.3048, 0}, "yd": {"length", 0.9144, 0}, "mi": {"length", 1609.344, 0},
+	"mg": {"mass", 1e-6, 0}, "g": {"mass", 0.001, 0}, "kg": {"mass", 1, 0}, "t": {"mass", 1000, 0},
+	"oz": {"mass", 0.028349523125, 0}, "lb": {"mass", 0.45359237, 0},
+	"ml": {"volume", 0.001, 0}, "l": {"volume", 1, 0}, "gal": {"volume", 3.785411784, 0},
+	"ms": {"time", 0.001, 0}, "s": {"time", 1, 0}, "min": {"time", 60, 0}, "h": {"time", 3600, 0}, "d": {"time", 86400, 0},
+	"b": {"data", 1, 0}, "kb": {"data", 1 << 10, 0}, "mb": {"data", 1 << 20, 0}, "gb": {"data", 1 << 30, 0}, "tb": {"data", 1 << 40, 0},
+	"c": {"temperature", 1, 0}, "f": {"temperature", 5.0 / 9, -160.0 / 9}, "k": {"temperature", 1, -273.15},
+}
+
+// conversion is v*factor+offset.
+type conversion struct {
+	factor, offset float64
+}
+
+func prepareConvertUnits(args []node) (interface{}, error) {
+	from, err := stringLiteral(args[1], "from unit")
+	if err != nil {
+		return nil, err
+	}
+	to, err := stringLiteral(args[2], "to unit")
+	if err != nil {
+		return nil, err
+	}
+	fu, ok := units[strings.ToLower(from)]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q", from)
+	}
+	tu, ok := units[strings.ToLower(to)]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q", to)
+	}
+	if fu.dimension != tu.dimension {
+		return nil, fmt.Errorf("cannot convert %s (%s) to %s (%s)", from, fu.dimension, to, tu.dimension)
+	}
+	// Round off the float noise of the division, so 1.7999999999999998
+	// renders as 1.8
+	return conversion{
+		factor: roundSignificant(fu.scale / tu.scale),
+		offset: roundSignificant((fu.offset - tu.offset) / tu.scale),
+	}, nil
+}
+
+func roundSignificant(f float64) float64 {
+	r, _ := strconv.ParseFloat(strconv.FormatFloat(f, 'g', 12, 64), 64)
+	return r
+}
+
+func evalConvertUnits(data interface{}, args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	f, ok := asFloat(args[0])
+	if !ok {
+		return nil, fmt.Errorf("needs a number, got %T", args[0])
+	}
+	c := data.(conversion)
+	return f*c.factor + c.offset, nil
+}
+
+// convertUnitsSQL renders convert_units as arithmetic, which Spark can run.
+func convertUnitsSQL(b *strings.Builder, n *callNode) {
+	c := n.data.(conversion)
+	b.WriteString("(")
+	writeOperand(b, n.args[0])
+	if c.factor != 1 {
+		b.WriteString(" * " + strconv.FormatFloat(c.factor, 'g', -1, 64))
+	}
+	switch {
+	case c.offset > 0:
+		b.WriteString(" + " + strconv.FormatFloat(c.offset, 'g', -1, 64))
+	case c.offset < 0:
+		b.WriteString(" - " + strconv.FormatFloat(-c.offset, 'g', -1, 64))
+	}
+	b.WriteString(")")
+}
+
+// Values
+
+func asInt(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// asFloat converts numbers, Decimal128s, and strings or bytes holding one,
+// such as decimals read from the source, to float64.
+func asFloat(v interface{}) (float64, bool) {
+	if i, ok := asInt(v); ok {
+		return float64(i), true
+	}
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bson.Decimal128:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		return f, err == nil
+	case string, []byte:
+		f, err := strconv.ParseFloat(strings.TrimSpace(toString(v)), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05", "2006-01-02"}
+
+func asTime(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string, []byte:
+		s := strings.TrimSpace(toString(v))
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		if v.Equal(truncTime(v, "day")) {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04:05.999999999")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func isNumeric(v interface{}) bool {
+	switch v.(type) {
+	case string, []byte:
+		return false
+	}
+	_, ok := asFloat(v)
+	return ok
+}
+
+// compare orders two non-NULL values: numbers numerically (strings
+// compared with numbers are parsed as numbers, as Spark casts them),
+// times chronologically, and everything else as strings.
+func compare(l, r interface{}) (int, error) {
+	switch {
+	case isNumeric(l) || isNumeric(r):
+		lf, lok := asFloat(l)
+		rf, rok := asFloat(r)
+		if !lok || !rok {
+			return 0, fmt.Errorf("cannot compare %v with %v", l, r)
+		}
+		return cmpFloat(lf, rf), nil
+	}
+	lt, lok := l.(time.Time)
+	rt, rok := r.(time.Time)
+	if lok || rok {
+		if !lok {
+			lt, lok = asTime(l)
+		}
+		if !rok {
+			rt, rok = asTime(r)
+		}
+		if !lok || !rok {
+			return 0, fmt.Errorf("cannot compare %v with %v", l, r)
+		}
+		return lt.Compare(rt), nil
+	}
+	if lb, ok := l.(bool); ok {
+		rb, ok := r.(bool)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare %v with %v", l, r)
+		}
+		return cmpFloat(boolInt(lb), boolInt(rb)), nil
+	}
+	return bytes.Compare([]byte(toString(l)), []byte(toString(r))), nil
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolInt(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// arith applies an arithmetic operator. Integers stay integers except
+// under /, which like Spark's always gives a double; dividing by zero
+// gives NULL.
+func arith(op string, l, r interface{}) (interface{}, error) {
+	li, lok := asInt(l)
+	ri, rok := asInt(r)
+	if lok && rok && op != "/" {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		default:
+			if ri == 0 {
+				return nil, nil
+			}
+			return li % ri, nil
+		}
+	}
+	lf, lok := asFloat(l)
+	rf, rok := asFloat(r)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%s needs numbers, got %v and %v", op, l, r)
+	}
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	}
+	if rf == 0 {
+		return nil, nil
+	}
+	if op == "/" {
+		return lf / rf, nil
+	}
+	return math.Mod(lf, rf), nil
+}
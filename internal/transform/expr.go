@@ -0,0 +1,846 @@
+package transform
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression is a parsed compute expression: a Spark SQL fragment built
+// from column names, literals, arithmetic, comparisons, AND/OR/NOT,
+// IS [NOT] NULL, [NOT] IN, [NOT] LIKE, CASE/WHEN, CAST and function
+// calls. Besides Spark's own functions it accepts convert_units(value,
+// 'from', 'to'), which is rewritten to arithmetic for Spark.
+//
+// Expressions are translated to PySpark expr() calls by SQL and evaluated
+// row by row by the native mover with Eval, which supports the functions
+// listed in nativeFunctions.
+type Expression struct {
+	root node
+}
+
+// ParseExpression parses a compute expression.
+func ParseExpression(s string) (*Expression, error) {
+	toks, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos+1)
+	}
+	return &Expression{root: root}, nil
+}
+
+// SQL returns the expression as Spark SQL, with convert_units calls
+// rewritten to arithmetic.
+func (x *Expression) SQL() string {
+	var b strings.Builder
+	x.root.sql(&b)
+	return b.String()
+}
+
+// Columns returns the columns the expression reads, in order of first use.
+func (x *Expression) Columns() []string {
+	var cols []string
+	seen := make(map[string]bool)
+	walk(x.root, func(n node) {
+		if c, ok := n.(*columnNode); ok && !seen[strings.ToLower(c.name)] {
+			seen[strings.ToLower(c.name)] = true
+			cols = append(cols, c.name)
+		}
+	})
+	return cols
+}
+
+// Native reports whether the native mover can evaluate the expression,
+// returning an error naming the first function or cast it cannot.
+func (x *Expression) Native() error {
+	var err error
+	walk(x.root, func(n node) {
+		if err != nil {
+			return
+		}
+		switch n := n.(type) {
+		case *callNode:
+			if _, ok := nativeFunctions[n.name]; !ok {
+				err = fmt.Errorf("function %s cannot be evaluated by the native mover", n.name)
+			}
+		case *castNode:
+			if castKind(n.typ) == "" {
+				err = fmt.Errorf("cast to %s cannot be evaluated by the native mover", n.typ)
+			}
+		}
+	})
+	return err
+}
+
+// Eval evaluates the expression against a source row. Column names match
+// case-insensitively, as in Spark, and NULLs propagate as they do there.
+func (x *Expression) Eval(row map[string]interface{}) (interface{}, error) {
+	return x.root.eval(row)
+}
+
+// node is a parsed expression node.
+type node interface {
+	sql(b *strings.Builder)
+	eval(row map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+	text  string // source text of numbers, rendered as written
+}
+
+type columnNode struct {
+	name   string
+	quoted bool // written in backticks
+}
+
+type parenNode struct {
+	inner node
+}
+
+type unaryNode struct {
+	op      string // "-" or "NOT"
+	operand node
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+type isNullNode struct {
+	operand node
+	not     bool
+}
+
+type inNode struct {
+	operand node
+	list    []node
+	not     bool
+}
+
+type likeNode struct {
+	operand, pattern node
+	not              bool
+	re               *regexp.Regexp // compiled when the pattern is a literal
+}
+
+type whenClause struct {
+	cond, result node
+}
+
+type caseNode struct {
+	operand node // nil for CASE WHEN <condition>
+	whens   []whenClause
+	els     node
+}
+
+type castNode struct {
+	operand node
+	typ     string
+}
+
+type callNode struct {
+	name string
+	args []node
+	fn   *nativeFunction // nil for functions only Spark evaluates
+	data interface{}     // literal arguments prepared by fn.prepare
+}
+
+// walk calls fn for n and every node beneath it.
+func walk(n node, fn func(node)) {
+	if n == nil {
+		return
+	}
+	fn(n)
+	switch n := n.(type) {
+	case *parenNode:
+		walk(n.inner, fn)
+	case *unaryNode:
+		walk(n.operand, fn)
+	case *binaryNode:
+		walk(n.left, fn)
+		walk(n.right, fn)
+	case *isNullNode:
+		walk(n.operand, fn)
+	case *inNode:
+		walk(n.operand, fn)
+		for _, e := range n.list {
+			walk(e, fn)
+		}
+	case *likeNode:
+		walk(n.operand, fn)
+		walk(n.pattern, fn)
+	case *caseNode:
+		walk(n.operand, fn)
+		for _, w := range n.whens {
+			walk(w.cond, fn)
+			walk(w.result, fn)
+		}
+		walk(n.els, fn)
+	case *castNode:
+		walk(n.operand, fn)
+	case *callNode:
+		for _, a := range n.args {
+			walk(a, fn)
+		}
+	}
+}
+
+// Rendering
+
+func (n *literalNode) sql(b *strings.Builder) {
+	switch v := n.value.(type) {
+	case nil:
+		b.WriteString("NULL")
+	case bool:
+		b.WriteString(strings.ToUpper(strconv.FormatBool(v)))
+	case string:
+		b.WriteString(quoteString(v))
+	default:
+		b.WriteString(n.text)
+	}
+}
+
+func (n *columnNode) sql(b *strings.Builder) {
+	if !n.quoted && isIdentifier(n.name) && !keywords[strings.ToUpper(n.name)] {
+		b.WriteString(n.name)
+		return
+	}
+	b.WriteString("`" + strings.ReplaceAll(n.name, "`", "``") + "`")
+}
+
+func (n *parenNode) sql(b *strings.Builder) {
+	b.WriteString("(")
+	n.inner.sql(b)
+	b.WriteString(")")
+}
+
+func (n *unaryNode) sql(b *strings.Builder) {
+	if n.op == "NOT" {
+		b.WriteString("NOT ")
+	} else {
+		b.WriteString(n.op)
+	}
+	n.operand.sql(b)
+}
+
+func (n *binaryNode) sql(b *strings.Builder) {
+	n.left.sql(b)
+	b.WriteString(" " + n.op + " ")
+	n.right.sql(b)
+}
+
+func (n *isNullNode) sql(b *strings.Builder) {
+	n.operand.sql(b)
+	if n.not {
+		b.WriteString(" IS NOT NULL")
+	} else {
+		b.WriteString(" IS NULL")
+	}
+}
+
+func (n *inNode) sql(b *strings.Builder) {
+	n.operand.sql(b)
+	if n.not {
+		b.WriteString(" NOT")
+	}
+	b.WriteString(" IN (")
+	writeList(b, n.list)
+	b.WriteString(")")
+}
+
+func (n *likeNode) sql(b *strings.Builder) {
+	n.operand.sql(b)
+	if n.not {
+		b.WriteString(" NOT")
+	}
+	b.WriteString(" LIKE ")
+	n.pattern.sql(b)
+}
+
+func (n *caseNode) sql(b *strings.Builder) {
+	b.WriteString("CASE")
+	if n.operand != nil {
+		b.WriteString(" ")
+		n.operand.sql(b)
+	}
+	for _, w := range n.whens {
+		b.WriteString(" WHEN ")
+		w.cond.sql(b)
+		b.WriteString(" THEN ")
+		w.result.sql(b)
+	}
+	if n.els != nil {
+		b.WriteString(" ELSE ")
+		n.els.sql(b)
+	}
+	b.WriteString(" END")
+}
+
+func (n *castNode) sql(b *strings.Builder) {
+	b.WriteString("CAST(")
+	n.operand.sql(b)
+	b.WriteString(" AS " + n.typ + ")")
+}
+
+func (n *callNode) sql(b *strings.Builder) {
+	if n.fn != nil && n.fn.sql != nil {
+		n.fn.sql(b, n)
+		return
+	}
+	b.WriteString(n.name + "(")
+	writeList(b, n.args)
+	b.WriteString(")")
+}
+
+func writeList(b *strings.Builder, nodes []node) {
+	for i, n := range nodes {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		n.sql(b)
+	}
+}
+
+// writeOperand renders n as the operand of an operator, parenthesized
+// unless it is a single term.
+func writeOperand(b *strings.Builder, n node) {
+	switch n.(type) {
+	case *literalNode, *columnNode, *parenNode, *callNode, *caseNode, *castNode:
+		n.sql(b)
+	default:
+		b.WriteString("(")
+		n.sql(b)
+		b.WriteString(")")
+	}
+}
+
+// quoteString renders s as a single-quoted Spark SQL string literal.
+func quoteString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\t", `\t`, "\r", `\r`).Replace(s) + "'"
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Tokenizing
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokQuoted // `backticked` identifier
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string // identifier, unquoted string, number or operator
+	pos  int
+}
+
+// keywords are the reserved words of the expression language. Columns
+// with these names must be backticked.
+var keywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "IS": true, "NULL": true,
+	"IN": true, "LIKE": true, "CASE": true, "WHEN": true, "THEN": true,
+	"ELSE": true, "END": true, "CAST": true, "AS": true, "TRUE": true,
+	"FALSE": true,
+}
+
+func tokenize(s string) ([]token, error) {
+	var toks []token
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '_' || unicode.IsLetter(r):
+			j := i
+			for j < len(rs) && (rs[j] == '_' || unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j])) {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: string(rs[i:j]), pos: i})
+			i = j
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			if j < len(rs) && (rs[j] == 'e' || rs[j] == 'E') {
+				k := j + 1
+				if k < len(rs) && (rs[k] == '+' || rs[k] == '-') {
+					k++
+				}
+				if k < len(rs) && unicode.IsDigit(rs[k]) {
+					for j = k; j < len(rs) && unicode.IsDigit(rs[j]); j++ {
+					}
+				}
+			}
+			text := string(rs[i:j])
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", text, i+1)
+			}
+			toks = append(toks, token{kind: tokNumber, text: text, pos: i})
+			i = j
+		case r == '\'' || r == '"' || r == '`':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(rs) && rs[j] != r; j++ {
+				if rs[j] == '\\' && r != '`' && j+1 < len(rs) {
+					j++
+					switch rs[j] {
+					case 'n':
+						b.WriteRune('\n')
+					case 't':
+						b.WriteRune('\t')
+					case 'r':
+						b.WriteRune('\r')
+					default:
+						b.WriteRune(rs[j])
+					}
+					continue
+				}
+				b.WriteRune(rs[j])
+			}
+			if j >= len(rs) {
+				return nil, fmt.Errorf("unterminated %c at position %d", r, i+1)
+			}
+			kind := tokString
+			if r == '`' {
+				kind = tokQuoted
+			}
+			toks = append(toks, token{kind: kind, text: b.String(), pos: i})
+			i = j + 1
+		default:
+			op := string(r)
+			if i+1 < len(rs) {
+				switch two := string(rs[i : i+2]); two {
+				case "<=", ">=", "<>", "!=", "==", "||":
+					op = two
+				}
+			}
+			if !strings.Contains("+-*/%=<>(),", op) && len(op) == 1 {
+				return nil, fmt.Errorf("unexpected %q at position %d", op, i+1)
+			}
+			toks = append(toks, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(rs)}), nil
+}
+
+// Parsing
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is the keyword kw.
+func (p *parser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokIdent && strings.EqualFold(t.text, kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// op consumes the next token if it is one of ops, returning it.
+func (p *parser) op(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp {
+		return "", false
+	}
+	for _, o := range ops {
+		if t.text == o {
+			p.i++
+			return o, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.op(op); !ok {
+		return p.unexpected("expected " + op)
+	}
+	return nil
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.keyword(kw) {
+		return p.unexpected("expected " + kw)
+	}
+	return nil
+}
+
+func (p *parser) unexpected(want string) error {
+	t := p.peek()
+	if t.kind == tokEOF {
+		return fmt.Errorf("%s at end of expression", want)
+	}
+	return fmt.Errorf("%s at position %d, found %q", want, t.pos+1, t.text)
+}
+
+func (p *parser) parseExpr() (node, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.keyword("NOT") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: "NOT", operand: operand}, nil
+	}
+	return p.parsePredicate()
+}
+
+func (p *parser) parsePredicate() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.op("=", "==", "<>", "!=", "<", "<=", ">", ">="); ok {
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, left: left, right: right}, nil
+	}
+	if p.keyword("IS") {
+		not := p.keyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &isNullNode{operand: left, not: not}, nil
+	}
+	not := p.keyword("NOT")
+	switch {
+	case p.keyword("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("IN needs at least one value")
+		}
+		return &inNode{operand: left, list: list, not: not}, nil
+	case p.keyword("LIKE"):
+		pattern, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		n := &likeNode{operand: left, pattern: pattern, not: not}
+		if lit, ok := pattern.(*literalNode); ok {
+			if s, ok := lit.value.(string); ok {
+				n.re = likePattern(s)
+			}
+		}
+		return n, nil
+	case not:
+		return nil, p.unexpected("expected IN or LIKE after NOT")
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.op("+", "-", "||")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.op("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if op, ok := p.op("-", "+"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			return operand, nil
+		}
+		return &unaryNode{op: "-", operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokNumber:
+		p.next()
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return &literalNode{value: i, text: t.text}, nil
+		}
+		f, _ := strconv.ParseFloat(t.text, 64)
+		return &literalNode{value: f, text: t.text}, nil
+	case tokString:
+		p.next()
+		return &literalNode{value: t.text}, nil
+	case tokQuoted:
+		p.next()
+		return &columnNode{name: t.text, quoted: true}, nil
+	case tokOp:
+		if t.text != "(" {
+			break
+		}
+		p.next()
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &parenNode{inner: inner}, nil
+	case tokIdent:
+		switch kw := strings.ToUpper(t.text); kw {
+		case "NULL":
+			p.next()
+			return &literalNode{}, nil
+		case "TRUE", "FALSE":
+			p.next()
+			return &literalNode{value: kw == "TRUE"}, nil
+		case "CASE":
+			p.next()
+			return p.parseCase()
+		case "CAST":
+			p.next()
+			return p.parseCast()
+		default:
+			if keywords[kw] {
+				return nil, p.unexpected("expected a value")
+			}
+		}
+		p.next()
+		if _, ok := p.op("("); ok {
+			args, err := p.parseList()
+			if err != nil {
+				return nil, err
+			}
+			return newCall(strings.ToLower(t.text), args)
+		}
+		return &columnNode{name: t.text}, nil
+	}
+	return nil, p.unexpected("expected a value")
+}
+
+// parseList parses comma-separated expressions up to a closing parenthesis.
+func (p *parser) parseList() ([]node, error) {
+	var list []node
+	if _, ok := p.op(")"); ok {
+		return list, nil
+	}
+	for {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		if _, ok := p.op(","); !ok {
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (p *parser) parseCase() (node, error) {
+	n := &caseNode{}
+	if t := p.peek(); !(t.kind == tokIdent && strings.EqualFold(t.text, "WHEN")) {
+		operand, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		n.operand = operand
+	}
+	for p.keyword("WHEN") {
+		cond, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("THEN"); err != nil {
+			return nil, err
+		}
+		result, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		n.whens = append(n.whens, whenClause{cond: cond, result: result})
+	}
+	if len(n.whens) == 0 {
+		return nil, p.unexpected("expected WHEN")
+	}
+	if p.keyword("ELSE") {
+		els, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		n.els = els
+	}
+	if err := p.expectKeyword("END"); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (p *parser) parseCast() (node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	operand, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("AS"); err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if t.kind != tokIdent {
+		p.i--
+		return nil, p.unexpected("expected a type")
+	}
+	typ := strings.ToUpper(t.text)
+	if _, ok := p.op("("); ok {
+		var params []string
+		for {
+			n := p.next()
+			if n.kind != tokNumber {
+				p.i--
+				return nil, p.unexpected("expected a number")
+			}
+			params = append(params, n.text)
+			if _, ok := p.op(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		typ += "(" + strings.Join(params, ",") + ")"
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return &castNode{operand: operand, typ: typ}, nil
+}
+
+// likePattern compiles a LIKE pattern, where % matches any run of
+// characters, _ any one character and \ escapes the next.
+func likePattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+	rs := []rune(pattern)
+	for i := 0; i < len(rs); i++ {
+		switch r := rs[i]; {
+		case r == '\\' && i+1 < len(rs):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(rs[i])))
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
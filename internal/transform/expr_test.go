@@ -0,0 +1,290 @@
+package transform
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func TestParseExpression_SQL(t *testing.T) {
+	tests := []struct {
+		expr, want string
+	}{
+		{"concat(first_name, ' ', last_name)", "concat(first_name, ' ', last_name)"},
+		{"a+b*2", "a + b * 2"},
+		{"(a + b) * 2", "(a + b) * 2"},
+		{"case when status = 'A' then 'active' when status is null then null else 'other' end",
+			"CASE WHEN status = 'A' THEN 'active' WHEN status IS NULL THEN NULL ELSE 'other' END"},
+		{"CASE tier WHEN 1 THEN 'gold' END", "CASE tier WHEN 1 THEN 'gold' END"},
+		{"DATE_TRUNC('month', created_at)", "date_trunc('month', created_at)"},
+		{"get_json_object(attrs, '$.color')", "get_json_object(attrs, '$.color')"},
+		{"cast(price as decimal(10, 2))", "CAST(price AS DECIMAL(10,2))"},
+		{"x not in (1, 2) and name not like 'A%'", "x NOT IN (1, 2) AND name NOT LIKE 'A%'"},
+		{"`order` || 'x'", "`order` || 'x'"},
+		{`"it's"`, `'it\'s'`},
+		{"convert_units(weight_lb, 'lb', 'kg')", "(weight_lb * 0.45359237)"},
+		{"convert_units(a + b, 'km', 'm')", "((a + b) * 1000)"},
+		{"convert_units(temp, 'C', 'F')", "(temp * 1.8 + 32)"},
+		{"convert_units(temp, 'F', 'C')", "(temp * 0.555555555556 - 17.7777777778)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			x, err := ParseExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseExpression: %v", err)
+			}
+			if got := x.SQL(); got != tt.want {
+				t.Errorf("SQL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExpression_Errors(t *testing.T) {
+	tests := []struct {
+		expr, wantErr string
+	}{
+		{"concat(a, ", "end of expression"},
+		{"a + ", "expected a value"},
+		{"'open", "unterminated"},
+		{"a # b", `unexpected "#"`},
+		{"CASE END", "expected a value"},
+		{"CASE x ELSE 1 END", "expected WHEN"},
+		{"a b", `unexpected "b"`},
+		{"upper(a, b)", "upper takes 1 arguments"},
+		{"date_trunc('fortnight', d)", `unknown unit "fortnight"`},
+		{"date_trunc(unit, d)", "unit must be a string literal"},
+		{"convert_units(x, 'kg', 'm')", "cannot convert kg (mass) to m (length)"},
+		{"convert_units(x, 'kg', 'stone')", `unknown unit "stone"`},
+		{"get_json_object(j, 'color')", "must start with $"},
+		{"from_json(j, '')", "schema is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseExpression(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpression_Eval(t *testing.T) {
+	created := time.Date(2024, 5, 15, 13, 45, 10, 0, time.UTC)
+	row := map[string]interface{}{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"middle":     nil,
+		"status":     "A",
+		"qty":        int64(7),
+		"price":      "2.50",
+		"weight_lb":  10.0,
+		"created_at": created,
+		"attrs":      `{"color": "red", "sizes": [8, 10], "dims": {"w": 2}}`,
+	}
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"concat(first_name, ' ', last_name)", "Ada Lovelace"},
+		{"concat(first_name, middle)", nil},
+		{"concat_ws(' ', first_name, middle, last_name)", "Ada Lovelace"},
+		{"first_name || '!'", "Ada!"},
+		{"upper(FIRST_NAME)", "ADA"},
+		{"coalesce(middle, 'none')", "none"},
+		{"qty * 2 + 1", int64(15)},
+		{"qty / 2", 3.5},
+		{"qty / 0", nil},
+		{"qty * price", 17.5},
+		{"-qty", int64(-7)},
+		{"round(qty / 3, 2)", 2.33},
+		{"round(price, 400)", 2.5},
+		{"round(qty, -400)", 0.0},
+		{"round(1.5e300, 300)", 1.5e300},
+		{"CASE WHEN qty > 5 THEN 'many' ELSE 'few' END", "many"},
+		{"CASE status WHEN 'A' THEN 'active' WHEN 'I' THEN 'inactive' END", "active"},
+		{"CASE WHEN middle = 'x' THEN 1 END", nil},
+		{"if(qty >= 7, 'yes', 'no')", "yes"},
+		{"middle IS NULL AND status IN ('A', 'B')", true},
+		{"status NOT IN ('B', NULL)", nil},
+		{"middle = 'x' OR qty > 1", true},
+		{"middle = 'x' AND qty > 1", nil},
+		{"NOT (qty < 1)", true},
+		{"last_name LIKE 'Love%'", true},
+		{"last_name LIKE '_ove'", false},
+		{"date_trunc('month', created_at)", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"date_trunc('quarter', created_at)", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"date_trunc('week', created_at)", time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{"date_trunc('hour', '2024-05-15 13:45:10')", time.Date(2024, 5, 15, 13, 0, 0, 0, time.UTC)},
+		{"created_at > '2024-01-01'", true},
+		{"get_json_object(attrs, '$.color')", "red"},
+		{"get_json_object(attrs, '$.sizes[1]')", "10"},
+		{"get_json_object(attrs, '$.dims')", `{"w":2}`},
+		{"get_json_object(attrs, '$.missing')", nil},
+		{"get_json_object(first_name, '$.a')", nil},
+		{"from_json(attrs, 'color STRING, sizes ARRAY<INT>, dims STRUCT<w: INT, h: INT>, shade STRING')",
+			map[string]interface{}{
+				"color": "red",
+				"sizes": []interface{}{int64(8), int64(10)},
+				"dims":  map[string]interface{}{"w": int64(2)},
+				"shade": nil,
+			}},
+		{"convert_units(weight_lb, 'lb', 'kg')", 4.5359237},
+		{"convert_units(100, 'c', 'f')", 212.0},
+		{"convert_units(2048, 'kb', 'mb')", 2.0},
+		{"CAST(price AS DOUBLE)", 2.5},
+		{"CAST(price AS INT)", int64(2)},
+		{"CAST(qty AS STRING)", "7"},
+		{"CAST(first_name AS INT)", nil},
+		{"CAST('9999999999999999999999' AS BIGINT)", nil},
+		{"CAST(-1e19 AS BIGINT)", nil},
+		{"CAST('-9.2e18' AS BIGINT)", int64(-9200000000000000000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			x, err := ParseExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseExpression: %v", err)
+			}
+			got, err := x.Eval(row)
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if f, ok := tt.want.(float64); ok {
+				if g, ok := got.(float64); ok && math.Abs(g-f) < 1e-9 {
+					return
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Eval() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpression_ColumnsAndNative(t *testing.T) {
+	x, err := ParseExpression("concat(upper(a), `b c`, A, soundex(d))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := x.Columns(); !reflect.DeepEqual(got, []string{"a", "b c", "d"}) {
+		t.Errorf("Columns() = %v", got)
+	}
+	if err := x.Native(); err == nil || !strings.Contains(err.Error(), "soundex") {
+		t.Errorf("Native() = %v, want soundex reported", err)
+	}
+	if _, err := x.Eval(map[string]interface{}{"a": "x", "b c": "y", "d": "z"}); err == nil {
+		t.Error("Eval should fail on a function only Spark runs")
+	}
+
+	x, _ = ParseExpression("CAST(a AS BINARY)")
+	if err := x.Native(); err == nil {
+		t.Error("Native() should reject casts the native mover cannot make")
+	}
+}
+
+func TestComputedFields(t *testing.T) {
+	ts := []mapping.Transformation{
+		{Operation: OpRename, SourceField: "a", TargetField: "b"},
+		{Operation: OpCompute, TargetField: "total", Expression: "qty * price"},
+		{Operation: OpCompute, TargetField: "label", Expression: "concat('$', total)"},
+	}
+	computed, err := ComputedFields(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := map[string]interface{}{"qty": int64(3), "price": 1.5}
+	if err := ApplyComputed(row, computed); err != nil {
+		t.Fatal(err)
+	}
+	if row["total"] != 4.5 || row["label"] != "$4.5" {
+		t.Errorf("row = %v", row)
+	}
+
+	if err := ApplyComputed(map[string]interface{}{"qty": int64(1)}, computed); err == nil || !strings.Contains(err.Error(), "price") {
+		t.Errorf("err = %v, want missing price reported", err)
+	}
+	if _, err := ComputedFields([]mapping.Transformation{{Operation: OpCompute, TargetField: "x", Expression: "md5(a)"}}); err == nil {
+		t.Error("ComputedFields should reject functions only Spark runs")
+	}
+}
+
+func TestCheckColumns(t *testing.T) {
+	cols := []string{"id", "first_name", "last_name"}
+	tests := []struct {
+		name    string
+		ts      []mapping.Transformation
+		wantErr string
+	}{
+		{"known columns", []mapping.Transformation{
+			{Operation: OpCompute, TargetField: "full", Expression: "concat(FIRST_NAME, ' ', last_name)"},
+		}, ""},
+		{"earlier compute", []mapping.Transformation{
+			{Operation: OpCompute, TargetField: "full", Expression: "concat(first_name, ' ', last_name)"},
+			{Operation: OpCompute, TargetField: "shout", Expression: "upper(full)"},
+		}, ""},
+		{"missing column", []mapping.Transformation{
+			{Operation: OpCompute, TargetField: "full", Expression: "concat(first_name, middle_name)"},
+		}, "compute full: column middle_name does not exist"},
+		{"later compute", []mapping.Transformation{
+			{Operation: OpCompute, TargetField: "shout", Expression: "upper(full)"},
+			{Operation: OpCompute, TargetField: "full", Expression: "first_name"},
+		}, "column full does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckColumns(tt.ts, cols)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMapping(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "customers", Columns: []schema.Column{{Name: "id"}, {Name: "name"}}},
+		{Name: "orders", Columns: []schema.Column{{Name: "customer_id"}, {Name: "amount"}}},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name: "customers", SourceTable: "customers",
+		Transformations: []mapping.Transformation{
+			{Operation: OpCompute, TargetField: "initial", Expression: "upper(name)"},
+		},
+		Embedded: []mapping.Embedded{{
+			SourceTable: "orders", FieldName: "orders",
+			Transformations: []mapping.Transformation{
+				{Operation: OpCompute, TargetField: "cents", Expression: "amount * 100"},
+			},
+		}},
+	}}}
+	if err := ValidateMapping(m, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.Collections[0].Embedded[0].Transformations[0].Expression = "amount * rate"
+	err := ValidateMapping(m, s)
+	if err == nil || !strings.Contains(err.Error(), "collection customers: embedded orders: compute cents: column rate does not exist") {
+		t.Errorf("err = %v", err)
+	}
+
+	// Without the table in the schema only the syntax is checked
+	if err := ValidateMapping(m, &schema.Schema{}); err != nil {
+		t.Errorf("unexpected error without schema tables: %v", err)
+	}
+	m.Collections[0].Transformations[0].Expression = "upper(name"
+	if err := ValidateMapping(m, nil); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
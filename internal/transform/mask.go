@@ -0,0 +1,156 @@
+package transform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+)
+
+// Masking operations replace a column's value so personal data is not
+// copied to the target. Each is deterministic: the same source value is
+// masked the same way in every row, table and run, so masked keys still
+// join.
+const (
+	OpHash       = "hash"       // SHA-256 of value (a salt) and the value, as hex
+	OpRedact     = "redact"     // value, or the value with every letter and digit starred
+	OpSynthesize = "synthesize" // a made-up value of the kind named by value
+	OpNullify    = "nullify"    // null
+)
+
+// Kinds of made-up values synthesize produces.
+const (
+	SynthName      = "name"
+	SynthFirstName = "first_name"
+	SynthLastName  = "last_name"
+	SynthEmail     = "email"
+	SynthPhone     = "phone"
+)
+
+var synthKinds = []string{SynthName, SynthFirstName, SynthLastName, SynthEmail, SynthPhone}
+
+// FirstNames and LastNames are the names synthesized values are made of.
+// The generated PySpark picks from the same lists, so both movers synthesize
+// the same value for a source value.
+var (
+	FirstNames = []string{
+		"Alex", "Blake", "Casey", "Dana", "Elliot", "Frankie", "Gray", "Harper",
+		"Indy", "Jordan", "Kai", "Logan", "Morgan", "Noel", "Oakley", "Parker",
+		"Quinn", "Riley", "Sage", "Taylor", "Umi", "Val", "Wren", "Yael",
+	}
+	LastNames = []string{
+		"Adams", "Brooks", "Carter", "Diaz", "Ellis", "Foster", "Garcia", "Hughes",
+		"Ito", "Jensen", "Kim", "Lopez", "Moreau", "Novak", "Okafor", "Patel",
+		"Quist", "Rossi", "Silva", "Tanaka", "Ueda", "Varga", "Weber", "Young",
+	}
+)
+
+// IsMask reports whether op is a masking operation.
+func IsMask(op string) bool {
+	switch op {
+	case OpHash, OpRedact, OpSynthesize, OpNullify:
+		return true
+	}
+	return false
+}
+
+// HasMasks reports whether any of the transformations masks a column.
+func HasMasks(transforms []mapping.Transformation) bool {
+	for _, t := range transforms {
+		if IsMask(t.Operation) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMask checks a masking transformation.
+func validateMask(t mapping.Transformation) error {
+	if t.SourceField == "" {
+		return fmt.Errorf("%s: source_field is required", t.Operation)
+	}
+	if t.Operation == OpSynthesize {
+		for _, k := range synthKinds {
+			if t.Value == k {
+				return nil
+			}
+		}
+		return fmt.Errorf("synthesize %s: value must be one of %s", t.SourceField, strings.Join(synthKinds, ", "))
+	}
+	return nil
+}
+
+var redactable = regexp.MustCompile(`[A-Za-z0-9]`)
+
+// MaskValue returns the value a masking transformation writes in place of
+// v. Null stays null.
+func MaskValue(t mapping.Transformation, v interface{}) interface{} {
+	if v == nil || t.Operation == OpNullify {
+		return nil
+	}
+	s := maskString(v)
+	switch t.Operation {
+	case OpHash:
+		sum := sha256.Sum256([]byte(t.Value + s))
+		return hex.EncodeToString(sum[:])
+	case OpRedact:
+		if t.Value != "" {
+			return t.Value
+		}
+		return redactable.ReplaceAllString(s, "*")
+	case OpSynthesize:
+		return synthesize(t.Value, s)
+	}
+	return v
+}
+
+// ApplyMasks masks the columns of a source row in place, in order.
+func ApplyMasks(row map[string]interface{}, transforms []mapping.Transformation) {
+	for _, t := range transforms {
+		if !IsMask(t.Operation) {
+			continue
+		}
+		if v, ok := row[t.SourceField]; ok {
+			row[t.SourceField] = MaskValue(t, v)
+		}
+	}
+}
+
+// maskString is the text a value is masked from, as Spark casts it to a
+// string.
+func maskString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// synthesize makes up a value of the kind from the SHA-256 of s: its first
+// three 8-digit hex segments pick the first name, the last name and a number.
+func synthesize(kind, s string) string {
+	sum := sha256.Sum256([]byte(s))
+	h := hex.EncodeToString(sum[:])
+	pick := func(seg int) int {
+		n, _ := strconv.ParseUint(h[seg*8:seg*8+8], 16, 32)
+		return int(n)
+	}
+	first, last := FirstNames[pick(0)%len(FirstNames)], LastNames[pick(1)%len(LastNames)]
+	switch kind {
+	case SynthFirstName:
+		return first
+	case SynthLastName:
+		return last
+	case SynthEmail:
+		return fmt.Sprintf("%s.%s.%d@example.com", strings.ToLower(first), strings.ToLower(last), pick(2)%1000)
+	case SynthPhone:
+		return fmt.Sprintf("555-01%02d", pick(2)%100)
+	}
+	return first + " " + last
+}
@@ -0,0 +1,133 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func TestMaskValue(t *testing.T) {
+	tests := []struct {
+		name string
+		mask mapping.Transformation
+		in   interface{}
+		want interface{}
+	}{
+		{"hash", mapping.Transformation{Operation: OpHash}, "alice", "2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90"},
+		{"hash integer as text", mapping.Transformation{Operation: OpHash}, int64(42), MaskValue(mapping.Transformation{Operation: OpHash}, "42")},
+		{"redact", mapping.Transformation{Operation: OpRedact}, "4111-1111 x", "****-**** *"},
+		{"redact with value", mapping.Transformation{Operation: OpRedact, Value: "[removed]"}, "secret", "[removed]"},
+		{"nullify", mapping.Transformation{Operation: OpNullify}, "1990-01-01", nil},
+		{"null stays null", mapping.Transformation{Operation: OpHash}, nil, nil},
+		{"phone", mapping.Transformation{Operation: OpSynthesize, Value: SynthPhone}, "+1 202 555 1234", MaskValue(mapping.Transformation{Operation: OpSynthesize, Value: SynthPhone}, "+1 202 555 1234")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaskValue(tt.mask, tt.in); got != tt.want {
+				t.Errorf("MaskValue(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	salted := MaskValue(mapping.Transformation{Operation: OpHash, Value: "pepper"}, "alice")
+	if salted == tests[0].want {
+		t.Error("salt does not change the hash")
+	}
+
+	email := MaskValue(mapping.Transformation{Operation: OpSynthesize, Value: SynthEmail}, "alice@corp.com").(string)
+	if !strings.HasSuffix(email, "@example.com") || strings.Contains(email, "alice") {
+		t.Errorf("synthesized email = %q", email)
+	}
+	name := MaskValue(mapping.Transformation{Operation: OpSynthesize, Value: SynthName}, "Alice Smith").(string)
+	first, last, _ := strings.Cut(name, " ")
+	if MaskValue(mapping.Transformation{Operation: OpSynthesize, Value: SynthFirstName}, "Alice Smith") != first ||
+		MaskValue(mapping.Transformation{Operation: OpSynthesize, Value: SynthLastName}, "Alice Smith") != last {
+		t.Errorf("name %q does not match its first and last names", name)
+	}
+}
+
+func TestApplyMasks(t *testing.T) {
+	row := map[string]interface{}{"id": 1, "ssn": "123-45-6789", "dob": "1990-01-01"}
+	ApplyMasks(row, []mapping.Transformation{
+		{SourceField: "ssn", Operation: OpRedact},
+		{SourceField: "dob", Operation: OpNullify},
+		{SourceField: "missing", Operation: OpNullify},
+		{SourceField: "id", Operation: "rename", TargetField: "_id"},
+	})
+	if row["ssn"] != "***-**-****" || row["dob"] != nil || row["id"] != 1 {
+		t.Errorf("masked row = %v", row)
+	}
+	if _, ok := row["missing"]; ok {
+		t.Error("masking added a missing column")
+	}
+}
+
+func TestValidateMask(t *testing.T) {
+	tests := []struct {
+		mask    mapping.Transformation
+		wantErr bool
+	}{
+		{mapping.Transformation{SourceField: "email", Operation: OpHash}, false},
+		{mapping.Transformation{SourceField: "email", Operation: OpSynthesize, Value: SynthEmail}, false},
+		{mapping.Transformation{SourceField: "email", Operation: OpSynthesize}, true},
+		{mapping.Transformation{SourceField: "email", Operation: OpSynthesize, Value: "postcode"}, true},
+		{mapping.Transformation{Operation: OpNullify}, true},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.mask); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) = %v, wantErr %v", tt.mask, err, tt.wantErr)
+		}
+	}
+}
+
+func TestToPySpark_Mask(t *testing.T) {
+	got := ToPySpark(mapping.Transformation{SourceField: "email", Operation: OpSynthesize, Value: SynthEmail}, "df")
+	want := `df = mask_column(df, "email", "synthesize", "email")`
+	if got != want {
+		t.Errorf("got:\n  %s\nwant:\n  %s", got, want)
+	}
+}
+
+func TestClassifyPII(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "users", Columns: []schema.Column{
+			{Name: "id", DataType: "integer"},
+			{Name: "email", DataType: "varchar"},
+			{Name: "email_verified", DataType: "boolean"},
+			{Name: "email_count", DataType: "integer"},
+			{Name: "first_name", DataType: "varchar"},
+			{Name: "ssn", DataType: "char"},
+			{Name: "date_of_birth", DataType: "date"},
+			{Name: "phone_updated_at", DataType: "timestamp"},
+			{Name: "ip_address", DataType: "varchar"},
+			{Name: "last_seen", DataType: "inet"},
+			{Name: "password_hash", DataType: "varchar"},
+			{Name: "shipping_address", DataType: "text"},
+		}},
+		{Name: "audit", Columns: []schema.Column{{Name: "email", DataType: "varchar"}}},
+	}}
+	want := map[string]string{
+		"email":            PIIEmail,
+		"first_name":       PIIName,
+		"ssn":              PIIGovernment,
+		"date_of_birth":    PIIBirthDate,
+		"ip_address":       PIINetwork,
+		"last_seen":        PIINetwork,
+		"shipping_address": PIIAddress,
+	}
+
+	got := ClassifyPII(s, []string{"users"})
+	if len(got) != len(want) {
+		t.Errorf("classified %d columns, want %d: %+v", len(got), len(want), got)
+	}
+	for _, p := range got {
+		if p.Table != "users" || want[p.Column] != p.Category {
+			t.Errorf("%s.%s classified %q, want %q", p.Table, p.Column, p.Category, want[p.Column])
+		}
+		if err := p.Validate(); err != nil {
+			t.Errorf("suggested mask is invalid: %v", err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// Categories of personal data the classifier recognizes.
+const (
+	PIICredential = "credential"
+	PIIGovernment = "government_id"
+	PIIFinancial  = "financial"
+	PIIEmail      = "email"
+	PIIPhone      = "phone"
+	PIIName       = "name"
+	PIIBirthDate  = "birth_date"
+	PIIAddress    = "address"
+	PIINetwork    = "network"
+)
+
+// PIIColumn is a column that looks like personal data, with the mask
+// suggested for it. Accepted is set when the mapping masks the column
+// wherever its table is migrated; Operation and Value are then the mask in
+// the mapping.
+type PIIColumn struct {
+	Table     string `json:"table"`
+	Column    string `json:"column"`
+	DataType  string `json:"data_type"`
+	Category  string `json:"category"`
+	Reason    string `json:"reason"`
+	Operation string `json:"operation"`
+	Value     string `json:"value,omitempty"`
+	Accepted  bool   `json:"accepted"`
+}
+
+// Transformation returns the masking transformation for the column.
+func (p PIIColumn) Transformation() mapping.Transformation {
+	return mapping.Transformation{SourceField: p.Column, Operation: p.Operation, Value: p.Value}
+}
+
+// Validate checks the column's mask.
+func (p PIIColumn) Validate() error {
+	if !IsMask(p.Operation) {
+		return fmt.Errorf("%s.%s: %q is not a masking operation (use hash, redact, synthesize or nullify)", p.Table, p.Column, p.Operation)
+	}
+	if err := Validate(p.Transformation()); err != nil {
+		return fmt.Errorf("%s.%s: %w", p.Table, p.Column, err)
+	}
+	return nil
+}
+
+// piiRule suggests a mask for columns whose name holds one of the words,
+// matched against the whole name or a run of its underscore-separated words.
+type piiRule struct {
+	category  string
+	words     []string
+	operation string
+	value     string
+}
+
+// piiRules are tried in order; network comes before address so ip_address
+// is not taken for a postal address.
+var piiRules = []piiRule{
+	{PIICredential, []string{"password", "passwd", "pwd", "secret", "token", "api_key", "apikey"}, OpNullify, ""},
+	{PIIGovernment, []string{"ssn", "social_security", "national_id", "tax_id", "taxid", "passport", "driver_license", "drivers_license"}, OpHash, ""},
+	{PIIFinancial, []string{"credit_card", "card_number", "cc_number", "iban", "account_number", "routing_number", "cvv"}, OpRedact, ""},
+	{PIIEmail, []string{"email", "e_mail"}, OpSynthesize, SynthEmail},
+	{PIIPhone, []string{"phone", "mobile", "fax", "telephone", "cell"}, OpSynthesize, SynthPhone},
+	{PIIName, []string{"first_name", "firstname", "given_name", "forename", "fname"}, OpSynthesize, SynthFirstName},
+	{PIIName, []string{"last_name", "lastname", "surname", "family_name", "lname"}, OpSynthesize, SynthLastName},
+	{PIIName, []string{"full_name", "fullname", "contact_name", "customer_name", "display_name", "legal_name"}, OpSynthesize, SynthName},
+	{PIIBirthDate, []string{"dob", "birth_date", "birthdate", "date_of_birth", "birthday"}, OpNullify, ""},
+	{PIINetwork, []string{"ip_address", "ip_addr", "ip"}, OpRedact, ""},
+	{PIIAddress, []string{"address", "street", "addr", "postcode", "postal_code", "zip_code"}, OpRedact, ""},
+}
+
+// notPII are last words of a column name that describe something about the
+// data rather than hold it, as in email_verified or phone_count.
+var notPII = map[string]bool{
+	"id": true, "count": true, "verified": true, "type": true, "status": true,
+	"flag": true, "enabled": true, "hash": true, "length": true,
+}
+
+// ClassifyPII suggests masks for the columns of tables that look like
+// personal data, by name (email, ssn, first_name) and by type (inet).
+// Boolean columns, and date and time columns other than birth dates, are
+// never suggested.
+func ClassifyPII(s *schema.Schema, tables []string) []PIIColumn {
+	if s == nil {
+		return nil
+	}
+	want := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		want[t] = true
+	}
+	var out []PIIColumn
+	for _, t := range s.Tables {
+		if !want[t.Name] {
+			continue
+		}
+		for _, c := range t.Columns {
+			if p, ok := classifyColumn(c); ok {
+				p.Table = t.Name
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+func classifyColumn(c schema.Column) (PIIColumn, bool) {
+	dt := strings.ToLower(c.DataType)
+	p := PIIColumn{Column: c.Name, DataType: c.DataType}
+	switch {
+	case strings.Contains(dt, "bool"):
+		return p, false
+	case dt == "inet" || dt == "cidr" || dt == "macaddr":
+		p.Category, p.Operation = PIINetwork, OpRedact
+		p.Reason = "data type " + c.DataType
+		return p, true
+	}
+	name := strings.ToLower(c.Name)
+	words := strings.Split(name, "_")
+	if notPII[words[len(words)-1]] {
+		return p, false
+	}
+	temporal := strings.Contains(dt, "date") || strings.Contains(dt, "time")
+	for _, r := range piiRules {
+		for _, w := range r.words {
+			if !nameHas(name, words, w) {
+				continue
+			}
+			if temporal && r.category != PIIBirthDate {
+				return p, false
+			}
+			p.Category, p.Operation, p.Value = r.category, r.operation, r.value
+			p.Reason = fmt.Sprintf("column name contains %q", w)
+			return p, true
+		}
+	}
+	return p, false
+}
+
+// nameHas reports whether a lowercase column name is word, or holds it as a
+// run of its underscore-separated words.
+func nameHas(name string, words []string, word string) bool {
+	if name == word {
+		return true
+	}
+	parts := strings.Split(word, "_")
+	for i := 0; i+len(parts) <= len(words); i++ {
+		match := true
+		for j, p := range parts {
+			if words[i+j] != p {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
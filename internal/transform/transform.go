@@ -3,9 +3,11 @@ package transform
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
 )
 
 // Operation types for transformations.
@@ -26,17 +28,28 @@ var validOps = map[string]bool{
 	OpFilter:  true,
 	OpDefault: true,
 	OpExclude: true,
+
+	OpHash:       true,
+	OpRedact:     true,
+	OpSynthesize: true,
+	OpNullify:    true,
 }
 
 // operationOrder defines the execution ordering for transformations.
-// Filters first (reduce data), then computes, renames, casts, defaults, excludes last.
+// Filters first (reduce data), then masks, so nothing computed sees a
+// column's original value, then computes, renames, casts, defaults,
+// excludes last.
 var operationOrder = map[string]int{
-	OpFilter:  0,
-	OpCompute: 1,
-	OpRename:  2,
-	OpCast:    3,
-	OpDefault: 4,
-	OpExclude: 5,
+	OpFilter:     0,
+	OpHash:       1,
+	OpRedact:     1,
+	OpSynthesize: 1,
+	OpNullify:    1,
+	OpCompute:    2,
+	OpRename:     3,
+	OpCast:       4,
+	OpDefault:    5,
+	OpExclude:    6,
 }
 
 // Validate checks that a single transformation is valid.
@@ -60,6 +73,9 @@ func Validate(t mapping.Transformation) error {
 		if t.Expression == "" {
 			return fmt.Errorf("compute: expression is required")
 		}
+		if _, err := ParseExpression(t.Expression); err != nil {
+			return fmt.Errorf("compute %s: %w", t.TargetField, err)
+		}
 	case OpCast:
 		if t.SourceField == "" {
 			return fmt.Errorf("cast: source_field is required")
@@ -82,6 +98,8 @@ func Validate(t mapping.Transformation) error {
 		if t.SourceField == "" {
 			return fmt.Errorf("exclude: source_field is required")
 		}
+	case OpHash, OpRedact, OpSynthesize, OpNullify:
+		return validateMask(t)
 	}
 
 	return nil
@@ -122,8 +140,12 @@ func ToPySpark(t mapping.Transformation, dfName string) string {
 		return fmt.Sprintf(`%s = %s.withColumnRenamed("%s", "%s")`,
 			dfName, dfName, t.SourceField, t.TargetField)
 	case OpCompute:
+		sql := t.Expression
+		if x, err := ParseExpression(t.Expression); err == nil {
+			sql = x.SQL()
+		}
 		return fmt.Sprintf(`%s = %s.withColumn("%s", expr("%s"))`,
-			dfName, dfName, t.TargetField, t.Expression)
+			dfName, dfName, t.TargetField, strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(sql))
 	case OpCast:
 		return fmt.Sprintf(`%s = %s.withColumn("%s", col("%s").cast("%s"))`,
 			dfName, dfName, t.SourceField, t.SourceField, t.TargetType)
@@ -136,13 +158,17 @@ func ToPySpark(t mapping.Transformation, dfName string) string {
 	case OpExclude:
 		return fmt.Sprintf(`%s = %s.drop("%s")`,
 			dfName, dfName, t.SourceField)
+	case OpHash, OpRedact, OpSynthesize, OpNullify:
+		return fmt.Sprintf(`%s = mask_column(%s, "%s", "%s", %s)`,
+			dfName, dfName, t.SourceField, t.Operation, pyString(t.Value))
 	default:
 		return fmt.Sprintf("# unknown operation: %s", t.Operation)
 	}
 }
 
 // ToPySparkAll generates ordered PySpark code snippets for all transformations.
-// Transformations are sorted by operation order: filter, compute, rename, cast, default, exclude.
+// Transformations are sorted by operation order: filter, masks, compute,
+// rename, cast, default, exclude.
 func ToPySparkAll(transforms []mapping.Transformation, dfName string) []string {
 	// Sort by operation order
 	sorted := make([]mapping.Transformation, len(transforms))
@@ -168,6 +194,11 @@ func formatLiteral(value string) string {
 	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(value, `"`, `\"`))
 }
 
+// pyString formats a value as a Python string literal.
+func pyString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
 func isNumber(s string) bool {
 	if s == "" {
 		return false
@@ -187,3 +218,229 @@ func isNumber(s string) bool {
 	}
 	return true
 }
+
+// CheckColumns reports compute expressions that read a column missing from
+// columns, the table's columns before its transformations run, and masks
+// of a missing column, which would leave the column meant unmasked. A
+// compute may read the fields of the computes before it.
+func CheckColumns(transforms []mapping.Transformation, columns []string) error {
+	known := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		known[strings.ToLower(c)] = true
+	}
+	for _, t := range transforms {
+		if IsMask(t.Operation) && !known[strings.ToLower(t.SourceField)] {
+			return fmt.Errorf("%s: column %s does not exist", t.Operation, t.SourceField)
+		}
+	}
+	for _, t := range transforms {
+		if t.Operation != OpCompute {
+			continue
+		}
+		x, err := ParseExpression(t.Expression)
+		if err != nil {
+			return fmt.Errorf("compute %s: %w", t.TargetField, err)
+		}
+		for _, c := range x.Columns() {
+			if !known[strings.ToLower(c)] {
+				return fmt.Errorf("compute %s: column %s does not exist", t.TargetField, c)
+			}
+		}
+		known[strings.ToLower(t.TargetField)] = true
+	}
+	return nil
+}
+
+// ValidateMapping validates the transformations of every mapped and
+// embedded table, checking compute expressions against the table's
+// columns when the schema has it.
+func ValidateMapping(m *mapping.Mapping, s *schema.Schema) error {
+	check := func(table string, transforms []mapping.Transformation) error {
+		if err := ValidateAll(transforms); err != nil {
+			return err
+		}
+		if cols := mapping.TableColumns(s, table, nil); cols != nil {
+			return CheckColumns(transforms, cols)
+		}
+		return nil
+	}
+	var checkEmbedded func(embs []mapping.Embedded) error
+	checkEmbedded = func(embs []mapping.Embedded) error {
+		for _, e := range embs {
+			if err := check(e.SourceTable, e.Transformations); err != nil {
+				return fmt.Errorf("embedded %s: %w", e.SourceTable, err)
+			}
+			if err := checkEmbedded(e.Embedded); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, c := range m.Collections {
+		if err := check(c.SourceTable, c.Transformations); err != nil {
+			return fmt.Errorf("collection %s: %w", c.Name, err)
+		}
+		if err := checkEmbedded(c.Embedded); err != nil {
+			return fmt.Errorf("collection %s: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// Computed is a compute transformation parsed for the native mover.
+type Computed struct {
+	Field string
+	Expr  *Expression
+}
+
+// ComputedFields parses the compute transformations, in order, checking
+// the native mover can evaluate each.
+func ComputedFields(transforms []mapping.Transformation) ([]Computed, error) {
+	var out []Computed
+	for _, t := range transforms {
+		if t.Operation != OpCompute {
+			continue
+		}
+		x, err := ParseExpression(t.Expression)
+		if err == nil {
+			err = x.Native()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("compute %s: %w", t.TargetField, err)
+		}
+		out = append(out, Computed{Field: t.TargetField, Expr: x})
+	}
+	return out, nil
+}
+
+// ApplyComputed sets the computed fields on a source row, in order, so a
+// compute sees the fields of those before it.
+func ApplyComputed(row map[string]interface{}, computed []Computed) error {
+	for _, c := range computed {
+		v, err := c.Expr.Eval(row)
+		if err != nil {
+			return fmt.Errorf("compute %s: %w", c.Field, err)
+		}
+		row[c.Field] = v
+	}
+	return nil
+}
+
+// Pipeline is a table's transformations prepared for the native mover. It
+// runs them on each source row in the order ToPySparkAll gives Spark, so
+// both movers make the same documents from a mapping.
+type Pipeline struct {
+	steps []step
+}
+
+// step is one transformation of a pipeline, with its expression parsed
+// for filters, computes and casts and its value for defaults.
+type step struct {
+	t     mapping.Transformation
+	expr  node
+	value interface{}
+}
+
+// NewPipeline prepares transformations for the native mover, returning an
+// error for any it cannot run as Spark would: filters and computes using
+// functions it cannot evaluate, and casts to types it cannot make.
+func NewPipeline(transforms []mapping.Transformation) (*Pipeline, error) {
+	sorted := make([]mapping.Transformation, len(transforms))
+	copy(sorted, transforms)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return operationOrder[sorted[i].Operation] < operationOrder[sorted[j].Operation]
+	})
+
+	p := &Pipeline{steps: make([]step, 0, len(sorted))}
+	for _, t := range sorted {
+		s := step{t: t}
+		switch t.Operation {
+		case OpFilter, OpCompute:
+			x, err := ParseExpression(t.Expression)
+			if err == nil {
+				err = x.Native()
+			}
+			if err != nil {
+				if t.Operation == OpFilter {
+					return nil, fmt.Errorf("filter %q: %w", t.Expression, err)
+				}
+				return nil, fmt.Errorf("compute %s: %w", t.TargetField, err)
+			}
+			s.expr = x.root
+		case OpCast:
+			typ := strings.ToUpper(t.TargetType)
+			if castKind(typ) == "" {
+				return nil, fmt.Errorf("cast %s: cast to %s cannot be evaluated by the native mover", t.SourceField, t.TargetType)
+			}
+			s.expr = &castNode{operand: &columnNode{name: t.SourceField}, typ: typ}
+		case OpDefault:
+			s.value = defaultValue(t.Value)
+		}
+		p.steps = append(p.steps, s)
+	}
+	return p, nil
+}
+
+// Apply transforms a source row in place, reporting false for a row a
+// filter drops; Spark keeps only the rows a filter is true for. Renames,
+// casts and defaults of columns the row lacks leave it alone.
+func (p *Pipeline) Apply(row map[string]interface{}) (bool, error) {
+	for _, s := range p.steps {
+		t := s.t
+		switch t.Operation {
+		case OpFilter:
+			v, err := s.expr.eval(row)
+			if err != nil {
+				return false, fmt.Errorf("filter %q: %w", t.Expression, err)
+			}
+			if b, ok := v.(bool); !ok || !b {
+				return false, nil
+			}
+		case OpHash, OpRedact, OpSynthesize, OpNullify:
+			if v, ok := row[t.SourceField]; ok {
+				row[t.SourceField] = MaskValue(t, v)
+			}
+		case OpCompute:
+			v, err := s.expr.eval(row)
+			if err != nil {
+				return false, fmt.Errorf("compute %s: %w", t.TargetField, err)
+			}
+			row[t.TargetField] = v
+		case OpRename:
+			if v, ok := row[t.SourceField]; ok {
+				delete(row, t.SourceField)
+				row[t.TargetField] = v
+			}
+		case OpCast:
+			if _, ok := row[t.SourceField]; !ok {
+				continue
+			}
+			v, err := s.expr.eval(row)
+			if err != nil {
+				return false, fmt.Errorf("cast %s: %w", t.SourceField, err)
+			}
+			row[t.SourceField] = v
+		case OpDefault:
+			if v, ok := row[t.SourceField]; ok && v == nil {
+				row[t.SourceField] = s.value
+			}
+		case OpExclude:
+			delete(row, t.SourceField)
+		}
+	}
+	return true, nil
+}
+
+// defaultValue is the value a default transformation sets, a number when
+// it looks like one, as formatLiteral writes it for Spark.
+func defaultValue(value string) interface{} {
+	if isNumber(value) {
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
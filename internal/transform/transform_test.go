@@ -235,3 +235,66 @@ func TestIsNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestPipeline_Apply(t *testing.T) {
+	p, err := NewPipeline([]mapping.Transformation{
+		{Operation: OpExclude, SourceField: "temp"},
+		{Operation: OpRename, SourceField: "qty", TargetField: "quantity"},
+		{Operation: OpFilter, Expression: "status <> 'deleted'"},
+		{Operation: OpCompute, TargetField: "total", Expression: "qty * price"},
+		{Operation: OpDefault, SourceField: "discount", Value: "0"},
+		{Operation: OpDefault, SourceField: "note", Value: "none"},
+		{Operation: OpCast, SourceField: "price", TargetType: "int"},
+		{Operation: OpRedact, SourceField: "email", Value: "x"},
+	})
+	if err != nil {
+		t.Fatalf("NewPipeline() error: %v", err)
+	}
+
+	row := map[string]interface{}{
+		"status": "open", "qty": int64(2), "price": 2.5, "discount": nil,
+		"note": nil, "temp": "t", "email": "a@b.c",
+	}
+	keep, err := p.Apply(row)
+	if err != nil || !keep {
+		t.Fatalf("Apply() = %v, %v; want the row kept", keep, err)
+	}
+	want := map[string]interface{}{
+		"status": "open", "quantity": int64(2), "price": int64(2), "total": 5.0,
+		"discount": int64(0), "note": "none", "email": "x",
+	}
+	if len(row) != len(want) {
+		t.Errorf("row = %v, want %v", row, want)
+	}
+	for k, v := range want {
+		if row[k] != v {
+			t.Errorf("row[%s] = %#v, want %#v", k, row[k], v)
+		}
+	}
+
+	// Filters keep only rows they are true for; NULL drops the row
+	for _, status := range []interface{}{"deleted", nil} {
+		keep, err := p.Apply(map[string]interface{}{"status": status, "qty": int64(1), "price": 1.0})
+		if err != nil || keep {
+			t.Errorf("Apply(status %v) = %v, %v; want the row dropped", status, keep, err)
+		}
+	}
+}
+
+func TestNewPipeline_Unsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		t    mapping.Transformation
+	}{
+		{"filter function", mapping.Transformation{Operation: OpFilter, Expression: "md5(a) = 'x'"}},
+		{"compute function", mapping.Transformation{Operation: OpCompute, TargetField: "x", Expression: "md5(a)"}},
+		{"cast type", mapping.Transformation{Operation: OpCast, SourceField: "a", TargetType: "binary"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPipeline([]mapping.Transformation{tt.t}); err == nil {
+				t.Error("NewPipeline() should refuse what the native mover cannot run")
+			}
+		})
+	}
+}
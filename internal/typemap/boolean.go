@@ -0,0 +1,209 @@
+package typemap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+// DefaultBooleanSampleSize is the number of rows sampled per table to
+// decide whether its flag-shaped columns hold booleans.
+const DefaultBooleanSampleSize = 1000
+
+// Values read as true or false, compared case-insensitively.
+var (
+	trueWords  = map[string]bool{"Y": true, "YES": true, "T": true, "TRUE": true, "1": true}
+	falseWords = map[string]bool{"N": true, "NO": true, "F": true, "FALSE": true, "0": true}
+)
+
+// BooleanColumn is a column that stores a flag as text or a number, such
+// as CHAR(1) Y/N or NUMBER(1) 0/1, with the sampled values read as true and
+// false. Accepted is set when the mapping converts it to a BSON boolean.
+type BooleanColumn struct {
+	Table       string   `json:"table"`
+	Column      string   `json:"column"`
+	DataType    string   `json:"data_type"`
+	Numeric     bool     `json:"numeric"`
+	TrueValues  []string `json:"true_values"`
+	FalseValues []string `json:"false_values"`
+	Sampled     int      `json:"sampled"`
+	Accepted    bool     `json:"accepted"`
+}
+
+// Expression returns the compute expression that rewrites the column as a
+// boolean. Text is compared trimmed and upper-cased, so CHAR padding and
+// case do not matter; values read as neither become null.
+func (b BooleanColumn) Expression() string {
+	col := "`" + strings.ReplaceAll(b.Column, "`", "``") + "`"
+	list := func(values []string) string {
+		out := make([]string, len(values))
+		for i, v := range values {
+			if b.Numeric {
+				out[i] = v
+			} else {
+				out[i] = "'" + v + "'"
+			}
+		}
+		return strings.Join(out, ", ")
+	}
+	if !b.Numeric {
+		col = "upper(trim(" + col + "))"
+	}
+	expr := "CASE"
+	if len(b.TrueValues) > 0 {
+		expr += fmt.Sprintf(" WHEN %s IN (%s) THEN TRUE", col, list(b.TrueValues))
+	}
+	if len(b.FalseValues) > 0 {
+		expr += fmt.Sprintf(" WHEN %s IN (%s) THEN FALSE", col, list(b.FalseValues))
+	}
+	return expr + " END"
+}
+
+// Validate checks that the values read as true and false are ones the
+// conversion understands, and digits for a numeric column.
+func (b BooleanColumn) Validate() error {
+	if len(b.TrueValues)+len(b.FalseValues) == 0 {
+		return fmt.Errorf("%s.%s: no true or false values", b.Table, b.Column)
+	}
+	check := func(values []string, words map[string]bool, kind string) error {
+		for _, v := range values {
+			if !words[v] || (b.Numeric && v != "0" && v != "1") {
+				return fmt.Errorf("%s.%s: %q cannot be read as %s", b.Table, b.Column, v, kind)
+			}
+		}
+		return nil
+	}
+	if err := check(b.TrueValues, trueWords, "true"); err != nil {
+		return err
+	}
+	return check(b.FalseValues, falseWords, "false")
+}
+
+// IsBooleanShaped reports whether a column's type could hold a flag: a one
+// character string, a one digit integer, or an enum whose labels all read
+// as true or false.
+func IsBooleanShaped(c schema.Column) bool {
+	switch strings.ToLower(c.DataType) {
+	case "char", "character", "bpchar", "nchar", "varchar", "character varying", "varchar2", "nvarchar2":
+		return c.MaxLength != nil && *c.MaxLength == 1
+	case "number", "numeric", "decimal":
+		return c.Precision != nil && *c.Precision == 1 && (c.Scale == nil || *c.Scale == 0)
+	case Enum:
+		if len(c.EnumValues) == 0 {
+			return false
+		}
+		_, _, ok := ClassifyBoolean(stringValues(c.EnumValues))
+		return ok
+	}
+	return false
+}
+
+// ClassifyBoolean splits sampled values into those read as true and false,
+// upper-cased and sorted. ok is false when a value reads as neither or no
+// value is set. Nulls are ignored.
+func ClassifyBoolean(values []interface{}) (trueValues, falseValues []string, ok bool) {
+	seen := make(map[string]bool)
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		var s string
+		if b, isBytes := v.([]byte); isBytes {
+			s = string(b)
+		} else {
+			s = fmt.Sprint(v)
+		}
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		switch {
+		case trueWords[s]:
+			trueValues = append(trueValues, s)
+		case falseWords[s]:
+			falseValues = append(falseValues, s)
+		default:
+			return nil, nil, false
+		}
+	}
+	sort.Strings(trueValues)
+	sort.Strings(falseValues)
+	return trueValues, falseValues, len(seen) > 0
+}
+
+// DetectBooleans samples the boolean-shaped columns of the named tables and
+// returns those whose values all read as true or false, ordered by table
+// and column. A sampleSize of zero uses DefaultBooleanSampleSize.
+func DetectBooleans(ctx context.Context, r source.Reader, s *schema.Schema, tables []string, sampleSize int) ([]BooleanColumn, error) {
+	if sampleSize <= 0 {
+		sampleSize = DefaultBooleanSampleSize
+	}
+	want := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		want[t] = true
+	}
+	var out []BooleanColumn
+	if s == nil {
+		return out, nil
+	}
+	for _, t := range s.Tables {
+		if !want[t.Name] {
+			continue
+		}
+		var cols []schema.Column
+		var names []string
+		for _, c := range t.Columns {
+			if IsBooleanShaped(c) {
+				cols = append(cols, c)
+				names = append(names, c.Name)
+			}
+		}
+		if len(cols) == 0 {
+			continue
+		}
+		rows, err := r.SampleRows(ctx, t.Name, names, sampleSize)
+		if err != nil {
+			return nil, fmt.Errorf("sampling %s: %w", t.Name, err)
+		}
+		for _, c := range cols {
+			values := make([]interface{}, len(rows))
+			for i, row := range rows {
+				values[i] = row[c.Name]
+			}
+			trueValues, falseValues, ok := ClassifyBoolean(values)
+			if !ok {
+				continue
+			}
+			dt := strings.ToLower(c.DataType)
+			out = append(out, BooleanColumn{
+				Table:       t.Name,
+				Column:      c.Name,
+				DataType:    c.DataType,
+				Numeric:     dt == "number" || dt == "numeric" || dt == "decimal",
+				TrueValues:  trueValues,
+				FalseValues: falseValues,
+				Sampled:     len(rows),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Table != out[j].Table {
+			return out[i].Table < out[j].Table
+		}
+		return out[i].Column < out[j].Column
+	})
+	return out, nil
+}
+
+func stringValues(ss []string) []interface{} {
+	out := make([]interface{}, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
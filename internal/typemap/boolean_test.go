@@ -0,0 +1,130 @@
+package typemap
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestIsBooleanShaped(t *testing.T) {
+	tests := []struct {
+		name string
+		col  schema.Column
+		want bool
+	}{
+		{"char(1)", schema.Column{DataType: "CHAR", MaxLength: intPtr(1)}, true},
+		{"character(1)", schema.Column{DataType: "character", MaxLength: intPtr(1)}, true},
+		{"char(2)", schema.Column{DataType: "CHAR", MaxLength: intPtr(2)}, false},
+		{"char without length", schema.Column{DataType: "CHAR"}, false},
+		{"number(1)", schema.Column{DataType: "NUMBER", Precision: intPtr(1), Scale: intPtr(0)}, true},
+		{"number(1,1)", schema.Column{DataType: "NUMBER", Precision: intPtr(1), Scale: intPtr(1)}, false},
+		{"number(10)", schema.Column{DataType: "NUMBER", Precision: intPtr(10)}, false},
+		{"true/false enum", schema.Column{DataType: Enum, EnumValues: []string{"true", "false"}}, true},
+		{"status enum", schema.Column{DataType: Enum, EnumValues: []string{"open", "closed"}}, false},
+		{"boolean", schema.Column{DataType: "boolean"}, false},
+		{"text", schema.Column{DataType: "text"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBooleanShaped(tt.col); got != tt.want {
+				t.Errorf("IsBooleanShaped = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyBoolean(t *testing.T) {
+	tests := []struct {
+		name             string
+		values           []interface{}
+		wantTrue, wantFa []string
+		wantOK           bool
+	}{
+		{"Y/N with nulls", []interface{}{"Y", "n", nil, "y ", "N"}, []string{"Y"}, []string{"N"}, true},
+		{"digits", []interface{}{int64(1), int64(0), "1"}, []string{"1"}, []string{"0"}, true},
+		{"bytes", []interface{}{[]byte("T"), []byte("F")}, []string{"T"}, []string{"F"}, true},
+		{"one side only", []interface{}{"N", "N"}, nil, []string{"N"}, true},
+		{"other value", []interface{}{"Y", "N", "X"}, nil, nil, false},
+		{"digit 2", []interface{}{int64(1), int64(2)}, nil, nil, false},
+		{"only nulls", []interface{}{nil, nil}, nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trueValues, falseValues, ok := ClassifyBoolean(tt.values)
+			if ok != tt.wantOK || !reflect.DeepEqual(trueValues, tt.wantTrue) || !reflect.DeepEqual(falseValues, tt.wantFa) {
+				t.Errorf("ClassifyBoolean = %v, %v, %v; want %v, %v, %v",
+					trueValues, falseValues, ok, tt.wantTrue, tt.wantFa, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBooleanColumn_Expression(t *testing.T) {
+	text := BooleanColumn{Column: "active", TrueValues: []string{"Y"}, FalseValues: []string{"N", "NO"}}
+	if got, want := text.Expression(), "CASE WHEN upper(trim(`active`)) IN ('Y') THEN TRUE WHEN upper(trim(`active`)) IN ('N', 'NO') THEN FALSE END"; got != want {
+		t.Errorf("text expression = %s, want %s", got, want)
+	}
+	num := BooleanColumn{Column: "IS_DELETED", Numeric: true, TrueValues: []string{"1"}, FalseValues: []string{"0"}}
+	if got, want := num.Expression(), "CASE WHEN `IS_DELETED` IN (1) THEN TRUE WHEN `IS_DELETED` IN (0) THEN FALSE END"; got != want {
+		t.Errorf("numeric expression = %s, want %s", got, want)
+	}
+}
+
+func TestBooleanColumn_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		col     BooleanColumn
+		wantErr bool
+	}{
+		{"Y/N", BooleanColumn{TrueValues: []string{"Y"}, FalseValues: []string{"N"}}, false},
+		{"numeric 1/0", BooleanColumn{Numeric: true, TrueValues: []string{"1"}, FalseValues: []string{"0"}}, false},
+		{"numeric Y", BooleanColumn{Numeric: true, TrueValues: []string{"Y"}}, true},
+		{"swapped", BooleanColumn{TrueValues: []string{"N"}}, true},
+		{"injected", BooleanColumn{TrueValues: []string{"Y') OR (1=1"}}, true},
+		{"empty", BooleanColumn{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.col.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDetectBooleans(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "USERS", Columns: []schema.Column{
+			{Name: "ID", DataType: "NUMBER", Precision: intPtr(10)},
+			{Name: "ACTIVE", DataType: "CHAR", MaxLength: intPtr(1)},
+			{Name: "GRADE", DataType: "CHAR", MaxLength: intPtr(1)},
+			{Name: "DELETED", DataType: "NUMBER", Precision: intPtr(1), Scale: intPtr(0)},
+		}},
+		{Name: "AUDIT", Columns: []schema.Column{{Name: "OK", DataType: "CHAR", MaxLength: intPtr(1)}}},
+	}}
+	r := &source.MockReader{Samples: map[string][]map[string]interface{}{
+		"USERS": {
+			{"ACTIVE": "Y", "GRADE": "A", "DELETED": "0"},
+			{"ACTIVE": "N", "GRADE": "B", "DELETED": "1"},
+			{"ACTIVE": nil, "GRADE": "C", "DELETED": "0"},
+		},
+		"AUDIT": {{"OK": "Y"}},
+	}}
+
+	got, err := DetectBooleans(context.Background(), r, s, []string{"USERS"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BooleanColumn{
+		{Table: "USERS", Column: "ACTIVE", DataType: "CHAR", TrueValues: []string{"Y"}, FalseValues: []string{"N"}, Sampled: 3},
+		{Table: "USERS", Column: "DELETED", DataType: "NUMBER", Numeric: true, TrueValues: []string{"1"}, FalseValues: []string{"0"}, Sampled: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectBooleans = %+v\nwant %+v", got, want)
+	}
+}
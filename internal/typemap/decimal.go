@@ -0,0 +1,232 @@
+package typemap
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// MaxDecimalPrecision is the number of significant digits a Decimal128
+// holds.
+const MaxDecimalPrecision = 34
+
+// maxLongDigits is the number of digits every NumberLong holds.
+const maxLongDigits = 18
+
+// fixedPointTypes are the source types whose precision and scale decide
+// the BSON type of their columns: Postgres numeric and Oracle NUMBER.
+var fixedPointTypes = map[string]bool{
+	"numeric": true,
+	"decimal": true,
+	"NUMBER":  true,
+}
+
+// IsFixedPoint reports whether columns of the source type hold exact
+// numbers of a declared precision and scale.
+func IsFixedPoint(dataType string) bool {
+	return fixedPointTypes[dataType]
+}
+
+// resolveFixedPoint returns the BSON type of a fixed-point column from its
+// precision and scale: a Decimal128 when it holds fractions or more digits
+// than a NumberLong, a NumberLong otherwise. An unconstrained column may
+// hold fractions and is a Decimal128; one declared without a precision but
+// with no fractional digits, such as an Oracle INTEGER, keeps its type's
+// mapping. A type the user overrode keeps the override.
+func (tm *TypeMap) resolveFixedPoint(c schema.Column) BSONType {
+	switch {
+	case tm.IsOverridden(c.DataType):
+		return tm.Resolve(c.DataType)
+	case c.Scale != nil && *c.Scale > 0:
+		return BSONDecimal128
+	case c.Precision != nil:
+		scale := 0
+		if c.Scale != nil {
+			scale = *c.Scale
+		}
+		// A negative scale rounds to tens, hundreds and so on, adding
+		// digits before the point
+		if *c.Precision-scale <= maxLongDigits {
+			return BSONNumberLong
+		}
+		return BSONDecimal128
+	case c.Scale == nil:
+		return BSONDecimal128
+	}
+	return tm.Resolve(c.DataType)
+}
+
+// DecimalColumn is a fixed-point column with the BSON type its precision
+// and scale resolve to. Warning says why the type may not hold every
+// value exactly.
+type DecimalColumn struct {
+	Table     string   `json:"table"`
+	Column    string   `json:"column"`
+	DataType  string   `json:"data_type"`
+	Precision *int     `json:"precision,omitempty"`
+	Scale     *int     `json:"scale,omitempty"`
+	BSONType  BSONType `json:"bson_type"`
+	Warning   string   `json:"warning,omitempty"`
+}
+
+// Declared returns the column's type with its precision and scale, such as
+// numeric(12,2).
+func (d DecimalColumn) Declared() string {
+	switch {
+	case d.Precision != nil && d.Scale != nil:
+		return fmt.Sprintf("%s(%d,%d)", d.DataType, *d.Precision, *d.Scale)
+	case d.Precision != nil:
+		return fmt.Sprintf("%s(%d)", d.DataType, *d.Precision)
+	}
+	return d.DataType
+}
+
+// DecimalColumns returns every fixed-point column of the schema with the
+// BSON type it is written as, ordered by table and column.
+func (tm *TypeMap) DecimalColumns(s *schema.Schema) []DecimalColumn {
+	var out []DecimalColumn
+	if s == nil {
+		return out
+	}
+	for _, t := range s.Tables {
+		for _, c := range t.Columns {
+			if !IsFixedPoint(c.DataType) {
+				continue
+			}
+			d := DecimalColumn{
+				Table: t.Name, Column: c.Name, DataType: c.DataType,
+				Precision: c.Precision, Scale: c.Scale, BSONType: tm.ResolveColumn(c),
+			}
+			d.Warning = decimalWarning(d)
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Table != out[j].Table {
+			return out[i].Table < out[j].Table
+		}
+		return out[i].Column < out[j].Column
+	})
+	return out
+}
+
+// DecimalWarnings returns the warnings of the schema's fixed-point columns,
+// each naming its column.
+func (tm *TypeMap) DecimalWarnings(s *schema.Schema) []string {
+	var out []string
+	for _, d := range tm.DecimalColumns(s) {
+		if d.Warning != "" {
+			out = append(out, fmt.Sprintf("%s.%s: %s", d.Table, d.Column, d.Warning))
+		}
+	}
+	return out
+}
+
+func decimalWarning(d DecimalColumn) string {
+	fraction := d.Scale != nil && *d.Scale > 0
+	switch d.BSONType {
+	case BSONDecimal128:
+		if d.Precision != nil && *d.Precision > MaxDecimalPrecision {
+			return fmt.Sprintf("%s allows %d digits, more than the %d a Decimal128 holds; values with more significant digits fail to migrate",
+				d.Declared(), *d.Precision, MaxDecimalPrecision)
+		}
+	case BSONDouble:
+		if fraction || d.Scale == nil {
+			return fmt.Sprintf("%s is written as a Double, which rounds decimal fractions; sums drift from the source, map it to Decimal128", d.Declared())
+		}
+	case BSONNumberLong:
+		if fraction {
+			return fmt.Sprintf("%s holds fractions a NumberLong cannot; rows with one fail to migrate, map it to Decimal128", d.Declared())
+		}
+	}
+	return ""
+}
+
+// NumberTypes returns, by table and column, the BSON type each fixed-point
+// column of the schema is written as.
+func (tm *TypeMap) NumberTypes(s *schema.Schema) map[string]map[string]BSONType {
+	out := make(map[string]map[string]BSONType)
+	for _, d := range tm.DecimalColumns(s) {
+		if out[d.Table] == nil {
+			out[d.Table] = make(map[string]BSONType)
+		}
+		out[d.Table][d.Column] = d.BSONType
+	}
+	return out
+}
+
+// ConvertNumber converts a value read from a fixed-point column to the BSON
+// type the column is written as. Drivers return these values as text or as
+// types of their own, which are read through their text; nil and values of
+// other types are kept. A value the type cannot hold exactly is an error.
+func ConvertNumber(v interface{}, t BSONType) (interface{}, error) {
+	if val, ok := v.(driver.Valuer); ok {
+		dv, err := val.Value()
+		if err != nil {
+			return nil, err
+		}
+		v = dv
+	}
+	if v == nil {
+		return nil, nil
+	}
+	text, ok := numberText(v)
+	if !ok {
+		return v, nil
+	}
+	switch t {
+	case BSONDecimal128:
+		d, err := bson.ParseDecimal128(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s does not fit the %d significant digits of a Decimal128", text, MaxDecimalPrecision)
+		}
+		return d, nil
+	case BSONNumberLong:
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n, nil
+		}
+		r, ok := new(big.Rat).SetString(text)
+		if !ok || !r.IsInt() || !r.Num().IsInt64() {
+			return nil, fmt.Errorf("%s is not a whole number a NumberLong holds", text)
+		}
+		return r.Num().Int64(), nil
+	case BSONDouble:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a number", text)
+		}
+		return f, nil
+	case BSONString:
+		return text, nil
+	}
+	return v, nil
+}
+
+// numberText returns the decimal text of a number as a driver returns it.
+func numberText(v interface{}) (string, bool) {
+	switch n := v.(type) {
+	case string:
+		return n, true
+	case []byte:
+		return string(n), true
+	case int64:
+		return strconv.FormatInt(n, 10), true
+	case int32:
+		return strconv.FormatInt(int64(n), 10), true
+	case int:
+		return strconv.Itoa(n), true
+	case float64:
+		return strconv.FormatFloat(n, 'g', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(n), 'g', -1, 32), true
+	case fmt.Stringer:
+		return n.String(), true
+	}
+	return "", false
+}
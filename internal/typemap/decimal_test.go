@@ -0,0 +1,128 @@
+package typemap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func TestResolveColumn_FixedPoint(t *testing.T) {
+	tests := []struct {
+		name string
+		db   string
+		col  schema.Column
+		want BSONType
+	}{
+		{"money", "postgresql", schema.Column{DataType: "numeric", Precision: intPtr(12), Scale: intPtr(2)}, BSONDecimal128},
+		{"whole", "postgresql", schema.Column{DataType: "numeric", Precision: intPtr(10), Scale: intPtr(0)}, BSONNumberLong},
+		{"wider than a long", "postgresql", schema.Column{DataType: "decimal", Precision: intPtr(20), Scale: intPtr(0)}, BSONDecimal128},
+		{"unconstrained", "postgresql", schema.Column{DataType: "numeric"}, BSONDecimal128},
+		{"oracle money", "oracle", schema.Column{DataType: "NUMBER", Precision: intPtr(10), Scale: intPtr(2)}, BSONDecimal128},
+		{"oracle flag", "oracle", schema.Column{DataType: "NUMBER", Precision: intPtr(1), Scale: intPtr(0)}, BSONNumberLong},
+		{"oracle integer", "oracle", schema.Column{DataType: "NUMBER", Scale: intPtr(0)}, BSONNumberLong},
+		{"oracle float", "oracle", schema.Column{DataType: "NUMBER"}, BSONDecimal128},
+		{"negative scale", "oracle", schema.Column{DataType: "NUMBER", Precision: intPtr(17), Scale: intPtr(-3)}, BSONDecimal128},
+		{"not fixed point", "postgresql", schema.Column{DataType: "double precision"}, BSONDouble},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ForDatabase(tt.db).ResolveColumn(tt.col); got != tt.want {
+				t.Errorf("ResolveColumn() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	tm := ForDatabase("postgresql")
+	tm.Override("numeric", BSONDouble)
+	if got := tm.ResolveColumn(schema.Column{DataType: "numeric", Precision: intPtr(12), Scale: intPtr(2)}); got != BSONDouble {
+		t.Errorf("overridden ResolveColumn() = %s, want Double", got)
+	}
+}
+
+func TestDecimalColumns(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{{
+		Name: "ledger",
+		Columns: []schema.Column{
+			{Name: "id", DataType: "integer"},
+			{Name: "amount", DataType: "numeric", Precision: intPtr(12), Scale: intPtr(2)},
+			{Name: "rate", DataType: "numeric", Precision: intPtr(40), Scale: intPtr(30)},
+			{Name: "count", DataType: "numeric", Precision: intPtr(9), Scale: intPtr(0)},
+		},
+	}}}
+	tm := ForDatabase("postgresql")
+
+	cols := tm.DecimalColumns(s)
+	if len(cols) != 3 || cols[0].Column != "amount" || cols[1].Column != "count" || cols[2].Column != "rate" {
+		t.Fatalf("DecimalColumns = %+v, want amount, count and rate", cols)
+	}
+	if cols[0].BSONType != BSONDecimal128 || cols[0].Warning != "" {
+		t.Errorf("amount = %+v, want Decimal128 without a warning", cols[0])
+	}
+	if cols[1].BSONType != BSONNumberLong {
+		t.Errorf("count = %s, want NumberLong", cols[1].BSONType)
+	}
+	if !strings.Contains(cols[2].Warning, "more than the 34") {
+		t.Errorf("rate warning = %q, want precision beyond Decimal128", cols[2].Warning)
+	}
+	if got := cols[2].Declared(); got != "numeric(40,30)" {
+		t.Errorf("Declared() = %q", got)
+	}
+
+	tm.Override("numeric", BSONDouble)
+	warnings := tm.DecimalWarnings(s)
+	if len(warnings) != 2 || !strings.HasPrefix(warnings[0], "ledger.amount: ") || !strings.Contains(warnings[0], "rounds decimal fractions") {
+		t.Errorf("DecimalWarnings() = %q, want amount and rate written as doubles", warnings)
+	}
+	if got := tm.NumberTypes(s)["ledger"]["count"]; got != BSONDouble {
+		t.Errorf("NumberTypes count = %s, want Double", got)
+	}
+}
+
+func TestConvertNumber(t *testing.T) {
+	var numeric pgtype.Numeric
+	if err := numeric.Scan("1234.50"); err != nil {
+		t.Fatal(err)
+	}
+	dec := func(s string) bson.Decimal128 {
+		d, err := bson.ParseDecimal128(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		name    string
+		v       interface{}
+		t       BSONType
+		want    interface{}
+		wantErr bool
+	}{
+		{"pgx numeric", numeric, BSONDecimal128, dec("1234.50"), false},
+		{"null pgx numeric", pgtype.Numeric{}, BSONDecimal128, nil, false},
+		{"text", "19.99", BSONDecimal128, dec("19.99"), false},
+		{"float", 1.25, BSONDecimal128, dec("1.25"), false},
+		{"too many digits", "1234567890123456789012345678901234567", BSONDecimal128, nil, true},
+		{"whole text", "42", BSONNumberLong, int64(42), false},
+		{"whole with zero fraction", "42.00", BSONNumberLong, int64(42), false},
+		{"fraction", "42.5", BSONNumberLong, nil, true},
+		{"double", "0.1", BSONDouble, 0.1, false},
+		{"string", int64(7), BSONString, "7", false},
+		{"nil", nil, BSONDecimal128, nil, false},
+		{"other type", true, BSONDecimal128, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertNumber(tt.v, tt.t)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertNumber() = %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package typemap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// Enum is the source type discovery gives PostgreSQL enum columns, whatever
+// their type's name, so one mapping covers every enum. The type's name and
+// labels are kept on the column.
+const Enum = "enum"
+
+// IsEnum reports whether columns of the source type hold enum labels.
+func IsEnum(dataType string) bool {
+	return dataType == Enum
+}
+
+// EnumLabel describes the enum types used by a schema's columns, for
+// listing the enum source type: the labels of a single type, as in
+// "enum(active, closed)", or the names of several, as in
+// "enum(mood, status)". It returns "enum" when no column has labels.
+func EnumLabel(s *schema.Schema) string {
+	types := make(map[string][]string)
+	if s != nil {
+		for _, t := range s.Tables {
+			for _, c := range t.Columns {
+				if IsEnum(c.DataType) && c.TypeName != "" {
+					types[c.TypeName] = c.EnumValues
+				}
+			}
+		}
+	}
+	switch len(types) {
+	case 0:
+		return Enum
+	case 1:
+		for _, labels := range types {
+			return fmt.Sprintf("%s(%s)", Enum, strings.Join(labels, ", "))
+		}
+	}
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("%s(%s)", Enum, strings.Join(names, ", "))
+}
+
+// EnumSQL returns the SQL expression that reads an enum column as its
+// label, so readers that do not know the type see plain text.
+func EnumSQL(col schema.Column) string {
+	return fmt.Sprintf("CAST(%s AS text)", col.Name)
+}
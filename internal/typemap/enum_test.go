@@ -0,0 +1,41 @@
+package typemap
+
+import (
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func TestEnumLabel(t *testing.T) {
+	status := schema.Column{Name: "status", DataType: "enum", TypeName: "status", EnumValues: []string{"active", "closed"}}
+	mood := schema.Column{Name: "mood", DataType: "enum", TypeName: "mood", EnumValues: []string{"sad", "ok", "happy"}}
+	tests := []struct {
+		name string
+		cols []schema.Column
+		want string
+	}{
+		{name: "no enums", cols: []schema.Column{{Name: "id", DataType: "integer"}}, want: "enum"},
+		{name: "one type", cols: []schema.Column{status, status}, want: "enum(active, closed)"},
+		{name: "several types", cols: []schema.Column{status, mood}, want: "enum(mood, status)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &schema.Schema{Tables: []schema.Table{{Name: "t", Columns: tt.cols}}}
+			if got := EnumLabel(s); got != tt.want {
+				t.Errorf("EnumLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := EnumLabel(nil); got != "enum" {
+		t.Errorf("EnumLabel(nil) = %q, want enum", got)
+	}
+}
+
+func TestEnumDefaults(t *testing.T) {
+	if got := ForDatabase("postgresql").Resolve(Enum); got != BSONString {
+		t.Errorf("enum resolves to %s, want String", got)
+	}
+	if got := EnumSQL(schema.Column{Name: "status"}); got != "CAST(status AS text)" {
+		t.Errorf("EnumSQL() = %q", got)
+	}
+}
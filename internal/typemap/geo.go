@@ -0,0 +1,125 @@
+package typemap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// WGS84 is the spatial reference of GeoJSON coordinates, longitude and
+// latitude in degrees, which 2dsphere indexes require.
+const WGS84 = 4326
+
+// oracleWGS84 is Oracle's own SRID for WGS 84 longitude/latitude.
+const oracleWGS84 = 8307
+
+// geoTypes are the source types holding geometries: PostGIS geometry and
+// geography, and Oracle Spatial's SDO_GEOMETRY.
+var geoTypes = map[string]bool{
+	"geometry":     true,
+	"geography":    true,
+	"SDO_GEOMETRY": true,
+}
+
+// IsGeo reports whether columns of the source type hold geometries.
+func IsGeo(dataType string) bool {
+	return geoTypes[dataType]
+}
+
+// geoJSONTypes maps the shape names of PostGIS type modifiers and Oracle
+// spatial index layer types to GeoJSON geometry types.
+var geoJSONTypes = map[string]string{
+	"POINT":              "Point",
+	"LINESTRING":         "LineString",
+	"LINE":               "LineString",
+	"POLYGON":            "Polygon",
+	"MULTIPOINT":         "MultiPoint",
+	"MULTILINESTRING":    "MultiLineString",
+	"MULTILINE":          "MultiLineString",
+	"MULTIPOLYGON":       "MultiPolygon",
+	"GEOMETRYCOLLECTION": "GeometryCollection",
+	"COLLECTION":         "GeometryCollection",
+}
+
+// GeoJSONType returns the GeoJSON geometry type for a shape name as the
+// source catalogs report it, e.g. "POINT", "MultiPolygon" or PostGIS's
+// "POINTZ". It returns "" for names that allow any shape, like "GEOMETRY".
+func GeoJSONType(name string) string {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if t, ok := geoJSONTypes[name]; ok {
+		return t
+	}
+	// Measured and 3D variants: POINTM, POINTZ, POINTZM
+	for _, suffix := range []string{"ZM", "M", "Z"} {
+		if t, ok := geoJSONTypes[strings.TrimSuffix(name, suffix)]; ok {
+			return t
+		}
+	}
+	return ""
+}
+
+// coordinateDepths is how deeply the coordinates of each GeoJSON type nest:
+// a Point holds one position, a LineString an array of them, and so on.
+var coordinateDepths = map[string]int{
+	"Point":           1,
+	"LineString":      2,
+	"MultiPoint":      2,
+	"Polygon":         3,
+	"MultiLineString": 3,
+	"MultiPolygon":    4,
+}
+
+// CoordinateDepth returns the number of array levels in the coordinates of
+// a GeoJSON type, counting the position itself, or 0 for a
+// GeometryCollection or an unknown type, whose shape is not fixed.
+func CoordinateDepth(geometryType string) int {
+	return coordinateDepths[geometryType]
+}
+
+// ResolveColumn returns the BSON type a column's values are written as. It
+// is Resolve of the column's type, except that fixed-point columns resolve
+// by their precision and scale, and geometry columns mapped to GeoJSON
+// whose shape is not fixed are written as GeoJSON text: their coordinates
+// nest differently from row to row.
+func (tm *TypeMap) ResolveColumn(c schema.Column) BSONType {
+	if IsFixedPoint(c.DataType) {
+		return tm.resolveFixedPoint(c)
+	}
+	t := tm.Resolve(c.DataType)
+	if t == BSONGeoJSON && CoordinateDepth(c.GeometryType) == 0 {
+		return BSONString
+	}
+	return t
+}
+
+// GeoJSONSQL returns the SQL expression that reads a geometry column of the
+// given database type as GeoJSON text, transformed to WGS 84 when the
+// column's SRID is another known reference.
+func GeoJSONSQL(dbType string, col schema.Column) string {
+	reproject := col.SRID != 0 && col.SRID != WGS84
+	if dbType == "oracle" {
+		if reproject && col.SRID != oracleWGS84 {
+			return fmt.Sprintf("SDO_UTIL.TO_GEOJSON(SDO_CS.TRANSFORM(%s, %d))", col.Name, WGS84)
+		}
+		return fmt.Sprintf("SDO_UTIL.TO_GEOJSON(%s)", col.Name)
+	}
+	if reproject && col.DataType == "geometry" {
+		return fmt.Sprintf("ST_AsGeoJSON(ST_Transform(%s, %d))", col.Name, WGS84)
+	}
+	return fmt.Sprintf("ST_AsGeoJSON(%s)", col.Name)
+}
+
+// GeoColumns returns the geometry columns of a table, in column order.
+func GeoColumns(t *schema.Table) []schema.Column {
+	var out []schema.Column
+	if t == nil {
+		return out
+	}
+	for _, c := range t.Columns {
+		if IsGeo(c.DataType) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package typemap
+
+import (
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func TestGeoJSONType(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"POINT", "Point"},
+		{"Point", "Point"},
+		{"POINTZ", "Point"},
+		{"MULTIPOLYGONM", "MultiPolygon"},
+		{"LINESTRINGZM", "LineString"},
+		{"LINE", "LineString"},
+		{"MULTILINE", "MultiLineString"},
+		{"COLLECTION", "GeometryCollection"},
+		{"GEOMETRY", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := GeoJSONType(tt.name); got != tt.want {
+			t.Errorf("GeoJSONType(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCoordinateDepth(t *testing.T) {
+	tests := []struct {
+		geometryType string
+		want         int
+	}{
+		{"Point", 1},
+		{"MultiPoint", 2},
+		{"Polygon", 3},
+		{"MultiPolygon", 4},
+		{"GeometryCollection", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := CoordinateDepth(tt.geometryType); got != tt.want {
+			t.Errorf("CoordinateDepth(%q) = %d, want %d", tt.geometryType, got, tt.want)
+		}
+	}
+}
+
+func TestGeoJSONSQL(t *testing.T) {
+	tests := []struct {
+		name   string
+		dbType string
+		col    schema.Column
+		want   string
+	}{
+		{"postgis wgs84", "postgresql", schema.Column{Name: "geom", DataType: "geometry", SRID: 4326}, "ST_AsGeoJSON(geom)"},
+		{"postgis unknown srid", "postgresql", schema.Column{Name: "geom", DataType: "geometry"}, "ST_AsGeoJSON(geom)"},
+		{"postgis projected", "postgresql", schema.Column{Name: "geom", DataType: "geometry", SRID: 3857}, "ST_AsGeoJSON(ST_Transform(geom, 4326))"},
+		{"geography", "postgresql", schema.Column{Name: "area", DataType: "geography", SRID: 4326}, "ST_AsGeoJSON(area)"},
+		{"oracle wgs84", "oracle", schema.Column{Name: "SHAPE", DataType: "SDO_GEOMETRY", SRID: 8307}, "SDO_UTIL.TO_GEOJSON(SHAPE)"},
+		{"oracle projected", "oracle", schema.Column{Name: "SHAPE", DataType: "SDO_GEOMETRY", SRID: 27700}, "SDO_UTIL.TO_GEOJSON(SDO_CS.TRANSFORM(SHAPE, 4326))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GeoJSONSQL(tt.dbType, tt.col); got != tt.want {
+				t.Errorf("GeoJSONSQL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultGeoMappings(t *testing.T) {
+	for _, tc := range []struct{ db, dataType string }{
+		{"postgresql", "geometry"},
+		{"postgresql", "geography"},
+		{"oracle", "SDO_GEOMETRY"},
+	} {
+		if !IsGeo(tc.dataType) {
+			t.Errorf("IsGeo(%q) = false", tc.dataType)
+		}
+		if got := ForDatabase(tc.db).Resolve(tc.dataType); got != BSONGeoJSON {
+			t.Errorf("%s %s maps to %s, want GeoJSON", tc.db, tc.dataType, got)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package typemap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// LOBStrategy decides how the values of a large object column reach MongoDB.
+type LOBStrategy string
+
+const (
+	// LOBInline stores the value in the document as BSON Binary (or String
+	// for character LOBs). Documents are still limited to 16MB.
+	LOBInline LOBStrategy = "inline"
+	// LOBSkip leaves the column out of the document.
+	LOBSkip LOBStrategy = "skip"
+	// LOBGridFS writes the value to a GridFS bucket in the target database
+	// and stores the file's ID in the document.
+	LOBGridFS LOBStrategy = "gridfs"
+	// LOBS3 writes the value to an S3 object and stores its s3:// URI in the
+	// document.
+	LOBS3 LOBStrategy = "s3"
+)
+
+// AllLOBStrategies lists the LOB strategies, in the order the editor cycles
+// through them.
+var AllLOBStrategies = []LOBStrategy{LOBInline, LOBSkip, LOBGridFS, LOBS3}
+
+// DefaultGridFSBucket is the GridFS bucket used when none is given.
+const DefaultGridFSBucket = "fs"
+
+// lobTypes are the source types holding large objects: Postgres bytea and
+// Oracle's LOB types.
+var lobTypes = map[string]bool{
+	"bytea": true,
+	"BLOB":  true,
+	"CLOB":  true,
+	"NCLOB": true,
+}
+
+// IsLOB reports whether columns of the source type hold large objects.
+func IsLOB(dataType string) bool {
+	return lobTypes[dataType]
+}
+
+// LOBColumn is the strategy chosen for one large object column. Location is
+// the GridFS bucket name for gridfs (default fs), or the s3://bucket/prefix
+// objects are written under for s3.
+type LOBColumn struct {
+	Table    string      `yaml:"table" json:"table"`
+	Column   string      `yaml:"column" json:"column"`
+	DataType string      `yaml:"-" json:"data_type,omitempty"`
+	Strategy LOBStrategy `yaml:"strategy" json:"strategy"`
+	Location string      `yaml:"location,omitempty" json:"location,omitempty"`
+}
+
+// Offloaded reports whether the value is stored outside the document.
+func (c LOBColumn) Offloaded() bool {
+	return c.Strategy == LOBGridFS || c.Strategy == LOBS3
+}
+
+// Validate checks the strategy and that S3 offloading has somewhere to go.
+func (c LOBColumn) Validate() error {
+	switch c.Strategy {
+	case LOBInline, LOBSkip, LOBGridFS:
+	case LOBS3:
+		if !strings.HasPrefix(c.Location, "s3://") || len(c.Location) == len("s3://") {
+			return fmt.Errorf("%s.%s: s3 strategy needs a location like s3://bucket/prefix", c.Table, c.Column)
+		}
+	default:
+		return fmt.Errorf("%s.%s: unknown LOB strategy %q (use inline, skip, gridfs or s3)", c.Table, c.Column, c.Strategy)
+	}
+	return nil
+}
+
+// LOB returns the strategy for a column. Columns without one are inlined.
+func (tm *TypeMap) LOB(table, column string) LOBColumn {
+	for _, c := range tm.LOBs {
+		if c.Table == table && c.Column == column {
+			if c.Strategy == LOBGridFS && c.Location == "" {
+				c.Location = DefaultGridFSBucket
+			}
+			return c
+		}
+	}
+	return LOBColumn{Table: table, Column: column, Strategy: LOBInline}
+}
+
+// SetLOB records the strategy for a column, replacing any earlier one.
+// Inline is the default and is not stored.
+func (tm *TypeMap) SetLOB(c LOBColumn) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	c.DataType = ""
+	lobs := tm.LOBs[:0]
+	for _, old := range tm.LOBs {
+		if old.Table != c.Table || old.Column != c.Column {
+			lobs = append(lobs, old)
+		}
+	}
+	if c.Strategy != LOBInline {
+		lobs = append(lobs, c)
+	}
+	sort.Slice(lobs, func(i, j int) bool {
+		if lobs[i].Table != lobs[j].Table {
+			return lobs[i].Table < lobs[j].Table
+		}
+		return lobs[i].Column < lobs[j].Column
+	})
+	tm.LOBs = lobs
+	return nil
+}
+
+// LOBColumns returns every large object column of the schema with its
+// strategy, ordered by table and column.
+func (tm *TypeMap) LOBColumns(s *schema.Schema) []LOBColumn {
+	var out []LOBColumn
+	if s == nil {
+		return out
+	}
+	for _, t := range s.Tables {
+		for _, col := range t.Columns {
+			if !IsLOB(col.DataType) {
+				continue
+			}
+			c := tm.LOB(t.Name, col.Name)
+			c.DataType = col.DataType
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Table != out[j].Table {
+			return out[i].Table < out[j].Table
+		}
+		return out[i].Column < out[j].Column
+	})
+	return out
+}
@@ -0,0 +1,127 @@
+package typemap
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func TestIsLOB(t *testing.T) {
+	tests := []struct {
+		dataType string
+		want     bool
+	}{
+		{"bytea", true},
+		{"BLOB", true},
+		{"CLOB", true},
+		{"NCLOB", true},
+		{"text", false},
+		{"VARCHAR2", false},
+	}
+	for _, tt := range tests {
+		if got := IsLOB(tt.dataType); got != tt.want {
+			t.Errorf("IsLOB(%q) = %v, want %v", tt.dataType, got, tt.want)
+		}
+	}
+}
+
+func TestLOBColumn_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		col     LOBColumn
+		wantErr bool
+	}{
+		{"inline", LOBColumn{Strategy: LOBInline}, false},
+		{"skip", LOBColumn{Strategy: LOBSkip}, false},
+		{"gridfs default bucket", LOBColumn{Strategy: LOBGridFS}, false},
+		{"s3", LOBColumn{Strategy: LOBS3, Location: "s3://docs/lobs"}, false},
+		{"s3 without location", LOBColumn{Strategy: LOBS3}, true},
+		{"s3 without bucket", LOBColumn{Strategy: LOBS3, Location: "s3://"}, true},
+		{"unknown", LOBColumn{Strategy: "zip"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.col.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetLOB(t *testing.T) {
+	tm := ForDatabase("postgresql")
+
+	if got := tm.LOB("staff", "picture"); got.Strategy != LOBInline {
+		t.Errorf("default strategy = %q, want inline", got.Strategy)
+	}
+	if err := tm.SetLOB(LOBColumn{Table: "staff", Column: "picture", Strategy: LOBGridFS}); err != nil {
+		t.Fatal(err)
+	}
+	if got := tm.LOB("staff", "picture"); got.Strategy != LOBGridFS || got.Location != DefaultGridFSBucket {
+		t.Errorf("LOB = %+v, want gridfs in the default bucket", got)
+	}
+	if err := tm.SetLOB(LOBColumn{Table: "staff", Column: "picture", Strategy: LOBS3, Location: "s3://b/p"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(tm.LOBs) != 1 || tm.LOBs[0].Strategy != LOBS3 {
+		t.Errorf("LOBs = %+v, want one s3 entry", tm.LOBs)
+	}
+	if err := tm.SetLOB(LOBColumn{Table: "staff", Column: "picture", Strategy: "bad"}); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+
+	// Setting inline again drops the entry.
+	if err := tm.SetLOB(LOBColumn{Table: "staff", Column: "picture", Strategy: LOBInline}); err != nil {
+		t.Fatal(err)
+	}
+	if len(tm.LOBs) != 0 {
+		t.Errorf("LOBs = %+v, want none", tm.LOBs)
+	}
+}
+
+func TestLOBColumns(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "staff", Columns: []schema.Column{
+			{Name: "id", DataType: "integer"},
+			{Name: "picture", DataType: "bytea"},
+		}},
+		{Name: "film", Columns: []schema.Column{
+			{Name: "script", DataType: "bytea"},
+		}},
+	}}
+	tm := ForDatabase("postgresql")
+	if err := tm.SetLOB(LOBColumn{Table: "film", Column: "script", Strategy: LOBSkip}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := tm.LOBColumns(s)
+	if len(got) != 2 {
+		t.Fatalf("LOBColumns = %+v, want 2", got)
+	}
+	if got[0].Table != "film" || got[0].Strategy != LOBSkip || got[0].DataType != "bytea" {
+		t.Errorf("first = %+v, want film.script skipped", got[0])
+	}
+	if got[1].Table != "staff" || got[1].Strategy != LOBInline {
+		t.Errorf("second = %+v, want staff.picture inline", got[1])
+	}
+}
+
+func TestLOBsRoundTrip(t *testing.T) {
+	tm := ForDatabase("oracle")
+	if err := tm.SetLOB(LOBColumn{Table: "DOCS", Column: "BODY", Strategy: LOBS3, Location: "s3://archive/docs"}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "typemap.yaml")
+	if err := tm.WriteYAML(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadYAML(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.LOB("DOCS", "BODY"); got.Strategy != LOBS3 || got.Location != "s3://archive/docs" {
+		t.Errorf("loaded LOB = %+v", got)
+	}
+}
@@ -0,0 +1,211 @@
+package typemap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+// TimezonePolicy decides which time zone the wall-clock values of a
+// timestamp column without a time zone are read in, before they become the
+// UTC instants MongoDB stores.
+type TimezonePolicy string
+
+const (
+	// TimezoneUTC reads the values as UTC.
+	TimezoneUTC TimezonePolicy = "utc"
+	// TimezoneServer reads the values in the source server's time zone,
+	// recorded at discovery.
+	TimezoneServer TimezonePolicy = "server"
+	// TimezoneNamed reads the values in the column's Zone, an IANA name
+	// such as Europe/Paris.
+	TimezoneNamed TimezonePolicy = "named"
+)
+
+// AllTimezonePolicies lists the time zone policies, in the order the
+// editor cycles through them.
+var AllTimezonePolicies = []TimezonePolicy{TimezoneUTC, TimezoneServer, TimezoneNamed}
+
+// naiveTimestampTypes are the source types holding a date and time of day
+// without a time zone: Postgres timestamps and Oracle DATE and TIMESTAMP.
+var naiveTimestampTypes = map[string]bool{
+	"timestamp":                   true,
+	"timestamp without time zone": true,
+	"TIMESTAMP":                   true,
+	"DATE":                        true,
+}
+
+// IsNaiveTimestamp reports whether columns of the source type hold dates
+// and times without a time zone.
+func IsNaiveTimestamp(dataType string) bool {
+	return naiveTimestampTypes[dataType]
+}
+
+// TimestampColumn is the time zone policy chosen for one timestamp column
+// without a time zone. Zone is the IANA time zone of the named policy.
+type TimestampColumn struct {
+	Table    string         `yaml:"table" json:"table"`
+	Column   string         `yaml:"column" json:"column"`
+	DataType string         `yaml:"-" json:"data_type,omitempty"`
+	Policy   TimezonePolicy `yaml:"policy" json:"policy"`
+	Zone     string         `yaml:"zone,omitempty" json:"zone,omitempty"`
+}
+
+// Validate checks the policy and that a named zone exists.
+func (c TimestampColumn) Validate() error {
+	switch c.Policy {
+	case TimezoneUTC, TimezoneServer:
+	case TimezoneNamed:
+		if c.Zone == "" {
+			return fmt.Errorf("%s.%s: named policy needs a zone like Europe/Paris", c.Table, c.Column)
+		}
+		if _, err := LoadZone(c.Zone); err != nil {
+			return fmt.Errorf("%s.%s: %w", c.Table, c.Column, err)
+		}
+	default:
+		return fmt.Errorf("%s.%s: unknown time zone policy %q (use utc, server or named)", c.Table, c.Column, c.Policy)
+	}
+	return nil
+}
+
+// SourceZone returns the name of the time zone the column's values are
+// read in, given the source server's time zone.
+func (c TimestampColumn) SourceZone(serverZone string) (string, error) {
+	switch c.Policy {
+	case TimezoneServer:
+		if serverZone == "" {
+			return "", fmt.Errorf("%s.%s: the source server's time zone is unknown; discover the schema again or name the zone", c.Table, c.Column)
+		}
+		return serverZone, nil
+	case TimezoneNamed:
+		return c.Zone, nil
+	}
+	return "UTC", nil
+}
+
+// Location returns the time zone the column's values are read in, given
+// the source server's time zone.
+func (c TimestampColumn) Location(serverZone string) (*time.Location, error) {
+	name, err := c.SourceZone(serverZone)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := LoadZone(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", c.Table, c.Column, err)
+	}
+	return loc, nil
+}
+
+// LoadZone returns the time zone of an IANA name, or of a fixed offset such
+// as +05:30 that Oracle reports for its database time zone.
+func LoadZone(name string) (*time.Location, error) {
+	if strings.HasPrefix(name, "+") || strings.HasPrefix(name, "-") {
+		t, err := time.Parse("-07:00", name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone offset %q", name)
+		}
+		_, offset := t.Zone()
+		return time.FixedZone(name, offset), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// InZone returns the instant a wall-clock time stands for in loc: its date
+// and time of day, whatever location t carries, read in loc.
+func InZone(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc).UTC()
+}
+
+// Timestamp returns the policy for a column. Columns without one are read
+// as UTC.
+func (tm *TypeMap) Timestamp(table, column string) TimestampColumn {
+	for _, c := range tm.Timestamps {
+		if c.Table == table && c.Column == column {
+			return c
+		}
+	}
+	return TimestampColumn{Table: table, Column: column, Policy: TimezoneUTC}
+}
+
+// SetTimestamp records the policy for a column, replacing any earlier one.
+// UTC is the default and is not stored.
+func (tm *TypeMap) SetTimestamp(c TimestampColumn) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	c.DataType = ""
+	if c.Policy != TimezoneNamed {
+		c.Zone = ""
+	}
+	cols := tm.Timestamps[:0]
+	for _, old := range tm.Timestamps {
+		if old.Table != c.Table || old.Column != c.Column {
+			cols = append(cols, old)
+		}
+	}
+	if c.Policy != TimezoneUTC {
+		cols = append(cols, c)
+	}
+	sort.Slice(cols, func(i, j int) bool {
+		if cols[i].Table != cols[j].Table {
+			return cols[i].Table < cols[j].Table
+		}
+		return cols[i].Column < cols[j].Column
+	})
+	tm.Timestamps = cols
+	return nil
+}
+
+// TimestampColumns returns every timestamp column without a time zone of
+// the schema with its policy, ordered by table and column.
+func (tm *TypeMap) TimestampColumns(s *schema.Schema) []TimestampColumn {
+	var out []TimestampColumn
+	if s == nil {
+		return out
+	}
+	for _, t := range s.Tables {
+		for _, col := range t.Columns {
+			if !IsNaiveTimestamp(col.DataType) {
+				continue
+			}
+			c := tm.Timestamp(t.Name, col.Name)
+			c.DataType = col.DataType
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Table != out[j].Table {
+			return out[i].Table < out[j].Table
+		}
+		return out[i].Column < out[j].Column
+	})
+	return out
+}
+
+// TimestampLocations returns, by table and column, the time zone each
+// timestamp column without a time zone of the schema is read in.
+func (tm *TypeMap) TimestampLocations(s *schema.Schema) (map[string]map[string]*time.Location, error) {
+	out := make(map[string]map[string]*time.Location)
+	if s == nil {
+		return out, nil
+	}
+	for _, c := range tm.TimestampColumns(s) {
+		loc, err := c.Location(s.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		if out[c.Table] == nil {
+			out[c.Table] = make(map[string]*time.Location)
+		}
+		out[c.Table][c.Column] = loc
+	}
+	return out, nil
+}
@@ -0,0 +1,146 @@
+package typemap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/schema"
+)
+
+func TestTimestampColumn_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		col     TimestampColumn
+		wantErr bool
+	}{
+		{"utc", TimestampColumn{Policy: TimezoneUTC}, false},
+		{"server", TimestampColumn{Policy: TimezoneServer}, false},
+		{"named", TimestampColumn{Policy: TimezoneNamed, Zone: "Europe/Paris"}, false},
+		{"named offset", TimestampColumn{Policy: TimezoneNamed, Zone: "+05:30"}, false},
+		{"named without zone", TimestampColumn{Policy: TimezoneNamed}, true},
+		{"unknown zone", TimestampColumn{Policy: TimezoneNamed, Zone: "Mars/Olympus"}, true},
+		{"unknown policy", TimestampColumn{Policy: "local"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.col.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadZone(t *testing.T) {
+	tests := []struct {
+		name    string
+		offset  int
+		wantErr bool
+	}{
+		{"UTC", 0, false},
+		{"+05:30", 5*3600 + 30*60, false},
+		{"-08:00", -8 * 3600, false},
+		{"+5", 0, true},
+		{"Nowhere/Else", 0, true},
+	}
+	for _, tt := range tests {
+		loc, err := LoadZone(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("LoadZone(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if _, offset := time.Date(2024, 1, 15, 0, 0, 0, 0, loc).Zone(); offset != tt.offset {
+			t.Errorf("LoadZone(%q) offset = %d, want %d", tt.name, offset, tt.offset)
+		}
+	}
+}
+
+func TestInZone(t *testing.T) {
+	paris, err := LoadZone("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The wall clock is kept whatever location it was read with
+	wall := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	if got, want := InZone(wall, paris), time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("InZone(summer) = %v, want %v", got, want)
+	}
+	wall = time.Date(2024, 1, 15, 12, 0, 0, 0, time.FixedZone("x", 3*3600))
+	if got, want := InZone(wall, paris), time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("InZone(winter) = %v, want %v", got, want)
+	}
+	if got := InZone(wall, time.UTC); got.Hour() != 12 {
+		t.Errorf("InZone(UTC) hour = %d, want 12", got.Hour())
+	}
+}
+
+func TestSetTimestamp(t *testing.T) {
+	tm := ForDatabase("postgresql")
+
+	if got := tm.Timestamp("orders", "placed_at"); got.Policy != TimezoneUTC {
+		t.Errorf("default policy = %q, want utc", got.Policy)
+	}
+	if err := tm.SetTimestamp(TimestampColumn{Table: "orders", Column: "placed_at", Policy: TimezoneNamed, Zone: "Asia/Tokyo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.SetTimestamp(TimestampColumn{Table: "orders", Column: "closed_at", Policy: TimezoneServer, Zone: "Asia/Tokyo"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(tm.Timestamps) != 2 || tm.Timestamps[0].Column != "closed_at" {
+		t.Fatalf("Timestamps = %+v, want closed_at then placed_at", tm.Timestamps)
+	}
+	if tm.Timestamps[0].Zone != "" {
+		t.Errorf("server policy should not keep a zone, got %q", tm.Timestamps[0].Zone)
+	}
+	if err := tm.SetTimestamp(TimestampColumn{Table: "orders", Column: "placed_at", Policy: TimezoneUTC}); err != nil {
+		t.Fatal(err)
+	}
+	if len(tm.Timestamps) != 1 {
+		t.Errorf("the UTC default should not be stored, got %+v", tm.Timestamps)
+	}
+	if err := tm.SetTimestamp(TimestampColumn{Table: "orders", Column: "placed_at", Policy: TimezoneNamed}); err == nil {
+		t.Error("expected error for a named policy without a zone")
+	}
+}
+
+func TestTimestampLocations(t *testing.T) {
+	s := &schema.Schema{Tables: []schema.Table{{
+		Name: "orders",
+		Columns: []schema.Column{
+			{Name: "id", DataType: "integer"},
+			{Name: "placed_at", DataType: "timestamp without time zone"},
+			{Name: "shipped_at", DataType: "timestamp with time zone"},
+			{Name: "closed_at", DataType: "timestamp"},
+		},
+	}}}
+	tm := ForDatabase("postgresql")
+	if err := tm.SetTimestamp(TimestampColumn{Table: "orders", Column: "closed_at", Policy: TimezoneServer}); err != nil {
+		t.Fatal(err)
+	}
+
+	cols := tm.TimestampColumns(s)
+	if len(cols) != 2 || cols[0].Column != "closed_at" || cols[1].Column != "placed_at" {
+		t.Fatalf("TimestampColumns = %+v, want closed_at and placed_at", cols)
+	}
+	if cols[1].DataType != "timestamp without time zone" {
+		t.Errorf("DataType = %q", cols[1].DataType)
+	}
+
+	if _, err := tm.TimestampLocations(s); err == nil {
+		t.Error("expected error for the server policy without a server time zone")
+	}
+	s.TimeZone = "America/New_York"
+	locs, err := tm.TimestampLocations(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := locs["orders"]["closed_at"].String(); got != "America/New_York" {
+		t.Errorf("closed_at zone = %q, want America/New_York", got)
+	}
+	if got := locs["orders"]["placed_at"]; got != time.UTC {
+		t.Errorf("placed_at zone = %v, want UTC", got)
+	}
+}
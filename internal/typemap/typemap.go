@@ -22,6 +22,9 @@ const (
 	BSONArray      BSONType = "Array"
 	BSONBoolean    BSONType = "Boolean"
 	BSONDouble     BSONType = "Double"
+	// BSONGeoJSON is a GeoJSON document such as
+	// {type: "Point", coordinates: [lng, lat]}, which 2dsphere indexes cover.
+	BSONGeoJSON BSONType = "GeoJSON"
 )
 
 // AllBSONTypes lists all known BSON types for cycling in the editor.
@@ -35,13 +38,16 @@ var AllBSONTypes = []BSONType{
 	BSONArray,
 	BSONBoolean,
 	BSONDouble,
+	BSONGeoJSON,
 }
 
 // TypeMap holds the mapping from source types to BSON types.
 type TypeMap struct {
-	Mappings  map[string]BSONType `yaml:"mappings"`
-	Overrides map[string]BSONType `yaml:"overrides,omitempty"`
-	defaults  map[string]BSONType // not serialized; populated by ForDatabase
+	Mappings   map[string]BSONType `yaml:"mappings" json:"mappings"`
+	Overrides  map[string]BSONType `yaml:"overrides,omitempty" json:"overrides,omitempty"`
+	LOBs       []LOBColumn         `yaml:"lobs,omitempty" json:"lobs,omitempty"`             // strategies for large object columns; unlisted ones are inlined
+	Timestamps []TimestampColumn   `yaml:"timestamps,omitempty" json:"timestamps,omitempty"` // time zone policies for timestamps without a time zone; unlisted ones are UTC
+	defaults   map[string]BSONType // not serialized; populated by ForDatabase
 }
 
 // DefaultPostgres returns the default type mapping for PostgreSQL.
@@ -71,6 +77,9 @@ func DefaultPostgres() *TypeMap {
 		"jsonb":                       BSONDocument,
 		"json":                        BSONDocument,
 		"ARRAY":                       BSONArray,
+		"geometry":                    BSONGeoJSON,
+		"geography":                   BSONGeoJSON,
+		"enum":                        BSONString,
 	}
 	return &TypeMap{Mappings: m}
 }
@@ -78,17 +87,18 @@ func DefaultPostgres() *TypeMap {
 // DefaultOracle returns the default type mapping for Oracle.
 func DefaultOracle() *TypeMap {
 	m := map[string]BSONType{
-		"NUMBER":    BSONNumberLong,
-		"VARCHAR2":  BSONString,
-		"NVARCHAR2": BSONString,
-		"CHAR":      BSONString,
-		"NCHAR":     BSONString,
-		"CLOB":      BSONString,
-		"NCLOB":     BSONString,
-		"DATE":      BSONISODate,
-		"TIMESTAMP": BSONISODate,
-		"BLOB":      BSONBinData,
-		"RAW":       BSONString,
+		"NUMBER":       BSONNumberLong,
+		"VARCHAR2":     BSONString,
+		"NVARCHAR2":    BSONString,
+		"CHAR":         BSONString,
+		"NCHAR":        BSONString,
+		"CLOB":         BSONString,
+		"NCLOB":        BSONString,
+		"DATE":         BSONISODate,
+		"TIMESTAMP":    BSONISODate,
+		"BLOB":         BSONBinData,
+		"RAW":          BSONString,
+		"SDO_GEOMETRY": BSONGeoJSON,
 	}
 	return &TypeMap{Mappings: m}
 }
@@ -4,30 +4,45 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/big"
 
 	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/typemap"
 )
 
 // AggregateCheck holds the result of aggregate comparison.
 type AggregateCheck struct {
-	Match  bool              `json:"match"`
-	Checks []AggregateDetail `json:"checks,omitempty"`
+	Match   bool              `json:"match"`
+	Checks  []AggregateDetail `json:"checks,omitempty"`
+	Message string            `json:"message,omitempty"`
 }
 
-// AggregateDetail describes a single aggregate comparison.
+// AggregateDetail describes a single aggregate comparison. The exact sums
+// of decimal columns are also given as decimal text.
 type AggregateDetail struct {
-	Type        string  `json:"type"` // "count_distinct" or "sum"
+	Type        string  `json:"type"` // "count_distinct", "sum" or "decimal_sum"
 	Column      string  `json:"column"`
 	SourceValue float64 `json:"source_value"`
 	TargetValue float64 `json:"target_value"`
+	SourceExact string  `json:"source_exact,omitempty"`
+	TargetExact string  `json:"target_exact,omitempty"`
 	Match       bool    `json:"match"`
 }
 
 // validateAggregates runs aggregate comparisons for the primary key column.
 // COUNT(DISTINCT pk) on source should equal COUNT(DISTINCT pk) on target.
+// Numeric columns are summed on both sides: sums of columns written as
+// Decimal128, such as monetary amounts, must be exactly equal, while the
+// others may differ by floating point rounding.
 func (v *Validator) validateAggregates(ctx context.Context, col mapping.Collection) (*AggregateCheck, error) {
 	check := &AggregateCheck{Match: true}
 
+	// Source aggregates cover the whole table, not just the migrated rows
+	if col.LiveFilter() != "" {
+		check.Message = "skipped: collection has a row filter"
+		return check, nil
+	}
+
 	// Find the primary key column for this source table
 	pkColumn := v.findPKColumn(col.SourceTable)
 	if pkColumn == "" {
@@ -58,9 +73,24 @@ func (v *Validator) validateAggregates(ctx context.Context, col mapping.Collecti
 		check.Match = false
 	}
 
-	// Find numeric columns for SUM comparison
+	// Find numeric columns for SUM comparison; masked columns no longer hold
+	// the source values
 	numericCols := v.findNumericColumns(col.SourceTable)
 	for _, nc := range numericCols {
+		if masked(col.Transformations, nc) {
+			continue
+		}
+		if v.exactSum(col.SourceTable, nc) {
+			d, err := v.compareDecimalSums(ctx, col, nc)
+			if err != nil {
+				return nil, err
+			}
+			check.Checks = append(check.Checks, *d)
+			if !d.Match {
+				check.Match = false
+			}
+			continue
+		}
 		sourceSum, err := v.Source.AggregateSum(ctx, col.SourceTable, nc)
 		if err != nil {
 			return nil, fmt.Errorf("source sum %s.%s: %w", col.SourceTable, nc, err)
@@ -87,6 +117,53 @@ func (v *Validator) validateAggregates(ctx context.Context, col mapping.Collecti
 	return check, nil
 }
 
+// compareDecimalSums sums a decimal column exactly on both sides.
+func (v *Validator) compareDecimalSums(ctx context.Context, col mapping.Collection, column string) (*AggregateDetail, error) {
+	sourceSum, err := v.Source.AggregateDecimalSum(ctx, col.SourceTable, column)
+	if err != nil {
+		return nil, fmt.Errorf("source sum %s.%s: %w", col.SourceTable, column, err)
+	}
+	targetSum, err := v.Target.AggregateDecimalSum(ctx, col.Name, column)
+	if err != nil {
+		return nil, fmt.Errorf("target sum %s.%s: %w", col.Name, column, err)
+	}
+	src, srcOK := new(big.Rat).SetString(sourceSum)
+	tgt, tgtOK := new(big.Rat).SetString(targetSum)
+	d := &AggregateDetail{
+		Type:        "decimal_sum",
+		Column:      column,
+		SourceExact: sourceSum,
+		TargetExact: targetSum,
+		Match:       srcOK && tgtOK && src.Cmp(tgt) == 0,
+	}
+	if srcOK {
+		d.SourceValue, _ = src.Float64()
+	}
+	if tgtOK {
+		d.TargetValue, _ = tgt.Float64()
+	}
+	return d, nil
+}
+
+// exactSum reports whether a column of the table is written as a
+// Decimal128, whose sum must not drift.
+func (v *Validator) exactSum(tableName, column string) bool {
+	if v.Schema == nil || v.TypeMap == nil {
+		return false
+	}
+	for _, t := range v.Schema.Tables {
+		if t.Name != tableName {
+			continue
+		}
+		for _, c := range t.Columns {
+			if c.Name == column {
+				return v.TypeMap.ResolveColumn(c) == typemap.BSONDecimal128
+			}
+		}
+	}
+	return false
+}
+
 func (v *Validator) findPKColumn(tableName string) string {
 	if v.Schema == nil {
 		return ""
@@ -0,0 +1,489 @@
+package validation
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// Validation modes.
+const (
+	// ModeFull compares row counts, sampled documents and aggregates.
+	ModeFull = "full"
+	// ModeChecksum compares row counts and per-key-range checksums of every
+	// row, reading the tables in chunks so it scales to billions of rows.
+	ModeChecksum = "checksum"
+)
+
+// Defaults for checksum validation.
+const (
+	DefaultChunkSize   = 100000
+	DefaultConcurrency = 4
+
+	// maxChunkMismatches caps the mismatched chunks kept in a result.
+	maxChunkMismatches = 50
+)
+
+// Config selects how the Validator checks collections. The zero value runs
+// the full checks.
+type Config struct {
+	Mode        string `yaml:"mode,omitempty" json:"mode,omitempty"`               // full (default) or checksum
+	ChunkSize   int64  `yaml:"chunk_size,omitempty" json:"chunk_size,omitempty"`   // key values per chunk
+	Concurrency int    `yaml:"concurrency,omitempty" json:"concurrency,omitempty"` // chunks compared at once
+
+	// Scheduling across collections; see Validator.Validate.
+	Parallelism int      `yaml:"parallelism,omitempty" json:"parallelism,omitempty"` // collections validated at once; default 1
+	TimeBudget  string   `yaml:"time_budget,omitempty" json:"time_budget,omitempty"` // e.g. 2h; collections not validated by then are skipped
+	Priority    []string `yaml:"priority,omitempty" json:"priority,omitempty"`       // collections validated first; the rest go largest first
+
+	// Custom rules; see Rule. Empty runs none.
+	RulesFile string `yaml:"rules_file,omitempty" json:"rules_file,omitempty"`
+
+	// Referential integrity; see validateReferences.
+	Referential string `yaml:"referential,omitempty" json:"referential,omitempty"` // sample (default), full or off
+
+	// Target reads; see target.ReadOptions. Empty reads from the primary.
+	ReadPreference string `yaml:"read_preference,omitempty" json:"read_preference,omitempty"` // e.g. secondaryPreferred
+	ReadConcern    string `yaml:"read_concern,omitempty" json:"read_concern,omitempty"`       // local, majority or linearizable
+}
+
+// Validate checks the mode and limits.
+func (c Config) Validate() error {
+	switch c.Mode {
+	case "", ModeFull, ModeChecksum:
+	default:
+		return fmt.Errorf("unknown validation mode %q (want %s or %s)", c.Mode, ModeFull, ModeChecksum)
+	}
+	switch c.Referential {
+	case "", ReferentialSample, ReferentialFull, ReferentialOff:
+	default:
+		return fmt.Errorf("unknown referential check %q (want %s, %s or %s)", c.Referential, ReferentialSample, ReferentialFull, ReferentialOff)
+	}
+	if c.ChunkSize < 0 {
+		return fmt.Errorf("chunk size must not be negative")
+	}
+	if c.Concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative")
+	}
+	if c.Parallelism < 0 {
+		return fmt.Errorf("parallelism must not be negative")
+	}
+	if _, err := c.timeBudget(); err != nil {
+		return err
+	}
+	return c.ReadOptions().Validate()
+}
+
+// WithTargetDefaults fills in the read preference and concern the target
+// config sets, where c leaves them empty.
+func (c Config) WithTargetDefaults(tgt config.TargetConfig) Config {
+	if c.ReadPreference == "" {
+		c.ReadPreference = tgt.ReadPreference
+	}
+	if c.ReadConcern == "" {
+		c.ReadConcern = tgt.ReadConcern
+	}
+	return c
+}
+
+// ReadOptions returns the options target reads are made with.
+func (c Config) ReadOptions() target.ReadOptions {
+	return target.ReadOptions{Preference: c.ReadPreference, Concern: c.ReadConcern}
+}
+
+func (c Config) chunkSize() int64 {
+	if c.ChunkSize > 0 {
+		return c.ChunkSize
+	}
+	return DefaultChunkSize
+}
+
+func (c Config) concurrency() int {
+	if c.Concurrency > 0 {
+		return c.Concurrency
+	}
+	return DefaultConcurrency
+}
+
+func (c Config) parallelism() int {
+	if c.Parallelism > 0 {
+		return c.Parallelism
+	}
+	return 1
+}
+
+// timeBudget parses TimeBudget; zero means no budget.
+func (c Config) timeBudget() (time.Duration, error) {
+	if c.TimeBudget == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.TimeBudget)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid time budget %q (want a positive duration such as 90m)", c.TimeBudget)
+	}
+	return d, nil
+}
+
+// ChecksumCheck holds the result of comparing per-key-range checksums.
+type ChecksumCheck struct {
+	KeyColumn        string          `json:"key_column,omitempty"`
+	Columns          []string        `json:"columns,omitempty"`
+	ChunkSize        int64           `json:"chunk_size"`
+	Chunks           int             `json:"chunks"`
+	SourceRows       int64           `json:"source_rows"`
+	TargetRows       int64           `json:"target_rows"`
+	MismatchedChunks int             `json:"mismatched_chunks"`
+	Mismatches       []ChunkMismatch `json:"mismatches,omitempty"`
+	Match            bool            `json:"match"`
+	Message          string          `json:"message,omitempty"`
+}
+
+// ChunkMismatch describes a key range whose rows differ between source and target.
+type ChunkMismatch struct {
+	From           int64  `json:"from"`
+	To             int64  `json:"to"` // exclusive
+	SourceRows     int64  `json:"source_rows"`
+	TargetRows     int64  `json:"target_rows"`
+	SourceChecksum string `json:"source_checksum"`
+	TargetChecksum string `json:"target_checksum"`
+}
+
+// ChunkProgress reports a compared chunk to Validator.OnChunk.
+type ChunkProgress struct {
+	Collection string `json:"collection"`
+	Chunk      int    `json:"chunk"` // 1-based
+	Chunks     int    `json:"chunks"`
+	From       int64  `json:"from"`
+	To         int64  `json:"to"`
+	SourceRows int64  `json:"source_rows"`
+	TargetRows int64  `json:"target_rows"`
+	Match      bool   `json:"match"`
+}
+
+// chunkSum is an order-independent checksum of a set of rows: the row count
+// and the wrapping sum of each row's hash.
+type chunkSum struct {
+	rows int64
+	sum  uint64
+}
+
+func (c chunkSum) String() string {
+	return fmt.Sprintf("%016x", c.sum)
+}
+
+// checksumColumn is a source column and the document path it is written to.
+type checksumColumn struct {
+	column string
+	field  string
+}
+
+// validateChecksum splits the collection's source table into ranges of its
+// integer primary key and compares a checksum of every row in each range
+// with the matching target documents, several ranges at a time.
+func (v *Validator) validateChecksum(ctx context.Context, col mapping.Collection) (*ChecksumCheck, error) {
+	check := &ChecksumCheck{ChunkSize: v.Config.chunkSize(), Match: true}
+
+	if col.LiveFilter() != "" {
+		check.Message = "skipped: collection has a row filter"
+		return check, nil
+	}
+
+	pk := v.findPKColumn(col.SourceTable)
+	if pk == "" || !v.isIntegerColumn(col.SourceTable, pk) {
+		check.Message = "skipped: no integer primary key to chunk by"
+		return check, nil
+	}
+	keyField, ok := col.RootField(pk)
+	if !ok {
+		check.Message = fmt.Sprintf("skipped: primary key %s is not written to the target", pk)
+		return check, nil
+	}
+	check.KeyColumn = pk
+
+	cols := v.checksumColumns(col)
+	for _, c := range cols {
+		check.Columns = append(check.Columns, c.column)
+	}
+
+	lo, hi, err := v.Source.KeyRange(ctx, col.SourceTable, pk)
+	if err != nil {
+		return nil, fmt.Errorf("reading key range of %s: %w", col.SourceTable, err)
+	}
+	targetCount, err := v.Target.CountDocuments(ctx, col.Name)
+	if err != nil {
+		return nil, fmt.Errorf("counting target docs for %s: %w", col.Name, err)
+	}
+
+	size := check.ChunkSize
+	var chunks []int64 // chunk start keys
+	for from := lo; from <= hi; from += size {
+		chunks = append(chunks, from)
+		if from > math.MaxInt64-size {
+			break
+		}
+	}
+	check.Chunks = len(chunks)
+
+	sums := make([][2]chunkSum, len(chunks)) // source, target
+	work := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		done     int
+	)
+
+	chunkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for w := 0; w < v.Config.concurrency() && w < len(chunks); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				from, to := chunks[i], chunkEnd(chunks[i], size)
+				src, tgt, err := v.compareChunk(chunkCtx, col, pk, keyField, cols, from, to)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					cancel()
+					mu.Unlock()
+					continue
+				}
+				sums[i] = [2]chunkSum{src, tgt}
+				done++
+				v.chunkDone(ChunkProgress{
+					Collection: col.Name,
+					Chunk:      done,
+					Chunks:     len(chunks),
+					From:       from,
+					To:         to,
+					SourceRows: src.rows,
+					TargetRows: tgt.rows,
+					Match:      src == tgt,
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range chunks {
+		if chunkCtx.Err() != nil {
+			break
+		}
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, s := range sums {
+		src, tgt := s[0], s[1]
+		check.SourceRows += src.rows
+		check.TargetRows += tgt.rows
+		if src == tgt {
+			continue
+		}
+		check.Match = false
+		check.MismatchedChunks++
+		if len(check.Mismatches) < maxChunkMismatches {
+			check.Mismatches = append(check.Mismatches, ChunkMismatch{
+				From:           chunks[i],
+				To:             chunkEnd(chunks[i], size),
+				SourceRows:     src.rows,
+				TargetRows:     tgt.rows,
+				SourceChecksum: src.String(),
+				TargetChecksum: tgt.String(),
+			})
+		}
+	}
+
+	// Documents whose key falls outside the source range are in no chunk.
+	if outside := targetCount - check.TargetRows; outside != 0 {
+		check.Match = false
+		check.Message = fmt.Sprintf("%d target documents outside the source key range %d-%d", outside, lo, hi)
+	} else if check.MismatchedChunks > 0 {
+		check.Message = fmt.Sprintf("%d of %d chunks differ", check.MismatchedChunks, check.Chunks)
+	}
+	return check, nil
+}
+
+// chunkEnd returns the exclusive end key of the chunk starting at from.
+func chunkEnd(from, size int64) int64 {
+	if from > math.MaxInt64-size {
+		return math.MaxInt64
+	}
+	return from + size
+}
+
+// compareChunk reads the rows with keys in [from, to) on both sides and
+// returns their checksums.
+func (v *Validator) compareChunk(ctx context.Context, col mapping.Collection, pk, keyField string, cols []checksumColumn, from, to int64) (chunkSum, chunkSum, error) {
+	columns := make([]string, len(cols))
+	for i, c := range cols {
+		columns[i] = c.column
+	}
+	rows, err := v.Source.RowsInRange(ctx, col.SourceTable, columns, pk, from, to)
+	if err != nil {
+		return chunkSum{}, chunkSum{}, fmt.Errorf("reading %s keys %d-%d: %w", col.SourceTable, from, to, err)
+	}
+	docs, err := v.Target.FindRange(ctx, col.Name, keyField, from, to)
+	if err != nil {
+		return chunkSum{}, chunkSum{}, fmt.Errorf("reading %s keys %d-%d: %w", col.Name, from, to, err)
+	}
+
+	var src, tgt chunkSum
+	for _, row := range rows {
+		src.add(rowHash(cols, func(c checksumColumn) interface{} { return row[c.column] }))
+	}
+	for _, doc := range docs {
+		tgt.add(rowHash(cols, func(c checksumColumn) interface{} {
+			v, _ := docField(doc, c.field)
+			return v
+		}))
+	}
+	return src, tgt, nil
+}
+
+func (c *chunkSum) add(h uint64) {
+	c.rows++
+	c.sum += h
+}
+
+// isIntegerColumn reports whether a column has an integer data type.
+func (v *Validator) isIntegerColumn(tableName, column string) bool {
+	integerTypes := map[string]bool{
+		"integer": true, "int": true, "int2": true, "int4": true, "int8": true,
+		"bigint": true, "smallint": true, "serial": true, "bigserial": true,
+		"number": true,
+	}
+	for _, t := range v.Schema.Tables {
+		if t.Name != tableName {
+			continue
+		}
+		for _, c := range t.Columns {
+			if c.Name == column {
+				return integerTypes[strings.ToLower(c.DataType)] && (c.Scale == nil || *c.Scale == 0)
+			}
+		}
+	}
+	return false
+}
+
+// checksumColumns returns the root table's columns that are copied to the
+// target unchanged, sorted by name. Excluded, computed and retyped columns
+// are left out since their values are expected to differ.
+func (v *Validator) checksumColumns(col mapping.Collection) []checksumColumn {
+	if v.Schema == nil {
+		return nil
+	}
+	changed := make(map[string]bool)
+	for _, t := range col.Transformations {
+		if t.Operation != "rename" || t.TargetType != "" {
+			changed[t.SourceField] = true
+		}
+	}
+	var cols []checksumColumn
+	for _, t := range v.Schema.Tables {
+		if t.Name != col.SourceTable {
+			continue
+		}
+		for _, c := range t.Columns {
+			if changed[c.Name] {
+				continue
+			}
+			if field, ok := col.RootField(c.Name); ok {
+				cols = append(cols, checksumColumn{column: c.Name, field: field})
+			}
+		}
+	}
+	sort.Slice(cols, func(i, j int) bool { return cols[i].column < cols[j].column })
+	return cols
+}
+
+// rowHash hashes the canonical form of a row's column values.
+func rowHash(cols []checksumColumn, value func(checksumColumn) interface{}) uint64 {
+	h := fnv.New64a()
+	for _, c := range cols {
+		h.Write([]byte(c.column))
+		h.Write([]byte{0})
+		h.Write([]byte(canonical(value(c))))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// canonical renders a value the same way whether it was read from the source
+// driver or decoded from BSON: integers and integral floats as integers,
+// times in UTC at millisecond precision, and driver values by their Value.
+func canonical(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case bool:
+		return strconv.FormatBool(x)
+	case int:
+		return strconv.FormatInt(int64(x), 10)
+	case int8:
+		return strconv.FormatInt(int64(x), 10)
+	case int16:
+		return strconv.FormatInt(int64(x), 10)
+	case int32:
+		return strconv.FormatInt(int64(x), 10)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case uint8:
+		return strconv.FormatUint(uint64(x), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(x), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(x), 10)
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	case float32:
+		return canonicalFloat(float64(x))
+	case float64:
+		return canonicalFloat(x)
+	case time.Time:
+		return x.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano)
+	case interface{ Time() time.Time }:
+		return canonical(x.Time())
+	case driver.Valuer:
+		if dv, err := x.Value(); err == nil {
+			return canonical(dv)
+		}
+	case fmt.Stringer:
+		return x.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func canonicalFloat(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,121 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/collation"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/source"
+)
+
+// CollationCheck holds the result of checking the embeds whose source join
+// ignores case: the child rows the source joins to a parent, comparing keys
+// with their collations, are counted again comparing keys byte by byte as
+// the migration does, lower-cased when the mapping normalizes them. Rows
+// only the source joins are left out of the documents and fail the check.
+type CollationCheck struct {
+	Joins   []CollationJoin `json:"joins"`
+	Dropped int64           `json:"dropped"` // child rows left out of the documents
+	Match   bool            `json:"match"`
+}
+
+// CollationJoin is the check of one embed joined ignoring case.
+type CollationJoin struct {
+	Field      string `json:"field"`
+	Table      string `json:"table"`
+	Parent     string `json:"parent"`
+	Source     string `json:"source"` // the source collation
+	Normalized bool   `json:"normalized"`
+	Joined     int64  `json:"joined"`   // child rows the source joins to a parent
+	Migrated   int64  `json:"migrated"` // of those, rows the migration joins
+	Dropped    int64  `json:"dropped"`
+}
+
+// Summary describes the join's dropped rows in a line.
+func (j CollationJoin) Summary() string {
+	s := fmt.Sprintf("%d of %d %s rows join %s only ignoring case (%s) and are not embedded", j.Dropped, j.Joined, j.Table, j.Parent, j.Source)
+	if !j.Normalized {
+		s += "; normalize the join keys to lower case"
+	}
+	return s
+}
+
+// validateCollation counts the child rows of the collection's embeds joined
+// ignoring case that the migration leaves out, or returns nil when none of
+// its embeds is.
+func (v *Validator) validateCollation(ctx context.Context, col mapping.Collection) (*CollationCheck, error) {
+	if v.Schema == nil {
+		return nil, nil
+	}
+	advice := make(map[string]collation.JoinAdvice)
+	for _, j := range collation.Joins(v.Schema, v.Mapping) {
+		if j.Collection == col.Name && !j.Reference {
+			advice[j.Field] = j
+		}
+	}
+	if len(advice) == 0 {
+		return nil, nil
+	}
+
+	check := &CollationCheck{Match: true}
+	var walk func(embs []mapping.Embedded, parentFilter, prefix string) error
+	walk = func(embs []mapping.Embedded, parentFilter, prefix string) error {
+		for i := range embs {
+			emb := &embs[i]
+			path := prefix + emb.FieldName
+			if j, ok := advice[path]; ok {
+				ref := source.Reference{
+					Table:         emb.SourceTable,
+					Columns:       emb.JoinKeys(),
+					Filter:        emb.Filter,
+					ParentTable:   j.ParentTable,
+					ParentColumns: emb.ParentKeys(),
+					ParentFilter:  parentFilter,
+				}
+				joined, err := v.Source.ReferencedRowCount(ctx, ref)
+				if err != nil {
+					return fmt.Errorf("counting %s rows joined to %s: %w", emb.SourceTable, j.ParentTable, err)
+				}
+				ref.Match = source.MatchBinary
+				if j.Normalized {
+					ref.Match = source.MatchLower
+				}
+				migrated, err := v.Source.ReferencedRowCount(ctx, ref)
+				if err != nil {
+					return fmt.Errorf("counting %s rows joined to %s byte by byte: %w", emb.SourceTable, j.ParentTable, err)
+				}
+				cj := CollationJoin{
+					Field: path, Table: emb.SourceTable, Parent: j.ParentTable, Source: j.Source, Normalized: j.Normalized,
+					Joined: joined, Migrated: migrated, Dropped: max(joined-migrated, 0),
+				}
+				check.Joins = append(check.Joins, cj)
+				check.Dropped += cj.Dropped
+			}
+			if err := walk(emb.Embedded, emb.Filter, path+"."); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(col.Embedded, col.LiveFilter(), ""); err != nil {
+		return nil, err
+	}
+	check.Match = check.Dropped == 0
+	return check, nil
+}
+
+// checkCollation adds the collation check to cr when the collection embeds
+// a table joined ignoring case.
+func (v *Validator) checkCollation(ctx context.Context, col mapping.Collection, cr *CollectionResult) error {
+	cc, err := v.validateCollation(ctx, col)
+	if err != nil || cc == nil {
+		return err
+	}
+	cr.CollationCheck = cc
+	if !cc.Match {
+		cr.Status = "FAIL"
+	}
+	v.notify(col.Name, "collation", cc.Match)
+	return nil
+}
@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// GroupCheck holds the results for the references a collection holds to
+// the other collections of its consistency group. Migrated from one source
+// snapshot, the documents whose parent exists in the target should number
+// the source rows whose parent row exists.
+type GroupCheck struct {
+	Group         string              `json:"group"`
+	Relationships []GroupRelationship `json:"relationships,omitempty"`
+	Message       string              `json:"message,omitempty"`
+	Match         bool                `json:"match"`
+}
+
+// GroupRelationship compares one reference within a consistency group.
+type GroupRelationship struct {
+	Field       string `json:"field"`
+	Parent      string `json:"parent"`
+	ParentField string `json:"parent_field"`
+	SourceCount int64  `json:"source_count"` // source rows whose parent row exists
+	TargetCount int64  `json:"target_count"` // documents whose parent document exists
+	Message     string `json:"message,omitempty"`
+}
+
+// Relationship names the reference as collection.field -> parent.field.
+func (r GroupRelationship) Relationship(collection string) string {
+	return fmt.Sprintf("%s.%s -> %s.%s", collection, r.Field, r.Parent, r.ParentField)
+}
+
+// validateGroup checks the collection against the other members of its
+// consistency group, or returns nil when it is in none, holds no reference
+// to them and was read from the same snapshot, or the referential check is
+// off. Every document of the collection is checked.
+func (v *Validator) validateGroup(ctx context.Context, col mapping.Collection) (*GroupCheck, error) {
+	g := v.Mapping.GroupOf(col.Name)
+	if g == nil || v.Config.Referential == ReferentialOff {
+		return nil, nil
+	}
+	check := &GroupCheck{Group: g.Name, Match: true}
+	if snaps := v.groupSnapshots(g); len(snaps) > 1 {
+		check.Message = fmt.Sprintf("members were read from different source snapshots (%s); migrate the group again in one run", strings.Join(snaps, ", "))
+		check.Match = false
+	}
+
+	lookup := target.CapabilitiesOf(v.Target).Lookup
+	for _, parent := range v.Mapping.Collections {
+		if parent.Name == col.Name || !slices.Contains(g.Collections, parent.Name) {
+			continue
+		}
+		for _, ref := range parent.References {
+			if ref.SourceTable != col.SourceTable {
+				continue
+			}
+			gr := GroupRelationship{Parent: parent.Name}
+			gr.Field, gr.ParentField, gr.Message = referenceFields(col, parent, ref)
+			if gr.Message == "" && !lookup {
+				gr.Message = "the target does not support $lookup"
+			}
+			if gr.Message != "" {
+				check.Relationships = append(check.Relationships, gr)
+				continue
+			}
+
+			n, err := v.Source.ReferencedRowCount(ctx, source.Reference{
+				Table:         col.SourceTable,
+				Columns:       ref.JoinKeys(),
+				Filter:        col.LiveFilter(),
+				ParentTable:   parent.SourceTable,
+				ParentColumns: ref.ParentKeys(),
+				ParentFilter:  parent.LiveFilter(),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("counting source references from %s to %s: %w", col.Name, parent.Name, err)
+			}
+			count, err := v.Target.CountOrphans(ctx, target.OrphanQuery{
+				Collection:  col.Name,
+				Field:       gr.Field,
+				Parent:      parent.Name,
+				ParentField: gr.ParentField,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("checking references from %s to %s: %w", col.Name, parent.Name, err)
+			}
+			gr.SourceCount, gr.TargetCount = n, count.Checked-count.Orphans
+			if gr.SourceCount != gr.TargetCount {
+				gr.Message = fmt.Sprintf("%d source rows have their %s, %d documents do", gr.SourceCount, parent.Name, gr.TargetCount)
+				check.Match = false
+			}
+			check.Relationships = append(check.Relationships, gr)
+		}
+	}
+	if len(check.Relationships) == 0 && check.Message == "" {
+		return nil, nil
+	}
+	return check, nil
+}
+
+// groupSnapshots returns the distinct source snapshots the group's members
+// were recorded as read from.
+func (v *Validator) groupSnapshots(g *mapping.ConsistencyGroup) []string {
+	var snaps []string
+	for _, name := range g.Collections {
+		if s := v.Snapshots[name]; s != "" && !slices.Contains(snaps, s) {
+			snaps = append(snaps, s)
+		}
+	}
+	sort.Strings(snaps)
+	return snaps
+}
+
+// checkGroup adds the consistency group check to cr when the collection
+// is in a group.
+func (v *Validator) checkGroup(ctx context.Context, col mapping.Collection, cr *CollectionResult) error {
+	gc, err := v.validateGroup(ctx, col)
+	if err != nil || gc == nil {
+		return err
+	}
+	cr.GroupCheck = gc
+	if !gc.Match {
+		cr.Status = "FAIL"
+	}
+	v.notify(col.Name, "consistency_group", gc.Match)
+	return nil
+}
@@ -0,0 +1,203 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/transform"
+)
+
+// MaskCheck holds the result of checking that masked fields never hold a
+// source value: for each masked column, source values are sampled and the
+// collection is searched for documents holding any of them in the field.
+// Any such document fails the check.
+type MaskCheck struct {
+	Fields []MaskField `json:"fields"`
+	Leaked int         `json:"leaked"` // documents holding a sampled source value
+	Match  bool        `json:"match"`
+}
+
+// MaskField is the check of one masked column.
+type MaskField struct {
+	Field     string `json:"field"`
+	Column    string `json:"column"` // table.column
+	Operation string `json:"operation"`
+	Sampled   int    `json:"sampled"` // source values masking changes
+	Leaked    int    `json:"leaked"`  // documents holding one of them
+}
+
+// maskedField is a masked column and the document path it is written to.
+type maskedField struct {
+	table string
+	mask  mapping.Transformation
+	path  string
+}
+
+// maskedFields returns the masked columns of the collection and of its
+// embedded tables, except those offloaded out of the document.
+func maskedFields(col mapping.Collection) []maskedField {
+	var out []maskedField
+	for _, t := range col.Transformations {
+		if !transform.IsMask(t.Operation) {
+			continue
+		}
+		if field, ok := col.RootField(t.SourceField); ok {
+			out = append(out, maskedField{table: col.SourceTable, mask: t, path: field})
+		}
+	}
+	var walk func(embs []mapping.Embedded, prefix string)
+	walk = func(embs []mapping.Embedded, prefix string) {
+		for i := range embs {
+			emb := &embs[i]
+			if emb.Offload != nil {
+				continue
+			}
+			base := prefix + emb.FieldName
+			for _, t := range emb.Transformations {
+				if !transform.IsMask(t.Operation) {
+					continue
+				}
+				if sub, ok := emb.SubField(t.SourceField); ok {
+					out = append(out, maskedField{table: emb.SourceTable, mask: t, path: base + "." + sub})
+				}
+			}
+			walk(emb.Embedded, base+".")
+		}
+	}
+	walk(col.Embedded, "")
+	return out
+}
+
+// validateMasks samples the source values of the collection's masked
+// columns and counts the documents still holding one, or returns nil when
+// nothing in the collection is masked.
+func (v *Validator) validateMasks(ctx context.Context, col mapping.Collection) (*MaskCheck, error) {
+	fields := maskedFields(col)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	sampleSize := v.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = 100
+	}
+
+	check := &MaskCheck{Match: true}
+	for _, f := range fields {
+		column := f.mask.SourceField
+		rows, err := v.Source.SampleRows(ctx, f.table, []string{column}, sampleSize)
+		if err != nil {
+			return nil, fmt.Errorf("sampling %s.%s: %w", f.table, column, err)
+		}
+		// Values masking leaves as they are cannot leak
+		originals := make(map[string]bool)
+		var in []interface{}
+		for _, row := range rows {
+			val := row[column]
+			if val == nil || fmt.Sprint(transform.MaskValue(f.mask, val)) == fmt.Sprint(val) {
+				continue
+			}
+			if key := fmt.Sprint(val); !originals[key] {
+				originals[key] = true
+				in = append(in, val)
+			}
+		}
+		mf := MaskField{Field: f.path, Column: f.table + "." + column, Operation: f.mask.Operation, Sampled: len(in)}
+		if len(in) > 0 {
+			docs, err := v.Target.FindDocuments(ctx, col.Name, map[string]interface{}{f.path: map[string]interface{}{"$in": in}})
+			if err != nil {
+				return nil, fmt.Errorf("searching %s for %s: %w", col.Name, f.path, err)
+			}
+			for _, doc := range docs {
+				for _, val := range fieldValues(doc, f.path) {
+					if originals[fmt.Sprint(val)] {
+						mf.Leaked++
+						break
+					}
+				}
+			}
+		}
+		check.Fields = append(check.Fields, mf)
+		check.Leaked += mf.Leaked
+	}
+	check.Match = check.Leaked == 0
+	return check, nil
+}
+
+// checkMasks adds the masking check to cr when the collection masks
+// columns.
+func (v *Validator) checkMasks(ctx context.Context, col mapping.Collection, cr *CollectionResult) error {
+	mc, err := v.validateMasks(ctx, col)
+	if err != nil || mc == nil {
+		return err
+	}
+	cr.MaskCheck = mc
+	if !mc.Match {
+		cr.Status = "FAIL"
+	}
+	v.notify(col.Name, "masking", mc.Match)
+	return nil
+}
+
+// masked reports whether one of the transformations masks column.
+func masked(transforms []mapping.Transformation, column string) bool {
+	for _, t := range transforms {
+		if transform.IsMask(t.Operation) && t.SourceField == column {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldValues returns the values at a dotted path of a document, following
+// arrays of subdocuments as MongoDB queries do.
+func fieldValues(v interface{}, path string) []interface{} {
+	if path == "" {
+		if arr, ok := v.(bson.A); ok {
+			return arr
+		}
+		if arr, ok := v.([]interface{}); ok {
+			return arr
+		}
+		return []interface{}{v}
+	}
+	part, rest, _ := strings.Cut(path, ".")
+	switch m := v.(type) {
+	case map[string]interface{}:
+		if sub, ok := m[part]; ok {
+			return fieldValues(sub, rest)
+		}
+	case bson.M:
+		if sub, ok := m[part]; ok {
+			return fieldValues(sub, rest)
+		}
+	case bson.D:
+		for _, e := range m {
+			if e.Key == part {
+				return fieldValues(e.Value, rest)
+			}
+		}
+	case bson.A:
+		return arrayValues(m, path)
+	case []interface{}:
+		return arrayValues(m, path)
+	case []map[string]interface{}:
+		var out []interface{}
+		for _, el := range m {
+			out = append(out, fieldValues(el, path)...)
+		}
+		return out
+	}
+	return nil
+}
+
+func arrayValues(arr []interface{}, path string) []interface{} {
+	var out []interface{}
+	for _, el := range arr {
+		out = append(out, fieldValues(el, path)...)
+	}
+	return out
+}
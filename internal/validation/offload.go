@@ -0,0 +1,113 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+)
+
+// OffloadCheck holds the results for a collection's embedded fields that
+// are stored outside its documents.
+type OffloadCheck struct {
+	Fields []OffloadFieldCheck `json:"fields"`
+	Match  bool                `json:"match"`
+}
+
+// OffloadFieldCheck compares one offloaded field with its source table.
+type OffloadFieldCheck struct {
+	Field      string `json:"field"`
+	Strategy   string `json:"strategy"`
+	Target     string `json:"target"`      // side collection or GridFS bucket
+	SourceRows int64  `json:"source_rows"` // embedded table rows
+	TargetDocs int64  `json:"target_docs"` // side collection documents, or files in the bucket
+	Match      bool   `json:"match"`
+	Message    string `json:"message,omitempty"`
+}
+
+// validateOffloads checks the collection's offloaded fields, or returns nil
+// when it has none. A side collection must hold a document for every
+// embedded row. A GridFS bucket may be shared, so for it the sampled
+// documents are checked to hold a reference rather than the value itself.
+func (v *Validator) validateOffloads(ctx context.Context, col mapping.Collection) (*OffloadCheck, error) {
+	var check *OffloadCheck
+	for _, emb := range col.Embedded {
+		if emb.Offload == nil {
+			continue
+		}
+		if check == nil {
+			check = &OffloadCheck{Match: true}
+		}
+		fc := OffloadFieldCheck{
+			Field:    emb.FieldName,
+			Strategy: emb.Offload.Strategy,
+			Target:   mapping.OffloadTarget(col.Name, emb),
+			Match:    true,
+		}
+
+		rows, err := v.Source.FilteredRowCount(ctx, emb.SourceTable, emb.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("counting source rows for %s: %w", emb.SourceTable, err)
+		}
+		fc.SourceRows = rows
+
+		switch emb.Offload.Strategy {
+		case mapping.OffloadCollection:
+			docs, err := v.Target.CountDocuments(ctx, fc.Target)
+			if err != nil {
+				return nil, fmt.Errorf("counting target docs for %s: %w", fc.Target, err)
+			}
+			fc.TargetDocs = docs
+			if docs != rows {
+				fc.Match = false
+				fc.Message = fmt.Sprintf("count mismatch: source=%d, side collection=%d (diff=%d)", rows, docs, rows-docs)
+			}
+		case mapping.OffloadGridFS:
+			files, err := v.Target.CountDocuments(ctx, fc.Target+".files")
+			if err != nil {
+				return nil, fmt.Errorf("counting GridFS files in %s: %w", fc.Target, err)
+			}
+			fc.TargetDocs = files
+			inline, err := v.inlineOffloads(ctx, col.Name, emb.FieldName)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case inline > 0:
+				fc.Match = false
+				fc.Message = fmt.Sprintf("%d sampled documents hold %s inline instead of a GridFS file ID", inline, emb.FieldName)
+			case rows > 0 && files == 0:
+				fc.Match = false
+				fc.Message = fmt.Sprintf("no files in GridFS bucket %s", fc.Target)
+			}
+		}
+
+		if !fc.Match {
+			check.Match = false
+		}
+		check.Fields = append(check.Fields, fc)
+	}
+	return check, nil
+}
+
+// inlineOffloads counts sampled documents whose offloaded field holds an
+// array or subdocument rather than a reference.
+func (v *Validator) inlineOffloads(ctx context.Context, collection, field string) (int, error) {
+	sampleSize := v.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = 100
+	}
+	docs, err := v.Target.SampleDocuments(ctx, collection, sampleSize)
+	if err != nil {
+		return 0, fmt.Errorf("sampling documents from %s: %w", collection, err)
+	}
+	var inline int
+	for _, doc := range docs {
+		switch doc[field].(type) {
+		case nil, string:
+		default:
+			inline++
+		}
+	}
+	return inline, nil
+}
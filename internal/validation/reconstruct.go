@@ -9,21 +9,21 @@ import (
 
 // ReconstructSQL builds a SQL SELECT that reconstructs the data for a collection
 // by joining the root table with embedded tables according to the mapping.
+// Tables with a row filter are read through a filtered subquery.
 // This is primarily used for documentation/debugging purposes.
 func ReconstructSQL(col mapping.Collection, schemaName string) string {
 	rootAlias := "t0"
 	var joins []string
 	var aliasIdx int
 
-	rootTable := qualifiedTable(schemaName, col.SourceTable)
+	rootTable := filteredTable(schemaName, col.SourceTable, col.LiveFilter())
 	selectCols := []string{rootAlias + ".*"}
 
 	for _, emb := range col.Embedded {
 		aliasIdx++
 		alias := fmt.Sprintf("t%d", aliasIdx)
-		joinTable := qualifiedTable(schemaName, emb.SourceTable)
-		join := fmt.Sprintf("LEFT JOIN %s %s ON %s.%s = %s.%s",
-			joinTable, alias, alias, emb.JoinColumn, rootAlias, emb.ParentColumn)
+		joinTable := filteredTable(schemaName, emb.SourceTable, emb.Filter)
+		join := fmt.Sprintf("LEFT JOIN %s %s ON %s", joinTable, alias, joinOn(emb, alias, rootAlias))
 		joins = append(joins, join)
 		selectCols = append(selectCols, alias+".*")
 
@@ -46,9 +46,8 @@ func buildNestedJoins(joins *[]string, selectCols *[]string, embedded []mapping.
 	for _, emb := range embedded {
 		aliasIdx++
 		alias := fmt.Sprintf("t%d", aliasIdx)
-		joinTable := qualifiedTable(schemaName, emb.SourceTable)
-		join := fmt.Sprintf("LEFT JOIN %s %s ON %s.%s = %s.%s",
-			joinTable, alias, alias, emb.JoinColumn, parentAlias, emb.ParentColumn)
+		joinTable := filteredTable(schemaName, emb.SourceTable, emb.Filter)
+		join := fmt.Sprintf("LEFT JOIN %s %s ON %s", joinTable, alias, joinOn(emb, alias, parentAlias))
 		*joins = append(*joins, join)
 		*selectCols = append(*selectCols, alias+".*")
 
@@ -57,9 +56,32 @@ func buildNestedJoins(joins *[]string, selectCols *[]string, embedded []mapping.
 	return aliasIdx
 }
 
+// joinOn returns the condition joining an embedded table to its parent on
+// each pair of key columns.
+func joinOn(emb mapping.Embedded, alias, parentAlias string) string {
+	parent := emb.ParentKeys()
+	var conds []string
+	for i, c := range emb.JoinKeys() {
+		if i < len(parent) {
+			conds = append(conds, fmt.Sprintf("%s.%s = %s.%s", alias, c, parentAlias, parent[i]))
+		}
+	}
+	return strings.Join(conds, " AND ")
+}
+
 func qualifiedTable(schemaName, table string) string {
 	if schemaName == "" {
 		return table
 	}
 	return schemaName + "." + table
 }
+
+// filteredTable returns the qualified table, or a subquery reading only the
+// rows that match filter. The caller supplies the alias.
+func filteredTable(schemaName, table, filter string) string {
+	t := qualifiedTable(schemaName, table)
+	if filter == "" {
+		return t
+	}
+	return fmt.Sprintf("(SELECT * FROM %s WHERE %s)", t, filter)
+}
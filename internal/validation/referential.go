@@ -0,0 +1,163 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// How the referential check reads the referencing collections.
+const (
+	// ReferentialSample checks the references of sampled documents.
+	ReferentialSample = "sample"
+	// ReferentialFull checks the reference of every document.
+	ReferentialFull = "full"
+	// ReferentialOff skips the referential check.
+	ReferentialOff = "off"
+)
+
+// ReferentialCheck holds the results for the references a collection holds
+// to the documents of other collections, one per mapping.Reference whose
+// source table the collection migrates.
+type ReferentialCheck struct {
+	Relationships []RelationshipCheck `json:"relationships"`
+	Sampled       bool                `json:"sampled"` // only sampled documents were checked
+	Orphans       int64               `json:"orphans"`
+	Match         bool                `json:"match"`
+}
+
+// RelationshipCheck counts the documents whose reference has no parent.
+type RelationshipCheck struct {
+	Field       string        `json:"field"`
+	Parent      string        `json:"parent"`
+	ParentField string        `json:"parent_field"`
+	Checked     int64         `json:"checked"`
+	Orphans     int64         `json:"orphans"`
+	Examples    []interface{} `json:"examples,omitempty"`
+	Message     string        `json:"message,omitempty"`
+}
+
+// Relationship names the reference as collection.field -> parent.field.
+func (r RelationshipCheck) Relationship(collection string) string {
+	return fmt.Sprintf("%s.%s -> %s.%s", collection, r.Field, r.Parent, r.ParentField)
+}
+
+// validateReferences checks that the parent of every reference the
+// collection holds exists in the target, or returns nil when the collection
+// holds none or the check is off. References on composite keys, on
+// columns the mapping excludes, or in a target without $lookup cannot be
+// followed and are reported without counts.
+func (v *Validator) validateReferences(ctx context.Context, col mapping.Collection) (*ReferentialCheck, error) {
+	if v.Config.Referential == ReferentialOff {
+		return nil, nil
+	}
+	sample := 0
+	if v.Config.Referential != ReferentialFull {
+		sample = v.SampleSize
+		if sample <= 0 {
+			sample = 100
+		}
+	}
+
+	lookup := target.CapabilitiesOf(v.Target).Lookup
+	var check *ReferentialCheck
+	for _, parent := range v.Mapping.Collections {
+		for _, ref := range parent.References {
+			if ref.SourceTable != col.SourceTable {
+				continue
+			}
+			if check == nil {
+				check = &ReferentialCheck{Sampled: sample > 0, Match: true}
+			}
+			rc := RelationshipCheck{Parent: parent.Name}
+			field, parentField, msg := referenceFields(col, parent, ref)
+			rc.Field, rc.ParentField, rc.Message = field, parentField, msg
+			if msg == "" && !lookup {
+				rc.Message = "the target does not support $lookup"
+			}
+			if rc.Message == "" {
+				count, err := v.Target.CountOrphans(ctx, target.OrphanQuery{
+					Collection:  col.Name,
+					Field:       field,
+					Parent:      parent.Name,
+					ParentField: parentField,
+					Sample:      sample,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("checking references from %s to %s: %w", col.Name, parent.Name, err)
+				}
+				rc.Checked, rc.Orphans, rc.Examples = count.Checked, count.Orphans, count.Examples
+				if rc.Orphans > 0 {
+					rc.Message = fmt.Sprintf("%d of %d documents refer to a missing %s", rc.Orphans, rc.Checked, parent.Name)
+					check.Orphans += rc.Orphans
+					check.Match = false
+				}
+			}
+			check.Relationships = append(check.Relationships, rc)
+		}
+	}
+	return check, nil
+}
+
+// referenceFields returns the document fields that hold the reference's
+// join column in the referencing collection and its parent column in the
+// parent collection, or why the reference cannot be checked.
+func referenceFields(col, parent mapping.Collection, ref mapping.Reference) (field, parentField, reason string) {
+	join, parentCols := ref.JoinKeys(), ref.ParentKeys()
+	if len(join) != 1 || len(parentCols) != 1 {
+		return strings.Join(join, ","), strings.Join(parentCols, ","), "composite references are not checked"
+	}
+	field, ok := col.RootField(join[0])
+	if !ok {
+		return join[0], parentCols[0], fmt.Sprintf("%s is not migrated", join[0])
+	}
+	parentField, ok = parent.RootField(parentCols[0])
+	if !ok {
+		return field, parentCols[0], fmt.Sprintf("%s.%s is not migrated", parent.Name, parentCols[0])
+	}
+	return field, parentField, ""
+}
+
+// checkReferences adds the referential check to cr when the collection
+// holds references, and the consistency group check when it is in a group.
+func (v *Validator) checkReferences(ctx context.Context, col mapping.Collection, cr *CollectionResult) error {
+	rc, err := v.validateReferences(ctx, col)
+	if err != nil {
+		return err
+	}
+	if rc != nil {
+		cr.ReferentialCheck = rc
+		if !rc.Match {
+			cr.Status = "FAIL"
+		}
+		v.notify(col.Name, "referential", rc.Match)
+	}
+	return v.checkGroup(ctx, col, cr)
+}
+
+// ValidateReferences runs only the referential integrity validation,
+// including the consistency group checks.
+func (v *Validator) ValidateReferences(ctx context.Context) (*Result, error) {
+	if err := v.Config.Validate(); err != nil {
+		return nil, err
+	}
+	result := &Result{StartedAt: time.Now()}
+
+	for _, col := range v.Mapping.Collections {
+		cr := CollectionResult{Name: col.Name, Status: "PASS"}
+		if err := v.checkReferences(ctx, col, &cr); err != nil {
+			return nil, err
+		}
+		if cr.ReferentialCheck != nil || cr.GroupCheck != nil {
+			result.Collections = append(result.Collections, cr)
+		}
+	}
+
+	result.CompletedAt = time.Now()
+	result.Status = computeOverallStatus(result.Collections)
+	return result, nil
+}
@@ -0,0 +1,273 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// Remediations offered for a collection that failed validation.
+const (
+	ActionRemigrate     = "remigrate"      // empty the collection and migrate it again
+	ActionAdjustMapping = "adjust_mapping" // change the mapping or type mapping, then re-migrate
+	ActionAccept        = "accept"         // keep the data as it is, with a justification
+)
+
+// Actions lists the remediations in the order they are offered.
+var Actions = []string{ActionRemigrate, ActionAdjustMapping, ActionAccept}
+
+// ValidAction reports whether action is one of Actions.
+func ValidAction(action string) bool {
+	for _, a := range Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Probable causes of a validation failure.
+const (
+	CauseFilter        = "filter_mismatch"
+	CauseTypeCoercion  = "type_coercion"
+	CauseOversized     = "oversized_documents"
+	CauseSourceChanged = "source_changed"
+	CauseMissingFields = "missing_fields"
+	CauseReferences    = "missing_references"
+	CauseShardKey      = "shard_key"
+	CauseOffload       = "offload"
+)
+
+// Cause is a probable reason a check failed, with the remediation most
+// likely to fix it.
+type Cause struct {
+	Kind    string `json:"kind"`
+	Check   string `json:"check"` // the failed check it explains
+	Message string `json:"message"`
+	Action  string `json:"action"`
+}
+
+// Diagnosis lists the probable causes of a collection's validation failure,
+// most likely first.
+type Diagnosis struct {
+	Collection string  `json:"collection"`
+	Status     string  `json:"status"`
+	Causes     []Cause `json:"causes"`
+}
+
+// Suggested returns the remediation of the most likely cause, or
+// ActionRemigrate when nothing was diagnosed.
+func (d Diagnosis) Suggested() string {
+	if len(d.Causes) == 0 {
+		return ActionRemigrate
+	}
+	return d.Causes[0].Action
+}
+
+// roundingTolerance is the relative difference under which a sum mismatch
+// is put down to floating point rounding.
+const roundingTolerance = 1e-6
+
+// Diagnose explains why a collection failed validation from its checks,
+// its mapping and, when known, its document size estimate. It returns nil
+// for a collection that did not fail.
+func Diagnose(cr CollectionResult, col mapping.Collection, est *mapping.CollectionSizeEstimate) *Diagnosis {
+	if cr.Status != "FAIL" {
+		return nil
+	}
+	d := &Diagnosis{Collection: cr.Name, Status: cr.Status}
+	add := func(kind, check, action, format string, args ...any) {
+		d.Causes = append(d.Causes, Cause{Kind: kind, Check: check, Action: action, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if rc := cr.RowCountCheck; rc != nil && !rc.Match {
+		diff := rc.TargetCount - rc.SourceCount
+		if diff < 0 && est != nil {
+			switch {
+			case est.ExceedsLimit:
+				add(CauseOversized, "row_count", ActionAdjustMapping,
+					"%d source rows have no document; documents are estimated at up to %.1f MB and MongoDB rejects those over 16 MB. Offload large embedded fields",
+					-diff, float64(est.MaxDocSizeBytes)/(1024*1024))
+			case len(est.InlineLOBs) > 0:
+				add(CauseOversized, "row_count", ActionAdjustMapping,
+					"%d source rows have no document; large objects stored in the document (%s) can take it over MongoDB's 16 MB limit. Offload or skip them",
+					-diff, strings.Join(est.InlineLOBs, ", "))
+			}
+		}
+		if filter := col.LiveFilter(); filter != "" {
+			add(CauseFilter, "row_count", ActionRemigrate,
+				"the row filter (%s) keeps %d source rows but the target has %d documents; the filter may have changed since the migration",
+				filter, rc.SourceCount, rc.TargetCount)
+		}
+		if diff < 0 {
+			add(CauseSourceChanged, "row_count", ActionRemigrate,
+				"%d source rows have no document: rows written to the source since the migration, or a run cut off", -diff)
+		} else {
+			add(CauseSourceChanged, "row_count", ActionRemigrate,
+				"%d documents more than source rows: rows deleted from the source since the migration, or documents written twice by a repeated run", diff)
+		}
+	}
+
+	if cc := cr.ChecksumCheck; cc != nil && !cc.Match {
+		add(CauseSourceChanged, "checksum", ActionRemigrate,
+			"%d of %d key ranges differ: rows changed in the source since the migration", cc.MismatchedChunks, cc.Chunks)
+	}
+
+	if tc := cr.TypeCheck; tc != nil && !tc.Match {
+		for _, f := range tc.Fields {
+			if f.Mismatched == 0 {
+				continue
+			}
+			add(CauseTypeCoercion, "types", ActionAdjustMapping,
+				"%s (%s) was written as %s in %d of %d sampled documents, not %s; change its type mapping, or re-migrate if the type mapping changed since",
+				f.Field, f.SourceType, actualTypes(f.Actual), f.Mismatched, f.Checked, f.Expected)
+		}
+	}
+
+	if sc := cr.SampleCheck; sc != nil && sc.MismatchCount > 0 {
+		fields := make(map[string]bool)
+		for _, m := range sc.Mismatches {
+			fields[m.Field] = true
+		}
+		add(CauseMissingFields, "sample", ActionRemigrate,
+			"%d of %d sampled documents lack fields of the mapping (%s); the mapping may have changed since the migration",
+			sc.MismatchCount, sc.Checked, strings.Join(sortedSet(fields), ", "))
+	}
+
+	if ac := cr.AggregateCheck; ac != nil && !ac.Match {
+		for _, a := range ac.Checks {
+			if a.Match {
+				continue
+			}
+			if a.Type == "sum" && relativeDiff(a.SourceValue, a.TargetValue) < roundingTolerance {
+				add(CauseTypeCoercion, "aggregate", ActionAccept,
+					"the sum of %s differs by %g (%g in the source, %g in the target): rounding from storing exact numbers as doubles",
+					a.Column, a.TargetValue-a.SourceValue, a.SourceValue, a.TargetValue)
+				continue
+			}
+			if a.Type == "decimal_sum" {
+				add(CauseTypeCoercion, "aggregate", ActionRemigrate,
+					"the exact sum of %s is %s in the source and %s in the target: decimal values were rounded on the way; check it is written as Decimal128 and re-migrate",
+					a.Column, a.SourceExact, a.TargetExact)
+				continue
+			}
+			add(CauseSourceChanged, "aggregate", ActionRemigrate,
+				"the %s of %s is %g in the source and %g in the target", a.Type, a.Column, a.SourceValue, a.TargetValue)
+		}
+	}
+
+	if oc := cr.OffloadCheck; oc != nil && !oc.Match {
+		for _, f := range oc.Fields {
+			if !f.Match {
+				add(CauseOffload, "offload", ActionRemigrate,
+					"offloaded field %s holds %d of %d rows in %s", f.Field, f.TargetDocs, f.SourceRows, f.Target)
+			}
+		}
+	}
+
+	if rc := cr.ReferentialCheck; rc != nil && !rc.Match {
+		for _, r := range rc.Relationships {
+			if r.Orphans == 0 {
+				continue
+			}
+			add(CauseReferences, "referential", ActionRemigrate,
+				"%d of %d checked documents reference a missing %s (%s): the parent rows were filtered out, or the collections were migrated at different times",
+				r.Orphans, r.Checked, r.Parent, r.Relationship(cr.Name))
+		}
+	}
+
+	if gc := cr.GroupCheck; gc != nil && !gc.Match {
+		msg := gc.Message
+		if msg == "" {
+			msg = "references within the group do not resolve as they did in the source"
+		}
+		add(CauseReferences, "consistency_group", ActionRemigrate, "consistency group %s: %s", gc.Group, msg)
+	}
+
+	if sk := cr.ShardKeyCheck; sk != nil && !sk.Match {
+		add(CauseShardKey, "shard_key", ActionAdjustMapping,
+			"%.1f%% of sampled documents lack shard key fields %s; map the columns to them or pick another shard key",
+			sk.MissingPercent, strings.Join(sk.Fields, ", "))
+	}
+
+	sortCauses(d.Causes)
+	return d
+}
+
+// DiagnoseResult diagnoses each collection that failed in result, in the
+// order they were validated. The mapping and size estimates may be nil.
+func DiagnoseResult(result *Result, m *mapping.Mapping, ests []mapping.CollectionSizeEstimate) []Diagnosis {
+	var diagnoses []Diagnosis
+	for _, cr := range result.Collections {
+		col := mapping.Collection{Name: cr.Name}
+		if m != nil {
+			for _, c := range m.Collections {
+				if c.Name == cr.Name {
+					col = c
+				}
+			}
+		}
+		var est *mapping.CollectionSizeEstimate
+		for i := range ests {
+			if ests[i].Collection == cr.Name {
+				est = &ests[i]
+			}
+		}
+		if d := Diagnose(cr, col, est); d != nil {
+			diagnoses = append(diagnoses, *d)
+		}
+	}
+	return diagnoses
+}
+
+// causeRank orders causes from the most to the least specific, so a cause
+// that only applies in particular circumstances is suggested first.
+var causeRank = map[string]int{
+	CauseOversized:     0,
+	CauseFilter:        1,
+	CauseTypeCoercion:  2,
+	CauseShardKey:      3,
+	CauseReferences:    4,
+	CauseOffload:       5,
+	CauseMissingFields: 6,
+	CauseSourceChanged: 7,
+}
+
+func sortCauses(causes []Cause) {
+	sort.SliceStable(causes, func(i, j int) bool {
+		return causeRank[causes[i].Kind] < causeRank[causes[j].Kind]
+	})
+}
+
+func actualTypes(actual map[typemap.BSONType]int) string {
+	var types []string
+	for t := range actual {
+		types = append(types, string(t))
+	}
+	sort.Strings(types)
+	if len(types) == 0 {
+		return "another type"
+	}
+	return strings.Join(types, " or ")
+}
+
+func relativeDiff(a, b float64) float64 {
+	scale := math.Max(math.Abs(a), math.Abs(b))
+	if scale == 0 {
+		return 0
+	}
+	return math.Abs(a-b) / scale
+}
+
+func sortedSet(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -11,14 +11,18 @@ import (
 type RowCountCheck struct {
 	SourceCount int64  `json:"source_count"`
 	TargetCount int64  `json:"target_count"`
+	Skipped     int64  `json:"skipped,omitempty"`     // source rows the error policy skipped
+	Quarantined int64  `json:"quarantined,omitempty"` // source rows recorded in mapping.ErrorCollection
 	Match       bool   `json:"match"`
 	Message     string `json:"message,omitempty"`
 }
 
 // validateRowCount compares the source table row count against the target collection document count.
 // For denormalized collections: expected count = root table row count (embedded children don't add documents).
+// Only rows matching the collection's row filter are counted, less those the collection's error policy
+// skipped or quarantined.
 func (v *Validator) validateRowCount(ctx context.Context, col mapping.Collection) (*RowCountCheck, error) {
-	sourceCount, err := v.Source.RowCount(ctx, col.SourceTable)
+	sourceCount, err := v.Source.FilteredRowCount(ctx, col.SourceTable, col.LiveFilter())
 	if err != nil {
 		return nil, fmt.Errorf("counting source rows for %s: %w", col.SourceTable, err)
 	}
@@ -31,13 +35,43 @@ func (v *Validator) validateRowCount(ctx context.Context, col mapping.Collection
 	check := &RowCountCheck{
 		SourceCount: sourceCount,
 		TargetCount: targetCount,
-		Match:       sourceCount == targetCount,
+		Skipped:     v.RowsSkipped[col.Name],
 	}
+	if col.ErrorPolicy() == mapping.OnErrorQuarantine {
+		if check.Quarantined, err = v.quarantinedRows(ctx, col.Name); err != nil {
+			return nil, err
+		}
+	}
+	expected := sourceCount - check.Skipped - check.Quarantined
+	check.Match = expected == targetCount
 
 	if !check.Match {
 		check.Message = fmt.Sprintf("count mismatch: source=%d, target=%d (diff=%d)",
-			sourceCount, targetCount, sourceCount-targetCount)
+			expected, targetCount, expected-targetCount)
+	}
+	if left := check.Skipped + check.Quarantined; left > 0 {
+		if check.Message != "" {
+			check.Message += "; "
+		}
+		check.Message += fmt.Sprintf("%d of %d source rows left out by the error policy (%d skipped, %d quarantined in %s)",
+			left, sourceCount, check.Skipped, check.Quarantined, mapping.ErrorCollection)
 	}
 
 	return check, nil
 }
+
+// quarantinedRows counts the rows of a collection recorded in
+// mapping.ErrorCollection.
+func (v *Validator) quarantinedRows(ctx context.Context, collection string) (int64, error) {
+	docs, err := v.Target.FindDocuments(ctx, mapping.ErrorCollection, map[string]interface{}{"collection": collection})
+	if err != nil {
+		return 0, fmt.Errorf("counting quarantined rows of %s: %w", collection, err)
+	}
+	var n int64
+	for _, d := range docs {
+		if d["collection"] == collection {
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,433 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// Rule types.
+const (
+	RuleAggregate = "aggregate" // an aggregate, optionally per group, matches
+	RuleNotNull   = "not_null"  // no document holds null or lacks the field
+)
+
+// Aggregate functions of aggregate rules.
+var ruleFunctions = map[string]string{
+	"count": "$sum",
+	"sum":   "$sum",
+	"min":   "$min",
+	"max":   "$max",
+	"avg":   "$avg",
+}
+
+// RulesFileName is the rules file validation reads from the project
+// directory when the config names none.
+const RulesFileName = "validation-rules.yaml"
+
+// maxRuleMismatches bounds the differing groups a rule check reports.
+const maxRuleMismatches = 20
+
+// Rule is a custom assertion about a collection, written in terms of the
+// source columns of its root table. The SQL run on the source and the
+// aggregation pipeline run on the target are generated from the mapping.
+type Rule struct {
+	Name       string                 `yaml:"name" json:"name"`
+	Collection string                 `yaml:"collection" json:"collection"`
+	Type       string                 `yaml:"type" json:"type"`                             // aggregate or not_null
+	Function   string                 `yaml:"function,omitempty" json:"function,omitempty"` // aggregate: count, sum, min, max or avg
+	Column     string                 `yaml:"column,omitempty" json:"column,omitempty"`     // aggregated or required column; count takes none
+	GroupBy    []string               `yaml:"group_by,omitempty" json:"group_by,omitempty"` // aggregate: columns compared per group
+	Filter     string                 `yaml:"filter,omitempty" json:"filter,omitempty"`     // SQL condition on source rows
+	Match      map[string]interface{} `yaml:"match,omitempty" json:"match,omitempty"`       // the same condition as a $match query on documents
+}
+
+// RuleSet is a rules file.
+type RuleSet struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// LoadRules reads a rules file.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules file: %w", err)
+	}
+	var rs RuleSet
+	if err := yaml.Unmarshal(data, &rs); err != nil {
+		return nil, fmt.Errorf("parsing rules file: %w", err)
+	}
+	return rs.Rules, nil
+}
+
+// ValidateRules checks that each rule is complete, names a collection of
+// the mapping and only columns its root table writes to the document.
+func ValidateRules(rules []Rule, m *mapping.Mapping) error {
+	cols := make(map[string]*mapping.Collection, len(m.Collections))
+	for i := range m.Collections {
+		cols[m.Collections[i].Name] = &m.Collections[i]
+	}
+	names := make(map[string]bool, len(rules))
+	var errs []error
+	for i, r := range rules {
+		label := r.Name
+		if label == "" {
+			label = fmt.Sprintf("rule %d", i+1)
+		} else if names[r.Collection+"/"+r.Name] {
+			errs = append(errs, fmt.Errorf("%s: duplicate rule name for collection %s", label, r.Collection))
+		}
+		names[r.Collection+"/"+r.Name] = true
+		if r.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: name is required", label))
+		}
+		col, ok := cols[r.Collection]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: no collection %q in the mapping", label, r.Collection))
+			continue
+		}
+		if _, err := r.Queries(*col, ""); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", label, err))
+		}
+		if (r.Filter == "") != (r.Match == nil) {
+			errs = append(errs, fmt.Errorf("%s: filter and match must be given together", label))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RuleQuery is the SQL and aggregation pipeline a rule runs. Both return
+// rows of the group columns g0, g1... and a value column.
+type RuleQuery struct {
+	SQL      string                   `json:"sql"`
+	Pipeline []map[string]interface{} `json:"pipeline"`
+}
+
+// Queries generates the rule's SQL, reading the collection's root table in
+// schemaName with its row filter, and its pipeline, reading the fields the
+// mapping writes the columns to.
+func (r Rule) Queries(col mapping.Collection, schemaName string) (*RuleQuery, error) {
+	field := func(column string) (string, error) {
+		f, ok := col.RootField(column)
+		if !ok {
+			return "", fmt.Errorf("column %s is not a field of collection %s", column, col.Name)
+		}
+		return f, nil
+	}
+
+	var where []string
+	if f := col.LiveFilter(); f != "" {
+		where = append(where, "("+f+")")
+	}
+	if r.Filter != "" {
+		where = append(where, "("+r.Filter+")")
+	}
+	var pipeline []map[string]interface{}
+	if r.Match != nil {
+		pipeline = append(pipeline, map[string]interface{}{"$match": r.Match})
+	}
+	from := quoteIdent(col.SourceTable)
+	if schemaName != "" {
+		from = quoteIdent(schemaName) + "." + from
+	}
+
+	switch r.Type {
+	case RuleNotNull:
+		if r.Column == "" {
+			return nil, fmt.Errorf("not_null: column is required")
+		}
+		f, err := field(r.Column)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, quoteIdent(r.Column)+" IS NULL")
+		pipeline = append(pipeline,
+			map[string]interface{}{"$match": map[string]interface{}{f: nil}},
+			map[string]interface{}{"$count": "value"},
+		)
+		return &RuleQuery{
+			SQL:      fmt.Sprintf(`SELECT COUNT(*) AS "value" FROM %s WHERE %s`, from, strings.Join(where, " AND ")),
+			Pipeline: pipeline,
+		}, nil
+
+	case RuleAggregate:
+		op, ok := ruleFunctions[r.Function]
+		if !ok {
+			return nil, fmt.Errorf("aggregate: unknown function %q (want count, sum, min, max or avg)", r.Function)
+		}
+		var sqlValue string
+		var acc interface{}
+		if r.Function == "count" {
+			if r.Column != "" {
+				return nil, fmt.Errorf("aggregate: count counts rows and takes no column")
+			}
+			sqlValue, acc = "COUNT(*)", 1
+		} else {
+			if r.Column == "" {
+				return nil, fmt.Errorf("aggregate: %s needs a column", r.Function)
+			}
+			f, err := field(r.Column)
+			if err != nil {
+				return nil, err
+			}
+			sqlValue, acc = fmt.Sprintf("%s(%s)", strings.ToUpper(r.Function), quoteIdent(r.Column)), "$"+f
+		}
+
+		selects := make([]string, 0, len(r.GroupBy)+1)
+		groups := make([]string, 0, len(r.GroupBy))
+		id := make(map[string]interface{}, len(r.GroupBy))
+		project := map[string]interface{}{"_id": 0, "value": 1}
+		for i, g := range r.GroupBy {
+			f, err := field(g)
+			if err != nil {
+				return nil, err
+			}
+			alias := "g" + strconv.Itoa(i)
+			selects = append(selects, fmt.Sprintf(`%s AS "%s"`, quoteIdent(g), alias))
+			groups = append(groups, quoteIdent(g))
+			id[alias] = "$" + f
+			project[alias] = "$_id." + alias
+		}
+		selects = append(selects, sqlValue+` AS "value"`)
+
+		sql := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "), from)
+		if len(where) > 0 {
+			sql += " WHERE " + strings.Join(where, " AND ")
+		}
+		if len(groups) > 0 {
+			sql += " GROUP BY " + strings.Join(groups, ", ")
+		}
+		var groupID interface{}
+		if len(id) > 0 {
+			groupID = id
+		}
+		pipeline = append(pipeline,
+			map[string]interface{}{"$group": map[string]interface{}{"_id": groupID, "value": map[string]interface{}{op: acc}}},
+			map[string]interface{}{"$project": project},
+		)
+		return &RuleQuery{SQL: sql, Pipeline: pipeline}, nil
+	}
+	return nil, fmt.Errorf("unknown rule type %q (want %s or %s)", r.Type, RuleAggregate, RuleNotNull)
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// RuleCheck holds the result of one rule.
+type RuleCheck struct {
+	Rule        string      `json:"rule"`
+	Type        string      `json:"type"`
+	Query       RuleQuery   `json:"query"`
+	SourceValue string      `json:"source_value,omitempty"` // not_null: source rows with a null
+	TargetValue string      `json:"target_value,omitempty"` // not_null: documents with a null
+	Groups      int         `json:"groups,omitempty"`       // aggregate: groups compared
+	Mismatches  []RuleGroup `json:"mismatches,omitempty"`   // aggregate: the first groups that differ
+	Mismatched  int         `json:"mismatched,omitempty"`   // aggregate: groups that differ
+	Match       bool        `json:"match"`
+}
+
+// Summary describes the outcome of the rule in a line.
+func (rc RuleCheck) Summary() string {
+	if rc.Type == RuleNotNull {
+		return fmt.Sprintf("%s documents with a null, %s source rows", rc.TargetValue, rc.SourceValue)
+	}
+	if rc.Match {
+		return fmt.Sprintf("%d groups match", rc.Groups)
+	}
+	s := fmt.Sprintf("%d of %d groups differ", rc.Mismatched, rc.Groups)
+	if len(rc.Mismatches) > 0 {
+		g := rc.Mismatches[0]
+		name := "total"
+		if len(g.Group) > 0 {
+			name = strings.Join(g.Group, ", ")
+		}
+		s += fmt.Sprintf(" (e.g. %s: source %s, target %s)", name, orMissing(g.SourceValue), orMissing(g.TargetValue))
+	}
+	return s
+}
+
+func orMissing(v string) string {
+	if v == "" {
+		return "missing"
+	}
+	return v
+}
+
+// RuleGroup is a group whose aggregate differs between source and target.
+// A value is empty when the group is missing from that side.
+type RuleGroup struct {
+	Group       []string `json:"group,omitempty"`
+	SourceValue string   `json:"source_value"`
+	TargetValue string   `json:"target_value"`
+}
+
+// rulesFor returns the rules of a collection, in file order.
+func (v *Validator) rulesFor(collection string) []Rule {
+	var out []Rule
+	for _, r := range v.rules {
+		if r.Collection == collection {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// loadRules reads and checks the config's rules file, if any.
+func (v *Validator) loadRules() error {
+	v.rules = nil
+	if v.Config.RulesFile == "" {
+		return nil
+	}
+	rules, err := LoadRules(v.Config.RulesFile)
+	if err != nil {
+		return err
+	}
+	if err := ValidateRules(rules, v.Mapping); err != nil {
+		return fmt.Errorf("rules file %s: %w", v.Config.RulesFile, err)
+	}
+	if len(rules) > 0 {
+		if _, ok := v.Target.(target.Aggregator); !ok {
+			return fmt.Errorf("rules file %s: the target cannot run aggregation pipelines", v.Config.RulesFile)
+		}
+	}
+	v.rules = rules
+	return nil
+}
+
+// checkRules runs the collection's rules and adds their checks to cr.
+func (v *Validator) checkRules(ctx context.Context, col mapping.Collection, cr *CollectionResult) error {
+	for _, r := range v.rulesFor(col.Name) {
+		rc, err := v.runRule(ctx, col, r)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		cr.RuleChecks = append(cr.RuleChecks, *rc)
+		if !rc.Match {
+			cr.Status = "FAIL"
+		}
+		v.notify(col.Name, "rule:"+r.Name, rc.Match)
+	}
+	return nil
+}
+
+func (v *Validator) runRule(ctx context.Context, col mapping.Collection, r Rule) (*RuleCheck, error) {
+	schemaName := ""
+	if v.Schema != nil {
+		schemaName = v.Schema.SchemaName
+	}
+	q, err := r.Queries(col, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	srcRows, err := v.Source.QueryRows(ctx, q.SQL)
+	if err != nil {
+		return nil, fmt.Errorf("source query: %w", err)
+	}
+	tgtRows, err := v.Target.(target.Aggregator).Aggregate(ctx, col.Name, q.Pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("target pipeline: %w", err)
+	}
+
+	rc := &RuleCheck{Rule: r.Name, Type: r.Type, Query: *q}
+	if r.Type == RuleNotNull {
+		rc.SourceValue = canonical(firstValue(srcRows, int64(0)))
+		rc.TargetValue = canonical(firstValue(tgtRows, int64(0)))
+		rc.Match = valuesMatch(rc.TargetValue, "0")
+		return rc, nil
+	}
+
+	// Aggregates are compared per group; an empty source sum is null where
+	// MongoDB's is 0
+	zero := r.Function == "count" || r.Function == "sum"
+	value := func(row map[string]interface{}) string {
+		if row["value"] == nil && zero {
+			return "0"
+		}
+		return canonical(row["value"])
+	}
+	src, srcGroups := ruleGroups(srcRows, len(r.GroupBy), value)
+	tgt, tgtGroups := ruleGroups(tgtRows, len(r.GroupBy), value)
+	keys := make([]string, 0, len(src)+len(tgt))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	for k := range tgt {
+		if _, ok := src[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	rc.Groups = len(keys)
+	for _, k := range keys {
+		s, inSrc := src[k]
+		t, inTgt := tgt[k]
+		if !inTgt && len(r.GroupBy) == 0 {
+			// The pipeline returns nothing when no document matched
+			t, inTgt = "null", true
+			if zero {
+				t = "0"
+			}
+		}
+		if inSrc && inTgt && valuesMatch(s, t) {
+			continue
+		}
+		rc.Mismatched++
+		if len(rc.Mismatches) < maxRuleMismatches {
+			group := srcGroups[k]
+			if !inSrc {
+				group = tgtGroups[k]
+			}
+			rc.Mismatches = append(rc.Mismatches, RuleGroup{Group: group, SourceValue: s, TargetValue: t})
+		}
+	}
+	rc.Match = rc.Mismatched == 0
+	return rc, nil
+}
+
+// ruleGroups indexes result rows by their group columns, returning each
+// group's value and its group values.
+func ruleGroups(rows []map[string]interface{}, n int, value func(map[string]interface{}) string) (map[string]string, map[string][]string) {
+	values := make(map[string]string, len(rows))
+	groups := make(map[string][]string, len(rows))
+	for _, row := range rows {
+		group := make([]string, n)
+		for i := range group {
+			group[i] = canonical(row["g"+strconv.Itoa(i)])
+		}
+		k := strings.Join(group, "\x00")
+		values[k] = value(row)
+		if n > 0 {
+			groups[k] = group
+		}
+	}
+	return values, groups
+}
+
+// firstValue returns the value column of the first row, or def without
+// rows.
+func firstValue(rows []map[string]interface{}, def interface{}) interface{} {
+	if len(rows) == 0 || rows[0]["value"] == nil {
+		return def
+	}
+	return rows[0]["value"]
+}
+
+// valuesMatch compares canonical values, numbers within floatClose.
+func valuesMatch(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	return errA == nil && errB == nil && floatClose(x, y)
+}
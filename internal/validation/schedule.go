@@ -0,0 +1,146 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+)
+
+// StatusSkipped marks a collection the time budget left unvalidated.
+const StatusSkipped = "SKIPPED"
+
+// collectionCheck validates one collection.
+type collectionCheck func(ctx context.Context, col mapping.Collection) (CollectionResult, error)
+
+// run applies check to every collection, Config.Parallelism at a time, in
+// the order of validationOrder. Once the time budget is spent, collections
+// not yet validated, and any interrupted by the deadline, are reported as
+// SKIPPED rather than failing the run. Results keep the mapping's order.
+func (v *Validator) run(ctx context.Context, check collectionCheck) (*Result, error) {
+	result := &Result{StartedAt: time.Now()}
+
+	order, err := v.validationOrder()
+	if err != nil {
+		return nil, err
+	}
+	budget, err := v.Config.timeBudget()
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	budgetCtx := runCtx
+	if budget > 0 {
+		var cancelBudget context.CancelFunc
+		budgetCtx, cancelBudget = context.WithTimeout(runCtx, budget)
+		defer cancelBudget()
+	}
+
+	cols := v.Mapping.Collections
+	results := make([]CollectionResult, len(cols))
+	work := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	skip := func(i int, msg string) {
+		results[i] = CollectionResult{Name: cols[i].Name, Status: StatusSkipped, Message: msg}
+	}
+
+	for w := 0; w < v.Config.parallelism() && w < len(cols); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				if budgetCtx.Err() != nil {
+					skip(i, fmt.Sprintf("not validated: time budget of %s used up", budget))
+					continue
+				}
+				cr, err := check(budgetCtx, cols[i])
+				switch {
+				case err == nil:
+					results[i] = cr
+				case budget > 0 && budgetCtx.Err() == context.DeadlineExceeded && runCtx.Err() == nil:
+					skip(i, fmt.Sprintf("interrupted: time budget of %s used up", budget))
+				default:
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					cancel()
+				}
+			}
+		}()
+	}
+	for _, i := range order {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, cr := range results {
+		if cr.Status == StatusSkipped {
+			result.BudgetExceeded = true
+		}
+	}
+	result.Collections = results
+	result.CompletedAt = time.Now()
+	result.Status = computeOverallStatus(result.Collections)
+	return result, nil
+}
+
+// validationOrder returns the indexes of the mapping's collections in the
+// order they are validated: those named in Config.Priority first, in that
+// order, then the rest largest first by source row count, so the most
+// important ones are covered if the time budget runs out.
+func (v *Validator) validationOrder() ([]int, error) {
+	cols := v.Mapping.Collections
+	index := make(map[string]int, len(cols))
+	for i, c := range cols {
+		index[c.Name] = i
+	}
+
+	var order []int
+	prioritized := make(map[int]bool, len(v.Config.Priority))
+	for _, name := range v.Config.Priority {
+		i, ok := index[name]
+		if !ok {
+			return nil, fmt.Errorf("priority collection %q is not in the mapping", name)
+		}
+		if !prioritized[i] {
+			prioritized[i] = true
+			order = append(order, i)
+		}
+	}
+
+	rows := make(map[string]int64)
+	if v.Schema != nil {
+		for _, t := range v.Schema.Tables {
+			rows[t.Name] = t.RowCount
+		}
+	}
+	var rest []int
+	for i := range cols {
+		if !prioritized[i] {
+			rest = append(rest, i)
+		}
+	}
+	sort.SliceStable(rest, func(a, b int) bool {
+		return rows[cols[rest[a]].SourceTable] > rows[cols[rest[b]].SourceTable]
+	})
+	return append(order, rest...), nil
+}
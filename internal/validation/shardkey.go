@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// ShardKeyCheck holds the result of checking that sampled documents of a
+// sharded collection hold every shard key field. A document missing one, or
+// holding null, is stored on the chunk for null and fails updates that
+// target it by shard key, so any such document fails the check.
+type ShardKeyCheck struct {
+	Fields         []string       `json:"fields"`
+	Sampled        int            `json:"sampled"`
+	Missing        int            `json:"missing"`                  // documents missing at least one field
+	MissingPercent float64        `json:"missing_percent"`          // of the sampled documents
+	MissingFields  map[string]int `json:"missing_fields,omitempty"` // documents missing each field
+	Match          bool           `json:"match"`
+}
+
+// validateShardKey samples the collection's documents and counts those
+// with an absent or null shard key field, or returns nil when the target
+// cannot read shard keys or the collection is not sharded.
+func (v *Validator) validateShardKey(ctx context.Context, col mapping.Collection) (*ShardKeyCheck, error) {
+	reader, ok := v.Target.(target.ShardKeyReader)
+	if !ok {
+		return nil, nil
+	}
+	fields, err := reader.ShardKey(ctx, col.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	sampleSize := v.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = 100
+	}
+	docs, err := v.Target.SampleDocuments(ctx, col.Name, sampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("sampling documents from %s: %w", col.Name, err)
+	}
+
+	check := &ShardKeyCheck{Fields: fields, Sampled: len(docs), Match: true}
+	for _, doc := range docs {
+		missing := false
+		for _, f := range fields {
+			if val, present := docField(doc, f); !present || val == nil {
+				if check.MissingFields == nil {
+					check.MissingFields = make(map[string]int)
+				}
+				check.MissingFields[f]++
+				missing = true
+			}
+		}
+		if missing {
+			check.Missing++
+		}
+	}
+	if check.Sampled > 0 {
+		check.MissingPercent = float64(check.Missing) / float64(check.Sampled) * 100
+	}
+	check.Match = check.Missing == 0
+	return check, nil
+}
+
+// checkShardKey adds the shard key check to cr when the collection is
+// sharded.
+func (v *Validator) checkShardKey(ctx context.Context, col mapping.Collection, cr *CollectionResult) error {
+	sc, err := v.validateShardKey(ctx, col)
+	if err != nil || sc == nil {
+		return err
+	}
+	cr.ShardKeyCheck = sc
+	if !sc.Match {
+		cr.Status = "FAIL"
+	}
+	v.notify(col.Name, "shard_key", sc.Match)
+	return nil
+}
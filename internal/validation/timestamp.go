@@ -0,0 +1,226 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// TimestampCheck holds the result of checking the time zone policies of
+// timestamp columns without a time zone: for sampled source rows, each
+// value read in its column's zone must be the instant the document holds,
+// compared as milliseconds since the epoch.
+type TimestampCheck struct {
+	Fields     []TimestampField `json:"fields"`
+	Mismatched int              `json:"mismatched"` // values holding another instant
+	Match      bool             `json:"match"`
+	Message    string           `json:"message,omitempty"` // why nothing was compared
+}
+
+// TimestampField is the check of one timestamp column.
+type TimestampField struct {
+	Field      string             `json:"field"`
+	Column     string             `json:"column"`
+	Policy     string             `json:"policy"`
+	Zone       string             `json:"zone"`
+	Checked    int                `json:"checked"`
+	Mismatched int                `json:"mismatched"`
+	Example    *TimestampMismatch `json:"example,omitempty"` // the first mismatch
+}
+
+// Summary describes the field's mismatches in a line.
+func (f TimestampField) Summary() string {
+	s := fmt.Sprintf("%d of %d sampled values are not the source time read in %s", f.Mismatched, f.Checked, f.Zone)
+	if e := f.Example; e != nil {
+		got := "no date"
+		if e.Actual != nil {
+			got = time.UnixMilli(*e.Actual).UTC().Format(time.RFC3339Nano)
+		}
+		s += fmt.Sprintf(" (e.g. %s: want %s, found %s)", e.SourceValue, time.UnixMilli(e.Expected).UTC().Format(time.RFC3339Nano), got)
+	}
+	return s
+}
+
+// TimestampMismatch is a sampled value whose document holds another
+// instant. Actual is nil when the field is missing or not a date.
+type TimestampMismatch struct {
+	DocumentID  interface{} `json:"document_id"`
+	SourceValue string      `json:"source_value"` // the wall-clock time read from the source
+	Expected    int64       `json:"expected"`     // epoch milliseconds
+	Actual      *int64      `json:"actual"`
+}
+
+// timestampColumn is a timestamp column of the root table and the zone its
+// values are read in.
+type timestampColumn struct {
+	policy typemap.TimestampColumn
+	zone   string
+	loc    *time.Location
+	field  string
+}
+
+// timestampColumns returns the root table's timestamp columns without a
+// time zone that reach the document unchanged, each with its zone.
+func (v *Validator) timestampColumns(col mapping.Collection, table *schema.Table) ([]timestampColumn, error) {
+	changed := make(map[string]bool)
+	for _, t := range col.Transformations {
+		if t.Operation == "cast" || t.Operation == "compute" {
+			changed[t.SourceField], changed[t.TargetField] = true, true
+		}
+	}
+	var out []timestampColumn
+	for _, c := range v.TypeMap.TimestampColumns(&schema.Schema{Tables: []schema.Table{*table}}) {
+		if changed[c.Column] || masked(col.Transformations, c.Column) {
+			continue
+		}
+		field, ok := col.RootField(c.Column)
+		if !ok {
+			continue
+		}
+		zone, err := c.SourceZone(v.Schema.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		loc, err := c.Location(v.Schema.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, timestampColumn{policy: c, zone: zone, loc: loc, field: field})
+	}
+	return out, nil
+}
+
+// validateTimestamps samples the root table's timestamp columns without a
+// time zone and compares them with the documents holding the same rows, or
+// returns nil when the collection has none.
+func (v *Validator) validateTimestamps(ctx context.Context, col mapping.Collection) (*TimestampCheck, error) {
+	if v.Schema == nil || v.TypeMap == nil {
+		return nil, nil
+	}
+	var table *schema.Table
+	for i := range v.Schema.Tables {
+		if v.Schema.Tables[i].Name == col.SourceTable {
+			table = &v.Schema.Tables[i]
+		}
+	}
+	if table == nil {
+		return nil, nil
+	}
+	cols, err := v.timestampColumns(col, table)
+	if err != nil || len(cols) == 0 {
+		return nil, err
+	}
+
+	check := &TimestampCheck{Match: true}
+	for _, c := range cols {
+		check.Fields = append(check.Fields, TimestampField{
+			Field: c.field, Column: table.Name + "." + c.policy.Column, Policy: string(c.policy.Policy), Zone: c.zone,
+		})
+	}
+	if table.PrimaryKey == nil || len(table.PrimaryKey.Columns) != 1 {
+		check.Message = "documents are matched to source rows by a single-column primary key, which " + table.Name + " lacks"
+		return check, nil
+	}
+	pk := table.PrimaryKey.Columns[0]
+	keyField, ok := col.RootField(pk)
+	if !ok {
+		check.Message = fmt.Sprintf("primary key %s is not migrated", pk)
+		return check, nil
+	}
+
+	sampleSize := v.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = 100
+	}
+	columns := []string{pk}
+	for _, c := range cols {
+		columns = append(columns, c.policy.Column)
+	}
+	rows, err := v.Source.SampleRows(ctx, table.Name, columns, sampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("sampling %s: %w", table.Name, err)
+	}
+	var ids []interface{}
+	for _, row := range rows {
+		if id := row[pk]; id != nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return check, nil
+	}
+	docs, err := v.Target.FindDocuments(ctx, col.Name, map[string]interface{}{keyField: map[string]interface{}{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("reading sampled documents of %s: %w", col.Name, err)
+	}
+	byKey := make(map[string]map[string]interface{}, len(docs))
+	for _, doc := range docs {
+		if vals := fieldValues(doc, keyField); len(vals) > 0 {
+			byKey[fmt.Sprint(vals[0])] = doc
+		}
+	}
+
+	for i, c := range cols {
+		f := &check.Fields[i]
+		for _, row := range rows {
+			t, ok := row[c.policy.Column].(time.Time)
+			doc := byKey[fmt.Sprint(row[pk])]
+			if !ok || doc == nil {
+				continue // nulls, and rows the row count check misses
+			}
+			f.Checked++
+			want := typemap.InZone(t, c.loc).UnixMilli()
+			got, ok := epochMillis(fieldValues(doc, c.field))
+			if ok && got == want {
+				continue
+			}
+			f.Mismatched++
+			if f.Example == nil {
+				m := &TimestampMismatch{DocumentID: doc["_id"], SourceValue: t.Format("2006-01-02 15:04:05.999999"), Expected: want}
+				if ok {
+					m.Actual = &got
+				}
+				f.Example = m
+			}
+		}
+		check.Mismatched += f.Mismatched
+	}
+	check.Match = check.Mismatched == 0
+	return check, nil
+}
+
+// epochMillis returns the milliseconds since the epoch of a date field's
+// value.
+func epochMillis(vals []interface{}) (int64, bool) {
+	if len(vals) != 1 {
+		return 0, false
+	}
+	switch t := vals[0].(type) {
+	case time.Time:
+		return t.UnixMilli(), true
+	case bson.DateTime:
+		return int64(t), true
+	}
+	return 0, false
+}
+
+// checkTimestamps adds the timestamp check to cr when the collection's root
+// table has timestamp columns without a time zone.
+func (v *Validator) checkTimestamps(ctx context.Context, col mapping.Collection, cr *CollectionResult) error {
+	tc, err := v.validateTimestamps(ctx, col)
+	if err != nil || tc == nil {
+		return err
+	}
+	cr.TimestampCheck = tc
+	if !tc.Match {
+		cr.Status = "FAIL"
+	}
+	v.notify(col.Name, "timestamps", tc.Match)
+	return nil
+}
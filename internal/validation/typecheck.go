@@ -0,0 +1,216 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/transform"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// BSON types that have no entry in the type map but can appear in documents.
+const (
+	bsonInt32     typemap.BSONType = "Int32"
+	bsonObjectID  typemap.BSONType = "ObjectId"
+	bsonTimestamp typemap.BSONType = "Timestamp"
+)
+
+// TypeCheck holds the result of comparing sampled documents' BSON types
+// against the type map.
+type TypeCheck struct {
+	Sampled        int              `json:"sampled"`
+	Fields         []FieldTypeCheck `json:"fields,omitempty"`
+	MismatchFields int              `json:"mismatch_fields"`
+	Match          bool             `json:"match"`
+}
+
+// FieldTypeCheck holds per-field type statistics over the sampled documents.
+// Documents where the field is missing or null are counted but not compared.
+type FieldTypeCheck struct {
+	Field      string                   `json:"field"`
+	Column     string                   `json:"column"`
+	SourceType string                   `json:"source_type"`
+	Expected   typemap.BSONType         `json:"expected"`
+	Checked    int                      `json:"checked"`
+	Mismatched int                      `json:"mismatched"`
+	Missing    int                      `json:"missing"`
+	Nulls      int                      `json:"nulls"`
+	Actual     map[typemap.BSONType]int `json:"actual,omitempty"` // mismatched values by type
+}
+
+// MismatchRate returns the share of checked values with the wrong type.
+func (f FieldTypeCheck) MismatchRate() float64 {
+	if f.Checked == 0 {
+		return 0
+	}
+	return float64(f.Mismatched) / float64(f.Checked)
+}
+
+// validateTypes samples documents and compares the BSON type of each field
+// with the type the type map gives its source column. Columns that are
+// excluded, cast or computed by a transformation are not checked.
+func (v *Validator) validateTypes(ctx context.Context, col mapping.Collection) (*TypeCheck, error) {
+	sampleSize := v.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = 100
+	}
+
+	docs, err := v.Target.SampleDocuments(ctx, col.Name, sampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("sampling documents from %s: %w", col.Name, err)
+	}
+
+	check := &TypeCheck{Sampled: len(docs), Match: true}
+	if v.Schema == nil || v.TypeMap == nil {
+		return check, nil
+	}
+
+	cast := make(map[string]bool)
+	for _, t := range col.Transformations {
+		switch t.Operation {
+		case "cast":
+			cast[t.SourceField] = true
+		case "compute":
+			cast[t.TargetField] = true
+		default:
+			if transform.IsMask(t.Operation) {
+				cast[t.SourceField] = true
+			}
+		}
+	}
+
+	for _, t := range v.Schema.Tables {
+		if t.Name != col.SourceTable {
+			continue
+		}
+		for _, c := range t.Columns {
+			if cast[c.Name] {
+				continue
+			}
+			field, ok := col.RootField(c.Name)
+			if !ok {
+				continue
+			}
+			fc := FieldTypeCheck{
+				Field:      field,
+				Column:     c.Name,
+				SourceType: c.DataType,
+				Expected:   v.TypeMap.ResolveColumn(c),
+			}
+			for _, doc := range docs {
+				val, present := docField(doc, field)
+				switch {
+				case !present:
+					fc.Missing++
+				case val == nil:
+					fc.Nulls++
+				default:
+					fc.Checked++
+					if actual := bsonTypeOf(val); actual != fc.Expected {
+						fc.Mismatched++
+						if fc.Actual == nil {
+							fc.Actual = make(map[typemap.BSONType]int)
+						}
+						fc.Actual[actual]++
+					}
+				}
+			}
+			if fc.Mismatched > 0 {
+				check.MismatchFields++
+				check.Match = false
+			}
+			check.Fields = append(check.Fields, fc)
+		}
+	}
+	sort.Slice(check.Fields, func(i, j int) bool { return check.Fields[i].Field < check.Fields[j].Field })
+	return check, nil
+}
+
+// docField returns the value at a dotted path in a document and whether the
+// path exists.
+func docField(doc map[string]interface{}, path string) (interface{}, bool) {
+	var cur interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		var ok bool
+		switch m := cur.(type) {
+		case map[string]interface{}:
+			cur, ok = m[part]
+		case bson.M:
+			cur, ok = m[part]
+		case bson.D:
+			for _, e := range m {
+				if e.Key == part {
+					cur, ok = e.Value, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// bsonTypeOf names the BSON type a decoded document value was stored as.
+func bsonTypeOf(v interface{}) typemap.BSONType {
+	switch v.(type) {
+	case int64:
+		return typemap.BSONNumberLong
+	case int32, int:
+		return bsonInt32
+	case float64, float32:
+		return typemap.BSONDouble
+	case bson.Decimal128:
+		return typemap.BSONDecimal128
+	case string:
+		return typemap.BSONString
+	case bool:
+		return typemap.BSONBoolean
+	case bson.DateTime, time.Time:
+		return typemap.BSONISODate
+	case bson.Binary, []byte:
+		return typemap.BSONBinData
+	case bson.D, bson.M, map[string]interface{}:
+		if isGeoJSON(v) {
+			return typemap.BSONGeoJSON
+		}
+		return typemap.BSONDocument
+	case bson.A, []interface{}:
+		return typemap.BSONArray
+	case bson.ObjectID:
+		return bsonObjectID
+	case bson.Timestamp:
+		return bsonTimestamp
+	}
+	return typemap.BSONType(fmt.Sprintf("%T", v))
+}
+
+// isGeoJSON reports whether a document is a GeoJSON geometry: a string type
+// with coordinates, or with geometries for a collection.
+func isGeoJSON(v interface{}) bool {
+	var doc map[string]interface{}
+	switch d := v.(type) {
+	case bson.D:
+		doc = make(map[string]interface{}, len(d))
+		for _, e := range d {
+			doc[e.Key] = e.Value
+		}
+	case bson.M:
+		doc = d
+	case map[string]interface{}:
+		doc = d
+	}
+	if _, ok := doc["type"].(string); !ok {
+		return false
+	}
+	_, coords := doc["coordinates"]
+	_, geoms := doc["geometries"]
+	return coords || geoms
+}
@@ -2,12 +2,15 @@ package validation
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/schema"
 	"github.com/reloquent/reloquent/internal/source"
 	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
 )
 
 // Result holds the outcome of post-migration validation.
@@ -16,75 +19,151 @@ type Result struct {
 	Collections []CollectionResult `json:"collections"`
 	StartedAt   time.Time          `json:"started_at"`
 	CompletedAt time.Time          `json:"completed_at"`
+
+	// BudgetExceeded is set when the time budget ran out before every
+	// collection was validated; those left are SKIPPED.
+	BudgetExceeded bool `json:"budget_exceeded,omitempty"`
 }
 
 // CollectionResult holds validation results for a single collection.
 type CollectionResult struct {
-	Name           string          `json:"name"`
-	RowCountCheck  *RowCountCheck  `json:"row_count_check,omitempty"`
-	SampleCheck    *SampleCheck    `json:"sample_check,omitempty"`
-	AggregateCheck *AggregateCheck `json:"aggregate_check,omitempty"`
-	Status         string          `json:"status"` // PASS, FAIL
+	Name             string            `json:"name"`
+	RowCountCheck    *RowCountCheck    `json:"row_count_check,omitempty"`
+	SampleCheck      *SampleCheck      `json:"sample_check,omitempty"`
+	AggregateCheck   *AggregateCheck   `json:"aggregate_check,omitempty"`
+	ChecksumCheck    *ChecksumCheck    `json:"checksum_check,omitempty"`
+	TypeCheck        *TypeCheck        `json:"type_check,omitempty"`
+	OffloadCheck     *OffloadCheck     `json:"offload_check,omitempty"`
+	ReferentialCheck *ReferentialCheck `json:"referential_check,omitempty"`
+	GroupCheck       *GroupCheck       `json:"group_check,omitempty"`
+	ShardKeyCheck    *ShardKeyCheck    `json:"shard_key_check,omitempty"`
+	MaskCheck        *MaskCheck        `json:"mask_check,omitempty"`
+	TimestampCheck   *TimestampCheck   `json:"timestamp_check,omitempty"`
+	CollationCheck   *CollationCheck   `json:"collation_check,omitempty"`
+	RuleChecks       []RuleCheck       `json:"rule_checks,omitempty"`
+	Status           string            `json:"status"` // PASS, FAIL, SKIPPED
+	Message          string            `json:"message,omitempty"`
 }
 
 // Validator performs post-migration validation.
 type Validator struct {
-	Source     source.Reader
-	Target     target.Operator
-	Schema     *schema.Schema
-	Mapping    *mapping.Mapping
-	SampleSize int
-	Callback   func(collection, checkType string, passed bool)
+	Source      source.Reader
+	Target      target.Operator
+	Schema      *schema.Schema
+	Mapping     *mapping.Mapping
+	SampleSize  int
+	TypeMap     *typemap.TypeMap  // enables the type fidelity check
+	Snapshots   map[string]string // source snapshot each collection was read from, for consistency groups
+	RowsSkipped map[string]int64  // source rows each collection's error policy skipped
+	Config      Config
+	Callback    func(collection, checkType string, passed bool) // never called concurrently
+	OnChunk     func(ChunkProgress)                             // checksum mode; never called concurrently
+
+	cbMu  sync.Mutex // serializes Callback and OnChunk across collections
+	rules []Rule     // from Config.RulesFile
 }
 
-// Validate runs all validation checks: row counts, samples, and aggregates.
+// Validate runs all validation checks: row counts, samples, aggregates and,
+// with a type map, BSON types; or row counts and checksums in checksum mode.
+// Both modes also check offloaded fields, that sampled documents of sharded
+// collections hold their shard key, that masked fields hold no sampled
+// source value, that timestamps without a time zone hold the instants their
+// policies give, that embeds joined ignoring case in the source leave no
+// rows out, the rules of the config's rules file and, unless the config
+// turns it off, that referenced documents exist.
 func (v *Validator) Validate(ctx context.Context) (*Result, error) {
-	result := &Result{
-		StartedAt: time.Now(),
+	if err := v.Config.Validate(); err != nil {
+		return nil, err
 	}
-
-	for _, col := range v.Mapping.Collections {
-		cr := CollectionResult{Name: col.Name, Status: "PASS"}
-
-		// Row count check
-		rc, err := v.validateRowCount(ctx, col)
-		if err != nil {
-			return nil, err
+	if err := v.loadRules(); err != nil {
+		return nil, err
+	}
+	if ro := v.Config.ReadOptions(); ro != (target.ReadOptions{}) {
+		if ro.Causal() && !target.CapabilitiesOf(v.Target).CausalReads {
+			return nil, fmt.Errorf("read preference %s: the target does not support causally consistent secondary reads", ro.Preference)
 		}
-		cr.RowCountCheck = rc
-		if !rc.Match {
-			cr.Status = "FAIL"
+		if err := v.Target.SetReadOptions(ctx, ro); err != nil {
+			return nil, fmt.Errorf("setting target read options: %w", err)
 		}
-		v.notify(col.Name, "row_count", rc.Match)
+	}
+	if v.Config.Mode == ModeChecksum {
+		return v.run(ctx, v.checksumCollection)
+	}
+	return v.run(ctx, v.validateCollection)
+}
 
-		// Sample check
-		sc, err := v.validateSample(ctx, col)
-		if err != nil {
-			return nil, err
-		}
-		cr.SampleCheck = sc
-		if sc.MismatchCount > 0 {
-			cr.Status = "FAIL"
-		}
-		v.notify(col.Name, "sample", sc.MismatchCount == 0)
+// validateCollection runs the full checks on one collection.
+func (v *Validator) validateCollection(ctx context.Context, col mapping.Collection) (CollectionResult, error) {
+	cr := CollectionResult{Name: col.Name, Status: "PASS"}
 
-		// Aggregate check
-		ac, err := v.validateAggregates(ctx, col)
+	// Row count check
+	rc, err := v.validateRowCount(ctx, col)
+	if err != nil {
+		return cr, err
+	}
+	cr.RowCountCheck = rc
+	if !rc.Match {
+		cr.Status = "FAIL"
+	}
+	v.notify(col.Name, "row_count", rc.Match)
+
+	// Sample check
+	sc, err := v.validateSample(ctx, col)
+	if err != nil {
+		return cr, err
+	}
+	cr.SampleCheck = sc
+	if sc.MismatchCount > 0 {
+		cr.Status = "FAIL"
+	}
+	v.notify(col.Name, "sample", sc.MismatchCount == 0)
+
+	// Aggregate check
+	ac, err := v.validateAggregates(ctx, col)
+	if err != nil {
+		return cr, err
+	}
+	cr.AggregateCheck = ac
+	if !ac.Match {
+		cr.Status = "FAIL"
+	}
+	v.notify(col.Name, "aggregate", ac.Match)
+
+	// Type fidelity check
+	if v.TypeMap != nil {
+		tc, err := v.validateTypes(ctx, col)
 		if err != nil {
-			return nil, err
+			return cr, err
 		}
-		cr.AggregateCheck = ac
-		if !ac.Match {
+		cr.TypeCheck = tc
+		if !tc.Match {
 			cr.Status = "FAIL"
 		}
-		v.notify(col.Name, "aggregate", ac.Match)
-
-		result.Collections = append(result.Collections, cr)
+		v.notify(col.Name, "types", tc.Match)
 	}
 
-	result.CompletedAt = time.Now()
-	result.Status = computeOverallStatus(result.Collections)
-	return result, nil
+	if err := v.checkOffloads(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkReferences(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkShardKey(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkMasks(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkTimestamps(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkCollation(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkRules(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	return cr, nil
 }
 
 // ValidateRowCounts runs only the row count validation.
@@ -156,23 +235,147 @@ func (v *Validator) ValidateAggregates(ctx context.Context) (*Result, error) {
 	return result, nil
 }
 
+// ValidateTypes runs only the type fidelity validation.
+func (v *Validator) ValidateTypes(ctx context.Context) (*Result, error) {
+	result := &Result{StartedAt: time.Now()}
+
+	for _, col := range v.Mapping.Collections {
+		cr := CollectionResult{Name: col.Name, Status: "PASS"}
+		tc, err := v.validateTypes(ctx, col)
+		if err != nil {
+			return nil, err
+		}
+		cr.TypeCheck = tc
+		if !tc.Match {
+			cr.Status = "FAIL"
+		}
+		v.notify(col.Name, "types", tc.Match)
+		result.Collections = append(result.Collections, cr)
+	}
+
+	result.CompletedAt = time.Now()
+	result.Status = computeOverallStatus(result.Collections)
+	return result, nil
+}
+
+// ValidateChecksums runs only the checksum validation.
+func (v *Validator) ValidateChecksums(ctx context.Context) (*Result, error) {
+	result := &Result{StartedAt: time.Now()}
+
+	for _, col := range v.Mapping.Collections {
+		cr := CollectionResult{Name: col.Name, Status: "PASS"}
+		cc, err := v.validateChecksum(ctx, col)
+		if err != nil {
+			return nil, err
+		}
+		cr.ChecksumCheck = cc
+		if !cc.Match {
+			cr.Status = "FAIL"
+		}
+		v.notify(col.Name, "checksum", cc.Match)
+		result.Collections = append(result.Collections, cr)
+	}
+
+	result.CompletedAt = time.Now()
+	result.Status = computeOverallStatus(result.Collections)
+	return result, nil
+}
+
+// checksumCollection compares row counts and checksums of one collection.
+func (v *Validator) checksumCollection(ctx context.Context, col mapping.Collection) (CollectionResult, error) {
+	cr := CollectionResult{Name: col.Name, Status: "PASS"}
+
+	rc, err := v.validateRowCount(ctx, col)
+	if err != nil {
+		return cr, err
+	}
+	cr.RowCountCheck = rc
+	if !rc.Match {
+		cr.Status = "FAIL"
+	}
+	v.notify(col.Name, "row_count", rc.Match)
+
+	cc, err := v.validateChecksum(ctx, col)
+	if err != nil {
+		return cr, err
+	}
+	cr.ChecksumCheck = cc
+	if !cc.Match {
+		cr.Status = "FAIL"
+	}
+	v.notify(col.Name, "checksum", cc.Match)
+
+	if err := v.checkOffloads(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkReferences(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkShardKey(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkMasks(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkTimestamps(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkCollation(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	if err := v.checkRules(ctx, col, &cr); err != nil {
+		return cr, err
+	}
+	return cr, nil
+}
+
+// checkOffloads adds the offload check to cr when the collection has
+// offloaded fields.
+func (v *Validator) checkOffloads(ctx context.Context, col mapping.Collection, cr *CollectionResult) error {
+	oc, err := v.validateOffloads(ctx, col)
+	if err != nil || oc == nil {
+		return err
+	}
+	cr.OffloadCheck = oc
+	if !oc.Match {
+		cr.Status = "FAIL"
+	}
+	v.notify(col.Name, "offload", oc.Match)
+	return nil
+}
+
 func (v *Validator) notify(collection, checkType string, passed bool) {
 	if v.Callback != nil {
+		v.cbMu.Lock()
+		defer v.cbMu.Unlock()
 		v.Callback(collection, checkType, passed)
 	}
 }
 
+func (v *Validator) chunkDone(p ChunkProgress) {
+	if v.OnChunk != nil {
+		v.cbMu.Lock()
+		defer v.cbMu.Unlock()
+		v.OnChunk(p)
+	}
+}
+
+// computeOverallStatus is PASS when every collection passed, FAIL when all
+// failed, and PARTIAL otherwise, including when some were skipped.
 func computeOverallStatus(collections []CollectionResult) string {
 	if len(collections) == 0 {
 		return "PASS"
 	}
-	failCount := 0
+	failCount, skipped := 0, 0
 	for _, c := range collections {
-		if c.Status == "FAIL" {
+		switch c.Status {
+		case "FAIL":
 			failCount++
+		case StatusSkipped:
+			skipped++
 		}
 	}
-	if failCount == 0 {
+	if failCount == 0 && skipped == 0 {
 		return "PASS"
 	}
 	if failCount == len(collections) {
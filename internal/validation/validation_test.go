@@ -2,12 +2,23 @@ package validation
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/config"
 	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/schema"
 	"github.com/reloquent/reloquent/internal/source"
 	"github.com/reloquent/reloquent/internal/target"
+	"github.com/reloquent/reloquent/internal/typemap"
 )
 
 func makeTestValidator(src *source.MockReader, tgt *target.MockOperator, s *schema.Schema, m *mapping.Mapping) *Validator {
@@ -72,6 +83,45 @@ func TestValidateRowCounts_Mismatch(t *testing.T) {
 	}
 }
 
+func TestValidateRowCounts_RowErrors(t *testing.T) {
+	src := &source.MockReader{
+		RowCounts: map[string]int64{"users": 1000, "orders": 500},
+	}
+	tgt := &target.MockOperator{
+		DocCounts: map[string]int64{"users": 997, "orders": 498},
+		Documents: map[string][]map[string]interface{}{
+			mapping.ErrorCollection: {
+				{"collection": "orders", "error": "invalid UTF-8 in note"},
+				{"collection": "orders", "error": "document of 17000000 bytes is over the 16 MB BSON limit"},
+				{"collection": "users", "error": "not counted: users skips"},
+			},
+		},
+	}
+	m := &mapping.Mapping{
+		Collections: []mapping.Collection{
+			{Name: "users", SourceTable: "users", OnError: mapping.OnErrorSkip},
+			{Name: "orders", SourceTable: "orders", OnError: mapping.OnErrorQuarantine},
+		},
+	}
+
+	v := makeTestValidator(src, tgt, nil, m)
+	v.RowsSkipped = map[string]int64{"users": 3}
+	result, err := v.ValidateRowCounts(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != "PASS" {
+		t.Errorf("expected PASS, got %s: %+v", result.Status, result.Collections)
+	}
+	users, orders := result.Collections[0].RowCountCheck, result.Collections[1].RowCountCheck
+	if users.Skipped != 3 || users.Quarantined != 0 || !strings.Contains(users.Message, "3 of 1000 source rows left out") {
+		t.Errorf("users = %+v", users)
+	}
+	if orders.Quarantined != 2 || orders.Skipped != 0 || !strings.Contains(orders.Message, "2 quarantined in _reloquent_errors") {
+		t.Errorf("orders = %+v", orders)
+	}
+}
+
 func TestValidateRowCounts_Partial(t *testing.T) {
 	src := &source.MockReader{
 		RowCounts: map[string]int64{"users": 100, "orders": 500},
@@ -245,6 +295,56 @@ func TestValidateAggregates_Mismatch(t *testing.T) {
 	}
 }
 
+func TestValidateAggregates_DecimalSums(t *testing.T) {
+	scale := 2
+	precision := 12
+	src := &source.MockReader{
+		CountDistincts: map[string]int64{"payments.id": 3},
+		DecimalSums:    map[string]string{"payments.amount": "300.30", "payments.fee": "1.5"},
+		Sums:           map[string]float64{"payments.weight": 10},
+	}
+	tgt := &target.MockOperator{
+		CountDistincts: map[string]int64{"payments.id": 3},
+		// A double holding 300.30 would pass within the float tolerance
+		DecimalSums: map[string]string{"payments.amount": "300.3000000000000000000000000000001", "payments.fee": "1.50"},
+		Sums:        map[string]float64{"payments.weight": 10.0000001},
+	}
+	s := &schema.Schema{Tables: []schema.Table{{
+		Name:       "payments",
+		PrimaryKey: &schema.PrimaryKey{Name: "pk", Columns: []string{"id"}},
+		Columns: []schema.Column{
+			{Name: "id", DataType: "integer"},
+			{Name: "amount", DataType: "numeric", Precision: &precision, Scale: &scale},
+			{Name: "fee", DataType: "numeric"},
+			{Name: "weight", DataType: "double precision"},
+		},
+	}}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "payments", SourceTable: "payments"}}}
+
+	v := makeTestValidator(src, tgt, s, m)
+	v.TypeMap = typemap.ForDatabase("postgresql")
+	ac, err := v.validateAggregates(context.Background(), m.Collections[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ac.Match {
+		t.Error("a decimal sum off in its last digit should fail")
+	}
+	byColumn := make(map[string]AggregateDetail)
+	for _, d := range ac.Checks {
+		byColumn[d.Column] = d
+	}
+	if d := byColumn["amount"]; d.Type != "decimal_sum" || d.Match || d.SourceExact != "300.30" {
+		t.Errorf("amount = %+v, want a failed exact sum", d)
+	}
+	if d := byColumn["fee"]; d.Type != "decimal_sum" || !d.Match || d.SourceValue != 1.5 {
+		t.Errorf("fee = %+v, want matching exact sums", d)
+	}
+	if d := byColumn["weight"]; d.Type != "sum" || !d.Match {
+		t.Errorf("weight = %+v, want a float sum within tolerance", d)
+	}
+}
+
 func TestValidate_FullPipeline(t *testing.T) {
 	src := &source.MockReader{
 		RowCounts:      map[string]int64{"users": 100},
@@ -325,6 +425,53 @@ func TestValidate_EmptyCollections(t *testing.T) {
 	}
 }
 
+func TestValidate_Filtered(t *testing.T) {
+	src := &source.MockReader{
+		RowCounts:      map[string]int64{"users": 3},
+		CountDistincts: map[string]int64{"users.id": 3},
+		TableRows: map[string][]map[string]interface{}{
+			"users": {{"id": 1, "status": "active"}, {"id": 2, "status": "deleted"}, {"id": 3, "status": "active"}},
+		},
+		Filters: map[string]func(map[string]interface{}) bool{
+			"status <> 'deleted'": func(row map[string]interface{}) bool { return row["status"] != "deleted" },
+		},
+	}
+	tgt := &target.MockOperator{
+		DocCounts:      map[string]int64{"users": 2},
+		CountDistincts: map[string]int64{"users.id": 2},
+	}
+	s := &schema.Schema{Tables: []schema.Table{{
+		Name:       "users",
+		Columns:    []schema.Column{{Name: "id", DataType: "integer"}, {Name: "status", DataType: "text"}},
+		PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}},
+	}}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "users", SourceTable: "users", Filter: "status <> 'deleted'"},
+	}}
+
+	v := makeTestValidator(src, tgt, s, m)
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cr := result.Collections[0]
+	if rc := cr.RowCountCheck; !rc.Match || rc.SourceCount != 2 {
+		t.Errorf("row count check = %+v, want 2 filtered rows matching", rc)
+	}
+	if ac := cr.AggregateCheck; !ac.Match || len(ac.Checks) != 0 || !contains(ac.Message, "skipped") {
+		t.Errorf("aggregate check = %+v, want it skipped", ac)
+	}
+
+	v.Config = Config{Mode: ModeChecksum}
+	result, err = v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cc := result.Collections[0].ChecksumCheck; !cc.Match || !contains(cc.Message, "row filter") {
+		t.Errorf("checksum check = %+v, want it skipped", cc)
+	}
+}
+
 func TestComputeOverallStatus(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -385,6 +532,74 @@ func TestReconstructSQL_NoEmbedded(t *testing.T) {
 	}
 }
 
+func TestValidate_Archived(t *testing.T) {
+	src := &source.MockReader{
+		RowCounts: map[string]int64{"orders": 3},
+		TableRows: map[string][]map[string]interface{}{
+			"orders": {{"id": 1, "year": 2018}, {"id": 2, "year": 2022}, {"id": 3, "year": 2023}},
+		},
+		Filters: map[string]func(map[string]interface{}) bool{
+			"(created_at >= TIMESTAMP '2020-01-01 00:00:00' OR created_at IS NULL)": func(row map[string]interface{}) bool {
+				return row["year"].(int) >= 2020
+			},
+		},
+	}
+	tgt := &target.MockOperator{DocCounts: map[string]int64{"orders": 2}}
+	s := &schema.Schema{Tables: []schema.Table{{Name: "orders", Columns: []schema.Column{{Name: "id", DataType: "integer"}}}}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name:        "orders",
+		SourceTable: "orders",
+		Archive:     &mapping.ArchivePolicy{Column: "created_at", Before: "2020-01-01", Location: "s3://cold/shop"},
+	}}}
+
+	v := makeTestValidator(src, tgt, s, m)
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rc := result.Collections[0].RowCountCheck; !rc.Match || rc.SourceCount != 2 {
+		t.Errorf("row count check = %+v, want only the rows kept live counted", rc)
+	}
+}
+
+func TestReconstructSQL_Filters(t *testing.T) {
+	col := mapping.Collection{
+		Name:        "orders",
+		SourceTable: "orders",
+		Filter:      "status <> 'void'",
+		Embedded: []mapping.Embedded{{
+			SourceTable:  "order_items",
+			JoinColumn:   "order_id",
+			ParentColumn: "id",
+			Filter:       "qty > 0",
+		}},
+	}
+	want := "SELECT t0.*, t1.*\n" +
+		"FROM (SELECT * FROM public.orders WHERE status <> 'void') t0\n" +
+		"LEFT JOIN (SELECT * FROM public.order_items WHERE qty > 0) t1 ON t1.order_id = t0.id"
+	if got := ReconstructSQL(col, "public"); got != want {
+		t.Errorf("ReconstructSQL() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestReconstructSQL_CompositeKey(t *testing.T) {
+	col := mapping.Collection{
+		Name:        "orders",
+		SourceTable: "orders",
+		Embedded: []mapping.Embedded{{
+			SourceTable:   "order_items",
+			JoinColumns:   []string{"tenant_id", "order_id"},
+			ParentColumns: []string{"tenant_id", "id"},
+		}},
+	}
+	want := "SELECT t0.*, t1.*\n" +
+		"FROM public.orders t0\n" +
+		"LEFT JOIN public.order_items t1 ON t1.tenant_id = t0.tenant_id AND t1.order_id = t0.id"
+	if got := ReconstructSQL(col, "public"); got != want {
+		t.Errorf("ReconstructSQL() =\n%s\nwant\n%s", got, want)
+	}
+}
+
 func TestFloatClose(t *testing.T) {
 	if !floatClose(100.0, 100.0) {
 		t.Error("identical values should match")
@@ -400,6 +615,460 @@ func TestFloatClose(t *testing.T) {
 	}
 }
 
+func checksumFixture() (*source.MockReader, *target.MockOperator, *schema.Schema, *mapping.Mapping) {
+	var rows, docs []map[string]interface{}
+	for i := 1; i <= 25; i++ {
+		rows = append(rows, map[string]interface{}{"id": int64(i), "name": fmt.Sprintf("user%d", i), "score": float64(i) * 1.5, "secret": "x"})
+		docs = append(docs, map[string]interface{}{"_id": i, "userId": int32(i), "name": fmt.Sprintf("user%d", i), "score": float64(i) * 1.5})
+	}
+	src := &source.MockReader{
+		RowCounts: map[string]int64{"users": 25},
+		TableRows: map[string][]map[string]interface{}{"users": rows},
+	}
+	tgt := &target.MockOperator{
+		DocCounts: map[string]int64{"users": 25},
+		Documents: map[string][]map[string]interface{}{"users": docs},
+	}
+	s := &schema.Schema{Tables: []schema.Table{{
+		Name: "users",
+		Columns: []schema.Column{
+			{Name: "id", DataType: "bigint"},
+			{Name: "name", DataType: "text"},
+			{Name: "score", DataType: "numeric"},
+			{Name: "secret", DataType: "text"},
+		},
+		PrimaryKey: &schema.PrimaryKey{Columns: []string{"id"}},
+	}}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name:        "users",
+		SourceTable: "users",
+		Transformations: []mapping.Transformation{
+			{SourceField: "id", Operation: "rename", TargetField: "userId"},
+			{SourceField: "secret", Operation: "exclude"},
+		},
+	}}}
+	return src, tgt, s, m
+}
+
+func TestValidate_ChecksumMode(t *testing.T) {
+	src, tgt, s, m := checksumFixture()
+	v := makeTestValidator(src, tgt, s, m)
+	v.Config = Config{Mode: ModeChecksum, ChunkSize: 10, Concurrency: 2}
+	var progress []ChunkProgress
+	v.OnChunk = func(p ChunkProgress) { progress = append(progress, p) }
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != "PASS" {
+		t.Fatalf("expected PASS, got %s: %+v", result.Status, result.Collections[0].ChecksumCheck)
+	}
+	cr := result.Collections[0]
+	if cr.SampleCheck != nil || cr.AggregateCheck != nil || cr.RowCountCheck == nil {
+		t.Error("checksum mode should run row counts and checksums only")
+	}
+	cc := cr.ChecksumCheck
+	if cc.KeyColumn != "id" || cc.Chunks != 3 || cc.SourceRows != 25 || cc.TargetRows != 25 {
+		t.Errorf("check = %+v, want 3 chunks of 25 rows keyed by id", cc)
+	}
+	if len(cc.Columns) != 3 {
+		t.Errorf("columns = %v, want the excluded column left out", cc.Columns)
+	}
+	if len(progress) != 3 || progress[2].Chunk != 3 || progress[2].Chunks != 3 {
+		t.Errorf("progress = %+v, want one callback per chunk", progress)
+	}
+}
+
+func TestValidate_ChecksumMismatch(t *testing.T) {
+	src, tgt, s, m := checksumFixture()
+	tgt.Documents["users"][13]["name"] = "changed"                                               // key 14
+	tgt.Documents["users"] = append(tgt.Documents["users"][:21], tgt.Documents["users"][22:]...) // drop key 22
+	tgt.DocCounts["users"] = 24
+
+	v := makeTestValidator(src, tgt, s, m)
+	v.Config = Config{Mode: ModeChecksum, ChunkSize: 10}
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cc := result.Collections[0].ChecksumCheck
+	if cc.Match || cc.MismatchedChunks != 2 || len(cc.Mismatches) != 2 {
+		t.Fatalf("check = %+v, want two mismatched chunks", cc)
+	}
+	if got := cc.Mismatches[0]; got.From != 11 || got.To != 21 || got.SourceRows != 10 || got.TargetRows != 10 {
+		t.Errorf("first mismatch = %+v, want keys 11-21 with equal row counts", got)
+	}
+	if got := cc.Mismatches[1]; got.From != 21 || got.SourceRows != 5 || got.TargetRows != 4 {
+		t.Errorf("second mismatch = %+v, want keys 21-31 missing a document", got)
+	}
+}
+
+func TestValidate_ChecksumExtraTargetDocs(t *testing.T) {
+	src, tgt, s, m := checksumFixture()
+	tgt.Documents["users"] = append(tgt.Documents["users"], map[string]interface{}{"userId": 99, "name": "ghost"})
+	tgt.DocCounts["users"] = 26
+
+	v := makeTestValidator(src, tgt, s, m)
+	v.Config = Config{Mode: ModeChecksum, ChunkSize: 10}
+	result, err := v.ValidateChecksums(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cc := result.Collections[0].ChecksumCheck
+	if cc.Match || cc.MismatchedChunks != 0 || !contains(cc.Message, "1 target documents outside") {
+		t.Errorf("check = %+v, want the extra document reported", cc)
+	}
+}
+
+func TestValidate_ChecksumErrors(t *testing.T) {
+	src, tgt, s, m := checksumFixture()
+	tgt.FindErr = fmt.Errorf("boom")
+	v := makeTestValidator(src, tgt, s, m)
+	v.Config = Config{Mode: ModeChecksum, ChunkSize: 5, Concurrency: 3}
+	if _, err := v.Validate(context.Background()); err == nil || !contains(err.Error(), "boom") {
+		t.Errorf("err = %v, want the target error", err)
+	}
+
+	v.Config = Config{Mode: "fast"}
+	if _, err := v.Validate(context.Background()); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestValidate_ChecksumSkipsNonIntegerKey(t *testing.T) {
+	src, tgt, s, m := checksumFixture()
+	s.Tables[0].Columns[0].DataType = "uuid"
+	v := makeTestValidator(src, tgt, s, m)
+	result, err := v.ValidateChecksums(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cc := result.Collections[0].ChecksumCheck; !cc.Match || !contains(cc.Message, "skipped") {
+		t.Errorf("check = %+v, want a skipped check", cc)
+	}
+}
+
+func TestValidate_ReadOptions(t *testing.T) {
+	src, tgt, s, m := checksumFixture()
+	v := makeTestValidator(src, tgt, s, m)
+	if _, err := v.Validate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tgt.ReadOptions != nil {
+		t.Errorf("read options = %+v, want driver defaults when none are configured", tgt.ReadOptions)
+	}
+
+	v.Config = Config{ReadPreference: target.ReadSecondaryPreferred}
+	if _, err := v.Validate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tgt.ReadOptions == nil || tgt.ReadOptions.Preference != target.ReadSecondaryPreferred {
+		t.Errorf("read options = %+v, want secondaryPreferred", tgt.ReadOptions)
+	}
+
+	v.Config = Config{ReadPreference: target.ReadSecondary, ReadConcern: target.ReadConcernLinearizable}
+	if _, err := v.Validate(context.Background()); err == nil {
+		t.Error("expected an error for linearizable reads from a secondary")
+	}
+}
+
+func TestValidate_ReadOptions_NoCausalReads(t *testing.T) {
+	src, tgt, s, m := checksumFixture()
+	tgt.Caps = &target.FerretDBCapabilities
+	v := makeTestValidator(src, tgt, s, m)
+	v.Config = Config{ReadPreference: target.ReadSecondaryPreferred}
+	if _, err := v.Validate(context.Background()); err == nil || !contains(err.Error(), "causally consistent") {
+		t.Errorf("err = %v, want secondary reads rejected", err)
+	}
+	if tgt.ReadOptions != nil {
+		t.Errorf("read options = %+v, want none set", tgt.ReadOptions)
+	}
+}
+
+func TestConfig_WithTargetDefaults(t *testing.T) {
+	tgt := config.TargetConfig{ReadPreference: "secondaryPreferred", ReadConcern: "local"}
+
+	got := Config{}.WithTargetDefaults(tgt)
+	if got.ReadPreference != "secondaryPreferred" || got.ReadConcern != "local" {
+		t.Errorf("config = %+v, want the target's read options", got)
+	}
+	got = Config{ReadConcern: "majority"}.WithTargetDefaults(tgt)
+	if got.ReadPreference != "secondaryPreferred" || got.ReadConcern != "majority" {
+		t.Errorf("config = %+v, want the explicit read concern kept", got)
+	}
+}
+
+func TestValidate_Offloads(t *testing.T) {
+	src := &source.MockReader{RowCounts: map[string]int64{"staff": 2, "documents": 5, "payments": 7}}
+	tgt := &target.MockOperator{
+		DocCounts: map[string]int64{"staff": 2, "staff_payments": 7, "fs.files": 2},
+		SampleDocs: map[string][]map[string]interface{}{"staff": {
+			{"_id": 1, "documents": "66f0c0ffee", "payments": map[string]interface{}{"collection": "staff_payments", "count": 4}},
+			{"_id": 2, "documents": nil},
+		}},
+	}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name: "staff", SourceTable: "staff",
+		Embedded: []mapping.Embedded{
+			{SourceTable: "documents", FieldName: "documents", Offload: &mapping.Offload{Strategy: mapping.OffloadGridFS}},
+			{SourceTable: "payments", FieldName: "payments", Offload: &mapping.Offload{Strategy: mapping.OffloadCollection}},
+		},
+	}}}
+
+	v := makeTestValidator(src, tgt, nil, m)
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	oc := result.Collections[0].OffloadCheck
+	if oc == nil || !oc.Match || len(oc.Fields) != 2 {
+		t.Fatalf("offload check = %+v, want two matching fields", oc)
+	}
+	if got := oc.Fields[1]; got.Target != "staff_payments" || got.SourceRows != 7 || got.TargetDocs != 7 {
+		t.Errorf("side collection check = %+v", got)
+	}
+
+	// A short side collection and an inlined GridFS field both fail
+	tgt.DocCounts["staff_payments"] = 6
+	tgt.SampleDocs["staff"][1]["documents"] = []interface{}{map[string]interface{}{"id": 1}}
+	result, err = v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	oc = result.Collections[0].OffloadCheck
+	if oc.Match || oc.Fields[0].Match || oc.Fields[1].Match || result.Status != "FAIL" {
+		t.Errorf("offload check = %+v, status %s, want both fields failing", oc, result.Status)
+	}
+	if !contains(oc.Fields[0].Message, "1 sampled documents") {
+		t.Errorf("message = %q", oc.Fields[0].Message)
+	}
+}
+
+func scheduleFixture(cfg Config) *Validator {
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "small", RowCount: 10},
+		{Name: "large", RowCount: 1000},
+		{Name: "medium", RowCount: 100},
+		{Name: "critical", RowCount: 1},
+	}}
+	m := &mapping.Mapping{}
+	for _, t := range s.Tables {
+		m.Collections = append(m.Collections, mapping.Collection{Name: t.Name, SourceTable: t.Name})
+	}
+	v := makeTestValidator(&source.MockReader{}, &target.MockOperator{}, s, m)
+	v.Config = cfg
+	return v
+}
+
+func TestValidationOrder(t *testing.T) {
+	v := scheduleFixture(Config{Priority: []string{"critical"}})
+	order, err := v.validationOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, i := range order {
+		names = append(names, v.Mapping.Collections[i].Name)
+	}
+	if got := fmt.Sprint(names); got != "[critical large medium small]" {
+		t.Errorf("order = %s, want priority first, then largest first", got)
+	}
+
+	v.Config.Priority = []string{"missing"}
+	if _, err := v.validationOrder(); err == nil {
+		t.Error("expected an error for an unknown priority collection")
+	}
+}
+
+func TestValidate_Parallelism(t *testing.T) {
+	v := scheduleFixture(Config{Parallelism: 3})
+	var mu sync.Mutex
+	running, peak := 0, 0
+	result, err := v.run(context.Background(), func(ctx context.Context, col mapping.Collection) (CollectionResult, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return CollectionResult{Name: col.Name, Status: "PASS"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak < 2 || peak > 3 {
+		t.Errorf("peak concurrency = %d, want 2-3", peak)
+	}
+	if result.Status != "PASS" || result.Collections[0].Name != "small" {
+		t.Errorf("result = %+v, want every collection passing in mapping order", result)
+	}
+}
+
+func TestValidate_TimeBudget(t *testing.T) {
+	v := scheduleFixture(Config{TimeBudget: "30ms", Priority: []string{"critical"}})
+	result, err := v.run(context.Background(), func(ctx context.Context, col mapping.Collection) (CollectionResult, error) {
+		if col.Name == "large" {
+			<-ctx.Done() // runs past the budget
+			return CollectionResult{}, ctx.Err()
+		}
+		return CollectionResult{Name: col.Name, Status: "PASS"}, nil
+	})
+	if err != nil {
+		t.Fatalf("budget should give partial results, got %v", err)
+	}
+	if !result.BudgetExceeded || result.Status != "PARTIAL" {
+		t.Errorf("result = %+v, want a partial result over budget", result)
+	}
+	status := map[string]string{}
+	for _, cr := range result.Collections {
+		status[cr.Name] = cr.Status
+	}
+	if status["critical"] != "PASS" || status["large"] != StatusSkipped || status["small"] != StatusSkipped {
+		t.Errorf("statuses = %v, want critical validated and the rest skipped", status)
+	}
+
+	v.Config.TimeBudget = "soon"
+	if err := v.Config.Validate(); err == nil {
+		t.Error("expected an error for an invalid time budget")
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.FixedZone("x", 3600))
+	tests := []struct {
+		a, b interface{}
+	}{
+		{int64(42), int32(42)},
+		{float64(42), int64(42)},
+		{ts, ts.UTC().Truncate(time.Millisecond)},
+		{[]byte("abc"), "abc"},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if canonical(tt.a) != canonical(tt.b) {
+			t.Errorf("canonical(%#v) = %q, canonical(%#v) = %q, want equal", tt.a, canonical(tt.a), tt.b, canonical(tt.b))
+		}
+	}
+	if canonical(1.5) == canonical(int64(1)) {
+		t.Error("fractional floats should keep their fraction")
+	}
+}
+
+func TestValidateTypes(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tgt := &target.MockOperator{
+		SampleDocs: map[string][]map[string]interface{}{
+			"orders": {
+				{"_id": int64(1), "total": bson.NewDecimal128(0, 1250), "placedAt": ts, "note": nil, "customer": bson.D{{Key: "name", Value: "Alice"}}},
+				{"_id": int64(2), "total": 12.5, "placedAt": "2024-03-01T12:00:00+01:00", "customer": bson.D{{Key: "name", Value: "Bob"}}},
+				{"_id": int32(3), "total": 8.75, "placedAt": ts, "note": "gift"},
+			},
+		},
+	}
+	s := &schema.Schema{Tables: []schema.Table{{
+		Name: "orders",
+		Columns: []schema.Column{
+			{Name: "id", DataType: "bigint"},
+			{Name: "total", DataType: "numeric"},
+			{Name: "placed_at", DataType: "timestamp with time zone"},
+			{Name: "note", DataType: "text"},
+			{Name: "customer_name", DataType: "text"},
+			{Name: "legacy_code", DataType: "integer"},
+		},
+	}}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name:        "orders",
+		SourceTable: "orders",
+		Fields: []mapping.FieldMapping{
+			{Column: "id", Target: "_id"},
+			{Column: "placed_at", Target: "placedAt"},
+			{Column: "customer_name", Target: "customer.name"},
+		},
+		Transformations: []mapping.Transformation{
+			{SourceField: "legacy_code", Operation: "cast", TargetType: "string"},
+		},
+	}}}
+
+	v := makeTestValidator(&source.MockReader{}, tgt, s, m)
+	v.TypeMap = typemap.ForDatabase("postgresql")
+	result, err := v.ValidateTypes(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tc := result.Collections[0].TypeCheck
+	if result.Status != "FAIL" || tc.Match || tc.Sampled != 3 {
+		t.Fatalf("status = %s, check = %+v, want a failed check over 3 documents", result.Status, tc)
+	}
+
+	fields := make(map[string]FieldTypeCheck)
+	for _, f := range tc.Fields {
+		fields[f.Field] = f
+	}
+	if _, ok := fields["legacy_code"]; ok {
+		t.Error("cast columns should not be checked")
+	}
+	tests := []struct {
+		field                               string
+		checked, mismatched, missing, nulls int
+		actual                              map[typemap.BSONType]int
+	}{
+		{"_id", 3, 1, 0, 0, map[typemap.BSONType]int{"Int32": 1}},
+		{"total", 3, 2, 0, 0, map[typemap.BSONType]int{typemap.BSONDouble: 2}},
+		{"placedAt", 3, 1, 0, 0, map[typemap.BSONType]int{typemap.BSONString: 1}},
+		{"note", 1, 0, 1, 1, nil},
+		{"customer.name", 2, 0, 1, 0, nil},
+	}
+	for _, tt := range tests {
+		f, ok := fields[tt.field]
+		if !ok {
+			t.Errorf("no type stats for %s", tt.field)
+			continue
+		}
+		if f.Checked != tt.checked || f.Mismatched != tt.mismatched || f.Missing != tt.missing || f.Nulls != tt.nulls {
+			t.Errorf("%s: checked, mismatched, missing, nulls = %d, %d, %d, %d, want %d, %d, %d, %d",
+				tt.field, f.Checked, f.Mismatched, f.Missing, f.Nulls, tt.checked, tt.mismatched, tt.missing, tt.nulls)
+		}
+		if !reflect.DeepEqual(f.Actual, tt.actual) {
+			t.Errorf("%s: actual = %v, want %v", tt.field, f.Actual, tt.actual)
+		}
+	}
+	if tc.MismatchFields != 3 {
+		t.Errorf("mismatch fields = %d, want 3", tc.MismatchFields)
+	}
+	if got := fields["total"].MismatchRate(); got < 0.66 || got > 0.67 {
+		t.Errorf("total mismatch rate = %v, want 2/3", got)
+	}
+}
+
+func TestValidate_TypesOnlyWithTypeMap(t *testing.T) {
+	src := &source.MockReader{RowCounts: map[string]int64{"users": 1}}
+	tgt := &target.MockOperator{DocCounts: map[string]int64{"users": 1}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "users", SourceTable: "users"}}}
+
+	v := makeTestValidator(src, tgt, nil, m)
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Collections[0].TypeCheck != nil {
+		t.Error("type check should not run without a type map")
+	}
+
+	v.TypeMap = typemap.DefaultPostgres()
+	result, err = v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tc := result.Collections[0].TypeCheck; tc == nil || !tc.Match {
+		t.Errorf("type check = %+v, want a passing check", tc)
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
 }
@@ -412,3 +1081,681 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestBSONTypeOf_GeoJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want typemap.BSONType
+	}{
+		{"point", bson.D{{Key: "type", Value: "Point"}, {Key: "coordinates", Value: bson.A{1.5, 2.5}}}, typemap.BSONGeoJSON},
+		{"collection", bson.M{"type": "GeometryCollection", "geometries": bson.A{}}, typemap.BSONGeoJSON},
+		{"document", map[string]interface{}{"type": "retail"}, typemap.BSONDocument},
+		{"text", `{"type":"Point","coordinates":[1,2]}`, typemap.BSONString},
+	}
+	for _, tt := range tests {
+		if got := bsonTypeOf(tt.v); got != tt.want {
+			t.Errorf("%s: bsonTypeOf = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func referentialFixture() (*Validator, *target.MockOperator) {
+	tgt := &target.MockOperator{
+		Documents: map[string][]map[string]interface{}{
+			"customers": {{"id": 1}, {"id": 2}},
+			"orders": {
+				{"id": 10, "cust": 1},
+				{"id": 11, "cust": 2},
+				{"id": 12, "cust": 3},
+				{"id": 13, "cust": nil},
+			},
+		},
+	}
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "customers", SourceTable: "customers", References: []mapping.Reference{
+			{SourceTable: "orders", FieldName: "orders", JoinColumn: "customer_id", ParentColumn: "id"},
+		}},
+		{Name: "orders", SourceTable: "orders", Transformations: []mapping.Transformation{
+			{SourceField: "customer_id", Operation: "rename", TargetField: "cust"},
+		}},
+	}}
+	return makeTestValidator(&source.MockReader{}, tgt, nil, m), tgt
+}
+
+func TestValidateReferences(t *testing.T) {
+	v, _ := referentialFixture()
+	var notified []string
+	v.Callback = func(collection, checkType string, passed bool) {
+		notified = append(notified, fmt.Sprintf("%s/%s/%v", collection, checkType, passed))
+	}
+
+	result, err := v.ValidateReferences(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Collections) != 1 || result.Status != "FAIL" {
+		t.Fatalf("result = %+v, want only orders, failing", result)
+	}
+	rc := result.Collections[0].ReferentialCheck
+	if rc == nil || rc.Match || rc.Orphans != 1 || !rc.Sampled {
+		t.Fatalf("referential check = %+v, want one orphan from a sample", rc)
+	}
+	rel := rc.Relationships[0]
+	if rel.Field != "cust" || rel.Parent != "customers" || rel.ParentField != "id" || rel.Checked != 3 {
+		t.Errorf("relationship = %+v", rel)
+	}
+	if !reflect.DeepEqual(rel.Examples, []interface{}{3}) {
+		t.Errorf("examples = %v, want [3]", rel.Examples)
+	}
+	if rel.Relationship("orders") != "orders.cust -> customers.id" {
+		t.Errorf("relationship = %q", rel.Relationship("orders"))
+	}
+	if !reflect.DeepEqual(notified, []string{"orders/referential/false"}) {
+		t.Errorf("notified = %v", notified)
+	}
+}
+
+func TestValidateReferences_Modes(t *testing.T) {
+	v, tgt := referentialFixture()
+	tgt.Documents["customers"] = append(tgt.Documents["customers"], map[string]interface{}{"id": 3})
+
+	v.Config.Referential = ReferentialFull
+	result, err := v.ValidateReferences(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rc := result.Collections[0].ReferentialCheck; !rc.Match || rc.Sampled || result.Status != "PASS" {
+		t.Errorf("full check = %+v, status %s", rc, result.Status)
+	}
+
+	v.Source = &source.MockReader{RowCounts: map[string]int64{"customers": 3, "orders": 4}}
+	tgt.DocCounts = map[string]int64{"customers": 3, "orders": 4}
+	v.Config.Referential = ReferentialOff
+	result, err = v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, c := range result.Collections {
+		if c.ReferentialCheck != nil {
+			t.Errorf("%s has a referential check with the check off", c.Name)
+		}
+	}
+
+	v.Config.Referential = "everything"
+	if _, err := v.Validate(context.Background()); err == nil {
+		t.Error("an unknown referential check should be rejected")
+	}
+}
+
+func TestValidateReferences_Unfollowable(t *testing.T) {
+	v, tgt := referentialFixture()
+	v.Mapping.Collections[0].References[0].JoinColumn = "customer_id, region"
+	v.Mapping.Collections[0].References[0].ParentColumn = "id, region"
+	tgt.OrphanErr = fmt.Errorf("should not be asked")
+
+	result, err := v.ValidateReferences(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rc := result.Collections[0].ReferentialCheck
+	if !rc.Match || !contains(rc.Relationships[0].Message, "composite") {
+		t.Errorf("referential check = %+v, want the composite reference reported and passing", rc)
+	}
+}
+
+func TestValidateReferences_NoLookup(t *testing.T) {
+	v, tgt := referentialFixture()
+	tgt.Caps = &target.Capabilities{}
+	tgt.OrphanErr = fmt.Errorf("should not be asked")
+
+	result, err := v.ValidateReferences(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rc := result.Collections[0].ReferentialCheck
+	if !rc.Match || !contains(rc.Relationships[0].Message, "$lookup") {
+		t.Errorf("referential check = %+v, want the reference reported without counts", rc)
+	}
+}
+
+func TestValidateReferences_ConsistencyGroup(t *testing.T) {
+	v, tgt := referentialFixture()
+	tgt.Documents["customers"] = append(tgt.Documents["customers"], map[string]interface{}{"id": 3})
+	v.Config.Referential = ReferentialFull
+	v.Mapping.ConsistencyGroups = []mapping.ConsistencyGroup{{Name: "sales", Collections: []string{"customers", "orders"}}}
+	src := &source.MockReader{TableRows: map[string][]map[string]interface{}{
+		"customers": {{"id": 1}, {"id": 2}, {"id": 3}},
+		"orders": {
+			{"id": 10, "customer_id": 1},
+			{"id": 11, "customer_id": 2},
+			{"id": 12, "customer_id": 3},
+			{"id": 13, "customer_id": nil},
+		},
+	}}
+	v.Source = src
+	v.Snapshots = map[string]string{"customers": "100", "orders": "100"}
+
+	result, err := v.ValidateReferences(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gc := result.Collections[0].GroupCheck
+	if gc == nil || !gc.Match || gc.Group != "sales" || result.Status != "PASS" {
+		t.Fatalf("group check = %+v, status %s; want sales passing", gc, result.Status)
+	}
+	if rel := gc.Relationships[0]; rel.SourceCount != 3 || rel.TargetCount != 3 || rel.Relationship("orders") != "orders.cust -> customers.id" {
+		t.Errorf("relationship = %+v", rel)
+	}
+
+	// An order whose customer was migrated from a later snapshot
+	src.TableRows["orders"][3]["customer_id"] = 2
+	v.Snapshots["customers"] = "120"
+	result, err = v.ValidateReferences(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Collections) != 2 || result.Collections[0].GroupCheck.Match {
+		t.Fatalf("collections = %+v, want customers failing on its snapshot too", result.Collections)
+	}
+	gc = result.Collections[1].GroupCheck
+	if gc.Match || !contains(gc.Message, "different source snapshots (100, 120)") {
+		t.Errorf("group check = %+v, want the snapshots reported", gc)
+	}
+	if rel := gc.Relationships[0]; rel.SourceCount != 4 || rel.TargetCount != 3 || rel.Message == "" {
+		t.Errorf("relationship = %+v, want the counts to differ", rel)
+	}
+
+	v.Config.Referential = ReferentialOff
+	result, err = v.ValidateReferences(context.Background())
+	if err != nil || len(result.Collections) != 0 {
+		t.Errorf("with the check off: %+v, %v", result, err)
+	}
+}
+
+func TestValidate_ShardKey(t *testing.T) {
+	src := &source.MockReader{RowCounts: map[string]int64{"orders": 4, "customers": 1}}
+	tgt := &target.MockOperator{
+		DocCounts: map[string]int64{"orders": 4, "customers": 1},
+		SampleDocs: map[string][]map[string]interface{}{"orders": {
+			{"_id": 1, "region": "eu", "customer": map[string]interface{}{"id": 7}},
+			{"_id": 2, "region": nil, "customer": map[string]interface{}{"id": 8}},
+			{"_id": 3, "customer": map[string]interface{}{}},
+			{"_id": 4, "region": "us", "customer": map[string]interface{}{"id": 9}},
+		}},
+		ShardKeys: map[string][]string{"orders": {"region", "customer.id"}},
+	}
+	m := &mapping.Mapping{Collections: []mapping.Collection{
+		{Name: "orders", SourceTable: "orders"},
+		{Name: "customers", SourceTable: "customers"},
+	}}
+
+	v := makeTestValidator(src, tgt, nil, m)
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sc := result.Collections[1].ShardKeyCheck; sc != nil {
+		t.Errorf("unsharded customers has a shard key check: %+v", sc)
+	}
+	sc := result.Collections[0].ShardKeyCheck
+	if sc == nil || sc.Match || sc.Sampled != 4 || sc.Missing != 2 || sc.MissingPercent != 50 {
+		t.Fatalf("shard key check = %+v, want 2 of 4 documents missing a key", sc)
+	}
+	if want := map[string]int{"region": 2, "customer.id": 1}; !reflect.DeepEqual(sc.MissingFields, want) {
+		t.Errorf("missing fields = %v, want %v", sc.MissingFields, want)
+	}
+	if result.Collections[0].Status != "FAIL" {
+		t.Errorf("status = %s, want FAIL", result.Collections[0].Status)
+	}
+
+	tgt.SampleDocs["orders"] = tgt.SampleDocs["orders"][:1]
+	result, err = v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sc := result.Collections[0].ShardKeyCheck; !sc.Match || sc.Missing != 0 {
+		t.Errorf("shard key check = %+v, want a match", sc)
+	}
+}
+
+func TestValidate_Masks(t *testing.T) {
+	src := &source.MockReader{
+		RowCounts: map[string]int64{"customers": 2},
+		Samples: map[string][]map[string]interface{}{
+			"customers": {{"email": "alice@corp.com"}, {"email": "bob@corp.com"}, {"email": nil}},
+			"orders":    {{"card": "4111"}},
+		},
+	}
+	tgt := &target.MockOperator{
+		DocCounts: map[string]int64{"customers": 2},
+		Documents: map[string][]map[string]interface{}{"customers": {
+			{"_id": 1, "contact": "ada.kim.1@example.com", "orders": []interface{}{map[string]interface{}{"card": "****"}}},
+			{"_id": 2, "contact": "bob@corp.com", "orders": []interface{}{map[string]interface{}{"card": "4111"}}},
+		}},
+	}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name: "customers", SourceTable: "customers",
+		Transformations: []mapping.Transformation{
+			{Operation: "rename", SourceField: "email", TargetField: "contact"},
+			{Operation: "synthesize", SourceField: "email", Value: "email"},
+		},
+		Embedded: []mapping.Embedded{{
+			SourceTable: "orders", FieldName: "orders", Relationship: "array",
+			Transformations: []mapping.Transformation{{Operation: "redact", SourceField: "card"}},
+		}},
+	}}}
+
+	v := makeTestValidator(src, tgt, nil, m)
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mc := result.Collections[0].MaskCheck
+	if mc == nil || mc.Match || mc.Leaked != 2 || len(mc.Fields) != 2 {
+		t.Fatalf("mask check = %+v, want two leaks", mc)
+	}
+	want := []MaskField{
+		{Field: "contact", Column: "customers.email", Operation: "synthesize", Sampled: 2, Leaked: 1},
+		{Field: "orders.card", Column: "orders.card", Operation: "redact", Sampled: 1, Leaked: 1},
+	}
+	if !reflect.DeepEqual(mc.Fields, want) {
+		t.Errorf("fields = %+v, want %+v", mc.Fields, want)
+	}
+	if result.Collections[0].Status != "FAIL" {
+		t.Errorf("status = %s, want FAIL", result.Collections[0].Status)
+	}
+
+	tgt.Documents["customers"] = tgt.Documents["customers"][:1]
+	result, err = v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mc := result.Collections[0].MaskCheck; !mc.Match || mc.Leaked != 0 {
+		t.Errorf("mask check = %+v, want a match", mc)
+	}
+}
+
+func TestValidate_Timestamps(t *testing.T) {
+	wall := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+	src := &source.MockReader{
+		RowCounts: map[string]int64{"orders": 2},
+		Samples: map[string][]map[string]interface{}{
+			"orders": {{"id": 1, "placed_at": wall}, {"id": 2, "placed_at": wall}, {"id": 3, "placed_at": nil}},
+		},
+	}
+	tgt := &target.MockOperator{
+		DocCounts: map[string]int64{"orders": 2},
+		Documents: map[string][]map[string]interface{}{"orders": {
+			{"_id": 1, "placed": time.Date(2024, 1, 15, 8, 30, 0, 0, time.UTC)},
+			{"_id": 2, "placed": bson.DateTime(wall.UnixMilli())},
+		}},
+	}
+	s := &schema.Schema{Tables: []schema.Table{{
+		Name:       "orders",
+		Columns:    []schema.Column{{Name: "id", DataType: "integer"}, {Name: "placed_at", DataType: "timestamp without time zone"}},
+		PrimaryKey: &schema.PrimaryKey{Name: "pk_orders", Columns: []string{"id"}},
+	}}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name: "orders", SourceTable: "orders",
+		Fields: []mapping.FieldMapping{{Column: "id", Target: "_id"}, {Column: "placed_at", Target: "placed"}},
+	}}}
+	tm := typemap.DefaultPostgres()
+	if err := tm.SetTimestamp(typemap.TimestampColumn{Table: "orders", Column: "placed_at", Policy: typemap.TimezoneNamed, Zone: "Europe/Paris"}); err != nil {
+		t.Fatal(err)
+	}
+
+	v := makeTestValidator(src, tgt, s, m)
+	v.TypeMap = tm
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tc := result.Collections[0].TimestampCheck
+	if tc == nil || tc.Match || tc.Mismatched != 1 || len(tc.Fields) != 1 {
+		t.Fatalf("timestamp check = %+v, want one mismatch", tc)
+	}
+	f := tc.Fields[0]
+	if f.Field != "placed" || f.Zone != "Europe/Paris" || f.Checked != 2 {
+		t.Errorf("field = %+v", f)
+	}
+	if f.Example == nil || f.Example.DocumentID != 2 || f.Example.Expected != wall.Add(-time.Hour).UnixMilli() {
+		t.Errorf("example = %+v, want document 2", f.Example)
+	}
+	if result.Collections[0].Status != "FAIL" {
+		t.Errorf("status = %s, want FAIL", result.Collections[0].Status)
+	}
+
+	// Without a single-column primary key nothing is compared
+	s.Tables[0].PrimaryKey = nil
+	result, err = v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tc := result.Collections[0].TimestampCheck; !tc.Match || tc.Message == "" {
+		t.Errorf("timestamp check = %+v, want a match with a message", tc)
+	}
+}
+
+func TestValidate_Collation(t *testing.T) {
+	src := &source.MockReader{
+		FoldKeys:  true,
+		RowCounts: map[string]int64{"customers": 2},
+		TableRows: map[string][]map[string]interface{}{
+			"customers": {{"code": "acme"}, {"code": "globex"}},
+			"orders":    {{"customer_code": "acme"}, {"customer_code": "ACME"}, {"customer_code": "Globex"}, {"customer_code": "initech"}},
+		},
+	}
+	tgt := &target.MockOperator{DocCounts: map[string]int64{"customers": 2}}
+	s := &schema.Schema{Tables: []schema.Table{
+		{Name: "customers", Columns: []schema.Column{{Name: "code", DataType: "citext", Collation: &schema.Collation{Name: "citext", CaseInsensitive: true}}}},
+		{Name: "orders", Columns: []schema.Column{{Name: "customer_code", DataType: "text"}}},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name: "customers", SourceTable: "customers",
+		Embedded: []mapping.Embedded{{SourceTable: "orders", FieldName: "orders", Relationship: "array", JoinColumn: "customer_code", ParentColumn: "code"}},
+	}}}
+
+	v := makeTestValidator(src, tgt, s, m)
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cc := result.Collections[0].CollationCheck
+	if cc == nil || cc.Match || cc.Dropped != 2 || len(cc.Joins) != 1 {
+		t.Fatalf("collation check = %+v, want two dropped rows", cc)
+	}
+	if j := cc.Joins[0]; j.Joined != 3 || j.Migrated != 1 || !strings.Contains(j.Summary(), "normalize the join keys") {
+		t.Errorf("join = %+v (%s)", j, j.Summary())
+	}
+	if result.Collections[0].Status != "FAIL" {
+		t.Errorf("status = %s, want FAIL", result.Collections[0].Status)
+	}
+
+	// Lower-cased keys join the rows the source does
+	m.SetNormalization("customers", "code", true)
+	m.SetNormalization("orders", "customer_code", true)
+	result, err = v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cc := result.Collections[0].CollationCheck; !cc.Match || !cc.Joins[0].Normalized || cc.Joins[0].Migrated != 3 {
+		t.Errorf("collation check = %+v, want a match", cc)
+	}
+}
+
+func TestRuleQueries(t *testing.T) {
+	col := mapping.Collection{
+		Name: "orders", SourceTable: "orders", Filter: "deleted = false",
+		Fields: []mapping.FieldMapping{{Column: "customer_id", Target: "customer.id"}},
+	}
+	tests := []struct {
+		name         string
+		rule         Rule
+		wantSQL      string
+		wantPipeline string
+	}{
+		{
+			name:         "sum by group",
+			rule:         Rule{Type: RuleAggregate, Function: "sum", Column: "total", GroupBy: []string{"status"}},
+			wantSQL:      `SELECT "status" AS "g0", SUM("total") AS "value" FROM "public"."orders" WHERE (deleted = false) GROUP BY "status"`,
+			wantPipeline: `[map[$group:map[_id:map[g0:$status] value:map[$sum:$total]]] map[$project:map[_id:0 g0:$_id.g0 value:1]]]`,
+		},
+		{
+			name:         "count with filter",
+			rule:         Rule{Type: RuleAggregate, Function: "count", Filter: "total > 100", Match: map[string]interface{}{"total": map[string]interface{}{"$gt": 100}}},
+			wantSQL:      `SELECT COUNT(*) AS "value" FROM "public"."orders" WHERE (deleted = false) AND (total > 100)`,
+			wantPipeline: `[map[$match:map[total:map[$gt:100]]] map[$group:map[_id:<nil> value:map[$sum:1]]] map[$project:map[_id:0 value:1]]]`,
+		},
+		{
+			name:         "not null of a renamed column",
+			rule:         Rule{Type: RuleNotNull, Column: "customer_id"},
+			wantSQL:      `SELECT COUNT(*) AS "value" FROM "public"."orders" WHERE (deleted = false) AND "customer_id" IS NULL`,
+			wantPipeline: `[map[$match:map[customer.id:<nil>]] map[$count:value]]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := tt.rule.Queries(col, "public")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.SQL != tt.wantSQL {
+				t.Errorf("SQL:\n  %s\nwant:\n  %s", q.SQL, tt.wantSQL)
+			}
+			if got := fmt.Sprint(q.Pipeline); got != tt.wantPipeline {
+				t.Errorf("pipeline:\n  %s\nwant:\n  %s", got, tt.wantPipeline)
+			}
+		})
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	m := &mapping.Mapping{Collections: []mapping.Collection{{
+		Name: "orders", SourceTable: "orders",
+		Transformations: []mapping.Transformation{{Operation: "exclude", SourceField: "notes"}},
+	}}}
+	tests := []struct {
+		rule    Rule
+		wantErr string
+	}{
+		{Rule{Name: "ok", Collection: "orders", Type: RuleAggregate, Function: "max", Column: "total"}, ""},
+		{Rule{Collection: "orders", Type: RuleNotNull, Column: "total"}, "name is required"},
+		{Rule{Name: "x", Collection: "users", Type: RuleNotNull, Column: "id"}, `no collection "users"`},
+		{Rule{Name: "x", Collection: "orders", Type: "unique", Column: "id"}, `unknown rule type "unique"`},
+		{Rule{Name: "x", Collection: "orders", Type: RuleAggregate, Function: "median", Column: "total"}, `unknown function "median"`},
+		{Rule{Name: "x", Collection: "orders", Type: RuleAggregate, Function: "sum"}, "sum needs a column"},
+		{Rule{Name: "x", Collection: "orders", Type: RuleAggregate, Function: "count", Column: "id"}, "takes no column"},
+		{Rule{Name: "x", Collection: "orders", Type: RuleNotNull, Column: "notes"}, "not a field of collection orders"},
+		{Rule{Name: "x", Collection: "orders", Type: RuleNotNull, Column: "id", Filter: "total > 0"}, "filter and match"},
+	}
+	for _, tt := range tests {
+		err := ValidateRules([]Rule{tt.rule}, m)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", tt.rule, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%+v: err = %v, want %q", tt.rule, err, tt.wantErr)
+		}
+	}
+
+	dup := Rule{Name: "ok", Collection: "orders", Type: RuleNotNull, Column: "id"}
+	if err := ValidateRules([]Rule{dup, dup}, m); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("duplicate names: err = %v", err)
+	}
+}
+
+func TestValidate_Rules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), RulesFileName)
+	rules := `rules:
+  - name: totals by status
+    collection: orders
+    type: aggregate
+    function: sum
+    column: total
+    group_by: [status]
+`
+	if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := &source.MockReader{
+		RowCounts: map[string]int64{"orders": 3},
+		QueryResult: []map[string]interface{}{
+			{"g0": "paid", "value": "150.50"},
+			{"g0": "open", "value": int64(20)},
+			{"g0": nil, "value": int64(5)},
+		},
+	}
+	tgt := &target.MockOperator{
+		DocCounts: map[string]int64{"orders": 3},
+		Aggregations: map[string][]map[string]interface{}{"orders": {
+			{"g0": "paid", "value": 150.5},
+			{"g0": "open", "value": int32(21)},
+		}},
+	}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "orders", SourceTable: "orders"}}}
+
+	v := makeTestValidator(src, tgt, nil, m)
+	v.Config.RulesFile = path
+	var checks []string
+	v.Callback = func(collection, checkType string, passed bool) {
+		if strings.HasPrefix(checkType, "rule:") {
+			checks = append(checks, fmt.Sprintf("%s %s %v", collection, checkType, passed))
+		}
+	}
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cr := result.Collections[0]
+	if len(cr.RuleChecks) != 1 {
+		t.Fatalf("rule checks = %+v", cr.RuleChecks)
+	}
+	rc := cr.RuleChecks[0]
+	want := []RuleGroup{
+		{Group: []string{"null"}, SourceValue: "5"},
+		{Group: []string{"open"}, SourceValue: "20", TargetValue: "21"},
+	}
+	if rc.Match || rc.Groups != 3 || rc.Mismatched != 2 || !reflect.DeepEqual(rc.Mismatches, want) {
+		t.Errorf("rule check = %+v, want open and null groups differing", rc)
+	}
+	if cr.Status != "FAIL" {
+		t.Errorf("status = %s, want FAIL", cr.Status)
+	}
+	if !reflect.DeepEqual(checks, []string{"orders rule:totals by status false"}) {
+		t.Errorf("callbacks = %v", checks)
+	}
+	if got := rc.Summary(); got != "2 of 3 groups differ (e.g. null: source 5, target missing)" {
+		t.Errorf("summary = %q", got)
+	}
+
+	tgt.Aggregations["orders"] = append(tgt.Aggregations["orders"][:1],
+		map[string]interface{}{"g0": "open", "value": int64(20)},
+		map[string]interface{}{"value": int64(5)})
+	result, err = v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rc := result.Collections[0].RuleChecks[0]; !rc.Match {
+		t.Errorf("rule check = %+v, want a match", rc)
+	}
+
+	// Rules that do not fit the mapping stop validation before it starts
+	if err := os.WriteFile(path, []byte(strings.Replace(rules, "column: total", "column: amount\n    filter: x > 1", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Validate(context.Background()); err == nil || !strings.Contains(err.Error(), "filter and match") {
+		t.Errorf("err = %v, want the rules file rejected", err)
+	}
+}
+
+func TestValidate_RulesNotNull(t *testing.T) {
+	src := &source.MockReader{
+		RowCounts:   map[string]int64{"orders": 2},
+		QueryResult: []map[string]interface{}{{"value": int64(1)}},
+	}
+	tgt := &target.MockOperator{DocCounts: map[string]int64{"orders": 2}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "orders", SourceTable: "orders"}}}
+	v := makeTestValidator(src, tgt, nil, m)
+	v.rules = []Rule{{Name: "customer set", Collection: "orders", Type: RuleNotNull, Column: "customer_id"}}
+
+	var cr CollectionResult
+	if err := v.checkRules(context.Background(), m.Collections[0], &cr); err != nil {
+		t.Fatal(err)
+	}
+	if rc := cr.RuleChecks[0]; !rc.Match || rc.SourceValue != "1" || rc.TargetValue != "0" {
+		t.Errorf("no null documents: rule check = %+v", rc)
+	}
+
+	tgt.Aggregations = map[string][]map[string]interface{}{"orders": {{"value": int32(1)}}}
+	cr = CollectionResult{Status: "PASS"}
+	if err := v.checkRules(context.Background(), m.Collections[0], &cr); err != nil {
+		t.Fatal(err)
+	}
+	if rc := cr.RuleChecks[0]; rc.Match || cr.Status != "FAIL" {
+		t.Errorf("a null document: rule check = %+v, status %s", rc, cr.Status)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	short := &RowCountCheck{SourceCount: 100, TargetCount: 97}
+	tests := []struct {
+		name      string
+		cr        CollectionResult
+		col       mapping.Collection
+		est       *mapping.CollectionSizeEstimate
+		kinds     []string
+		suggested string
+	}{
+		{
+			name: "passed",
+			cr:   CollectionResult{Name: "orders", Status: "PASS"},
+		},
+		{
+			name:      "rows missing",
+			cr:        CollectionResult{Name: "orders", Status: "FAIL", RowCountCheck: short},
+			kinds:     []string{CauseSourceChanged},
+			suggested: ActionRemigrate,
+		},
+		{
+			name:      "oversized documents",
+			cr:        CollectionResult{Name: "orders", Status: "FAIL", RowCountCheck: short},
+			est:       &mapping.CollectionSizeEstimate{ExceedsLimit: true, MaxDocSizeBytes: 20 << 20},
+			kinds:     []string{CauseOversized, CauseSourceChanged},
+			suggested: ActionAdjustMapping,
+		},
+		{
+			name:      "filter",
+			cr:        CollectionResult{Name: "orders", Status: "FAIL", RowCountCheck: short},
+			col:       mapping.Collection{Filter: "status = 'open'"},
+			kinds:     []string{CauseFilter, CauseSourceChanged},
+			suggested: ActionRemigrate,
+		},
+		{
+			name: "type coercion",
+			cr: CollectionResult{Name: "orders", Status: "FAIL", TypeCheck: &TypeCheck{Fields: []FieldTypeCheck{
+				{Field: "total", SourceType: "numeric", Expected: "Decimal128", Checked: 10, Mismatched: 10, Actual: map[typemap.BSONType]int{"Double": 10}},
+				{Field: "id", Expected: "Int64", Checked: 10},
+			}}},
+			kinds:     []string{CauseTypeCoercion},
+			suggested: ActionAdjustMapping,
+		},
+		{
+			name: "rounding",
+			cr: CollectionResult{Name: "orders", Status: "FAIL", AggregateCheck: &AggregateCheck{Checks: []AggregateDetail{
+				{Type: "sum", Column: "total", SourceValue: 1000000.01, TargetValue: 1000000.0100001},
+			}}},
+			kinds:     []string{CauseTypeCoercion},
+			suggested: ActionAccept,
+		},
+		{
+			name:      "nothing diagnosed",
+			cr:        CollectionResult{Name: "orders", Status: "FAIL"},
+			suggested: ActionRemigrate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Diagnose(tt.cr, tt.col, tt.est)
+			if tt.suggested == "" {
+				if d != nil {
+					t.Errorf("Diagnose = %+v, want nil", d)
+				}
+				return
+			}
+			var kinds []string
+			for _, c := range d.Causes {
+				kinds = append(kinds, c.Kind)
+			}
+			if !reflect.DeepEqual(kinds, tt.kinds) {
+				t.Errorf("causes = %v, want %v", kinds, tt.kinds)
+			}
+			if got := d.Suggested(); got != tt.suggested {
+				t.Errorf("Suggested = %s, want %s", got, tt.suggested)
+			}
+		})
+	}
+}
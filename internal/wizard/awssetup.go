@@ -15,7 +15,7 @@ type AWSSetupResult struct {
 	Region   string
 	Profile  string
 	S3Bucket string
-	Platform string // "emr" or "glue"
+	Platform string // "auto", "emr", "glue", "scripts-only", or "native"
 	Identity *aws.CallerIdentity
 	Access   *aws.PlatformAccess
 }
@@ -31,7 +31,7 @@ const (
 type AWSSetupModel struct {
 	inputs       []textinput.Model
 	focused      int
-	platform     int // 0=auto, 1=EMR, 2=Glue, 3=scripts-only
+	platform     int // 0=auto, 1=EMR, 2=Glue, 3=scripts-only, 4=native
 	identity     *aws.CallerIdentity
 	access       *aws.PlatformAccess
 	credStatus   string
@@ -98,7 +98,7 @@ func (m AWSSetupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.inputs[m.focused].Focus()
 			return m, nil
 		case "ctrl+p":
-			m.platform = (m.platform + 1) % 4
+			m.platform = (m.platform + 1) % 5
 			return m, nil
 		}
 	}
@@ -137,7 +137,7 @@ func (m AWSSetupModel) View() string {
 
 	// Platform choice
 	b.WriteString("\n")
-	platforms := []string{"Auto", "EMR", "Glue", "Scripts Only"}
+	platforms := []string{"Auto", "EMR", "Glue", "Scripts Only", "Native (no Spark)"}
 	b.WriteString("  Platform: ")
 	for i, p := range platforms {
 		if i == m.platform {
@@ -167,7 +167,7 @@ func (m AWSSetupModel) Cancelled() bool {
 
 // Result returns the AWS setup result.
 func (m AWSSetupModel) Result() *AWSSetupResult {
-	platforms := []string{"auto", "emr", "glue", "scripts-only"}
+	platforms := []string{"auto", "emr", "glue", "scripts-only", "native"}
 	return &AWSSetupResult{
 		Region:   m.inputs[awsFieldRegion].Value(),
 		Profile:  m.inputs[awsFieldProfile].Value(),
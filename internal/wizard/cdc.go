@@ -0,0 +1,134 @@
+package wizard
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/reloquent/reloquent/internal/cdc"
+)
+
+// CDCStatusMsg delivers a replication status update to the CDC model.
+type CDCStatusMsg cdc.Status
+
+// CDCModel is the bubbletea model for Step 12: Change Data Capture.
+type CDCModel struct {
+	status    cdc.Status
+	startPos  string
+	done      bool
+	cancelled bool
+	skipped   bool
+	width     int
+	height    int
+}
+
+// NewCDCModel creates a CDC step model starting from the given position.
+func NewCDCModel(startPos string) CDCModel {
+	return CDCModel{
+		status:   cdc.Status{State: "idle"},
+		startPos: startPos,
+		width:    100,
+		height:   24,
+	}
+}
+
+func (m CDCModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m CDCModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case CDCStatusMsg:
+		m.status = cdc.Status(msg)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc":
+			m.done = true
+			m.cancelled = true
+			return m, tea.Quit
+		case "s":
+			m.done = true
+			m.skipped = true
+			return m, tea.Quit
+		case "enter":
+			if m.status.State == "running" {
+				m.done = true
+				return m, tea.Quit
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m CDCModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Step 12: Change Data Capture"))
+	b.WriteString("\n\n")
+
+	b.WriteString("  Replicating source changes into MongoDB until you cut over.\n\n")
+
+	if m.startPos != "" {
+		b.WriteString(fmt.Sprintf("  Start position: %s\n", m.startPos))
+	}
+
+	var state string
+	switch m.status.State {
+	case "running":
+		state = highlightStyle.Render("running")
+	case "failed":
+		state = errStyle.Render("failed")
+	default:
+		state = dimStyle.Render(m.status.State)
+	}
+	b.WriteString(fmt.Sprintf("  State:          %s\n", state))
+	if m.status.Position != "" {
+		b.WriteString(fmt.Sprintf("  Position:       %s\n", m.status.Position))
+	}
+	b.WriteString(fmt.Sprintf("  Applied:        %d changes", m.status.Applied))
+	if m.status.Skipped > 0 {
+		b.WriteString(fmt.Sprintf(" (%d skipped)", m.status.Skipped))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("  Lag:            %s, %.1fs\n", formatBytes(m.status.LagBytes), m.status.LagSeconds))
+
+	if m.status.LastError != "" {
+		b.WriteString("\n")
+		b.WriteString(errStyle.Render("  Error: " + m.status.LastError))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if m.status.State == "running" {
+		b.WriteString(dimStyle.Render("  Stop writes to the source, wait for lag to reach zero, then press enter to cut over"))
+		b.WriteString("\n")
+	}
+	b.WriteString(dimStyle.Render("  s: skip CDC  q: cancel"))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// Done returns true when the model is finished.
+func (m CDCModel) Done() bool {
+	return m.done
+}
+
+// Cancelled returns true if the user cancelled.
+func (m CDCModel) Cancelled() bool {
+	return m.cancelled
+}
+
+// Skipped returns true if the user chose to skip CDC.
+func (m CDCModel) Skipped() bool {
+	return m.skipped
+}
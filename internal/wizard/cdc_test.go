@@ -0,0 +1,74 @@
+package wizard
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNewCDCModel(t *testing.T) {
+	m := NewCDCModel("0/16B3748")
+	if m.Done() || m.Cancelled() || m.Skipped() {
+		t.Error("should not be finished initially")
+	}
+	if !strings.Contains(m.View(), "0/16B3748") {
+		t.Error("should show start position")
+	}
+}
+
+func TestCDCModel_StatusUpdate(t *testing.T) {
+	m := NewCDCModel("")
+	result, _ := m.Update(CDCStatusMsg{State: "running", Applied: 42, LagBytes: 2048, LagSeconds: 1.5})
+	rm := result.(CDCModel)
+
+	v := rm.View()
+	if !strings.Contains(v, "42 changes") {
+		t.Error("should show applied count")
+	}
+	if !strings.Contains(v, "2.0 KB") {
+		t.Error("should show lag bytes")
+	}
+	if !strings.Contains(v, "cut over") {
+		t.Error("should show cutover prompt while running")
+	}
+}
+
+func TestCDCModel_EnterRequiresRunning(t *testing.T) {
+	m := NewCDCModel("")
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if result.(CDCModel).Done() {
+		t.Error("enter should be ignored before replication is running")
+	}
+
+	result, _ = m.Update(CDCStatusMsg{State: "running"})
+	result, _ = result.(CDCModel).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !result.(CDCModel).Done() {
+		t.Error("enter while running should complete")
+	}
+}
+
+func TestCDCModel_Skip(t *testing.T) {
+	m := NewCDCModel("")
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	rm := result.(CDCModel)
+	if !rm.Skipped() || rm.Cancelled() {
+		t.Error("s should skip without cancelling")
+	}
+}
+
+func TestCDCModel_Cancel(t *testing.T) {
+	m := NewCDCModel("")
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	if !result.(CDCModel).Cancelled() {
+		t.Error("q should cancel")
+	}
+}
+
+func TestCDCModel_Error(t *testing.T) {
+	m := NewCDCModel("")
+	result, _ := m.Update(CDCStatusMsg{State: "failed", LastError: "slot missing"})
+	if !strings.Contains(result.(CDCModel).View(), "slot missing") {
+		t.Error("should show last error")
+	}
+}
@@ -1,12 +1,16 @@
 package wizard
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/reloquent/reloquent/internal/collation"
 	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/schema"
 )
@@ -15,9 +19,9 @@ import (
 type RelChoice int
 
 const (
-	ChoiceReference  RelChoice = iota // keep as separate collection
-	ChoiceEmbedArray                  // embed child rows as array in parent
-	ChoiceEmbedSingle                 // embed single child doc in parent
+	ChoiceReference   RelChoice = iota // keep as separate collection
+	ChoiceEmbedArray                   // embed child rows as array in parent
+	ChoiceEmbedSingle                  // embed single child doc in parent
 )
 
 func (c RelChoice) String() string {
@@ -48,6 +52,11 @@ type fkRelationship struct {
 	// Metadata for display
 	IsSelfRef   bool
 	IsJoinTable bool
+	// Collation is the source collation the join compares the keys with
+	// when it ignores case; Normalize lower-cases the text keys on both
+	// sides so the migration joins the same rows.
+	Collation string
+	Normalize bool
 }
 
 // DenormModel is the bubbletea model for the denormalization designer.
@@ -61,6 +70,35 @@ type DenormModel struct {
 	height    int
 	warnings  []string
 	graph     *mapping.FKGraph
+
+	// Column mapping editor, opened with c
+	fields     map[string][]mapping.FieldMapping // table → field mappings
+	colMode    bool
+	colTable   int
+	colCursor  int
+	editing    bool
+	fieldInput textinput.Model
+	fieldErr   string
+
+	// Document preview, opened with p when there is a previewer
+	previewer  DocumentPreviewFunc
+	docMode    bool
+	docCol     int // index into the designed mapping's collections
+	docLoading bool
+	docs       []string // indented JSON, one per document
+	docErr     string
+	docScroll  int
+}
+
+// DocumentPreviewFunc builds a few documents of a collection of the
+// designed mapping from source rows, rendered as JSON.
+type DocumentPreviewFunc func(m *mapping.Mapping, collection string) ([]json.RawMessage, error)
+
+// documentPreviewMsg carries the documents built for a collection.
+type documentPreviewMsg struct {
+	collection string
+	docs       []json.RawMessage
+	err        error
 }
 
 // NewDenormModel creates a denormalization designer from the selected tables.
@@ -80,7 +118,18 @@ func NewDenormModel(tables []schema.Table) DenormModel {
 		joinTables[jt.JoinTable] = true
 	}
 
+	byName := make(map[string]*schema.Table, len(tables))
+	for i := range tables {
+		byName[tables[i].Name] = &tables[i]
+	}
 	for i := range rels {
+		c := collation.KeyCollation(byName[rels[i].ChildTable], rels[i].ChildColumns)
+		if c == nil {
+			c = collation.KeyCollation(byName[rels[i].ParentTable], rels[i].ParentColumns)
+		}
+		if c != nil {
+			rels[i].Collation = c.String()
+		}
 		if rels[i].ChildTable == rels[i].ParentTable {
 			rels[i].IsSelfRef = true
 		}
@@ -89,15 +138,27 @@ func NewDenormModel(tables []schema.Table) DenormModel {
 		}
 	}
 
+	input := textinput.New()
+	input.Placeholder = "field name or dotted path, e.g. address.street"
+	input.CharLimit = 256
+
 	return DenormModel{
-		tables: tables,
-		rels:   rels,
-		width:  100,
-		height: 24,
-		graph:  graph,
+		tables:     tables,
+		rels:       rels,
+		width:      100,
+		height:     24,
+		graph:      graph,
+		fields:     make(map[string][]mapping.FieldMapping),
+		fieldInput: input,
 	}
 }
 
+// SetDocumentPreview lets the designer show documents built from real
+// source rows, with p. Without it, only the tree of tables is previewed.
+func (m *DenormModel) SetDocumentPreview(fn DocumentPreviewFunc) {
+	m.previewer = fn
+}
+
 // extractRelationships finds FK relationships between the given tables.
 func extractRelationships(tables []schema.Table) []fkRelationship {
 	tableSet := make(map[string]bool, len(tables))
@@ -144,8 +205,55 @@ func (m DenormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		return m, nil
 
+	case documentPreviewMsg:
+		cols := m.BuildMapping().Collections
+		if !m.docMode || m.docCol >= len(cols) || cols[m.docCol].Name != msg.collection {
+			return m, nil // the pane has moved on
+		}
+		m.docLoading = false
+		m.docs, m.docErr, m.docScroll = nil, "", 0
+		if msg.err != nil {
+			m.docErr = msg.err.Error()
+			return m, nil
+		}
+		for _, d := range msg.docs {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, d, "", "  "); err != nil {
+				buf.Reset()
+				buf.Write(d)
+			}
+			m.docs = append(m.docs, buf.String())
+		}
+		return m, nil
+
 	case tea.KeyMsg:
-		// If no relationships, only f/q/esc are valid
+		if m.colMode {
+			return m.updateColumns(msg)
+		}
+		if m.docMode {
+			return m.updateDocuments(msg)
+		}
+		if msg.String() == "p" && m.previewer != nil && len(m.tables) > 0 {
+			m.docMode = true
+			m.docCol = 0
+			if len(m.rels) > 0 {
+				// Start at the collection of the selected relationship's parent
+				for i, c := range m.BuildMapping().Collections {
+					if c.SourceTable == m.rels[m.cursor].ParentTable {
+						m.docCol = i
+					}
+				}
+			}
+			return m.loadDocuments()
+		}
+		if msg.String() == "c" && len(m.tables) > 0 {
+			m.colMode = true
+			m.colCursor = 0
+			m.fieldErr = ""
+			return m, nil
+		}
+
+		// If no relationships, only c/f/q/esc are valid
 		if len(m.rels) == 0 {
 			switch msg.String() {
 			case "f", "enter":
@@ -187,6 +295,11 @@ func (m DenormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "r": // direct set: reference
 			m.rels[m.cursor].Choice = ChoiceReference
 
+		case "n": // toggle lower-casing keys joined ignoring case
+			if m.rels[m.cursor].Collation != "" {
+				m.rels[m.cursor].Normalize = !m.rels[m.cursor].Normalize
+			}
+
 		case "f", "enter":
 			m.enforceCycleConstraints()
 			m.done = true
@@ -197,6 +310,196 @@ func (m DenormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateColumns handles keys in the column mapping editor.
+func (m DenormModel) updateColumns(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	table := m.tables[m.colTable]
+
+	if m.editing {
+		switch msg.String() {
+		case "enter":
+			col := table.Columns[m.colCursor].Name
+			target := strings.TrimSpace(m.fieldInput.Value())
+			if target == col {
+				target = ""
+			}
+			if err := m.setField(table, mapping.FieldMapping{Column: col, Target: target}); err != nil {
+				m.fieldErr = err.Error()
+				return m, nil
+			}
+			m.editing = false
+			m.fieldInput.Blur()
+		case "esc":
+			m.editing = false
+			m.fieldErr = ""
+			m.fieldInput.Blur()
+		default:
+			var cmd tea.Cmd
+			m.fieldInput, cmd = m.fieldInput.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		m.done = true
+		m.cancelled = true
+		return m, tea.Quit
+
+	case "c", "esc":
+		m.colMode = false
+		m.fieldErr = ""
+
+	case "tab", "l", "right":
+		m.colTable = (m.colTable + 1) % len(m.tables)
+		m.colCursor = 0
+		m.fieldErr = ""
+
+	case "shift+tab", "h", "left":
+		m.colTable = (m.colTable + len(m.tables) - 1) % len(m.tables)
+		m.colCursor = 0
+		m.fieldErr = ""
+
+	case "j", "down":
+		if m.colCursor < len(table.Columns)-1 {
+			m.colCursor++
+		}
+
+	case "k", "up":
+		if m.colCursor > 0 {
+			m.colCursor--
+		}
+
+	case "x": // toggle exclude
+		if len(table.Columns) == 0 {
+			break
+		}
+		col := table.Columns[m.colCursor].Name
+		_, included := mapping.FieldTarget(m.fields[table.Name], col)
+		m.fieldErr = ""
+		if err := m.setField(table, mapping.FieldMapping{Column: col, Exclude: included}); err != nil {
+			m.fieldErr = err.Error()
+		}
+
+	case "e", "enter": // edit target field
+		if len(table.Columns) == 0 {
+			break
+		}
+		col := table.Columns[m.colCursor].Name
+		target, _ := mapping.FieldTarget(m.fields[table.Name], col)
+		if target == "" {
+			target = col
+		}
+		m.editing = true
+		m.fieldErr = ""
+		m.fieldInput.SetValue(target)
+		m.fieldInput.CursorEnd()
+		return m, m.fieldInput.Focus()
+	}
+	return m, nil
+}
+
+// updateDocuments handles keys in the document preview.
+func (m DenormModel) updateDocuments(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := len(m.BuildMapping().Collections)
+	switch msg.String() {
+	case "ctrl+c":
+		m.done = true
+		m.cancelled = true
+		return m, tea.Quit
+
+	case "p", "esc":
+		m.docMode = false
+
+	case "tab", "l", "right":
+		if n > 0 {
+			m.docCol = (m.docCol + 1) % n
+			return m.loadDocuments()
+		}
+
+	case "shift+tab", "h", "left":
+		if n > 0 {
+			m.docCol = (m.docCol + n - 1) % n
+			return m.loadDocuments()
+		}
+
+	case "r": // read the rows again
+		return m.loadDocuments()
+
+	case "j", "down":
+		if m.docScroll < len(m.docLines())-1 {
+			m.docScroll++
+		}
+
+	case "k", "up":
+		if m.docScroll > 0 {
+			m.docScroll--
+		}
+	}
+	return m, nil
+}
+
+// loadDocuments starts building the documents of the previewed collection
+// from the mapping as currently designed.
+func (m DenormModel) loadDocuments() (tea.Model, tea.Cmd) {
+	mp := m.BuildMapping()
+	if m.docCol >= len(mp.Collections) {
+		m.docCol = 0
+	}
+	if len(mp.Collections) == 0 {
+		return m, nil
+	}
+	m.docLoading = true
+	m.docs, m.docErr, m.docScroll = nil, "", 0
+	collection, fn := mp.Collections[m.docCol].Name, m.previewer
+	return m, func() tea.Msg {
+		docs, err := fn(mp, collection)
+		return documentPreviewMsg{collection: collection, docs: docs, err: err}
+	}
+}
+
+// setField replaces a column's field mapping, dropping it when the column
+// keeps its name, or the path its prefix group gives it. Prefix groups are
+// kept. The table's mappings are left unchanged if the result would be
+// invalid.
+func (m *DenormModel) setField(table schema.Table, f mapping.FieldMapping) error {
+	var fields, groups []mapping.FieldMapping
+	for _, existing := range m.fields[table.Name] {
+		if existing.Prefix != "" {
+			groups = append(groups, existing)
+		}
+	}
+	columns := make([]string, len(table.Columns))
+	for i, c := range table.Columns {
+		columns[i] = c.Name
+		if c.Name == f.Column {
+			def, included := mapping.FieldTarget(groups, c.Name)
+			if f.Exclude == included || (!f.Exclude && f.TargetPath() != def) {
+				if !f.Exclude && f.Target == "" {
+					f.Target = f.Column
+				}
+				fields = append(fields, f)
+			}
+			continue
+		}
+		for _, existing := range m.fields[table.Name] {
+			if existing.Column == c.Name {
+				fields = append(fields, existing)
+			}
+		}
+	}
+	fields = append(fields, groups...)
+	if err := mapping.ValidateFields(fields, columns); err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		delete(m.fields, table.Name)
+	} else {
+		m.fields[table.Name] = fields
+	}
+	return nil
+}
+
 // enforceCycleConstraints detects cycles where all edges are "embed" and forces one to "reference".
 func (m *DenormModel) enforceCycleConstraints() {
 	m.warnings = nil
@@ -246,10 +549,23 @@ func (m DenormModel) View() string {
 	title := titleStyle.Render("Step 4: Denormalization Design")
 	b.WriteString(title + "\n\n")
 
+	if m.colMode {
+		b.WriteString(m.columnsView())
+		return b.String()
+	}
+	if m.docMode {
+		b.WriteString(m.documentsView())
+		return b.String()
+	}
+
 	if len(m.rels) == 0 {
 		b.WriteString("  No foreign key relationships between selected tables.\n")
 		b.WriteString("  All tables will become standalone collections.\n\n")
-		b.WriteString(dimStyle.Render("  Press f to confirm • q to cancel\n"))
+		if m.previewer != nil {
+			b.WriteString(dimStyle.Render("  Press c to map columns • p to preview documents • f to confirm • q to cancel\n"))
+		} else {
+			b.WriteString(dimStyle.Render("  Press c to map columns • f to confirm • q to cancel\n"))
+		}
 		return b.String()
 	}
 
@@ -274,6 +590,13 @@ func (m DenormModel) View() string {
 		if rel.IsJoinTable {
 			labels = " (M2M join)"
 		}
+		if rel.Collation != "" {
+			if rel.Normalize {
+				labels += " (keys lower-cased)"
+			} else {
+				labels += " (ignores case)"
+			}
+		}
 
 		choiceStr := m.choiceLabel(rel.Choice)
 
@@ -281,9 +604,19 @@ func (m DenormModel) View() string {
 	}
 
 	// Warnings
-	if len(m.warnings) > 0 {
+	warnings := m.warnings
+	for _, rel := range m.rels {
+		if rel.Collation != "" && !rel.Normalize {
+			warnings = append(warnings, fmt.Sprintf("%s → %s compares keys as %s, MongoDB byte by byte: keys differing only in case will not join (n lower-cases them)",
+				rel.ChildTable, rel.ParentTable, rel.Collation))
+		}
+	}
+	for _, f := range m.BuildMapping().UnsafeFields(&schema.Schema{Tables: m.tables}) {
+		warnings = append(warnings, f.Warning())
+	}
+	if len(warnings) > 0 {
 		b.WriteString("\n")
-		for _, w := range m.warnings {
+		for _, w := range warnings {
 			b.WriteString(errStyle.Render("  ⚠ "+w) + "\n")
 		}
 	}
@@ -298,11 +631,113 @@ func (m DenormModel) View() string {
 
 	// Help
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("  j/k navigate • space cycle • a embed array • s embed single • r reference • f confirm • q cancel\n"))
+	help := "  j/k navigate • space cycle • a embed array • s embed single • r reference • n normalize keys • c map columns"
+	if m.previewer != nil {
+		help += " • p preview documents"
+	}
+	b.WriteString(dimStyle.Render(help + " • f confirm • q cancel\n"))
 
 	return b.String()
 }
 
+// docLines returns the lines of the previewed documents.
+func (m DenormModel) docLines() []string {
+	var lines []string
+	for i, d := range m.docs {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Split(d, "\n")...)
+	}
+	return lines
+}
+
+// documentsView renders the documents built for the previewed collection,
+// as many lines as fit from the scroll position.
+func (m DenormModel) documentsView() string {
+	var b strings.Builder
+	cols := m.BuildMapping().Collections
+	if m.docCol >= len(cols) {
+		b.WriteString("  No collections to preview.\n")
+		b.WriteString(dimStyle.Render("\n  p back\n"))
+		return b.String()
+	}
+	c := cols[m.docCol]
+
+	b.WriteString(dimStyle.Render(fmt.Sprintf("  Documents of %s from %s rows (%d/%d):", c.Name, c.SourceTable, m.docCol+1, len(cols))) + "\n\n")
+	switch {
+	case m.docLoading:
+		b.WriteString("  Reading sample rows…\n")
+	case m.docErr != "":
+		b.WriteString(errStyle.Render("  ⚠ "+m.docErr) + "\n")
+	case len(m.docs) == 0:
+		b.WriteString("  No rows in the source table pass the collection's filter.\n")
+	default:
+		lines := m.docLines()
+		room := m.height - 8
+		if room < 5 {
+			room = 5
+		}
+		end := m.docScroll + room
+		if end > len(lines) {
+			end = len(lines)
+		}
+		for _, line := range lines[m.docScroll:end] {
+			b.WriteString("  " + line + "\n")
+		}
+		if end < len(lines) {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  … %d more lines", len(lines)-end)) + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("  j/k scroll • tab/h/l switch collection • r reload • p back\n"))
+	return b.String()
+}
+
+// columnsView renders the column mapping editor for the selected table.
+func (m DenormModel) columnsView() string {
+	var b strings.Builder
+	table := m.tables[m.colTable]
+
+	b.WriteString(dimStyle.Render(fmt.Sprintf("  Columns of %s (%d/%d):", table.Name, m.colTable+1, len(m.tables))) + "\n\n")
+	if len(table.Columns) == 0 {
+		b.WriteString("  No columns discovered for this table.\n")
+	}
+	for i, c := range table.Columns {
+		cursor := "  "
+		if i == m.colCursor {
+			cursor = highlightStyle.Render("> ")
+		}
+		target, included := mapping.FieldTarget(m.fields[table.Name], c.Name)
+		var dest string
+		switch {
+		case !included:
+			dest = errStyle.Render("excluded")
+		case target != c.Name:
+			dest = successStyle.Render(target)
+		default:
+			dest = dimStyle.Render(target)
+		}
+		b.WriteString(fmt.Sprintf("%s%-30s → %s\n", cursor, c.Name, dest))
+	}
+
+	if m.editing {
+		b.WriteString("\n  Target field: " + m.fieldInput.View() + "\n")
+	}
+	if m.fieldErr != "" {
+		b.WriteString("\n" + errStyle.Render("  ⚠ "+m.fieldErr) + "\n")
+	}
+
+	b.WriteString("\n")
+	if m.editing {
+		b.WriteString(dimStyle.Render("  enter save • esc cancel\n"))
+	} else {
+		b.WriteString(dimStyle.Render("  j/k navigate • tab/h/l switch table • e rename • x toggle exclude • c back\n"))
+	}
+	return b.String()
+}
+
 func (m DenormModel) choiceLabel(c RelChoice) string {
 	switch c {
 	case ChoiceReference:
@@ -389,11 +824,11 @@ func (m DenormModel) buildPreview() []string {
 func (m DenormModel) BuildMapping() *mapping.Mapping {
 	// Track which tables are embedded (child→parent)
 	type embedEntry struct {
-		parentTable  string
-		childTable   string
-		joinColumn   string
-		parentColumn string
-		relationship string
+		parentTable   string
+		childTable    string
+		joinColumns   []string
+		parentColumns []string
+		relationship  string
 	}
 
 	var embeds []embedEntry
@@ -411,11 +846,11 @@ func (m DenormModel) BuildMapping() *mapping.Mapping {
 			relType = "single"
 		}
 		embeds = append(embeds, embedEntry{
-			parentTable:  rel.ParentTable,
-			childTable:   rel.ChildTable,
-			joinColumn:   strings.Join(rel.ChildColumns, ","),
-			parentColumn: strings.Join(rel.ParentColumns, ","),
-			relationship: relType,
+			parentTable:   rel.ParentTable,
+			childTable:    rel.ChildTable,
+			joinColumns:   rel.ChildColumns,
+			parentColumns: rel.ParentColumns,
+			relationship:  relType,
 		})
 		embeddedSet[rel.ChildTable] = true
 	}
@@ -439,10 +874,10 @@ func (m DenormModel) BuildMapping() *mapping.Mapping {
 				SourceTable:  e.childTable,
 				FieldName:    e.childTable,
 				Relationship: e.relationship,
-				JoinColumn:   e.joinColumn,
-				ParentColumn: e.parentColumn,
 				Embedded:     buildEmbedded(e.childTable), // recurse
+				Fields:       m.fields[e.childTable],
 			}
+			emb.SetJoin(e.joinColumns, e.parentColumns)
 			result = append(result, emb)
 		}
 		return result
@@ -450,10 +885,10 @@ func (m DenormModel) BuildMapping() *mapping.Mapping {
 
 	// Build reference list
 	type refInfo struct {
-		parentTable  string
-		childTable   string
-		joinColumn   string
-		parentColumn string
+		parentTable   string
+		childTable    string
+		joinColumns   []string
+		parentColumns []string
 	}
 	var refs []refInfo
 	for _, rel := range m.rels {
@@ -464,10 +899,10 @@ func (m DenormModel) BuildMapping() *mapping.Mapping {
 			}
 		}
 		refs = append(refs, refInfo{
-			parentTable:  rel.ParentTable,
-			childTable:   rel.ChildTable,
-			joinColumn:   strings.Join(rel.ChildColumns, ","),
-			parentColumn: strings.Join(rel.ParentColumns, ","),
+			parentTable:   rel.ParentTable,
+			childTable:    rel.ChildTable,
+			joinColumns:   rel.ChildColumns,
+			parentColumns: rel.ParentColumns,
 		})
 	}
 
@@ -482,6 +917,7 @@ func (m DenormModel) BuildMapping() *mapping.Mapping {
 			Name:        t.Name,
 			SourceTable: t.Name,
 			Embedded:    buildEmbedded(t.Name),
+			Fields:      m.fields[t.Name],
 		}
 		collMap[t.Name] = c
 		collOrder = append(collOrder, t.Name)
@@ -494,12 +930,9 @@ func (m DenormModel) BuildMapping() *mapping.Mapping {
 		if !ok {
 			continue
 		}
-		parent.References = append(parent.References, mapping.Reference{
-			SourceTable:  r.childTable,
-			FieldName:    r.childTable,
-			JoinColumn:   r.joinColumn,
-			ParentColumn: r.parentColumn,
-		})
+		ref := mapping.Reference{SourceTable: r.childTable, FieldName: r.childTable}
+		ref.SetJoin(r.joinColumns, r.parentColumns)
+		parent.References = append(parent.References, ref)
 	}
 
 	// Deduplicate collection order
@@ -513,7 +946,18 @@ func (m DenormModel) BuildMapping() *mapping.Mapping {
 		collections = append(collections, *collMap[name])
 	}
 
-	return &mapping.Mapping{Collections: collections}
+	mp := &mapping.Mapping{Collections: collections}
+	s := &schema.Schema{Tables: m.tables}
+	for _, rel := range m.rels {
+		if rel.Collation != "" && rel.Normalize {
+			collation.SetNormalized(s, mp, collation.JoinAdvice{
+				Table: rel.ChildTable, Columns: rel.ChildColumns,
+				ParentTable: rel.ParentTable, ParentColumns: rel.ParentColumns,
+			}, true)
+		}
+	}
+	mp.RenameUnsafeFields(s)
+	return mp
 }
 
 // Done returns true if the model has finished.
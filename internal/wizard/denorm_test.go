@@ -1,6 +1,8 @@
 package wizard
 
 import (
+	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 
@@ -599,3 +601,194 @@ func TestRelChoiceString(t *testing.T) {
 	}
 }
 
+
+func testTablesWithColumns() []schema.Table {
+	return []schema.Table{
+		{Name: "customers", Columns: []schema.Column{{Name: "id"}, {Name: "street"}, {Name: "ssn"}}},
+		{Name: "orders", Columns: []schema.Column{{Name: "id"}, {Name: "customer_id"}, {Name: "total"}}, ForeignKeys: []schema.ForeignKey{
+			{Name: "fk_orders_customer", Columns: []string{"customer_id"}, ReferencedTable: "customers", ReferencedColumns: []string{"id"}},
+		}},
+	}
+}
+
+func denormKeys(m DenormModel, keys ...string) DenormModel {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		case "ctrl+u":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlU}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		result, _ := m.Update(msg)
+		m = result.(DenormModel)
+	}
+	return m
+}
+
+func TestDenormColumnMapping(t *testing.T) {
+	m := NewDenormModel(testTablesWithColumns())
+
+	// Embed orders, then open the column editor on customers
+	m = denormKeys(m, "a", "c")
+	if !m.colMode {
+		t.Fatal("c should open the column editor")
+	}
+
+	// Rename street → address.street and exclude ssn
+	m = denormKeys(m, "j", "e", "ctrl+u", "address.street", "enter", "j", "x")
+	if m.editing {
+		t.Fatal("enter should finish editing")
+	}
+
+	// Switch to orders and rename total
+	m = denormKeys(m, "tab", "j", "j", "e", "ctrl+u", "amount", "enter", "c")
+	if m.colMode {
+		t.Fatal("c should close the column editor")
+	}
+
+	mp := denormKeys(m, "f").BuildMapping()
+	if len(mp.Collections) != 1 {
+		t.Fatalf("expected 1 collection, got %d", len(mp.Collections))
+	}
+	c := mp.Collections[0]
+	want := []mapping.FieldMapping{
+		{Column: "street", Target: "address.street"},
+		{Column: "ssn", Exclude: true},
+	}
+	if len(c.Fields) != 2 || c.Fields[0] != want[0] || c.Fields[1] != want[1] {
+		t.Errorf("collection fields = %+v, want %+v", c.Fields, want)
+	}
+	if len(c.Embedded) != 1 || len(c.Embedded[0].Fields) != 1 || c.Embedded[0].Fields[0].Target != "amount" {
+		t.Errorf("embedded fields = %+v, want total renamed to amount", c.Embedded)
+	}
+}
+
+func TestDenormColumnMapping_Invalid(t *testing.T) {
+	m := NewDenormModel(testTablesWithColumns())
+
+	// street → id collides with the id column
+	m = denormKeys(m, "c", "j", "e", "ctrl+u", "id", "enter")
+	if !m.editing {
+		t.Error("invalid target should keep the editor open")
+	}
+	if m.fieldErr == "" {
+		t.Error("invalid target should show an error")
+	}
+	if !strings.Contains(m.View(), m.fieldErr) {
+		t.Error("view should render the error")
+	}
+
+	// Cancel leaves the column unmapped
+	m = denormKeys(m, "esc")
+	if len(m.fields["customers"]) != 0 {
+		t.Errorf("fields = %+v, want none after cancel", m.fields["customers"])
+	}
+}
+
+func TestDenormColumnMapping_ToggleExclude(t *testing.T) {
+	m := NewDenormModel(testTablesWithColumns())
+
+	m = denormKeys(m, "c", "x")
+	if _, ok := mapping.FieldTarget(m.fields["customers"], "id"); ok {
+		t.Error("x should exclude the column")
+	}
+	if !strings.Contains(m.View(), "excluded") {
+		t.Error("view should mark the column excluded")
+	}
+	m = denormKeys(m, "x")
+	if len(m.fields["customers"]) != 0 {
+		t.Errorf("second x should restore the column, got %+v", m.fields["customers"])
+	}
+}
+
+func TestDenormCaseInsensitiveJoin(t *testing.T) {
+	ci := &schema.Collation{Name: "citext", CaseInsensitive: true}
+	tables := []schema.Table{
+		{Name: "customers", Columns: []schema.Column{{Name: "code", DataType: "citext", Collation: ci}}},
+		{Name: "orders", Columns: []schema.Column{{Name: "id", DataType: "integer"}, {Name: "customer_code", DataType: "text"}}, ForeignKeys: []schema.ForeignKey{
+			{Name: "fk_orders_customer", Columns: []string{"customer_code"}, ReferencedTable: "customers", ReferencedColumns: []string{"code"}},
+		}},
+	}
+	m := NewDenormModel(tables)
+	if m.rels[0].Collation == "" {
+		t.Fatal("join on a citext key should be flagged")
+	}
+	m.rels[0].Choice = ChoiceEmbedArray
+	if view := m.View(); !strings.Contains(view, "(ignores case)") || !strings.Contains(view, "n lower-cases them") {
+		t.Errorf("view should warn about the case-insensitive join:\n%s", view)
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = result.(DenormModel)
+	if !m.rels[0].Normalize {
+		t.Fatal("'n' should normalize the join keys")
+	}
+	if strings.Contains(m.View(), "n lower-cases them") {
+		t.Error("normalized join should not be warned about")
+	}
+
+	mp := m.BuildMapping()
+	if len(mp.Collections) != 1 {
+		t.Fatalf("collections = %+v, want customers only", mp.Collections)
+	}
+	c := mp.Collections[0]
+	if !mp.NormalizesColumn("customers", "code") || len(c.Transformations) != 1 {
+		t.Errorf("customers transformations = %+v, want code lower-cased", c.Transformations)
+	}
+	if ts := c.Embedded[0].Transformations; len(ts) != 1 || ts[0] != mapping.NormalizeTransformation("customer_code") {
+		t.Errorf("orders transformations = %+v, want customer_code lower-cased", ts)
+	}
+}
+
+func TestDenormDocumentPreview(t *testing.T) {
+	m := NewDenormModel(testTablesWithColumns())
+	if strings.Contains(m.View(), "p preview documents") {
+		t.Error("preview should not be offered without a previewer")
+	}
+	var got *mapping.Mapping
+	m.SetDocumentPreview(func(mp *mapping.Mapping, collection string) ([]json.RawMessage, error) {
+		got = mp
+		if collection != "customers" {
+			return nil, errors.New("no rows")
+		}
+		return []json.RawMessage{json.RawMessage(`{"id":1,"orders":[{"total":5}]}`)}, nil
+	})
+
+	m = denormKeys(m, "a")
+	result, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	m = result.(DenormModel)
+	if !m.docMode || !m.docLoading || cmd == nil {
+		t.Fatal("p should open the document preview and start reading")
+	}
+	if !strings.Contains(m.View(), "Reading sample rows") {
+		t.Errorf("view should show the preview loading:\n%s", m.View())
+	}
+	result, _ = m.Update(cmd())
+	m = result.(DenormModel)
+	if got == nil || len(got.Collections) != 1 || len(got.Collections[0].Embedded) != 1 {
+		t.Errorf("previewed mapping = %+v, want orders embedded in customers", got)
+	}
+	view := m.View()
+	if m.docLoading || !strings.Contains(view, "Documents of customers") || !strings.Contains(view, `"total": 5`) {
+		t.Errorf("view should show the indented documents:\n%s", view)
+	}
+
+	// A late answer for another collection is ignored
+	result, _ = m.Update(documentPreviewMsg{collection: "orders", err: errors.New("stale")})
+	m = result.(DenormModel)
+	if m.docErr != "" {
+		t.Errorf("docErr = %q, want the stale answer ignored", m.docErr)
+	}
+
+	m = denormKeys(m, "p")
+	if m.docMode {
+		t.Error("p should close the document preview")
+	}
+}
@@ -0,0 +1,153 @@
+package wizard
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/postmigration"
+)
+
+// IDGenerationModel is the bubbletea model for choosing how collections
+// whose source ids came from a sequence make new ids after cutover
+// (Step 4c).
+type IDGenerationModel struct {
+	candidates []postmigration.IDCandidate
+	strategies []string
+	cursor     int
+	done       bool
+	skipped    bool
+	width      int
+	height     int
+}
+
+// NewIDGenerationModel creates an ID generation chooser for the candidates,
+// starting from their current strategies.
+func NewIDGenerationModel(candidates []postmigration.IDCandidate) IDGenerationModel {
+	strategies := make([]string, len(candidates))
+	for i, c := range candidates {
+		strategies[i] = c.Strategy
+	}
+	return IDGenerationModel{
+		candidates: candidates,
+		strategies: strategies,
+		width:      100,
+		height:     24,
+	}
+}
+
+func (m IDGenerationModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m IDGenerationModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		if len(m.candidates) == 0 {
+			switch msg.String() {
+			case "enter", "q", "esc", "ctrl+c":
+				m.done = true
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			m.done = true
+			m.skipped = true
+			return m, tea.Quit
+
+		case "j", "down":
+			if m.cursor < len(m.candidates)-1 {
+				m.cursor++
+			}
+
+		case "k", "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "c":
+			m.strategies[m.cursor] = mapping.IDCounter
+
+		case "o":
+			m.strategies[m.cursor] = mapping.IDObjectID
+
+		case "x":
+			m.strategies[m.cursor] = ""
+
+		case "enter":
+			m.done = true
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+func (m IDGenerationModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Step 4c: ID Generation"))
+	b.WriteString("\n\n")
+
+	if len(m.candidates) == 0 {
+		b.WriteString("  No collections with sequence or identity primary keys.\n\n")
+		b.WriteString(dimStyle.Render("  Press enter to continue\n"))
+		return b.String()
+	}
+
+	b.WriteString("  These primary keys came from sequences. Choose how the application\n")
+	b.WriteString("  makes new ids once MongoDB takes the writes.\n\n")
+	b.WriteString(fmt.Sprintf("  %-24s %-28s %s\n", "Collection", "Key", "New ids"))
+	b.WriteString("  " + strings.Repeat("─", 76) + "\n")
+	for i, c := range m.candidates {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = highlightStyle.Render("> ")
+		}
+		strategy := dimStyle.Render("undecided")
+		switch m.strategies[i] {
+		case mapping.IDCounter:
+			strategy = successStyle.Render("counter (seeded with the highest id)")
+		case mapping.IDObjectID:
+			strategy = successStyle.Render("ObjectId _id")
+		}
+		b.WriteString(fmt.Sprintf("%s%-24s %-28s %s\n", cursor, c.Collection, c.Table+"."+c.Column, strategy))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("  c counter • o ObjectId • x clear • enter confirm • q skip\n"))
+	return b.String()
+}
+
+// Result returns the strategy chosen for each candidate collection, empty
+// where none was, or nil if the step was skipped.
+func (m IDGenerationModel) Result() map[string]string {
+	if m.skipped {
+		return nil
+	}
+	out := make(map[string]string, len(m.candidates))
+	for i, c := range m.candidates {
+		out[c.Collection] = m.strategies[i]
+	}
+	return out
+}
+
+// Done returns true if the model has finished.
+func (m IDGenerationModel) Done() bool {
+	return m.done
+}
+
+// Skipped returns true if the user left without changing any strategy.
+func (m IDGenerationModel) Skipped() bool {
+	return m.done && m.skipped
+}
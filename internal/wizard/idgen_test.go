@@ -0,0 +1,57 @@
+package wizard
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/postmigration"
+)
+
+func pressIDGeneration(m IDGenerationModel, keys ...string) IDGenerationModel {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		if k == "enter" {
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		} else {
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		result, _ := m.Update(msg)
+		m = result.(IDGenerationModel)
+	}
+	return m
+}
+
+func testIDGenerationModel() IDGenerationModel {
+	return NewIDGenerationModel([]postmigration.IDCandidate{
+		{Collection: "orders", Table: "orders", Column: "order_id", Field: "order_id"},
+		{Collection: "invoices", Table: "invoices", Column: "id", Field: "id", Strategy: mapping.IDObjectID},
+	})
+}
+
+func TestIDGenerationModel_Choose(t *testing.T) {
+	m := pressIDGeneration(testIDGenerationModel(), "c")
+	if !strings.Contains(m.View(), "counter (seeded with the highest id)") {
+		t.Error("view should show the counter")
+	}
+	m = pressIDGeneration(m, "j", "x", "enter")
+	if !m.Done() || m.Skipped() {
+		t.Fatal("enter should finish the step")
+	}
+	want := map[string]string{"orders": mapping.IDCounter, "invoices": ""}
+	got := m.Result()
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Result()[%s] = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestIDGenerationModel_Skip(t *testing.T) {
+	m := pressIDGeneration(testIDGenerationModel(), "c", "q")
+	if !m.Skipped() || m.Result() != nil {
+		t.Errorf("q should skip without changes, got %v", m.Result())
+	}
+}
@@ -6,14 +6,25 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/reloquent/reloquent/internal/postmigration"
 	"github.com/reloquent/reloquent/internal/target"
 )
 
+// IndexProgressMsg delivers per-index build progress to the index build model.
+type IndexProgressMsg []target.IndexBuildStatus
+
+// IndexBuildsDoneMsg reports that the index builds have ended.
+type IndexBuildsDoneMsg struct {
+	Err error
+}
+
 // IndexBuildModel is the bubbletea model for Step 11: Index Builds.
 type IndexBuildModel struct {
 	totalIndexes int
 	completed    int
 	statuses     []target.IndexBuildStatus
+	control      *postmigration.IndexBuildControl
+	err          error
 	done         bool
 	cancelled    bool
 	finished     bool
@@ -41,6 +52,18 @@ func (m IndexBuildModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		return m, nil
 
+	case IndexProgressMsg:
+		m.UpdateProgress(msg)
+		return m, nil
+
+	case IndexBuildsDoneMsg:
+		if msg.Err != nil {
+			m.err = msg.Err
+		} else {
+			m.SetFinished()
+		}
+		return m, nil
+
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "q", "esc":
@@ -48,10 +71,18 @@ func (m IndexBuildModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.cancelled = true
 			return m, tea.Quit
 		case "enter":
-			if m.finished {
+			if m.finished || m.err != nil {
 				m.done = true
 				return m, tea.Quit
 			}
+		case "p":
+			if m.control != nil && !m.finished && m.err == nil {
+				if m.control.Paused() {
+					m.control.Resume()
+				} else {
+					m.control.Pause()
+				}
+			}
 		}
 	}
 
@@ -72,12 +103,21 @@ func (m IndexBuildModel) View() string {
 	}
 
 	// Summary
-	if m.finished {
+	switch {
+	case m.finished:
 		b.WriteString(successStyle.Render(fmt.Sprintf("  All %d indexes built successfully.", m.totalIndexes)))
 		b.WriteString("\n\n")
-	} else {
-		b.WriteString(fmt.Sprintf("  Building %d indexes... %d/%d complete.\n\n",
-			m.totalIndexes, m.completed, m.totalIndexes))
+	case m.err != nil:
+		b.WriteString(errStyle.Render(fmt.Sprintf("  Index builds failed: %v", m.err)))
+		b.WriteString("\n\n")
+	default:
+		b.WriteString(fmt.Sprintf("  Building %d indexes... %d/%d complete (%.0f%%).\n",
+			m.totalIndexes, m.completed, m.totalIndexes, postmigration.OverallIndexProgress(m.statuses)))
+		if m.control.Paused() {
+			b.WriteString(warnStyle.Render("  Paused: running builds finish, no new ones start."))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
 	}
 
 	// Per-index status
@@ -88,31 +128,41 @@ func (m IndexBuildModel) View() string {
 			icon = successStyle.Render("OK")
 		case "building":
 			icon = highlightStyle.Render(">>")
+		case "failed":
+			icon = errStyle.Render("XX")
 		default:
 			icon = dimStyle.Render("..")
 		}
 
 		line := fmt.Sprintf("  %s %-30s %-20s", icon, s.IndexName, s.Collection)
-		if s.Phase == "building" && s.Progress > 0 {
+		if s.Phase == "building" {
 			barWidth := m.width - 60
 			if barWidth < 10 {
 				barWidth = 10
 			}
 			bar := renderProgressBar(s.Progress, barWidth)
 			line += fmt.Sprintf(" %s %.0f%%", bar, s.Progress)
+			if s.Message == target.EstimatedProgressMessage {
+				line += dimStyle.Render(" (est.)")
+			}
+		} else if s.Phase == "failed" && s.Message != "" {
+			line += " " + errStyle.Render(s.Message)
 		}
 		b.WriteString(line + "\n")
 	}
 
-	if m.finished {
-		b.WriteString("\n")
+	b.WriteString("\n")
+	switch {
+	case m.finished || m.err != nil:
 		b.WriteString(dimStyle.Render("  Press enter to continue"))
-		b.WriteString("\n")
-	} else {
-		b.WriteString("\n")
+	case m.control != nil && m.control.Paused():
+		b.WriteString(dimStyle.Render("  p: resume • q: cancel"))
+	case m.control != nil:
+		b.WriteString(dimStyle.Render("  p: pause • q: cancel"))
+	default:
 		b.WriteString(dimStyle.Render("  q: cancel"))
-		b.WriteString("\n")
 	}
+	b.WriteString("\n")
 
 	return b.String()
 }
@@ -127,6 +177,16 @@ func (m IndexBuildModel) Cancelled() bool {
 	return m.cancelled
 }
 
+// Err returns the error that ended the index builds, if any.
+func (m IndexBuildModel) Err() error {
+	return m.err
+}
+
+// SetControl lets the model pause and resume the builds with p.
+func (m *IndexBuildModel) SetControl(control *postmigration.IndexBuildControl) {
+	m.control = control
+}
+
 // UpdateProgress sets the current index build statuses.
 func (m *IndexBuildModel) UpdateProgress(statuses []target.IndexBuildStatus) {
 	m.statuses = statuses
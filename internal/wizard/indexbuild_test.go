@@ -1,11 +1,13 @@
 package wizard
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/reloquent/reloquent/internal/postmigration"
 	"github.com/reloquent/reloquent/internal/target"
 )
 
@@ -84,3 +86,52 @@ func TestIndexBuildModel_View_Title(t *testing.T) {
 		t.Error("view should contain step title")
 	}
 }
+
+func TestIndexBuildModel_ProgressMsgs(t *testing.T) {
+	m := NewIndexBuildModel(2)
+	result, _ := m.Update(IndexProgressMsg{
+		{Collection: "orders", IndexName: "idx_a", Phase: "complete", Progress: 100},
+		{Collection: "orders", IndexName: "idx_b", Phase: "building", Progress: 50},
+	})
+	rm := result.(IndexBuildModel)
+	v := rm.View()
+	if !strings.Contains(v, "1/2 complete (75%)") {
+		t.Errorf("view should show overall progress: %s", v)
+	}
+	if !strings.Contains(v, "50%") {
+		t.Error("view should show per-index progress")
+	}
+
+	result, _ = rm.Update(IndexBuildsDoneMsg{})
+	if !strings.Contains(result.View(), "successfully") {
+		t.Error("done without error should finish the builds")
+	}
+
+	result, _ = rm.Update(IndexBuildsDoneMsg{Err: errors.New("duplicate key")})
+	rm = result.(IndexBuildModel)
+	if rm.Err() == nil || !strings.Contains(rm.View(), "duplicate key") {
+		t.Error("failure should be shown")
+	}
+	result, _ = rm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !result.(IndexBuildModel).Done() {
+		t.Error("enter after a failure should exit")
+	}
+}
+
+func TestIndexBuildModel_PauseKey(t *testing.T) {
+	m := NewIndexBuildModel(1)
+	control := postmigration.NewIndexBuildControl()
+	m.SetControl(control)
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	if !control.Paused() {
+		t.Fatal("p should pause the builds")
+	}
+	if !strings.Contains(result.View(), "Paused") {
+		t.Error("view should show the builds are paused")
+	}
+	result.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	if control.Paused() {
+		t.Error("p again should resume the builds")
+	}
+}
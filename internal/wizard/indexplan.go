@@ -0,0 +1,302 @@
+package wizard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// IndexPlanModel is the bubbletea model for reviewing and editing the index
+// plan before indexes are built (Step 11a).
+type IndexPlanModel struct {
+	plan    *indexes.IndexPlan
+	mapping *mapping.Mapping
+	cursor  int
+	adding  bool
+	input   textinput.Model
+	err     string
+	changed bool
+	done    bool
+	skipped bool
+	width   int
+	height  int
+}
+
+// NewIndexPlanModel creates an index plan editor working on a copy of plan.
+func NewIndexPlanModel(plan *indexes.IndexPlan, m *mapping.Mapping) IndexPlanModel {
+	edited := &indexes.IndexPlan{Explanations: plan.Explanations, Edited: plan.Edited}
+	edited.Indexes = append(edited.Indexes, plan.Indexes...)
+
+	input := textinput.New()
+	input.Placeholder = "orders customer_id,created_at:-1"
+	input.CharLimit = 200
+	input.Width = 60
+
+	return IndexPlanModel{
+		plan:    edited,
+		mapping: m,
+		input:   input,
+		width:   100,
+		height:  24,
+	}
+}
+
+func (m IndexPlanModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m IndexPlanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.adding {
+			return m.updateAdding(msg)
+		}
+		m.err = ""
+
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			m.done = true
+			m.skipped = true
+			return m, tea.Quit
+
+		case "j", "down":
+			if m.cursor < len(m.plan.Indexes)-1 {
+				m.cursor++
+			}
+
+		case "k", "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "J", "shift+down": // build later
+			m.move(1)
+
+		case "K", "shift+up": // build sooner
+			m.move(-1)
+
+		case "a":
+			m.adding = true
+			m.input.SetValue("")
+			m.input.Focus()
+			return m, textinput.Blink
+
+		case "d", "x":
+			if m.plan.Remove(m.cursor) == nil {
+				m.changed = true
+				m.cursor = max(0, min(m.cursor, len(m.plan.Indexes)-1))
+			}
+
+		case "u":
+			m.toggle(m.plan.ToggleUnique)
+
+		case "t":
+			m.toggle(m.plan.ToggleTTL)
+
+		case "p":
+			m.toggle(m.plan.TogglePartial)
+
+		case "enter", "f":
+			if err := m.plan.Validate(m.mapping); err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			m.done = true
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+// updateAdding handles keys while a new index is being typed.
+func (m IndexPlanModel) updateAdding(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.adding = false
+		m.input.Blur()
+		return m, nil
+
+	case "enter":
+		collection, keys, err := parseIndexSpec(m.input.Value())
+		if err == nil {
+			err = m.plan.Add(collection, target.IndexDefinition{Keys: keys})
+		}
+		if err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		m.err = ""
+		m.adding = false
+		m.input.Blur()
+		m.changed = true
+		m.cursor = len(m.plan.Indexes) - 1
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// move shifts the selected index by delta, keeping it selected.
+func (m *IndexPlanModel) move(delta int) {
+	if pos := m.plan.Move(m.cursor, delta); pos != m.cursor {
+		m.cursor = pos
+		m.changed = true
+	}
+}
+
+// toggle applies an option toggle to the selected index.
+func (m *IndexPlanModel) toggle(fn func(int) error) {
+	if len(m.plan.Indexes) == 0 {
+		return
+	}
+	if err := fn(m.cursor); err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.changed = true
+}
+
+// parseIndexSpec parses "collection field[:order],..." as typed when adding
+// an index; orders default to ascending, "text" makes a text key,
+// "2dsphere" a geospatial one and "search" an Atlas Search field.
+func parseIndexSpec(spec string) (string, []target.IndexKey, error) {
+	collection, fields, ok := strings.Cut(strings.TrimSpace(spec), " ")
+	fields = strings.TrimSpace(fields)
+	if !ok || collection == "" || fields == "" {
+		return "", nil, fmt.Errorf("enter a collection and its key fields, e.g. orders customer_id,created_at:-1")
+	}
+	var keys []target.IndexKey
+	for _, f := range strings.Split(fields, ",") {
+		name, order, hasOrder := strings.Cut(strings.TrimSpace(f), ":")
+		k := target.IndexKey{Field: name, Order: 1}
+		if hasOrder && (order == target.IndexText || order == target.Index2dsphere || order == target.IndexSearch) {
+			k = target.IndexKey{Field: name, Type: order}
+		} else if hasOrder {
+			n, err := strconv.Atoi(order)
+			if err != nil || (n != 1 && n != -1) {
+				return "", nil, fmt.Errorf("order of %s must be 1, -1, text, 2dsphere or search", name)
+			}
+			k.Order = n
+		}
+		if k.Field == "" {
+			return "", nil, fmt.Errorf("empty field in %q", fields)
+		}
+		keys = append(keys, k)
+	}
+	return collection, keys, nil
+}
+
+func (m IndexPlanModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Step 11a: Index Plan"))
+	b.WriteString("\n\n")
+
+	if len(m.plan.Indexes) == 0 {
+		b.WriteString("  No indexes planned.\n")
+	} else {
+		b.WriteString(fmt.Sprintf("  %-3s %-20s %-40s %s\n", "#", "Collection", "Keys", "Options"))
+		b.WriteString("  " + strings.Repeat("─", 80) + "\n")
+		for i, ci := range m.plan.Indexes {
+			cursor := "  "
+			if i == m.cursor {
+				cursor = highlightStyle.Render("> ")
+			}
+			b.WriteString(fmt.Sprintf("%s%-3d %-20s %-40s %s\n", cursor, i+1, ci.Collection,
+				indexKeysLabel(ci.Index.Keys), indexOptionsLabel(ci.Index)))
+		}
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("  "+m.plan.Indexes[m.cursor].Index.Name) + "\n")
+	}
+
+	if m.adding {
+		b.WriteString("\n  New index (collection field[:1|-1|text],...):\n  ")
+		b.WriteString(m.input.View())
+		b.WriteString("\n")
+	}
+	if m.err != "" {
+		b.WriteString("\n" + errStyle.Render("  "+m.err) + "\n")
+	}
+	if m.changed && !m.adding {
+		b.WriteString("\n" + warnStyle.Render("  Edited: the saved plan will be built exactly as shown") + "\n")
+	}
+
+	b.WriteString("\n")
+	if m.adding {
+		b.WriteString(dimStyle.Render("  enter add • esc cancel\n"))
+	} else {
+		b.WriteString(dimStyle.Render("  a add • d remove • J/K reorder • u unique • t TTL • p partial • enter approve • q keep plan\n"))
+	}
+	return b.String()
+}
+
+// indexKeysLabel renders index keys as field:order pairs.
+func indexKeysLabel(keys []target.IndexKey) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s:%v", k.Field, k.Value())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// indexOptionsLabel lists the options set on an index.
+func indexOptionsLabel(idx target.IndexDefinition) string {
+	var opts []string
+	if idx.Unique {
+		opts = append(opts, "unique")
+	}
+	switch secs := idx.ExpireAfterSeconds; {
+	case secs > 0 && secs%86400 == 0:
+		opts = append(opts, fmt.Sprintf("ttl %dd", secs/86400))
+	case secs > 0:
+		opts = append(opts, fmt.Sprintf("ttl %ds", secs))
+	}
+	if len(idx.PartialFilter) > 0 {
+		opts = append(opts, "partial")
+	}
+	if idx.Sparse {
+		opts = append(opts, "sparse")
+	}
+	if c := idx.Collation; c != nil {
+		opts = append(opts, fmt.Sprintf("collation %s/%d", c.Locale, c.Strength))
+	}
+	return strings.Join(opts, ", ")
+}
+
+// Result returns the approved plan, or nil if the step was skipped.
+func (m IndexPlanModel) Result() *indexes.IndexPlan {
+	if m.skipped {
+		return nil
+	}
+	return m.plan
+}
+
+// Changed returns true if the plan was edited.
+func (m IndexPlanModel) Changed() bool {
+	return m.changed
+}
+
+// Done returns true if the model has finished.
+func (m IndexPlanModel) Done() bool {
+	return m.done
+}
+
+// Skipped returns true if the user kept the plan without approving edits.
+func (m IndexPlanModel) Skipped() bool {
+	return m.done && m.skipped
+}
@@ -0,0 +1,100 @@
+package wizard
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+func testIndexPlanModel() IndexPlanModel {
+	plan := &indexes.IndexPlan{Indexes: []target.CollectionIndex{
+		{Collection: "users", Index: target.IndexDefinition{Name: "pk_users", Keys: []target.IndexKey{{Field: "user_id", Order: 1}}, Unique: true}},
+		{Collection: "orders", Index: target.IndexDefinition{Name: "ref_orders_user", Keys: []target.IndexKey{{Field: "user_id", Order: 1}}}},
+	}}
+	m := &mapping.Mapping{Collections: []mapping.Collection{{Name: "users"}, {Name: "orders"}}}
+	return NewIndexPlanModel(plan, m)
+}
+
+func pressIndexPlan(m IndexPlanModel, keys ...string) IndexPlanModel {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		result, _ := m.Update(msg)
+		m = result.(IndexPlanModel)
+	}
+	return m
+}
+
+func TestIndexPlanModel_Edit(t *testing.T) {
+	m := pressIndexPlan(testIndexPlanModel(), "u", "t", "j", "p", "K")
+	if !strings.Contains(m.View(), "partial") || !m.Changed() {
+		t.Error("view should show the edited options")
+	}
+	m = pressIndexPlan(m, "a", "orders created_at:-1,status", "enter", "enter")
+	if !m.Done() || m.Skipped() {
+		t.Fatal("enter should approve the plan")
+	}
+
+	got := m.Result().Indexes
+	if len(got) != 3 || got[0].Collection != "orders" || got[0].Index.PartialFilter == nil {
+		t.Fatalf("plan = %+v, want the partial orders index moved first", got)
+	}
+	if u := got[1].Index; u.Unique || u.ExpireAfterSeconds != indexes.DefaultTTLSeconds {
+		t.Errorf("users index = %+v, want non-unique with a TTL", u)
+	}
+	if k := got[2].Index.Keys; len(k) != 2 || k[0].Order != -1 || k[1].Field != "status" {
+		t.Errorf("added keys = %+v", k)
+	}
+}
+
+func TestIndexPlanModel_Errors(t *testing.T) {
+	m := pressIndexPlan(testIndexPlanModel(), "a", "people", "enter")
+	if !m.adding || !strings.Contains(m.View(), "enter a collection") {
+		t.Error("an incomplete spec should keep the prompt open with an error")
+	}
+	m = pressIndexPlan(m, "esc", "a", "people name", "enter", "enter")
+	if m.Done() || !strings.Contains(m.View(), "not in the mapping") {
+		t.Error("approving a plan for an unknown collection should fail validation")
+	}
+	m = pressIndexPlan(m, "d", "q")
+	if !m.Skipped() || m.Result() != nil {
+		t.Error("q should keep the plan without a result")
+	}
+}
+
+func TestParseIndexSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+		want    string
+	}{
+		{"orders customer_id", false, "customer_id:1"},
+		{"orders  a:-1, b", false, "a:-1, b:1"},
+		{"orders title:text,a", false, "title:text, a:1"},
+		{"orders a:2", true, ""},
+		{"orders", true, ""},
+		{"orders a,,b", true, ""},
+	}
+	for _, tt := range tests {
+		coll, keys, err := parseIndexSpec(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseIndexSpec(%q) err = %v", tt.spec, err)
+			continue
+		}
+		if !tt.wantErr && (coll != "orders" || indexKeysLabel(keys) != tt.want) {
+			t.Errorf("parseIndexSpec(%q) = %s %s, want %s", tt.spec, coll, indexKeysLabel(keys), tt.want)
+		}
+	}
+}
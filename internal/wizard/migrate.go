@@ -1,6 +1,7 @@
 package wizard
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -10,9 +11,21 @@ import (
 	"github.com/reloquent/reloquent/internal/sizing"
 )
 
+// MigrationStatusMsg delivers a migration status update to the migrate model.
+type MigrationStatusMsg migration.Status
+
+// MigrationPauser pauses and resumes the running migration, as the engine
+// does.
+type MigrationPauser interface {
+	PauseMigration() error
+	ResumeMigration(ctx context.Context, callback migration.StatusCallback) error
+}
+
 // MigrateModel is the bubbletea model for Step 9: Migration Execution.
 type MigrateModel struct {
 	status      *migration.Status
+	pauser      MigrationPauser
+	pauseErr    error
 	failAction  migration.FailureAction
 	showingFail bool
 	done        bool
@@ -41,6 +54,11 @@ func (m MigrateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		return m, nil
 
+	case MigrationStatusMsg:
+		status := migration.Status(msg)
+		m.SetStatus(&status)
+		return m, nil
+
 	case tea.KeyMsg:
 		if m.showingFail {
 			switch msg.String() {
@@ -67,10 +85,20 @@ func (m MigrateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.cancelled = true
 			return m, tea.Quit
 		case "enter":
-			if m.status.Phase == "completed" || m.status.Phase == "failed" || m.status.Phase == "partial_failure" {
+			switch m.status.Phase {
+			case "completed", "failed", "partial_failure", migration.PhaseWindowExpired:
 				m.done = true
 				return m, tea.Quit
 			}
+		case "p":
+			if m.pauser == nil {
+				return m, nil
+			}
+			if m.status.Phase == migration.PhasePaused {
+				m.pauseErr = m.pauser.ResumeMigration(context.Background(), nil)
+			} else if m.pausable() {
+				m.pauseErr = m.pauser.PauseMigration()
+			}
 		}
 	}
 
@@ -91,12 +119,14 @@ func (m MigrateModel) View() string {
 	// Phase
 	phaseStyle := dimStyle
 	switch m.status.Phase {
-	case "running":
+	case "uploading", "submitting", "starting", "running":
 		phaseStyle = highlightStyle
 	case "completed":
 		phaseStyle = successStyle
 	case "failed", "partial_failure":
 		phaseStyle = errStyle
+	case migration.PhaseWindowExpired, migration.PhasePaused:
+		phaseStyle = warnStyle
 	}
 	b.WriteString(fmt.Sprintf("  Phase: %s\n", phaseStyle.Render(m.status.Phase)))
 
@@ -169,9 +199,27 @@ func (m MigrateModel) View() string {
 		b.WriteString(successStyle.Render("  Migration completed successfully!"))
 		b.WriteString("\n")
 		b.WriteString(dimStyle.Render("  Press enter to continue"))
+	} else if m.status.Phase == migration.PhaseWindowExpired {
+		b.WriteString("\n")
+		b.WriteString(warnStyle.Render("  Migration window expired; resume in the next window."))
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("  Press enter to exit"))
+	} else if m.status.Phase == migration.PhasePaused {
+		b.WriteString("\n")
+		b.WriteString(warnStyle.Render("  Paused; the state is saved, so the migration can also be resumed after a restart."))
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("  p: resume • q: cancel migration"))
 	} else if m.status.Phase != "failed" && m.status.Phase != "partial_failure" {
 		b.WriteString("\n")
-		b.WriteString(dimStyle.Render("  q: cancel migration"))
+		if m.pauser != nil && m.pausable() {
+			b.WriteString(dimStyle.Render("  p: pause • q: cancel migration"))
+		} else {
+			b.WriteString(dimStyle.Render("  q: cancel migration"))
+		}
+	}
+	if m.pauseErr != nil {
+		b.WriteString("\n")
+		b.WriteString(errStyle.Render("  " + m.pauseErr.Error()))
 	}
 
 	return b.String()
@@ -192,6 +240,20 @@ func (m MigrateModel) FailureAction() migration.FailureAction {
 	return m.failAction
 }
 
+// SetPauser lets the model pause and resume the migration with p.
+func (m *MigrateModel) SetPauser(p MigrationPauser) {
+	m.pauser = p
+}
+
+// pausable reports whether the migration is in a phase that can pause.
+func (m MigrateModel) pausable() bool {
+	switch m.status.Phase {
+	case "uploading", "submitting", "starting", "running":
+		return true
+	}
+	return false
+}
+
 // SetStatus updates the migration status for display.
 func (m *MigrateModel) SetStatus(status *migration.Status) {
 	m.status = status
@@ -1,6 +1,7 @@
 package wizard
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -28,6 +29,21 @@ func TestMigrateModel_Cancel(t *testing.T) {
 	}
 }
 
+func TestMigrateModel_StatusMsg(t *testing.T) {
+	m := NewMigrateModel()
+	result, _ := m.Update(MigrationStatusMsg(migration.Status{
+		Phase: "starting",
+		Collections: []migration.CollectionStatus{
+			{Name: "orders", State: "pending"},
+		},
+	}))
+	rm := result.(MigrateModel)
+	view := rm.View()
+	if !strings.Contains(view, "starting") || !strings.Contains(view, "orders") {
+		t.Errorf("view should show the received status, got:\n%s", view)
+	}
+}
+
 func TestMigrateModel_ProgressDisplay(t *testing.T) {
 	m := NewMigrateModel()
 	m.SetStatus(&migration.Status{
@@ -136,3 +152,71 @@ func TestRenderProgressBar(t *testing.T) {
 		t.Error("progress bar should be enclosed in brackets")
 	}
 }
+
+func TestMigrateModel_WindowExpired(t *testing.T) {
+	m := NewMigrateModel()
+	m.SetStatus(&migration.Status{
+		Phase:       migration.PhaseWindowExpired,
+		Collections: []migration.CollectionStatus{{Name: "orders", State: "pending"}},
+		Errors:      []string{"Run `reloquent migrate --resume` in the next window"},
+	})
+
+	v := m.View()
+	if !strings.Contains(v, "window expired") || !strings.Contains(v, "--resume") {
+		t.Errorf("view should explain how to resume, got:\n%s", v)
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	rm := result.(MigrateModel)
+	if !rm.Done() || rm.Cancelled() {
+		t.Error("enter should finish without cancelling")
+	}
+}
+
+type fakePauser struct {
+	pauses, resumes int
+}
+
+func (f *fakePauser) PauseMigration() error {
+	f.pauses++
+	return nil
+}
+
+func (f *fakePauser) ResumeMigration(context.Context, migration.StatusCallback) error {
+	f.resumes++
+	return nil
+}
+
+func TestMigrateModel_PauseResume(t *testing.T) {
+	p := &fakePauser{}
+	m := NewMigrateModel()
+	m.SetPauser(p)
+	key := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}}
+
+	m.SetStatus(&migration.Status{Phase: "running"})
+	if !strings.Contains(m.View(), "p: pause") {
+		t.Error("running view should offer pausing")
+	}
+	result, _ := m.Update(key)
+	m = result.(MigrateModel)
+	if p.pauses != 1 {
+		t.Fatalf("pauses = %d, want 1", p.pauses)
+	}
+
+	m.SetStatus(&migration.Status{Phase: migration.PhasePaused})
+	if !strings.Contains(m.View(), "p: resume") {
+		t.Error("paused view should offer resuming")
+	}
+	result, _ = m.Update(key)
+	m = result.(MigrateModel)
+	if p.resumes != 1 {
+		t.Errorf("resumes = %d, want 1", p.resumes)
+	}
+
+	// a finished migration cannot pause
+	m.SetStatus(&migration.Status{Phase: "completed"})
+	m.Update(key)
+	if p.pauses != 1 {
+		t.Errorf("pauses = %d after completion, want 1", p.pauses)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/target"
 )
 
@@ -14,6 +15,8 @@ type PreMigrationModel struct {
 	topology   *target.TopologyInfo
 	validation *target.ValidationResult
 	collections []string
+	levels      []string // validation level of each collection, when the mapping is known
+	cursor      int
 	setupDone   bool
 	done        bool
 	cancelled   bool
@@ -43,6 +46,18 @@ func (m PreMigrationModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		switch msg.String() {
+		case "j", "down":
+			if m.cursor < len(m.collections)-1 {
+				m.cursor++
+			}
+		case "k", "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "v":
+			if m.levels != nil {
+				m.levels[m.cursor] = nextValidationLevel(m.levels[m.cursor])
+			}
 		case "enter", "f":
 			m.done = true
 			return m, tea.Quit
@@ -109,12 +124,34 @@ func (m PreMigrationModel) View() string {
 		b.WriteString(" Pending")
 	}
 	b.WriteString("\n")
-	for _, name := range m.collections {
-		b.WriteString(fmt.Sprintf("    %s\n", name))
+	if m.levels == nil {
+		for _, name := range m.collections {
+			b.WriteString(fmt.Sprintf("    %s\n", name))
+		}
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("  enter: continue  q: cancel"))
+		return b.String()
+	}
+
+	b.WriteString(dimStyle.Render("  Schema validation rejects writes that break the source's NOT NULL,\n"))
+	b.WriteString(dimStyle.Render("  type and enum rules: moderate for inserts and valid documents, strict for all.\n"))
+	for i, name := range m.collections {
+		cursor := "    "
+		if i == m.cursor {
+			cursor = highlightStyle.Render("  > ")
+		}
+		level := dimStyle.Render(mapping.ValidationOff)
+		switch m.levels[i] {
+		case mapping.ValidationModerate:
+			level = successStyle.Render(mapping.ValidationModerate)
+		case mapping.ValidationStrict:
+			level = warnStyle.Render(mapping.ValidationStrict)
+		}
+		b.WriteString(fmt.Sprintf("%s%-28s validation: %s\n", cursor, name, level))
 	}
 
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("  enter: continue  q: cancel"))
+	b.WriteString(dimStyle.Render("  j/k: move  v: validation level  enter: continue  q: cancel"))
 
 	return b.String()
 }
@@ -139,6 +176,41 @@ func (m *PreMigrationModel) SetValidation(result *target.ValidationResult) {
 	m.validation = result
 }
 
+// SetValidationLevels shows the collections of the mapping with their
+// validation levels and lets the user change them.
+func (m *PreMigrationModel) SetValidationLevels(cols []mapping.Collection) {
+	m.collections = make([]string, len(cols))
+	m.levels = make([]string, len(cols))
+	for i, c := range cols {
+		m.collections[i] = c.Name
+		m.levels[i] = c.ValidationLevel()
+	}
+	m.cursor = 0
+}
+
+// ValidationLevels returns the validation level chosen for each collection,
+// or nil when the mapping was not known.
+func (m PreMigrationModel) ValidationLevels() map[string]string {
+	if m.levels == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m.levels))
+	for i, name := range m.collections {
+		out[name] = m.levels[i]
+	}
+	return out
+}
+
+// nextValidationLevel cycles off, moderate, strict.
+func nextValidationLevel(level string) string {
+	for i, l := range mapping.ValidationLevels {
+		if l == level {
+			return mapping.ValidationLevels[(i+1)%len(mapping.ValidationLevels)]
+		}
+	}
+	return mapping.ValidationOff
+}
+
 // SetSetupDone marks collection creation as complete.
 func (m *PreMigrationModel) SetSetupDone() {
 	m.setupDone = true
@@ -6,6 +6,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/target"
 )
 
@@ -99,3 +100,37 @@ func TestPreMigrationModel_View(t *testing.T) {
 		t.Error("view should list collections")
 	}
 }
+
+func TestPreMigrationModel_ValidationLevels(t *testing.T) {
+	m := NewPreMigrationModel(nil)
+	if m.ValidationLevels() != nil {
+		t.Error("levels should be nil without a mapping")
+	}
+	m.SetValidationLevels([]mapping.Collection{
+		{Name: "users"},
+		{Name: "orders", Validation: mapping.ValidationModerate},
+	})
+
+	keys := []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune{'v'}}, // users: off -> moderate
+		{Type: tea.KeyRunes, Runes: []rune{'j'}},
+		{Type: tea.KeyRunes, Runes: []rune{'v'}}, // orders: moderate -> strict
+	}
+	var model tea.Model = m
+	for _, k := range keys {
+		model, _ = model.Update(k)
+	}
+	rm := model.(PreMigrationModel)
+	levels := rm.ValidationLevels()
+	if levels["users"] != mapping.ValidationModerate || levels["orders"] != mapping.ValidationStrict {
+		t.Errorf("levels = %v", levels)
+	}
+	if v := rm.View(); !strings.Contains(v, "strict") {
+		t.Error("view should show the validation levels")
+	}
+
+	model, _ = rm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	if got := model.(PreMigrationModel).ValidationLevels()["orders"]; got != mapping.ValidationOff {
+		t.Errorf("strict should cycle to off, got %s", got)
+	}
+}
@@ -0,0 +1,535 @@
+package wizard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/reloquent/reloquent/internal/client"
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/discovery"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/typemap"
+	"github.com/reloquent/reloquent/internal/validation"
+)
+
+// pollInterval is how often the remote wizard asks the server for the
+// progress of a migration, validation or index build.
+const pollInterval = 2 * time.Second
+
+// Remote runs the wizard against a reloquent API server. The screens run
+// here while discovery, connection tests, migration, validation and index
+// builds run on the server, which is the host that reaches the databases;
+// the project's state lives on the server too.
+type Remote struct {
+	client *client.Client
+	schema *schema.Schema
+}
+
+// remoteStep is a wizard step as the remote wizard runs it.
+type remoteStep struct {
+	step state.Step
+	run  func(*Remote, context.Context) error
+}
+
+// remoteSteps lists the steps in the order the server allows moving
+// through them.
+var remoteSteps = []remoteStep{
+	{state.StepSourceConnection, (*Remote).runSource},
+	{state.StepTableSelection, (*Remote).runTableSelect},
+	{state.StepDenormalization, (*Remote).runDenorm},
+	{state.StepTypeMapping, (*Remote).runTypeMapping},
+	{state.StepSizing, (*Remote).runSizing},
+	{state.StepReview, (*Remote).runReview},
+	{state.StepTargetConnection, (*Remote).runTarget},
+	{state.StepAWSSetup, (*Remote).runAWSSetup},
+	{state.StepPreMigration, (*Remote).runPreMigration},
+	{state.StepMigration, (*Remote).runMigrate},
+	{state.StepValidation, (*Remote).runValidation},
+	{state.StepIndexBuilds, (*Remote).runIndexBuilds},
+	{state.StepCDC, (*Remote).runCDC},
+}
+
+// NewRemote creates a wizard that works through the server c calls.
+func NewRemote(c *client.Client) *Remote {
+	return &Remote{client: c}
+}
+
+// Run executes the wizard from the project's current step on the server.
+func (r *Remote) Run() error {
+	ctx := context.Background()
+	if err := r.client.Health(ctx); err != nil {
+		return fmt.Errorf("connecting to %s: %w", r.client.URL(), err)
+	}
+	st, err := r.client.State(ctx)
+	if err != nil {
+		return fmt.Errorf("loading wizard state: %w", err)
+	}
+	fmt.Printf("Connected to %s.\n\n", r.client.URL())
+
+	started := false
+	for i, s := range remoteSteps {
+		if !started && string(s.step) != st.CurrentStep {
+			continue
+		}
+		started = true
+		if err := s.run(r, ctx); err != nil {
+			return err
+		}
+		next := state.StepComplete
+		if i+1 < len(remoteSteps) {
+			next = remoteSteps[i+1].step
+		}
+		if err := r.client.SetStep(ctx, next); err != nil {
+			return fmt.Errorf("moving to %s: %w", next, err)
+		}
+	}
+	if !started && st.CurrentStep != string(state.StepComplete) {
+		return fmt.Errorf("unknown step on the server: %s", st.CurrentStep)
+	}
+	return nil
+}
+
+func (r *Remote) runSource(ctx context.Context) error {
+	m := NewSourceModel()
+	m.discover = func(cfg *config.SourceConfig, _ discovery.ProgressFunc) discoveryDoneMsg {
+		s, err := r.client.Discover(ctx, cfg)
+		if err != nil {
+			return discoveryDoneMsg{err: err}
+		}
+		return discoveryDoneMsg{cfg: cfg, schema: s}
+	}
+	finalModel, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("running source wizard: %w", err)
+	}
+	sm := finalModel.(SourceModel)
+	if sm.Cancelled() {
+		return fmt.Errorf("cancelled")
+	}
+	result := sm.Result()
+	if result == nil {
+		return fmt.Errorf("no source result")
+	}
+	r.schema = result.Schema
+	fmt.Printf("\nDiscovered %d tables on the server.\n\n", len(r.schema.Tables))
+	return nil
+}
+
+func (r *Remote) runTarget(ctx context.Context) error {
+	m := NewTargetModel()
+	m.connect = func(cfg *config.TargetConfig) error {
+		return r.client.TestTargetConnection(ctx, cfg)
+	}
+	finalModel, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("running target wizard: %w", err)
+	}
+	tm := finalModel.(TargetModel)
+	if tm.Cancelled() {
+		return fmt.Errorf("cancelled")
+	}
+	result := tm.Result()
+	if result == nil {
+		return fmt.Errorf("no target result")
+	}
+	if err := r.client.SaveTargetConfig(ctx, result.Config); err != nil {
+		return fmt.Errorf("saving target: %w", err)
+	}
+	fmt.Printf("\nThe server connected to MongoDB (%s).\n\n", result.Config.Database)
+	return nil
+}
+
+// loadSchema fetches the discovered schema when resuming past discovery.
+func (r *Remote) loadSchema(ctx context.Context) error {
+	if r.schema != nil {
+		return nil
+	}
+	s, err := r.client.Schema(ctx)
+	if err != nil {
+		return fmt.Errorf("loading schema: %w", err)
+	}
+	r.schema = s
+	return nil
+}
+
+// selectedTables returns the discovered tables selected for migration.
+func (r *Remote) selectedTables(ctx context.Context) ([]schema.Table, error) {
+	if err := r.loadSchema(ctx); err != nil {
+		return nil, err
+	}
+	tables, err := r.client.Tables(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading tables: %w", err)
+	}
+	return filterTables(r.schema.Tables, selectedNames(tables)), nil
+}
+
+func (r *Remote) runTableSelect(ctx context.Context) error {
+	if err := r.loadSchema(ctx); err != nil {
+		return err
+	}
+	tables, err := r.client.Tables(ctx)
+	if err != nil {
+		return fmt.Errorf("loading tables: %w", err)
+	}
+
+	m := NewTableSelectModel(r.schema.Tables, selectedNames(tables))
+	finalModel, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("running table selection: %w", err)
+	}
+	tsm := finalModel.(TableSelectModel)
+	if tsm.Cancelled() {
+		return fmt.Errorf("cancelled")
+	}
+	result := tsm.Result()
+	if result == nil {
+		return fmt.Errorf("no tables selected")
+	}
+
+	names := make([]string, len(result.Selected))
+	for i, t := range result.Selected {
+		names[i] = t.Name
+	}
+	if err := r.client.SelectTables(ctx, names); err != nil {
+		return fmt.Errorf("saving table selection: %w", err)
+	}
+	fmt.Printf("\nSelected %d tables for migration.\n", len(names))
+	return nil
+}
+
+func (r *Remote) runDenorm(ctx context.Context) error {
+	tables, err := r.selectedTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	finalModel, err := tea.NewProgram(NewDenormModel(tables), tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("running denormalization designer: %w", err)
+	}
+	dm := finalModel.(DenormModel)
+	if dm.Cancelled() {
+		return fmt.Errorf("cancelled")
+	}
+	result := dm.BuildMapping()
+	if err := r.client.SaveMapping(ctx, result); err != nil {
+		return fmt.Errorf("saving mapping: %w", err)
+	}
+	fmt.Printf("\nMapping saved with %d collections.\n", len(result.Collections))
+	return nil
+}
+
+func (r *Remote) runTypeMapping(ctx context.Context) error {
+	tables, err := r.selectedTables(ctx)
+	if err != nil {
+		return err
+	}
+	entries, err := r.client.TypeMap(ctx)
+	if err != nil {
+		return fmt.Errorf("loading type mapping: %w", err)
+	}
+
+	existing := typemap.ForDatabase(r.schema.DatabaseType)
+	for _, e := range entries {
+		if e.Overridden {
+			existing.Override(e.SourceType, typemap.BSONType(e.BSONType))
+		}
+	}
+	filtered := &schema.Schema{DatabaseType: r.schema.DatabaseType, Tables: tables}
+
+	m := NewTypeMapModel(filtered, r.schema.DatabaseType, existing)
+	finalModel, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("running type mapping review: %w", err)
+	}
+	tmm := finalModel.(TypeMapModel)
+	if tmm.Cancelled() {
+		return fmt.Errorf("cancelled")
+	}
+	result := tmm.Result()
+	if result == nil {
+		return fmt.Errorf("no type mapping result")
+	}
+
+	if changes := typeMapChanges(entries, result); len(changes) > 0 {
+		if err := r.client.SaveTypeMap(ctx, changes); err != nil {
+			return fmt.Errorf("saving type mapping: %w", err)
+		}
+	}
+	fmt.Printf("\nType mapping saved.\n")
+	return nil
+}
+
+func (r *Remote) runSizing(ctx context.Context) error {
+	plan, err := r.client.Sizing(ctx)
+	if err != nil {
+		return fmt.Errorf("computing sizing: %w", err)
+	}
+	finalModel, err := tea.NewProgram(NewSizingModel(plan), tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("running sizing step: %w", err)
+	}
+	if finalModel.(SizingModel).Cancelled() {
+		return fmt.Errorf("cancelled")
+	}
+	return nil
+}
+
+func (r *Remote) runReview(ctx context.Context) error {
+	plan, err := r.client.Sizing(ctx)
+	if err != nil {
+		return fmt.Errorf("computing sizing: %w", err)
+	}
+	m := NewReviewModel(plan, "")
+	if unique, err := r.client.UniqueConstraints(ctx); err == nil {
+		m.SetUniqueReport(unique.UniqueReport)
+	}
+	if issues, err := r.client.MappingIssues(ctx); err == nil {
+		m.SetMappingIssues(issues)
+	}
+	finalModel, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("running review: %w", err)
+	}
+	rm := finalModel.(ReviewModel)
+	if rm.Cancelled() {
+		return fmt.Errorf("cancelled")
+	}
+	if !rm.Confirmed() {
+		return fmt.Errorf("not confirmed")
+	}
+	return nil
+}
+
+// runAWSSetup only reports: the server's config decides where the
+// migration runs, so there is nothing to set up from here.
+func (r *Remote) runAWSSetup(context.Context) error {
+	fmt.Println("The server's config decides where the migration runs; skipping AWS setup.")
+	return nil
+}
+
+func (r *Remote) runPreMigration(ctx context.Context) error {
+	fmt.Println("Preparing the target collections...")
+	if err := r.client.PrepareTarget(ctx); err != nil {
+		return fmt.Errorf("preparing target: %w", err)
+	}
+	fmt.Printf("Pre-migration setup complete.\n\n")
+	return nil
+}
+
+func (r *Remote) runMigrate(ctx context.Context) error {
+	if err := r.client.StartMigration(ctx); err != nil {
+		return fmt.Errorf("starting migration: %w", err)
+	}
+
+	p := tea.NewProgram(NewMigrateModel(), tea.WithAltScreen())
+	stop := r.poll(ctx, func(ctx context.Context) (bool, error) {
+		st, err := r.client.MigrationStatus(ctx)
+		if err != nil {
+			return false, err
+		}
+		p.Send(MigrationStatusMsg(*st))
+		return migrationFinished(st.Phase), nil
+	})
+	finalModel, err := p.Run()
+	stop()
+	if err != nil {
+		return fmt.Errorf("running migration: %w", err)
+	}
+
+	mm := finalModel.(MigrateModel)
+	if mm.Cancelled() {
+		return fmt.Errorf("cancelled; the migration keeps running on the server")
+	}
+	if mm.status != nil && mm.status.Phase != "completed" {
+		return fmt.Errorf("migration %s: %s", strings.ReplaceAll(mm.status.Phase, "_", " "), strings.Join(mm.status.Errors, "; "))
+	}
+	fmt.Printf("\nMigration complete.\n")
+	return nil
+}
+
+// migrationFinished reports whether a migration in the phase has stopped.
+func migrationFinished(phase string) bool {
+	switch phase {
+	case "completed", "failed", "partial_failure", migration.PhaseWindowExpired:
+		return true
+	}
+	return false
+}
+
+func (r *Remote) runValidation(ctx context.Context) error {
+	previous, err := r.client.ValidationResults(ctx)
+	if err != nil {
+		return fmt.Errorf("loading validation results: %w", err)
+	}
+	if err := r.client.RunValidation(ctx, validation.Config{}); err != nil {
+		return fmt.Errorf("starting validation: %w", err)
+	}
+
+	p := tea.NewProgram(NewValidationModel(), tea.WithAltScreen())
+	stop := r.poll(ctx, func(ctx context.Context) (bool, error) {
+		result, err := r.client.ValidationResults(ctx)
+		if err != nil || result == nil || (previous != nil && result.StartedAt.Equal(previous.StartedAt)) {
+			return false, err
+		}
+		p.Send(ValidationResultMsg(*result))
+		return true, nil
+	})
+	finalModel, err := p.Run()
+	stop()
+	if err != nil {
+		return fmt.Errorf("running validation UI: %w", err)
+	}
+	if finalModel.(ValidationModel).Cancelled() {
+		return fmt.Errorf("cancelled")
+	}
+	return nil
+}
+
+func (r *Remote) runIndexBuilds(ctx context.Context) error {
+	plan, err := r.client.IndexPlan(ctx)
+	if err != nil {
+		return fmt.Errorf("loading index plan: %w", err)
+	}
+	m, err := r.client.Mapping(ctx)
+	if err != nil {
+		return fmt.Errorf("loading mapping: %w", err)
+	}
+
+	finalModel, err := tea.NewProgram(NewIndexPlanModel(plan, m), tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("running index plan editor: %w", err)
+	}
+	if fm := finalModel.(IndexPlanModel); fm.Result() != nil && fm.Changed() {
+		plan = fm.Result()
+		if err := r.client.SaveIndexPlan(ctx, plan); err != nil {
+			return fmt.Errorf("saving index plan: %w", err)
+		}
+		fmt.Printf("Index plan saved on the server (%d indexes)\n", len(plan.Indexes))
+	}
+
+	if err := r.client.BuildIndexes(ctx); err != nil {
+		return fmt.Errorf("starting index builds: %w", err)
+	}
+	p := tea.NewProgram(NewIndexBuildModel(len(plan.Indexes)), tea.WithAltScreen())
+	stop := r.poll(ctx, func(ctx context.Context) (bool, error) {
+		st, err := r.client.IndexStatus(ctx)
+		if err != nil {
+			return false, err
+		}
+		p.Send(IndexProgressMsg(st.Indexes))
+		switch st.Status {
+		case "complete":
+			p.Send(IndexBuildsDoneMsg{})
+			return true, nil
+		case "failed":
+			p.Send(IndexBuildsDoneMsg{Err: fmt.Errorf("index builds failed on the server")})
+			return true, nil
+		}
+		return false, nil
+	})
+	finalModel, err = p.Run()
+	stop()
+	if err != nil {
+		return fmt.Errorf("running index build UI: %w", err)
+	}
+	fm := finalModel.(IndexBuildModel)
+	if fm.Cancelled() {
+		return fmt.Errorf("cancelled")
+	}
+	if fm.err != nil {
+		return fmt.Errorf("index builds: %w", fm.err)
+	}
+	return nil
+}
+
+// runCDC only reports: change data capture streams for as long as the
+// application keeps writing, which a session on a laptop cannot promise.
+func (r *Remote) runCDC(context.Context) error {
+	fmt.Println("Run change data capture on the server, from the web UI or with `reloquent cdc run`.")
+	return nil
+}
+
+// poll calls check every pollInterval until it reports done. A failed
+// check is retried on the next tick, as the server may be restarting. The
+// returned function stops polling and waits for it to finish.
+func (r *Remote) poll(ctx context.Context, check func(context.Context) (bool, error)) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			if finished, _ := check(ctx); finished {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// selectedNames returns the names of the selected tables.
+func selectedNames(tables []client.Table) []string {
+	var names []string
+	for _, t := range tables {
+		if t.Selected {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// filterTables returns the tables named, or all of them when none are.
+func filterTables(tables []schema.Table, names []string) []schema.Table {
+	if len(names) == 0 {
+		return tables
+	}
+	selected := make(map[string]bool, len(names))
+	for _, n := range names {
+		selected[n] = true
+	}
+	var out []schema.Table
+	for _, t := range tables {
+		if selected[t.Name] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// typeMapChanges returns the source types whose BSON type the review
+// changed from what the server has, to send as overrides. Types the server
+// does not list resolve to strings there.
+func typeMapChanges(server []client.TypeMapEntry, tm *typemap.TypeMap) map[string]string {
+	current := make(map[string]string, len(server))
+	for _, e := range server {
+		current[e.SourceType] = e.BSONType
+	}
+	changes := make(map[string]string)
+	for sourceType, bsonType := range tm.AllMappings() {
+		cur, ok := current[sourceType]
+		if !ok {
+			cur = string(typemap.BSONString)
+		}
+		if cur != string(bsonType) {
+			changes[sourceType] = string(bsonType)
+		}
+	}
+	return changes
+}
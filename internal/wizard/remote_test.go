@@ -0,0 +1,83 @@
+package wizard
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/api"
+	"github.com/reloquent/reloquent/internal/client"
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+func TestRemoteSteps_ServerOrder(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	eng := engine.New(&config.Config{Version: 1}, slog.Default())
+	srv := httptest.NewServer(api.New(eng, slog.Default(), 0).Handler())
+	defer srv.Close()
+	c, err := client.New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The server only moves one step ahead at a time, so the remote
+	// wizard must advance in its order.
+	ctx := context.Background()
+	for _, s := range remoteSteps[1:] {
+		if err := c.SetStep(ctx, s.step); err != nil {
+			t.Fatalf("moving to %s: %v", s.step, err)
+		}
+	}
+	if err := c.SetStep(ctx, state.StepComplete); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrationFinished(t *testing.T) {
+	for phase, want := range map[string]bool{
+		"pending": false, "running": false, "completed": true,
+		"failed": true, "partial_failure": true, migration.PhaseWindowExpired: true,
+	} {
+		if got := migrationFinished(phase); got != want {
+			t.Errorf("migrationFinished(%q) = %v, want %v", phase, got, want)
+		}
+	}
+}
+
+func TestFilterTables(t *testing.T) {
+	tables := []schema.Table{{Name: "users"}, {Name: "orders"}, {Name: "audit"}}
+	selected := selectedNames([]client.Table{{Name: "users", Selected: true}, {Name: "orders"}, {Name: "audit", Selected: true}})
+
+	got := filterTables(tables, selected)
+	if len(got) != 2 || got[0].Name != "users" || got[1].Name != "audit" {
+		t.Errorf("filterTables = %v, want users and audit", got)
+	}
+	if got := filterTables(tables, nil); len(got) != 3 {
+		t.Errorf("no selection should keep every table, got %v", got)
+	}
+}
+
+func TestTypeMapChanges(t *testing.T) {
+	server := []client.TypeMapEntry{
+		{SourceType: "integer", BSONType: "NumberLong"},
+		{SourceType: "numeric", BSONType: "Decimal128"},
+	}
+	tm := &typemap.TypeMap{Mappings: map[string]typemap.BSONType{
+		"integer": "NumberInt",  // changed in the review
+		"numeric": "Decimal128", // unchanged
+		"money":   "Decimal128", // only in the schema, a string on the server
+		"point":   "String",
+	}}
+
+	want := map[string]string{"integer": "NumberInt", "money": "Decimal128"}
+	if got := typeMapChanges(server, tm); !reflect.DeepEqual(got, want) {
+		t.Errorf("typeMapChanges = %v, want %v", got, want)
+	}
+}
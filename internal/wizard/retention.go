@@ -0,0 +1,223 @@
+package wizard
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/retention"
+)
+
+// Ages offered for archiving and expiry, in days; zero turns that part off.
+var (
+	archiveDayChoices = []int{0, 30, 90, 180, 365, 730}
+	expireDayChoices  = []int{0, 30, 90, 180, 365, 730, 1825, 3650}
+)
+
+// RetentionModel is the bubbletea model for designing TTL and archival
+// policies on collections with time-based source data (Step 4b).
+type RetentionModel struct {
+	candidates []retention.Candidate
+	histograms map[string]*retention.Histogram // by collection
+	policies   []*mapping.RetentionPolicy
+	cursor     int
+	err        string
+	done       bool
+	skipped    bool
+	width      int
+	height     int
+}
+
+// NewRetentionModel creates a retention designer for the candidates, starting
+// from their current policies. Histograms are optional.
+func NewRetentionModel(candidates []retention.Candidate, histograms map[string]*retention.Histogram) RetentionModel {
+	policies := make([]*mapping.RetentionPolicy, len(candidates))
+	for i, c := range candidates {
+		if c.Policy != nil {
+			p := *c.Policy
+			policies[i] = &p
+		}
+	}
+	return RetentionModel{
+		candidates: candidates,
+		histograms: histograms,
+		policies:   policies,
+		width:      100,
+		height:     24,
+	}
+}
+
+func (m RetentionModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m RetentionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		m.err = ""
+		if len(m.candidates) == 0 {
+			switch msg.String() {
+			case "enter", "f", "q", "esc", "ctrl+c":
+				m.done = true
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			m.done = true
+			m.skipped = true
+			return m, tea.Quit
+
+		case "j", "down":
+			if m.cursor < len(m.candidates)-1 {
+				m.cursor++
+			}
+
+		case "k", "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "a": // cycle archive age
+			p := m.policy()
+			p.ArchiveAfterDays = nextDays(archiveDayChoices, p.ArchiveAfterDays)
+			m.tidy()
+
+		case "t": // cycle TTL expiry age
+			p := m.policy()
+			p.ExpireAfterDays = nextDays(expireDayChoices, p.ExpireAfterDays)
+			m.tidy()
+
+		case "s": // apply the histogram's suggestion
+			if h := m.histograms[m.candidates[m.cursor].Collection]; h != nil {
+				if sg := retention.Suggest(h); sg != nil {
+					m.policies[m.cursor] = sg
+				} else {
+					m.err = "no suggestion: most rows are recent"
+				}
+			}
+
+		case "x": // clear
+			m.policies[m.cursor] = nil
+
+		case "enter", "f":
+			for i, p := range m.policies {
+				if p == nil {
+					continue
+				}
+				if err := p.Validate(); err != nil {
+					m.cursor = i
+					m.err = err.Error()
+					return m, nil
+				}
+			}
+			m.done = true
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+// policy returns the selected candidate's policy, creating an empty one.
+func (m *RetentionModel) policy() *mapping.RetentionPolicy {
+	if m.policies[m.cursor] == nil {
+		m.policies[m.cursor] = &mapping.RetentionPolicy{Column: m.candidates[m.cursor].Column}
+	}
+	return m.policies[m.cursor]
+}
+
+// tidy drops the selected policy once both ages are off.
+func (m *RetentionModel) tidy() {
+	if p := m.policies[m.cursor]; p != nil && p.ArchiveAfterDays == 0 && p.ExpireAfterDays == 0 {
+		m.policies[m.cursor] = nil
+	}
+}
+
+// nextDays returns the choice after current, wrapping to the first.
+func nextDays(choices []int, current int) int {
+	for i, d := range choices {
+		if d == current {
+			return choices[(i+1)%len(choices)]
+		}
+	}
+	return choices[0]
+}
+
+func (m RetentionModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Step 4b: Retention & Archival"))
+	b.WriteString("\n\n")
+
+	if len(m.candidates) == 0 {
+		b.WriteString("  No collections with time-based data.\n\n")
+		b.WriteString(dimStyle.Render("  Press enter to continue\n"))
+		return b.String()
+	}
+
+	b.WriteString(fmt.Sprintf("  %-24s %-28s %s\n", "Collection", "Date column", "Policy"))
+	b.WriteString("  " + strings.Repeat("─", 76) + "\n")
+	for i, c := range m.candidates {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = highlightStyle.Render("> ")
+		}
+		policy := dimStyle.Render("none")
+		if p := m.policies[i]; p != nil {
+			policy = successStyle.Render(retention.Describe(p))
+		}
+		b.WriteString(fmt.Sprintf("%s%-24s %-28s %s\n", cursor, c.Collection, c.Table+"."+c.Column, policy))
+	}
+	b.WriteString("\n")
+
+	c := m.candidates[m.cursor]
+	b.WriteString(dimStyle.Render("  "+c.Reason) + "\n")
+	if h := m.histograms[c.Collection]; h != nil {
+		for _, line := range strings.Split(strings.TrimRight(h.Format(30, m.policies[m.cursor]), "\n"), "\n") {
+			b.WriteString("  " + line + "\n")
+		}
+	} else {
+		b.WriteString(dimStyle.Render("  No age histogram (source not reachable)") + "\n")
+	}
+
+	if m.err != "" {
+		b.WriteString("\n" + errStyle.Render("  "+m.err) + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("  a archive age • t TTL expiry • s suggest • x clear • enter confirm • q skip\n"))
+	return b.String()
+}
+
+// Result returns the policy chosen for each candidate collection, nil where
+// none was set, or nil if the step was skipped.
+func (m RetentionModel) Result() map[string]*mapping.RetentionPolicy {
+	if m.skipped {
+		return nil
+	}
+	out := make(map[string]*mapping.RetentionPolicy, len(m.candidates))
+	for i, c := range m.candidates {
+		out[c.Collection] = m.policies[i]
+	}
+	return out
+}
+
+// Done returns true if the model has finished.
+func (m RetentionModel) Done() bool {
+	return m.done
+}
+
+// Skipped returns true if the user left without changing any policy.
+func (m RetentionModel) Skipped() bool {
+	return m.done && m.skipped
+}
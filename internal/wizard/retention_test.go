@@ -0,0 +1,86 @@
+package wizard
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/retention"
+)
+
+func testRetentionModel() RetentionModel {
+	candidates := []retention.Candidate{
+		{Collection: "auditLog", Table: "audit_log", Column: "logged_at", Reason: "table name suggests audit data"},
+		{Collection: "sessions", Table: "sessions", Column: "started", Reason: "retention policy set",
+			Policy: &mapping.RetentionPolicy{Column: "started", ExpireAfterDays: 30}},
+	}
+	histograms := map[string]*retention.Histogram{
+		"auditLog": retention.NewHistogram("audit_log", "logged_at", map[int64]int64{5: 50, 400: 50}),
+	}
+	return NewRetentionModel(candidates, histograms)
+}
+
+func pressRetention(m RetentionModel, keys ...string) RetentionModel {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		if k == "enter" {
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		} else {
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		result, _ := m.Update(msg)
+		m = result.(RetentionModel)
+	}
+	return m
+}
+
+func TestRetentionModel_CycleAges(t *testing.T) {
+	m := pressRetention(testRetentionModel(), "a", "a", "t", "t", "t", "t", "t")
+	p := m.policies[0]
+	if p == nil || p.Column != "logged_at" || p.ArchiveAfterDays != 90 || p.ExpireAfterDays != 730 {
+		t.Fatalf("policy = %+v, want archive 90, expire 730 by logged_at", p)
+	}
+	if !strings.Contains(m.View(), "archive after 90 days, expire after 730 days") {
+		t.Error("view should describe the policy")
+	}
+
+	// Cycling both ages back to zero removes the policy
+	m = pressRetention(m, "a", "a", "a", "a", "t", "t", "t")
+	if m.policies[0] != nil {
+		t.Errorf("policy = %+v, want nil once both ages are off", m.policies[0])
+	}
+}
+
+func TestRetentionModel_SuggestAndConfirm(t *testing.T) {
+	m := pressRetention(testRetentionModel(), "s", "j", "x", "enter")
+	if !m.Done() || m.Skipped() {
+		t.Fatal("enter should finish the step")
+	}
+	got := m.Result()
+	if p := got["auditLog"]; p == nil || p.ArchiveAfterDays != 365 {
+		t.Errorf("auditLog policy = %+v, want the suggested archive after 365 days", p)
+	}
+	if p, ok := got["sessions"]; !ok || p != nil {
+		t.Errorf("sessions policy = %+v, want cleared", p)
+	}
+}
+
+func TestRetentionModel_InvalidPolicyBlocksConfirm(t *testing.T) {
+	// archive after 365 days but expire after 30
+	m := pressRetention(testRetentionModel(), "j", "a", "a", "a", "a", "enter")
+	if m.Done() {
+		t.Fatal("an invalid policy should keep the step open")
+	}
+	if m.cursor != 1 || m.err == "" {
+		t.Errorf("cursor, err = %d, %q, want the invalid policy selected with an error", m.cursor, m.err)
+	}
+}
+
+func TestRetentionModel_Skip(t *testing.T) {
+	m := pressRetention(testRetentionModel(), "a", "q")
+	if !m.Skipped() || m.Result() != nil {
+		t.Error("q should skip the step without a result")
+	}
+}
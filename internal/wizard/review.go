@@ -6,6 +6,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/sizing"
 )
 
@@ -13,6 +15,8 @@ import (
 type ReviewModel struct {
 	plan       *sizing.SizingPlan
 	script     string
+	unique     *indexes.UniqueReport
+	issues     mapping.Issues
 	showScript bool
 	confirmed  bool
 	done       bool
@@ -45,6 +49,9 @@ func (m ReviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
+			if len(m.issues.Errors()) > 0 {
+				return m, nil
+			}
 			m.done = true
 			m.confirmed = true
 			return m, tea.Quit
@@ -84,12 +91,53 @@ func (m ReviewModel) View() string {
 		b.WriteString(fmt.Sprintf("  Target:    %s → %s\n", mp.MigrationTier, mp.ProductionTier))
 		b.WriteString(fmt.Sprintf("  Storage:   %d GB\n", mp.StorageGB))
 		b.WriteString(fmt.Sprintf("  Duration:  %s\n", sizing.FormatDuration(m.plan.EstimatedTime)))
+		if est := m.plan.Estimate; est != nil {
+			b.WriteString(fmt.Sprintf("  Run:       %s wall clock, $%.2f (compute $%.2f, S3 $%.2f, data transfer $%.2f)\n",
+				sizing.FormatDuration(est.Duration), est.TotalCost, est.ComputeCost, est.S3Cost, est.TransferCost))
+		}
 
 		if m.plan.ShardPlan != nil && m.plan.ShardPlan.Recommended {
 			b.WriteString(fmt.Sprintf("  Sharding:  %d shards\n", m.plan.ShardPlan.ShardCount))
 		}
 	}
 
+	if m.unique != nil && len(m.unique.Constraints) > 0 {
+		b.WriteString("\n")
+		b.WriteString(highlightStyle.Render("  Unique Constraints"))
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf("  %d preserved, %d need a partial unique index, %d lost\n",
+			m.unique.Preserved, m.unique.Convertible, m.unique.Lost))
+		for _, c := range m.unique.Constraints {
+			if c.Status == indexes.UniquePreserved {
+				continue
+			}
+			line := fmt.Sprintf("  %-11s %s.%s: %s", c.Status, c.Collection, c.Name, c.Reason)
+			if c.Status == indexes.UniqueLost {
+				b.WriteString(warnStyle.Render(line))
+			} else {
+				b.WriteString(dimStyle.Render(line))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(m.issues) > 0 {
+		errs := m.issues.Errors()
+		b.WriteString("\n")
+		b.WriteString(highlightStyle.Render("  Mapping Checks"))
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf("  %d errors, %d warnings\n", len(errs), len(m.issues)-len(errs)))
+		for _, is := range m.issues {
+			line := fmt.Sprintf("  %-7s %s", is.Severity, is)
+			if is.Severity == mapping.SeverityError {
+				b.WriteString(errStyle.Render(line))
+			} else {
+				b.WriteString(warnStyle.Render(line))
+			}
+			b.WriteString("\n")
+		}
+	}
+
 	// Script toggle
 	b.WriteString("\n")
 	if m.showScript {
@@ -113,6 +161,14 @@ func (m ReviewModel) View() string {
 		b.WriteString("\n")
 	}
 
+	if len(m.issues.Errors()) > 0 {
+		b.WriteString("\n")
+		b.WriteString(errStyle.Render("  Fix the mapping errors above before starting the migration."))
+		b.WriteString("\n\n")
+		b.WriteString(dimStyle.Render("  v: toggle script  q: go back"))
+		return b.String()
+	}
+
 	// Point-of-no-return warning
 	b.WriteString("\n")
 	b.WriteString(errStyle.Render("  WARNING: Pressing enter will start the migration."))
@@ -125,6 +181,17 @@ func (m ReviewModel) View() string {
 	return b.String()
 }
 
+// SetUniqueReport shows how the source unique constraints carry over.
+func (m *ReviewModel) SetUniqueReport(r *indexes.UniqueReport) {
+	m.unique = r
+}
+
+// SetMappingIssues shows the problems the mapping lint found. While any is
+// an error, the migration cannot be started.
+func (m *ReviewModel) SetMappingIssues(issues mapping.Issues) {
+	m.issues = issues
+}
+
 // Done returns true when the model is finished.
 func (m ReviewModel) Done() bool {
 	return m.done
@@ -7,6 +7,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/reloquent/reloquent/internal/indexes"
+	"github.com/reloquent/reloquent/internal/mapping"
 	"github.com/reloquent/reloquent/internal/sizing"
 )
 
@@ -41,6 +43,31 @@ func TestReviewModel_Confirm(t *testing.T) {
 	}
 }
 
+func TestReviewModel_MappingErrorsBlockStart(t *testing.T) {
+	m := NewReviewModel(nil, "")
+	m.SetMappingIssues(mapping.Issues{
+		{Severity: mapping.SeverityError, Code: mapping.IssueMissingJoin, Collection: "orders", Path: "lines", Message: "order_items has no join columns to orders"},
+		{Severity: mapping.SeverityWarning, Code: mapping.IssueTooDeep, Collection: "orders", Path: "a.b.c.d", Message: "too deep"},
+	})
+	view := m.View()
+	if !strings.Contains(view, "1 errors, 1 warnings") || !strings.Contains(view, "orders.lines: order_items has no join columns") {
+		t.Errorf("view should list the mapping issues:\n%s", view)
+	}
+	if strings.Contains(view, "START MIGRATION") {
+		t.Error("view should not offer to start with mapping errors")
+	}
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if rm := result.(ReviewModel); rm.Done() || rm.Confirmed() {
+		t.Error("enter should not start the migration with mapping errors")
+	}
+
+	m.SetMappingIssues(m.issues.Warnings())
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !result.(ReviewModel).Confirmed() {
+		t.Error("warnings should not block the migration")
+	}
+}
+
 func TestReviewModel_Cancel(t *testing.T) {
 	m := NewReviewModel(nil, "")
 	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
@@ -80,6 +107,8 @@ func TestReviewModel_View_Summary(t *testing.T) {
 		SparkPlan:     sizing.SparkPlan{Platform: "emr", InstanceType: "r5.4xlarge", WorkerCount: 10, CostEstimate: "$100-200"},
 		MongoPlan:     sizing.MongoPlan{MigrationTier: "M60", ProductionTier: "M40", StorageGB: 100},
 		EstimatedTime: time.Hour,
+		Estimate: &sizing.Estimate{Units: 10, Duration: 70 * time.Minute,
+			ComputeCost: 14.7, S3Cost: 0.02, TransferCost: 2.05, TotalCost: 16.77},
 	}
 	m := NewReviewModel(plan, "print('test')")
 	m.width = 100
@@ -95,6 +124,9 @@ func TestReviewModel_View_Summary(t *testing.T) {
 	if !strings.Contains(v, "$100-200") {
 		t.Error("view should show cost")
 	}
+	if !strings.Contains(v, "$16.77 (compute $14.70, S3 $0.02, data transfer $2.05)") {
+		t.Error("view should show the run cost breakdown")
+	}
 	if !strings.Contains(v, "WARNING") {
 		t.Error("view should show warning")
 	}
@@ -111,3 +143,25 @@ func TestReviewModel_View_ScriptVisible(t *testing.T) {
 		t.Error("view should show script when toggled")
 	}
 }
+
+func TestReviewModel_UniqueReport(t *testing.T) {
+	m := NewReviewModel(nil, "")
+	m.SetUniqueReport(&indexes.UniqueReport{
+		Constraints: []indexes.UniqueConstraint{
+			{Collection: "users", Name: "users_pkey", Status: indexes.UniquePreserved},
+			{Collection: "users", Name: "ux_phone", Status: indexes.UniqueConvertible, Reason: "rows with NULL in phone never collide"},
+			{Collection: "users", Name: "ux_label", Status: indexes.UniqueLost, Reason: "rows become elements of the addresses array"},
+		},
+		Preserved: 1, Convertible: 1, Lost: 1,
+	})
+
+	view := m.View()
+	for _, want := range []string{"Unique Constraints", "1 preserved, 1 need a partial unique index, 1 lost", "users.ux_phone", "users.ux_label"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q", want)
+		}
+	}
+	if strings.Contains(view, "users.users_pkey") {
+		t.Error("preserved constraints should only be counted")
+	}
+}
@@ -0,0 +1,322 @@
+package wizard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/sizing"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// SessionVersion is the format version of recorded sessions.
+const SessionVersion = 1
+
+// Session records the decisions made in the design steps of a wizard run,
+// source connection through AWS setup, so they can be replayed without the
+// screens. A session recorded against staging can be edited (hosts,
+// databases, credential references) and replayed unattended against
+// production, where the source is discovered afresh and the recorded
+// design applied to it.
+type Session struct {
+	Version    int          `yaml:"version"`
+	RecordedAt time.Time    `yaml:"recorded_at"`
+	Steps      []state.Step `yaml:"steps"` // steps completed, in the order they were
+
+	Source    *config.SourceConfig       `yaml:"source,omitempty"` // passwords as credential references
+	Target    *config.TargetConfig       `yaml:"target,omitempty"`
+	Tables    []string                   `yaml:"tables,omitempty"`
+	Mapping   *mapping.Mapping           `yaml:"mapping,omitempty"` // with retention and ID generation choices
+	TypeMap   *typemap.TypeMap           `yaml:"type_map,omitempty"`
+	ShardKeys map[string]SessionShardKey `yaml:"shard_keys,omitempty"` // by collection
+	AWS       *SessionAWS                `yaml:"aws,omitempty"`
+}
+
+// SessionShardKey is a shard key chosen in the shard key advisor.
+type SessionShardKey struct {
+	Field    string `yaml:"field"`
+	Strategy string `yaml:"strategy"` // hashed or ranged
+	Score    int    `yaml:"score,omitempty"`
+	Reason   string `yaml:"reason,omitempty"`
+}
+
+// SessionAWS is what was entered in AWS setup.
+type SessionAWS struct {
+	Region   string `yaml:"region,omitempty"`
+	Profile  string `yaml:"profile,omitempty"`
+	S3Bucket string `yaml:"s3_bucket,omitempty"`
+	Platform string `yaml:"platform,omitempty"`
+}
+
+// SessionPath returns where the wizard records the session of the project
+// whose state is at statePath.
+func SessionPath(statePath string) string {
+	return filepath.Join(filepath.Dir(config.ExpandHome(statePath)), "session.yaml")
+}
+
+// LoadSession reads a recorded session.
+func LoadSession(path string) (*Session, error) {
+	data, err := os.ReadFile(config.ExpandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading session: %w", err)
+	}
+	var s Session
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing session: %w", err)
+	}
+	if s.Version > SessionVersion {
+		return nil, fmt.Errorf("session format %d is newer than this reloquent understands (%d)", s.Version, SessionVersion)
+	}
+	return &s, nil
+}
+
+// WriteYAML saves the session.
+func (s *Session) WriteYAML(path string) error {
+	s.Version = SessionVersion
+	s.RecordedAt = time.Now()
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshaling session: %w", err)
+	}
+	path = config.ExpandHome(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating session directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Has reports whether the session recorded the step.
+func (s *Session) Has(step state.Step) bool {
+	return slices.Contains(s.Steps, step)
+}
+
+// loadOrNewSession reads the session at path, or starts an empty one if
+// none was recorded yet.
+func loadOrNewSession(path string) (*Session, error) {
+	s, err := LoadSession(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Session{Version: SessionVersion}, nil
+	}
+	return s, err
+}
+
+// recordSession records a completed step in the session next to the state
+// at statePath.
+func recordSession(statePath string, step state.Step, update func(*Session)) error {
+	path := SessionPath(statePath)
+	s, err := loadOrNewSession(path)
+	if err != nil {
+		return err
+	}
+	s.record(step, update)
+	return s.WriteYAML(path)
+}
+
+func (s *Session) record(step state.Step, update func(*Session)) {
+	update(s)
+	s.Steps = slices.DeleteFunc(s.Steps, func(st state.Step) bool { return st == step })
+	s.Steps = append(s.Steps, step)
+}
+
+// record applies a completed step's decisions to the wizard's session and
+// saves it.
+func (w *Wizard) record(step state.Step, update func(*Session)) error {
+	w.session.record(step, update)
+	if err := w.session.WriteYAML(SessionPath(w.statePath)); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+	return nil
+}
+
+func recordShardKeys(keys map[string]*sizing.ShardKeyCandidate) map[string]SessionShardKey {
+	if len(keys) == 0 {
+		return nil
+	}
+	out := make(map[string]SessionShardKey, len(keys))
+	for name, c := range keys {
+		out[name] = SessionShardKey{Field: c.Field, Strategy: c.Strategy, Score: c.Score, Reason: c.Reason}
+	}
+	return out
+}
+
+// replayStep is a design step as a replay runs it.
+type replayStep struct {
+	step state.Step
+	run  func(*Wizard, *Session) error
+}
+
+// replaySteps lists the steps a session can replay, in wizard order.
+var replaySteps = []replayStep{
+	{state.StepSourceConnection, (*Wizard).replaySource},
+	{state.StepTargetConnection, (*Wizard).replayTarget},
+	{state.StepTableSelection, (*Wizard).replayTableSelect},
+	{state.StepDenormalization, (*Wizard).replayDenorm},
+	{state.StepTypeMapping, (*Wizard).replayTypeMapping},
+	{state.StepSizing, (*Wizard).replaySizing},
+	{state.StepAWSSetup, (*Wizard).replayAWSSetup},
+}
+
+// Replay makes the decisions recorded in a session without the screens,
+// from the current step up to the first step the session did not record or
+// pre-migration, whichever comes first. The rest of the run is left to the
+// interactive wizard. Replay fails rather than guess when the source no
+// longer matches the recorded design.
+func (w *Wizard) Replay(s *Session) error {
+	replayed := 0
+	for _, rs := range replaySteps {
+		if w.state.CurrentStep != rs.step {
+			continue
+		}
+		if !s.Has(rs.step) {
+			break
+		}
+		fmt.Printf("Replaying %s...\n", strings.ReplaceAll(string(rs.step), "_", " "))
+		if err := rs.run(w, s); err != nil {
+			return fmt.Errorf("replaying %s: %w", rs.step, err)
+		}
+		replayed++
+	}
+
+	fmt.Printf("\nReplayed %d steps; the wizard is at %s.\n", replayed, strings.ReplaceAll(string(w.state.CurrentStep), "_", " "))
+	fmt.Println("Run `reloquent` to continue interactively.")
+	return nil
+}
+
+func (w *Wizard) replaySource(s *Session) error {
+	if s.Source == nil {
+		return fmt.Errorf("the session has no source connection")
+	}
+	fmt.Printf("Discovering %s on %s...\n", s.Source.Database, s.Source.Host)
+	done := discover(s.Source, nil)
+	if done.err != nil {
+		return done.err
+	}
+	return w.completeSource(&SourceResult{Config: done.cfg, Schema: done.schema})
+}
+
+func (w *Wizard) replayTarget(s *Session) error {
+	if s.Target == nil {
+		return fmt.Errorf("the session has no target connection")
+	}
+	if err := pingTarget(s.Target); err != nil {
+		return fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	return w.completeTarget(s.Target)
+}
+
+func (w *Wizard) replayTableSelect(s *Session) error {
+	if err := w.loadSchema(); err != nil {
+		return err
+	}
+	if missing := missingTables(w.schema, s.Tables); len(missing) > 0 {
+		return fmt.Errorf("the source has no table %s", strings.Join(missing, ", "))
+	}
+	return w.completeTableSelect(s.Tables)
+}
+
+func (w *Wizard) replayDenorm(s *Session) error {
+	if s.Mapping == nil {
+		return fmt.Errorf("the session has no mapping")
+	}
+	if err := w.loadSchema(); err != nil {
+		return err
+	}
+	if stale := s.Mapping.StaleReferences(w.schema); len(stale) > 0 {
+		refs := make([]string, len(stale))
+		for i, r := range stale {
+			refs[i] = r.String()
+		}
+		return fmt.Errorf("the mapping refers to what the source no longer has: %s", strings.Join(refs, "; "))
+	}
+	if err := s.Mapping.ValidateIDGeneration(w.schema); err != nil {
+		return err
+	}
+	if err := w.saveMapping(s.Mapping); err != nil {
+		return err
+	}
+	return w.record(state.StepDenormalization, func(rec *Session) {
+		rec.Mapping = s.Mapping
+	})
+}
+
+func (w *Wizard) replayTypeMapping(s *Session) error {
+	if s.TypeMap == nil {
+		return fmt.Errorf("the session has no type mapping")
+	}
+	return w.completeTypeMapping(s.TypeMap)
+}
+
+func (w *Wizard) replaySizing(s *Session) error {
+	plan, err := w.calculateSizing()
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]*sizing.ShardKeyCandidate, len(s.ShardKeys))
+	if plan.ShardPlan != nil {
+		for name, k := range s.ShardKeys {
+			c := &sizing.ShardKeyCandidate{
+				ShardKeyStats: sizing.ShardKeyStats{Field: k.Field},
+				Strategy:      k.Strategy,
+				Score:         k.Score,
+				Reason:        k.Reason,
+			}
+			plan.ShardPlan.ApplyShardKey(name, c)
+			keys[name] = c
+		}
+	}
+	return w.completeSizing(plan, keys)
+}
+
+func (w *Wizard) replayAWSSetup(s *Session) error {
+	if s.AWS == nil {
+		return fmt.Errorf("the session has no AWS setup")
+	}
+	return w.completeAWSSetup(&AWSSetupResult{
+		Region:   s.AWS.Region,
+		Profile:  s.AWS.Profile,
+		S3Bucket: s.AWS.S3Bucket,
+		Platform: s.AWS.Platform,
+	})
+}
+
+// loadSchema loads the discovered schema when resuming without it in
+// memory.
+func (w *Wizard) loadSchema() error {
+	if w.schema != nil {
+		return nil
+	}
+	if w.state.SchemaPath == "" {
+		return fmt.Errorf("no schema available; run source discovery first")
+	}
+	s, err := schema.LoadYAML(w.state.SchemaPath)
+	if err != nil {
+		return fmt.Errorf("loading schema: %w", err)
+	}
+	w.schema = s
+	return nil
+}
+
+// missingTables returns the names the schema has no table for.
+func missingTables(s *schema.Schema, names []string) []string {
+	have := make(map[string]bool, len(s.Tables))
+	for _, t := range s.Tables {
+		have[t.Name] = true
+	}
+	var missing []string
+	for _, n := range names {
+		if !have[n] {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,170 @@
+package wizard
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/state"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// discoveredProject creates a project whose source has been discovered and
+// whose target is connected, ready for table selection.
+func discoveredProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	s := &schema.Schema{
+		DatabaseType: "postgresql",
+		Tables: []schema.Table{
+			{Name: "customers", RowCount: 10, SizeBytes: 1000, Columns: []schema.Column{{Name: "id", DataType: "integer"}}},
+			{Name: "orders", RowCount: 100, SizeBytes: 8000, Columns: []schema.Column{{Name: "id", DataType: "integer"}}},
+		},
+	}
+	schemaPath := filepath.Join(dir, "schema.yaml")
+	if err := s.WriteYAML(schemaPath); err != nil {
+		t.Fatal(err)
+	}
+	st := state.New()
+	st.SchemaPath = schemaPath
+	st.CompleteStep(state.StepSourceConnection, state.StepTargetConnection)
+	st.CompleteStep(state.StepTargetConnection, state.StepTableSelection)
+	statePath := filepath.Join(dir, "state.yaml")
+	if err := st.Save(statePath); err != nil {
+		t.Fatal(err)
+	}
+	return statePath
+}
+
+func designSession() *Session {
+	return &Session{
+		Steps: []state.Step{
+			state.StepSourceConnection, state.StepTargetConnection, state.StepTableSelection,
+			state.StepDenormalization, state.StepTypeMapping, state.StepSizing, state.StepAWSSetup,
+		},
+		Tables: []string{"customers", "orders"},
+		Mapping: &mapping.Mapping{Collections: []mapping.Collection{
+			{Name: "customers", SourceTable: "customers"},
+			{Name: "orders", SourceTable: "orders"},
+		}},
+		TypeMap: &typemap.TypeMap{Mappings: map[string]typemap.BSONType{"integer": "int"}},
+		AWS:     &SessionAWS{Region: "us-east-1", Platform: "scripts-only"},
+	}
+}
+
+func TestSession_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.yaml")
+	want := designSession()
+	want.ShardKeys = map[string]SessionShardKey{"orders": {Field: "id", Strategy: "hashed", Score: 80}}
+	if err := want.WriteYAML(path); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != SessionVersion || len(got.Steps) != 7 || len(got.Mapping.Collections) != 2 {
+		t.Errorf("loaded %+v", got)
+	}
+	if got.ShardKeys["orders"].Strategy != "hashed" || got.AWS.Region != "us-east-1" {
+		t.Errorf("shard keys = %+v, aws = %+v", got.ShardKeys, got.AWS)
+	}
+}
+
+func TestSession_Record(t *testing.T) {
+	s := &Session{}
+	s.record(state.StepTableSelection, func(s *Session) { s.Tables = []string{"a"} })
+	s.record(state.StepDenormalization, func(*Session) {})
+	s.record(state.StepTableSelection, func(s *Session) { s.Tables = []string{"a", "b"} })
+
+	if len(s.Steps) != 2 || s.Steps[1] != state.StepTableSelection {
+		t.Errorf("steps = %v, want each step once, the latest last", s.Steps)
+	}
+	if len(s.Tables) != 2 || !s.Has(state.StepDenormalization) || s.Has(state.StepSizing) {
+		t.Errorf("session = %+v", s)
+	}
+}
+
+func TestWizard_Replay(t *testing.T) {
+	statePath := discoveredProject(t)
+	w, err := New(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Replay(designSession()); err != nil {
+		t.Fatal(err)
+	}
+	if w.state.CurrentStep != state.StepPreMigration {
+		t.Errorf("current step = %s, want pre_migration", w.state.CurrentStep)
+	}
+	if len(w.state.SelectedTables) != 2 || w.state.MappingPath == "" || w.state.TypeMappingPath == "" || w.state.SizingPlanPath == "" {
+		t.Errorf("state after replay = %+v", w.state)
+	}
+
+	// The replay is recorded in the project it ran in
+	rec, err := LoadSession(SessionPath(statePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range []state.Step{state.StepTableSelection, state.StepDenormalization, state.StepAWSSetup} {
+		if !rec.Has(step) {
+			t.Errorf("recorded session is missing %s: %v", step, rec.Steps)
+		}
+	}
+}
+
+func TestWizard_ReplayStopsAtUnrecordedStep(t *testing.T) {
+	statePath := discoveredProject(t)
+	w, err := New(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess := designSession()
+	sess.Steps = sess.Steps[:4] // through denormalization
+	if err := w.Replay(sess); err != nil {
+		t.Fatal(err)
+	}
+	if w.state.CurrentStep != state.StepTypeMapping {
+		t.Errorf("current step = %s, want type_mapping", w.state.CurrentStep)
+	}
+}
+
+func TestWizard_ReplayMissingTable(t *testing.T) {
+	statePath := discoveredProject(t)
+	w, err := New(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess := designSession()
+	sess.Tables = append(sess.Tables, "invoices")
+	err = w.Replay(sess)
+	if err == nil || !strings.Contains(err.Error(), "invoices") {
+		t.Fatalf("err = %v, want the missing table named", err)
+	}
+	if w.state.CurrentStep != state.StepTableSelection {
+		t.Errorf("current step = %s, want table_selection unchanged", w.state.CurrentStep)
+	}
+}
+
+func TestWizard_ReplayStaleMapping(t *testing.T) {
+	statePath := discoveredProject(t)
+	w, err := New(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess := designSession()
+	sess.Mapping.Collections = append(sess.Mapping.Collections, mapping.Collection{Name: "invoices", SourceTable: "invoices"})
+	if err := w.Replay(sess); err == nil {
+		t.Fatal("replaying a mapping of a table the source lacks should fail")
+	}
+	if w.state.CurrentStep != state.StepDenormalization {
+		t.Errorf("current step = %s, want denormalization", w.state.CurrentStep)
+	}
+}
@@ -0,0 +1,186 @@
+package wizard
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/reloquent/reloquent/internal/sizing"
+)
+
+// ShardAdvisorModel is the bubbletea model for choosing shard keys from
+// sampled data distribution (Step 6b).
+type ShardAdvisorModel struct {
+	advice  []sizing.ShardAdvice
+	choices []int // candidate index per collection
+	cursor  int
+	done    bool
+	skipped bool
+	width   int
+	height  int
+}
+
+// NewShardAdvisorModel creates a shard key advisor starting from each
+// collection's best scoring candidate.
+func NewShardAdvisorModel(advice []sizing.ShardAdvice) ShardAdvisorModel {
+	return ShardAdvisorModel{
+		advice:  advice,
+		choices: make([]int, len(advice)),
+		width:   100,
+		height:  24,
+	}
+}
+
+func (m ShardAdvisorModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ShardAdvisorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			m.done = true
+			m.skipped = true
+			return m, tea.Quit
+
+		case "j", "down":
+			if m.cursor < len(m.advice)-1 {
+				m.cursor++
+			}
+
+		case "k", "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "l", "right", "tab": // next candidate
+			if n := len(m.candidates()); n > 0 {
+				m.choices[m.cursor] = (m.choices[m.cursor] + 1) % n
+			}
+
+		case "h", "left", "shift+tab": // previous candidate
+			if n := len(m.candidates()); n > 0 {
+				m.choices[m.cursor] = (m.choices[m.cursor] + n - 1) % n
+			}
+
+		case "r": // reset to the best candidate
+			if len(m.advice) > 0 {
+				m.choices[m.cursor] = 0
+			}
+
+		case "enter", "f":
+			m.done = true
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+// candidates returns the selected collection's candidates.
+func (m ShardAdvisorModel) candidates() []sizing.ShardKeyCandidate {
+	if len(m.advice) == 0 {
+		return nil
+	}
+	return m.advice[m.cursor].Candidates
+}
+
+func (m ShardAdvisorModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Step 6b: Shard Key Advisor"))
+	b.WriteString("\n\n")
+
+	if len(m.advice) == 0 {
+		b.WriteString("  No collections to advise on.\n\n")
+		b.WriteString(dimStyle.Render("  Press enter to continue\n"))
+		return b.String()
+	}
+
+	b.WriteString(fmt.Sprintf("  %-24s %12s  %-30s %s\n", "Collection", "Rows", "Shard key", "Score"))
+	b.WriteString("  " + strings.Repeat("─", 76) + "\n")
+	for i, a := range m.advice {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = highlightStyle.Render("> ")
+		}
+		key, score := "no candidates", ""
+		if a.Error != "" {
+			key = "sampling failed"
+		} else if len(a.Candidates) > 0 {
+			c := a.Candidates[m.choices[i]]
+			key = fmt.Sprintf("%s (%s)", c.Field, c.Strategy)
+			score = scoreStyle(c.Score).Render(fmt.Sprintf("%d", c.Score))
+		}
+		b.WriteString(fmt.Sprintf("%s%-24s %12d  %-30s %s\n", cursor, a.CollectionName, a.RowCount, key, score))
+	}
+	b.WriteString("\n")
+
+	a := m.advice[m.cursor]
+	switch {
+	case a.Error != "":
+		b.WriteString(errStyle.Render("  "+a.Error) + "\n")
+	case len(a.Candidates) == 0:
+		b.WriteString(dimStyle.Render("  No shardable columns; the plan keeps its default key.") + "\n")
+	default:
+		for i, c := range a.Candidates {
+			mark := "  "
+			if i == m.choices[m.cursor] {
+				mark = highlightStyle.Render("* ")
+			}
+			b.WriteString(fmt.Sprintf("  %s%-24s %-7s %3d  %s\n", mark, c.Field, c.Strategy, c.Score,
+				dimStyle.Render(fmt.Sprintf("%d distinct, top value %.1f%%", c.Distinct, c.TopShare*100))))
+		}
+		c := a.Candidates[m.choices[m.cursor]]
+		b.WriteString("\n" + dimStyle.Render("  "+c.Reason) + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("  ←/→ choose key • r best • enter confirm • q keep plan\n"))
+	return b.String()
+}
+
+// scoreStyle colours a candidate's score by how good it is.
+func scoreStyle(score int) lipgloss.Style {
+	switch {
+	case score >= 70:
+		return successStyle
+	case score >= 40:
+		return warnStyle
+	}
+	return errStyle
+}
+
+// Result returns the chosen candidate per collection, leaving out
+// collections without candidates, or nil if the step was skipped.
+func (m ShardAdvisorModel) Result() map[string]*sizing.ShardKeyCandidate {
+	if m.skipped {
+		return nil
+	}
+	out := make(map[string]*sizing.ShardKeyCandidate)
+	for i, a := range m.advice {
+		if len(a.Candidates) > 0 {
+			c := a.Candidates[m.choices[i]]
+			out[a.CollectionName] = &c
+		}
+	}
+	return out
+}
+
+// Done returns true if the model has finished.
+func (m ShardAdvisorModel) Done() bool {
+	return m.done
+}
+
+// Skipped returns true if the user kept the computed plan's keys.
+func (m ShardAdvisorModel) Skipped() bool {
+	return m.done && m.skipped
+}
@@ -0,0 +1,74 @@
+package wizard
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/reloquent/reloquent/internal/sizing"
+)
+
+func testShardAdvice() []sizing.ShardAdvice {
+	return []sizing.ShardAdvice{
+		{
+			CollectionName: "users",
+			SourceTable:    "users",
+			RowCount:       100000,
+			Candidates: []sizing.ShardKeyCandidate{
+				{ShardKeyStats: sizing.ShardKeyStats{Column: "id", Field: "_id", Distinct: 100000}, Strategy: sizing.ShardHashed, Score: 90, Reason: "unique values"},
+				{ShardKeyStats: sizing.ShardKeyStats{Column: "id", Field: "_id", Distinct: 100000}, Strategy: sizing.ShardRanged, Score: 80, Reason: "grows with inserts"},
+			},
+		},
+		{CollectionName: "events", SourceTable: "events", Error: "counting rows of events: permission denied"},
+	}
+}
+
+func pressShardAdvisor(m ShardAdvisorModel, keys ...string) ShardAdvisorModel {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		if k == "enter" {
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		} else {
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		result, _ := m.Update(msg)
+		m = result.(ShardAdvisorModel)
+	}
+	return m
+}
+
+func TestShardAdvisorModel_Choose(t *testing.T) {
+	m := pressShardAdvisor(NewShardAdvisorModel(testShardAdvice()), "l")
+	if !strings.Contains(m.View(), "grows with inserts") {
+		t.Error("view should explain the selected candidate")
+	}
+	m = pressShardAdvisor(m, "j", "l", "enter")
+	if !m.Done() || m.Skipped() {
+		t.Fatal("enter should finish the step")
+	}
+	got := m.Result()
+	if c := got["users"]; c == nil || c.Strategy != sizing.ShardRanged {
+		t.Errorf("users = %+v, want the ranged candidate", c)
+	}
+	if _, ok := got["events"]; ok {
+		t.Error("a collection that failed sampling should have no choice")
+	}
+}
+
+func TestShardAdvisorModel_Reset(t *testing.T) {
+	m := pressShardAdvisor(NewShardAdvisorModel(testShardAdvice()), "l", "r", "enter")
+	if c := m.Result()["users"]; c == nil || c.Strategy != sizing.ShardHashed {
+		t.Errorf("users = %+v, want the best candidate after reset", c)
+	}
+}
+
+func TestShardAdvisorModel_Skip(t *testing.T) {
+	m := pressShardAdvisor(NewShardAdvisorModel(testShardAdvice()), "l", "q")
+	if !m.Skipped() || m.Result() != nil {
+		t.Error("q should keep the plan without a result")
+	}
+	if v := NewShardAdvisorModel(nil).View(); !strings.Contains(v, "No collections") {
+		t.Errorf("empty view = %q", v)
+	}
+}
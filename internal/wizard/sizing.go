@@ -15,6 +15,7 @@ type SizingModel struct {
 	plan        *sizing.SizingPlan
 	benchResult *benchmark.Result
 	cursor      int
+	showMath    bool
 	done        bool
 	cancelled   bool
 	width       int
@@ -53,6 +54,15 @@ func (m SizingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "b":
 			// Toggle benchmark (placeholder — actual benchmark is run externally)
 			return m, nil
+		case "e":
+			m.showMath = !m.showMath
+			return m, nil
+		case "+", "=":
+			m.resize(1)
+			return m, nil
+		case "-", "_":
+			m.resize(-1)
+			return m, nil
 		}
 	}
 
@@ -95,10 +105,23 @@ func (m SizingModel) View() string {
 	b.WriteString(highlightStyle.Render("  Duration:"))
 	b.WriteString(fmt.Sprintf(" %s\n", sizing.FormatDuration(m.plan.EstimatedTime)))
 
+	if m.plan.Estimate != nil {
+		b.WriteString(estimateView(m.plan))
+	}
+
 	// Sharding
 	if m.plan.ShardPlan != nil && m.plan.ShardPlan.Recommended {
 		b.WriteString(highlightStyle.Render("  Sharding:"))
 		b.WriteString(fmt.Sprintf(" %d shards recommended\n", m.plan.ShardPlan.ShardCount))
+		if zones := m.plan.ShardPlan.Zones(); len(zones) > 0 {
+			b.WriteString(highlightStyle.Render("  Zones:"))
+			b.WriteString(fmt.Sprintf(" %s\n", strings.Join(zones, ", ")))
+		}
+	}
+
+	if m.showMath && m.plan.Derivation != nil {
+		b.WriteString("\n")
+		b.WriteString(derivationView(m.plan.Derivation))
 	}
 
 	// Benchmark result
@@ -109,7 +132,70 @@ func (m SizingModel) View() string {
 	}
 
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("  b: run benchmark  enter: continue  q: cancel"))
+	help := "  b: run benchmark  e: show how this was calculated  enter: continue  q: cancel"
+	if m.plan.Estimate != nil {
+		help = fmt.Sprintf("  +/-: adjust %s  b: run benchmark  e: show how this was calculated  enter: continue  q: cancel", m.plan.UnitName())
+	}
+	b.WriteString(dimStyle.Render(help))
+
+	return b.String()
+}
+
+// estimateView renders the wall-clock duration and AWS cost breakdown of
+// running the migration on the plan's cluster.
+func estimateView(plan *sizing.SizingPlan) string {
+	var b strings.Builder
+	est := plan.Estimate
+	b.WriteString(highlightStyle.Render("  Run estimate:"))
+	b.WriteString(fmt.Sprintf(" %d %s, %s wall clock, $%.2f\n", est.Units, plan.UnitName(), sizing.FormatDuration(est.Duration), est.TotalCost))
+	b.WriteString(fmt.Sprintf("    Compute $%.2f (%.1f %s-hours)  S3 $%.2f  Data transfer $%.2f\n",
+		est.ComputeCost, est.ComputeHours, strings.TrimSuffix(plan.UnitName(), "s"), est.S3Cost, est.TransferCost))
+	if est.Units != est.RecommendedUnits {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("    Recommended: %d %s", est.RecommendedUnits, plan.UnitName())))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// resize grows or shrinks the plan's cluster by delta workers or DPUs,
+// stopping at the smallest cluster the platform allows.
+func (m *SizingModel) resize(delta int) {
+	if m.plan == nil || m.plan.Estimate == nil {
+		return
+	}
+	_ = m.plan.Resize(m.plan.Estimate.Units + delta)
+}
+
+// derivationView renders the inputs, formulas, assumptions and safety
+// margins behind the plan.
+func derivationView(d *sizing.Derivation) string {
+	var b strings.Builder
+
+	b.WriteString(highlightStyle.Render("  Inputs"))
+	b.WriteString("\n")
+	for _, in := range d.Inputs {
+		b.WriteString(fmt.Sprintf("    %-34s %s %s\n", in.Name, in.Value, dimStyle.Render("("+in.Source+")")))
+	}
+
+	b.WriteString(highlightStyle.Render("  Calculation"))
+	b.WriteString("\n")
+	for _, st := range d.Steps {
+		b.WriteString(fmt.Sprintf("    %-34s %s\n", st.Name, st.Value))
+		b.WriteString(fmt.Sprintf("      %s\n", dimStyle.Render(st.Formula)))
+	}
+
+	b.WriteString(highlightStyle.Render("  Assumptions"))
+	b.WriteString("\n")
+	for _, a := range d.Assumptions {
+		b.WriteString(fmt.Sprintf("    - %s\n", a))
+	}
+
+	b.WriteString(highlightStyle.Render("  Safety margins"))
+	b.WriteString("\n")
+	for _, sm := range d.SafetyMargins {
+		b.WriteString(fmt.Sprintf("    %-34s %s\n", sm.Name, sm.Margin))
+		b.WriteString(fmt.Sprintf("      %s\n", dimStyle.Render(sm.Reason)))
+	}
 
 	return b.String()
 }
@@ -124,6 +210,11 @@ func (m SizingModel) Cancelled() bool {
 	return m.cancelled
 }
 
+// Plan returns the sizing plan, resized to the cluster the user chose.
+func (m SizingModel) Plan() *sizing.SizingPlan {
+	return m.plan
+}
+
 // SetBenchmarkResult sets the benchmark result for display.
 func (m *SizingModel) SetBenchmarkResult(result *benchmark.Result) {
 	m.benchResult = result
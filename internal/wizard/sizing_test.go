@@ -1,6 +1,7 @@
 package wizard
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -113,3 +114,53 @@ func TestSizingModel_ConfirmWithF(t *testing.T) {
 		t.Error("f should finish")
 	}
 }
+
+func TestSizingModel_ShowDerivation(t *testing.T) {
+	plan := testSizingPlan()
+	plan.Derivation = &sizing.Derivation{
+		Inputs:        []sizing.Value{{Name: "Source data size", Value: "1.0 TB", Source: "schema"}},
+		Steps:         []sizing.Value{{Name: "Estimated target size", Value: "1.4 TB", Formula: "source size × expansion = 1.0 TB × 1.40"}},
+		Assumptions:   []string{"Sizes use binary units."},
+		SafetyMargins: []sizing.SafetyMargin{{Name: "Storage overhead", Margin: "1.5x", Reason: "Indexes and padding."}},
+	}
+	m := NewSizingModel(plan)
+
+	if strings.Contains(m.View(), "Safety margins") {
+		t.Error("derivation should be hidden by default")
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	v := result.(SizingModel).View()
+	for _, want := range []string{"Inputs", "1.0 TB × 1.40", "Sizes use binary units.", "Storage overhead"} {
+		if !strings.Contains(v, want) {
+			t.Errorf("view should contain %q", want)
+		}
+	}
+}
+
+func TestSizingModel_Resize(t *testing.T) {
+	plan := sizing.Calculate(sizing.Input{TotalDataBytes: 1 << 40, DenormExpansionFactor: 1, BenchmarkMBps: 100})
+	workers := plan.SparkPlan.WorkerCount
+	m := NewSizingModel(plan)
+
+	v := m.View()
+	for _, want := range []string{"Run estimate:", fmt.Sprintf("%d workers", workers), "Data transfer", "+/-: adjust workers"} {
+		if !strings.Contains(v, want) {
+			t.Errorf("view should contain %q", want)
+		}
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'-'}})
+	rm := result.(SizingModel)
+	if got := rm.Plan().SparkPlan.WorkerCount; got != workers-1 {
+		t.Errorf("workers = %d, want %d", got, workers-1)
+	}
+	if !strings.Contains(rm.View(), fmt.Sprintf("Recommended: %d workers", workers)) {
+		t.Error("view should show the recommended size once resized")
+	}
+
+	result, _ = rm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}})
+	if got := result.(SizingModel).Plan().Estimate.Units; got != workers {
+		t.Errorf("units = %d, want %d", got, workers)
+	}
+}
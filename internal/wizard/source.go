@@ -41,6 +41,9 @@ type SourceModel struct {
 	dbTypeChoice int // 0=PostgreSQL, 1=Oracle
 	err          error
 	discovering  bool
+	progress     *discovery.Progress
+	events       chan tea.Msg                                                        // progress then the result of a running discovery
+	discover     func(*config.SourceConfig, discovery.ProgressFunc) discoveryDoneMsg // nil discovers from here
 	spinner      spinner.Model
 	result       *SourceResult
 	done         bool
@@ -54,6 +57,12 @@ type discoveryDoneMsg struct {
 	err    error
 }
 
+type discoveryProgressMsg discovery.Progress
+
+// connectTimeout bounds connecting to the source. Discovery itself has no
+// limit: thousands of tables can take minutes, with progress shown.
+const connectTimeout = 30 * time.Second
+
 func NewSourceModel() SourceModel {
 	inputs := make([]textinput.Model, fieldCount)
 
@@ -147,8 +156,15 @@ func (m SourceModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.updateFocus()
 		}
 
+	case discoveryProgressMsg:
+		p := discovery.Progress(msg)
+		m.progress = &p
+		return m, waitForDiscovery(m.events)
+
 	case discoveryDoneMsg:
 		m.discovering = false
+		m.progress = nil
+		m.events = nil
 		if msg.err != nil {
 			m.err = msg.err
 			m.statusMsg = fmt.Sprintf("Connection failed: %v", msg.err)
@@ -205,7 +221,10 @@ func (m SourceModel) View() string {
 
 	b.WriteString("\n")
 
-	if m.discovering {
+	if m.discovering && m.progress != nil {
+		b.WriteString(fmt.Sprintf("  %s Discovering schema: %s\n", m.spinner.View(), discoveryPhaseLabel(*m.progress)))
+		b.WriteString(fmt.Sprintf("  %s %.0f%%\n", renderProgressBar(m.progress.Percent, 40), m.progress.Percent))
+	} else if m.discovering {
 		b.WriteString(fmt.Sprintf("  %s Connecting and discovering schema...\n", m.spinner.View()))
 	} else if m.err != nil {
 		b.WriteString(errStyle.Render("  "+m.statusMsg) + "\n")
@@ -248,33 +267,74 @@ func (m *SourceModel) startDiscovery() tea.Cmd {
 	m.discovering = true
 	m.err = nil
 	m.statusMsg = ""
+	m.progress = nil
 
 	cfg := m.buildConfig()
+	events := make(chan tea.Msg, 16)
+	m.events = events
 
-	return tea.Batch(
-		m.spinner.Tick,
-		func() tea.Msg {
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-
-			d, err := discovery.New(cfg)
-			if err != nil {
-				return discoveryDoneMsg{err: err}
+	run := m.discover
+	if run == nil {
+		run = discover
+	}
+	go func() {
+		events <- run(cfg, func(p discovery.Progress) {
+			select {
+			case events <- discoveryProgressMsg(p):
+			default: // the view only needs the latest
 			}
-			defer d.Close()
+		})
+	}()
 
-			if err := d.Connect(ctx); err != nil {
-				return discoveryDoneMsg{err: err}
-			}
+	return tea.Batch(m.spinner.Tick, waitForDiscovery(events))
+}
 
-			s, err := d.Discover(ctx)
-			if err != nil {
-				return discoveryDoneMsg{err: err}
-			}
+// discover connects to the source and discovers its schema.
+func discover(cfg *config.SourceConfig, progress discovery.ProgressFunc) discoveryDoneMsg {
+	d, err := discovery.New(cfg)
+	if err != nil {
+		return discoveryDoneMsg{err: err}
+	}
+	defer d.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	err = d.Connect(ctx)
+	cancel()
+	if err != nil {
+		return discoveryDoneMsg{err: err}
+	}
 
-			return discoveryDoneMsg{cfg: cfg, schema: s}
-		},
-	)
+	s, err := d.Discover(context.Background(), progress)
+	if err != nil {
+		return discoveryDoneMsg{err: err}
+	}
+	return discoveryDoneMsg{cfg: cfg, schema: s}
+}
+
+// waitForDiscovery delivers the next event of a running discovery.
+func waitForDiscovery(events <-chan tea.Msg) tea.Cmd {
+	if events == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+// discoveryPhaseLabel describes where a discovery has got to.
+func discoveryPhaseLabel(p discovery.Progress) string {
+	label := strings.ReplaceAll(p.Phase, "_", " ")
+	if p.Phase == discovery.PhaseTables {
+		if p.TablesTotal == 0 {
+			return "enumerating tables"
+		}
+		return fmt.Sprintf("%d tables found", p.TablesTotal)
+	}
+	s := fmt.Sprintf("%s (%d/%d), %d/%d tables", label, p.PhaseIndex, p.PhaseCount, p.TablesDone, p.TablesTotal)
+	if p.Table != "" {
+		s += " - " + p.Table
+	}
+	return s
 }
 
 func (m *SourceModel) buildConfig() *config.SourceConfig {
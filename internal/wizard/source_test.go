@@ -0,0 +1,55 @@
+package wizard
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/reloquent/reloquent/internal/discovery"
+)
+
+func TestSourceModel_DiscoveryProgress(t *testing.T) {
+	m := NewSourceModel()
+	m.discovering = true
+	m.events = make(chan tea.Msg, 1)
+
+	updated, cmd := m.Update(discoveryProgressMsg(discovery.Progress{
+		Phase: discovery.PhaseColumns, PhaseIndex: 2, PhaseCount: 7,
+		Table: "ORDERS", TablesDone: 1200, TablesTotal: 4000, Percent: 18.6,
+	}))
+	m = updated.(SourceModel)
+	if cmd == nil {
+		t.Error("progress should wait for the next discovery event")
+	}
+	view := m.View()
+	for _, want := range []string{"columns (2/7), 1200/4000 tables - ORDERS", "19%"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	updated, _ = m.Update(discoveryDoneMsg{err: errors.New("connection refused")})
+	m = updated.(SourceModel)
+	if m.discovering || m.progress != nil || strings.Contains(m.View(), "tables -") {
+		t.Error("progress should clear when discovery ends")
+	}
+}
+
+func TestDiscoveryPhaseLabel(t *testing.T) {
+	tests := []struct {
+		p    discovery.Progress
+		want string
+	}{
+		{discovery.Progress{Phase: discovery.PhaseTables}, "enumerating tables"},
+		{discovery.Progress{Phase: discovery.PhaseTables, TablesTotal: 4000, TablesDone: 4000}, "4000 tables found"},
+		{discovery.Progress{Phase: discovery.PhaseForeignKeys, PhaseIndex: 4, PhaseCount: 7, TablesDone: 10, TablesTotal: 20},
+			"foreign keys (4/7), 10/20 tables"},
+	}
+	for _, tt := range tests {
+		if got := discoveryPhaseLabel(tt.p); got != tt.want {
+			t.Errorf("discoveryPhaseLabel(%+v) = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
 	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/target"
 )
 
 // TargetResult is returned when the target step completes.
@@ -39,6 +40,7 @@ type TargetModel struct {
 	focused    int
 	err        error
 	connecting bool
+	connect    func(*config.TargetConfig) error // nil connects from here
 	spinner    spinner.Model
 	result     *TargetResult
 	done       bool
@@ -205,25 +207,34 @@ func (m *TargetModel) startConnect() tea.Cmd {
 
 	cfg := m.buildConfig()
 
+	connect := m.connect
+	if connect == nil {
+		connect = pingTarget
+	}
 	return tea.Batch(
 		m.spinner.Tick,
 		func() tea.Msg {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer cancel()
+			return targetConnectDoneMsg{err: connect(cfg)}
+		},
+	)
+}
 
-			client, err := mongo.Connect(options.Client().ApplyURI(cfg.ConnectionString))
-			if err != nil {
-				return targetConnectDoneMsg{err: err}
-			}
-			defer client.Disconnect(ctx)
+// pingTarget connects to MongoDB and pings it.
+func pingTarget(cfg *config.TargetConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-			if err := client.Ping(ctx, nil); err != nil {
-				return targetConnectDoneMsg{err: err}
-			}
+	resolved, err := cfg.Resolve()
+	if err != nil {
+		return err
+	}
+	client, err := mongo.Connect(options.Client().ApplyURI(target.WireURI(resolved.ConnectionString)))
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect(ctx)
 
-			return targetConnectDoneMsg{}
-		},
-	)
+	return client.Ping(ctx, nil)
 }
 
 func (m *TargetModel) buildConfig() *config.TargetConfig {
@@ -15,6 +15,12 @@ import (
 type TypeMapModel struct {
 	typeMap    *typemap.TypeMap
 	types     []string // source types actually in use, sorted
+	lobs      []typemap.LOBColumn // large object columns, listed after the types
+	enumLabel string              // how the enum source type is listed
+	booleans  []typemap.BooleanColumn // boolean-like columns, listed after the LOB columns
+	timestamps []typemap.TimestampColumn // timestamps without a time zone, listed last
+	serverZone string                    // the source server's time zone, if discovered
+	schema     *schema.Schema            // for the warnings of fixed-point columns
 	cursor    int
 	done      bool
 	cancelled bool
@@ -57,6 +63,11 @@ func NewTypeMapModel(s *schema.Schema, dbType string, existing *typemap.TypeMap)
 	return TypeMapModel{
 		typeMap: tm,
 		types:  types,
+		lobs:   tm.LOBColumns(s),
+		enumLabel: typemap.EnumLabel(s),
+		timestamps: tm.TimestampColumns(s),
+		serverZone: s.TimeZone,
+		schema:     s,
 		width:  100,
 		height: 24,
 	}
@@ -94,7 +105,7 @@ func (m TypeMapModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case "j", "down":
-			if m.cursor < len(m.types)-1 {
+			if m.cursor < m.rows()-1 {
 				m.cursor++
 			}
 
@@ -103,8 +114,22 @@ func (m TypeMapModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor--
 			}
 
-		case "e": // edit: cycle through BSON types
-			if m.cursor < len(m.types) {
+		case "a", "r": // accept or reject a boolean conversion
+			if i, ok := m.booleanAtCursor(); ok {
+				m.booleans[i].Accepted = msg.String() == "a"
+			}
+
+		case "e": // edit: cycle through BSON types, LOB strategies or time zone policies
+			if i, ok := m.booleanAtCursor(); ok {
+				m.booleans[i].Accepted = !m.booleans[i].Accepted
+			} else if i, ok := m.timestampAtCursor(); ok {
+				c := m.timestamps[i]
+				c.Policy = m.nextTimezonePolicy(c.Policy)
+				m.setTimestamp(i, c)
+			} else if lob, ok := m.lobAtCursor(); ok {
+				lob.Strategy = nextLOBStrategy(lob.Strategy)
+				m.setLOB(lob)
+			} else if m.cursor < len(m.types) {
 				sourceType := m.types[m.cursor]
 				current := m.typeMap.Resolve(sourceType)
 				next := nextBSONType(current)
@@ -112,7 +137,15 @@ func (m TypeMapModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "d": // restore default
-			if m.cursor < len(m.types) {
+			if i, ok := m.timestampAtCursor(); ok {
+				c := m.timestamps[i]
+				c.Policy = typemap.TimezoneUTC
+				m.setTimestamp(i, c)
+			} else if lob, ok := m.lobAtCursor(); ok {
+				lob.Strategy = typemap.LOBInline
+				lob.Location = ""
+				m.setLOB(lob)
+			} else if m.cursor < len(m.types) {
 				sourceType := m.types[m.cursor]
 				m.typeMap.RestoreDefault(sourceType)
 			}
@@ -152,11 +185,23 @@ func (m TypeMapModel) View() string {
 		start = m.cursor - maxVisible + 1
 	}
 	end := start + maxVisible
-	if end > len(m.types) {
-		end = len(m.types)
+	if end > m.rows() {
+		end = m.rows()
 	}
 
 	for i := start; i < end; i++ {
+		if i >= len(m.types)+len(m.lobs)+len(m.booleans) {
+			b.WriteString(m.timestampRow(i))
+			continue
+		}
+		if i >= len(m.types)+len(m.lobs) {
+			b.WriteString(m.booleanRow(i))
+			continue
+		}
+		if i >= len(m.types) {
+			b.WriteString(m.lobRow(i))
+			continue
+		}
 		sourceType := m.types[i]
 		bsonType := m.typeMap.Resolve(sourceType)
 
@@ -168,18 +213,180 @@ func (m TypeMapModel) View() string {
 		status := dimStyle.Render("default")
 		if m.typeMap.IsOverridden(sourceType) {
 			status = successStyle.Render("override ★")
+		} else if typemap.IsFixedPoint(sourceType) {
+			status = dimStyle.Render("by precision and scale")
 		}
 
 		b.WriteString(fmt.Sprintf("%s%-30s %-16s %s\n",
-			cursor, sourceType, string(bsonType), status))
+			cursor, m.typeLabel(sourceType), string(bsonType), status))
+	}
+
+	for _, w := range m.typeMap.DecimalWarnings(m.schema) {
+		b.WriteString(warnStyle.Render("  ⚠ "+w) + "\n")
 	}
 
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("  e edit • d restore default • enter confirm • q cancel\n"))
+	help := "  e edit • d restore default • enter confirm • q cancel\n"
+	if len(m.booleans) > 0 {
+		help = "  e edit • d restore default • a/r accept/reject boolean • enter confirm • q cancel\n"
+	}
+	b.WriteString(dimStyle.Render(help))
+
+	return b.String()
+}
+
+// typeLabel is how a source type is listed: enums show their labels, cut
+// to fit the column.
+func (m TypeMapModel) typeLabel(sourceType string) string {
+	if !typemap.IsEnum(sourceType) || m.enumLabel == "" {
+		return sourceType
+	}
+	if r := []rune(m.enumLabel); len(r) > 30 {
+		return string(r[:29]) + "…"
+	}
+	return m.enumLabel
+}
 
+// lobRow renders a large object column, under a heading for the first one.
+func (m TypeMapModel) lobRow(i int) string {
+	var b strings.Builder
+	lob := m.lobs[i-len(m.types)]
+	if i == len(m.types) {
+		b.WriteString("\n  " + fmt.Sprintf("%-30s %-16s %s\n", "Large Object Column", "Strategy", "Location"))
+		b.WriteString("  " + strings.Repeat("─", 60) + "\n")
+	}
+	cursor := "  "
+	if i == m.cursor {
+		cursor = highlightStyle.Render("> ")
+	}
+	strategy := dimStyle.Render(string(lob.Strategy))
+	if lob.Strategy != typemap.LOBInline {
+		strategy = successStyle.Render(string(lob.Strategy))
+	}
+	b.WriteString(fmt.Sprintf("%s%-30s %-16s %s\n", cursor, lob.Table+"."+lob.Column, strategy, lob.Location))
+	return b.String()
+}
+
+// booleanRow renders a boolean-like column with its sampled values, under
+// a heading for the first one.
+func (m TypeMapModel) booleanRow(i int) string {
+	var b strings.Builder
+	col := m.booleans[i-len(m.types)-len(m.lobs)]
+	if i == len(m.types)+len(m.lobs) {
+		b.WriteString("\n  " + fmt.Sprintf("%-30s %-16s %s\n", "Boolean-like Column", "Values", "Convert to Boolean"))
+		b.WriteString("  " + strings.Repeat("─", 60) + "\n")
+	}
+	cursor := "  "
+	if i == m.cursor {
+		cursor = highlightStyle.Render("> ")
+	}
+	values := strings.Join(col.TrueValues, ",") + "/" + strings.Join(col.FalseValues, ",")
+	decision := dimStyle.Render("rejected")
+	if col.Accepted {
+		decision = successStyle.Render("accepted")
+	}
+	b.WriteString(fmt.Sprintf("%s%-30s %-16s %s\n", cursor, col.Table+"."+col.Column, values, decision))
+	return b.String()
+}
+
+// timestampRow renders a timestamp column without a time zone with its
+// policy, under a heading for the first one.
+func (m TypeMapModel) timestampRow(i int) string {
+	var b strings.Builder
+	first := len(m.types) + len(m.lobs) + len(m.booleans)
+	c := m.timestamps[i-first]
+	if i == first {
+		b.WriteString("\n  " + fmt.Sprintf("%-30s %-16s %s\n", "Timestamp Column", "Time Zone", "Zone"))
+		b.WriteString("  " + strings.Repeat("─", 60) + "\n")
+	}
+	cursor := "  "
+	if i == m.cursor {
+		cursor = highlightStyle.Render("> ")
+	}
+	policy := dimStyle.Render(string(c.Policy))
+	if c.Policy != typemap.TimezoneUTC {
+		policy = successStyle.Render(string(c.Policy))
+	}
+	zone, _ := c.SourceZone(m.serverZone)
+	b.WriteString(fmt.Sprintf("%s%-30s %-16s %s\n", cursor, c.Table+"."+c.Column, policy, zone))
 	return b.String()
 }
 
+// rows is the number of selectable rows: the types, then the LOB columns,
+// the boolean-like columns and the timestamp columns.
+func (m TypeMapModel) rows() int {
+	return len(m.types) + len(m.lobs) + len(m.booleans) + len(m.timestamps)
+}
+
+// timestampAtCursor returns the index of the timestamp column under the
+// cursor, if any.
+func (m TypeMapModel) timestampAtCursor() (int, bool) {
+	i := m.cursor - len(m.types) - len(m.lobs) - len(m.booleans)
+	if i < 0 || i >= len(m.timestamps) {
+		return 0, false
+	}
+	return i, true
+}
+
+// setTimestamp records a column's policy in the type map and the listed
+// rows.
+func (m *TypeMapModel) setTimestamp(i int, c typemap.TimestampColumn) {
+	if err := m.typeMap.SetTimestamp(c); err != nil {
+		return
+	}
+	m.timestamps[i] = m.typeMap.Timestamp(c.Table, c.Column)
+	m.timestamps[i].DataType = c.DataType
+}
+
+// nextTimezonePolicy returns the next time zone policy in the cycle. The
+// server's zone is offered only when discovery recorded it, and a named
+// zone is set in typemap.yaml or the web UI.
+func (m TypeMapModel) nextTimezonePolicy(current typemap.TimezonePolicy) typemap.TimezonePolicy {
+	if current == typemap.TimezoneUTC && m.serverZone != "" {
+		return typemap.TimezoneServer
+	}
+	return typemap.TimezoneUTC
+}
+
+// booleanAtCursor returns the index of the boolean-like column under the
+// cursor, if any.
+func (m TypeMapModel) booleanAtCursor() (int, bool) {
+	i := m.cursor - len(m.types) - len(m.lobs)
+	if i < 0 || i >= len(m.booleans) {
+		return 0, false
+	}
+	return i, true
+}
+
+// SetBooleans lists boolean-like columns sampled from the source as
+// conversions to accept or reject.
+func (m *TypeMapModel) SetBooleans(cols []typemap.BooleanColumn) {
+	m.booleans = cols
+}
+
+// Booleans returns the boolean-like columns with the user's decisions.
+func (m TypeMapModel) Booleans() []typemap.BooleanColumn {
+	return m.booleans
+}
+
+// lobAtCursor returns the large object column under the cursor, if any.
+func (m TypeMapModel) lobAtCursor() (typemap.LOBColumn, bool) {
+	i := m.cursor - len(m.types)
+	if i < 0 || i >= len(m.lobs) {
+		return typemap.LOBColumn{}, false
+	}
+	return m.lobs[i], true
+}
+
+// setLOB records a column's strategy in the type map and the listed rows.
+func (m *TypeMapModel) setLOB(lob typemap.LOBColumn) {
+	if err := m.typeMap.SetLOB(lob); err != nil {
+		return
+	}
+	m.lobs[m.cursor-len(m.types)] = m.typeMap.LOB(lob.Table, lob.Column)
+	m.lobs[m.cursor-len(m.types)].DataType = lob.DataType
+}
+
 // Result returns the type mapping.
 func (m TypeMapModel) Result() *typemap.TypeMap {
 	if m.cancelled {
@@ -198,6 +405,19 @@ func (m TypeMapModel) Cancelled() bool {
 	return m.done && m.cancelled
 }
 
+// nextLOBStrategy returns the next LOB strategy in the cycle. S3 needs a
+// location, so it is set in typemap.yaml or the web UI and not cycled to.
+func nextLOBStrategy(current typemap.LOBStrategy) typemap.LOBStrategy {
+	switch current {
+	case typemap.LOBInline:
+		return typemap.LOBSkip
+	case typemap.LOBSkip:
+		return typemap.LOBGridFS
+	default:
+		return typemap.LOBInline
+	}
+}
+
 // nextBSONType returns the next BSON type in the cycle.
 func nextBSONType(current typemap.BSONType) typemap.BSONType {
 	types := typemap.AllBSONTypes
@@ -195,6 +195,24 @@ func TestTypeMapModel_View(t *testing.T) {
 	}
 }
 
+func TestTypeMapModel_EnumLabel(t *testing.T) {
+	s := testSchemaForTypeMap()
+	s.Tables[1].Columns = append(s.Tables[1].Columns, schema.Column{
+		Name: "status", DataType: "enum", TypeName: "order_status",
+		EnumValues: []string{"pending", "paid", "shipped", "cancelled"},
+	})
+	m := NewTypeMapModel(s, "postgresql", nil)
+	m.height = 30
+
+	if got := m.typeMap.Resolve("enum"); got != typemap.BSONString {
+		t.Errorf("enum resolves to %s, want String", got)
+	}
+	v := m.View()
+	if !strings.Contains(v, "enum(pending, paid, shipped, …") {
+		t.Errorf("view should list the enum's labels, cut to fit:\n%s", v)
+	}
+}
+
 func TestTypeMapModel_ExistingOverrides(t *testing.T) {
 	s := testSchemaForTypeMap()
 	existing := typemap.ForDatabase("postgresql")
@@ -228,3 +246,76 @@ func TestNextBSONType(t *testing.T) {
 		t.Error("should wrap around to first type")
 	}
 }
+
+func TestTypeMapModel_LOBStrategies(t *testing.T) {
+	s := testSchemaForTypeMap()
+	s.Tables[0].Columns = append(s.Tables[0].Columns, schema.Column{Name: "avatar", DataType: "bytea"})
+	m := NewTypeMapModel(s, "postgresql", nil)
+
+	if len(m.lobs) != 1 || m.lobs[0].Column != "avatar" {
+		t.Fatalf("lobs = %+v, want users.avatar", m.lobs)
+	}
+
+	// The LOB column is listed after the types
+	for i := 0; i < len(m.types); i++ {
+		result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+		m = result.(TypeMapModel)
+	}
+	if _, ok := m.lobAtCursor(); !ok {
+		t.Fatalf("cursor %d should be on the LOB column", m.cursor)
+	}
+
+	for _, want := range []typemap.LOBStrategy{typemap.LOBSkip, typemap.LOBGridFS, typemap.LOBInline} {
+		result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+		m = result.(TypeMapModel)
+		if got := m.typeMap.LOB("users", "avatar").Strategy; got != want {
+			t.Errorf("strategy = %s, want %s", got, want)
+		}
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	m = result.(TypeMapModel)
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	m = result.(TypeMapModel)
+	if got := m.typeMap.LOB("users", "avatar").Strategy; got != typemap.LOBInline {
+		t.Errorf("after restore: strategy = %s, want inline", got)
+	}
+
+	if v := m.View(); !strings.Contains(v, "Large Object Column") || !strings.Contains(v, "users.avatar") {
+		t.Error("view should list the LOB column")
+	}
+}
+
+func TestTypeMapModel_Booleans(t *testing.T) {
+	m := NewTypeMapModel(testSchemaForTypeMap(), "postgresql", nil)
+	m.SetBooleans([]typemap.BooleanColumn{
+		{Table: "users", Column: "active", TrueValues: []string{"Y"}, FalseValues: []string{"N"}},
+	})
+
+	// The boolean-like column is listed after the types
+	for i := 0; i < len(m.types); i++ {
+		result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+		m = result.(TypeMapModel)
+	}
+	if _, ok := m.booleanAtCursor(); !ok {
+		t.Fatalf("cursor %d should be on the boolean column", m.cursor)
+	}
+
+	for _, tc := range []struct {
+		key  rune
+		want bool
+	}{{'a', true}, {'a', true}, {'r', false}, {'e', true}} {
+		result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{tc.key}})
+		m = result.(TypeMapModel)
+		if got := m.Booleans()[0].Accepted; got != tc.want {
+			t.Errorf("after %c: accepted = %v, want %v", tc.key, got, tc.want)
+		}
+	}
+
+	v := m.View()
+	for _, want := range []string{"Boolean-like Column", "users.active", "Y/N", "accepted"} {
+		if !strings.Contains(v, want) {
+			t.Errorf("view missing %q", want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/reloquent/reloquent/internal/validation"
@@ -18,8 +19,26 @@ type ValidationModel struct {
 	failed     bool
 	width      int
 	height     int
+
+	// Fix-it workflow for failed collections.
+	diagnoses     []validation.Diagnosis
+	decisions     map[string]ValidationDecision
+	fixing        bool
+	cursor        int
+	justifying    bool
+	justification textinput.Model
+}
+
+// ValidationDecision is the remediation picked for a failed collection.
+type ValidationDecision struct {
+	Action        string
+	Justification string
 }
 
+// ValidationResultMsg delivers the final result to a running validation
+// model, for validation that runs elsewhere.
+type ValidationResultMsg validation.Result
+
 type validationCheck struct {
 	Collection string
 	CheckType  string
@@ -45,8 +64,20 @@ func (m ValidationModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		return m, nil
 
+	case ValidationResultMsg:
+		result := validation.Result(msg)
+		m.SetResult(&result)
+		return m, nil
+
 	case tea.KeyMsg:
+		if m.fixing {
+			return m.updateFixing(msg)
+		}
 		switch msg.String() {
+		case "f":
+			if m.failed && len(m.diagnoses) > 0 {
+				m.fixing = true
+			}
 		case "q", "esc":
 			m.done = true
 			m.cancelled = true
@@ -67,12 +98,82 @@ func (m ValidationModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateFixing handles keys in the fix-it view: pick a failed collection
+// and a remediation for it, typing a justification to accept a variance.
+func (m ValidationModel) updateFixing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.justifying {
+		switch msg.String() {
+		case "esc":
+			m.justifying = false
+			return m, nil
+		case "enter":
+			text := strings.TrimSpace(m.justification.Value())
+			if text == "" {
+				return m, nil
+			}
+			m.decide(validation.ActionAccept, text)
+			m.justifying = false
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.justification, cmd = m.justification.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.diagnoses)-1 {
+			m.cursor++
+		}
+	case "r":
+		m.decide(validation.ActionRemigrate, "")
+	case "m":
+		m.decide(validation.ActionAdjustMapping, "")
+	case "a":
+		m.justifying = true
+		m.justification = textinput.New()
+		m.justification.Placeholder = "why the difference is acceptable"
+		m.justification.CharLimit = 200
+		m.justification.Focus()
+		return m, textinput.Blink
+	case "esc":
+		m.fixing = false
+	case "enter":
+		if len(m.decisions) > 0 {
+			m.done = true
+			return m, tea.Quit
+		}
+	case "q":
+		m.done = true
+		m.cancelled = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// decide records the remediation for the selected collection.
+func (m *ValidationModel) decide(action, justification string) {
+	if m.decisions == nil {
+		m.decisions = make(map[string]ValidationDecision)
+	}
+	m.decisions[m.diagnoses[m.cursor].Collection] = ValidationDecision{Action: action, Justification: justification}
+}
+
 func (m ValidationModel) View() string {
 	var b strings.Builder
 
 	b.WriteString(titleStyle.Render("Step 10: Validation"))
 	b.WriteString("\n\n")
 
+	if m.fixing {
+		b.WriteString(m.fixView())
+		return b.String()
+	}
+
 	if len(m.checks) == 0 && m.result == nil {
 		b.WriteString("  Waiting for validation to start...\n")
 		return b.String()
@@ -97,6 +198,12 @@ func (m ValidationModel) View() string {
 
 	// Overall status
 	if m.result != nil {
+		b.WriteString(referentialSummary(m.result))
+		b.WriteString(groupSummary(m.result))
+		b.WriteString(shardKeySummary(m.result))
+		b.WriteString(timestampSummary(m.result))
+		b.WriteString(collationSummary(m.result))
+		b.WriteString(ruleSummary(m.result))
 		b.WriteString("\n")
 		switch m.result.Status {
 		case "PASS":
@@ -104,11 +211,14 @@ func (m ValidationModel) View() string {
 		case "FAIL":
 			b.WriteString(errStyle.Render("  Validation: FAIL"))
 		case "PARTIAL":
-			b.WriteString(errStyle.Render("  Validation: PARTIAL (some checks failed)"))
+			b.WriteString(errStyle.Render("  Validation: PARTIAL (some checks failed or collections were skipped)"))
 		}
 		b.WriteString("\n\n")
 
-		if m.failed {
+		if m.failed && len(m.diagnoses) > 0 {
+			b.WriteString(dimStyle.Render("  f: fix failures  p: proceed anyway  q: cancel"))
+			b.WriteString("\n")
+		} else if m.failed {
 			b.WriteString(dimStyle.Render("  p: proceed anyway  q: cancel"))
 			b.WriteString("\n")
 		} else {
@@ -120,6 +230,177 @@ func (m ValidationModel) View() string {
 	return b.String()
 }
 
+// fixView lists the failed collections with the remediation decided for
+// each, and the probable causes of the selected one's failure.
+func (m ValidationModel) fixView() string {
+	var b strings.Builder
+	b.WriteString("  Fix validation failures\n\n")
+	for i, d := range m.diagnoses {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		decision := dimStyle.Render("suggested: " + d.Suggested())
+		if dec, ok := m.decisions[d.Collection]; ok {
+			decision = successStyle.Render(dec.Action)
+		}
+		b.WriteString(fmt.Sprintf("  %s%s  %s\n", cursor, highlightStyle.Render(d.Collection), decision))
+	}
+
+	d := m.diagnoses[m.cursor]
+	b.WriteString(fmt.Sprintf("\n  Probable causes for %s:\n", d.Collection))
+	if len(d.Causes) == 0 {
+		b.WriteString("    none found; re-migrating is the safest fix\n")
+	}
+	for _, c := range d.Causes {
+		b.WriteString(fmt.Sprintf("    - %s (%s)\n", c.Message, c.Action))
+	}
+	b.WriteString("\n")
+
+	if m.justifying {
+		b.WriteString("  Justification: " + m.justification.View() + "\n\n")
+		b.WriteString(dimStyle.Render("  enter: accept  esc: back"))
+		b.WriteString("\n")
+		return b.String()
+	}
+	b.WriteString(dimStyle.Render("  r: re-migrate  m: adjust mapping  a: accept variance  enter: apply  esc: back  q: cancel"))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// referentialSummary lists, per relationship, the references whose parent
+// is missing from the target, or is empty when every reference resolved.
+func referentialSummary(result *validation.Result) string {
+	var b strings.Builder
+	for _, c := range result.Collections {
+		if c.ReferentialCheck == nil || c.ReferentialCheck.Match {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("\n  Referential integrity:\n")
+		}
+		for _, r := range c.ReferentialCheck.Relationships {
+			if r.Orphans == 0 {
+				continue
+			}
+			line := fmt.Sprintf("    %s: %d orphaned of %d checked", r.Relationship(c.Name), r.Orphans, r.Checked)
+			if len(r.Examples) > 0 {
+				line += fmt.Sprintf(" (e.g. %v)", r.Examples[0])
+			}
+			b.WriteString(errStyle.Render(line) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// groupSummary lists the consistency groups whose references do not
+// resolve in the target as they did in the source, or is empty when every
+// group matched.
+func groupSummary(result *validation.Result) string {
+	var b strings.Builder
+	for _, c := range result.Collections {
+		gc := c.GroupCheck
+		if gc == nil || gc.Match {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("\n  Consistency groups:\n")
+		}
+		if gc.Message != "" {
+			b.WriteString(errStyle.Render(fmt.Sprintf("    %s (%s): %s", c.Name, gc.Group, gc.Message)) + "\n")
+		}
+		for _, r := range gc.Relationships {
+			if r.SourceCount == r.TargetCount {
+				continue
+			}
+			line := fmt.Sprintf("    %s: %d source rows with a parent, %d documents", r.Relationship(c.Name), r.SourceCount, r.TargetCount)
+			b.WriteString(errStyle.Render(line) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// shardKeySummary lists the sharded collections with sampled documents
+// lacking a shard key field, or is empty when every document held its key.
+func shardKeySummary(result *validation.Result) string {
+	var b strings.Builder
+	for _, c := range result.Collections {
+		sc := c.ShardKeyCheck
+		if sc == nil || sc.Match {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("\n  Shard keys:\n")
+		}
+		line := fmt.Sprintf("    %s: %.1f%% of %d sampled documents lack %s",
+			c.Name, sc.MissingPercent, sc.Sampled, strings.Join(sc.Fields, ", "))
+		b.WriteString(errStyle.Render(line) + "\n")
+	}
+	return b.String()
+}
+
+// timestampSummary lists the timestamp fields holding other instants than
+// their time zone policies give, or is empty when every sampled value did.
+func timestampSummary(result *validation.Result) string {
+	var b strings.Builder
+	for _, c := range result.Collections {
+		tc := c.TimestampCheck
+		if tc == nil || tc.Match {
+			continue
+		}
+		for _, f := range tc.Fields {
+			if f.Mismatched == 0 {
+				continue
+			}
+			if b.Len() == 0 {
+				b.WriteString("\n  Timestamps:\n")
+			}
+			b.WriteString(errStyle.Render(fmt.Sprintf("    %s.%s: %s", c.Name, f.Field, f.Summary())) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// collationSummary lists the embeds that leave out rows the source joins
+// ignoring case, or is empty when none does.
+func collationSummary(result *validation.Result) string {
+	var b strings.Builder
+	for _, c := range result.Collections {
+		cc := c.CollationCheck
+		if cc == nil || cc.Match {
+			continue
+		}
+		for _, j := range cc.Joins {
+			if j.Dropped == 0 {
+				continue
+			}
+			if b.Len() == 0 {
+				b.WriteString("\n  Case-insensitive joins:\n")
+			}
+			b.WriteString(errStyle.Render(fmt.Sprintf("    %s.%s: %s", c.Name, j.Field, j.Summary())) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// ruleSummary lists the custom rules that failed, or is empty when every
+// rule held.
+func ruleSummary(result *validation.Result) string {
+	var b strings.Builder
+	for _, c := range result.Collections {
+		for _, rc := range c.RuleChecks {
+			if rc.Match {
+				continue
+			}
+			if b.Len() == 0 {
+				b.WriteString("\n  Rules:\n")
+			}
+			b.WriteString(errStyle.Render(fmt.Sprintf("    %s (%s): %s", rc.Rule, c.Name, rc.Summary())) + "\n")
+		}
+	}
+	return b.String()
+}
+
 // Done returns true when the model is finished.
 func (m ValidationModel) Done() bool {
 	return m.done
@@ -144,6 +425,18 @@ func (m *ValidationModel) AddCheck(collection, checkType string, passed bool) {
 	})
 }
 
+// SetDiagnoses sets the diagnoses of the failed collections offered in the
+// fix-it view.
+func (m *ValidationModel) SetDiagnoses(diagnoses []validation.Diagnosis) {
+	m.diagnoses = diagnoses
+	m.cursor = 0
+}
+
+// Decisions returns the remediation picked for each failed collection.
+func (m ValidationModel) Decisions() map[string]ValidationDecision {
+	return m.decisions
+}
+
 // SetResult sets the final validation result.
 func (m *ValidationModel) SetResult(result *validation.Result) {
 	m.result = result
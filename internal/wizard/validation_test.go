@@ -49,6 +49,19 @@ func TestValidationModel_SetResult_Pass(t *testing.T) {
 	}
 }
 
+func TestValidationModel_ResultMsg(t *testing.T) {
+	m := NewValidationModel()
+	updated, _ := m.Update(ValidationResultMsg(validation.Result{Status: "FAIL"}))
+	vm := updated.(ValidationModel)
+
+	if vm.Result() == nil || vm.Result().Status != "FAIL" {
+		t.Fatalf("result = %+v, want FAIL", vm.Result())
+	}
+	if !strings.Contains(vm.View(), "proceed") {
+		t.Error("should show proceed option on failure")
+	}
+}
+
 func TestValidationModel_SetResult_Fail(t *testing.T) {
 	m := NewValidationModel()
 	m.SetResult(&validation.Result{Status: "FAIL"})
@@ -105,3 +118,81 @@ func TestValidationModel_View_Title(t *testing.T) {
 		t.Error("view should contain step title")
 	}
 }
+
+func TestValidationModel_View_Referential(t *testing.T) {
+	m := NewValidationModel()
+	m.AddCheck("orders", "referential", false)
+	m.SetResult(&validation.Result{Status: "FAIL", Collections: []validation.CollectionResult{{
+		Name:   "orders",
+		Status: "FAIL",
+		ReferentialCheck: &validation.ReferentialCheck{Orphans: 2, Relationships: []validation.RelationshipCheck{
+			{Field: "customer_id", Parent: "customers", ParentField: "id", Checked: 100, Orphans: 2, Examples: []interface{}{int64(7)}},
+		}},
+	}}})
+
+	v := m.View()
+	for _, want := range []string{"referential", "orders.customer_id -> customers.id: 2 orphaned of 100 checked", "e.g. 7"} {
+		if !strings.Contains(v, want) {
+			t.Errorf("view missing %q:\n%s", want, v)
+		}
+	}
+}
+
+func TestValidationModel_View_ShardKey(t *testing.T) {
+	m := NewValidationModel()
+	m.SetResult(&validation.Result{Status: "FAIL", Collections: []validation.CollectionResult{{
+		Name:          "orders",
+		Status:        "FAIL",
+		ShardKeyCheck: &validation.ShardKeyCheck{Fields: []string{"region", "_id"}, Sampled: 200, Missing: 5, MissingPercent: 2.5},
+	}}})
+
+	if v := m.View(); !strings.Contains(v, "orders: 2.5% of 200 sampled documents lack region, _id") {
+		t.Errorf("view missing the shard key summary:\n%s", v)
+	}
+}
+
+func TestValidationModel_FixFailures(t *testing.T) {
+	m := NewValidationModel()
+	m.SetResult(&validation.Result{Status: "FAIL"})
+	m.SetDiagnoses([]validation.Diagnosis{
+		{Collection: "orders", Status: "FAIL", Causes: []validation.Cause{
+			{Kind: validation.CauseSourceChanged, Action: validation.ActionRemigrate, Message: "3 source rows have no document"},
+		}},
+		{Collection: "payments", Status: "FAIL"},
+	})
+	if !strings.Contains(m.View(), "f: fix failures") {
+		t.Fatal("should offer the fix-it view on failure")
+	}
+
+	key := func(model tea.Model, k tea.KeyMsg) ValidationModel {
+		updated, _ := model.Update(k)
+		return updated.(ValidationModel)
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	m = key(m, runes("f"))
+	if v := m.View(); !strings.Contains(v, "3 source rows have no document") || !strings.Contains(v, "suggested: remigrate") {
+		t.Errorf("fix-it view missing the causes:\n%s", v)
+	}
+	m = key(m, runes("r"))
+	m = key(m, runes("j"))
+	m = key(m, runes("a"))
+	m = key(m, tea.KeyMsg{Type: tea.KeyEnter}) // an empty justification is refused
+	if !m.justifying {
+		t.Fatal("accepting needs a justification")
+	}
+	m = key(m, runes("rounding"))
+	m = key(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = key(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.Done() || m.Cancelled() {
+		t.Fatal("enter should apply the decisions")
+	}
+
+	want := map[string]ValidationDecision{
+		"orders":   {Action: validation.ActionRemigrate},
+		"payments": {Action: validation.ActionAccept, Justification: "rounding"},
+	}
+	if got := m.Decisions(); len(got) != 2 || got["orders"] != want["orders"] || got["payments"] != want["payments"] {
+		t.Errorf("decisions = %+v, want %+v", got, want)
+	}
+}
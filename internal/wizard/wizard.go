@@ -2,16 +2,26 @@ package wizard
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"path/filepath"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/reloquent/reloquent/internal/atlas"
 	"github.com/reloquent/reloquent/internal/benchmark"
+	"github.com/reloquent/reloquent/internal/cdc"
 	"github.com/reloquent/reloquent/internal/config"
+	"github.com/reloquent/reloquent/internal/engine"
 	"github.com/reloquent/reloquent/internal/indexes"
 	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/migration"
 	"github.com/reloquent/reloquent/internal/postmigration"
+	"github.com/reloquent/reloquent/internal/retention"
 	"github.com/reloquent/reloquent/internal/schema"
 	"github.com/reloquent/reloquent/internal/sizing"
 	"github.com/reloquent/reloquent/internal/source"
@@ -41,17 +51,30 @@ type Wizard struct {
 	// Phase 4 data
 	validationResult *validation.Result
 	indexPlan        *indexes.IndexPlan
+
+	// session records the decisions made so far; it is saved after every
+	// design step so the run can be replayed
+	session *Session
 }
 
-// New creates a new Wizard, loading any saved state for resume.
+// New creates a new Wizard, loading any saved state for resume. An empty
+// statePath uses the active project.
 func New(statePath string) (*Wizard, error) {
+	if statePath == "" {
+		statePath = state.Active().StatePath()
+	}
 	s, err := state.Load(statePath)
 	if err != nil {
 		return nil, fmt.Errorf("loading wizard state: %w", err)
 	}
+	sess, err := loadOrNewSession(SessionPath(statePath))
+	if err != nil {
+		return nil, err
+	}
 	return &Wizard{
 		state:     s,
 		statePath: statePath,
+		session:   sess,
 	}, nil
 }
 
@@ -151,6 +174,14 @@ func (w *Wizard) Run() error {
 		if err := w.runIndexBuilds(); err != nil {
 			return err
 		}
+		step = w.state.CurrentStep
+	}
+
+	// Step 12: Change Data Capture
+	if step == state.StepCDC {
+		if err := w.runCDC(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -174,27 +205,74 @@ func (w *Wizard) runSource() error {
 	if result == nil {
 		return fmt.Errorf("no source result")
 	}
+	return w.completeSource(result)
+}
 
+// completeSource keeps a discovery as a schema snapshot and moves on to
+// the target connection.
+func (w *Wizard) completeSource(result *SourceResult) error {
 	w.sourceConfig = result.Config
 	w.schema = result.Schema
 
-	// Save schema to disk
-	schemaDir := filepath.Dir(config.ExpandHome(w.statePath))
-	schemaPath := filepath.Join(schemaDir, "source-schema.yaml")
-	if err := w.schema.WriteYAML(schemaPath); err != nil {
-		return fmt.Errorf("saving schema: %w", err)
+	// Keep the earlier discovery, if any, to report what changed
+	var previous *schema.Schema
+	if w.state.SchemaPath != "" {
+		previous, _ = schema.LoadYAML(w.state.SchemaPath)
+	}
+
+	// Keep the discovery as a snapshot; it becomes the design baseline
+	// unless another snapshot is pinned
+	snaps := schema.OpenSnapshots(filepath.Dir(config.ExpandHome(w.statePath)))
+	info, err := snaps.Record(w.schema, w.state.SchemaPath)
+	if err != nil {
+		return err
 	}
 
 	// Update state
 	w.state.SourceConfig = result.Config
-	w.state.SchemaPath = schemaPath
+	if w.state.SchemaSnapshot == "" {
+		w.state.SchemaPath = snaps.Path(info.ID)
+	}
 	w.state.CompleteStep(state.StepSourceConnection, state.StepTargetConnection)
 	if err := w.state.Save(w.statePath); err != nil {
 		return fmt.Errorf("saving state: %w", err)
 	}
 
 	fmt.Printf("\nDiscovered %d tables.\n\n", len(w.schema.Tables))
-	return nil
+	if previous != nil {
+		if d := schema.Diff(previous, w.schema); d.HasChanges() {
+			fmt.Printf("The schema changed since the last discovery:\n%s\n\n", d.Summary())
+		}
+		if w.state.MappingPath != "" {
+			if m, err := mapping.LoadYAML(w.state.MappingPath); err == nil {
+				warnStaleMapping(w.schema, m)
+			}
+		}
+	}
+	if w.state.SchemaSnapshot != "" {
+		fmt.Printf("Saved as schema snapshot %s. Snapshot %s stays pinned as the design baseline.\n\n", info.ID, w.state.SchemaSnapshot)
+		if pinned, err := schema.LoadYAML(w.state.SchemaPath); err == nil {
+			w.schema = pinned
+		}
+	}
+	return w.record(state.StepSourceConnection, func(s *Session) {
+		s.Source = w.state.SourceConfig
+	})
+}
+
+// warnStaleMapping prints a warning for every table or column the saved
+// mapping refers to that the schema no longer has.
+func warnStaleMapping(s *schema.Schema, m *mapping.Mapping) {
+	stale := m.StaleReferences(s)
+	if len(stale) == 0 {
+		return
+	}
+	fmt.Println(warnStyle.Render(fmt.Sprintf("Warning: the saved mapping refers to %d tables or columns that no longer exist:", len(stale))))
+	for _, r := range stale {
+		fmt.Println(warnStyle.Render("  " + r.String()))
+	}
+	fmt.Println("Revisit the denormalization design (reloquent design) before generating the migration.")
+	fmt.Println()
 }
 
 func (w *Wizard) runTarget() error {
@@ -215,18 +293,23 @@ func (w *Wizard) runTarget() error {
 	if result == nil {
 		return fmt.Errorf("no target result")
 	}
+	return w.completeTarget(result.Config)
+}
 
-	w.targetConfig = result.Config
+func (w *Wizard) completeTarget(cfg *config.TargetConfig) error {
+	w.targetConfig = cfg
 
 	// Update state
-	w.state.TargetConfig = result.Config
+	w.state.TargetConfig = cfg
 	w.state.CompleteStep(state.StepTargetConnection, state.StepTableSelection)
 	if err := w.state.Save(w.statePath); err != nil {
 		return fmt.Errorf("saving state: %w", err)
 	}
 
-	fmt.Printf("\nConnected to MongoDB (%s).\n\n", result.Config.Database)
-	return nil
+	fmt.Printf("\nConnected to MongoDB (%s).\n\n", cfg.Database)
+	return w.record(state.StepTargetConnection, func(s *Session) {
+		s.Target = w.state.TargetConfig
+	})
 }
 
 func (w *Wizard) runTableSelect() error {
@@ -265,19 +348,28 @@ func (w *Wizard) runTableSelect() error {
 	for i, t := range result.Selected {
 		names[i] = t.Name
 	}
+	return w.completeTableSelect(names)
+}
+
+func (w *Wizard) completeTableSelect(names []string) error {
 	w.state.SelectedTables = names
 	w.state.CompleteStep(state.StepTableSelection, state.StepDenormalization)
 	if err := w.state.Save(w.statePath); err != nil {
 		return fmt.Errorf("saving state: %w", err)
 	}
 
-	fmt.Printf("\nSelected %d tables for migration.\n", len(result.Selected))
-	return nil
+	fmt.Printf("\nSelected %d tables for migration.\n", len(names))
+	return w.record(state.StepTableSelection, func(s *Session) {
+		s.Tables = names
+	})
 }
 
 // RunTableSelectStandalone runs only the table selection step.
 // Used by the `reloquent select` subcommand.
 func RunTableSelectStandalone(schemaPath string, statePath string) error {
+	if statePath == "" {
+		statePath = state.Active().StatePath()
+	}
 	s, err := schema.LoadYAML(schemaPath)
 	if err != nil {
 		return fmt.Errorf("loading schema: %w", err)
@@ -317,7 +409,9 @@ func RunTableSelectStandalone(schemaPath string, statePath string) error {
 	}
 
 	fmt.Printf("Selected %d tables for migration.\n", len(result.Selected))
-	return nil
+	return recordSession(statePath, state.StepTableSelection, func(s *Session) {
+		s.Tables = names
+	})
 }
 
 func (w *Wizard) runDenorm() error {
@@ -346,6 +440,9 @@ func (w *Wizard) runDenorm() error {
 	}
 
 	m := NewDenormModel(tables)
+	if w.state.SourceConfig != nil {
+		m.SetDocumentPreview(sourceDocumentPreview(*w.state.SourceConfig, w.filteredSchema()))
+	}
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	finalModel, err := p.Run()
@@ -358,7 +455,22 @@ func (w *Wizard) runDenorm() error {
 		return fmt.Errorf("cancelled")
 	}
 
-	result := dm.BuildMapping()
+	if err := w.saveMapping(dm.BuildMapping()); err != nil {
+		return err
+	}
+	if err := w.runRetention(); err != nil {
+		return err
+	}
+	if err := w.runIDGenerationDesign(); err != nil {
+		return err
+	}
+	return w.record(state.StepDenormalization, func(s *Session) {
+		s.Mapping = w.mapping
+	})
+}
+
+// saveMapping saves a denormalization design and moves on to type mapping.
+func (w *Wizard) saveMapping(result *mapping.Mapping) error {
 	w.mapping = result
 
 	// Save mapping to disk
@@ -379,9 +491,99 @@ func (w *Wizard) runDenorm() error {
 	return nil
 }
 
+// runRetention lets the user set TTL and archival policies on collections
+// whose source tables hold time-based data. It is skipped when there are
+// none, and histograms are left out when the source cannot be reached.
+func (w *Wizard) runRetention() error {
+	candidates := retention.Candidates(w.schema, w.mapping)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	histograms := make(map[string]*retention.Histogram)
+	if reader, err := w.buildSourceReader(); err != nil {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("Row age histograms unavailable: %v", err)))
+	} else {
+		for _, c := range candidates {
+			h, err := retention.Load(context.Background(), reader, c.Table, c.Column)
+			if err != nil {
+				fmt.Println(warnStyle.Render(fmt.Sprintf("Row ages of %s.%s: %v", c.Table, c.Column, err)))
+				continue
+			}
+			histograms[c.Collection] = h
+		}
+		reader.Close()
+	}
+
+	p := tea.NewProgram(NewRetentionModel(candidates, histograms), tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return fmt.Errorf("running retention designer: %w", err)
+	}
+	policies := finalModel.(RetentionModel).Result()
+	if policies == nil {
+		return nil
+	}
+
+	for i := range w.mapping.Collections {
+		col := &w.mapping.Collections[i]
+		if p, ok := policies[col.Name]; ok {
+			col.Retention = p
+		}
+	}
+	if err := w.mapping.WriteYAML(w.state.MappingPath); err != nil {
+		return fmt.Errorf("saving mapping: %w", err)
+	}
+	for _, c := range candidates {
+		if p := policies[c.Collection]; p != nil {
+			fmt.Printf("Retention for %s: %s\n", c.Collection, retention.Describe(p))
+		}
+	}
+	return nil
+}
+
+// runIDGenerationDesign lets the user choose how collections whose primary
+// key came from a sequence make new ids after cutover. It is skipped when
+// there are none.
+func (w *Wizard) runIDGenerationDesign() error {
+	candidates := postmigration.IDCandidates(w.schema, w.mapping)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	p := tea.NewProgram(NewIDGenerationModel(candidates), tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return fmt.Errorf("running id generation chooser: %w", err)
+	}
+	strategies := finalModel.(IDGenerationModel).Result()
+	if strategies == nil {
+		return nil
+	}
+
+	for i := range w.mapping.Collections {
+		col := &w.mapping.Collections[i]
+		if s, ok := strategies[col.Name]; ok {
+			col.IDGeneration = s
+		}
+	}
+	if err := w.mapping.WriteYAML(w.state.MappingPath); err != nil {
+		return fmt.Errorf("saving mapping: %w", err)
+	}
+	for _, c := range candidates {
+		if s := strategies[c.Collection]; s != "" {
+			fmt.Printf("New ids for %s: %s\n", c.Collection, s)
+		}
+	}
+	return nil
+}
+
 // RunDenormStandalone runs only the denormalization designer step.
 // Used by the `reloquent design` subcommand.
 func RunDenormStandalone(schemaPath string, statePath string) error {
+	if statePath == "" {
+		statePath = state.Active().StatePath()
+	}
 	s, err := schema.LoadYAML(schemaPath)
 	if err != nil {
 		return fmt.Errorf("loading schema: %w", err)
@@ -409,6 +611,9 @@ func RunDenormStandalone(schemaPath string, statePath string) error {
 	}
 
 	m := NewDenormModel(tables)
+	if st.SourceConfig != nil {
+		m.SetDocumentPreview(sourceDocumentPreview(*st.SourceConfig, &schema.Schema{DatabaseType: s.DatabaseType, Tables: tables}))
+	}
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	finalModel, err := p.Run()
@@ -437,7 +642,9 @@ func RunDenormStandalone(schemaPath string, statePath string) error {
 	}
 
 	fmt.Printf("Mapping saved with %d collections.\n", len(result.Collections))
-	return nil
+	return recordSession(statePath, state.StepDenormalization, func(s *Session) {
+		s.Mapping = result
+	})
 }
 
 func (w *Wizard) runTypeMapping() error {
@@ -472,6 +679,7 @@ func (w *Wizard) runTypeMapping() error {
 	filteredSchema := w.filteredSchema()
 
 	m := NewTypeMapModel(filteredSchema, dbType, existing)
+	m.SetBooleans(w.detectBooleans())
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	finalModel, err := p.Run()
@@ -488,7 +696,58 @@ func (w *Wizard) runTypeMapping() error {
 	if result == nil {
 		return fmt.Errorf("no type mapping result")
 	}
+	if err := w.saveBooleanConversions(tmm.Booleans()); err != nil {
+		return err
+	}
+	return w.completeTypeMapping(result)
+}
+
+// detectBooleans samples the mapped tables for columns storing flags as
+// text or numbers. The source being unreachable only skips the suggestions.
+func (w *Wizard) detectBooleans() []typemap.BooleanColumn {
+	if w.mapping == nil {
+		return nil
+	}
+	reader, err := w.buildSourceReader()
+	if err != nil {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("Boolean column detection unavailable: %v", err)))
+		return nil
+	}
+	defer reader.Close()
+	fmt.Println("Sampling flag-like columns...")
+	cols, err := typemap.DetectBooleans(context.Background(), reader, w.schema, w.mapping.SourceTables(), 0)
+	if err != nil {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("Boolean column detection failed: %v", err)))
+		return nil
+	}
+	for i := range cols {
+		cols[i].Accepted = w.mapping.ConvertsBoolean(cols[i])
+	}
+	return cols
+}
+
+// saveBooleanConversions applies the accepted and rejected boolean
+// conversions to the mapping, saving it when any changed.
+func (w *Wizard) saveBooleanConversions(cols []typemap.BooleanColumn) error {
+	changed := 0
+	for _, b := range cols {
+		changed += w.mapping.SetBooleanConversion(b, b.Accepted)
+	}
+	if changed == 0 {
+		return nil
+	}
+	if err := w.mapping.WriteYAML(w.state.MappingPath); err != nil {
+		return fmt.Errorf("saving mapping: %w", err)
+	}
+	for _, b := range cols {
+		if b.Accepted {
+			fmt.Printf("Converting %s.%s to Boolean\n", b.Table, b.Column)
+		}
+	}
+	return nil
+}
 
+func (w *Wizard) completeTypeMapping(result *typemap.TypeMap) error {
 	w.typeMap = result
 
 	// Save type mapping to disk
@@ -507,12 +766,17 @@ func (w *Wizard) runTypeMapping() error {
 
 	fmt.Printf("\nType mapping saved.\n")
 	fmt.Println("Run `reloquent generate` to create the PySpark migration script.")
-	return nil
+	return w.record(state.StepTypeMapping, func(s *Session) {
+		s.TypeMap = result
+	})
 }
 
 // RunTypeMapStandalone runs only the type mapping review step.
 // Used by the `reloquent config type-mapping` subcommand.
 func RunTypeMapStandalone(statePath string) error {
+	if statePath == "" {
+		statePath = state.Active().StatePath()
+	}
 	st, err := state.Load(statePath)
 	if err != nil {
 		return fmt.Errorf("loading state: %w", err)
@@ -571,17 +835,46 @@ func RunTypeMapStandalone(statePath string) error {
 	}
 
 	fmt.Println("Type mapping saved.")
-	return nil
+	return recordSession(statePath, state.StepTypeMapping, func(s *Session) {
+		s.TypeMap = result
+	})
 }
 
 func (w *Wizard) runSizing() error {
-	// Load schema for data size calculation
-	if w.schema == nil && w.state.SchemaPath != "" {
-		s, err := schema.LoadYAML(w.state.SchemaPath)
-		if err != nil {
-			return fmt.Errorf("loading schema: %w", err)
+	plan, err := w.calculateSizing()
+	if err != nil {
+		return err
+	}
+
+	m := NewSizingModel(plan)
+	p := tea.NewProgram(m, tea.WithAltScreen())
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return fmt.Errorf("running sizing step: %w", err)
+	}
+
+	sm := finalModel.(SizingModel)
+	if sm.Cancelled() {
+		return fmt.Errorf("cancelled")
+	}
+	plan = sm.Plan()
+
+	var keys map[string]*sizing.ShardKeyCandidate
+	if plan.ShardPlan != nil && plan.ShardPlan.Recommended {
+		if keys, err = w.runShardAdvisor(plan.ShardPlan); err != nil {
+			return err
 		}
-		w.schema = s
+	}
+	return w.completeSizing(plan, keys)
+}
+
+// calculateSizing computes the sizing plan for the selected tables and the
+// mapping.
+func (w *Wizard) calculateSizing() (*sizing.SizingPlan, error) {
+	// Load schema for data size calculation and mapping for zone ranges
+	if err := w.ensureSchemaAndMapping(); err != nil {
+		return nil, err
 	}
 
 	// Compute sizing input from schema
@@ -597,33 +890,37 @@ func (w *Wizard) runSizing() error {
 		TotalRowCount:         totalRows,
 		DenormExpansionFactor: 1.4,
 		CollectionCount:       len(w.state.SelectedTables),
+		Collections:           sizing.ZoneInputs(w.mapping, w.schema),
+		Storage:               sizing.StorageInputs(w.mapping, w.filteredSchema(), w.state.EmbedCounts),
 	}
 	if w.benchResult != nil {
 		input.BenchmarkMBps = w.benchResult.ThroughputMBps
 	}
+	if w.state.WriteBenchmarkPath != "" {
+		if wb, err := benchmark.LoadWriteResult(w.state.WriteBenchmarkPath); err == nil {
+			input.TargetWriteMBps = wb.ThroughputMBps
+		}
+	}
 	if w.state.SourceConfig != nil {
 		input.MaxSourceConnections = w.state.SourceConfig.MaxConnections
 	}
 
 	plan := sizing.Calculate(input)
+	if plan.ShardPlan != nil {
+		if err := plan.ShardPlan.ValidateZones(); err != nil {
+			return nil, fmt.Errorf("invalid zone configuration: %w", err)
+		}
+	}
 	w.sizingPlan = plan
 	if plan.ShardPlan != nil {
 		w.shardingPlan = plan.ShardPlan
 	}
+	return plan, nil
+}
 
-	m := NewSizingModel(plan)
-	p := tea.NewProgram(m, tea.WithAltScreen())
-
-	finalModel, err := p.Run()
-	if err != nil {
-		return fmt.Errorf("running sizing step: %w", err)
-	}
-
-	sm := finalModel.(SizingModel)
-	if sm.Cancelled() {
-		return fmt.Errorf("cancelled")
-	}
-
+// completeSizing saves the sizing plan, with the shard keys chosen in the
+// advisor, and moves on to AWS setup.
+func (w *Wizard) completeSizing(plan *sizing.SizingPlan, keys map[string]*sizing.ShardKeyCandidate) error {
 	// Save sizing plan
 	stateDir := filepath.Dir(config.ExpandHome(w.statePath))
 	sizingPath := filepath.Join(stateDir, "sizing.yaml")
@@ -638,7 +935,35 @@ func (w *Wizard) runSizing() error {
 	}
 
 	fmt.Printf("\nSizing plan saved.\n")
-	return nil
+	return w.record(state.StepSizing, func(s *Session) {
+		s.ShardKeys = recordShardKeys(keys)
+	})
+}
+
+// runShardAdvisor samples the source to rank shard key candidates and lets
+// the user replace the plan's keys with them, returning the keys chosen. It
+// is skipped when the source cannot be reached.
+func (w *Wizard) runShardAdvisor(sp *sizing.ShardingPlan) (map[string]*sizing.ShardKeyCandidate, error) {
+	reader, err := w.buildSourceReader()
+	if err != nil {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("Shard key advisor unavailable: %v", err)))
+		return nil, nil
+	}
+	fmt.Println("Sampling candidate shard key columns...")
+	advice := sizing.AdviseShardKeys(context.Background(), reader, w.mapping, w.schema, 0)
+	reader.Close()
+
+	p := tea.NewProgram(NewShardAdvisorModel(advice), tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return nil, fmt.Errorf("running shard key advisor: %w", err)
+	}
+	keys := finalModel.(ShardAdvisorModel).Result()
+	for name, c := range keys {
+		sp.ApplyShardKey(name, c)
+		fmt.Printf("Shard key for %s: %s (%s)\n", name, c.Field, c.Strategy)
+	}
+	return keys, nil
 }
 
 func (w *Wizard) runAWSSetup() error {
@@ -655,7 +980,10 @@ func (w *Wizard) runAWSSetup() error {
 		return fmt.Errorf("cancelled")
 	}
 
-	result := am.Result()
+	return w.completeAWSSetup(am.Result())
+}
+
+func (w *Wizard) completeAWSSetup(result *AWSSetupResult) error {
 	fmt.Printf("\nAWS: region=%s, profile=%s, bucket=%s, platform=%s\n",
 		result.Region, result.Profile, result.S3Bucket, result.Platform)
 
@@ -664,13 +992,23 @@ func (w *Wizard) runAWSSetup() error {
 		return fmt.Errorf("saving state: %w", err)
 	}
 
-	return nil
+	return w.record(state.StepAWSSetup, func(s *Session) {
+		s.AWS = &SessionAWS{
+			Region:   result.Region,
+			Profile:  result.Profile,
+			S3Bucket: result.S3Bucket,
+			Platform: result.Platform,
+		}
+	})
 }
 
 func (w *Wizard) runPreMigration() error {
 	collections := w.state.SelectedTables
 
 	m := NewPreMigrationModel(collections)
+	if err := w.ensureSchemaAndMapping(); err == nil && w.mapping != nil {
+		m.SetValidationLevels(w.mapping.Collections)
+	}
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	finalModel, err := p.Run()
@@ -682,6 +1020,18 @@ func (w *Wizard) runPreMigration() error {
 	if pm.Cancelled() {
 		return fmt.Errorf("cancelled")
 	}
+	if levels := pm.ValidationLevels(); levels != nil {
+		for i := range w.mapping.Collections {
+			col := &w.mapping.Collections[i]
+			col.Validation = levels[col.Name]
+			if col.Validation == mapping.ValidationOff {
+				col.Validation = ""
+			}
+		}
+		if err := w.mapping.WriteYAML(w.state.MappingPath); err != nil {
+			return fmt.Errorf("saving mapping: %w", err)
+		}
+	}
 
 	w.state.CompleteStep(state.StepPreMigration, state.StepReview)
 	if err := w.state.Save(w.statePath); err != nil {
@@ -703,6 +1053,10 @@ func (w *Wizard) runReview() error {
 	}
 
 	m := NewReviewModel(w.sizingPlan, "")
+	if err := w.ensureSchemaAndMapping(); err == nil && w.schema != nil && w.mapping != nil {
+		m.SetUniqueReport(indexes.AnalyzeUnique(w.filteredSchema(), w.mapping, w.typeMap))
+		m.SetMappingIssues(mapping.Validate(w.filteredSchema(), w.mapping))
+	}
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	finalModel, err := p.Run()
@@ -728,10 +1082,19 @@ func (w *Wizard) runReview() error {
 }
 
 func (w *Wizard) runMigrate() error {
+	eng, err := w.sparkEngine()
+	if err != nil {
+		return err
+	}
 	m := NewMigrateModel()
+	if eng != nil {
+		m.SetPauser(eng)
+	}
 	p := tea.NewProgram(m, tea.WithAltScreen())
+	stop := w.startSparkMigration(p, eng)
 
 	finalModel, err := p.Run()
+	stop()
 	if err != nil {
 		return fmt.Errorf("running migration: %w", err)
 	}
@@ -740,6 +1103,12 @@ func (w *Wizard) runMigrate() error {
 	if mm.Cancelled() {
 		return fmt.Errorf("cancelled")
 	}
+	if mm.status != nil && mm.status.Phase == "failed" {
+		return fmt.Errorf("migration failed: %s", strings.Join(mm.status.Errors, "; "))
+	}
+	if mm.status != nil && mm.status.Phase == migration.PhaseWindowExpired {
+		return fmt.Errorf("%w: %s", migration.ErrWindowExpired, strings.Join(mm.status.Errors, "; "))
+	}
 
 	w.state.MigrationStatus = "completed"
 	w.state.CompleteStep(state.StepMigration, state.StepValidation)
@@ -751,6 +1120,76 @@ func (w *Wizard) runMigrate() error {
 	return nil
 }
 
+// sparkEngine returns an engine to run the migration on EMR, EMR
+// Serverless or Glue when the config selects one, or nil.
+func (w *Wizard) sparkEngine() (*engine.Engine, error) {
+	cfg, err := config.Load("")
+	if err != nil {
+		return nil, nil
+	}
+	switch cfg.AWS.Platform {
+	case "emr", "emr-serverless", "glue":
+	default:
+		return nil, nil
+	}
+	if err := w.ensureSchemaAndMapping(); err != nil {
+		return nil, err
+	}
+
+	eng := engine.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	eng.Schema = w.filteredSchema()
+	eng.SetMapping(w.mapping)
+	eng.State = w.state
+	return eng, nil
+}
+
+// previewTimeout bounds how long the designer's document preview reads.
+const previewTimeout = time.Minute
+
+// sourceDocumentPreview returns a document previewer for the
+// denormalization designer that reads the source with cfg, converting
+// values with the default type mapping of the schema's database.
+func sourceDocumentPreview(cfg config.SourceConfig, s *schema.Schema) DocumentPreviewFunc {
+	return func(m *mapping.Mapping, collection string) ([]json.RawMessage, error) {
+		eng := engine.New(&config.Config{Source: cfg}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		eng.Schema = s
+		eng.SetMapping(m)
+		ctx, cancel := context.WithTimeout(context.Background(), previewTimeout)
+		defer cancel()
+		p, err := eng.DocumentPreview(ctx, collection, 0)
+		if err != nil {
+			return nil, err
+		}
+		return p.Documents, nil
+	}
+}
+
+// startSparkMigration runs the migration with the Spark engine, if there is
+// one, streaming status to the program. The returned function cancels the
+// job if it is still running and waits for it to stop.
+func (w *Wizard) startSparkMigration(p *tea.Program, eng *engine.Engine) func() {
+	if eng == nil {
+		return func() {}
+	}
+	resume := eng.CanResume()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		status, err := eng.MigrateSpark(ctx, resume, func(s *migration.Status) {
+			p.Send(MigrationStatusMsg(*s))
+		})
+		if err != nil && status == nil {
+			p.Send(MigrationStatusMsg(migration.Status{Phase: "failed", Errors: []string{err.Error()}}))
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
 func (w *Wizard) runValidation() error {
 	// Load schema and mapping if needed
 	if err := w.ensureSchemaAndMapping(); err != nil {
@@ -771,8 +1210,8 @@ func (w *Wizard) runValidation() error {
 	}
 	defer tgtOp.Close(context.Background())
 
-	// Infer index plan
-	w.indexPlan = indexes.Infer(w.filteredSchema(), w.mapping)
+	// Load the saved index plan or infer one
+	w.indexPlan = w.loadIndexPlan()
 
 	// Create orchestrator
 	orch := &postmigration.Orchestrator{
@@ -784,6 +1223,12 @@ func (w *Wizard) runValidation() error {
 		StatePath:  w.statePath,
 		IndexPlan:  w.indexPlan,
 		SampleSize: 100,
+		TypeMap:    w.typeMap,
+	}
+	if orch.TypeMap == nil && w.state.TypeMappingPath != "" {
+		if tm, err := typemap.LoadYAML(w.state.TypeMappingPath); err == nil {
+			orch.TypeMap = tm
+		}
 	}
 
 	// Create validation TUI model
@@ -802,19 +1247,26 @@ func (w *Wizard) runValidation() error {
 	}
 	vm.SetResult(result)
 	w.validationResult = result
+	diagnoses := validation.DiagnoseResult(result, w.mapping,
+		mapping.EstimateSizesMeasured(w.filteredSchema(), w.mapping, orch.TypeMap, w.state.EmbedCounts))
+	vm.SetDiagnoses(diagnoses)
 
 	// Show the validation TUI
-	p := tea.NewProgram(&vm, tea.WithAltScreen())
+	p := tea.NewProgram(vm, tea.WithAltScreen())
 	finalModel, err := p.Run()
 	if err != nil {
 		return fmt.Errorf("running validation UI: %w", err)
 	}
 
-	fm := finalModel.(*ValidationModel)
+	fm := finalModel.(ValidationModel)
 	if fm.Cancelled() {
 		return fmt.Errorf("cancelled")
 	}
 
+	if decisions := fm.Decisions(); len(decisions) > 0 {
+		return w.applyRemediations(tgtOp, diagnoses, decisions)
+	}
+
 	w.state.CompleteStep(state.StepValidation, state.StepIndexBuilds)
 	if err := w.state.Save(w.statePath); err != nil {
 		return fmt.Errorf("saving state: %w", err)
@@ -823,15 +1275,149 @@ func (w *Wizard) runValidation() error {
 	return nil
 }
 
+// applyRemediations records the decisions taken in the validation fix-it
+// view. Collections to re-migrate are emptied in the target, with the rest
+// of their consistency group, and the wizard goes back to the migration
+// step; otherwise it goes on to index builds.
+func (w *Wizard) applyRemediations(tgtOp target.Operator, diagnoses []validation.Diagnosis, decisions map[string]ValidationDecision) error {
+	var remigrate []string
+	for _, d := range diagnoses {
+		dec, ok := decisions[d.Collection]
+		if !ok {
+			continue
+		}
+		r := state.Remediation{Action: dec.Action, Justification: dec.Justification}
+		for _, c := range d.Causes {
+			r.Causes = append(r.Causes, c.Message)
+		}
+		w.state.SetRemediation(d.Collection, r)
+		switch dec.Action {
+		case validation.ActionRemigrate:
+			if g := w.mapping.GroupOf(d.Collection); g != nil {
+				remigrate = append(remigrate, g.Collections...)
+			} else {
+				remigrate = append(remigrate, d.Collection)
+			}
+		case validation.ActionAdjustMapping:
+			fmt.Printf("Change the mapping of %s with `reloquent design`, then re-migrate it with `reloquent remediate %s --action remigrate`.\n", d.Collection, d.Collection)
+		}
+	}
+
+	next := state.StepIndexBuilds
+	if len(remigrate) > 0 {
+		emptier, ok := tgtOp.(target.CollectionEmptier)
+		if !ok {
+			return fmt.Errorf("the target cannot empty collections; drop %s and migrate again", strings.Join(remigrate, ", "))
+		}
+		seen := make(map[string]bool)
+		var emptied []string
+		for _, c := range remigrate {
+			if seen[c] {
+				continue
+			}
+			seen[c] = true
+			if _, err := emptier.EmptyCollection(context.Background(), c); err != nil {
+				return fmt.Errorf("emptying %s: %w", c, err)
+			}
+			w.state.ClearCollection(c)
+			emptied = append(emptied, c)
+		}
+		next = state.StepMigration
+		fmt.Printf("Emptied %s; run the wizard again to migrate them and validate again.\n", strings.Join(emptied, ", "))
+	}
+
+	w.state.CompleteStep(state.StepValidation, next)
+	if err := w.state.Save(w.statePath); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+	return nil
+}
+
+// loadIndexPlan returns the index plan saved by the plan editor, falling
+// back to inferring one.
+func (w *Wizard) loadIndexPlan() *indexes.IndexPlan {
+	if w.state.IndexPlanPath != "" {
+		plan, err := indexes.LoadYAML(w.state.IndexPlanPath)
+		if err == nil {
+			return plan
+		}
+		fmt.Printf("Warning: ignoring saved index plan: %v\n", err)
+	}
+	return w.inferIndexPlan()
+}
+
+// inferIndexPlan infers indexes from the schema and mapping, adding those
+// suggested by the source query log when the config names one, and names
+// them by the config's index name template.
+func (w *Wizard) inferIndexPlan() *indexes.IndexPlan {
+	plan := indexes.Infer(w.filteredSchema(), w.mapping)
+	cfg, err := config.Load("")
+	if err != nil {
+		return plan
+	}
+	if cfg.Indexes.QueryLog != "" {
+		stats, err := indexes.LoadQueryStats(config.ExpandHome(cfg.Indexes.QueryLog))
+		if err != nil {
+			fmt.Printf("Warning: skipping query log: %v\n", err)
+		} else {
+			plan.AddQuerySuggestions(indexes.SuggestFromQueries(w.filteredSchema(), w.mapping, stats,
+				cfg.Indexes.MinCalls, cfg.Indexes.MaxSuggestions))
+		}
+	}
+	if cfg.Indexes.PartialUnique {
+		plan.AddUniqueConversions(indexes.AnalyzeUnique(w.filteredSchema(), w.mapping, w.typeMap))
+	}
+	if cfg.Indexes.AtlasSearch {
+		plan.AddSearchIndexes(w.filteredSchema(), w.mapping)
+	}
+	if err := indexes.NameConvention(cfg.Indexes.NameTemplate).Apply(plan); err != nil {
+		fmt.Printf("Warning: keeping inferred index names: %v\n", err)
+	}
+	return plan
+}
+
+// runIndexPlanEditor shows the index plan for editing. An edited plan is
+// saved next to the state file so later builds create exactly what was
+// approved.
+func (w *Wizard) runIndexPlanEditor() error {
+	p := tea.NewProgram(NewIndexPlanModel(w.indexPlan, w.mapping), tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return fmt.Errorf("running index plan editor: %w", err)
+	}
+	fm := finalModel.(IndexPlanModel)
+	plan := fm.Result()
+	if plan == nil || !fm.Changed() {
+		return nil
+	}
+
+	plan.Edited = true
+	path := filepath.Join(filepath.Dir(config.ExpandHome(w.statePath)), "index-plan.yaml")
+	if err := plan.WriteYAML(path); err != nil {
+		return fmt.Errorf("saving index plan: %w", err)
+	}
+	w.state.IndexPlanPath = path
+	if err := w.state.Save(w.statePath); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+	w.indexPlan = plan
+	fmt.Printf("Index plan saved: %s (%d indexes)\n", path, len(plan.Indexes))
+	return nil
+}
+
 func (w *Wizard) runIndexBuilds() error {
 	// Load schema and mapping if needed
 	if err := w.ensureSchemaAndMapping(); err != nil {
 		return err
 	}
 
-	// Infer index plan if not already done
+	// Load or infer the index plan if not already done, then let the user
+	// edit and approve it
 	if w.indexPlan == nil {
-		w.indexPlan = indexes.Infer(w.filteredSchema(), w.mapping)
+		w.indexPlan = w.loadIndexPlan()
+	}
+	if err := w.runIndexPlanEditor(); err != nil {
+		return err
 	}
 
 	// Build target operator
@@ -858,37 +1444,61 @@ func (w *Wizard) runIndexBuilds() error {
 		orch.Topology = topo
 	}
 
-	// Create index build TUI model
-	ibm := NewIndexBuildModel(len(w.indexPlan.Indexes))
-
-	cb := postmigration.Callbacks{
-		OnIndexProgress: func(statuses []target.IndexBuildStatus) {
-			ibm.UpdateProgress(statuses)
-		},
-	}
-
-	// Run index builds
-	if err := orch.RunIndexBuilds(context.Background(), cb); err != nil {
-		return fmt.Errorf("index builds: %w", err)
+	// Schedule the builds as configured
+	if cfg, err := config.Load(""); err == nil {
+		orch.IndexBuilds = engine.IndexBuildOptions(cfg.Indexes)
+		if cfg.Atlas.Configured() {
+			client, err := atlas.NewClient(cfg.Atlas, w.state.TargetConfig.Database)
+			if err != nil {
+				return err
+			}
+			orch.SearchIndexes = client
+		}
 	}
-	ibm.SetFinished()
+	control := postmigration.NewIndexBuildControl()
+	orch.IndexControl = control
 
-	// Run post-ops (balancer, write concern)
-	if err := orch.RunPostOps(context.Background()); err != nil {
-		return fmt.Errorf("post-migration ops: %w", err)
-	}
+	// Show the index build TUI while the builds run
+	ibm := NewIndexBuildModel(len(w.indexPlan.Indexes))
+	ibm.SetControl(control)
+	p := tea.NewProgram(ibm, tea.WithAltScreen())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	built := make(chan error, 1)
+	go func() {
+		cb := postmigration.Callbacks{
+			OnIndexProgress: func(statuses []target.IndexBuildStatus) {
+				p.Send(IndexProgressMsg(statuses))
+			},
+		}
+		err := orch.RunIndexBuilds(ctx, cb)
+		p.Send(IndexBuildsDoneMsg{Err: err})
+		built <- err
+	}()
 
-	// Show the index build TUI
-	p := tea.NewProgram(&ibm, tea.WithAltScreen())
 	finalModel, err := p.Run()
+	cancel()
+	buildErr := <-built
 	if err != nil {
 		return fmt.Errorf("running index build UI: %w", err)
 	}
 
-	fm := finalModel.(*IndexBuildModel)
+	fm := finalModel.(IndexBuildModel)
 	if fm.Cancelled() {
 		return fmt.Errorf("cancelled")
 	}
+	if buildErr != nil {
+		return fmt.Errorf("index builds: %w", buildErr)
+	}
+
+	// Run post-ops (balancer, write concern)
+	if err := orch.RunPostOps(context.Background()); err != nil {
+		return fmt.Errorf("post-migration ops: %w", err)
+	}
+
+	// Seed counters and document the ID generation strategies
+	w.seedCounters(orch)
 
 	// Check readiness and generate report
 	rpt, err := orch.CheckReadiness(context.Background())
@@ -905,14 +1515,130 @@ func (w *Wizard) runIndexBuilds() error {
 		return fmt.Errorf("running readiness UI: %w", err)
 	}
 
-	w.state.CompleteStep(state.StepIndexBuilds, state.StepComplete)
+	w.state.CompleteStep(state.StepIndexBuilds, state.StepCDC)
+	if err := w.state.Save(w.statePath); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+
+	return nil
+}
+
+// seedCounters runs the ID generation step of the post-migration
+// orchestrator, reading the highest ids from the source. Failures are
+// reported but do not stop the wizard; the readiness report flags them.
+func (w *Wizard) seedCounters(orch *postmigration.Orchestrator) {
+	chosen := false
+	for _, c := range postmigration.IDCandidates(orch.Schema, orch.Mapping) {
+		chosen = chosen || c.Strategy != ""
+	}
+	if !chosen {
+		return
+	}
+	reader, err := w.buildSourceReader()
+	if err != nil {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("Counters not seeded: %v", err)))
+		return
+	}
+	defer reader.Close()
+	orch.Source = reader
+
+	_, err = orch.RunIDGeneration(context.Background(), postmigration.Callbacks{
+		OnCounterSeeded: func(s postmigration.CounterSeed) {
+			if s.Error != "" {
+				fmt.Println(warnStyle.Render(fmt.Sprintf("Counter %s: %s", s.Collection, s.Error)))
+				return
+			}
+			fmt.Printf("Counter %s seeded at %d\n", s.Collection, s.Seq)
+		},
+	})
+	if err != nil {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("ID generation: %v", err)))
+		return
+	}
+	fmt.Printf("ID generation guide: %s\n", w.state.IDGenerationDocPath)
+}
+
+func (w *Wizard) runCDC() error {
+	if err := w.ensureSchemaAndMapping(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	eng, err := w.cdcEngine()
+	if err != nil {
+		return err
+	}
+
+	// Normally prepared before the bulk load; prepare now if that was skipped.
+	if w.state.CDCStartPosition == "" {
+		if _, err := eng.PrepareCDC(ctx); err != nil {
+			return fmt.Errorf("preparing CDC: %w", err)
+		}
+	}
+
+	cm := NewCDCModel(w.state.CDCStartPosition)
+	p := tea.NewProgram(cm, tea.WithAltScreen())
+	send := func(s cdc.Status) { p.Send(CDCStatusMsg(s)) }
+	if err := eng.StartCDC(ctx, send); err != nil {
+		return fmt.Errorf("starting CDC: %w", err)
+	}
+
+	finalModel, err := p.Run()
+	if err != nil {
+		_ = eng.StopCDC()
+		return fmt.Errorf("running CDC UI: %w", err)
+	}
+
+	fm := finalModel.(CDCModel)
+	if fm.Cancelled() || fm.Skipped() {
+		_ = eng.StopCDC()
+	} else if err := eng.FinishCDC(); err != nil {
+		// Whatever arrived between the last poll and cutover is applied
+		// before it stops
+		return fmt.Errorf("draining CDC: %w", err)
+	}
+	if fm.Cancelled() {
+		return fmt.Errorf("cancelled")
+	}
+	position := eng.CDCStatus().Position
+
+	if err := eng.TeardownCDC(ctx); err != nil {
+		return fmt.Errorf("tearing down CDC: %w", err)
+	}
+
+	w.state.CDCStatus = "skipped"
+	if !fm.Skipped() {
+		w.state.CDCStatus = "completed"
+	}
+	w.state.CompleteStep(state.StepCDC, state.StepComplete)
 	if err := w.state.Save(w.statePath); err != nil {
 		return fmt.Errorf("saving state: %w", err)
 	}
 
+	if !fm.Skipped() {
+		fmt.Printf("\nCDC complete at position %s. The application can now use MongoDB.\n", position)
+	}
 	return nil
 }
 
+// cdcEngine returns an engine that replicates changes with the wizard's
+// source and target settings, mapping and state.
+func (w *Wizard) cdcEngine() (*engine.Engine, error) {
+	if w.state.SourceConfig == nil {
+		return nil, fmt.Errorf("no source configuration; run source discovery first")
+	}
+	if w.state.TargetConfig == nil {
+		return nil, fmt.Errorf("no target configuration; run target setup first")
+	}
+	cfg := &config.Config{Source: *w.state.SourceConfig, Target: *w.state.TargetConfig}
+	eng := engine.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	eng.SetProject(&state.Project{Dir: filepath.Dir(w.statePath)})
+	eng.Schema = w.filteredSchema()
+	eng.SetMapping(w.mapping)
+	eng.State = w.state
+	return eng, nil
+}
+
 func (w *Wizard) ensureSchemaAndMapping() error {
 	if w.schema == nil && w.state.SchemaPath != "" {
 		s, err := schema.LoadYAML(w.state.SchemaPath)
@@ -927,6 +1653,9 @@ func (w *Wizard) ensureSchemaAndMapping() error {
 			return fmt.Errorf("loading mapping: %w", err)
 		}
 		w.mapping = m
+		if w.schema != nil {
+			warnStaleMapping(w.schema, m)
+		}
 	}
 	return nil
 }
@@ -935,25 +1664,9 @@ func (w *Wizard) buildSourceReader() (source.Reader, error) {
 	if w.state.SourceConfig == nil {
 		return nil, fmt.Errorf("no source configuration; run source discovery first")
 	}
-	sc := w.state.SourceConfig
-	var reader source.Reader
-
-	switch sc.Type {
-	case "postgresql":
-		connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/%s",
-			sc.Username, sc.Password, sc.Host, sc.Port, sc.Database)
-		if sc.SSL {
-			connStr += "?sslmode=require"
-		} else {
-			connStr += "?sslmode=disable"
-		}
-		reader = source.NewPostgresReader(connStr, sc.Schema)
-	case "oracle":
-		connStr := fmt.Sprintf("oracle://%s:%s@%s:%d/%s",
-			sc.Username, sc.Password, sc.Host, sc.Port, sc.Database)
-		reader = source.NewOracleReader(connStr, sc.Schema)
-	default:
-		return nil, fmt.Errorf("unsupported source type: %s", sc.Type)
+	reader, err := source.NewReader(*w.state.SourceConfig)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := reader.Connect(context.Background()); err != nil {
@@ -966,7 +1679,7 @@ func (w *Wizard) buildTargetOperator() (target.Operator, error) {
 	if w.state.TargetConfig == nil {
 		return nil, fmt.Errorf("no target configuration; run target setup first")
 	}
-	return target.NewMongoOperator(context.Background(),
+	return target.Open(context.Background(),
 		w.state.TargetConfig.ConnectionString,
 		w.state.TargetConfig.Database)
 }
@@ -1003,6 +1716,11 @@ func RunSizingStandalone(statePath string) (*sizing.SizingPlan, error) {
 	if st.SourceConfig != nil {
 		input.MaxSourceConnections = st.SourceConfig.MaxConnections
 	}
+	if st.WriteBenchmarkPath != "" {
+		if wb, err := benchmark.LoadWriteResult(st.WriteBenchmarkPath); err == nil {
+			input.TargetWriteMBps = wb.ThroughputMBps
+		}
+	}
 
 	return sizing.Calculate(input), nil
 }
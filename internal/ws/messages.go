@@ -8,9 +8,12 @@ type MessageType string
 const (
 	MsgStateChanged       MessageType = "state_changed"
 	MsgDiscoveryComplete  MessageType = "discovery_complete"
+	MsgDiscoveryProgress  MessageType = "discovery_progress"
 	MsgMigrationProgress  MessageType = "migration_progress"
 	MsgValidationCheck    MessageType = "validation_check"
+	MsgValidationChunk    MessageType = "validation_chunk"
 	MsgIndexProgress      MessageType = "index_progress"
+	MsgCDCLag             MessageType = "cdc_lag"
 	MsgError              MessageType = "error"
 	MsgSync               MessageType = "sync"
 	MsgFullState          MessageType = "full_state"
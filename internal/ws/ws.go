@@ -112,6 +112,24 @@ func (h *Hub) BroadcastValidationCheck(payload any) {
 	h.Broadcast(msg)
 }
 
+// BroadcastValidationChunk broadcasts checksum validation progress.
+func (h *Hub) BroadcastValidationChunk(payload any) {
+	msg, err := NewMessage(MsgValidationChunk, payload)
+	if err != nil {
+		return
+	}
+	h.Broadcast(msg)
+}
+
+// BroadcastDiscoveryProgress broadcasts schema discovery progress.
+func (h *Hub) BroadcastDiscoveryProgress(payload any) {
+	msg, err := NewMessage(MsgDiscoveryProgress, payload)
+	if err != nil {
+		return
+	}
+	h.Broadcast(msg)
+}
+
 // BroadcastIndexProgress broadcasts index build progress.
 func (h *Hub) BroadcastIndexProgress(payload any) {
 	msg, err := NewMessage(MsgIndexProgress, payload)
@@ -121,6 +139,15 @@ func (h *Hub) BroadcastIndexProgress(payload any) {
 	h.Broadcast(msg)
 }
 
+// BroadcastCDCLag broadcasts CDC replication status and lag.
+func (h *Hub) BroadcastCDCLag(payload any) {
+	msg, err := NewMessage(MsgCDCLag, payload)
+	if err != nil {
+		return
+	}
+	h.Broadcast(msg)
+}
+
 // BroadcastError broadcasts an error to all clients.
 func (h *Hub) BroadcastError(errMsg string) {
 	msg, err := NewMessage(MsgError, map[string]string{"message": errMsg})
@@ -178,8 +178,8 @@ func TestNewMessage_WithPayload(t *testing.T) {
 
 func TestNewMessage_AllTypes(t *testing.T) {
 	types := []MessageType{
-		MsgStateChanged, MsgDiscoveryComplete, MsgMigrationProgress,
-		MsgValidationCheck, MsgIndexProgress, MsgError, MsgSync, MsgFullState,
+		MsgStateChanged, MsgDiscoveryComplete, MsgDiscoveryProgress, MsgMigrationProgress,
+		MsgValidationCheck, MsgValidationChunk, MsgIndexProgress, MsgCDCLag, MsgError, MsgSync, MsgFullState,
 	}
 	for _, mt := range types {
 		data, err := NewMessage(mt, nil)
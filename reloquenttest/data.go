@@ -0,0 +1,212 @@
+package reloquenttest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/reloquent/reloquent/internal/migration"
+	"github.com/reloquent/reloquent/internal/typemap"
+)
+
+// sampleRows is the number of rows or documents the doubles return from
+// SampleRows and SampleDocuments.
+const sampleRows = 10
+
+// baseTime is the first generated date; each row is a day later.
+var baseTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Rows generates n deterministic rows for a table of the schema. Values
+// follow the column's BSON type in the default type map: primary keys and
+// integers count up from 1, foreign keys cycle through the referenced
+// table's keys, strings are "<column>-<n>", and every fourth value of a
+// nullable column is nil. It panics if the table is not in the schema.
+func Rows(s *Schema, table string, n int) []Row {
+	var t *Table
+	for i := range s.Tables {
+		if s.Tables[i].Name == table {
+			t = &s.Tables[i]
+		}
+	}
+	if t == nil {
+		panic(fmt.Sprintf("reloquenttest: unknown table %s", table))
+	}
+
+	// Foreign key columns cycle through the referenced table's keys.
+	parents := make(map[string]int64)
+	for _, fk := range t.ForeignKeys {
+		count := int64(n)
+		for _, rt := range s.Tables {
+			if rt.Name == fk.ReferencedTable && rt.RowCount > 0 {
+				count = rt.RowCount
+			}
+		}
+		for _, c := range fk.Columns {
+			parents[c] = count
+		}
+	}
+
+	tm := typemap.ForDatabase(s.DatabaseType)
+	rows := make([]Row, n)
+	for i := range rows {
+		row := make(Row, len(t.Columns))
+		for _, c := range t.Columns {
+			switch {
+			case parents[c.Name] > 0:
+				row[c.Name] = int64(i)%parents[c.Name] + 1
+			case c.Nullable && (i+1)%4 == 0:
+				row[c.Name] = nil
+			default:
+				row[c.Name] = value(tm.ResolveColumn(c), c.Name, i)
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// value generates the i-th value of a column stored as the given BSON type.
+func value(t typemap.BSONType, column string, i int) interface{} {
+	switch t {
+	case typemap.BSONNumberLong:
+		return int64(i + 1)
+	case typemap.BSONDecimal128, typemap.BSONDouble:
+		return float64(i+1) * 1.25
+	case typemap.BSONISODate:
+		return baseTime.AddDate(0, 0, i)
+	case typemap.BSONBoolean:
+		return i%2 == 0
+	case typemap.BSONBinData:
+		return []byte{byte(i)}
+	case typemap.BSONDocument:
+		return map[string]interface{}{"n": int64(i + 1)}
+	case typemap.BSONArray:
+		return []interface{}{int64(i + 1)}
+	case typemap.BSONGeoJSON:
+		return map[string]interface{}{"type": "Point", "coordinates": []interface{}{float64(i%360) - 180, float64(i%180) - 90}}
+	}
+	return fmt.Sprintf("%s-%d", column, i+1)
+}
+
+// NewSource returns a source double holding RowCount generated rows for
+// every table in the schema, with row counts, samples, sums of numeric
+// columns and distinct counts of every column to match. Keep row counts
+// small: all rows are held in memory.
+func NewSource(s *Schema) *MockReader {
+	m := &MockReader{
+		RowCounts:      make(map[string]int64),
+		Samples:        make(map[string][]map[string]interface{}),
+		Sums:           make(map[string]float64),
+		CountDistincts: make(map[string]int64),
+		TableRows:      make(map[string][]map[string]interface{}),
+	}
+	for _, t := range s.Tables {
+		rows := Rows(s, t.Name, int(t.RowCount))
+		m.RowCounts[t.Name] = int64(len(rows))
+		m.Samples[t.Name] = head(rows)
+		m.TableRows[t.Name] = rows
+		aggregate(rows, t.Name, m.Sums, m.CountDistincts)
+	}
+	return m
+}
+
+// NewTarget returns a target double holding the given documents by
+// collection, with document counts, samples, sums of numeric top-level
+// fields and distinct counts of every top-level field to match.
+func NewTarget(docs map[string][]Row) *MockOperator {
+	m := &MockOperator{}
+	setDocuments(m, docs)
+	return m
+}
+
+// Migrate runs reloquent's native data mover from src into a new target
+// double and returns it with the migrated documents loaded, as NewTarget
+// would. The source rows are copied first, so src is left as it was.
+func Migrate(ctx context.Context, src *MockReader, s *Schema, m *Mapping) (*MockOperator, error) {
+	rows := make(map[string][]map[string]interface{}, len(src.TableRows))
+	for table, rs := range src.TableRows {
+		copied := make([]map[string]interface{}, len(rs))
+		for i, r := range rs {
+			copied[i] = make(Row, len(r))
+			for k, v := range r {
+				copied[i][k] = v
+			}
+		}
+		rows[table] = copied
+	}
+
+	op := &MockOperator{}
+	exec := migration.NewNativeExecutor(&MockReader{TableRows: rows}, op, m, s)
+	if _, err := exec.Run(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	docs := make(map[string][]Row, len(op.InsertedDocs))
+	for collection, inserted := range op.InsertedDocs {
+		for _, d := range inserted {
+			doc, ok := d.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("unexpected %T document in %s", d, collection)
+			}
+			docs[collection] = append(docs[collection], doc)
+		}
+	}
+	setDocuments(op, docs)
+	return op, nil
+}
+
+func setDocuments(m *MockOperator, docs map[string][]Row) {
+	m.Documents = make(map[string][]map[string]interface{}, len(docs))
+	m.DocCounts = make(map[string]int64, len(docs))
+	m.SampleDocs = make(map[string][]map[string]interface{}, len(docs))
+	m.Sums = make(map[string]float64)
+	m.CountDistincts = make(map[string]int64)
+	for collection, ds := range docs {
+		m.Documents[collection] = ds
+		m.DocCounts[collection] = int64(len(ds))
+		m.SampleDocs[collection] = head(ds)
+		aggregate(ds, collection, m.Sums, m.CountDistincts)
+	}
+}
+
+func head(rows []Row) []Row {
+	if len(rows) > sampleRows {
+		return rows[:sampleRows]
+	}
+	return rows
+}
+
+// aggregate records the sum of each numeric field and the distinct count of
+// each scalar field, keyed "<name>.<field>" as the doubles expect.
+func aggregate(rows []Row, name string, sums map[string]float64, distincts map[string]int64) {
+	seen := make(map[string]map[string]bool)
+	for _, r := range rows {
+		for k, v := range r {
+			switch n := v.(type) {
+			case int64:
+				sums[name+"."+k] += float64(n)
+			case int32:
+				sums[name+"."+k] += float64(n)
+			case int:
+				sums[name+"."+k] += float64(n)
+			case float64:
+				sums[name+"."+k] += n
+			case bson.Decimal128:
+				f, _ := strconv.ParseFloat(n.String(), 64)
+				sums[name+"."+k] += f
+			case nil, map[string]interface{}, []interface{}, []map[string]interface{}:
+				continue
+			}
+			if seen[k] == nil {
+				seen[k] = make(map[string]bool)
+			}
+			seen[k][fmt.Sprintf("%T:%v", v, v)] = true
+		}
+	}
+	for k, vals := range seen {
+		distincts[name+"."+k] = int64(len(vals))
+	}
+}
@@ -0,0 +1,49 @@
+// Package reloquenttest provides test fixtures for code that extends
+// reloquent: builders for synthetic schemas and mappings, generated source
+// rows, and the same in-memory source and target doubles reloquent's own
+// tests use.
+//
+// The schema, mapping and test double types are aliases of reloquent's
+// internal types, so values built here can be passed straight to the engine
+// and to any package that accepts them.
+package reloquenttest
+
+import (
+	"github.com/reloquent/reloquent/internal/mapping"
+	"github.com/reloquent/reloquent/internal/schema"
+	"github.com/reloquent/reloquent/internal/source"
+	"github.com/reloquent/reloquent/internal/target"
+)
+
+// Schema types.
+type (
+	Schema     = schema.Schema
+	Table      = schema.Table
+	Column     = schema.Column
+	PrimaryKey = schema.PrimaryKey
+	ForeignKey = schema.ForeignKey
+)
+
+// Mapping types.
+type (
+	Mapping        = mapping.Mapping
+	Collection     = mapping.Collection
+	Embedded       = mapping.Embedded
+	Reference      = mapping.Reference
+	Transformation = mapping.Transformation
+	FieldMapping   = mapping.FieldMapping
+)
+
+// Source and target interfaces and their test doubles. MockReader serves
+// rows from its TableRows, Samples and RowCounts fields; MockOperator
+// records the collections, indexes and documents written to it and serves
+// reads from its Documents, SampleDocs and DocCounts fields.
+type (
+	Reader       = source.Reader
+	Operator     = target.Operator
+	MockReader   = source.MockReader
+	MockOperator = target.MockOperator
+)
+
+// Row is a source row or a target document, keyed by column or field name.
+type Row = map[string]interface{}
@@ -0,0 +1,167 @@
+package reloquenttest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/reloquent/reloquent/reloquenttest"
+)
+
+func TestSchemaBuilder(t *testing.T) {
+	b := reloquenttest.NewSchema("oracle").
+		Table("DEPT", 4, reloquenttest.Col("DEPTNO", "NUMBER")).
+		Table("EMP", 12,
+			reloquenttest.Col("EMPNO", "NUMBER"),
+			reloquenttest.NullCol("DEPTNO", "NUMBER")).
+		ForeignKey("EMP", "DEPTNO", "DEPT", "DEPTNO")
+	s := b.Build()
+
+	if s.DatabaseType != "oracle" || len(s.Tables) != 2 {
+		t.Fatalf("schema = %+v, want two oracle tables", s)
+	}
+	emp := s.Tables[1]
+	if emp.RowCount != 12 || emp.PrimaryKey == nil || emp.PrimaryKey.Columns[0] != "EMPNO" {
+		t.Errorf("EMP = %+v, want 12 rows keyed by EMPNO", emp)
+	}
+	if !emp.Columns[1].Nullable {
+		t.Error("DEPTNO should be nullable")
+	}
+	if len(emp.ForeignKeys) != 1 || emp.ForeignKeys[0].ReferencedTable != "DEPT" {
+		t.Errorf("foreign keys = %+v, want EMP.DEPTNO -> DEPT", emp.ForeignKeys)
+	}
+
+	// Built schemas are independent of the builder
+	b.ForeignKey("DEPT", "DEPTNO", "EMP", "EMPNO")
+	if len(s.Tables[0].ForeignKeys) != 0 {
+		t.Error("building more should not change an earlier schema")
+	}
+}
+
+func TestSchemaBuilder_UnknownTablePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a foreign key on an unknown table")
+		}
+	}()
+	reloquenttest.NewSchema("postgresql").ForeignKey("orders", "customer_id", "customers", "id")
+}
+
+func TestMappings(t *testing.T) {
+	s := reloquenttest.ShopSchema()
+
+	flat := reloquenttest.FlatMapping(s)
+	if len(flat.Collections) != 3 || flat.Collections[1].Name != "orders" {
+		t.Errorf("flat mapping = %+v, want one collection per table", flat.Collections)
+	}
+
+	suggested := reloquenttest.SuggestedMapping(s, "customers")
+	if len(suggested.Collections) != 1 || suggested.Collections[0].Name != "customers" {
+		t.Fatalf("suggested mapping = %+v, want a customers collection", suggested.Collections)
+	}
+	if emb := suggested.Collections[0].Embedded; len(emb) == 0 || emb[0].SourceTable != "orders" {
+		t.Errorf("embedded = %+v, want orders embedded in customers", suggested.Collections[0].Embedded)
+	}
+}
+
+func TestRows(t *testing.T) {
+	s := reloquenttest.ShopSchema()
+
+	customers := reloquenttest.Rows(s, "customers", 4)
+	tests := []struct {
+		row    int
+		column string
+		want   interface{}
+	}{
+		{0, "id", int64(1)},
+		{2, "name", "name-3"},
+		{0, "email", "email-1"},
+		{3, "email", nil},
+		{1, "created_at", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := customers[tt.row][tt.column]; got != tt.want {
+			t.Errorf("row %d %s = %v, want %v", tt.row, tt.column, got, tt.want)
+		}
+	}
+
+	// Foreign keys cycle through the 10 customers
+	orders := reloquenttest.Rows(s, "orders", 30)
+	if got := orders[12]["customer_id"]; got != int64(3) {
+		t.Errorf("orders[12].customer_id = %v, want 3", got)
+	}
+	if got := orders[1]["total"]; got != 2.5 {
+		t.Errorf("orders[1].total = %v, want 2.5", got)
+	}
+}
+
+func TestNewSource(t *testing.T) {
+	ctx := context.Background()
+	src := reloquenttest.NewSource(reloquenttest.ShopSchema())
+
+	var reader reloquenttest.Reader = src
+	n, err := reader.RowCount(ctx, "orders")
+	if err != nil || n != 30 {
+		t.Errorf("RowCount(orders) = %d, %v, want 30", n, err)
+	}
+	sample, _ := reader.SampleRows(ctx, "order_items", nil, 5)
+	if len(sample) != 10 {
+		t.Errorf("samples = %d, want 10", len(sample))
+	}
+	sum, _ := reader.AggregateSum(ctx, "customers", "id")
+	if sum != 55 {
+		t.Errorf("sum of customers.id = %v, want 55", sum)
+	}
+	distinct, _ := reader.AggregateCountDistinct(ctx, "orders", "customer_id")
+	if distinct != 10 {
+		t.Errorf("distinct orders.customer_id = %d, want 10", distinct)
+	}
+	lo, hi, _ := reader.KeyRange(ctx, "order_items", "id")
+	if lo != 1 || hi != 60 {
+		t.Errorf("KeyRange = %d..%d, want 1..60", lo, hi)
+	}
+}
+
+func TestNewTarget(t *testing.T) {
+	ctx := context.Background()
+	var op reloquenttest.Operator = reloquenttest.NewTarget(map[string][]reloquenttest.Row{
+		"users": {
+			{"_id": int64(1), "score": 2.5, "tags": []interface{}{"a"}},
+			{"_id": int64(2), "score": 4.0},
+		},
+	})
+
+	n, _ := op.CountDocuments(ctx, "users")
+	if n != 2 {
+		t.Errorf("CountDocuments = %d, want 2", n)
+	}
+	sum, _ := op.AggregateSum(ctx, "users", "score")
+	if sum != 6.5 {
+		t.Errorf("sum of score = %v, want 6.5", sum)
+	}
+	distinct, _ := op.AggregateCountDistinct(ctx, "users", "tags")
+	if distinct != 0 {
+		t.Errorf("distinct tags = %d, want arrays skipped", distinct)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	s := reloquenttest.ShopSchema()
+	src := reloquenttest.NewSource(s)
+	m := reloquenttest.SuggestedMapping(s, "customers")
+
+	op, err := reloquenttest.Migrate(context.Background(), src, s, m)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if op.DocCounts["customers"] != 10 {
+		t.Errorf("customers documents = %d, want 10", op.DocCounts["customers"])
+	}
+	orders, ok := op.Documents["customers"][0]["orders"].([]map[string]interface{})
+	if !ok || len(orders) != 3 {
+		t.Errorf("first customer's orders = %v, want 3 embedded orders", op.Documents["customers"][0]["orders"])
+	}
+	if _, ok := src.TableRows["customers"][0]["orders"]; ok {
+		t.Error("Migrate should not change the source rows")
+	}
+}
@@ -0,0 +1,115 @@
+package reloquenttest
+
+import (
+	"fmt"
+
+	"github.com/reloquent/reloquent/internal/mapping"
+)
+
+// Col returns a NOT NULL column.
+func Col(name, dataType string) Column {
+	return Column{Name: name, DataType: dataType}
+}
+
+// NullCol returns a nullable column.
+func NullCol(name, dataType string) Column {
+	return Column{Name: name, DataType: dataType, Nullable: true}
+}
+
+// SchemaBuilder assembles a synthetic source schema.
+type SchemaBuilder struct {
+	s Schema
+}
+
+// NewSchema starts a schema for a source database type ("postgresql" or
+// "oracle").
+func NewSchema(databaseType string) *SchemaBuilder {
+	return &SchemaBuilder{s: Schema{DatabaseType: databaseType, Database: "test"}}
+}
+
+// Table adds a table with the given row count. Its first column is the
+// primary key.
+func (b *SchemaBuilder) Table(name string, rows int64, columns ...Column) *SchemaBuilder {
+	t := Table{Name: name, Columns: columns, RowCount: rows}
+	if len(columns) > 0 {
+		t.PrimaryKey = &PrimaryKey{Name: name + "_pkey", Columns: []string{columns[0].Name}}
+	}
+	b.s.Tables = append(b.s.Tables, t)
+	return b
+}
+
+// ForeignKey adds a single-column foreign key. It panics if the table has
+// not been added.
+func (b *SchemaBuilder) ForeignKey(table, column, refTable, refColumn string) *SchemaBuilder {
+	for i := range b.s.Tables {
+		t := &b.s.Tables[i]
+		if t.Name != table {
+			continue
+		}
+		t.ForeignKeys = append(t.ForeignKeys, ForeignKey{
+			Name:              fmt.Sprintf("%s_%s_fkey", table, column),
+			Columns:           []string{column},
+			ReferencedTable:   refTable,
+			ReferencedColumns: []string{refColumn},
+		})
+		return b
+	}
+	panic(fmt.Sprintf("reloquenttest: foreign key on unknown table %s", table))
+}
+
+// Build returns the schema. Later changes to the builder do not affect it.
+func (b *SchemaBuilder) Build() *Schema {
+	s := b.s
+	s.Tables = make([]Table, len(b.s.Tables))
+	for i, t := range b.s.Tables {
+		t.Columns = append([]Column(nil), t.Columns...)
+		t.ForeignKeys = append([]ForeignKey(nil), t.ForeignKeys...)
+		s.Tables[i] = t
+	}
+	return &s
+}
+
+// ShopSchema returns a small PostgreSQL schema: 10 customers, 30 orders and
+// 60 order_items, linked by foreign keys.
+func ShopSchema() *Schema {
+	return NewSchema("postgresql").
+		Table("customers", 10,
+			Col("id", "integer"),
+			Col("name", "varchar"),
+			NullCol("email", "varchar"),
+			Col("created_at", "timestamp")).
+		Table("orders", 30,
+			Col("id", "integer"),
+			Col("customer_id", "integer"),
+			Col("total", "numeric"),
+			Col("placed_at", "timestamp")).
+		Table("order_items", 60,
+			Col("id", "integer"),
+			Col("order_id", "integer"),
+			Col("sku", "varchar"),
+			Col("quantity", "integer")).
+		ForeignKey("orders", "customer_id", "customers", "id").
+		ForeignKey("order_items", "order_id", "orders", "id").
+		Build()
+}
+
+// FlatMapping maps every table to a collection of the same name, with no
+// embedding.
+func FlatMapping(s *Schema) *Mapping {
+	m := &Mapping{}
+	for _, t := range s.Tables {
+		m.Collections = append(m.Collections, Collection{Name: t.Name, SourceTable: t.Name})
+	}
+	return m
+}
+
+// SuggestedMapping returns the mapping reloquent suggests for the whole
+// schema, embedding child tables along foreign keys. If roots are given,
+// only those tables become collections.
+func SuggestedMapping(s *Schema, roots ...string) *Mapping {
+	tables := make([]string, len(s.Tables))
+	for i, t := range s.Tables {
+		tables[i] = t.Name
+	}
+	return mapping.Suggest(s, tables, roots...)
+}
@@ -130,7 +130,7 @@ func TestAPIWizardFlow(t *testing.T) {
 	}
 
 	// Step 3: Discover schema
-	s, err := eng.Discover(t.Context())
+	s, err := eng.Discover(t.Context(), nil)
 	if err != nil {
 		t.Fatalf("discovery failed: %v", err)
 	}
@@ -237,7 +237,7 @@ func TestAPISourceEndpoints(t *testing.T) {
 
 	// Discover
 	eng.SetSourceConfig(&sourceCfg)
-	s, err := eng.Discover(t.Context())
+	s, err := eng.Discover(t.Context(), nil)
 	if err != nil {
 		t.Fatalf("discover failed: %v", err)
 	}
@@ -34,7 +34,7 @@ func TestDiscoverPagila(t *testing.T) {
 		t.Fatalf("connecting: %v", err)
 	}
 
-	s, err := d.Discover(ctx)
+	s, err := d.Discover(ctx, nil)
 	if err != nil {
 		t.Fatalf("discovering: %v", err)
 	}
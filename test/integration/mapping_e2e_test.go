@@ -35,7 +35,7 @@ func TestDiscoverAndSuggestMapping(t *testing.T) {
 		t.Fatalf("connecting: %v", err)
 	}
 
-	s, err := d.Discover(ctx)
+	s, err := d.Discover(ctx, nil)
 	if err != nil {
 		t.Fatalf("discovering: %v", err)
 	}
@@ -111,7 +111,7 @@ func TestMappingSizeEstimate(t *testing.T) {
 		t.Fatalf("connecting: %v", err)
 	}
 
-	s, err := d.Discover(ctx)
+	s, err := d.Discover(ctx, nil)
 	if err != nil {
 		t.Fatalf("discovering: %v", err)
 	}
@@ -122,7 +122,7 @@ func TestMappingSizeEstimate(t *testing.T) {
 	}
 
 	m := mapping.Suggest(s, tableNames)
-	estimates := mapping.EstimateSizes(s, m)
+	estimates := mapping.EstimateSizes(s, m, nil)
 
 	if len(estimates) == 0 {
 		t.Fatal("no size estimates returned")
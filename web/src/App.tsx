@@ -16,6 +16,7 @@ import Review from "./pages/Review";
 import Migration from "./pages/Migration";
 import Validation from "./pages/Validation";
 import IndexBuilds from "./pages/IndexBuilds";
+import CDC from "./pages/CDC";
 import Readiness from "./pages/Readiness";
 
 const queryClient = new QueryClient({
@@ -147,6 +148,14 @@ function App() {
                 </GuardedStep>
               }
             />
+            <Route
+              path="/cdc"
+              element={
+                <GuardedStep stepId="cdc">
+                  <CDC />
+                </GuardedStep>
+              }
+            />
             <Route
               path="/readiness"
               element={
@@ -10,15 +10,42 @@ export class ApiError extends Error {
   }
 }
 
+const PROJECT_KEY = "reloquent-project";
+
+// The project the UI works in; unset means the server's current project.
+export function getProject(): string | null {
+  return localStorage.getItem(PROJECT_KEY);
+}
+
+export function setProject(name: string | null) {
+  if (name) {
+    localStorage.setItem(PROJECT_KEY, name);
+  } else {
+    localStorage.removeItem(PROJECT_KEY);
+  }
+}
+
 async function request<T>(
   path: string,
   options?: RequestInit,
 ): Promise<T> {
+  const headers: Record<string, string> = { "Content-Type": "application/json" };
+  const project = getProject();
+  if (project) {
+    headers["X-Reloquent-Project"] = project;
+  }
   const res = await fetch(`${BASE_URL}${path}`, {
-    headers: { "Content-Type": "application/json" },
+    headers,
     ...options,
   });
 
+  if (res.status === 401) {
+    throw new ApiError(
+      401,
+      "Not signed in. Open the web UI link printed by `reloquent serve`.",
+    );
+  }
+
   if (!res.ok) {
     const body = await res.json().catch(() => ({ error: res.statusText }));
     throw new ApiError(res.status, body.error || res.statusText);
@@ -33,4 +60,5 @@ export const api = {
     request<T>(path, { method: "POST", body: JSON.stringify(body) }),
   put: <T>(path: string, body?: unknown) =>
     request<T>(path, { method: "PUT", body: JSON.stringify(body) }),
+  delete: <T>(path: string) => request<T>(path, { method: "DELETE" }),
 };
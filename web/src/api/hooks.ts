@@ -1,18 +1,54 @@
-import { useQuery, useMutation, useQueryClient } from "@tanstack/react-query";
+import {
+  keepPreviousData,
+  useQuery,
+  useMutation,
+  useQueryClient,
+} from "@tanstack/react-query";
 import { useNavigate } from "react-router-dom";
 import { useCallback } from "react";
-import { api } from "./client";
+import { api, setProject } from "./client";
 import type {
   WizardState,
   ConnectionTestResult,
   Schema,
+  SchemaChanges,
+  SchemaSnapshot,
   TableInfo,
   Mapping,
+  FieldGroupSuggestion,
+  CollectionSizeEstimate,
+  FilterPreview,
   TypeMapEntry,
+  LOBColumn,
+  BooleanColumn,
+  TimestampColumn,
+  DecimalColumn,
+  MappingIssuesResponse,
   SizingPlan,
+  ShardAdvice,
+  SourceImpact,
+  UniqueConstraintReport,
+  IDCandidate,
+  CounterSeed,
+  CollectionValidator,
+  MigrationPlan,
   SourceConfig,
   TargetConfig,
   AWSConfig,
+  CDCStatus,
+  HookStatus,
+  Project,
+  ProjectList,
+  RetentionInfo,
+  AgeHistogram,
+  RetentionPolicy,
+  ValidationConfig,
+  ValidationChunkProgress,
+  DiscoveryProgress,
+  DataDictionary,
+  IndexPlan,
+  IndexBuildStatusResult,
+  RunThroughput,
 } from "./types";
 import { STEP_ROUTES } from "./types";
 
@@ -24,6 +60,34 @@ export function useWizardState() {
   });
 }
 
+export function useProjects() {
+  return useQuery<ProjectList>({
+    queryKey: ["projects"],
+    queryFn: () => api.get("/api/projects"),
+  });
+}
+
+export function useCreateProject() {
+  const qc = useQueryClient();
+  return useMutation<Project, Error, string>({
+    mutationFn: (name) => api.post("/api/projects", { name }),
+    onSuccess: () => qc.invalidateQueries({ queryKey: ["projects"] }),
+  });
+}
+
+// Switches the project this browser works in. Every cached query belongs to
+// the old project, so all of them are dropped.
+export function useSwitchProject() {
+  const qc = useQueryClient();
+  return useCallback(
+    (name: string | null) => {
+      setProject(name);
+      qc.clear();
+    },
+    [qc],
+  );
+}
+
 export function useSetStep() {
   const qc = useQueryClient();
   return useMutation({
@@ -62,13 +126,26 @@ export function useDiscoverSchema() {
   const qc = useQueryClient();
   return useMutation<Schema, Error, SourceConfig>({
     mutationFn: (cfg) => api.post("/api/source/discover", cfg),
+    onMutate: () => {
+      qc.removeQueries({ queryKey: ["discovery-progress"] });
+    },
     onSuccess: () => {
       qc.invalidateQueries({ queryKey: ["schema"] });
       qc.invalidateQueries({ queryKey: ["tables"] });
+      qc.invalidateQueries({ queryKey: ["schema-snapshots"] });
     },
   });
 }
 
+// Filled in by discovery_progress WebSocket messages while a discovery runs.
+export function useDiscoveryProgress() {
+  return useQuery<DiscoveryProgress | null>({
+    queryKey: ["discovery-progress"],
+    queryFn: () => null,
+    enabled: false,
+  });
+}
+
 export function useSchema() {
   return useQuery<Schema>({
     queryKey: ["schema"],
@@ -77,6 +154,185 @@ export function useSchema() {
   });
 }
 
+// Rediscovers the source, so it only runs when refetched explicitly.
+export function useSchemaDiff() {
+  return useQuery<SchemaChanges>({
+    queryKey: ["schemaDiff"],
+    queryFn: () => api.get("/api/source/schema/diff"),
+    enabled: false,
+    retry: false,
+  });
+}
+
+// Lists every snapshot, deleted ones included, so they can be restored.
+export function useSchemaSnapshots() {
+  return useQuery<SchemaSnapshot[]>({
+    queryKey: ["schema-snapshots"],
+    queryFn: () => api.get("/api/schema/snapshots?deleted=true"),
+  });
+}
+
+export function useRunThroughput(runId: number | null, running = false) {
+  return useQuery<RunThroughput>({
+    queryKey: ["run-throughput", runId],
+    queryFn: () => api.get(`/api/runs/${runId}/throughput`),
+    enabled: runId !== null,
+    refetchInterval: running ? 10000 : false,
+  });
+}
+
+export function useSchemaSnapshotDiff(from: string, to: string) {
+  return useQuery<SchemaChanges>({
+    queryKey: ["schema-snapshot-diff", from, to],
+    queryFn: () =>
+      api.get(
+        `/api/schema/snapshots/diff?from=${encodeURIComponent(from)}&to=${encodeURIComponent(to)}`,
+      ),
+    enabled: from !== "" && to !== "",
+    retry: false,
+  });
+}
+
+export type SchemaSnapshotAction = "pin" | "unpin" | "delete" | "restore";
+
+// Pins, unpins, deletes or restores a snapshot. Every action responds with
+// the new snapshot list; changing the baseline also changes the schema.
+export function useSchemaSnapshotAction() {
+  const qc = useQueryClient();
+  return useMutation<
+    SchemaSnapshot[],
+    Error,
+    { action: SchemaSnapshotAction; id?: string }
+  >({
+    mutationFn: ({ action, id }) => {
+      const path = `/api/schema/snapshots/${encodeURIComponent(id ?? "")}`;
+      switch (action) {
+        case "unpin":
+          return api.post("/api/schema/unpin", {});
+        case "delete":
+          return api.delete(path);
+        default:
+          return api.post(`${path}/${action}`, {});
+      }
+    },
+    onSuccess: (list) => {
+      qc.setQueryData(["schema-snapshots"], list);
+      qc.invalidateQueries({ queryKey: ["schema"] });
+      qc.invalidateQueries({ queryKey: ["tables"] });
+    },
+  });
+}
+
+export function useRunValidation() {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: (config?: ValidationConfig) =>
+      api.post("/api/validation/run", config ?? {}),
+    onSuccess: () => {
+      qc.removeQueries({ queryKey: ["validation-progress"] });
+      qc.invalidateQueries({ queryKey: ["validation-results"] });
+    },
+  });
+}
+
+// Filled in by validation_chunk WebSocket messages during checksum validation.
+export function useValidationProgress() {
+  return useQuery<ValidationChunkProgress | null>({
+    queryKey: ["validation-progress"],
+    queryFn: () => null,
+    enabled: false,
+  });
+}
+
+export function useRetention() {
+  return useQuery<RetentionInfo>({
+    queryKey: ["retention"],
+    queryFn: () => api.get("/api/retention"),
+    retry: false,
+  });
+}
+
+// Reads row ages from the source, so it only runs for a chosen table.
+export function useRetentionHistogram(table?: string, column?: string) {
+  return useQuery<AgeHistogram>({
+    queryKey: ["retentionHistogram", table, column],
+    queryFn: () =>
+      api.get(
+        `/api/retention/histogram?table=${encodeURIComponent(table!)}&column=${encodeURIComponent(column!)}`,
+      ),
+    enabled: !!table && !!column,
+    retry: false,
+  });
+}
+
+export function useSetRetentionPolicy() {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: (req: { collection: string; policy: RetentionPolicy | null }) =>
+      api.put("/api/retention/policy", req),
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["retention"] });
+      qc.invalidateQueries({ queryKey: ["mapping"] });
+    },
+  });
+}
+
+export function useIndexPlan() {
+  return useQuery<IndexPlan>({
+    queryKey: ["indexPlan"],
+    queryFn: () => api.get("/api/indexes/plan"),
+    retry: false,
+  });
+}
+
+export function useSaveIndexPlan() {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: (plan: IndexPlan) =>
+      api.put<IndexPlan>("/api/indexes/plan", plan),
+    onSuccess: (plan) => qc.setQueryData(["indexPlan"], plan),
+  });
+}
+
+// Discards the edits so the plan is inferred from the mapping again.
+export function useResetIndexPlan() {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: () => api.delete<IndexPlan>("/api/indexes/plan"),
+    onSuccess: (plan) => qc.setQueryData(["indexPlan"], plan),
+  });
+}
+
+export function useIndexBuildStatus() {
+  return useQuery<IndexBuildStatusResult>({
+    queryKey: ["index-status"],
+    queryFn: () => api.get("/api/indexes/status"),
+    refetchInterval: 2000,
+    retry: false,
+  });
+}
+
+function useIndexBuildAction(path: string) {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: () => api.post(path, {}),
+    onSuccess: () => qc.invalidateQueries({ queryKey: ["index-status"] }),
+  });
+}
+
+export function useBuildIndexes() {
+  return useIndexBuildAction("/api/indexes/build");
+}
+
+// Pausing stops further builds from starting; running builds finish.
+export function usePauseIndexBuilds() {
+  return useIndexBuildAction("/api/indexes/pause");
+}
+
+export function useResumeIndexBuilds() {
+  return useIndexBuildAction("/api/indexes/resume");
+}
+
 export function useTargetConfig() {
   return useQuery<TargetConfig>({
     queryKey: ["targetConfig"],
@@ -122,6 +378,14 @@ export function useMapping() {
   });
 }
 
+export function useMappingLint() {
+  return useQuery<MappingIssuesResponse>({
+    queryKey: ["mappingLint"],
+    queryFn: () => api.get("/api/mapping/lint"),
+    retry: false,
+  });
+}
+
 export function useMappingPreview(rootTables?: string[]) {
   const rootsParam = rootTables?.length ? `?roots=${rootTables.join(",")}` : "";
   return useQuery<Mapping>({
@@ -131,11 +395,40 @@ export function useMappingPreview(rootTables?: string[]) {
   });
 }
 
+export function useFieldGroupSuggestions(enabled: boolean) {
+  return useQuery<FieldGroupSuggestion[]>({
+    queryKey: ["fieldGroups"],
+    queryFn: () => api.get("/api/mapping/field-groups"),
+    enabled,
+    retry: false,
+  });
+}
+
+export function useSizeEstimates(enabled: boolean) {
+  return useQuery<CollectionSizeEstimate[]>({
+    queryKey: ["sizeEstimates"],
+    queryFn: () => api.get("/api/mapping/size-estimate"),
+    enabled,
+    retry: false,
+  });
+}
+
 export function useSaveMapping() {
   const qc = useQueryClient();
   return useMutation({
     mutationFn: (mapping: Mapping) => api.post("/api/mapping", mapping),
-    onSuccess: () => qc.invalidateQueries({ queryKey: ["mapping"] }),
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["mapping"] });
+      qc.invalidateQueries({ queryKey: ["mappingLint"] });
+      qc.invalidateQueries({ queryKey: ["fieldGroups"] });
+      qc.invalidateQueries({ queryKey: ["sizeEstimates"] });
+    },
+  });
+}
+
+export function usePreviewFilter() {
+  return useMutation<FilterPreview, Error, { table: string; filter: string }>({
+    mutationFn: (req) => api.post("/api/mapping/filter-preview", req),
   });
 }
 
@@ -156,10 +449,178 @@ export function useSaveTypeMap() {
   });
 }
 
-export function useSizing() {
+export function useLOBs() {
+  return useQuery<LOBColumn[]>({
+    queryKey: ["typemap-lobs"],
+    queryFn: () => api.get("/api/typemap/lobs"),
+    retry: false,
+  });
+}
+
+export function useSaveLOBs() {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: (lobs: LOBColumn[]) => api.post("/api/typemap/lobs", lobs),
+    onSuccess: () => qc.invalidateQueries({ queryKey: ["typemap-lobs"] }),
+  });
+}
+
+export function useBooleanColumns() {
+  return useQuery<BooleanColumn[]>({
+    queryKey: ["typemap-booleans"],
+    queryFn: () => api.get("/api/typemap/booleans"),
+    retry: false,
+  });
+}
+
+export function useSaveBooleanColumns() {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: (columns: BooleanColumn[]) =>
+      api.post("/api/typemap/booleans", columns),
+    onSuccess: () => qc.invalidateQueries({ queryKey: ["typemap-booleans"] }),
+  });
+}
+
+export function useTimestamps() {
+  return useQuery<TimestampColumn[]>({
+    queryKey: ["typemap-timestamps"],
+    queryFn: () => api.get("/api/typemap/timestamps"),
+    retry: false,
+  });
+}
+
+export function useSaveTimestamps() {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: (columns: TimestampColumn[]) =>
+      api.post("/api/typemap/timestamps", columns),
+    onSuccess: () => qc.invalidateQueries({ queryKey: ["typemap-timestamps"] }),
+  });
+}
+
+// Listed under the type map's key so saving type overrides refreshes it.
+export function useDecimals() {
+  return useQuery<DecimalColumn[]>({
+    queryKey: ["typemap", "decimals"],
+    queryFn: () => api.get("/api/typemap/decimals"),
+    retry: false,
+  });
+}
+
+// units previews the plan resized to that many workers or DPUs.
+export function useSizing(units?: number) {
   return useQuery<SizingPlan>({
-    queryKey: ["sizing"],
-    queryFn: () => api.get("/api/sizing"),
+    queryKey: ["sizing", units],
+    queryFn: () =>
+      api.get(units ? `/api/sizing?units=${units}` : "/api/sizing"),
+    placeholderData: keepPreviousData,
+    retry: false,
+  });
+}
+
+// Samples the source tables, so it only runs once requested.
+export function useShardAdvisor(enabled: boolean) {
+  return useQuery<{ collections: ShardAdvice[] }>({
+    queryKey: ["shardAdvisor"],
+    queryFn: () => api.get("/api/sizing/shard-advisor"),
+    enabled,
+    retry: false,
+  });
+}
+
+export function useSourceImpact() {
+  return useQuery<SourceImpact>({
+    queryKey: ["sourceImpact"],
+    queryFn: () => api.get("/api/source/impact"),
+    retry: false,
+  });
+}
+
+export function useUniqueConstraints() {
+  return useQuery<UniqueConstraintReport>({
+    queryKey: ["uniqueConstraints"],
+    queryFn: () => api.get("/api/unique-constraints"),
+    retry: false,
+  });
+}
+
+// Adds partial unique indexes for the convertible constraints to the index
+// plan.
+export function useApplyUniqueConversions() {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: () =>
+      api.post<{ added: number }>("/api/unique-constraints/apply"),
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["uniqueConstraints"] });
+      qc.invalidateQueries({ queryKey: ["indexPlan"] });
+    },
+  });
+}
+
+export function useIDGeneration() {
+  return useQuery<IDCandidate[]>({
+    queryKey: ["idGeneration"],
+    queryFn: () => api.get("/api/id-generation"),
+    retry: false,
+  });
+}
+
+// Sets a collection's ID generation strategy; an empty strategy clears it.
+export function useSetIDGeneration() {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: (req: { collection: string; strategy: string }) =>
+      api.put("/api/id-generation", req),
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["idGeneration"] });
+      qc.invalidateQueries({ queryKey: ["mapping"] });
+    },
+  });
+}
+
+export function useSeedCounters() {
+  return useMutation({
+    mutationFn: () => api.post<CounterSeed[]>("/api/id-generation/seed"),
+  });
+}
+
+export function useValidators() {
+  return useQuery<CollectionValidator[]>({
+    queryKey: ["validators"],
+    queryFn: () => api.get("/api/premigration/validators"),
+    retry: false,
+  });
+}
+
+// Sets how strictly pre-migration enforces a collection's validator.
+export function useSetValidationLevel() {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: (req: { collection: string; level: string }) =>
+      api.put("/api/premigration/validators", req),
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["validators"] });
+      qc.invalidateQueries({ queryKey: ["mapping"] });
+    },
+  });
+}
+
+export function useMigrationPlan() {
+  return useQuery<MigrationPlan>({
+    queryKey: ["plan"],
+    queryFn: () => api.get("/api/plan"),
+    retry: false,
+  });
+}
+
+// Samples documents from the target for example values.
+export function useDataDictionary(enabled = true) {
+  return useQuery<DataDictionary>({
+    queryKey: ["dictionary"],
+    queryFn: () => api.get("/api/dictionary?samples=5"),
+    enabled,
     retry: false,
   });
 }
@@ -169,3 +630,44 @@ export function useConfigureAWS() {
     mutationFn: (cfg: AWSConfig) => api.post("/api/aws/configure", cfg),
   });
 }
+
+export function useCDCStatus() {
+  return useQuery<CDCStatus>({
+    queryKey: ["cdc-status"],
+    queryFn: () => api.get("/api/cdc/status"),
+    refetchInterval: 5000,
+    retry: false,
+  });
+}
+
+export function useHooks() {
+  return useQuery<HookStatus[]>({
+    queryKey: ["hooks"],
+    queryFn: () => api.get("/api/hooks"),
+    refetchInterval: 5000,
+  });
+}
+
+function useCDCAction(path: string) {
+  const qc = useQueryClient();
+  return useMutation({
+    mutationFn: () => api.post(path),
+    onSuccess: () => qc.invalidateQueries({ queryKey: ["cdc-status"] }),
+  });
+}
+
+export function usePrepareCDC() {
+  return useCDCAction("/api/cdc/prepare");
+}
+
+export function useStartCDC() {
+  return useCDCAction("/api/cdc/start");
+}
+
+export function useStopCDC() {
+  return useCDCAction("/api/cdc/stop");
+}
+
+export function useTeardownCDC() {
+  return useCDCAction("/api/cdc/teardown");
+}
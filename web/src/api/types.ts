@@ -28,6 +28,7 @@ export const ALL_STEPS: StepInfo[] = [
   { id: "migration", label: "Migration", order: 10 },
   { id: "validation", label: "Validation", order: 11 },
   { id: "index_builds", label: "Index Builds", order: 12 },
+  { id: "cdc", label: "Change Data Capture", order: 13 },
 ];
 
 export interface SourceConfig {
@@ -71,6 +72,8 @@ export interface Column {
   data_type: string;
   nullable: boolean;
   max_length?: number;
+  srid?: number;
+  geometry_type?: string;
 }
 
 export interface Table {
@@ -97,15 +100,154 @@ export interface Schema {
   tables: Table[];
 }
 
+export interface ColumnChange {
+  name: string;
+  changes: string[];
+}
+
+export interface TableDiff {
+  name: string;
+  added_columns: string[] | null;
+  removed_columns: string[] | null;
+  changed_columns: ColumnChange[] | null;
+  primary_key_changed: boolean;
+}
+
+export interface StaleReference {
+  path: string;
+  table: string;
+  column?: string;
+  use: string;
+}
+
+export interface SchemaChanges {
+  diff: {
+    added_tables: string[];
+    removed_tables: string[];
+    changed_tables: TableDiff[];
+  };
+  stale_references: StaleReference[];
+}
+
+export interface SchemaSnapshot {
+  id: string;
+  created_at: string;
+  tables: number;
+  deleted_at?: string;
+  baseline: boolean;
+  pinned: boolean;
+}
+
 export interface Mapping {
   collections: Collection[];
+  views?: View[];
+  queries?: CanaryQuery[];
+  // What happens to columns whose names are not safe document keys.
+  field_names?: "rename" | "keep" | "fail";
+  max_depth?: number; // embedding depth before the mapping lint warns; 3 unless set
+  suggestions?: FieldGroupSuggestion[]; // proposed by the preview, never saved
+}
+
+// A problem the mapping lint found. Errors stop the mapping from being
+// saved or generated; warnings do not.
+export interface MappingIssue {
+  severity: "error" | "warning";
+  code:
+    | "missing_join"
+    | "unselected_table"
+    | "duplicate_field"
+    | "too_deep"
+    | "unindexed_join";
+  collection: string;
+  path?: string; // embedded or reference field, dotted from the collection
+  message: string;
+}
+
+export interface MappingIssuesResponse {
+  status?: string;
+  error?: string;
+  issues: MappingIssue[];
+}
+
+// A proposal to nest a table's columns sharing a prefix under a subdocument.
+export interface FieldGroupSuggestion {
+  collection: string;
+  embedded?: number[]; // index path to the embedded table; absent for the root
+  table: string;
+  prefix: string;
+  target: string;
+  columns: string[];
+}
+
+export interface CanaryQuery {
+  name: string;
+  collection: string;
+  filter?: string;
+  fields?: string[];
+  sort?: string;
+  limit?: number;
+}
+
+export interface QueryStats {
+  stages: string[];
+  index_name?: string;
+  collection_scan: boolean;
+  n_returned: number;
+  keys_examined: number;
+  docs_examined: number;
+  execution_ms: number;
+}
+
+export interface CanaryResult {
+  query: CanaryQuery;
+  stats?: QueryStats;
+  flags?: string[];
+  error?: string;
+}
+
+export interface CanaryStatus {
+  status: "not_started" | "passed" | "flagged";
+  queries: CanaryQuery[];
+  results?: CanaryResult[];
+  report_path?: string;
+}
+
+export interface View {
+  name: string;
+  source: string;
+  pipeline: string;
+  on?: string[];
+  when_matched?: "replace" | "keepExisting" | "merge" | "fail";
+  when_not_matched?: "insert" | "discard" | "fail";
 }
 
 export interface Collection {
   name: string;
   source_table: string;
+  filter?: string;
+  watermark?: string; // column for delta migrations
   embedded?: Embedded[];
   references?: Reference[];
+  fields?: FieldMapping[];
+  field_order?: string[]; // document paths written first, in this order
+  zones?: ZoneConfig;
+  storage?: StorageOptions;
+}
+
+export interface StorageOptions {
+  block_compressor?: "none" | "snappy" | "zlib" | "zstd";
+  config_string?: string;
+}
+
+export interface ZoneConfig {
+  field: string;
+  ranges: ZoneRange[];
+}
+
+export interface ZoneRange {
+  zone: string;
+  min: string | number;
+  max: string | number;
 }
 
 export interface Embedded {
@@ -114,7 +256,46 @@ export interface Embedded {
   relationship: string;
   join_column: string;
   parent_column: string;
+  join_columns?: string[]; // composite key, matched to parent_columns in order
+  parent_columns?: string[];
+  filter?: string;
   embedded?: Embedded[];
+  fields?: FieldMapping[];
+  field_order?: string[];
+  offload?: Offload;
+}
+
+// Offload stores an embedded field outside the parent document: in a GridFS
+// file, or as documents of a side collection.
+export interface Offload {
+  strategy: "gridfs" | "collection";
+  target?: string;
+}
+
+export interface CollectionSizeEstimate {
+  collection: string;
+  source_table: string;
+  avg_doc_size_bytes: number;
+  max_doc_size_bytes: number;
+  avg_row_count: number;
+  exceeds_limit: boolean;
+  warning?: string;
+  inline_lobs?: string[];
+  offload_candidates?: string[];
+}
+
+export interface FilterPreview {
+  table: string;
+  filter: string;
+  total_rows: number;
+  filtered_rows: number;
+}
+
+export interface FieldMapping {
+  column: string; // empty for a prefix group
+  prefix?: string; // groups the columns starting with it under target
+  target?: string;
+  exclude?: boolean;
 }
 
 export interface Reference {
@@ -122,14 +303,61 @@ export interface Reference {
   field_name: string;
   join_column: string;
   parent_column: string;
+  join_columns?: string[];
+  parent_columns?: string[];
 }
 
 export interface TypeMapEntry {
   source_type: string;
+  label?: string; // enum(…) for the enum type
   bson_type: string;
   overridden: boolean;
 }
 
+export type LOBStrategy = "inline" | "skip" | "gridfs" | "s3";
+
+// How a large object column (bytea, BLOB, CLOB) is migrated.
+export interface LOBColumn {
+  table: string;
+  column: string;
+  data_type?: string;
+  strategy: LOBStrategy;
+  location?: string; // GridFS bucket, or s3://bucket/prefix
+}
+
+export type TimezonePolicy = "utc" | "server" | "named";
+
+// The time zone the values of a timestamp column without one are read in.
+export interface TimestampColumn {
+  table: string;
+  column: string;
+  data_type?: string;
+  policy: TimezonePolicy;
+  zone?: string; // IANA name or offset, for the named policy
+}
+
+// A numeric column and the BSON type its precision and scale resolve to.
+export interface DecimalColumn {
+  table: string;
+  column: string;
+  data_type: string;
+  precision?: number;
+  scale?: number;
+  bson_type: string;
+  warning?: string; // why the type may not hold every value exactly
+}
+
+export interface BooleanColumn {
+  table: string;
+  column: string;
+  data_type: string;
+  numeric: boolean;
+  true_values: string[];
+  false_values: string[];
+  sampled: number;
+  accepted: boolean;
+}
+
 export interface SizingPlan {
   spark_plan: {
     platform: string;
@@ -148,7 +376,202 @@ export interface SizingPlan {
     production_ram_gb: number;
   };
   estimated_time: string;
+  estimate?: RunEstimate;
   explanations: { category: string; summary: string; detail: string }[];
+  derivation?: SizingDerivation;
+}
+
+// Units are EMR workers or Glue DPUs; the duration is in nanoseconds.
+export interface RunEstimate {
+  data_bytes: number;
+  throughput_mbps: number;
+  recommended_units: number;
+  units: number;
+  duration: number;
+  compute_hours: number;
+  compute_cost: number;
+  s3_cost: number;
+  transfer_cost: number;
+  total_cost: number;
+}
+
+// Durations are in nanoseconds.
+export interface SourceImpactTable {
+  table: string;
+  collection: string;
+  embedded?: boolean;
+  rows: number;
+  size_bytes: number;
+  connections: number;
+  duration: number;
+  cache_impact: "low" | "moderate" | "high";
+}
+
+export interface SourceImpactReport {
+  generated_at: string;
+  source_type: string;
+  platform: string;
+  connections: number;
+  read_mbps: number;
+  throughput_source: "benchmark" | "default";
+  total_rows: number;
+  total_bytes: number;
+  estimated_duration: number;
+  tables: SourceImpactTable[] | null;
+  notes?: string[];
+  actual?: {
+    phase: string;
+    started_at: string;
+    duration: number;
+    read_mbps: number;
+    collections: {
+      name: string;
+      state: string;
+      documents: number;
+      duration?: number;
+    }[];
+  };
+}
+
+export interface SourceImpact {
+  estimate: SourceImpactReport;
+  last_run?: SourceImpactReport;
+}
+
+// How a source primary key or unique index carries over to MongoDB: kept by
+// a unique index or _id, kept by a partial unique index, or not enforceable.
+export interface UniqueConstraint {
+  collection: string;
+  table: string;
+  name: string;
+  columns: string[];
+  status: "preserved" | "convertible" | "lost";
+  reason: string;
+  index?: IndexDefinition;
+}
+
+export interface UniqueConstraintReport {
+  constraints: UniqueConstraint[];
+  preserved: number;
+  convertible: number;
+  lost: number;
+  unenforced: UniqueConstraint[];
+}
+
+// A collection whose primary key came from a sequence or identity column,
+// and how the application makes new ids after cutover.
+export interface IDCandidate {
+  collection: string;
+  table: string;
+  column: string;
+  field: string;
+  strategy?: "counter" | "objectid";
+}
+
+export interface CounterSeed {
+  collection: string;
+  max_id: number;
+  seq: number;
+  error?: string;
+}
+
+export type ValidationLevel = "off" | "moderate" | "strict";
+
+export interface CollectionValidator {
+  collection: string;
+  level: ValidationLevel;
+  validator: Record<string, unknown>;
+}
+
+export interface ShardKeyCandidate {
+  column: string;
+  field: string;
+  row_count: number;
+  distinct: number;
+  sample_size: number;
+  top_share: number;
+  sequential: boolean;
+  strategy: "hashed" | "ranged";
+  score: number;
+  reason: string;
+}
+
+export interface ShardAdvice {
+  collection_name: string;
+  source_table: string;
+  row_count: number;
+  candidates: ShardKeyCandidate[] | null;
+  error?: string;
+}
+
+export interface SizingDerivation {
+  inputs: SizingValue[];
+  steps: SizingValue[];
+  assumptions: string[];
+  safety_margins: { name: string; margin: string; reason: string }[];
+}
+
+export interface SizingValue {
+  name: string;
+  value: string;
+  source?: string;
+  formula?: string;
+}
+
+export interface MigrationPlan {
+  version: number;
+  generated_at: string;
+  source: {
+    type: string;
+    host: string;
+    database: string;
+    schema?: string;
+    jdbc_url?: string;
+  };
+  target: { database: string };
+  collections: PlanCollection[];
+  sizing?: SizingPlan;
+  totals: {
+    collections: number;
+    documents: number;
+    estimated_bytes: number;
+    indexes: number;
+  };
+  warnings?: string[];
+}
+
+export interface PlanCollection {
+  name: string;
+  source_table: string;
+  embedded_tables?: string[];
+  reads: SourceRead[];
+  fields: PlanField[];
+  shard_key?: Record<string, string>;
+  indexes?: {
+    name: string;
+    keys: { field: string; order: number }[];
+    unique: boolean;
+  }[];
+  storage?: StorageOptions;
+  estimated_documents: number;
+  avg_doc_size_bytes: number;
+  max_doc_size_bytes: number;
+  estimated_bytes: number;
+}
+
+export interface SourceRead {
+  collection: string;
+  table: string;
+  partition_column: string;
+  num_partitions: number;
+  sql: string;
+}
+
+export interface PlanField {
+  column: string;
+  target: string;
+  source_type: string;
+  bson_type: string;
 }
 
 export interface AWSConfig {
@@ -158,6 +581,217 @@ export interface AWSConfig {
   platform: string;
 }
 
+export interface CDCStatus {
+  state: string;
+  position?: string;
+  applied: number;
+  skipped: number;
+  lag_bytes: number;
+  lag_seconds: number;
+  started_at?: string;
+  last_error?: string;
+}
+
+export interface HookRun {
+  status: "running" | "complete" | "failed";
+  started_at: string;
+  completed_at?: string;
+  message?: string;
+  outputs?: Record<string, string>;
+}
+
+export interface HookStatus {
+  name: string;
+  when: string;
+  command: string[];
+  last_run?: HookRun;
+}
+
+export interface ValidationConfig {
+  mode?: "full" | "checksum";
+  chunk_size?: number;
+  concurrency?: number;
+  read_preference?: "primary" | "primaryPreferred" | "secondary" | "secondaryPreferred" | "nearest";
+  read_concern?: "local" | "majority" | "linearizable";
+  parallelism?: number;
+  time_budget?: string;
+  priority?: string[];
+}
+
+export interface DiscoveryProgress {
+  phase: string;
+  phase_index: number;
+  phase_count: number;
+  table?: string;
+  tables_done: number;
+  tables_total: number;
+  percent: number;
+}
+
+export interface ValidationChunkProgress {
+  collection: string;
+  chunk: number;
+  chunks: number;
+  from: number;
+  to: number;
+  source_rows: number;
+  target_rows: number;
+  match: boolean;
+}
+
+export interface RetentionPolicy {
+  column: string;
+  expire_after_days?: number;
+  archive_after_days?: number;
+}
+
+export interface RetentionCandidate {
+  collection: string;
+  table: string;
+  column: string;
+  reason: string;
+  policy?: RetentionPolicy;
+}
+
+export interface OnlineArchiveRule {
+  dbName: string;
+  collName: string;
+  criteria: {
+    type: string;
+    dateField: string;
+    dateFormat: string;
+    expireAfterDays: number;
+  };
+  partitionFields: { fieldName: string; order: number }[];
+}
+
+export interface RetentionInfo {
+  candidates: RetentionCandidate[];
+  online_archive?: OnlineArchiveRule[];
+}
+
+export interface AgeBucket {
+  label: string;
+  min_days: number;
+  max_days?: number;
+  rows: number;
+}
+
+export interface AgeHistogram {
+  table: string;
+  column: string;
+  rows: number;
+  null_rows: number;
+  oldest_days: number;
+  buckets: AgeBucket[];
+}
+
+export interface DictionaryField {
+  path: string;
+  bson_type: string;
+  source: string;
+  source_type?: string;
+  nullable: boolean;
+  references?: string;
+  examples?: string[];
+}
+
+export interface DictionaryCollection {
+  name: string;
+  source_table: string;
+  documents: number;
+  sampled: number;
+  fields: DictionaryField[];
+}
+
+export interface DataDictionary {
+  database: string;
+  generated_at: string;
+  collections: DictionaryCollection[];
+}
+
+// A key with type "text" is a text key and one with type "search" a field of
+// an Atlas Search index; a field ending in "$**" is a wildcard key.
+export interface IndexKey {
+  field: string;
+  order: number;
+  type?: "text" | "2dsphere" | "search";
+}
+
+export interface IndexDefinition {
+  keys: IndexKey[];
+  name: string;
+  unique: boolean;
+  expire_after_seconds?: number;
+  partial_filter?: Record<string, unknown>;
+  sparse?: boolean;
+  collation?: { locale: string; strength?: number };
+  analyzer?: string;
+}
+
+export interface CollectionIndex {
+  collection: string;
+  index: IndexDefinition;
+}
+
+// Indexes are built in plan order; an edited plan is built exactly as saved.
+export interface IndexPlan {
+  indexes: CollectionIndex[];
+  explanations: string[];
+  edited?: boolean;
+}
+
+export interface IndexBuildStatus {
+  collection: string;
+  index_name: string;
+  phase: "pending" | "building" | "complete" | "failed" | "skipped";
+  progress: number;
+  message?: string;
+}
+
+// Progress is the mean over the indexes, in percent.
+export interface IndexBuildStatusResult {
+  status: string;
+  progress: number;
+  paused: boolean;
+  concurrency?: number;
+  max_per_shard?: number;
+  indexes?: IndexBuildStatus[];
+}
+
+export interface Project {
+  name: string;
+  dir: string;
+}
+
+export interface ProjectList {
+  current: string;
+  projects: Project[];
+}
+
+export interface RunRecord {
+  id: number;
+  command: string;
+  status: "running" | "ok" | "failed";
+  started_at: string;
+  finished_at: string;
+  report?: string;
+}
+
+export interface ThroughputSample {
+  time: string;
+  collection: string;
+  docs_written: number;
+  bytes_written?: number;
+  rows_per_sec: number;
+  mb_per_sec: number;
+}
+
+export interface RunThroughput {
+  run: RunRecord;
+  samples: ThroughputSample[];
+}
+
 // Step ID → route path mapping
 export const STEP_ROUTES: Record<string, string> = {
   source_connection: "/source",
@@ -172,4 +806,6 @@ export const STEP_ROUTES: Record<string, string> = {
   migration: "/migration",
   validation: "/validation",
   index_builds: "/indexes",
+  cdc: "/cdc",
+  complete: "/readiness",
 };
@@ -26,15 +26,24 @@ export function useWebSocket() {
           queryClient.invalidateQueries({ queryKey: ["schema"] });
           queryClient.invalidateQueries({ queryKey: ["tables"] });
           break;
+        case "discovery_progress":
+          queryClient.setQueryData(["discovery-progress"], msg.payload);
+          break;
         case "migration_progress":
           queryClient.invalidateQueries({ queryKey: ["migration-status"] });
           break;
         case "validation_check":
           queryClient.invalidateQueries({ queryKey: ["validation-results"] });
           break;
+        case "validation_chunk":
+          queryClient.setQueryData(["validation-progress"], msg.payload);
+          break;
         case "index_progress":
           queryClient.invalidateQueries({ queryKey: ["index-status"] });
           break;
+        case "cdc_lag":
+          queryClient.setQueryData(["cdc-status"], msg.payload);
+          break;
       }
 
       // Browser notifications when tab is hidden
@@ -1,9 +1,12 @@
 export type WSMessageType =
   | "state_changed"
   | "discovery_complete"
+  | "discovery_progress"
   | "migration_progress"
   | "validation_check"
+  | "validation_chunk"
   | "index_progress"
+  | "cdc_lag"
   | "error"
   | "sync"
   | "full_state";
@@ -0,0 +1,65 @@
+import type { BooleanColumn } from "../api/types";
+
+interface BooleanColumnsProps {
+  columns: BooleanColumn[];
+  onChange: (column: BooleanColumn) => void;
+}
+
+// BooleanColumns lists columns whose sampled values are all flags, such as
+// CHAR(1) Y/N or NUMBER(1) 0/1, so each conversion to a BSON boolean can be
+// accepted or rejected.
+export function BooleanColumns({ columns, onChange }: BooleanColumnsProps) {
+  if (columns.length === 0) return null;
+
+  return (
+    <div className="mt-8">
+      <h3 className="text-lg font-semibold text-gray-900">Boolean-like Columns</h3>
+      <p className="mt-1 text-sm text-gray-600">
+        These columns only held flag values in a sample of the source. Accepted
+        columns are written as true or false instead of strings or numbers;
+        values read as neither become null.
+      </p>
+      <div className="mt-4 rounded-lg border border-gray-200 bg-white overflow-hidden">
+        <table className="w-full text-sm">
+          <thead>
+            <tr className="border-b border-gray-200 bg-gray-50">
+              <th className="px-4 py-3 text-left font-medium text-gray-700">Column</th>
+              <th className="px-4 py-3 text-left font-medium text-gray-700">Type</th>
+              <th className="px-4 py-3 text-left font-medium text-gray-700">True</th>
+              <th className="px-4 py-3 text-left font-medium text-gray-700">False</th>
+              <th className="px-4 py-3 text-left font-medium text-gray-700">Convert</th>
+            </tr>
+          </thead>
+          <tbody>
+            {columns.map((col) => (
+              <tr key={`${col.table}.${col.column}`} className="border-b border-gray-100">
+                <td className="px-4 py-2.5 font-mono text-gray-900">
+                  {col.table}.{col.column}
+                </td>
+                <td className="px-4 py-2.5 font-mono text-gray-500">{col.data_type}</td>
+                <td className="px-4 py-2.5 font-mono">{col.true_values.join(", ")}</td>
+                <td className="px-4 py-2.5 font-mono">{col.false_values.join(", ")}</td>
+                <td className="px-4 py-2.5">
+                  <div className="flex gap-2">
+                    <button
+                      onClick={() => onChange({ ...col, accepted: true })}
+                      className={`text-xs px-2 py-0.5 rounded-full ${col.accepted ? "bg-green-100 text-green-800" : "text-gray-500 hover:text-gray-800"}`}
+                    >
+                      Accept
+                    </button>
+                    <button
+                      onClick={() => onChange({ ...col, accepted: false })}
+                      className={`text-xs px-2 py-0.5 rounded-full ${!col.accepted ? "bg-gray-200 text-gray-800" : "text-gray-500 hover:text-gray-800"}`}
+                    >
+                      Reject
+                    </button>
+                  </div>
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </div>
+    </div>
+  );
+}
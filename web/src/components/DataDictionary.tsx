@@ -0,0 +1,69 @@
+import type { DictionaryCollection } from "../api/types";
+
+interface DataDictionaryTableProps {
+  collection: DictionaryCollection;
+}
+
+export function DataDictionaryTable({ collection }: DataDictionaryTableProps) {
+  return (
+    <div className="rounded-lg border border-gray-200 bg-white overflow-hidden">
+      <div className="px-4 py-3 border-b border-gray-200">
+        <h4 className="text-sm font-medium text-gray-900 font-mono">
+          {collection.name}
+        </h4>
+        <p className="text-xs text-gray-500">
+          Source table {collection.source_table},{" "}
+          {collection.documents.toLocaleString()} documents
+          {collection.sampled > 0 &&
+            `; examples from ${collection.sampled} sampled documents`}
+        </p>
+      </div>
+      <table className="w-full text-sm">
+        <thead>
+          <tr className="border-b border-gray-200 bg-gray-50">
+            <th className="px-4 py-2 text-left font-medium text-gray-700">
+              Field
+            </th>
+            <th className="px-4 py-2 text-left font-medium text-gray-700">
+              BSON Type
+            </th>
+            <th className="px-4 py-2 text-left font-medium text-gray-700">
+              Source
+            </th>
+            <th className="px-4 py-2 text-left font-medium text-gray-700 w-20">
+              Nullable
+            </th>
+            <th className="px-4 py-2 text-left font-medium text-gray-700">
+              Examples
+            </th>
+          </tr>
+        </thead>
+        <tbody>
+          {collection.fields.map((f) => (
+            <tr key={f.path} className="border-b border-gray-100">
+              <td className="px-4 py-2 font-mono text-gray-900">{f.path}</td>
+              <td className="px-4 py-2 text-gray-700">{f.bson_type}</td>
+              <td className="px-4 py-2 text-gray-700">
+                {f.source}
+                {f.source_type && (
+                  <span className="text-gray-500"> ({f.source_type})</span>
+                )}
+                {f.references && (
+                  <span className="block text-xs text-gray-500">
+                    references {f.references}
+                  </span>
+                )}
+              </td>
+              <td className="px-4 py-2 text-gray-700">
+                {f.nullable ? "yes" : "no"}
+              </td>
+              <td className="px-4 py-2 font-mono text-xs text-gray-600">
+                {f.examples?.join(", ")}
+              </td>
+            </tr>
+          ))}
+        </tbody>
+      </table>
+    </div>
+  );
+}
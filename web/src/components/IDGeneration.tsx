@@ -0,0 +1,99 @@
+import { Alert } from "./Alert";
+import { Button } from "./Button";
+import {
+  useIDGeneration,
+  useSetIDGeneration,
+  useSeedCounters,
+} from "../api/hooks";
+
+// IDGenerationCard lets the user choose, per collection whose source key came
+// from a sequence, whether new ids come from a seeded counter or an ObjectId,
+// and seeds the counters.
+export function IDGenerationCard() {
+  const { data, error } = useIDGeneration();
+  const setStrategy = useSetIDGeneration();
+  const seed = useSeedCounters();
+
+  if (error) return null;
+  if (!data || data.length === 0) return null;
+
+  const anyChosen = data.some((c) => c.strategy);
+  const mutationError = setStrategy.error ?? seed.error;
+
+  return (
+    <div className="mt-6 rounded-lg border border-gray-200 bg-white p-4">
+      <h3 className="text-sm font-medium text-gray-700 mb-3">ID Generation</h3>
+      <p className="text-sm text-gray-600">
+        These primary keys came from sequences. Choose how the application
+        makes new ids once MongoDB takes the writes.
+      </p>
+      <table className="mt-3 w-full text-sm">
+        <thead>
+          <tr className="text-left text-xs text-gray-500">
+            <th className="py-1">Collection</th>
+            <th>Key</th>
+            <th>New ids</th>
+          </tr>
+        </thead>
+        <tbody>
+          {data.map((c) => (
+            <tr key={c.collection} className="text-gray-700">
+              <td className="py-1 font-mono">{c.collection}</td>
+              <td className="font-mono">
+                {c.table}.{c.column}
+              </td>
+              <td>
+                <select
+                  value={c.strategy ?? ""}
+                  onChange={(e) =>
+                    setStrategy.mutate({
+                      collection: c.collection,
+                      strategy: e.target.value,
+                    })
+                  }
+                  className="rounded border border-gray-300 px-2 py-1 text-sm"
+                >
+                  <option value="">Undecided</option>
+                  <option value="counter">Counter (seeded with the highest id)</option>
+                  <option value="objectid">ObjectId _id</option>
+                </select>
+              </td>
+            </tr>
+          ))}
+        </tbody>
+      </table>
+
+      {mutationError && (
+        <div className="mt-3">
+          <Alert type="error">{mutationError.message}</Alert>
+        </div>
+      )}
+      {seed.data && (
+        <ul className="mt-3 text-sm">
+          {seed.data.map((s) => (
+            <li
+              key={s.collection}
+              className={s.error ? "text-red-700" : "text-green-700"}
+            >
+              {s.collection}:{" "}
+              {s.error
+                ? s.error
+                : `seeded at ${s.seq} (highest source id ${s.max_id})`}
+            </li>
+          ))}
+        </ul>
+      )}
+      {anyChosen && (
+        <div className="mt-3">
+          <Button
+            variant="secondary"
+            loading={seed.isPending}
+            onClick={() => seed.mutate()}
+          >
+            Seed counters and write the ID generation guide
+          </Button>
+        </div>
+      )}
+    </div>
+  );
+}
@@ -0,0 +1,227 @@
+import { useEffect, useState } from "react";
+import { Alert } from "./Alert";
+import { Button } from "./Button";
+import { useIndexPlan, useResetIndexPlan, useSaveIndexPlan } from "../api/hooks";
+import type { CollectionIndex, IndexKey } from "../api/types";
+
+const DEFAULT_TTL_SECONDS = 30 * 24 * 60 * 60;
+
+// parseKeys reads "field[:order], ..." with orders defaulting to ascending
+// and "text", "2dsphere" or "search" making a text, geospatial or Atlas
+// Search key.
+function parseKeys(text: string): IndexKey[] | null {
+  const keys: IndexKey[] = [];
+  for (const part of text.split(",")) {
+    const [field, order = "1"] = part.trim().split(":");
+    if (!field) return null;
+    if (order === "text" || order === "2dsphere" || order === "search") keys.push({ field, order: 0, type: order });
+    else if (order === "1" || order === "-1") keys.push({ field, order: Number(order) });
+    else return null;
+  }
+  return keys;
+}
+
+function keysLabel(keys: IndexKey[]): string {
+  return keys.map((k) => `${k.field}:${k.type ?? k.order}`).join(", ");
+}
+
+export function IndexPlanEditor() {
+  const { data: plan, error } = useIndexPlan();
+  const save = useSaveIndexPlan();
+  const reset = useResetIndexPlan();
+  const [rows, setRows] = useState<CollectionIndex[]>([]);
+  const [dirty, setDirty] = useState(false);
+  const [collection, setCollection] = useState("");
+  const [keysText, setKeysText] = useState("");
+  const [addError, setAddError] = useState("");
+
+  useEffect(() => {
+    if (plan) {
+      setRows(plan.indexes ?? []);
+      setDirty(false);
+    }
+  }, [plan]);
+
+  if (error) return <Alert type="error">{(error as Error).message}</Alert>;
+  if (!plan) return null;
+
+  const update = (next: CollectionIndex[]) => {
+    setRows(next);
+    setDirty(true);
+  };
+  const edit = (i: number, fn: (ci: CollectionIndex) => CollectionIndex) =>
+    update(rows.map((ci, j) => (j === i ? fn(ci) : ci)));
+  const move = (i: number, delta: number) => {
+    const j = i + delta;
+    if (j < 0 || j >= rows.length) return;
+    const next = [...rows];
+    [next[i], next[j]] = [next[j], next[i]];
+    update(next);
+  };
+  const add = () => {
+    const keys = parseKeys(keysText);
+    if (!collection || !keys) {
+      setAddError("Enter a collection and keys like customer_id,created_at:-1");
+      return;
+    }
+    const name = `idx_${collection}_${keys.map((k) => k.field.replaceAll(".", "_")).join("_")}`;
+    update([...rows, { collection, index: { name, keys, unique: false } }]);
+    setCollection("");
+    setKeysText("");
+    setAddError("");
+  };
+
+  return (
+    <div className="mt-6 rounded-lg border border-gray-200 bg-white p-4">
+      <div className="flex items-center justify-between">
+        <h3 className="text-lg font-semibold text-gray-900">Index Plan</h3>
+        {plan.edited && (
+          <span className="text-xs rounded-full bg-blue-100 px-2 py-0.5 text-blue-700">
+            edited
+          </span>
+        )}
+      </div>
+      <p className="mt-1 text-sm text-gray-600">
+        Indexes are built top to bottom. Once saved, the plan is built exactly
+        as shown.
+      </p>
+
+      <table className="mt-3 w-full text-sm">
+        <thead>
+          <tr className="text-left text-xs text-gray-500">
+            <th className="py-1">Collection</th>
+            <th>Keys</th>
+            <th className="text-center">Unique</th>
+            <th className="text-center">TTL</th>
+            <th className="text-center">Partial</th>
+            <th />
+          </tr>
+        </thead>
+        <tbody>
+          {rows.map((ci, i) => (
+            <tr key={`${ci.collection}-${ci.index.name}-${i}`} className="text-gray-700">
+              <td className="py-1">{ci.collection}</td>
+              <td className="font-mono" title={ci.index.name}>
+                {keysLabel(ci.index.keys)}
+                {ci.index.sparse && <span className="ml-2 text-xs text-gray-500">sparse</span>}
+                {ci.index.collation && (
+                  <span className="ml-2 text-xs text-gray-500">
+                    collation {ci.index.collation.locale}/{ci.index.collation.strength}
+                  </span>
+                )}
+              </td>
+              <td className="text-center">
+                <input
+                  type="checkbox"
+                  checked={ci.index.unique}
+                  onChange={() =>
+                    edit(i, (c) => ({ ...c, index: { ...c.index, unique: !c.index.unique } }))
+                  }
+                />
+              </td>
+              <td className="text-center">
+                <input
+                  type="checkbox"
+                  checked={!!ci.index.expire_after_seconds}
+                  disabled={
+                    ci.index.keys.length !== 1 ||
+                    !!ci.index.keys[0].type ||
+                    ci.index.keys[0].field.endsWith("$**")
+                  }
+                  title="Only single-field ordered indexes can expire documents"
+                  onChange={() =>
+                    edit(i, (c) => ({
+                      ...c,
+                      index: {
+                        ...c.index,
+                        expire_after_seconds: c.index.expire_after_seconds
+                          ? undefined
+                          : DEFAULT_TTL_SECONDS,
+                      },
+                    }))
+                  }
+                />
+              </td>
+              <td className="text-center">
+                <input
+                  type="checkbox"
+                  checked={!!ci.index.partial_filter}
+                  title="Only index documents that have the leading field"
+                  onChange={() =>
+                    edit(i, (c) => ({
+                      ...c,
+                      index: {
+                        ...c.index,
+                        partial_filter: c.index.partial_filter
+                          ? undefined
+                          : { [c.index.keys[0].field]: { $exists: true } },
+                        sparse: c.index.partial_filter ? c.index.sparse : false,
+                      },
+                    }))
+                  }
+                />
+              </td>
+              <td className="text-right whitespace-nowrap">
+                <button className="px-1 text-gray-500 hover:text-gray-900" onClick={() => move(i, -1)}>
+                  ↑
+                </button>
+                <button className="px-1 text-gray-500 hover:text-gray-900" onClick={() => move(i, 1)}>
+                  ↓
+                </button>
+                <button
+                  className="px-1 text-red-600 hover:text-red-800"
+                  onClick={() => update(rows.filter((_, j) => j !== i))}
+                >
+                  Remove
+                </button>
+              </td>
+            </tr>
+          ))}
+        </tbody>
+      </table>
+
+      <div className="mt-3 flex gap-2">
+        <input
+          className="w-40 rounded-md border border-gray-300 px-2 py-1 text-sm"
+          placeholder="collection"
+          value={collection}
+          onChange={(e) => setCollection(e.target.value)}
+        />
+        <input
+          className="flex-1 rounded-md border border-gray-300 px-2 py-1 text-sm font-mono"
+          placeholder="customer_id,created_at:-1 or title:text"
+          value={keysText}
+          onChange={(e) => setKeysText(e.target.value)}
+        />
+        <Button variant="secondary" onClick={add}>
+          Add
+        </Button>
+      </div>
+      {addError && <p className="mt-1 text-xs text-red-600">{addError}</p>}
+
+      {save.error && (
+        <div className="mt-3">
+          <Alert type="error">{(save.error as Error).message}</Alert>
+        </div>
+      )}
+
+      <div className="mt-4 flex gap-3">
+        <Button
+          disabled={!dirty}
+          loading={save.isPending}
+          onClick={() => save.mutate({ ...plan, indexes: rows })}
+        >
+          Save plan
+        </Button>
+        <Button
+          variant="secondary"
+          disabled={!plan.edited && !dirty}
+          loading={reset.isPending}
+          onClick={() => reset.mutate()}
+        >
+          Reset to inferred
+        </Button>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,88 @@
+import type { LOBColumn, LOBStrategy } from "../api/types";
+
+const strategies: { value: LOBStrategy; label: string }[] = [
+  { value: "inline", label: "Inline (BSON Binary)" },
+  { value: "skip", label: "Skip" },
+  { value: "gridfs", label: "Offload to GridFS" },
+  { value: "s3", label: "Offload to S3" },
+];
+
+const placeholders: Partial<Record<LOBStrategy, string>> = {
+  gridfs: "fs",
+  s3: "s3://bucket/prefix",
+};
+
+interface LOBStrategiesProps {
+  lobs: LOBColumn[];
+  onChange: (lob: LOBColumn) => void;
+}
+
+// LOBStrategies picks, per large object column, whether its values are
+// stored in the document, left out, or offloaded with a reference kept in
+// the document.
+export function LOBStrategies({ lobs, onChange }: LOBStrategiesProps) {
+  if (lobs.length === 0) return null;
+
+  return (
+    <div className="mt-8">
+      <h3 className="text-lg font-semibold text-gray-900">Large Objects</h3>
+      <p className="mt-1 text-sm text-gray-600">
+        A MongoDB document is limited to 16MB, so large binary and text values
+        may not fit inline. Offloaded values are written to GridFS or S3 and
+        the document keeps the file ID or s3:// URI.
+      </p>
+      <div className="mt-4 rounded-lg border border-gray-200 bg-white overflow-hidden">
+        <table className="w-full text-sm">
+          <thead>
+            <tr className="border-b border-gray-200 bg-gray-50">
+              <th className="px-4 py-3 text-left font-medium text-gray-700">Column</th>
+              <th className="px-4 py-3 text-left font-medium text-gray-700">Type</th>
+              <th className="px-4 py-3 text-left font-medium text-gray-700">Strategy</th>
+              <th className="px-4 py-3 text-left font-medium text-gray-700">Location</th>
+            </tr>
+          </thead>
+          <tbody>
+            {lobs.map((lob) => (
+              <tr key={`${lob.table}.${lob.column}`} className="border-b border-gray-100">
+                <td className="px-4 py-2.5 font-mono text-gray-900">
+                  {lob.table}.{lob.column}
+                </td>
+                <td className="px-4 py-2.5 font-mono text-gray-500">{lob.data_type}</td>
+                <td className="px-4 py-2.5">
+                  <select
+                    value={lob.strategy}
+                    onChange={(e) =>
+                      onChange({
+                        ...lob,
+                        strategy: e.target.value as LOBStrategy,
+                        location: undefined,
+                      })
+                    }
+                    className="rounded-md border border-gray-300 px-2 py-1 text-sm focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+                  >
+                    {strategies.map((s) => (
+                      <option key={s.value} value={s.value}>
+                        {s.label}
+                      </option>
+                    ))}
+                  </select>
+                </td>
+                <td className="px-4 py-2.5">
+                  {placeholders[lob.strategy] !== undefined && (
+                    <input
+                      type="text"
+                      value={lob.location ?? ""}
+                      placeholder={placeholders[lob.strategy]}
+                      onChange={(e) => onChange({ ...lob, location: e.target.value })}
+                      className="w-full rounded-md border border-gray-300 px-2 py-1 text-sm font-mono focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+                    />
+                  )}
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,108 @@
+import type { RunEstimate } from "../api/types";
+
+// formatDuration renders a Go duration in nanoseconds.
+function formatDuration(ns: number): string {
+  const secs = Math.round(ns / 1e9);
+  if (secs < 60) return `${secs}s`;
+  const mins = Math.floor(secs / 60);
+  if (mins < 60) return `${mins}m`;
+  const rest = mins % 60;
+  return rest ? `${Math.floor(mins / 60)}h ${rest}m` : `${mins / 60}h`;
+}
+
+interface RunEstimateCardProps {
+  estimate: RunEstimate;
+  unitName: string;
+  minUnits: number;
+  onUnitsChange: (units: number) => void;
+}
+
+// RunEstimateCard breaks down the duration and AWS cost of the run and lets
+// the cluster be resized to compare trade-offs.
+export function RunEstimateCard({
+  estimate,
+  unitName,
+  minUnits,
+  onUnitsChange,
+}: RunEstimateCardProps) {
+  const rows: [string, number][] = [
+    [
+      `Compute (${estimate.compute_hours.toFixed(1)} ${unitName.replace(/s$/, "")}-hours)`,
+      estimate.compute_cost,
+    ],
+    ["S3", estimate.s3_cost],
+    ["Data transfer", estimate.transfer_cost],
+  ];
+
+  return (
+    <div className="mt-4 rounded-lg border border-gray-200 bg-white p-4">
+      <div className="flex items-center justify-between">
+        <div>
+          <h3 className="text-sm font-medium text-gray-700">Run Estimate</h3>
+          <p className="text-xs text-gray-500">
+            Wall-clock time and AWS cost, including cluster startup.
+          </p>
+        </div>
+        <label className="flex items-center gap-2 text-sm text-gray-700">
+          {unitName}
+          <input
+            type="number"
+            min={minUnits}
+            value={estimate.units}
+            onChange={(e) => {
+              const n = parseInt(e.target.value, 10);
+              if (n >= minUnits) onUnitsChange(n);
+            }}
+            className="w-20 rounded border border-gray-300 px-2 py-1"
+          />
+        </label>
+      </div>
+
+      <div className="mt-3 grid grid-cols-2 gap-4">
+        <div>
+          <p className="text-sm text-gray-600">Duration</p>
+          <p className="mt-1 text-2xl font-bold text-gray-900">
+            {formatDuration(estimate.duration)}
+          </p>
+        </div>
+        <div>
+          <p className="text-sm text-gray-600">Total Cost</p>
+          <p className="mt-1 text-2xl font-bold text-gray-900">
+            ${estimate.total_cost.toFixed(2)}
+          </p>
+        </div>
+      </div>
+
+      <dl className="mt-3 space-y-1 text-sm">
+        {rows.map(([label, cost]) => (
+          <div key={label} className="flex justify-between">
+            <dt className="text-gray-500">{label}</dt>
+            <dd className="font-medium">${cost.toFixed(2)}</dd>
+          </div>
+        ))}
+      </dl>
+
+      {estimate.units !== estimate.recommended_units && (
+        <p className="mt-2 text-xs text-gray-500">
+          Recommended: {estimate.recommended_units} {unitName}.{" "}
+          <button
+            type="button"
+            className="text-blue-600 hover:underline"
+            onClick={() => onUnitsChange(estimate.recommended_units)}
+          >
+            Reset
+          </button>
+        </p>
+      )}
+    </div>
+  );
+}
+
+// RunEstimateSummary is the one-line duration and cost of the run.
+export function RunEstimateSummary({ estimate }: { estimate: RunEstimate }) {
+  return (
+    <>
+      {formatDuration(estimate.duration)}, ${estimate.total_cost.toFixed(2)}
+    </>
+  );
+}
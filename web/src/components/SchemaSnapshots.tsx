@@ -0,0 +1,172 @@
+import { useState } from "react";
+import { Alert } from "./Alert";
+import { Button } from "./Button";
+import {
+  useSchemaSnapshots,
+  useSchemaSnapshotDiff,
+  useSchemaSnapshotAction,
+} from "../api/hooks";
+import type { SchemaChanges, SchemaSnapshot } from "../api/types";
+
+function formatTime(iso: string): string {
+  return new Date(iso).toLocaleString();
+}
+
+function DiffSummary({ changes }: { changes: SchemaChanges }) {
+  const { added_tables, removed_tables, changed_tables } = changes.diff;
+  if (!added_tables.length && !removed_tables.length && !changed_tables.length) {
+    return <p className="text-sm text-gray-600">No schema changes.</p>;
+  }
+  return (
+    <ul className="list-disc pl-5 space-y-1 text-sm text-gray-700">
+      {added_tables.map((t) => (
+        <li key={`+${t}`}>Added table {t}</li>
+      ))}
+      {removed_tables.map((t) => (
+        <li key={`-${t}`}>Removed table {t}</li>
+      ))}
+      {changed_tables.map((t) => (
+        <li key={`~${t.name}`}>
+          Changed table {t.name}
+          {t.added_columns?.length ? `: added ${t.added_columns.join(", ")}` : ""}
+          {t.removed_columns?.length
+            ? `; removed ${t.removed_columns.join(", ")}`
+            : ""}
+          {t.changed_columns?.length
+            ? `; changed ${t.changed_columns.map((c) => c.name).join(", ")}`
+            : ""}
+          {t.primary_key_changed ? "; primary key changed" : ""}
+        </li>
+      ))}
+      {changes.stale_references.map((r) => (
+        <li key={`${r.path}-${r.table}-${r.column ?? ""}`} className="text-red-700">
+          Mapping {r.path}: {r.column ? `column ${r.table}.${r.column}` : `table ${r.table}`} ({r.use}) no longer exists
+        </li>
+      ))}
+    </ul>
+  );
+}
+
+function snapshotStatus(s: SchemaSnapshot): string {
+  if (s.pinned) return "baseline (pinned)";
+  if (s.baseline) return "baseline";
+  if (s.deleted_at) return `deleted ${formatTime(s.deleted_at)}`;
+  return "";
+}
+
+// SchemaSnapshotsCard lists every discovery of the source. Any snapshot can
+// be pinned as the design baseline, compared with another, or deleted and
+// restored.
+export function SchemaSnapshotsCard() {
+  const { data: snapshots, error } = useSchemaSnapshots();
+  const action = useSchemaSnapshotAction();
+  const [from, setFrom] = useState("");
+  const [to, setTo] = useState("");
+  const diff = useSchemaSnapshotDiff(from, to);
+
+  if (error) return <Alert type="warning">{error.message}</Alert>;
+  if (!snapshots || snapshots.length === 0) return null;
+
+  const pinned = snapshots.some((s) => s.pinned);
+
+  return (
+    <div className="rounded-lg border border-gray-200 bg-white p-4">
+      <div className="flex items-center justify-between mb-3">
+        <h3 className="text-sm font-medium text-gray-700">Schema Snapshots</h3>
+        {pinned && (
+          <Button
+            variant="secondary"
+            onClick={() => action.mutate({ action: "unpin" })}
+            loading={action.isPending}
+          >
+            Follow Latest Discovery
+          </Button>
+        )}
+      </div>
+      <table className="w-full text-sm">
+        <thead>
+          <tr className="text-left text-xs text-gray-500">
+            <th className="py-1">Compare</th>
+            <th>Discovered</th>
+            <th className="text-right">Tables</th>
+            <th className="pl-3">Status</th>
+            <th />
+          </tr>
+        </thead>
+        <tbody>
+          {snapshots.map((s) => (
+            <tr
+              key={s.id}
+              className={s.deleted_at ? "text-gray-400" : "text-gray-700"}
+            >
+              <td className="py-1 space-x-2">
+                <input
+                  type="radio"
+                  name="snapshot-from"
+                  aria-label={`compare from ${s.id}`}
+                  checked={from === s.id}
+                  onChange={() => setFrom(s.id)}
+                />
+                <input
+                  type="radio"
+                  name="snapshot-to"
+                  aria-label={`compare to ${s.id}`}
+                  checked={to === s.id}
+                  onChange={() => setTo(s.id)}
+                />
+              </td>
+              <td title={s.id}>{formatTime(s.created_at)}</td>
+              <td className="text-right">{s.tables}</td>
+              <td className="pl-3">{snapshotStatus(s)}</td>
+              <td className="text-right space-x-3">
+                {s.deleted_at ? (
+                  <button
+                    className="text-blue-600 hover:underline"
+                    onClick={() => action.mutate({ action: "restore", id: s.id })}
+                  >
+                    Restore
+                  </button>
+                ) : (
+                  <>
+                    {!s.pinned && (
+                      <button
+                        className="text-blue-600 hover:underline"
+                        onClick={() => action.mutate({ action: "pin", id: s.id })}
+                      >
+                        Pin
+                      </button>
+                    )}
+                    {!s.pinned && (
+                      <button
+                        className="text-red-600 hover:underline"
+                        onClick={() => action.mutate({ action: "delete", id: s.id })}
+                      >
+                        Delete
+                      </button>
+                    )}
+                  </>
+                )}
+              </td>
+            </tr>
+          ))}
+        </tbody>
+      </table>
+
+      {action.error && (
+        <div className="mt-3">
+          <Alert type="error">{action.error.message}</Alert>
+        </div>
+      )}
+
+      {from && to && (
+        <div className="mt-4 border-t border-gray-200 pt-3">
+          <h4 className="text-xs font-medium text-gray-500 mb-2">
+            Changes from {from} to {to}
+          </h4>
+          {diff.error && <Alert type="error">{diff.error.message}</Alert>}
+          {diff.data && <DiffSummary changes={diff.data} />}
+        </div>
+      )}
+    </div>
+  );
+}
@@ -9,6 +9,7 @@ const POINT_OF_NO_RETURN_STEPS = new Set([
   "migration",
   "validation",
   "index_builds",
+  "cdc",
 ]);
 
 function stepStatusIcon(status: string | undefined, isCurrent: boolean) {
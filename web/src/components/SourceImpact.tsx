@@ -0,0 +1,128 @@
+import { Alert } from "./Alert";
+import { useSourceImpact } from "../api/hooks";
+import type { SourceImpactReport } from "../api/types";
+
+function formatBytes(bytes: number): string {
+  if (bytes >= 1024 ** 4) return `${(bytes / 1024 ** 4).toFixed(1)} TB`;
+  if (bytes >= 1024 ** 3) return `${(bytes / 1024 ** 3).toFixed(1)} GB`;
+  if (bytes >= 1024 ** 2) return `${(bytes / 1024 ** 2).toFixed(1)} MB`;
+  if (bytes >= 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+  return `${bytes} B`;
+}
+
+// formatDuration renders a Go duration in nanoseconds.
+function formatDuration(ns: number): string {
+  const secs = Math.round(ns / 1e9);
+  if (secs < 60) return `${secs}s`;
+  const mins = Math.floor(secs / 60);
+  if (mins < 60) return `${mins}m`;
+  const rest = mins % 60;
+  return rest ? `${Math.floor(mins / 60)}h ${rest}m` : `${mins / 60}h`;
+}
+
+const cacheColors = {
+  low: "text-green-700",
+  moderate: "text-yellow-700",
+  high: "text-red-700",
+};
+
+function ImpactTable({ report }: { report: SourceImpactReport }) {
+  const actual = new Map(
+    (report.actual?.collections ?? []).map((c) => [c.name, c]),
+  );
+  return (
+    <table className="mt-3 w-full text-sm">
+      <thead>
+        <tr className="text-left text-xs text-gray-500">
+          <th className="py-1">Table</th>
+          <th>Collection</th>
+          <th className="text-right">Rows</th>
+          <th className="text-right">Size</th>
+          <th className="text-right">Conns</th>
+          <th className="text-right">Est.</th>
+          <th className="pl-3">Cache</th>
+          {report.actual && <th>Actual</th>}
+        </tr>
+      </thead>
+      <tbody>
+        {(report.tables ?? []).map((t) => {
+          const got = t.embedded ? undefined : actual.get(t.collection);
+          return (
+            <tr key={`${t.collection}-${t.table}`} className="text-gray-700">
+              <td className={`py-1 font-mono ${t.embedded ? "pl-4" : ""}`}>
+                {t.table}
+              </td>
+              <td>{t.collection}</td>
+              <td className="text-right">{t.rows.toLocaleString()}</td>
+              <td className="text-right">{formatBytes(t.size_bytes)}</td>
+              <td className="text-right">{t.connections}</td>
+              <td className="text-right">{formatDuration(t.duration)}</td>
+              <td className={`pl-3 ${cacheColors[t.cache_impact]}`}>
+                {t.cache_impact}
+              </td>
+              {report.actual && (
+                <td>
+                  {got
+                    ? got.duration
+                      ? formatDuration(got.duration)
+                      : got.state
+                    : ""}
+                </td>
+              )}
+            </tr>
+          );
+        })}
+      </tbody>
+    </table>
+  );
+}
+
+// SourceImpactCard shows the load the migration will place on the source,
+// and the last full run's estimate against what it actually did.
+export function SourceImpactCard() {
+  const { data, error } = useSourceImpact();
+
+  if (error) return <Alert type="warning">{error.message}</Alert>;
+  if (!data) return null;
+
+  const est = data.estimate;
+  const last = data.last_run;
+  return (
+    <div className="rounded-lg border border-gray-200 bg-white p-4">
+      <h3 className="text-sm font-medium text-gray-700 mb-3">Source Impact</h3>
+      <dl className="grid grid-cols-2 gap-x-4 gap-y-2 text-sm">
+        <dt className="text-gray-500">Connections</dt>
+        <dd className="font-medium">up to {est.connections}</dd>
+        <dt className="text-gray-500">Read Rate</dt>
+        <dd className="font-medium">
+          {est.read_mbps.toFixed(1)} MB/s ({est.throughput_source})
+        </dd>
+        <dt className="text-gray-500">Data Read</dt>
+        <dd className="font-medium">{formatBytes(est.total_bytes)}</dd>
+        <dt className="text-gray-500">Duration</dt>
+        <dd className="font-medium">
+          {formatDuration(est.estimated_duration)}
+        </dd>
+      </dl>
+      <ImpactTable report={est} />
+      {est.notes && (
+        <ul className="mt-3 list-disc pl-5 space-y-1 text-xs text-gray-600">
+          {est.notes.map((n) => (
+            <li key={n}>{n}</li>
+          ))}
+        </ul>
+      )}
+
+      {last?.actual && (
+        <details className="mt-4">
+          <summary className="cursor-pointer text-sm font-medium text-gray-700">
+            Last run: estimated {formatDuration(last.estimated_duration)},
+            took {formatDuration(last.actual.duration)} at{" "}
+            {last.actual.read_mbps.toFixed(1)} MB/s ({last.actual.phase})
+          </summary>
+          <ImpactTable report={last} />
+        </details>
+      )}
+    </div>
+  );
+}
@@ -0,0 +1,82 @@
+import type { TimestampColumn, TimezonePolicy } from "../api/types";
+
+const policies: { value: TimezonePolicy; label: string }[] = [
+  { value: "utc", label: "Assume UTC" },
+  { value: "server", label: "Assume the source server's zone" },
+  { value: "named", label: "Assume a named zone" },
+];
+
+interface TimestampPoliciesProps {
+  columns: TimestampColumn[];
+  onChange: (col: TimestampColumn) => void;
+}
+
+// TimestampPolicies picks, per timestamp column without a time zone, the
+// zone its wall-clock values are read in before they are stored as UTC
+// instants.
+export function TimestampPolicies({ columns, onChange }: TimestampPoliciesProps) {
+  if (columns.length === 0) return null;
+
+  return (
+    <div className="mt-8">
+      <h3 className="text-lg font-semibold text-gray-900">Timestamps Without a Time Zone</h3>
+      <p className="mt-1 text-sm text-gray-600">
+        MongoDB stores dates as UTC instants, but these columns hold only a
+        date and time of day. Choose the zone each was written in; validation
+        compares a sample of the migrated instants with the source.
+      </p>
+      <div className="mt-4 rounded-lg border border-gray-200 bg-white overflow-hidden">
+        <table className="w-full text-sm">
+          <thead>
+            <tr className="border-b border-gray-200 bg-gray-50">
+              <th className="px-4 py-3 text-left font-medium text-gray-700">Column</th>
+              <th className="px-4 py-3 text-left font-medium text-gray-700">Type</th>
+              <th className="px-4 py-3 text-left font-medium text-gray-700">Policy</th>
+              <th className="px-4 py-3 text-left font-medium text-gray-700">Zone</th>
+            </tr>
+          </thead>
+          <tbody>
+            {columns.map((col) => (
+              <tr key={`${col.table}.${col.column}`} className="border-b border-gray-100">
+                <td className="px-4 py-2.5 font-mono text-gray-900">
+                  {col.table}.{col.column}
+                </td>
+                <td className="px-4 py-2.5 font-mono text-gray-500">{col.data_type}</td>
+                <td className="px-4 py-2.5">
+                  <select
+                    value={col.policy}
+                    onChange={(e) =>
+                      onChange({
+                        ...col,
+                        policy: e.target.value as TimezonePolicy,
+                        zone: undefined,
+                      })
+                    }
+                    className="rounded-md border border-gray-300 px-2 py-1 text-sm focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+                  >
+                    {policies.map((p) => (
+                      <option key={p.value} value={p.value}>
+                        {p.label}
+                      </option>
+                    ))}
+                  </select>
+                </td>
+                <td className="px-4 py-2.5">
+                  {col.policy === "named" && (
+                    <input
+                      type="text"
+                      value={col.zone ?? ""}
+                      placeholder="Europe/Paris"
+                      onChange={(e) => onChange({ ...col, zone: e.target.value })}
+                      className="w-full rounded-md border border-gray-300 px-2 py-1 text-sm font-mono focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+                    />
+                  )}
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </div>
+    </div>
+  );
+}
@@ -10,6 +10,7 @@ const BSON_TYPES = [
   "Array",
   "Boolean",
   "Double",
+  "GeoJSON",
 ];
 
 interface TypeSelectProps {
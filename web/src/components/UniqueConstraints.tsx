@@ -0,0 +1,84 @@
+import { Alert } from "./Alert";
+import { Button } from "./Button";
+import {
+  useUniqueConstraints,
+  useApplyUniqueConversions,
+} from "../api/hooks";
+
+const statusColors = {
+  preserved: "text-green-700",
+  convertible: "text-yellow-700",
+  lost: "text-red-700",
+};
+
+// UniqueConstraintsCard shows how each source unique constraint carries
+// over, and adds partial unique indexes for the convertible ones to the
+// index plan.
+export function UniqueConstraintsCard() {
+  const { data, error } = useUniqueConstraints();
+  const apply = useApplyUniqueConversions();
+
+  if (error) return <Alert type="warning">{error.message}</Alert>;
+  if (!data || data.constraints.length === 0) return null;
+
+  return (
+    <div className="rounded-lg border border-gray-200 bg-white p-4">
+      <h3 className="text-sm font-medium text-gray-700 mb-3">
+        Unique Constraints
+      </h3>
+      <p className="text-sm text-gray-600">
+        {data.preserved} preserved, {data.convertible} need a partial unique
+        index, {data.lost} lost
+      </p>
+      <table className="mt-3 w-full text-sm">
+        <thead>
+          <tr className="text-left text-xs text-gray-500">
+            <th className="py-1">Constraint</th>
+            <th>Columns</th>
+            <th>Status</th>
+            <th>Reason</th>
+          </tr>
+        </thead>
+        <tbody>
+          {data.constraints.map((c) => (
+            <tr
+              key={`${c.collection}-${c.table}-${c.name}`}
+              className="text-gray-700 align-top"
+            >
+              <td className="py-1 font-mono">
+                {c.collection}.{c.name}
+              </td>
+              <td className="font-mono">{c.columns.join(", ")}</td>
+              <td className={statusColors[c.status]}>{c.status}</td>
+              <td className="text-xs text-gray-600">{c.reason}</td>
+            </tr>
+          ))}
+        </tbody>
+      </table>
+
+      {data.lost > 0 && (
+        <p className="mt-3 text-xs text-gray-600">
+          Lost constraints cannot be enforced by an index; the application
+          must enforce them after cutover.
+        </p>
+      )}
+      {apply.error && (
+        <div className="mt-3">
+          <Alert type="error">{apply.error.message}</Alert>
+        </div>
+      )}
+      {data.unenforced.length > 0 && (
+        <div className="mt-3">
+          <Button
+            variant="secondary"
+            loading={apply.isPending}
+            onClick={() => apply.mutate()}
+          >
+            Add {data.unenforced.length} partial unique indexes to the index
+            plan
+          </Button>
+        </div>
+      )}
+    </div>
+  );
+}
@@ -5,6 +5,9 @@ interface ValidationResultCardProps {
   rowCountPassed?: boolean;
   samplePassed?: boolean;
   aggregatePassed?: boolean;
+  typesPassed?: boolean;
+  offloadPassed?: boolean; // only for collections with offloaded fields
+  referentialPassed?: boolean; // only for collections holding references
   status: string;
 }
 
@@ -13,8 +16,14 @@ export function ValidationResultCard({
   rowCountPassed,
   samplePassed,
   aggregatePassed,
+  typesPassed,
+  offloadPassed,
+  referentialPassed,
   status,
 }: ValidationResultCardProps) {
+  const optional = [offloadPassed, referentialPassed].filter(
+    (p) => p !== undefined,
+  ).length;
   const overallStatus =
     status === "pass" ? "pass" : status === "fail" ? "fail" : "pending";
 
@@ -29,10 +38,19 @@ export function ValidationResultCard({
           label={status}
         />
       </div>
-      <div className="grid grid-cols-3 gap-2">
+      <div
+        className={`grid gap-2 ${["grid-cols-4", "grid-cols-5", "grid-cols-6"][optional]}`}
+      >
         <Check label="Row Count" passed={rowCountPassed} />
         <Check label="Sample" passed={samplePassed} />
         <Check label="Aggregate" passed={aggregatePassed} />
+        <Check label="Types" passed={typesPassed} />
+        {offloadPassed !== undefined && (
+          <Check label="Offload" passed={offloadPassed} />
+        )}
+        {referentialPassed !== undefined && (
+          <Check label="References" passed={referentialPassed} />
+        )}
       </div>
     </div>
   );
@@ -0,0 +1,83 @@
+import { useState } from "react";
+import { Alert } from "./Alert";
+import { useValidators, useSetValidationLevel } from "../api/hooks";
+
+// ValidatorsCard lets the user choose, per collection, how strictly the
+// $jsonSchema validator generated from the source schema is enforced, and
+// shows the validator.
+export function ValidatorsCard() {
+  const { data, error } = useValidators();
+  const setLevel = useSetValidationLevel();
+  const [shown, setShown] = useState<string | null>(null);
+
+  if (error) return null;
+  if (!data || data.length === 0) return null;
+
+  const validator = data.find((v) => v.collection === shown)?.validator;
+
+  return (
+    <div className="mt-6 rounded-lg border border-gray-200 bg-white p-4">
+      <h3 className="text-sm font-medium text-gray-700 mb-3">
+        Schema Validation
+      </h3>
+      <p className="text-sm text-gray-600">
+        Validators keep writes to the source's NOT NULL, type and enum rules.
+        Moderate checks inserts and updates of valid documents; strict checks
+        every write.
+      </p>
+      <table className="mt-3 w-full text-sm">
+        <thead>
+          <tr className="text-left text-xs text-gray-500">
+            <th className="py-1">Collection</th>
+            <th>Validation</th>
+            <th />
+          </tr>
+        </thead>
+        <tbody>
+          {data.map((v) => (
+            <tr key={v.collection} className="text-gray-700">
+              <td className="py-1 font-mono">{v.collection}</td>
+              <td>
+                <select
+                  value={v.level}
+                  onChange={(e) =>
+                    setLevel.mutate({
+                      collection: v.collection,
+                      level: e.target.value,
+                    })
+                  }
+                  className="rounded border border-gray-300 px-2 py-1 text-sm"
+                >
+                  <option value="off">Off</option>
+                  <option value="moderate">Moderate</option>
+                  <option value="strict">Strict</option>
+                </select>
+              </td>
+              <td>
+                <button
+                  className="text-xs text-blue-600 hover:underline"
+                  onClick={() =>
+                    setShown(shown === v.collection ? null : v.collection)
+                  }
+                >
+                  {shown === v.collection ? "Hide" : "Show"} validator
+                </button>
+              </td>
+            </tr>
+          ))}
+        </tbody>
+      </table>
+
+      {validator && (
+        <pre className="mt-3 max-h-80 overflow-auto rounded bg-gray-50 p-3 text-xs text-gray-700">
+          {JSON.stringify(validator, null, 2)}
+        </pre>
+      )}
+      {setLevel.error && (
+        <div className="mt-3">
+          <Alert type="error">{setLevel.error.message}</Alert>
+        </div>
+      )}
+    </div>
+  );
+}
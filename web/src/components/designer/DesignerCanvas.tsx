@@ -78,9 +78,9 @@ function buildNodesAndEdges(
         c.embedded?.some(
           (e) =>
             (e.source_table === table.name &&
-              e.join_column === fk.columns[0]) ||
+              (e.join_columns?.[0] ?? e.join_column) === fk.columns[0]) ||
             (e.source_table === fk.referenced_table &&
-              e.parent_column === fk.columns[0]),
+              (e.parent_columns?.[0] ?? e.parent_column) === fk.columns[0]),
         ),
       );
 
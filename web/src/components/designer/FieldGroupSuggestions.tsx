@@ -0,0 +1,61 @@
+import type { FieldGroupSuggestion } from "../../api/types";
+
+// suggestionKey identifies a suggestion across refetches, for dismissals.
+export function suggestionKey(s: FieldGroupSuggestion): string {
+  return [s.collection, (s.embedded ?? []).join("."), s.prefix].join("/");
+}
+
+interface FieldGroupSuggestionsProps {
+  suggestions: FieldGroupSuggestion[];
+  onAccept: (s: FieldGroupSuggestion) => void;
+  onReject: (s: FieldGroupSuggestion) => void;
+}
+
+// FieldGroupSuggestions lists columns that share a prefix and could be
+// nested under a subdocument. Nothing is grouped until accepted.
+export function FieldGroupSuggestions({
+  suggestions,
+  onAccept,
+  onReject,
+}: FieldGroupSuggestionsProps) {
+  if (suggestions.length === 0) return null;
+
+  return (
+    <div className="rounded-lg border border-gray-200 bg-white p-3">
+      <h3 className="text-sm font-medium text-gray-700">Suggested Subdocuments</h3>
+      <p className="text-xs text-gray-500 mb-3">
+        Columns sharing a prefix can be nested under one field.
+      </p>
+      <ul className="space-y-3">
+        {suggestions.map((s) => (
+          <li key={suggestionKey(s)} className="text-xs">
+            <p className="text-gray-700">
+              <span className="font-mono">{s.table}</span>: group{" "}
+              <span className="font-mono">{s.prefix}*</span> under{" "}
+              <span className="font-mono">{s.target}</span>
+            </p>
+            <p className="text-gray-500 font-mono">
+              {s.columns
+                .map((c) => `${s.target}.${c.slice(s.prefix.length)}`)
+                .join(", ")}
+            </p>
+            <div className="mt-1 space-x-3">
+              <button
+                className="text-blue-600 hover:underline"
+                onClick={() => onAccept(s)}
+              >
+                Accept
+              </button>
+              <button
+                className="text-gray-500 hover:underline"
+                onClick={() => onReject(s)}
+              >
+                Reject
+              </button>
+            </div>
+          </li>
+        ))}
+      </ul>
+    </div>
+  );
+}
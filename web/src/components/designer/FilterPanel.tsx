@@ -0,0 +1,105 @@
+import { useState } from "react";
+import { Button } from "../Button";
+import { usePreviewFilter } from "../../api/hooks";
+import type { Collection, Embedded } from "../../api/types";
+
+// A table read by a collection, with the index path to it from the root:
+// [] is the root table, [i, j] is collection.embedded[i].embedded[j].
+interface FilteredTable {
+  path: number[];
+  table: string;
+  filter: string;
+}
+
+function filteredTables(col: Collection): FilteredTable[] {
+  const tables: FilteredTable[] = [
+    { path: [], table: col.source_table, filter: col.filter ?? "" },
+  ];
+  const walk = (embedded: Embedded[] | undefined, path: number[]) => {
+    embedded?.forEach((e, i) => {
+      tables.push({
+        path: [...path, i],
+        table: e.source_table,
+        filter: e.filter ?? "",
+      });
+      walk(e.embedded, [...path, i]);
+    });
+  };
+  walk(col.embedded, []);
+  return tables;
+}
+
+interface FilterRowProps {
+  table: string;
+  filter: string;
+  onChange: (filter: string) => void;
+}
+
+function FilterRow({ table, filter, onChange }: FilterRowProps) {
+  const preview = usePreviewFilter();
+  const result = preview.data;
+
+  return (
+    <div className="space-y-1">
+      <label className="block text-xs font-medium text-gray-700 font-mono">
+        {table}
+      </label>
+      <div className="flex gap-2">
+        <input
+          type="text"
+          value={filter}
+          onChange={(e) => {
+            preview.reset();
+            onChange(e.target.value);
+          }}
+          placeholder="e.g. status <> 'deleted'"
+          className="min-w-0 flex-1 rounded-md border border-gray-300 px-2 py-1 text-xs font-mono focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+        />
+        <Button
+          variant="secondary"
+          className="px-2 py-1 text-xs"
+          disabled={!filter.trim()}
+          loading={preview.isPending}
+          onClick={() => preview.mutate({ table, filter: filter.trim() })}
+        >
+          Preview
+        </Button>
+      </div>
+      {result && (
+        <p className="text-xs text-gray-500">
+          {result.filtered_rows.toLocaleString()} of{" "}
+          {result.total_rows.toLocaleString()} rows match
+        </p>
+      )}
+      {preview.error && (
+        <p className="text-xs text-red-600">{preview.error.message}</p>
+      )}
+    </div>
+  );
+}
+
+interface FilterPanelProps {
+  collection: Collection;
+  onChange: (path: number[], filter: string) => void;
+}
+
+export function FilterPanel({ collection, onChange }: FilterPanelProps) {
+  return (
+    <div className="rounded-lg border border-gray-200 bg-white p-3">
+      <h3 className="text-sm font-medium text-gray-700">Row Filters</h3>
+      <p className="text-xs text-gray-500 mb-3">
+        SQL conditions; only matching rows are migrated.
+      </p>
+      <div className="space-y-3">
+        {filteredTables(collection).map((t) => (
+          <FilterRow
+            key={t.path.join(".") || "root"}
+            table={t.table}
+            filter={t.filter}
+            onChange={(f) => onChange(t.path, f)}
+          />
+        ))}
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,88 @@
+import type {
+  Collection,
+  CollectionSizeEstimate,
+  Offload,
+} from "../../api/types";
+
+const MB = 1024 * 1024;
+
+interface OffloadPanelProps {
+  collections: Collection[];
+  estimates: CollectionSizeEstimate[];
+  onChange: (collection: string, index: number, offload?: Offload) => void;
+}
+
+// OffloadPanel lists collections whose documents are estimated over the
+// 16MB BSON limit and lets the user move embedded fields out of them, to
+// GridFS or a side collection. Suggested fields are marked.
+export function OffloadPanel({
+  collections,
+  estimates,
+  onChange,
+}: OffloadPanelProps) {
+  const oversized = collections.filter(
+    (c) =>
+      (c.embedded?.some((e) => e.offload) ?? false) ||
+      estimates.some((e) => e.collection === c.name && e.exceeds_limit),
+  );
+  if (oversized.length === 0) return null;
+
+  return (
+    <div className="rounded-lg border border-gray-200 bg-white p-3">
+      <h3 className="text-sm font-medium text-gray-700">Oversized Documents</h3>
+      <p className="text-xs text-gray-500 mb-3">
+        Offloaded fields keep a GridFS file ID or a side collection reference
+        in the document. Estimates update when the mapping is saved.
+      </p>
+      <ul className="space-y-3">
+        {oversized.map((col) => {
+          const est = estimates.find((e) => e.collection === col.name);
+          return (
+            <li key={col.name} className="text-xs">
+              <p className="text-gray-700">
+                <span className="font-mono">{col.name}</span>
+                {est && (
+                  <span
+                    className={est.exceeds_limit ? "text-red-600" : "text-gray-500"}
+                  >
+                    {" "}
+                    up to {(est.max_doc_size_bytes / MB).toFixed(1)} MB
+                  </span>
+                )}
+              </p>
+              <ul className="mt-1 space-y-1">
+                {(col.embedded ?? []).map((emb, i) => (
+                  <li key={emb.field_name} className="flex items-center gap-2">
+                    <span className="font-mono text-gray-600 flex-1 truncate">
+                      {emb.field_name}
+                      {est?.offload_candidates?.includes(emb.field_name) && (
+                        <span className="ml-1 text-amber-600">suggested</span>
+                      )}
+                    </span>
+                    <select
+                      value={emb.offload?.strategy ?? ""}
+                      onChange={(e) =>
+                        onChange(
+                          col.name,
+                          i,
+                          e.target.value
+                            ? { strategy: e.target.value as Offload["strategy"] }
+                            : undefined,
+                        )
+                      }
+                      className="rounded-md border border-gray-300 px-1 py-0.5 text-xs"
+                    >
+                      <option value="">Embed</option>
+                      <option value="gridfs">GridFS</option>
+                      <option value="collection">Side collection</option>
+                    </select>
+                  </li>
+                ))}
+              </ul>
+            </li>
+          );
+        })}
+      </ul>
+    </div>
+  );
+}
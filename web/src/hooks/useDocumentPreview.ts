@@ -1,5 +1,92 @@
 import { useMemo } from "react";
-import type { Mapping, Schema, Collection, Embedded } from "../api/types";
+import type {
+  Mapping,
+  Schema,
+  Collection,
+  Embedded,
+  FieldMapping,
+} from "../api/types";
+
+// fieldTarget mirrors mapping.FieldTarget: a column's own mapping wins over
+// prefix groups, and the longest matching prefix wins among those. Returns
+// null for an excluded column.
+function fieldTarget(fields: FieldMapping[] | undefined, column: string): string | null {
+  let group: FieldMapping | undefined;
+  for (const f of fields ?? []) {
+    if (!f.prefix) {
+      if (f.column === column) return f.exclude ? null : f.target || column;
+      continue;
+    }
+    if (
+      column.length > f.prefix.length &&
+      column.startsWith(f.prefix) &&
+      (!group || f.prefix.length > group.prefix!.length)
+    ) {
+      group = f;
+    }
+  }
+  if (group) {
+    return group.exclude
+      ? null
+      : `${group.target}.${column.slice(group.prefix!.length)}`;
+  }
+  return column;
+}
+
+function setPath(doc: Record<string, unknown>, path: string, value: unknown) {
+  const parts = path.split(".");
+  let node = doc;
+  for (const part of parts.slice(0, -1)) {
+    if (typeof node[part] !== "object" || node[part] === null) {
+      node[part] = {};
+    }
+    node = node[part] as Record<string, unknown>;
+  }
+  node[parts[parts.length - 1]] = value;
+}
+
+// orderFields mirrors mapping.OrderedKeys: paths listed in order come first,
+// in that order, and the rest follow by name.
+function orderFields(
+  doc: Record<string, unknown>,
+  order: string[],
+  path = "",
+): Record<string, unknown> {
+  const rank = new Map(order.map((p, i) => [p, i]));
+  const full = (k: string) => (path ? `${path}.${k}` : k);
+  const keys = Object.keys(doc).sort((a, b) => {
+    const ra = rank.get(full(a));
+    const rb = rank.get(full(b));
+    if (ra !== undefined && rb !== undefined) return ra - rb;
+    if (ra !== undefined) return -1;
+    if (rb !== undefined) return 1;
+    return a < b ? -1 : a > b ? 1 : 0;
+  });
+  const out: Record<string, unknown> = {};
+  for (const k of keys) {
+    const v = doc[k];
+    out[k] =
+      typeof v === "object" && v !== null && !Array.isArray(v)
+        ? orderFields(v as Record<string, unknown>, order, full(k))
+        : v;
+  }
+  return out;
+}
+
+// shapeDocument places sample values at the paths the field mappings give
+// them, then orders the fields when the mapping lists an order.
+function shapeDocument(
+  values: [string, unknown][],
+  fields: FieldMapping[] | undefined,
+  order: string[] | undefined,
+): Record<string, unknown> {
+  const doc: Record<string, unknown> = {};
+  for (const [column, value] of values) {
+    const path = fieldTarget(fields, column);
+    if (path !== null) setPath(doc, path, value);
+  }
+  return order?.length ? orderFields(doc, order) : doc;
+}
 
 function buildPreview(
   collection: Collection,
@@ -10,24 +97,23 @@ function buildPreview(
   );
   if (!table) return { _id: "ObjectId(...)" };
 
-  const doc: Record<string, unknown> = { _id: "ObjectId(...)" };
-  for (const col of table.columns) {
-    doc[col.name] = sampleValue(col.data_type);
-  }
+  const values: [string, unknown][] = table.columns.map((col) => [
+    col.name,
+    sampleValue(col.data_type),
+  ]);
 
-  if (collection.embedded) {
-    for (const emb of collection.embedded) {
-      doc[emb.field_name] = buildEmbeddedPreview(emb, schema);
-    }
+  for (const emb of collection.embedded ?? []) {
+    values.push([emb.field_name, buildEmbeddedPreview(emb, schema)]);
   }
 
-  if (collection.references) {
-    for (const ref of collection.references) {
-      doc[ref.field_name] = "ObjectId(...)";
-    }
+  for (const ref of collection.references ?? []) {
+    values.push([ref.field_name, "ObjectId(...)"]);
   }
 
-  return doc;
+  return {
+    _id: "ObjectId(...)",
+    ...shapeDocument(values, collection.fields, collection.field_order),
+  };
 }
 
 function buildEmbeddedPreview(
@@ -35,21 +121,21 @@ function buildEmbeddedPreview(
   schema: Schema,
 ): unknown {
   const table = schema.tables.find((t) => t.name === emb.source_table);
-  const subdoc: Record<string, unknown> = {};
+  const values: [string, unknown][] = [];
+  const joinColumns = emb.join_columns?.length ? emb.join_columns : [emb.join_column];
   if (table) {
     for (const col of table.columns) {
-      if (col.name !== emb.join_column) {
-        subdoc[col.name] = sampleValue(col.data_type);
+      if (!joinColumns.includes(col.name)) {
+        values.push([col.name, sampleValue(col.data_type)]);
       }
     }
   }
 
-  if (emb.embedded) {
-    for (const nested of emb.embedded) {
-      subdoc[nested.field_name] = buildEmbeddedPreview(nested, schema);
-    }
+  for (const nested of emb.embedded ?? []) {
+    values.push([nested.field_name, buildEmbeddedPreview(nested, schema)]);
   }
 
+  const subdoc = shapeDocument(values, emb.fields, emb.field_order);
   return emb.relationship === "array" ? [subdoc, "..."] : subdoc;
 }
 
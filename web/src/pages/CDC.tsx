@@ -0,0 +1,142 @@
+import { Button } from "../components/Button";
+import { Alert } from "../components/Alert";
+import { PageContainer } from "../components/PageContainer";
+import {
+  useNavigateToStep,
+  useCDCStatus,
+  usePrepareCDC,
+  useStartCDC,
+  useStopCDC,
+  useTeardownCDC,
+} from "../api/hooks";
+
+function formatBytes(bytes: number): string {
+  if (bytes >= 1 << 30) return `${(bytes / (1 << 30)).toFixed(1)} GB`;
+  if (bytes >= 1 << 20) return `${(bytes / (1 << 20)).toFixed(1)} MB`;
+  if (bytes >= 1 << 10) return `${(bytes / (1 << 10)).toFixed(1)} KB`;
+  return `${bytes} B`;
+}
+
+export default function CDC() {
+  const goToStep = useNavigateToStep();
+  const { data: status } = useCDCStatus();
+  const prepare = usePrepareCDC();
+  const start = useStartCDC();
+  const stop = useStopCDC();
+  const teardown = useTeardownCDC();
+
+  const state = status?.state ?? "idle";
+  const running = state === "running";
+  const actionError =
+    prepare.error || start.error || stop.error || teardown.error;
+
+  return (
+    <PageContainer>
+    <div>
+      <h2 className="text-2xl font-bold text-gray-900">Change Data Capture</h2>
+      <p className="mt-2 text-gray-600">
+        Replicate changes made on the source since the bulk load so the
+        application can cut over with minimal downtime. Stop writes to the
+        source, wait for lag to reach zero, then stop replication and cut over.
+      </p>
+
+      {status && (
+        <div className="mt-6 grid grid-cols-2 gap-4 sm:grid-cols-4">
+          <div className="rounded-lg border border-gray-200 bg-white p-4">
+            <p className="text-xs text-gray-500">State</p>
+            <p
+              className={`mt-1 text-lg font-semibold ${
+                running
+                  ? "text-blue-700"
+                  : state === "failed"
+                    ? "text-red-700"
+                    : "text-gray-900"
+              }`}
+            >
+              {state}
+            </p>
+          </div>
+          <div className="rounded-lg border border-gray-200 bg-white p-4">
+            <p className="text-xs text-gray-500">Applied</p>
+            <p className="mt-1 text-lg font-semibold text-gray-900">
+              {status.applied.toLocaleString()}
+            </p>
+            {status.skipped > 0 && (
+              <p className="text-xs text-gray-500">
+                {status.skipped.toLocaleString()} skipped
+              </p>
+            )}
+          </div>
+          <div className="rounded-lg border border-gray-200 bg-white p-4">
+            <p className="text-xs text-gray-500">Lag</p>
+            <p className="mt-1 text-lg font-semibold text-gray-900">
+              {status.lag_seconds.toFixed(1)}s
+            </p>
+            <p className="text-xs text-gray-500">
+              {formatBytes(status.lag_bytes)}
+            </p>
+          </div>
+          <div className="rounded-lg border border-gray-200 bg-white p-4">
+            <p className="text-xs text-gray-500">Position</p>
+            <p className="mt-1 text-sm font-mono text-gray-900 break-all">
+              {status.position || "—"}
+            </p>
+          </div>
+        </div>
+      )}
+
+      {status?.last_error && (
+        <div className="mt-4">
+          <Alert type="error">{status.last_error}</Alert>
+        </div>
+      )}
+
+      {actionError && (
+        <div className="mt-4">
+          <Alert type="error">{actionError.message}</Alert>
+        </div>
+      )}
+
+      <div className="mt-6 flex gap-3">
+        {state === "idle" && (
+          <Button
+            variant="secondary"
+            loading={prepare.isPending}
+            onClick={() => prepare.mutate()}
+          >
+            Prepare
+          </Button>
+        )}
+        {!running && state !== "torn_down" && (
+          <Button loading={start.isPending} onClick={() => start.mutate()}>
+            Start Replication
+          </Button>
+        )}
+        {running && (
+          <Button
+            variant="secondary"
+            loading={stop.isPending}
+            onClick={() => stop.mutate()}
+          >
+            Stop Replication
+          </Button>
+        )}
+        {(state === "stopped" || state === "failed") && (
+          <Button
+            variant="danger"
+            loading={teardown.isPending}
+            onClick={() => teardown.mutate()}
+          >
+            Release Replication Slot
+          </Button>
+        )}
+        {!running && (
+          <Button variant="secondary" onClick={() => goToStep("complete")}>
+            {state === "torn_down" ? "Continue to Readiness" : "Skip to Readiness"}
+          </Button>
+        )}
+      </div>
+    </div>
+    </PageContainer>
+  );
+}
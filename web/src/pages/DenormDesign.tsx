@@ -4,18 +4,87 @@ import { DesignerCanvas } from "../components/designer/DesignerCanvas";
 import { EdgeConfigPanel } from "../components/designer/EdgeConfigPanel";
 import { DocumentPreview } from "../components/designer/DocumentPreview";
 import { DesignerToolbar } from "../components/designer/DesignerToolbar";
+import { FilterPanel } from "../components/designer/FilterPanel";
+import { OffloadPanel } from "../components/designer/OffloadPanel";
+import {
+  FieldGroupSuggestions,
+  suggestionKey,
+} from "../components/designer/FieldGroupSuggestions";
 import { Alert } from "../components/Alert";
 import { Button } from "../components/Button";
 import {
   useSchema,
   useMapping,
   useMappingPreview,
+  useFieldGroupSuggestions,
+  useSizeEstimates,
   useSaveMapping,
   useNavigateToStep,
 } from "../api/hooks";
 import { useDesignerState } from "../hooks/useDesignerState";
 import { useDocumentPreview } from "../hooks/useDocumentPreview";
-import type { Mapping, Embedded, Reference } from "../api/types";
+import type {
+  Mapping,
+  Embedded,
+  Reference,
+  FieldMapping,
+  FieldGroupSuggestion,
+  Offload,
+} from "../api/types";
+
+// withFilter returns embedded with the table at path given filter. An empty
+// filter removes it.
+function withFilter(
+  embedded: Embedded[],
+  path: number[],
+  filter: string,
+): Embedded[] {
+  const [i, ...rest] = path;
+  return embedded.map((e, j) => {
+    if (j !== i) return e;
+    if (rest.length > 0) {
+      return { ...e, embedded: withFilter(e.embedded || [], rest, filter) };
+    }
+    return { ...e, filter: filter || undefined };
+  });
+}
+
+// withField returns embedded with field added to the table at path.
+function withField(
+  embedded: Embedded[],
+  path: number[],
+  field: FieldMapping,
+): Embedded[] {
+  const [i, ...rest] = path;
+  return embedded.map((e, j) => {
+    if (j !== i) return e;
+    if (rest.length > 0) {
+      return { ...e, embedded: withField(e.embedded || [], rest, field) };
+    }
+    return { ...e, fields: [...(e.fields || []), field] };
+  });
+}
+
+// fieldsAt returns the field mappings of the table a suggestion is for, or
+// undefined when the mapping no longer has that table.
+function fieldsAt(
+  m: Mapping,
+  s: FieldGroupSuggestion,
+): FieldMapping[] | undefined {
+  const col = m.collections.find((c) => c.name === s.collection);
+  if (!col) return undefined;
+  let fields = col.fields;
+  let table = col.source_table;
+  let embedded = col.embedded;
+  for (const i of s.embedded ?? []) {
+    const e = embedded?.[i];
+    if (!e) return undefined;
+    fields = e.fields;
+    table = e.source_table;
+    embedded = e.embedded;
+  }
+  return table === s.table ? fields || [] : undefined;
+}
 
 function RootCollectionPicker({
   tables,
@@ -138,6 +207,67 @@ export default function DenormDesign() {
     relationship: string;
   } | null>(null);
 
+  // Suggested field groups: from the preview for a new design, or computed
+  // for the saved mapping. Accepted and rejected ones are hidden.
+  const savedSuggestions = useFieldGroupSuggestions(!!serverMapping);
+  const [rejected, setRejected] = useState<Set<string>>(new Set());
+  const suggestions = (
+    (serverMapping ? savedSuggestions.data : previewMapping?.suggestions) ?? []
+  ).filter((s) => {
+    const fields = fieldsAt(mapping, s);
+    return (
+      fields !== undefined &&
+      !fields.some((f) => f.prefix === s.prefix) &&
+      !rejected.has(suggestionKey(s))
+    );
+  });
+
+  const handleAcceptGroup = useCallback(
+    (s: FieldGroupSuggestion) => {
+      const field: FieldMapping = { column: "", prefix: s.prefix, target: s.target };
+      updateMapping((m: Mapping) => ({
+        ...m,
+        collections: m.collections.map((col) => {
+          if (col.name !== s.collection) return col;
+          if (!s.embedded?.length) {
+            return { ...col, fields: [...(col.fields || []), field] };
+          }
+          return {
+            ...col,
+            embedded: withField(col.embedded || [], s.embedded, field),
+          };
+        }),
+      }));
+    },
+    [updateMapping],
+  );
+
+  const handleRejectGroup = useCallback((s: FieldGroupSuggestion) => {
+    setRejected((prev) => new Set(prev).add(suggestionKey(s)));
+  }, []);
+
+  // Size estimates are for the saved mapping.
+  const { data: estimates } = useSizeEstimates(!!serverMapping);
+
+  const handleChangeOffload = useCallback(
+    (collection: string, index: number, offload?: Offload) => {
+      updateMapping((m: Mapping) => ({
+        ...m,
+        collections: m.collections.map((col) =>
+          col.name !== collection
+            ? col
+            : {
+                ...col,
+                embedded: (col.embedded || []).map((e, i) =>
+                  i === index ? { ...e, offload } : e,
+                ),
+              },
+        ),
+      }));
+    },
+    [updateMapping],
+  );
+
   const [selectedCollection, setSelectedCollection] = useState<string>();
   const previewJson = useDocumentPreview(mapping, schema, selectedCollection);
 
@@ -273,6 +403,30 @@ export default function DenormDesign() {
     setSelectedEdge(null);
   }, [selectedEdge, updateMapping]);
 
+  const handleChangeFilter = useCallback(
+    (path: number[], filter: string) => {
+      if (!selectedCollection) return;
+      updateMapping((m: Mapping) => ({
+        ...m,
+        collections: m.collections.map((col) => {
+          if (col.name !== selectedCollection) return col;
+          if (path.length === 0) {
+            return { ...col, filter: filter || undefined };
+          }
+          return {
+            ...col,
+            embedded: withFilter(col.embedded || [], path, filter),
+          };
+        }),
+      }));
+    },
+    [selectedCollection, updateMapping],
+  );
+
+  const selected = mapping.collections.find(
+    (c) => c.name === selectedCollection,
+  );
+
   const handleSave = () => {
     saveMapping.mutate(mapping, {
       onSuccess: () => goToStep("type_mapping"),
@@ -384,6 +538,22 @@ export default function DenormDesign() {
             collectionName={selectedCollection}
           />
 
+          <FieldGroupSuggestions
+            suggestions={suggestions}
+            onAccept={handleAcceptGroup}
+            onReject={handleRejectGroup}
+          />
+
+          <OffloadPanel
+            collections={mapping.collections}
+            estimates={estimates ?? []}
+            onChange={handleChangeOffload}
+          />
+
+          {selected && (
+            <FilterPanel collection={selected} onChange={handleChangeFilter} />
+          )}
+
           {mapping.collections.length > 0 && (
             <div className="rounded-lg border border-gray-200 bg-white p-3">
               <h3 className="text-sm font-medium text-gray-700 mb-2">
@@ -1,31 +1,34 @@
-import { useQuery } from "@tanstack/react-query";
 import { ProgressBar } from "../components/ProgressBar";
 import { Button } from "../components/Button";
 import { Alert } from "../components/Alert";
 import { PageContainer } from "../components/PageContainer";
-import { useNavigateToStep } from "../api/hooks";
-import { api } from "../api/client";
+import { IndexPlanEditor } from "../components/IndexPlanEditor";
+import { IDGenerationCard } from "../components/IDGeneration";
+import {
+  useBuildIndexes,
+  useIndexBuildStatus,
+  useNavigateToStep,
+  usePauseIndexBuilds,
+  useResumeIndexBuilds,
+} from "../api/hooks";
 
-interface IndexStatus {
-  collection: string;
-  index_name: string;
-  phase: string;
-  progress: number;
-  message: string;
-}
+const phaseStyles: Record<string, string> = {
+  complete: "bg-green-100 text-green-700",
+  building: "bg-blue-100 text-blue-700",
+  failed: "bg-red-100 text-red-700",
+};
 
 export default function IndexBuilds() {
   const goToStep = useNavigateToStep();
+  const { data: result } = useIndexBuildStatus();
+  const build = useBuildIndexes();
+  const pause = usePauseIndexBuilds();
+  const resume = useResumeIndexBuilds();
 
-  const { data: indexes } = useQuery<IndexStatus[]>({
-    queryKey: ["index-status"],
-    queryFn: () => api.get("/api/indexes/status"),
-    refetchInterval: 2000,
-    retry: false,
-  });
-
-  const allComplete =
-    indexes && indexes.length > 0 && indexes.every((i) => i.phase === "complete");
+  const indexes = result?.indexes ?? [];
+  const building = result?.status === "building";
+  const allComplete = result?.status === "complete" || result?.status === "skipped";
+  const actionError = build.error ?? pause.error ?? resume.error;
 
   return (
     <PageContainer>
@@ -35,7 +38,57 @@ export default function IndexBuilds() {
         Building indexes on the migrated collections.
       </p>
 
-      {indexes && indexes.length > 0 && (
+      <IndexPlanEditor />
+
+      {result && !building && !allComplete && (
+        <div className="mt-6 flex gap-3">
+          <Button loading={build.isPending} onClick={() => build.mutate()}>
+            {result.status === "failed" ? "Retry index builds" : "Build indexes"}
+          </Button>
+        </div>
+      )}
+
+      {actionError && (
+        <div className="mt-4">
+          <Alert type="error">{(actionError as Error).message}</Alert>
+        </div>
+      )}
+
+      {indexes.length > 0 && (
+        <div className="mt-6 rounded-lg border border-gray-200 bg-white p-4">
+          <div className="flex items-center justify-between mb-2">
+            <span className="text-sm font-medium text-gray-900">
+              {indexes.filter((i) => i.phase === "complete").length}/{indexes.length} indexes
+              built ({Math.round(result?.progress ?? 0)}%)
+            </span>
+            <span className="text-xs text-gray-500">
+              {result?.concurrency ?? 1} collection(s) at a time
+              {result?.max_per_shard ? `, at most ${result.max_per_shard} per shard` : ""}
+            </span>
+          </div>
+          <ProgressBar percent={result?.progress ?? 0} animated={building && !result?.paused} />
+          {building && (
+            <div className="mt-3 flex items-center gap-3">
+              {result?.paused ? (
+                <Button loading={resume.isPending} onClick={() => resume.mutate()}>
+                  Resume
+                </Button>
+              ) : (
+                <Button variant="secondary" loading={pause.isPending} onClick={() => pause.mutate()}>
+                  Pause
+                </Button>
+              )}
+              {result?.paused && (
+                <span className="text-sm text-yellow-700">
+                  Paused: running builds finish, no new ones start.
+                </span>
+              )}
+            </div>
+          )}
+        </div>
+      )}
+
+      {indexes.length > 0 && (
         <div className="mt-6 space-y-3">
           {indexes.map((idx) => (
             <div
@@ -53,19 +106,16 @@ export default function IndexBuilds() {
                 </div>
                 <span
                   className={`text-xs px-2 py-0.5 rounded-full ${
-                    idx.phase === "complete"
-                      ? "bg-green-100 text-green-700"
-                      : idx.phase === "building"
-                        ? "bg-blue-100 text-blue-700"
-                        : "bg-gray-100 text-gray-600"
+                    phaseStyles[idx.phase] ?? "bg-gray-100 text-gray-600"
                   }`}
                 >
                   {idx.phase}
+                  {idx.phase === "building" && ` ${Math.round(idx.progress)}%`}
                 </span>
               </div>
               <ProgressBar
                 percent={idx.progress}
-                color={idx.phase === "complete" ? "green" : "blue"}
+                color={idx.phase === "complete" ? "green" : idx.phase === "failed" ? "red" : "blue"}
                 animated={idx.phase === "building"}
               />
               {idx.message && (
@@ -76,20 +126,18 @@ export default function IndexBuilds() {
         </div>
       )}
 
-      {indexes && indexes.length === 0 && (
-        <Alert type="info">No indexes to build.</Alert>
-      )}
-
-      {!indexes && (
+      {!result && (
         <div className="mt-6">
           <Alert type="info">Loading index build status...</Alert>
         </div>
       )}
 
+      <IDGenerationCard />
+
       {allComplete && (
         <div className="mt-6 flex gap-3">
-          <Button onClick={() => goToStep("complete")}>
-            Continue to Readiness
+          <Button onClick={() => goToStep("cdc")}>
+            Continue to Change Data Capture
           </Button>
         </div>
       )}
@@ -30,6 +30,14 @@ interface MigrationStatus {
   errors: string[];
 }
 
+const PHASE_LABELS: Record<string, string> = {
+  uploading: "Uploading migration script to S3",
+  submitting: "Submitting Spark job",
+  starting: "Waiting for the Spark cluster to start",
+  running: "Spark job running",
+  paused: "Paused — the native mover holds at its next bulk write; a Spark job is stopped and resumes from its checkpointed partitions",
+};
+
 export default function Migration() {
   const goToStep = useNavigateToStep();
   const [showFailure, setShowFailure] = useState(false);
@@ -43,11 +51,18 @@ export default function Migration() {
 
   const failedCollections =
     status?.collections
-      .filter((c) => c.state === "failed")
+      ?.filter((c) => c.state === "failed")
       .map((c) => c.name) || [];
 
-  const isComplete = status?.phase === "complete";
+  const isComplete =
+    status?.phase === "complete" || status?.phase === "completed";
+  const phaseLabel = status ? PHASE_LABELS[status.phase] : undefined;
   const hasFailed = failedCollections.length > 0;
+  const windowExpired = status?.phase === "window-expired";
+  const paused = status?.phase === "paused";
+  const running = ["uploading", "submitting", "starting", "running"].includes(
+    status?.phase ?? "",
+  );
 
   return (
     <PageContainer>
@@ -56,11 +71,21 @@ export default function Migration() {
       <p className="mt-2 text-gray-600">
         {isComplete
           ? "Migration complete."
-          : "Migration in progress. You can safely close this browser — the migration continues server-side."}
+          : windowExpired
+            ? "The migration window ended before the migration finished."
+            : "Migration in progress. You can safely close this browser — the migration continues server-side."}
       </p>
 
       {status && (
         <div className="mt-6 space-y-6">
+          {phaseLabel && <Alert type="info">{phaseLabel}</Alert>}
+          {windowExpired && (
+            <Alert type="warning">
+              Reads and writes were stopped at the deadline and the target's
+              write concern and balancer restored. Completed collections are
+              kept; resume in the next window to migrate the rest.
+            </Alert>
+          )}
           <div className="rounded-lg border border-gray-200 bg-white p-4">
             <div className="flex items-center justify-between mb-3">
               <h3 className="text-sm font-medium text-gray-700">
@@ -86,7 +111,7 @@ export default function Migration() {
             <h3 className="text-sm font-medium text-gray-700">
               Per-Collection
             </h3>
-            {status.collections.map((col) => (
+            {status.collections?.map((col) => (
               <div
                 key={col.name}
                 className="rounded-lg border border-gray-100 bg-white p-3"
@@ -129,7 +154,7 @@ export default function Migration() {
           {status.errors && status.errors.length > 0 && (
             <div className="space-y-1">
               {status.errors.map((err, i) => (
-                <Alert key={i} type="error">
+                <Alert key={i} type={windowExpired ? "warning" : "error"}>
                   {err}
                 </Alert>
               ))}
@@ -137,6 +162,24 @@ export default function Migration() {
           )}
 
           <div className="flex gap-3">
+            {running && (
+              <Button
+                variant="secondary"
+                onClick={() => api.post("/api/migration/pause")}
+              >
+                Pause Migration
+              </Button>
+            )}
+            {paused && (
+              <Button onClick={() => api.post("/api/migration/resume")}>
+                Resume Migration
+              </Button>
+            )}
+            {windowExpired && (
+              <Button onClick={() => api.post("/api/migration/retry", {})}>
+                Resume Migration
+              </Button>
+            )}
             {hasFailed && !isComplete && (
               <Button
                 variant="danger"
@@ -150,6 +193,16 @@ export default function Migration() {
                 Continue to Validation
               </Button>
             )}
+            {isComplete && (
+              <Button
+                variant="secondary"
+                onClick={() =>
+                  api.post("/api/migration/start", { delta: true })
+                }
+              >
+                Run Delta Migration
+              </Button>
+            )}
           </div>
         </div>
       )}
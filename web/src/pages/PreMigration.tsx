@@ -1,6 +1,7 @@
 import { Button } from "../components/Button";
 import { StatusBadge } from "../components/StatusBadge";
 import { PageContainer } from "../components/PageContainer";
+import { ValidatorsCard } from "../components/Validators";
 import { useNavigateToStep } from "../api/hooks";
 
 interface Check {
@@ -64,6 +65,8 @@ export default function PreMigration() {
         ))}
       </div>
 
+      <ValidatorsCard />
+
       <div className="mt-6 flex gap-3">
         <Button onClick={() => goToStep("migration")}>
           Continue to Migration
@@ -1,10 +1,92 @@
 import { Button } from "../components/Button";
 import { Alert } from "../components/Alert";
+import { Spinner } from "../components/Spinner";
 import { PageContainer } from "../components/PageContainer";
-import { useWizardState, useNavigateToStep } from "../api/hooks";
+import { SourceImpactCard } from "../components/SourceImpact";
+import { UniqueConstraintsCard } from "../components/UniqueConstraints";
+import { RunEstimateSummary } from "../components/RunEstimate";
+import {
+  useWizardState,
+  useNavigateToStep,
+  useMigrationPlan,
+  useMappingLint,
+} from "../api/hooks";
+import type { PlanCollection } from "../api/types";
+
+function formatBytes(bytes: number): string {
+  if (bytes >= 1024 ** 4) return `${(bytes / 1024 ** 4).toFixed(1)} TB`;
+  if (bytes >= 1024 ** 3) return `${(bytes / 1024 ** 3).toFixed(1)} GB`;
+  if (bytes >= 1024 ** 2) return `${(bytes / 1024 ** 2).toFixed(1)} MB`;
+  if (bytes >= 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+  return `${bytes} B`;
+}
+
+function PlanCollectionCard({ collection }: { collection: PlanCollection }) {
+  const shardKey = collection.shard_key
+    ? Object.entries(collection.shard_key)
+        .map(([field, kind]) => `${field}: ${kind}`)
+        .join(", ")
+    : null;
+
+  return (
+    <div className="rounded-lg border border-gray-200 bg-white p-4">
+      <div className="flex items-baseline justify-between">
+        <h4 className="font-medium text-gray-900">{collection.name}</h4>
+        <span className="text-xs text-gray-500">
+          from {collection.source_table}
+          {collection.embedded_tables?.length
+            ? ` + ${collection.embedded_tables.join(", ")}`
+            : ""}
+        </span>
+      </div>
+
+      <dl className="mt-3 grid grid-cols-4 gap-x-4 gap-y-1 text-sm">
+        <dt className="text-gray-500">Documents</dt>
+        <dd className="font-medium">
+          {collection.estimated_documents.toLocaleString()}
+        </dd>
+        <dt className="text-gray-500">Estimated Size</dt>
+        <dd className="font-medium">
+          {formatBytes(collection.estimated_bytes)}
+        </dd>
+        <dt className="text-gray-500">Avg / Max Doc</dt>
+        <dd className="font-medium">
+          {formatBytes(collection.avg_doc_size_bytes)} /{" "}
+          {formatBytes(collection.max_doc_size_bytes)}
+        </dd>
+        <dt className="text-gray-500">Shard Key</dt>
+        <dd className="font-medium">{shardKey || "—"}</dd>
+        <dt className="text-gray-500">Indexes</dt>
+        <dd className="font-medium">
+          {collection.indexes?.map((idx) => idx.name).join(", ") || "—"}
+        </dd>
+        <dt className="text-gray-500">Compression</dt>
+        <dd className="font-medium">
+          {collection.storage?.block_compressor || "default"}
+        </dd>
+      </dl>
+
+      <div className="mt-3 space-y-1">
+        {collection.reads.map((read) => (
+          <pre
+            key={read.table}
+            className="overflow-x-auto rounded bg-gray-50 px-2 py-1 text-xs text-gray-700"
+          >
+            {read.sql}
+            {`  -- ${read.num_partitions} partitions on ${read.partition_column}`}
+          </pre>
+        ))}
+      </div>
+    </div>
+  );
+}
 
 export default function Review() {
   const { data: state } = useWizardState();
+  const { data: plan, isLoading, error } = useMigrationPlan();
+  const { data: lint } = useMappingLint();
+  const lintErrors =
+    lint?.issues.filter((i) => i.severity === "error").length ?? 0;
   const goToStep = useNavigateToStep();
 
   const handleContinue = () => {
@@ -34,9 +116,100 @@ export default function Review() {
             <dd className="font-medium">{completedSteps} / 12</dd>
             <dt className="text-gray-500">Current Step</dt>
             <dd className="font-medium">{state?.current_step || "—"}</dd>
+            {plan && (
+              <>
+                <dt className="text-gray-500">Source</dt>
+                <dd className="font-medium">
+                  {plan.source.type}://{plan.source.host}/
+                  {plan.source.database}
+                </dd>
+                <dt className="text-gray-500">Target Database</dt>
+                <dd className="font-medium">{plan.target.database}</dd>
+                <dt className="text-gray-500">Collections</dt>
+                <dd className="font-medium">{plan.totals.collections}</dd>
+                <dt className="text-gray-500">Documents</dt>
+                <dd className="font-medium">
+                  {plan.totals.documents.toLocaleString()}
+                </dd>
+                <dt className="text-gray-500">Estimated Size</dt>
+                <dd className="font-medium">
+                  {formatBytes(plan.totals.estimated_bytes)}
+                </dd>
+                <dt className="text-gray-500">Indexes</dt>
+                <dd className="font-medium">{plan.totals.indexes}</dd>
+                {plan.sizing && (
+                  <>
+                    <dt className="text-gray-500">Spark</dt>
+                    <dd className="font-medium">
+                      {plan.sizing.spark_plan.platform} (
+                      {plan.sizing.spark_plan.cost_estimate})
+                    </dd>
+                    <dt className="text-gray-500">MongoDB Tier</dt>
+                    <dd className="font-medium">
+                      {plan.sizing.mongo_plan.migration_tier}
+                    </dd>
+                    {plan.sizing.estimate && (
+                      <>
+                        <dt className="text-gray-500">Estimated Run</dt>
+                        <dd className="font-medium">
+                          <RunEstimateSummary estimate={plan.sizing.estimate} />
+                        </dd>
+                      </>
+                    )}
+                  </>
+                )}
+              </>
+            )}
           </dl>
         </div>
 
+        {isLoading && (
+          <div className="flex justify-center py-6">
+            <Spinner />
+          </div>
+        )}
+        {error && <Alert type="error">{error.message}</Alert>}
+
+        {plan?.warnings?.map((w) => (
+          <Alert key={w} type="warning">
+            {w}
+          </Alert>
+        ))}
+
+        {lint?.issues.map((i) => (
+          <Alert
+            key={`${i.code}:${i.collection}.${i.path ?? ""}:${i.message}`}
+            type={i.severity}
+          >
+            {i.collection}
+            {i.path ? `.${i.path}` : ""}: {i.message}
+          </Alert>
+        ))}
+
+        {plan && (
+          <div className="space-y-3">
+            <div className="flex items-center justify-between">
+              <h3 className="text-sm font-medium text-gray-700">
+                Collections
+              </h3>
+              <a
+                href="/api/plan?format=yaml"
+                download="migration-plan.yaml"
+                className="text-sm text-blue-600 hover:underline"
+              >
+                Download plan (YAML)
+              </a>
+            </div>
+            {plan.collections.map((c) => (
+              <PlanCollectionCard key={c.name} collection={c} />
+            ))}
+          </div>
+        )}
+
+        <SourceImpactCard />
+
+        <UniqueConstraintsCard />
+
         <Alert type="warning">
           Starting the migration is a point of no return. You will not be able
           to navigate back to configuration steps once the migration begins.
@@ -47,6 +220,7 @@ export default function Review() {
           <Button
             variant="primary"
             onClick={handleContinue}
+            disabled={lintErrors > 0}
           >
             Continue to Target Connection
           </Button>
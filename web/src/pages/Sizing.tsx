@@ -2,11 +2,14 @@ import { Button } from "../components/Button";
 import { Alert } from "../components/Alert";
 import { ExplanationCard } from "../components/ExplanationCard";
 import { CostEstimate } from "../components/CostEstimate";
+import { RunEstimateCard } from "../components/RunEstimate";
 import { PageContainer } from "../components/PageContainer";
-import { useSizing, useNavigateToStep } from "../api/hooks";
+import { useState } from "react";
+import { useSizing, useShardAdvisor, useNavigateToStep } from "../api/hooks";
 
 export default function Sizing() {
-  const { data: plan, isLoading, error } = useSizing();
+  const [units, setUnits] = useState<number>();
+  const { data: plan, isLoading, error } = useSizing(units);
   const goToStep = useNavigateToStep();
 
   if (isLoading) {
@@ -100,6 +103,15 @@ export default function Sizing() {
         </div>
       </div>
 
+      {plan.estimate && (
+        <RunEstimateCard
+          estimate={plan.estimate}
+          unitName={plan.spark_plan.platform === "glue" ? "DPUs" : "workers"}
+          minUnits={plan.spark_plan.platform === "glue" ? 2 : 1}
+          onUnitsChange={setUnits}
+        />
+      )}
+
       {plan.explanations && plan.explanations.length > 0 && (
         <div className="mt-6 space-y-3">
           <h3 className="text-sm font-medium text-gray-700">Explanations</h3>
@@ -114,6 +126,78 @@ export default function Sizing() {
         </div>
       )}
 
+      {plan.derivation && (
+        <details className="mt-6 rounded-lg border border-gray-200 bg-white p-4">
+          <summary className="cursor-pointer text-sm font-medium text-gray-700">
+            How this was calculated
+          </summary>
+
+          <h4 className="mt-4 text-xs font-semibold uppercase text-gray-500">
+            Inputs
+          </h4>
+          <dl className="mt-2 space-y-1 text-sm">
+            {plan.derivation.inputs.map((v) => (
+              <div key={v.name} className="flex justify-between">
+                <dt className="text-gray-500">{v.name}</dt>
+                <dd className="font-medium">
+                  {v.value}
+                  {v.source && (
+                    <span className="ml-2 text-xs text-gray-400">
+                      {v.source}
+                    </span>
+                  )}
+                </dd>
+              </div>
+            ))}
+          </dl>
+
+          <h4 className="mt-4 text-xs font-semibold uppercase text-gray-500">
+            Calculation
+          </h4>
+          <ol className="mt-2 space-y-2 text-sm">
+            {plan.derivation.steps.map((v) => (
+              <li key={v.name}>
+                <div className="flex justify-between">
+                  <span className="text-gray-700">{v.name}</span>
+                  <span className="font-medium">{v.value}</span>
+                </div>
+                {v.formula && (
+                  <code className="block text-xs text-gray-500">
+                    {v.formula}
+                  </code>
+                )}
+              </li>
+            ))}
+          </ol>
+
+          <h4 className="mt-4 text-xs font-semibold uppercase text-gray-500">
+            Assumptions
+          </h4>
+          <ul className="mt-2 list-disc pl-5 space-y-1 text-sm text-gray-600">
+            {plan.derivation.assumptions.map((a) => (
+              <li key={a}>{a}</li>
+            ))}
+          </ul>
+
+          <h4 className="mt-4 text-xs font-semibold uppercase text-gray-500">
+            Safety Margins
+          </h4>
+          <dl className="mt-2 space-y-2 text-sm">
+            {plan.derivation.safety_margins.map((m) => (
+              <div key={m.name}>
+                <div className="flex justify-between">
+                  <dt className="text-gray-700">{m.name}</dt>
+                  <dd className="font-medium">{m.margin}</dd>
+                </div>
+                <p className="text-xs text-gray-500">{m.reason}</p>
+              </div>
+            ))}
+          </dl>
+        </details>
+      )}
+
+      <ShardAdvisor />
+
       <div className="mt-6 flex gap-3">
         <Button onClick={() => goToStep("review")}>
           Continue to Review
@@ -123,3 +207,78 @@ export default function Sizing() {
     </PageContainer>
   );
 }
+
+// ShardAdvisor ranks shard key candidates by sampled cardinality and skew.
+function ShardAdvisor() {
+  const [requested, setRequested] = useState(false);
+  const { data, isFetching, error, refetch } = useShardAdvisor(requested);
+
+  return (
+    <div className="mt-6 rounded-lg border border-gray-200 bg-white p-4">
+      <div className="flex items-center justify-between">
+        <div>
+          <h3 className="text-sm font-medium text-gray-700">Shard Key Advisor</h3>
+          <p className="text-xs text-gray-500">
+            Samples distinct values and skew of key candidates from the source.
+          </p>
+        </div>
+        <Button
+          variant="secondary"
+          loading={isFetching}
+          onClick={() => (requested ? refetch() : setRequested(true))}
+        >
+          {requested ? "Resample" : "Analyze Shard Keys"}
+        </Button>
+      </div>
+
+      {error && (
+        <div className="mt-3">
+          <Alert type="error">{error.message}</Alert>
+        </div>
+      )}
+
+      {data?.collections.map((a) => (
+        <div key={a.collection_name} className="mt-4">
+          <h4 className="text-sm font-medium text-gray-900">
+            {a.collection_name}
+            <span className="ml-2 text-xs font-normal text-gray-500">
+              {a.row_count.toLocaleString()} rows
+            </span>
+          </h4>
+          {a.error ? (
+            <p className="text-xs text-red-600">{a.error}</p>
+          ) : !a.candidates || a.candidates.length === 0 ? (
+            <p className="text-xs text-gray-500">No shardable columns.</p>
+          ) : (
+            <table className="mt-1 w-full text-sm">
+              <thead>
+                <tr className="text-left text-xs text-gray-500">
+                  <th className="py-1">Field</th>
+                  <th>Strategy</th>
+                  <th>Distinct</th>
+                  <th>Top value</th>
+                  <th>Score</th>
+                </tr>
+              </thead>
+              <tbody>
+                {a.candidates.map((c, i) => (
+                  <tr
+                    key={`${c.field}-${c.strategy}`}
+                    title={c.reason}
+                    className={i === 0 ? "font-medium text-green-700" : "text-gray-700"}
+                  >
+                    <td className="py-1">{c.field}</td>
+                    <td>{c.strategy}</td>
+                    <td>{c.distinct.toLocaleString()}</td>
+                    <td>{(c.top_share * 100).toFixed(1)}%</td>
+                    <td>{c.score}</td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          )}
+        </div>
+      ))}
+    </div>
+  );
+}
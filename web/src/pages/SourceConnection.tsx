@@ -5,13 +5,16 @@ import { Button } from "../components/Button";
 import { Alert } from "../components/Alert";
 import { StatusBadge } from "../components/StatusBadge";
 import { PageContainer } from "../components/PageContainer";
+import { ProgressBar } from "../components/ProgressBar";
+import { SchemaSnapshotsCard } from "../components/SchemaSnapshots";
 import {
   useSourceConfig,
   useTestSourceConnection,
   useDiscoverSchema,
+  useDiscoveryProgress,
   useNavigateToStep,
 } from "../api/hooks";
-import type { SourceConfig, Schema } from "../api/types";
+import type { SourceConfig, Schema, DiscoveryProgress } from "../api/types";
 
 const DEFAULT_PORTS: Record<string, number> = {
   postgresql: 5432,
@@ -34,6 +37,7 @@ export default function SourceConnection() {
   const sourceConfig = useSourceConfig();
   const testConn = useTestSourceConnection();
   const discover = useDiscoverSchema();
+  const discoveryProgress = useDiscoveryProgress();
   const goToStep = useNavigateToStep();
 
   useEffect(() => {
@@ -165,6 +169,14 @@ export default function SourceConnection() {
           <Alert type="error">{testConn.error.message}</Alert>
         )}
 
+        {discover.isPending && discoveryProgress.data && (
+          <ProgressBar
+            percent={discoveryProgress.data.percent}
+            label={discoveryLabel(discoveryProgress.data)}
+            animated
+          />
+        )}
+
         {discover.error && (
           <Alert type="error">{discover.error.message}</Alert>
         )}
@@ -198,7 +210,20 @@ export default function SourceConnection() {
           )}
         </div>
       </div>
+
+      <div className="mt-6">
+        <SchemaSnapshotsCard />
+      </div>
     </div>
     </PageContainer>
   );
 }
+
+function discoveryLabel(p: DiscoveryProgress): string {
+  if (p.phase === "tables") {
+    return p.tables_total ? `${p.tables_total} tables found` : "Enumerating tables";
+  }
+  const phase = p.phase.replace(/_/g, " ");
+  const table = p.table ? ` - ${p.table}` : "";
+  return `Reading ${phase} (${p.phase_index}/${p.phase_count}): ${p.tables_done}/${p.tables_total} tables${table}`;
+}
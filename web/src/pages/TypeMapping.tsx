@@ -3,11 +3,36 @@ import { Button } from "../components/Button";
 import { Alert } from "../components/Alert";
 import { TypeSelect } from "../components/TypeSelect";
 import { PageContainer } from "../components/PageContainer";
-import { useTypeMap, useSaveTypeMap, useNavigateToStep } from "../api/hooks";
+import { LOBStrategies } from "../components/LOBStrategies";
+import { BooleanColumns } from "../components/BooleanColumns";
+import { TimestampPolicies } from "../components/TimestampPolicies";
+import {
+  useTypeMap,
+  useSaveTypeMap,
+  useLOBs,
+  useSaveLOBs,
+  useBooleanColumns,
+  useSaveBooleanColumns,
+  useTimestamps,
+  useSaveTimestamps,
+  useDecimals,
+  useNavigateToStep,
+} from "../api/hooks";
+import type { BooleanColumn, LOBColumn, TimestampColumn } from "../api/types";
 
 export default function TypeMapping() {
   const { data: entries, isLoading, error } = useTypeMap();
   const saveTypeMap = useSaveTypeMap();
+  const { data: savedLOBs } = useLOBs();
+  const saveLOBs = useSaveLOBs();
+  const [lobs, setLOBs] = useState<LOBColumn[]>();
+  const { data: savedBooleans } = useBooleanColumns();
+  const saveBooleans = useSaveBooleanColumns();
+  const [booleans, setBooleans] = useState<BooleanColumn[]>();
+  const { data: savedTimestamps } = useTimestamps();
+  const saveTimestamps = useSaveTimestamps();
+  const [timestamps, setTimestamps] = useState<TimestampColumn[]>();
+  const { data: decimals } = useDecimals();
   const goToStep = useNavigateToStep();
   const [overrides, setOverrides] = useState<Record<string, string>>({});
   const [initialized, setInitialized] = useState(false);
@@ -42,9 +67,53 @@ export default function TypeMapping() {
     });
   };
 
+  const handleLOBChange = (lob: LOBColumn) => {
+    setLOBs((prev) =>
+      (prev ?? savedLOBs ?? []).map((l) =>
+        l.table === lob.table && l.column === lob.column ? lob : l,
+      ),
+    );
+  };
+
+  const handleBooleanChange = (col: BooleanColumn) => {
+    setBooleans((prev) =>
+      (prev ?? savedBooleans ?? []).map((b) =>
+        b.table === col.table && b.column === col.column ? col : b,
+      ),
+    );
+  };
+
+  const handleTimestampChange = (col: TimestampColumn) => {
+    setTimestamps((prev) =>
+      (prev ?? savedTimestamps ?? []).map((c) =>
+        c.table === col.table && c.column === col.column ? col : c,
+      ),
+    );
+  };
+
   const handleSave = () => {
+    const saveTimestampsThenContinue = () => {
+      if (!timestamps) {
+        goToStep("sizing");
+        return;
+      }
+      saveTimestamps.mutate(timestamps, { onSuccess: () => goToStep("sizing") });
+    };
+    const saveBooleansThenContinue = () => {
+      if (!booleans) {
+        saveTimestampsThenContinue();
+        return;
+      }
+      saveBooleans.mutate(booleans, { onSuccess: saveTimestampsThenContinue });
+    };
     saveTypeMap.mutate(overrides, {
-      onSuccess: () => goToStep("sizing"),
+      onSuccess: () => {
+        if (!lobs) {
+          saveBooleansThenContinue();
+          return;
+        }
+        saveLOBs.mutate(lobs, { onSuccess: saveBooleansThenContinue });
+      },
     });
   };
 
@@ -100,7 +169,7 @@ export default function TypeMapping() {
                 className={`border-b border-gray-100 ${isModified(entry.source_type) ? "bg-yellow-50" : ""}`}
               >
                 <td className="px-4 py-2.5 font-mono text-gray-900">
-                  {entry.source_type}
+                  {entry.label ?? entry.source_type}
                 </td>
                 <td className="px-4 py-2.5">
                   <TypeSelect
@@ -131,12 +200,47 @@ export default function TypeMapping() {
         </table>
       </div>
 
+      {decimals
+        ?.filter((d) => d.warning)
+        .map((d) => (
+          <Alert key={`${d.table}.${d.column}`} type="warning">
+            {d.table}.{d.column}: {d.warning}
+          </Alert>
+        ))}
+
+      <LOBStrategies lobs={lobs ?? savedLOBs ?? []} onChange={handleLOBChange} />
+
+      <BooleanColumns
+        columns={booleans ?? savedBooleans ?? []}
+        onChange={handleBooleanChange}
+      />
+
+      <TimestampPolicies
+        columns={timestamps ?? savedTimestamps ?? []}
+        onChange={handleTimestampChange}
+      />
+
       {saveTypeMap.error && (
         <Alert type="error">{saveTypeMap.error.message}</Alert>
       )}
+      {saveLOBs.error && <Alert type="error">{saveLOBs.error.message}</Alert>}
+      {saveBooleans.error && (
+        <Alert type="error">{saveBooleans.error.message}</Alert>
+      )}
+      {saveTimestamps.error && (
+        <Alert type="error">{saveTimestamps.error.message}</Alert>
+      )}
 
       <div className="mt-6 flex gap-3">
-        <Button onClick={handleSave} loading={saveTypeMap.isPending}>
+        <Button
+          onClick={handleSave}
+          loading={
+            saveTypeMap.isPending ||
+            saveLOBs.isPending ||
+            saveBooleans.isPending ||
+            saveTimestamps.isPending
+          }
+        >
           Save & Continue
         </Button>
       </div>
@@ -3,7 +3,12 @@ import { ValidationResultCard } from "../components/ValidationResult";
 import { Button } from "../components/Button";
 import { Alert } from "../components/Alert";
 import { PageContainer } from "../components/PageContainer";
-import { useNavigateToStep } from "../api/hooks";
+import { DataDictionaryTable } from "../components/DataDictionary";
+import {
+  useDataDictionary,
+  useNavigateToStep,
+  useValidationProgress,
+} from "../api/hooks";
 import { api } from "../api/client";
 
 interface ValidationResults {
@@ -13,6 +18,26 @@ interface ValidationResults {
     row_count_check?: { passed: boolean };
     sample_check?: { passed: boolean };
     aggregate_check?: { passed: boolean };
+    type_check?: { match: boolean; mismatch_fields: number };
+    offload_check?: { match: boolean };
+    referential_check?: {
+      match: boolean;
+      orphans: number;
+      relationships: {
+        field: string;
+        parent: string;
+        parent_field: string;
+        checked: number;
+        orphans: number;
+      }[];
+    };
+    shard_key_check?: {
+      fields: string[];
+      sampled: number;
+      missing: number;
+      missing_percent: number;
+      match: boolean;
+    };
     status: string;
   }[];
 }
@@ -27,7 +52,11 @@ export default function Validation() {
     retry: false,
   });
 
+  const { data: progress } = useValidationProgress();
+
   const allPassed = results?.status === "pass";
+  const finished = allPassed || results?.status === "fail";
+  const { data: dictionary } = useDataDictionary(finished);
 
   return (
     <PageContainer>
@@ -59,12 +88,53 @@ export default function Validation() {
                 rowCountPassed={col.row_count_check?.passed}
                 samplePassed={col.sample_check?.passed}
                 aggregatePassed={col.aggregate_check?.passed}
+                typesPassed={col.type_check?.match}
+                offloadPassed={col.offload_check?.match}
+                referentialPassed={col.referential_check?.match}
                 status={col.status}
               />
             ))}
           </div>
 
-          {(allPassed || results.status === "fail") && (
+          {results.collections.some((c) => c.referential_check?.orphans) && (
+            <Alert type="error">
+              <p>Documents refer to parents missing from the target:</p>
+              <ul className="mt-1 list-disc pl-5">
+                {results.collections.flatMap((c) =>
+                  (c.referential_check?.relationships ?? [])
+                    .filter((r) => r.orphans > 0)
+                    .map((r) => (
+                      <li key={`${c.name}.${r.field}`} className="font-mono">
+                        {c.name}.{r.field} → {r.parent}.{r.parent_field}:{" "}
+                        {r.orphans} of {r.checked}
+                      </li>
+                    )),
+                )}
+              </ul>
+            </Alert>
+          )}
+
+          {results.collections.some((c) => c.shard_key_check?.missing) && (
+            <Alert type="error">
+              <p>
+                Sampled documents lack their shard key, so inserts and updates
+                by shard key will fail for them:
+              </p>
+              <ul className="mt-1 list-disc pl-5">
+                {results.collections
+                  .filter((c) => c.shard_key_check?.missing)
+                  .map((c) => (
+                    <li key={c.name} className="font-mono">
+                      {c.name} ({c.shard_key_check!.fields.join(", ")}):{" "}
+                      {c.shard_key_check!.missing_percent.toFixed(1)}% of{" "}
+                      {c.shard_key_check!.sampled}
+                    </li>
+                  ))}
+              </ul>
+            </Alert>
+          )}
+
+          {finished && (
             <div className="flex gap-3">
               <Button onClick={() => goToStep("index_builds")}>
                 Continue to Index Builds
@@ -74,6 +144,34 @@ export default function Validation() {
         </div>
       )}
 
+      {dictionary && (
+        <div className="mt-8">
+          <h3 className="text-lg font-semibold text-gray-900">
+            Data Dictionary
+          </h3>
+          <p className="mt-1 text-sm text-gray-600">
+            Fields of the migrated collections for application teams. Run{" "}
+            <code>reloquent dictionary --output dictionary.html</code> to
+            publish it as Markdown or HTML.
+          </p>
+          <div className="mt-4 space-y-4">
+            {dictionary.collections.map((col) => (
+              <DataDictionaryTable key={col.name} collection={col} />
+            ))}
+          </div>
+        </div>
+      )}
+
+      {progress && (
+        <div className="mt-6">
+          <Alert type={progress.match ? "info" : "warning"}>
+            Checksums for {progress.collection}: chunk {progress.chunk} of{" "}
+            {progress.chunks} ({progress.source_rows.toLocaleString()} source
+            rows, {progress.target_rows.toLocaleString()} target documents)
+          </Alert>
+        </div>
+      )}
+
       {!results && (
         <div className="mt-6">
           <Alert type="info">